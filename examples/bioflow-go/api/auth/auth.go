@@ -0,0 +1,213 @@
+// Package auth authenticates the users of the BioFlow API. People sign in
+// to the web UI through an OpenID Connect provider and get a session
+// cookie; programs send an API key. Each user has a workspace: the
+// pipeline jobs they started, which other users cannot see.
+//
+// Authentication is optional. Without an Authenticator every request is
+// anonymous and all jobs are shared, as on a single-user server.
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// User is an authenticated user. ID names the user's workspace, so an
+// API key should be given the ID its owner signs in with.
+type User struct {
+	ID     string `json:"id"`
+	Name   string `json:"name,omitempty"`
+	Email  string `json:"email,omitempty"`
+	Method string `json:"method"` // "oidc" or "api_key"
+}
+
+type userKey struct{}
+
+// WithUser returns ctx carrying an authenticated user.
+func WithUser(ctx context.Context, u *User) context.Context {
+	return context.WithValue(ctx, userKey{}, u)
+}
+
+// FromContext returns the user of a request, or nil if it is anonymous.
+func FromContext(ctx context.Context) *User {
+	u, _ := ctx.Value(userKey{}).(*User)
+	return u
+}
+
+// Owner returns the workspace of a request's user, or "" for anonymous
+// requests, which see every workspace.
+func Owner(ctx context.Context) string {
+	if u := FromContext(ctx); u != nil {
+		return u.ID
+	}
+	return ""
+}
+
+// Options configures an Authenticator. Set Issuer to enable single
+// sign-on, APIKeys for programmatic access, or both.
+type Options struct {
+	// Issuer is the URL of the OpenID Connect provider, whose discovery
+	// document is fetched by New.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback URL registered with the provider,
+	// ending in /auth/callback.
+	RedirectURL string
+	// Scopes are requested besides openid (default: profile and email).
+	Scopes []string
+	// UserClaim is the ID token claim naming users (default: email).
+	UserClaim string
+
+	// APIKeys maps API keys to user IDs; see LoadAPIKeys.
+	APIKeys map[string]string
+
+	// SessionKey signs session cookies. If empty, a random key is used
+	// and sessions end when the server restarts.
+	SessionKey []byte
+	// SessionTTL is how long a sign-in lasts (default: 12h).
+	SessionTTL time.Duration
+}
+
+// Authenticator authenticates API requests and runs the sign-in flow.
+type Authenticator struct {
+	opts     Options
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+	apiKeys  map[[sha256.Size]byte]string
+	secure   bool
+}
+
+// New creates an Authenticator, discovering the OpenID Connect provider
+// if one is configured.
+func New(ctx context.Context, opts Options) (*Authenticator, error) {
+	if opts.Issuer == "" && len(opts.APIKeys) == 0 {
+		return nil, fmt.Errorf("neither an OpenID Connect issuer nor API keys are configured")
+	}
+	if opts.UserClaim == "" {
+		opts.UserClaim = "email"
+	}
+	if opts.SessionTTL <= 0 {
+		opts.SessionTTL = 12 * time.Hour
+	}
+	if len(opts.SessionKey) == 0 {
+		opts.SessionKey = make([]byte, 32)
+		if _, err := rand.Read(opts.SessionKey); err != nil {
+			return nil, err
+		}
+	}
+
+	a := &Authenticator{opts: opts, apiKeys: make(map[[sha256.Size]byte]string, len(opts.APIKeys))}
+	// Keys are looked up by hash, so that lookups take no longer for
+	// near misses.
+	for key, user := range opts.APIKeys {
+		a.apiKeys[sha256.Sum256([]byte(key))] = user
+	}
+
+	if opts.Issuer != "" {
+		if opts.ClientID == "" || opts.RedirectURL == "" {
+			return nil, fmt.Errorf("single sign-on needs a client ID and a redirect URL")
+		}
+		provider, err := oidc.NewProvider(ctx, opts.Issuer)
+		if err != nil {
+			return nil, fmt.Errorf("discovering %s: %w", opts.Issuer, err)
+		}
+		scopes := opts.Scopes
+		if len(scopes) == 0 {
+			scopes = []string{"profile", "email"}
+		}
+		a.oauth = &oauth2.Config{
+			ClientID:     opts.ClientID,
+			ClientSecret: opts.ClientSecret,
+			RedirectURL:  opts.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       append([]string{oidc.ScopeOpenID}, scopes...),
+		}
+		a.verifier = provider.Verifier(&oidc.Config{ClientID: opts.ClientID})
+		a.secure = strings.HasPrefix(opts.RedirectURL, "https://")
+	}
+	return a, nil
+}
+
+// SingleSignOn reports whether users can sign in through a provider.
+func (a *Authenticator) SingleSignOn() bool {
+	return a.oauth != nil
+}
+
+// Middleware rejects requests without a valid API key or session with
+// 401, and passes the user of the others on in their context.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u := a.authenticate(r)
+		if u == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="bioflow"`)
+			http.Error(w, `{"error": "authentication required"}`, http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), u)))
+	})
+}
+
+// authenticate returns the user of an API key, given as a bearer token or
+// in X-API-Key, or of a session cookie; or nil.
+func (a *Authenticator) authenticate(r *http.Request) *User {
+	key := r.Header.Get("X-API-Key")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		key = strings.TrimSpace(bearer)
+	}
+	if key != "" {
+		if id, ok := a.apiKeys[sha256.Sum256([]byte(key))]; ok {
+			return &User{ID: id, Method: "api_key"}
+		}
+		return nil
+	}
+	return a.session(r)
+}
+
+// minKeyLength is the length of the shortest API key accepted.
+const minKeyLength = 16
+
+// LoadAPIKeys reads API keys from a file of "user key" lines; blank lines
+// and lines starting with # are skipped. Keys must be at least 16
+// characters long and are best generated, e.g. with openssl rand -hex 32.
+func LoadAPIKeys(filename string) (map[string]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading API keys: %w", err)
+	}
+	defer file.Close()
+
+	keys := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected a user and a key", filename, lineNum)
+		}
+		if len(fields[1]) < minKeyLength {
+			return nil, fmt.Errorf("%s:%d: key of %s is shorter than %d characters", filename, lineNum, fields[0], minKeyLength)
+		}
+		if _, dup := keys[fields[1]]; dup {
+			return nil, fmt.Errorf("%s:%d: key of %s is already in use", filename, lineNum, fields[0])
+		}
+		keys[fields[1]] = fields[0]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading API keys: %w", err)
+	}
+	return keys, nil
+}
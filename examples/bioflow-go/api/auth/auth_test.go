@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKey = "0123456789abcdef0123"
+
+// newTestAuthenticator returns an Authenticator with one API key, for
+// alice, and a fixed session key.
+func newTestAuthenticator(t *testing.T) *Authenticator {
+	t.Helper()
+	a, err := New(context.Background(), Options{
+		APIKeys:    map[string]string{testKey: "alice"},
+		SessionKey: []byte("session key for tests"),
+	})
+	require.NoError(t, err)
+	return a
+}
+
+// sessionRequest returns a request carrying a session cookie of value.
+func sessionRequest(value string) *http.Request {
+	r := httptest.NewRequest("GET", "/api/v1/jobs", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: value})
+	return r
+}
+
+func TestVerify(t *testing.T) {
+	a := newTestAuthenticator(t)
+	value, err := a.sign(&session{User: User{ID: "bob", Method: "oidc"}, Expires: 42})
+	require.NoError(t, err)
+
+	var s session
+	require.NoError(t, a.verify(value, &s))
+	assert.Equal(t, "bob", s.User.ID)
+	assert.Equal(t, int64(42), s.Expires)
+
+	payload, sig, _ := strings.Cut(value, ".")
+	enc := base64.RawURLEncoding
+	forged := enc.EncodeToString([]byte(`{"user":{"id":"admin","method":"oidc"},"exp":42}`))
+	badSig := []byte{1, 2, 3}
+	other, err := New(context.Background(), Options{APIKeys: map[string]string{testKey: "alice"}, SessionKey: []byte("another key")})
+	require.NoError(t, err)
+	otherValue, err := other.sign(&session{User: User{ID: "bob"}, Expires: 42})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"tampered payload", forged + "." + sig},
+		{"tampered signature", payload + "." + enc.EncodeToString(badSig)},
+		{"signature of another key", otherValue},
+		{"no signature", payload},
+		{"empty signature", payload + "."},
+		{"payload not base64", "!!!." + sig},
+		{"signature not base64", payload + ".!!!"},
+		{"empty", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s session
+			assert.Error(t, a.verify(tt.value, &s))
+			assert.Nil(t, a.session(sessionRequest(tt.value)))
+		})
+	}
+}
+
+func TestSession(t *testing.T) {
+	a := newTestAuthenticator(t)
+	cookie := func(expires time.Time) string {
+		value, err := a.sign(&session{User: User{ID: "bob", Method: "oidc"}, Expires: expires.Unix()})
+		require.NoError(t, err)
+		return value
+	}
+
+	u := a.session(sessionRequest(cookie(time.Now().Add(time.Hour))))
+	require.NotNil(t, u)
+	assert.Equal(t, "bob", u.ID)
+
+	assert.Nil(t, a.session(sessionRequest(cookie(time.Now().Add(-time.Second)))), "expired")
+	assert.Nil(t, a.session(httptest.NewRequest("GET", "/", nil)), "no cookie")
+
+	// Cookies set by setCookie carry the signed value, and ttl 0 deletes
+	// them.
+	rec := httptest.NewRecorder()
+	require.NoError(t, a.setCookie(rec, sessionCookie, "/", &session{User: User{ID: "carol"}, Expires: time.Now().Add(time.Hour).Unix()}, time.Hour))
+	set := rec.Result().Cookies()
+	require.Len(t, set, 1)
+	assert.True(t, set[0].HttpOnly)
+	assert.Equal(t, 3600, set[0].MaxAge)
+	u = a.session(sessionRequest(set[0].Value))
+	require.NotNil(t, u)
+	assert.Equal(t, "carol", u.ID)
+
+	rec = httptest.NewRecorder()
+	require.NoError(t, a.setCookie(rec, sessionCookie, "/", nil, 0))
+	assert.Equal(t, -1, rec.Result().Cookies()[0].MaxAge)
+}
+
+func TestLocalPath(t *testing.T) {
+	tests := []struct {
+		next string
+		want string
+	}{
+		{"/pipeline/jobs?id=3", "/pipeline/jobs?id=3"},
+		{"/", "/"},
+		{"", "/"},
+		{"//evil.com", "/"},
+		{"//evil.com/path", "/"},
+		{"/\\evil.com", "/"},
+		{"https://evil.com/", "/"},
+		{"http://localhost/", "/"},
+		{"javascript:alert(1)", "/"},
+		{"jobs", "/"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, localPath(tt.next), tt.next)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	a := newTestAuthenticator(t)
+	valid, err := a.sign(&session{User: User{ID: "bob", Method: "oidc"}, Expires: time.Now().Add(time.Hour).Unix()})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		cookie  string
+		want    string // "" for no user
+	}{
+		{"bearer key", map[string]string{"Authorization": "Bearer " + testKey}, "", "alice"},
+		{"X-API-Key", map[string]string{"X-API-Key": testKey}, "", "alice"},
+		{"bearer key over X-API-Key", map[string]string{"Authorization": "Bearer " + testKey, "X-API-Key": "unknown key 123456"}, "", "alice"},
+		{"session", nil, valid, "bob"},
+		{"key over session", map[string]string{"X-API-Key": testKey}, valid, "alice"},
+		{"unknown bearer key with a session", map[string]string{"Authorization": "Bearer unknown key 123456"}, valid, ""},
+		{"unknown X-API-Key with a session", map[string]string{"X-API-Key": "unknown key 123456"}, valid, ""},
+		{"other scheme", map[string]string{"Authorization": "Basic " + testKey}, "", ""},
+		{"nothing", nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/v1/jobs", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: sessionCookie, Value: tt.cookie})
+			}
+			u := a.authenticate(r)
+			if tt.want == "" {
+				assert.Nil(t, u)
+				return
+			}
+			require.NotNil(t, u)
+			assert.Equal(t, tt.want, u.ID)
+		})
+	}
+
+	// The middleware answers 401 without a user, and passes the user on.
+	var owner string
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		owner = Owner(r.Context())
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/jobs", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+
+	r := httptest.NewRequest("GET", "/api/v1/jobs", nil)
+	r.Header.Set("X-API-Key", testKey)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "alice", owner)
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	write := func(content string) string {
+		path := filepath.Join(t.TempDir(), "keys")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	keys, err := LoadAPIKeys(write("# users\n\nalice 0123456789abcdef\n  bob\tfedcba9876543210abcd  \n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"0123456789abcdef": "alice", "fedcba9876543210abcd": "bob"}, keys)
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"short key", "alice 0123456789abcde\n", ":1: key of alice is shorter than 16 characters"},
+		{"duplicate key", "alice 0123456789abcdef\n# again\nbob 0123456789abcdef\n", ":3: key of bob is already in use"},
+		{"no key", "alice\n", ":1: expected a user and a key"},
+		{"extra field", "alice 0123456789abcdef admin\n", ":1: expected a user and a key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadAPIKeys(write(tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	_, err = LoadAPIKeys(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
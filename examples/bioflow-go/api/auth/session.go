@@ -0,0 +1,256 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// sessionCookie holds the signed-in user.
+	sessionCookie = "bioflow_session"
+	// loginCookie holds the state of a sign-in in progress.
+	loginCookie = "bioflow_login"
+	// loginTTL bounds the time to sign in at the provider.
+	loginTTL = 10 * time.Minute
+)
+
+// session is the content of the session cookie.
+type session struct {
+	User    User  `json:"user"`
+	Expires int64 `json:"exp"`
+}
+
+// login is the content of the login cookie: what the callback checks the
+// provider's answer against, and where to go next.
+type login struct {
+	State    string `json:"state"`
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+	Next     string `json:"next"`
+	Expires  int64  `json:"exp"`
+}
+
+// sign encodes v as a cookie value signed with the session key.
+func (a *Authenticator) sign(v any) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, a.opts.SessionKey)
+	mac.Write(payload)
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(mac.Sum(nil)), nil
+}
+
+// verify decodes a cookie value made by sign into v, checking its
+// signature.
+func (a *Authenticator) verify(value string, v any) error {
+	enc := base64.RawURLEncoding
+	data, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return errors.New("malformed cookie")
+	}
+	payload, err := enc.DecodeString(data)
+	if err != nil {
+		return err
+	}
+	got, err := enc.DecodeString(sig)
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, a.opts.SessionKey)
+	mac.Write(payload)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return errors.New("bad cookie signature")
+	}
+	return json.Unmarshal(payload, v)
+}
+
+// setCookie sets a signed cookie; ttl 0 deletes it.
+func (a *Authenticator) setCookie(w http.ResponseWriter, name, path string, v any, ttl time.Duration) error {
+	c := &http.Cookie{Name: name, Path: path, HttpOnly: true, Secure: a.secure, SameSite: http.SameSiteLaxMode}
+	if ttl <= 0 {
+		c.MaxAge = -1
+	} else {
+		value, err := a.sign(v)
+		if err != nil {
+			return err
+		}
+		c.Value, c.MaxAge = value, int(ttl.Seconds())
+	}
+	http.SetCookie(w, c)
+	return nil
+}
+
+// session returns the user of a valid session cookie, or nil.
+func (a *Authenticator) session(r *http.Request) *User {
+	c, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil
+	}
+	var s session
+	if err := a.verify(c.Value, &s); err != nil || time.Now().Unix() > s.Expires {
+		return nil
+	}
+	return &s.User
+}
+
+// randomString returns a random URL-safe string.
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// localPath returns next if it is a path on this server, or "/": the
+// sign-in flow must not redirect elsewhere.
+func localPath(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+// LoginHandler starts signing in: it redirects to the provider, which
+// sends the user back to CallbackHandler. The next query parameter is the
+// page to return to afterwards.
+func (a *Authenticator) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	if a.oauth == nil {
+		http.Error(w, `{"error": "single sign-on is not configured"}`, http.StatusNotFound)
+		return
+	}
+	var l login
+	var err error
+	if l.State, err = randomString(); err == nil {
+		if l.Nonce, err = randomString(); err == nil {
+			l.Verifier = oauth2.GenerateVerifier()
+		}
+	}
+	l.Next, l.Expires = localPath(r.URL.Query().Get("next")), time.Now().Add(loginTTL).Unix()
+	if err == nil {
+		err = a.setCookie(w, loginCookie, "/auth/", &l, loginTTL)
+	}
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+		return
+	}
+	url := a.oauth.AuthCodeURL(l.State, oauth2.S256ChallengeOption(l.Verifier), oauth2.SetAuthURLParam("nonce", l.Nonce))
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+// CallbackHandler finishes signing in: it checks the provider's answer,
+// exchanges its code for an ID token, and starts a session for the user
+// the token names.
+func (a *Authenticator) CallbackHandler(w http.ResponseWriter, r *http.Request) {
+	if a.oauth == nil {
+		http.Error(w, `{"error": "single sign-on is not configured"}`, http.StatusNotFound)
+		return
+	}
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		http.Error(w, `{"error": "sign-in failed: `+jsonEscape(e+" "+q.Get("error_description"))+`"}`, http.StatusUnauthorized)
+		return
+	}
+	var l login
+	c, err := r.Cookie(loginCookie)
+	if err == nil {
+		err = a.verify(c.Value, &l)
+	}
+	if err != nil || time.Now().Unix() > l.Expires || l.State == "" || q.Get("state") != l.State {
+		http.Error(w, `{"error": "sign-in expired or was not started here; try again"}`, http.StatusBadRequest)
+		return
+	}
+	a.setCookie(w, loginCookie, "/auth/", nil, 0)
+
+	u, err := a.exchange(r, q.Get("code"), &l)
+	if err != nil {
+		log.Printf("Sign-in failed: %v", err)
+		http.Error(w, `{"error": "sign-in failed: `+jsonEscape(err.Error())+`"}`, http.StatusUnauthorized)
+		return
+	}
+	s := session{User: *u, Expires: time.Now().Add(a.opts.SessionTTL).Unix()}
+	if err := a.setCookie(w, sessionCookie, "/", &s, a.opts.SessionTTL); err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+		return
+	}
+	log.Printf("%s signed in", u.ID)
+	http.Redirect(w, r, l.Next, http.StatusFound)
+}
+
+// exchange redeems an authorization code and returns the user named by
+// the verified ID token.
+func (a *Authenticator) exchange(r *http.Request, code string, l *login) (*User, error) {
+	token, err := a.oauth.Exchange(r.Context(), code, oauth2.VerifierOption(l.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchanging code: %w", err)
+	}
+	raw, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("no ID token in the provider's answer")
+	}
+	idToken, err := a.verifier.Verify(r.Context(), raw)
+	if err != nil {
+		return nil, fmt.Errorf("verifying ID token: %w", err)
+	}
+	if idToken.Nonce != l.Nonce {
+		return nil, errors.New("ID token nonce does not match")
+	}
+
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+	if verified, ok := claims["email_verified"].(bool); ok && !verified && a.opts.UserClaim == "email" {
+		return nil, errors.New("email address is not verified")
+	}
+	id, _ := claims[a.opts.UserClaim].(string)
+	if id == "" {
+		return nil, fmt.Errorf("ID token has no %s claim", a.opts.UserClaim)
+	}
+	u := &User{ID: id, Method: "oidc"}
+	u.Name, _ = claims["name"].(string)
+	u.Email, _ = claims["email"].(string)
+	return u, nil
+}
+
+// LogoutHandler ends the session and returns to the home page.
+func (a *Authenticator) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	a.setCookie(w, sessionCookie, "/", nil, 0)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// MeHandler replies with the signed-in user, or 401 with the sign-in URL
+// when single sign-on is available.
+func (a *Authenticator) MeHandler(w http.ResponseWriter, r *http.Request) {
+	u := a.authenticate(r)
+	w.Header().Set("Content-Type", "application/json")
+	if u == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		reply := map[string]string{"error": "not signed in"}
+		if a.oauth != nil {
+			reply["login"] = "/auth/login"
+		}
+		json.NewEncoder(w).Encode(reply)
+		return
+	}
+	json.NewEncoder(w).Encode(u)
+}
+
+// jsonEscape escapes s for a JSON string.
+func jsonEscape(s string) string {
+	b, _ := json.Marshal(strings.TrimSpace(s))
+	return string(b[1 : len(b)-1])
+}
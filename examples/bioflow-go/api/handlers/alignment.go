@@ -1,35 +1,43 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/aria-lang/bioflow-go/pkg/bioflow"
 )
 
+// AlignmentLimits bounds each alignment request so pathological inputs are
+// rejected up front rather than timing out after burning CPU.
+var AlignmentLimits = bioflow.AlignmentLimits{
+	MaxCells: 25_000_000,
+	Timeout:  10 * time.Second,
+}
+
 // AlignmentRequest represents an alignment request.
 type AlignmentRequest struct {
 	Sequence1 string `json:"sequence1"`
 	Sequence2 string `json:"sequence2"`
+	// DatabaseLength and DatabaseSequences describe the search space for
+	// local alignment E-values; sequence2 alone is used when unset.
+	DatabaseLength    int64 `json:"database_length,omitempty"`
+	DatabaseSequences int   `json:"database_sequences,omitempty"`
+	// Ambiguity selects how N is scored: mismatch (default), neutral or iupac.
+	Ambiguity string `json:"ambiguity,omitempty"`
 }
 
-// AlignmentResponse represents the response for alignment.
-type AlignmentResponse struct {
-	AlignedSeq1 string  `json:"aligned_seq1"`
-	AlignedSeq2 string  `json:"aligned_seq2"`
-	Score       int     `json:"score"`
-	Identity    float64 `json:"identity"`
-	CIGAR       string  `json:"cigar"`
-	Matches     int     `json:"matches"`
-	Mismatches  int     `json:"mismatches"`
-	Gaps        int     `json:"gaps"`
-}
+// AlignmentResponse represents the response for alignment. It shares its
+// schema with the CLI's align -json output; coordinates are 1-based and
+// inclusive.
+type AlignmentResponse = bioflow.AlignmentSummary
 
 // LocalAlignHandler handles local alignment requests.
 func LocalAlignHandler(w http.ResponseWriter, r *http.Request) {
 	var req AlignmentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -45,30 +53,57 @@ func LocalAlignHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	alignment, err := bioflow.Align(seq1, seq2)
+	scoring, err := requestScoring(req)
 	if err != nil {
 		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
 		return
 	}
 
+	resp, err := localAlignment(r.Context(), seq1, seq2, scoring, req, AlignmentLimits)
+	if err != nil {
+		alignmentError(w, err)
+		return
+	}
+
+	countAlignment("local", seq1, seq2)
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(AlignmentResponse{
-		AlignedSeq1: alignment.AlignedSeq1,
-		AlignedSeq2: alignment.AlignedSeq2,
-		Score:       alignment.Score,
-		Identity:    alignment.Identity,
-		CIGAR:       alignment.ToCIGAR(),
-		Matches:     alignment.MatchCount(),
-		Mismatches:  alignment.MismatchCount(),
-		Gaps:        alignment.TotalGaps(),
-	})
+	json.NewEncoder(w).Encode(resp)
+}
+
+// localAlignment aligns two sequences locally, with the bit score and
+// E-value of the alignment in the search space of the request.
+func localAlignment(ctx context.Context, seq1, seq2 *bioflow.Sequence, scoring *bioflow.ScoringMatrix, req AlignmentRequest, limits bioflow.AlignmentLimits) (AlignmentResponse, error) {
+	alignment, err := bioflow.AlignContext(ctx, seq1, seq2, scoring, limits)
+	if err != nil {
+		return AlignmentResponse{}, err
+	}
+
+	stats, err := bioflow.AlignmentStatistics(scoring)
+	if err != nil {
+		return AlignmentResponse{}, err
+	}
+	space := bioflow.SearchSpace{
+		QueryLength:       seq1.Len(),
+		DatabaseLength:    req.DatabaseLength,
+		DatabaseSequences: req.DatabaseSequences,
+	}
+	if space.DatabaseLength <= 0 {
+		space.DatabaseLength = int64(seq2.Len())
+		space.DatabaseSequences = 1
+	}
+	sig := alignment.Significance(stats, space)
+
+	resp := alignment.Summary()
+	resp.BitScore = sig.BitScore
+	resp.EValue = sig.EValue
+	return resp, nil
 }
 
 // GlobalAlignHandler handles global alignment requests.
 func GlobalAlignHandler(w http.ResponseWriter, r *http.Request) {
 	var req AlignmentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -84,35 +119,40 @@ func GlobalAlignHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	alignment, err := bioflow.AlignGlobal(seq1, seq2)
+	scoring, err := requestScoring(req)
 	if err != nil {
 		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
 		return
 	}
 
+	alignment, err := bioflow.AlignGlobalContext(r.Context(), seq1, seq2, scoring, AlignmentLimits)
+	if err != nil {
+		alignmentError(w, err)
+		return
+	}
+
+	countAlignment("global", seq1, seq2)
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(AlignmentResponse{
-		AlignedSeq1: alignment.AlignedSeq1,
-		AlignedSeq2: alignment.AlignedSeq2,
-		Score:       alignment.Score,
-		Identity:    alignment.Identity,
-		CIGAR:       alignment.ToCIGAR(),
-		Matches:     alignment.MatchCount(),
-		Mismatches:  alignment.MismatchCount(),
-		Gaps:        alignment.TotalGaps(),
-	})
+	json.NewEncoder(w).Encode(alignment.Summary())
 }
 
-// ScoreResponse represents the response for alignment score.
+// ScoreResponse represents the response for alignment score. Coordinates
+// are 1-based and inclusive, as in AlignmentResponse.
 type ScoreResponse struct {
-	Score int `json:"score"`
+	Score  int `json:"score"`
+	Start1 int `json:"start1"`
+	End1   int `json:"end1"`
+	Start2 int `json:"start2"`
+	End2   int `json:"end2"`
+	// Profile is the best score reached at each position of sequence1.
+	Profile []int `json:"profile"`
 }
 
 // AlignmentScoreHandler handles alignment score requests.
 func AlignmentScoreHandler(w http.ResponseWriter, r *http.Request) {
 	var req AlignmentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -128,12 +168,54 @@ func AlignmentScoreHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	alignment, err := bioflow.Align(seq1, seq2)
+	scoring, err := requestScoring(req)
 	if err != nil {
 		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
 		return
 	}
 
+	profile, err := bioflow.LocalScoreProfileContext(r.Context(), seq1, seq2, scoring, bioflow.ScoreProfileOptions{
+		LocateStart: true,
+		Limits:      AlignmentLimits,
+	})
+	if err != nil {
+		alignmentError(w, err)
+		return
+	}
+
+	countAlignment("score", seq1, seq2)
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(ScoreResponse{Score: alignment.Score})
+	json.NewEncoder(w).Encode(ScoreResponse{
+		Score:   profile.Score,
+		Start1:  profile.Start1 + 1,
+		End1:    profile.End1,
+		Start2:  profile.Start2 + 1,
+		End2:    profile.End2,
+		Profile: profile.Profile,
+	})
+}
+
+// requestScoring returns the default scoring matrix with the request's
+// ambiguity mode applied.
+func requestScoring(req AlignmentRequest) (*bioflow.ScoringMatrix, error) {
+	mode, err := bioflow.ParseAmbiguityMode(req.Ambiguity)
+	if err != nil {
+		return nil, err
+	}
+	return bioflow.DefaultScoring().WithAmbiguity(mode), nil
+}
+
+// alignmentError writes an alignment failure with a status matching its
+// cause: 413 for inputs over the cell budget, 503 when the time limit ran
+// out, and 400 otherwise.
+func alignmentError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, bioflow.ErrAlignmentTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusServiceUnavailable
+	}
+	http.Error(w, `{"error": "`+err.Error()+`"}`, status)
 }
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aria-lang/bioflow-go/api/auth"
+	"github.com/aria-lang/bioflow-go/pkg/bioflow"
+)
+
+// auditLog is the audit log set by UseAuditLog, or nil.
+var auditLog *bioflow.AuditLog
+
+// AuditAdmins are the users allowed to export the audit log when
+// authentication is on; without it, anyone who can reach the API can.
+var AuditAdmins = map[string]bool{}
+
+// UseAuditLog serves the audit log at AuditExportHandler and checks it
+// for readiness.
+func UseAuditLog(l *bioflow.AuditLog) {
+	auditLog = l
+	RegisterReadinessCheck("audit_log", checkAuditLog)
+}
+
+// AuditExportHandler replies with the entries of the audit log as JSON
+// Lines, oldest first, selected by the from and to (RFC 3339) and user
+// query parameters.
+func AuditExportHandler(w http.ResponseWriter, r *http.Request) {
+	if auditLog == nil {
+		http.Error(w, `{"error": "audit log is not enabled"}`, http.StatusNotFound)
+		return
+	}
+	if u := auth.FromContext(r.Context()); u != nil && !AuditAdmins[u.ID] {
+		http.Error(w, `{"error": "exporting the audit log needs an audit administrator"}`, http.StatusForbidden)
+		return
+	}
+
+	q := r.URL.Query()
+	f := bioflow.AuditFilter{User: q.Get("user")}
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				http.Error(w, `{"error": "`+p.name+` must be an RFC 3339 time"}`, http.StatusBadRequest)
+				return
+			}
+			*p.t = t
+		}
+	}
+
+	w.Header().Set("Content-Type", bioflow.JSONLinesMediaType)
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.jsonl"`)
+	if _, err := bioflow.ExportAuditLog(auditLog.Dir(), f, w); err != nil {
+		// The status is sent; cut the export short so that it is not
+		// mistaken for a complete one.
+		panic(http.ErrAbortHandler)
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/pkg/bioflow"
+)
+
+// jobClient sends pipeline jobs to workers once UseBroker has been
+// called; otherwise jobs run in this process.
+var jobClient *bioflow.BrokerClient
+
+// pipelineTaskKind is the kind of broker tasks running pipeline jobs.
+const pipelineTaskKind = "pipeline"
+
+// pipelineTask is the body of a pipeline task: the job and its input
+// reads as JSON Lines.
+type pipelineTask struct {
+	Spec  pipelineSpec `json:"spec"`
+	Reads string       `json:"reads"`
+}
+
+// pipelineResult is the body of the reply to a finished pipeline task.
+type pipelineResult struct {
+	Reports []bioflow.StageReport `json:"reports"`
+	Reads   string                `json:"reads"`
+}
+
+// UseBroker sends pipeline jobs to workers through a broker from now on,
+// instead of running them here, and handles the workers' replies until
+// ctx is done. Jobs whose worker goes silent are run again, up to the
+// usual number of attempts. Call it before OpenJobStore so that restored
+// jobs go to the workers too.
+func UseBroker(ctx context.Context, b bioflow.Broker, opts bioflow.BrokerClientOptions) {
+	jobClient = bioflow.NewBrokerClient(b, opts)
+	go jobClient.Run(ctx, handleReply, requeueLost)
+}
+
+// dispatch submits a job to the workers. Reads may be nil for a job
+// whose input is in the job store.
+func (job *PipelineJob) dispatch(reads []*bioflow.Read) error {
+	if reads == nil && job.input != "" {
+		var err error
+		if reads, err = bioflow.ReadReadsFile(jobStore.Path(job.input)); err != nil {
+			return err
+		}
+	}
+	var b strings.Builder
+	if err := bioflow.FormatJSONLReads(&b, reads); err != nil {
+		return err
+	}
+	body, err := json.Marshal(pipelineTask{Spec: job.spec, Reads: b.String()})
+	if err != nil {
+		return err
+	}
+	task := &bioflow.BrokerTask{ID: job.ID, Kind: pipelineTaskKind, Priority: job.Priority, Body: body}
+	return jobClient.Submit(context.Background(), task)
+}
+
+// handleReply applies a worker's reply to its job.
+func handleReply(r *bioflow.BrokerReply) {
+	jobs.Lock()
+	job, ok := jobs.byID[r.ID]
+	jobs.Unlock()
+	if !ok {
+		return
+	}
+	switch r.Kind {
+	case bioflow.TaskStarted:
+		job.start(r.Worker)
+	case bioflow.TaskProgress:
+		var e bioflow.Event
+		if err := json.Unmarshal(r.Body, &e); err != nil {
+			log.Printf("Error decoding progress of %s: %v", job.ID, err)
+			return
+		}
+		job.observe(e)
+	case bioflow.TaskDone:
+		var res pipelineResult
+		err := json.Unmarshal(r.Body, &res)
+		var out []*bioflow.Read
+		if err == nil {
+			out, err = bioflow.ParseJSONLReads(strings.NewReader(res.Reads), bioflow.StrictPolicy())
+		}
+		if err != nil {
+			err = fmt.Errorf("decoding result from %s: %w", r.Worker, err)
+		}
+		job.finish(out, res.Reports, err)
+	case bioflow.TaskFailed:
+		job.finish(nil, nil, errors.New(r.Error))
+	}
+}
+
+// requeueLost submits again a task whose worker has gone silent, unless
+// its job has used up its attempts.
+func requeueLost(task *bioflow.BrokerTask) {
+	jobs.Lock()
+	job, ok := jobs.byID[task.ID]
+	if !ok {
+		jobs.Unlock()
+		return
+	}
+	worker, attempts := job.Worker, job.attempts
+	if attempts < maxJobAttempts {
+		job.reset()
+	}
+	jobs.Unlock()
+
+	if attempts >= maxJobAttempts {
+		job.fail(fmt.Errorf("lost worker %s, %d attempts made", worker, attempts))
+		return
+	}
+	log.Printf("Lost worker %s running %s, queueing it again", worker, job.ID)
+	if err := job.save(); err != nil {
+		log.Printf("Error saving %s: %v", job.ID, err)
+	}
+	if err := jobClient.Submit(context.Background(), task); err != nil {
+		job.fail(fmt.Errorf("requeueing after losing worker %s: %w", worker, err))
+	}
+}
+
+// RunPipelineWorker runs pipeline jobs taken from a broker until ctx is
+// done, for a server started as a worker rather than an API node.
+func RunPipelineWorker(ctx context.Context, b bioflow.Broker, opts bioflow.WorkerOptions) error {
+	return bioflow.ServeTasks(ctx, b, opts, map[string]bioflow.TaskHandler{pipelineTaskKind: runPipelineTask})
+}
+
+// runPipelineTask runs a pipeline task, reporting its events as progress.
+func runPipelineTask(task *bioflow.BrokerTask, progress func(json.RawMessage)) (json.RawMessage, error) {
+	var t pipelineTask
+	if err := json.Unmarshal(task.Body, &t); err != nil {
+		return nil, fmt.Errorf("decoding task: %w", err)
+	}
+	ctx, span := startJobSpan(t.Spec, task.ID)
+	reads, err := bioflow.ParseJSONLReads(strings.NewReader(t.Reads), bioflow.StrictPolicy())
+	var out []*bioflow.Read
+	var reports []bioflow.StageReport
+	if err == nil {
+		out, reports, err = runPipeline(ctx, t.Spec, reads, func(e bioflow.Event) {
+			if data, err := json.Marshal(e); err == nil {
+				progress(data)
+			}
+		})
+	}
+	bioflow.EndSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	if err := bioflow.FormatJSONLReads(&b, out); err != nil {
+		return nil, err
+	}
+	return json.Marshal(pipelineResult{Reports: reports, Reads: b.String()})
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/pkg/bioflow"
+)
+
+// exportFormat describes a downloadable alignment file format.
+type exportFormat struct {
+	ext         string
+	contentType string
+}
+
+// exportFormats are the formats of alignment downloads, by name.
+var exportFormats = map[string]exportFormat{
+	"fasta":     {".fasta", "text/x-fasta; charset=utf-8"},
+	"clustal":   {".aln", "text/plain; charset=utf-8"},
+	"stockholm": {".sto", "text/plain; charset=utf-8"},
+	"phylip":    {".phy", "text/plain; charset=utf-8"},
+	"sam":       {".sam", "text/x-sam; charset=utf-8"},
+}
+
+// AlignmentExportRequest is an alignment request whose result is returned
+// as a file.
+type AlignmentExportRequest struct {
+	AlignmentRequest
+	// Mode is "local" (default) or "global".
+	Mode string `json:"mode,omitempty"`
+	// Name1 and Name2 name the sequences in the file; sequence1 is the
+	// reference of SAM output.
+	Name1 string `json:"name1,omitempty"`
+	Name2 string `json:"name2,omitempty"`
+	// Format is fasta (default), clustal or sam.
+	Format string `json:"format,omitempty"`
+}
+
+// AlignmentExportHandler aligns two sequences and returns the alignment as
+// a file download: pairwise aligned FASTA, Clustal, or SAM with the second
+// sequence mapped to the first.
+func AlignmentExportHandler(w http.ResponseWriter, r *http.Request) {
+	var req AlignmentExportRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	name := strings.ToLower(req.Format)
+	if name == "" {
+		name = "fasta"
+	}
+	format, ok := exportFormats[name]
+	if !ok || name == "stockholm" || name == "phylip" {
+		http.Error(w, `{"error": "format must be fasta, clustal or sam"}`, http.StatusBadRequest)
+		return
+	}
+
+	seq1, err := bioflow.NewSequence(req.Sequence1)
+	if err != nil {
+		http.Error(w, `{"error": "sequence1: `+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	seq1.ID = req.Name1
+	if seq1.ID == "" {
+		seq1.ID = "seq1"
+	}
+
+	seq2, err := bioflow.NewSequence(req.Sequence2)
+	if err != nil {
+		http.Error(w, `{"error": "sequence2: `+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	seq2.ID = req.Name2
+	if seq2.ID == "" {
+		seq2.ID = "seq2"
+	}
+
+	scoring, err := requestScoring(req.AlignmentRequest)
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	var alignment *bioflow.Alignment
+	switch req.Mode {
+	case "", "local":
+		alignment, err = bioflow.AlignContext(r.Context(), seq1, seq2, scoring, AlignmentLimits)
+	case "global":
+		alignment, err = bioflow.AlignGlobalContext(r.Context(), seq1, seq2, scoring, AlignmentLimits)
+	default:
+		http.Error(w, `{"error": "mode must be local or global"}`, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		alignmentError(w, err)
+		return
+	}
+	if req.Mode == "global" {
+		countAlignment("global", seq1, seq2)
+	} else {
+		countAlignment("local", seq1, seq2)
+	}
+
+	// Render fully before writing so errors can still be reported.
+	var buf bytes.Buffer
+	if name == "sam" {
+		header, records, err := bioflow.AlignmentToSAM(seq1, seq2, alignment)
+		if err == nil {
+			err = bioflow.WriteSAM(&buf, header, records)
+		}
+		if err != nil {
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+	} else {
+		m, err := bioflow.NewMSA([]string{seq1.ID, seq2.ID}, []string{alignment.AlignedSeq1, alignment.AlignedSeq2})
+		if err != nil {
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusUnprocessableEntity)
+			return
+		}
+		if err := writeMSA(&buf, m, name); err != nil {
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+	writeDownload(w, "alignment"+format.ext, format.contentType, buf.Bytes())
+}
+
+// MSAExportRequest is a multiple sequence alignment to convert into a
+// file, given as named gapped rows or as text in another format.
+type MSAExportRequest struct {
+	Names []string `json:"names,omitempty"`
+	Rows  []string `json:"rows,omitempty"`
+	// Alignment is the alignment as text in InputFormat (default fasta),
+	// when Rows is empty.
+	Alignment   string `json:"alignment,omitempty"`
+	InputFormat string `json:"input_format,omitempty"`
+	// Format is fasta (default), clustal, stockholm or phylip.
+	Format string `json:"format,omitempty"`
+}
+
+// MSAExportHandler returns a multiple sequence alignment as a file
+// download in the requested format.
+func MSAExportHandler(w http.ResponseWriter, r *http.Request) {
+	var req MSAExportRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	name := strings.ToLower(req.Format)
+	if name == "" {
+		name = "fasta"
+	}
+	format, ok := exportFormats[name]
+	if !ok || name == "sam" {
+		http.Error(w, `{"error": "format must be fasta, clustal, stockholm or phylip"}`, http.StatusBadRequest)
+		return
+	}
+
+	var m *bioflow.MSA
+	var err error
+	if len(req.Rows) > 0 {
+		names := req.Names
+		if len(names) == 0 {
+			for i := range req.Rows {
+				names = append(names, "seq"+strconv.Itoa(i+1))
+			}
+		}
+		m, err = bioflow.NewMSA(names, req.Rows)
+	} else {
+		inputFormat := bioflow.FormatAlignedFASTA
+		if req.InputFormat != "" {
+			if inputFormat, err = bioflow.ParseMSAFormat(req.InputFormat); err != nil {
+				http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+				return
+			}
+		}
+		m, err = bioflow.ParseMSA(strings.NewReader(req.Alignment), inputFormat)
+	}
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := writeMSA(&buf, m, name); err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusUnprocessableEntity)
+		return
+	}
+	writeDownload(w, "alignment"+format.ext, format.contentType, buf.Bytes())
+}
+
+// writeMSA writes an alignment in the format of the given name.
+func writeMSA(buf *bytes.Buffer, m *bioflow.MSA, name string) error {
+	format, err := bioflow.ParseMSAFormat(name)
+	if err != nil {
+		return err
+	}
+	return bioflow.FormatMSA(buf, m, format)
+}
+
+// writeDownload sends a rendered file as an attachment named filename.
+func writeDownload(w http.ResponseWriter, filename, contentType string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Write(data)
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aria-lang/bioflow-go/pkg/bioflow"
+)
+
+// ReadinessCheck checks a dependency the server needs to serve requests.
+// It returns a short description of the dependency's state, or an error
+// if it is unusable.
+type ReadinessCheck func(ctx context.Context) (string, error)
+
+// ReadinessTimeout bounds the time ReadinessHandler waits for all checks;
+// a check still running then has failed.
+var ReadinessTimeout = 2 * time.Second
+
+// readiness holds the registered checks, by name.
+var readiness = struct {
+	sync.Mutex
+	checks map[string]ReadinessCheck
+}{checks: map[string]ReadinessCheck{"job_queue": checkJobQueue}}
+
+// RegisterReadinessCheck adds a check run by ReadinessHandler, replacing
+// any of the same name.
+func RegisterReadinessCheck(name string, check ReadinessCheck) {
+	readiness.Lock()
+	defer readiness.Unlock()
+	readiness.checks[name] = check
+}
+
+// started is when the server started, for the reported uptime.
+var started = time.Now()
+
+// CheckResult is the outcome of a readiness check.
+type CheckResult struct {
+	Status   string  `json:"status"` // "ok" or "failing"
+	Detail   string  `json:"detail,omitempty"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"duration_seconds"`
+}
+
+// HealthReport is the body of LivenessHandler and ReadinessHandler.
+type HealthReport struct {
+	Status  string                 `json:"status"` // "ok" or "unavailable"
+	Version string                 `json:"version"`
+	Uptime  float64                `json:"uptime_seconds"`
+	Checks  map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// LivenessHandler reports that the server is up, for liveness probes. It
+// checks nothing else, so that a failing dependency does not get the
+// server restarted.
+func LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, HealthReport{Status: "ok", Version: bioflow.Version(), Uptime: time.Since(started).Seconds()})
+}
+
+// ReadinessHandler runs the readiness checks concurrently and replies 200
+// if they all pass, or 503 otherwise, with the result of each check.
+func ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	readiness.Lock()
+	checks := make(map[string]ReadinessCheck, len(readiness.checks))
+	for name, check := range readiness.checks {
+		checks[name] = check
+	}
+	readiness.Unlock()
+
+	ctx, cancel := context.WithTimeout(r.Context(), ReadinessTimeout)
+	defer cancel()
+	type outcome struct {
+		name   string
+		result CheckResult
+	}
+	outcomes := make(chan outcome, len(checks))
+	for name, check := range checks {
+		go func(name string, check ReadinessCheck) {
+			begin := time.Now()
+			detail, err := check(ctx)
+			res := CheckResult{Status: "ok", Detail: detail, Duration: time.Since(begin).Seconds()}
+			if err != nil {
+				res.Status, res.Error = "failing", err.Error()
+			}
+			outcomes <- outcome{name, res}
+		}(name, check)
+	}
+
+	report := HealthReport{Status: "ok", Version: bioflow.Version(), Uptime: time.Since(started).Seconds(), Checks: make(map[string]CheckResult)}
+wait:
+	for range checks {
+		select {
+		case o := <-outcomes:
+			report.Checks[o.name] = o.result
+		case <-ctx.Done():
+			break wait
+		}
+	}
+	// Checks that did not answer in time have failed.
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := report.Checks[name]; !ok {
+			report.Checks[name] = CheckResult{Status: "failing", Error: "timed out", Duration: ReadinessTimeout.Seconds()}
+		}
+		if report.Checks[name].Status != "ok" {
+			report.Status = "unavailable"
+		}
+	}
+
+	if report.Status != "ok" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(report)
+		return
+	}
+	writeHealth(w, report)
+}
+
+// writeHealth writes a health report with status 200.
+func writeHealth(w http.ResponseWriter, report HealthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// checkJobQueue checks that the pipeline job queue answers: the local
+// queue, or the broker and its workers.
+func checkJobQueue(ctx context.Context) (string, error) {
+	if jobClient != nil {
+		st, err := jobClient.Stats(ctx)
+		if err != nil {
+			return "", fmt.Errorf("broker: %w", err)
+		}
+		if st.Workers == 0 {
+			// Jobs still queue, to run once a worker is up.
+			return fmt.Sprintf("no workers up, %d queued", st.Queued), nil
+		}
+		return fmt.Sprintf("%d worker slots, %d running, %d queued", st.Workers, st.Running, st.Queued), nil
+	}
+
+	stats := make(chan bioflow.JobQueueStats, 1)
+	go func() { stats <- pipelineQueue().Stats() }()
+	select {
+	case st := <-stats:
+		return fmt.Sprintf("%d workers, %d running, %d queued", st.Workers, st.Running, st.Queued), nil
+	case <-ctx.Done():
+		return "", errors.New("job queue is not responding")
+	}
+}
+
+// checkJobStore checks that the job store can be read and written.
+func checkJobStore(ctx context.Context) (string, error) {
+	if err := jobStore.Check(); err != nil {
+		return "", err
+	}
+	return jobStore.Path(""), nil
+}
+
+// checkAuditLog checks that the last audit entry was written.
+func checkAuditLog(ctx context.Context) (string, error) {
+	if err := auditLog.Check(); err != nil {
+		return "", err
+	}
+	return auditLog.Dir(), nil
+}
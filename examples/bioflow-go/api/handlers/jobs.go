@@ -0,0 +1,769 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/aria-lang/bioflow-go/api/auth"
+	"github.com/aria-lang/bioflow-go/pkg/bioflow"
+)
+
+// JobRequest starts an asynchronous job: an operation over the sequences
+// of a FASTA payload or the reads of a FASTQ payload, for inputs too large
+// to answer within the request timeout. Params holds the options of the
+// operation, as its synchronous endpoint takes them.
+type JobRequest struct {
+	Operation string          `json:"operation"`
+	FASTA     string          `json:"fasta,omitempty"`
+	FASTQ     string          `json:"fastq,omitempty"`
+	Encoding  string          `json:"encoding,omitempty"` // of the FASTQ: "phred33" or "phred64"
+	Params    json.RawMessage `json:"params,omitempty"`
+	// Priority is "interactive", "normal" (the default) or "batch".
+	Priority string `json:"priority,omitempty"`
+}
+
+// AsyncJob is the state of an asynchronous job. Result holds the output
+// of the operation, as its synchronous endpoint replies with it, once the
+// job is done; finished jobs are forgotten at Expires. Jobs are kept with
+// pipeline jobs in the job store, when there is one, and so survive
+// restarts.
+type AsyncJob struct {
+	ID        string              `json:"id"`
+	Operation string              `json:"operation"`
+	Status    string              `json:"status"` // "queued", "running", "done" or "failed"
+	Priority  bioflow.JobPriority `json:"priority"`
+	Owner     string              `json:"owner,omitempty"`
+	Error     string              `json:"error,omitempty"`
+	Submitted time.Time           `json:"submitted"`
+	Started   *time.Time          `json:"started,omitempty"`
+	Finished  *time.Time          `json:"finished,omitempty"`
+	Expires   *time.Time          `json:"expires,omitempty"`
+	Result    json.RawMessage     `json:"result,omitempty"`
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Fields saved in the job store.
+	params   json.RawMessage
+	seq      uint64
+	attempts int
+	input    string
+}
+
+// asyncJobSpec is what an asynchronous job runs, besides its input.
+type asyncJobSpec struct {
+	Operation string          `json:"operation"`
+	Params    json.RawMessage `json:"params,omitempty"`
+}
+
+// asyncJobPrefix starts the IDs of asynchronous jobs, which tells them
+// from pipeline jobs in the job store.
+const asyncJobPrefix = "async-"
+
+// AsyncJobLimits bounds the asynchronous jobs run at once and queued per
+// priority class, apart from pipeline jobs. Set it before the first job.
+var AsyncJobLimits = bioflow.DefaultJobQueueOptions()
+
+// AsyncJobTTL is how long the result of a finished job is kept; zero keeps
+// results until the server stops. AsyncJobTimeout bounds the run of a
+// job, and AsyncAlignmentLimits the alignments of align jobs, which may be
+// far larger than those of the alignment endpoints.
+var (
+	AsyncJobTTL          = time.Hour
+	AsyncJobTimeout      = 30 * time.Minute
+	AsyncAlignmentLimits = bioflow.AlignmentLimits{MaxCells: 400_000_000, Timeout: 10 * time.Minute}
+)
+
+// asyncQueue runs asynchronous jobs; it is created on first use from
+// AsyncJobLimits.
+var asyncQueue struct {
+	once sync.Once
+	q    *bioflow.JobQueue
+}
+
+// asyncJobQueue returns the queue of asynchronous jobs.
+func asyncJobQueue() *bioflow.JobQueue {
+	asyncQueue.once.Do(func() {
+		asyncQueue.q = bioflow.NewJobQueue(AsyncJobLimits)
+	})
+	return asyncQueue.q
+}
+
+// asyncJobs holds asynchronous jobs in memory, in order of creation.
+// Their IDs are numbered from the job store when there is one.
+var asyncJobs = struct {
+	sync.Mutex
+	byID  map[string]*AsyncJob
+	order []string
+	next  uint64
+}{byID: make(map[string]*AsyncJob)}
+
+// jobInput is the parsed payload of a job.
+type jobInput struct {
+	sequences []*bioflow.Sequence
+	reads     []*bioflow.Read
+}
+
+// jobRun computes the result of a job.
+type jobRun func(ctx context.Context) (any, error)
+
+// jobOperation is an operation jobs run. Input is the payload it takes,
+// "fasta" or "fastq", and prepare checks its params and input before the
+// job is queued.
+type jobOperation struct {
+	input   string
+	prepare func(params json.RawMessage, in jobInput) (jobRun, error)
+}
+
+// jobOperations are the operations of asynchronous jobs, by name.
+var jobOperations = map[string]jobOperation{
+	"align-local":    {input: "fasta", prepare: prepareAlignment(true)},
+	"align-global":   {input: "fasta", prepare: prepareAlignment(false)},
+	"sequence-stats": {input: "fasta", prepare: prepareSequenceStats},
+	"read-stats":     {input: "fastq", prepare: prepareReadStats},
+	"kmer-count":     {input: "fasta", prepare: prepareKMerCount},
+	"filter":         {input: "fastq", prepare: prepareFilter},
+}
+
+// jobOperationNames returns the sorted names of the job operations.
+func jobOperationNames() []string {
+	names := make([]string, 0, len(jobOperations))
+	for name := range jobOperations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// decodeParams decodes the params of a job into v; missing params leave v
+// as it is.
+func decodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+	return nil
+}
+
+// prepareAlignment aligns the two sequences of the FASTA payload, locally
+// or globally, with the options of AlignmentRequest.
+func prepareAlignment(local bool) func(json.RawMessage, jobInput) (jobRun, error) {
+	return func(params json.RawMessage, in jobInput) (jobRun, error) {
+		var req AlignmentRequest
+		if err := decodeParams(params, &req); err != nil {
+			return nil, err
+		}
+		if len(in.sequences) != 2 {
+			return nil, fmt.Errorf("alignment needs 2 sequences, got %d", len(in.sequences))
+		}
+		scoring, err := requestScoring(req)
+		if err != nil {
+			return nil, err
+		}
+		seq1, seq2 := in.sequences[0], in.sequences[1]
+		return func(ctx context.Context) (any, error) {
+			if local {
+				resp, err := localAlignment(ctx, seq1, seq2, scoring, req, AsyncAlignmentLimits)
+				if err != nil {
+					return nil, err
+				}
+				countAlignment("local", seq1, seq2)
+				return resp, nil
+			}
+			alignment, err := bioflow.AlignGlobalContext(ctx, seq1, seq2, scoring, AsyncAlignmentLimits)
+			if err != nil {
+				return nil, err
+			}
+			countAlignment("global", seq1, seq2)
+			return alignment.Summary(), nil
+		}, nil
+	}
+}
+
+// prepareSequenceStats summarizes the sequences of the FASTA payload, with
+// the bootstrap options of SequenceSetRequest.
+func prepareSequenceStats(params json.RawMessage, in jobInput) (jobRun, error) {
+	var req SequenceSetRequest
+	if err := decodeParams(params, &req); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) (any, error) {
+		stats, err := bioflow.SequenceSetStats(in.sequences)
+		if err != nil {
+			return nil, err
+		}
+		if req.Bootstrap > 0 {
+			stats.Intervals, err = bioflow.BootstrapSequencesContext(ctx, in.sequences, bootstrapOptions(req.Bootstrap, req.Seed))
+		}
+		return stats, err
+	}, nil
+}
+
+// prepareReadStats summarizes the reads of the FASTQ payload, with the
+// bootstrap options of ReadSetStatsRequest.
+func prepareReadStats(params json.RawMessage, in jobInput) (jobRun, error) {
+	var req ReadSetStatsRequest
+	if err := decodeParams(params, &req); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) (any, error) {
+		stats, err := bioflow.ReadStats(in.reads)
+		if err != nil {
+			return nil, err
+		}
+		if req.Bootstrap > 0 {
+			if stats.Intervals, err = bioflow.BootstrapReadsContext(ctx, in.reads, bootstrapOptions(req.Bootstrap, req.Seed)); err != nil {
+				return nil, err
+			}
+		}
+		return ReadSetStatsResponse{ReadSetStats: stats, HighQualityRatio: stats.HighQualityRatio()}, nil
+	}, nil
+}
+
+// KMerJobParams are the params of a kmer-count job: k, and the number of
+// most frequent k-mers reported.
+type KMerJobParams struct {
+	K   int `json:"k"`
+	Top int `json:"top,omitempty"` // default 100
+}
+
+// KMerJobResult is the result of a kmer-count job: the k-mers of all the
+// sequences, counted on the forward strand.
+type KMerJobResult struct {
+	K           int        `json:"k"`
+	UniqueCount int        `json:"unique_count"`
+	TotalCount  int        `json:"total_count"`
+	KMers       []KMerItem `json:"kmers"`
+}
+
+// prepareKMerCount counts the k-mers of the FASTA payload and reports the
+// most frequent ones.
+func prepareKMerCount(params json.RawMessage, in jobInput) (jobRun, error) {
+	p := KMerJobParams{Top: 100}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if p.K <= 0 || p.Top <= 0 {
+		return nil, fmt.Errorf("k and top must be positive")
+	}
+	if limit := limitsFor("/api/jobs").MaxK; limit > 0 && p.K > limit {
+		return nil, fmt.Errorf("k of %d exceeds the limit of %d", p.K, limit)
+	}
+	return func(ctx context.Context) (any, error) {
+		total := &bioflow.PackedKMerCounter{K: p.K, Counts: make(map[uint64]int)}
+		for _, seq := range in.sequences {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if seq.Len() < p.K {
+				continue
+			}
+			counter, err := bioflow.CountPackedKMers(seq, p.K, bioflow.ForwardStrand, false)
+			if err != nil {
+				return nil, err
+			}
+			for code, n := range counter.Counts {
+				total.Counts[code] += n
+			}
+			total.Total += counter.Total
+		}
+		top, err := total.MostFrequent(p.Top)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]KMerItem, len(top))
+		for i, kc := range top {
+			items[i] = KMerItem{KMer: kc.KMer, Count: kc.Count}
+		}
+		return KMerJobResult{K: p.K, UniqueCount: total.UniqueCount(), TotalCount: total.Total, KMers: items}, nil
+	}, nil
+}
+
+// FilterJobResult is the result of a filter job: the reads that passed
+// and failed, and the reasons of the failures, grouped as in QC reports.
+type FilterJobResult struct {
+	Total          int            `json:"total"`
+	Passed         int            `json:"passed"`
+	Failed         int            `json:"failed"`
+	AdapterTrimmed int            `json:"adapter_trimmed"`
+	Reasons        map[string]int `json:"reasons,omitempty"`
+}
+
+// prepareFilter trims and filters the reads of the FASTQ payload, with the
+// filter options of FilterReadRequest.
+func prepareFilter(params json.RawMessage, in jobInput) (jobRun, error) {
+	var req FilterReadRequest
+	if err := decodeParams(params, &req); err != nil {
+		return nil, err
+	}
+	filter, err := requestFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) (any, error) {
+		result, err := bioflow.NewPipeline(filter).ProcessReadsContext(ctx, in.reads)
+		if err != nil {
+			return nil, err
+		}
+		countReadBases("quality", in.reads)
+		countFiltered(result.PassedCount, result.FailedCount)
+		summary := bioflow.BatchFilterSummary(result)
+		return FilterJobResult{
+			Total:          summary.Total,
+			Passed:         summary.Passed,
+			Failed:         summary.Failed,
+			AdapterTrimmed: result.AdapterTrimmed,
+			Reasons:        summary.Reasons,
+		}, nil
+	}, nil
+}
+
+// parseJobInput parses the payload an operation takes.
+func parseJobInput(ctx context.Context, req JobRequest, op jobOperation) (jobInput, error) {
+	var in jobInput
+	var err error
+	switch op.input {
+	case "fasta":
+		if req.FASTA == "" {
+			return in, fmt.Errorf("operation %s needs 'fasta'", req.Operation)
+		}
+		in.sequences, err = bioflow.ParseFASTA(strings.NewReader(req.FASTA))
+		if err == nil && len(in.sequences) == 0 {
+			err = fmt.Errorf("no sequences in 'fasta'")
+		}
+	case "fastq":
+		if req.FASTQ == "" {
+			return in, fmt.Errorf("operation %s needs 'fastq'", req.Operation)
+		}
+		in.reads, err = parseReadInputs(ctx, ReadSetStatsRequest{FASTQ: req.FASTQ, Encoding: req.Encoding})
+	}
+	return in, err
+}
+
+// addAsyncJob registers a new queued job and forgets expired ones.
+func addAsyncJob(operation string, params json.RawMessage, priority bioflow.JobPriority, owner string) (*AsyncJob, error) {
+	var seq uint64
+	if jobStore != nil {
+		var err error
+		if seq, err = jobStore.NextSeq(); err != nil {
+			return nil, err
+		}
+	}
+
+	asyncJobs.Lock()
+	expired := expireAsyncJobs(time.Now())
+	if jobStore == nil {
+		asyncJobs.next++
+		seq = asyncJobs.next
+	}
+	job := &AsyncJob{
+		ID:        fmt.Sprintf("%s%d", asyncJobPrefix, seq),
+		Operation: operation,
+		Status:    bioflow.JobQueued,
+		Priority:  priority,
+		Owner:     owner,
+		Submitted: time.Now().UTC(),
+		params:    params,
+		seq:       seq,
+	}
+	job.ctx, job.cancel = context.WithCancel(context.Background())
+	asyncJobs.byID[job.ID] = job
+	asyncJobs.order = append(asyncJobs.order, job.ID)
+	asyncJobs.Unlock()
+
+	deleteStoredJobs(expired)
+	return job, nil
+}
+
+// removeAsyncJob forgets a job, cancelling it if it has not finished.
+func removeAsyncJob(job *AsyncJob) {
+	asyncJobs.Lock()
+	job.cancel()
+	delete(asyncJobs.byID, job.ID)
+	for i, id := range asyncJobs.order {
+		if id == job.ID {
+			asyncJobs.order = append(asyncJobs.order[:i], asyncJobs.order[i+1:]...)
+			break
+		}
+	}
+	asyncJobs.Unlock()
+	deleteStoredJobs([]string{job.ID})
+}
+
+// expireAsyncJobs forgets the jobs whose results have expired, returning
+// their IDs to be deleted from the job store; asyncJobs must be locked.
+func expireAsyncJobs(now time.Time) []string {
+	var expired []string
+	order := asyncJobs.order[:0]
+	for _, id := range asyncJobs.order {
+		if job := asyncJobs.byID[id]; job.Expires != nil && !now.Before(*job.Expires) {
+			delete(asyncJobs.byID, id)
+			expired = append(expired, id)
+			continue
+		}
+		order = append(order, id)
+	}
+	asyncJobs.order = order
+	return expired
+}
+
+// deleteStoredJobs deletes forgotten jobs, and their files, from the job
+// store, if there is one.
+func deleteStoredJobs(ids []string) {
+	if jobStore == nil {
+		return
+	}
+	for _, id := range ids {
+		if err := jobStore.Delete(id); err != nil && !errors.Is(err, bioflow.ErrJobNotFound) {
+			log.Printf("Error deleting %s from the job store: %v", id, err)
+		}
+	}
+}
+
+// record returns the job store record of a job; asyncJobs must be
+// locked.
+func (job *AsyncJob) record() (*bioflow.JobRecord, error) {
+	spec, err := json.Marshal(asyncJobSpec{Operation: job.Operation, Params: job.params})
+	if err != nil {
+		return nil, err
+	}
+	state, err := json.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+	return &bioflow.JobRecord{
+		ID: job.ID, Seq: job.seq, Status: job.Status, Attempts: job.attempts,
+		Spec: spec, State: state, Input: job.input,
+	}, nil
+}
+
+// save writes a job to the job store, if there is one.
+func (job *AsyncJob) save() error {
+	if jobStore == nil {
+		return nil
+	}
+	asyncJobs.Lock()
+	rec, err := job.record()
+	asyncJobs.Unlock()
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", job.ID, err)
+	}
+	return jobStore.Put(rec)
+}
+
+// saveInput writes the parsed input of a job to the job store, so that it
+// can be run again after a restart.
+func (job *AsyncJob) saveInput(in jobInput) error {
+	if in.reads != nil {
+		job.input = job.ID + ".in.jsonl.zst"
+		return bioflow.WriteJSONLReads(jobStore.Path(job.input), in.reads)
+	}
+	job.input = job.ID + ".in.fa.zst"
+	return bioflow.WriteFASTA(jobStore.Path(job.input), in.sequences)
+}
+
+// loadInput reads back the input of a job saved by saveInput.
+func (job *AsyncJob) loadInput() (jobInput, error) {
+	var in jobInput
+	var err error
+	if bioflow.IsJSONLines(job.input) {
+		in.reads, err = bioflow.ReadReadsFile(jobStore.Path(job.input))
+	} else {
+		in.sequences, err = bioflow.ReadFASTA(jobStore.Path(job.input))
+	}
+	return in, err
+}
+
+// run runs a job within AsyncJobTimeout and records its result.
+func (job *AsyncJob) run(op jobRun) {
+	if job.ctx.Err() != nil {
+		return // deleted while queued
+	}
+	asyncJobs.Lock()
+	started := time.Now().UTC()
+	job.Status, job.Started = bioflow.JobRunning, &started
+	job.attempts++
+	asyncJobs.Unlock()
+	if err := job.save(); err != nil {
+		log.Printf("Error saving %s: %v", job.ID, err)
+	}
+
+	ctx := job.ctx
+	if AsyncJobTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, AsyncJobTimeout)
+		defer cancel()
+	}
+	ctx, span := bioflow.StartSpan(ctx, "async job", attribute.String("bioflow.job", job.ID), attribute.String("bioflow.operation", job.Operation))
+	var data []byte
+	result, err := op(ctx)
+	if err == nil {
+		data, err = json.Marshal(result)
+	}
+	bioflow.EndSpan(span, err)
+	if job.ctx.Err() != nil {
+		return // deleted while running
+	}
+
+	asyncJobs.Lock()
+	job.finish()
+	if err != nil {
+		job.Status, job.Error = bioflow.JobFailed, err.Error()
+	} else {
+		job.Status, job.Result = bioflow.JobDone, data
+	}
+	asyncJobs.Unlock()
+	if err := job.save(); err != nil {
+		log.Printf("Error saving %s: %v", job.ID, err)
+	}
+}
+
+// finish sets the time a job finished, and that its result expires;
+// asyncJobs must be locked.
+func (job *AsyncJob) finish() {
+	now := time.Now().UTC()
+	job.Finished = &now
+	if AsyncJobTTL > 0 {
+		expires := now.Add(AsyncJobTTL)
+		job.Expires = &expires
+	}
+}
+
+// fail marks a job failed and saves it.
+func (job *AsyncJob) fail(err error) {
+	asyncJobs.Lock()
+	job.finish()
+	job.Status, job.Error = bioflow.JobFailed, err.Error()
+	asyncJobs.Unlock()
+	if err := job.save(); err != nil {
+		log.Printf("Error saving %s: %v", job.ID, err)
+	}
+}
+
+// restoreAsyncJobs restores the asynchronous jobs of the job store, from
+// its records in order of creation, and queues those to run (again). It
+// returns the number of jobs queued.
+func restoreAsyncJobs(records []*bioflow.JobRecord, queued map[string]bool) int {
+	restored := make([]*AsyncJob, 0, len(records))
+	for _, rec := range records {
+		job, err := restoreAsyncJob(rec, queued[rec.ID])
+		if err != nil {
+			log.Printf("Error restoring %s: %v", rec.ID, err)
+			continue
+		}
+		restored = append(restored, job)
+	}
+
+	asyncJobs.Lock()
+	for _, job := range restored {
+		asyncJobs.byID[job.ID] = job
+		asyncJobs.order = append(asyncJobs.order, job.ID)
+	}
+	asyncJobs.Unlock()
+
+	n := 0
+	for _, job := range restored {
+		switch {
+		case job.Status == bioflow.JobQueued:
+			if err := job.resubmit(); err != nil {
+				job.fail(fmt.Errorf("requeueing after restart: %w", err))
+				continue
+			}
+			n++
+		case job.Status == bioflow.JobFailed && job.Finished == nil:
+			job.fail(fmt.Errorf("interrupted %d times", job.attempts))
+		}
+	}
+	return n
+}
+
+// restoreAsyncJob rebuilds a job from its record. Jobs to run again
+// restart from the beginning.
+func restoreAsyncJob(rec *bioflow.JobRecord, requeue bool) (*AsyncJob, error) {
+	var job AsyncJob
+	if err := json.Unmarshal(rec.State, &job); err != nil {
+		return nil, err
+	}
+	var spec asyncJobSpec
+	if err := json.Unmarshal(rec.Spec, &spec); err != nil {
+		return nil, err
+	}
+	job.ID, job.Status, job.seq, job.attempts = rec.ID, rec.Status, rec.Seq, rec.Attempts
+	job.Operation, job.params, job.input = spec.Operation, spec.Params, rec.Input
+	job.ctx, job.cancel = context.WithCancel(context.Background())
+	if requeue {
+		job.Status, job.Started = bioflow.JobQueued, nil
+	}
+	return &job, nil
+}
+
+// resubmit queues a restored job, preparing its operation again from its
+// saved params and input.
+func (job *AsyncJob) resubmit() error {
+	op, ok := jobOperations[job.Operation]
+	if !ok {
+		return fmt.Errorf("unknown operation %q", job.Operation)
+	}
+	in, err := job.loadInput()
+	if err != nil {
+		return err
+	}
+	run, err := op.prepare(job.params, in)
+	if err != nil {
+		return err
+	}
+	return asyncJobQueue().Submit(job.Priority, func() { job.run(run) })
+}
+
+// StartJobHandler validates an asynchronous job and queues it, replying
+// 202 with the job; poll JobHandler for its status and result. Unknown
+// operations and invalid input or params get 400, and a full queue 503
+// with a Retry-After header.
+func StartJobHandler(w http.ResponseWriter, r *http.Request) {
+	var req JobRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	op, ok := jobOperations[req.Operation]
+	if !ok {
+		http.Error(w, `{"error": "unknown operation, use one of: `+strings.Join(jobOperationNames(), ", ")+`"}`, http.StatusBadRequest)
+		return
+	}
+	priority, err := bioflow.ParseJobPriority(req.Priority)
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	in, err := parseJobInput(r.Context(), req, op)
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	run, err := op.prepare(req.Params, in)
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	job, err := addAsyncJob(req.Operation, req.Params, priority, auth.Owner(r.Context()))
+	if err == nil && jobStore != nil {
+		err = job.saveInput(in)
+		if err == nil {
+			err = job.save()
+		}
+	}
+	if err != nil {
+		if job != nil {
+			removeAsyncJob(job)
+		}
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+		return
+	}
+	if err := asyncJobQueue().Submit(priority, func() { job.run(run) }); err != nil {
+		removeAsyncJob(job)
+		if errors.Is(err, bioflow.ErrJobQueueFull) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(asyncJobQueue().RetryAfter(priority).Seconds())))
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job.snapshot())
+}
+
+// snapshot returns a copy of a job, to be encoded without holding
+// asyncJobs while the client reads it.
+func (job *AsyncJob) snapshot() AsyncJob {
+	asyncJobs.Lock()
+	defer asyncJobs.Unlock()
+	return *job
+}
+
+// lookupAsyncJob returns a job by the ID in the request path, if it is in
+// the workspace of the request's user and has not expired.
+func lookupAsyncJob(r *http.Request) (*AsyncJob, bool) {
+	asyncJobs.Lock()
+	expired := expireAsyncJobs(time.Now())
+	job, ok := asyncJobs.byID[chi.URLParam(r, "id")]
+	asyncJobs.Unlock()
+	deleteStoredJobs(expired)
+	if !ok || (auth.Owner(r.Context()) != "" && job.Owner != auth.Owner(r.Context())) {
+		return nil, false
+	}
+	return job, true
+}
+
+// ListJobsHandler lists the asynchronous jobs in the workspace of the
+// request's user, newest first, without their results.
+func ListJobsHandler(w http.ResponseWriter, r *http.Request) {
+	owner := auth.Owner(r.Context())
+	asyncJobs.Lock()
+	expired := expireAsyncJobs(time.Now())
+	list := make([]AsyncJob, 0, len(asyncJobs.order))
+	for i := len(asyncJobs.order) - 1; i >= 0; i-- {
+		job := asyncJobs.byID[asyncJobs.order[i]]
+		if owner != "" && job.Owner != owner {
+			continue
+		}
+		list = append(list, AsyncJob{
+			ID: job.ID, Operation: job.Operation, Status: job.Status, Priority: job.Priority, Owner: job.Owner,
+			Error: job.Error, Submitted: job.Submitted, Started: job.Started, Finished: job.Finished, Expires: job.Expires,
+		})
+	}
+	asyncJobs.Unlock()
+	deleteStoredJobs(expired)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// JobHandler reports the status of an asynchronous job, with its result
+// once it is done.
+func JobHandler(w http.ResponseWriter, r *http.Request) {
+	job, ok := lookupAsyncJob(r)
+	if !ok {
+		http.Error(w, `{"error": "job not found"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job.snapshot())
+}
+
+// DeleteJobHandler cancels an asynchronous job that has not finished, and
+// forgets it and its result.
+func DeleteJobHandler(w http.ResponseWriter, r *http.Request) {
+	job, ok := lookupAsyncJob(r)
+	if !ok {
+		http.Error(w, `{"error": "job not found"}`, http.StatusNotFound)
+		return
+	}
+	removeAsyncJob(job)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// JobQueueHandler reports the queue of asynchronous jobs, as
+// PipelineQueueHandler does that of pipeline jobs.
+func JobQueueHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(asyncJobQueue().Stats())
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/aria-lang/bioflow-go/pkg/bioflow"
@@ -11,11 +13,22 @@ import (
 type KMerRequest struct {
 	Sequence string `json:"sequence"`
 	K        int    `json:"k"`
+	// Seed is an optional spaced-seed pattern (e.g. "1101101"); when set
+	// it replaces K.
+	Seed string `json:"seed,omitempty"`
+	// SkipMasked leaves out k-mers overlapping soft-masked (lower-case)
+	// bases.
+	SkipMasked bool `json:"skip_masked,omitempty"`
+	// Strand counts the forward strand (the default), the reverse strand
+	// or both, without merging reverse complements.
+	Strand string `json:"strand,omitempty"`
 }
 
 // KMerCountResponse represents the response for k-mer counting.
 type KMerCountResponse struct {
 	K           int               `json:"k"`
+	Seed        string            `json:"seed,omitempty"`
+	Strand      string            `json:"strand"`
 	UniqueCount int               `json:"unique_count"`
 	TotalCount  int               `json:"total_count"`
 	Counts      map[string]int    `json:"counts"`
@@ -24,31 +37,62 @@ type KMerCountResponse struct {
 // KMerCountHandler handles k-mer counting requests.
 func KMerCountHandler(w http.ResponseWriter, r *http.Request) {
 	var req KMerRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	if req.K <= 0 {
+	if req.K <= 0 && req.Seed == "" {
 		http.Error(w, `{"error": "k must be positive"}`, http.StatusBadRequest)
 		return
 	}
 
-	seq, err := bioflow.NewSequence(req.Sequence)
+	seq, err := newMaskedSequence(req.Sequence, req.SkipMasked)
 	if err != nil {
 		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
 		return
 	}
 
-	counter, err := bioflow.CountKMers(seq, req.K)
+	strand := bioflow.ForwardStrand
+	if req.Strand != "" {
+		if strand, err = bioflow.ParseStrand(req.Strand); err != nil {
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
+	}
+
+	var counter *bioflow.KMerCounter
+	if req.SkipMasked && req.Seed != "" {
+		http.Error(w, `{"error": "skip_masked needs contiguous k-mers, not a seed"}`, http.StatusBadRequest)
+		return
+	}
+	if strand != bioflow.ForwardStrand && req.Seed != "" {
+		http.Error(w, `{"error": "strand needs contiguous k-mers, not a seed"}`, http.StatusBadRequest)
+		return
+	}
+	if req.SkipMasked || strand != bioflow.ForwardStrand {
+		counter, err = bioflow.CountStrandedKMers(seq, req.K, strand, req.SkipMasked)
+	} else if req.Seed != "" {
+		seed, err := bioflow.ParseSpacedSeed(req.Seed)
+		if err != nil {
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
+		counter, err = bioflow.CountSpacedKMersContext(r.Context(), seq, seed)
+	} else {
+		counter, err = bioflow.CountKMersContext(r.Context(), seq, req.K)
+	}
 	if err != nil {
-		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		countError(w, err)
 		return
 	}
 
+	countBases("kmer", seq)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(KMerCountResponse{
-		K:           req.K,
+		K:           counter.K,
+		Seed:        req.Seed,
+		Strand:      strand.Name(),
 		UniqueCount: counter.UniqueCount(),
 		TotalCount:  counter.Total,
 		Counts:      counter.Counts,
@@ -76,8 +120,7 @@ type KMerItem struct {
 // MostFrequentKMersHandler handles most frequent k-mers requests.
 func MostFrequentKMersHandler(w http.ResponseWriter, r *http.Request) {
 	var req MostFrequentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -92,7 +135,12 @@ func MostFrequentKMersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	kmers, err := bioflow.MostFrequentKMers(seq, req.K, req.N)
+	counter, err := bioflow.CountKMersContext(r.Context(), seq, req.K)
+	if err != nil {
+		countError(w, err)
+		return
+	}
+	kmers, err := counter.MostFrequent(req.N)
 	if err != nil {
 		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
 		return
@@ -103,6 +151,8 @@ func MostFrequentKMersHandler(w http.ResponseWriter, r *http.Request) {
 		items[i] = KMerItem{KMer: kc.KMer, Count: kc.Count}
 	}
 
+	countBases("kmer", seq)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(MostFrequentResponse{KMers: items})
 }
@@ -112,6 +162,7 @@ type KMerDistanceRequest struct {
 	Sequence1 string `json:"sequence1"`
 	Sequence2 string `json:"sequence2"`
 	K         int    `json:"k"`
+	Seed      string `json:"seed,omitempty"`
 }
 
 // KMerDistanceResponse represents the response for k-mer distance.
@@ -123,12 +174,11 @@ type KMerDistanceResponse struct {
 // KMerDistanceHandler handles k-mer distance requests.
 func KMerDistanceHandler(w http.ResponseWriter, r *http.Request) {
 	var req KMerDistanceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	if req.K <= 0 {
+	if req.K <= 0 && req.Seed == "" {
 		http.Error(w, `{"error": "k must be positive"}`, http.StatusBadRequest)
 		return
 	}
@@ -145,12 +195,24 @@ func KMerDistanceHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	distance, err := bioflow.KMerDistance(seq1, seq2, req.K)
+	var distance float64
+	if req.Seed != "" {
+		seed, err := bioflow.ParseSpacedSeed(req.Seed)
+		if err != nil {
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
+		distance, err = bioflow.SpacedKMerDistance(seq1, seq2, seed)
+	} else {
+		distance, err = bioflow.KMerDistance(seq1, seq2, req.K)
+	}
 	if err != nil {
 		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
 		return
 	}
 
+	countBases("kmer", seq1, seq2)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(KMerDistanceResponse{
 		Distance:   distance,
@@ -163,19 +225,50 @@ type SharedKMersRequest struct {
 	Sequence1 string `json:"sequence1"`
 	Sequence2 string `json:"sequence2"`
 	K         int    `json:"k"`
+	// Positions adds the anchor pairs (positions in both sequences).
+	// K-mers occurring more than sharedMaxOccurrences times in sequence2
+	// give no anchors, and more than sharedMaxAnchors anchors fail the
+	// request with 413.
+	Positions bool `json:"positions,omitempty"`
+	// BothStrands also matches reverse complements when Positions is set.
+	BothStrands bool `json:"both_strands,omitempty"`
+	// Chain adds collinear chains of anchors when Positions is set.
+	Chain bool `json:"chain,omitempty"`
+	// SkipMasked finds no anchors overlapping soft-masked (lower-case)
+	// bases.
+	SkipMasked bool `json:"skip_masked,omitempty"`
 }
 
 // SharedKMersResponse represents the response for shared k-mers.
 type SharedKMersResponse struct {
-	SharedKMers []string `json:"shared_kmers"`
-	Count       int      `json:"count"`
+	SharedKMers []string     `json:"shared_kmers"`
+	Count       int          `json:"count"`
+	Anchors     []AnchorItem `json:"anchors,omitempty"`
+	Chains      []ChainItem  `json:"chains,omitempty"`
+}
+
+// AnchorItem is a shared k-mer position pair.
+type AnchorItem struct {
+	Pos1   int    `json:"pos1"`
+	Pos2   int    `json:"pos2"`
+	Strand string `json:"strand"`
+}
+
+// ChainItem is a collinear chain of anchors.
+type ChainItem struct {
+	Strand  string  `json:"strand"`
+	Score   float64 `json:"score"`
+	Start1  int     `json:"start1"`
+	End1    int     `json:"end1"`
+	Start2  int     `json:"start2"`
+	End2    int     `json:"end2"`
+	Anchors int     `json:"anchors"`
 }
 
 // SharedKMersHandler handles shared k-mers requests.
 func SharedKMersHandler(w http.ResponseWriter, r *http.Request) {
 	var req SharedKMersRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -184,13 +277,13 @@ func SharedKMersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	seq1, err := bioflow.NewSequence(req.Sequence1)
+	seq1, err := newMaskedSequence(req.Sequence1, req.SkipMasked)
 	if err != nil {
 		http.Error(w, `{"error": "sequence1: `+err.Error()+`"}`, http.StatusBadRequest)
 		return
 	}
 
-	seq2, err := bioflow.NewSequence(req.Sequence2)
+	seq2, err := newMaskedSequence(req.Sequence2, req.SkipMasked)
 	if err != nil {
 		http.Error(w, `{"error": "sequence2: `+err.Error()+`"}`, http.StatusBadRequest)
 		return
@@ -202,9 +295,90 @@ func SharedKMersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(SharedKMersResponse{
+	resp := SharedKMersResponse{
 		SharedKMers: shared,
 		Count:       len(shared),
-	})
+	}
+
+	if req.Positions {
+		anchors, err := bioflow.FindAnchorsContext(r.Context(), seq1, seq2, bioflow.AnchorOptions{
+			K:              req.K,
+			BothStrands:    req.BothStrands,
+			MaxOccurrences: sharedMaxOccurrences,
+			MaxAnchors:     sharedMaxAnchors,
+			SkipSoftMasked: req.SkipMasked,
+		})
+		if err != nil {
+			anchorError(w, err)
+			return
+		}
+		resp.Anchors = make([]AnchorItem, len(anchors))
+		for i, a := range anchors {
+			resp.Anchors[i] = AnchorItem{Pos1: a.Pos1, Pos2: a.Pos2, Strand: string(a.Strand)}
+		}
+
+		if req.Chain {
+			chains, err := bioflow.ChainAnchors(anchors, bioflow.DefaultChainOptions(req.K))
+			if err != nil {
+				http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+				return
+			}
+			resp.Chains = make([]ChainItem, len(chains))
+			for i, c := range chains {
+				resp.Chains[i] = ChainItem{
+					Strand:  string(c.Strand),
+					Score:   c.Score,
+					Start1:  c.Start1,
+					End1:    c.End1,
+					Start2:  c.Start2,
+					End2:    c.End2,
+					Anchors: len(c.Anchors),
+				}
+			}
+		}
+	}
+
+	countBases("kmer", seq1, seq2)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Anchor limits of SharedKMersHandler. Repeats such as poly-A would
+// otherwise pair every position of one sequence with every position of
+// the other.
+const (
+	sharedMaxOccurrences = 64
+	sharedMaxAnchors     = 1 << 20
+)
+
+// anchorError writes an anchor finding failure: 413 for too many
+// anchors, and as countError otherwise.
+func anchorError(w http.ResponseWriter, err error) {
+	if errors.Is(err, bioflow.ErrTooManyAnchors) {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusRequestEntityTooLarge)
+		return
+	}
+	countError(w, err)
+}
+
+// countError writes a k-mer counting failure: 503 when the request timed
+// out, and 400 otherwise.
+func countError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, context.DeadlineExceeded) {
+		status = http.StatusServiceUnavailable
+	}
+	http.Error(w, `{"error": "`+err.Error()+`"}`, status)
+}
+
+// newMaskedSequence creates a sequence from request bases, recording
+// their soft-masked runs when masking is to be honoured.
+func newMaskedSequence(bases string, keepMask bool) (*bioflow.Sequence, error) {
+	if !keepMask {
+		return bioflow.NewSequence(bases)
+	}
+	policy := bioflow.StrictPolicy()
+	policy.PreserveCase = true
+	return bioflow.NewSequenceWithPolicy(bases, "", "", policy)
 }
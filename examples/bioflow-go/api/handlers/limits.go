@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// InputLimits bounds the input of a request, so that oversized input is
+// turned away before it is parsed, or reaches code whose time or memory
+// grows with it. Zero fields are unlimited.
+type InputLimits struct {
+	// MaxBodyBytes bounds the request body; larger bodies get 413.
+	MaxBodyBytes int64 `json:"max_body_bytes,omitempty"`
+	// MaxSequenceLength bounds each sequence or read; longer ones get 413.
+	MaxSequenceLength int `json:"max_sequence_length,omitempty"`
+	// MaxK bounds k-mer sizes; larger ones get 422.
+	MaxK int `json:"max_k,omitempty"`
+	// MaxBatch bounds the sequences or reads of one request; more get
+	// 413.
+	MaxBatch int `json:"max_batch,omitempty"`
+	// MaxCells bounds the dynamic programming matrix of a pairwise
+	// alignment, (m+1)*(n+1) cells for sequences of lengths m and n;
+	// larger ones get 413.
+	MaxCells int64 `json:"max_cells,omitempty"`
+}
+
+// DefaultInputLimits returns the limits of endpoints without limits of
+// their own: 32 MiB bodies, 10 Mb sequences, k up to 64 and 100,000
+// sequences per request.
+func DefaultInputLimits() InputLimits {
+	return InputLimits{MaxBodyBytes: 32 << 20, MaxSequenceLength: 10_000_000, MaxK: 64, MaxBatch: 100_000}
+}
+
+// RequestLimits holds the limits of all endpoints, and EndpointLimits
+// those of particular ones, by unversioned path (which also covers the
+// endpoint under /api/v1), whose non-zero fields take precedence. By
+// default, the alignment and folding endpoints, whose dynamic programming
+// grows with the square (or cube) of the input, take shorter sequences,
+// and alignments at most 100 million cells, whose byte traceback and
+// score rows stay within a few hundred MB however AlignmentLimits is set.
+// Set them before serving requests.
+var (
+	RequestLimits  = DefaultInputLimits()
+	EndpointLimits = map[string]InputLimits{
+		"/api/alignment/local":  {MaxSequenceLength: 100_000, MaxCells: 100_000_000},
+		"/api/alignment/global": {MaxSequenceLength: 100_000, MaxCells: 100_000_000},
+		"/api/alignment/score":  {MaxSequenceLength: 100_000, MaxCells: 100_000_000},
+		"/api/alignment/export": {MaxSequenceLength: 100_000, MaxCells: 100_000_000},
+		// Folding is O(n^3): 1.5 kb takes a few seconds, while 5 kb
+		// would run for minutes, far past the request timeout.
+		"/api/rna/fold": {MaxSequenceLength: 1_500},
+		"/api/jobs":     {MaxBodyBytes: 256 << 20},
+	}
+)
+
+// LoadEndpointLimits reads per-endpoint limits from a JSON file mapping
+// paths to InputLimits objects, and adds them to EndpointLimits.
+func LoadEndpointLimits(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("reading limits: %w", err)
+	}
+	var limits map[string]InputLimits
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return fmt.Errorf("parsing limits %s: %w", filename, err)
+	}
+	for path, l := range limits {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("limits %s: %q is not a path", filename, path)
+		}
+		EndpointLimits[endpointPath(path)] = l
+	}
+	return nil
+}
+
+// endpointPath returns the key of a path in EndpointLimits: unversioned,
+// without a trailing slash.
+func endpointPath(path string) string {
+	if rest, ok := strings.CutPrefix(path, "/api/v1/"); ok {
+		path = "/api/" + rest
+	}
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path
+}
+
+// limitsFor returns the limits of an endpoint.
+func limitsFor(path string) InputLimits {
+	l := RequestLimits
+	e, ok := EndpointLimits[endpointPath(path)]
+	if !ok {
+		return l
+	}
+	if e.MaxBodyBytes != 0 {
+		l.MaxBodyBytes = e.MaxBodyBytes
+	}
+	if e.MaxSequenceLength != 0 {
+		l.MaxSequenceLength = e.MaxSequenceLength
+	}
+	if e.MaxK != 0 {
+		l.MaxK = e.MaxK
+	}
+	if e.MaxBatch != 0 {
+		l.MaxBatch = e.MaxBatch
+	}
+	if e.MaxCells != 0 {
+		l.MaxCells = e.MaxCells
+	}
+	return l
+}
+
+// requestSize measures the input of a request.
+type requestSize struct {
+	Longest int // longest sequence, read or score list
+	K       int
+	Batch   int   // sequences or reads
+	Cells   int64 // alignment matrix cells
+}
+
+// sizedRequest is a request whose size is checked against InputLimits.
+type sizedRequest interface {
+	inputSize() requestSize
+}
+
+// decodeRequest decodes a JSON request body into req, within the body
+// limit of the endpoint, and checks the size of the input. It replies
+// with an error and returns false if the body is invalid or too large.
+func decodeRequest(w http.ResponseWriter, r *http.Request, req sizedRequest) bool {
+	limits := limitsFor(r.URL.Path)
+	if limits.MaxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, limits.MaxBodyBytes)
+	}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, `{"error": "request body exceeds the limit of `+strconv.FormatInt(tooLarge.Limit, 10)+` bytes"}`, http.StatusRequestEntityTooLarge)
+			return false
+		}
+		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+		return false
+	}
+
+	size := req.inputSize()
+	switch {
+	case limits.MaxSequenceLength > 0 && size.Longest > limits.MaxSequenceLength:
+		http.Error(w, fmt.Sprintf(`{"error": "sequence of %d exceeds the limit of %d"}`, size.Longest, limits.MaxSequenceLength), http.StatusRequestEntityTooLarge)
+	case limits.MaxBatch > 0 && size.Batch > limits.MaxBatch:
+		http.Error(w, fmt.Sprintf(`{"error": "%d sequences exceed the limit of %d per request"}`, size.Batch, limits.MaxBatch), http.StatusRequestEntityTooLarge)
+	case limits.MaxCells > 0 && size.Cells > limits.MaxCells:
+		http.Error(w, fmt.Sprintf(`{"error": "alignment of %d cells exceeds the limit of %d"}`, size.Cells, limits.MaxCells), http.StatusRequestEntityTooLarge)
+	case limits.MaxK > 0 && size.K > limits.MaxK:
+		http.Error(w, fmt.Sprintf(`{"error": "k of %d exceeds the limit of %d"}`, size.K, limits.MaxK), http.StatusUnprocessableEntity)
+	default:
+		return true
+	}
+	return false
+}
+
+// longest returns the length of the longest string.
+func longest(s ...string) int {
+	n := 0
+	for _, x := range s {
+		if len(x) > n {
+			n = len(x)
+		}
+	}
+	return n
+}
+
+// lineSize measures text without parsing it: the longest line and the
+// number of lines.
+func lineSize(text string) (longestLine, lines int) {
+	for len(text) > 0 {
+		end := strings.IndexByte(text, '\n')
+		if end < 0 {
+			end = len(text)
+		}
+		if end > longestLine {
+			longestLine = end
+		}
+		lines++
+		if end == len(text) {
+			break
+		}
+		text = text[end+1:]
+	}
+	return longestLine, lines
+}
+
+// fastaSize measures FASTA text without parsing it: the longest sequence,
+// over its wrapped lines, and the number of records.
+func fastaSize(text string) requestSize {
+	var size requestSize
+	length := 0
+	for len(text) > 0 {
+		line, rest, _ := strings.Cut(text, "\n")
+		if strings.HasPrefix(line, ">") {
+			size.Batch++
+			length = 0
+		} else {
+			length += len(strings.TrimRight(line, "\r"))
+			size.Longest = max(size.Longest, length)
+		}
+		text = rest
+	}
+	return size
+}
+
+// readsSize measures a list of reads or FASTQ text.
+func readsSize(reads []ReadInput, fastq string) requestSize {
+	n, lines := lineSize(fastq)
+	size := requestSize{Longest: n, Batch: (lines+3)/4 + len(reads)}
+	for _, read := range reads {
+		size.Longest = max(size.Longest, longest(read.Sequence, read.Quality), len(read.Scores))
+	}
+	return size
+}
+
+func (req *SequenceRequest) inputSize() requestSize {
+	return requestSize{Longest: len(req.Sequence), Batch: 1}
+}
+
+func (req *ValidateRequest) inputSize() requestSize {
+	return requestSize{Longest: len(req.Sequence), Batch: 1}
+}
+
+func (req *SequenceSetRequest) inputSize() requestSize {
+	return requestSize{Longest: longest(req.Sequences...), Batch: len(req.Sequences)}
+}
+
+func (req *HistogramRequest) inputSize() requestSize {
+	return requestSize{Longest: longest(req.Sequences...), Batch: len(req.Sequences)}
+}
+
+func (req *CompareSetsRequest) inputSize() requestSize {
+	la, na := lineSize(req.FASTQA)
+	lb, nb := lineSize(req.FASTQB)
+	return requestSize{
+		Longest: max(longest(req.A...), longest(req.B...), la, lb),
+		Batch:   len(req.A) + len(req.B) + (na+3)/4 + (nb+3)/4,
+	}
+}
+
+func (req *ReadSetStatsRequest) inputSize() requestSize {
+	return readsSize(req.Reads, req.FASTQ)
+}
+
+func (req *PipelineJobRequest) inputSize() requestSize {
+	return readsSize(req.Reads, req.FASTQ)
+}
+
+func (req *JobRequest) inputSize() requestSize {
+	size := fastaSize(req.FASTA)
+	reads := readsSize(nil, req.FASTQ)
+	return requestSize{Longest: max(size.Longest, reads.Longest), Batch: size.Batch + reads.Batch}
+}
+
+func (req *AlignmentRequest) inputSize() requestSize {
+	return requestSize{
+		Longest: longest(req.Sequence1, req.Sequence2),
+		Batch:   2,
+		Cells:   int64(len(req.Sequence1)+1) * int64(len(req.Sequence2)+1),
+	}
+}
+
+func (req *AlignmentExportRequest) inputSize() requestSize {
+	return req.AlignmentRequest.inputSize()
+}
+
+func (req *MSAExportRequest) inputSize() requestSize {
+	n, _ := lineSize(req.Alignment)
+	return requestSize{Longest: max(longest(req.Rows...), n), Batch: len(req.Rows)}
+}
+
+func (req *KMerRequest) inputSize() requestSize {
+	return requestSize{Longest: len(req.Sequence), K: req.K, Batch: 1}
+}
+
+func (req *MostFrequentRequest) inputSize() requestSize {
+	return requestSize{Longest: len(req.Sequence), K: req.K, Batch: 1}
+}
+
+func (req *KMerDistanceRequest) inputSize() requestSize {
+	return requestSize{Longest: longest(req.Sequence1, req.Sequence2), K: req.K, Batch: 2}
+}
+
+func (req *SharedKMersRequest) inputSize() requestSize {
+	return requestSize{Longest: longest(req.Sequence1, req.Sequence2), K: req.K, Batch: 2}
+}
+
+func (req *ProteinPropertiesRequest) inputSize() requestSize {
+	return requestSize{Longest: longest(req.Protein, req.DNA), Batch: 1}
+}
+
+func (req *QualityRequest) inputSize() requestSize {
+	return requestSize{Longest: max(len(req.Scores), len(req.Encoded)), Batch: 1}
+}
+
+func (req *QualityStatsRequest) inputSize() requestSize {
+	return requestSize{Longest: len(req.Scores), Batch: 1}
+}
+
+func (req *FilterReadRequest) inputSize() requestSize {
+	return requestSize{Longest: max(len(req.Sequence), len(req.Scores)), Batch: 1}
+}
+
+func (req *FoldRequest) inputSize() requestSize {
+	return requestSize{Longest: longest(req.Sequence, req.Constraint), Batch: 1}
+}
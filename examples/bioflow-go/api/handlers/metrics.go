@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"github.com/aria-lang/bioflow-go/pkg/bioflow"
+)
+
+// The domain metrics of the server, served at /metrics with the HTTP
+// metrics of the middleware. They count the work done, which request
+// counts hide: one request may carry a base or a hundred thousand reads.
+var (
+	basesProcessed = bioflow.Metrics.Counter("bioflow_bases_processed_total",
+		"Bases of sequences and reads analyzed, by kind of analysis.", "analysis")
+	readsFiltered = bioflow.Metrics.Counter("bioflow_reads_filtered_total",
+		"Reads run through quality filters and pipelines, by whether they passed or were removed.", "result")
+	alignmentsComputed = bioflow.Metrics.Counter("bioflow_alignments_computed_total",
+		"Pairwise alignments computed, by mode.", "mode")
+)
+
+// countBases adds the bases of sequences to the bases processed by an
+// analysis.
+func countBases(analysis string, seqs ...*bioflow.Sequence) {
+	n := 0
+	for _, seq := range seqs {
+		n += seq.Len()
+	}
+	basesProcessed.Add(float64(n), analysis)
+}
+
+// countReadBases adds the bases of reads to the bases processed by an
+// analysis.
+func countReadBases(analysis string, reads []*bioflow.Read) {
+	n := 0
+	for _, read := range reads {
+		n += read.Sequence.Len()
+	}
+	basesProcessed.Add(float64(n), analysis)
+}
+
+// countAlignment counts an alignment of two sequences in a mode (local,
+// global or score), and their bases.
+func countAlignment(mode string, seq1, seq2 *bioflow.Sequence) {
+	alignmentsComputed.Inc(mode)
+	countBases("alignment", seq1, seq2)
+}
+
+// countFiltered adds reads that passed a filter and reads it removed.
+func countFiltered(passed, removed int) {
+	readsFiltered.Add(float64(passed), "passed")
+	readsFiltered.Add(float64(removed), "removed")
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/aria-lang/bioflow-go/pkg/bioflow"
+)
+
+// APIPrefix is the path the current version of the API is served under.
+const APIPrefix = "/api/v1"
+
+// histogramBody is how a bioflow.Histogram is encoded.
+type histogramBody struct {
+	Edges     []float64 `json:"edges"`
+	Counts    []int     `json:"counts"`
+	Fractions []float64 `json:"fractions,omitempty"`
+	Density   []float64 `json:"density,omitempty"`
+	Underflow int       `json:"underflow"`
+	Overflow  int       `json:"overflow"`
+	Total     int       `json:"total"`
+}
+
+// Media types of downloads.
+const (
+	fastaMediaType = "text/x-fasta"
+	textMediaType  = "text/plain"
+	samMediaType   = "text/x-sam"
+	htmlMediaType  = "text/html"
+)
+
+// openAPIEndpoints describes the endpoints apiRoutes in the server
+// registers, in the same order, with paths under APIPrefix; the health
+// checks are outside it. Keep the two in step.
+func openAPIEndpoints(doc *bioflow.OpenAPIDocument) []bioflow.OpenAPIEndpoint {
+	return []bioflow.OpenAPIEndpoint{
+		{Method: "GET", Path: "/healthz", ID: "Liveness", Tag: "health", Response: HealthReport{},
+			Summary: "Liveness: replies 200 with the version and uptime while the server is up."},
+		{Method: "GET", Path: "/readyz", ID: "Readiness", Tag: "health", Response: HealthReport{},
+			Summary: "Readiness: checks the job queue (or broker) and the job store, replying 200 if all pass and 503 otherwise, with the result of each check."},
+
+		{Method: "POST", Path: "/sequence/gc-content", ID: "GCContent", Tag: "sequence", Request: SequenceRequest{}, Response: GCContentResponse{},
+			Summary: "Calculate the GC content of a sequence."},
+		{Method: "POST", Path: "/sequence/at-content", ID: "ATContent", Tag: "sequence", Request: SequenceRequest{}, Response: ATContentResponse{},
+			Summary: "Calculate the AT content of a sequence."},
+		{Method: "POST", Path: "/sequence/complement", ID: "Complement", Tag: "sequence", Request: SequenceRequest{}, Response: ComplementResponse{},
+			Summary: "Get the complement of a DNA sequence."},
+		{Method: "POST", Path: "/sequence/reverse-complement", ID: "ReverseComplement", Tag: "sequence", Request: SequenceRequest{}, Response: ReverseComplementResponse{},
+			Summary: "Get the reverse complement of a DNA sequence."},
+		{Method: "POST", Path: "/sequence/transcribe", ID: "Transcribe", Tag: "sequence", Request: SequenceRequest{}, Response: TranscribeResponse{},
+			Summary: "Transcribe DNA into RNA."},
+		{Method: "POST", Path: "/sequence/info", ID: "SequenceInfo", Tag: "sequence", Request: SequenceRequest{}, Response: SequenceInfoResponse{},
+			Summary: "Length, composition and type of a sequence."},
+		{Method: "POST", Path: "/sequence/validate", ID: "Validate", Tag: "sequence", Request: ValidateRequest{}, Response: ValidateResponse{},
+			Summary: "Validate a sequence. The type (DNA, RNA or protein) is detected from the input unless given."},
+
+		{Method: "POST", Path: "/kmer/count", ID: "KMerCount", Tag: "kmer", Request: KMerRequest{}, Response: KMerCountResponse{},
+			Summary: "Count k-mers in a sequence. With skip_masked, k-mers overlapping soft-masked (lower-case) bases are left out. strand counts the forward strand (default), the reverse strand or both, without merging reverse complements."},
+		{Method: "POST", Path: "/kmer/most-frequent", ID: "MostFrequentKMers", Tag: "kmer", Request: MostFrequentRequest{}, Response: MostFrequentResponse{},
+			Summary: "The most frequent k-mers of a sequence."},
+		{Method: "POST", Path: "/kmer/distance", ID: "KMerDistance", Tag: "kmer", Request: KMerDistanceRequest{}, Response: KMerDistanceResponse{},
+			Summary: "K-mer distance between two sequences."},
+		{Method: "POST", Path: "/kmer/shared", ID: "SharedKMers", Tag: "kmer", Request: SharedKMersRequest{}, Response: SharedKMersResponse{},
+			Summary: "K-mers shared by two sequences, as anchors and chains of anchors."},
+
+		{Method: "POST", Path: "/alignment/local", ID: "LocalAlign", Tag: "alignment", Request: AlignmentRequest{}, Response: AlignmentResponse{},
+			Summary: "Perform local alignment (Smith-Waterman)."},
+		{Method: "POST", Path: "/alignment/global", ID: "GlobalAlign", Tag: "alignment", Request: AlignmentRequest{}, Response: AlignmentResponse{},
+			Summary: "Perform global alignment (Needleman-Wunsch)."},
+		{Method: "POST", Path: "/alignment/score", ID: "AlignmentScore", Tag: "alignment", Request: AlignmentRequest{}, Response: ScoreResponse{},
+			Summary: "Score of the local alignment of two sequences, with its significance."},
+		{Method: "POST", Path: "/alignment/export", ID: "AlignmentExport", Tag: "alignment", Request: AlignmentExportRequest{},
+			Content: map[string]*bioflow.OpenAPISchema{fastaMediaType: nil, textMediaType: nil, samMediaType: nil},
+			Summary: "Download a local or global alignment as pairwise FASTA, Clustal, or SAM with sequence2 mapped to sequence1."},
+
+		{Method: "POST", Path: "/msa/export", ID: "MSAExport", Tag: "alignment", Request: MSAExportRequest{},
+			Content: map[string]*bioflow.OpenAPISchema{fastaMediaType: nil, textMediaType: nil},
+			Summary: "Download a multiple sequence alignment, given as rows or as text in another format, as FASTA, Clustal, Stockholm or PHYLIP."},
+
+		{Method: "POST", Path: "/quality/parse", ID: "ParseQuality", Tag: "quality", Request: QualityRequest{}, Response: QualityResponse{},
+			Summary: "Decode a quality string into Phred scores."},
+		{Method: "POST", Path: "/quality/stats", ID: "QualityStats", Tag: "quality", Request: QualityStatsRequest{}, Response: QualityStatsResponse{},
+			Summary: "Calculate quality score statistics."},
+		{Method: "POST", Path: "/quality/filter", ID: "FilterRead", Tag: "quality", Request: FilterReadRequest{}, Response: FilterReadResponse{},
+			Summary: "Trim and filter a read, reporting why it was rejected."},
+
+		{Method: "POST", Path: "/stats/sequence", ID: "SequenceStats", Tag: "stats", Request: SequenceRequest{}, Response: bioflow.SequenceStatistics{},
+			Summary: "Statistics of a sequence."},
+		{Method: "POST", Path: "/stats/set", ID: "SequenceSetStats", Tag: "stats", Request: SequenceSetRequest{}, Response: bioflow.SequenceSetStatistics{},
+			Summary: "Statistics of a set of sequences, with bootstrap confidence intervals of the means."},
+		{Method: "POST", Path: "/stats/compare", ID: "CompareSets", Tag: "stats", Request: CompareSetsRequest{}, Response: bioflow.SetComparison{},
+			Summary: "Compare length and GC content (and mean quality for two FASTQ sets) between two sets: KS test and effect sizes."},
+		{Method: "POST", Path: "/stats/reads", ID: "ReadSetStats", Tag: "stats", Request: ReadSetStatsRequest{}, Response: ReadSetStatsResponse{},
+			Summary: `Length and quality statistics of a read set, with the quality distribution. Set "bootstrap" to a number of resamples for 95% confidence intervals of the means.`},
+		{Method: "POST", Path: "/stats/histogram", ID: "Histogram", Tag: "stats", Request: HistogramRequest{}, Response: bioflow.Histogram{},
+			Summary: "GC content or length histogram of a sequence set, with explicit bin edges, fractions and density."},
+		{Method: "POST", Path: "/stats/qc-report", ID: "QCReport", Tag: "stats", Request: ReadSetStatsRequest{},
+			Content: map[string]*bioflow.OpenAPISchema{htmlMediaType: nil},
+			Summary: "Download a self-contained HTML QC report (per-position quality, k-mer and adapter content) for a read set."},
+
+		{Method: "POST", Path: "/protein/properties", ID: "ProteinProperties", Tag: "protein", Request: ProteinPropertiesRequest{}, Response: ProteinPropertiesResponse{},
+			Summary: "Molecular weight, pI, GRAVY, instability index and composition of a protein (or translated DNA)."},
+
+		{Method: "POST", Path: "/rna/fold", ID: "Fold", Tag: "rna", Request: FoldRequest{}, Response: FoldResponse{},
+			Summary: "Predict RNA secondary structure (Nussinov)."},
+
+		{Method: "POST", Path: "/pipeline/jobs", ID: "StartPipelineJob", Tag: "pipeline", Request: PipelineJobRequest{}, Response: PipelineJob{},
+			Status: http.StatusAccepted, Headers: map[string]string{"Location": "URL of the job."},
+			Summary: "Queue a pipeline job over reads (trim, filter, dedupe, stats and registered stages). Replies 503 with Retry-After when the queue of its priority (interactive, normal or batch) is full."},
+		{Method: "GET", Path: "/pipeline/jobs", ID: "ListPipelineJobs", Tag: "pipeline", Response: []PipelineJob{},
+			Summary: "The jobs of your workspace, newest first, without their progress and output."},
+		{Method: "GET", Path: "/pipeline/queue", ID: "PipelineQueue", Tag: "pipeline", Response: bioflow.JobQueueStats{},
+			Summary: "Job queue metrics: running and queued jobs, rejections, and mean, max and oldest wait times per priority class."},
+		{Method: "GET", Path: "/pipeline/jobs/{id}", ID: "PipelineJob", Tag: "pipeline", Response: PipelineJob{},
+			Summary: "Progress of a pipeline job: per-stage counters and timings, sampled rejected reads, and the stage reports and FASTQ output once done."},
+		{Method: "GET", Path: "/pipeline/jobs/{id}/reads", ID: "PipelineJobReads", Tag: "pipeline",
+			Content: map[string]*bioflow.OpenAPISchema{bioflow.JSONLinesMediaType: doc.JSONSchema(bioflow.JSONRecord{})},
+			Summary: "Stream the output reads of a finished job as JSON Lines, one record per line."},
+
+		{Method: "POST", Path: "/jobs", ID: "StartJob", Tag: "jobs", Request: JobRequest{}, Response: AsyncJob{},
+			Status: http.StatusAccepted, Headers: map[string]string{"Location": "URL of the job."},
+			Summary: "Queue an asynchronous job for inputs too large to answer within the request timeout: align-local or align-global (the two sequences of the FASTA), sequence-stats, kmer-count (k and top), read-stats or filter (FASTQ). Params take the options of the matching endpoint. Replies 503 with Retry-After when the queue is full."},
+		{Method: "GET", Path: "/jobs", ID: "ListJobs", Tag: "jobs", Response: []AsyncJob{},
+			Summary: "The asynchronous jobs of your workspace, newest first, without their results."},
+		{Method: "GET", Path: "/jobs/queue", ID: "JobQueue", Tag: "jobs", Response: bioflow.JobQueueStats{},
+			Summary: "Metrics of the asynchronous job queue, as for pipeline jobs."},
+		{Method: "GET", Path: "/jobs/{id}", ID: "Job", Tag: "jobs", Response: AsyncJob{},
+			Summary: "Status of an asynchronous job (queued, running, done or failed), with its result once done. Finished jobs are forgotten after a while, one hour by default."},
+		{Method: "DELETE", Path: "/jobs/{id}", ID: "DeleteJob", Tag: "jobs", Status: http.StatusNoContent,
+			Summary: "Cancel an asynchronous job that has not finished, and forget it and its result."},
+
+		{Method: "GET", Path: "/references", ID: "ListReferences", Tag: "references", Response: []ReferenceInfo{},
+			Summary: "The references served, with the name and length of each of their sequences."},
+		{Method: "GET", Path: "/references/{name}/regions", ID: "ReferenceRegions", Tag: "references", Response: []RegionSequence{},
+			Content: map[string]*bioflow.OpenAPISchema{fastaMediaType: nil},
+			Query: []bioflow.OpenAPIParameter{
+				{Name: "region", Required: true, Description: "Regions as samtools faidx takes them, 1-based and inclusive, such as chr1:1,001-2,000; repeat for several.",
+					Schema: &bioflow.OpenAPISchema{Type: "array", Items: &bioflow.OpenAPISchema{Type: "string"}}},
+				{Name: "format", Description: "json (default) or fasta.", Schema: &bioflow.OpenAPISchema{Type: "string", Enum: []string{"json", "fasta"}}},
+			},
+			Summary: "Extract regions from an indexed reference, read by seeking rather than parsing the whole file. Start and end are 0-based and half-open in the reply."},
+
+		{Method: "GET", Path: "/audit", ID: "AuditExport", Tag: "audit",
+			Content: map[string]*bioflow.OpenAPISchema{bioflow.JSONLinesMediaType: doc.JSONSchema(bioflow.AuditEntry{})},
+			Query: []bioflow.OpenAPIParameter{
+				{Name: "from", Description: "Earliest time, RFC 3339."},
+				{Name: "to", Description: "Latest time, RFC 3339."},
+				{Name: "user", Description: "Only the entries of this user."},
+			},
+			Summary: "Export the audit log as JSON Lines, oldest first, optionally by time range and user. Only for audit administrators when sign-in is on."},
+	}
+}
+
+// openAPI holds the document OpenAPISpec builds on first use.
+var openAPI struct {
+	once sync.Once
+	doc  *bioflow.OpenAPIDocument
+	err  error
+}
+
+// OpenAPISpec returns the OpenAPI document of the API: its endpoints and
+// the schemas of their request and response bodies, derived from the
+// types the handlers decode and encode.
+func OpenAPISpec() (*bioflow.OpenAPIDocument, error) {
+	openAPI.once.Do(func() {
+		doc := bioflow.NewOpenAPIDocument(bioflow.OpenAPIInfo{
+			Title:       "BioFlow API",
+			Description: "A REST API for genomic sequence analysis. Endpoints are versioned under " + APIPrefix + "; /api serves the same endpoints for older clients. When the server requires authentication, send an API key as Authorization: Bearer <key> or X-API-Key. Errors reply with {\"error\": \"...\"}.",
+			Version:     bioflow.Version(),
+		})
+		doc.Servers = []bioflow.OpenAPIServer{{URL: "/"}}
+		doc.Like(bioflow.Histogram{}, histogramBody{})
+		for _, name := range []struct {
+			v    interface{}
+			name string
+		}{
+			{bioflow.AlignmentSummary{}, "AlignmentSummary"},
+			{bioflow.AuditEntry{}, "AuditEntry"},
+			{bioflow.JobQueueStats{}, "JobQueueStats"},
+			{bioflow.JSONRecord{}, "JSONRecord"},
+			{bioflow.RNAPair{}, "RNAPair"},
+			{bioflow.WorkflowStage{}, "WorkflowStage"},
+		} {
+			if err := doc.Name(name.v, name.name); err != nil {
+				openAPI.err = err
+				return
+			}
+		}
+		for _, e := range openAPIEndpoints(doc) {
+			if e.Path != "/healthz" && e.Path != "/readyz" {
+				e.Path = APIPrefix + e.Path
+			}
+			if err := doc.Add(e); err != nil {
+				openAPI.err = err
+				return
+			}
+		}
+		openAPI.doc = doc
+	})
+	return openAPI.doc, openAPI.err
+}
+
+// OpenAPIHandler serves the OpenAPI document of the API as JSON.
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	doc, err := OpenAPISpec()
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(doc)
+}
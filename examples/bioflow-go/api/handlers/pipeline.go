@@ -0,0 +1,648 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/aria-lang/bioflow-go/api/auth"
+	"github.com/aria-lang/bioflow-go/pkg/bioflow"
+)
+
+// PipelineJobRequest starts a pipeline job: the reads, given as in
+// ReadSetStatsRequest, run through the stages of a pipeline definition.
+type PipelineJobRequest struct {
+	Reads    []ReadInput             `json:"reads,omitempty"`
+	FASTQ    string                  `json:"fastq,omitempty"`
+	Encoding string                  `json:"encoding,omitempty"`
+	Stages   []bioflow.WorkflowStage `json:"stages"`
+	// SampleRejected is the number of rejected reads reported per stage.
+	SampleRejected int `json:"sample_rejected,omitempty"`
+	// Priority is "interactive", "normal" (the default) or "batch".
+	Priority string `json:"priority,omitempty"`
+}
+
+// PipelineJob is the state of a pipeline job. Progress holds the latest
+// event of each stage that has started; Reports and FASTQ are set once
+// the job is done, and the output reads can then be streamed as JSON
+// Lines from PipelineJobReadsHandler. Worker names the worker running a
+// job sent through a broker, and Owner the user whose workspace holds the
+// job, when users are authenticated.
+type PipelineJob struct {
+	ID        string                `json:"id"`
+	Status    string                `json:"status"` // "queued", "running", "done" or "failed"
+	Priority  bioflow.JobPriority   `json:"priority"`
+	Owner     string                `json:"owner,omitempty"`
+	Error     string                `json:"error,omitempty"`
+	Submitted time.Time             `json:"submitted"`
+	Started   *time.Time            `json:"started,omitempty"`
+	Worker    string                `json:"worker,omitempty"`
+	Finished  *time.Time            `json:"finished,omitempty"`
+	Progress  []bioflow.Event       `json:"progress"`
+	Rejected  []bioflow.Event       `json:"rejected,omitempty"`
+	Reports   []bioflow.StageReport `json:"reports,omitempty"`
+	FASTQ     string                `json:"fastq,omitempty"`
+	reads     []*bioflow.Read
+
+	// Fields saved in the job store.
+	spec     pipelineSpec
+	seq      uint64
+	attempts int
+	input    string
+	result   string
+}
+
+// pipelineSpec is what a pipeline job runs, besides its reads.
+type pipelineSpec struct {
+	Stages         []bioflow.WorkflowStage `json:"stages"`
+	SampleRejected int                     `json:"sample_rejected,omitempty"`
+	// Trace carries the trace context of the request that started the
+	// job, so that its runs, here or on a worker, join that trace.
+	Trace map[string]string `json:"trace,omitempty"`
+}
+
+// JobQueueLimits bounds the pipeline jobs run at once and queued per
+// priority class, so that a flood of large jobs is turned away with 503
+// rather than starving interactive ones. Set it before the first job.
+var JobQueueLimits = bioflow.DefaultJobQueueOptions()
+
+// jobQueue runs pipeline jobs; it is created on first use from
+// JobQueueLimits.
+var jobQueue struct {
+	once sync.Once
+	q    *bioflow.JobQueue
+}
+
+// pipelineQueue returns the queue of pipeline jobs.
+func pipelineQueue() *bioflow.JobQueue {
+	jobQueue.once.Do(func() {
+		jobQueue.q = bioflow.NewJobQueue(JobQueueLimits)
+	})
+	return jobQueue.q
+}
+
+// jobStore saves pipeline jobs, with their input and output reads, and
+// asynchronous jobs, with their input, when OpenJobStore has been called;
+// otherwise jobs live in memory only.
+var jobStore *bioflow.JobStore
+
+// maxJobAttempts is the number of times a job is started before it is
+// failed rather than run again after a restart, in case it is what
+// brought the server down.
+const maxJobAttempts = 3
+
+// maxJobs is the number of jobs kept; the oldest finished jobs are
+// forgotten first.
+const maxJobs = 100
+
+// jobs holds pipeline jobs in memory, in order of creation.
+var jobs = struct {
+	sync.Mutex
+	byID  map[string]*PipelineJob
+	order []string
+	next  uint64
+}{byID: make(map[string]*PipelineJob)}
+
+// addJob registers a new queued job and forgets old finished ones.
+func addJob(spec pipelineSpec, priority bioflow.JobPriority, owner string) (*PipelineJob, error) {
+	var seq uint64
+	if jobStore != nil {
+		var err error
+		if seq, err = jobStore.NextSeq(); err != nil {
+			return nil, err
+		}
+	}
+
+	jobs.Lock()
+	if jobStore == nil {
+		jobs.next++
+		seq = jobs.next
+	}
+	job := &PipelineJob{
+		ID:        fmt.Sprintf("job-%d", seq),
+		Status:    bioflow.JobQueued,
+		Priority:  priority,
+		Owner:     owner,
+		Submitted: time.Now().UTC(),
+		Progress:  make([]bioflow.Event, 0, len(spec.Stages)),
+		spec:      spec,
+		seq:       seq,
+	}
+	jobs.byID[job.ID] = job
+	jobs.order = append(jobs.order, job.ID)
+	forgotten := make([]string, 0)
+	for i := 0; len(jobs.order) > maxJobs && i < len(jobs.order); {
+		if old := jobs.byID[jobs.order[i]]; old.Finished != nil {
+			delete(jobs.byID, old.ID)
+			jobs.order = append(jobs.order[:i], jobs.order[i+1:]...)
+			forgotten = append(forgotten, old.ID)
+			continue
+		}
+		i++
+	}
+	jobs.Unlock()
+
+	if jobStore != nil {
+		for _, id := range forgotten {
+			if err := jobStore.Delete(id); err != nil {
+				log.Printf("Error deleting %s from the job store: %v", id, err)
+			}
+		}
+	}
+	return job, nil
+}
+
+// dropJob forgets a job that was never queued.
+func dropJob(job *PipelineJob) {
+	jobs.Lock()
+	delete(jobs.byID, job.ID)
+	for i, id := range jobs.order {
+		if id == job.ID {
+			jobs.order = append(jobs.order[:i], jobs.order[i+1:]...)
+			break
+		}
+	}
+	jobs.Unlock()
+	if jobStore != nil {
+		if err := jobStore.Delete(job.ID); err != nil && !errors.Is(err, bioflow.ErrJobNotFound) {
+			log.Printf("Error deleting %s from the job store: %v", job.ID, err)
+		}
+	}
+}
+
+// record returns the job store record of a job; jobs must be locked.
+func (job *PipelineJob) record() (*bioflow.JobRecord, error) {
+	spec, err := json.Marshal(job.spec)
+	if err != nil {
+		return nil, err
+	}
+	// The output is saved as reads in the result file.
+	state := *job
+	state.FASTQ = ""
+	data, err := json.Marshal(&state)
+	if err != nil {
+		return nil, err
+	}
+	return &bioflow.JobRecord{
+		ID: job.ID, Seq: job.seq, Status: job.Status, Attempts: job.attempts,
+		Spec: spec, State: data, Input: job.input, Result: job.result,
+	}, nil
+}
+
+// save writes a job to the job store, if there is one.
+func (job *PipelineJob) save() error {
+	if jobStore == nil {
+		return nil
+	}
+	jobs.Lock()
+	rec, err := job.record()
+	jobs.Unlock()
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", job.ID, err)
+	}
+	return jobStore.Put(rec)
+}
+
+// observe records a pipeline event in a job.
+func (job *PipelineJob) observe(e bioflow.Event) {
+	jobs.Lock()
+	defer jobs.Unlock()
+	if e.Kind == bioflow.ReadRejected {
+		job.Rejected = append(job.Rejected, e)
+		return
+	}
+	if e.Index < len(job.Progress) {
+		job.Progress[e.Index] = e
+	} else {
+		job.Progress = append(job.Progress, e)
+	}
+}
+
+// submit queues a job, on a worker through the broker if UseBroker has
+// been called. Reads may be nil for a job whose input is in the job
+// store.
+func (job *PipelineJob) submit(reads []*bioflow.Read) error {
+	if jobClient != nil {
+		return job.dispatch(reads)
+	}
+	return pipelineQueue().Submit(job.Priority, func() { job.run(reads) })
+}
+
+// run processes the reads and records the outcome.
+func (job *PipelineJob) run(reads []*bioflow.Read) {
+	job.start("")
+	ctx, span := startJobSpan(job.spec, job.ID)
+	var err error
+	if reads == nil && job.input != "" {
+		reads, err = bioflow.ReadReadsFile(jobStore.Path(job.input))
+	}
+	var out []*bioflow.Read
+	var reports []bioflow.StageReport
+	if err == nil {
+		out, reports, err = runPipeline(ctx, job.spec, reads, job.observe)
+	}
+
+	bioflow.EndSpan(span, err)
+	job.finish(out, reports, err)
+}
+
+// startJobSpan starts the span of a run of a job, in the trace of the
+// request that started it.
+func startJobSpan(spec pipelineSpec, id string) (context.Context, trace.Span) {
+	ctx := bioflow.ContextWithTrace(context.Background(), spec.Trace)
+	return bioflow.StartSpan(ctx, "pipeline job", attribute.String("bioflow.job", id))
+}
+
+// runPipeline runs the stages of a job over its reads, and counts them in
+// the metrics.
+func runPipeline(ctx context.Context, spec pipelineSpec, reads []*bioflow.Read, observe func(bioflow.Event)) ([]*bioflow.Read, []bioflow.StageReport, error) {
+	out, reports, err := bioflow.ProcessWorkflowReadsContext(ctx, spec.Stages, reads, bioflow.Monitor{
+		Observer:         bioflow.ObserverFunc(observe),
+		ProgressEvery:    10000,
+		ProgressInterval: time.Second,
+		SampleRejected:   spec.SampleRejected,
+	})
+	if err == nil {
+		countReadBases("pipeline", reads)
+		countFiltered(len(out), len(reads)-len(out))
+	}
+	return out, reports, err
+}
+
+// start marks a job running on a worker ("" for this process) and saves
+// it.
+func (job *PipelineJob) start(worker string) {
+	jobs.Lock()
+	started := time.Now().UTC()
+	job.Status, job.Started, job.Worker = bioflow.JobRunning, &started, worker
+	job.attempts++
+	jobs.Unlock()
+	if err := job.save(); err != nil {
+		log.Printf("Error saving %s: %v", job.ID, err)
+	}
+}
+
+// finish records the outcome of a job and saves it.
+func (job *PipelineJob) finish(out []*bioflow.Read, reports []bioflow.StageReport, err error) {
+	var fastq strings.Builder
+	if err == nil {
+		err = bioflow.FormatFASTQ(&fastq, out)
+	}
+	var result string
+	if err == nil && jobStore != nil {
+		result = job.ID + ".out.jsonl.zst"
+		err = bioflow.WriteJSONLReads(jobStore.Path(result), out)
+	}
+
+	jobs.Lock()
+	now := time.Now().UTC()
+	job.Finished = &now
+	if err != nil {
+		job.Status, job.Error = bioflow.JobFailed, err.Error()
+	} else {
+		job.Status, job.Reports, job.FASTQ, job.reads, job.result = bioflow.JobDone, reports, fastq.String(), out, result
+	}
+	jobs.Unlock()
+	if err := job.save(); err != nil {
+		log.Printf("Error saving %s: %v", job.ID, err)
+	}
+}
+
+// reset clears the progress of a job to be run again; jobs must be
+// locked.
+func (job *PipelineJob) reset() {
+	job.Status = bioflow.JobQueued
+	job.Started, job.Rejected, job.Worker = nil, nil, ""
+	job.Progress = make([]bioflow.Event, 0, len(job.spec.Stages))
+}
+
+// output returns the output reads and FASTQ of a finished job, reading
+// them back from the job store for jobs finished before a restart.
+func (job *PipelineJob) output() ([]*bioflow.Read, string, error) {
+	jobs.Lock()
+	reads, fastq, result := job.reads, job.FASTQ, job.result
+	jobs.Unlock()
+	if reads != nil || result == "" || jobStore == nil {
+		return reads, fastq, nil
+	}
+
+	reads, err := bioflow.ReadReadsFile(jobStore.Path(result))
+	if err != nil {
+		return nil, "", err
+	}
+	var b strings.Builder
+	if err := bioflow.FormatFASTQ(&b, reads); err != nil {
+		return nil, "", err
+	}
+	jobs.Lock()
+	job.reads, job.FASTQ = reads, b.String()
+	jobs.Unlock()
+	return reads, b.String(), nil
+}
+
+// OpenJobStore keeps pipeline and asynchronous jobs in a directory from
+// now on, and restores the jobs saved there: finished jobs can be queried
+// again, and queued jobs, and those interrupted mid-run, are queued to run
+// (again). It returns the number of jobs queued. Call it before serving
+// requests.
+func OpenJobStore(dir string) (int, error) {
+	store, err := bioflow.OpenJobStore(dir)
+	if err != nil {
+		return 0, err
+	}
+	pending, err := store.Recover(maxJobAttempts)
+	if err != nil {
+		store.Close()
+		return 0, err
+	}
+	records, err := store.List()
+	if err != nil {
+		store.Close()
+		return 0, err
+	}
+	jobStore = store
+	RegisterReadinessCheck("job_store", checkJobStore)
+
+	queued := make(map[string]bool, len(pending))
+	for _, rec := range pending {
+		queued[rec.ID] = true
+	}
+	restored := make([]*PipelineJob, 0, len(records))
+	async := make([]*bioflow.JobRecord, 0)
+	for _, rec := range records {
+		if strings.HasPrefix(rec.ID, asyncJobPrefix) {
+			async = append(async, rec)
+			continue
+		}
+		job, err := restoreJob(rec, queued[rec.ID])
+		if err != nil {
+			log.Printf("Error restoring %s: %v", rec.ID, err)
+			continue
+		}
+		restored = append(restored, job)
+	}
+
+	jobs.Lock()
+	for _, job := range restored {
+		jobs.byID[job.ID] = job
+		jobs.order = append(jobs.order, job.ID)
+	}
+	jobs.Unlock()
+
+	n := 0
+	for _, job := range restored {
+		switch {
+		case job.Status == bioflow.JobQueued:
+			if err := job.submit(nil); err != nil {
+				job.fail(fmt.Errorf("requeueing after restart: %w", err))
+				continue
+			}
+			n++
+		case job.Status == bioflow.JobFailed && job.Finished == nil:
+			job.fail(fmt.Errorf("interrupted %d times", job.attempts))
+		}
+	}
+	return n + restoreAsyncJobs(async, queued), nil
+}
+
+// restoreJob rebuilds a job from its record. Jobs to run again restart
+// from the beginning.
+func restoreJob(rec *bioflow.JobRecord, requeue bool) (*PipelineJob, error) {
+	var job PipelineJob
+	if err := json.Unmarshal(rec.State, &job); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(rec.Spec, &job.spec); err != nil {
+		return nil, err
+	}
+	job.ID, job.Status, job.seq, job.attempts = rec.ID, rec.Status, rec.Seq, rec.Attempts
+	job.input, job.result = rec.Input, rec.Result
+	if requeue {
+		job.reset()
+	}
+	return &job, nil
+}
+
+// fail marks a job failed and saves it.
+func (job *PipelineJob) fail(err error) {
+	jobs.Lock()
+	now := time.Now().UTC()
+	job.Status, job.Error, job.Finished = bioflow.JobFailed, err.Error(), &now
+	jobs.Unlock()
+	if err := job.save(); err != nil {
+		log.Printf("Error saving %s: %v", job.ID, err)
+	}
+}
+
+// CloseJobStore closes the job store opened by OpenJobStore. Jobs still
+// running are run again when the store is next opened.
+func CloseJobStore() error {
+	if jobStore == nil {
+		return nil
+	}
+	return jobStore.Close()
+}
+
+// StartPipelineJobHandler validates a pipeline job and queues it to run
+// in the background, replying 202 with the job; poll PipelineJobHandler
+// for its progress and result. If the queue of the job's priority class
+// is full it replies 503 with a Retry-After header.
+func StartPipelineJobHandler(w http.ResponseWriter, r *http.Request) {
+	var req PipelineJobRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	reads, err := parseReadInputs(r.Context(), ReadSetStatsRequest{Reads: req.Reads, FASTQ: req.FASTQ, Encoding: req.Encoding})
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	if err := bioflow.CheckWorkflowStages(req.Stages); err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	priority, err := bioflow.ParseJobPriority(req.Priority)
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	spec := pipelineSpec{Stages: req.Stages, SampleRejected: req.SampleRejected, Trace: bioflow.TraceCarrier(r.Context())}
+	job, err := addJob(spec, priority, auth.Owner(r.Context()))
+	if err == nil && jobStore != nil {
+		job.input = job.ID + ".in.jsonl.zst"
+		err = bioflow.WriteJSONLReads(jobStore.Path(job.input), reads)
+		if err == nil {
+			err = job.save()
+		}
+	}
+	if err != nil {
+		if job != nil {
+			dropJob(job)
+		}
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+		return
+	}
+	if err := job.submit(reads); err != nil {
+		dropJob(job)
+		if errors.Is(err, bioflow.ErrJobQueueFull) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter(r.Context(), priority).Seconds())))
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/pipeline/jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	jobs.Lock()
+	defer jobs.Unlock()
+	json.NewEncoder(w).Encode(job)
+}
+
+// lookupJob returns a job by the ID in the request path, if it is in the
+// workspace of the request's user.
+func lookupJob(r *http.Request) (*PipelineJob, bool) {
+	jobs.Lock()
+	defer jobs.Unlock()
+	job, ok := jobs.byID[chi.URLParam(r, "id")]
+	if !ok || !visible(job, auth.Owner(r.Context())) {
+		return nil, false
+	}
+	return job, true
+}
+
+// visible reports whether a job is in the workspace of owner; anonymous
+// requests see all jobs.
+func visible(job *PipelineJob, owner string) bool {
+	return owner == "" || job.Owner == owner
+}
+
+// ListPipelineJobsHandler lists the pipeline jobs in the workspace of the
+// request's user, newest first, without their progress and output.
+func ListPipelineJobsHandler(w http.ResponseWriter, r *http.Request) {
+	owner := auth.Owner(r.Context())
+	jobs.Lock()
+	list := make([]PipelineJob, 0, len(jobs.order))
+	for i := len(jobs.order) - 1; i >= 0; i-- {
+		job := jobs.byID[jobs.order[i]]
+		if !visible(job, owner) {
+			continue
+		}
+		list = append(list, PipelineJob{
+			ID: job.ID, Status: job.Status, Priority: job.Priority, Owner: job.Owner, Error: job.Error,
+			Submitted: job.Submitted, Started: job.Started, Worker: job.Worker, Finished: job.Finished,
+		})
+	}
+	jobs.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// PipelineJobHandler reports the progress, or the result, of a pipeline
+// job.
+func PipelineJobHandler(w http.ResponseWriter, r *http.Request) {
+	job, ok := lookupJob(r)
+	if !ok {
+		http.Error(w, `{"error": "job not found"}`, http.StatusNotFound)
+		return
+	}
+	jobs.Lock()
+	done := job.Status == bioflow.JobDone
+	jobs.Unlock()
+	if done {
+		if _, _, err := job.output(); err != nil {
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	jobs.Lock()
+	defer jobs.Unlock()
+	json.NewEncoder(w).Encode(job)
+}
+
+// retryAfter estimates when a client whose job was rejected should try
+// again.
+func retryAfter(ctx context.Context, priority bioflow.JobPriority) time.Duration {
+	if jobClient != nil {
+		return jobClient.RetryAfter(ctx, priority)
+	}
+	return pipelineQueue().RetryAfter(priority)
+}
+
+// PipelineQueueHandler reports the job queue: jobs running and queued
+// per priority class, rejections, and the times jobs waited to start.
+// With a broker, workers counts the jobs the workers that are up can run
+// at once, and queued the jobs waiting in the broker.
+func PipelineQueueHandler(w http.ResponseWriter, r *http.Request) {
+	stats := pipelineQueue().Stats()
+	if jobClient != nil {
+		var err error
+		if stats, err = jobClient.Stats(r.Context()); err != nil {
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusServiceUnavailable)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// streamFlushEvery is the number of records written between flushes of a
+// streamed response.
+const streamFlushEvery = 1000
+
+// PipelineJobReadsHandler streams the output reads of a finished pipeline
+// job as JSON Lines, one record per read, flushing as it goes so that
+// clients can process reads before the response is complete.
+func PipelineJobReadsHandler(w http.ResponseWriter, r *http.Request) {
+	job, ok := lookupJob(r)
+	if !ok {
+		http.Error(w, `{"error": "job not found"}`, http.StatusNotFound)
+		return
+	}
+	jobs.Lock()
+	status := job.Status
+	jobs.Unlock()
+	if status != bioflow.JobDone {
+		http.Error(w, `{"error": "job is `+status+`"}`, http.StatusConflict)
+		return
+	}
+	reads, _, err := job.output()
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", bioflow.JSONLinesMediaType)
+	flusher, _ := w.(http.Flusher)
+	jw := bioflow.NewJSONLWriter(w)
+	for i, read := range reads {
+		if err := jw.WriteRead(read); err != nil {
+			return
+		}
+		if (i+1)%streamFlushEvery == 0 && flusher != nil {
+			if jw.Flush() != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+	jw.Flush()
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aria-lang/bioflow-go/pkg/bioflow"
+)
+
+// ProteinPropertiesRequest represents a protein properties request. Either
+// a protein sequence or a DNA sequence to translate must be given.
+type ProteinPropertiesRequest struct {
+	Protein string `json:"protein"`
+	DNA     string `json:"dna"`
+	Frame   int    `json:"frame"`
+}
+
+// ProteinPropertiesResponse represents the response for protein properties.
+type ProteinPropertiesResponse struct {
+	Protein string `json:"protein"`
+	*bioflow.ProteinProperties
+}
+
+// ProteinPropertiesHandler handles protein property requests.
+func ProteinPropertiesHandler(w http.ResponseWriter, r *http.Request) {
+	var req ProteinPropertiesRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	var p *bioflow.Protein
+	var err error
+	switch {
+	case req.Protein != "":
+		p, err = bioflow.NewProtein(req.Protein)
+	case req.DNA != "":
+		var seq *bioflow.Sequence
+		seq, err = bioflow.NewSequence(req.DNA)
+		if err == nil {
+			p, err = bioflow.Translate(seq, req.Frame, true)
+		}
+	default:
+		http.Error(w, `{"error": "either protein or dna is required"}`, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ProteinPropertiesResponse{
+		Protein:           p.Residues,
+		ProteinProperties: bioflow.ProteinStats(p),
+	})
+}
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/aria-lang/bioflow-go/pkg/bioflow"
 )
@@ -23,8 +25,7 @@ type QualityResponse struct {
 // ParseQualityHandler handles quality parsing requests.
 func ParseQualityHandler(w http.ResponseWriter, r *http.Request) {
 	var req QualityRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -83,8 +84,7 @@ type QualityStatsResponse struct {
 // QualityStatsHandler handles quality statistics requests.
 func QualityStatsHandler(w http.ResponseWriter, r *http.Request) {
 	var req QualityStatsRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -120,6 +120,11 @@ type FilterReadRequest struct {
 	MinQuality int    `json:"min_quality,omitempty"`
 	MinLength  int    `json:"min_length,omitempty"`
 	Strict     bool   `json:"strict,omitempty"`
+	// Adapters are trimmed from the 3' end before quality trimming:
+	// "truseq", "nextera", "default" or adapter sequences.
+	Adapters          []string `json:"adapters,omitempty"`
+	AdapterErrorRate  *float64 `json:"adapter_error_rate,omitempty"`
+	AdapterMinOverlap int      `json:"adapter_min_overlap,omitempty"`
 }
 
 // FilterReadResponse represents the response for read filtering.
@@ -133,13 +138,13 @@ type FilterReadResponse struct {
 	OriginalLength   int     `json:"original_length"`
 	TrimmedLength    int     `json:"trimmed_length"`
 	MeanQuality      float64 `json:"mean_quality"`
+	Adapter          string  `json:"adapter,omitempty"`
 }
 
 // FilterReadHandler handles read filtering requests.
 func FilterReadHandler(w http.ResponseWriter, r *http.Request) {
 	var req FilterReadRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -160,17 +165,10 @@ func FilterReadHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var filter *bioflow.Filter
-	if req.Strict {
-		filter = bioflow.StrictFilter()
-	} else {
-		filter = bioflow.DefaultFilter()
-		if req.MinQuality > 0 {
-			filter.MinQuality = req.MinQuality
-		}
-		if req.MinLength > 0 {
-			filter.MinLength = req.MinLength
-		}
+	filter, err := requestFilter(req)
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
 	}
 
 	result, err := filter.TrimAndFilter(seq, quality)
@@ -179,6 +177,13 @@ func FilterReadHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	countBases("quality", seq)
+	if result.Passed {
+		countFiltered(1, 0)
+	} else {
+		countFiltered(0, 1)
+	}
+
 	response := FilterReadResponse{
 		Passed:         result.Passed,
 		Reason:         result.Reason,
@@ -186,6 +191,7 @@ func FilterReadHandler(w http.ResponseWriter, r *http.Request) {
 		TrimEnd:        result.TrimEnd,
 		OriginalLength: seq.Len(),
 		MeanQuality:    result.MeanQuality,
+		Adapter:        result.Adapter,
 	}
 
 	if result.TrimmedSeq != nil {
@@ -197,3 +203,32 @@ func FilterReadHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// requestFilter returns the filter of a filter read request.
+func requestFilter(req FilterReadRequest) (*bioflow.Filter, error) {
+	var filter *bioflow.Filter
+	if req.Strict {
+		filter = bioflow.StrictFilter()
+	} else {
+		filter = bioflow.DefaultFilter()
+		if req.MinQuality > 0 {
+			filter.MinQuality = req.MinQuality
+		}
+		if req.MinLength > 0 {
+			filter.MinLength = req.MinLength
+		}
+	}
+
+	adapters, err := bioflow.ParseAdapters(strings.Join(req.Adapters, ","))
+	if err != nil {
+		return nil, fmt.Errorf("adapters: %w", err)
+	}
+	filter.Adapters = adapters
+	filter.AdapterErrorRate = bioflow.DefaultAdapterErrorRate
+	if req.AdapterErrorRate != nil {
+		filter.AdapterErrorRate = *req.AdapterErrorRate
+	}
+	filter.AdapterMinOverlap = req.AdapterMinOverlap
+
+	return filter, nil
+}
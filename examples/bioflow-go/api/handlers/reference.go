@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/pkg/bioflow"
+	"github.com/go-chi/chi/v5"
+)
+
+// references are the indexed FASTA files loaded by LoadReferences, by
+// name.
+var references = map[string]*bioflow.FastaIndex{}
+
+// referenceExts are the extensions of the FASTA files LoadReferences
+// opens.
+var referenceExts = map[string]bool{".fa": true, ".fasta": true, ".fna": true}
+
+// LoadReferences opens the FASTA files in a directory (.fa, .fasta and
+// .fna, uncompressed) for region extraction, named by their file name
+// without the extension, and returns how many were opened. Files without
+// a .fai index are indexed, and the index written next to them.
+func LoadReferences(dir string) (int, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("reading references: %w", err)
+	}
+	n := 0
+	for _, f := range files {
+		ext := filepath.Ext(f.Name())
+		if f.IsDir() || !referenceExts[ext] {
+			continue
+		}
+		idx, err := bioflow.OpenFastaIndex(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return n, err
+		}
+		references[strings.TrimSuffix(f.Name(), ext)] = idx
+		n++
+	}
+	return n, nil
+}
+
+// ReferenceInfo describes a reference and its sequences.
+type ReferenceInfo struct {
+	Name      string              `json:"name"`
+	Sequences []ReferenceSequence `json:"sequences"`
+}
+
+// ReferenceSequence is a sequence of a reference.
+type ReferenceSequence struct {
+	Name   string `json:"name"`
+	Length int64  `json:"length"`
+}
+
+// ListReferencesHandler replies with the references regions can be
+// extracted from, by name.
+func ListReferencesHandler(w http.ResponseWriter, r *http.Request) {
+	infos := make([]ReferenceInfo, 0, len(references))
+	for name, idx := range references {
+		info := ReferenceInfo{Name: name, Sequences: make([]ReferenceSequence, 0)}
+		for _, e := range idx.Entries() {
+			info.Sequences = append(info.Sequences, ReferenceSequence{Name: e.Name, Length: e.Length})
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(infos)
+}
+
+// RegionSequence is a region extracted from a reference; Start and End
+// are 0-based and half-open.
+type RegionSequence struct {
+	Region   string `json:"region"`
+	Name     string `json:"name"`
+	Start    int64  `json:"start"`
+	End      int64  `json:"end"`
+	Sequence string `json:"sequence"`
+}
+
+// ReferenceRegionsHandler extracts the regions given by the region query
+// parameters (samtools regions such as chr1:1,000-2,000) from a
+// reference, replying with JSON, or FASTA with format=fasta. Regions are
+// bounded by the sequence length and batch limits.
+func ReferenceRegionsHandler(w http.ResponseWriter, r *http.Request) {
+	idx, ok := references[chi.URLParam(r, "name")]
+	if !ok {
+		http.Error(w, `{"error": "unknown reference"}`, http.StatusNotFound)
+		return
+	}
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "fasta" {
+		http.Error(w, `{"error": "format must be json or fasta"}`, http.StatusBadRequest)
+		return
+	}
+	names := q["region"]
+	if len(names) == 0 {
+		http.Error(w, `{"error": "region is required"}`, http.StatusBadRequest)
+		return
+	}
+	limits := limitsFor(r.URL.Path)
+	if limits.MaxBatch > 0 && len(names) > limits.MaxBatch {
+		http.Error(w, fmt.Sprintf(`{"error": "%d regions exceed the limit of %d per request"}`, len(names), limits.MaxBatch), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	regions := make([]bioflow.Region, len(names))
+	for i, name := range names {
+		region, err := bioflow.ParseRegion(name)
+		if err != nil {
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
+		e, ok := idx.Entry(region.Name)
+		if !ok {
+			http.Error(w, `{"error": "`+region.Name+` is not in the reference"}`, http.StatusNotFound)
+			return
+		}
+		if region.End < 0 || region.End > e.Length {
+			region.End = e.Length
+		}
+		region.Start = min(region.Start, region.End)
+		if size := region.End - region.Start; limits.MaxSequenceLength > 0 && size > int64(limits.MaxSequenceLength) {
+			http.Error(w, fmt.Sprintf(`{"error": "region of %d exceeds the limit of %d"}`, size, limits.MaxSequenceLength), http.StatusRequestEntityTooLarge)
+			return
+		}
+		regions[i] = region
+	}
+
+	results := make([]RegionSequence, len(regions))
+	for i, region := range regions {
+		bases, err := idx.Fetch(region.Name, region.Start, region.End)
+		if err != nil {
+			http.Error(w, `{"error": "reading reference"}`, http.StatusInternalServerError)
+			return
+		}
+		results[i] = RegionSequence{Region: names[i], Name: region.Name, Start: region.Start, End: region.End, Sequence: bases}
+	}
+
+	if format == "fasta" {
+		w.Header().Set("Content-Type", "text/x-fasta")
+		for _, res := range results {
+			fmt.Fprintf(w, ">%s\n%s\n", res.Region, res.Sequence)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
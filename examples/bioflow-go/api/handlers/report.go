@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/aria-lang/bioflow-go/pkg/bioflow"
+)
+
+// QCReportHandler builds a read QC report and returns it as a
+// self-contained HTML file download. It accepts the same body as
+// ReadSetStatsHandler.
+func QCReportHandler(w http.ResponseWriter, r *http.Request) {
+	var req ReadSetStatsRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	reads, err := parseReadInputs(r.Context(), req)
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	qc, err := bioflow.ReadQC(reads, bioflow.DefaultQCOptions())
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	// Render fully before writing so errors can still be reported.
+	var buf bytes.Buffer
+	if err := bioflow.QCHTMLReport("QC report", qc).WriteHTML(&buf); err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+		return
+	}
+
+	writeDownload(w, "qc-report.html", "text/html; charset=utf-8", buf.Bytes())
+}
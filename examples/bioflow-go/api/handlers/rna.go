@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/pkg/bioflow"
+)
+
+// FoldRequest represents an RNA folding request.
+type FoldRequest struct {
+	Sequence   string `json:"sequence"`
+	Constraint string `json:"constraint"`
+	MinLoop    int    `json:"min_loop"`
+	Model      string `json:"model"`
+	NoWobble   bool   `json:"no_wobble"`
+}
+
+// FoldResponse represents the response for RNA folding.
+type FoldResponse struct {
+	*bioflow.RNAStructure
+	PairCount      int     `json:"pair_count"`
+	PairedFraction float64 `json:"paired_fraction"`
+}
+
+// FoldHandler handles RNA secondary structure prediction requests.
+func FoldHandler(w http.ResponseWriter, r *http.Request) {
+	var req FoldRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if req.MinLoop < 0 {
+		http.Error(w, `{"error": "min_loop must be non-negative"}`, http.StatusBadRequest)
+		return
+	}
+
+	model, err := bioflow.ParseFoldModel(req.Model)
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	newSeq := bioflow.NewSequence
+	if strings.ContainsAny(req.Sequence, "Uu") {
+		newSeq = bioflow.NewRNASequence
+	}
+	seq, err := newSeq(req.Sequence)
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	structure, err := bioflow.FoldRNAContext(r.Context(), seq, bioflow.FoldOptions{
+		MinLoop:    req.MinLoop,
+		NoWobble:   req.NoWobble,
+		Model:      model,
+		Constraint: req.Constraint,
+	})
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, context.DeadlineExceeded) {
+			status = http.StatusServiceUnavailable
+		}
+		http.Error(w, `{"error": "`+err.Error()+`"}`, status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(FoldResponse{
+		RNAStructure:   structure,
+		PairCount:      len(structure.Pairs),
+		PairedFraction: structure.PairedFraction(),
+	})
+}
@@ -2,8 +2,11 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/aria-lang/bioflow-go/pkg/bioflow"
 )
@@ -22,8 +25,7 @@ type GCContentResponse struct {
 // GCContentHandler handles GC content calculation requests.
 func GCContentHandler(w http.ResponseWriter, r *http.Request) {
 	var req SequenceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -34,6 +36,8 @@ func GCContentHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	gc := seq.GCContent()
+	countBases("sequence", seq)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(GCContentResponse{
 		GCContent: gc,
@@ -50,8 +54,7 @@ type ATContentResponse struct {
 // ATContentHandler handles AT content calculation requests.
 func ATContentHandler(w http.ResponseWriter, r *http.Request) {
 	var req SequenceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -67,6 +70,8 @@ func ATContentHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	countBases("sequence", seq)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(ATContentResponse{
 		ATContent: at,
@@ -82,8 +87,7 @@ type ComplementResponse struct {
 // ComplementHandler handles complement requests.
 func ComplementHandler(w http.ResponseWriter, r *http.Request) {
 	var req SequenceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -99,6 +103,8 @@ func ComplementHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	countBases("sequence", seq)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(ComplementResponse{
 		Complement: comp.Bases,
@@ -113,8 +119,7 @@ type ReverseComplementResponse struct {
 // ReverseComplementHandler handles reverse complement requests.
 func ReverseComplementHandler(w http.ResponseWriter, r *http.Request) {
 	var req SequenceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -130,6 +135,8 @@ func ReverseComplementHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	countBases("sequence", seq)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(ReverseComplementResponse{
 		ReverseComplement: rc.Bases,
@@ -144,8 +151,7 @@ type TranscribeResponse struct {
 // TranscribeHandler handles transcription requests.
 func TranscribeHandler(w http.ResponseWriter, r *http.Request) {
 	var req SequenceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -161,6 +167,8 @@ func TranscribeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	countBases("sequence", seq)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(TranscribeResponse{
 		RNA: rna.Bases,
@@ -183,8 +191,7 @@ type SequenceInfoResponse struct {
 // SequenceInfoHandler handles sequence info requests.
 func SequenceInfoHandler(w http.ResponseWriter, r *http.Request) {
 	var req SequenceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -196,6 +203,8 @@ func SequenceInfoHandler(w http.ResponseWriter, r *http.Request) {
 
 	stats := bioflow.SequenceStats(seq)
 
+	countBases("sequence", seq)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(SequenceInfoResponse{
 		Length:       stats.Length,
@@ -210,31 +219,67 @@ func SequenceInfoHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ValidateRequest represents a validation request. Type is "dna", "rna",
+// "protein", or "auto" (the default) to detect it from the input.
+type ValidateRequest struct {
+	Sequence string `json:"sequence"`
+	Type     string `json:"type,omitempty"`
+}
+
 // ValidateResponse represents validation result.
 type ValidateResponse struct {
 	Valid   bool   `json:"valid"`
+	Type    string `json:"type"`
 	Message string `json:"message,omitempty"`
 }
 
 // ValidateHandler handles sequence validation requests.
 func ValidateHandler(w http.ResponseWriter, r *http.Request) {
-	var req SequenceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	var req ValidateRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	var seqType bioflow.SequenceType
+	switch strings.ToLower(req.Type) {
+	case "", "auto":
+		seqType = bioflow.DetectType(req.Sequence)
+	case "dna":
+		seqType = bioflow.DNA
+	case "rna":
+		seqType = bioflow.RNA
+	case "protein":
+		seqType = bioflow.ProteinType
+	default:
+		http.Error(w, `{"error": "type must be auto, dna, rna or protein"}`, http.StatusBadRequest)
 		return
 	}
 
-	_, err := bioflow.NewSequence(req.Sequence)
+	var err error
+	switch seqType {
+	case bioflow.DNA:
+		_, err = bioflow.NewSequence(req.Sequence)
+	case bioflow.RNA:
+		_, err = bioflow.NewRNASequence(req.Sequence)
+	case bioflow.ProteinType:
+		_, err = bioflow.NewProtein(req.Sequence)
+	case bioflow.AmbiguousType:
+		err = fmt.Errorf("cannot tell whether the sequence is DNA, RNA or protein; set type")
+	default:
+		err = fmt.Errorf("sequence is empty or not in a nucleotide or protein alphabet")
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err != nil {
 		json.NewEncoder(w).Encode(ValidateResponse{
 			Valid:   false,
+			Type:    seqType.String(),
 			Message: err.Error(),
 		})
 	} else {
 		json.NewEncoder(w).Encode(ValidateResponse{
 			Valid: true,
+			Type:  seqType.String(),
 		})
 	}
 }
@@ -242,8 +287,7 @@ func ValidateHandler(w http.ResponseWriter, r *http.Request) {
 // SequenceStatsHandler handles sequence statistics requests.
 func SequenceStatsHandler(w http.ResponseWriter, r *http.Request) {
 	var req SequenceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -255,6 +299,8 @@ func SequenceStatsHandler(w http.ResponseWriter, r *http.Request) {
 
 	stats := bioflow.SequenceStats(seq)
 
+	countBases("stats", seq)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stats)
 }
@@ -262,13 +308,16 @@ func SequenceStatsHandler(w http.ResponseWriter, r *http.Request) {
 // SequenceSetRequest represents a request with multiple sequences.
 type SequenceSetRequest struct {
 	Sequences []string `json:"sequences"`
+	// Bootstrap is the number of resamples for confidence intervals of
+	// the means; zero disables them.
+	Bootstrap int    `json:"bootstrap,omitempty"`
+	Seed      *int64 `json:"seed,omitempty"`
 }
 
 // SequenceSetStatsHandler handles sequence set statistics requests.
 func SequenceSetStatsHandler(w http.ResponseWriter, r *http.Request) {
 	var req SequenceSetRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -287,7 +336,276 @@ func SequenceSetStatsHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
 		return
 	}
+	if req.Bootstrap > 0 {
+		stats.Intervals, err = bioflow.BootstrapSequences(sequences, bootstrapOptions(req.Bootstrap, req.Seed))
+		if err != nil {
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
+	}
+
+	countBases("stats", sequences...)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stats)
 }
+
+// ReadInput is one read of a read set request. The quality is given either
+// as an encoded string or as raw scores.
+type ReadInput struct {
+	Sequence string `json:"sequence"`
+	Quality  string `json:"quality,omitempty"`
+	Scores   []int  `json:"scores,omitempty"`
+}
+
+// ReadSetStatsRequest represents a read set statistics request: either a
+// list of reads or FASTQ text.
+type ReadSetStatsRequest struct {
+	Reads    []ReadInput `json:"reads,omitempty"`
+	FASTQ    string      `json:"fastq,omitempty"`
+	Encoding string      `json:"encoding,omitempty"` // "phred33" or "phred64"
+	// Bootstrap is the number of resamples for confidence intervals of
+	// the means; zero disables them.
+	Bootstrap int    `json:"bootstrap,omitempty"`
+	Seed      *int64 `json:"seed,omitempty"`
+}
+
+// ReadSetStatsResponse is a read set summary with its high-quality ratio.
+type ReadSetStatsResponse struct {
+	*bioflow.ReadSetStats
+	HighQualityRatio float64 `json:"high_quality_ratio"`
+}
+
+// ReadSetStatsHandler handles read set statistics requests.
+func ReadSetStatsHandler(w http.ResponseWriter, r *http.Request) {
+	var req ReadSetStatsRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	reads, err := parseReadInputs(r.Context(), req)
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	stats, err := bioflow.ReadStats(reads)
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	if req.Bootstrap > 0 {
+		stats.Intervals, err = bioflow.BootstrapReads(reads, bootstrapOptions(req.Bootstrap, req.Seed))
+		if err != nil {
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
+	}
+
+	countReadBases("stats", reads)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ReadSetStatsResponse{
+		ReadSetStats:     stats,
+		HighQualityRatio: stats.HighQualityRatio(),
+	})
+}
+
+// bootstrapOptions returns default bootstrap options with the requested
+// number of resamples and seed. Zero is a valid seed, so only a missing
+// seed means the default.
+func bootstrapOptions(resamples int, seed *int64) bioflow.BootstrapOptions {
+	opts := bioflow.DefaultBootstrapOptions()
+	opts.Resamples = resamples
+	if seed != nil {
+		opts.Seed = *seed
+	}
+	return opts
+}
+
+// parseReadInputs builds reads from FASTQ text, parsed in a span of ctx,
+// or a list of reads.
+func parseReadInputs(ctx context.Context, req ReadSetStatsRequest) ([]*bioflow.Read, error) {
+	if req.FASTQ != "" {
+		return bioflow.ParseFASTQContext(ctx, strings.NewReader(req.FASTQ))
+	}
+	if len(req.Reads) == 0 {
+		return nil, fmt.Errorf("either 'reads' or 'fastq' is required")
+	}
+
+	reads := make([]*bioflow.Read, 0, len(req.Reads))
+	for i, in := range req.Reads {
+		scores := in.Scores
+		if in.Quality != "" {
+			var quality *bioflow.QualityScores
+			var err error
+			switch req.Encoding {
+			case "", "phred33":
+				quality, err = bioflow.ParseQualityPhred33(in.Quality)
+			case "phred64":
+				quality, err = bioflow.ParseQualityPhred64(in.Quality)
+			default:
+				return nil, fmt.Errorf("unknown encoding, use 'phred33' or 'phred64'")
+			}
+			if err != nil {
+				return nil, fmt.Errorf("read %d: %w", i+1, err)
+			}
+			scores = quality.Values
+		}
+		read, err := bioflow.NewRead(in.Sequence, scores)
+		if err != nil {
+			return nil, fmt.Errorf("read %d: %w", i+1, err)
+		}
+		reads = append(reads, read)
+	}
+	return reads, nil
+}
+
+// HistogramRequest represents a histogram request over a sequence set.
+// Edges take precedence over Bins; without edges, GC content is binned
+// over [0, 1] and lengths over the observed range.
+type HistogramRequest struct {
+	Sequences []string  `json:"sequences"`
+	Metric    string    `json:"metric"` // "gc" or "length"
+	Bins      int       `json:"bins,omitempty"`
+	Edges     []float64 `json:"edges,omitempty"`
+}
+
+// HistogramHandler handles sequence set histogram requests.
+func HistogramHandler(w http.ResponseWriter, r *http.Request) {
+	var req HistogramRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if len(req.Sequences) == 0 {
+		http.Error(w, `{"error": "sequences array is required"}`, http.StatusBadRequest)
+		return
+	}
+	sequences := make([]*bioflow.Sequence, 0, len(req.Sequences))
+	for _, s := range req.Sequences {
+		seq, err := bioflow.NewSequence(s)
+		if err != nil {
+			http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
+		sequences = append(sequences, seq)
+	}
+
+	bins := req.Bins
+	if bins == 0 {
+		bins = 20
+	}
+	edges := req.Edges
+	var err error
+	var hist *bioflow.Histogram
+	switch req.Metric {
+	case "gc", "":
+		if edges == nil {
+			edges, err = bioflow.UniformEdges(0, 1, bins)
+		}
+		if err == nil {
+			hist, err = bioflow.GCContentHistogram(sequences, edges)
+		}
+	case "length":
+		if edges == nil {
+			lo, hi := sequences[0].Len(), sequences[0].Len()
+			for _, s := range sequences {
+				if s.Len() < lo {
+					lo = s.Len()
+				}
+				if s.Len() > hi {
+					hi = s.Len()
+				}
+			}
+			edges, err = bioflow.UniformEdges(float64(lo), float64(hi+1), bins)
+		}
+		if err == nil {
+			hist, err = bioflow.SequenceLengthHistogram(sequences, edges)
+		}
+	default:
+		http.Error(w, `{"error": "unknown metric, use 'gc' or 'length'"}`, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	countBases("stats", sequences...)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hist)
+}
+
+// CompareSetsRequest represents a two-set comparison request. Each set is
+// either a list of sequences or FASTQ text; mean quality is compared when
+// both sets are FASTQ.
+type CompareSetsRequest struct {
+	A      []string `json:"a,omitempty"`
+	B      []string `json:"b,omitempty"`
+	FASTQA string   `json:"fastq_a,omitempty"`
+	FASTQB string   `json:"fastq_b,omitempty"`
+}
+
+// CompareSetsHandler compares the length, GC content and quality
+// distributions of two sets.
+func CompareSetsHandler(w http.ResponseWriter, r *http.Request) {
+	var req CompareSetsRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	var comparison *bioflow.SetComparison
+	var err error
+	if req.FASTQA != "" && req.FASTQB != "" {
+		var readsA, readsB []*bioflow.Read
+		readsA, err = bioflow.ParseFASTQ(strings.NewReader(req.FASTQA))
+		if err == nil {
+			readsB, err = bioflow.ParseFASTQ(strings.NewReader(req.FASTQB))
+		}
+		if err == nil {
+			comparison, err = bioflow.CompareReadSets(readsA, readsB)
+		}
+	} else {
+		var setA, setB []*bioflow.Sequence
+		setA, err = parseSequenceSet(req.A, req.FASTQA)
+		if err == nil {
+			setB, err = parseSequenceSet(req.B, req.FASTQB)
+		}
+		if err == nil {
+			comparison, err = bioflow.CompareSequenceSets(setA, setB)
+		}
+	}
+	if err != nil {
+		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comparison)
+}
+
+// parseSequenceSet builds sequences from a list or from FASTQ text.
+func parseSequenceSet(raw []string, fastq string) ([]*bioflow.Sequence, error) {
+	if fastq != "" {
+		reads, err := bioflow.ParseFASTQ(strings.NewReader(fastq))
+		if err != nil {
+			return nil, err
+		}
+		sequences := make([]*bioflow.Sequence, len(reads))
+		for i, r := range reads {
+			sequences[i] = r.Sequence
+		}
+		return sequences, nil
+	}
+	sequences := make([]*bioflow.Sequence, 0, len(raw))
+	for _, s := range raw {
+		seq, err := bioflow.NewSequence(s)
+		if err != nil {
+			return nil, err
+		}
+		sequences = append(sequences, seq)
+	}
+	return sequences, nil
+}
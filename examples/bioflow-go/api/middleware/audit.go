@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aria-lang/bioflow-go/api/auth"
+	"github.com/aria-lang/bioflow-go/pkg/bioflow"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// AuditDataFields are the request fields holding sample data. The audit
+// log records their digests, never their content.
+var AuditDataFields = map[string]bool{
+	"sequence": true, "sequence1": true, "sequence2": true, "sequences": true,
+	"protein": true, "dna": true, "a": true, "b": true,
+	"reads": true, "fasta": true, "fastq": true, "fastq_a": true, "fastq_b": true, "quality": true,
+	"rows": true, "alignment": true, "scores": true, "encoded": true, "constraint": true,
+}
+
+const (
+	// auditCapture is the largest body whose fields are recorded; larger
+	// ones are recorded by digest only.
+	auditCapture = 1 << 20
+	// auditParamBytes is the largest parameter recorded as is; larger
+	// ones are recorded by digest.
+	auditParamBytes = 256
+	// auditDrain is how much of a body left unread by the handler is
+	// read to complete its digest.
+	auditDrain = 64 << 10
+)
+
+// Audit returns a middleware recording each request in an audit log: who
+// made it, the digests of the body and its data fields, its other
+// parameters, the response status and the digest of the response body.
+// Mount it after authentication, so that requests carry their user.
+//
+// A request whose entry cannot be written is still answered; the log's
+// error is reported by its readiness check.
+func Audit(l *bioflow.AuditLog) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			body := &auditBody{ReadCloser: r.Body, hash: sha256.New()}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = body
+			} else {
+				body.eof = true
+			}
+			rw := &auditWriter{ResponseWriter: w, status: http.StatusOK, hash: sha256.New()}
+
+			next.ServeHTTP(rw, r)
+
+			if !body.eof {
+				io.CopyN(io.Discard, body, auditDrain)
+			}
+			e := &bioflow.AuditEntry{
+				Time:      start.UTC(),
+				User:      auth.Owner(r.Context()),
+				Remote:    r.RemoteAddr,
+				RequestID: chimiddleware.GetReqID(r.Context()),
+				Operation: r.Method + " " + r.URL.Path,
+				Status:    rw.status,
+				Duration:  time.Since(start).Seconds(),
+			}
+			e.Inputs, e.Params = auditRequest(r, body)
+			if rw.size > 0 {
+				e.Result = "sha256:" + hex.EncodeToString(rw.hash.Sum(nil))
+			}
+			if err := l.Append(e); err != nil {
+				log.Printf("Audit log: %v", err)
+			}
+		})
+	}
+}
+
+// auditRequest returns the input digests and parameters of a request.
+func auditRequest(r *http.Request, body *auditBody) (map[string]string, map[string]any) {
+	inputs := map[string]string{}
+	params := map[string]any{}
+	for name, values := range r.URL.Query() {
+		if len(values) == 1 {
+			params[name] = values[0]
+		} else {
+			params[name] = values
+		}
+	}
+	if body.size > 0 && body.eof {
+		inputs["body"] = "sha256:" + hex.EncodeToString(body.hash.Sum(nil))
+	}
+
+	var fields map[string]json.RawMessage
+	if body.eof && body.size <= auditCapture && json.Unmarshal(body.captured.Bytes(), &fields) == nil {
+		for name, raw := range fields {
+			switch {
+			case AuditDataFields[name]:
+				inputs[name] = fieldDigest(raw)
+			case len(raw) <= auditParamBytes:
+				params[name] = raw
+			default:
+				params[name] = fieldDigest(raw)
+			}
+		}
+	}
+	if len(inputs) == 0 {
+		inputs = nil
+	}
+	if len(params) == 0 {
+		params = nil
+	}
+	return inputs, params
+}
+
+// fieldDigest digests a JSON value: a string by its content, so that the
+// digest of a sequence can be checked against the sequence itself, and
+// anything else by its compact JSON.
+func fieldDigest(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return bioflow.AuditDigest([]byte(s))
+	}
+	var compact bytes.Buffer
+	if json.Compact(&compact, raw) == nil {
+		raw = compact.Bytes()
+	}
+	return bioflow.AuditDigest(raw)
+}
+
+// auditBody digests a request body as the handler reads it, keeping the
+// start of it for its fields.
+type auditBody struct {
+	io.ReadCloser
+	hash     hash.Hash
+	captured bytes.Buffer
+	size     int64
+	eof      bool
+}
+
+func (b *auditBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.hash.Write(p[:n])
+	if room := auditCapture + 1 - b.captured.Len(); room > 0 {
+		b.captured.Write(p[:min(n, room)])
+	}
+	b.size += int64(n)
+	if errors.Is(err, io.EOF) {
+		b.eof = true
+	}
+	return n, err
+}
+
+// auditWriter digests a response as it is written.
+type auditWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	hash        hash.Hash
+	size        int64
+}
+
+func (w *auditWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = code, true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *auditWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(p)
+	w.hash.Write(p[:n])
+	w.size += int64(n)
+	return n, err
+}
+
+// Flush passes flushes on, for streamed responses.
+func (w *auditWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/aria-lang/bioflow-go/pkg/bioflow"
+)
+
+// The HTTP metrics of the server. Requests are labeled by route pattern,
+// not path, so that IDs in paths do not make a series each; requests
+// matching no route share the route "unmatched".
+var (
+	httpRequests = bioflow.Metrics.Counter("bioflow_http_requests_total",
+		"HTTP requests served, by method, route and status code.", "method", "route", "code")
+	httpDuration = bioflow.Metrics.Histogram("bioflow_http_request_duration_seconds",
+		"Time taken to serve HTTP requests, by method and route.", nil, "method", "route")
+	httpInFlight = bioflow.Metrics.Gauge("bioflow_http_requests_in_flight",
+		"HTTP requests being served.")
+)
+
+// Metrics is a middleware that counts requests by route and status, and
+// records their latency and the number in flight, for the /metrics
+// endpoint.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		httpInFlight.Inc()
+		defer httpInFlight.Dec()
+
+		wrapped := wrapResponseWriter(w)
+		next.ServeHTTP(wrapped, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		httpRequests.Inc(r.Method, route, strconv.Itoa(wrapped.status))
+		httpDuration.Observe(time.Since(start).Seconds(), r.Method, route)
+	})
+}
@@ -0,0 +1,348 @@
+// BioFlow web UI. Plain browser JavaScript over the JSON API; no build step.
+'use strict';
+
+const API = '/api/v1';
+const POLL_MS = 2000;
+
+// call sends a JSON request to the API and returns the decoded reply,
+// throwing the API's error message on failure.
+async function call(path, body) {
+    const init = body === undefined ? {} : {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(body),
+    };
+    const res = await fetch(API + path, init);
+    const text = await res.text();
+    let data = text;
+    try { data = JSON.parse(text); } catch (e) { /* not JSON */ }
+    if (!res.ok) {
+        throw new Error((data && data.error) || `${res.status} ${res.statusText}`);
+    }
+    return data;
+}
+
+// download posts a request whose reply is a file, and saves it under the
+// name given by its Content-Disposition header.
+async function download(path, body) {
+    const res = await fetch(API + path, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(body),
+    });
+    if (!res.ok) {
+        const text = await res.text();
+        let message = `${res.status} ${res.statusText}`;
+        try { message = JSON.parse(text).error || message; } catch (e) { /* not JSON */ }
+        throw new Error(message);
+    }
+    const match = /filename="([^"]+)"/.exec(res.headers.get('Content-Disposition') || '');
+    saveBlob(await res.blob(), match ? match[1] : 'download');
+}
+
+function saveBlob(blob, filename) {
+    const url = URL.createObjectURL(blob);
+    const a = document.createElement('a');
+    a.href = url;
+    a.download = filename;
+    document.body.appendChild(a);
+    a.click();
+    a.remove();
+    URL.revokeObjectURL(url);
+}
+
+function show(id, data) {
+    const el = document.getElementById(id);
+    el.textContent = typeof data === 'string' ? data : JSON.stringify(data, null, 2);
+    el.hidden = false;
+}
+
+let errorTimer;
+function showError(err) {
+    const el = document.getElementById('error');
+    el.textContent = err.message || String(err);
+    el.hidden = false;
+    clearTimeout(errorTimer);
+    errorTimer = setTimeout(() => { el.hidden = true; }, 6000);
+}
+
+// busy disables a form's buttons while a request runs.
+async function busy(form, fn) {
+    const buttons = form.querySelectorAll('button');
+    buttons.forEach(b => { b.disabled = true; });
+    try {
+        await fn();
+    } catch (err) {
+        showError(err);
+    } finally {
+        buttons.forEach(b => { b.disabled = false; });
+    }
+}
+
+// Tabs follow the URL fragment.
+function selectTab() {
+    const id = (location.hash || '#sequence').slice(1);
+    document.querySelectorAll('.panel').forEach(p => p.classList.toggle('active', p.id === id));
+    document.querySelectorAll('nav a.tab').forEach(a => a.classList.toggle('active', a.hash === '#' + id));
+    if (id === 'jobs') {
+        refreshJobs();
+    }
+}
+window.addEventListener('hashchange', selectTab);
+
+// Sequence analysis
+
+const sequenceForm = document.getElementById('sequence-form');
+const analysisRequests = {
+    'info': s => ['/sequence/info', { sequence: s }],
+    'gc-content': s => ['/sequence/gc-content', { sequence: s }],
+    'validate': s => ['/sequence/validate', { sequence: s, type: 'auto' }],
+    'complement': s => ['/sequence/complement', { sequence: s }],
+    'reverse-complement': s => ['/sequence/reverse-complement', { sequence: s }],
+    'transcribe': s => ['/sequence/transcribe', { sequence: s }],
+    'kmer': (s, k) => ['/kmer/count', { sequence: s, k: k }],
+    'protein': s => ['/protein/properties', { protein: s }],
+    'fold': s => ['/rna/fold', { sequence: s }],
+};
+
+function toggleK() {
+    sequenceForm.querySelector('.kmer-only').hidden = sequenceForm.analysis.value !== 'kmer';
+}
+sequenceForm.analysis.addEventListener('change', toggleK);
+
+sequenceForm.addEventListener('submit', e => {
+    e.preventDefault();
+    const f = sequenceForm;
+    const seq = f.sequence.value.replace(/\s+/g, '');
+    const [path, body] = analysisRequests[f.analysis.value](seq, Number(f.k.value));
+    busy(f, async () => show('sequence-result', await call(path, body)));
+});
+
+// Alignment
+
+const alignmentForm = document.getElementById('alignment-form');
+
+function alignmentRequest() {
+    const f = alignmentForm;
+    return {
+        sequence1: f.sequence1.value.replace(/\s+/g, ''),
+        sequence2: f.sequence2.value.replace(/\s+/g, ''),
+        name1: f.name1.value,
+        name2: f.name2.value,
+        mode: f.mode.value,
+        format: f.format.value,
+    };
+}
+
+alignmentForm.addEventListener('submit', e => {
+    e.preventDefault();
+    const req = alignmentRequest();
+    busy(alignmentForm, async () => {
+        const a = await call('/alignment/' + req.mode, req);
+        show('alignment-result',
+            `${a.aligned_seq1}\n${a.aligned_seq2}\n\n` +
+            `score ${a.score}, identity ${(a.identity * 100).toFixed(1)}%, CIGAR ${a.cigar}\n` +
+            `sequence 1: ${a.start1}-${a.end1}, sequence 2: ${a.start2}-${a.end2}` +
+            (a.e_value ? `\nbit score ${a.bit_score.toFixed(1)}, E-value ${a.e_value.toExponential(2)}` : ''));
+    });
+});
+
+document.getElementById('alignment-download').addEventListener('click', () => {
+    if (!alignmentForm.reportValidity()) {
+        return;
+    }
+    busy(alignmentForm, () => download('/alignment/export', alignmentRequest()));
+});
+
+// Reads
+
+const readsForm = document.getElementById('reads-form');
+
+async function readFASTQ() {
+    const f = readsForm;
+    if (f.file.files.length > 0) {
+        return f.file.files[0].text();
+    }
+    if (f.fastq.value.trim() === '') {
+        throw new Error('choose a FASTQ file or paste FASTQ text');
+    }
+    return f.fastq.value;
+}
+
+function pipelineStages() {
+    const f = readsForm;
+    const stages = [];
+    if (f.trim.checked) {
+        stages.push({ type: 'trim', params: { threshold: Number(f.threshold.value) } });
+    }
+    if (f.filter.checked) {
+        stages.push({ type: 'filter', params: { min_length: Number(f.min_length.value) } });
+    }
+    if (f.dedupe.checked) {
+        stages.push({ type: 'dedupe' });
+    }
+    if (f.stats.checked) {
+        stages.push({ type: 'stats' });
+    }
+    return stages;
+}
+
+readsForm.addEventListener('submit', e => {
+    e.preventDefault();
+    const action = e.submitter ? e.submitter.value : 'stats';
+    busy(readsForm, async () => {
+        const fastq = await readFASTQ();
+        switch (action) {
+        case 'stats':
+            show('reads-result', await call('/stats/reads', { fastq: fastq }));
+            break;
+        case 'report':
+            await download('/stats/qc-report', { fastq: fastq });
+            break;
+        case 'job':
+            await call('/pipeline/jobs', {
+                fastq: fastq,
+                stages: pipelineStages(),
+                sample_rejected: 5,
+                priority: readsForm.priority.value,
+            });
+            location.hash = '#jobs';
+            break;
+        }
+    });
+});
+
+// Jobs
+
+let pollTimer;
+
+function jobProgress(job) {
+    if (job.status === 'failed') {
+        return job.error || '';
+    }
+    if (!job.progress || job.progress.length === 0) {
+        return '';
+    }
+    const last = job.progress[job.progress.length - 1];
+    return `stage ${last.index + 1}/${last.stages} ${last.stage}: ${last.processed} reads, ${last.rejected} rejected`;
+}
+
+function link(text, onclick) {
+    const a = document.createElement('a');
+    a.href = '#jobs';
+    a.textContent = text;
+    a.addEventListener('click', e => {
+        e.preventDefault();
+        onclick();
+    });
+    return a;
+}
+
+async function showJob(id) {
+    try {
+        show('job-result', await call(`/pipeline/jobs/${encodeURIComponent(id)}`));
+    } catch (err) {
+        showError(err);
+    }
+}
+
+async function saveFASTQ(id) {
+    try {
+        const job = await call(`/pipeline/jobs/${encodeURIComponent(id)}`);
+        saveBlob(new Blob([job.fastq || '']), `${id}.fastq`);
+    } catch (err) {
+        showError(err);
+    }
+}
+
+function renderJobs(list, progress) {
+    const tbody = document.getElementById('job-list');
+    tbody.replaceChildren();
+    for (const job of list) {
+        const tr = document.createElement('tr');
+        const cells = [job.id, job.status, job.priority, progress.get(job.id) || job.error || '',
+            new Date(job.submitted).toLocaleString()];
+        for (const text of cells) {
+            const td = document.createElement('td');
+            td.textContent = text;
+            tr.appendChild(td);
+        }
+        tr.children[1].className = 'status-' + job.status;
+
+        const actions = document.createElement('td');
+        actions.appendChild(link('details', () => showJob(job.id)));
+        if (job.status === 'done') {
+            actions.appendChild(link('FASTQ', () => saveFASTQ(job.id)));
+            const reads = document.createElement('a');
+            reads.href = `${API}/pipeline/jobs/${encodeURIComponent(job.id)}/reads`;
+            reads.download = `${job.id}.jsonl`;
+            reads.textContent = 'JSON Lines';
+            actions.appendChild(reads);
+        }
+        tr.appendChild(actions);
+        tbody.appendChild(tr);
+    }
+}
+
+// refreshJobs lists the jobs of the user's workspace, following the
+// progress of those still running.
+async function refreshJobs() {
+    clearTimeout(pollTimer);
+    let list;
+    try {
+        list = await call('/pipeline/jobs');
+    } catch (err) {
+        showError(err);
+        return;
+    }
+    const active = list.filter(j => j.status === 'queued' || j.status === 'running');
+    const progress = new Map();
+    await Promise.all(active.map(async j => {
+        try {
+            progress.set(j.id, jobProgress(await call(`/pipeline/jobs/${encodeURIComponent(j.id)}`)));
+        } catch (err) {
+            // The job finished and was forgotten meanwhile.
+        }
+    }));
+    try {
+        const q = await call('/pipeline/queue');
+        document.getElementById('queue').textContent =
+            `Queue: ${q.running} running, ${q.queued} queued on ${q.workers} workers.`;
+    } catch (err) {
+        // The queue metrics are informational.
+    }
+    renderJobs(list, progress);
+    if (active.length > 0 && location.hash === '#jobs') {
+        pollTimer = setTimeout(refreshJobs, POLL_MS);
+    }
+}
+
+document.getElementById('jobs-refresh').addEventListener('click', refreshJobs);
+
+// Sign-in. Without authentication /auth/me does not exist and the UI is
+// anonymous.
+
+async function checkUser() {
+    const res = await fetch('/auth/me');
+    if (res.status === 404) {
+        return;
+    }
+    const data = await res.json();
+    const el = document.getElementById('user');
+    if (res.ok) {
+        el.textContent = (data.name || data.id) + ' · ';
+        const out = document.createElement('a');
+        out.href = '/auth/logout';
+        out.textContent = 'Sign out';
+        el.appendChild(out);
+    } else if (data.login) {
+        location.href = data.login + '?next=' + encodeURIComponent(location.pathname + location.hash);
+        return;
+    } else {
+        el.textContent = 'Not signed in: this server takes API keys only.';
+    }
+    el.hidden = false;
+}
+
+toggleK();
+checkUser().finally(selectTab);
@@ -0,0 +1,27 @@
+// Package web serves the BioFlow web UI: a single page, embedded in the
+// binary, with forms for sequence analysis, alignment, read file upload
+// and pipeline jobs, and downloads of their results. The page talks to
+// the JSON API under /api/v1 and needs no build step; the API reference
+// is served alongside it as /api.html.
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// static holds the UI files.
+//
+//go:embed static
+var static embed.FS
+
+// Handler serves the UI files, with index.html at the root.
+func Handler() http.Handler {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		// The directory is embedded, so this cannot happen.
+		panic(err)
+	}
+	return http.FileServer(http.FS(files))
+}
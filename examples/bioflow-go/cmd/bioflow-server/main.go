@@ -8,21 +8,130 @@
 //
 //	-port     Port to listen on (default: 8080)
 //	-host     Host to bind to (default: localhost)
+//	-max-align-cells  Largest alignment DP matrix accepted (default: 25000000)
+//	-align-timeout    Time limit for a single alignment (default: 10s)
+//	-max-body         Largest request body in bytes (default: 33554432)
+//	-max-seq-len      Longest sequence or read accepted (default: 10000000)
+//	-max-k            Largest k-mer size accepted (default: 64)
+//	-max-batch        Most sequences or reads per request (default: 100000)
+//	-limits           JSON file of per-endpoint limits, by path (default: none)
+//	-job-workers      Pipeline jobs run at once (default: 2)
+//	-job-queue        Pipeline jobs queued per priority class (default: 32)
+//	-job-dir          Directory keeping pipeline and asynchronous jobs across restarts (default: memory only)
+//	-async-workers    Asynchronous jobs run at once (default: 2)
+//	-async-queue      Asynchronous jobs queued per priority class (default: 32)
+//	-async-ttl        Time the result of a finished asynchronous job is kept (default: 1h)
+//	-async-timeout    Time limit for an asynchronous job (default: 30m)
+//	-async-align-cells  Largest alignment DP matrix of an asynchronous job (default: 400000000)
+//	-broker           Redis URL of a queue sending pipeline jobs to workers (default: run jobs here)
+//	-worker           Run pipeline jobs from the -broker queue instead of serving the API
+//	-node             Name of this server in the broker (default: host name)
+//	-trace            Export OpenTelemetry spans: otlp or stdout (default: off)
+//	-oidc-issuer      OpenID Connect provider users sign in with (default: no sign-in)
+//	-oidc-client-id   Client ID registered with the provider
+//	-oidc-client-secret  Client secret (default: $BIOFLOW_OIDC_CLIENT_SECRET)
+//	-oidc-redirect    Callback URL registered with the provider (default: http://host:port/auth/callback)
+//	-oidc-user-claim  ID token claim naming users (default: email)
+//	-api-keys         File of "user key" lines for programmatic access (default: none)
+//	-session-key      Key signing session cookies (default: $BIOFLOW_SESSION_KEY, or random)
+//	-audit-dir        Directory of the audit log of API requests (default: no audit log)
+//	-audit-max-size   Size in bytes at which audit log files are rotated (default: 104857600)
+//	-audit-admins     Comma-separated users allowed to export the audit log
+//	-references       Directory of FASTA files to serve regions of (default: none)
+//	-data-dir         Directory overriding the built-in data tables (default: $BIOFLOW_DATA_DIR)
+//	-openapi          Print the OpenAPI specification of the API and exit
+//
+// The API is served under /api/v1, and under /api for older clients. The
+// root serves an embedded web UI built on it, and /api.html the API
+// reference. /api/openapi.json describes every endpoint and the schemas
+// of its bodies as OpenAPI 3, for generating clients; pkg/bioflowclient
+// is the Go client generated from it.
+//
+// With -oidc-issuer or -api-keys, the API needs authentication: people
+// sign in to the web UI at /auth/login through the provider, and programs
+// send an API key as a bearer token or in X-API-Key. Each user sees only
+// the pipeline jobs they started, so give API keys the user IDs their
+// owners sign in with:
+//
+//	bioflow-server -oidc-issuer https://sso.example.org/realms/lab \
+//	    -oidc-client-id bioflow -oidc-redirect https://bioflow.example.org/auth/callback \
+//	    -api-keys /etc/bioflow/keys
+//
+// With -audit-dir, every API request is appended to a hash-chained audit
+// log: who made it and when, the operation, digests of its input data,
+// its other parameters, and the status and digest of the response.
+// Sample data itself is never written to the log. Files are rotated at
+// -audit-max-size and never removed; "bioflow audit" checks that none was
+// altered. GET /api/v1/audit exports entries by time range and user, to
+// the -audit-admins only when authentication is on:
+//
+//	bioflow-server -oidc-issuer ... -audit-dir /var/lib/bioflow/audit -audit-admins qa@example.org
+//
+// With -references, the uncompressed FASTA files (.fa, .fasta, .fna) of a
+// directory are indexed as samtools faidx does, if they have no .fai
+// index yet, and GET /api/v1/references/{name}/regions extracts regions of
+// them without reading the rest of the file:
+//
+//	curl 'http://localhost:8080/api/v1/references/hg38/regions?region=chr1:1,000,001-1,000,100'
+//
+// With -broker, one server answers the API and queues pipeline jobs in
+// Redis, and any number of servers started with -worker and the same
+// -broker run them, -job-workers at a time each:
+//
+//	bioflow-server -broker redis://queue:6379 -job-dir /var/lib/bioflow
+//	bioflow-server -broker redis://queue:6379 -worker -job-workers 8
+//
+// Inputs too large to answer within the 60-second request timeout, such
+// as long alignments, run as asynchronous jobs: POST /api/v1/jobs queues
+// an operation over a FASTA or FASTQ payload and replies with a job ID,
+// and GET /api/v1/jobs/{id} reports its status, and its result once done,
+// until -async-ttl after it finished:
+//
+//	curl -d '{"operation": "align-global", "fasta": ">a\nACGT...\n>b\nACGA...\n"}' http://localhost:8080/api/v1/jobs
+//	curl http://localhost:8080/api/v1/jobs/async-1
+//
+// Oversized input is turned away before it is parsed: bodies over
+// -max-body, and sequences or batches over their limits, get 413, and k
+// over -max-k gets 422. The alignment and folding endpoints take shorter
+// sequences by default, and alignments of at most 100 million cells; a
+// -limits file overrides any endpoint:
+//
+//	{"/api/alignment/local": {"max_sequence_length": 20000, "max_cells": 10000000}}
+//
+// With -trace otlp, requests, pipeline jobs (on workers too) and their
+// parse, align, count and stage phases are traced and sent to the
+// collector set by OTEL_EXPORTER_OTLP_ENDPOINT (default localhost:4318).
+// Incoming W3C traceparent headers are honoured, and each response
+// carries its trace ID in X-Trace-Id.
+//
+// GET /metrics serves metrics for Prometheus to scrape, outside the
+// authentication of the API: requests by route and status code, their
+// latency per route, the requests in flight, and the work done in bases
+// processed, reads filtered and alignments computed:
+//
+//	scrape_configs:
+//	  - job_name: bioflow
+//	    static_configs: [{targets: ["bioflow.example.org:8080"]}]
 package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/aria-lang/bioflow-go/api/auth"
 	"github.com/aria-lang/bioflow-go/api/handlers"
 	"github.com/aria-lang/bioflow-go/api/middleware"
+	"github.com/aria-lang/bioflow-go/api/web"
+	"github.com/aria-lang/bioflow-go/pkg/bioflow"
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 )
@@ -30,124 +139,196 @@ import (
 func main() {
 	port := flag.Int("port", 8080, "Port to listen on")
 	host := flag.String("host", "localhost", "Host to bind to")
+	maxCells := flag.Int64("max-align-cells", handlers.AlignmentLimits.MaxCells, "Largest alignment DP matrix (cells) accepted; 0 for no limit")
+	alignTimeout := flag.Duration("align-timeout", handlers.AlignmentLimits.Timeout, "Time limit for a single alignment; 0 for no limit")
+	maxBody := flag.Int64("max-body", handlers.RequestLimits.MaxBodyBytes, "Largest request body (bytes) accepted; 0 for no limit")
+	maxSeqLen := flag.Int("max-seq-len", handlers.RequestLimits.MaxSequenceLength, "Longest sequence or read accepted; 0 for no limit")
+	maxK := flag.Int("max-k", handlers.RequestLimits.MaxK, "Largest k-mer size accepted; 0 for no limit")
+	maxBatch := flag.Int("max-batch", handlers.RequestLimits.MaxBatch, "Most sequences or reads per request; 0 for no limit")
+	limitsFile := flag.String("limits", "", "JSON file of per-endpoint input limits, by path")
+	jobWorkers := flag.Int("job-workers", handlers.JobQueueLimits.Workers, "Pipeline jobs run at once")
+	jobQueue := flag.Int("job-queue", handlers.JobQueueLimits.MaxQueued, "Pipeline jobs queued per priority class before new ones get 503")
+	jobDir := flag.String("job-dir", "", "Directory keeping pipeline and asynchronous jobs and their input across restarts (default: memory only)")
+	asyncWorkers := flag.Int("async-workers", handlers.AsyncJobLimits.Workers, "Asynchronous jobs run at once")
+	asyncQueue := flag.Int("async-queue", handlers.AsyncJobLimits.MaxQueued, "Asynchronous jobs queued per priority class before new ones get 503")
+	asyncTTL := flag.Duration("async-ttl", handlers.AsyncJobTTL, "Time the result of a finished asynchronous job is kept; 0 to keep results")
+	asyncTimeout := flag.Duration("async-timeout", handlers.AsyncJobTimeout, "Time limit for an asynchronous job; 0 for no limit")
+	asyncCells := flag.Int64("async-align-cells", handlers.AsyncAlignmentLimits.MaxCells, "Largest alignment DP matrix (cells) of an asynchronous job; 0 for no limit")
+	brokerURL := flag.String("broker", "", "Redis URL (redis://host:port) of a queue sending pipeline jobs to workers (default: run jobs here)")
+	worker := flag.Bool("worker", false, "Run pipeline jobs from the -broker queue instead of serving the API")
+	node := flag.String("node", "", "Name of this server in the broker (default: host name)")
+	traceExporter := flag.String("trace", "", "Export OpenTelemetry spans: otlp (to OTEL_EXPORTER_OTLP_ENDPOINT) or stdout (default: off)")
+	oidcIssuer := flag.String("oidc-issuer", "", "URL of the OpenID Connect provider users sign in with (default: no sign-in)")
+	oidcClientID := flag.String("oidc-client-id", "", "Client ID registered with the OpenID Connect provider")
+	oidcClientSecret := flag.String("oidc-client-secret", os.Getenv("BIOFLOW_OIDC_CLIENT_SECRET"), "Client secret registered with the provider (default: $BIOFLOW_OIDC_CLIENT_SECRET)")
+	oidcRedirect := flag.String("oidc-redirect", "", "Callback URL registered with the provider (default: http://host:port/auth/callback)")
+	oidcUserClaim := flag.String("oidc-user-claim", "email", "ID token claim naming users and their workspaces")
+	apiKeysFile := flag.String("api-keys", "", "File of \"user key\" lines granting programmatic access")
+	sessionKey := flag.String("session-key", os.Getenv("BIOFLOW_SESSION_KEY"), "Key signing session cookies, kept across restarts (default: $BIOFLOW_SESSION_KEY, or random)")
+	auditDir := flag.String("audit-dir", "", "Directory of the audit log of API requests (default: no audit log)")
+	auditMaxSize := flag.Int64("audit-max-size", 100<<20, "Size (bytes) at which audit log files are rotated")
+	auditAdmins := flag.String("audit-admins", "", "Comma-separated users allowed to export the audit log when authentication is on")
+	referenceDir := flag.String("references", "", "Directory of FASTA files (.fa, .fasta, .fna) to serve regions of")
+	dataDir := flag.String("data-dir", os.Getenv(bioflow.DataDirEnv), "Directory of data tables (codon usage, adapters, enzymes, matrices, genetic codes) overriding the built-in ones (default: $BIOFLOW_DATA_DIR)")
+	printOpenAPI := flag.Bool("openapi", false, "Print the OpenAPI specification of the API, as served at /api/openapi.json, and exit")
 	flag.Parse()
 
+	if *printOpenAPI {
+		doc, err := handlers.OpenAPISpec()
+		if err != nil {
+			log.Fatalf("Could not describe the API: %v\n", err)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(doc); err != nil {
+			log.Fatalf("Could not write the specification: %v\n", err)
+		}
+		return
+	}
+
+	handlers.AlignmentLimits = bioflow.AlignmentLimits{MaxCells: *maxCells, Timeout: *alignTimeout}
+	handlers.JobQueueLimits = bioflow.JobQueueOptions{Workers: *jobWorkers, MaxQueued: *jobQueue}
+	handlers.AsyncJobLimits = bioflow.JobQueueOptions{Workers: *asyncWorkers, MaxQueued: *asyncQueue}
+	handlers.AsyncJobTTL, handlers.AsyncJobTimeout = *asyncTTL, *asyncTimeout
+	handlers.AsyncAlignmentLimits.MaxCells = *asyncCells
+	handlers.RequestLimits = handlers.InputLimits{MaxBodyBytes: *maxBody, MaxSequenceLength: *maxSeqLen, MaxK: *maxK, MaxBatch: *maxBatch}
+	if *limitsFile != "" {
+		if err := handlers.LoadEndpointLimits(*limitsFile); err != nil {
+			log.Fatalf("Could not load limits: %v\n", err)
+		}
+	}
+	if *dataDir != "" {
+		if info, err := os.Stat(*dataDir); err != nil || !info.IsDir() {
+			log.Fatalf("Data directory %s is not a directory\n", *dataDir)
+		}
+		bioflow.SetDataDir(*dataDir)
+		log.Printf("Data tables from %s override the built-in ones\n", *dataDir)
+	}
+	if *referenceDir != "" {
+		n, err := handlers.LoadReferences(*referenceDir)
+		if err != nil {
+			log.Fatalf("Could not load references: %v\n", err)
+		}
+		log.Printf("Serving regions of %d references from %s\n", n, *referenceDir)
+	}
+	if *worker && *brokerURL == "" {
+		log.Fatalf("-worker needs a -broker to take jobs from\n")
+	}
+	service := "bioflow-server"
+	if *worker {
+		service = "bioflow-worker"
+	}
+	shutdownTracing, err := bioflow.SetupTracing(context.Background(), *traceExporter, service)
+	if err != nil {
+		log.Fatalf("Could not set up tracing: %v\n", err)
+	}
+	defer flushTraces(shutdownTracing)
+	brokerCtx, stopBroker := context.WithCancel(context.Background())
+	defer stopBroker()
+	if *brokerURL != "" {
+		b, err := bioflow.OpenBroker(*brokerURL)
+		if err != nil {
+			log.Fatalf("Could not connect to broker: %v\n", err)
+		}
+		defer b.Close()
+		if *worker {
+			runWorker(b, bioflow.WorkerOptions{Name: *node, Workers: *jobWorkers})
+			return
+		}
+		handlers.UseBroker(brokerCtx, b, bioflow.BrokerClientOptions{Queue: *node, MaxQueued: *jobQueue})
+		log.Printf("Sending pipeline jobs to workers through %s\n", *brokerURL)
+	}
+	if *jobDir != "" {
+		queued, err := handlers.OpenJobStore(*jobDir)
+		if err != nil {
+			log.Fatalf("Could not open job store: %v\n", err)
+		}
+		log.Printf("Restored jobs from %s (%d queued)\n", *jobDir, queued)
+	}
+
+	var authn *auth.Authenticator
+	if *oidcIssuer != "" || *apiKeysFile != "" {
+		opts := auth.Options{
+			Issuer:       *oidcIssuer,
+			ClientID:     *oidcClientID,
+			ClientSecret: *oidcClientSecret,
+			RedirectURL:  *oidcRedirect,
+			UserClaim:    *oidcUserClaim,
+			SessionKey:   []byte(*sessionKey),
+		}
+		if opts.Issuer != "" && opts.RedirectURL == "" {
+			opts.RedirectURL = fmt.Sprintf("http://%s:%d/auth/callback", *host, *port)
+		}
+		if *apiKeysFile != "" {
+			if opts.APIKeys, err = auth.LoadAPIKeys(*apiKeysFile); err != nil {
+				log.Fatalf("Could not load API keys: %v\n", err)
+			}
+		}
+		if authn, err = auth.New(context.Background(), opts); err != nil {
+			log.Fatalf("Could not set up authentication: %v\n", err)
+		}
+		log.Printf("Authentication required (single sign-on: %t, API keys: %d)\n", authn.SingleSignOn(), len(opts.APIKeys))
+	}
+
+	var auditLog *bioflow.AuditLog
+	if *auditDir != "" {
+		if auditLog, err = bioflow.OpenAuditLog(*auditDir, bioflow.AuditOptions{MaxBytes: *auditMaxSize}); err != nil {
+			log.Fatalf("Could not open audit log: %v\n", err)
+		}
+		defer auditLog.Close()
+		handlers.UseAuditLog(auditLog)
+		for _, user := range strings.Split(*auditAdmins, ",") {
+			if user = strings.TrimSpace(user); user != "" {
+				handlers.AuditAdmins[user] = true
+			}
+		}
+		log.Printf("Auditing API requests to %s\n", *auditDir)
+	}
+
 	r := chi.NewRouter()
 
 	// Global middleware
 	r.Use(chimiddleware.RequestID)
 	r.Use(chimiddleware.RealIP)
+	r.Use(middleware.Tracing)
+	r.Use(middleware.Metrics)
 	r.Use(middleware.Logger)
 	r.Use(chimiddleware.Recoverer)
 	r.Use(chimiddleware.Timeout(60 * time.Second))
 
-	// Health check
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
+	// Health checks, for liveness and readiness probes
+	r.Get("/healthz", handlers.LivenessHandler)
+	r.Get("/readyz", handlers.ReadinessHandler)
 
-	// API routes
-	r.Route("/api", func(r chi.Router) {
-		// Sequence endpoints
-		r.Route("/sequence", func(r chi.Router) {
-			r.Post("/gc-content", handlers.GCContentHandler)
-			r.Post("/at-content", handlers.ATContentHandler)
-			r.Post("/complement", handlers.ComplementHandler)
-			r.Post("/reverse-complement", handlers.ReverseComplementHandler)
-			r.Post("/transcribe", handlers.TranscribeHandler)
-			r.Post("/info", handlers.SequenceInfoHandler)
-			r.Post("/validate", handlers.ValidateHandler)
-		})
-
-		// K-mer endpoints
-		r.Route("/kmer", func(r chi.Router) {
-			r.Post("/count", handlers.KMerCountHandler)
-			r.Post("/most-frequent", handlers.MostFrequentKMersHandler)
-			r.Post("/distance", handlers.KMerDistanceHandler)
-			r.Post("/shared", handlers.SharedKMersHandler)
-		})
+	// Metrics, for Prometheus to scrape
+	r.Handle("/metrics", bioflow.Metrics.Handler())
 
-		// Alignment endpoints
-		r.Route("/alignment", func(r chi.Router) {
-			r.Post("/local", handlers.LocalAlignHandler)
-			r.Post("/global", handlers.GlobalAlignHandler)
-			r.Post("/score", handlers.AlignmentScoreHandler)
-		})
+	// API routes, versioned under /api/v1; /api serves the current
+	// version for older clients.
+	r.Route("/api", func(r chi.Router) {
+		if authn != nil {
+			r.Use(authn.Middleware)
+		}
+		if auditLog != nil {
+			r.Use(middleware.Audit(auditLog))
+		}
+		r.Route("/v1", apiRoutes)
+		apiRoutes(r)
+	})
 
-		// Quality endpoints
-		r.Route("/quality", func(r chi.Router) {
-			r.Post("/parse", handlers.ParseQualityHandler)
-			r.Post("/stats", handlers.QualityStatsHandler)
-			r.Post("/filter", handlers.FilterReadHandler)
+	// Sign-in for the web UI
+	if authn != nil {
+		r.Route("/auth", func(r chi.Router) {
+			r.Get("/login", authn.LoginHandler)
+			r.Get("/callback", authn.CallbackHandler)
+			r.Get("/logout", authn.LogoutHandler)
+			r.Post("/logout", authn.LogoutHandler)
+			r.Get("/me", authn.MeHandler)
 		})
+	}
 
-		// Statistics endpoints
-		r.Route("/stats", func(r chi.Router) {
-			r.Post("/sequence", handlers.SequenceStatsHandler)
-			r.Post("/set", handlers.SequenceSetStatsHandler)
-		})
-	})
-
-	// Serve static files
-	fileServer := http.FileServer(http.Dir("./web/static"))
-	r.Handle("/static/*", http.StripPrefix("/static/", fileServer))
-
-	// Home page
-	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/html")
-		w.Write([]byte(`<!DOCTYPE html>
-<html>
-<head>
-    <title>BioFlow API</title>
-    <style>
-        body { font-family: system-ui, sans-serif; max-width: 800px; margin: 2rem auto; padding: 0 1rem; }
-        h1 { color: #2563eb; }
-        pre { background: #f3f4f6; padding: 1rem; border-radius: 0.5rem; overflow-x: auto; }
-        .endpoint { margin: 1rem 0; padding: 1rem; border: 1px solid #e5e7eb; border-radius: 0.5rem; }
-        .method { display: inline-block; padding: 0.25rem 0.5rem; background: #10b981; color: white; border-radius: 0.25rem; font-size: 0.875rem; }
-    </style>
-</head>
-<body>
-    <h1>BioFlow API</h1>
-    <p>A REST API for genomic sequence analysis.</p>
-
-    <h2>Endpoints</h2>
-
-    <div class="endpoint">
-        <span class="method">POST</span> <code>/api/sequence/gc-content</code>
-        <p>Calculate GC content of a sequence.</p>
-        <pre>{"sequence": "ATGCATGC"}</pre>
-    </div>
-
-    <div class="endpoint">
-        <span class="method">POST</span> <code>/api/sequence/complement</code>
-        <p>Get the complement of a DNA sequence.</p>
-        <pre>{"sequence": "ATGC"}</pre>
-    </div>
-
-    <div class="endpoint">
-        <span class="method">POST</span> <code>/api/kmer/count</code>
-        <p>Count k-mers in a sequence.</p>
-        <pre>{"sequence": "ATGATGATG", "k": 3}</pre>
-    </div>
-
-    <div class="endpoint">
-        <span class="method">POST</span> <code>/api/alignment/local</code>
-        <p>Perform local alignment (Smith-Waterman).</p>
-        <pre>{"sequence1": "ATGCATGC", "sequence2": "ATGCGGGG"}</pre>
-    </div>
-
-    <div class="endpoint">
-        <span class="method">POST</span> <code>/api/quality/stats</code>
-        <p>Calculate quality score statistics.</p>
-        <pre>{"scores": [30, 30, 35, 35, 40]}</pre>
-    </div>
-
-    <p>For more information, see the <a href="https://github.com/aria-lang/bioflow-go">documentation</a>.</p>
-</body>
-</html>`))
-	})
+	// Web UI, and the API reference at /api.html
+	r.Handle("/*", web.Handler())
 
 	addr := fmt.Sprintf("%s:%d", *host, *port)
 	server := &http.Server{
@@ -175,6 +356,11 @@ func main() {
 		if err := server.Shutdown(ctx); err != nil {
 			log.Fatalf("Could not gracefully shutdown: %v\n", err)
 		}
+		stopBroker()
+		// Jobs still running are run again on the next start.
+		if err := handlers.CloseJobStore(); err != nil {
+			log.Printf("Could not close job store: %v\n", err)
+		}
 		close(done)
 	}()
 
@@ -186,3 +372,118 @@ func main() {
 	<-done
 	log.Println("Server stopped")
 }
+
+// apiRoutes registers the API endpoints on r.
+func apiRoutes(r chi.Router) {
+	// Sequence endpoints
+	r.Route("/sequence", func(r chi.Router) {
+		r.Post("/gc-content", handlers.GCContentHandler)
+		r.Post("/at-content", handlers.ATContentHandler)
+		r.Post("/complement", handlers.ComplementHandler)
+		r.Post("/reverse-complement", handlers.ReverseComplementHandler)
+		r.Post("/transcribe", handlers.TranscribeHandler)
+		r.Post("/info", handlers.SequenceInfoHandler)
+		r.Post("/validate", handlers.ValidateHandler)
+	})
+
+	// K-mer endpoints
+	r.Route("/kmer", func(r chi.Router) {
+		r.Post("/count", handlers.KMerCountHandler)
+		r.Post("/most-frequent", handlers.MostFrequentKMersHandler)
+		r.Post("/distance", handlers.KMerDistanceHandler)
+		r.Post("/shared", handlers.SharedKMersHandler)
+	})
+
+	// Alignment endpoints
+	r.Route("/alignment", func(r chi.Router) {
+		r.Post("/local", handlers.LocalAlignHandler)
+		r.Post("/global", handlers.GlobalAlignHandler)
+		r.Post("/score", handlers.AlignmentScoreHandler)
+		r.Post("/export", handlers.AlignmentExportHandler)
+	})
+
+	// Multiple sequence alignment endpoints
+	r.Route("/msa", func(r chi.Router) {
+		r.Post("/export", handlers.MSAExportHandler)
+	})
+
+	// Quality endpoints
+	r.Route("/quality", func(r chi.Router) {
+		r.Post("/parse", handlers.ParseQualityHandler)
+		r.Post("/stats", handlers.QualityStatsHandler)
+		r.Post("/filter", handlers.FilterReadHandler)
+	})
+
+	// Statistics endpoints
+	r.Route("/stats", func(r chi.Router) {
+		r.Post("/sequence", handlers.SequenceStatsHandler)
+		r.Post("/set", handlers.SequenceSetStatsHandler)
+		r.Post("/compare", handlers.CompareSetsHandler)
+		r.Post("/reads", handlers.ReadSetStatsHandler)
+		r.Post("/histogram", handlers.HistogramHandler)
+		r.Post("/qc-report", handlers.QCReportHandler)
+	})
+
+	// Protein endpoints
+	r.Route("/protein", func(r chi.Router) {
+		r.Post("/properties", handlers.ProteinPropertiesHandler)
+	})
+
+	// RNA endpoints
+	r.Route("/rna", func(r chi.Router) {
+		r.Post("/fold", handlers.FoldHandler)
+	})
+
+	// Pipeline endpoints
+	r.Route("/pipeline", func(r chi.Router) {
+		r.Post("/jobs", handlers.StartPipelineJobHandler)
+		r.Get("/jobs", handlers.ListPipelineJobsHandler)
+		r.Get("/queue", handlers.PipelineQueueHandler)
+		r.Get("/jobs/{id}", handlers.PipelineJobHandler)
+		r.Get("/jobs/{id}/reads", handlers.PipelineJobReadsHandler)
+	})
+
+	// Asynchronous job endpoints
+	r.Route("/jobs", func(r chi.Router) {
+		r.Post("/", handlers.StartJobHandler)
+		r.Get("/", handlers.ListJobsHandler)
+		r.Get("/queue", handlers.JobQueueHandler)
+		r.Get("/{id}", handlers.JobHandler)
+		r.Delete("/{id}", handlers.DeleteJobHandler)
+	})
+
+	// Reference region endpoints
+	r.Route("/references", func(r chi.Router) {
+		r.Get("/", handlers.ListReferencesHandler)
+		r.Get("/{name}/regions", handlers.ReferenceRegionsHandler)
+	})
+
+	// Audit log export
+	r.Get("/audit", handlers.AuditExportHandler)
+
+	// OpenAPI description of these endpoints
+	r.Get("/openapi.json", handlers.OpenAPIHandler)
+}
+
+// runWorker runs pipeline jobs from the broker until the process is
+// interrupted, finishing the jobs it has started.
+func runWorker(b bioflow.Broker, opts bioflow.WorkerOptions) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("BioFlow worker running %d pipeline jobs at a time\n", opts.Workers)
+	if err := handlers.RunPipelineWorker(ctx, b, opts); err != nil {
+		log.Fatalf("Worker failed: %v\n", err)
+	}
+	log.Println("Worker stopped")
+}
+
+// flushTraces sends the spans not yet exported, waiting a few seconds at
+// most.
+func flushTraces(shutdown func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		log.Printf("Could not flush traces: %v\n", err)
+	}
+}
@@ -10,16 +10,86 @@
 //	gc          Calculate GC content
 //	kmer        Count k-mers
 //	align       Align two sequences
-//	stats       Calculate sequence statistics
+//	stats       Calculate sequence or read (FASTQ) statistics
 //	filter      Filter reads by quality
+//	tree        Build or manipulate Newick trees
+//	conservation  Per-column conservation of an alignment
+//	pssm        Build a PSSM from an alignment and scan sequences
+//	fold        Predict RNA secondary structure
+//	protein-stats  Protein physico-chemical properties
+//	backtranslate  Codon-optimized reverse translation of a protein
+//	orf         Find open reading frames (optionally frameshift-tolerant)
+//	telomere    Report telomeric / repeat-motif content in windows
+//	repeats     Annotate duplicated regions by self-comparison
+//	inverted    Find inverted repeats and hairpins
+//	probes      Design hybridization probes unique to a target
+//	faidx       Index a FASTA file (.fai) and extract regions
+//	bisulfite   In-silico bisulfite conversion and methylation contexts
+//	chimera     Reference-based chimera detection for amplicon sequences
+//	cluster     Cluster amplicons into OTUs or ASV-like groups per sample
+//	diversity   Alpha and beta diversity of an abundance table
+//	rarefaction Rarefaction curves of richness by subsampled depth
+//	ani         Average nucleotide identity between genomes
+//	completeness Genome completeness from conserved marker k-mer panels
+//	complexity  Per-window entropy and linguistic complexity tracks
+//	cgr         Chaos game representation (FCGR) vectors and images
+//	kmer-matrix Normalized k-mer feature matrices (CSV, NumPy)
+//	sketch      Syncmer and randstrobe seed extraction
+//	mappability Per-position k-mer uniqueness track (bedGraph/WIG)
+//	mask        Mask bases covered by high-abundance k-mers
+//	anchors     Shared k-mer anchors, collinear chains and PAF output
+//	realign     Realign a read around indels and left-normalize its CIGAR
+//	vcf-norm    Left-align, trim and split VCF variants
+//	pileup      Pile up SAM/BAM reads, call variants or build a consensus
+//	snp-density Windowed SNP density (per kb) from VCF or pileup calls, as bedGraph
+//	coverage    Coverage uniformity and GC-bias QC report
+//	liftover    Lift BED intervals to another assembly through chains
+//	asm-stats   Assembly QC: N50/NG50, gaps, misassemblies
+//	gaps        N-gap statistics and splitting of scaffolds (AGP)
+//	qc          Read QC: per-cycle quality, k-mer and adapter content
+//	compare-stats Compare length/GC/quality distributions of two sets
+//	windows     Windowed GC, skew, entropy and complexity tracks
+//	validate    Check FASTA/FASTQ files for malformed records
+//	subset      Filter, sort, group and combine FASTA sequence sets
+//	readgroup   Read group metadata from read names; tag SAM @RG
+//	run         Run a pipeline defined in a YAML file
+//	batch       Run a pipeline over the samples of a sample sheet
+//	import      Import sequences or reads from CSV/TSV
+//	verify      Check output files against a manifest
+//	anonymize   Replace identifiers with keyed pseudonyms
+//	audit       Check and export a server's audit log
+//	tooldef     Write CWL or WDL descriptors of the commands
 //	version     Show version information
+//
+// Commands and pipeline stages registered by plugins are available too.
+// Plugins are either imported into a custom build of this command or
+// listed, as Go plugin files, in the BIOFLOW_PLUGINS environment variable.
+//
+// Built-in data tables (genetic codes, codon usage tables, adapter sets,
+// restriction enzymes and substitution matrices) are embedded in the
+// binary. Files in the directory named by BIOFLOW_DATA_DIR override them
+// or add tables, laid out as the embedded ones: for example
+// codon-usage/yeast.txt adds a codon usage table for -organism yeast.
+//
+// Output files only replace their destination once completely written, so
+// a failed or interrupted command never leaves a partial file. Commands
+// that write files accept -compress (gzip, bgzf or zstd, or from a .gz,
+// .bgz or .zst name), -compress-level and -fsync, and -manifest to record
+// the size, SHA-256 digest and record count of each file written, with the
+// version and parameters of the run, for checking later with "bioflow
+// verify".
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/aria-lang/bioflow-go/pkg/bioflow"
 )
@@ -27,33 +97,41 @@ import (
 func main() {
 	if len(os.Args) < 2 {
 		printUsage()
-		os.Exit(1)
-	}
-
-	command := os.Args[1]
-
-	switch command {
-	case "info":
-		infoCmd(os.Args[2:])
-	case "gc":
-		gcCmd(os.Args[2:])
-	case "kmer":
-		kmerCmd(os.Args[2:])
-	case "align":
-		alignCmd(os.Args[2:])
-	case "stats":
-		statsCmd(os.Args[2:])
-	case "filter":
-		filterCmd(os.Args[2:])
+		exit(1)
+	}
+
+	if err := bioflow.LoadPluginsFromEnv(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+
+	name := os.Args[1]
+
+	if c, ok := lookupCommand(name); ok {
+		runCommand(c, os.Args[2:])
+		writeManifest()
+		return
+	}
+	switch name {
+	case "tooldef":
+		runCommand(command{name: "tooldef", flags: tooldefCmd}, os.Args[2:])
 	case "version":
 		fmt.Println(bioflow.Info())
 	case "help", "-h", "--help":
 		printUsage()
 	default:
-		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", command)
+		if cmd, ok := bioflow.LookupCommand(name); ok {
+			if err := cmd.Run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", name)
 		printUsage()
-		os.Exit(1)
+		exit(1)
 	}
+	writeManifest()
 }
 
 func printUsage() {
@@ -67,276 +145,4856 @@ Commands:
   gc        Calculate GC content
   kmer      Count k-mers
   align     Align two sequences
-  stats     Calculate sequence statistics
+  stats     Calculate sequence or read (FASTQ) statistics
   filter    Filter reads by quality
+  tree      Build or manipulate Newick trees
+  conservation  Per-column conservation of an alignment
+  pssm      Build a PSSM from an alignment and scan sequences
+  fold      Predict RNA secondary structure
+  protein-stats  Protein physico-chemical properties
+  backtranslate  Codon-optimized reverse translation of a protein
+  orf       Find open reading frames (optionally frameshift-tolerant)
+  telomere  Report telomeric / repeat-motif content in windows
+  repeats   Annotate duplicated regions by self-comparison
+  inverted  Find inverted repeats and hairpins
+  probes    Design hybridization probes unique to a target
+  faidx     Index a FASTA file (.fai) and extract regions
+  bisulfite In-silico bisulfite conversion and methylation contexts
+  chimera   Reference-based chimera detection for amplicon sequences
+  cluster   Cluster amplicons into OTUs or ASV-like groups per sample
+  diversity Alpha and beta diversity of an abundance table
+  rarefaction Rarefaction curves of richness by subsampled depth
+  ani       Average nucleotide identity between genomes
+  completeness Genome completeness from conserved marker k-mer panels
+  complexity  Per-window entropy and linguistic complexity tracks
+  cgr       Chaos game representation (FCGR) vectors and images
+  kmer-matrix Normalized k-mer feature matrices (CSV, NumPy)
+  sketch    Syncmer and randstrobe seed extraction
+  mappability Per-position k-mer uniqueness track (bedGraph/WIG)
+  mask      Mask bases covered by high-abundance k-mers
+  anchors   Shared k-mer anchors, collinear chains and PAF output
+  realign   Realign a read around indels and left-normalize its CIGAR
+  vcf-norm  Left-align, trim and split VCF variants
+  pileup    Pile up SAM/BAM reads, call variants or build a consensus
+  snp-density Windowed SNP density (per kb) from VCF or pileup calls, as bedGraph
+  coverage  Coverage uniformity and GC-bias QC report
+  liftover  Lift BED intervals to another assembly through chains
+  asm-stats Assembly QC: N50/NG50, gaps, misassemblies
+  gaps      N-gap statistics and splitting of scaffolds (AGP)
+  qc        Read QC: per-cycle quality, k-mer and adapter content
+  compare-stats Compare length/GC/quality distributions of two sets
+  windows   Windowed GC, skew, entropy and complexity tracks
+  validate  Check FASTA/FASTQ files for malformed records
+  subset    Filter, sort, group and combine FASTA sequence sets
+  readgroup Read group metadata from read names; tag SAM @RG
+  run       Run a pipeline defined in a YAML file
+  batch     Run a pipeline over the samples of a sample sheet
+  import    Import sequences or reads from CSV/TSV
+  verify    Check output files against a manifest
+  anonymize Replace identifiers with keyed pseudonyms
+  audit     Check and export a server's audit log
+  tooldef   Write CWL or WDL descriptors of the commands
   version   Show version information
   help      Show this help message
 
 Use "bioflow <command> -h" for more information about a command.`)
+	if cmds := bioflow.Commands(); len(cmds) > 0 {
+		fmt.Println("\nPlugin commands:")
+		for _, cmd := range cmds {
+			fmt.Printf("  %-9s %s\n", cmd.Name, cmd.Description)
+		}
+	}
 }
 
-func infoCmd(args []string) {
-	fs := flag.NewFlagSet("info", flag.ExitOnError)
+func infoCmd(fs *flag.FlagSet) func() {
 	file := fs.String("file", "", "FASTA file to analyze")
 	seq := fs.String("seq", "", "Sequence string to analyze")
-	fs.Parse(args)
-
-	if *file == "" && *seq == "" {
-		fmt.Fprintln(os.Stderr, "Error: Either -file or -seq is required")
-		fs.Usage()
-		os.Exit(1)
-	}
+	return func() {
+		if *file == "" && *seq == "" {
+			fmt.Fprintln(os.Stderr, "Error: Either -file or -seq is required")
+			fs.Usage()
+			exit(1)
+		}
 
-	var sequences []*bioflow.Sequence
-	var err error
+		var sequences []*bioflow.Sequence
+		var err error
 
-	if *file != "" {
-		sequences, err = bioflow.ReadFASTA(*file)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
-			os.Exit(1)
-		}
-	} else {
-		s, err := bioflow.NewSequence(*seq)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating sequence: %v\n", err)
-			os.Exit(1)
+		if *file != "" {
+			sequences, err = bioflow.ReadFASTA(*file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				exit(1)
+			}
+		} else {
+			s, err := bioflow.NewSequence(*seq)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating sequence: %v\n", err)
+				exit(1)
+			}
+			sequences = []*bioflow.Sequence{s}
 		}
-		sequences = []*bioflow.Sequence{s}
-	}
 
-	for i, s := range sequences {
-		stats := bioflow.SequenceStats(s)
-		fmt.Printf("Sequence %d:\n", i+1)
-		if s.ID != "" {
-			fmt.Printf("  ID: %s\n", s.ID)
+		for i, s := range sequences {
+			stats := bioflow.SequenceStats(s)
+			fmt.Printf("Sequence %d:\n", i+1)
+			if s.ID != "" {
+				fmt.Printf("  ID: %s\n", s.ID)
+			}
+			fmt.Printf("  Length: %d bp\n", stats.Length)
+			fmt.Printf("  GC Content: %.2f%%\n", stats.GCContent*100)
+			fmt.Printf("  AT Content: %.2f%%\n", stats.ATContent*100)
+			fmt.Printf("  Base Counts: A=%d, C=%d, G=%d, T=%d, N=%d\n",
+				stats.ACount, stats.CCount, stats.GCount, stats.TCount, stats.NCount)
+			fmt.Println()
 		}
-		fmt.Printf("  Length: %d bp\n", stats.Length)
-		fmt.Printf("  GC Content: %.2f%%\n", stats.GCContent*100)
-		fmt.Printf("  AT Content: %.2f%%\n", stats.ATContent*100)
-		fmt.Printf("  Base Counts: A=%d, C=%d, G=%d, T=%d, N=%d\n",
-			stats.ACount, stats.CCount, stats.GCount, stats.TCount, stats.NCount)
-		fmt.Println()
 	}
 }
 
-func gcCmd(args []string) {
-	fs := flag.NewFlagSet("gc", flag.ExitOnError)
+func gcCmd(fs *flag.FlagSet) func() {
 	file := fs.String("file", "", "FASTA file to analyze")
 	seq := fs.String("seq", "", "Sequence string to analyze")
-	fs.Parse(args)
-
-	if *file == "" && *seq == "" {
-		fmt.Fprintln(os.Stderr, "Error: Either -file or -seq is required")
-		fs.Usage()
-		os.Exit(1)
-	}
+	window := fs.Int("window", 0, "Write a GC profile over windows of this size instead of totals")
+	step := fs.Int("step", 0, "Window step for -window (default: half the window)")
+	format := fs.String("format", "bedgraph", "Profile format: bedgraph, wig, variablestep, tsv or json")
+	return func() {
+		if *file == "" && *seq == "" {
+			fmt.Fprintln(os.Stderr, "Error: Either -file or -seq is required")
+			fs.Usage()
+			exit(1)
+		}
 
-	var sequences []*bioflow.Sequence
-	var err error
+		var sequences []*bioflow.Sequence
+		var err error
 
-	if *file != "" {
-		sequences, err = bioflow.ReadFASTA(*file)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
-			os.Exit(1)
+		if *file != "" {
+			sequences, err = bioflow.ReadFASTA(*file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				exit(1)
+			}
+		} else {
+			s, err := bioflow.NewSequence(*seq)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating sequence: %v\n", err)
+				exit(1)
+			}
+			sequences = []*bioflow.Sequence{s}
 		}
-	} else {
-		s, err := bioflow.NewSequence(*seq)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating sequence: %v\n", err)
-			os.Exit(1)
+
+		if *window > 0 {
+			trackFormat, err := bioflow.ParseTrackFormat(*format)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+			tracks := make([]*bioflow.Track, 0, len(sequences))
+			for _, s := range sequences {
+				if s.ID == "" {
+					s.ID = "sequence"
+				}
+				t, err := bioflow.GCProfile(s, *window, *step)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error profiling %s: %v\n", s.ID, err)
+					exit(1)
+				}
+				tracks = append(tracks, t)
+			}
+			if err := bioflow.WriteTracks(os.Stdout, trackFormat, tracks...); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+				exit(1)
+			}
+			return
 		}
-		sequences = []*bioflow.Sequence{s}
-	}
 
-	for _, s := range sequences {
-		id := s.ID
-		if id == "" {
-			id = "sequence"
+		for _, s := range sequences {
+			id := s.ID
+			if id == "" {
+				id = "sequence"
+			}
+			fmt.Printf("%s: %.4f (%.2f%%)\n", id, s.GCContent(), s.GCContent()*100)
 		}
-		fmt.Printf("%s: %.4f (%.2f%%)\n", id, s.GCContent(), s.GCContent()*100)
 	}
 }
 
-func kmerCmd(args []string) {
-	fs := flag.NewFlagSet("kmer", flag.ExitOnError)
+func kmerCmd(fs *flag.FlagSet) func() {
 	file := fs.String("file", "", "FASTA file to analyze")
 	seq := fs.String("seq", "", "Sequence string to analyze")
 	k := fs.Int("k", 21, "K-mer size")
 	top := fs.Int("top", 10, "Number of top k-mers to show")
-	fs.Parse(args)
+	seedPattern := fs.String("seed", "", "Spaced-seed pattern (e.g. 1101101); overrides -k")
+	countDistinct := fs.Bool("count-distinct", false, "Estimate distinct canonical k-mers with HyperLogLog (streams -file, '-' for stdin)")
+	precision := fs.Int("precision", bioflow.DefaultHLLPrecision, "HyperLogLog precision (4-18)")
+	dump := fs.String("dump", "", "Write all counts to this file")
+	dumpFormat := fs.String("dump-format", "kmc", "Dump format: jellyfish, jellyfish-column or kmc")
+	skipMasked := fs.Bool("skip-masked", false, "Skip k-mers overlapping soft-masked (lower-case) bases")
+	strandName := fs.String("strand", "forward", "Strands to count, without merging reverse complements: forward, reverse or both")
+	packed := fs.Bool("packed", false, "Count k-mers as 2-bit packed codes, using less memory (k <= 32)")
+	addOutputFlags(fs)
+	return func() {
+		bioflow.SetOutputDefaults(outputOptions)
+		strand := parseStrand(*strandName)
 
-	if *file == "" && *seq == "" {
-		fmt.Fprintln(os.Stderr, "Error: Either -file or -seq is required")
-		fs.Usage()
-		os.Exit(1)
-	}
-
-	var s *bioflow.Sequence
-	var err error
+		if *file == "" && *seq == "" {
+			fmt.Fprintln(os.Stderr, "Error: Either -file or -seq is required")
+			fs.Usage()
+			exit(1)
+		}
 
-	if *file != "" {
-		sequences, err := bioflow.ReadFASTA(*file)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
-			os.Exit(1)
+		if *countDistinct {
+			var in io.Reader = strings.NewReader(*seq)
+			if *file == "-" {
+				in = os.Stdin
+			} else if *file != "" {
+				f, err := bioflow.OpenInput(*file)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+					exit(1)
+				}
+				defer f.Close()
+				in = f
+			}
+			est, err := bioflow.CountDistinctKMers(in, *k, *precision, true)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error counting k-mers: %v\n", err)
+				exit(1)
+			}
+			fmt.Printf("Distinct k-mer estimate (k=%d, HyperLogLog p=%d)\n", *k, *precision)
+			fmt.Printf("Total k-mers: %d\n", est.Total)
+			fmt.Printf("Distinct k-mers: %.0f\n", est.Distinct)
+			fmt.Printf("95%% interval: %.0f - %.0f (standard error %.2f%%)\n", est.Lower, est.Upper, 100*est.StandardError)
+			return
 		}
-		if len(sequences) == 0 {
-			fmt.Fprintln(os.Stderr, "No sequences found in file")
-			os.Exit(1)
+
+		var s *bioflow.Sequence
+		var err error
+
+		policy := bioflow.StrictPolicy()
+		if *skipMasked {
+			policy = maskedPolicy()
 		}
-		s = sequences[0]
-	} else {
-		s, err = bioflow.NewSequence(*seq)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating sequence: %v\n", err)
-			os.Exit(1)
+		if *file != "" {
+			sequences, err := bioflow.ReadFASTAWithPolicy(*file, policy)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				exit(1)
+			}
+			if len(sequences) == 0 {
+				fmt.Fprintln(os.Stderr, "No sequences found in file")
+				exit(1)
+			}
+			s = sequences[0]
+		} else {
+			s, err = bioflow.NewSequenceWithPolicy(*seq, "", "", policy)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating sequence: %v\n", err)
+				exit(1)
+			}
 		}
-	}
 
-	counter, err := bioflow.CountKMers(s, *k)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error counting k-mers: %v\n", err)
-		os.Exit(1)
-	}
+		var counter *bioflow.KMerCounter
+		var packedCounter *bioflow.PackedKMerCounter
+		if *seedPattern != "" && strand != bioflow.ForwardStrand {
+			fmt.Fprintln(os.Stderr, "Error: -strand needs contiguous k-mers, not -seed")
+			exit(1)
+		}
+		if *seedPattern != "" && *packed {
+			fmt.Fprintln(os.Stderr, "Error: -packed needs contiguous k-mers, not -seed")
+			exit(1)
+		}
+		strandNote := ""
+		switch strand {
+		case bioflow.ReverseStrand:
+			strandNote = ", reverse strand"
+		case bioflow.BothStrands:
+			strandNote = ", both strands"
+		}
+		if *packed {
+			strandNote += ", packed"
+		}
+		if *seedPattern != "" {
+			seed, err := bioflow.ParseSpacedSeed(*seedPattern)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+			counter, err = bioflow.CountSpacedKMers(s, seed)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error counting k-mers: %v\n", err)
+				exit(1)
+			}
+			fmt.Printf("K-mer Analysis (seed=%s, weight=%d, span=%d)\n", seed, seed.Weight, seed.Span)
+		} else if *packed {
+			packedCounter, err = bioflow.CountPackedKMers(s, *k, strand, *skipMasked)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error counting k-mers: %v\n", err)
+				exit(1)
+			}
+			if *skipMasked {
+				fmt.Printf("K-mer Analysis (k=%d%s, soft-masked bases skipped)\n", *k, strandNote)
+				fmt.Printf("Soft-masked bases: %d\n", bioflow.MaskedBases(bioflow.SoftMaskedRuns(s)))
+				fmt.Printf("Hard-masked bases: %d\n", bioflow.MaskedBases(bioflow.HardMaskedRuns(s)))
+			} else {
+				fmt.Printf("K-mer Analysis (k=%d%s)\n", *k, strandNote)
+			}
+		} else if *skipMasked {
+			counter, err = bioflow.CountStrandedKMers(s, *k, strand, true)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error counting k-mers: %v\n", err)
+				exit(1)
+			}
+			fmt.Printf("K-mer Analysis (k=%d%s, soft-masked bases skipped)\n", *k, strandNote)
+			fmt.Printf("Soft-masked bases: %d\n", bioflow.MaskedBases(bioflow.SoftMaskedRuns(s)))
+			fmt.Printf("Hard-masked bases: %d\n", bioflow.MaskedBases(bioflow.HardMaskedRuns(s)))
+		} else {
+			counter, err = bioflow.CountStrandedKMers(s, *k, strand, false)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error counting k-mers: %v\n", err)
+				exit(1)
+			}
+			fmt.Printf("K-mer Analysis (k=%d%s)\n", *k, strandNote)
+		}
+		// Packed counts are only unpacked to strings to dump them.
+		var counts interface {
+			UniqueCount() int
+			MostFrequent(n int) ([]bioflow.KMerCount, error)
+		} = counter
+		total := 0
+		if packedCounter != nil {
+			counts, total = packedCounter, packedCounter.Total
+		} else {
+			total = counter.Total
+		}
+		fmt.Printf("Unique k-mers: %d\n", counts.UniqueCount())
+		fmt.Printf("Total k-mers: %d\n", total)
+		fmt.Println()
 
-	fmt.Printf("K-mer Analysis (k=%d)\n", *k)
-	fmt.Printf("Unique k-mers: %d\n", counter.UniqueCount())
-	fmt.Printf("Total k-mers: %d\n", counter.Total)
-	fmt.Println()
+		if *dump != "" {
+			format, err := bioflow.ParseKMerDumpFormat(*dumpFormat)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+			if packedCounter != nil {
+				counter = packedCounter.Unpack()
+			}
+			if err := bioflow.SaveKMerDump(*dump, counter, format); err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving counts: %v\n", err)
+				exit(1)
+			}
+			fmt.Printf("Counts written to %s (%s)\n\n", *dump, format)
+		}
 
-	topKMers, err := counter.MostFrequent(*top)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error getting top k-mers: %v\n", err)
-		os.Exit(1)
-	}
+		topKMers, err := counts.MostFrequent(*top)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting top k-mers: %v\n", err)
+			exit(1)
+		}
 
-	fmt.Printf("Top %d k-mers:\n", len(topKMers))
-	for i, kc := range topKMers {
-		fmt.Printf("%2d. %s: %d\n", i+1, kc.KMer, kc.Count)
+		fmt.Printf("Top %d k-mers:\n", len(topKMers))
+		for i, kc := range topKMers {
+			fmt.Printf("%2d. %s: %d\n", i+1, kc.KMer, kc.Count)
+		}
 	}
 }
 
-func alignCmd(args []string) {
-	fs := flag.NewFlagSet("align", flag.ExitOnError)
+func alignCmd(fs *flag.FlagSet) func() {
 	seq1 := fs.String("seq1", "", "First sequence")
 	seq2 := fs.String("seq2", "", "Second sequence")
 	global := fs.Bool("global", false, "Use global alignment (Needleman-Wunsch)")
-	fs.Parse(args)
+	dbSize := fs.Int64("db-size", 0, "Database length for E-values (default: length of seq2)")
+	dbSeqs := fs.Int("db-seqs", 1, "Number of database sequences for E-values")
+	ambiguity := fs.String("ambiguity", "mismatch", "Scoring of N: mismatch, neutral (0) or iupac")
+	scoreOnly := fs.Bool("score-only", false, "Report local score, coordinates and score profile without traceback")
+	band := fs.Int("band", 1, "Positions of seq1 per score profile entry (with -score-only)")
+	asJSON := fs.Bool("json", false, "Output the alignment as JSON")
+	anchorK := fs.Int("anchor-k", 0, "Global alignment guided by shared k-mers of this length (0 = off)")
+	matrix := fs.String("matrix", "", "Align proteins with a substitution matrix: "+strings.Join(bioflow.SubstitutionMatrixNames(), ", ")+" or an NCBI-format file")
+	return func() {
+		if *seq1 == "" || *seq2 == "" {
+			fmt.Fprintln(os.Stderr, "Error: Both -seq1 and -seq2 are required")
+			fs.Usage()
+			exit(1)
+		}
+		if *matrix != "" {
+			if *scoreOnly || *anchorK > 0 {
+				fmt.Fprintln(os.Stderr, "Error: -matrix cannot be combined with -score-only or -anchor-k")
+				exit(1)
+			}
+			alignProteinsCmd(*seq1, *seq2, *matrix, *global, *asJSON, *dbSize, *dbSeqs)
+			return
+		}
+
+		s1, err := bioflow.NewSequence(*seq1)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating sequence 1: %v\n", err)
+			exit(1)
+		}
+
+		s2, err := bioflow.NewSequence(*seq2)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating sequence 2: %v\n", err)
+			exit(1)
+		}
+
+		mode, err := bioflow.ParseAmbiguityMode(*ambiguity)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		scoring := bioflow.DefaultScoring().WithAmbiguity(mode)
+
+		if *scoreOnly {
+			profile, err := bioflow.LocalScoreProfile(s1, s2, scoring, bioflow.ScoreProfileOptions{
+				BandWidth:   *band,
+				LocateStart: true,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error scoring sequences: %v\n", err)
+				exit(1)
+			}
+			fmt.Printf("Score: %d\n", profile.Score)
+			fmt.Printf("Seq1: %d-%d\n", profile.Start1+1, profile.End1)
+			fmt.Printf("Seq2: %d-%d\n", profile.Start2+1, profile.End2)
+			fmt.Println("Profile:")
+			for b, v := range profile.Profile {
+				fmt.Printf("  %d\t%d\n", b*profile.BandWidth+1, v)
+			}
+			return
+		}
+
+		var alignment *bioflow.Alignment
+		if *anchorK > 0 {
+			*global = true
+			alignment, err = bioflow.AlignAnchored(s1, s2, *anchorK, scoring)
+		} else if *global {
+			alignment, err = bioflow.AlignGlobalWithScoring(s1, s2, scoring)
+		} else {
+			alignment, err = bioflow.AlignWithScoring(s1, s2, scoring)
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error aligning sequences: %v\n", err)
+			exit(1)
+		}
+
+		summary := alignment.Summary()
+		if !*global {
+			stats, err := bioflow.AlignmentStatistics(scoring)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error computing alignment statistics: %v\n", err)
+				exit(1)
+			}
+			space := bioflow.SearchSpace{
+				QueryLength:       s1.Len(),
+				DatabaseLength:    *dbSize,
+				DatabaseSequences: *dbSeqs,
+			}
+			if space.DatabaseLength <= 0 {
+				space.DatabaseLength = int64(s2.Len())
+				space.DatabaseSequences = 1
+			}
+			sig := alignment.Significance(stats, space)
+			summary.BitScore = sig.BitScore
+			summary.EValue = sig.EValue
+		}
 
-	if *seq1 == "" || *seq2 == "" {
-		fmt.Fprintln(os.Stderr, "Error: Both -seq1 and -seq2 are required")
-		fs.Usage()
-		os.Exit(1)
+		printAlignment(alignment, summary, *global, *asJSON)
 	}
+}
 
-	s1, err := bioflow.NewSequence(*seq1)
+// alignProteinsCmd aligns two proteins with a built-in or file
+// substitution matrix and BLAST's default gap costs.
+func alignProteinsCmd(seq1, seq2, matrix string, global, asJSON bool, dbSize int64, dbSeqs int) {
+	p1, err := bioflow.NewProtein(seq1)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating sequence 1: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error creating protein 1: %v\n", err)
+		exit(1)
 	}
-
-	s2, err := bioflow.NewSequence(*seq2)
+	p2, err := bioflow.NewProtein(seq2)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating sequence 2: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error creating protein 2: %v\n", err)
+		exit(1)
 	}
-
-	var alignment *bioflow.Alignment
-	if *global {
-		alignment, err = bioflow.AlignGlobal(s1, s2)
-	} else {
-		alignment, err = bioflow.Align(s1, s2)
+	m, err := bioflow.SubstitutionMatrixByName(matrix)
+	if err != nil {
+		if m, err = bioflow.LoadSubstitutionMatrix(matrix); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
+	defaults := bioflow.DefaultProteinScoring()
+	scoring, err := bioflow.NewSubstitutionScoring(m, defaults.GapOpenPenalty, defaults.GapExtendPenalty)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
 	}
 
+	alignment, err := bioflow.AlignProteins(p1, p2, scoring, global)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error aligning sequences: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error aligning proteins: %v\n", err)
+		exit(1)
+	}
+	summary := alignment.Summary()
+	if !global {
+		stats, err := bioflow.AlignmentStatistics(scoring)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error computing alignment statistics: %v\n", err)
+			exit(1)
+		}
+		space := bioflow.SearchSpace{QueryLength: p1.Len(), DatabaseLength: dbSize, DatabaseSequences: dbSeqs}
+		if space.DatabaseLength <= 0 {
+			space.DatabaseLength = int64(p2.Len())
+			space.DatabaseSequences = 1
+		}
+		sig := alignment.Significance(stats, space)
+		summary.BitScore = sig.BitScore
+		summary.EValue = sig.EValue
+	}
+	printAlignment(alignment, summary, global, asJSON)
+}
+
+// printAlignment prints an alignment and its summary, as text or JSON.
+func printAlignment(alignment *bioflow.Alignment, summary bioflow.AlignmentSummary, global, asJSON bool) {
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(summary); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			exit(1)
+		}
+		return
 	}
 
 	fmt.Println(alignment.Format())
+	fmt.Printf("Seq1: %d-%d  Seq2: %d-%d  (%s, length %d, %d gap opens)\n",
+		summary.Start1, summary.End1, summary.Start2, summary.End2,
+		summary.AlignmentType, summary.AlignedLength, summary.GapOpens)
+	if !global {
+		fmt.Printf("Bit score: %.1f\n", summary.BitScore)
+		fmt.Printf("E-value:   %.2g\n", summary.EValue)
+	}
 }
 
-func statsCmd(args []string) {
-	fs := flag.NewFlagSet("stats", flag.ExitOnError)
-	file := fs.String("file", "", "FASTA file to analyze")
-	fs.Parse(args)
+func statsCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "FASTA or FASTQ file to analyze")
+	format := fs.String("format", "auto", "Input format: auto, fasta, fastq (auto uses the extension)")
+	htmlOut := fs.String("html", "", "Also write a self-contained HTML report to this file")
+	joint := fs.Bool("joint", false, "Report length/GC and quality/length correlations")
+	resamples := fs.Int("bootstrap", 0, "Bootstrap resamples for confidence intervals of the means (0 disables)")
+	level := fs.Float64("level", bioflow.DefaultBootstrapOptions().Level, "Confidence level for bootstrap intervals")
+	seed := fs.Int64("seed", 1, "Random seed for bootstrap resampling")
+	stream := fs.Bool("stream", false, "Summarize in constant memory (exact N50, approximate GC/quality quantiles)")
+	jsonOut := fs.Bool("json", false, "With -stream, write the summary as JSON")
+	policyName := fs.String("policy", "strict", "Base validation policy: strict, iupac, permissive")
+	addOutputFlags(fs)
+	return func() {
+		boot := bioflow.BootstrapOptions{Resamples: *resamples, Level: *level, Seed: *seed}
+
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+
+		fastq, err := isFASTQ(*file, *format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		policy, err := bioflow.ParsePolicy(*policyName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		if *stream {
+			streamStats(*file, fastq, *jsonOut, policy)
+			return
+		}
+		if fastq {
+			readStats(*file, *htmlOut, *joint, boot, policy)
+			return
+		}
+
+		sequences, err := bioflow.ReadFASTAWithPolicy(*file, policy)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
 
-	if *file == "" {
-		fmt.Fprintln(os.Stderr, "Error: -file is required")
-		fs.Usage()
-		os.Exit(1)
+		if len(sequences) == 0 {
+			fmt.Fprintln(os.Stderr, "No sequences found in file")
+			exit(1)
+		}
+
+		stats, err := bioflow.SequenceSetStats(sequences)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error calculating statistics: %v\n", err)
+			exit(1)
+		}
+
+		fmt.Println("Sequence Set Statistics")
+		fmt.Println(strings.Repeat("-", 40))
+		fmt.Printf("Number of sequences: %d\n", stats.Count)
+		fmt.Printf("Total bases: %d\n", stats.TotalBases)
+		fmt.Printf("Length range: %d - %d bp\n", stats.MinLength, stats.MaxLength)
+		fmt.Printf("Mean length: %.1f bp\n", stats.MeanLength)
+		fmt.Printf("Median length: %d bp\n", stats.MedianLength)
+		fmt.Printf("N50: %d bp\n", stats.N50)
+		fmt.Printf("Mean GC content: %.2f%%\n", stats.MeanGCContent*100)
+		fmt.Printf("Total ambiguous bases: %d\n", stats.TotalAmbiguous)
+		if boot.Resamples > 0 {
+			if err := stats.Bootstrap(sequences, boot); err != nil {
+				fmt.Fprintf(os.Stderr, "Error bootstrapping statistics: %v\n", err)
+				exit(1)
+			}
+			printIntervals(stats.Intervals)
+		}
+
+		var jointStats *bioflow.JointStats
+		if *joint {
+			jointStats, err = bioflow.SequenceJointStats(sequences, bioflow.DefaultJointOptions())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error calculating joint statistics: %v\n", err)
+				exit(1)
+			}
+			printJointStats(jointStats)
+		}
+		if *htmlOut != "" {
+			r := bioflow.NewHTMLReport("Sequence statistics: " + filepath.Base(*file))
+			r.Add(bioflow.SequenceSetSection(stats))
+			if edges, err := bioflow.UniformEdges(0, 1, 20); err == nil {
+				if h, err := bioflow.GCContentHistogram(sequences, edges); err == nil {
+					r.Add(bioflow.HistogramSection("GC content", "GC fraction", h))
+				}
+			}
+			if edges, err := bioflow.UniformEdges(float64(stats.MinLength), float64(stats.MaxLength+1), 20); err == nil {
+				if h, err := bioflow.SequenceLengthHistogram(sequences, edges); err == nil {
+					r.Add(bioflow.HistogramSection("Sequence length", "Length (bp)", h))
+				}
+			}
+			if jointStats != nil {
+				r.Add(bioflow.JointSection(jointStats))
+			}
+			writeHTMLReport(*htmlOut, r)
+		}
 	}
+}
 
-	sequences, err := bioflow.ReadFASTA(*file)
+// readStats prints read set statistics for a FASTQ file, with joint
+// statistics and bootstrap intervals if requested, and optionally writes
+// them as an HTML report.
+func readStats(file, htmlOut string, joint bool, boot bioflow.BootstrapOptions, policy bioflow.Policy) {
+	reads, err := bioflow.ReadFASTQWithPolicy(file, policy)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
-		os.Exit(1)
+		exit(1)
 	}
 
-	if len(sequences) == 0 {
-		fmt.Fprintln(os.Stderr, "No sequences found in file")
-		os.Exit(1)
+	if len(reads) == 0 {
+		fmt.Fprintln(os.Stderr, "No reads found in file")
+		exit(1)
 	}
 
-	stats, err := bioflow.SequenceSetStats(sequences)
+	stats, err := bioflow.ReadStats(reads)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error calculating statistics: %v\n", err)
-		os.Exit(1)
+		exit(1)
 	}
 
-	fmt.Println("Sequence Set Statistics")
+	dist := stats.QualityDistribution
+	fmt.Println("Read Set Statistics")
 	fmt.Println(strings.Repeat("-", 40))
-	fmt.Printf("Number of sequences: %d\n", stats.Count)
+	fmt.Printf("Number of reads: %d\n", stats.Count)
 	fmt.Printf("Total bases: %d\n", stats.TotalBases)
 	fmt.Printf("Length range: %d - %d bp\n", stats.MinLength, stats.MaxLength)
 	fmt.Printf("Mean length: %.1f bp\n", stats.MeanLength)
-	fmt.Printf("Median length: %d bp\n", stats.MedianLength)
-	fmt.Printf("N50: %d bp\n", stats.N50)
-	fmt.Printf("Mean GC content: %.2f%%\n", stats.MeanGCContent*100)
-	fmt.Printf("Total ambiguous bases: %d\n", stats.TotalAmbiguous)
+	fmt.Printf("Mean quality: %.1f\n", stats.MeanQuality)
+	fmt.Printf("Median quality: %.1f\n", stats.MedianQuality)
+	fmt.Printf("High quality reads (Q30+): %d (%.1f%%)\n", stats.HighQualityCount, stats.HighQualityRatio()*100)
+	if boot.Resamples > 0 {
+		stats.Intervals, err = bioflow.BootstrapReads(reads, boot)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error bootstrapping statistics: %v\n", err)
+			exit(1)
+		}
+		printIntervals(stats.Intervals)
+	}
+	fmt.Println()
+	fmt.Println("Quality Distribution (mean read quality)")
+	fmt.Println(strings.Repeat("-", 40))
+	fmt.Printf("Poor (Q<10):       %d\n", dist.PoorCount)
+	fmt.Printf("Low (Q10-20):      %d\n", dist.LowCount)
+	fmt.Printf("Medium (Q20-30):   %d\n", dist.MediumCount)
+	fmt.Printf("High (Q30-40):     %d\n", dist.HighCount)
+	fmt.Printf("Excellent (Q40+):  %d\n", dist.ExcellentCount)
+	fmt.Printf("Acceptable (Q20+): %.1f%%\n", dist.AcceptableRatio()*100)
+	fmt.Println()
+	fmt.Println("Per-position Quality")
+	fmt.Println(strings.Repeat("-", 40))
+	if err := bioflow.WritePositionQualityTSV(os.Stdout, stats.PositionQuality); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		exit(1)
+	}
+
+	var jointStats *bioflow.JointStats
+	if joint {
+		jointStats, err = bioflow.ReadJointStats(reads, bioflow.DefaultJointOptions())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error calculating joint statistics: %v\n", err)
+			exit(1)
+		}
+		printJointStats(jointStats)
+	}
+	if htmlOut != "" {
+		r := bioflow.NewHTMLReport("Read statistics: " + filepath.Base(file))
+		r.Add(bioflow.ReadSetSections(stats)...)
+		if jointStats != nil {
+			r.Add(bioflow.JointSection(jointStats))
+		}
+		writeHTMLReport(htmlOut, r)
+	}
+}
+
+// streamStats prints a constant-memory summary of a FASTA or FASTQ file.
+func streamStats(file string, fastq, jsonOut bool, policy bioflow.Policy) {
+	sum, err := bioflow.StreamFileStats(file, fastq, bioflow.DefaultCompression, policy)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error calculating statistics: %v\n", err)
+		exit(1)
+	}
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(sum)
+		return
+	}
+
+	fmt.Println("Streaming Statistics")
+	fmt.Println(strings.Repeat("-", 40))
+	fmt.Printf("Number of sequences: %d\n", sum.Count)
+	fmt.Printf("Total bases: %d\n", sum.TotalBases)
+	fmt.Printf("Length range: %d - %d bp\n", sum.MinLength, sum.MaxLength)
+	fmt.Printf("Mean length: %.1f bp\n", sum.MeanLength)
+	fmt.Printf("N50: %d bp (L50: %d)\n", sum.N50, sum.L50)
+	fmt.Printf("N90: %d bp\n", sum.N90)
+	fmt.Println()
+	fmt.Printf("%-14s %10s %10s %10s %10s %10s\n", "quantiles", "p5", "q1", "median", "q3", "p95")
+	l := sum.Length
+	fmt.Printf("%-14s %10.0f %10.0f %10.0f %10.0f %10.0f\n", "length", l.P5, l.Q1, l.Median, l.Q3, l.P95)
+	g := sum.GCContent
+	fmt.Printf("%-14s %10.4f %10.4f %10.4f %10.4f %10.4f\n", "gc_content~", g.P5, g.Q1, g.Median, g.Q3, g.P95)
+	if q := sum.Quality; q != nil {
+		fmt.Printf("%-14s %10.2f %10.2f %10.2f %10.2f %10.2f\n", "mean_quality~", q.P5, q.Q1, q.Median, q.Q3, q.P95)
+	}
+	fmt.Println("(~ approximate)")
+}
+
+// printIntervals prints bootstrap confidence intervals.
+func printIntervals(ci *bioflow.Intervals) {
+	fmt.Printf("Bootstrap %g%% CI (%d resamples):\n", 100*ci.Level, ci.Resamples)
+	fmt.Printf("  Mean length: %.1f - %.1f bp\n", ci.MeanLength.Lower, ci.MeanLength.Upper)
+	fmt.Printf("  Mean GC content: %.2f%% - %.2f%%\n", 100*ci.MeanGCContent.Lower, 100*ci.MeanGCContent.Upper)
+	if q := ci.MeanQuality; q != nil {
+		fmt.Printf("  Mean quality: %.1f - %.1f\n", q.Lower, q.Upper)
+	}
+}
+
+// printJointStats prints correlations and flagged artifacts.
+func printJointStats(j *bioflow.JointStats) {
+	fmt.Println()
+	fmt.Println("Joint Statistics")
+	fmt.Println(strings.Repeat("-", 40))
+	for _, c := range []*bioflow.Correlation{j.LengthGC, j.QualityLength} {
+		if c != nil {
+			fmt.Printf("%s vs %s: Pearson %.3f, Spearman %.3f (%d pairs)\n", c.X, c.Y, c.Pearson, c.Spearman, c.Pairs)
+		}
+	}
+	for _, a := range j.Artifacts {
+		fmt.Printf("Warning: %s\n", a.Message)
+	}
 }
 
-func filterCmd(args []string) {
-	fs := flag.NewFlagSet("filter", flag.ExitOnError)
+func filterCmd(fs *flag.FlagSet) func() {
 	file := fs.String("file", "", "FASTQ file to filter")
 	minQuality := fs.Int("min-quality", 20, "Minimum average quality")
 	minLength := fs.Int("min-length", 50, "Minimum sequence length")
 	strict := fs.Bool("strict", false, "Use strict filtering")
-	fs.Parse(args)
+	checkpoint := fs.String("checkpoint", "", "Checkpoint file for resumable streaming (resumes if it exists)")
+	checkpointEvery := fs.Int("checkpoint-every", bioflow.DefaultCheckpointEvery, "Records between checkpoint saves")
+	htmlOut := fs.String("html", "", "Write a self-contained HTML report to this file")
+	progress := fs.Int("progress", 0, "Log progress every N reads (0 disables)")
+	interval := fs.Duration("progress-interval", 0, "Log progress at this interval (0 disables)")
+	sampleRejected := fs.Int("sample-rejected", 0, "Log the first N rejected reads")
+	primersFile := fs.String("primers", "", "FASTA of amplicon primers to trim before filtering")
+	primerWindow := fs.Int("primer-window", 0, "Bases allowed before a 5' primer or after a 3' primer, trimmed with it")
+	requirePrimers := fs.String("require-primers", "none", "Discard reads missing primers: none, 5prime or both")
+	adapterSpec := fs.String("adapters", "", "Trim 3' adapters before quality trimming: "+strings.Join(bioflow.AdapterSetNames(), ", ")+", default (truseq and nextera) or sequences, comma-separated")
+	adapterErrorRate := fs.Float64("adapter-error-rate", bioflow.DefaultAdapterErrorRate, "Mismatches allowed per base of adapter overlap")
+	adapterMinOverlap := fs.Int("adapter-min-overlap", bioflow.DefaultAdapterMinOverlap, "Shortest adapter prefix trimmed at the end of a read")
+	output := fs.String("o", "", "Write the reads that pass, trimmed, as FASTQ to this file")
+	fs.StringVar(output, "out", "", "Same as -o")
+	failedOutput := fs.String("out-failed", "", "Write the reads that fail, untrimmed, as FASTQ to this file")
+	addOutputFlags(fs)
+	return func() {
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+		if *checkpoint != "" && (*output != "" || *failedOutput != "") {
+			if _, err := os.Stat(*checkpoint); err == nil {
+				fmt.Fprintln(os.Stderr, "Error: -o and -out-failed cannot resume from a checkpoint; remove it to start over")
+				exit(1)
+			}
+		}
+		adapters, err := bioflow.ParseAdapters(*adapterSpec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+
+		var trimmer *bioflow.PrimerTrimmer
+		if *primersFile != "" {
+			if *checkpoint != "" {
+				fmt.Fprintln(os.Stderr, "Error: -primers cannot be combined with -checkpoint")
+				exit(1)
+			}
+			primers, err := bioflow.ReadPrimers(*primersFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading primers: %v\n", err)
+				exit(1)
+			}
+			requirement, err := bioflow.ParsePrimerRequirement(*requirePrimers)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+			trimmer, err = bioflow.NewPrimerTrimmer(primers, bioflow.PrimerOptions{Window: *primerWindow, Require: requirement})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+		}
+
+		var filter *bioflow.Filter
+		if *strict {
+			filter = bioflow.StrictFilter()
+		} else {
+			filter = bioflow.DefaultFilter()
+			filter.MinQuality = *minQuality
+			filter.MinLength = *minLength
+		}
+		filter.Adapters = adapters
+		filter.AdapterErrorRate = *adapterErrorRate
+		filter.AdapterMinOverlap = *adapterMinOverlap
+
+		pipeline := bioflow.NewPipeline(filter)
+		pipeline.SetMonitor(progressMonitor(*progress, *interval, *sampleRejected, false))
+
+		// passed and failed write the reads kept and rejected, if asked for.
+		var passed, failed *bioflow.FASTQWriter
+		var passedOut, failedOut *bioflow.OutputFile
+		if *output != "" {
+			passedOut = createOutput(*output)
+			defer closeOutput(passedOut)
+			passed = bioflow.NewFASTQWriter(passedOut)
+		}
+		if *failedOutput != "" {
+			failedOut = createOutput(*failedOutput)
+			defer closeOutput(failedOut)
+			failed = bioflow.NewFASTQWriter(failedOut)
+		}
+		flushReads := func() {
+			for _, o := range []struct {
+				w *bioflow.FASTQWriter
+				f *bioflow.OutputFile
+			}{{passed, passedOut}, {failed, failedOut}} {
+				if o.w == nil {
+					continue
+				}
+				if err := o.w.Flush(); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing reads: %v\n", err)
+					exit(1)
+				}
+				o.f.AddRecords(o.w.Count())
+			}
+		}
+
+		if *checkpoint != "" {
+			streamResult, err := pipeline.ProcessFASTQFile(*file, bioflow.StreamOptions{
+				CheckpointPath:  *checkpoint,
+				CheckpointEvery: *checkpointEvery,
+				OnRead: func(read *bioflow.Read, r *bioflow.TrimAndFilterResult) error {
+					switch {
+					case r.Passed && passed != nil:
+						return passed.Write(&bioflow.Read{Sequence: r.TrimmedSeq, Quality: r.TrimmedQual})
+					case !r.Passed && failed != nil:
+						return failed.Write(read)
+					}
+					return nil
+				},
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error filtering reads: %v\n", err)
+				exit(1)
+			}
+			flushReads()
+
+			fmt.Println("Filter Results")
+			fmt.Println(strings.Repeat("-", 40))
+			if streamResult.ResumedFrom > 0 {
+				fmt.Printf("Resumed from byte offset: %d\n", streamResult.ResumedFrom)
+			}
+			printFilterCounts(streamResult.TotalProcessed, streamResult.PassedCount, streamResult.AdapterTrimmed, len(adapters) > 0)
+			if *htmlOut != "" {
+				r := bioflow.NewHTMLReport("Filter report: " + filepath.Base(*file))
+				r.Add(bioflow.FilterSection(bioflow.StreamFilterSummary(streamResult)))
+				writeHTMLReport(*htmlOut, r)
+			}
+			return
+		}
+
+		reads, err := bioflow.ReadFASTQ(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+		if trimmer != nil {
+			if reads, err = trimmer.ProcessReads(reads); err != nil {
+				fmt.Fprintf(os.Stderr, "Error trimming primers: %v\n", err)
+				exit(1)
+			}
+		}
 
-	if *file == "" {
-		fmt.Fprintln(os.Stderr, "Error: -file is required")
-		fs.Usage()
-		os.Exit(1)
+		result, err := pipeline.ProcessReads(reads)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error filtering reads: %v\n", err)
+			exit(1)
+		}
+
+		fmt.Println("Filter Results")
+		fmt.Println(strings.Repeat("-", 40))
+		if trimmer != nil {
+			ps := trimmer.Stats()
+			fmt.Printf("Reads before primer trimming: %d\n", ps.Reads)
+			fmt.Printf("5' primer found: %d, 3' primer found: %d\n", ps.FivePrime, ps.ThreePrime)
+			fmt.Printf("Discarded for primers: %d\n", ps.Discarded)
+			for _, name := range ps.PrimerNames() {
+				fmt.Printf("  %s: %d\n", name, ps.ByPrimer[name])
+			}
+		}
+		printFilterCounts(result.TotalProcessed, result.PassedCount, result.AdapterTrimmed, len(adapters) > 0)
+		if passed != nil {
+			for i, seq := range result.PassedSequences {
+				if err := passed.Write(&bioflow.Read{Sequence: seq, Quality: result.PassedQualities[i]}); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing reads: %v\n", err)
+					exit(1)
+				}
+			}
+		}
+		if failed != nil {
+			for _, i := range result.FailedIndices {
+				if err := failed.Write(reads[i]); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing reads: %v\n", err)
+					exit(1)
+				}
+			}
+		}
+		flushReads()
+		if *htmlOut != "" {
+			r := bioflow.NewHTMLReport("Filter report: " + filepath.Base(*file))
+			r.Add(bioflow.FilterSection(bioflow.BatchFilterSummary(result)))
+			if stats, err := bioflow.ReadStats(reads); err == nil {
+				r.Add(bioflow.ReadSetSections(stats)...)
+			}
+			writeHTMLReport(*htmlOut, r)
+		}
 	}
+}
 
-	reads, err := bioflow.ReadFASTQ(*file)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
-		os.Exit(1)
+// printFilterCounts prints the read counts of a filter run, the same for
+// batch and streaming runs. The adapter count is printed when adapters
+// are trimmed.
+func printFilterCounts(total, passed, adapterTrimmed int, trimsAdapters bool) {
+	rate := 0.0
+	if total > 0 {
+		rate = float64(passed) / float64(total)
+	}
+	fmt.Printf("Total reads: %d\n", total)
+	if trimsAdapters {
+		fmt.Printf("Adapter trimmed: %d\n", adapterTrimmed)
+	}
+	fmt.Printf("Passed: %d (%.1f%%)\n", passed, rate*100)
+	fmt.Printf("Failed: %d (%.1f%%)\n", total-passed, (1-rate)*100)
+}
+
+func treeCmd(fs *flag.FlagSet) func() {
+	in := fs.String("in", "", "Newick tree file")
+	file := fs.String("file", "", "FASTA file to build a tree from")
+	method := fs.String("method", "nj", "Tree building method: nj or upgma")
+	k := fs.Int("k", 8, "K-mer size for distance estimation")
+	reroot := fs.String("reroot", "", "Re-root on the branch leading to this node")
+	prune := fs.String("prune", "", "Comma-separated leaf names to remove")
+	distances := fs.Bool("distances", false, "Print the patristic distance matrix")
+	return func() {
+		if *in == "" && *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: Either -in or -file is required")
+			fs.Usage()
+			exit(1)
+		}
+
+		var t *bioflow.Tree
+		var err error
+
+		if *in != "" {
+			t, err = bioflow.ReadNewick(*in)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading tree: %v\n", err)
+				exit(1)
+			}
+		} else {
+			sequences, err := bioflow.ReadFASTA(*file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				exit(1)
+			}
+			t, err = bioflow.BuildTree(sequences, *k, *method)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error building tree: %v\n", err)
+				exit(1)
+			}
+		}
+
+		if *prune != "" {
+			if err := t.Prune(strings.Split(*prune, ",")...); err != nil {
+				fmt.Fprintf(os.Stderr, "Error pruning tree: %v\n", err)
+				exit(1)
+			}
+		}
+
+		if *reroot != "" {
+			if err := t.RerootAt(*reroot); err != nil {
+				fmt.Fprintf(os.Stderr, "Error re-rooting tree: %v\n", err)
+				exit(1)
+			}
+		}
+
+		fmt.Println(t.Newick())
+
+		if *distances {
+			names := t.LeafNames()
+			matrix := t.DistanceMatrix()
+			fmt.Println()
+			fmt.Printf("%-12s", "")
+			for _, n := range names {
+				fmt.Printf(" %10s", n)
+			}
+			fmt.Println()
+			for i, row := range matrix {
+				fmt.Printf("%-12s", names[i])
+				for _, d := range row {
+					fmt.Printf(" %10.4f", d)
+				}
+				fmt.Println()
+			}
+		}
 	}
+}
+
+func conservationCmd(fs *flag.FlagSet) func() {
+	in := fs.String("in", "", "Alignment file (.aln, .sto, .phy, .afa)")
+	format := fs.String("format", "", "Alignment format (default: from file extension)")
+	asJSON := fs.Bool("json", false, "Output the logo matrix as JSON")
+	width := fs.Int("width", 60, "Columns per output block")
+	return func() {
+		if *in == "" {
+			fmt.Fprintln(os.Stderr, "Error: -in is required")
+			fs.Usage()
+			exit(1)
+		}
 
-	var filter *bioflow.Filter
-	if *strict {
-		filter = bioflow.StrictFilter()
-	} else {
-		filter = bioflow.DefaultFilter()
-		filter.MinQuality = *minQuality
-		filter.MinLength = *minLength
+		m, err := readMSA(*in, *format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading alignment: %v\n", err)
+			exit(1)
+		}
+
+		logo := bioflow.MSAProfile(m)
+
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(logo); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+				exit(1)
+			}
+			return
+		}
+
+		if *width <= 0 {
+			*width = m.Width()
+		}
+
+		nameWidth := len("consensus")
+		for _, n := range m.Names {
+			if len(n) > nameWidth {
+				nameWidth = len(n)
+			}
+		}
+
+		track := logo.ConservationTrack()
+		for start := 0; start < m.Width(); start += *width {
+			end := start + *width
+			if end > m.Width() {
+				end = m.Width()
+			}
+			for i, name := range m.Names {
+				fmt.Printf("%-*s  %s\n", nameWidth, name, m.Rows[i][start:end])
+			}
+			fmt.Printf("%-*s  %s\n", nameWidth, "consensus", logo.Consensus[start:end])
+			fmt.Printf("%-*s  %s\n\n", nameWidth, "", track[start:end])
+		}
+		fmt.Printf("Mean information content: %.3f bits\n", logo.MeanInformation())
 	}
+}
 
-	pipeline := bioflow.NewPipeline(filter)
-	result, err := pipeline.ProcessReads(reads)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error filtering reads: %v\n", err)
-		os.Exit(1)
+func pssmCmd(fs *flag.FlagSet) func() {
+	in := fs.String("in", "", "Alignment of motif sites (.aln, .sto, .phy, .afa)")
+	format := fs.String("format", "", "Alignment format (default: from file extension)")
+	file := fs.String("file", "", "FASTA file to scan")
+	threshold := fs.Float64("threshold", 0, "Minimum bit score")
+	relative := fs.Float64("relative", 0, "Threshold as a fraction of the score range (overrides -threshold)")
+	pseudocount := fs.Float64("pseudocount", bioflow.DefaultPseudocount, "Total pseudocount per column")
+	bgFromMSA := fs.Bool("background-from-msa", false, "Use the alignment's base composition as background")
+	strandName := fs.String("strand", "both", "Strands to scan: forward, reverse or both")
+	return func() {
+		strand := parseStrand(*strandName)
+
+		if *in == "" {
+			fmt.Fprintln(os.Stderr, "Error: -in is required")
+			fs.Usage()
+			exit(1)
+		}
+
+		m, err := readMSA(*in, *format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading alignment: %v\n", err)
+			exit(1)
+		}
+
+		pssm, err := bioflow.BuildPSSM(m, bioflow.PSSMOptions{
+			Pseudocount:             *pseudocount,
+			BackgroundFromAlignment: *bgFromMSA,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error building PSSM: %v\n", err)
+			exit(1)
+		}
+
+		fmt.Printf("%s\n", pssm)
+		fmt.Printf("Consensus: %s\n", pssm.Consensus())
+		fmt.Printf("Score range: %.2f to %.2f bits\n", pssm.MinScore(), pssm.MaxScore())
+
+		if *file == "" {
+			fmt.Println()
+			fmt.Printf("%-4s %8s %8s %8s %8s\n", "Pos", "A", "C", "G", "T")
+			for i, col := range pssm.Scores {
+				fmt.Printf("%-4d %8.3f %8.3f %8.3f %8.3f\n", i+1, col[0], col[1], col[2], col[3])
+			}
+			return
+		}
+
+		sequences, err := bioflow.ReadFASTA(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+
+		cutoff := *threshold
+		if *relative > 0 {
+			cutoff = pssm.RelativeThreshold(*relative)
+		}
+
+		hits := bioflow.ScanPSSM(pssm, sequences, cutoff, strand)
+		fmt.Printf("Threshold: %.2f bits\n\n", cutoff)
+		fmt.Printf("%-20s %8s %8s %6s %8s  %s\n", "Sequence", "Start", "End", "Strand", "Score", "Match")
+		for _, h := range hits {
+			fmt.Printf("%-20s %8d %8d %6c %8.2f  %s\n", h.SequenceID, h.Start+1, h.End, h.Strand, h.Score, h.Match)
+		}
+		fmt.Printf("\n%d hits\n", len(hits))
 	}
+}
 
-	fmt.Println("Filter Results")
-	fmt.Println(strings.Repeat("-", 40))
-	fmt.Printf("Total reads: %d\n", result.TotalProcessed)
-	fmt.Printf("Passed: %d (%.1f%%)\n", result.PassedCount, result.PassRate()*100)
-	fmt.Printf("Failed: %d (%.1f%%)\n", result.FailedCount, (1-result.PassRate())*100)
+func foldCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "FASTA file of RNA or DNA sequences")
+	seq := fs.String("seq", "", "Sequence string to fold")
+	constraint := fs.String("constraint", "", "Dot-bracket constraint ('(' ')' forced pair, 'x' unpaired)")
+	minLoop := fs.Int("min-loop", bioflow.DefaultMinLoop, "Minimum hairpin loop length")
+	model := fs.String("model", "pairs", "Scoring model: pairs or hbonds")
+	noWobble := fs.Bool("no-wobble", false, "Disallow G-U wobble pairs")
+	showPairs := fs.Bool("pairs", false, "List pairing coordinates")
+	return func() {
+		if *file == "" && *seq == "" {
+			fmt.Fprintln(os.Stderr, "Error: Either -file or -seq is required")
+			fs.Usage()
+			exit(1)
+		}
+
+		m, err := bioflow.ParseFoldModel(*model)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+
+		var sequences []*bioflow.Sequence
+		if *file != "" {
+			sequences, err = bioflow.ReadFASTA(*file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				exit(1)
+			}
+		} else {
+			newSeq := bioflow.NewSequence
+			if strings.ContainsAny(*seq, "Uu") {
+				newSeq = bioflow.NewRNASequence
+			}
+			s, err := newSeq(*seq)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating sequence: %v\n", err)
+				exit(1)
+			}
+			sequences = []*bioflow.Sequence{s}
+		}
+
+		opts := bioflow.FoldOptions{
+			MinLoop:    *minLoop,
+			NoWobble:   *noWobble,
+			Model:      m,
+			Constraint: *constraint,
+		}
+
+		for _, s := range sequences {
+			structure, err := bioflow.FoldRNA(s, opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error folding %s: %v\n", s.ID, err)
+				exit(1)
+			}
+			if s.ID != "" {
+				fmt.Printf(">%s\n", s.ID)
+			}
+			fmt.Println(structure.Sequence)
+			fmt.Printf("%s (%d pairs, score %d)\n", structure.DotBracket, len(structure.Pairs), structure.Score)
+			if *showPairs {
+				for _, p := range structure.Pairs {
+					fmt.Printf("  %d\t%d\t%c-%c\n", p.I+1, p.J+1, structure.Sequence[p.I], structure.Sequence[p.J])
+				}
+			}
+		}
+	}
+}
+
+func proteinStatsCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "FASTA file (protein, or DNA with -translate)")
+	seq := fs.String("seq", "", "Sequence string (protein, or DNA with -translate)")
+	translate := fs.Bool("translate", false, "Input is DNA: translate before computing properties")
+	frame := fs.Int("frame", 0, "Reading frame for -translate (0, 1 or 2)")
+	asJSON := fs.Bool("json", false, "Output as JSON")
+	return func() {
+		if *file == "" && *seq == "" {
+			fmt.Fprintln(os.Stderr, "Error: Either -file or -seq is required")
+			fs.Usage()
+			exit(1)
+		}
+
+		var proteins []*bioflow.Protein
+		var err error
+
+		if *translate {
+			var sequences []*bioflow.Sequence
+			if *file != "" {
+				sequences, err = bioflow.ReadFASTA(*file)
+			} else {
+				var s *bioflow.Sequence
+				s, err = bioflow.NewSequence(*seq)
+				sequences = []*bioflow.Sequence{s}
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading sequences: %v\n", err)
+				exit(1)
+			}
+			for _, s := range sequences {
+				p, err := bioflow.Translate(s, *frame, true)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error translating %s: %v\n", s.ID, err)
+					exit(1)
+				}
+				proteins = append(proteins, p)
+			}
+		} else if *file != "" {
+			proteins, err = bioflow.ReadProteinFASTA(*file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				exit(1)
+			}
+		} else {
+			p, err := bioflow.NewProtein(*seq)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating protein: %v\n", err)
+				exit(1)
+			}
+			proteins = []*bioflow.Protein{p}
+		}
+
+		if *asJSON {
+			results := make([]map[string]interface{}, 0, len(proteins))
+			for _, p := range proteins {
+				results = append(results, map[string]interface{}{
+					"id":         p.ID,
+					"protein":    p.Residues,
+					"properties": bioflow.ProteinStats(p),
+				})
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(results); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+				exit(1)
+			}
+			return
+		}
+
+		for i, p := range proteins {
+			props := bioflow.ProteinStats(p)
+			fmt.Printf("Protein %d:\n", i+1)
+			if p.ID != "" {
+				fmt.Printf("  ID: %s\n", p.ID)
+			}
+			stability := "unstable"
+			if props.Stable {
+				stability = "stable"
+			}
+			fmt.Printf("  Length: %d aa\n", props.Length)
+			fmt.Printf("  Molecular Weight: %.2f Da\n", props.MolecularWeight)
+			fmt.Printf("  Isoelectric Point: %.2f\n", props.IsoelectricPoint)
+			fmt.Printf("  Charge at pH 7: %.2f\n", props.ChargeAtPH7)
+			fmt.Printf("  GRAVY: %.3f\n", props.GRAVY)
+			fmt.Printf("  Instability Index: %.2f (%s)\n", props.InstabilityIndex, stability)
+			fmt.Printf("  Composition:")
+			for _, aa := range bioflow.AminoAcids {
+				if c := props.Composition[string(aa)]; c > 0 {
+					fmt.Printf(" %c=%d", aa, c)
+				}
+			}
+			fmt.Println()
+			fmt.Println()
+		}
+	}
+}
+
+func backTranslateCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "Protein FASTA file")
+	seq := fs.String("seq", "", "Protein sequence string")
+	organism := fs.String("organism", "ecoli", "Built-in codon usage table: "+strings.Join(bioflow.CodonTableNames(), ", "))
+	table := fs.String("table", "", "Codon usage table file (overrides -organism)")
+	strategy := fs.String("strategy", "frequent", "Codon choice: frequent or weighted")
+	seed := fs.Int64("seed", 1, "Random seed for -strategy weighted")
+	minFraction := fs.Float64("min-fraction", 0, "Skip codons used less than this fraction of the preferred codon")
+	avoid := fs.String("avoid", "", "Comma-separated restriction sites or enzymes to avoid (e.g. GAATTC,BamHI)")
+	return func() {
+		if *file == "" && *seq == "" {
+			fmt.Fprintln(os.Stderr, "Error: Either -file or -seq is required")
+			fs.Usage()
+			exit(1)
+		}
+
+		var usage bioflow.CodonUsage
+		var err error
+		if *table != "" {
+			usage, err = bioflow.ReadCodonUsage(*table)
+		} else {
+			usage, err = bioflow.CodonTable(*organism)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading codon table: %v\n", err)
+			exit(1)
+		}
+
+		strat, err := bioflow.ParseCodonStrategy(*strategy)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+
+		var proteins []*bioflow.Protein
+		if *file != "" {
+			proteins, err = bioflow.ReadProteinFASTA(*file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				exit(1)
+			}
+		} else {
+			p, err := bioflow.NewProtein(*seq)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating protein: %v\n", err)
+				exit(1)
+			}
+			p.ID = "backtranslated"
+			proteins = []*bioflow.Protein{p}
+		}
+
+		opts := bioflow.BackTranslateOptions{
+			Usage:       usage,
+			Strategy:    strat,
+			Seed:        *seed,
+			MinFraction: *minFraction,
+		}
+		if *avoid != "" {
+			opts.Avoid = strings.Split(*avoid, ",")
+		}
+
+		for _, p := range proteins {
+			dna, err := bioflow.BackTranslate(p, opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error back-translating %s: %v\n", p.ID, err)
+				exit(1)
+			}
+			dna.Description = fmt.Sprintf("CAI=%.3f", usage.CAI(dna.Bases))
+			fmt.Print(dna.ToFASTA())
+		}
+	}
+}
+
+func orfCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "FASTA file to search")
+	seq := fs.String("seq", "", "Sequence string to search")
+	minLength := fs.Int("min-length", bioflow.DefaultMinORFLength, "Minimum ORF length in amino acids")
+	frameshift := fs.Bool("frameshift", false, "Tolerate single-base frameshifts and report suspected indels")
+	shiftPenalty := fs.Int("shift-penalty", 0, "Frameshift cost in codons (default 30)")
+	reference := fs.String("reference", "", "Protein FASTA: align each sequence to its first record allowing frameshifts")
+	showProtein := fs.Bool("protein", false, "Print translated proteins")
+	strandName := fs.String("strand", "both", "Strands to search: forward, reverse or both")
+	return func() {
+		strand := parseStrand(*strandName)
+
+		if *file == "" && *seq == "" {
+			fmt.Fprintln(os.Stderr, "Error: Either -file or -seq is required")
+			fs.Usage()
+			exit(1)
+		}
+
+		var sequences []*bioflow.Sequence
+		var err error
+		if *file != "" {
+			sequences, err = bioflow.ReadFASTA(*file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				exit(1)
+			}
+		} else {
+			s, err := bioflow.NewSequence(*seq)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating sequence: %v\n", err)
+				exit(1)
+			}
+			sequences = []*bioflow.Sequence{s}
+		}
+
+		printShifts := func(shifts []bioflow.Frameshift) {
+			for _, s := range shifts {
+				fmt.Printf("    %s at %d\n", s.Kind, s.Position+1)
+			}
+		}
+
+		if *reference != "" {
+			proteins, err := bioflow.ReadProteinFASTA(*reference)
+			if err != nil || len(proteins) == 0 {
+				fmt.Fprintf(os.Stderr, "Error reading reference: %v\n", err)
+				exit(1)
+			}
+			for _, s := range sequences {
+				hit, err := bioflow.AlignORFToReference(s, proteins[0], strand)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error aligning %s: %v\n", s.ID, err)
+					continue
+				}
+				fmt.Printf("%s\t%d\t%d\t%c\tref:%d-%d\tidentity=%.3f\tshifts=%d\n",
+					s.ID, hit.Start+1, hit.End, hit.Strand, hit.RefStart+1, hit.RefEnd, hit.Identity, len(hit.Shifts))
+				printShifts(hit.Shifts)
+				if *showProtein {
+					fmt.Printf("    %s\n", hit.Protein)
+				}
+			}
+			return
+		}
+
+		fmt.Printf("%-20s %8s %8s %6s %5s %8s %6s\n", "Sequence", "Start", "End", "Strand", "Frame", "Length", "Shifts")
+		for _, s := range sequences {
+			if *frameshift {
+				orfs, err := bioflow.FindFrameshiftedORFs(s, bioflow.FrameshiftOptions{MinLength: *minLength, ShiftPenalty: *shiftPenalty, Strand: strand})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error finding ORFs in %s: %v\n", s.ID, err)
+					exit(1)
+				}
+				for _, o := range orfs {
+					fmt.Printf("%-20s %8d %8d %6c %5d %8d %6d\n", s.ID, o.Start+1, o.End, o.Strand, o.Frame, len(o.Protein), len(o.Shifts))
+					printShifts(o.Shifts)
+					if *showProtein {
+						fmt.Printf("    %s\n", o.Protein)
+					}
+				}
+				continue
+			}
+
+			orfs, err := bioflow.FindORFs(s, *minLength, strand)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error finding ORFs in %s: %v\n", s.ID, err)
+				exit(1)
+			}
+			for _, o := range orfs {
+				fmt.Printf("%-20s %8d %8d %6c %5d %8d %6d\n", s.ID, o.Start+1, o.End, o.Strand, o.Frame, len(o.Protein), 0)
+				if *showProtein {
+					fmt.Printf("    %s\n", o.Protein)
+				}
+			}
+		}
+	}
+}
+
+func telomereCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "FASTA file (e.g. an assembly)")
+	motifs := fs.String("motif", bioflow.DefaultTelomereMotif, "Comma-separated repeat motifs")
+	forwardOnly := fs.Bool("forward-only", false, "Don't count reverse complements of the motifs")
+	window := fs.Int("window", 1000, "Window size")
+	step := fs.Int("step", 0, "Window step (default: window size)")
+	minDensity := fs.Float64("min-density", 0.5, "Minimum fraction of a window covered by the motif")
+	windows := fs.Bool("windows", false, "Print per-window counts as TSV")
+	asJSON := fs.Bool("json", false, "Output reports as JSON")
+	return func() {
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+
+		sequences, err := bioflow.ReadFASTA(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+
+		opts := bioflow.RepeatScanOptions{
+			Motifs:      strings.Split(*motifs, ","),
+			ForwardOnly: *forwardOnly,
+			WindowSize:  *window,
+			Step:        *step,
+			MinDensity:  *minDensity,
+		}
+
+		reports := make([]*bioflow.RepeatReport, 0, len(sequences))
+		for _, s := range sequences {
+			report, err := bioflow.ScanRepeatMotifs(s, opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error scanning %s: %v\n", s.ID, err)
+				exit(1)
+			}
+			reports = append(reports, report)
+		}
+
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(reports); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+				exit(1)
+			}
+			return
+		}
+
+		if *windows {
+			for i, r := range reports {
+				if err := r.WriteTSV(os.Stdout, i == 0); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+					exit(1)
+				}
+			}
+			return
+		}
+
+		fmt.Printf("%-20s %10s %6s %6s %s\n", "Sequence", "Length", "5'", "3'", "Regions")
+		yesNo := func(b bool) string {
+			if b {
+				return "yes"
+			}
+			return "no"
+		}
+		for _, r := range reports {
+			regions := make([]string, 0, len(r.Regions))
+			for _, reg := range r.Regions {
+				regions = append(regions, fmt.Sprintf("%d-%d(%s,%.2f)", reg.Start+1, reg.End, reg.Strand, reg.MeanDensity))
+			}
+			fmt.Printf("%-20s %10d %6s %6s %s\n", r.SequenceID, r.Length, yesNo(r.TelomereAtStart), yesNo(r.TelomereAtEnd), strings.Join(regions, " "))
+		}
+	}
+}
+
+func repeatsCmd(fs *flag.FlagSet) func() {
+	defaults := bioflow.DefaultDuplicationOptions()
+	file := fs.String("file", "", "FASTA file (e.g. an assembly)")
+	k := fs.Int("k", defaults.K, "Minimizer k-mer size")
+	w := fs.Int("w", defaults.W, "Minimizer window, in k-mers")
+	minLength := fs.Int("min-length", defaults.MinLength, "Shortest duplicated segment reported")
+	maxOcc := fs.Int("max-occ", defaults.MaxOccurrences, "Ignore minimizers occurring more than this many times")
+	maxGap := fs.Int("max-gap", defaults.MaxGap, "Largest gap between anchors of a copy")
+	forwardOnly := fs.Bool("forward-only", false, "Don't find inverted copies")
+	format := fs.String("format", "bed", "Output format: bed, gff or json")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	addOutputFlags(fs)
+	return func() {
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+		if *format != "bed" && *format != "gff" && *format != "json" {
+			fmt.Fprintf(os.Stderr, "Error: unknown format %q (want bed, gff or json)\n", *format)
+			exit(1)
+		}
+
+		sequences, err := bioflow.ReadFASTA(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+
+		opts := bioflow.DuplicationOptions{
+			K:              *k,
+			W:              *w,
+			MinLength:      *minLength,
+			MaxOccurrences: *maxOcc,
+			MaxGap:         *maxGap,
+			ForwardOnly:    *forwardOnly,
+		}
+		dups := make([]bioflow.Duplication, 0)
+		for _, s := range sequences {
+			found, err := bioflow.FindDuplications(s, opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error annotating %s: %v\n", s.ID, err)
+				exit(1)
+			}
+			covered := 0
+			for _, d := range found {
+				covered += d.End - d.Start
+			}
+			fmt.Fprintf(os.Stderr, "%s: %d duplicated regions, %d bp\n", s.ID, len(found), covered)
+			dups = append(dups, found...)
+		}
+
+		out := createOutput(*output)
+		defer closeOutput(out)
+		switch *format {
+		case "json":
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(dups)
+		case "gff":
+			err = bioflow.WriteDuplicationsGFF(out, dups)
+		default:
+			err = bioflow.WriteDuplicationsBED(out, dups)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			exit(1)
+		}
+	}
+}
+
+func invertedCmd(fs *flag.FlagSet) func() {
+	defaults := bioflow.DefaultInvertedRepeatOptions()
+	file := fs.String("file", "", "FASTA file to search")
+	minStem := fs.Int("min-stem", defaults.MinStem, "Minimum stem (arm) length")
+	maxStem := fs.Int("max-stem", defaults.MaxStem, "Maximum stem (arm) length")
+	minLoop := fs.Int("min-loop", defaults.MinLoop, "Minimum loop (spacer) length")
+	maxLoop := fs.Int("max-loop", defaults.MaxLoop, "Maximum loop (spacer) length; 0 finds only palindromes")
+	mismatches := fs.Int("mismatches", 0, "Maximum unpaired positions in a stem")
+	format := fs.String("format", "bed", "Output format: bed, gff or json")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	addOutputFlags(fs)
+	return func() {
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+		if *format != "bed" && *format != "gff" && *format != "json" {
+			fmt.Fprintf(os.Stderr, "Error: unknown format %q (want bed, gff or json)\n", *format)
+			exit(1)
+		}
+
+		sequences, err := bioflow.ReadFASTA(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+
+		opts := bioflow.InvertedRepeatOptions{
+			MinStem:       *minStem,
+			MaxStem:       *maxStem,
+			MinLoop:       *minLoop,
+			MaxLoop:       *maxLoop,
+			MaxMismatches: *mismatches,
+		}
+		repeats := make([]bioflow.InvertedRepeat, 0)
+		for _, s := range sequences {
+			found, err := bioflow.FindInvertedRepeats(s, opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error searching %s: %v\n", s.ID, err)
+				exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "%s: %d inverted repeats\n", s.ID, len(found))
+			repeats = append(repeats, found...)
+		}
+
+		out := createOutput(*output)
+		defer closeOutput(out)
+		switch *format {
+		case "json":
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(repeats)
+		case "gff":
+			err = bioflow.WriteInvertedRepeatsGFF(out, repeats)
+		default:
+			err = bioflow.WriteInvertedRepeatsBED(out, repeats)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			exit(1)
+		}
+	}
+}
+
+func probesCmd(fs *flag.FlagSet) func() {
+	defaults := bioflow.DefaultProbeOptions()
+	file := fs.String("file", "", "FASTA file of targets")
+	background := fs.String("background", "", "FASTA file of background sequences, without the targets (e.g. the rest of the genome)")
+	length := fs.Int("length", defaults.Length, "Probe length")
+	maxDistance := fs.Int("max-distance", defaults.MaxDistance, "Reject probes with another site within this many edits")
+	minGC := fs.Float64("min-gc", defaults.MinGC, "Minimum GC fraction")
+	maxGC := fs.Float64("max-gc", defaults.MaxGC, "Maximum GC fraction (0: no limit)")
+	minTm := fs.Float64("min-tm", 0, "Minimum melting temperature in °C")
+	maxTm := fs.Float64("max-tm", 0, "Maximum melting temperature in °C (0: no limit)")
+	sodium := fs.Float64("sodium", defaults.Conditions.Sodium, "Monovalent cation concentration in mM")
+	oligo := fs.Float64("oligo", defaults.Conditions.Oligo, "Oligo concentration in nM")
+	spacing := fs.Int("spacing", 0, "Minimum distance between probe starts (0: report all)")
+	format := fs.String("format", "tsv", "Output format: tsv, fasta or json")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	addOutputFlags(fs)
+	return func() {
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+		if *format != "tsv" && *format != "fasta" && *format != "json" {
+			fmt.Fprintf(os.Stderr, "Error: unknown format %q (want tsv, fasta or json)\n", *format)
+			exit(1)
+		}
+
+		targets, err := bioflow.ReadFASTA(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+		var bg []*bioflow.Sequence
+		if *background != "" {
+			bg, err = bioflow.ReadFASTA(*background)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading background: %v\n", err)
+				exit(1)
+			}
+		}
+
+		probes, err := bioflow.DesignProbes(targets, bg, bioflow.ProbeOptions{
+			Length:      *length,
+			MaxDistance: *maxDistance,
+			MinGC:       *minGC,
+			MaxGC:       *maxGC,
+			MinTm:       *minTm,
+			MaxTm:       *maxTm,
+			Conditions:  bioflow.TmConditions{Sodium: *sodium, Oligo: *oligo},
+			MinSpacing:  *spacing,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error designing probes: %v\n", err)
+			exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "%d probes\n", len(probes))
+
+		out := createOutput(*output)
+		defer closeOutput(out)
+		switch *format {
+		case "json":
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(probes)
+		case "fasta":
+			for _, p := range probes {
+				if _, err = fmt.Fprintf(out, ">%s:%d-%d gc=%.2f tm=%.1f\n%s\n", p.SequenceID, p.Start+1, p.End, p.GC, p.Tm, p.Sequence); err != nil {
+					break
+				}
+			}
+		default:
+			_, err = fmt.Fprintln(out, "sequence_id\tstart\tend\tprobe\tgc\ttm")
+			for _, p := range probes {
+				if err != nil {
+					break
+				}
+				_, err = fmt.Fprintf(out, "%s\t%d\t%d\t%s\t%.3f\t%.1f\n", p.SequenceID, p.Start, p.End, p.Sequence, p.GC, p.Tm)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			exit(1)
+		}
+	}
+}
+
+func faidxCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "Uncompressed FASTA file to index")
+	regionFile := fs.String("regions", "", "File of regions to extract, one per line")
+	width := fs.Int("width", 60, "Bases per line of the extracted sequences (0: one line)")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bioflow faidx -file ref.fa [options] [region ...]")
+		fmt.Fprintln(os.Stderr, "Without regions, writes the index to ref.fa.fai. Regions are name, name:start or name:start-end, 1-based and inclusive.")
+		fs.PrintDefaults()
+	}
+	addOutputFlags(fs)
+	return func() {
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+		names := fs.Args()
+		if *regionFile != "" {
+			data, err := os.ReadFile(*regionFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading regions: %v\n", err)
+				exit(1)
+			}
+			for _, line := range strings.Split(string(data), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					names = append(names, line)
+				}
+			}
+		}
+		regions := make([]bioflow.Region, len(names))
+		for i, name := range names {
+			r, err := bioflow.ParseRegion(name)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+			regions[i] = r
+		}
+
+		if len(regions) == 0 {
+			entries, err := bioflow.BuildFastaIndex(*file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error indexing file: %v\n", err)
+				exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Indexed %d sequences to %s\n", len(entries), bioflow.FastaIndexPath(*file))
+			return
+		}
+
+		idx, err := bioflow.OpenFastaIndex(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+			exit(1)
+		}
+		defer idx.Close()
+
+		out := createOutput(*output)
+		defer closeOutput(out)
+		for _, r := range regions {
+			bases, err := idx.FetchRegion(r)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s: %v\n", r, err)
+				exit(1)
+			}
+			_, err = fmt.Fprintf(out, ">%s\n", r)
+			for len(bases) > 0 && err == nil {
+				n := len(bases)
+				if *width > 0 {
+					n = min(n, *width)
+				}
+				_, err = fmt.Fprintln(out, bases[:n])
+				bases = bases[n:]
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+				exit(1)
+			}
+		}
+		out.AddRecords(len(regions))
+	}
+}
+
+func bisulfiteCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "FASTA file to convert")
+	strand := fs.String("strand", "both", "Strand to convert: top (C to T), bottom (G to A) or both")
+	protect := fs.String("protect", "", "Comma-separated contexts left unconverted as methylated (e.g. CG)")
+	sitesOut := fs.String("sites", "", "Write the cytosines of both strands and their context to this file")
+	sitesFormat := fs.String("sites-format", "bed", "Format of -sites: bed, report (Bismark cytosine report) or json")
+	output := fs.String("o", "", "Output file of converted sequences (default: stdout)")
+	addOutputFlags(fs)
+	return func() {
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+		var strands []bioflow.BisulfiteStrand
+		switch *strand {
+		case "top":
+			strands = []bioflow.BisulfiteStrand{bioflow.BisulfiteTop}
+		case "bottom":
+			strands = []bioflow.BisulfiteStrand{bioflow.BisulfiteBottom}
+		case "both":
+			strands = []bioflow.BisulfiteStrand{bioflow.BisulfiteTop, bioflow.BisulfiteBottom}
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unknown strand %q (want top, bottom or both)\n", *strand)
+			exit(1)
+		}
+		if *sitesFormat != "bed" && *sitesFormat != "report" && *sitesFormat != "json" {
+			fmt.Fprintf(os.Stderr, "Error: unknown sites format %q (want bed, report or json)\n", *sitesFormat)
+			exit(1)
+		}
+		var protected []bioflow.MethylationContext
+		for _, name := range strings.Split(*protect, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			c, err := bioflow.ParseMethylationContext(name)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+			protected = append(protected, c)
+		}
+
+		sequences, err := bioflow.ReadFASTA(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+
+		out := createOutput(*output)
+		defer closeOutput(out)
+		var sites []bioflow.MethylationSite
+		for _, s := range sequences {
+			found := bioflow.FindMethylationSites(s)
+			sum := bioflow.SummarizeMethylationSites(found)
+			fmt.Fprintf(os.Stderr, "%s: %d CG, %d CHG, %d CHH cytosines on both strands\n", s.ID, sum.CG, sum.CHG, sum.CHH)
+			sites = append(sites, found...)
+
+			for _, st := range strands {
+				converted, err := bioflow.BisulfiteConvert(s, bioflow.BisulfiteOptions{Strand: st, Protect: protected})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error converting %s: %v\n", s.ID, err)
+					exit(1)
+				}
+				// Both conversions of a sequence are told apart as
+				// Bismark's genome preparation does.
+				if len(strands) == 2 {
+					if st == bioflow.BisulfiteTop {
+						converted.ID += "_CT"
+					} else {
+						converted.ID += "_GA"
+					}
+				}
+				if _, err := io.WriteString(out, converted.ToFASTA()); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+					exit(1)
+				}
+			}
+		}
+		out.AddRecords(len(sequences) * len(strands))
+
+		if *sitesOut != "" {
+			f := createOutput(*sitesOut)
+			switch *sitesFormat {
+			case "json":
+				enc := json.NewEncoder(f)
+				enc.SetIndent("", "  ")
+				err = enc.Encode(sites)
+			case "report":
+				err = bioflow.WriteCytosineReport(f, sites)
+			default:
+				err = bioflow.WriteMethylationSitesBED(f, sites)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing sites: %v\n", err)
+				exit(1)
+			}
+			f.AddRecords(len(sites))
+			closeOutput(f)
+		}
+	}
+}
+
+func chimeraCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "FASTA file of amplicon sequences to check")
+	ref := fs.String("ref", "", "FASTA file of non-chimeric reference sequences")
+	defaults := bioflow.DefaultChimeraOptions()
+	minScore := fs.Float64("min-score", defaults.MinScore, "Lowest score of a chimera")
+	minDiv := fs.Float64("min-div", defaults.MinDivergence, "Lowest divergence of a chimera from its closest parent (fraction)")
+	minDiffs := fs.Int("min-diffs", defaults.MinDiffs, "Fewest differences supporting each side of a chimera")
+	format := fs.String("format", "tsv", "Output format: tsv or json")
+	nonChimeras := fs.String("nonchimeras", "", "Write the clean sequences as FASTA to this file")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	addOutputFlags(fs)
+	return func() {
+		if *file == "" || *ref == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file and -ref are required")
+			fs.Usage()
+			exit(1)
+		}
+		if *format != "tsv" && *format != "json" {
+			fmt.Fprintf(os.Stderr, "Error: unknown format %q (want tsv or json)\n", *format)
+			exit(1)
+		}
+		queries, err := bioflow.ReadFASTA(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+		refs, err := bioflow.ReadFASTA(*ref)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading references: %v\n", err)
+			exit(1)
+		}
+
+		opts := defaults
+		opts.MinScore, opts.MinDivergence, opts.MinDiffs = *minScore, *minDiv, *minDiffs
+		calls, err := bioflow.DetectChimeras(queries, refs, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+
+		out := createOutput(*output)
+		defer closeOutput(out)
+		if *format == "json" {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(calls)
+		} else {
+			fmt.Fprintln(out, "id\tcall\tscore\tparent_a\tparent_b\tcrossover\tmodel_identity\tparent_identity\tdivergence")
+			for _, c := range calls {
+				_, err = fmt.Fprintf(out, "%s\t%s\t%.4f\t%s\t%s\t%d\t%.4f\t%.4f\t%.4f\n",
+					c.ID, c.Label(), c.Score, c.ParentA, c.ParentB, c.Crossover, c.ModelIdentity, c.ParentIdentity, c.Divergence)
+				if err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			exit(1)
+		}
+		out.AddRecords(len(calls))
+
+		chimeric := 0
+		var clean []*bioflow.Sequence
+		for i, c := range calls {
+			if c.Chimeric {
+				chimeric++
+			} else {
+				clean = append(clean, queries[i])
+			}
+		}
+		fmt.Fprintf(os.Stderr, "%d of %d sequences chimeric\n", chimeric, len(calls))
+
+		if *nonChimeras != "" {
+			f := createOutput(*nonChimeras)
+			for _, s := range clean {
+				if _, err := io.WriteString(f, s.ToFASTA()); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+					exit(1)
+				}
+			}
+			f.AddRecords(len(clean))
+			closeOutput(f)
+		}
+	}
+}
+
+func clusterCmd(fs *flag.FlagSet) func() {
+	defaults := bioflow.DefaultClusterOptions()
+	identity := fs.Float64("identity", defaults.Identity, "Lowest identity to a cluster centroid (1 keeps each distinct sequence apart)")
+	minSize := fs.Int("min-size", defaults.MinSize, "Drop distinct sequences seen fewer times before clustering")
+	maxRejects := fs.Int("max-rejects", defaults.MaxRejects, "Centroids compared before a sequence starts a new cluster")
+	prefix := fs.String("prefix", defaults.Prefix, "Prefix of cluster names")
+	format := fs.String("format", "auto", "Input format: auto (from extension), fasta or fastq")
+	table := fs.String("table", "", "Write the abundance table (clusters by samples) to this file")
+	useConsensus := fs.Bool("consensus", false, "Represent clusters by their consensus instead of their centroid")
+	output := fs.String("o", "", "Output FASTA of cluster representatives (default: stdout)")
+	addOutputFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bioflow cluster [options] sample1.fastq [sample2.fastq ...]")
+		fmt.Fprintln(os.Stderr, "Each file is one sample, named after the file.")
+		fs.PrintDefaults()
+	}
+	return func() {
+		if fs.NArg() == 0 {
+			fmt.Fprintln(os.Stderr, "Error: at least one sample file is required")
+			fs.Usage()
+			exit(1)
+		}
+		samples := make([]string, fs.NArg())
+		sets := make([][]*bioflow.Sequence, fs.NArg())
+		for i, file := range fs.Args() {
+			fastq, err := isFASTQ(file, *format)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+			if sets[i], err = readSequenceSet(file, fastq); err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", file, err)
+				exit(1)
+			}
+			name := filepath.Base(bioflow.TrimCompressionExt(file))
+			samples[i] = strings.TrimSuffix(name, filepath.Ext(name))
+		}
+
+		opts := defaults
+		opts.Identity, opts.MinSize, opts.MaxRejects, opts.Prefix = *identity, *minSize, *maxRejects, *prefix
+		clustering, err := bioflow.ClusterAmplicons(samples, sets, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "%d clusters from %d samples; %d sequences below -min-size dropped\n",
+			len(clustering.Clusters), len(clustering.Samples), clustering.Dropped)
+
+		out := createOutput(*output)
+		defer closeOutput(out)
+		if err := clustering.WriteRepresentatives(out, *useConsensus); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			exit(1)
+		}
+		out.AddRecords(len(clustering.Clusters))
+
+		if *table != "" {
+			f := createOutput(*table)
+			if err := clustering.WriteTable(f); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing table: %v\n", err)
+				exit(1)
+			}
+			f.AddRecords(len(clustering.Clusters))
+			closeOutput(f)
+		}
+	}
+}
+
+func diversityCmd(fs *flag.FlagSet) func() {
+	tableFile := fs.String("table", "", "Abundance table (\"#OTU ID\" TSV, as written by cluster -table)")
+	metric := fs.String("metric", "bray-curtis", "Beta diversity metric: bray-curtis or jaccard")
+	matrixOut := fs.String("matrix", "", "Write the beta diversity distance matrix to this file")
+	matrixFormat := fs.String("matrix-format", "tsv", "Format of -matrix: tsv, phylip or json")
+	asJSON := fs.Bool("json", false, "Output alpha diversity as JSON")
+	output := fs.String("o", "", "Output file of alpha diversity (default: stdout)")
+	addOutputFlags(fs)
+	return func() {
+		if *tableFile == "" {
+			fmt.Fprintln(os.Stderr, "Error: -table is required")
+			fs.Usage()
+			exit(1)
+		}
+		m, err := bioflow.ParseBetaMetric(*metric)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		if *matrixFormat != "tsv" && *matrixFormat != "phylip" && *matrixFormat != "json" {
+			fmt.Fprintf(os.Stderr, "Error: unknown matrix format %q (want tsv, phylip or json)\n", *matrixFormat)
+			exit(1)
+		}
+		table, err := bioflow.ReadAbundanceTable(*tableFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading table: %v\n", err)
+			exit(1)
+		}
+
+		alpha := table.AlphaDiversity()
+		out := createOutput(*output)
+		defer closeOutput(out)
+		if *asJSON {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(alpha)
+		} else {
+			fmt.Fprintln(out, "sample\tdepth\tobserved\tshannon\tsimpson\tchao1")
+			for _, a := range alpha {
+				if _, err = fmt.Fprintf(out, "%s\t%d\t%d\t%.4f\t%.4f\t%.2f\n", a.Sample, a.Depth, a.Observed, a.Shannon, a.Simpson, a.Chao1); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			exit(1)
+		}
+		out.AddRecords(len(alpha))
+
+		if *matrixOut != "" {
+			matrix := table.BetaDiversity(m)
+			f := createOutput(*matrixOut)
+			switch *matrixFormat {
+			case "json":
+				enc := json.NewEncoder(f)
+				enc.SetIndent("", "  ")
+				err = enc.Encode(matrix)
+			case "phylip":
+				err = matrix.WritePHYLIP(f)
+			default:
+				err = matrix.WriteTSV(f)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing matrix: %v\n", err)
+				exit(1)
+			}
+			f.AddRecords(len(matrix.Names))
+			closeOutput(f)
+		}
+	}
+}
+
+func rarefactionCmd(fs *flag.FlagSet) func() {
+	defaults := bioflow.DefaultRarefactionOptions()
+	tableFile := fs.String("table", "", "Abundance table (\"#OTU ID\" TSV) instead of sample files")
+	depths := fs.String("depths", "", "Comma-separated subsampling depths (default: -steps depths up to the deepest sample)")
+	steps := fs.Int("steps", defaults.Steps, "Number of evenly spaced depths when -depths is not given")
+	iterations := fs.Int("iterations", defaults.Iterations, "Subsamples drawn at each depth")
+	seed := fs.Int64("seed", bioflow.DefaultSeed, "Random seed for subsampling")
+	format := fs.String("format", "auto", "Input format of sample files: auto (from extension), fasta or fastq")
+	outFormat := fs.String("output-format", "tsv", "Output format: tsv or json")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	addOutputFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bioflow rarefaction [options] -table otus.tsv")
+		fmt.Fprintln(os.Stderr, "       bioflow rarefaction [options] sample1.fastq [sample2.fastq ...]")
+		fmt.Fprintln(os.Stderr, "Each file is one sample, named after the file, whose distinct sequences are counted.")
+		fs.PrintDefaults()
+	}
+	return func() {
+		if (*tableFile == "") == (fs.NArg() == 0) {
+			fmt.Fprintln(os.Stderr, "Error: give either -table or sample files")
+			fs.Usage()
+			exit(1)
+		}
+		if *outFormat != "tsv" && *outFormat != "json" {
+			fmt.Fprintf(os.Stderr, "Error: unknown output format %q (want tsv or json)\n", *outFormat)
+			exit(1)
+		}
+		opts := defaults
+		opts.Steps, opts.Iterations, opts.Seed = *steps, *iterations, *seed
+		if *depths != "" {
+			for _, field := range strings.Split(*depths, ",") {
+				var d int
+				if _, err := fmt.Sscanf(strings.TrimSpace(field), "%d", &d); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: invalid depth %q\n", field)
+					exit(1)
+				}
+				opts.Depths = append(opts.Depths, d)
+			}
+		}
+
+		var curves []bioflow.RarefactionCurve
+		var err error
+		if *tableFile != "" {
+			var table *bioflow.AbundanceTable
+			if table, err = bioflow.ReadAbundanceTable(*tableFile); err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading table: %v\n", err)
+				exit(1)
+			}
+			curves, err = table.Rarefaction(opts)
+		} else {
+			samples := make([]string, fs.NArg())
+			sets := make([][]*bioflow.Sequence, fs.NArg())
+			for i, file := range fs.Args() {
+				fastq, err := isFASTQ(file, *format)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					exit(1)
+				}
+				if sets[i], err = readSequenceSet(file, fastq); err != nil {
+					fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", file, err)
+					exit(1)
+				}
+				name := filepath.Base(bioflow.TrimCompressionExt(file))
+				samples[i] = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			curves, err = bioflow.RarefyReads(samples, sets, opts)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+
+		out := createOutput(*output)
+		defer closeOutput(out)
+		if *outFormat == "json" {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(curves)
+		} else {
+			err = bioflow.WriteRarefactionTSV(out, curves)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			exit(1)
+		}
+		out.AddRecords(len(curves))
+	}
+}
+
+func aniCmd(fs *flag.FlagSet) func() {
+	defaults := bioflow.DefaultANIOptions()
+	method := fs.String("method", "fragment", "ANI method: fragment (reciprocal best fragment alignments) or kmer (Mash estimate)")
+	k := fs.Int("k", 0, "K-mer length (default: 16 for fragment seeds, 21 for kmer)")
+	fragment := fs.Int("fragment", defaults.FragmentLength, "Fragment length")
+	minIdentity := fs.Float64("min-identity", defaults.MinIdentity, "Lowest identity of a fragment hit")
+	minCoverage := fs.Float64("min-coverage", defaults.MinCoverage, "Lowest fraction of a fragment its hit must cover")
+	format := fs.String("format", "matrix", "Output format: matrix (TSV heatmap), pairs (TSV), phylip (distances) or json")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	addOutputFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bioflow ani [options] genome1.fasta genome2.fasta [...]")
+		fmt.Fprintln(os.Stderr, "Each file is one genome, named after the file.")
+		fs.PrintDefaults()
+	}
+	return func() {
+		if fs.NArg() < 2 {
+			fmt.Fprintln(os.Stderr, "Error: at least two genome files are required")
+			fs.Usage()
+			exit(1)
+		}
+		if *format != "matrix" && *format != "pairs" && *format != "phylip" && *format != "json" {
+			fmt.Fprintf(os.Stderr, "Error: unknown format %q (want matrix, pairs, phylip or json)\n", *format)
+			exit(1)
+		}
+		m, err := bioflow.ParseANIMethod(*method)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		genomes := make([]*bioflow.Genome, fs.NArg())
+		for i, file := range fs.Args() {
+			if genomes[i], err = bioflow.ReadGenome(file); err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", file, err)
+				exit(1)
+			}
+		}
+
+		opts := defaults
+		opts.Method, opts.K, opts.FragmentLength = m, *k, *fragment
+		opts.MinIdentity, opts.MinCoverage = *minIdentity, *minCoverage
+		matrix, err := bioflow.PairwiseANI(genomes, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+
+		out := createOutput(*output)
+		defer closeOutput(out)
+		switch *format {
+		case "json":
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(matrix)
+		case "pairs":
+			err = matrix.WritePairs(out)
+		case "phylip":
+			err = matrix.WritePHYLIP(out)
+		default:
+			err = matrix.WriteTSV(out)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			exit(1)
+		}
+		out.AddRecords(len(matrix.Pairs))
+	}
+}
+
+func completenessCmd(fs *flag.FlagSet) func() {
+	panelFile := fs.String("panel", "", "Marker panel: one marker per line, its name, a tab and its k-mers")
+	markersFile := fs.String("markers", "", "FASTA of marker genes to build the panel from, instead of -panel")
+	k := fs.Int("k", 21, "K-mer length of a panel built from -markers")
+	savePanel := fs.String("save-panel", "", "Write the panel built from -markers to this file")
+	minFraction := fs.Float64("min-fraction", bioflow.DefaultMarkerFraction, "Fraction of a marker's k-mers needed for it to be present")
+	asJSON := fs.Bool("json", false, "Output per-marker results as JSON")
+	matrix := fs.String("matrix", "", "Write the fraction of each marker found, by genome, to this file")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	addOutputFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bioflow completeness [options] -panel markers.tsv genome1.fasta [genome2.fasta ...]")
+		fmt.Fprintln(os.Stderr, "Each file is one genome, named after the file.")
+		fs.PrintDefaults()
+	}
+	return func() {
+		if (*panelFile == "") == (*markersFile == "") {
+			fmt.Fprintln(os.Stderr, "Error: give either -panel or -markers")
+			fs.Usage()
+			exit(1)
+		}
+		if fs.NArg() == 0 {
+			fmt.Fprintln(os.Stderr, "Error: at least one genome file is required")
+			fs.Usage()
+			exit(1)
+		}
+
+		var panel *bioflow.MarkerPanel
+		var err error
+		if *panelFile != "" {
+			if panel, err = bioflow.ReadMarkerPanel(*panelFile); err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading panel: %v\n", err)
+				exit(1)
+			}
+		} else {
+			markers, err := bioflow.ReadFASTA(*markersFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading markers: %v\n", err)
+				exit(1)
+			}
+			if panel, err = bioflow.MarkerPanelFromSequences(markers, *k); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+			if *savePanel != "" {
+				f := createOutput(*savePanel)
+				if err := panel.Write(f); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing panel: %v\n", err)
+					exit(1)
+				}
+				f.AddRecords(len(panel.Markers))
+				closeOutput(f)
+			}
+		}
+
+		results := make([]*bioflow.Completeness, fs.NArg())
+		for i, file := range fs.Args() {
+			g, err := bioflow.ReadGenome(file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", file, err)
+				exit(1)
+			}
+			if results[i], err = bioflow.EstimateCompleteness(g, panel, *minFraction); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+		}
+
+		out := createOutput(*output)
+		defer closeOutput(out)
+		if *asJSON {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(results)
+		} else {
+			err = bioflow.WriteCompletenessTSV(out, results)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			exit(1)
+		}
+		out.AddRecords(len(results))
+
+		if *matrix != "" {
+			f := createOutput(*matrix)
+			if err := bioflow.WriteMarkerPresence(f, results); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing matrix: %v\n", err)
+				exit(1)
+			}
+			f.AddRecords(len(panel.Markers))
+			closeOutput(f)
+		}
+	}
+}
+
+func complexityCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "FASTA file to profile")
+	window := fs.Int("window", 64, "Window size")
+	step := fs.Int("step", 0, "Window step (default: half the window)")
+	k := fs.Int("k", 6, "Largest k-mer size for linguistic complexity")
+	asJSON := fs.Bool("json", false, "Output tracks as JSON (same as -format json)")
+	format := fs.String("format", "tsv", "Output format: tsv, json, bedgraph, wig or variablestep")
+	return func() {
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+		if *asJSON {
+			*format = "json"
+		}
+		trackFormat, err := bioflow.ParseTrackFormat(*format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+
+		sequences, err := bioflow.ReadFASTA(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+
+		opts := bioflow.ComplexityOptions{Window: *window, Step: *step, MaxK: *k}
+		var tracks []*bioflow.Track
+		for _, s := range sequences {
+			entropy, complexity, err := bioflow.ComplexityProfile(s, opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error profiling %s: %v\n", s.ID, err)
+				exit(1)
+			}
+			tracks = append(tracks, entropy, complexity)
+		}
+
+		if err := bioflow.WriteTracks(os.Stdout, trackFormat, tracks...); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			exit(1)
+		}
+	}
+}
+
+func cgrCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "FASTA file")
+	k := fs.Int("k", 6, "FCGR resolution (grid of 2^k x 2^k cells)")
+	normalize := fs.Bool("normalize", true, "Store frequencies instead of counts")
+	csvOut := fs.String("csv", "", "Write FCGR vectors to this CSV file (default: stdout)")
+	pngDir := fs.String("png-dir", "", "Write one PNG image per sequence to this directory")
+	scale := fs.Int("scale", 4, "Pixels per FCGR cell in PNG images")
+	addOutputFlags(fs)
+	return func() {
+		bioflow.SetOutputDefaults(outputOptions)
+
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+
+		sequences, err := bioflow.ReadFASTA(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+
+		if *pngDir != "" {
+			if err := os.MkdirAll(*pngDir, 0755); err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating directory: %v\n", err)
+				exit(1)
+			}
+		}
+
+		fcgrs := make([]*bioflow.FCGR, 0, len(sequences))
+		for i, s := range sequences {
+			f, err := bioflow.ComputeFCGR(s, *k, *normalize)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error computing FCGR: %v\n", err)
+				exit(1)
+			}
+			if f.SequenceID == "" {
+				f.SequenceID = fmt.Sprintf("seq%d", i+1)
+			}
+			fcgrs = append(fcgrs, f)
+
+			if *pngDir != "" {
+				path := filepath.Join(*pngDir, f.SequenceID+".png")
+				if err := bioflow.SaveFCGRPNG(path, f, *scale); err != nil {
+					fmt.Fprintf(os.Stderr, "Error saving %s: %v\n", path, err)
+					exit(1)
+				}
+			}
+		}
+
+		if *csvOut == "" && *pngDir != "" {
+			return
+		}
+		out := createOutput(*csvOut)
+		defer closeOutput(out)
+
+		if err := bioflow.WriteFCGRCSV(out, fcgrs); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+			exit(1)
+		}
+	}
+}
+
+func kmerMatrixCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "FASTA file")
+	k := fs.Int("k", 4, "K-mer size")
+	norm := fs.String("norm", "relative", "Normalization: raw, relative, tfidf or clr")
+	canonical := fs.Bool("canonical", false, "Merge k-mers with their reverse complements")
+	allKMers := fs.Bool("all", false, "Use every possible k-mer as a column")
+	sparse := fs.Bool("sparse", false, "Write CSV as row,column,value triplets")
+	csvOut := fs.String("csv", "", "Write the matrix to this CSV file (default: stdout)")
+	npyOut := fs.String("npy", "", "Write the dense matrix to this .npy file")
+	npzOut := fs.String("npz", "", "Write the sparse matrix to this .npz file (scipy.sparse.load_npz)")
+	addOutputFlags(fs)
+	return func() {
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+
+		normalization, err := bioflow.ParseNormalization(*norm)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+
+		sequences, err := bioflow.ReadFASTA(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+
+		m, err := bioflow.BuildKMerMatrix(sequences, bioflow.KMerMatrixOptions{
+			K:             *k,
+			Normalization: normalization,
+			Canonical:     *canonical,
+			AllKMers:      *allKMers,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error building matrix: %v\n", err)
+			exit(1)
+		}
+
+		save := func(path string, write func(io.Writer) error) {
+			f := createOutput(path)
+			if err := write(f); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", path, err)
+				exit(1)
+			}
+			closeOutput(f)
+		}
+
+		if *npyOut != "" {
+			save(*npyOut, m.WriteNPY)
+		}
+		if *npzOut != "" {
+			save(*npzOut, m.ToCSR().WriteNPZ)
+		}
+
+		writeCSV := m.WriteCSV
+		if *sparse {
+			writeCSV = m.WriteSparseCSV
+		}
+		if *csvOut != "" {
+			save(*csvOut, writeCSV)
+		} else if *npyOut == "" && *npzOut == "" {
+			if err := writeCSV(os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+				exit(1)
+			}
+		}
+	}
+}
+
+func sketchCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "FASTA file")
+	method := fs.String("method", "syncmer", "Seed type: syncmer or randstrobe")
+	k := fs.Int("k", 15, "K-mer (strobe) length")
+	s := fs.Int("s", 5, "Syncmer s-mer length")
+	offset := fs.Int("offset", -1, "Open syncmer s-mer offset (default (k-s)/2)")
+	closed := fs.Bool("closed", false, "Closed syncmers")
+	order := fs.Int("order", 2, "Randstrobe order (2 or 3)")
+	wMin := fs.Int("wmin", bioflow.DefaultRandstrobeOptions.WMin, "Randstrobe window start")
+	wMax := fs.Int("wmax", bioflow.DefaultRandstrobeOptions.WMax, "Randstrobe window end")
+	canonical := fs.Bool("canonical", true, "Strand-independent hashing")
+	skipMasked := fs.Bool("skip-masked", false, "Select no seeds overlapping soft-masked (lower-case) bases")
+	return func() {
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+
+		policy := bioflow.StrictPolicy()
+		if *skipMasked {
+			policy = maskedPolicy()
+		}
+		sequences, err := bioflow.ReadFASTAWithPolicy(*file, policy)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+
+		switch *method {
+		case "syncmer":
+			if *offset < 0 {
+				*offset = (*k - *s) / 2
+			}
+			opts := bioflow.SyncmerOptions{K: *k, S: *s, Offset: *offset, Closed: *closed, Canonical: *canonical, SkipSoftMasked: *skipMasked}
+			fmt.Println("sequence\tposition\tkmer\thash")
+			for _, seq := range sequences {
+				syncmers, err := bioflow.Syncmers(seq, opts)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error extracting syncmers: %v\n", err)
+					exit(1)
+				}
+				for _, m := range syncmers {
+					fmt.Printf("%s\t%d\t%s\t%016x\n", seq.ID, m.Position, m.KMer, m.Hash)
+				}
+			}
+		case "randstrobe":
+			opts := bioflow.RandstrobeOptions{Order: *order, K: *k, WMin: *wMin, WMax: *wMax, Canonical: *canonical, SkipSoftMasked: *skipMasked}
+			fmt.Println("sequence\tstart\tend\tpositions\thash")
+			for _, seq := range sequences {
+				strobes, err := bioflow.Randstrobes(seq, opts)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error extracting randstrobes: %v\n", err)
+					exit(1)
+				}
+				for _, r := range strobes {
+					positions := make([]string, len(r.Positions))
+					for i, p := range r.Positions {
+						positions[i] = fmt.Sprintf("%d", p)
+					}
+					fmt.Printf("%s\t%d\t%d\t%s\t%016x\n", seq.ID, r.Start(), r.End(*k), strings.Join(positions, ","), r.Hash)
+				}
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unknown method %q (want syncmer or randstrobe)\n", *method)
+			exit(1)
+		}
+	}
+}
+
+func mappabilityCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "Reference FASTA file")
+	k := fs.Int("k", 36, "K-mer (read) length")
+	format := fs.String("format", "bedgraph", "Output format: bedgraph, wig, variablestep, tsv or json")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	strandSpecific := fs.Bool("strand-specific", false, "Don't merge k-mers with their reverse complements")
+	addOutputFlags(fs)
+	return func() {
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+
+		trackFormat, err := bioflow.ParseTrackFormat(*format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+
+		sequences, err := bioflow.ReadFASTA(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+
+		index, err := bioflow.BuildKMerIndex(sequences, *k, !*strandSpecific)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error building k-mer index: %v\n", err)
+			exit(1)
+		}
+
+		tracks := make([]*bioflow.Track, 0, len(sequences))
+		for _, s := range sequences {
+			if s.Len() < *k {
+				continue
+			}
+			t, err := bioflow.Mappability(s, index)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error computing mappability for %s: %v\n", s.ID, err)
+				exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "%s: %.2f%% uniquely mappable\n", s.ID, 100*bioflow.UniqueFraction(t))
+			tracks = append(tracks, t)
+		}
+
+		out := createOutput(*output)
+		defer closeOutput(out)
+		if err := bioflow.WriteTracks(out, trackFormat, tracks...); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing track: %v\n", err)
+			exit(1)
+		}
+	}
+}
+
+func maskCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "FASTA file to mask")
+	k := fs.Int("k", 21, "K-mer size")
+	threshold := fs.Int("threshold", 10, "Mask k-mers occurring more than this many times")
+	reference := fs.String("reference", "", "Count k-mers in this FASTA file instead of the input")
+	db := fs.String("db", "", "Load counts from a Jellyfish or KMC text dump instead of counting")
+	dbFormat := fs.String("db-format", "auto", "Dump format: auto, jellyfish, jellyfish-column or kmc")
+	dbStranded := fs.Bool("db-stranded", false, "The dump holds strand-specific (non-canonical) counts")
+	soft := fs.Bool("soft", false, "Soft-mask (lowercase) instead of replacing with N")
+	output := fs.String("o", "", "Output FASTA file (default: stdout)")
+	addOutputFlags(fs)
+	return func() {
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+
+		sequences, err := bioflow.ReadFASTA(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+
+		var counter *bioflow.KMerCounter
+		if *db != "" {
+			format, err := bioflow.ParseKMerDumpFormat(*dbFormat)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+			counter, err = bioflow.LoadKMerDump(*db, format)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading k-mer database: %v\n", err)
+				exit(1)
+			}
+			counter.Canonical = !*dbStranded
+		} else {
+			source := sequences
+			if *reference != "" {
+				source, err = bioflow.ReadFASTA(*reference)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error reading reference: %v\n", err)
+					exit(1)
+				}
+			}
+			counter, err = bioflow.BuildKMerIndex(source, *k, true)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error counting k-mers: %v\n", err)
+				exit(1)
+			}
+		}
+
+		opts := bioflow.MaskOptions{Threshold: *threshold, Mode: bioflow.HardMask}
+		if *soft {
+			opts.Mode = bioflow.SoftMask
+		}
+
+		out := createOutput(*output)
+		defer closeOutput(out)
+
+		for _, s := range sequences {
+			result, err := bioflow.MaskByAbundance(s, counter, opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error masking %s: %v\n", s.ID, err)
+				exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "%s: masked %d bases (%.2f%%) in %d regions\n", s.ID, result.MaskedBases, 100*result.MaskedFraction(), len(result.Regions))
+			if _, err := io.WriteString(out, result.Sequence.ToFASTA()); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+				exit(1)
+			}
+		}
+		out.AddRecords(len(sequences))
+	}
+}
+
+func anchorsCmd(fs *flag.FlagSet) func() {
+	file1 := fs.String("file1", "", "FASTA file with the first sequence")
+	file2 := fs.String("file2", "", "FASTA file with the second sequence")
+	seq1 := fs.String("seq1", "", "First sequence")
+	seq2 := fs.String("seq2", "", "Second sequence")
+	k := fs.Int("k", 15, "K-mer size")
+	bothStrands := fs.Bool("both-strands", true, "Also match reverse complements")
+	maxOcc := fs.Int("max-occ", 0, "Skip k-mers occurring more often than this in the second sequence (0: no limit)")
+	chain := fs.Bool("chain", false, "Report collinear chains instead of individual anchors")
+	asPAF := fs.Bool("paf", false, "Report chains as PAF (implies -chain)")
+	skipMasked := fs.Bool("skip-masked", false, "Skip k-mers overlapping soft-masked (lower-case) bases")
+	return func() {
+		policy := bioflow.StrictPolicy()
+		if *skipMasked {
+			policy = maskedPolicy()
+		}
+		load := func(file, bases, name string) *bioflow.Sequence {
+			if file == "" && bases == "" {
+				fmt.Fprintf(os.Stderr, "Error: Either -file%s or -seq%s is required\n", name, name)
+				fs.Usage()
+				exit(1)
+			}
+			if file != "" {
+				sequences, err := bioflow.ReadFASTAWithPolicy(file, policy)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+					exit(1)
+				}
+				if len(sequences) == 0 {
+					fmt.Fprintln(os.Stderr, "No sequences found in file")
+					exit(1)
+				}
+				return sequences[0]
+			}
+			s, err := bioflow.NewSequenceWithPolicy(bases, "", "", policy)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating sequence %s: %v\n", name, err)
+				exit(1)
+			}
+			return s
+		}
+		s1 := load(*file1, *seq1, "1")
+		s2 := load(*file2, *seq2, "2")
+
+		anchors, err := bioflow.FindAnchors(s1, s2, bioflow.AnchorOptions{K: *k, BothStrands: *bothStrands, MaxOccurrences: *maxOcc, SkipSoftMasked: *skipMasked})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error finding anchors: %v\n", err)
+			exit(1)
+		}
+
+		if !*chain && !*asPAF {
+			fmt.Println("pos1\tpos2\tstrand")
+			for _, a := range anchors {
+				fmt.Printf("%d\t%d\t%c\n", a.Pos1, a.Pos2, a.Strand)
+			}
+			return
+		}
+
+		chains, err := bioflow.ChainAnchors(anchors, bioflow.DefaultChainOptions(*k))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error chaining anchors: %v\n", err)
+			exit(1)
+		}
+		if *asPAF {
+			records := make([]*bioflow.PAFRecord, len(chains))
+			for i, c := range chains {
+				records[i] = bioflow.ChainToPAF(s1, s2, c, *k)
+			}
+			if err := bioflow.WritePAF(os.Stdout, records); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing PAF: %v\n", err)
+				exit(1)
+			}
+			return
+		}
+
+		fmt.Println("start1\tend1\tstart2\tend2\tstrand\tanchors\tscore")
+		for _, c := range chains {
+			fmt.Printf("%d\t%d\t%d\t%d\t%c\t%d\t%.1f\n", c.Start1, c.End1, c.Start2, c.End2, c.Strand, len(c.Anchors), c.Score)
+		}
+	}
+}
+
+func realignCmd(fs *flag.FlagSet) func() {
+	refFile := fs.String("ref", "", "Reference FASTA file (first record is used unless -chrom is set)")
+	chrom := fs.String("chrom", "", "Reference record ID")
+	read := fs.String("read", "", "Read sequence")
+	pos := fs.Int("pos", 0, "1-based reference position of the first aligned read base")
+	cigar := fs.String("cigar", "", "Read CIGAR")
+	band := fs.Int("band", 8, "Extra diagonals explored around the alignment")
+	asVCF := fs.Bool("vcf", false, "Print the read's normalized variants as VCF")
+	return func() {
+		if *refFile == "" || *read == "" || *pos <= 0 || *cigar == "" {
+			fmt.Fprintln(os.Stderr, "Error: -ref, -read, -pos and -cigar are required")
+			fs.Usage()
+			exit(1)
+		}
+
+		references, err := bioflow.ReadSequenceSet(*refFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading reference: %v\n", err)
+			exit(1)
+		}
+		ref, ok := references.Get(*chrom)
+		if *chrom == "" && references.Len() > 0 {
+			ref, ok = references.Sequences()[0], true
+		}
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: reference %q not found\n", *chrom)
+			exit(1)
+		}
+
+		r, err := bioflow.NewSequence(*read)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating read: %v\n", err)
+			exit(1)
+		}
+
+		result, err := bioflow.RealignIndels(ref, r, *pos-1, *cigar, bioflow.RealignOptions{Band: *band})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error realigning read: %v\n", err)
+			exit(1)
+		}
+
+		if *asVCF {
+			variants, err := bioflow.VariantsFromRealignment(ref, result)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error extracting variants: %v\n", err)
+				exit(1)
+			}
+			if err := bioflow.WriteVCF(os.Stdout, bioflow.VariantsToVCF(variants)); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing VCF: %v\n", err)
+				exit(1)
+			}
+			return
+		}
+
+		fmt.Printf("CIGAR:    %s -> %s\n", *cigar, result.CIGAR)
+		fmt.Printf("Score:    %d -> %d\n", result.OriginalScore, result.Score)
+		fmt.Printf("Changed:  %v\n", result.Changed)
+		fmt.Printf("Ref:  %s\nRead: %s\n", result.AlignedRef, result.AlignedRead)
+	}
+}
+
+func vcfNormCmd(fs *flag.FlagSet) func() {
+	vcfFile := fs.String("vcf", "", "Input VCF file (- for stdin)")
+	refFile := fs.String("ref", "", "Reference FASTA file")
+	split := fs.Bool("split", false, "Split multi-allelic records")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	addOutputFlags(fs)
+	return func() {
+		if *vcfFile == "" || *refFile == "" {
+			fmt.Fprintln(os.Stderr, "Error: -vcf and -ref are required")
+			fs.Usage()
+			exit(1)
+		}
+
+		vcf, err := bioflow.ReadVCF(*vcfFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading VCF: %v\n", err)
+			exit(1)
+		}
+		references, err := bioflow.ReadFASTA(*refFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading reference: %v\n", err)
+			exit(1)
+		}
+
+		normalized, err := bioflow.NormalizeVCF(vcf, references, *split)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error normalizing variants: %v\n", err)
+			exit(1)
+		}
+
+		w := createOutput(*output)
+		defer closeOutput(w)
+		if err := bioflow.WriteVCF(w, normalized); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing VCF: %v\n", err)
+			exit(1)
+		}
+	}
+}
+
+func pileupCmd(fs *flag.FlagSet) func() {
+	samFile := fs.String("sam", "", "Input SAM or BAM file (- for stdin)")
+	refFile := fs.String("ref", "", "Reference FASTA file")
+	minMapQ := fs.Int("min-mapq", 0, "Minimum mapping quality")
+	minBaseQ := fs.Int("min-baseq", 13, "Minimum base quality")
+	call := fs.Bool("call", false, "Call variants and write VCF")
+	minDepth := fs.Int("min-depth", 8, "Minimum depth for variant calls and consensus")
+	minAF := fs.Float64("min-af", 0.2, "Minimum allele fraction for variant calls")
+	minAlt := fs.Int("min-alt", 2, "Minimum supporting reads for variant calls")
+	consensus := fs.Bool("consensus", false, "Write the majority consensus as FASTA")
+	minFraction := fs.Float64("min-fraction", 0.5, "Minimum allele fraction for consensus bases")
+	format := fs.String("format", "columns", "Pileup output: columns, or a coverage track as bedgraph, wig or variablestep")
+	window := fs.Int("window", 0, "Average the coverage track over windows of this size (0 for per-base runs)")
+	step := fs.Int("step", 0, "Coverage window step (default: half the window)")
+	strandName := fs.String("strand", "both", "Reads to stack by the strand they align to: forward, reverse or both")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	addOutputFlags(fs)
+	return func() {
+		strand := parseStrand(*strandName)
+
+		if *samFile == "" || *refFile == "" {
+			fmt.Fprintln(os.Stderr, "Error: -sam and -ref are required")
+			fs.Usage()
+			exit(1)
+		}
+
+		_, records, err := bioflow.ReadSAM(*samFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading alignments: %v\n", err)
+			exit(1)
+		}
+		references, err := bioflow.ReadFASTA(*refFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading reference: %v\n", err)
+			exit(1)
+		}
+
+		opts := bioflow.DefaultPileupOptions()
+		opts.MinMapQ = *minMapQ
+		opts.MinBaseQ = *minBaseQ
+		opts.Strand = strand
+		p, err := bioflow.BuildPileup(references, records, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error building pileup: %v\n", err)
+			exit(1)
+		}
+
+		w := createOutput(*output)
+		defer closeOutput(w)
+
+		switch {
+		case *call:
+			callOpts := bioflow.DefaultCallOptions()
+			callOpts.MinDepth = *minDepth
+			callOpts.MinAlleleFraction = *minAF
+			callOpts.MinAltReads = *minAlt
+			if err := bioflow.WriteVCF(w, bioflow.PileupVCF(p, callOpts)); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing VCF: %v\n", err)
+				exit(1)
+			}
+		case *consensus:
+			for _, ref := range references {
+				seq := p.Consensus(ref.ID, *minDepth, *minFraction)
+				if seq == "" {
+					continue
+				}
+				fmt.Fprintf(w, ">%s consensus\n", ref.ID)
+				for i := 0; i < len(seq); i += 60 {
+					end := i + 60
+					if end > len(seq) {
+						end = len(seq)
+					}
+					fmt.Fprintln(w, seq[i:end])
+				}
+			}
+		case *format != "columns":
+			trackFormat, err := bioflow.ParseTrackFormat(*format)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+			tracks := bioflow.PileupCoverageTracks(p)
+			if *window > 0 {
+				tracks, err = bioflow.PileupWindowCoverage(p, bioflow.WindowOptions{Window: *window, Step: *step})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					exit(1)
+				}
+			}
+			if err := bioflow.WriteTracks(w, trackFormat, tracks...); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing track: %v\n", err)
+				exit(1)
+			}
+		default:
+			fmt.Fprintln(w, "chrom\tpos\tref\tdepth\tA\tC\tG\tT\tN\tdel\tins")
+			for _, chrom := range p.Chromosomes() {
+				for _, c := range p.Columns(chrom) {
+					ins := 0
+					for _, n := range c.Insertions {
+						ins += n
+					}
+					fmt.Fprintf(w, "%s\t%d\t%c\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n",
+						c.Chrom, c.Pos, c.RefBase, c.Depth(),
+						c.Counts[0], c.Counts[1], c.Counts[2], c.Counts[3], c.Counts[4], c.Deleted, ins)
+				}
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Used %d reads, skipped %d\n", p.Reads, p.Skipped)
+	}
+}
+
+func snpDensityCmd(fs *flag.FlagSet) func() {
+	vcfFile := fs.String("vcf", "", "Input VCF file (- for stdin)")
+	samFile := fs.String("sam", "", "Input SAM or BAM file to call variants from, instead of -vcf")
+	refFile := fs.String("ref", "", "Reference FASTA file")
+	window := fs.Int("window", bioflow.DefaultDensityWindow, "Window size in bases")
+	step := fs.Int("step", 0, "Window step (default: the window size)")
+	kinds := fs.String("kinds", "snv", "Comma-separated variant kinds counted: snv, mnv, insertion, deletion, complex or all")
+	het := fs.Bool("het", false, "Count heterozygous sites only: in the -sample genotype of a VCF, or calls below -max-af")
+	sample := fs.String("sample", "", "VCF sample whose genotypes -het reads (default: the first)")
+	pass := fs.Bool("pass", false, "Count VCF records that passed all filters only")
+	minMapQ := fs.Int("min-mapq", 0, "Minimum mapping quality of reads for calls")
+	minBaseQ := fs.Int("min-baseq", 13, "Minimum base quality for calls")
+	minDepth := fs.Int("min-depth", 8, "Minimum depth for variant calls")
+	minAF := fs.Float64("min-af", 0.2, "Minimum allele fraction for variant calls")
+	maxAF := fs.Float64("max-af", 0.8, "Largest allele fraction of a heterozygous call with -het")
+	minAlt := fs.Int("min-alt", 2, "Minimum supporting reads for variant calls")
+	name := fs.String("name", "", "Track name (default: snp_density, or het_density with -het)")
+	format := fs.String("format", "bedgraph", "Output format: bedgraph, wig, variablestep, tsv or json")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	addOutputFlags(fs)
+	return func() {
+		if *refFile == "" || (*vcfFile == "") == (*samFile == "") {
+			fmt.Fprintln(os.Stderr, "Error: -ref and one of -vcf or -sam are required")
+			fs.Usage()
+			exit(1)
+		}
+		trackFormat, err := bioflow.ParseTrackFormat(*format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		opts := bioflow.DensityOptions{Window: *window, Step: *step, Name: *name}
+		if opts.Kinds, err = bioflow.ParseVariantKinds(*kinds); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		if opts.Name == "" && *het {
+			opts.Name = "het_density"
+		}
+
+		references, err := bioflow.ReadFASTA(*refFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading reference: %v\n", err)
+			exit(1)
+		}
+
+		var variants []bioflow.Variant
+		if *vcfFile != "" {
+			vcf, err := bioflow.ReadVCF(*vcfFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading VCF: %v\n", err)
+				exit(1)
+			}
+			if variants, err = bioflow.VCFVariants(vcf, *pass, *het, *sample); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+		} else {
+			_, records, err := bioflow.ReadSAM(*samFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading alignments: %v\n", err)
+				exit(1)
+			}
+			pileupOpts := bioflow.DefaultPileupOptions()
+			pileupOpts.MinMapQ = *minMapQ
+			pileupOpts.MinBaseQ = *minBaseQ
+			p, err := bioflow.BuildPileup(references, records, pileupOpts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error building pileup: %v\n", err)
+				exit(1)
+			}
+			callOpts := bioflow.DefaultCallOptions()
+			callOpts.MinDepth = *minDepth
+			callOpts.MinAlleleFraction = *minAF
+			callOpts.MinAltReads = *minAlt
+			for _, call := range bioflow.PileupCalls(p, callOpts) {
+				if !*het || call.AlleleFraction <= *maxAF {
+					variants = append(variants, call.Variant)
+				}
+			}
+		}
+
+		tracks, err := bioflow.VariantDensity(variants, references, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+
+		w := createOutput(*output)
+		defer closeOutput(w)
+		if err := bioflow.WriteTracks(w, trackFormat, tracks...); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing track: %v\n", err)
+			exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Counted %d variants over %d sequences\n", len(variants), len(tracks))
+	}
+}
+
+func coverageCmd(fs *flag.FlagSet) func() {
+	samFile := fs.String("sam", "", "Input SAM or BAM file (- for stdin)")
+	refFile := fs.String("ref", "", "Reference FASTA file")
+	minMapQ := fs.Int("min-mapq", 0, "Minimum mapping quality")
+	minBaseQ := fs.Int("min-baseq", 13, "Minimum base quality")
+	thresholds := fs.String("thresholds", "1,5,10,20,30,50,100", "Comma-separated depths for covered fractions")
+	maxDepth := fs.Int("max-depth", bioflow.DefaultCoverageOptions().MaxDepth, "Histogram depth cap")
+	window := fs.Int("window", bioflow.DefaultCoverageOptions().Window, "Window size for the GC-bias curve")
+	gcBins := fs.Int("gc-bins", bioflow.DefaultCoverageOptions().GCBins, "Number of GC content bins")
+	format := fs.String("format", "tsv", "Output format: tsv or json")
+	strandName := fs.String("strand", "both", "Reads to count by the strand they align to: forward, reverse or both")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	addOutputFlags(fs)
+	return func() {
+		strand := parseStrand(*strandName)
+
+		if *samFile == "" || *refFile == "" {
+			fmt.Fprintln(os.Stderr, "Error: -sam and -ref are required")
+			fs.Usage()
+			exit(1)
+		}
+
+		opts := bioflow.DefaultCoverageOptions()
+		opts.MaxDepth = *maxDepth
+		opts.Window = *window
+		opts.GCBins = *gcBins
+		opts.Thresholds = nil
+		for _, field := range strings.Split(*thresholds, ",") {
+			var t int
+			if _, err := fmt.Sscanf(strings.TrimSpace(field), "%d", &t); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid threshold %q\n", field)
+				exit(1)
+			}
+			opts.Thresholds = append(opts.Thresholds, t)
+		}
+
+		_, records, err := bioflow.ReadSAM(*samFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading alignments: %v\n", err)
+			exit(1)
+		}
+		references, err := bioflow.ReadFASTA(*refFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading reference: %v\n", err)
+			exit(1)
+		}
+
+		pileupOpts := bioflow.DefaultPileupOptions()
+		pileupOpts.MinMapQ = *minMapQ
+		pileupOpts.MinBaseQ = *minBaseQ
+		pileupOpts.Strand = strand
+		p, err := bioflow.BuildPileup(references, records, pileupOpts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error building pileup: %v\n", err)
+			exit(1)
+		}
+		report, err := bioflow.PileupCoverageReport(references, p, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error computing coverage: %v\n", err)
+			exit(1)
+		}
+
+		w := createOutput(*output)
+		defer closeOutput(w)
+
+		switch *format {
+		case "json":
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(report)
+		case "tsv":
+			err = report.WriteTSV(w)
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unknown format %q\n", *format)
+			exit(1)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+			exit(1)
+		}
+	}
+}
+
+func liftoverCmd(fs *flag.FlagSet) func() {
+	bedFile := fs.String("bed", "", "Intervals to lift, as BED (- for stdin)")
+	chainFile := fs.String("chain", "", "UCSC chain file from the old to the new assembly")
+	fromFile := fs.String("from", "", "Old assembly FASTA (builds chains with -to instead of -chain)")
+	toFile := fs.String("to", "", "New assembly FASTA")
+	k := fs.Int("k", 19, "Anchor k-mer size when building chains")
+	writeChain := fs.String("write-chain", "", "Write the built chains to this file")
+	minMatch := fs.Float64("min-match", bioflow.DefaultLiftMinMatch, "Minimum fraction of bases that must remap")
+	output := fs.String("o", "", "Lifted BED output (default: stdout)")
+	unmapped := fs.String("unmapped", "", "Write failed intervals with reasons to this file")
+	asJSON := fs.Bool("json", false, "Write per-interval results as JSON instead of BED")
+	addOutputFlags(fs)
+	return func() {
+		if *bedFile == "" || (*chainFile == "") == (*fromFile == "" || *toFile == "") {
+			fmt.Fprintln(os.Stderr, "Error: -bed and either -chain or both -from and -to are required")
+			fs.Usage()
+			exit(1)
+		}
+
+		var chains []*bioflow.LiftChain
+		var err error
+		if *chainFile != "" {
+			chains, err = bioflow.ReadChains(*chainFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading chains: %v\n", err)
+				exit(1)
+			}
+		} else {
+			from, err := bioflow.ReadFASTA(*fromFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading old assembly: %v\n", err)
+				exit(1)
+			}
+			to, err := bioflow.ReadFASTA(*toFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading new assembly: %v\n", err)
+				exit(1)
+			}
+			chains, err = bioflow.BuildLiftChains(from, to, *k)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error building chains: %v\n", err)
+				exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Built %d chains\n", len(chains))
+		}
+		if *writeChain != "" {
+			f := createOutput(*writeChain)
+			if err := bioflow.WriteChains(f, chains); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing chains: %v\n", err)
+				exit(1)
+			}
+			closeOutput(f)
+		}
+
+		intervals, err := bioflow.ReadBED(*bedFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading BED: %v\n", err)
+			exit(1)
+		}
+		lifter, err := bioflow.NewLifter(chains, *minMatch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		results := lifter.LiftAll(intervals)
+
+		w := createOutput(*output)
+		defer closeOutput(w)
+
+		counts := map[bioflow.LiftStatus]int{}
+		lifted := make([]bioflow.LiftInterval, 0, len(results))
+		for _, r := range results {
+			counts[r.Status]++
+			if r.Target != nil {
+				lifted = append(lifted, *r.Target)
+			}
+		}
+		if *asJSON {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(results)
+		} else {
+			err = bioflow.WriteBED(w, lifted)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			exit(1)
+		}
+
+		if *unmapped != "" {
+			f := createOutput(*unmapped)
+			defer closeOutput(f)
+			for _, r := range results {
+				if r.Status == bioflow.LiftFailed {
+					fmt.Fprintf(f, "#%s\n", r.Reason)
+					bioflow.WriteBED(f, []bioflow.LiftInterval{r.Source})
+				}
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Lifted %d, partial %d, failed %d\n",
+			counts[bioflow.LiftLifted], counts[bioflow.LiftPartial], counts[bioflow.LiftFailed])
+	}
+}
+
+func asmStatsCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "Assembly FASTA file (contigs or scaffolds)")
+	refFile := fs.String("ref", "", "Optional reference FASTA for genome fraction and misassemblies")
+	genomeSize := fs.Int("genome-size", 0, "Expected genome size for NG50 (default: reference length)")
+	minContig := fs.Int("min-contig", bioflow.DefaultMinContig, "Skip contigs shorter than this")
+	k := fs.Int("k", bioflow.DefaultAssemblyAlignOptions().K, "Anchor k-mer size for reference alignment")
+	minBlock := fs.Int("min-block", bioflow.DefaultAssemblyAlignOptions().MinBlock, "Shortest aligned block kept")
+	relocation := fs.Int("relocation", bioflow.DefaultAssemblyAlignOptions().RelocationDistance, "Reference gap that makes a relocation")
+	format := fs.String("format", "text", "Output format: text, json or html")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	addOutputFlags(fs)
+	return func() {
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+
+		contigs, err := bioflow.ReadFASTA(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading assembly: %v\n", err)
+			exit(1)
+		}
+		var references []*bioflow.Sequence
+		if *refFile != "" {
+			references, err = bioflow.ReadFASTA(*refFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading reference: %v\n", err)
+				exit(1)
+			}
+		}
+
+		opts := bioflow.AssemblyOptions{MinContig: *minContig, GenomeSize: *genomeSize}
+		align := bioflow.AssemblyAlignOptions{K: *k, MinBlock: *minBlock, RelocationDistance: *relocation}
+		report, err := bioflow.AssemblyStats(contigs, references, opts, align)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error computing assembly stats: %v\n", err)
+			exit(1)
+		}
+
+		w := createOutput(*output)
+		defer closeOutput(w)
+
+		switch *format {
+		case "text":
+			err = report.WriteText(w)
+		case "json":
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(report)
+		case "html":
+			err = report.WriteHTML(w)
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unknown format %q (want text, json or html)\n", *format)
+			exit(1)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+			exit(1)
+		}
+	}
+}
+
+func gapsCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "Scaffold FASTA file")
+	minGap := fs.Int("min-gap", 1, "Shortest N run reported as a gap")
+	bedOut := fs.String("bed", "", "Write gap intervals to this BED file")
+	split := fs.Int("split", 0, "Split scaffolds at gaps of at least this length (0: don't split)")
+	contigsOut := fs.String("contigs", "", "Write split contigs to this FASTA file (with -split)")
+	agpOut := fs.String("agp", "", "Write AGP placements of split contigs to this file (with -split)")
+	asJSON := fs.Bool("json", false, "Print gap statistics as JSON")
+	addOutputFlags(fs)
+	return func() {
+		bioflow.SetOutputDefaults(outputOptions)
+
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+		if *split == 0 && (*contigsOut != "" || *agpOut != "") {
+			fmt.Fprintln(os.Stderr, "Error: -contigs and -agp require -split")
+			exit(1)
+		}
+
+		scaffolds, err := bioflow.ReadFASTA(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+
+		stats, gaps := bioflow.FindGaps(scaffolds, *minGap)
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(stats); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+				exit(1)
+			}
+		} else {
+			fmt.Printf("Scaffolds: %d (%d with gaps)\n", stats.Sequences, stats.ScaffoldsWithGaps)
+			fmt.Printf("Gaps: %d totalling %d bp\n", stats.Count, stats.TotalLength)
+			if stats.Count > 0 {
+				fmt.Printf("Gap length: min %d, median %d, mean %.1f, max %d\n",
+					stats.MinLength, stats.MedianLength, stats.MeanLength, stats.MaxLength)
+				fmt.Printf("Unknown-size (%d bp) gaps: %d\n", bioflow.UnknownGapLength, stats.UnknownSize)
+				for _, bin := range stats.Distribution {
+					if bin.Max == 0 {
+						fmt.Printf("  >=%d bp: %d\n", bin.Min, bin.Count)
+					} else {
+						fmt.Printf("  %d-%d bp: %d\n", bin.Min, bin.Max, bin.Count)
+					}
+				}
+			}
+		}
+
+		if *bedOut != "" {
+			intervals := make([]bioflow.LiftInterval, len(gaps))
+			for i, g := range gaps {
+				intervals[i] = bioflow.LiftInterval{Chrom: g.SequenceID, Start: g.Start, End: g.End}
+			}
+			f := createOutput(*bedOut)
+			if err := bioflow.WriteBED(f, intervals); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing BED: %v\n", err)
+				exit(1)
+			}
+			closeOutput(f)
+		}
+
+		if *split > 0 {
+			contigs, records, err := bioflow.SplitScaffolds(scaffolds, *split)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error splitting scaffolds: %v\n", err)
+				exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Split %d scaffolds into %d contigs\n", len(scaffolds), len(contigs))
+			if *contigsOut != "" {
+				if err := bioflow.WriteFASTA(*contigsOut, contigs); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing contigs: %v\n", err)
+					exit(1)
+				}
+			}
+			if *agpOut != "" {
+				f := createOutput(*agpOut)
+				if err := bioflow.WriteAGP(f, records); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing AGP: %v\n", err)
+					exit(1)
+				}
+				closeOutput(f)
+			}
+		}
+	}
+}
+
+func qcCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "FASTQ file to analyze")
+	k := fs.Int("k", bioflow.DefaultQCOptions().KMers.K, "K-mer size for positional enrichment")
+	minCount := fs.Int("min-count", bioflow.DefaultQCOptions().KMers.MinCount, "Fewest occurrences of a reported k-mer")
+	minRatio := fs.Float64("min-ratio", bioflow.DefaultQCOptions().KMers.MinRatio, "Smallest observed/expected ratio of a reported k-mer")
+	top := fs.Int("top", bioflow.DefaultQCOptions().KMers.Top, "Maximum number of reported k-mers (0: all)")
+	adapter := fs.String("adapter", "", "Additional adapter sequence to scan for")
+	asJSON := fs.Bool("json", false, "Output the report as JSON")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	htmlOut := fs.String("html", "", "Also write a self-contained HTML report to this file")
+	metadata := fs.String("metadata", "", "Read group sidecar file (key=value: sample, library, run, lane, platform)")
+	addOutputFlags(fs)
+	return func() {
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+
+		reads, err := bioflow.ReadFASTQ(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+		declared := readGroupMetadata(*metadata)
+		bioflow.AssignReadGroups(reads, declared)
+
+		opts := bioflow.DefaultQCOptions()
+		opts.KMers.K = *k
+		opts.KMers.MinCount = *minCount
+		opts.KMers.MinRatio = *minRatio
+		opts.KMers.Top = *top
+		if *adapter != "" {
+			opts.Adapters = append(opts.Adapters, bioflow.Adapter{Name: "Custom", Sequence: strings.ToUpper(*adapter)})
+		}
+
+		report, err := bioflow.ReadQC(reads, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error building QC report: %v\n", err)
+			exit(1)
+		}
+		if *htmlOut != "" {
+			writeHTMLReport(*htmlOut, bioflow.QCHTMLReport("QC report: "+filepath.Base(*file), report))
+		}
+
+		w := createOutput(*output)
+		defer closeOutput(w)
+
+		if *asJSON {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(report)
+		} else {
+			err = report.WriteText(w)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+			exit(1)
+		}
+	}
+}
+
+// writeHTMLReport writes an HTML report to path, exiting on error.
+func writeHTMLReport(path string, r *bioflow.HTMLReport) {
+	f := createOutput(path)
+	if err := r.WriteHTML(f); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing HTML report: %v\n", err)
+		exit(1)
+	}
+	closeOutput(f)
+}
+
+func compareStatsCmd(fs *flag.FlagSet) func() {
+	fileA := fs.String("a", "", "First FASTA or FASTQ file (e.g. before filtering)")
+	fileB := fs.String("b", "", "Second FASTA or FASTQ file (e.g. after filtering)")
+	format := fs.String("format", "auto", "Input format: auto, fasta, fastq (auto uses the extension)")
+	jsonOut := fs.Bool("json", false, "Write the comparison as JSON")
+	output := fs.String("o", "", "Output file (default stdout)")
+	addOutputFlags(fs)
+	return func() {
+		if *fileA == "" || *fileB == "" {
+			fmt.Fprintln(os.Stderr, "Error: -a and -b are required")
+			fs.Usage()
+			exit(1)
+		}
+
+		fastqA, err := isFASTQ(*fileA, *format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		fastqB, _ := isFASTQ(*fileB, *format)
+
+		var comparison *bioflow.SetComparison
+		if fastqA && fastqB {
+			readsA, err := bioflow.ReadFASTQ(*fileA)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				exit(1)
+			}
+			readsB, err := bioflow.ReadFASTQ(*fileB)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				exit(1)
+			}
+			comparison, err = bioflow.CompareReadSets(readsA, readsB)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error comparing sets: %v\n", err)
+				exit(1)
+			}
+		} else {
+			seqsA, err := readSequenceSet(*fileA, fastqA)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				exit(1)
+			}
+			seqsB, err := readSequenceSet(*fileB, fastqB)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				exit(1)
+			}
+			comparison, err = bioflow.CompareSequenceSets(seqsA, seqsB)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error comparing sets: %v\n", err)
+				exit(1)
+			}
+		}
+		comparison.LabelA, comparison.LabelB = filepath.Base(*fileA), filepath.Base(*fileB)
+
+		w := createOutput(*output)
+		defer closeOutput(w)
+
+		if *jsonOut {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(comparison)
+		} else {
+			err = comparison.WriteText(w)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing comparison: %v\n", err)
+			exit(1)
+		}
+	}
+}
+
+// isFASTQ resolves an input format, using the file extension for "auto".
+func isFASTQ(file, format string) (bool, error) {
+	switch format {
+	case "auto":
+		lower := strings.ToLower(bioflow.TrimCompressionExt(file))
+		return strings.HasSuffix(lower, ".fastq") || strings.HasSuffix(lower, ".fq"), nil
+	case "fastq":
+		return true, nil
+	case "fasta":
+		return false, nil
+	}
+	return false, fmt.Errorf("unknown format %q", format)
+}
+
+// readSequenceSet reads the sequences of a FASTA or FASTQ file.
+func readSequenceSet(file string, fastq bool) ([]*bioflow.Sequence, error) {
+	if !fastq {
+		return bioflow.ReadFASTA(file)
+	}
+	reads, err := bioflow.ReadFASTQ(file)
+	if err != nil {
+		return nil, err
+	}
+	sequences := make([]*bioflow.Sequence, len(reads))
+	for i, r := range reads {
+		sequences[i] = r.Sequence
+	}
+	return sequences, nil
+}
+
+func windowsCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "FASTA file to profile")
+	statList := fs.String("stats", "gc,gc_skew", "Comma-separated statistics: "+strings.Join(bioflow.WindowStatisticNames(), ", "))
+	window := fs.Int("window", 1000, "Window size")
+	step := fs.Int("step", 0, "Window step (default: half the window)")
+	k := fs.Int("k", 6, "Largest k-mer size for linguistic complexity")
+	format := fs.String("format", "tsv", "Output format: tsv, json, bedgraph, wig or variablestep")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	addOutputFlags(fs)
+	return func() {
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+		statistics, err := bioflow.ParseWindowStatistics(*statList, *k)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		trackFormat, err := bioflow.ParseTrackFormat(*format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+
+		sequences, err := bioflow.ReadFASTA(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+
+		opts := bioflow.WindowOptions{Window: *window, Step: *step}
+		var tracks []*bioflow.Track
+		for _, s := range sequences {
+			t, err := bioflow.WindowTracks(s, opts, statistics...)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error profiling %s: %v\n", s.ID, err)
+				exit(1)
+			}
+			tracks = append(tracks, t...)
+		}
+
+		w := createOutput(*output)
+		defer closeOutput(w)
+		if err := bioflow.WriteTracks(w, trackFormat, tracks...); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			exit(1)
+		}
+	}
+}
+
+func validateCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "FASTA or FASTQ file to check (more files may follow the flags)")
+	format := fs.String("format", "auto", "Input format: auto, fasta or fastq")
+	alphabet := fs.String("alphabet", "dna", "Accepted bases: dna, rna or iupac")
+	maxIssues := fs.Int("max-issues", bioflow.DefaultValidationOptions().MaxIssues, "Issues to list per file (-1 for all)")
+	jsonOut := fs.Bool("json", false, "Write the reports as JSON")
+	return func() {
+		files := fs.Args()
+		if *file != "" {
+			files = append([]string{*file}, files...)
+		}
+		if len(files) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+
+		opts := bioflow.DefaultValidationOptions()
+		opts.Format = bioflow.ValidationFormat(*format)
+		opts.Alphabet = bioflow.ValidationAlphabet(*alphabet)
+		opts.MaxIssues = *maxIssues
+
+		passed := true
+		reports := make(map[string]*bioflow.ValidationReport, len(files))
+		for _, f := range files {
+			report, err := bioflow.ValidateFile(f, opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error validating %s: %v\n", f, err)
+				exit(1)
+			}
+			reports[f] = report
+			passed = passed && report.Passed()
+			if !*jsonOut {
+				if len(files) > 1 {
+					fmt.Printf("== %s\n", f)
+				}
+				report.WriteText(os.Stdout)
+			}
+		}
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(reports)
+		}
+		if !passed {
+			exit(1)
+		}
+	}
+}
+
+// subsetCmd selects, orders, groups and combines FASTA sequence sets.
+func subsetCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "Input FASTA file")
+	ids := fs.String("ids", "", "Comma-separated IDs to keep, in the given order")
+	minLen := fs.Int("min-length", 0, "Minimum sequence length")
+	maxLen := fs.Int("max-length", 0, "Maximum sequence length (0 for no limit)")
+	minGC := fs.Float64("min-gc", 0, "Minimum GC content (0-1)")
+	maxGC := fs.Float64("max-gc", 1, "Maximum GC content (0-1)")
+	sortBy := fs.String("sort", "", "Sort by id, length or gc")
+	descending := fs.Bool("desc", false, "Sort in descending order")
+	sample := fs.Int("sample", 0, "Keep this many randomly chosen sequences, in their original order")
+	shuffle := fs.Bool("shuffle", false, "Write the sequences in random order")
+	seed := fs.Int64("seed", bioflow.DefaultSeed, "Random seed for -sample and -shuffle")
+	union := fs.String("union", "", "Add the sequences of this FASTA file not already present")
+	intersect := fs.String("intersect", "", "Keep only sequences also present in this FASTA file")
+	subtract := fs.String("subtract", "", "Drop sequences present in this FASTA file")
+	by := fs.String("by", "id", "Match sequences for -union/-intersect/-subtract by id or checksum")
+	group := fs.String("group", "", "Summarize groups by a description regex (first capture group) instead of writing FASTA")
+	output := fs.String("o", "", "Output FASTA file (default: stdout)")
+	addOutputFlags(fs)
+	return func() {
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+
+		set, err := bioflow.ReadSequenceSet(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+		key, err := bioflow.ParseSetKey(*by)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+
+		for _, op := range []struct {
+			file  string
+			apply func(other *bioflow.SequenceSet) *bioflow.SequenceSet
+		}{
+			{*union, func(o *bioflow.SequenceSet) *bioflow.SequenceSet { return set.Union(o, key) }},
+			{*intersect, func(o *bioflow.SequenceSet) *bioflow.SequenceSet { return set.Intersect(o, key) }},
+			{*subtract, func(o *bioflow.SequenceSet) *bioflow.SequenceSet { return set.Subtract(o, key) }},
+		} {
+			if op.file == "" {
+				continue
+			}
+			other, err := bioflow.ReadSequenceSet(op.file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", op.file, err)
+				exit(1)
+			}
+			set = op.apply(other)
+		}
+
+		if *ids != "" {
+			selected := bioflow.NewSequenceSet()
+			for _, id := range strings.Split(*ids, ",") {
+				id = strings.TrimSpace(id)
+				seq, ok := set.Get(id)
+				if !ok {
+					fmt.Fprintf(os.Stderr, "Error: sequence %q not found\n", id)
+					exit(1)
+				}
+				selected.Add(seq)
+			}
+			set = selected
+		}
+		set = set.FilterLength(*minLen, *maxLen).FilterGC(*minGC, *maxGC)
+
+		if *shuffle && *sortBy != "" {
+			fmt.Fprintln(os.Stderr, "Error: -shuffle and -sort are mutually exclusive")
+			exit(1)
+		}
+		rng := bioflow.NewRand(*seed)
+		if *sample > 0 {
+			set = set.Sample(*sample, rng)
+		}
+		if *shuffle {
+			set = set.Shuffle(rng)
+		}
+		if *sortBy != "" {
+			sortKey, err := bioflow.ParseSortKey(*sortBy)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+			set = set.Sort(sortKey, *descending)
+		}
+
+		w := createOutput(*output)
+		defer closeOutput(w)
+
+		if *group != "" {
+			pattern, err := regexp.Compile(*group)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid -group pattern: %v\n", err)
+				exit(1)
+			}
+			fmt.Fprintf(w, "%-20s %10s %12s\n", "group", "sequences", "bases")
+			for _, g := range set.GroupBy(pattern) {
+				name := g.Key
+				if name == "" {
+					name = "(unmatched)"
+				}
+				fmt.Fprintf(w, "%-20s %10d %12d\n", name, g.Set.Len(), g.Set.TotalBases())
+			}
+			return
+		}
+
+		for _, seq := range set.Sequences() {
+			if _, err := io.WriteString(w, seq.ToFASTA()); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+				exit(1)
+			}
+		}
+		w.AddRecords(set.Len())
+		fmt.Fprintf(os.Stderr, "Wrote %d sequences (%d bp)\n", set.Len(), set.TotalBases())
+	}
+}
+
+// readgroupCmd reports the read groups of a FASTQ file, or tags a SAM/BAM
+// file with @RG header lines and RG tags.
+func readgroupCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "FASTQ file whose read groups to report")
+	samFile := fs.String("sam", "", "SAM/BAM file to tag with read groups (written as SAM)")
+	metadata := fs.String("metadata", "", "Read group sidecar file (key=value: sample, library, run, lane, platform)")
+	asJSON := fs.Bool("json", false, "Report read groups as JSON")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	addOutputFlags(fs)
+	return func() {
+		if (*file == "") == (*samFile == "") {
+			fmt.Fprintln(os.Stderr, "Error: exactly one of -file and -sam is required")
+			fs.Usage()
+			exit(1)
+		}
+		declared := readGroupMetadata(*metadata)
+
+		w := createOutput(*output)
+		defer closeOutput(w)
+
+		if *samFile != "" {
+			header, records, err := bioflow.ReadSAM(*samFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading SAM: %v\n", err)
+				exit(1)
+			}
+			groups := bioflow.TagReadGroups(header, records, declared)
+			if err := bioflow.WriteSAM(w, header, records); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+				exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Tagged %d records with %d read groups\n", len(records), len(groups))
+			return
+		}
+
+		reads, err := bioflow.ReadFASTQ(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+		groups := bioflow.AssignReadGroups(reads, declared)
+		if *asJSON {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			enc.Encode(groups)
+			return
+		}
+		if len(groups) == 0 {
+			fmt.Fprintln(w, "No read group information in read names; use -metadata")
+			return
+		}
+		for _, g := range groups {
+			fmt.Fprintf(w, "%s\t# %d reads\n", g.SAMHeaderLine(), g.Reads)
+		}
+	}
+}
+
+// readGroupMetadata reads a read group sidecar file, or returns an empty
+// read group when no file is given.
+func readGroupMetadata(file string) bioflow.ReadGroup {
+	if file == "" {
+		return bioflow.ReadGroup{}
+	}
+	g, err := bioflow.ParseReadGroup(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading metadata: %v\n", err)
+		exit(1)
+	}
+	return g
+}
+
+// runCmd runs a pipeline defined in a YAML file:
+//
+//	bioflow run [-dry-run] [-fresh] [-json] pipeline.yaml
+func runCmd(fs *flag.FlagSet) func() {
+	dryRun := fs.Bool("dry-run", false, "Validate the pipeline and show what would run")
+	fresh := fs.Bool("fresh", false, "Ignore earlier runs and run every stage")
+	asJSON := fs.Bool("json", false, "Print the stage reports as JSON")
+	progress := fs.Int("progress", bioflow.DefaultProgressEvery, "Log progress every N reads (0 disables)")
+	interval := fs.Duration("progress-interval", 10*time.Second, "Also log progress at this interval (0 disables)")
+	sampleRejected := fs.Int("sample-rejected", 0, "Log the first N rejected reads of each stage")
+	addOutputFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bioflow run [options] pipeline.yaml")
+		fmt.Fprintf(os.Stderr, "Stage types: %s\n", strings.Join(bioflow.WorkflowStageTypes(), ", "))
+		fs.PrintDefaults()
+	}
+	return func() {
+		bioflow.SetOutputDefaults(outputOptions)
+		specFile := fs.Arg(0)
+		if specFile == "" {
+			fs.Usage()
+			exit(1)
+		}
+		if m := outputOptions.Manifest; m != nil {
+			m.Arguments = []string{specFile}
+		}
+
+		spec, err := bioflow.LoadWorkflow(specFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading pipeline: %v\n", err)
+			exit(1)
+		}
+		result, err := bioflow.RunWorkflow(spec, bioflow.WorkflowOptions{
+			DryRun:  *dryRun,
+			Fresh:   *fresh,
+			Log:     os.Stderr,
+			Monitor: progressMonitor(*progress, *interval, *sampleRejected, true),
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error running pipeline: %v\n", err)
+			exit(1)
+		}
+
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(result)
+			return
+		}
+		if *dryRun {
+			fmt.Printf("Pipeline %q is valid: %d stages, %d to run\n", spec.Name, len(spec.Stages), len(spec.Stages)-result.Resume)
+			return
+		}
+		fmt.Printf("%-16s %-8s %10s %10s %10s\n", "Stage", "Type", "In", "Out", "Removed")
+		for _, s := range result.Stages {
+			note := ""
+			if s.Skipped {
+				note = "  (resumed)"
+			}
+			fmt.Printf("%-16s %-8s %10d %10d %10d%s\n", s.Stage, s.Type, s.InputReads, s.OutputReads, s.Removed, note)
+		}
+	}
+}
+
+// batchCmd runs a pipeline over every sample of a sample sheet and
+// summarizes the samples side by side.
+func batchCmd(fs *flag.FlagSet) func() {
+	sheetFile := fs.String("sheet", "", "Sample sheet: Illumina SampleSheet.csv, or a CSV/TSV table with sample, fastq and stage.param columns")
+	fastqDir := fs.String("fastq-dir", "", "Directory to find the FASTQ of samples without a fastq column in (e.g. bcl2fastq output)")
+	dryRun := fs.Bool("dry-run", false, "Validate the pipeline for every sample and show what would run")
+	fresh := fs.Bool("fresh", false, "Ignore earlier runs and run every stage of every sample")
+	keepGoing := fs.Bool("keep-going", false, "Run the remaining samples after one fails")
+	asJSON := fs.Bool("json", false, "Print the results of all samples as JSON")
+	html := fs.String("html", "", "Also write a multi-sample HTML report to this file")
+	output := fs.String("o", "", "Summary TSV file (default: stdout)")
+	progress := fs.Int("progress", 0, "Log progress every N reads (0 disables)")
+	sampleRejected := fs.Int("sample-rejected", 0, "Log the first N rejected reads of each stage")
+	addOutputFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bioflow batch [options] -sheet samples.csv pipeline.yaml")
+		fmt.Fprintf(os.Stderr, "Use %s in output file names to give each sample its own files.\n", bioflow.SamplePlaceholder)
+		fs.PrintDefaults()
+	}
+	return func() {
+		specFile := fs.Arg(0)
+		if specFile == "" || *sheetFile == "" {
+			fs.Usage()
+			exit(1)
+		}
+		if m := outputOptions.Manifest; m != nil {
+			m.Arguments = []string{specFile}
+		}
+
+		spec, err := bioflow.LoadWorkflow(specFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading pipeline: %v\n", err)
+			exit(1)
+		}
+		sheet, err := bioflow.LoadSampleSheet(*sheetFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading sample sheet: %v\n", err)
+			exit(1)
+		}
+		if *fastqDir != "" {
+			if err := sheet.FindFASTQ(*fastqDir); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+		}
+
+		result, err := bioflow.RunBatch(spec, sheet, bioflow.BatchOptions{
+			WorkflowOptions: bioflow.WorkflowOptions{
+				DryRun:  *dryRun,
+				Fresh:   *fresh,
+				Log:     os.Stderr,
+				Monitor: progressMonitor(*progress, 0, *sampleRejected, false),
+			},
+			KeepGoing: *keepGoing,
+		})
+		if result == nil {
+			fmt.Fprintf(os.Stderr, "Error running batch: %v\n", err)
+			exit(1)
+		}
+
+		if *html != "" {
+			title := "Batch report: " + filepath.Base(*sheetFile)
+			if spec.Name != "" {
+				title = "Batch report: " + spec.Name
+			}
+			writeHTMLReport(*html, bioflow.BatchHTMLReport(title, result))
+		}
+		out := createOutput(*output)
+		if *asJSON {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			enc.Encode(result)
+		} else if err := result.WriteTSV(out); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing summary: %v\n", err)
+			exit(1)
+		}
+		out.AddRecords(len(result.Samples))
+		closeOutput(out)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error running batch: %v\n", err)
+			exit(1)
+		}
+		if n := result.Failed(); n > 0 {
+			fmt.Fprintf(os.Stderr, "%d of %d samples failed\n", n, len(result.Samples))
+			exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Ran %d samples\n", len(result.Samples))
+	}
+}
+
+// progressMonitor logs pipeline events to stderr: progress every n reads
+// and at the given interval, and the first sample rejected reads of each
+// stage. Unless always is set, nothing is logged when all three are zero.
+func progressMonitor(n int, interval time.Duration, sample int, always bool) bioflow.Monitor {
+	if !always && n <= 0 && interval <= 0 && sample <= 0 {
+		return bioflow.Monitor{}
+	}
+	if n <= 0 {
+		n = -1
+	}
+	return bioflow.Monitor{
+		Observer:         bioflow.LogObserver(os.Stderr),
+		ProgressEvery:    n,
+		ProgressInterval: interval,
+		SampleRejected:   sample,
+	}
+}
+
+func importCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "CSV or TSV file to import")
+	delim := fs.String("delim", "auto", "Delimiter: auto (from the file name), csv, tsv, semicolon or a character")
+	columns := fs.String("columns", "", "Column mapping, e.g. id=name,seq=2,qual=quality,desc=notes,meta=plate;well (default: from the header)")
+	noHeader := fs.Bool("no-header", false, "The file has no header row")
+	policyName := fs.String("policy", "strict", "Validation policy: strict, iupac or permissive")
+	format := fs.String("format", "fasta", "Output format: fasta, jsonl, or fastq (needs a quality column)")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	addOutputFlags(fs)
+	return func() {
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "Error: -file is required")
+			fs.Usage()
+			exit(1)
+		}
+		comma, err := bioflow.ParseDelimiter(*delim)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		mapping, err := bioflow.ParseDelimitedColumns(*columns)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing -columns: %v\n", err)
+			exit(1)
+		}
+		policy, err := bioflow.ParsePolicy(*policyName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		opts := bioflow.DelimitedOptions{Comma: comma, NoHeader: *noHeader, Columns: mapping}
+
+		w := createOutput(*output)
+		defer closeOutput(w)
+
+		switch *format {
+		case "fasta":
+			sequences, err := bioflow.ReadDelimitedSequences(*file, opts, policy)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				exit(1)
+			}
+			for _, seq := range sequences {
+				fmt.Fprint(w, seq.ToFASTA())
+			}
+			w.AddRecords(len(sequences))
+			fmt.Fprintf(os.Stderr, "Imported %d sequences\n", len(sequences))
+		case "fastq":
+			reads, err := bioflow.ReadDelimitedReads(*file, opts, policy)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				exit(1)
+			}
+			if err := bioflow.FormatFASTQ(w, reads); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+				exit(1)
+			}
+			w.AddRecords(len(reads))
+			fmt.Fprintf(os.Stderr, "Imported %d reads\n", len(reads))
+		case "jsonl":
+			records, err := bioflow.ReadDelimitedRecords(*file, opts, policy)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				exit(1)
+			}
+			jw := bioflow.NewJSONLWriter(w)
+			for _, rec := range records {
+				if err := jw.Write(rec); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+					exit(1)
+				}
+			}
+			if err := jw.Flush(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+				exit(1)
+			}
+			w.AddRecords(len(records))
+			fmt.Fprintf(os.Stderr, "Imported %d records\n", len(records))
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unknown format %q (use fasta, fastq or jsonl)\n", *format)
+			exit(1)
+		}
+	}
+}
+
+// verifyCmd checks the files listed in a manifest written with -manifest
+// against their recorded sizes and digests.
+func verifyCmd(fs *flag.FlagSet) func() {
+	quiet := fs.Bool("q", false, "Only list files that fail")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bioflow verify [options] manifest.json")
+		fs.PrintDefaults()
+	}
+	return func() {
+		if fs.NArg() != 1 {
+			fs.Usage()
+			exit(1)
+		}
+
+		results, err := bioflow.VerifyManifest(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading manifest: %v\n", err)
+			exit(1)
+		}
+		failed := 0
+		for _, r := range results {
+			if r.Err != nil {
+				failed++
+				fmt.Printf("FAILED  %s: %v\n", r.Entry.Path, r.Err)
+			} else if !*quiet {
+				fmt.Printf("OK      %s\n", r.Entry.Path)
+			}
+		}
+		if failed > 0 {
+			fmt.Fprintf(os.Stderr, "%d of %d files failed verification\n", failed, len(results))
+			exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "All %d files verified\n", len(results))
+	}
+}
+
+// auditCmd checks the hash chain of a bioflow-server audit log directory
+// and optionally exports some of its entries.
+func auditCmd(fs *flag.FlagSet) func() {
+	export := fs.Bool("export", false, "Write the selected entries to stdout after checking")
+	from := fs.String("from", "", "Export entries from this time (RFC 3339)")
+	to := fs.String("to", "", "Export entries before this time (RFC 3339)")
+	user := fs.String("user", "", "Export entries of this user only")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bioflow audit [options] audit-dir")
+		fs.PrintDefaults()
+	}
+	return func() {
+		if fs.NArg() != 1 {
+			fs.Usage()
+			exit(1)
+		}
+
+		f := bioflow.AuditFilter{User: *user}
+		for _, t := range []struct {
+			value string
+			dst   *time.Time
+		}{{*from, &f.From}, {*to, &f.To}} {
+			if t.value == "" {
+				continue
+			}
+			parsed, err := time.Parse(time.RFC3339, t.value)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+			*t.dst = parsed
+		}
+
+		n, err := bioflow.VerifyAuditLog(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "FAILED  %v\n", err)
+			exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "All %d entries verified\n", n)
+		if *export {
+			if _, err := bioflow.ExportAuditLog(fs.Arg(0), f, os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+		}
+	}
+}
+
+// anonymizeCmd replaces the identifiers of a FASTA or FASTQ file with
+// keyed pseudonyms and strips header descriptions.
+func anonymizeCmd(fs *flag.FlagSet) func() {
+	file := fs.String("file", "", "FASTA or FASTQ file to anonymize")
+	format := fs.String("format", "auto", "Input format: auto, fasta or fastq")
+	keyFile := fs.String("key-file", "", "File holding the secret key")
+	generateKey := fs.String("generate-key", "", "Write a new random key to this file and exit")
+	prefix := fs.String("prefix", "", "Prefix of the pseudonyms")
+	length := fs.Int("length", 16, "Hex digits per pseudonym (8-64)")
+	keepDesc := fs.Bool("keep-description", false, "Keep header text after the identifier")
+	lookup := fs.String("lookup", "", "Print the pseudonym of this identifier and exit")
+	policyName := fs.String("policy", "strict", "Validation policy: strict, iupac or permissive")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	addOutputFlags(fs)
+	return func() {
+		if *generateKey != "" {
+			key, err := bioflow.GenerateAnonymizationKey()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+			// Keys are secrets: never replace one, and keep it private.
+			f, err := os.OpenFile(*generateKey, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+			if err == nil {
+				_, err = fmt.Fprintln(f, key)
+				if cerr := f.Close(); err == nil {
+					err = cerr
+				}
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing key: %v\n", err)
+				exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Wrote a new key to %s\n", *generateKey)
+			return
+		}
+
+		if *keyFile == "" || (*file == "" && *lookup == "") {
+			fmt.Fprintln(os.Stderr, "Error: -key-file and -file (or -lookup) are required")
+			fs.Usage()
+			exit(1)
+		}
+		key, err := bioflow.ReadAnonymizationKey(*keyFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		an, err := bioflow.NewAnonymizer(key, bioflow.AnonymizeOptions{Prefix: *prefix, Length: *length, KeepDescription: *keepDesc})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		if *lookup != "" {
+			fmt.Println(an.Pseudonym(*lookup))
+			return
+		}
+
+		fastq, err := isFASTQ(*file, *format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		policy, err := bioflow.ParsePolicy(*policyName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		in, err := bioflow.OpenInput(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+		defer in.Close()
+
+		w := createOutput(*output)
+		defer closeOutput(w)
+
+		n := 0
+		if fastq {
+			fr := bioflow.NewFASTQReader(in)
+			fr.SetPolicy(policy)
+			batch := make([]*bioflow.Read, 0, 1000)
+			for {
+				read, err := fr.Next()
+				if err != nil && err != io.EOF {
+					fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+					exit(1)
+				}
+				if read != nil {
+					an.AnonymizeRead(read)
+					batch = append(batch, read)
+				}
+				if len(batch) == cap(batch) || (err == io.EOF && len(batch) > 0) {
+					if err := bioflow.FormatFASTQ(w, batch); err != nil {
+						fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+						exit(1)
+					}
+					n += len(batch)
+					batch = batch[:0]
+				}
+				if err == io.EOF {
+					break
+				}
+			}
+		} else {
+			fr := bioflow.NewFASTAReader(in)
+			fr.SetPolicy(policy)
+			for {
+				seq, err := fr.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+					exit(1)
+				}
+				an.AnonymizeSequence(seq)
+				if _, err := io.WriteString(w, seq.ToFASTA()); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+					exit(1)
+				}
+				n++
+			}
+		}
+		w.AddRecords(n)
+		fmt.Fprintf(os.Stderr, "Anonymized %d records\n", n)
+	}
+}
+
+// command is a built-in command: its summary, the function defining its
+// flags and its positional arguments. The flags function registers the
+// flags on fs and returns the function running the command once they are
+// parsed, so that tooldef describes the flags without running anything.
+type command struct {
+	name    string
+	summary string
+	flags   func(fs *flag.FlagSet) (run func())
+	args    []bioflow.ToolArgument
+}
+
+// commands returns the built-in commands, in the order of the usage.
+func commands() []command {
+	files := func(name, doc string) []bioflow.ToolArgument {
+		return []bioflow.ToolArgument{{Name: name, Type: bioflow.ToolFile, Many: true, Doc: doc}}
+	}
+	return []command{
+		{"info", "Show sequence information", infoCmd, nil},
+		{"gc", "Calculate GC content", gcCmd, nil},
+		{"kmer", "Count k-mers", kmerCmd, nil},
+		{"align", "Align two sequences", alignCmd, nil},
+		{"stats", "Calculate sequence or read (FASTQ) statistics", statsCmd, nil},
+		{"filter", "Filter reads by quality", filterCmd, nil},
+		{"tree", "Build or manipulate Newick trees", treeCmd, nil},
+		{"conservation", "Per-column conservation of an alignment", conservationCmd, nil},
+		{"pssm", "Build a PSSM from an alignment and scan sequences", pssmCmd, nil},
+		{"fold", "Predict RNA secondary structure", foldCmd, nil},
+		{"protein-stats", "Protein physico-chemical properties", proteinStatsCmd, nil},
+		{"backtranslate", "Codon-optimized reverse translation of a protein", backTranslateCmd, nil},
+		{"orf", "Find open reading frames (optionally frameshift-tolerant)", orfCmd, nil},
+		{"telomere", "Report telomeric / repeat-motif content in windows", telomereCmd, nil},
+		{"repeats", "Annotate duplicated regions by self-comparison", repeatsCmd, nil},
+		{"inverted", "Find inverted repeats and hairpins", invertedCmd, nil},
+		{"probes", "Design hybridization probes unique to a target", probesCmd, nil},
+		{"faidx", "Index a FASTA file (.fai) and extract regions", faidxCmd, []bioflow.ToolArgument{{
+			Name: "region", Type: bioflow.ToolString, Many: true, Optional: true,
+			Doc: "Regions to extract: name, name:start or name:start-end, 1-based and inclusive"}}},
+		{"bisulfite", "In-silico bisulfite conversion and methylation contexts", bisulfiteCmd, nil},
+		{"chimera", "Reference-based chimera detection for amplicon sequences", chimeraCmd, nil},
+		{"cluster", "Cluster amplicons into OTUs or ASV-like groups per sample", clusterCmd, files("samples", "FASTQ or FASTA file of each sample")},
+		{"diversity", "Alpha and beta diversity of an abundance table", diversityCmd, nil},
+		{"rarefaction", "Rarefaction curves of richness by subsampled depth", rarefactionCmd, []bioflow.ToolArgument{{
+			Name: "samples", Type: bioflow.ToolFile, Many: true, Optional: true, Doc: "FASTQ or FASTA file of each sample, instead of -table"}}},
+		{"ani", "Average nucleotide identity between genomes", aniCmd, files("genomes", "FASTA file of each genome")},
+		{"completeness", "Genome completeness from conserved marker k-mer panels", completenessCmd, files("genomes", "FASTA file of each genome")},
+		{"complexity", "Per-window entropy and linguistic complexity tracks", complexityCmd, nil},
+		{"cgr", "Chaos game representation (FCGR) vectors and images", cgrCmd, nil},
+		{"kmer-matrix", "Normalized k-mer feature matrices (CSV, NumPy)", kmerMatrixCmd, nil},
+		{"sketch", "Syncmer and randstrobe seed extraction", sketchCmd, nil},
+		{"mappability", "Per-position k-mer uniqueness track (bedGraph/WIG)", mappabilityCmd, nil},
+		{"mask", "Mask bases covered by high-abundance k-mers", maskCmd, nil},
+		{"anchors", "Shared k-mer anchors, collinear chains and PAF output", anchorsCmd, nil},
+		{"realign", "Realign a read around indels and left-normalize its CIGAR", realignCmd, nil},
+		{"vcf-norm", "Left-align, trim and split VCF variants", vcfNormCmd, nil},
+		{"pileup", "Pile up SAM/BAM reads, call variants or build a consensus", pileupCmd, nil},
+		{"snp-density", "Windowed SNP density (per kb) from VCF or pileup calls, as bedGraph", snpDensityCmd, nil},
+		{"coverage", "Coverage uniformity and GC-bias QC report", coverageCmd, nil},
+		{"liftover", "Lift BED intervals to another assembly through chains", liftoverCmd, nil},
+		{"asm-stats", "Assembly QC: N50/NG50, gaps, misassemblies", asmStatsCmd, nil},
+		{"gaps", "N-gap statistics and splitting of scaffolds (AGP)", gapsCmd, nil},
+		{"qc", "Read QC: per-cycle quality, k-mer and adapter content", qcCmd, nil},
+		{"compare-stats", "Compare length/GC/quality distributions of two sets", compareStatsCmd, nil},
+		{"windows", "Windowed GC, skew, entropy and complexity tracks", windowsCmd, nil},
+		{"validate", "Check FASTA/FASTQ files for malformed records", validateCmd, []bioflow.ToolArgument{{
+			Name: "files", Type: bioflow.ToolFile, Many: true, Optional: true, Doc: "More FASTA or FASTQ files to check"}}},
+		{"subset", "Filter, sort, group and combine FASTA sequence sets", subsetCmd, nil},
+		{"readgroup", "Read group metadata from read names; tag SAM @RG", readgroupCmd, nil},
+		{"run", "Run a pipeline defined in a YAML file", runCmd, []bioflow.ToolArgument{{
+			Name: "pipeline", Type: bioflow.ToolFile, Doc: "Pipeline definition (YAML)"}}},
+		{"batch", "Run a pipeline over the samples of a sample sheet", batchCmd, []bioflow.ToolArgument{{
+			Name: "pipeline", Type: bioflow.ToolFile, Doc: "Pipeline definition (YAML)"}}},
+		{"import", "Import sequences or reads from CSV/TSV", importCmd, nil},
+		{"verify", "Check output files against a manifest", verifyCmd, []bioflow.ToolArgument{{
+			Name: "manifest", Type: bioflow.ToolFile, Doc: "Manifest to check the files of"}}},
+		{"anonymize", "Replace identifiers with keyed pseudonyms", anonymizeCmd, nil},
+		{"audit", "Check and export a server's audit log", auditCmd, []bioflow.ToolArgument{{
+			Name: "log", Type: bioflow.ToolDirectory, Doc: "Directory of the audit log"}}},
+	}
+}
+
+// lookupCommand returns the built-in command of a name.
+func lookupCommand(name string) (command, bool) {
+	for _, c := range commands() {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// runCommand parses the flags of a command and runs it. The first
+// argument may also come before the flags, as in "bioflow run
+// pipeline.yaml -dry-run".
+func runCommand(c command, args []string) {
+	fs := flag.NewFlagSet(c.name, flag.ExitOnError)
+	run := c.flags(fs)
+	var first []string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		first, args = args[:1], args[1:]
+	}
+	fs.Parse(args)
+	if first != nil {
+		// Parsing stops at the first argument, leaving it in front of
+		// the others.
+		fs.Parse(append(first, fs.Args()...))
+	}
+	setOutputFlags(fs)
+	run()
+}
+
+// tooldefCmd writes CWL or WDL descriptors of the commands, from their
+// flags, for running them from workflow engines.
+func tooldefCmd(fs *flag.FlagSet) func() {
+	format := fs.String("format", "cwl", "Descriptor format: cwl or wdl")
+	docker := fs.String("docker", "", "Docker image to run the commands in")
+	output := fs.String("o", "", "Output file of all descriptors (default: stdout)")
+	dir := fs.String("dir", "", "Write one descriptor file per command to this directory instead")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bioflow tooldef [options] [command ...]")
+		fmt.Fprintln(os.Stderr, "Describes the given commands, or all of them.")
+		fs.PrintDefaults()
+	}
+	addOutputFlags(fs)
+	return func() {
+		var write func(io.Writer, ...*bioflow.ToolDescriptor) error
+		switch *format {
+		case "cwl":
+			write = bioflow.WriteCWL
+		case "wdl":
+			write = bioflow.WriteWDL
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unknown format %q (want cwl or wdl)\n", *format)
+			exit(1)
+		}
+		if *dir != "" && *output != "" {
+			fmt.Fprintln(os.Stderr, "Error: -o and -dir are exclusive")
+			exit(1)
+		}
+
+		described := commands()
+		if fs.NArg() > 0 {
+			described = described[:0]
+			for _, name := range fs.Args() {
+				c, ok := lookupCommand(name)
+				if !ok {
+					fmt.Fprintf(os.Stderr, "Error: cannot describe command %q\n", name)
+					exit(1)
+				}
+				described = append(described, c)
+			}
+		}
+		tools := make([]*bioflow.ToolDescriptor, len(described))
+		for i, c := range described {
+			tools[i] = &bioflow.ToolDescriptor{
+				Command:     []string{"bioflow", c.name},
+				Description: c.summary,
+				Inputs:      bioflow.ToolInputs(commandFlags(c)),
+				Arguments:   c.args,
+				Docker:      *docker,
+			}
+		}
+
+		if *dir == "" {
+			w := createOutput(*output)
+			if err := write(w, tools...); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing descriptors: %v\n", err)
+				exit(1)
+			}
+			closeOutput(w)
+			return
+		}
+		if err := os.MkdirAll(*dir, 0o755); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		for i, tool := range tools {
+			w := createOutput(filepath.Join(*dir, described[i].name+"."+*format))
+			if err := write(w, tool); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing descriptors: %v\n", err)
+				exit(1)
+			}
+			closeOutput(w)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %d descriptor files to %s\n", len(tools), *dir)
+	}
+}
+
+// exit discards unfinished output files and exits. Commands call it
+// instead of os.Exit, which would skip their deferred cleanup.
+func exit(code int) {
+	bioflow.AbortOutputs()
+	os.Exit(code)
+}
+
+// outputOptions are the output options of the running command, set from
+// the flags addOutputFlags registers.
+var outputOptions bioflow.OutputOptions
+
+// outputFlags are the flags of the running command, and manifestPath the
+// manifest it writes, if any.
+var (
+	outputFlags  *flag.FlagSet
+	manifestPath string
+)
+
+// maskedPolicy reads sequences as the strict policy does, but records
+// their soft-masked runs for -skip-masked.
+func maskedPolicy() bioflow.Policy {
+	policy := bioflow.StrictPolicy()
+	policy.PreserveCase = true
+	return policy
+}
+
+// parseStrand parses a -strand flag, exiting on an unknown name.
+func parseStrand(name string) bioflow.Strand {
+	strand, err := bioflow.ParseStrand(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+	return strand
+}
+
+// commandFlags returns the flags of a command, without running it.
+func commandFlags(c command) *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	c.flags(fs)
+	return fs
+}
+
+// addOutputFlags registers the output flags shared by commands that
+// write files. setOutputFlags reads them once they are parsed.
+func addOutputFlags(fs *flag.FlagSet) {
+	fs.String("manifest", "", "Write a manifest of the output files (size, SHA-256, records) to this file")
+	fs.String("compress", bioflow.CompressionAuto,
+		"Output compression: auto (from the file name), none, or "+strings.Join(bioflow.CompressionCodecs(), ", "))
+	fs.Int("compress-level", 0, "Compression level (0 = codec default)")
+	fs.Bool("fsync", false, "Sync output files to disk before replacing the destination")
+}
+
+// setOutputFlags sets outputFlags to the parsed flags of the running
+// command, and outputOptions and manifestPath from its output flags, if
+// it has them.
+func setOutputFlags(fs *flag.FlagSet) {
+	outputFlags = fs
+	outputOptions, manifestPath = bioflow.OutputOptions{}, ""
+	if fs.Lookup("compress") == nil {
+		return
+	}
+	value := func(name string) interface{} {
+		return fs.Lookup(name).Value.(flag.Getter).Get()
+	}
+	outputOptions.Compression = value("compress").(string)
+	outputOptions.Level = value("compress-level").(int)
+	outputOptions.Sync = value("fsync").(bool)
+	if manifestPath = value("manifest").(string); manifestPath != "" {
+		outputOptions.Manifest = bioflow.NewManifest(fs.Name())
+	}
+}
+
+// createOutput starts writing an output file, or standard output if path
+// is empty, exiting on error. The file only replaces path once
+// closeOutput succeeds.
+func createOutput(path string) *bioflow.OutputFile {
+	bioflow.SetOutputDefaults(outputOptions)
+	f, err := bioflow.CreateOutput(path, outputOptions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating output: %v\n", err)
+		exit(1)
+	}
+	return f
+}
+
+// closeOutput finishes an output file, exiting on error.
+func closeOutput(f *bioflow.OutputFile) {
+	if err := f.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		exit(1)
+	}
+}
+
+// writeManifest writes the manifest requested with -manifest, if any,
+// recording the flags and arguments of the command.
+func writeManifest() {
+	m := outputOptions.Manifest
+	if m == nil {
+		return
+	}
+	outputFlags.Visit(func(f *flag.Flag) {
+		if f.Name != "manifest" {
+			m.SetParameter(f.Name, f.Value.String())
+		}
+	})
+	if m.Arguments == nil {
+		m.Arguments = outputFlags.Args()
+	}
+	if err := bioflow.WriteManifest(manifestPath, m); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing manifest: %v\n", err)
+		exit(1)
+	}
+}
+
+// readMSA reads an alignment, using an explicit format name when given.
+func readMSA(path, format string) (*bioflow.MSA, error) {
+	if format == "" {
+		return bioflow.ReadMSA(path)
+	}
+	f, err := bioflow.ParseMSAFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	return bioflow.ReadMSAFormat(path, f)
 }
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aria-lang/bioflow-go/pkg/bioflow"
+)
+
+func TestFilterCmdOutputs(t *testing.T) {
+	insert := strings.Repeat("ACGTTGCA", 8)[:60]
+	adapter := "AGATCGGAAGAGCACACGTCTGAACTCCAGTCA"
+	records := []struct{ id, bases, qual string }{
+		{"clean", insert, strings.Repeat("I", 60)},
+		{"adapter", insert + adapter, strings.Repeat("I", 93)},
+		{"poor-tail", insert + "ACGTACGTAC", strings.Repeat("I", 60) + strings.Repeat("#", 10)},
+		{"low-quality", insert, strings.Repeat("#", 60)},
+		{"short", insert[:20] + adapter, strings.Repeat("I", 53)},
+	}
+	var fastq strings.Builder
+	for _, r := range records {
+		fmt.Fprintf(&fastq, "@%s\n%s\n+\n%s\n", r.id, r.bases, r.qual)
+	}
+	dir := t.TempDir()
+	input := filepath.Join(dir, "reads.fq")
+	require.NoError(t, os.WriteFile(input, []byte(fastq.String()), 0o644))
+
+	// The in-memory and the checkpointed, streaming runs write the same
+	// reads, and print the same counts.
+	summaries := make(map[bool]string)
+	for _, streaming := range []bool{false, true} {
+		t.Run(fmt.Sprintf("streaming=%v", streaming), func(t *testing.T) {
+			passedPath := filepath.Join(dir, fmt.Sprintf("passed-%v.fq", streaming))
+			failedPath := filepath.Join(dir, fmt.Sprintf("failed-%v.fq.gz", streaming))
+			args := []string{"-file", input, "-adapters", "default", "-o", passedPath, "-out-failed", failedPath}
+			if streaming {
+				args = append(args, "-checkpoint", filepath.Join(dir, "filter.checkpoint"))
+			}
+			summaries[streaming] = captureStdout(t, func() {
+				runCommand(command{name: "filter", flags: filterCmd}, args)
+			})
+			assert.Contains(t, summaries[streaming], "Total reads: 5\nAdapter trimmed: 2\nPassed: 3 (60.0%)\nFailed: 2 (40.0%)\n")
+
+			passed, err := bioflow.ReadFASTQ(passedPath)
+			require.NoError(t, err)
+			require.Len(t, passed, 3)
+			assert.Equal(t, "clean", passed[0].Sequence.ID)
+			assert.Equal(t, insert, passed[0].Sequence.Bases)
+			assert.Equal(t, "adapter", passed[1].Sequence.ID)
+			assert.Equal(t, insert, passed[1].Sequence.Bases, "the adapter is trimmed")
+			assert.Equal(t, "poor-tail", passed[2].Sequence.ID)
+			assert.True(t, strings.HasPrefix(records[2].bases, passed[2].Sequence.Bases))
+			assert.Less(t, passed[2].Sequence.Len(), len(records[2].bases), "the poor tail is trimmed")
+			for _, read := range passed {
+				assert.Equal(t, read.Sequence.Len(), read.Quality.Len(), read.Sequence.ID)
+			}
+
+			// Failed reads are written untrimmed, compressed as the file
+			// name says.
+			data, err := os.ReadFile(failedPath)
+			require.NoError(t, err)
+			assert.Equal(t, []byte{0x1f, 0x8b}, data[:2], "gzip magic")
+			failed, err := bioflow.ReadFASTQ(failedPath)
+			require.NoError(t, err)
+			require.Len(t, failed, 2)
+			for i, id := range []string{"low-quality", "short"} {
+				rec := records[3+i]
+				assert.Equal(t, id, failed[i].Sequence.ID)
+				assert.Equal(t, rec.bases, failed[i].Sequence.Bases)
+				assert.Equal(t, len(rec.qual), failed[i].Quality.Len())
+			}
+		})
+	}
+	assert.Equal(t, summaries[false], summaries[true])
+}
+
+// captureStdout returns what fn writes to standard output.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "stdout")
+	require.NoError(t, err)
+	defer f.Close()
+	stdout := os.Stdout
+	os.Stdout = f
+	defer func() { os.Stdout = stdout }()
+	fn()
+	data, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+	return string(data)
+}
@@ -1,8 +1,16 @@
 package alignment
 
 import (
+	"context"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/aria-lang/bioflow-go/internal/data"
+	"github.com/aria-lang/bioflow-go/internal/protein"
 	"github.com/aria-lang/bioflow-go/internal/sequence"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -311,6 +319,389 @@ func TestGlobalAlignmentScoreOnly(t *testing.T) {
 	assert.Equal(t, alignment.Score, score)
 }
 
+func TestKarlinAltschul(t *testing.T) {
+	// Ungapped BLASTN parameters for uniform nucleotides.
+	tests := []struct {
+		match, mismatch int
+		lambda, k, h    float64
+	}{
+		{1, -3, 1.374, 0.711, 1.31},
+		{1, -2, 1.33, 0.621, 1.12},
+		{1, -1, 1.10, 0.333, 0.549},
+	}
+	for _, tt := range tests {
+		ka, err := NewKarlinAltschul(&ScoringMatrix{MatchScore: tt.match, MismatchPenalty: tt.mismatch})
+		require.NoError(t, err)
+		assert.InDelta(t, tt.lambda, ka.Lambda, 0.01)
+		assert.InDelta(t, tt.k, ka.K, 0.01)
+		assert.InDelta(t, tt.h, ka.H, 0.01)
+	}
+
+	_, err := NewKarlinAltschul(&ScoringMatrix{MatchScore: 3, MismatchPenalty: -1})
+	assert.Error(t, err)
+	_, err = NewKarlinAltschulBackground(DefaultDNA(), [4]float64{0.5, 0.5, 0.5, 0.5})
+	assert.Error(t, err)
+}
+
+func TestEValue(t *testing.T) {
+	ka := &KarlinAltschul{Lambda: 1.374, K: 0.711, H: 1.31}
+	assert.InDelta(t, (1.374*30-math.Log(0.711))/math.Ln2, ka.BitScore(30), 1e-9)
+
+	space := SearchSpace{QueryLength: 500, DatabaseLength: 1_000_000, DatabaseSequences: 100}
+	l := ka.LengthAdjustment(space)
+	assert.Greater(t, l, 0)
+	assert.Less(t, l, 30)
+	assert.InDelta(t, float64((500-l)*(1_000_000-100*l)), ka.EffectiveSearchSpace(space), 1e-6)
+
+	// Higher scores are less likely by chance.
+	assert.Less(t, ka.EValue(40, space), ka.EValue(30, space))
+	assert.InDelta(t, ka.EffectiveSearchSpace(space)*math.Pow(2, -ka.BitScore(30)), ka.EValue(30, space), 1e-12)
+	assert.InDelta(t, 1-math.Exp(-ka.EValue(30, space)), ka.PValue(30, space), 1e-12)
+
+	seq1, _ := sequence.New("ACGTACGTACGTAAACCCGGGTTT")
+	seq2, _ := sequence.New("TTTTACGTACGTACGTAAACCCGGGTTTTT")
+	aln, err := SmithWaterman(seq1, seq2, DefaultDNA())
+	require.NoError(t, err)
+	stats, err := NewKarlinAltschul(DefaultDNA())
+	require.NoError(t, err)
+	sig := aln.Significance(stats, SearchSpace{})
+	assert.Equal(t, aln.Score, sig.RawScore)
+	assert.Greater(t, sig.BitScore, 0.0)
+	assert.Less(t, sig.EValue, 1e-3)
+}
+
+func TestAmbiguityScoring(t *testing.T) {
+	scoring := DefaultDNA()
+	assert.Equal(t, 2, scoring.Score('N', 'N'))
+	assert.Equal(t, -1, scoring.Score('N', 'A'))
+
+	neutral := scoring.WithAmbiguity(AmbiguityNeutral)
+	assert.Equal(t, AmbiguityMismatch, scoring.Ambiguity, "original unchanged")
+	assert.Equal(t, 0, neutral.Score('N', 'A'))
+	assert.Equal(t, 0, neutral.Score('G', 'N'))
+	assert.Equal(t, 2, neutral.Score('A', 'A'))
+	assert.Equal(t, -1, neutral.Score('A', 'C'))
+
+	iupac := scoring.WithAmbiguity(AmbiguityIUPAC)
+	assert.Equal(t, 0, iupac.Score('N', 'A'))  // 1/4*2 + 3/4*-1 = -0.25
+	assert.Equal(t, 1, iupac.Score('R', 'A'))  // 1/2*2 + 1/2*-1 = 0.5
+	assert.Equal(t, -1, iupac.Score('R', 'C')) // no shared base
+	assert.Equal(t, 2, iupac.Score('T', 'T'))
+	assert.Equal(t, -1, iupac.Score('C', 'T'))
+
+	mode, err := ParseAmbiguityMode("IUPAC")
+	require.NoError(t, err)
+	assert.Equal(t, AmbiguityIUPAC, mode)
+	_, err = ParseAmbiguityMode("fuzzy")
+	assert.Error(t, err)
+}
+
+func TestAlignWithNeutralN(t *testing.T) {
+	seq1, err := sequence.New("ACGTACGTNNNNACGTACGT")
+	require.NoError(t, err)
+	seq2, err := sequence.New("ACGTACGTTTGCACGTACGT")
+	require.NoError(t, err)
+
+	strict, err := NeedlemanWunsch(seq1, seq2, DefaultDNA())
+	require.NoError(t, err)
+	relaxed, err := NeedlemanWunsch(seq1, seq2, DefaultDNA().WithAmbiguity(AmbiguityNeutral))
+	require.NoError(t, err)
+
+	assert.Equal(t, 16*2-4, strict.Score)
+	assert.Equal(t, 16*2, relaxed.Score)
+}
+
+func TestLocalScoreProfile(t *testing.T) {
+	seq1, err := sequence.New("TTTTTACGTACGGATCCATTTTT")
+	require.NoError(t, err)
+	seq2, err := sequence.New("GGGACGTACGGATCCAGGG")
+	require.NoError(t, err)
+
+	full, err := SmithWaterman(seq1, seq2, nil)
+	require.NoError(t, err)
+
+	profile, err := LocalScoreProfile(seq1, seq2, nil, ScoreProfileOptions{LocateStart: true})
+	require.NoError(t, err)
+	assert.Equal(t, full.Score, profile.Score)
+	assert.Equal(t, full.End1, profile.End1)
+	assert.Equal(t, full.End2, profile.End2)
+	assert.True(t, profile.StartLocated)
+	assert.Equal(t, full.Start1, profile.Start1)
+	assert.Equal(t, full.Start2, profile.Start2)
+
+	require.Len(t, profile.Profile, seq1.Len())
+	assert.Equal(t, full.Score, profile.Profile[profile.End1-1])
+	for _, v := range profile.Profile {
+		assert.LessOrEqual(t, v, full.Score)
+	}
+
+	banded, err := LocalScoreProfile(seq1, seq2, nil, ScoreProfileOptions{BandWidth: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 10, banded.BandWidth)
+	require.Len(t, banded.Profile, 3)
+	assert.Equal(t, full.Score, banded.Profile[1])
+	assert.False(t, banded.StartLocated)
+
+	score, err := AlignmentScoreOnly(seq1, seq2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, score, banded.Score)
+
+	_, err = LocalScoreProfile(seq1, seq2, nil, ScoreProfileOptions{BandWidth: -1})
+	assert.Error(t, err)
+}
+
+func TestAlignmentSummary(t *testing.T) {
+	seq1, err := sequence.New("TTTACGTACGTTT")
+	require.NoError(t, err)
+	seq2, err := sequence.New("GGACGTACGGG")
+	require.NoError(t, err)
+
+	local, err := SmithWaterman(seq1, seq2, nil)
+	require.NoError(t, err)
+	summary := local.Summary()
+	assert.Equal(t, "local", summary.AlignmentType)
+	assert.Equal(t, local.Start1+1, summary.Start1)
+	assert.Equal(t, local.End1, summary.End1)
+	assert.Equal(t, "ACGTACG", seq1.Bases[summary.Start1-1:summary.End1])
+	assert.Equal(t, "ACGTACG", seq2.Bases[summary.Start2-1:summary.End2])
+	assert.Equal(t, local.Length(), summary.AlignedLength)
+	assert.Equal(t, local.ToCIGAR(), summary.CIGAR)
+
+	global, err := NeedlemanWunsch(seq1, seq2, nil)
+	require.NoError(t, err)
+	summary = global.Summary()
+	assert.Equal(t, "global", summary.AlignmentType)
+	assert.Equal(t, 1, summary.Start1)
+	assert.Equal(t, seq1.Len(), summary.End1)
+	assert.Equal(t, seq2.Len(), summary.End2)
+	assert.Equal(t, global.GapOpenings(), summary.GapOpens)
+}
+
+func TestAlignmentLimits(t *testing.T) {
+	seq1, err := sequence.New(strings.Repeat("ACGT", 50))
+	require.NoError(t, err)
+	seq2, err := sequence.New(strings.Repeat("AGCT", 50))
+	require.NoError(t, err)
+
+	limits := Limits{MaxCells: 1000}
+	_, err = SmithWatermanContext(context.Background(), seq1, seq2, nil, limits)
+	assert.ErrorIs(t, err, ErrTooLarge)
+	_, err = NeedlemanWunschContext(context.Background(), seq1, seq2, nil, limits)
+	assert.ErrorIs(t, err, ErrTooLarge)
+	_, err = LocalScoreProfile(seq1, seq2, nil, ScoreProfileOptions{Limits: limits})
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	assert.Equal(t, int64(201*201), Cells(200, 200))
+	assert.NoError(t, Limits{MaxCells: Cells(200, 200)}.Check(200, 200))
+	assert.NoError(t, Limits{}.Check(1e6, 1e6))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = SmithWatermanContext(ctx, seq1, seq2, nil, Limits{})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = LocalScoreProfileContext(ctx, seq1, seq2, nil, ScoreProfileOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	a, err := SmithWatermanContext(context.Background(), seq1, seq2, nil, Limits{MaxCells: Cells(200, 200)})
+	require.NoError(t, err)
+	b, err := SmithWaterman(seq1, seq2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, b.Score, a.Score)
+}
+
+func TestAnchoredAlignment(t *testing.T) {
+	left := "ACGTTGCATGCCATAGGCTA"
+	right := "TTGACCGATGCAATCGGATC"
+	seq1, err := sequence.New(left + "GATTACA" + right)
+	require.NoError(t, err)
+	seq2, err := sequence.New(left + "GACA" + right)
+	require.NoError(t, err)
+
+	anchors := []AnchorPair{
+		{Pos1: 0, Pos2: 0, Length: 12},
+		{Pos1: 8, Pos2: 8, Length: 12}, // overlaps the first on the same diagonal
+		{Pos1: 27, Pos2: 24, Length: 20},
+		{Pos1: 30, Pos2: 2, Length: 5}, // goes backwards in seq2
+	}
+	anchored, err := AnchoredAlignment(seq1, seq2, anchors, nil)
+	require.NoError(t, err)
+	full, err := NeedlemanWunsch(seq1, seq2, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, full.Score, anchored.Score)
+	assert.Equal(t, Global, anchored.AlignmentType)
+	assert.Equal(t, seq1.Bases, strings.ReplaceAll(anchored.AlignedSeq1, "-", ""))
+	assert.Equal(t, seq2.Bases, strings.ReplaceAll(anchored.AlignedSeq2, "-", ""))
+	assert.Equal(t, 3, anchored.TotalGaps())
+
+	blocks := collinearBlocks(anchors)
+	assert.Equal(t, []AnchorPair{{0, 0, 20}, {27, 24, 20}}, blocks)
+
+	none, err := AnchoredAlignment(seq1, seq2, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, full.Score, none.Score)
+
+	_, err = AnchoredAlignment(seq1, seq2, []AnchorPair{{Pos1: 40, Pos2: 0, Length: 10}}, nil)
+	assert.Error(t, err)
+}
+
+func TestParseCIGAR(t *testing.T) {
+	ops, err := ParseCIGAR("5S20M2D3I10M")
+	require.NoError(t, err)
+	assert.Equal(t, []CIGAROp{{'S', 5}, {'M', 20}, {'D', 2}, {'I', 3}, {'M', 10}}, ops)
+	assert.Equal(t, "5S20M2D3I10M", FormatCIGAR(ops))
+	assert.Equal(t, "7M", FormatCIGAR([]CIGAROp{{'M', 3}, {'M', 4}}))
+
+	for _, bad := range []string{"10", "M", "3Q", "0M"} {
+		_, err := ParseCIGAR(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestRealignIndels(t *testing.T) {
+	ref, err := sequence.New("TTGGCTCAAAATGCCGTT")
+	require.NoError(t, err)
+
+	// One A deleted from the homopolymer, reported at its right end.
+	read, err := sequence.New("GGCTCAAATGCCG")
+	require.NoError(t, err)
+	r, err := RealignIndels(ref, read, 2, "8M1D5M", RealignOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "5M1D8M", r.CIGAR)
+	assert.True(t, r.Changed)
+	assert.Equal(t, r.OriginalScore, r.Score)
+	assert.Equal(t, "GGCTC-AAATGCCG", r.AlignedRead)
+
+	// An inserted A, soft clips kept.
+	read, err = sequence.New("NNGGCTCAAAAATGCCG")
+	require.NoError(t, err)
+	r, err = RealignIndels(ref, read, 2, "2S9M1I5M", RealignOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "2S5M1I9M", r.CIGAR)
+
+	// A misplaced deletion is moved to where it belongs.
+	ref, err = sequence.New("ACGTACGTTAGCATGCATCGA")
+	require.NoError(t, err)
+	read, err = sequence.New("ACGTACGTAGCATGCATCGA")
+	require.NoError(t, err)
+	r, err = RealignIndels(ref, read, 0, "3M1D17M", RealignOptions{})
+	require.NoError(t, err)
+	assert.Greater(t, r.Score, r.OriginalScore)
+	assert.Equal(t, "7M1D13M", r.CIGAR)
+
+	// Already normalized alignments are unchanged.
+	r, err = RealignIndels(ref, read, 0, "7M1D13M", RealignOptions{})
+	require.NoError(t, err)
+	assert.False(t, r.Changed)
+
+	_, err = RealignIndels(ref, read, 0, "20M", RealignOptions{})
+	assert.NoError(t, err)
+	_, err = RealignIndels(ref, read, 0, "19M", RealignOptions{})
+	assert.Error(t, err)
+	_, err = RealignIndels(ref, read, 5, "7M1D13M", RealignOptions{})
+	assert.Error(t, err)
+}
+
+func TestAffineGaps(t *testing.T) {
+	align := func(t *testing.T, fn func(*sequence.Sequence, *sequence.Sequence, *ScoringMatrix) (*Alignment, error),
+		s1, s2 string, scoring *ScoringMatrix) *Alignment {
+		seq1, err := sequence.New(s1)
+		require.NoError(t, err)
+		seq2, err := sequence.New(s2)
+		require.NoError(t, err)
+		a, err := fn(seq1, seq2, scoring)
+		require.NoError(t, err)
+		return a
+	}
+
+	t.Run("GapScore", func(t *testing.T) {
+		s := DefaultDNA()
+		assert.Equal(t, 0, s.GapScore(0))
+		assert.Equal(t, -2, s.GapScore(1))
+		assert.Equal(t, -4, s.GapScore(3))
+	})
+
+	t.Run("global one long gap", func(t *testing.T) {
+		// 6 matches and a 3-base gap: 12 - 2 - 1 - 1.
+		a := align(t, NeedlemanWunsch, "AAAGGGTTT", "AAATTT", nil)
+		assert.Equal(t, 8, a.Score)
+		assert.Equal(t, "AAA---TTT", a.AlignedSeq2)
+		assert.Equal(t, 1, a.GapOpenings())
+	})
+
+	t.Run("gap open and extend change the alignment", func(t *testing.T) {
+		affine, err := NewScoringMatrix(2, -1, -5, -1)
+		require.NoError(t, err)
+		a := align(t, NeedlemanWunsch, "CCTGCGAGGCG", "CTCTCGA", affine)
+		assert.Equal(t, -3, a.Score)
+		assert.Equal(t, "CCTGCGAGGCG", a.AlignedSeq1)
+		assert.Equal(t, "CTCTCGA----", a.AlignedSeq2)
+
+		linear, err := Simple(2, -1, -2)
+		require.NoError(t, err)
+		a = align(t, NeedlemanWunsch, "CCTGCGAGGCG", "CTCTCGA", linear)
+		assert.Equal(t, 0, a.Score)
+		assert.Equal(t, "C-CTGCGAGGCG", a.AlignedSeq1)
+		assert.Equal(t, "CTCT-CGA----", a.AlignedSeq2)
+	})
+
+	t.Run("local alignment bridges a gap", func(t *testing.T) {
+		// Bridging costs 5 + 3*1, less than the 12 the second block adds.
+		scoring, err := NewScoringMatrix(3, -3, -5, -1)
+		require.NoError(t, err)
+		a := align(t, SmithWaterman, "GGGGAAAACCCCTTTT", "AAAATTTT", scoring)
+		assert.Equal(t, 16, a.Score)
+		assert.Equal(t, "AAAACCCCTTTT", a.AlignedSeq1)
+		assert.Equal(t, "AAAA----TTTT", a.AlignedSeq2)
+		assert.Equal(t, 4, a.Start1)
+		assert.Equal(t, 16, a.End1)
+
+		// With a linear penalty of 5 per base the blocks stay apart.
+		linear, err := Simple(3, -3, -5)
+		require.NoError(t, err)
+		a = align(t, SmithWaterman, "GGGGAAAACCCCTTTT", "AAAATTTT", linear)
+		assert.Equal(t, 12, a.Score)
+		assert.Equal(t, 0, a.TotalGaps())
+	})
+
+	t.Run("semi-global read with a deletion", func(t *testing.T) {
+		a := align(t, SemiGlobalAlignment, "ACGTAGCTAG", "GGGGACGTAGTTCTAGGGGG", nil)
+		assert.Equal(t, "----ACGTAG--CTAG----", a.AlignedSeq1)
+		assert.Equal(t, 20-2-1, a.Score)
+	})
+
+	t.Run("scores agree with the alignments", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(7))
+		random := func(n int) string {
+			b := make([]byte, n)
+			for i := range b {
+				b[i] = "ACGT"[rng.Intn(4)]
+			}
+			return string(b)
+		}
+		scorings := []*ScoringMatrix{DefaultDNA(), BLASTLike(), {MatchScore: 1, MismatchPenalty: -1, GapOpenPenalty: -1, GapExtendPenalty: -3}}
+		for trial := 0; trial < 50; trial++ {
+			s1, s2 := random(5+rng.Intn(30)), random(5+rng.Intn(30))
+			seq1, _ := sequence.New(s1)
+			seq2, _ := sequence.New(s2)
+			for _, scoring := range scorings {
+				global := align(t, NeedlemanWunsch, s1, s2, scoring)
+				assert.Equal(t, affineScore(global.AlignedSeq1, global.AlignedSeq2, scoring), global.Score)
+				score, err := GlobalAlignmentScoreOnly(seq1, seq2, scoring)
+				require.NoError(t, err)
+				assert.Equal(t, global.Score, score)
+
+				local := align(t, SmithWaterman, s1, s2, scoring)
+				assert.Equal(t, affineScore(local.AlignedSeq1, local.AlignedSeq2, scoring), local.Score)
+				assert.Equal(t, s1[local.Start1:local.End1], strings.ReplaceAll(local.AlignedSeq1, "-", ""))
+				score, err = AlignmentScoreOnly(seq1, seq2, scoring)
+				require.NoError(t, err)
+				assert.Equal(t, local.Score, score)
+			}
+		}
+	})
+}
+
 func BenchmarkSmithWaterman(b *testing.B) {
 	s1 := ""
 	s2 := ""
@@ -358,3 +749,109 @@ func BenchmarkAlignmentScoreOnly(b *testing.B) {
 		_, _ = AlignmentScoreOnly(seq1, seq2, DefaultDNA())
 	}
 }
+
+func TestSubstitutionMatrix(t *testing.T) {
+	t.Run("BuiltIn", func(t *testing.T) {
+		assert.Equal(t, []string{"BLOSUM62", "BLOSUM80", "PAM250"}, SubstitutionMatrixNames())
+		for _, name := range SubstitutionMatrixNames() {
+			m, err := SubstitutionMatrixByName(strings.ToLower(name))
+			require.NoError(t, err)
+			assert.Equal(t, name, m.Name)
+			alphabet := m.Alphabet()
+			for i := 0; i < len(alphabet); i++ {
+				for j := 0; j < len(alphabet); j++ {
+					assert.Equal(t, m.Score(alphabet[i], alphabet[j]), m.Score(alphabet[j], alphabet[i]), "%s %c%c", name, alphabet[i], alphabet[j])
+				}
+			}
+		}
+		_, err := SubstitutionMatrixByName("BLOSUM45")
+		assert.Error(t, err)
+
+		b62, err := SubstitutionMatrixByName("BLOSUM62")
+		require.NoError(t, err)
+		assert.Equal(t, 11, b62.Score('W', 'W'))
+		assert.Equal(t, -1, b62.Score('A', 'R'))
+		assert.Equal(t, 4, b62.Score('a', 'A'))
+		assert.Equal(t, -1, b62.Score('U', 'L'), "unknown residues score as X")
+		assert.Equal(t, 11, b62.Max())
+		assert.Equal(t, -4, b62.Min())
+
+		pam, err := SubstitutionMatrixByName("PAM250")
+		require.NoError(t, err)
+		assert.Equal(t, 17, pam.Score('W', 'W'))
+		assert.Equal(t, 12, pam.Score('C', 'C'))
+	})
+
+	t.Run("Parse", func(t *testing.T) {
+		m, err := ParseSubstitutionMatrix("nuc", strings.NewReader("# comment\n   A  C\nC -1  2\nA  1 -1\n"))
+		require.NoError(t, err)
+		assert.Equal(t, "AC", m.Alphabet())
+		assert.Equal(t, 2, m.Score('c', 'C'))
+		assert.Equal(t, -1, m.Score('A', 'G'), "without X, unknown residues score the minimum")
+
+		for _, bad := range []string{
+			"",
+			"  A C\nA 1\nC -1 1\n",
+			"  A C\nA 1 -1\n",
+			"  A C\nA 1 x\nC -1 1\n",
+			"  A C\nA 1 -1\nG -1 1\n",
+			"  A AB\nA 1 -1\n",
+		} {
+			_, err := ParseSubstitutionMatrix("bad", strings.NewReader(bad))
+			assert.Error(t, err, bad)
+		}
+	})
+
+	t.Run("Scoring", func(t *testing.T) {
+		s := DefaultProtein()
+		assert.Equal(t, 5, s.Score('K', 'K'))
+		assert.Equal(t, -12, s.GapScore(1))
+
+		assert.Contains(t, s.String(), "BLOSUM62")
+		_, err := NewSubstitutionScoring(s.Substitution, 1, -1)
+		assert.Error(t, err)
+
+		// BLAST's ungapped BLOSUM62 parameters.
+		ka, err := NewKarlinAltschul(s)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.3176, ka.Lambda, 1e-3)
+		assert.InDelta(t, 0.134, ka.K, 1e-3)
+		assert.InDelta(t, 0.401, ka.H, 1e-3)
+
+		// A malformed override breaks the named lookup, not the default.
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "matrices"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "matrices", "BLOSUM62"), []byte("not a matrix\n"), 0o644))
+		t.Setenv(data.EnvDir, dir)
+		_, err = SubstitutionMatrixByName("BLOSUM62")
+		assert.Error(t, err)
+		assert.Equal(t, 5, DefaultProtein().Score('K', 'K'))
+	})
+
+	t.Run("AlignProteins", func(t *testing.T) {
+		p1, err := protein.New("HEAGAWGHEE*")
+		require.NoError(t, err)
+		p2, err := protein.New("PAWHEAE")
+		require.NoError(t, err)
+
+		scoring, err := NewSubstitutionScoring(mustMatrix(t, "BLOSUM62"), -8, -8)
+		require.NoError(t, err)
+		global, err := AlignProteins(context.Background(), p1, p2, scoring, Global)
+		require.NoError(t, err)
+		assert.Equal(t, "HEAGAWGHEE", strings.ReplaceAll(global.AlignedSeq1, "-", ""))
+		assert.Equal(t, "PAWHEAE", strings.ReplaceAll(global.AlignedSeq2, "-", ""))
+		assert.Equal(t, affineScore(global.AlignedSeq1, global.AlignedSeq2, scoring), global.Score)
+
+		local, err := AlignProteins(context.Background(), p1, p2, nil, Local)
+		require.NoError(t, err)
+		assert.Equal(t, "HEAGAWGHEE"[local.Start1:local.End1], strings.ReplaceAll(local.AlignedSeq1, "-", ""))
+		assert.Equal(t, affineScore(local.AlignedSeq1, local.AlignedSeq2, DefaultProtein()), local.Score)
+	})
+}
+
+// mustMatrix returns a built-in substitution matrix.
+func mustMatrix(t *testing.T, name string) *SubstitutionMatrix {
+	m, err := SubstitutionMatrixByName(name)
+	require.NoError(t, err)
+	return m
+}
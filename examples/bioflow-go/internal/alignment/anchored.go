@@ -0,0 +1,149 @@
+package alignment
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// AnchorPair is a known gapless match of Length bases between
+// seq1[Pos1:Pos1+Length] and seq2[Pos2:Pos2+Length], such as a shared
+// k-mer. Positions are 0-based.
+type AnchorPair struct {
+	Pos1   int `json:"pos1"`
+	Pos2   int `json:"pos2"`
+	Length int `json:"length"`
+}
+
+// AnchoredAlignment performs a global alignment guided by anchors. The
+// anchors are fixed as gapless blocks and Needleman-Wunsch runs only on
+// the regions between consecutive blocks (and the flanks), so long,
+// mostly similar sequences align in time proportional to the unanchored
+// regions rather than to m*n.
+//
+// Anchors are sorted and made collinear first: overlapping anchors on the
+// same diagonal are merged, an anchor overlapping the previous block on
+// another diagonal is trimmed, and anchors that go backwards in either
+// sequence are dropped. Bases inside a block are scored as matches or
+// mismatches, so a stale anchor degrades the score rather than failing.
+//
+// Aria equivalent:
+//
+//	fn anchored_alignment(seq1: Sequence, seq2: Sequence, anchors: [AnchorPair],
+//	                      scoring: ScoringMatrix) -> Result<Alignment, AlignError>
+//	  requires seq1.len() > 0 and seq2.len() > 0
+//	  requires anchors.all(|a| a.pos1 + a.length <= seq1.len() and a.pos2 + a.length <= seq2.len())
+//	  ensures result.alignment_type == Global
+//	  ensures result.aligned_seq1.len() == result.aligned_seq2.len()
+func AnchoredAlignment(seq1, seq2 *sequence.Sequence, anchors []AnchorPair, scoring *ScoringMatrix) (*Alignment, error) {
+	if scoring == nil {
+		scoring = DefaultDNA()
+	}
+	if seq1.Len() == 0 || seq2.Len() == 0 {
+		return nil, fmt.Errorf("sequences must be non-empty")
+	}
+	m, n := seq1.Len(), seq2.Len()
+	for _, a := range anchors {
+		if a.Pos1 < 0 || a.Pos2 < 0 || a.Length <= 0 || a.Pos1+a.Length > m || a.Pos2+a.Length > n {
+			return nil, fmt.Errorf("anchor (%d, %d, %d) out of range", a.Pos1, a.Pos2, a.Length)
+		}
+	}
+
+	blocks := collinearBlocks(anchors)
+	s1, s2 := seq1.Bases, seq2.Bases
+
+	var aligned1, aligned2 strings.Builder
+	score := 0
+	end1, end2 := 0, 0
+	for _, b := range append(blocks, AnchorPair{Pos1: m, Pos2: n}) {
+		a1, a2, s, err := alignRegion(seq1, seq2, end1, b.Pos1, end2, b.Pos2, scoring)
+		if err != nil {
+			return nil, err
+		}
+		aligned1.WriteString(a1)
+		aligned2.WriteString(a2)
+		score += s
+
+		for i := 0; i < b.Length; i++ {
+			score += scoring.Score(rune(s1[b.Pos1+i]), rune(s2[b.Pos2+i]))
+		}
+		aligned1.WriteString(s1[b.Pos1 : b.Pos1+b.Length])
+		aligned2.WriteString(s2[b.Pos2 : b.Pos2+b.Length])
+		end1, end2 = b.Pos1+b.Length, b.Pos2+b.Length
+	}
+
+	return NewAlignmentWithPositions(aligned1.String(), aligned2.String(), score,
+		0, m, 0, n, Global)
+}
+
+// collinearBlocks turns anchors into non-overlapping blocks that advance
+// in both sequences.
+func collinearBlocks(anchors []AnchorPair) []AnchorPair {
+	sorted := make([]AnchorPair, len(anchors))
+	copy(sorted, anchors)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Pos1 != sorted[j].Pos1 {
+			return sorted[i].Pos1 < sorted[j].Pos1
+		}
+		return sorted[i].Pos2 < sorted[j].Pos2
+	})
+
+	blocks := make([]AnchorPair, 0, len(sorted))
+	for _, a := range sorted {
+		if len(blocks) == 0 {
+			blocks = append(blocks, a)
+			continue
+		}
+		last := &blocks[len(blocks)-1]
+		end1, end2 := last.Pos1+last.Length, last.Pos2+last.Length
+		if a.Pos1-a.Pos2 == last.Pos1-last.Pos2 && a.Pos1 <= end1 {
+			// Same diagonal and touching: extend the block.
+			if e := a.Pos1 + a.Length; e > end1 {
+				last.Length = e - last.Pos1
+			}
+			continue
+		}
+		shift := max(end1-a.Pos1, end2-a.Pos2)
+		if shift >= a.Length {
+			continue
+		}
+		if shift > 0 {
+			a.Pos1 += shift
+			a.Pos2 += shift
+			a.Length -= shift
+		}
+		blocks = append(blocks, a)
+	}
+	return blocks
+}
+
+// alignRegion globally aligns seq1[start1:end1] with seq2[start2:end2].
+// An empty side becomes a run of gaps.
+func alignRegion(seq1, seq2 *sequence.Sequence, start1, end1, start2, end2 int,
+	scoring *ScoringMatrix) (string, string, int, error) {
+	len1, len2 := end1-start1, end2-start2
+	switch {
+	case len1 == 0 && len2 == 0:
+		return "", "", 0, nil
+	case len1 == 0:
+		return strings.Repeat("-", len2), seq2.Bases[start2:end2], scoring.GapScore(len2), nil
+	case len2 == 0:
+		return seq1.Bases[start1:end1], strings.Repeat("-", len1), scoring.GapScore(len1), nil
+	}
+
+	sub1, err := seq1.Subsequence(start1, end1)
+	if err != nil {
+		return "", "", 0, err
+	}
+	sub2, err := seq2.Subsequence(start2, end2)
+	if err != nil {
+		return "", "", 0, err
+	}
+	a, err := NeedlemanWunsch(sub1, sub2, scoring)
+	if err != nil {
+		return "", "", 0, err
+	}
+	return a.AlignedSeq1, a.AlignedSeq2, a.Score, nil
+}
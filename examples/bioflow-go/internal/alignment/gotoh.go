@@ -0,0 +1,224 @@
+package alignment
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// The aligners score gaps with Gotoh's algorithm: a gap of length L costs
+// GapOpenPenalty + (L-1)*GapExtendPenalty. Each DP cell keeps three
+// scores, for the best paths ending in an aligned column, in a gap in
+// sequence 2 (a deletion) and in a gap in sequence 1 (an insertion). Only
+// two rows of scores are kept; the traceback is one byte per cell.
+
+// negInf scores cells no path can reach, leaving room to add penalties.
+const negInf = math.MinInt32 / 2
+
+// gotohCell holds the scores of a DP cell.
+type gotohCell struct {
+	h   int // ends in an aligned column
+	del int // ends in a gap in sequence 2
+	ins int // ends in a gap in sequence 1
+}
+
+func (c gotohCell) best() int {
+	return max(c.h, max(c.del, c.ins))
+}
+
+// Traceback states. The low two bits of a traceback byte give the state
+// the aligned column of the cell was reached from, or traceStart when the
+// alignment starts at the cell; the other bits say where its gaps came
+// from.
+const (
+	inH = iota
+	inDel
+	inIns
+	traceStart
+
+	stateMask  = 3
+	delExtend  = 1 << 2 // the deletion extends the one in the cell above
+	delFromIns = 1 << 3 // the deletion follows an insertion
+	insExtend  = 1 << 4 // the insertion extends the one in the cell to the left
+	insFromDel = 1 << 5 // the insertion follows a deletion
+)
+
+// gotohStep computes a cell from its diagonal, upper and left neighbours
+// for aligning base a of sequence 1 with base b of sequence 2. Local
+// alignments start at the cell rather than take a score below zero.
+func gotohStep(scoring *ScoringMatrix, a, b byte, diag, up, left gotohCell, local bool) (gotohCell, byte) {
+	open, ext := scoring.GapOpenPenalty, scoring.GapExtendPenalty
+	var c gotohCell
+
+	h, from := diag.h, byte(inH)
+	if diag.del > h {
+		h, from = diag.del, inDel
+	}
+	if diag.ins > h {
+		h, from = diag.ins, inIns
+	}
+	c.h = h + scoring.Score(rune(a), rune(b))
+	if local && c.h <= 0 {
+		c.h, from = 0, traceStart
+	}
+	t := from
+
+	c.del = up.h + open
+	if v := up.ins + open; v > c.del {
+		c.del, t = v, t|delFromIns
+	}
+	if v := up.del + ext; v > c.del {
+		c.del, t = v, t&^delFromIns|delExtend
+	}
+
+	c.ins = left.h + open
+	if v := left.del + open; v > c.ins {
+		c.ins, t = v, t|insFromDel
+	}
+	if v := left.ins + ext; v > c.ins {
+		c.ins, t = v, t&^insFromDel|insExtend
+	}
+	return c, t
+}
+
+// gotohBorder returns the cells of row 0 (or column 0) of a DP matrix,
+// with their traceback, for an alignment of kind. Global alignments pay
+// for leading gaps; local ones start anywhere. Semi-global alignments
+// start anywhere in sequence 2 but at the start of sequence 1, so only row
+// 0 is free.
+func gotohBorder(scoring *ScoringMatrix, kind AlignmentType, k int, row bool) (gotohCell, byte) {
+	if k == 0 {
+		return gotohCell{0, negInf, negInf}, traceStart
+	}
+	if kind == Local || (kind == SemiGlobal && row) {
+		return gotohCell{0, negInf, negInf}, traceStart
+	}
+	gap := scoring.GapScore(k)
+	if row {
+		t := byte(traceStart)
+		if k > 1 {
+			t |= insExtend
+		}
+		return gotohCell{negInf, negInf, gap}, t
+	}
+	t := byte(traceStart)
+	if k > 1 {
+		t |= delExtend
+	}
+	return gotohCell{negInf, gap, negInf}, t
+}
+
+// affineAlign aligns s1 with s2 with affine gaps. A local alignment ends
+// at the first cell, in row-major order, with the best score; a
+// semi-global one spans s1, with free gaps at both ends of s2.
+func affineAlign(ctx context.Context, s1, s2 string, scoring *ScoringMatrix, kind AlignmentType) (*Alignment, error) {
+	m, n := len(s1), len(s2)
+	trace := make([][]byte, m+1)
+	for i := range trace {
+		trace[i] = make([]byte, n+1)
+	}
+	prev := make([]gotohCell, n+1)
+	curr := make([]gotohCell, n+1)
+	for j := 0; j <= n; j++ {
+		prev[j], trace[0][j] = gotohBorder(scoring, kind, j, true)
+	}
+
+	maxScore, maxI, maxJ := 0, 0, 0
+	for i := 1; i <= m; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("aligning: %w", err)
+		}
+		curr[0], trace[i][0] = gotohBorder(scoring, kind, i, false)
+		for j := 1; j <= n; j++ {
+			curr[j], trace[i][j] = gotohStep(scoring, s1[i-1], s2[j-1], prev[j-1], prev[j], curr[j-1], kind == Local)
+			if kind == Local && curr[j].h > maxScore {
+				maxScore, maxI, maxJ = curr[j].h, i, j
+			}
+		}
+		prev, curr = curr, prev
+	}
+
+	// prev is now row m.
+	state := inH
+	switch kind {
+	case Global:
+		maxI, maxJ = m, n
+		maxScore, state = bestState(prev[n])
+	case SemiGlobal:
+		maxI = m
+		maxScore, state = bestState(prev[0])
+		for j := 1; j <= n; j++ {
+			if score, s := bestState(prev[j]); score > maxScore {
+				maxScore, state, maxJ = score, s, j
+			}
+		}
+	}
+
+	var r1, r2 []byte
+	i, j := maxI, maxJ
+	for state != traceStart {
+		t := trace[i][j]
+		switch state {
+		case inH:
+			if t&stateMask == traceStart {
+				state = traceStart
+				continue
+			}
+			r1 = append(r1, s1[i-1])
+			r2 = append(r2, s2[j-1])
+			i--
+			j--
+			state = int(t & stateMask)
+		case inDel:
+			r1 = append(r1, s1[i-1])
+			r2 = append(r2, '-')
+			i--
+			switch {
+			case t&delExtend != 0:
+				state = inDel
+			case t&delFromIns != 0:
+				state = inIns
+			default:
+				state = inH
+			}
+		case inIns:
+			r1 = append(r1, '-')
+			r2 = append(r2, s2[j-1])
+			j--
+			switch {
+			case t&insExtend != 0:
+				state = inIns
+			case t&insFromDel != 0:
+				state = inDel
+			default:
+				state = inH
+			}
+		}
+	}
+	aligned1, aligned2 := reverse(string(r1)), reverse(string(r2))
+
+	switch kind {
+	case Local:
+		return NewAlignmentWithPositions(aligned1, aligned2, maxScore, i, maxI, j, maxJ, Local)
+	case SemiGlobal:
+		// The unaligned ends of s2 are shown against gaps.
+		aligned1 = strings.Repeat("-", j) + aligned1 + strings.Repeat("-", n-maxJ)
+		aligned2 = s2[:j] + aligned2 + s2[maxJ:]
+		return NewAlignmentWithPositions(aligned1, aligned2, maxScore, 0, m, 0, n, SemiGlobal)
+	}
+	return NewAlignmentWithPositions(aligned1, aligned2, maxScore, 0, m, 0, n, Global)
+}
+
+// bestState returns the best score of a cell and the state holding it,
+// preferring an aligned column on ties.
+func bestState(c gotohCell) (int, int) {
+	score, state := c.h, inH
+	if c.del > score {
+		score, state = c.del, inDel
+	}
+	if c.ins > score {
+		score, state = c.ins, inIns
+	}
+	return score, state
+}
@@ -0,0 +1,58 @@
+package alignment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTooLarge is returned when an alignment's dynamic programming matrix
+// would exceed the configured cell budget. Use errors.Is to detect it.
+var ErrTooLarge = errors.New("alignment too large")
+
+// Limits bounds the work done by a single alignment. Zero values mean no
+// limit.
+//
+// Aria equivalent:
+//
+//	struct Limits
+//	  max_cells: Int
+//	  timeout: Duration
+//	  invariant self.max_cells >= 0
+type Limits struct {
+	// MaxCells is the largest DP matrix, (m+1)*(n+1) cells, allowed.
+	MaxCells int64
+	// Timeout is the longest a single alignment may run.
+	Timeout time.Duration
+}
+
+// Cells returns the number of DP cells needed to align sequences of
+// lengths m and n.
+func Cells(m, n int) int64 {
+	return int64(m+1) * int64(n+1)
+}
+
+// Check returns an error wrapping ErrTooLarge when aligning sequences of
+// lengths m and n would exceed the cell budget.
+//
+// Aria equivalent:
+//
+//	fn check(self, m: Int, n: Int) -> Result<(), Error>
+//	  ensures result.is_ok() implies self.max_cells == 0 or cells(m, n) <= self.max_cells
+func (l Limits) Check(m, n int) error {
+	if l.MaxCells > 0 {
+		if cells := Cells(m, n); cells > l.MaxCells {
+			return fmt.Errorf("%w: %dx%d needs %d cells, limit is %d", ErrTooLarge, m, n, cells, l.MaxCells)
+		}
+	}
+	return nil
+}
+
+// context derives a context carrying the timeout, if any.
+func (l Limits) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if l.Timeout > 0 {
+		return context.WithTimeout(ctx, l.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
@@ -1,8 +1,8 @@
 package alignment
 
 import (
+	"context"
 	"fmt"
-	"strings"
 
 	"github.com/aria-lang/bioflow-go/internal/sequence"
 )
@@ -18,6 +18,23 @@ import (
 //	  requires seq1.len() > 0 and seq2.len() > 0
 //	  ensures result.aligned_seq1.len() == result.aligned_seq2.len()
 func NeedlemanWunsch(seq1, seq2 *sequence.Sequence, scoring *ScoringMatrix) (*Alignment, error) {
+	return needlemanWunsch(context.Background(), seq1, seq2, scoring)
+}
+
+// NeedlemanWunschContext is NeedlemanWunsch bounded by limits. It fails with
+// ErrTooLarge before allocating when the DP matrix would exceed
+// limits.MaxCells, and stops with the context's error once ctx is done or
+// limits.Timeout has elapsed.
+func NeedlemanWunschContext(ctx context.Context, seq1, seq2 *sequence.Sequence, scoring *ScoringMatrix, limits Limits) (*Alignment, error) {
+	if err := limits.Check(seq1.Len(), seq2.Len()); err != nil {
+		return nil, err
+	}
+	ctx, cancel := limits.context(ctx)
+	defer cancel()
+	return needlemanWunsch(ctx, seq1, seq2, scoring)
+}
+
+func needlemanWunsch(ctx context.Context, seq1, seq2 *sequence.Sequence, scoring *ScoringMatrix) (*Alignment, error) {
 	if scoring == nil {
 		scoring = DefaultDNA()
 	}
@@ -26,104 +43,7 @@ func NeedlemanWunsch(seq1, seq2 *sequence.Sequence, scoring *ScoringMatrix) (*Al
 		return nil, fmt.Errorf("sequences must be non-empty")
 	}
 
-	m, n := seq1.Len(), seq2.Len()
-	s1, s2 := seq1.Bases, seq2.Bases
-
-	// Initialize scoring matrix with gap penalties
-	H := make([][]int, m+1)
-	traceback := make([][]AlignDirection, m+1)
-	for i := range H {
-		H[i] = make([]int, n+1)
-		traceback[i] = make([]AlignDirection, n+1)
-	}
-
-	// First row and column initialized with gap penalties
-	for i := 0; i <= m; i++ {
-		H[i][0] = i * scoring.GapPenalty()
-		if i > 0 {
-			traceback[i][0] = Up
-		}
-	}
-	for j := 0; j <= n; j++ {
-		H[0][j] = j * scoring.GapPenalty()
-		if j > 0 {
-			traceback[0][j] = Left
-		}
-	}
-
-	// Fill matrices
-	for i := 1; i <= m; i++ {
-		for j := 1; j <= n; j++ {
-			matchScore := scoring.Score(rune(s1[i-1]), rune(s2[j-1]))
-
-			diag := H[i-1][j-1] + matchScore
-			up := H[i-1][j] + scoring.GapPenalty()
-			left := H[i][j-1] + scoring.GapPenalty()
-
-			// Find maximum (no zero threshold for global)
-			best := diag
-			direction := Diagonal
-
-			if up > best {
-				best = up
-				direction = Up
-			}
-			if left > best {
-				best = left
-				direction = Left
-			}
-
-			H[i][j] = best
-			traceback[i][j] = direction
-		}
-	}
-
-	// Traceback from bottom-right corner
-	aligned1, aligned2 := tracebackGlobal(s1, s2, traceback, m, n)
-
-	return NewAlignment(aligned1, aligned2, H[m][n], Global)
-}
-
-// tracebackGlobal performs traceback for global alignment.
-func tracebackGlobal(seq1, seq2 string, traceback [][]AlignDirection, m, n int) (string, string) {
-	var aligned1, aligned2 strings.Builder
-	i, j := m, n
-
-	for i > 0 || j > 0 {
-		if i == 0 {
-			aligned1.WriteByte('-')
-			aligned2.WriteByte(seq2[j-1])
-			j--
-		} else if j == 0 {
-			aligned1.WriteByte(seq1[i-1])
-			aligned2.WriteByte('-')
-			i--
-		} else {
-			direction := traceback[i][j]
-
-			switch direction {
-			case Diagonal:
-				aligned1.WriteByte(seq1[i-1])
-				aligned2.WriteByte(seq2[j-1])
-				i--
-				j--
-			case Up:
-				aligned1.WriteByte(seq1[i-1])
-				aligned2.WriteByte('-')
-				i--
-			case Left:
-				aligned1.WriteByte('-')
-				aligned2.WriteByte(seq2[j-1])
-				j--
-			default:
-				break
-			}
-		}
-	}
-
-	a1 := aligned1.String()
-	a2 := aligned2.String()
-	return reverse(a1), reverse(a2)
+	return affineAlign(ctx, seq1.Bases, seq2.Bases, scoring, Global)
 }
 
 // SemiGlobalAlignment performs semi-global alignment.
@@ -139,70 +59,7 @@ func SemiGlobalAlignment(seq1, seq2 *sequence.Sequence, scoring *ScoringMatrix)
 		return nil, fmt.Errorf("sequences must be non-empty")
 	}
 
-	m, n := seq1.Len(), seq2.Len()
-	s1, s2 := seq1.Bases, seq2.Bases
-
-	// Initialize scoring matrix
-	H := make([][]int, m+1)
-	traceback := make([][]AlignDirection, m+1)
-	for i := range H {
-		H[i] = make([]int, n+1)
-		traceback[i] = make([]AlignDirection, n+1)
-	}
-
-	// First row initialized with zeros (no penalty for gaps at start of seq1)
-	// First column initialized with gap penalties
-	for i := 1; i <= m; i++ {
-		H[i][0] = i * scoring.GapPenalty()
-		traceback[i][0] = Up
-	}
-
-	// Fill matrices
-	for i := 1; i <= m; i++ {
-		for j := 1; j <= n; j++ {
-			matchScore := scoring.Score(rune(s1[i-1]), rune(s2[j-1]))
-
-			diag := H[i-1][j-1] + matchScore
-			up := H[i-1][j] + scoring.GapPenalty()
-			left := H[i][j-1] + scoring.GapPenalty()
-
-			best := diag
-			direction := Diagonal
-
-			if up > best {
-				best = up
-				direction = Up
-			}
-			if left > best {
-				best = left
-				direction = Left
-			}
-
-			H[i][j] = best
-			traceback[i][j] = direction
-		}
-	}
-
-	// Find best score in last row (allowing free end gaps in seq1)
-	maxScore := H[m][0]
-	maxJ := 0
-	for j := 1; j <= n; j++ {
-		if H[m][j] > maxScore {
-			maxScore = H[m][j]
-			maxJ = j
-		}
-	}
-
-	// Traceback
-	aligned1, aligned2 := tracebackGlobal(s1, s2, traceback, m, maxJ)
-
-	// Add trailing gaps if needed
-	for j := maxJ + 1; j <= n; j++ {
-		aligned1 = aligned1 + "-"
-		aligned2 = aligned2 + string(s2[j-1])
-	}
-
-	return NewAlignment(aligned1, aligned2, maxScore, SemiGlobal)
+	return affineAlign(context.Background(), seq1.Bases, seq2.Bases, scoring, SemiGlobal)
 }
 
 // AlignAgainstMultiple aligns a sequence against multiple targets.
@@ -285,29 +142,19 @@ func GlobalAlignmentScoreOnly(seq1, seq2 *sequence.Sequence, scoring *ScoringMat
 	s1, s2 := seq1.Bases, seq2.Bases
 
 	// Use two rows
-	prevRow := make([]int, n+1)
-	currRow := make([]int, n+1)
-
-	// Initialize first row
+	prevRow := make([]gotohCell, n+1)
+	currRow := make([]gotohCell, n+1)
 	for j := 0; j <= n; j++ {
-		prevRow[j] = j * scoring.GapPenalty()
+		prevRow[j], _ = gotohBorder(scoring, Global, j, true)
 	}
 
 	for i := 1; i <= m; i++ {
-		currRow[0] = i * scoring.GapPenalty()
-
+		currRow[0], _ = gotohBorder(scoring, Global, i, false)
 		for j := 1; j <= n; j++ {
-			matchScore := scoring.Score(rune(s1[i-1]), rune(s2[j-1]))
-
-			diag := prevRow[j-1] + matchScore
-			up := prevRow[j] + scoring.GapPenalty()
-			left := currRow[j-1] + scoring.GapPenalty()
-
-			currRow[j] = max(diag, max(up, left))
+			currRow[j], _ = gotohStep(scoring, s1[i-1], s2[j-1], prevRow[j-1], prevRow[j], currRow[j-1], false)
 		}
-
 		prevRow, currRow = currRow, prevRow
 	}
 
-	return prevRow[n], nil
+	return prevRow[n].best(), nil
 }
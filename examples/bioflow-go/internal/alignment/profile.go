@@ -0,0 +1,181 @@
+package alignment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// ScoreProfile is the result of a score-only local alignment pass: the
+// best score, where the best alignment ends (and optionally starts), and
+// the best score reached within each band of sequence 1.
+//
+// Coordinates follow Alignment: 0-based starts and exclusive ends, so
+// seq1.Bases[Start1:End1] is the aligned region of sequence 1.
+//
+// Aria equivalent:
+//
+//	struct ScoreProfile
+//	  score: Int
+//	  start1: Int
+//	  end1: Int
+//	  start2: Int
+//	  end2: Int
+//	  band_width: Int
+//	  profile: [Int]
+//	  invariant self.start1 <= self.end1 and self.start2 <= self.end2
+type ScoreProfile struct {
+	Score  int
+	Start1 int
+	End1   int
+	Start2 int
+	End2   int
+	// StartLocated is set when Start1 and Start2 were found by a reverse
+	// pass; otherwise they are zero.
+	StartLocated bool
+	// BandWidth is the number of sequence 1 positions summarized by each
+	// entry of Profile.
+	BandWidth int
+	// Profile holds the maximum local score of any cell in each band of
+	// BandWidth rows, so Profile[b] covers seq1 positions
+	// [b*BandWidth, (b+1)*BandWidth).
+	Profile []int
+}
+
+// ScoreProfileOptions configures LocalScoreProfile.
+type ScoreProfileOptions struct {
+	// BandWidth groups rows of the DP matrix into bands for the profile
+	// (default 1, one entry per base of sequence 1).
+	BandWidth int
+	// LocateStart runs a second, reverse pass over the aligned prefixes to
+	// find where the best alignment starts.
+	LocateStart bool
+	// Limits bounds the pass; only the time limit matters for memory, but
+	// MaxCells still caps the work done.
+	Limits Limits
+}
+
+// LocalScoreProfile computes the Smith-Waterman score in O(n) memory, like
+// AlignmentScoreOnly, and also reports the end coordinates of the best
+// local alignment and a per-band score profile. With LocateStart the
+// start coordinates are found too, so a caller can run a full traceback
+// on just the aligned region after a cheap screening pass.
+//
+// The end position is the first maximum cell in row-major order, the same
+// cell SmithWaterman traces back from.
+//
+// Aria equivalent:
+//
+//	fn local_score_profile(seq1: Sequence, seq2: Sequence, scoring: ScoringMatrix,
+//	                       opts: ScoreProfileOptions) -> ScoreProfile
+//	  requires seq1.len() > 0 and seq2.len() > 0
+//	  requires opts.band_width >= 0
+//	  ensures result.score == alignment_score_only(seq1, seq2, scoring)
+//	  ensures result.end1 <= seq1.len() and result.end2 <= seq2.len()
+func LocalScoreProfile(seq1, seq2 *sequence.Sequence, scoring *ScoringMatrix, opts ScoreProfileOptions) (*ScoreProfile, error) {
+	return LocalScoreProfileContext(context.Background(), seq1, seq2, scoring, opts)
+}
+
+// LocalScoreProfileContext is LocalScoreProfile that also stops with the
+// context's error once ctx is done, besides opts.Limits.Timeout.
+func LocalScoreProfileContext(ctx context.Context, seq1, seq2 *sequence.Sequence, scoring *ScoringMatrix, opts ScoreProfileOptions) (*ScoreProfile, error) {
+	if scoring == nil {
+		scoring = DefaultDNA()
+	}
+	if seq1.Len() == 0 || seq2.Len() == 0 {
+		return nil, fmt.Errorf("sequences must be non-empty")
+	}
+	if opts.BandWidth < 0 {
+		return nil, fmt.Errorf("band width must be non-negative")
+	}
+	if err := opts.Limits.Check(seq1.Len(), seq2.Len()); err != nil {
+		return nil, err
+	}
+	ctx, cancel := opts.Limits.context(ctx)
+	defer cancel()
+
+	band := opts.BandWidth
+	if band == 0 {
+		band = 1
+	}
+
+	s1, s2 := seq1.Bases, seq2.Bases
+	m := len(s1)
+	result := &ScoreProfile{
+		BandWidth: band,
+		Profile:   make([]int, (m+band-1)/band),
+	}
+
+	var err error
+	result.Score, result.End1, result.End2, err = localScorePass(ctx, s1, s2, scoring, func(i, rowMax int) {
+		b := (i - 1) / band
+		if rowMax > result.Profile[b] {
+			result.Profile[b] = rowMax
+		}
+	}, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.LocateStart && result.Score > 0 {
+		// Aligning the reversed prefixes, the first cell that reaches the
+		// best score marks the far end of the alignment, i.e. its start.
+		r1 := reverse(s1[:result.End1])
+		r2 := reverse(s2[:result.End2])
+		_, i, j, err := localScorePass(ctx, r1, r2, scoring, nil, result.Score)
+		if err != nil {
+			return nil, err
+		}
+		result.Start1 = result.End1 - i
+		result.Start2 = result.End2 - j
+		result.StartLocated = true
+	} else if opts.LocateStart {
+		result.Start1, result.Start2 = result.End1, result.End2
+		result.StartLocated = true
+	}
+
+	return result, nil
+}
+
+// localScorePass fills the Smith-Waterman matrix two rows at a time and
+// returns the best score and the (exclusive) end of the first cell that
+// reaches it. rowDone, when non-nil, receives each row's maximum. A
+// positive stopAt ends the pass at the first cell scoring at least stopAt.
+// The pass is abandoned with the context's error once ctx is done.
+func localScorePass(ctx context.Context, s1, s2 string, scoring *ScoringMatrix, rowDone func(i, rowMax int), stopAt int) (int, int, int, error) {
+	n := len(s2)
+	prevRow := make([]gotohCell, n+1)
+	currRow := make([]gotohCell, n+1)
+	for j := range prevRow {
+		prevRow[j], _ = gotohBorder(scoring, Local, j, true)
+	}
+
+	maxScore, maxI, maxJ := 0, 0, 0
+	for i := 1; i <= len(s1); i++ {
+		if err := ctx.Err(); err != nil {
+			return 0, 0, 0, fmt.Errorf("aligning: %w", err)
+		}
+		currRow[0], _ = gotohBorder(scoring, Local, i, false)
+		rowMax := 0
+		for j := 1; j <= n; j++ {
+			currRow[j], _ = gotohStep(scoring, s1[i-1], s2[j-1], prevRow[j-1], prevRow[j], currRow[j-1], true)
+			best := currRow[j].h
+
+			if best > rowMax {
+				rowMax = best
+			}
+			if best > maxScore {
+				maxScore, maxI, maxJ = best, i, j
+				if stopAt > 0 && best >= stopAt {
+					return maxScore, maxI, maxJ, nil
+				}
+			}
+		}
+		if rowDone != nil {
+			rowDone(i, rowMax)
+		}
+		prevRow, currRow = currRow, prevRow
+	}
+	return maxScore, maxI, maxJ, nil
+}
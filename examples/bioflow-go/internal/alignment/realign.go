@@ -0,0 +1,414 @@
+package alignment
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// CIGAROp is one operation of a SAM-style CIGAR string, e.g. {'M', 10}.
+type CIGAROp struct {
+	Op  byte
+	Len int
+}
+
+// consumesRef reports whether the operation advances along the reference.
+func (o CIGAROp) consumesRef() bool {
+	return strings.IndexByte("MDN=X", o.Op) >= 0
+}
+
+// consumesRead reports whether the operation advances along the read.
+func (o CIGAROp) consumesRead() bool {
+	return strings.IndexByte("MIS=X", o.Op) >= 0
+}
+
+// ParseCIGAR parses a CIGAR string such as "5S20M2D30M". The operations
+// M, I, D, N, S, H, P, = and X are accepted.
+func ParseCIGAR(cigar string) ([]CIGAROp, error) {
+	ops := make([]CIGAROp, 0)
+	start := 0
+	for i := 0; i < len(cigar); i++ {
+		c := cigar[i]
+		if c >= '0' && c <= '9' {
+			continue
+		}
+		if strings.IndexByte("MIDNSHP=X", c) < 0 {
+			return nil, fmt.Errorf("invalid CIGAR operation %q", c)
+		}
+		n, err := strconv.Atoi(cigar[start:i])
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid CIGAR length before %q", c)
+		}
+		ops = append(ops, CIGAROp{Op: c, Len: n})
+		start = i + 1
+	}
+	if start != len(cigar) {
+		return nil, fmt.Errorf("CIGAR ends without an operation")
+	}
+	return ops, nil
+}
+
+// FormatCIGAR formats operations as a CIGAR string, merging adjacent
+// operations of the same kind.
+func FormatCIGAR(ops []CIGAROp) string {
+	var sb strings.Builder
+	for i := 0; i < len(ops); i++ {
+		n := ops[i].Len
+		for i+1 < len(ops) && ops[i+1].Op == ops[i].Op {
+			i++
+			n += ops[i].Len
+		}
+		sb.WriteString(strconv.Itoa(n))
+		sb.WriteByte(ops[i].Op)
+	}
+	return sb.String()
+}
+
+// RealignOptions configures RealignIndels.
+type RealignOptions struct {
+	// Band is the number of diagonals explored on either side of the
+	// expected path, in addition to the net indel length (default 8).
+	Band int
+	// Scoring supplies match, mismatch and affine gap scores (default
+	// DefaultDNA).
+	Scoring *ScoringMatrix
+}
+
+// Realignment is the result of RealignIndels.
+type Realignment struct {
+	// RefStart is the 0-based reference position of the first aligned
+	// base; realignment keeps the original start.
+	RefStart int
+	// CIGAR is the realigned, left-normalized CIGAR using M, I, D and the
+	// original clips.
+	CIGAR string
+	// Score and OriginalScore are the affine-gap scores of the new and the
+	// input alignment.
+	Score         int
+	OriginalScore int
+	// Changed reports whether CIGAR differs from the input.
+	Changed bool
+	// AlignedRef and AlignedRead are the gapped rows of the aligned
+	// (unclipped) part of the read.
+	AlignedRef  string
+	AlignedRead string
+}
+
+// RealignIndels re-aligns a read around its indels. Given the read, its
+// CIGAR and the 0-based reference position of its first aligned base, it
+// re-runs a banded affine-gap global alignment of the aligned part of the
+// read against the reference window the CIGAR covers, keeps whichever of
+// the new and original alignments scores higher, and then shifts every
+// indel as far left as it can go without changing the score. Reads at the
+// same locus therefore get the same gap placement, which keeps indel
+// calls consistent downstream.
+//
+// Soft and hard clips are preserved; CIGARs with N or P operations are
+// rejected.
+//
+// Aria equivalent:
+//
+//	fn realign_indels(reference: Sequence, read: Sequence, ref_start: Int, cigar: String,
+//	                  options: RealignOptions) -> Result<Realignment, AlignError>
+//	  requires ref_start >= 0
+//	  ensures result.score >= result.original_score
+//	  ensures reference span of result.cigar == reference span of cigar
+func RealignIndels(reference, read *sequence.Sequence, refStart int, cigar string, opts RealignOptions) (*Realignment, error) {
+	scoring := opts.Scoring
+	if scoring == nil {
+		scoring = DefaultDNA()
+	}
+	band := opts.Band
+	if band <= 0 {
+		band = 8
+	}
+	ops, err := ParseCIGAR(cigar)
+	if err != nil {
+		return nil, err
+	}
+
+	// Split off clips and measure the aligned part.
+	var leading, trailing []CIGAROp
+	for len(ops) > 0 && (ops[0].Op == 'S' || ops[0].Op == 'H') {
+		leading = append(leading, ops[0])
+		ops = ops[1:]
+	}
+	for len(ops) > 0 && (ops[len(ops)-1].Op == 'S' || ops[len(ops)-1].Op == 'H') {
+		trailing = append([]CIGAROp{ops[len(ops)-1]}, trailing...)
+		ops = ops[:len(ops)-1]
+	}
+	clip5 := 0
+	for _, o := range leading {
+		if o.Op == 'S' {
+			clip5 += o.Len
+		}
+	}
+	refSpan, readSpan := 0, 0
+	for _, o := range ops {
+		switch o.Op {
+		case 'N', 'P', 'S', 'H':
+			return nil, fmt.Errorf("unsupported CIGAR operation %q inside alignment", o.Op)
+		}
+		if o.consumesRef() {
+			refSpan += o.Len
+		}
+		if o.consumesRead() {
+			readSpan += o.Len
+		}
+	}
+	readLen := clip5 + readSpan
+	for _, o := range trailing {
+		if o.Op == 'S' {
+			readLen += o.Len
+		}
+	}
+	if readLen != read.Len() {
+		return nil, fmt.Errorf("CIGAR covers %d read bases, read has %d", readLen, read.Len())
+	}
+	if refStart < 0 || refStart+refSpan > reference.Len() {
+		return nil, fmt.Errorf("CIGAR extends past the reference")
+	}
+	if refSpan == 0 || readSpan == 0 {
+		return nil, fmt.Errorf("CIGAR has no aligned bases")
+	}
+
+	refWin := reference.Bases[refStart : refStart+refSpan]
+	readSeg := read.Bases[clip5 : clip5+readSpan]
+
+	origRef, origRead := gappedRows(refWin, readSeg, ops)
+	origScore := affineScore(origRef, origRead, scoring)
+
+	newRef, newRead, newScore := bandedAffineGlobal(refWin, readSeg, band, scoring)
+	rowRef, rowRead, score := origRef, origRead, origScore
+	if newScore > origScore {
+		rowRef, rowRead, score = newRef, newRead, newScore
+	}
+	rowRef, rowRead = leftAlignRows(rowRef, rowRead)
+
+	result := append(append([]CIGAROp{}, leading...), rowsToCIGAR(rowRef, rowRead)...)
+	result = append(result, trailing...)
+	formatted := FormatCIGAR(result)
+
+	return &Realignment{
+		RefStart:      refStart,
+		CIGAR:         formatted,
+		Score:         score,
+		OriginalScore: origScore,
+		Changed:       formatted != cigar,
+		AlignedRef:    rowRef,
+		AlignedRead:   rowRead,
+	}, nil
+}
+
+// gappedRows expands CIGAR operations (without clips) into gapped rows.
+func gappedRows(ref, read string, ops []CIGAROp) (string, string) {
+	var r, q strings.Builder
+	i, j := 0, 0
+	for _, o := range ops {
+		for n := 0; n < o.Len; n++ {
+			switch o.Op {
+			case 'I':
+				r.WriteByte('-')
+				q.WriteByte(read[j])
+				j++
+			case 'D':
+				r.WriteByte(ref[i])
+				q.WriteByte('-')
+				i++
+			default:
+				r.WriteByte(ref[i])
+				q.WriteByte(read[j])
+				i++
+				j++
+			}
+		}
+	}
+	return r.String(), q.String()
+}
+
+// affineScore scores gapped rows with affine gaps: a gap of length L
+// costs GapOpenPenalty + (L-1)*GapExtendPenalty.
+func affineScore(ref, read string, scoring *ScoringMatrix) int {
+	score := 0
+	for c := 0; c < len(ref); c++ {
+		switch {
+		case ref[c] == '-':
+			if c > 0 && ref[c-1] == '-' {
+				score += scoring.GapExtendPenalty
+			} else {
+				score += scoring.GapOpenPenalty
+			}
+		case read[c] == '-':
+			if c > 0 && read[c-1] == '-' {
+				score += scoring.GapExtendPenalty
+			} else {
+				score += scoring.GapOpenPenalty
+			}
+		default:
+			score += scoring.Score(rune(ref[c]), rune(read[c]))
+		}
+	}
+	return score
+}
+
+// bandedAffineGlobal aligns read against ref end to end with Gotoh's
+// affine-gap recurrences, computing only cells within band diagonals of
+// the range spanned by the length difference.
+func bandedAffineGlobal(ref, read string, band int, scoring *ScoringMatrix) (string, string, int) {
+	m, n := len(ref), len(read)
+	lo, hi := -band, band
+	if d := n - m; d < 0 {
+		lo += d
+	} else {
+		hi += d
+	}
+	inBand := func(i, j int) bool { return j-i >= lo && j-i <= hi }
+
+	const negInf = math.MinInt32 / 2
+	open, ext := scoring.GapOpenPenalty, scoring.GapExtendPenalty
+	// H ends in an aligned column, D in a deletion (gap in the read) and
+	// I in an insertion (gap in the reference).
+	H := make([][]int, m+1)
+	D := make([][]int, m+1)
+	I := make([][]int, m+1)
+	for i := 0; i <= m; i++ {
+		H[i] = make([]int, n+1)
+		D[i] = make([]int, n+1)
+		I[i] = make([]int, n+1)
+		for j := 0; j <= n; j++ {
+			H[i][j], D[i][j], I[i][j] = negInf, negInf, negInf
+		}
+	}
+	H[0][0] = 0
+	for i := 1; i <= m && inBand(i, 0); i++ {
+		D[i][0] = open + (i-1)*ext
+	}
+	for j := 1; j <= n && inBand(0, j); j++ {
+		I[0][j] = open + (j-1)*ext
+	}
+	best := func(i, j int) int { return max(H[i][j], max(D[i][j], I[i][j])) }
+
+	for i := 1; i <= m; i++ {
+		for j := 1; j <= n; j++ {
+			if !inBand(i, j) {
+				continue
+			}
+			if p := best(i-1, j-1); p > negInf {
+				H[i][j] = p + scoring.Score(rune(ref[i-1]), rune(read[j-1]))
+			}
+			D[i][j] = max(max(H[i-1][j], I[i-1][j])+open, D[i-1][j]+ext)
+			I[i][j] = max(max(H[i][j-1], D[i][j-1])+open, I[i][j-1]+ext)
+		}
+	}
+
+	// Trace back, tracking which matrix the path is in.
+	const inH, inD, inI = 0, 1, 2
+	score := best(m, n)
+	state := inI
+	switch score {
+	case H[m][n]:
+		state = inH
+	case D[m][n]:
+		state = inD
+	}
+	var r, q []byte
+	i, j := m, n
+	for i > 0 || j > 0 {
+		switch state {
+		case inH:
+			prev := H[i][j] - scoring.Score(rune(ref[i-1]), rune(read[j-1]))
+			r = append(r, ref[i-1])
+			q = append(q, read[j-1])
+			i--
+			j--
+			switch prev {
+			case H[i][j]:
+				state = inH
+			case D[i][j]:
+				state = inD
+			default:
+				state = inI
+			}
+		case inD:
+			r = append(r, ref[i-1])
+			q = append(q, '-')
+			cur := D[i][j]
+			i--
+			switch cur {
+			case D[i][j] + ext:
+				state = inD
+			case H[i][j] + open:
+				state = inH
+			default:
+				state = inI
+			}
+		case inI:
+			r = append(r, '-')
+			q = append(q, read[j-1])
+			cur := I[i][j]
+			j--
+			switch cur {
+			case I[i][j] + ext:
+				state = inI
+			case H[i][j] + open:
+				state = inH
+			default:
+				state = inD
+			}
+		}
+	}
+	return reverse(string(r)), reverse(string(q)), score
+}
+
+// leftAlignRows shifts each gap run left while the aligned column before
+// it can move to the run's end without changing the alignment score.
+func leftAlignRows(ref, read string) (string, string) {
+	r, q := []byte(ref), []byte(read)
+	for c := 1; c < len(r); c++ {
+		var gapRow, otherRow []byte
+		switch {
+		case q[c] == '-' && q[c-1] != '-':
+			gapRow, otherRow = q, r
+		case r[c] == '-' && r[c-1] != '-':
+			gapRow, otherRow = r, q
+		default:
+			continue
+		}
+		start, end := c, c
+		for end+1 < len(r) && gapRow[end+1] == '-' {
+			end++
+		}
+		c = end
+		for start > 0 && gapRow[start-1] != '-' && otherRow[start-1] != '-' &&
+			otherRow[start-1] == otherRow[end] {
+			gapRow[end] = gapRow[start-1]
+			gapRow[start-1] = '-'
+			start--
+			end--
+		}
+	}
+	return string(r), string(q)
+}
+
+// rowsToCIGAR converts gapped reference and read rows into M/I/D
+// operations.
+func rowsToCIGAR(ref, read string) []CIGAROp {
+	ops := make([]CIGAROp, 0)
+	for c := 0; c < len(ref); c++ {
+		op := byte('M')
+		if ref[c] == '-' {
+			op = 'I'
+		} else if read[c] == '-' {
+			op = 'D'
+		}
+		if len(ops) > 0 && ops[len(ops)-1].Op == op {
+			ops[len(ops)-1].Len++
+		} else {
+			ops = append(ops, CIGAROp{Op: op, Len: 1})
+		}
+	}
+	return ops
+}
@@ -1,10 +1,17 @@
 // Package alignment provides sequence alignment algorithms.
 //
 // This package implements Smith-Waterman (local) and Needleman-Wunsch (global)
-// alignment algorithms for comparing genomic sequences.
+// alignment algorithms for comparing genomic sequences, and, with
+// substitution matrices such as BLOSUM62, proteins.
 package alignment
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
 
 // AlignDirection represents the traceback direction in the alignment matrix.
 type AlignDirection int
@@ -45,7 +52,52 @@ func (t AlignmentType) String() string {
 	}
 }
 
-// ScoringMatrix represents the scoring parameters for alignment.
+// AmbiguityMode selects how ambiguous bases (N and other IUPAC codes) are
+// scored against other bases.
+type AmbiguityMode int
+
+const (
+	// AmbiguityMismatch scores an ambiguous base like any other character:
+	// it matches only an identical code.
+	AmbiguityMismatch AmbiguityMode = iota
+	// AmbiguityNeutral scores any pair involving N as 0, so gaps in draft
+	// assemblies and quality-masked bases neither help nor hurt.
+	AmbiguityNeutral
+	// AmbiguityIUPAC scores IUPAC codes by the expected score over the
+	// concrete bases they represent; incompatible codes are mismatches.
+	AmbiguityIUPAC
+)
+
+func (m AmbiguityMode) String() string {
+	switch m {
+	case AmbiguityMismatch:
+		return "mismatch"
+	case AmbiguityNeutral:
+		return "neutral"
+	case AmbiguityIUPAC:
+		return "iupac"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseAmbiguityMode parses "mismatch", "neutral" or "iupac".
+func ParseAmbiguityMode(s string) (AmbiguityMode, error) {
+	switch strings.ToLower(s) {
+	case "", "mismatch":
+		return AmbiguityMismatch, nil
+	case "neutral", "n":
+		return AmbiguityNeutral, nil
+	case "iupac":
+		return AmbiguityIUPAC, nil
+	default:
+		return 0, fmt.Errorf("unknown ambiguity mode %q (want mismatch, neutral or iupac)", s)
+	}
+}
+
+// ScoringMatrix represents the scoring parameters for alignment. Pairs
+// are scored by match and mismatch scores, or by a substitution matrix
+// such as BLOSUM62 when Substitution is set.
 //
 // Aria equivalent:
 //
@@ -63,6 +115,11 @@ type ScoringMatrix struct {
 	MismatchPenalty  int
 	GapOpenPenalty   int
 	GapExtendPenalty int
+	// Ambiguity controls scoring of N and other IUPAC codes.
+	Ambiguity AmbiguityMode
+	// Substitution, when set, scores every pair instead of MatchScore,
+	// MismatchPenalty and Ambiguity.
+	Substitution *SubstitutionMatrix
 }
 
 // NewScoringMatrix creates a new scoring matrix with validation.
@@ -113,21 +170,105 @@ func Simple(match, mismatch, gap int) (*ScoringMatrix, error) {
 	return NewScoringMatrix(match, mismatch, gap, gap)
 }
 
+// WithAmbiguity returns a copy of the scoring matrix using the given
+// ambiguity mode.
+func (s *ScoringMatrix) WithAmbiguity(mode AmbiguityMode) *ScoringMatrix {
+	c := *s
+	c.Ambiguity = mode
+	return &c
+}
+
 // Score returns the score for comparing two bases.
+//
+// Aria equivalent:
+//
+//	fn score(self, base1: Char, base2: Char) -> Int
+//	  ensures result >= self.mismatch_penalty and result <= self.match_score
 func (s *ScoringMatrix) Score(base1, base2 rune) int {
+	if s.Substitution != nil {
+		if base1 > 0xff || base2 > 0xff {
+			return s.Substitution.Score('X', 'X')
+		}
+		return s.Substitution.Score(byte(base1), byte(base2))
+	}
+	switch s.Ambiguity {
+	case AmbiguityNeutral:
+		if base1 == 'N' || base2 == 'N' {
+			return 0
+		}
+	case AmbiguityIUPAC:
+		if isAmbiguous(base1) || isAmbiguous(base2) {
+			return s.iupacScore(base1, base2)
+		}
+	}
 	if base1 == base2 {
 		return s.MatchScore
 	}
 	return s.MismatchPenalty
 }
 
-// GapPenalty returns the linear gap penalty.
+// isAmbiguous reports whether a base is an IUPAC code other than A, C, G,
+// T or U.
+func isAmbiguous(base rune) bool {
+	if base > 0x7f {
+		return false
+	}
+	return len(sequence.IUPACBases(byte(base))) > 1
+}
+
+// iupacScore returns the expected score of two IUPAC codes when each
+// stands for one of its bases with equal probability, rounded to the
+// nearest integer. Codes that share no base score as a mismatch.
+func (s *ScoringMatrix) iupacScore(base1, base2 rune) int {
+	if base1 > 0x7f || base2 > 0x7f {
+		return s.MismatchPenalty
+	}
+	b1 := sequence.IUPACBases(byte(base1))
+	b2 := sequence.IUPACBases(byte(base2))
+	shared := 0
+	for i := 0; i < len(b1); i++ {
+		if strings.IndexByte(b2, b1[i]) >= 0 {
+			shared++
+		}
+	}
+	if shared == 0 {
+		return s.MismatchPenalty
+	}
+	p := float64(shared) / float64(len(b1)*len(b2))
+	return int(math.Round(p*float64(s.MatchScore) + (1-p)*float64(s.MismatchPenalty)))
+}
+
+// GapPenalty returns the penalty of a one-base gap.
 func (s *ScoringMatrix) GapPenalty() int {
 	return s.GapOpenPenalty
 }
 
+// GapScore returns the score of a gap of the given length: the aligners
+// charge GapOpenPenalty for its first base and GapExtendPenalty for each
+// further one.
+//
+// Aria equivalent:
+//
+//	fn gap_score(self, length: Int) -> Int
+//	  requires length >= 0
+//	  ensures length == 0 implies result == 0
+func (s *ScoringMatrix) GapScore(length int) int {
+	if length <= 0 {
+		return 0
+	}
+	return s.GapOpenPenalty + (length-1)*s.GapExtendPenalty
+}
+
 // String returns a string representation of the scoring matrix.
 func (s *ScoringMatrix) String() string {
+	if s.Substitution != nil {
+		return fmt.Sprintf("ScoringMatrix { matrix: %s, gap_open: %d, gap_extend: %d }",
+			s.Substitution.Name, s.GapOpenPenalty, s.GapExtendPenalty)
+	}
+	if s.Ambiguity != AmbiguityMismatch {
+		return fmt.Sprintf("ScoringMatrix { match: %d, mismatch: %d, gap_open: %d, gap_extend: %d, ambiguity: %s }",
+			s.MatchScore, s.MismatchPenalty, s.GapOpenPenalty, s.GapExtendPenalty, s.Ambiguity)
+	}
 	return fmt.Sprintf("ScoringMatrix { match: %d, mismatch: %d, gap_open: %d, gap_extend: %d }",
 		s.MatchScore, s.MismatchPenalty, s.GapOpenPenalty, s.GapExtendPenalty)
 }
@@ -1,6 +1,7 @@
 package alignment
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -221,6 +222,51 @@ func (a *Alignment) String() string {
 		a.Score, a.Identity*100, a.Length())
 }
 
+// Summary is the serializable form of an alignment shared by the API and
+// the CLI. Coordinates are 1-based and inclusive, so Start1..End1 is the
+// aligned region of sequence 1.
+type Summary struct {
+	AlignmentType string  `json:"alignment_type"`
+	AlignedSeq1   string  `json:"aligned_seq1"`
+	AlignedSeq2   string  `json:"aligned_seq2"`
+	Start1        int     `json:"start1"`
+	End1          int     `json:"end1"`
+	Start2        int     `json:"start2"`
+	End2          int     `json:"end2"`
+	Score         int     `json:"score"`
+	Identity      float64 `json:"identity"`
+	CIGAR         string  `json:"cigar"`
+	AlignedLength int     `json:"aligned_length"`
+	Matches       int     `json:"matches"`
+	Mismatches    int     `json:"mismatches"`
+	Gaps          int     `json:"gaps"`
+	GapOpens      int     `json:"gap_opens"`
+	BitScore      float64 `json:"bit_score,omitempty"`
+	EValue        float64 `json:"e_value,omitempty"`
+}
+
+// Summary returns the serializable summary of the alignment. BitScore and
+// EValue are left for the caller to fill from Significance.
+func (a *Alignment) Summary() Summary {
+	return Summary{
+		AlignmentType: a.AlignmentType.String(),
+		AlignedSeq1:   a.AlignedSeq1,
+		AlignedSeq2:   a.AlignedSeq2,
+		Start1:        a.Start1 + 1,
+		End1:          a.End1,
+		Start2:        a.Start2 + 1,
+		End2:          a.End2,
+		Score:         a.Score,
+		Identity:      a.Identity,
+		CIGAR:         a.ToCIGAR(),
+		AlignedLength: a.Length(),
+		Matches:       a.MatchCount(),
+		Mismatches:    a.MismatchCount(),
+		Gaps:          a.TotalGaps(),
+		GapOpens:      a.GapOpenings(),
+	}
+}
+
 // SmithWaterman performs local alignment using the Smith-Waterman algorithm.
 //
 // Finds the optimal local alignment between two sequences.
@@ -233,6 +279,23 @@ func (a *Alignment) String() string {
 //	  ensures result.score >= 0
 //	  ensures result.aligned_seq1.len() == result.aligned_seq2.len()
 func SmithWaterman(seq1, seq2 *sequence.Sequence, scoring *ScoringMatrix) (*Alignment, error) {
+	return smithWaterman(context.Background(), seq1, seq2, scoring)
+}
+
+// SmithWatermanContext is SmithWaterman bounded by limits. It fails with
+// ErrTooLarge before allocating when the DP matrix would exceed
+// limits.MaxCells, and stops with the context's error once ctx is done or
+// limits.Timeout has elapsed.
+func SmithWatermanContext(ctx context.Context, seq1, seq2 *sequence.Sequence, scoring *ScoringMatrix, limits Limits) (*Alignment, error) {
+	if err := limits.Check(seq1.Len(), seq2.Len()); err != nil {
+		return nil, err
+	}
+	ctx, cancel := limits.context(ctx)
+	defer cancel()
+	return smithWaterman(ctx, seq1, seq2, scoring)
+}
+
+func smithWaterman(ctx context.Context, seq1, seq2 *sequence.Sequence, scoring *ScoringMatrix) (*Alignment, error) {
 	if scoring == nil {
 		scoring = DefaultDNA()
 	}
@@ -241,97 +304,7 @@ func SmithWaterman(seq1, seq2 *sequence.Sequence, scoring *ScoringMatrix) (*Alig
 		return nil, fmt.Errorf("sequences must be non-empty")
 	}
 
-	m, n := seq1.Len(), seq2.Len()
-	s1, s2 := seq1.Bases, seq2.Bases
-
-	// Initialize scoring matrix with zeros
-	H := make([][]int, m+1)
-	traceback := make([][]AlignDirection, m+1)
-	for i := range H {
-		H[i] = make([]int, n+1)
-		traceback[i] = make([]AlignDirection, n+1)
-	}
-
-	// Track maximum score and position
-	maxScore := 0
-	maxI, maxJ := 0, 0
-
-	// Fill matrices
-	for i := 1; i <= m; i++ {
-		for j := 1; j <= n; j++ {
-			matchScore := scoring.Score(rune(s1[i-1]), rune(s2[j-1]))
-
-			diag := H[i-1][j-1] + matchScore
-			up := H[i-1][j] + scoring.GapPenalty()
-			left := H[i][j-1] + scoring.GapPenalty()
-
-			// Find maximum (including 0 for local alignment)
-			best := 0
-			direction := Stop
-
-			if diag > best {
-				best = diag
-				direction = Diagonal
-			}
-			if up > best {
-				best = up
-				direction = Up
-			}
-			if left > best {
-				best = left
-				direction = Left
-			}
-
-			H[i][j] = best
-			traceback[i][j] = direction
-
-			if best > maxScore {
-				maxScore = best
-				maxI, maxJ = i, j
-			}
-		}
-	}
-
-	// Traceback
-	aligned1, aligned2, start1, start2 := tracebackLocal(s1, s2, traceback, maxI, maxJ)
-
-	return NewAlignmentWithPositions(aligned1, aligned2, maxScore,
-		start1, maxI, start2, maxJ, Local)
-}
-
-// tracebackLocal performs traceback for local alignment.
-func tracebackLocal(seq1, seq2 string, traceback [][]AlignDirection,
-	startI, startJ int) (string, string, int, int) {
-	var aligned1, aligned2 strings.Builder
-	i, j := startI, startJ
-
-	for i > 0 && j > 0 {
-		direction := traceback[i][j]
-
-		switch direction {
-		case Stop:
-			goto done
-		case Diagonal:
-			aligned1.WriteByte(seq1[i-1])
-			aligned2.WriteByte(seq2[j-1])
-			i--
-			j--
-		case Up:
-			aligned1.WriteByte(seq1[i-1])
-			aligned2.WriteByte('-')
-			i--
-		case Left:
-			aligned1.WriteByte('-')
-			aligned2.WriteByte(seq2[j-1])
-			j--
-		}
-	}
-done:
-
-	// Reverse the strings
-	a1 := aligned1.String()
-	a2 := aligned2.String()
-	return reverse(a1), reverse(a2), i, j
+	return affineAlign(ctx, seq1.Bases, seq2.Bases, scoring, Local)
 }
 
 // reverse reverses a string.
@@ -361,41 +334,8 @@ func AlignmentScoreOnly(seq1, seq2 *sequence.Sequence, scoring *ScoringMatrix) (
 		return 0, fmt.Errorf("sequences must be non-empty")
 	}
 
-	m, n := seq1.Len(), seq2.Len()
-	s1, s2 := seq1.Bases, seq2.Bases
-
-	// Use two rows instead of full matrix
-	prevRow := make([]int, n+1)
-	currRow := make([]int, n+1)
-
-	maxScore := 0
-
-	for i := 1; i <= m; i++ {
-		// Reset current row
-		for j := range currRow {
-			currRow[j] = 0
-		}
-
-		for j := 1; j <= n; j++ {
-			matchScore := scoring.Score(rune(s1[i-1]), rune(s2[j-1]))
-
-			diag := prevRow[j-1] + matchScore
-			up := prevRow[j] + scoring.GapPenalty()
-			left := currRow[j-1] + scoring.GapPenalty()
-
-			best := max(0, max(diag, max(up, left)))
-			currRow[j] = best
-
-			if best > maxScore {
-				maxScore = best
-			}
-		}
-
-		// Swap rows
-		prevRow, currRow = currRow, prevRow
-	}
-
-	return maxScore, nil
+	score, _, _, err := localScorePass(context.Background(), seq1.Bases, seq2.Bases, scoring, nil, -1)
+	return score, err
 }
 
 // max returns the maximum of two integers.
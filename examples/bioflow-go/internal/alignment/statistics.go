@@ -0,0 +1,310 @@
+package alignment
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/protein"
+)
+
+// KarlinAltschul holds the statistical parameters of a scoring scheme:
+// the score scale Lambda, the search-space constant K and the relative
+// entropy H (nats per aligned pair).
+//
+// The parameters are computed exactly for ungapped alignment (Karlin &
+// Altschul 1990). No closed form exists for gapped alignment; BLAST uses
+// simulated values there. Using the ungapped values for gapped scores
+// slightly underestimates E-values, so callers with published gapped
+// parameters for their scheme can set Lambda and K directly.
+//
+// Aria equivalent:
+//
+//	struct KarlinAltschul
+//	  lambda: Float
+//	  k: Float
+//	  h: Float
+//	  invariant self.lambda > 0.0 and self.k > 0.0
+type KarlinAltschul struct {
+	Lambda float64 `json:"lambda"`
+	K      float64 `json:"k"`
+	H      float64 `json:"h"`
+}
+
+// UniformBackground is the equiprobable nucleotide composition.
+var UniformBackground = [4]float64{0.25, 0.25, 0.25, 0.25}
+
+// NewKarlinAltschul computes the parameters of a scoring matrix for
+// uniformly distributed nucleotides, or, for a substitution matrix over
+// the amino acids, for residues at AminoAcidBackground frequencies.
+func NewKarlinAltschul(scoring *ScoringMatrix) (*KarlinAltschul, error) {
+	if scoring != nil && scoring.Substitution != nil && strings.Trim(protein.AminoAcids, scoring.Substitution.Alphabet()) == "" {
+		return karlinAltschul(substitutionProbs(scoring.Substitution, AminoAcidBackground))
+	}
+	return NewKarlinAltschulBackground(scoring, UniformBackground)
+}
+
+// NewKarlinAltschulBackground computes the parameters of a scoring matrix
+// for a nucleotide composition (A, C, G, T frequencies summing to 1).
+//
+// Aria equivalent:
+//
+//	fn new_karlin_altschul(scoring: ScoringMatrix, background: [Float; 4]) -> Result<KarlinAltschul, StatsError>
+//	  requires background.sum() == 1.0
+//	  requires expected_score(scoring, background) < 0.0
+//	  ensures result.lambda > 0.0
+func NewKarlinAltschulBackground(scoring *ScoringMatrix, background [4]float64) (*KarlinAltschul, error) {
+	if scoring == nil {
+		scoring = DefaultDNA()
+	}
+	sum, same := 0.0, 0.0
+	for _, p := range background {
+		if p < 0 {
+			return nil, fmt.Errorf("background frequencies must be non-negative")
+		}
+		sum += p
+		same += p * p
+	}
+	if math.Abs(sum-1) > 1e-6 {
+		return nil, fmt.Errorf("background frequencies must sum to 1")
+	}
+
+	if scoring.Substitution != nil {
+		freqs := make(map[byte]float64, 4)
+		for i, b := range []byte("ACGT") {
+			freqs[b] = background[i]
+		}
+		return karlinAltschul(substitutionProbs(scoring.Substitution, freqs))
+	}
+	probs := map[int]float64{}
+	probs[scoring.MatchScore] += same
+	probs[scoring.MismatchPenalty] += 1 - same
+	return karlinAltschul(probs)
+}
+
+// substitutionProbs returns the distribution of the scores of a
+// substitution matrix for pairs of residues drawn independently with the
+// given frequencies, which are normalized to sum to 1.
+func substitutionProbs(m *SubstitutionMatrix, freqs map[byte]float64) map[int]float64 {
+	total := 0.0
+	for _, p := range freqs {
+		total += p
+	}
+	probs := map[int]float64{}
+	for a, pa := range freqs {
+		for b, pb := range freqs {
+			probs[m.Score(a, b)] += pa * pb / (total * total)
+		}
+	}
+	return probs
+}
+
+// karlinAltschul computes Lambda, H and K for an integer score
+// distribution.
+func karlinAltschul(probs map[int]float64) (*KarlinAltschul, error) {
+	expected, low, high := 0.0, 0, 0
+	delta := 0
+	for s, p := range probs {
+		if p <= 0 {
+			continue
+		}
+		expected += float64(s) * p
+		if s < low {
+			low = s
+		}
+		if s > high {
+			high = s
+		}
+		delta = gcd(delta, abs(s))
+	}
+	if expected >= 0 {
+		return nil, fmt.Errorf("expected score must be negative for local alignment statistics")
+	}
+	if high <= 0 {
+		return nil, fmt.Errorf("scoring scheme needs a positive score")
+	}
+
+	// Lambda is the unique positive root of sum p(s) e^(lambda s) = 1.
+	f := func(lambda float64) float64 {
+		total := 0.0
+		for s, p := range probs {
+			total += p * math.Exp(lambda*float64(s))
+		}
+		return total - 1
+	}
+	lo, hi := 0.0, 1.0
+	for f(hi) < 0 {
+		lo, hi = hi, 2*hi
+	}
+	for i := 0; i < 200; i++ {
+		mid := (lo + hi) / 2
+		if f(mid) < 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	lambda := (lo + hi) / 2
+
+	h := 0.0
+	for s, p := range probs {
+		h += float64(s) * p * math.Exp(lambda*float64(s))
+	}
+	h *= lambda
+
+	// K = lambda delta e^(-2 sigma) / (H (1 - e^(-lambda delta))), where
+	// sigma = sum_k 1/k (E[e^(lambda S_k); S_k < 0] + P(S_k >= 0)) over
+	// the partial sums S_k of the random walk.
+	dist := map[int]float64{0: 1}
+	sigma := 0.0
+	for k := 1; k <= 1000; k++ {
+		next := make(map[int]float64, len(dist)+len(probs))
+		for x, px := range dist {
+			for s, ps := range probs {
+				next[x+s] += px * ps
+			}
+		}
+		dist = next
+
+		term := 0.0
+		for x, px := range dist {
+			if x < 0 {
+				term += px * math.Exp(lambda*float64(x))
+			} else {
+				term += px
+			}
+		}
+		sigma += term / float64(k)
+		if term/float64(k) < 1e-12 {
+			break
+		}
+		// Drop negligible mass far below zero to keep the walk small.
+		for x, px := range dist {
+			if px*math.Exp(lambda*float64(x)) < 1e-300 && x < 0 {
+				delete(dist, x)
+			}
+		}
+	}
+
+	fd := float64(delta)
+	kConst := lambda * fd * math.Exp(-2*sigma) / (h * (1 - math.Exp(-lambda*fd)))
+	return &KarlinAltschul{Lambda: lambda, K: kConst, H: h}, nil
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// SearchSpace describes the query and database an alignment was found in.
+type SearchSpace struct {
+	QueryLength int
+	// DatabaseLength is the total number of residues in the database.
+	DatabaseLength int64
+	// DatabaseSequences is the number of sequences in the database
+	// (default 1).
+	DatabaseSequences int
+}
+
+// LengthAdjustment returns the expected alignment length correction l
+// for a search space, the fixed point of l = ln(K (m - l)(n - N l)) / H
+// used by BLAST to compute effective lengths.
+func (ka *KarlinAltschul) LengthAdjustment(space SearchSpace) int {
+	m := float64(space.QueryLength)
+	n := float64(space.DatabaseLength)
+	N := float64(space.DatabaseSequences)
+	if N < 1 {
+		N = 1
+	}
+	l := 0.0
+	for i := 0; i < 20; i++ {
+		mEff := m - l
+		nEff := n - N*l
+		if mEff < 1 || nEff < 1 {
+			break
+		}
+		next := math.Log(ka.K*mEff*nEff) / ka.H
+		if next < 0 {
+			next = 0
+		}
+		if math.Abs(next-l) < 0.5 {
+			l = next
+			break
+		}
+		l = next
+	}
+	// Keep at least one residue per query and database sequence.
+	maxL := math.Min(m-1, n/N-1)
+	if l > maxL {
+		l = math.Max(maxL, 0)
+	}
+	return int(l)
+}
+
+// EffectiveSearchSpace returns (m - l)(n - N l).
+func (ka *KarlinAltschul) EffectiveSearchSpace(space SearchSpace) float64 {
+	l := float64(ka.LengthAdjustment(space))
+	n := float64(space.DatabaseSequences)
+	if n < 1 {
+		n = 1
+	}
+	return (float64(space.QueryLength) - l) * (float64(space.DatabaseLength) - n*l)
+}
+
+// BitScore converts a raw score to a bit score, (lambda S - ln K) / ln 2,
+// which is comparable across scoring schemes.
+func (ka *KarlinAltschul) BitScore(score int) float64 {
+	return (ka.Lambda*float64(score) - math.Log(ka.K)) / math.Ln2
+}
+
+// EValue returns the expected number of chance alignments scoring at
+// least score in the search space, K m' n' e^(-lambda S).
+//
+// Aria equivalent:
+//
+//	fn e_value(self, score: Int, space: SearchSpace) -> Float
+//	  requires space.query_length > 0 and space.database_length > 0
+//	  ensures result >= 0.0
+func (ka *KarlinAltschul) EValue(score int, space SearchSpace) float64 {
+	return ka.EffectiveSearchSpace(space) * math.Exp2(-ka.BitScore(score))
+}
+
+// PValue returns the probability of at least one chance alignment
+// scoring at least score, 1 - e^(-E).
+func (ka *KarlinAltschul) PValue(score int, space SearchSpace) float64 {
+	return -math.Expm1(-ka.EValue(score, space))
+}
+
+// Significance is the statistical summary of an alignment score.
+type Significance struct {
+	RawScore int     `json:"raw_score"`
+	BitScore float64 `json:"bit_score"`
+	EValue   float64 `json:"e_value"`
+}
+
+// Significance returns the bit score and E-value of a local alignment in
+// a search space. When the database is unspecified the target sequence
+// alone is used.
+func (a *Alignment) Significance(ka *KarlinAltschul, space SearchSpace) Significance {
+	if space.QueryLength <= 0 {
+		space.QueryLength = a.End1
+	}
+	if space.DatabaseLength <= 0 {
+		space.DatabaseLength = int64(a.End2)
+		space.DatabaseSequences = 1
+	}
+	return Significance{
+		RawScore: a.Score,
+		BitScore: ka.BitScore(a.Score),
+		EValue:   ka.EValue(a.Score, space),
+	}
+}
@@ -0,0 +1,284 @@
+package alignment
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/data"
+	"github.com/aria-lang/bioflow-go/internal/protein"
+)
+
+// SubstitutionMatrix scores every pair of residues, as BLOSUM and PAM
+// matrices do for amino acids. Residues are matched case-insensitively;
+// residues outside the alphabet score as X, or as the lowest score of the
+// matrix if it has no X.
+//
+// Aria equivalent:
+//
+//	struct SubstitutionMatrix
+//	  name: String
+//	  alphabet: String
+//	  scores: Map<(Char, Char), Int>
+//	  invariant self.alphabet.chars().all(|a| self.alphabet.chars().all(|b| self.scores.contains((a, b))))
+type SubstitutionMatrix struct {
+	Name     string
+	alphabet string
+	// index maps a byte to its row in scores, or -1.
+	index    [256]int8
+	scores   [][]int
+	fallback int // row of X, or -1
+	min, max int
+}
+
+// ParseSubstitutionMatrix reads a matrix in the NCBI format of BLAST's
+// data directory: lines starting with # are comments, the first other
+// line lists the residues of the columns, and each further line gives a
+// residue and its scores against the columns.
+//
+// Aria equivalent:
+//
+//	fn parse_substitution_matrix(name: String, reader: Reader) -> Result<SubstitutionMatrix, AlignmentError>
+func ParseSubstitutionMatrix(name string, r io.Reader) (*SubstitutionMatrix, error) {
+	m := &SubstitutionMatrix{Name: name, fallback: -1}
+	for i := range m.index {
+		m.index[i] = -1
+	}
+	var columns []byte
+	rows := make(map[byte][]int)
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if columns == nil {
+			for _, f := range fields {
+				if len(f) != 1 {
+					return nil, fmt.Errorf("matrix %s line %d: residue %q is not one character", name, lineNum, f)
+				}
+				c := upper(f[0])
+				if strings.IndexByte(string(columns), c) >= 0 {
+					return nil, fmt.Errorf("matrix %s line %d: residue %c listed twice", name, lineNum, c)
+				}
+				columns = append(columns, c)
+			}
+			if len(columns) > 127 {
+				return nil, fmt.Errorf("matrix %s: too many residues", name)
+			}
+			continue
+		}
+		if len(fields[0]) != 1 {
+			return nil, fmt.Errorf("matrix %s line %d: residue %q is not one character", name, lineNum, fields[0])
+		}
+		c := upper(fields[0][0])
+		if strings.IndexByte(string(columns), c) < 0 {
+			return nil, fmt.Errorf("matrix %s line %d: residue %c is not a column", name, lineNum, c)
+		}
+		if _, dup := rows[c]; dup {
+			return nil, fmt.Errorf("matrix %s line %d: residue %c listed twice", name, lineNum, c)
+		}
+		if len(fields)-1 != len(columns) {
+			return nil, fmt.Errorf("matrix %s line %d: expected %d scores, got %d", name, lineNum, len(columns), len(fields)-1)
+		}
+		row := make([]int, len(columns))
+		for j, f := range fields[1:] {
+			v, err := strconv.Atoi(f)
+			if err != nil {
+				return nil, fmt.Errorf("matrix %s line %d: invalid score %q", name, lineNum, f)
+			}
+			row[j] = v
+		}
+		rows[c] = row
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading matrix %s: %w", name, err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("matrix %s has no residues", name)
+	}
+
+	m.alphabet = string(columns)
+	m.scores = make([][]int, len(columns))
+	for i, c := range columns {
+		row, ok := rows[c]
+		if !ok {
+			return nil, fmt.Errorf("matrix %s has no row for %c", name, c)
+		}
+		m.scores[i] = row
+		m.index[c] = int8(i)
+		if c >= 'A' && c <= 'Z' {
+			m.index[c|0x20] = int8(i)
+		}
+	}
+	m.min, m.max = m.scores[0][0], m.scores[0][0]
+	for _, row := range m.scores {
+		for _, v := range row {
+			m.min, m.max = min(m.min, v), max(m.max, v)
+		}
+	}
+	if i := m.index['X']; i >= 0 {
+		m.fallback = int(i)
+	}
+	return m, nil
+}
+
+// upper returns the upper case of an ASCII letter.
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 0x20
+	}
+	return c
+}
+
+// LoadSubstitutionMatrix reads an NCBI-format matrix file, named by its
+// file name.
+func LoadSubstitutionMatrix(path string) (*SubstitutionMatrix, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening matrix: %w", err)
+	}
+	defer f.Close()
+	return ParseSubstitutionMatrix(filepath.Base(path), f)
+}
+
+// SubstitutionMatrixNames returns the names of the built-in matrices,
+// with those added by the data directory.
+func SubstitutionMatrixNames() []string {
+	return data.Names("matrices")
+}
+
+// SubstitutionMatrixByName returns a built-in matrix (BLOSUM62, BLOSUM80
+// or PAM250), or one of the data directory, by case-insensitive name.
+//
+// Aria equivalent:
+//
+//	fn substitution_matrix(name: String) -> Result<SubstitutionMatrix, AlignmentError>
+func SubstitutionMatrixByName(name string) (*SubstitutionMatrix, error) {
+	_, content, err := data.Lookup("matrices", name)
+	if errors.Is(err, data.ErrNotFound) {
+		return nil, fmt.Errorf("unknown substitution matrix %q (want %s)", name, strings.Join(SubstitutionMatrixNames(), ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseSubstitutionMatrix(strings.ToUpper(name), bytes.NewReader(content))
+}
+
+// Alphabet returns the residues of the matrix, in the order of its file.
+func (m *SubstitutionMatrix) Alphabet() string {
+	return m.alphabet
+}
+
+// Min returns the lowest score of the matrix.
+func (m *SubstitutionMatrix) Min() int {
+	return m.min
+}
+
+// Max returns the highest score of the matrix.
+func (m *SubstitutionMatrix) Max() int {
+	return m.max
+}
+
+// Score returns the score of aligning two residues.
+func (m *SubstitutionMatrix) Score(a, b byte) int {
+	i, j := int(m.index[a]), int(m.index[b])
+	if i < 0 {
+		i = m.fallback
+	}
+	if j < 0 {
+		j = m.fallback
+	}
+	if i < 0 || j < 0 {
+		return m.min
+	}
+	return m.scores[i][j]
+}
+
+// NewSubstitutionScoring returns a scoring matrix backed by a substitution
+// matrix, with affine gap penalties. MatchScore and MismatchPenalty are
+// set to the highest and lowest scores of the matrix.
+//
+// Aria equivalent:
+//
+//	fn new_substitution_scoring(matrix: SubstitutionMatrix, gap_open: Int, gap_extend: Int) -> Result<ScoringMatrix, AlignmentError>
+//	  requires gap_open <= 0 and gap_extend <= 0
+func NewSubstitutionScoring(m *SubstitutionMatrix, gapOpen, gapExtend int) (*ScoringMatrix, error) {
+	if m == nil {
+		return nil, fmt.Errorf("substitution matrix is required")
+	}
+	if gapOpen > 0 {
+		return nil, fmt.Errorf("gap open penalty should be <= 0")
+	}
+	if gapExtend > 0 {
+		return nil, fmt.Errorf("gap extend penalty should be <= 0")
+	}
+	return &ScoringMatrix{
+		MatchScore:       m.Max(),
+		MismatchPenalty:  m.Min(),
+		GapOpenPenalty:   gapOpen,
+		GapExtendPenalty: gapExtend,
+		Substitution:     m,
+	}, nil
+}
+
+// DefaultProtein creates a protein scoring matrix: BLOSUM62 with BLAST's
+// default gap costs of 11 to open and 1 per residue, so a one-residue gap
+// scores -12. It uses the built-in BLOSUM62 whatever the data directory
+// holds; SubstitutionMatrixByName loads an overriding one.
+func DefaultProtein() *ScoringMatrix {
+	s, _ := NewSubstitutionScoring(blosum62, -12, -1)
+	return s
+}
+
+// blosum62 is the built-in BLOSUM62 matrix of DefaultProtein.
+var blosum62 = builtinMatrix("BLOSUM62")
+
+// builtinMatrix parses a built-in substitution matrix, which cannot fail.
+func builtinMatrix(name string) *SubstitutionMatrix {
+	content, err := data.Embedded("matrices/" + name)
+	if err != nil {
+		panic(err)
+	}
+	m, err := ParseSubstitutionMatrix(name, bytes.NewReader(content))
+	if err != nil {
+		panic(fmt.Sprintf("substitution matrix %s: %v", name, err))
+	}
+	return m
+}
+
+// AlignProteins aligns two proteins, ignoring trailing stops, with local,
+// global or semi-global alignment. A nil scoring uses DefaultProtein.
+//
+// Aria equivalent:
+//
+//	fn align_proteins(p1: Protein, p2: Protein, scoring: ScoringMatrix, kind: AlignmentType) -> Result<Alignment, AlignmentError>
+//	  requires p1.len() > 0 and p2.len() > 0
+func AlignProteins(ctx context.Context, p1, p2 *protein.Protein, scoring *ScoringMatrix, kind AlignmentType) (*Alignment, error) {
+	if scoring == nil {
+		scoring = DefaultProtein()
+	}
+	s1, s2 := p1.TrimStop(), p2.TrimStop()
+	if len(s1) == 0 || len(s2) == 0 {
+		return nil, fmt.Errorf("proteins must be non-empty")
+	}
+	return affineAlign(ctx, s1, s2, scoring, kind)
+}
+
+// AminoAcidBackground holds the amino acid frequencies of Robinson and
+// Robinson (1991), which BLAST computes protein statistics with.
+var AminoAcidBackground = map[byte]float64{
+	'A': 0.07805, 'R': 0.05129, 'N': 0.04487, 'D': 0.05364, 'C': 0.01925,
+	'Q': 0.04264, 'E': 0.06295, 'G': 0.07377, 'H': 0.02199, 'I': 0.05142,
+	'L': 0.09019, 'K': 0.05744, 'M': 0.02243, 'F': 0.03856, 'P': 0.05203,
+	'S': 0.07120, 'T': 0.05841, 'W': 0.01330, 'Y': 0.03216, 'V': 0.06441,
+}
@@ -0,0 +1,284 @@
+// Package amplicon trims PCR primers from amplicon reads.
+//
+// Reads of a tiled amplicon panel start with one of the panel's primers
+// and, when they run through the whole amplicon, end with the reverse
+// complement of its partner. Primer bases carry the primer sequence, not
+// the sample's, so they are trimmed before variant calling. A Trimmer
+// looks for an exact primer starting within a window of the 5' end and an
+// exact reverse-complemented primer ending within a window of the 3' end,
+// trims each together with any bases outside it, and can discard reads in
+// which the primers were not found.
+//
+// PCR also joins the ends of two templates into chimeras, which look like
+// novel sequences. A ChimeraDetector finds them against a reference set,
+// as UCHIME does. The remaining sequences are dereplicated and clustered
+// into OTUs, or kept apart as ASV-like groups, with an abundance table
+// across samples from which alpha and beta diversity are measured.
+// Rarefaction curves, of richness in seeded random subsamples, show
+// whether samples were sequenced deeply enough to compare.
+//
+// Comparison with Aria:
+//
+//	Aria states what trimming keeps:
+//	  fn match(self, bases: String) -> Match
+//	    ensures 0 <= result.start and result.start <= result.end
+//	    ensures result.end <= bases.len()
+//
+//	Go documents it and checks it in tests.
+package amplicon
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Primer is a named primer sequence, 5' to 3'.
+type Primer struct {
+	Name     string `json:"name"`
+	Sequence string `json:"sequence"`
+}
+
+// Requirement selects the reads kept by primer they contain.
+type Requirement int
+
+const (
+	// RequireNone keeps every read, trimmed where primers were found.
+	RequireNone Requirement = iota
+	// RequireFivePrime discards reads without a primer at the 5' end.
+	RequireFivePrime
+	// RequireBoth discards reads without a primer at both ends.
+	RequireBoth
+)
+
+// String returns the requirement as ParseRequirement accepts it.
+func (r Requirement) String() string {
+	switch r {
+	case RequireNone:
+		return "none"
+	case RequireFivePrime:
+		return "5prime"
+	case RequireBoth:
+		return "both"
+	}
+	return "unknown"
+}
+
+// ParseRequirement parses none, 5prime or both.
+func ParseRequirement(name string) (Requirement, error) {
+	switch strings.ToLower(name) {
+	case "none", "":
+		return RequireNone, nil
+	case "5prime", "5'", "five_prime":
+		return RequireFivePrime, nil
+	case "both":
+		return RequireBoth, nil
+	}
+	return 0, fmt.Errorf("unknown primer requirement %q (want none, 5prime or both)", name)
+}
+
+// Options configures a Trimmer.
+type Options struct {
+	// Window is how many bases may precede a 5' primer or follow a 3'
+	// primer, such as inline barcodes or a staggered start; they are
+	// trimmed with the primer. Zero requires primers at the read ends.
+	Window int
+	// Require selects the reads kept.
+	Require Requirement
+}
+
+// Match is where primers were found in a read. Start and End are the
+// 0-based, half-open bounds of the bases left after trimming.
+type Match struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// FivePrime and ThreePrime name the primers found, or are empty.
+	FivePrime  string `json:"five_prime,omitempty"`
+	ThreePrime string `json:"three_prime,omitempty"`
+}
+
+// Trimmer finds and trims primers in reads.
+type Trimmer struct {
+	opts    Options
+	primers []Primer
+	// rc holds the reverse complement of each primer.
+	rc []string
+	// k is the seed length: the primers are indexed by their first k
+	// bases for the 5' end and the last k bases of their reverse
+	// complement for the 3' end.
+	k     int
+	five  map[string][]int
+	three map[string][]int
+}
+
+// maxSeed caps the seed length of the primer index.
+const maxSeed = 12
+
+// NewTrimmer creates a Trimmer for a primer set. Primer sequences are
+// matched case-insensitively and must consist of A, C, G and T.
+//
+// Aria equivalent:
+//
+//	fn new_trimmer(primers: [Primer], options: Options) -> Result<Trimmer, AmpliconError>
+//	  requires primers.len() > 0
+//	  requires options.window >= 0
+func NewTrimmer(primers []Primer, opts Options) (*Trimmer, error) {
+	if len(primers) == 0 {
+		return nil, fmt.Errorf("no primers given")
+	}
+	if opts.Window < 0 {
+		return nil, fmt.Errorf("primer window must not be negative")
+	}
+	t := &Trimmer{
+		opts:    opts,
+		primers: make([]Primer, len(primers)),
+		rc:      make([]string, len(primers)),
+		k:       maxSeed,
+		five:    make(map[string][]int),
+		three:   make(map[string][]int),
+	}
+	for i, p := range primers {
+		bases := strings.ToUpper(p.Sequence)
+		if bases == "" {
+			return nil, fmt.Errorf("primer %s is empty", p.Name)
+		}
+		if j := strings.IndexFunc(bases, func(c rune) bool { return !strings.ContainsRune("ACGT", c) }); j >= 0 {
+			return nil, fmt.Errorf("primer %s: invalid base %q at %d", p.Name, bases[j], j+1)
+		}
+		t.primers[i] = Primer{Name: p.Name, Sequence: bases}
+		t.rc[i] = reverseComplement(bases)
+		t.k = min(t.k, len(bases))
+	}
+	for i, p := range t.primers {
+		t.five[p.Sequence[:t.k]] = append(t.five[p.Sequence[:t.k]], i)
+		seed := t.rc[i][len(t.rc[i])-t.k:]
+		t.three[seed] = append(t.three[seed], i)
+	}
+	return t, nil
+}
+
+// Primers returns the primers of the trimmer, upper-cased.
+func (t *Trimmer) Primers() []Primer {
+	return t.primers
+}
+
+// Match finds the primers of a read of upper-case bases. At each end the
+// primer found closest to the end wins, the longest at the same place;
+// the 3' primer must not overlap the 5' one.
+//
+// Aria equivalent:
+//
+//	fn match(self, bases: String) -> Match
+//	  ensures 0 <= result.start and result.start <= result.end and result.end <= bases.len()
+func (t *Trimmer) Match(bases string) Match {
+	m := Match{End: len(bases)}
+	for o := 0; o <= t.opts.Window && o+t.k <= len(bases); o++ {
+		best := -1
+		for _, i := range t.five[bases[o:o+t.k]] {
+			p := t.primers[i].Sequence
+			if strings.HasPrefix(bases[o:], p) && (best < 0 || len(p) > len(t.primers[best].Sequence)) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			m.Start = o + len(t.primers[best].Sequence)
+			m.FivePrime = t.primers[best].Name
+			break
+		}
+	}
+	for e := len(bases); e >= len(bases)-t.opts.Window && e-t.k >= m.Start; e-- {
+		best := -1
+		for _, i := range t.three[bases[e-t.k:e]] {
+			rc := t.rc[i]
+			if e-len(rc) >= m.Start && strings.HasSuffix(bases[:e], rc) && (best < 0 || len(rc) > len(t.rc[best])) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			m.End = e - len(t.rc[best])
+			m.ThreePrime = t.primers[best].Name
+			break
+		}
+	}
+	return m
+}
+
+// Check returns why a read with the given match is discarded, or the
+// empty string if it is kept.
+func (t *Trimmer) Check(m Match) string {
+	switch {
+	case m.FivePrime == "" && t.opts.Require != RequireNone:
+		return "no 5' primer"
+	case m.ThreePrime == "" && t.opts.Require == RequireBoth:
+		return "no 3' primer"
+	case m.End <= m.Start:
+		return "no bases left after primer trimming"
+	}
+	return ""
+}
+
+// reverseComplement returns the reverse complement of ACGT bases.
+func reverseComplement(bases string) string {
+	b := make([]byte, len(bases))
+	for i := 0; i < len(bases); i++ {
+		var c byte
+		switch bases[len(bases)-1-i] {
+		case 'A':
+			c = 'T'
+		case 'C':
+			c = 'G'
+		case 'G':
+			c = 'C'
+		case 'T':
+			c = 'A'
+		}
+		b[i] = c
+	}
+	return string(b)
+}
+
+// Stats counts the primers found in reads.
+type Stats struct {
+	Reads      int `json:"reads"`
+	FivePrime  int `json:"five_prime"`
+	ThreePrime int `json:"three_prime"`
+	Discarded  int `json:"discarded"`
+	// ByPrimer counts the reads each primer was found in, at either end.
+	ByPrimer map[string]int `json:"by_primer"`
+}
+
+// Add counts the match of one read, discarded or not.
+func (s *Stats) Add(m Match, discarded bool) {
+	if s.ByPrimer == nil {
+		s.ByPrimer = make(map[string]int)
+	}
+	s.Reads++
+	if m.FivePrime != "" {
+		s.FivePrime++
+		s.ByPrimer[m.FivePrime]++
+	}
+	if m.ThreePrime != "" {
+		s.ThreePrime++
+		if m.ThreePrime != m.FivePrime {
+			s.ByPrimer[m.ThreePrime]++
+		}
+	}
+	if discarded {
+		s.Discarded++
+	}
+}
+
+// PrimerNames returns the names of the primers found, most frequent first.
+func (s *Stats) PrimerNames() []string {
+	names := make([]string, 0, len(s.ByPrimer))
+	for name := range s.ByPrimer {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if s.ByPrimer[names[i]] != s.ByPrimer[names[j]] {
+			return s.ByPrimer[names[i]] > s.ByPrimer[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
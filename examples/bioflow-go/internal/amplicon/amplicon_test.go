@@ -0,0 +1,313 @@
+package amplicon
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPrimers = []Primer{
+	{Name: "amp1_LEFT", Sequence: "acgtacgga"},
+	{Name: "amp1_RIGHT", Sequence: "TTGCAGGCA"},
+	{Name: "amp1_LEFT_alt", Sequence: "ACGTACGGATC"},
+}
+
+func TestMatch(t *testing.T) {
+	tr, err := NewTrimmer(testPrimers, Options{Window: 2})
+	require.NoError(t, err)
+
+	// The longer alternative wins at the 5' end; the 3' end holds the
+	// reverse complement of amp1_RIGHT (TGCCTGCAA) followed by one base.
+	insert := "GGGGCCCCAAAATTTT"
+	read := "T" + "ACGTACGGATC" + insert + "TGCCTGCAA" + "G"
+	m := tr.Match(read)
+	assert.Equal(t, "amp1_LEFT_alt", m.FivePrime)
+	assert.Equal(t, "amp1_RIGHT", m.ThreePrime)
+	assert.Equal(t, insert, read[m.Start:m.End])
+	assert.Empty(t, tr.Check(m))
+
+	// Outside the window, nothing is trimmed.
+	far := "TTT" + "ACGTACGGA" + insert
+	m = tr.Match(far)
+	assert.Equal(t, Match{Start: 0, End: len(far)}, m)
+
+	// A primer must match exactly.
+	m = tr.Match("ACGTACCGA" + insert)
+	assert.Empty(t, m.FivePrime)
+}
+
+func TestCheck(t *testing.T) {
+	five, err := NewTrimmer(testPrimers, Options{Require: RequireFivePrime})
+	require.NoError(t, err)
+	both, err := NewTrimmer(testPrimers, Options{Require: RequireBoth})
+	require.NoError(t, err)
+
+	read := "ACGTACGGA" + "GGGGCCCC"
+	assert.Empty(t, five.Check(five.Match(read)))
+	assert.Equal(t, "no 3' primer", both.Check(both.Match(read)))
+	assert.Equal(t, "no 5' primer", five.Check(five.Match("GGGGCCCC")))
+
+	// A read that is only a primer has nothing left.
+	assert.Equal(t, "no bases left after primer trimming", five.Check(five.Match("ACGTACGGA")))
+
+	var stats Stats
+	stats.Add(both.Match(read), true)
+	stats.Add(both.Match("TTGCAGGCA"+"AAAA"+"TCCGTACGT"), false)
+	assert.Equal(t, 2, stats.Reads)
+	assert.Equal(t, 2, stats.FivePrime)
+	assert.Equal(t, 1, stats.ThreePrime)
+	assert.Equal(t, 1, stats.Discarded)
+	assert.Equal(t, []string{"amp1_LEFT", "amp1_RIGHT"}, stats.PrimerNames())
+}
+
+func TestNewTrimmer(t *testing.T) {
+	_, err := NewTrimmer(nil, Options{})
+	assert.Error(t, err)
+	_, err = NewTrimmer([]Primer{{Name: "p", Sequence: "ACGN"}}, Options{})
+	assert.Error(t, err)
+	_, err = NewTrimmer(testPrimers, Options{Window: -1})
+	assert.Error(t, err)
+
+	r, err := ParseRequirement("5prime")
+	require.NoError(t, err)
+	assert.Equal(t, RequireFivePrime, r)
+	assert.Equal(t, "both", RequireBoth.String())
+	_, err = ParseRequirement("3prime")
+	assert.Error(t, err)
+}
+
+// diverged returns bases with every step-th base substituted.
+func diverged(bases string, step int) string {
+	b := []byte(bases)
+	for i := step / 2; i < len(b); i += step {
+		b[i] = "CGTA"[strings.IndexByte("ACGT", b[i])]
+	}
+	return string(b)
+}
+
+func randomBases(rng *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = "ACGT"[rng.Intn(4)]
+	}
+	return string(b)
+}
+
+func TestDetectChimeras(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	a := randomBases(rng, 300)
+	b := diverged(a, 8)
+	c := randomBases(rng, 300)
+	query := func(bases, id string) *sequence.Sequence {
+		s, err := sequence.WithID(bases, id)
+		require.NoError(t, err)
+		return s
+	}
+	refs := []*sequence.Sequence{query(a, "A"), query(b, "B"), query(c, "C")}
+	queries := []*sequence.Sequence{
+		query(a[:150]+b[150:], "ab"),
+		query(b[:120]+a[120:], "ba"),
+		query(a[:100]+"T"+a[101:], "a_variant"),
+		query(b, "b"),
+	}
+	calls, err := DetectChimeras(queries, refs, DefaultChimeraOptions())
+	require.NoError(t, err)
+	require.Len(t, calls, 4)
+
+	ab := calls[0]
+	assert.True(t, ab.Chimeric)
+	assert.Equal(t, "chimeric", ab.Label())
+	assert.Equal(t, "A", ab.ParentA)
+	assert.Equal(t, "B", ab.ParentB)
+	assert.InDelta(t, 150, ab.Crossover, 8)
+	assert.Equal(t, 1.0, ab.ModelIdentity)
+	assert.InDelta(t, 0.94, ab.ParentIdentity, 0.01)
+
+	assert.True(t, calls[1].Chimeric)
+	assert.Equal(t, "B", calls[1].ParentA)
+	assert.Equal(t, "A", calls[1].ParentB)
+
+	assert.False(t, calls[2].Chimeric)
+	assert.Equal(t, "clean", calls[2].Label())
+	assert.False(t, calls[3].Chimeric)
+	assert.Equal(t, 1.0, calls[3].ParentIdentity)
+
+	_, err = DetectChimeras(queries, nil, DefaultChimeraOptions())
+	assert.Error(t, err)
+	_, err = DetectChimeras(queries, refs, ChimeraOptions{})
+	assert.Error(t, err)
+}
+
+func TestClusterUniques(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	a := randomBases(rng, 200)
+	b := randomBases(rng, 200)
+	// One substitution from a (99.5%), and a with a base deleted.
+	a1 := a[:50] + "A" + a[51:]
+	if a1 == a {
+		a1 = a[:50] + "C" + a[51:]
+	}
+	a2 := a[:120] + a[121:]
+
+	d := NewDereplicator()
+	for i := 0; i < 5; i++ {
+		d.Add("s1", a)
+		d.Add("s2", strings.ToLower(b))
+	}
+	d.Add("s1", b)
+	d.Add("s2", a1)
+	d.Add("s2", a1)
+	d.Add("s1", a2)
+	d.Add("s1", a2)
+	d.Add("s2", a2)
+	d.Add("s2", randomBases(rng, 200))
+	assert.Equal(t, []string{"s1", "s2"}, d.Samples())
+	uniques := d.Uniques()
+	require.Len(t, uniques, 5)
+	assert.Equal(t, 6, uniques[0].Size)
+
+	otus, err := ClusterUniques(uniques, d.Samples(), DefaultClusterOptions())
+	require.NoError(t, err)
+	require.Len(t, otus.Clusters, 2)
+	assert.Equal(t, 1, otus.Dropped)
+
+	first := otus.Clusters[0]
+	assert.Equal(t, "OTU_1", first.ID)
+	assert.Equal(t, b, first.Centroid)
+	assert.Equal(t, map[string]int{"s1": 1, "s2": 5}, first.Counts)
+	second := otus.Clusters[1]
+	assert.Equal(t, a, second.Centroid)
+	assert.Equal(t, a, second.Consensus)
+	assert.Equal(t, 10, second.Size)
+	assert.Equal(t, 3, second.Uniques)
+	assert.Equal(t, map[string]int{"s1": 7, "s2": 3}, second.Counts)
+
+	var table strings.Builder
+	require.NoError(t, otus.WriteTable(&table))
+	assert.Equal(t, "#OTU ID\ts1\ts2\nOTU_1\t1\t5\nOTU_2\t7\t3\n", table.String())
+	var fasta strings.Builder
+	require.NoError(t, otus.WriteRepresentatives(&fasta, false))
+	assert.True(t, strings.HasPrefix(fasta.String(), ">OTU_1;size=6\n"+b+"\n"))
+
+	// At 100% identity each distinct sequence is its own cluster.
+	opts := DefaultClusterOptions()
+	opts.Identity, opts.MinSize = 1, 1
+	asvs, err := ClusterUniques(uniques, d.Samples(), opts)
+	require.NoError(t, err)
+	assert.Len(t, asvs.Clusters, 5)
+
+	opts.Identity = 0
+	_, err = ClusterUniques(uniques, d.Samples(), opts)
+	assert.Error(t, err)
+}
+
+func TestConsensus(t *testing.T) {
+	// Two of three members (by abundance) carry a T where the centroid
+	// has a C, and most have a gap at the third position.
+	profile := [][5]int{{3, 0, 0, 0, 0}, {0, 1, 0, 2, 0}, {1, 0, 0, 0, 2}, {0, 0, 3, 0, 0}}
+	assert.Equal(t, "ATG", consensus(profile))
+}
+
+func TestDiversity(t *testing.T) {
+	table, err := ReadAbundanceTable(strings.NewReader(
+		"# Constructed from biom file\n#OTU ID\tS1\tS2\tS3\nOTU_1\t10\t0\t0\nOTU_2\t10\t1.0\t0\nOTU_3\t1\t2\t0\nOTU_4\t1\t0\t0\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"S1", "S2", "S3"}, table.Samples)
+	assert.Equal(t, []string{"OTU_1", "OTU_2", "OTU_3", "OTU_4"}, table.Features)
+
+	alpha := table.AlphaDiversity()
+	s1 := alpha[0]
+	assert.Equal(t, 22, s1.Depth)
+	assert.Equal(t, 4, s1.Observed)
+	p := []float64{10.0 / 22, 10.0 / 22, 1.0 / 22, 1.0 / 22}
+	shannon, simpson := 0.0, 1.0
+	for _, x := range p {
+		shannon -= x * math.Log(x)
+		simpson -= x * x
+	}
+	assert.InDelta(t, shannon, s1.Shannon, 1e-12)
+	assert.InDelta(t, simpson, s1.Simpson, 1e-12)
+	// Two singletons and no doubleton: 4 + 2*1/2.
+	assert.InDelta(t, 5, s1.Chao1, 1e-12)
+	// One singleton and one doubleton: 2 + 1/2.
+	assert.InDelta(t, 2.5, alpha[1].Chao1, 1e-12)
+	assert.Equal(t, Alpha{Sample: "S3"}, alpha[2])
+
+	bc := table.BetaDiversity(BrayCurtis)
+	// |10-0| + |10-1| + |1-2| + |1-0| over 22 + 3.
+	assert.InDelta(t, 21.0/25, bc.Values[0][1], 1e-12)
+	assert.Equal(t, bc.Values[0][1], bc.Values[1][0])
+	assert.Zero(t, bc.Values[0][0])
+	assert.Equal(t, 1.0, bc.Values[0][2])
+	jac := table.BetaDiversity(Jaccard)
+	assert.InDelta(t, 0.5, jac.Values[0][1], 1e-12)
+	assert.Zero(t, jac.Values[2][2])
+
+	var out strings.Builder
+	require.NoError(t, jac.WritePHYLIP(&out))
+	assert.Equal(t, "3\nS1 0.000000 0.500000 1.000000\nS2 0.500000 0.000000 1.000000\nS3 1.000000 1.000000 0.000000\n", out.String())
+
+	out.Reset()
+	require.NoError(t, table.Write(&out))
+	again, err := ReadAbundanceTable(strings.NewReader(out.String()))
+	require.NoError(t, err)
+	assert.Equal(t, table, again)
+
+	_, err = ReadAbundanceTable(strings.NewReader("#OTU ID\tS1\nOTU_1\t1\t2\n"))
+	assert.Error(t, err)
+	_, err = ReadAbundanceTable(strings.NewReader("#OTU ID\tS1\nOTU_1\t1.5\n"))
+	assert.Error(t, err)
+	_, err = ParseBetaMetric("unifrac")
+	assert.Error(t, err)
+}
+
+func TestRarefaction(t *testing.T) {
+	table := &AbundanceTable{
+		Samples:  []string{"even", "skewed"},
+		Features: []string{"a", "b", "c"},
+		Counts:   [][]int{{5, 18}, {5, 1}, {0, 1}},
+	}
+	opts := DefaultRarefactionOptions()
+	opts.Depths = []int{10, 1, 15, 10}
+	curves, err := table.Rarefaction(opts)
+	require.NoError(t, err)
+	require.Len(t, curves, 2)
+
+	even := curves[0]
+	assert.Equal(t, 10, even.Depth)
+	require.Len(t, even.Points, 2)
+	assert.Equal(t, RarefactionPoint{Depth: 1, Mean: 1, Min: 1, Max: 1}, even.Points[0])
+	assert.Equal(t, RarefactionPoint{Depth: 10, Mean: 2, Min: 2, Max: 2}, even.Points[1])
+
+	skewed := curves[1]
+	require.Len(t, skewed.Points, 4)
+	assert.Equal(t, []int{1, 10, 15, 20}, []int{skewed.Points[0].Depth, skewed.Points[1].Depth, skewed.Points[2].Depth, skewed.Points[3].Depth})
+	assert.Equal(t, 3.0, skewed.Points[3].Mean)
+	p := skewed.Points[2]
+	assert.True(t, p.Min >= 1 && p.Max <= 3 && p.Mean >= float64(p.Min) && p.Mean <= float64(p.Max))
+
+	again, err := table.Rarefaction(opts)
+	require.NoError(t, err)
+	assert.Equal(t, curves, again)
+
+	steps := DefaultRarefactionOptions()
+	steps.Steps = 4
+	curves, err = table.Rarefaction(steps)
+	require.NoError(t, err)
+	assert.Len(t, curves[1].Points, 4)
+	assert.Len(t, curves[0].Points, 2)
+
+	opts.Iterations = 0
+	_, err = table.Rarefaction(opts)
+	assert.Error(t, err)
+
+	var sb strings.Builder
+	require.NoError(t, WriteRarefactionTSV(&sb, []RarefactionCurve{even}))
+	assert.Equal(t, "sample\tdepth\tmean\tstddev\tmin\tmax\neven\t1\t1.000\t0.000\t1\t1\neven\t10\t2.000\t0.000\t2\t2\n", sb.String())
+}
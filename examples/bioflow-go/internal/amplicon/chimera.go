@@ -0,0 +1,305 @@
+package amplicon
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/alignment"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// ChimeraOptions configures reference-based chimera detection. A query
+// is scored, as UCHIME does, by how well a model joining the left part of
+// one reference (parent A) to the right part of another (parent B)
+// explains it: at each position where the parents differ, the query votes
+// for the model if it agrees with the parent on that side, against it if
+// it agrees with the other parent, and abstains otherwise.
+type ChimeraOptions struct {
+	// K is the length of the k-mers used to choose candidate parents.
+	K int
+	// Chunks is the number of pieces the query is split into; the
+	// reference sharing the most k-mers with each piece is a candidate.
+	Chunks int
+	// Candidates is the number of references sharing the most k-mers
+	// with the whole query that are candidates as well.
+	Candidates int
+	// Beta weighs votes against the model relative to abstentions.
+	Beta float64
+	// Pseudo is the pseudo-count added to the votes against on each side.
+	Pseudo float64
+	// MinScore is the lowest score of a chimera.
+	MinScore float64
+	// MinDiffs is the fewest votes for the model needed on each side.
+	MinDiffs int
+	// MinDivergence is how much closer, as a fraction of the query
+	// length, the model must be to the query than the closest parent.
+	MinDivergence float64
+}
+
+// DefaultChimeraOptions returns the defaults of UCHIME's reference mode.
+func DefaultChimeraOptions() ChimeraOptions {
+	return ChimeraOptions{
+		K:             8,
+		Chunks:        4,
+		Candidates:    2,
+		Beta:          8,
+		Pseudo:        1.4,
+		MinScore:      0.28,
+		MinDiffs:      3,
+		MinDivergence: 0.005,
+	}
+}
+
+// ChimeraCall is the verdict on one query.
+type ChimeraCall struct {
+	ID       string  `json:"id"`
+	Chimeric bool    `json:"chimeric"`
+	Score    float64 `json:"score"`
+	// ParentA and ParentB name the references of the best model, left
+	// and right of Crossover, the first query position taken from
+	// ParentB. They are empty when no model had enough votes.
+	ParentA   string `json:"parent_a,omitempty"`
+	ParentB   string `json:"parent_b,omitempty"`
+	Crossover int    `json:"crossover,omitempty"`
+	// ModelIdentity and ParentIdentity are the fractions of the query
+	// matched by the model and by the closest single reference.
+	ModelIdentity  float64 `json:"model_identity"`
+	ParentIdentity float64 `json:"parent_identity"`
+	Divergence     float64 `json:"divergence"`
+}
+
+// Label returns "chimeric" or "clean".
+func (c ChimeraCall) Label() string {
+	if c.Chimeric {
+		return "chimeric"
+	}
+	return "clean"
+}
+
+// ChimeraDetector checks queries against a reference set of non-chimeric
+// sequences, such as a curated 16S database.
+type ChimeraDetector struct {
+	opts ChimeraOptions
+	refs []*sequence.Sequence
+	// index maps each k-mer to the references containing it.
+	index map[string][]int
+}
+
+// NewChimeraDetector indexes the references of a ChimeraDetector.
+//
+// Aria equivalent:
+//
+//	fn new_chimera_detector(references: [Sequence], options: ChimeraOptions) -> Result<ChimeraDetector, AmpliconError>
+//	  requires references.len() > 0
+//	  requires options.k > 0 and options.chunks > 0
+func NewChimeraDetector(refs []*sequence.Sequence, opts ChimeraOptions) (*ChimeraDetector, error) {
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("no reference sequences given")
+	}
+	if opts.K <= 0 || opts.Chunks <= 0 || opts.Candidates < 0 {
+		return nil, fmt.Errorf("k and chunks must be positive")
+	}
+	if opts.Beta < 0 || opts.Pseudo <= 0 || opts.MinDiffs < 1 {
+		return nil, fmt.Errorf("beta must not be negative, and the pseudo-count and minimum diffs must be positive")
+	}
+	d := &ChimeraDetector{opts: opts, refs: refs, index: make(map[string][]int)}
+	for r, ref := range refs {
+		bases := strings.ToUpper(ref.Bases)
+		for i := 0; i+opts.K <= len(bases); i++ {
+			kmer := bases[i : i+opts.K]
+			if hits := d.index[kmer]; len(hits) == 0 || hits[len(hits)-1] != r {
+				d.index[kmer] = append(hits, r)
+			}
+		}
+	}
+	return d, nil
+}
+
+// Check calls a query chimeric or clean. A query identical to a
+// reference is always clean.
+//
+// Aria equivalent:
+//
+//	fn check(self, query: Sequence) -> Result<ChimeraCall, AmpliconError>
+//	  ensures result.chimeric implies result.score >= self.options.min_score
+func (d *ChimeraDetector) Check(query *sequence.Sequence) (ChimeraCall, error) {
+	call := ChimeraCall{ID: query.ID}
+	q := strings.ToUpper(query.Bases)
+	candidates := d.candidates(q)
+	if len(candidates) == 0 {
+		return call, nil
+	}
+
+	// rows[c][i] is the base of candidate c aligned to query position i,
+	// or '-'; matches[c][i] counts its matches to the query before i.
+	rows := make([][]byte, len(candidates))
+	matches := make([][]int, len(candidates))
+	for c, r := range candidates {
+		aln, err := alignment.SemiGlobalAlignment(query, d.refs[r], nil)
+		if err != nil {
+			return call, fmt.Errorf("aligning %s to %s: %w", query.ID, d.refs[r].ID, err)
+		}
+		rows[c] = projectRow(aln)
+		matches[c] = make([]int, len(q)+1)
+		for i := range q {
+			matches[c][i+1] = matches[c][i]
+			if rows[c][i] == q[i] {
+				matches[c][i+1]++
+			}
+		}
+		call.ParentIdentity = max(call.ParentIdentity, float64(matches[c][len(q)])/float64(len(q)))
+	}
+
+	for a := range candidates {
+		for b := range candidates {
+			if a == b {
+				continue
+			}
+			score, x, ok := d.bestCrossover(q, rows[a], rows[b])
+			if !ok || score <= call.Score {
+				continue
+			}
+			call.Score = score
+			call.ParentA, call.ParentB, call.Crossover = d.refs[candidates[a]].ID, d.refs[candidates[b]].ID, x
+			call.ModelIdentity = float64(matches[a][x]+matches[b][len(q)]-matches[b][x]) / float64(len(q))
+		}
+	}
+	if call.ParentA != "" {
+		call.Divergence = call.ModelIdentity - call.ParentIdentity
+		call.Chimeric = call.Score >= d.opts.MinScore && call.Divergence >= d.opts.MinDivergence
+	}
+	return call, nil
+}
+
+// candidates returns the references sharing the most k-mers with each
+// chunk of q and with all of it, most shared first.
+func (d *ChimeraDetector) candidates(q string) []int {
+	k := d.opts.K
+	if len(q) < k {
+		return nil
+	}
+	total := make(map[int]int)
+	chosen := make(map[int]bool)
+	chunk := (len(q) + d.opts.Chunks - 1) / d.opts.Chunks
+	for start := 0; start < len(q); start += chunk {
+		shared := make(map[int]int)
+		for i := start; i < start+chunk && i+k <= len(q); i++ {
+			for _, r := range d.index[q[i:i+k]] {
+				shared[r]++
+				total[r]++
+			}
+		}
+		if best, ok := mostShared(shared, 1); ok {
+			chosen[best[0]] = true
+		}
+	}
+	if top, ok := mostShared(total, d.opts.Candidates); ok {
+		for _, r := range top {
+			chosen[r] = true
+		}
+	}
+	out := make([]int, 0, len(chosen))
+	for r := range chosen {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if total[out[i]] != total[out[j]] {
+			return total[out[i]] > total[out[j]]
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
+
+// mostShared returns up to n references with the highest counts, ties to
+// the earlier reference.
+func mostShared(counts map[int]int, n int) ([]int, bool) {
+	refs := make([]int, 0, len(counts))
+	for r := range counts {
+		refs = append(refs, r)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if counts[refs[i]] != counts[refs[j]] {
+			return counts[refs[i]] > counts[refs[j]]
+		}
+		return refs[i] < refs[j]
+	})
+	n = min(n, len(refs))
+	return refs[:n], n > 0
+}
+
+// projectRow returns the reference base aligned to each query position of
+// a semi-global alignment of the query to a reference, or '-'.
+func projectRow(aln *alignment.Alignment) []byte {
+	row := make([]byte, 0, len(aln.AlignedSeq1))
+	for i := 0; i < len(aln.AlignedSeq1); i++ {
+		if aln.AlignedSeq1[i] != '-' {
+			row = append(row, upper(aln.AlignedSeq2[i]))
+		}
+	}
+	return row
+}
+
+func upper(b byte) byte {
+	if b >= 'a' && b <= 'z' {
+		return b - 'a' + 'A'
+	}
+	return b
+}
+
+// bestCrossover returns the best score of a model taking q[:x] from a and
+// q[x:] from b, and x, or false if no crossover has MinDiffs votes for the
+// model on both sides.
+func (d *ChimeraDetector) bestCrossover(q string, a, b []byte) (float64, int, bool) {
+	// forA, forB and abstain count the votes before each position.
+	n := len(q)
+	forA, forB, abstain := make([]int, n+1), make([]int, n+1), make([]int, n+1)
+	for i := 0; i < n; i++ {
+		forA[i+1], forB[i+1], abstain[i+1] = forA[i], forB[i], abstain[i]
+		switch {
+		case a[i] == b[i]:
+		case q[i] == a[i]:
+			forA[i+1]++
+		case q[i] == b[i]:
+			forB[i+1]++
+		default:
+			abstain[i+1]++
+		}
+	}
+
+	best, bestX := 0.0, 0
+	for x := 1; x < n; x++ {
+		yesL, noL, absL := forA[x], forB[x], abstain[x]
+		yesR, noR, absR := forB[n]-forB[x], forA[n]-forA[x], abstain[n]-abstain[x]
+		if yesL < d.opts.MinDiffs || yesR < d.opts.MinDiffs {
+			continue
+		}
+		left := float64(yesL) / (d.opts.Beta*(float64(noL)+d.opts.Pseudo) + float64(absL))
+		right := float64(yesR) / (d.opts.Beta*(float64(noR)+d.opts.Pseudo) + float64(absR))
+		if score := left * right; score > best {
+			best, bestX = score, x
+		}
+	}
+	return best, bestX, bestX > 0
+}
+
+// DetectChimeras checks each query against the references.
+//
+// Aria equivalent:
+//
+//	fn detect_chimeras(queries: [Sequence], references: [Sequence], options: ChimeraOptions) -> Result<[ChimeraCall], AmpliconError>
+//	  ensures result.len() == queries.len()
+func DetectChimeras(queries, refs []*sequence.Sequence, opts ChimeraOptions) ([]ChimeraCall, error) {
+	d, err := NewChimeraDetector(refs, opts)
+	if err != nil {
+		return nil, err
+	}
+	calls := make([]ChimeraCall, len(queries))
+	for i, q := range queries {
+		if calls[i], err = d.Check(q); err != nil {
+			return nil, err
+		}
+	}
+	return calls, nil
+}
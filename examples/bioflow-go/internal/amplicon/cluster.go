@@ -0,0 +1,293 @@
+package amplicon
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/alignment"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Unique is a distinct amplicon sequence and how often it was seen in
+// each sample.
+type Unique struct {
+	Bases  string         `json:"bases"`
+	Size   int            `json:"size"`
+	Counts map[string]int `json:"counts"`
+}
+
+// Dereplicator collapses identical sequences across samples.
+type Dereplicator struct {
+	uniques map[string]*Unique
+	samples []string
+	seen    map[string]bool
+}
+
+// NewDereplicator creates an empty Dereplicator.
+func NewDereplicator() *Dereplicator {
+	return &Dereplicator{uniques: make(map[string]*Unique), seen: make(map[string]bool)}
+}
+
+// Add counts one sequence of a sample. Bases are compared
+// case-insensitively.
+func (d *Dereplicator) Add(sample, bases string) {
+	if !d.seen[sample] {
+		d.seen[sample] = true
+		d.samples = append(d.samples, sample)
+	}
+	bases = strings.ToUpper(bases)
+	u := d.uniques[bases]
+	if u == nil {
+		u = &Unique{Bases: bases, Counts: make(map[string]int)}
+		d.uniques[bases] = u
+	}
+	u.Size++
+	u.Counts[sample]++
+}
+
+// Samples returns the samples in the order they were first added.
+func (d *Dereplicator) Samples() []string {
+	return d.samples
+}
+
+// Uniques returns the distinct sequences, most abundant first, ties
+// broken by sequence.
+func (d *Dereplicator) Uniques() []*Unique {
+	out := make([]*Unique, 0, len(d.uniques))
+	for _, u := range d.uniques {
+		out = append(out, u)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Size != out[j].Size {
+			return out[i].Size > out[j].Size
+		}
+		return out[i].Bases < out[j].Bases
+	})
+	return out
+}
+
+// ClusterOptions configures greedy clustering.
+type ClusterOptions struct {
+	// Identity is the lowest identity of a sequence to the centroid of
+	// its cluster. At 1, each distinct sequence is its own cluster, as
+	// for amplicon sequence variants.
+	Identity float64
+	// MinSize drops distinct sequences seen fewer times, such as
+	// singletons, before clustering.
+	MinSize int
+	// MaxRejects is how many centroids, most k-mers shared first, are
+	// aligned to before a sequence starts a cluster of its own.
+	MaxRejects int
+	// K is the length of the k-mers used to order the centroids.
+	K int
+	// Prefix names the clusters Prefix1, Prefix2 and so on.
+	Prefix string
+}
+
+// DefaultClusterOptions returns 97% OTU clustering without singletons.
+func DefaultClusterOptions() ClusterOptions {
+	return ClusterOptions{Identity: 0.97, MinSize: 2, MaxRejects: 8, K: 8, Prefix: "OTU_"}
+}
+
+// Cluster is a group of sequences around the most abundant one.
+type Cluster struct {
+	ID string `json:"id"`
+	// Centroid is the sequence that started the cluster; Consensus is
+	// the majority base of the members, weighted by abundance, at each
+	// centroid position.
+	Centroid  string `json:"centroid"`
+	Consensus string `json:"consensus"`
+	// Size counts the sequences of the cluster and Uniques the distinct
+	// ones; Counts splits Size by sample.
+	Size    int            `json:"size"`
+	Uniques int            `json:"uniques"`
+	Counts  map[string]int `json:"counts"`
+
+	// profile counts, for each centroid position, the member bases
+	// aligned to it, indexed as "ACGT-".
+	profile [][5]int
+	kmers   map[string]bool
+}
+
+// Clustering is the result of clustering, with an abundance table of
+// clusters by samples.
+type Clustering struct {
+	Samples  []string  `json:"samples"`
+	Clusters []Cluster `json:"clusters"`
+	// Dropped counts the sequences below MinSize.
+	Dropped int `json:"dropped"`
+}
+
+// ClusterUniques groups distinct sequences, most abundant first: each
+// joins the first cluster whose centroid it matches at the identity
+// threshold, or starts a new one, as the abundance-sorted greedy
+// clustering of UPARSE and VSEARCH does.
+//
+// Aria equivalent:
+//
+//	fn cluster_uniques(uniques: [Unique], samples: [String], options: ClusterOptions) -> Result<Clustering, AmpliconError>
+//	  requires options.identity > 0.0 and options.identity <= 1.0
+//	  ensures result.clusters.map(|c| c.size).sum() + result.dropped == uniques.map(|u| u.size).sum()
+func ClusterUniques(uniques []*Unique, samples []string, opts ClusterOptions) (*Clustering, error) {
+	if opts.Identity <= 0 || opts.Identity > 1 {
+		return nil, fmt.Errorf("identity must be in (0, 1]")
+	}
+	if opts.K <= 0 || opts.MaxRejects <= 0 {
+		return nil, fmt.Errorf("k and max rejects must be positive")
+	}
+	result := &Clustering{Samples: samples, Clusters: make([]Cluster, 0)}
+	var clusters []*Cluster
+	for _, u := range uniques {
+		if u.Size < opts.MinSize {
+			result.Dropped += u.Size
+			continue
+		}
+		c, row, err := assign(clusters, u.Bases, opts)
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			c = &Cluster{
+				ID:       fmt.Sprintf("%s%d", opts.Prefix, len(clusters)+1),
+				Centroid: u.Bases,
+				Counts:   make(map[string]int),
+				profile:  make([][5]int, len(u.Bases)),
+				kmers:    kmerSet(u.Bases, opts.K),
+			}
+			clusters = append(clusters, c)
+			row = []byte(u.Bases)
+		}
+		c.Size += u.Size
+		c.Uniques++
+		for sample, n := range u.Counts {
+			c.Counts[sample] += n
+		}
+		for i, b := range row {
+			if j := strings.IndexByte("ACGT-", b); j >= 0 {
+				c.profile[i][j] += u.Size
+			}
+		}
+	}
+	for _, c := range clusters {
+		c.Consensus = consensus(c.profile)
+		result.Clusters = append(result.Clusters, *c)
+	}
+	return result, nil
+}
+
+// assign returns the cluster a sequence joins and the member base aligned
+// to each centroid position, or nil if it joins none.
+func assign(clusters []*Cluster, bases string, opts ClusterOptions) (*Cluster, []byte, error) {
+	if opts.Identity == 1 {
+		return nil, nil, nil
+	}
+	kmers := kmerSet(bases, opts.K)
+	order := make([]int, len(clusters))
+	shared := make([]int, len(clusters))
+	for i, c := range clusters {
+		order[i] = i
+		for kmer := range kmers {
+			if c.kmers[kmer] {
+				shared[i]++
+			}
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool { return shared[order[i]] > shared[order[j]] })
+
+	query, err := sequence.New(bases)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, i := range order[:min(len(order), opts.MaxRejects)] {
+		centroid, err := sequence.New(clusters[i].Centroid)
+		if err != nil {
+			return nil, nil, err
+		}
+		aln, err := alignment.NeedlemanWunsch(centroid, query, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		if id, row := alignedIdentity(aln); id >= opts.Identity {
+			return clusters[i], row, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// alignedIdentity returns the identity of a global alignment of a
+// centroid and a sequence, leaving out terminal gaps, and the base of the
+// sequence aligned to each centroid position, or '-'.
+func alignedIdentity(aln *alignment.Alignment) (float64, []byte) {
+	a, b := aln.AlignedSeq1, aln.AlignedSeq2
+	start, end := 0, len(a)
+	for start < end && (a[start] == '-' || b[start] == '-') {
+		start++
+	}
+	for end > start && (a[end-1] == '-' || b[end-1] == '-') {
+		end--
+	}
+	matches := 0
+	for i := start; i < end; i++ {
+		if a[i] == b[i] {
+			matches++
+		}
+	}
+	row := make([]byte, 0, len(a))
+	for i := 0; i < len(a); i++ {
+		if a[i] != '-' {
+			row = append(row, b[i])
+		}
+	}
+	if end == start {
+		return 0, row
+	}
+	return float64(matches) / float64(end-start), row
+}
+
+// consensus returns the majority base of each profile position, leaving
+// out positions where most members have a gap.
+func consensus(profile [][5]int) string {
+	var sb strings.Builder
+	for _, counts := range profile {
+		best := 0
+		for j := 1; j < 5; j++ {
+			if counts[j] > counts[best] {
+				best = j
+			}
+		}
+		if best < 4 {
+			sb.WriteByte("ACGT"[best])
+		}
+	}
+	return sb.String()
+}
+
+func kmerSet(bases string, k int) map[string]bool {
+	set := make(map[string]bool)
+	for i := 0; i+k <= len(bases); i++ {
+		set[bases[i:i+k]] = true
+	}
+	return set
+}
+
+// WriteTable writes the abundance table of the clustering.
+func (c *Clustering) WriteTable(w io.Writer) error {
+	return c.Table().Write(w)
+}
+
+// WriteRepresentatives writes each cluster as a FASTA record named
+// "ID;size=N", its centroid or, with useConsensus, its consensus.
+func (c *Clustering) WriteRepresentatives(w io.Writer, useConsensus bool) error {
+	for _, cl := range c.Clusters {
+		bases := cl.Centroid
+		if useConsensus {
+			bases = cl.Consensus
+		}
+		if _, err := fmt.Fprintf(w, ">%s;size=%d\n%s\n", cl.ID, cl.Size, bases); err != nil {
+			return err
+		}
+	}
+	return nil
+}
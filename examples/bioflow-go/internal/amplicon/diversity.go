@@ -0,0 +1,338 @@
+package amplicon
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// AbundanceTable counts features, such as OTUs or taxa, in samples.
+// Counts[i][j] is the count of feature i in sample j.
+type AbundanceTable struct {
+	Samples  []string `json:"samples"`
+	Features []string `json:"features"`
+	Counts   [][]int  `json:"counts"`
+}
+
+// Table returns the abundance table of a clustering.
+func (c *Clustering) Table() *AbundanceTable {
+	t := &AbundanceTable{
+		Samples:  c.Samples,
+		Features: make([]string, len(c.Clusters)),
+		Counts:   make([][]int, len(c.Clusters)),
+	}
+	for i, cl := range c.Clusters {
+		t.Features[i] = cl.ID
+		t.Counts[i] = make([]int, len(c.Samples))
+		for j, s := range c.Samples {
+			t.Counts[i][j] = cl.Counts[s]
+		}
+	}
+	return t
+}
+
+// Table returns the abundance table of the distinct sequences, named
+// Uniq1, Uniq2 and so on, most abundant first.
+func (d *Dereplicator) Table() *AbundanceTable {
+	uniques := d.Uniques()
+	t := &AbundanceTable{
+		Samples:  d.samples,
+		Features: make([]string, len(uniques)),
+		Counts:   make([][]int, len(uniques)),
+	}
+	for i, u := range uniques {
+		t.Features[i] = fmt.Sprintf("Uniq%d", i+1)
+		t.Counts[i] = make([]int, len(d.samples))
+		for j, s := range d.samples {
+			t.Counts[i][j] = u.Counts[s]
+		}
+	}
+	return t
+}
+
+// Write writes the table as tab-separated text: a "#OTU ID" header naming
+// the samples, then one row of counts per feature, as the cluster
+// command, USEARCH and biom convert write it.
+func (t *AbundanceTable) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "#OTU ID\t%s\n", strings.Join(t.Samples, "\t"))
+	for i, f := range t.Features {
+		bw.WriteString(f)
+		for _, n := range t.Counts[i] {
+			fmt.Fprintf(bw, "\t%d", n)
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// ReadAbundanceTable parses a table written by Write. Lines starting with
+// "#" before the header, such as biom's "# Constructed from biom file",
+// are skipped.
+//
+// Aria equivalent:
+//
+//	fn read_abundance_table(r: Reader) -> Result<AbundanceTable, AmpliconError> with IO
+//	  ensures result.counts.all(|row| row.len() == result.samples.len())
+func ReadAbundanceTable(r io.Reader) (*AbundanceTable, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	t := &AbundanceTable{}
+	header := false
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if !header {
+			switch {
+			case strings.HasPrefix(text, "#") && len(fields) > 1:
+				t.Samples = fields[1:]
+				header = true
+			case !strings.HasPrefix(text, "#"):
+				return nil, fmt.Errorf("line %d: expected a \"#OTU ID\" header", line)
+			}
+			continue
+		}
+		if len(fields) != len(t.Samples)+1 {
+			return nil, fmt.Errorf("line %d: %d counts for %d samples", line, len(fields)-1, len(t.Samples))
+		}
+		row := make([]int, len(t.Samples))
+		for j, f := range fields[1:] {
+			// biom convert writes counts as floats.
+			v, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+			if err != nil || v < 0 || v != math.Trunc(v) {
+				return nil, fmt.Errorf("line %d: invalid count %q", line, f)
+			}
+			row[j] = int(v)
+		}
+		t.Features = append(t.Features, fields[0])
+		t.Counts = append(t.Counts, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !header {
+		return nil, fmt.Errorf("empty abundance table")
+	}
+	return t, nil
+}
+
+// column returns the counts of sample j.
+func (t *AbundanceTable) column(j int) []int {
+	col := make([]int, len(t.Counts))
+	for i, row := range t.Counts {
+		col[i] = row[j]
+	}
+	return col
+}
+
+// Alpha holds the diversity within one sample.
+type Alpha struct {
+	Sample string `json:"sample"`
+	// Depth is the total count and Observed the number of features seen.
+	Depth    int `json:"depth"`
+	Observed int `json:"observed"`
+	// Shannon is the Shannon entropy, in nats.
+	Shannon float64 `json:"shannon"`
+	// Simpson is the Gini-Simpson index, 1 - sum(p^2): the chance that
+	// two counts drawn with replacement are of different features.
+	Simpson float64 `json:"simpson"`
+	// Chao1 estimates the richness including unseen features from the
+	// singletons and doubletons.
+	Chao1 float64 `json:"chao1"`
+}
+
+// AlphaDiversity returns the alpha diversity of each sample.
+//
+// Aria equivalent:
+//
+//	fn alpha_diversity(self) -> [Alpha]
+//	  ensures result.len() == self.samples.len()
+//	  ensures result.all(|a| a.chao1 >= a.observed as Float)
+func (t *AbundanceTable) AlphaDiversity() []Alpha {
+	out := make([]Alpha, len(t.Samples))
+	for j, s := range t.Samples {
+		out[j] = alpha(s, t.column(j))
+	}
+	return out
+}
+
+func alpha(sample string, counts []int) Alpha {
+	a := Alpha{Sample: sample}
+	singletons, doubletons := 0, 0
+	for _, n := range counts {
+		a.Depth += n
+		if n > 0 {
+			a.Observed++
+		}
+		switch n {
+		case 1:
+			singletons++
+		case 2:
+			doubletons++
+		}
+	}
+	if a.Depth == 0 {
+		return a
+	}
+	sumSquares := 0.0
+	for _, n := range counts {
+		if n == 0 {
+			continue
+		}
+		p := float64(n) / float64(a.Depth)
+		a.Shannon -= p * math.Log(p)
+		sumSquares += p * p
+	}
+	a.Simpson = 1 - sumSquares
+	// Without doubletons, the bias-corrected form keeps Chao1 finite.
+	f1, f2 := float64(singletons), float64(doubletons)
+	if doubletons > 0 {
+		a.Chao1 = float64(a.Observed) + f1*f1/(2*f2)
+	} else {
+		a.Chao1 = float64(a.Observed) + f1*(f1-1)/2
+	}
+	return a
+}
+
+// BetaMetric selects a dissimilarity between samples.
+type BetaMetric int
+
+const (
+	// BrayCurtis is sum|a-b| / sum(a+b), weighing features by abundance.
+	BrayCurtis BetaMetric = iota
+	// Jaccard is the fraction of the features in either sample that are
+	// not in both, from presence alone.
+	Jaccard
+)
+
+// String returns the metric as ParseBetaMetric accepts it.
+func (m BetaMetric) String() string {
+	switch m {
+	case BrayCurtis:
+		return "bray-curtis"
+	case Jaccard:
+		return "jaccard"
+	}
+	return "unknown"
+}
+
+// ParseBetaMetric parses bray-curtis or jaccard.
+func ParseBetaMetric(name string) (BetaMetric, error) {
+	switch strings.ToLower(name) {
+	case "bray-curtis", "braycurtis", "bray_curtis":
+		return BrayCurtis, nil
+	case "jaccard":
+		return Jaccard, nil
+	}
+	return 0, fmt.Errorf("unknown beta diversity metric %q (want bray-curtis or jaccard)", name)
+}
+
+// Dissimilarity returns the dissimilarity of two samples' counts, 0 for
+// identical samples and 1 for samples sharing no feature. Two empty
+// samples are identical.
+func (m BetaMetric) Dissimilarity(a, b []int) float64 {
+	switch m {
+	case Jaccard:
+		both, either := 0, 0
+		for i := range a {
+			if a[i] > 0 || b[i] > 0 {
+				either++
+				if a[i] > 0 && b[i] > 0 {
+					both++
+				}
+			}
+		}
+		if either == 0 {
+			return 0
+		}
+		return 1 - float64(both)/float64(either)
+	default:
+		diff, total := 0, 0
+		for i := range a {
+			if a[i] > b[i] {
+				diff += a[i] - b[i]
+			} else {
+				diff += b[i] - a[i]
+			}
+			total += a[i] + b[i]
+		}
+		if total == 0 {
+			return 0
+		}
+		return float64(diff) / float64(total)
+	}
+}
+
+// DistanceMatrix is a symmetric matrix of dissimilarities between named
+// samples.
+type DistanceMatrix struct {
+	Metric string      `json:"metric"`
+	Names  []string    `json:"names"`
+	Values [][]float64 `json:"values"`
+}
+
+// BetaDiversity returns the dissimilarity of every pair of samples.
+//
+// Aria equivalent:
+//
+//	fn beta_diversity(self, metric: BetaMetric) -> DistanceMatrix
+//	  ensures result.values.all(|row| row.all(|d| d >= 0.0 and d <= 1.0))
+func (t *AbundanceTable) BetaDiversity(metric BetaMetric) *DistanceMatrix {
+	n := len(t.Samples)
+	cols := make([][]int, n)
+	for j := range cols {
+		cols[j] = t.column(j)
+	}
+	d := &DistanceMatrix{Metric: metric.String(), Names: t.Samples, Values: make([][]float64, n)}
+	for i := range d.Values {
+		d.Values[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			v := metric.Dissimilarity(cols[i], cols[j])
+			d.Values[i][j], d.Values[j][i] = v, v
+		}
+	}
+	return d
+}
+
+// WriteTSV writes the matrix with a header row of names and one row per
+// name, as QIIME 2 exports distance matrices.
+func (d *DistanceMatrix) WriteTSV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "\t%s\n", strings.Join(d.Names, "\t"))
+	for i, row := range d.Values {
+		bw.WriteString(d.Names[i])
+		for _, v := range row {
+			fmt.Fprintf(bw, "\t%.6f", v)
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// WritePHYLIP writes the matrix in square PHYLIP distance format, as read
+// by neighbor and other tree builders, with relaxed names.
+func (d *DistanceMatrix) WritePHYLIP(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%d\n", len(d.Names))
+	for i, row := range d.Values {
+		if strings.ContainsAny(d.Names[i], " \t") {
+			return fmt.Errorf("name %q contains whitespace, not allowed in relaxed PHYLIP", d.Names[i])
+		}
+		bw.WriteString(d.Names[i])
+		for _, v := range row {
+			fmt.Fprintf(bw, " %.6f", v)
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
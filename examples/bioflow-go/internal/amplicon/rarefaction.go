@@ -0,0 +1,195 @@
+package amplicon
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/aria-lang/bioflow-go/internal/random"
+)
+
+// RarefactionOptions configures rarefaction curves.
+type RarefactionOptions struct {
+	// Depths are the subsampling depths. When empty, Steps depths are
+	// spread evenly up to the deepest sample.
+	Depths []int
+	Steps  int
+	// Iterations is the number of subsamples drawn at each depth.
+	Iterations int
+	// Seed seeds a generator per sample, unless Rand is set.
+	Seed int64
+	Rand *rand.Rand
+}
+
+// DefaultRarefactionOptions returns 10 depths of 10 iterations each.
+func DefaultRarefactionOptions() RarefactionOptions {
+	return RarefactionOptions{Steps: 10, Iterations: 10, Seed: random.DefaultSeed}
+}
+
+// RarefactionPoint is the richness observed in subsamples of one depth.
+type RarefactionPoint struct {
+	Depth int `json:"depth"`
+	// Mean and StdDev summarize the features observed over the
+	// iterations; Min and Max bound them.
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	Min    int     `json:"min"`
+	Max    int     `json:"max"`
+}
+
+// RarefactionCurve is the richness of one sample by subsampled depth.
+// Depths beyond the sample's own are left out; the full depth ends the
+// curve.
+type RarefactionCurve struct {
+	Sample string             `json:"sample"`
+	Depth  int                `json:"depth"`
+	Points []RarefactionPoint `json:"points"`
+}
+
+// Rarefaction returns the rarefaction curve of each sample.
+//
+// Aria equivalent:
+//
+//	fn rarefaction(self, options: RarefactionOptions) -> Result<[RarefactionCurve], AmpliconError> with Random
+//	  requires options.iterations > 0
+//	  ensures result.len() == self.samples.len()
+func (t *AbundanceTable) Rarefaction(opts RarefactionOptions) ([]RarefactionCurve, error) {
+	if opts.Iterations <= 0 {
+		return nil, fmt.Errorf("iterations must be positive")
+	}
+	columns := make([][]int, len(t.Samples))
+	deepest := 0
+	for j := range t.Samples {
+		columns[j] = t.column(j)
+		deepest = max(deepest, sum(columns[j]))
+	}
+	depths, err := rarefactionDepths(opts, deepest)
+	if err != nil {
+		return nil, err
+	}
+	curves := make([]RarefactionCurve, len(t.Samples))
+	for j, s := range t.Samples {
+		rng := random.Or(opts.Rand, random.Derive(opts.Seed, "rarefaction/"+s))
+		curves[j] = rarefy(s, columns[j], depths, opts.Iterations, rng)
+	}
+	return curves, nil
+}
+
+// rarefactionDepths returns the sorted, distinct positive depths of a
+// curve.
+func rarefactionDepths(opts RarefactionOptions, deepest int) ([]int, error) {
+	var depths []int
+	if len(opts.Depths) > 0 {
+		for _, d := range opts.Depths {
+			if d <= 0 {
+				return nil, fmt.Errorf("rarefaction depths must be positive")
+			}
+			depths = append(depths, d)
+		}
+	} else {
+		if opts.Steps <= 0 {
+			return nil, fmt.Errorf("steps must be positive")
+		}
+		for i := 1; i <= opts.Steps; i++ {
+			if d := int(math.Round(float64(i) * float64(deepest) / float64(opts.Steps))); d > 0 {
+				depths = append(depths, d)
+			}
+		}
+	}
+	sort.Ints(depths)
+	out := depths[:0]
+	for _, d := range depths {
+		if len(out) == 0 || out[len(out)-1] != d {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func rarefy(sample string, counts, depths []int, iterations int, rng *rand.Rand) RarefactionCurve {
+	total := sum(counts)
+	curve := RarefactionCurve{Sample: sample, Depth: total, Points: make([]RarefactionPoint, 0)}
+	// ends[i] is the index after the last read of feature i.
+	ends := make([]int, len(counts))
+	for i, n := range counts {
+		ends[i] = n
+		if i > 0 {
+			ends[i] += ends[i-1]
+		}
+	}
+	var observed []int
+	for _, d := range depths {
+		if d > total {
+			break
+		}
+		observed = observed[:0]
+		for it := 0; it < iterations; it++ {
+			observed = append(observed, distinctFeatures(random.Sample(rng, total, d), ends))
+		}
+		curve.Points = append(curve.Points, summarize(d, observed))
+	}
+	if total > 0 && (len(curve.Points) == 0 || curve.Points[len(curve.Points)-1].Depth != total) {
+		// Subsampling every read is not random.
+		n := 0
+		for _, c := range counts {
+			if c > 0 {
+				n++
+			}
+		}
+		curve.Points = append(curve.Points, RarefactionPoint{Depth: total, Mean: float64(n), Min: n, Max: n})
+	}
+	return curve
+}
+
+// distinctFeatures counts the features of the sorted read indices.
+func distinctFeatures(reads, ends []int) int {
+	n, feature := 0, -1
+	for _, r := range reads {
+		if feature >= 0 && r < ends[feature] {
+			continue
+		}
+		feature = sort.SearchInts(ends, r+1)
+		n++
+	}
+	return n
+}
+
+func summarize(depth int, observed []int) RarefactionPoint {
+	p := RarefactionPoint{Depth: depth, Min: observed[0], Max: observed[0]}
+	for _, n := range observed {
+		p.Mean += float64(n)
+		p.Min, p.Max = min(p.Min, n), max(p.Max, n)
+	}
+	p.Mean /= float64(len(observed))
+	if len(observed) > 1 {
+		for _, n := range observed {
+			p.StdDev += (float64(n) - p.Mean) * (float64(n) - p.Mean)
+		}
+		p.StdDev = math.Sqrt(p.StdDev / float64(len(observed)-1))
+	}
+	return p
+}
+
+func sum(counts []int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
+
+// WriteRarefactionTSV writes curves as one tab-separated row per sample
+// and depth, for plotting.
+func WriteRarefactionTSV(w io.Writer, curves []RarefactionCurve) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "sample\tdepth\tmean\tstddev\tmin\tmax")
+	for _, c := range curves {
+		for _, p := range c.Points {
+			fmt.Fprintf(bw, "%s\t%d\t%.3f\t%.3f\t%d\t%d\n", c.Sample, p.Depth, p.Mean, p.StdDev, p.Min, p.Max)
+		}
+	}
+	return bw.Flush()
+}
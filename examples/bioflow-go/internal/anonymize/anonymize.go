@@ -0,0 +1,158 @@
+// Package anonymize replaces the identifiers of sequences and reads with
+// keyed pseudonyms so that clinical datasets can be shared.
+//
+// A pseudonym is the HMAC-SHA256 of the identifier under a secret key,
+// truncated and hex-encoded. The same identifier always gets the same
+// pseudonym under the same key, so records stay joinable across files
+// and mates of a pair keep matching names, while recovering an identifier
+// from its pseudonym requires the key: key holders re-link records by
+// computing the pseudonyms of the identifiers they know.
+//
+// Header text after the identifier, which often carries sample names,
+// barcodes or instrument details, is stripped unless kept explicitly.
+//
+// Comparison with Aria:
+//
+//	Aria would keep the key out of reach of the rest of the program with
+//	a capability:
+//	  fn pseudonym(id: String) -> String with Secret<Key>
+//
+//	Go holds the key in an unexported field of the Anonymizer.
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/readgroup"
+)
+
+// MinKeySize is the shortest key accepted, in bytes.
+const MinKeySize = 16
+
+// DefaultLength is the default number of hex digits of a pseudonym. 16
+// digits (64 bits) make collisions unlikely below billions of records.
+const DefaultLength = 16
+
+// Options configures an Anonymizer.
+type Options struct {
+	// Prefix is prepended to every pseudonym, e.g. "anon_".
+	Prefix string
+	// Length is the number of hex digits kept, from 8 to 64; zero means
+	// DefaultLength.
+	Length int
+	// KeepDescription keeps header text after the identifier.
+	KeepDescription bool
+}
+
+// Anonymizer computes pseudonyms under a key. It is safe for concurrent
+// use.
+type Anonymizer struct {
+	key  []byte
+	opts Options
+}
+
+// New creates an Anonymizer.
+//
+// Aria equivalent:
+//
+//	fn new(key: Bytes, options: Options) -> Result<Anonymizer, AnonymizeError>
+//	  requires key.len() >= MIN_KEY_SIZE
+func New(key []byte, opts Options) (*Anonymizer, error) {
+	if len(key) < MinKeySize {
+		return nil, fmt.Errorf("key must be at least %d bytes, got %d", MinKeySize, len(key))
+	}
+	if opts.Length == 0 {
+		opts.Length = DefaultLength
+	}
+	if opts.Length < 8 || opts.Length > 2*sha256.Size {
+		return nil, fmt.Errorf("pseudonym length %d out of range (8-%d)", opts.Length, 2*sha256.Size)
+	}
+	if strings.ContainsAny(opts.Prefix, " \t\r\n") {
+		return nil, errors.New("pseudonym prefix must not contain whitespace")
+	}
+	return &Anonymizer{key: append([]byte(nil), key...), opts: opts}, nil
+}
+
+// Pseudonym returns the pseudonym of an identifier.
+//
+// Aria equivalent:
+//
+//	fn pseudonym(self, id: String) -> String
+//	  ensures result.starts_with(self.prefix)
+//	  ensures result.len() == self.prefix.len() + self.length
+func (a *Anonymizer) Pseudonym(id string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(id))
+	return a.opts.Prefix + hex.EncodeToString(mac.Sum(nil))[:a.opts.Length]
+}
+
+// ID returns the pseudonym of a sequence or read identifier. A mate
+// suffix ("/1", "/2") is kept, so that the mates of a pair still match.
+func (a *Anonymizer) ID(id string) string {
+	if n := len(id); n > 2 && id[n-2] == '/' && (id[n-1] == '1' || id[n-1] == '2') {
+		return a.Pseudonym(id[:n-2]) + id[n-2:]
+	}
+	return a.Pseudonym(id)
+}
+
+// Header returns the anonymized identifier and description of a record.
+// The identifier ends at the first space or tab; any text after it, as
+// in FASTQ headers read whole, belongs to the description.
+func (a *Anonymizer) Header(id, description string) (string, string) {
+	if i := strings.IndexAny(id, " \t"); i >= 0 {
+		if rest := strings.TrimSpace(id[i+1:]); rest != "" {
+			description = strings.TrimSpace(rest + " " + description)
+		}
+		id = id[:i]
+	}
+	if !a.opts.KeepDescription {
+		description = ""
+	}
+	return a.ID(id), description
+}
+
+// ReadGroup returns an anonymized read group: its ID, sample and library
+// are replaced with pseudonyms, and the run, flowcell and barcode, which
+// identify the sequencing instrument and run, are removed. The lane and
+// platform are kept.
+func (a *Anonymizer) ReadGroup(g readgroup.ReadGroup) readgroup.ReadGroup {
+	out := readgroup.ReadGroup{ID: a.Pseudonym(g.ID), Lane: g.Lane, Platform: g.Platform}
+	if g.Sample != "" {
+		out.Sample = a.Pseudonym(g.Sample)
+	}
+	if g.Library != "" {
+		out.Library = a.Pseudonym(g.Library)
+	}
+	return out
+}
+
+// GenerateKey returns a new random key, hex-encoded, as stored in key
+// files.
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("generating key: %w", err)
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// ReadKeyFile reads a key file. Surrounding whitespace is ignored; the
+// rest of the file, as written, is the key.
+func ReadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading key file: %w", err)
+	}
+	key := []byte(strings.TrimSpace(string(data)))
+	if len(key) < MinKeySize {
+		return nil, fmt.Errorf("key file %s holds %d bytes, need at least %d", path, len(key), MinKeySize)
+	}
+	return key, nil
+}
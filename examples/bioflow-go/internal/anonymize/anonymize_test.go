@@ -0,0 +1,107 @@
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/readgroup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestPseudonym(t *testing.T) {
+	a, err := New(testKey, Options{Prefix: "anon_"})
+	require.NoError(t, err)
+
+	mac := hmac.New(sha256.New, testKey)
+	mac.Write([]byte("patient-7"))
+	want := "anon_" + hex.EncodeToString(mac.Sum(nil))[:DefaultLength]
+	assert.Equal(t, want, a.Pseudonym("patient-7"))
+	assert.Equal(t, want, a.Pseudonym("patient-7"), "stable")
+	assert.NotEqual(t, want, a.Pseudonym("patient-8"))
+
+	other, err := New([]byte("another key of sixteen bytes"), Options{Prefix: "anon_"})
+	require.NoError(t, err)
+	assert.NotEqual(t, want, other.Pseudonym("patient-7"), "depends on the key")
+
+	short, err := New(testKey, Options{Length: 8})
+	require.NoError(t, err)
+	assert.Equal(t, want[len("anon_"):len("anon_")+8], short.Pseudonym("patient-7"))
+
+	for name, opts := range map[string]Options{
+		"too short": {Length: 4},
+		"too long":  {Length: 65},
+		"prefix":    {Prefix: "a b"},
+	} {
+		_, err := New(testKey, opts)
+		assert.Error(t, err, name)
+	}
+	_, err = New([]byte("short"), Options{})
+	assert.Error(t, err)
+}
+
+func TestHeader(t *testing.T) {
+	a, err := New(testKey, Options{})
+	require.NoError(t, err)
+
+	id, desc := a.Header("r1 1:N:0:ACGT", "")
+	assert.Equal(t, a.Pseudonym("r1"), id)
+	assert.Empty(t, desc)
+
+	id, desc = a.Header("chr1", "patient X")
+	assert.Equal(t, a.Pseudonym("chr1"), id)
+	assert.Empty(t, desc)
+
+	// Mates keep their suffix and match each other.
+	id1, _ := a.Header("frag/1", "")
+	id2, _ := a.Header("frag/2", "")
+	assert.Equal(t, a.Pseudonym("frag")+"/1", id1)
+	assert.Equal(t, a.Pseudonym("frag")+"/2", id2)
+
+	keep, err := New(testKey, Options{KeepDescription: true})
+	require.NoError(t, err)
+	id, desc = keep.Header("r1 1:N:0:ACGT", "lane 1")
+	assert.Equal(t, a.Pseudonym("r1"), id)
+	assert.Equal(t, "1:N:0:ACGT lane 1", desc)
+}
+
+func TestReadGroup(t *testing.T) {
+	a, err := New(testKey, Options{})
+	require.NoError(t, err)
+	g := a.ReadGroup(readgroup.ReadGroup{
+		ID: "FCX.1", Sample: "NA12878", Library: "lib1", Run: "7",
+		Flowcell: "FCX", Lane: 1, Barcode: "ACGT", Platform: "ILLUMINA",
+	})
+	assert.Equal(t, readgroup.ReadGroup{
+		ID: a.Pseudonym("FCX.1"), Sample: a.Pseudonym("NA12878"), Library: a.Pseudonym("lib1"),
+		Lane: 1, Platform: "ILLUMINA",
+	}, g)
+}
+
+func TestKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	key, err := GenerateKey()
+	require.NoError(t, err)
+	assert.Len(t, key, 64)
+	other, err := GenerateKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+
+	path := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(path, []byte(key+"\n"), 0o600))
+	read, err := ReadKeyFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, key, string(read))
+
+	require.NoError(t, os.WriteFile(path, []byte("short\n"), 0o600))
+	_, err = ReadKeyFile(path)
+	assert.Error(t, err)
+	_, err = ReadKeyFile(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,134 @@
+// Package assembly computes assembly quality metrics in the spirit of
+// QUAST: contiguity (N50, NG50, L50), size, gap content and, when a
+// reference is available, genome fraction and misassembly candidates
+// found at alignment breakpoints. It also analyses N-gap runs in
+// scaffolds and splits scaffolds into contigs with AGP placements.
+//
+// Comparison with Aria:
+//
+//	Aria states the contiguity invariants on the metrics:
+//	  struct Metrics
+//	    invariant self.n50 <= self.largest
+//	    invariant self.l50 <= self.contigs
+//	    invariant self.n_bases <= self.total_length
+//
+//	Go computes them from sorted lengths and checks nothing at runtime.
+package assembly
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// DefaultMinContig is the shortest contig counted, as in QUAST.
+const DefaultMinContig = 500
+
+// Options configures assembly metrics.
+type Options struct {
+	// MinContig skips shorter contigs.
+	MinContig int
+	// GenomeSize is the expected genome size for NG50/LG50; zero leaves
+	// them unset unless a reference is given.
+	GenomeSize int
+}
+
+// Metrics holds the contiguity and composition metrics of an assembly.
+type Metrics struct {
+	Contigs     int     `json:"contigs"`
+	Skipped     int     `json:"skipped_short_contigs"`
+	TotalLength int     `json:"total_length"`
+	Largest     int     `json:"largest_contig"`
+	N50         int     `json:"n50"`
+	N90         int     `json:"n90"`
+	L50         int     `json:"l50"`
+	NG50        int     `json:"ng50,omitempty"`
+	LG50        int     `json:"lg50,omitempty"`
+	GCContent   float64 `json:"gc_content"`
+	// NBases counts ambiguous (N) bases; NsPer100kbp normalizes them by
+	// the total length.
+	NBases      int     `json:"n_bases"`
+	NsPer100kbp float64 `json:"ns_per_100kbp"`
+	// Gaps counts runs of N.
+	Gaps int `json:"gaps"`
+}
+
+// Compute calculates the metrics of the contigs not shorter than
+// opts.MinContig.
+//
+// Aria equivalent:
+//
+//	fn compute(contigs: [Sequence], options: Options) -> Result<Metrics, AssemblyError>
+//	  requires options.min_contig >= 0 and options.genome_size >= 0
+//	  ensures result.contigs + result.skipped == contigs.len()
+func Compute(contigs []*sequence.Sequence, opts Options) (*Metrics, error) {
+	if opts.MinContig < 0 || opts.GenomeSize < 0 {
+		return nil, fmt.Errorf("minimum contig length and genome size must be non-negative")
+	}
+	m := &Metrics{}
+	lengths := make([]int, 0, len(contigs))
+	gc, acgt := 0, 0
+	for _, c := range contigs {
+		if c.Len() < opts.MinContig {
+			m.Skipped++
+			continue
+		}
+		lengths = append(lengths, c.Len())
+		for i := 0; i < len(c.Bases); i++ {
+			switch c.Bases[i] {
+			case 'G', 'C', 'g', 'c':
+				gc++
+				acgt++
+			case 'A', 'T', 'a', 't':
+				acgt++
+			case 'N', 'n':
+				m.NBases++
+			}
+		}
+		m.Gaps += len(FindGaps(c, 1))
+	}
+	if len(lengths) == 0 {
+		return nil, fmt.Errorf("no contigs of at least %d bp", opts.MinContig)
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(lengths)))
+	m.Contigs = len(lengths)
+	m.Largest = lengths[0]
+	for _, l := range lengths {
+		m.TotalLength += l
+	}
+	m.N50, m.L50 = Nx(lengths, m.TotalLength, 0.5)
+	m.N90, _ = Nx(lengths, m.TotalLength, 0.9)
+	if opts.GenomeSize > 0 {
+		m.NG50, m.LG50 = Nx(lengths, opts.GenomeSize, 0.5)
+	}
+	if acgt > 0 {
+		m.GCContent = float64(gc) / float64(acgt)
+	}
+	m.NsPer100kbp = float64(m.NBases) * 100000 / float64(m.TotalLength)
+	return m, nil
+}
+
+// Nx returns the length of the contig at which the cumulative length of
+// the longest contigs first reaches fraction of total, and the number of
+// contigs needed (the Lx). lengths must be sorted in decreasing order.
+// Both are zero when the contigs never reach the fraction, as happens for
+// NG50 of an assembly shorter than half the genome.
+//
+// Aria equivalent:
+//
+//	fn nx(lengths: [Int], total: Int, fraction: Float) -> (Int, Int)
+//	  requires lengths.is_sorted_by(|a, b| a >= b)
+//	  requires fraction > 0.0 and fraction <= 1.0
+func Nx(lengths []int, total int, fraction float64) (int, int) {
+	target := fraction * float64(total)
+	sum := 0
+	for i, l := range lengths {
+		sum += l
+		if float64(sum) >= target {
+			return l, i + 1
+		}
+	}
+	return 0, 0
+}
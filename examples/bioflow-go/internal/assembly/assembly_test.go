@@ -0,0 +1,144 @@
+package assembly
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seqWithID(t *testing.T, id, bases string) *sequence.Sequence {
+	s, err := sequence.New(bases)
+	require.NoError(t, err)
+	s.ID = id
+	return s
+}
+
+func randomBases(n int, seed int64) string {
+	rng := rand.New(rand.NewSource(seed))
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = "ACGT"[rng.Intn(4)]
+	}
+	return string(b)
+}
+
+func TestNx(t *testing.T) {
+	lengths := []int{50, 30, 10, 10}
+	n, l := Nx(lengths, 100, 0.5)
+	assert.Equal(t, 50, n)
+	assert.Equal(t, 1, l)
+	n, l = Nx(lengths, 100, 0.9)
+	assert.Equal(t, 10, n)
+	assert.Equal(t, 3, l)
+	n, _ = Nx(lengths, 1000, 0.5)
+	assert.Zero(t, n)
+}
+
+func TestCompute(t *testing.T) {
+	contigs := []*sequence.Sequence{
+		seqWithID(t, "c1", strings.Repeat("GC", 300)),
+		seqWithID(t, "c2", strings.Repeat("AT", 200)+strings.Repeat("N", 10)+strings.Repeat("AT", 200)+"NN"),
+		seqWithID(t, "short", "ACGT"),
+	}
+	m, err := Compute(contigs, Options{MinContig: 500, GenomeSize: 4000})
+	require.NoError(t, err)
+	assert.Equal(t, 2, m.Contigs)
+	assert.Equal(t, 1, m.Skipped)
+	assert.Equal(t, 1412, m.TotalLength)
+	assert.Equal(t, 812, m.Largest)
+	assert.Equal(t, 812, m.N50)
+	assert.Equal(t, 1, m.L50)
+	assert.Zero(t, m.NG50)
+	assert.Equal(t, 12, m.NBases)
+	assert.Equal(t, 2, m.Gaps)
+	assert.InDelta(t, 600.0/1400, m.GCContent, 1e-9)
+
+	_, err = Compute(contigs[2:], Options{MinContig: 500})
+	assert.Error(t, err)
+}
+
+func TestReportMisassemblies(t *testing.T) {
+	ref := randomBases(6000, 7)
+	fwd, err := sequence.New(ref[4000:5000])
+	require.NoError(t, err)
+	rc, err := fwd.ReverseComplement()
+	require.NoError(t, err)
+	contigs := []*sequence.Sequence{
+		seqWithID(t, "ok", ref[0:2000]),
+		seqWithID(t, "reloc", ref[2000:3000]+ref[5000:6000]),
+		seqWithID(t, "inv", ref[3000:4000]+rc.Bases),
+		seqWithID(t, "novel", randomBases(800, 99)),
+	}
+	references := []*sequence.Sequence{seqWithID(t, "chr1", ref)}
+
+	report, err := NewReport(contigs, references, Options{MinContig: 500}, DefaultAlignOptions())
+	require.NoError(t, err)
+	assert.Equal(t, 2000, report.Metrics.NG50)
+	assert.Equal(t, 2, report.Metrics.LG50)
+	require.NotNil(t, report.Reference)
+	assert.Equal(t, 1, report.Reference.UnalignedContigs)
+	assert.InDelta(t, 1.0, report.Reference.GenomeFraction, 0.01)
+	require.Len(t, report.Reference.Breakpoints, 2)
+	assert.Equal(t, "reloc", report.Reference.Breakpoints[0].Contig)
+	assert.Equal(t, Relocation, report.Reference.Breakpoints[0].Kind)
+	assert.InDelta(t, 1000, report.Reference.Breakpoints[0].Position, 20)
+	assert.Equal(t, Inversion, report.Reference.Breakpoints[1].Kind)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteHTML(&buf))
+	assert.Contains(t, buf.String(), "<td>relocation</td>")
+	buf.Reset()
+	require.NoError(t, report.WriteText(&buf))
+	assert.Regexp(t, `# misassemblies +2\n`, buf.String())
+}
+func TestGaps(t *testing.T) {
+	scaffold := seqWithID(t, "scf1", "NNACGT"+strings.Repeat("N", 100)+"GGCCNNAATT"+strings.Repeat("N", 20)+"TTAANNN")
+	gaps := FindGaps(scaffold, 1)
+	require.Len(t, gaps, 5)
+	assert.Equal(t, Gap{SequenceID: "scf1", Start: 6, End: 106}, gaps[1])
+	assert.Len(t, FindGaps(scaffold, 10), 2)
+
+	stats, all := NewGapStats([]*sequence.Sequence{scaffold, seqWithID(t, "scf2", "ACGT")}, 1)
+	assert.Len(t, all, 5)
+	assert.Equal(t, 5, stats.Count)
+	assert.Equal(t, 1, stats.ScaffoldsWithGaps)
+	assert.Equal(t, 127, stats.TotalLength)
+	assert.Equal(t, 2, stats.MinLength)
+	assert.Equal(t, 100, stats.MaxLength)
+	assert.Equal(t, 3, stats.MedianLength)
+	assert.Equal(t, 1, stats.UnknownSize)
+	assert.Equal(t, GapBin{Min: 1, Max: 9, Count: 3}, stats.Distribution[0])
+	assert.Equal(t, GapBin{Min: 10, Max: 99, Count: 1}, stats.Distribution[1])
+	assert.Equal(t, GapBin{Min: 100, Max: 999, Count: 1}, stats.Distribution[2])
+	assert.Equal(t, GapBin{Min: 10000, Max: 0}, stats.Distribution[4])
+
+	contigs, records, err := SplitScaffolds([]*sequence.Sequence{scaffold}, 10)
+	require.NoError(t, err)
+	require.Len(t, contigs, 3)
+	assert.Equal(t, "scf1_1", contigs[0].ID)
+	assert.Equal(t, "ACGT", contigs[0].Bases)
+	assert.Equal(t, "GGCCNNAATT", contigs[1].Bases)
+	assert.Equal(t, "TTAA", contigs[2].Bases)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteAGP(&buf, records))
+	assert.Equal(t, "##agp-version\t2.1\n"+
+		"scf1\t1\t4\t1\tW\tscf1_1\t1\t4\t+\n"+
+		"scf1\t5\t104\t2\tU\t100\tscaffold\tyes\tunspecified\n"+
+		"scf1\t105\t114\t3\tW\tscf1_2\t1\t10\t+\n"+
+		"scf1\t115\t134\t4\tN\t20\tscaffold\tyes\tunspecified\n"+
+		"scf1\t135\t138\t5\tW\tscf1_3\t1\t4\t+\n", buf.String())
+
+	contigs, records, err = SplitScaffolds([]*sequence.Sequence{seqWithID(t, "s", "ACGTNNACGT")}, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "s", contigs[0].ID)
+	assert.Len(t, records, 1)
+
+	_, _, err = SplitScaffolds(nil, 0)
+	assert.Error(t, err)
+}
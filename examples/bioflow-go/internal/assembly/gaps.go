@@ -0,0 +1,244 @@
+package assembly
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// UnknownGapLength is the conventional length of gaps of unknown size,
+// written as AGP component type U.
+const UnknownGapLength = 100
+
+// Gap is a run of N in a sequence, 0-based and half-open.
+type Gap struct {
+	SequenceID string `json:"sequence_id"`
+	Start      int    `json:"start"`
+	End        int    `json:"end"`
+}
+
+// Len returns the gap length.
+func (g Gap) Len() int {
+	return g.End - g.Start
+}
+
+// FindGaps returns the runs of at least minLen N bases in a sequence.
+//
+// Aria equivalent:
+//
+//	fn find_gaps(seq: Sequence, min_len: Int) -> [Gap]
+//	  requires min_len >= 1
+//	  ensures result.all(|g| g.len() >= min_len)
+func FindGaps(seq *sequence.Sequence, minLen int) []Gap {
+	if minLen < 1 {
+		minLen = 1
+	}
+	gaps := make([]Gap, 0)
+	start := -1
+	for i := 0; i <= len(seq.Bases); i++ {
+		isN := i < len(seq.Bases) && (seq.Bases[i] == 'N' || seq.Bases[i] == 'n')
+		switch {
+		case isN && start < 0:
+			start = i
+		case !isN && start >= 0:
+			if i-start >= minLen {
+				gaps = append(gaps, Gap{SequenceID: seq.ID, Start: start, End: i})
+			}
+			start = -1
+		}
+	}
+	return gaps
+}
+
+// GapBin counts gaps with lengths in [Min, Max]; Max is 0 for the
+// open-ended last bin.
+type GapBin struct {
+	Min   int `json:"min"`
+	Max   int `json:"max"`
+	Count int `json:"count"`
+}
+
+// GapStats summarizes the gaps of a set of sequences.
+type GapStats struct {
+	Sequences         int     `json:"sequences"`
+	ScaffoldsWithGaps int     `json:"scaffolds_with_gaps"`
+	Count             int     `json:"count"`
+	TotalLength       int     `json:"total_length"`
+	MinLength         int     `json:"min_length"`
+	MaxLength         int     `json:"max_length"`
+	MeanLength        float64 `json:"mean_length"`
+	MedianLength      int     `json:"median_length"`
+	// UnknownSize counts gaps of exactly UnknownGapLength.
+	UnknownSize  int      `json:"unknown_size"`
+	Distribution []GapBin `json:"distribution"`
+}
+
+// NewGapStats finds the gaps of at least minLen bases and summarizes
+// their lengths in decade bins (1-9, 10-99, ... 10000+).
+//
+// Aria equivalent:
+//
+//	fn gap_stats(sequences: [Sequence], min_len: Int) -> (GapStats, [Gap])
+//	  ensures result.0.count == result.1.len()
+func NewGapStats(sequences []*sequence.Sequence, minLen int) (*GapStats, []Gap) {
+	stats := &GapStats{Sequences: len(sequences)}
+	for lo := 1; lo <= 10000; lo *= 10 {
+		bin := GapBin{Min: lo, Max: lo*10 - 1}
+		if lo == 10000 {
+			bin.Max = 0
+		}
+		stats.Distribution = append(stats.Distribution, bin)
+	}
+
+	all := make([]Gap, 0)
+	for _, seq := range sequences {
+		gaps := FindGaps(seq, minLen)
+		if len(gaps) > 0 {
+			stats.ScaffoldsWithGaps++
+		}
+		all = append(all, gaps...)
+	}
+	if len(all) == 0 {
+		return stats, all
+	}
+
+	lengths := make([]int, len(all))
+	for i, g := range all {
+		lengths[i] = g.Len()
+		stats.TotalLength += g.Len()
+		if g.Len() == UnknownGapLength {
+			stats.UnknownSize++
+		}
+		for b := len(stats.Distribution) - 1; b >= 0; b-- {
+			if g.Len() >= stats.Distribution[b].Min {
+				stats.Distribution[b].Count++
+				break
+			}
+		}
+	}
+	sort.Ints(lengths)
+	stats.Count = len(all)
+	stats.MinLength = lengths[0]
+	stats.MaxLength = lengths[len(lengths)-1]
+	stats.MeanLength = float64(stats.TotalLength) / float64(len(all))
+	stats.MedianLength = lengths[len(lengths)/2]
+	return stats, all
+}
+
+// AGPRecord is one line of an AGP 2.1 file. Component fields are used for
+// W lines and gap fields for N and U lines.
+type AGPRecord struct {
+	Object         string `json:"object"`
+	ObjectStart    int    `json:"object_start"`
+	ObjectEnd      int    `json:"object_end"`
+	PartNumber     int    `json:"part_number"`
+	ComponentType  byte   `json:"-"`
+	ComponentID    string `json:"component_id,omitempty"`
+	ComponentStart int    `json:"component_start,omitempty"`
+	ComponentEnd   int    `json:"component_end,omitempty"`
+	Orientation    byte   `json:"-"`
+	GapLength      int    `json:"gap_length,omitempty"`
+	GapType        string `json:"gap_type,omitempty"`
+	Linkage        string `json:"linkage,omitempty"`
+	Evidence       string `json:"evidence,omitempty"`
+}
+
+// String formats the record as a tab-separated AGP line.
+func (r AGPRecord) String() string {
+	prefix := fmt.Sprintf("%s\t%d\t%d\t%d\t%c", r.Object, r.ObjectStart, r.ObjectEnd, r.PartNumber, r.ComponentType)
+	if r.ComponentType == 'N' || r.ComponentType == 'U' {
+		return fmt.Sprintf("%s\t%d\t%s\t%s\t%s", prefix, r.GapLength, r.GapType, r.Linkage, r.Evidence)
+	}
+	return fmt.Sprintf("%s\t%s\t%d\t%d\t%c", prefix, r.ComponentID, r.ComponentStart, r.ComponentEnd, r.Orientation)
+}
+
+// WriteAGP writes AGP 2.1 records after the version header.
+func WriteAGP(w io.Writer, records []AGPRecord) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "##agp-version\t2.1")
+	for _, r := range records {
+		fmt.Fprintln(bw, r.String())
+	}
+	return bw.Flush()
+}
+
+// SplitScaffolds breaks scaffolds into contigs at gaps of at least minGap
+// N bases and describes each scaffold as an AGP object made of those
+// contigs and gaps. Shorter gaps stay inside contigs. Leading and trailing
+// N runs are trimmed, since AGP objects cannot start or end with a gap;
+// object coordinates refer to the trimmed scaffold. Contigs are named
+// "<scaffold>_<n>", or keep the scaffold name when it has no split.
+//
+// Aria equivalent:
+//
+//	fn split_scaffolds(scaffolds: [Sequence], min_gap: Int) -> Result<([Sequence], [AGPRecord]), AssemblyError>
+//	  requires min_gap >= 1
+//	  ensures result.0.all(|c| not c.bases.starts_with("N") and not c.bases.ends_with("N"))
+func SplitScaffolds(scaffolds []*sequence.Sequence, minGap int) ([]*sequence.Sequence, []AGPRecord, error) {
+	if minGap < 1 {
+		return nil, nil, fmt.Errorf("minimum gap length must be at least 1")
+	}
+	contigs := make([]*sequence.Sequence, 0, len(scaffolds))
+	records := make([]AGPRecord, 0)
+	for _, scaffold := range scaffolds {
+		// Terminal N runs of any length are trimmed.
+		start, end := 0, len(scaffold.Bases)
+		for start < end && (scaffold.Bases[start] == 'N' || scaffold.Bases[start] == 'n') {
+			start++
+		}
+		for end > start && (scaffold.Bases[end-1] == 'N' || scaffold.Bases[end-1] == 'n') {
+			end--
+		}
+		if start == end {
+			continue
+		}
+
+		pieces := make([][2]int, 0)
+		pieceStart := start
+		gaps := FindGaps(scaffold, minGap)
+		for _, g := range gaps {
+			if g.Start < start || g.End > end {
+				continue
+			}
+			pieces = append(pieces, [2]int{pieceStart, g.Start})
+			pieceStart = g.End
+		}
+		pieces = append(pieces, [2]int{pieceStart, end})
+
+		part := 0
+		for i, p := range pieces {
+			name := scaffold.ID
+			if len(pieces) > 1 {
+				name = fmt.Sprintf("%s_%d", scaffold.ID, i+1)
+			}
+			contig, err := sequence.New(scaffold.Bases[p[0]:p[1]])
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", name, err)
+			}
+			contig.ID = name
+			contigs = append(contigs, contig)
+
+			part++
+			records = append(records, AGPRecord{
+				Object: scaffold.ID, ObjectStart: p[0] - start + 1, ObjectEnd: p[1] - start, PartNumber: part,
+				ComponentType: 'W', ComponentID: name, ComponentStart: 1, ComponentEnd: p[1] - p[0], Orientation: '+',
+			})
+			if i+1 < len(pieces) {
+				gapLen := pieces[i+1][0] - p[1]
+				gapType := byte('N')
+				if gapLen == UnknownGapLength {
+					gapType = 'U'
+				}
+				part++
+				records = append(records, AGPRecord{
+					Object: scaffold.ID, ObjectStart: p[1] - start + 1, ObjectEnd: pieces[i+1][0] - start, PartNumber: part,
+					ComponentType: gapType, GapLength: gapLen, GapType: "scaffold", Linkage: "yes", Evidence: "unspecified",
+				})
+			}
+		}
+	}
+	return contigs, records, nil
+}
@@ -0,0 +1,235 @@
+package assembly
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aria-lang/bioflow-go/internal/kmer"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Misassembly kinds, following QUAST.
+const (
+	// Relocation joins two parts of the same reference strand that are
+	// more than the relocation distance apart or overlap by more.
+	Relocation = "relocation"
+	// Inversion joins parts aligned to opposite strands.
+	Inversion = "inversion"
+	// Translocation joins parts of different reference sequences.
+	Translocation = "translocation"
+)
+
+// AlignOptions configures contig-to-reference alignment.
+type AlignOptions struct {
+	// K is the anchor k-mer size.
+	K int
+	// MinBlock is the shortest aligned block kept, on the contig.
+	MinBlock int
+	// RelocationDistance is the largest reference gap or overlap between
+	// consecutive blocks that is still considered contiguous (QUAST uses
+	// 1 kbp).
+	RelocationDistance int
+}
+
+// DefaultAlignOptions returns the default alignment parameters.
+func DefaultAlignOptions() AlignOptions {
+	return AlignOptions{K: 19, MinBlock: 200, RelocationDistance: 1000}
+}
+
+// Block is a collinear alignment of part of a contig to a reference.
+// Coordinates are 0-based, half-open on the forward strands.
+type Block struct {
+	Contig   string  `json:"contig"`
+	Start    int     `json:"start"`
+	End      int     `json:"end"`
+	Ref      string  `json:"ref"`
+	RefStart int     `json:"ref_start"`
+	RefEnd   int     `json:"ref_end"`
+	Strand   string  `json:"strand"`
+	Score    float64 `json:"score"`
+	// covered holds the reference intervals covered by the block's
+	// anchors, which exclude deletions spanned by the block.
+	covered [][2]int
+}
+
+// Breakpoint is a misassembly candidate between two consecutive blocks of
+// a contig.
+type Breakpoint struct {
+	Contig string `json:"contig"`
+	// Position is the 0-based contig position between the blocks.
+	Position int    `json:"position"`
+	Kind     string `json:"kind"`
+	Left     Block  `json:"left"`
+	Right    Block  `json:"right"`
+}
+
+// AlignContigs aligns every contig to every reference sequence with
+// chained k-mer anchors and keeps, per contig, a best-first set of blocks
+// that do not overlap on the contig, ordered by contig position.
+//
+// Aria equivalent:
+//
+//	fn align_contigs(contigs: [Sequence], references: [Sequence], options: AlignOptions) -> Result<[Block], AssemblyError>
+//	  requires options.k > 0
+//	  ensures result.all(|b| b.end - b.start >= options.min_block)
+func AlignContigs(contigs, references []*sequence.Sequence, opts AlignOptions) ([]Block, error) {
+	if opts.K <= 0 {
+		return nil, fmt.Errorf("k must be positive")
+	}
+	// Anchors further apart than the relocation distance must not chain,
+	// or a relocation would be swallowed by a single block.
+	chainOpts := kmer.DefaultChainOptions(opts.K)
+	if opts.RelocationDistance > 0 {
+		chainOpts.MaxGap = opts.RelocationDistance
+	}
+	blocks := make([]Block, 0)
+	for _, c := range contigs {
+		candidates := make([]Block, 0)
+		for _, r := range references {
+			anchors, err := kmer.FindAnchors(c, r, kmer.AnchorOptions{K: opts.K, BothStrands: true, MaxOccurrences: 10})
+			if err != nil {
+				return nil, err
+			}
+			chains, err := kmer.ChainAnchors(anchors, chainOpts)
+			if err != nil {
+				return nil, err
+			}
+			for _, ch := range chains {
+				if ch.End1-ch.Start1 < opts.MinBlock {
+					continue
+				}
+				candidates = append(candidates, Block{
+					Contig: c.ID, Start: ch.Start1, End: ch.End1,
+					Ref: r.ID, RefStart: ch.Start2, RefEnd: ch.End2,
+					Strand: string(ch.Strand), Score: ch.Score,
+					covered: anchorSpans(ch, opts.K),
+				})
+			}
+		}
+		blocks = append(blocks, bestBlocks(candidates)...)
+	}
+	return blocks, nil
+}
+
+// anchorSpans returns the reference intervals covered by a chain's
+// anchors.
+func anchorSpans(ch kmer.Chain, k int) [][2]int {
+	spans := make([][2]int, 0, len(ch.Anchors))
+	for _, a := range ch.Anchors {
+		spans = append(spans, [2]int{a.Pos2, a.Pos2 + k})
+	}
+	return spans
+}
+
+// bestBlocks keeps candidates best score first, dropping any that
+// overlap a kept block by more than half of its contig span, and returns
+// the kept blocks in contig order.
+func bestBlocks(candidates []Block) []Block {
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+	kept := make([]Block, 0)
+	for _, b := range candidates {
+		ok := true
+		for _, k := range kept {
+			overlap := minInt(b.End, k.End) - maxInt(b.Start, k.Start)
+			if overlap*2 > b.End-b.Start {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			kept = append(kept, b)
+		}
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].Start < kept[j].Start })
+	return kept
+}
+
+// FindMisassemblies classifies the junctions between consecutive blocks
+// of each contig. Blocks must be grouped by contig and ordered by
+// position, as AlignContigs returns them.
+//
+// Aria equivalent:
+//
+//	fn find_misassemblies(blocks: [Block], relocation_distance: Int) -> [Breakpoint]
+//	  requires relocation_distance >= 0
+func FindMisassemblies(blocks []Block, relocationDistance int) []Breakpoint {
+	breakpoints := make([]Breakpoint, 0)
+	for i := 1; i < len(blocks); i++ {
+		left, right := blocks[i-1], blocks[i]
+		if left.Contig != right.Contig {
+			continue
+		}
+		kind := ""
+		switch {
+		case left.Ref != right.Ref:
+			kind = Translocation
+		case left.Strand != right.Strand:
+			kind = Inversion
+		default:
+			// Expected reference distance equals the contig distance;
+			// on the '-' strand the reference runs backwards.
+			contigGap := right.Start - left.End
+			refGap := right.RefStart - left.RefEnd
+			if left.Strand == "-" {
+				refGap = left.RefStart - right.RefEnd
+			}
+			if d := refGap - contigGap; d > relocationDistance || d < -relocationDistance {
+				kind = Relocation
+			}
+		}
+		if kind != "" {
+			breakpoints = append(breakpoints, Breakpoint{
+				Contig: left.Contig, Position: (left.End + right.Start) / 2, Kind: kind, Left: left, Right: right,
+			})
+		}
+	}
+	return breakpoints
+}
+
+// GenomeFraction returns the fraction of reference bases covered by at
+// least one block. Blocks from AlignContigs count only the bases covered
+// by their anchors; other blocks count their full reference span.
+func GenomeFraction(blocks []Block, references []*sequence.Sequence) float64 {
+	total := 0
+	byRef := make(map[string][][2]int)
+	for _, r := range references {
+		total += r.Len()
+	}
+	for _, b := range blocks {
+		if b.covered != nil {
+			byRef[b.Ref] = append(byRef[b.Ref], b.covered...)
+		} else {
+			byRef[b.Ref] = append(byRef[b.Ref], [2]int{b.RefStart, b.RefEnd})
+		}
+	}
+	covered := 0
+	for _, spans := range byRef {
+		sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+		end := -1
+		for _, s := range spans {
+			start := maxInt(s[0], end)
+			if s[1] > start {
+				covered += s[1] - start
+			}
+			end = maxInt(end, s[1])
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(covered) / float64(total)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
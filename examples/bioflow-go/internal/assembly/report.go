@@ -0,0 +1,168 @@
+package assembly
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// ReferenceMetrics holds the reference-based part of a report.
+type ReferenceMetrics struct {
+	ReferenceLength  int          `json:"reference_length"`
+	GenomeFraction   float64      `json:"genome_fraction"`
+	AlignedContigs   int          `json:"aligned_contigs"`
+	UnalignedContigs int          `json:"unaligned_contigs"`
+	Misassemblies    int          `json:"misassemblies"`
+	Breakpoints      []Breakpoint `json:"breakpoints"`
+	Blocks           []Block      `json:"blocks,omitempty"`
+}
+
+// Report is a full assembly QC report.
+type Report struct {
+	Metrics   *Metrics          `json:"metrics"`
+	Reference *ReferenceMetrics `json:"reference,omitempty"`
+}
+
+// NewReport computes assembly metrics and, when references are given,
+// aligns the contigs to them to find the genome fraction and misassembly
+// candidates. Without an explicit genome size, NG50 uses the reference
+// length.
+//
+// Aria equivalent:
+//
+//	fn new_report(contigs: [Sequence], references: [Sequence], options: Options, align: AlignOptions) -> Result<Report, AssemblyError>
+//	  ensures references.is_empty() == result.reference.is_none()
+func NewReport(contigs, references []*sequence.Sequence, opts Options, align AlignOptions) (*Report, error) {
+	refLength := 0
+	for _, r := range references {
+		refLength += r.Len()
+	}
+	if opts.GenomeSize == 0 {
+		opts.GenomeSize = refLength
+	}
+	metrics, err := Compute(contigs, opts)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Metrics: metrics}
+	if len(references) == 0 {
+		return report, nil
+	}
+
+	kept := make([]*sequence.Sequence, 0, len(contigs))
+	for _, c := range contigs {
+		if c.Len() >= opts.MinContig {
+			kept = append(kept, c)
+		}
+	}
+	blocks, err := AlignContigs(kept, references, align)
+	if err != nil {
+		return nil, err
+	}
+	aligned := make(map[string]bool)
+	for _, b := range blocks {
+		aligned[b.Contig] = true
+	}
+	breakpoints := FindMisassemblies(blocks, align.RelocationDistance)
+	report.Reference = &ReferenceMetrics{
+		ReferenceLength:  refLength,
+		GenomeFraction:   GenomeFraction(blocks, references),
+		AlignedContigs:   len(aligned),
+		UnalignedContigs: len(kept) - len(aligned),
+		Misassemblies:    len(breakpoints),
+		Breakpoints:      breakpoints,
+		Blocks:           blocks,
+	}
+	return report, nil
+}
+
+// WriteText writes the report as an aligned two-column table.
+func (r *Report) WriteText(w io.Writer) error {
+	rows := r.rows()
+	for _, row := range rows {
+		if _, err := fmt.Fprintf(w, "%-28s %s\n", row[0], row[1]); err != nil {
+			return err
+		}
+	}
+	if r.Reference != nil {
+		for _, bp := range r.Reference.Breakpoints {
+			if _, err := fmt.Fprintf(w, "%s %s:%d (%s:%d-%d%s | %s:%d-%d%s)\n", bp.Kind, bp.Contig, bp.Position+1,
+				bp.Left.Ref, bp.Left.RefStart+1, bp.Left.RefEnd, bp.Left.Strand,
+				bp.Right.Ref, bp.Right.RefStart+1, bp.Right.RefEnd, bp.Right.Strand); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rows returns the report's summary table.
+func (r *Report) rows() [][2]string {
+	m := r.Metrics
+	rows := [][2]string{
+		{"# contigs", fmt.Sprint(m.Contigs)},
+		{"Total length", fmt.Sprint(m.TotalLength)},
+		{"Largest contig", fmt.Sprint(m.Largest)},
+		{"N50", fmt.Sprint(m.N50)},
+		{"N90", fmt.Sprint(m.N90)},
+		{"L50", fmt.Sprint(m.L50)},
+	}
+	if m.NG50 > 0 {
+		rows = append(rows, [2]string{"NG50", fmt.Sprint(m.NG50)}, [2]string{"LG50", fmt.Sprint(m.LG50)})
+	}
+	rows = append(rows,
+		[2]string{"GC (%)", fmt.Sprintf("%.2f", 100*m.GCContent)},
+		[2]string{"# N's per 100 kbp", fmt.Sprintf("%.2f", m.NsPer100kbp)},
+		[2]string{"# gaps", fmt.Sprint(m.Gaps)},
+		[2]string{"# short contigs skipped", fmt.Sprint(m.Skipped)},
+	)
+	if ref := r.Reference; ref != nil {
+		rows = append(rows,
+			[2]string{"Reference length", fmt.Sprint(ref.ReferenceLength)},
+			[2]string{"Genome fraction (%)", fmt.Sprintf("%.3f", 100*ref.GenomeFraction)},
+			[2]string{"# misassemblies", fmt.Sprint(ref.Misassemblies)},
+			[2]string{"# unaligned contigs", fmt.Sprint(ref.UnalignedContigs)},
+		)
+	}
+	return rows
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Assembly report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: left; }
+td.num { text-align: right; }
+</style>
+</head>
+<body>
+<h1>Assembly report</h1>
+<table>
+{{range .Rows}}<tr><th>{{index . 0}}</th><td class="num">{{index . 1}}</td></tr>
+{{end}}</table>
+{{if .Breakpoints}}<h2>Misassembly candidates</h2>
+<table>
+<tr><th>Kind</th><th>Contig</th><th>Position</th><th>Left block</th><th>Right block</th></tr>
+{{range .Breakpoints}}<tr><td>{{.Kind}}</td><td>{{.Contig}}</td><td class="num">{{.Position}}</td><td>{{.Left.Ref}}:{{.Left.RefStart}}-{{.Left.RefEnd}} ({{.Left.Strand}})</td><td>{{.Right.Ref}}:{{.Right.RefStart}}-{{.Right.RefEnd}} ({{.Right.Strand}})</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+// WriteHTML writes the report as a standalone HTML page.
+func (r *Report) WriteHTML(w io.Writer) error {
+	data := struct {
+		Rows        [][2]string
+		Breakpoints []Breakpoint
+	}{Rows: r.rows()}
+	if r.Reference != nil {
+		data.Breakpoints = r.Reference.Breakpoints
+	}
+	return reportTemplate.Execute(w, data)
+}
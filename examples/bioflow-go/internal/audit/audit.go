@@ -0,0 +1,366 @@
+// Package audit keeps an append-only log of the operations of a service:
+// who ran what, when, on which inputs and with which result.
+//
+// A Log is a directory of JSON Lines files, one entry per line. Entries
+// are only ever appended; once the current file reaches its size limit it
+// is renamed after the time it was closed and a new file started, and no
+// file is ever removed. Inputs and results are recorded as SHA-256
+// digests rather than copied, so that the log can be kept where the data
+// itself may not be.
+//
+// Each entry holds the digest of the line before it, across files, so
+// that Verify detects entries that were changed, removed or reordered
+// after the fact.
+//
+// Comparison with Aria:
+//
+//	Aria would state the chain as an invariant of the log:
+//	  invariant forall i in 1..entries.len():
+//	    entries[i].prev == sha256(entries[i - 1].line())
+//
+//	Go maintains it in Append and checks it in Verify.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// currentFile is the name of the file entries are appended to.
+const currentFile = "audit.jsonl"
+
+// rotatedTime names rotated files after the time they were closed, so
+// that they sort in order.
+const rotatedTime = "20060102T150405.000000000Z"
+
+// Entry is one audited operation.
+type Entry struct {
+	Seq       uint64    `json:"seq"`
+	Time      time.Time `json:"time"`
+	User      string    `json:"user,omitempty"`
+	Remote    string    `json:"remote,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	// Operation names what was done, such as "POST /api/v1/alignment/local".
+	Operation string `json:"operation"`
+	// Inputs holds the digests of the operation's inputs, by name, and
+	// Params its other parameters.
+	Inputs map[string]string `json:"inputs,omitempty"`
+	Params map[string]any    `json:"params,omitempty"`
+	Status int               `json:"status"`
+	// Result is the digest of the operation's output.
+	Result   string  `json:"result,omitempty"`
+	Duration float64 `json:"duration_seconds"`
+	// Prev is the digest of the previous line of the log.
+	Prev string `json:"prev"`
+}
+
+// Digest returns the SHA-256 digest of data, as recorded in entries.
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Options configures a Log.
+type Options struct {
+	// MaxBytes is the size at which the current file is rotated
+	// (default: 100 MiB).
+	MaxBytes int64
+	// NoSync skips syncing each entry to disk. Entries appended just
+	// before a crash may then be lost.
+	NoSync bool
+}
+
+// Log is an audit log directory. Its methods may be called concurrently.
+type Log struct {
+	dir  string
+	opts Options
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	seq  uint64
+	prev string
+	err  error
+}
+
+// Open opens the log in dir, creating it if needed, and continues the
+// chain of the entries already there.
+//
+// Aria equivalent:
+//
+//	fn open(dir: Path, opts: Options) -> Result<Log, IOError> with IO
+func Open(dir string, opts Options) (*Log, error) {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 100 << 20
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating audit log: %w", err)
+	}
+	l := &Log{dir: dir, opts: opts}
+
+	files, err := logFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	// The chain continues from the last entry of the newest file with
+	// entries.
+	for i := len(files) - 1; i >= 0; i-- {
+		line, err := lastLine(files[i])
+		if err != nil {
+			return nil, err
+		}
+		if line == nil {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("reading last entry of %s: %w", files[i], err)
+		}
+		l.seq, l.prev = e.Seq, Digest(line)
+		break
+	}
+
+	l.file, err = os.OpenFile(filepath.Join(dir, currentFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	info, err := l.file.Stat()
+	if err != nil {
+		l.file.Close()
+		return nil, err
+	}
+	l.size = info.Size()
+	return l, nil
+}
+
+// Dir returns the directory of the log.
+func (l *Log) Dir() string {
+	return l.dir
+}
+
+// Append adds an entry to the log, setting its sequence number, time
+// (if unset) and chain digest.
+func (l *Log) Append(e *Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return errors.New("audit log is closed")
+	}
+
+	e.Seq, e.Prev = l.seq+1, l.prev
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if l.size > 0 && l.size+int64(len(line))+1 > l.opts.MaxBytes {
+		if err := l.rotate(); err != nil {
+			l.err = err
+			return err
+		}
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		l.err = fmt.Errorf("writing audit log: %w", err)
+		return l.err
+	}
+	if !l.opts.NoSync {
+		if err := l.file.Sync(); err != nil {
+			l.err = fmt.Errorf("syncing audit log: %w", err)
+			return l.err
+		}
+	}
+	l.size += int64(len(line)) + 1
+	l.seq, l.prev, l.err = e.Seq, Digest(line), nil
+	return nil
+}
+
+// rotate renames the current file after the current time and starts a
+// new one; l.mu must be held.
+func (l *Log) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	rotated := filepath.Join(l.dir, "audit-"+time.Now().UTC().Format(rotatedTime)+".jsonl")
+	if err := os.Rename(filepath.Join(l.dir, currentFile), rotated); err != nil {
+		return fmt.Errorf("rotating audit log: %w", err)
+	}
+	file, err := os.OpenFile(filepath.Join(l.dir, currentFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("rotating audit log: %w", err)
+	}
+	l.file, l.size = file, 0
+	return nil
+}
+
+// Check returns the error of the last failed append, if the log has not
+// been written to successfully since.
+func (l *Log) Check() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return errors.New("audit log is closed")
+	}
+	return l.err
+}
+
+// Close closes the log.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// logFiles returns the log files of a directory, oldest first.
+func logFiles(dir string) ([]string, error) {
+	rotated, err := filepath.Glob(filepath.Join(dir, "audit-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(rotated)
+	current := filepath.Join(dir, currentFile)
+	if _, err := os.Stat(current); err == nil {
+		rotated = append(rotated, current)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return rotated, nil
+}
+
+// lastLine returns the last line of a file, or nil if it is empty.
+func lastLine(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimRight(data, "\n")
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+	return data, nil
+}
+
+// Filter selects entries to export. Zero fields select everything.
+type Filter struct {
+	From, To time.Time
+	User     string
+}
+
+func (f Filter) match(e *Entry) bool {
+	return (f.From.IsZero() || !e.Time.Before(f.From)) &&
+		(f.To.IsZero() || e.Time.Before(f.To)) &&
+		(f.User == "" || e.User == f.User)
+}
+
+// Export writes the entries of a log directory selected by f to w, as
+// JSON Lines, oldest first. Lines are copied as they are in the log, so
+// that their digests can be checked against it.
+//
+// Aria equivalent:
+//
+//	fn export(dir: Path, f: Filter, w: Writer) -> Result<Int, IOError> with IO
+func Export(dir string, f Filter, w io.Writer) (int, error) {
+	n := 0
+	err := scan(dir, func(line []byte, e *Entry) error {
+		if !f.match(e) {
+			return nil
+		}
+		n++
+		_, err := w.Write(append(line, '\n'))
+		return err
+	})
+	return n, err
+}
+
+// Verify checks the chain of a log directory: that each entry holds the
+// digest of the one before it and that sequence numbers follow each
+// other. It returns the number of entries checked.
+//
+// Aria equivalent:
+//
+//	fn verify(dir: Path) -> Result<Int, ChainError> with IO
+func Verify(dir string) (int, error) {
+	n := 0
+	var seq uint64
+	prev := ""
+	err := scan(dir, func(line []byte, e *Entry) error {
+		if n > 0 && e.Prev != prev {
+			return fmt.Errorf("entry %d does not follow entry %d: chain broken", e.Seq, seq)
+		}
+		if n > 0 && e.Seq != seq+1 {
+			return fmt.Errorf("entry %d follows entry %d: entries missing", e.Seq, seq)
+		}
+		n++
+		seq, prev = e.Seq, Digest(line)
+		return nil
+	})
+	return n, err
+}
+
+// scan calls fn with each line of the log directory and its entry, oldest
+// first.
+func scan(dir string, fn func(line []byte, e *Entry) error) error {
+	files, err := logFiles(dir)
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		if err := scanFile(path, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanFile calls fn with each line of a log file. A last line without a
+// newline is an entry still being written, and is skipped.
+func scanFile(path string, fn func(line []byte, e *Entry) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	name := filepath.Base(path)
+	reader := bufio.NewReader(file)
+	for lineNum := 1; ; lineNum++ {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", name, err)
+		}
+		line = bytes.TrimSuffix(line, []byte("\n"))
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return fmt.Errorf("%s:%d: %w", name, lineNum, err)
+		}
+		if err := fn(line, &e); err != nil {
+			return fmt.Errorf("%s:%d: %w", name, lineNum, err)
+		}
+	}
+}
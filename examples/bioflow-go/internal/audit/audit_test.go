@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, Options{NoSync: true})
+	require.NoError(t, err)
+
+	t0 := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, l.Append(&Entry{Time: t0, User: "ada", Operation: "POST /api/v1/alignment/local",
+		Inputs: map[string]string{"sequence1": Digest([]byte("ACGT"))}, Status: 200}))
+	e := &Entry{Time: t0.Add(time.Hour), User: "bob", Operation: "GET /api/v1/pipeline/jobs", Status: 200}
+	require.NoError(t, l.Append(e))
+	assert.Equal(t, uint64(2), e.Seq)
+	assert.NotEmpty(t, e.Prev)
+	require.NoError(t, l.Check())
+
+	// The chain continues across reopening.
+	require.NoError(t, l.Close())
+	assert.Error(t, l.Append(&Entry{}))
+	l, err = Open(dir, Options{NoSync: true})
+	require.NoError(t, err)
+	e = &Entry{Time: t0.Add(2 * time.Hour), User: "ada", Operation: "GET /api/v1/audit", Status: 200}
+	require.NoError(t, l.Append(e))
+	assert.Equal(t, uint64(3), e.Seq)
+	require.NoError(t, l.Close())
+
+	n, err := Verify(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	var buf bytes.Buffer
+	n, err = Export(dir, Filter{User: "ada"}, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Contains(t, buf.String(), Digest([]byte("ACGT")))
+
+	buf.Reset()
+	n, err = Export(dir, Filter{From: t0.Add(time.Hour), To: t0.Add(2 * time.Hour)}, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Contains(t, buf.String(), `"user":"bob"`)
+}
+
+func TestLogRotation(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, Options{MaxBytes: 300, NoSync: true})
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, l.Append(&Entry{Operation: "POST /api/v1/sequence/info", Status: 200}))
+	}
+	require.NoError(t, l.Close())
+
+	rotated, err := filepath.Glob(filepath.Join(dir, "audit-*.jsonl"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, rotated)
+
+	n, err := Verify(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 10, n)
+	n, err = Export(dir, Filter{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, 10, n)
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, Options{NoSync: true})
+	require.NoError(t, err)
+	for _, user := range []string{"ada", "bob", "eve"} {
+		require.NoError(t, l.Append(&Entry{User: user, Operation: "POST /api/v1/stats/reads", Status: 200}))
+	}
+	require.NoError(t, l.Close())
+
+	path := filepath.Join(dir, currentFile)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.SplitAfter(string(data), "\n")
+
+	// An edited entry breaks the chain at the next one.
+	edited := strings.Replace(string(data), `"user":"bob"`, `"user":"ada"`, 1)
+	require.NoError(t, os.WriteFile(path, []byte(edited), 0o600))
+	_, err = Verify(dir)
+	assert.ErrorContains(t, err, "chain broken")
+
+	// So does a removed one.
+	require.NoError(t, os.WriteFile(path, []byte(lines[0]+lines[2]), 0o600))
+	_, err = Verify(dir)
+	assert.Error(t, err)
+}
@@ -0,0 +1,295 @@
+// Package bisulfite simulates bisulfite conversion of DNA and annotates
+// the methylation context of its cytosines.
+//
+// Bisulfite turns unmethylated cytosines into uracil, read as thymine,
+// and leaves methylated ones. Converted sequences of a reference, C to T
+// on the top strand and G to A for the bottom one, are what bisulfite
+// reads are aligned to; the context of each cytosine (CG, CHG or CHH,
+// where H is A, C or T) is what methylation levels are reported by.
+//
+// Comparison with Aria:
+//
+//	Aria can state what conversion leaves behind:
+//	  fn convert(seq: Sequence, options: ConvertOptions) -> Sequence
+//	    ensures options.protect.is_empty() implies !result.bases.contains('C')
+//
+//	Go documents it and checks it in tests.
+package bisulfite
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Context is the methylation context of a cytosine, by the bases 3' of it
+// on its strand.
+type Context int
+
+const (
+	// CG is a cytosine followed by G.
+	CG Context = iota
+	// CHG is a cytosine followed by A, C or T, then G.
+	CHG
+	// CHH is a cytosine followed by two bases other than G.
+	CHH
+)
+
+// Contexts lists the contexts in order.
+var Contexts = []Context{CG, CHG, CHH}
+
+// String returns the name of the context.
+func (c Context) String() string {
+	switch c {
+	case CG:
+		return "CG"
+	case CHG:
+		return "CHG"
+	case CHH:
+		return "CHH"
+	}
+	return "unknown"
+}
+
+// MarshalText encodes the context by name.
+func (c Context) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// ParseContext parses a context name, case-insensitively; CpG is accepted
+// for CG.
+func ParseContext(name string) (Context, error) {
+	switch strings.ToUpper(name) {
+	case "CG", "CPG":
+		return CG, nil
+	case "CHG":
+		return CHG, nil
+	case "CHH":
+		return CHH, nil
+	}
+	return 0, fmt.Errorf("unknown methylation context %q (want CG, CHG or CHH)", name)
+}
+
+// Strand is a strand of a duplex.
+type Strand byte
+
+const (
+	// Top converts C to T, as bisulfite does to the strand given.
+	Top Strand = '+'
+	// Bottom converts G to A, which is C to T on the opposite strand,
+	// reported in the coordinates of the strand given.
+	Bottom Strand = '-'
+)
+
+// ConvertOptions configures conversion.
+type ConvertOptions struct {
+	// Strand is the strand converted (default Top).
+	Strand Strand
+	// Protect lists the contexts whose cytosines are taken to be
+	// methylated and left unconverted, such as CG to model the fully
+	// methylated CpGs of mammalian genomes. Empty converts every cytosine.
+	Protect []Context
+}
+
+// Convert returns a copy of seq as bisulfite converts it when no cytosine
+// outside the protected contexts is methylated: every C of the top strand
+// becomes T, or every G (a C of the bottom strand) becomes A.
+//
+// Aria equivalent:
+//
+//	fn convert(seq: Sequence, options: ConvertOptions) -> Result<Sequence, BisulfiteError>
+//	  requires seq.seq_type == SequenceType::DNA
+//	  ensures result.len() == seq.len()
+func Convert(seq *sequence.Sequence, opts ConvertOptions) (*sequence.Sequence, error) {
+	if seq.SeqType != sequence.DNA {
+		return nil, fmt.Errorf("bisulfite conversion needs a DNA sequence")
+	}
+	if opts.Strand == 0 {
+		opts.Strand = Top
+	}
+	if opts.Strand != Top && opts.Strand != Bottom {
+		return nil, fmt.Errorf("unknown strand %q", opts.Strand)
+	}
+	protect := make(map[Context]bool, len(opts.Protect))
+	for _, c := range opts.Protect {
+		protect[c] = true
+	}
+
+	from, to := byte('C'), byte('T')
+	if opts.Strand == Bottom {
+		from, to = 'G', 'A'
+	}
+	bases := []byte(seq.Bases)
+	for i := range bases {
+		if bases[i] != from {
+			continue
+		}
+		if len(protect) > 0 {
+			if c, ok := contextAt(seq.Bases, i, opts.Strand); ok && protect[c] {
+				continue
+			}
+		}
+		bases[i] = to
+	}
+	return &sequence.Sequence{
+		Bases:       string(bases),
+		ID:          seq.ID,
+		Description: seq.Description,
+		SeqType:     seq.SeqType,
+	}, nil
+}
+
+// Site is a cytosine and its methylation context. Position is 0-based, on
+// the strand given; a cytosine of the bottom strand is a G of it.
+type Site struct {
+	SequenceID string  `json:"sequence_id,omitempty"`
+	Position   int     `json:"position"`
+	Strand     string  `json:"strand"`
+	Context    Context `json:"context"`
+	// Trinucleotide is the cytosine and the two bases 3' of it, read on
+	// its strand.
+	Trinucleotide string `json:"trinucleotide"`
+}
+
+// FindSites returns the cytosines of both strands with their context, in
+// order of position, the top strand first at a position. Cytosines whose
+// context is not known, next to an N or the end of the sequence, are
+// left out.
+//
+// Aria equivalent:
+//
+//	fn find_sites(seq: Sequence) -> [Site]
+//	  ensures result.is_sorted_by_key(|s| s.position)
+func FindSites(seq *sequence.Sequence) []Site {
+	bases := seq.Bases
+	sites := make([]Site, 0)
+	for i := 0; i < len(bases); i++ {
+		strand := Top
+		switch bases[i] {
+		case 'C':
+		case 'G':
+			strand = Bottom
+		default:
+			continue
+		}
+		c, ok := contextAt(bases, i, strand)
+		if !ok {
+			continue
+		}
+		site := Site{SequenceID: seq.ID, Position: i, Strand: string(strand), Context: c}
+		if strand == Top {
+			site.Trinucleotide = bases[i:min(i+3, len(bases))]
+		} else {
+			site.Trinucleotide = reverseComplement(bases[max(i-2, 0) : i+1])
+		}
+		sites = append(sites, site)
+	}
+	return sites
+}
+
+// contextAt returns the context of the cytosine at i on a strand: a C of
+// the top strand, or a G, read as a C of the bottom strand.
+func contextAt(bases string, i int, strand Strand) (Context, bool) {
+	// next returns the k-th base 3' of i on the strand, as read on it.
+	next := func(k int) byte {
+		if strand == Top {
+			if i+k >= len(bases) {
+				return 'N'
+			}
+			return bases[i+k]
+		}
+		if i-k < 0 {
+			return 'N'
+		}
+		return complement(bases[i-k])
+	}
+	isH := func(b byte) bool { return b == 'A' || b == 'C' || b == 'T' }
+
+	b1 := next(1)
+	switch {
+	case b1 == 'G':
+		return CG, true
+	case !isH(b1):
+		return 0, false
+	}
+	b2 := next(2)
+	switch {
+	case b2 == 'G':
+		return CHG, true
+	case isH(b2):
+		return CHH, true
+	}
+	return 0, false
+}
+
+// complement returns the complement of a DNA base, or N.
+func complement(b byte) byte {
+	switch b {
+	case 'A':
+		return 'T'
+	case 'C':
+		return 'G'
+	case 'G':
+		return 'C'
+	case 'T':
+		return 'A'
+	}
+	return 'N'
+}
+
+// reverseComplement returns the reverse complement of a DNA string.
+func reverseComplement(s string) string {
+	b := make([]byte, len(s))
+	for i := 0; i < len(s); i++ {
+		b[len(s)-1-i] = complement(s[i])
+	}
+	return string(b)
+}
+
+// ContextSummary counts the cytosines of each context on both strands.
+type ContextSummary struct {
+	CG  int `json:"cg"`
+	CHG int `json:"chg"`
+	CHH int `json:"chh"`
+}
+
+// Summarize counts sites by context.
+func Summarize(sites []Site) ContextSummary {
+	var s ContextSummary
+	for _, site := range sites {
+		switch site.Context {
+		case CG:
+			s.CG++
+		case CHG:
+			s.CHG++
+		case CHH:
+			s.CHH++
+		}
+	}
+	return s
+}
+
+// WriteSitesBED writes sites as BED6, one base each, named by context.
+func WriteSitesBED(w io.Writer, sites []Site) error {
+	bw := bufio.NewWriter(w)
+	for _, s := range sites {
+		fmt.Fprintf(bw, "%s\t%d\t%d\t%s\t0\t%s\n", s.SequenceID, s.Position, s.Position+1, s.Context, s.Strand)
+	}
+	return bw.Flush()
+}
+
+// WriteCytosineReport writes sites in the layout of Bismark's genome-wide
+// cytosine report: sequence, 1-based position, strand, methylated and
+// unmethylated counts (zero here, to be filled in from reads), context and
+// trinucleotide. Tools built on Bismark output can take it as the list of
+// cytosines to report on.
+func WriteCytosineReport(w io.Writer, sites []Site) error {
+	bw := bufio.NewWriter(w)
+	for _, s := range sites {
+		fmt.Fprintf(bw, "%s\t%d\t%s\t0\t0\t%s\t%s\n", s.SequenceID, s.Position+1, s.Strand, s.Context, s.Trinucleotide)
+	}
+	return bw.Flush()
+}
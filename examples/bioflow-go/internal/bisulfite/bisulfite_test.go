@@ -0,0 +1,76 @@
+package bisulfite
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	seq, err := sequence.WithID("ACGTCAGCTTCCAG", "s1")
+	require.NoError(t, err)
+
+	top, err := Convert(seq, ConvertOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "ATGTTAGTTTTTAG", top.Bases)
+	assert.Equal(t, "s1", top.ID)
+
+	bottom, err := Convert(seq, ConvertOptions{Strand: Bottom})
+	require.NoError(t, err)
+	assert.Equal(t, "ACATCAACTTCCAA", bottom.Bases)
+
+	// The CpG at 1 stays methylated on both strands.
+	cpg, err := Convert(seq, ConvertOptions{Protect: []Context{CG}})
+	require.NoError(t, err)
+	assert.Equal(t, "ACGTTAGTTTTTAG", cpg.Bases)
+	cpg, err = Convert(seq, ConvertOptions{Strand: Bottom, Protect: []Context{CG}})
+	require.NoError(t, err)
+	assert.Equal(t, "ACGTCAACTTCCAA", cpg.Bases)
+
+	rna, err := sequence.WithMetadata("ACGU", "r", "", sequence.RNA)
+	require.NoError(t, err)
+	_, err = Convert(rna, ConvertOptions{})
+	assert.Error(t, err)
+}
+
+func TestFindSites(t *testing.T) {
+	seq, err := sequence.WithID("CGCAGCTTNCA", "s1")
+	require.NoError(t, err)
+
+	sites := FindSites(seq)
+	got := make([]string, len(sites))
+	for i, s := range sites {
+		got[i] = s.Strand + s.Context.String() + ":" + s.Trinucleotide
+		assert.Equal(t, "s1", s.SequenceID)
+	}
+	assert.Equal(t, []string{
+		"+CG:CGC", // 0
+		"-CG:CG",  // 1, reading CG on the bottom strand
+		"+CHG:CAG",
+		"-CHG:CTG", // 4 is G, read as CTG on the bottom strand
+		"+CHH:CTT",
+	}, got)
+	assert.Equal(t, []int{0, 1, 2, 4, 5}, []int{sites[0].Position, sites[1].Position, sites[2].Position, sites[3].Position, sites[4].Position})
+	assert.Equal(t, ContextSummary{CG: 2, CHG: 2, CHH: 1}, Summarize(sites))
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSitesBED(&buf, sites[:1]))
+	assert.Equal(t, "s1\t0\t1\tCG\t0\t+\n", buf.String())
+	buf.Reset()
+	require.NoError(t, WriteCytosineReport(&buf, sites[3:4]))
+	assert.Equal(t, "s1\t5\t-\t0\t0\tCHG\tCTG\n", buf.String())
+}
+
+func TestParseContext(t *testing.T) {
+	c, err := ParseContext("CpG")
+	require.NoError(t, err)
+	assert.Equal(t, CG, c)
+	c, err = ParseContext("chh")
+	require.NoError(t, err)
+	assert.Equal(t, CHH, c)
+	_, err = ParseContext("CHN")
+	assert.Error(t, err)
+}
@@ -0,0 +1,115 @@
+// Package broker distributes background jobs to worker processes through
+// a shared queue, so that heavy jobs can be spread over several machines
+// while one server answers the API.
+//
+// The API server submits Tasks with a Client. Workers, started with
+// Serve, pop tasks from the queue, highest priority class first, and send
+// Replies back to the queue of the server that submitted them: started,
+// progress and heartbeat replies while a task runs, then done or failed.
+// A Client that hears nothing from the worker running a task for longer
+// than its lease considers the worker lost and hands the task back to
+// the caller to run again.
+//
+// Each submission of a task carries a fresh token, and replies for any
+// other token are dropped, so that a worker that was thought lost, or a
+// copy of a task queued before a restart, cannot overwrite the result of
+// the run the server is waiting for.
+//
+// Comparison with Aria:
+//
+//	Aria would describe the protocol as a session type:
+//	  protocol Task = Started . (Progress | Heartbeat)* . (Done | Failed)
+//
+//	Go checks the order of replies at run time in the Client.
+package broker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aria-lang/bioflow-go/internal/jobqueue"
+)
+
+// Task is a job sent to a worker. Kind selects the worker's handler and
+// Body holds its input.
+type Task struct {
+	ID       string            `json:"id"`
+	Kind     string            `json:"kind"`
+	Priority jobqueue.Priority `json:"priority"`
+	// Token identifies this submission of the task; ReplyTo names the
+	// reply queue of the submitting Client.
+	Token   string          `json:"token"`
+	ReplyTo string          `json:"reply_to"`
+	Body    json.RawMessage `json:"body,omitempty"`
+}
+
+// Reply kinds.
+const (
+	Started   = "started"
+	Progress  = "progress"
+	Heartbeat = "heartbeat"
+	Done      = "done"
+	Failed    = "failed"
+)
+
+// Reply is a message from the worker running a task. Body holds the
+// progress report of Progress replies and the result of Done ones.
+type Reply struct {
+	ID     string          `json:"id"`
+	Token  string          `json:"token"`
+	Kind   string          `json:"kind"`
+	Worker string          `json:"worker"`
+	Error  string          `json:"error,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// Broker is a shared queue of tasks, and of replies to the servers that
+// submitted them.
+type Broker interface {
+	// Push queues a task.
+	Push(ctx context.Context, t *Task) error
+	// Pop takes the oldest task of the highest priority class, waiting up
+	// to timeout for one; it returns nil if there is none.
+	Pop(ctx context.Context, timeout time.Duration) (*Task, error)
+	// Len returns the number of tasks of a class waiting for a worker.
+	Len(ctx context.Context, p jobqueue.Priority) (int, error)
+	// Send queues a reply for the Client reading the named queue.
+	Send(ctx context.Context, queue string, r *Reply) error
+	// Receive takes the oldest reply of the named queue, waiting up to
+	// timeout for one; it returns nil if there is none.
+	Receive(ctx context.Context, queue string, timeout time.Duration) (*Reply, error)
+	// Announce records that a worker is up with the given number of
+	// slots, for ttl; zero slots withdraws it.
+	Announce(ctx context.Context, worker string, slots int, ttl time.Duration) error
+	// Workers returns the slots of the workers that are up, by name.
+	Workers(ctx context.Context) (map[string]int, error)
+	Close() error
+}
+
+// Open connects to the broker at a URL. Only Redis is supported, as
+// redis://[user:password@]host:port[/db] or rediss:// for TLS.
+//
+// Aria equivalent:
+//
+//	fn open(url: String) -> Result<Broker, BrokerError> with Network
+func Open(url string) (Broker, error) {
+	switch {
+	case strings.HasPrefix(url, "redis://"), strings.HasPrefix(url, "rediss://"):
+		return OpenRedis(url)
+	}
+	return nil, fmt.Errorf("unsupported broker URL %q (use redis://host:port)", url)
+}
+
+// newToken returns a random submission token.
+func newToken() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,183 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aria-lang/bioflow-go/internal/jobqueue"
+)
+
+func openTest(t *testing.T) Broker {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	b, err := Open("redis://" + srv.Addr())
+	require.NoError(t, err)
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	b := openTest(t)
+
+	_, err := Open("nats://localhost:4222")
+	assert.Error(t, err)
+
+	require.NoError(t, b.Push(ctx, &Task{ID: "b1", Priority: jobqueue.Batch}))
+	require.NoError(t, b.Push(ctx, &Task{ID: "n1", Priority: jobqueue.Normal}))
+	require.NoError(t, b.Push(ctx, &Task{ID: "n2", Priority: jobqueue.Normal}))
+	require.NoError(t, b.Push(ctx, &Task{ID: "i1", Priority: jobqueue.Interactive, Body: json.RawMessage(`{"k":3}`)}))
+	n, err := b.Len(ctx, jobqueue.Normal)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	// Highest class first, oldest first within a class.
+	for _, want := range []string{"i1", "n1", "n2", "b1"} {
+		task, err := b.Pop(ctx, time.Second)
+		require.NoError(t, err)
+		require.NotNil(t, task)
+		assert.Equal(t, want, task.ID)
+		if want == "i1" {
+			assert.JSONEq(t, `{"k":3}`, string(task.Body))
+		}
+	}
+	task, err := b.Pop(ctx, time.Second)
+	require.NoError(t, err)
+	assert.Nil(t, task)
+
+	require.NoError(t, b.Send(ctx, "api", &Reply{ID: "i1", Kind: Started}))
+	require.NoError(t, b.Send(ctx, "api", &Reply{ID: "i1", Kind: Done}))
+	for _, want := range []string{Started, Done} {
+		r, err := b.Receive(ctx, "api", time.Second)
+		require.NoError(t, err)
+		require.NotNil(t, r)
+		assert.Equal(t, want, r.Kind)
+	}
+
+	require.NoError(t, b.Announce(ctx, "w1", 4, time.Minute))
+	require.NoError(t, b.Announce(ctx, "w2", 2, time.Minute))
+	require.NoError(t, b.Announce(ctx, "w2", 0, 0))
+	workers, err := b.Workers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"w1": 4}, workers)
+}
+
+// collect runs a client until it has received n replies or lost tasks.
+func collect(t *testing.T, c *Client, n int) ([]*Reply, []*Task) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var replies []*Reply
+	var lost []*Task
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Run(ctx, func(r *Reply) {
+			replies = append(replies, r)
+			if len(replies)+len(lost) == n {
+				cancel()
+			}
+		}, func(task *Task) {
+			lost = append(lost, task)
+			if len(replies)+len(lost) == n {
+				cancel()
+			}
+		})
+	}()
+	<-done
+	require.Equal(t, n, len(replies)+len(lost), "timed out")
+	return replies, lost
+}
+
+func TestServe(t *testing.T) {
+	ctx := context.Background()
+	b := openTest(t)
+	c := NewClient(b, ClientOptions{Queue: "api"})
+
+	handlers := map[string]Handler{
+		"echo": func(task *Task, progress func(json.RawMessage)) (json.RawMessage, error) {
+			progress(json.RawMessage(`{"step":1}`))
+			return task.Body, nil
+		},
+		"fail": func(*Task, func(json.RawMessage)) (json.RawMessage, error) {
+			return nil, errors.New("bad input")
+		},
+	}
+	serveCtx, stop := context.WithCancel(ctx)
+	served := make(chan error)
+	go func() { served <- Serve(serveCtx, b, WorkerOptions{Name: "w1", Workers: 2}, handlers) }()
+
+	stale := &Task{ID: "job-1", Kind: "echo", Body: json.RawMessage(`"old"`)}
+	require.NoError(t, c.Submit(ctx, stale))
+	// Resubmitting replaces the first submission, whose replies are
+	// dropped.
+	require.NoError(t, c.Submit(ctx, &Task{ID: "job-1", Kind: "echo", Body: json.RawMessage(`"new"`)}))
+	require.NoError(t, c.Submit(ctx, &Task{ID: "job-2", Kind: "fail"}))
+	require.NoError(t, c.Submit(ctx, &Task{ID: "job-3", Kind: "unknown"}))
+
+	replies, lost := collect(t, c, 6)
+	assert.Empty(t, lost)
+	byID := make(map[string][]string)
+	for _, r := range replies {
+		assert.Equal(t, "w1", r.Worker)
+		byID[r.ID] = append(byID[r.ID], r.Kind)
+		switch {
+		case r.ID == "job-1" && r.Kind == Done:
+			assert.JSONEq(t, `"new"`, string(r.Body))
+		case r.ID == "job-1" && r.Kind == Progress:
+			assert.JSONEq(t, `{"step":1}`, string(r.Body))
+		case r.ID == "job-2" && r.Kind == Failed:
+			assert.Equal(t, "bad input", r.Error)
+		case r.ID == "job-3":
+			assert.Contains(t, r.Error, "cannot run")
+		}
+	}
+	assert.Equal(t, []string{Started, Progress, Done}, byID["job-1"])
+	assert.Equal(t, []string{Started, Failed}, byID["job-2"])
+	assert.Equal(t, []string{Failed}, byID["job-3"])
+
+	st, err := c.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, st.Workers)
+	assert.Equal(t, 0, st.Running)
+	assert.Equal(t, int64(2), st.Classes["batch"].Started)
+
+	stop()
+	require.NoError(t, <-served)
+	workers, err := b.Workers(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, workers)
+}
+
+func TestLease(t *testing.T) {
+	ctx := context.Background()
+	b := openTest(t)
+	c := NewClient(b, ClientOptions{Queue: "api", MaxQueued: 1, Lease: 100 * time.Millisecond})
+
+	require.NoError(t, c.Submit(ctx, &Task{ID: "job-1", Priority: jobqueue.Batch}))
+	assert.ErrorIs(t, c.Submit(ctx, &Task{ID: "job-2", Priority: jobqueue.Batch}), jobqueue.ErrFull)
+	assert.GreaterOrEqual(t, c.RetryAfter(ctx, jobqueue.Batch), time.Second)
+
+	// A worker takes the task, starts it and goes silent.
+	task, err := b.Pop(ctx, time.Second)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	require.NoError(t, b.Send(ctx, task.ReplyTo, &Reply{ID: task.ID, Token: task.Token, Kind: Started}))
+
+	replies, lost := collect(t, c, 2)
+	require.Len(t, replies, 1)
+	require.Len(t, lost, 1)
+	assert.Equal(t, "job-1", lost[0].ID)
+
+	st, err := c.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), st.Classes["batch"].Rejected)
+	assert.Equal(t, 0, st.Running)
+}
@@ -0,0 +1,298 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aria-lang/bioflow-go/internal/jobqueue"
+)
+
+// ClientOptions configures a Client.
+type ClientOptions struct {
+	// Queue names the reply queue of the client; it defaults to the host
+	// name, so that a restarted server reads the replies to its
+	// predecessor. Servers sharing a broker need distinct names.
+	Queue string
+	// MaxQueued is the number of tasks of each class that may wait for a
+	// worker; 0 means no limit.
+	MaxQueued int
+	// Lease is how long a running task may go without a reply before its
+	// worker is considered lost; it defaults to 30 seconds.
+	Lease time.Duration
+	// ErrorLog receives broker errors; nil means the standard logger.
+	ErrorLog *log.Logger
+}
+
+// pending is a submitted task awaiting its result.
+type pending struct {
+	task      *Task
+	submitted time.Time
+	started   time.Time
+	lastSeen  time.Time
+}
+
+// Client submits tasks to workers and tracks them until they are over.
+// Its methods may be called concurrently.
+type Client struct {
+	b    Broker
+	opts ClientOptions
+	log  *log.Logger
+
+	mu      sync.Mutex
+	pending map[string]*pending
+	stats   map[jobqueue.Priority]*classStats
+	// runAvg is a moving average of task run times, used to estimate
+	// when a rejected client should retry.
+	runAvg time.Duration
+}
+
+// classStats accumulates the statistics of a class.
+type classStats struct {
+	started  int64
+	rejected int64
+	waitSum  time.Duration
+	waitMax  time.Duration
+}
+
+// NewClient creates a Client. Call Run to receive replies.
+//
+// Aria equivalent:
+//
+//	fn new_client(broker: Broker, options: ClientOptions) -> Client
+func NewClient(b Broker, opts ClientOptions) *Client {
+	if opts.Queue == "" {
+		opts.Queue, _ = os.Hostname()
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = 30 * time.Second
+	}
+	logger := opts.ErrorLog
+	if logger == nil {
+		logger = log.Default()
+	}
+	c := &Client{
+		b:       b,
+		opts:    opts,
+		log:     logger,
+		pending: make(map[string]*pending),
+		stats:   make(map[jobqueue.Priority]*classStats),
+	}
+	for _, p := range []jobqueue.Priority{jobqueue.Interactive, jobqueue.Normal, jobqueue.Batch} {
+		c.stats[p] = &classStats{}
+	}
+	return c
+}
+
+// Submit queues a task with a new token, replacing any earlier submission
+// of the same ID, whose replies are dropped from then on. It returns
+// jobqueue.ErrFull if the queue of the task's class is full.
+//
+// Aria equivalent:
+//
+//	fn submit(self, ctx: Context, task: Task) -> Result<(), BrokerError> with Network
+func (c *Client) Submit(ctx context.Context, t *Task) error {
+	s, ok := c.stats[t.Priority]
+	if !ok {
+		return fmt.Errorf("unknown priority %d", t.Priority)
+	}
+	if c.opts.MaxQueued > 0 {
+		n, err := c.b.Len(ctx, t.Priority)
+		if err != nil {
+			return err
+		}
+		if n >= c.opts.MaxQueued {
+			c.mu.Lock()
+			s.rejected++
+			c.mu.Unlock()
+			return jobqueue.ErrFull
+		}
+	}
+	t.Token, t.ReplyTo = newToken(), c.opts.Queue
+
+	c.mu.Lock()
+	c.pending[t.ID] = &pending{task: t, submitted: time.Now()}
+	c.mu.Unlock()
+	if err := c.b.Push(ctx, t); err != nil {
+		c.mu.Lock()
+		if p := c.pending[t.ID]; p != nil && p.task == t {
+			delete(c.pending, t.ID)
+		}
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Run receives replies until ctx is done, passing those of the current
+// submission of each task to handle, in order. It also checks the leases
+// of running tasks: a task whose worker has been silent for longer than
+// the lease is forgotten and passed to lost, which may submit it again.
+// Broker errors are logged and retried.
+//
+// Aria equivalent:
+//
+//	fn run(self, ctx: Context, handle: fn(Reply), lost: fn(Task)) with Network
+func (c *Client) Run(ctx context.Context, handle func(*Reply), lost func(*Task)) {
+	for ctx.Err() == nil {
+		r, err := c.b.Receive(ctx, c.opts.Queue, pollTimeout)
+		if err != nil {
+			if ctx.Err() == nil {
+				c.log.Printf("Error receiving replies: %v", err)
+				time.Sleep(pollTimeout)
+			}
+			continue
+		}
+		if r != nil && c.accept(r) {
+			handle(r)
+		}
+		for _, t := range c.expired() {
+			lost(t)
+		}
+	}
+}
+
+// accept records a reply and reports whether it belongs to the current
+// submission of a task.
+func (c *Client) accept(r *Reply) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.pending[r.ID]
+	if !ok || p.task.Token != r.Token {
+		return false
+	}
+	now := time.Now()
+	p.lastSeen = now
+	switch r.Kind {
+	case Started:
+		if p.started.IsZero() {
+			p.started = now
+			s := c.stats[p.task.Priority]
+			wait := now.Sub(p.submitted)
+			s.started++
+			s.waitSum += wait
+			if wait > s.waitMax {
+				s.waitMax = wait
+			}
+		}
+	case Done, Failed:
+		delete(c.pending, r.ID)
+		if !p.started.IsZero() {
+			took := now.Sub(p.started)
+			if c.runAvg == 0 {
+				c.runAvg = took
+			} else {
+				c.runAvg = (4*c.runAvg + took) / 5
+			}
+		}
+	}
+	return true
+}
+
+// expired forgets and returns the running tasks whose leases are over.
+func (c *Client) expired() []*Task {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lost := make([]*Task, 0)
+	for id, p := range c.pending {
+		if !p.started.IsZero() && time.Since(p.lastSeen) > c.opts.Lease {
+			delete(c.pending, id)
+			lost = append(lost, p.task)
+		}
+	}
+	return lost
+}
+
+// slots returns the number of tasks the workers that are up can run at
+// once.
+func (c *Client) slots(ctx context.Context) (int, error) {
+	workers, err := c.b.Workers(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, slots := range workers {
+		n += slots
+	}
+	return n, nil
+}
+
+// RetryAfter estimates how long a client whose task of class p was
+// rejected should wait before trying again, from the number of tasks
+// ahead of it, the workers that are up and recent run times. It is at
+// least one second.
+func (c *Client) RetryAfter(ctx context.Context, p jobqueue.Priority) time.Duration {
+	ahead := 0
+	for _, higher := range []jobqueue.Priority{jobqueue.Interactive, jobqueue.Normal, jobqueue.Batch} {
+		n, err := c.b.Len(ctx, higher)
+		if err != nil {
+			return time.Second
+		}
+		ahead += n
+		if higher == p {
+			break
+		}
+	}
+	slots, err := c.slots(ctx)
+	if err != nil || slots < 1 {
+		slots = 1
+	}
+	c.mu.Lock()
+	wait := c.runAvg * time.Duration(ahead+1) / time.Duration(slots)
+	c.mu.Unlock()
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return wait.Round(time.Second)
+}
+
+// Stats returns the tasks queued in the broker and running per class,
+// with the wait times of the tasks of this client, in the form of a job
+// queue's statistics; Workers counts the slots of the workers that are up.
+func (c *Client) Stats(ctx context.Context) (jobqueue.Stats, error) {
+	st := jobqueue.Stats{MaxQueued: c.opts.MaxQueued, Classes: make(map[string]jobqueue.ClassStats)}
+	var err error
+	if st.Workers, err = c.slots(ctx); err != nil {
+		return st, err
+	}
+	queued := make(map[jobqueue.Priority]int)
+	for p := range c.stats {
+		if queued[p], err = c.b.Len(ctx, p); err != nil {
+			return st, err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	running := make(map[jobqueue.Priority]int)
+	oldest := make(map[jobqueue.Priority]time.Duration)
+	for _, p := range c.pending {
+		prio := p.task.Priority
+		if !p.started.IsZero() {
+			running[prio]++
+		} else if wait := now.Sub(p.submitted); wait > oldest[prio] {
+			oldest[prio] = wait
+		}
+	}
+	for p, s := range c.stats {
+		cs := jobqueue.ClassStats{
+			Queued:     queued[p],
+			Running:    running[p],
+			Started:    s.started,
+			Rejected:   s.rejected,
+			MaxWait:    s.waitMax.Seconds(),
+			OldestWait: oldest[p].Seconds(),
+		}
+		if s.started > 0 {
+			cs.MeanWait = (s.waitSum / time.Duration(s.started)).Seconds()
+		}
+		st.Queued += cs.Queued
+		st.Running += cs.Running
+		st.Classes[p.String()] = cs
+	}
+	return st, nil
+}
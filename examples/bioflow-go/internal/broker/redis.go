@@ -0,0 +1,171 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/aria-lang/bioflow-go/internal/jobqueue"
+)
+
+// keyPrefix starts the names of all keys used by the broker.
+const keyPrefix = "bioflow:"
+
+// replyTTL is how long an unread reply queue is kept, so that the queues
+// of servers that went away do not pile up.
+const replyTTL = 24 * time.Hour
+
+// Redis is a Broker keeping tasks in Redis lists, one per priority class,
+// and replies in one list per reply queue.
+type Redis struct {
+	client *redis.Client
+}
+
+// OpenRedis connects to Redis at a redis:// or rediss:// URL.
+//
+// Aria equivalent:
+//
+//	fn open_redis(url: String) -> Result<Redis, BrokerError> with Network
+func OpenRedis(url string) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("parsing broker URL: %w", err)
+	}
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("connecting to broker: %w", err)
+	}
+	return &Redis{client: client}, nil
+}
+
+// queueKey returns the list of tasks of a class.
+func queueKey(p jobqueue.Priority) string {
+	return keyPrefix + "queue:" + p.String()
+}
+
+// Push queues a task.
+func (r *Redis) Push(ctx context.Context, t *Task) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("encoding task %s: %w", t.ID, err)
+	}
+	return r.client.LPush(ctx, queueKey(t.Priority), data).Err()
+}
+
+// Pop takes the oldest task of the highest priority class, waiting up to
+// timeout (at least a second) for one.
+func (r *Redis) Pop(ctx context.Context, timeout time.Duration) (*Task, error) {
+	keys := []string{queueKey(jobqueue.Interactive), queueKey(jobqueue.Normal), queueKey(jobqueue.Batch)}
+	data, err := r.pop(ctx, timeout, keys...)
+	if data == nil || err != nil {
+		return nil, err
+	}
+	var t Task
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("decoding task: %w", err)
+	}
+	return &t, nil
+}
+
+// pop takes an element from the first non-empty list; it returns nil if
+// they all stay empty for timeout.
+func (r *Redis) pop(ctx context.Context, timeout time.Duration, keys ...string) ([]byte, error) {
+	if timeout < time.Second {
+		timeout = time.Second
+	}
+	res, err := r.client.BRPop(ctx, timeout, keys...).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The result is the key followed by the element.
+	return []byte(res[1]), nil
+}
+
+// Len returns the number of tasks of a class waiting for a worker.
+func (r *Redis) Len(ctx context.Context, p jobqueue.Priority) (int, error) {
+	n, err := r.client.LLen(ctx, queueKey(p)).Result()
+	return int(n), err
+}
+
+// replyKey returns the list of a reply queue.
+func replyKey(queue string) string {
+	return keyPrefix + "replies:" + queue
+}
+
+// Send queues a reply.
+func (r *Redis) Send(ctx context.Context, queue string, reply *Reply) error {
+	data, err := json.Marshal(reply)
+	if err != nil {
+		return fmt.Errorf("encoding reply for %s: %w", reply.ID, err)
+	}
+	pipe := r.client.TxPipeline()
+	pipe.LPush(ctx, replyKey(queue), data)
+	pipe.Expire(ctx, replyKey(queue), replyTTL)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Receive takes the oldest reply of a queue, waiting up to timeout (at
+// least a second) for one.
+func (r *Redis) Receive(ctx context.Context, queue string, timeout time.Duration) (*Reply, error) {
+	data, err := r.pop(ctx, timeout, replyKey(queue))
+	if data == nil || err != nil {
+		return nil, err
+	}
+	var reply Reply
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return nil, fmt.Errorf("decoding reply: %w", err)
+	}
+	return &reply, nil
+}
+
+// workerKey returns the key announcing a worker.
+func workerKey(worker string) string {
+	return keyPrefix + "worker:" + worker
+}
+
+// Announce records a worker as a key expiring after ttl.
+func (r *Redis) Announce(ctx context.Context, worker string, slots int, ttl time.Duration) error {
+	if slots <= 0 {
+		return r.client.Del(ctx, workerKey(worker)).Err()
+	}
+	return r.client.Set(ctx, workerKey(worker), slots, ttl).Err()
+}
+
+// Workers returns the workers whose announcements have not expired.
+func (r *Redis) Workers(ctx context.Context) (map[string]int, error) {
+	workers := make(map[string]int)
+	iter := r.client.Scan(ctx, 0, workerKey("*"), 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		value, err := r.client.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			continue // expired since the scan
+		}
+		if err != nil {
+			return nil, err
+		}
+		slots, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", key, err)
+		}
+		workers[key[len(workerKey("")):]] = slots
+	}
+	return workers, iter.Err()
+}
+
+// Close closes the connection.
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
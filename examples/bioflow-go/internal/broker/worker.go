@@ -0,0 +1,158 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Handler runs a task. It may call progress to report on the task while
+// it runs; it returns the result sent in the Done reply.
+type Handler func(t *Task, progress func(body json.RawMessage)) (json.RawMessage, error)
+
+// WorkerOptions configures Serve.
+type WorkerOptions struct {
+	// Name identifies the worker in replies; it defaults to the host
+	// name and process ID.
+	Name string
+	// Workers is the number of tasks run at once; at least 1.
+	Workers int
+	// Heartbeat is the interval between heartbeats while a task runs, and
+	// between announcements of the worker; it defaults to 5 seconds.
+	Heartbeat time.Duration
+	// ErrorLog receives broker errors; nil means the standard logger.
+	ErrorLog *log.Logger
+}
+
+// pollTimeout bounds how long a worker waits for a task before checking
+// whether it should stop.
+const pollTimeout = time.Second
+
+// Serve runs tasks from the broker with the handler of their kind until
+// ctx is done, then waits for the running tasks to finish. Broker errors
+// are logged and retried, so that workers ride out a broker restart.
+//
+// Aria equivalent:
+//
+//	fn serve(ctx: Context, broker: Broker, options: WorkerOptions,
+//	         handlers: Map<String, Handler>) -> Result<(), BrokerError> with Network
+func Serve(ctx context.Context, b Broker, opts WorkerOptions, handlers map[string]Handler) error {
+	if opts.Name == "" {
+		host, _ := os.Hostname()
+		opts.Name = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	if opts.Heartbeat <= 0 {
+		opts.Heartbeat = 5 * time.Second
+	}
+	logger := opts.ErrorLog
+	if logger == nil {
+		logger = log.Default()
+	}
+	w := &worker{b: b, opts: opts, handlers: handlers, log: logger}
+
+	if err := b.Announce(ctx, opts.Name, opts.Workers, 3*opts.Heartbeat); err != nil {
+		return fmt.Errorf("announcing worker: %w", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+
+	ticker := time.NewTicker(opts.Heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return b.Announce(context.Background(), opts.Name, 0, 0)
+		case <-ticker.C:
+			if err := b.Announce(ctx, opts.Name, opts.Workers, 3*opts.Heartbeat); err != nil && ctx.Err() == nil {
+				logger.Printf("Error announcing worker %s: %v", opts.Name, err)
+			}
+		}
+	}
+}
+
+// worker is the state shared by the task loops of Serve.
+type worker struct {
+	b        Broker
+	opts     WorkerOptions
+	handlers map[string]Handler
+	log      *log.Logger
+}
+
+// loop pops and runs tasks until ctx is done.
+func (w *worker) loop(ctx context.Context) {
+	for ctx.Err() == nil {
+		t, err := w.b.Pop(ctx, pollTimeout)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.log.Printf("Error taking a task: %v", err)
+				time.Sleep(pollTimeout)
+			}
+			continue
+		}
+		if t != nil {
+			w.run(t)
+		}
+	}
+}
+
+// run runs a task, sending heartbeats until it is over. Replies are sent
+// even when Serve is stopping, as the task has been taken off the queue.
+func (w *worker) run(t *Task) {
+	var mu sync.Mutex
+	send := func(kind string, body json.RawMessage, err error) {
+		r := &Reply{ID: t.ID, Token: t.Token, Kind: kind, Worker: w.opts.Name, Body: body}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if err := w.b.Send(context.Background(), t.ReplyTo, r); err != nil {
+			w.log.Printf("Error replying to %s for %s: %v", t.ReplyTo, t.ID, err)
+		}
+	}
+
+	handler, ok := w.handlers[t.Kind]
+	if !ok {
+		send(Failed, nil, fmt.Errorf("worker %s cannot run %q tasks", w.opts.Name, t.Kind))
+		return
+	}
+	send(Started, nil, nil)
+
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(w.opts.Heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				send(Heartbeat, nil, nil)
+			}
+		}
+	}()
+
+	result, err := handler(t, func(body json.RawMessage) { send(Progress, body, nil) })
+	close(stop)
+	<-stopped
+	if err != nil {
+		send(Failed, nil, err)
+		return
+	}
+	send(Done, result, nil)
+}
@@ -0,0 +1,113 @@
+package compress
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// BGZF, written by bgzip and samtools, is gzip cut into independently
+// compressed blocks of at most 64 KiB, each a gzip member whose extra
+// field records its size. Any gzip reader reads it; indexers such as
+// tabix and samtools faidx need it.
+
+// bgzfMagic starts every BGZF block: a gzip header with an extra field
+// holding the "BC" subfield.
+var bgzfMagic = []byte{0x1f, 0x8b, 0x08, 0x04, 0, 0, 0, 0, 0, 0xff, 0x06, 0x00, 'B', 'C'}
+
+// bgzfEOF is the empty block that ends a BGZF file.
+var bgzfEOF = []byte{
+	0x1f, 0x8b, 0x08, 0x04, 0, 0, 0, 0, 0, 0xff, 0x06, 0x00, 'B', 'C', 0x02, 0x00,
+	0x1b, 0x00, 0x03, 0x00, 0, 0, 0, 0, 0, 0, 0, 0,
+}
+
+// bgzfBlockData is how much data goes in a block; it is what bgzip uses,
+// leaving room for incompressible data to fit in 64 KiB.
+const bgzfBlockData = 0xff00
+
+// bgzfWriter compresses data into BGZF blocks.
+type bgzfWriter struct {
+	w     io.Writer
+	level int
+	buf   []byte
+	block bytes.Buffer
+	fw    *flate.Writer
+	err   error
+}
+
+// newBGZFWriter returns a BGZF writer; levels run from 1 (fastest) to 9
+// (smallest), as for gzip.
+func newBGZFWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	if level == 0 {
+		level = flate.DefaultCompression
+	} else if level < flate.BestSpeed || level > flate.BestCompression {
+		return nil, fmt.Errorf("bgzf level %d out of range (1-9)", level)
+	}
+	return &bgzfWriter{w: w, level: level, buf: make([]byte, 0, bgzfBlockData)}, nil
+}
+
+func (b *bgzfWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 && b.err == nil {
+		k := min(len(p), bgzfBlockData-len(b.buf))
+		b.buf = append(b.buf, p[:k]...)
+		p, n = p[k:], n+k
+		if len(b.buf) == bgzfBlockData {
+			b.flush()
+		}
+	}
+	return n, b.err
+}
+
+// flush writes the buffered data as one block.
+func (b *bgzfWriter) flush() {
+	if b.err != nil || len(b.buf) == 0 {
+		return
+	}
+	b.block.Reset()
+	b.block.Write(bgzfMagic)
+	b.block.Write([]byte{0x02, 0x00, 0, 0}) // subfield length, then block size
+	if b.fw == nil {
+		b.fw, b.err = flate.NewWriter(&b.block, b.level)
+	} else {
+		b.fw.Reset(&b.block)
+	}
+	if b.err == nil {
+		b.fw.Write(b.buf)
+		b.err = b.fw.Close()
+	}
+	if b.block.Len()+8 > 1<<16 {
+		// Incompressible data is stored instead.
+		b.block.Truncate(18)
+		stored, _ := flate.NewWriter(&b.block, flate.NoCompression)
+		stored.Write(b.buf)
+		stored.Close()
+	}
+	binary.Write(&b.block, binary.LittleEndian, crc32.ChecksumIEEE(b.buf))
+	binary.Write(&b.block, binary.LittleEndian, uint32(len(b.buf)))
+	data := b.block.Bytes()
+	binary.LittleEndian.PutUint16(data[16:], uint16(len(data)-1))
+	if b.err == nil {
+		_, b.err = b.w.Write(data)
+	}
+	b.buf = b.buf[:0]
+}
+
+// Close writes the last block and the end-of-file marker.
+func (b *bgzfWriter) Close() error {
+	b.flush()
+	if b.err == nil {
+		_, b.err = b.w.Write(bgzfEOF)
+	}
+	return b.err
+}
+
+// newBGZFReader returns a reader of BGZF data, which is gzip with one
+// member per block.
+func newBGZFReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
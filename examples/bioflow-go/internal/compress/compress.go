@@ -0,0 +1,291 @@
+// Package compress knows the compression formats of sequence files and
+// saved artifacts. gzip, its blocked variant BGZF and zstd are built in;
+// zstd is now common in pipelines because it is several times faster than
+// gzip at a similar ratio. Other formats can be added with Register.
+//
+// Writers choose a format by name or file extension (.gz, .bgz, .zst); readers
+// recognize compressed data by its magic bytes, whatever the file is
+// called, and pass uncompressed data through.
+//
+// Comparison with Aria:
+//
+//	Aria would describe a codec as a trait implemented by each format:
+//	  trait Codec
+//	    fn writer(w: Writer, level: Int) -> Result<Writer, IoError>
+//	    fn reader(r: Reader) -> Result<Reader, IoError>
+//
+//	Go keeps the formats in a registry of Codec values instead.
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// None is the name of uncompressed data.
+const None = "none"
+
+// Codec is a compression format. Magic is the prefix of compressed data,
+// at most 16 bytes, by which readers recognize it.
+type Codec struct {
+	Name       string
+	Extensions []string
+	Magic      []byte
+	// NewWriter returns a compressing writer at the given level; zero
+	// means the codec's default level.
+	NewWriter func(w io.Writer, level int) (io.WriteCloser, error)
+	// NewReader returns a decompressing reader.
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+var codecs = struct {
+	sync.Mutex
+	byName map[string]Codec
+	byExt  map[string]string
+}{
+	byName: map[string]Codec{
+		"gzip": {
+			Name:       "gzip",
+			Extensions: []string{".gz"},
+			Magic:      []byte{0x1f, 0x8b},
+			NewWriter:  newGzipWriter,
+			NewReader:  func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+		},
+		"bgzf": {
+			Name:       "bgzf",
+			Extensions: []string{".bgz"},
+			Magic:      bgzfMagic,
+			NewWriter:  newBGZFWriter,
+			NewReader:  newBGZFReader,
+		},
+		"zstd": {
+			Name:       "zstd",
+			Extensions: []string{".zst"},
+			Magic:      []byte{0x28, 0xb5, 0x2f, 0xfd},
+			NewWriter:  newZstdWriter,
+			NewReader:  newZstdReader,
+		},
+	},
+	byExt: map[string]string{".gz": "gzip", ".bgz": "bgzf", ".zst": "zstd"},
+}
+
+// newGzipWriter returns a gzip writer; levels run from 1 (fastest) to 9
+// (smallest).
+func newGzipWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	} else if level < gzip.BestSpeed || level > gzip.BestCompression {
+		return nil, fmt.Errorf("gzip level %d out of range (1-9)", level)
+	}
+	return gzip.NewWriterLevel(w, level)
+}
+
+// newZstdWriter returns a zstd writer; levels run from 1 (fastest) to 22
+// (smallest), as for the zstd command, and default to 3.
+func newZstdWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	if level == 0 {
+		level = 3
+	} else if level < 1 || level > 22 {
+		return nil, fmt.Errorf("zstd level %d out of range (1-22)", level)
+	}
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+}
+
+// newZstdReader returns a zstd reader. Decoding runs in the calling
+// goroutine, which is fastest for the sequential reads of parsers.
+func newZstdReader(r io.Reader) (io.ReadCloser, error) {
+	d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return d.IOReadCloser(), nil
+}
+
+// Register adds a compression codec, typically from a plugin.
+//
+// Aria equivalent:
+//
+//	fn register(codec: Codec) -> Result<(), CompressError>
+//	  requires codec.name.len() > 0
+func Register(c Codec) error {
+	codecs.Lock()
+	defer codecs.Unlock()
+	c.Name = strings.ToLower(c.Name)
+	if c.Name == "" || c.Name == None || c.Name == "auto" || c.NewWriter == nil || c.NewReader == nil {
+		return fmt.Errorf("invalid codec %q", c.Name)
+	}
+	if _, dup := codecs.byName[c.Name]; dup {
+		return fmt.Errorf("codec %q already registered", c.Name)
+	}
+	codecs.byName[c.Name] = c
+	for _, ext := range c.Extensions {
+		codecs.byExt[strings.ToLower(ext)] = c.Name
+	}
+	return nil
+}
+
+// Codecs returns the names of the available codecs, sorted.
+func Codecs() []string {
+	codecs.Lock()
+	defer codecs.Unlock()
+	return sortedNames()
+}
+
+// sortedNames returns the codec names; codecs must be locked.
+func sortedNames() []string {
+	names := make([]string, 0, len(codecs.byName))
+	for name := range codecs.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the codec for a name, or nil for None.
+func Lookup(name string) (*Codec, error) {
+	if name == None {
+		return nil, nil
+	}
+	codecs.Lock()
+	defer codecs.Unlock()
+	c, ok := codecs.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("%s compression is not available (available: %s, none)", name, strings.Join(sortedNames(), ", "))
+	}
+	return &c, nil
+}
+
+// ForPath returns the compression a file name implies, or None.
+func ForPath(path string) string {
+	codecs.Lock()
+	defer codecs.Unlock()
+	if name, ok := codecs.byExt[strings.ToLower(filepath.Ext(path))]; ok {
+		return name
+	}
+	return None
+}
+
+// TrimExt returns path without a compression extension, so that
+// "reads.fa.gz" can be recognized as FASTA.
+func TrimExt(path string) string {
+	if ForPath(path) == None {
+		return path
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path))
+}
+
+// Detect returns the codec whose magic bytes start data, or nil. When
+// several match, as gzip and BGZF do, the longest magic wins.
+func Detect(data []byte) *Codec {
+	codecs.Lock()
+	defer codecs.Unlock()
+	var found *Codec
+	for _, name := range sortedNames() {
+		c := codecs.byName[name]
+		if len(c.Magic) > 0 && bytes.HasPrefix(data, c.Magic) && (found == nil || len(c.Magic) > len(found.Magic)) {
+			found = &c
+		}
+	}
+	return found
+}
+
+// NewReader returns a reader of the decompressed data of r, or of r
+// itself if it is not compressed.
+//
+// Aria equivalent:
+//
+//	fn new_reader(r: Reader) -> Result<Reader, CompressError>
+func NewReader(r io.Reader) (io.ReadCloser, error) {
+	rc, _, err := decompress(r)
+	return rc, err
+}
+
+// decompress returns a reader of the decompressed data of r and its
+// codec name.
+func decompress(r io.Reader) (io.ReadCloser, string, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
+	magic, _ := br.Peek(16)
+	c := Detect(magic)
+	if c == nil {
+		return io.NopCloser(br), None, nil
+	}
+	zr, err := c.NewReader(br)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading %s data: %w", c.Name, err)
+	}
+	return zr, c.Name, nil
+}
+
+// File is an open, possibly compressed, input file.
+type File struct {
+	io.ReadCloser
+	file  *os.File
+	codec string
+}
+
+// Open opens a file for reading, decompressing it if it is compressed.
+func Open(path string) (*File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	rc, codec, err := decompress(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &File{ReadCloser: rc, file: file, codec: codec}, nil
+}
+
+// Codec returns the compression of the file, or None.
+func (f *File) Codec() string {
+	return f.codec
+}
+
+// Stat returns the file info of the underlying (compressed) file.
+func (f *File) Stat() (os.FileInfo, error) {
+	return f.file.Stat()
+}
+
+// SeekTo moves to an offset in the decompressed data, counted from the
+// start of the file. Uncompressed files seek directly; compressed data is
+// decompressed up to offset and discarded, so SeekTo should be called
+// before reading.
+func (f *File) SeekTo(offset int64) error {
+	if f.codec == None {
+		if info, err := f.file.Stat(); err == nil && offset > info.Size() {
+			return io.ErrUnexpectedEOF
+		}
+		if _, err := f.file.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		f.ReadCloser = io.NopCloser(bufio.NewReaderSize(f.file, 64*1024))
+		return nil
+	}
+	if _, err := io.CopyN(io.Discard, f.ReadCloser, offset); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return nil
+}
+
+// Close closes the decompressor and the file.
+func (f *File) Close() error {
+	err := f.ReadCloser.Close()
+	if cerr := f.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
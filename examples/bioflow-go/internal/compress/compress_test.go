@@ -0,0 +1,197 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fasta = ">s1 test\nACGTACGTACGT\n>s2\nGGGGCCCC\n"
+
+// compressed returns data compressed with a codec.
+func compressed(t *testing.T, name string, level int, data string) []byte {
+	c, err := Lookup(name)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	w, err := c.NewWriter(&buf, level)
+	require.NoError(t, err)
+	_, err = io.WriteString(w, data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, name := range []string{"gzip", "bgzf", "zstd"} {
+		for _, level := range []int{0, 1, 9} {
+			data := compressed(t, name, level, fasta)
+			assert.Equal(t, name, Detect(data).Name)
+
+			r, err := NewReader(bytes.NewReader(data))
+			require.NoError(t, err, name)
+			got, err := io.ReadAll(r)
+			require.NoError(t, err, name)
+			assert.Equal(t, fasta, string(got), name)
+		}
+	}
+
+	// Concatenated members, as written by pigz -i and pzstd, read as one.
+	for _, name := range []string{"gzip", "zstd"} {
+		data := append(compressed(t, name, 0, ">a\nAC\n"), compressed(t, name, 0, ">b\nGT\n")...)
+		r, err := NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		got, _ := io.ReadAll(r)
+		assert.Equal(t, ">a\nAC\n>b\nGT\n", string(got), name)
+	}
+
+	r, err := NewReader(strings.NewReader(fasta))
+	require.NoError(t, err)
+	got, _ := io.ReadAll(r)
+	assert.Equal(t, fasta, string(got), "uncompressed data passes through")
+	assert.Nil(t, Detect([]byte{0x1f}), "short input")
+
+	c, _ := Lookup("gzip")
+	_, err = c.NewWriter(io.Discard, 10)
+	assert.Error(t, err)
+	c, _ = Lookup("zstd")
+	_, err = c.NewWriter(io.Discard, 23)
+	assert.Error(t, err)
+	_, err = Lookup("lz4")
+	assert.Error(t, err)
+}
+
+func TestBGZF(t *testing.T) {
+	// Enough data for several blocks, some of it incompressible.
+	var data bytes.Buffer
+	for data.Len() < 3*bgzfBlockData {
+		data.WriteString(fasta)
+	}
+	noise := make([]byte, bgzfBlockData+100)
+	for i := range noise {
+		noise[i] = byte(i*7919 + i*i*31 + i>>3)
+	}
+	data.Write(noise)
+
+	out := compressed(t, "bgzf", 0, data.String())
+	assert.Equal(t, "bgzf", Detect(out).Name)
+	assert.Equal(t, "gzip", Detect(compressed(t, "gzip", 0, fasta)).Name)
+	assert.True(t, bytes.HasSuffix(out, bgzfEOF))
+
+	// Every block is a gzip member recording its own size.
+	blocks := 0
+	for rest := out; len(rest) > 0; blocks++ {
+		require.True(t, bytes.HasPrefix(rest, bgzfMagic))
+		size := int(rest[16]) | int(rest[17])<<8 + 1
+		require.LessOrEqual(t, size, 1<<16)
+		zr, err := gzip.NewReader(bytes.NewReader(rest[:size]))
+		require.NoError(t, err)
+		zr.Multistream(false)
+		block, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(block), bgzfBlockData)
+		rest = rest[size:]
+	}
+	assert.Equal(t, 6, blocks, "five data blocks and the EOF marker")
+
+	r, err := NewReader(bytes.NewReader(out))
+	require.NoError(t, err)
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data.Bytes(), got)
+
+	// An empty file is just the EOF marker.
+	assert.Equal(t, bgzfEOF, compressed(t, "bgzf", 0, ""))
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	// Compression is recognized by content, not by name.
+	path := filepath.Join(dir, "reads.fa")
+	require.NoError(t, os.WriteFile(path, compressed(t, "zstd", 0, fasta), 0o644))
+	f, err := Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, "zstd", f.Codec())
+	info, err := f.Stat()
+	require.NoError(t, err)
+	assert.Less(t, info.Size(), int64(len(fasta)*2))
+	got, _ := io.ReadAll(f)
+	assert.Equal(t, fasta, string(got))
+	require.NoError(t, f.Close())
+
+	require.NoError(t, os.WriteFile(path, []byte(fasta), 0o644))
+	f, err = Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, None, f.Codec())
+	f.Close()
+
+	// Resuming part way through works with and without compression.
+	for name, data := range map[string][]byte{"none": []byte(fasta), "gzip": compressed(t, "gzip", 0, fasta)} {
+		require.NoError(t, os.WriteFile(path, data, 0o644))
+		f, err := Open(path)
+		require.NoError(t, err)
+		require.NoError(t, f.SeekTo(22), name)
+		got, _ := io.ReadAll(f)
+		assert.Equal(t, ">s2\nGGGGCCCC\n", string(got), name)
+		assert.Error(t, f.SeekTo(1000), name)
+		f.Close()
+	}
+
+	_, err = Open(filepath.Join(dir, "missing.fa"))
+	assert.Error(t, err)
+	require.NoError(t, os.WriteFile(path, []byte{0x28, 0xb5, 0x2f, 0xfd, 0, 0}, 0o644))
+	if f, err := Open(path); err == nil {
+		_, err = io.ReadAll(f)
+		f.Close()
+		assert.Error(t, err, "corrupt data")
+	}
+}
+
+func TestNames(t *testing.T) {
+	assert.Equal(t, "gzip", ForPath("A.FQ.GZ"))
+	assert.Equal(t, "zstd", ForPath("a.fq.zst"))
+	assert.Equal(t, None, ForPath("a.fq"))
+	assert.Equal(t, "a.fa", TrimExt("a.fa.zst"))
+	assert.Equal(t, "a.fa", TrimExt("a.fa"))
+	assert.Equal(t, "bgzf", ForPath("a.vcf.bgz"))
+	assert.Equal(t, "a.fa", TrimExt("a.fa.bgz"))
+	assert.Equal(t, []string{"bgzf", "gzip", "zstd"}, Codecs())
+}
+
+// upper is a toy codec for testing registration.
+type upper struct{ w io.Writer }
+
+func (u upper) Write(p []byte) (int, error) { return u.w.Write(bytes.ToUpper(p)) }
+func (u upper) Close() error                { return nil }
+
+func TestRegister(t *testing.T) {
+	codec := Codec{
+		Name:       "test-upper",
+		Extensions: []string{".up"},
+		Magic:      []byte("UP:"),
+		NewWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			return upper{w}, nil
+		},
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(r), nil
+		},
+	}
+	require.NoError(t, Register(codec))
+	defer func() {
+		delete(codecs.byName, codec.Name)
+		delete(codecs.byExt, ".up")
+	}()
+	assert.Contains(t, Codecs(), "test-upper")
+	assert.Equal(t, "test-upper", ForPath("x.up"))
+	assert.Equal(t, "test-upper", Detect([]byte("UP:acgt")).Name)
+
+	assert.Error(t, Register(codec), "already registered")
+	assert.Error(t, Register(Codec{Name: None, NewWriter: codec.NewWriter, NewReader: codec.NewReader}))
+	assert.Error(t, Register(Codec{Name: "no-reader", NewWriter: codec.NewWriter}))
+}
@@ -0,0 +1,166 @@
+// Package data holds the built-in data tables of BioFlow: genetic codes,
+// codon usage tables, adapter sets, restriction enzymes and substitution
+// matrices. The tables are embedded in the binary, so it runs offline and
+// in minimal containers without data files beside it.
+//
+// A directory named by the BIOFLOW_DATA_DIR environment variable
+// overrides them: a file there replaces the built-in table of the same
+// name, and new files add tables, such as a codon usage table of another
+// organism. The directory mirrors the layout of the embedded tables:
+//
+//	genetic-codes/standard.txt   NCBI translation tables (AAs and Base1-3 lines)
+//	codon-usage/ecoli.txt        codon usage, "codon value" pairs
+//	adapters/truseq.fa           adapter sets, as FASTA
+//	enzymes.tsv                  restriction enzymes, name and site
+//	matrices/BLOSUM62            substitution matrices, in the NCBI format
+//
+// Tables are named by their file name without extension, matched
+// case-insensitively.
+//
+// Comparison with Aria:
+//
+//	Aria would include the tables as resources of the module, typed by
+//	their parser at compile time:
+//	  const BLOSUM62: SubstitutionMatrix = include!("matrices/BLOSUM62")
+//
+//	Go embeds them as files with embed.FS and parses them when looked
+//	up, which is also what lets a directory override them.
+package data
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// EnvDir is the environment variable naming the directory that
+// overrides the built-in tables.
+const EnvDir = "BIOFLOW_DATA_DIR"
+
+// ErrNotFound is returned for a table that is neither built in nor in
+// the override directory.
+var ErrNotFound = errors.New("no such table")
+
+// tables holds the built-in tables.
+//
+//go:embed tables
+var tables embed.FS
+
+// dir is the directory set with SetDir.
+var dir string
+
+// SetDir sets the directory overriding the built-in tables, in place of
+// the environment variable. It is meant to be called on start, before
+// tables are looked up.
+func SetDir(path string) {
+	dir = path
+}
+
+// Dir returns the directory overriding the built-in tables, or "" for
+// none.
+func Dir() string {
+	if dir != "" {
+		return dir
+	}
+	return os.Getenv(EnvDir)
+}
+
+// ReadFile returns a table by its path, such as "enzymes.tsv", from the
+// override directory if it has it, or else the built-in one.
+//
+// Aria equivalent:
+//
+//	fn read_file(name: String) -> Result<Bytes, DataError> with FileSystem
+//	  ensures Dir().is_empty() implies result == embedded(name)
+func ReadFile(name string) ([]byte, error) {
+	if override := Dir(); override != "" {
+		content, err := os.ReadFile(filepath.Join(override, filepath.FromSlash(name)))
+		if err == nil {
+			return content, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+	}
+	return Embedded(name)
+}
+
+// Embedded returns a built-in table by its path, ignoring the override
+// directory, for tables that are loaded once on start.
+func Embedded(name string) ([]byte, error) {
+	content, err := tables.ReadFile(path.Join("tables", name))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return content, nil
+}
+
+// Lookup returns a table of a directory of tables, such as "matrices",
+// by its case-insensitive name, and the name of its file without
+// extension.
+//
+// Aria equivalent:
+//
+//	fn lookup(dir: String, name: String) -> Result<(String, Bytes), DataError> with FileSystem
+//	  ensures result.is_ok() implies result.unwrap().0.lower() == name.lower()
+func Lookup(table, name string) (string, []byte, error) {
+	files, err := list(table)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, file := range files {
+		base := strings.TrimSuffix(file, path.Ext(file))
+		if strings.EqualFold(base, name) {
+			content, err := ReadFile(path.Join(table, file))
+			return base, content, err
+		}
+	}
+	return "", nil, fmt.Errorf("%w: %s/%s", ErrNotFound, table, name)
+}
+
+// Names returns the names of the tables of a directory, built-in and
+// overriding, sorted.
+func Names(table string) []string {
+	files, _ := list(table)
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = strings.TrimSuffix(file, path.Ext(file))
+	}
+	return names
+}
+
+// list returns the file names of a directory of tables, built-in and
+// overriding, sorted. A file of the override directory hides a built-in
+// file with the same name, whatever its extension and case.
+func list(table string) ([]string, error) {
+	byName := make(map[string]string)
+	entries, _ := tables.ReadDir(path.Join("tables", table))
+	for _, e := range entries {
+		if !e.IsDir() {
+			byName[strings.ToLower(strings.TrimSuffix(e.Name(), path.Ext(e.Name())))] = e.Name()
+		}
+	}
+	if override := Dir(); override != "" {
+		entries, err := os.ReadDir(filepath.Join(override, filepath.FromSlash(table)))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("listing %s: %w", table, err)
+		}
+		for _, e := range entries {
+			if !e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+				byName[strings.ToLower(strings.TrimSuffix(e.Name(), path.Ext(e.Name())))] = e.Name()
+			}
+		}
+	}
+	files := make([]string, 0, len(byName))
+	for _, file := range byName {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return strings.ToLower(files[i]) < strings.ToLower(files[j]) })
+	return files, nil
+}
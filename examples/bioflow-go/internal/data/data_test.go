@@ -0,0 +1,68 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinTables(t *testing.T) {
+	t.Setenv(EnvDir, "")
+	assert.Equal(t, []string{"BLOSUM62", "BLOSUM80", "PAM250"}, Names("matrices"))
+	assert.Equal(t, []string{"ecoli", "human"}, Names("codon-usage"))
+	assert.Equal(t, []string{"fastqc", "nextera", "truseq"}, Names("adapters"))
+	assert.Contains(t, Names("genetic-codes"), "standard")
+	assert.Empty(t, Names("nothing"))
+
+	name, content, err := Lookup("matrices", "blosum62")
+	require.NoError(t, err)
+	assert.Equal(t, "BLOSUM62", name)
+	assert.Contains(t, string(content), "BLOSUM Clustered Scoring Matrix")
+
+	_, _, err = Lookup("matrices", "BLOSUM45")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	content, err = ReadFile("enzymes.tsv")
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "EcoRI\tGAATTC")
+	_, err = ReadFile("nothing.tsv")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestOverrideDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "matrices"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "matrices", "blosum62.txt"), []byte("# mine\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "matrices", "BLOSUM45"), []byte("# added\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "matrices", ".hidden"), nil, 0o644))
+	t.Setenv(EnvDir, dir)
+	assert.Equal(t, dir, Dir())
+
+	assert.Equal(t, []string{"BLOSUM45", "blosum62", "BLOSUM80", "PAM250"}, Names("matrices"),
+		"files of the directory replace built-in ones of any case and extension")
+	name, content, err := Lookup("matrices", "BLOSUM62")
+	require.NoError(t, err)
+	assert.Equal(t, "blosum62", name)
+	assert.Equal(t, "# mine\n", string(content))
+	_, content, err = Lookup("matrices", "blosum45")
+	require.NoError(t, err)
+	assert.Equal(t, "# added\n", string(content))
+	_, content, err = Lookup("matrices", "PAM250")
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "pam")
+
+	builtin, err := Embedded("matrices/BLOSUM62")
+	require.NoError(t, err)
+	assert.Contains(t, string(builtin), "BLOSUM Clustered Scoring Matrix", "embedded tables ignore the directory")
+
+	other := t.TempDir()
+	SetDir(other)
+	defer SetDir("")
+	assert.Equal(t, other, Dir(), "SetDir takes precedence over the environment")
+	_, content, err = Lookup("matrices", "BLOSUM62")
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "BLOSUM Clustered Scoring Matrix")
+}
@@ -0,0 +1,401 @@
+// Package faidx indexes FASTA files for random access, with the .fai
+// index format of samtools faidx.
+//
+// An index records, for each sequence, where its bases start in the file
+// and how its lines are laid out, so that any region can be read with a
+// single seek without parsing the rest of the file. It needs every line of
+// a sequence but the last to hold the same number of bases.
+//
+// Comparison with Aria:
+//
+//	Aria can state the bounds of a fetch in its signature:
+//	  fn fetch(index: FastaIndex, name: String, start: Int, end: Int) -> Result<String, FaidxError>
+//	    requires start >= 0 and start <= end
+//
+//	Go checks the region at runtime and returns an error.
+package faidx
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/compress"
+)
+
+// Entry is the index of one sequence: one line of a .fai file.
+type Entry struct {
+	Name   string `json:"name"`
+	Length int64  `json:"length"`
+	// Offset is the byte offset of the first base in the file.
+	Offset int64 `json:"offset"`
+	// LineBases is the number of bases on each line, and LineWidth the
+	// number of bytes, including the line ending.
+	LineBases int `json:"line_bases"`
+	LineWidth int `json:"line_width"`
+}
+
+// position returns the byte offset of base pos of the sequence.
+func (e Entry) position(pos int64) int64 {
+	if e.LineBases == 0 {
+		return e.Offset
+	}
+	return e.Offset + pos/int64(e.LineBases)*int64(e.LineWidth) + pos%int64(e.LineBases)
+}
+
+// Build indexes FASTA data. It fails on lines of a sequence that differ in
+// length other than the last, and on sequence names used twice.
+//
+// Aria equivalent:
+//
+//	fn build(reader: Reader) -> Result<[Entry], FaidxError>
+//	  ensures result.all(|e| e.line_width >= e.line_bases)
+func Build(r io.Reader) ([]Entry, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
+	entries := make([]Entry, 0)
+	seen := make(map[string]bool)
+	var (
+		offset  int64
+		lineNum int
+		current *Entry
+		// ended is set once a line shorter than the first ends the
+		// sequence; only blank lines may follow it.
+		ended bool
+	)
+	for {
+		line, err := br.ReadSlice('\n')
+		var header []byte
+		if len(line) > 0 && line[0] == '>' {
+			header = append(header, line...)
+		}
+		width := len(line)
+		for err == bufio.ErrBufferFull {
+			line, err = br.ReadSlice('\n')
+			width += len(line)
+		}
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("reading FASTA: %w", err)
+		}
+		if width == 0 {
+			break
+		}
+		lineNum++
+		start := offset
+		offset += int64(width)
+
+		bases := width
+		if bytes.HasSuffix(line, []byte("\n")) {
+			bases--
+			if bytes.HasSuffix(line, []byte("\r\n")) {
+				bases--
+			}
+		}
+
+		if header != nil {
+			name := strings.Fields(string(header[1:]))
+			if len(name) == 0 {
+				return nil, fmt.Errorf("line %d: sequence without a name", lineNum)
+			}
+			if seen[name[0]] {
+				return nil, fmt.Errorf("line %d: duplicate sequence name %q", lineNum, name[0])
+			}
+			seen[name[0]] = true
+			entries = append(entries, Entry{Name: name[0], Offset: offset})
+			current, ended = &entries[len(entries)-1], false
+			continue
+		}
+		if current == nil {
+			if bases == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: sequence data before the first header", lineNum)
+		}
+		if bases == 0 {
+			ended = current.LineBases > 0
+			continue
+		}
+		switch {
+		case current.LineBases == 0:
+			current.Offset, current.LineBases, current.LineWidth = start, bases, width
+		case ended || bases > current.LineBases || (bases == current.LineBases && width != current.LineWidth && err == nil):
+			return nil, fmt.Errorf("line %d: sequence %q has lines of different lengths", lineNum, current.Name)
+		}
+		if bases < current.LineBases {
+			ended = true
+		}
+		current.Length += int64(bases)
+		if err == io.EOF {
+			break
+		}
+	}
+	return entries, nil
+}
+
+// WriteIndex writes entries as a .fai file.
+func WriteIndex(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range entries {
+		fmt.Fprintf(bw, "%s\t%d\t%d\t%d\t%d\n", e.Name, e.Length, e.Offset, e.LineBases, e.LineWidth)
+	}
+	return bw.Flush()
+}
+
+// ReadIndex reads a .fai file.
+func ReadIndex(r io.Reader) ([]Entry, error) {
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		if scanner.Text() == "" {
+			continue
+		}
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("index line %d: expected 5 fields, got %d", lineNum, len(fields))
+		}
+		e := Entry{Name: fields[0]}
+		var errs [4]error
+		e.Length, errs[0] = strconv.ParseInt(fields[1], 10, 64)
+		e.Offset, errs[1] = strconv.ParseInt(fields[2], 10, 64)
+		e.LineBases, errs[2] = strconv.Atoi(fields[3])
+		e.LineWidth, errs[3] = strconv.Atoi(fields[4])
+		if err := errors.Join(errs[:]...); err != nil || e.Length < 0 || e.Offset < 0 || e.LineBases < 0 || e.LineWidth < e.LineBases {
+			return nil, fmt.Errorf("index line %d: invalid entry for %q", lineNum, e.Name)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading index: %w", err)
+	}
+	return entries, nil
+}
+
+// IndexPath returns the path of the index of a FASTA file.
+func IndexPath(path string) string {
+	return path + ".fai"
+}
+
+// BuildFile indexes a FASTA file and writes its index next to it.
+// Compressed files cannot be indexed.
+//
+// Aria equivalent:
+//
+//	fn build_file(path: Path) -> Result<[Entry], FaidxError> with FileSystem
+func BuildFile(path string) ([]Entry, error) {
+	f, err := openUncompressed(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	entries, err := Build(f)
+	if err != nil {
+		return nil, fmt.Errorf("indexing %s: %w", path, err)
+	}
+	out, err := os.Create(IndexPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("writing index: %w", err)
+	}
+	if err := WriteIndex(out, entries); err != nil {
+		out.Close()
+		return nil, fmt.Errorf("writing index: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return nil, fmt.Errorf("writing index: %w", err)
+	}
+	return entries, nil
+}
+
+// openUncompressed opens a file, failing if its data is compressed.
+func openUncompressed(path string) (*os.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	magic := make([]byte, 16)
+	n, _ := io.ReadFull(f, magic)
+	if c := compress.Detect(magic[:n]); c != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s is %s-compressed; random access needs an uncompressed FASTA file", path, c.Name)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// FastaIndex reads regions of an indexed FASTA file. It is safe for
+// concurrent use.
+type FastaIndex struct {
+	file    *os.File
+	entries []Entry
+	byName  map[string]int
+}
+
+// Open opens a FASTA file with its .fai index, building the index, and
+// writing it next to the file, if there is none.
+//
+// Aria equivalent:
+//
+//	fn open(path: Path) -> Result<FastaIndex, FaidxError> with FileSystem
+func Open(path string) (*FastaIndex, error) {
+	var entries []Entry
+	idx, err := os.Open(IndexPath(path))
+	switch {
+	case err == nil:
+		entries, err = ReadIndex(idx)
+		idx.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", IndexPath(path), err)
+		}
+	case errors.Is(err, os.ErrNotExist):
+		if entries, err = BuildFile(path); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("opening index: %w", err)
+	}
+	f, err := openUncompressed(path)
+	if err != nil {
+		return nil, err
+	}
+	x := &FastaIndex{file: f, entries: entries, byName: make(map[string]int, len(entries))}
+	for i, e := range entries {
+		x.byName[e.Name] = i
+	}
+	return x, nil
+}
+
+// Entries returns the index entries, in file order.
+func (x *FastaIndex) Entries() []Entry {
+	return x.entries
+}
+
+// Entry returns the index entry of a sequence.
+func (x *FastaIndex) Entry(name string) (Entry, bool) {
+	i, ok := x.byName[name]
+	if !ok {
+		return Entry{}, false
+	}
+	return x.entries[i], true
+}
+
+// Fetch returns bases start to end (0-based, half-open) of a sequence; an
+// end past the sequence is taken as its end.
+//
+// Aria equivalent:
+//
+//	fn fetch(self, name: String, start: Int, end: Int) -> Result<String, FaidxError>
+//	  requires start >= 0 and start <= end
+//	  ensures result.len() <= end - start
+func (x *FastaIndex) Fetch(name string, start, end int64) (string, error) {
+	e, ok := x.Entry(name)
+	if !ok {
+		return "", fmt.Errorf("sequence %q is not in the index", name)
+	}
+	if start < 0 || end < start {
+		return "", fmt.Errorf("invalid region %d-%d", start, end)
+	}
+	end = min(end, e.Length)
+	if start >= end {
+		return "", nil
+	}
+	from, to := e.position(start), e.position(end-1)+1
+	buf := make([]byte, to-from)
+	if _, err := x.file.ReadAt(buf, from); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", fmt.Errorf("reading %s: %w", name, err)
+	}
+	bases := buf[:0]
+	for _, c := range buf {
+		if c != '\n' && c != '\r' {
+			bases = append(bases, c)
+		}
+	}
+	if int64(len(bases)) != end-start {
+		return "", fmt.Errorf("index does not match the file at %s:%d", name, start+1)
+	}
+	return string(bases), nil
+}
+
+// FetchRegion returns the bases of a region.
+func (x *FastaIndex) FetchRegion(r Region) (string, error) {
+	end := r.End
+	if end < 0 {
+		e, ok := x.Entry(r.Name)
+		if !ok {
+			return "", fmt.Errorf("sequence %q is not in the index", r.Name)
+		}
+		end = e.Length
+	}
+	return x.Fetch(r.Name, r.Start, end)
+}
+
+// Close closes the FASTA file.
+func (x *FastaIndex) Close() error {
+	return x.file.Close()
+}
+
+// Region is a region of a sequence, 0-based and half-open. An End of -1
+// runs to the end of the sequence.
+type Region struct {
+	Name  string `json:"name"`
+	Start int64  `json:"start"`
+	End   int64  `json:"end"`
+}
+
+// String formats the region as samtools does, 1-based and inclusive.
+func (r Region) String() string {
+	if r.End < 0 {
+		if r.Start == 0 {
+			return r.Name
+		}
+		return fmt.Sprintf("%s:%d", r.Name, r.Start+1)
+	}
+	return fmt.Sprintf("%s:%d-%d", r.Name, r.Start+1, r.End)
+}
+
+// ParseRegion parses a samtools region: "name", "name:start" or
+// "name:start-end", 1-based and inclusive, with optional thousands
+// separators.
+//
+// Aria equivalent:
+//
+//	fn parse_region(s: String) -> Result<Region, FaidxError>
+func ParseRegion(s string) (Region, error) {
+	// Names may contain colons; a span follows the last one.
+	name, span, ok := s, "", false
+	if i := strings.LastIndexByte(s, ':'); i >= 0 {
+		name, span, ok = s[:i], s[i+1:], true
+	}
+	if name == "" {
+		return Region{}, fmt.Errorf("region %q has no sequence name", s)
+	}
+	r := Region{Name: name, End: -1}
+	if !ok {
+		return r, nil
+	}
+	span = strings.ReplaceAll(span, ",", "")
+	from, to, hasEnd := strings.Cut(span, "-")
+	start, err := strconv.ParseInt(from, 10, 64)
+	if err != nil || start < 1 {
+		return Region{}, fmt.Errorf("region %q: invalid start", s)
+	}
+	r.Start = start - 1
+	if hasEnd {
+		end, err := strconv.ParseInt(to, 10, 64)
+		if err != nil || end < start {
+			return Region{}, fmt.Errorf("region %q: invalid end", s)
+		}
+		r.End = end
+	}
+	return r, nil
+}
@@ -0,0 +1,111 @@
+package faidx
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fasta = ">chr1 first\nACGTA\nCGTAC\nGT\n>chr2\nTTTT\nGG\n\n>empty\n>chr3\r\nAAC\r\nGT\r\n"
+
+func TestBuild(t *testing.T) {
+	entries, err := Build(strings.NewReader(fasta))
+	require.NoError(t, err)
+	// The index samtools faidx writes for the same file.
+	var buf bytes.Buffer
+	require.NoError(t, WriteIndex(&buf, entries))
+	assert.Equal(t, "chr1\t12\t12\t5\t6\n"+
+		"chr2\t6\t33\t4\t5\n"+
+		"empty\t0\t49\t0\t0\n"+
+		"chr3\t5\t56\t3\t5\n", buf.String())
+
+	read, err := ReadIndex(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, entries, read)
+
+	for name, data := range map[string]string{
+		"ragged":     ">a\nACGT\nAC\nACGT\n",
+		"long":       ">a\nAC\nACGT\n",
+		"gap":        ">a\nACGT\n\nACGT\n",
+		"duplicate":  ">a\nAC\n>a\nGT\n",
+		"headerless": "ACGT\n",
+	} {
+		_, err := Build(strings.NewReader(data))
+		assert.Error(t, err, name)
+	}
+	_, err = ReadIndex(strings.NewReader("chr1\t12\tx\t5\t6\n"))
+	assert.Error(t, err)
+}
+
+func TestFetch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ref.fa")
+	require.NoError(t, os.WriteFile(path, []byte(fasta), 0o644))
+
+	x, err := Open(path)
+	require.NoError(t, err)
+	defer x.Close()
+	assert.FileExists(t, IndexPath(path))
+	assert.Len(t, x.Entries(), 4)
+
+	for _, tc := range []struct {
+		name       string
+		start, end int64
+		want       string
+	}{
+		{"chr1", 0, 12, "ACGTACGTACGT"},
+		{"chr1", 3, 8, "TACGT"},
+		{"chr1", 5, 6, "C"},
+		{"chr1", 10, 100, "GT"},
+		{"chr2", 2, 6, "TTGG"},
+		{"chr3", 1, 5, "ACGT"},
+		{"empty", 0, 10, ""},
+	} {
+		got, err := x.Fetch(tc.name, tc.start, tc.end)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got, "%s:%d-%d", tc.name, tc.start, tc.end)
+	}
+	_, err = x.Fetch("chrX", 0, 1)
+	assert.Error(t, err)
+	_, err = x.Fetch("chr1", 5, 2)
+	assert.Error(t, err)
+
+	// A second open reads the index written by the first.
+	y, err := Open(path)
+	require.NoError(t, err)
+	defer y.Close()
+	assert.Equal(t, x.Entries(), y.Entries())
+
+	r, err := ParseRegion("chr2:3")
+	require.NoError(t, err)
+	got, err := x.FetchRegion(r)
+	require.NoError(t, err)
+	assert.Equal(t, "TTGG", got)
+
+	gz := filepath.Join(t.TempDir(), "ref.fa.gz")
+	require.NoError(t, os.WriteFile(gz, []byte{0x1f, 0x8b, 0x08, 0, 0, 0, 0, 0}, 0o644))
+	_, err = Open(gz)
+	assert.Error(t, err)
+}
+
+func TestParseRegion(t *testing.T) {
+	for in, want := range map[string]Region{
+		"chr1":              {Name: "chr1", End: -1},
+		"chr1:100":          {Name: "chr1", Start: 99, End: -1},
+		"chr1:1,000-2,000":  {Name: "chr1", Start: 999, End: 2000},
+		"HLA:01:02:100-200": {Name: "HLA:01:02", Start: 99, End: 200},
+	} {
+		got, err := ParseRegion(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	assert.Equal(t, "chr1:1000-2000", Region{Name: "chr1", Start: 999, End: 2000}.String())
+	for _, in := range []string{"", ":1-2", "chr1:0-5", "chr1:10-5", "chr1:a"} {
+		_, err := ParseRegion(in)
+		assert.Error(t, err, in)
+	}
+}
@@ -0,0 +1,244 @@
+// Package features converts sequences into fixed-length numeric vectors
+// for alignment-free comparison and machine-learning workflows.
+//
+// Comparison with Aria:
+//
+//	Aria can tie vector length to its parameters:
+//	  fn fcgr(seq: Sequence, k: Int) -> Vector<Float, 4^k>
+//
+//	Go returns slices and documents their length.
+package features
+
+import (
+	"encoding/csv"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strconv"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// MaxFCGRK bounds the FCGR resolution (4^k cells).
+const MaxFCGRK = 12
+
+// Point is a position in the unit square.
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// cgrCorner returns the x and y bits of a base's CGR corner:
+// A (0,0), C (0,1), G (1,1), T (1,0).
+func cgrCorner(b byte) (x, y int, ok bool) {
+	switch b {
+	case 'A':
+		return 0, 0, true
+	case 'C':
+		return 0, 1, true
+	case 'G':
+		return 1, 1, true
+	case 'T', 'U':
+		return 1, 0, true
+	default:
+		return 0, 0, false
+	}
+}
+
+// CGR returns the chaos game representation of a sequence: starting at
+// the centre of the unit square, each base moves halfway towards its
+// corner. Bases other than A, C, G, T leave the position unchanged and
+// produce no point.
+//
+// Aria equivalent:
+//
+//	fn cgr(seq: Sequence) -> [Point]
+//	  ensures result.all(|p| p.x >= 0.0 and p.x <= 1.0 and p.y >= 0.0 and p.y <= 1.0)
+func CGR(seq *sequence.Sequence) []Point {
+	points := make([]Point, 0, seq.Len())
+	x, y := 0.5, 0.5
+	for i := 0; i < seq.Len(); i++ {
+		cx, cy, ok := cgrCorner(seq.Bases[i])
+		if !ok {
+			continue
+		}
+		x = (x + float64(cx)) / 2
+		y = (y + float64(cy)) / 2
+		points = append(points, Point{X: x, Y: y})
+	}
+	return points
+}
+
+// FCGR is a frequency chaos game representation: a 2^k x 2^k grid
+// counting the CGR points in each cell, which is equivalent to counting
+// k-mers. Grid is indexed [row][column] with row 0 at the bottom (y = 0).
+type FCGR struct {
+	SequenceID string      `json:"sequence_id"`
+	K          int         `json:"k"`
+	Total      int         `json:"total"`
+	Grid       [][]float64 `json:"grid"`
+}
+
+// NewFCGR computes the FCGR of a sequence at resolution k. When
+// normalize is set, cells hold k-mer frequencies instead of counts.
+//
+// Aria equivalent:
+//
+//	fn fcgr(seq: Sequence, k: Int, normalize: Bool) -> Result<FCGR, FeatureError>
+//	  requires k > 0 and k <= MAX_FCGR_K
+//	  ensures result.grid.len() == 2^k
+func NewFCGR(seq *sequence.Sequence, k int, normalize bool) (*FCGR, error) {
+	if k <= 0 || k > MaxFCGRK {
+		return nil, fmt.Errorf("k must be between 1 and %d", MaxFCGRK)
+	}
+
+	size := 1 << uint(k)
+	f := &FCGR{SequenceID: seq.ID, K: k, Grid: make([][]float64, size)}
+	for i := range f.Grid {
+		f.Grid[i] = make([]float64, size)
+	}
+
+	// Slide over the sequence keeping the cell of the last k bases; the
+	// most recent base selects the top-level quadrant.
+	mask := size - 1
+	col, row, valid := 0, 0, 0
+	for i := 0; i < seq.Len(); i++ {
+		cx, cy, ok := cgrCorner(seq.Bases[i])
+		if !ok {
+			valid = 0
+			continue
+		}
+		col = (col>>1 | cx<<uint(k-1)) & mask
+		row = (row>>1 | cy<<uint(k-1)) & mask
+		valid++
+		if valid >= k {
+			f.Grid[row][col]++
+			f.Total++
+		}
+	}
+
+	if normalize && f.Total > 0 {
+		for _, r := range f.Grid {
+			for c := range r {
+				r[c] /= float64(f.Total)
+			}
+		}
+	}
+	return f, nil
+}
+
+// Vector flattens the grid row by row into a 4^k vector.
+func (f *FCGR) Vector() []float64 {
+	v := make([]float64, 0, len(f.Grid)*len(f.Grid))
+	for _, r := range f.Grid {
+		v = append(v, r...)
+	}
+	return v
+}
+
+// CellKMer returns the k-mer counted in a grid cell.
+func (f *FCGR) CellKMer(row, col int) string {
+	kmer := make([]byte, f.K)
+	// Bit k-1 holds the most recent (last) base.
+	for j := 0; j < f.K; j++ {
+		shift := uint(f.K - 1 - j)
+		x, y := (col>>shift)&1, (row>>shift)&1
+		var b byte
+		switch {
+		case x == 0 && y == 0:
+			b = 'A'
+		case x == 0 && y == 1:
+			b = 'C'
+		case x == 1 && y == 1:
+			b = 'G'
+		default:
+			b = 'T'
+		}
+		kmer[f.K-1-j] = b
+	}
+	return string(kmer)
+}
+
+// Labels returns the k-mer for each element of Vector.
+func (f *FCGR) Labels() []string {
+	labels := make([]string, 0, len(f.Grid)*len(f.Grid))
+	for r := range f.Grid {
+		for c := range f.Grid[r] {
+			labels = append(labels, f.CellKMer(r, c))
+		}
+	}
+	return labels
+}
+
+// Image renders the FCGR as a grayscale image where darker cells are more
+// frequent, with each cell drawn as scale x scale pixels. The top of the
+// image corresponds to y = 1 (the C and G corners).
+func (f *FCGR) Image(scale int) *image.Gray {
+	if scale <= 0 {
+		scale = 1
+	}
+	size := len(f.Grid)
+	img := image.NewGray(image.Rect(0, 0, size*scale, size*scale))
+
+	max := 0.0
+	for _, r := range f.Grid {
+		for _, v := range r {
+			if v > max {
+				max = v
+			}
+		}
+	}
+
+	for row := 0; row < size; row++ {
+		for col := 0; col < size; col++ {
+			level := uint8(255)
+			if max > 0 {
+				level = uint8(255 - 255*f.Grid[row][col]/max)
+			}
+			py := (size - 1 - row) * scale
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetGray(col*scale+dx, py+dy, color.Gray{Y: level})
+				}
+			}
+		}
+	}
+	return img
+}
+
+// WritePNG writes the FCGR image as PNG.
+func (f *FCGR) WritePNG(w io.Writer, scale int) error {
+	return png.Encode(w, f.Image(scale))
+}
+
+// WriteFCGRCSV writes one row per FCGR (sequence ID followed by the
+// flattened vector) under a header of k-mer labels. All FCGRs must share k.
+func WriteFCGRCSV(w io.Writer, fcgrs []*FCGR) error {
+	if len(fcgrs) == 0 {
+		return fmt.Errorf("no FCGRs to write")
+	}
+	k := fcgrs[0].K
+
+	cw := csv.NewWriter(w)
+	header := append([]string{"id"}, fcgrs[0].Labels()...)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, f := range fcgrs {
+		if f.K != k {
+			return fmt.Errorf("FCGR for %s has k=%d, expected %d", f.SequenceID, f.K, k)
+		}
+		record := make([]string, 0, len(header))
+		record = append(record, f.SequenceID)
+		for _, v := range f.Vector() {
+			record = append(record, strconv.FormatFloat(v, 'g', 6, 64))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
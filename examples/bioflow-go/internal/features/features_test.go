@@ -0,0 +1,194 @@
+package features
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/kmer"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCGR(t *testing.T) {
+	seq, err := sequence.New("ANG")
+	require.NoError(t, err)
+
+	points := CGR(seq)
+	require.Len(t, points, 2)
+	assert.Equal(t, Point{X: 0.25, Y: 0.25}, points[0])
+	// N is skipped; G moves halfway towards (1, 1).
+	assert.Equal(t, Point{X: 0.625, Y: 0.625}, points[1])
+}
+
+func TestFCGRMatchesKMerCounts(t *testing.T) {
+	seq, err := sequence.WithID("ATGCGATACGCTTGAGGCTAAACGTTAGC", "s1")
+	require.NoError(t, err)
+
+	f, err := NewFCGR(seq, 3, false)
+	require.NoError(t, err)
+	assert.Len(t, f.Grid, 8)
+	assert.Equal(t, "s1", f.SequenceID)
+
+	counter, err := kmer.CountKMers(seq, 3)
+	require.NoError(t, err)
+	assert.Equal(t, counter.Total, f.Total)
+
+	labels := f.Labels()
+	vector := f.Vector()
+	require.Len(t, vector, 64)
+	for i, label := range labels {
+		assert.Equal(t, float64(counter.Counts[label]), vector[i], label)
+	}
+}
+
+func TestFCGRCellGeometry(t *testing.T) {
+	// A single trailing G lands in the upper-right quadrant at k=1.
+	seq, err := sequence.New("G")
+	require.NoError(t, err)
+	f, err := NewFCGR(seq, 1, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, f.Grid[1][1])
+	assert.Equal(t, "G", f.CellKMer(1, 1))
+	assert.Equal(t, "A", f.CellKMer(0, 0))
+	assert.Equal(t, "C", f.CellKMer(1, 0))
+	assert.Equal(t, "T", f.CellKMer(0, 1))
+
+	_, err = NewFCGR(seq, 0, false)
+	assert.Error(t, err)
+	_, err = NewFCGR(seq, MaxFCGRK+1, false)
+	assert.Error(t, err)
+}
+
+func TestFCGRNormalizeAndNs(t *testing.T) {
+	seq, err := sequence.New("ACGNNACG")
+	require.NoError(t, err)
+	f, err := NewFCGR(seq, 2, true)
+	require.NoError(t, err)
+	// AC, CG twice each; k-mers spanning N are skipped.
+	assert.Equal(t, 4, f.Total)
+	sum := 0.0
+	for _, v := range f.Vector() {
+		sum += v
+	}
+	assert.InDelta(t, 1.0, sum, 1e-9)
+}
+
+func TestFCGRExport(t *testing.T) {
+	a, err := sequence.WithID("ACGTACGT", "a")
+	require.NoError(t, err)
+	b, err := sequence.WithID("AAAACCCC", "b")
+	require.NoError(t, err)
+	fa, err := NewFCGR(a, 2, false)
+	require.NoError(t, err)
+	fb, err := NewFCGR(b, 2, false)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteFCGRCSV(&buf, []*FCGR{fa, fb}))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "id,AA,"))
+	assert.Len(t, strings.Split(lines[1], ","), 17)
+
+	fc, err := NewFCGR(a, 3, false)
+	require.NoError(t, err)
+	assert.Error(t, WriteFCGRCSV(&buf, []*FCGR{fa, fc}))
+
+	buf.Reset()
+	require.NoError(t, fa.WritePNG(&buf, 4))
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, 16, img.Bounds().Dx())
+}
+
+func TestKMerMatrix(t *testing.T) {
+	a, err := sequence.WithID("AAAAC", "a")
+	require.NoError(t, err)
+	b, err := sequence.WithID("ACGT", "b")
+	require.NoError(t, err)
+	seqs := []*sequence.Sequence{a, b}
+
+	m, err := KMerMatrix(seqs, MatrixOptions{K: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, m.Rows)
+	assert.Equal(t, []string{"AA", "AC", "CG", "GT"}, m.Columns)
+	assert.Equal(t, []float64{3, 1, 0, 0}, m.Values[0])
+	assert.Equal(t, []float64{0, 1, 1, 1}, m.Values[1])
+
+	rel, err := KMerMatrix(seqs, MatrixOptions{K: 2, Normalization: Relative})
+	require.NoError(t, err)
+	assert.InDelta(t, 0.75, rel.Values[0][0], 1e-9)
+
+	tfidf, err := KMerMatrix(seqs, MatrixOptions{K: 2, Normalization: TFIDF})
+	require.NoError(t, err)
+	for _, row := range tfidf.Values {
+		norm := 0.0
+		for _, v := range row {
+			norm += v * v
+		}
+		assert.InDelta(t, 1.0, norm, 1e-9)
+	}
+	// AC occurs in both sequences, so it is down-weighted relative to CG.
+	assert.Less(t, tfidf.Values[1][1], tfidf.Values[1][2])
+
+	clr, err := KMerMatrix(seqs, MatrixOptions{K: 2, Normalization: CLR})
+	require.NoError(t, err)
+	sum := 0.0
+	for _, v := range clr.Values[0] {
+		sum += v
+	}
+	assert.InDelta(t, 0.0, sum, 1e-9)
+
+	all, err := KMerMatrix(seqs, MatrixOptions{K: 2, AllKMers: true, Canonical: true})
+	require.NoError(t, err)
+	assert.Len(t, all.Columns, 10)
+
+	_, err = KMerMatrix(nil, MatrixOptions{K: 2})
+	assert.Error(t, err)
+	_, err = ParseNormalization("zscore")
+	assert.Error(t, err)
+}
+
+func TestMatrixExport(t *testing.T) {
+	a, err := sequence.WithID("AAAAC", "a")
+	require.NoError(t, err)
+	b, err := sequence.WithID("ACGT", "b")
+	require.NoError(t, err)
+	m, err := KMerMatrix([]*sequence.Sequence{a, b}, MatrixOptions{K: 2})
+	require.NoError(t, err)
+
+	csr := m.ToCSR()
+	assert.Equal(t, []int{0, 2, 5}, csr.Indptr)
+	assert.Equal(t, []int{0, 1, 1, 2, 3}, csr.Indices)
+	assert.InDelta(t, 5.0/8.0, csr.Density(), 1e-9)
+
+	var buf bytes.Buffer
+	require.NoError(t, m.WriteCSV(&buf))
+	assert.True(t, strings.HasPrefix(buf.String(), "id,AA,AC,CG,GT\na,3,1,0,0\n"))
+
+	buf.Reset()
+	require.NoError(t, m.WriteNPY(&buf))
+	data := buf.Bytes()
+	require.True(t, bytes.HasPrefix(data, []byte("\x93NUMPY\x01\x00")))
+	headerLen := int(binary.LittleEndian.Uint16(data[8:10]))
+	assert.Zero(t, (10+headerLen)%64)
+	assert.Contains(t, string(data[10:10+headerLen]), "'shape': (2, 4)")
+	assert.Len(t, data, 10+headerLen+8*8)
+	assert.Equal(t, 3.0, math.Float64frombits(binary.LittleEndian.Uint64(data[10+headerLen:])))
+
+	buf.Reset()
+	require.NoError(t, csr.WriteNPZ(&buf))
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	names := make([]string, 0, len(zr.File))
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.ElementsMatch(t, []string{"indices.npy", "indptr.npy", "format.npy", "shape.npy", "data.npy"}, names)
+}
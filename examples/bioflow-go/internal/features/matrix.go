@@ -0,0 +1,326 @@
+package features
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/kmer"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Normalization selects how k-mer counts are turned into features.
+type Normalization int
+
+const (
+	// Raw keeps k-mer counts.
+	Raw Normalization = iota
+	// Relative divides counts by the row total.
+	Relative
+	// TFIDF weights relative frequencies by smoothed inverse document
+	// frequency, ln((1+n)/(1+df)) + 1, and L2-normalizes each row.
+	TFIDF
+	// CLR is the centred log-ratio transform of pseudocounted counts.
+	CLR
+)
+
+func (n Normalization) String() string {
+	switch n {
+	case Raw:
+		return "raw"
+	case Relative:
+		return "relative"
+	case TFIDF:
+		return "tfidf"
+	case CLR:
+		return "clr"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseNormalization parses a normalization name.
+func ParseNormalization(name string) (Normalization, error) {
+	switch strings.ToLower(name) {
+	case "", "raw", "counts":
+		return Raw, nil
+	case "relative", "freq", "frequency":
+		return Relative, nil
+	case "tfidf", "tf-idf":
+		return TFIDF, nil
+	case "clr":
+		return CLR, nil
+	default:
+		return 0, fmt.Errorf("unknown normalization: %s", name)
+	}
+}
+
+// DefaultCLRPseudocount is added to every count before the CLR transform.
+const DefaultCLRPseudocount = 0.5
+
+// MatrixOptions configures k-mer feature extraction.
+type MatrixOptions struct {
+	K             int
+	Normalization Normalization
+	// Canonical merges each k-mer with its reverse complement.
+	Canonical bool
+	// AllKMers uses every possible k-mer as a column instead of only
+	// those observed in at least one sequence.
+	AllKMers bool
+	// Pseudocount for CLR (default DefaultCLRPseudocount).
+	Pseudocount float64
+}
+
+// Matrix is a dense sequences x k-mers feature matrix.
+//
+// Aria equivalent:
+//
+//	struct Matrix
+//	  invariant self.values.len() == self.rows.len()
+//	  invariant self.values.all(|r| r.len() == self.columns.len())
+type Matrix struct {
+	Rows          []string    `json:"rows"`
+	Columns       []string    `json:"columns"`
+	Values        [][]float64 `json:"values"`
+	Normalization string      `json:"normalization"`
+}
+
+// CSR is a compressed sparse row matrix (SciPy layout).
+type CSR struct {
+	NumRows int       `json:"num_rows"`
+	NumCols int       `json:"num_cols"`
+	Indptr  []int     `json:"indptr"`
+	Indices []int     `json:"indices"`
+	Data    []float64 `json:"data"`
+}
+
+// allKMers enumerates every k-mer over ACGT in lexicographic order.
+func allKMers(k int) []string {
+	kmers := []string{""}
+	for i := 0; i < k; i++ {
+		next := make([]string, 0, len(kmers)*4)
+		for _, prefix := range kmers {
+			for _, b := range "ACGT" {
+				next = append(next, prefix+string(b))
+			}
+		}
+		kmers = next
+	}
+	return kmers
+}
+
+// KMerMatrix converts sequences into a normalized k-mer feature matrix.
+// Row names are sequence IDs (or seqN when missing); columns are sorted
+// k-mers.
+//
+// Aria equivalent:
+//
+//	fn kmer_matrix(seqs: [Sequence], options: MatrixOptions) -> Result<Matrix, FeatureError>
+//	  requires seqs.len() > 0
+//	  requires options.k > 0
+//	  ensures result.rows.len() == seqs.len()
+func KMerMatrix(seqs []*sequence.Sequence, opts MatrixOptions) (*Matrix, error) {
+	if len(seqs) == 0 {
+		return nil, fmt.Errorf("no sequences")
+	}
+	if opts.K <= 0 {
+		return nil, fmt.Errorf("k must be positive")
+	}
+	if opts.AllKMers && opts.K > 10 {
+		return nil, fmt.Errorf("all-k-mer columns are limited to k <= 10")
+	}
+	if opts.Pseudocount <= 0 {
+		opts.Pseudocount = DefaultCLRPseudocount
+	}
+
+	counters := make([]*kmer.Counter, len(seqs))
+	rows := make([]string, len(seqs))
+	observed := make(map[string]struct{})
+	for i, s := range seqs {
+		var c *kmer.Counter
+		var err error
+		switch {
+		case s.Len() < opts.K:
+			c, err = kmer.NewCounter(opts.K)
+		case opts.Canonical:
+			c, err = kmer.CountKMersCanonical(s, opts.K)
+		default:
+			c, err = kmer.CountKMers(s, opts.K)
+		}
+		if err != nil {
+			return nil, err
+		}
+		counters[i] = c
+		for km := range c.Counts {
+			observed[km] = struct{}{}
+		}
+		rows[i] = s.ID
+		if rows[i] == "" {
+			rows[i] = fmt.Sprintf("seq%d", i+1)
+		}
+	}
+
+	var columns []string
+	if opts.AllKMers {
+		for _, km := range allKMers(opts.K) {
+			if opts.Canonical {
+				k, _ := kmer.NewKMer(km)
+				if k.Canonical().Sequence != km {
+					continue
+				}
+			}
+			columns = append(columns, km)
+		}
+	} else {
+		columns = make([]string, 0, len(observed))
+		for km := range observed {
+			columns = append(columns, km)
+		}
+		sort.Strings(columns)
+	}
+
+	values := make([][]float64, len(seqs))
+	for i, c := range counters {
+		values[i] = make([]float64, len(columns))
+		for j, km := range columns {
+			values[i][j] = float64(c.Counts[km])
+		}
+	}
+
+	m := &Matrix{Rows: rows, Columns: columns, Values: values, Normalization: opts.Normalization.String()}
+	switch opts.Normalization {
+	case Raw:
+	case Relative:
+		for _, row := range values {
+			normalizeSum(row)
+		}
+	case TFIDF:
+		df := make([]int, len(columns))
+		for _, row := range values {
+			for j, v := range row {
+				if v > 0 {
+					df[j]++
+				}
+			}
+		}
+		n := float64(len(values))
+		for _, row := range values {
+			normalizeSum(row)
+			norm := 0.0
+			for j := range row {
+				row[j] *= math.Log((1+n)/(1+float64(df[j]))) + 1
+				norm += row[j] * row[j]
+			}
+			if norm > 0 {
+				norm = math.Sqrt(norm)
+				for j := range row {
+					row[j] /= norm
+				}
+			}
+		}
+	case CLR:
+		for _, row := range values {
+			meanLog := 0.0
+			for j := range row {
+				row[j] = math.Log(row[j] + opts.Pseudocount)
+				meanLog += row[j]
+			}
+			if len(row) > 0 {
+				meanLog /= float64(len(row))
+			}
+			for j := range row {
+				row[j] -= meanLog
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown normalization")
+	}
+
+	return m, nil
+}
+
+// normalizeSum scales a row to sum to 1 (rows summing to 0 are unchanged).
+func normalizeSum(row []float64) {
+	total := 0.0
+	for _, v := range row {
+		total += v
+	}
+	if total == 0 {
+		return
+	}
+	for j := range row {
+		row[j] /= total
+	}
+}
+
+// ToCSR converts the matrix to compressed sparse row form, dropping zeros.
+func (m *Matrix) ToCSR() *CSR {
+	c := &CSR{NumRows: len(m.Rows), NumCols: len(m.Columns), Indptr: make([]int, 1, len(m.Rows)+1)}
+	for _, row := range m.Values {
+		for j, v := range row {
+			if v != 0 {
+				c.Indices = append(c.Indices, j)
+				c.Data = append(c.Data, v)
+			}
+		}
+		c.Indptr = append(c.Indptr, len(c.Data))
+	}
+	if c.Indices == nil {
+		c.Indices, c.Data = []int{}, []float64{}
+	}
+	return c
+}
+
+// Density returns the fraction of non-zero entries.
+func (c *CSR) Density() float64 {
+	if c.NumRows == 0 || c.NumCols == 0 {
+		return 0
+	}
+	return float64(len(c.Data)) / float64(c.NumRows*c.NumCols)
+}
+
+// WriteCSV writes the dense matrix with an "id" column followed by one
+// column per k-mer.
+func (m *Matrix) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"id"}, m.Columns...)); err != nil {
+		return err
+	}
+	for i, row := range m.Values {
+		record := make([]string, 0, len(row)+1)
+		record = append(record, m.Rows[i])
+		for _, v := range row {
+			record = append(record, strconv.FormatFloat(v, 'g', 8, 64))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSparseCSV writes non-zero entries as "row,column,value" triplets
+// (0-based indices, as expected by scipy.sparse.coo_matrix).
+func (m *Matrix) WriteSparseCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"row", "column", "value"}); err != nil {
+		return err
+	}
+	for i, row := range m.Values {
+		for j, v := range row {
+			if v == 0 {
+				continue
+			}
+			if err := cw.Write([]string{strconv.Itoa(i), strconv.Itoa(j), strconv.FormatFloat(v, 'g', 8, 64)}); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
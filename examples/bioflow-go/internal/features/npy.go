@@ -0,0 +1,99 @@
+package features
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// writeNPY writes an array in NumPy .npy format (version 1.0). data must
+// already be encoded according to descr (e.g. "<f8" little-endian float64).
+func writeNPY(w io.Writer, descr string, shape []int, data []byte) error {
+	dims := make([]string, len(shape))
+	for i, d := range shape {
+		dims[i] = fmt.Sprintf("%d", d)
+	}
+	shapeStr := "(" + strings.Join(dims, ", ")
+	if len(shape) == 1 {
+		shapeStr += ","
+	}
+	shapeStr += ")"
+
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': %s, }", descr, shapeStr)
+	// Magic (6) + version (2) + header length (2) + header, padded with
+	// spaces and a newline to a multiple of 64 bytes.
+	total := 10 + len(header) + 1
+	if pad := total % 64; pad != 0 {
+		header += strings.Repeat(" ", 64-pad)
+	}
+	header += "\n"
+
+	prefix := make([]byte, 10)
+	copy(prefix, "\x93NUMPY")
+	prefix[6], prefix[7] = 1, 0
+	binary.LittleEndian.PutUint16(prefix[8:], uint16(len(header)))
+
+	for _, chunk := range [][]byte{prefix, []byte(header), data} {
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeFloat64s(values []float64) []byte {
+	buf := make([]byte, 8*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint64(buf[8*i:], math.Float64bits(v))
+	}
+	return buf
+}
+
+func encodeInt64s(values []int) []byte {
+	buf := make([]byte, 8*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint64(buf[8*i:], uint64(int64(v)))
+	}
+	return buf
+}
+
+// WriteNPY writes the dense matrix as a 2-D float64 .npy array, readable
+// with numpy.load.
+func (m *Matrix) WriteNPY(w io.Writer) error {
+	flat := make([]float64, 0, len(m.Rows)*len(m.Columns))
+	for _, row := range m.Values {
+		flat = append(flat, row...)
+	}
+	return writeNPY(w, "<f8", []int{len(m.Rows), len(m.Columns)}, encodeFloat64s(flat))
+}
+
+// WriteNPZ writes the CSR matrix in the layout of scipy.sparse.save_npz,
+// readable with scipy.sparse.load_npz.
+func (c *CSR) WriteNPZ(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	entries := []struct {
+		name  string
+		descr string
+		shape []int
+		data  []byte
+	}{
+		{"indices.npy", "<i8", []int{len(c.Indices)}, encodeInt64s(c.Indices)},
+		{"indptr.npy", "<i8", []int{len(c.Indptr)}, encodeInt64s(c.Indptr)},
+		{"format.npy", "|S3", []int{}, []byte("csr")},
+		{"shape.npy", "<i8", []int{2}, encodeInt64s([]int{c.NumRows, c.NumCols})},
+		{"data.npy", "<f8", []int{len(c.Data)}, encodeFloat64s(c.Data)},
+	}
+	for _, e := range entries {
+		fw, err := zw.Create(e.name)
+		if err != nil {
+			return err
+		}
+		if err := writeNPY(fw, e.descr, e.shape, e.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,549 @@
+// Package genome compares and assesses whole genome assemblies.
+//
+// Average nucleotide identity (ANI) is the mean identity of the regions
+// two genomes share, and genomes of the same prokaryotic species share
+// about 95% or more. It is computed from reciprocal best alignments of
+// fragments, as ANIb and OrthoANI do, or estimated from shared k-mers, as
+// Mash does, which is much faster but less exact below about 90%.
+//
+// Completeness is estimated from a panel of conserved marker genes, each
+// given by its k-mers: the fraction of markers a genome contains, and the
+// fraction of those found more than once, hint at how complete and how
+// contaminated an assembly is before slower gene-based tools are run.
+//
+// Comparison with Aria:
+//
+//	Aria bounds the result in the signature:
+//	  fn ani(a: Genome, b: Genome, options: ANIOptions) -> Result<ANIResult, GenomeError>
+//	    ensures result.ani >= 0.0 and result.ani <= 100.0
+//
+//	Go documents the bounds and checks them in tests.
+package genome
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/alignment"
+	"github.com/aria-lang/bioflow-go/internal/kmer"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Genome is a named assembly of one or more contigs.
+type Genome struct {
+	Name    string
+	Contigs []*sequence.Sequence
+}
+
+// Length returns the total length of the contigs.
+func (g *Genome) Length() int {
+	n := 0
+	for _, c := range g.Contigs {
+		n += c.Len()
+	}
+	return n
+}
+
+// ANIMethod selects how ANI is computed.
+type ANIMethod int
+
+const (
+	// FragmentANI aligns fragments of each genome to the other and
+	// averages the identity of reciprocal best hits.
+	FragmentANI ANIMethod = iota
+	// KMerANI estimates ANI from the Jaccard index of the canonical
+	// k-mers of the genomes, as the Mash distance does.
+	KMerANI
+)
+
+// String returns the method as ParseANIMethod accepts it.
+func (m ANIMethod) String() string {
+	switch m {
+	case FragmentANI:
+		return "fragment"
+	case KMerANI:
+		return "kmer"
+	}
+	return "unknown"
+}
+
+// ParseANIMethod parses fragment or kmer.
+func ParseANIMethod(name string) (ANIMethod, error) {
+	switch strings.ToLower(name) {
+	case "fragment", "fragments", "anib":
+		return FragmentANI, nil
+	case "kmer", "k-mer", "mash":
+		return KMerANI, nil
+	}
+	return 0, fmt.Errorf("unknown ANI method %q (want fragment or kmer)", name)
+}
+
+// SpeciesANI is the ANI, in percent, at and above which two genomes are
+// usually taken to be of the same species.
+const SpeciesANI = 95.0
+
+// ANIOptions configures ANI.
+type ANIOptions struct {
+	Method ANIMethod
+	// K is the k-mer length of the seeds locating fragments or of the
+	// estimate. Zero means 16 for seeds and 21, as in Mash, for the
+	// estimate.
+	K int
+	// FragmentLength is the length of the fragments genomes are cut
+	// into; shorter ends of contigs are left out.
+	FragmentLength int
+	// MinIdentity and MinCoverage are the lowest identity of a fragment
+	// hit and the lowest fraction of the fragment it must cover.
+	MinIdentity float64
+	MinCoverage float64
+	// MaxOccurrences skips seed k-mers occurring more often in a genome,
+	// as in repeats.
+	MaxOccurrences int
+}
+
+// DefaultANIOptions returns ANIb's fragments and thresholds.
+func DefaultANIOptions() ANIOptions {
+	return ANIOptions{
+		Method:         FragmentANI,
+		FragmentLength: 1020,
+		MinIdentity:    0.3,
+		MinCoverage:    0.7,
+		MaxOccurrences: 32,
+	}
+}
+
+// ANIResult is the ANI of two genomes.
+type ANIResult struct {
+	Query     string `json:"query"`
+	Reference string `json:"reference"`
+	// ANI is in percent, and 0 when the genomes share nothing.
+	ANI float64 `json:"ani"`
+	// QueryFraction and ReferenceFraction are the fractions of each
+	// genome's fragments in reciprocal pairs or, for the k-mer estimate,
+	// of its k-mers shared with the other.
+	QueryFraction     float64 `json:"query_fraction"`
+	ReferenceFraction float64 `json:"reference_fraction"`
+	// Fragments counts the reciprocal fragment pairs.
+	Fragments int `json:"fragments,omitempty"`
+}
+
+// SameSpecies reports whether the ANI is at least SpeciesANI.
+func (r ANIResult) SameSpecies() bool {
+	return r.ANI >= SpeciesANI
+}
+
+// ANI returns the average nucleotide identity of two genomes. It is
+// symmetric: swapping a and b swaps only the names and fractions.
+//
+// Aria equivalent:
+//
+//	fn ani(a: Genome, b: Genome, options: ANIOptions) -> Result<ANIResult, GenomeError>
+//	  requires options.k >= 0 and options.k <= 32
+//	  ensures result.ani >= 0.0 and result.ani <= 100.0
+func ANI(a, b *Genome, opts ANIOptions) (ANIResult, error) {
+	opts = opts.withK()
+	if err := checkANIOptions(opts); err != nil {
+		return ANIResult{}, err
+	}
+	if opts.Method == KMerANI {
+		return kmerANI(kmerSet(a, opts.K), kmerSet(b, opts.K), a.Name, b.Name, opts.K), nil
+	}
+	return fragmentANI(newFragmentIndex(a, opts), newFragmentIndex(b, opts), opts)
+}
+
+// withK returns the options with K set to its default if zero.
+func (opts ANIOptions) withK() ANIOptions {
+	if opts.K == 0 {
+		opts.K = 16
+		if opts.Method == KMerANI {
+			opts.K = 21
+		}
+	}
+	return opts
+}
+
+func checkANIOptions(opts ANIOptions) error {
+	if opts.K <= 0 || opts.K > kmer.MaxPackedK {
+		return fmt.Errorf("k must be between 1 and %d", kmer.MaxPackedK)
+	}
+	if opts.Method == FragmentANI && opts.FragmentLength < 2*opts.K {
+		return fmt.Errorf("fragment length must be at least twice k")
+	}
+	if opts.MinIdentity < 0 || opts.MinIdentity > 1 || opts.MinCoverage < 0 || opts.MinCoverage > 1 {
+		return fmt.Errorf("minimum identity and coverage must be in [0, 1]")
+	}
+	return nil
+}
+
+// kmerSet returns the canonical k-mers of a genome.
+func kmerSet(g *Genome, k int) map[uint64]int {
+	counter, _ := kmer.NewPackedCounter(k)
+	counter.Canonical = true
+	for _, c := range g.Contigs {
+		counter.CountKMers(strings.ToUpper(c.Bases))
+	}
+	return counter.Counts
+}
+
+// kmerANI estimates ANI as 1 - D, where D = -ln(2J/(1+J))/k is the Mash
+// distance of genomes whose k-mer sets have Jaccard index J.
+func kmerANI(a, b map[uint64]int, nameA, nameB string, k int) ANIResult {
+	r := ANIResult{Query: nameA, Reference: nameB}
+	shared := 0
+	for code := range a {
+		if _, ok := b[code]; ok {
+			shared++
+		}
+	}
+	if shared == 0 {
+		return r
+	}
+	j := float64(shared) / float64(len(a)+len(b)-shared)
+	r.ANI = 100 * max(0, 1+math.Log(2*j/(1+j))/float64(k))
+	r.QueryFraction = float64(shared) / float64(len(a))
+	r.ReferenceFraction = float64(shared) / float64(len(b))
+	return r
+}
+
+// fragmentIndex holds a genome's fragments and the positions of its seed
+// k-mers.
+type fragmentIndex struct {
+	genome *Genome
+	bases  []string
+	// first[c] is the number of fragments before contig c.
+	first []int
+	seeds map[uint64][]seed
+}
+
+// seed is a k-mer occurrence; forward is set when the k-mer reads as its
+// canonical code on the forward strand.
+type seed struct {
+	contig, pos int32
+	forward     bool
+}
+
+func newFragmentIndex(g *Genome, opts ANIOptions) *fragmentIndex {
+	idx := &fragmentIndex{genome: g, seeds: make(map[uint64][]seed)}
+	n := 0
+	for c, contig := range g.Contigs {
+		bases := strings.ToUpper(contig.Bases)
+		idx.bases = append(idx.bases, bases)
+		idx.first = append(idx.first, n)
+		n += len(bases) / opts.FragmentLength
+		eachKMer(bases, opts.K, func(i int, code uint64, forward bool) {
+			idx.seeds[code] = append(idx.seeds[code], seed{int32(c), int32(i), forward})
+		})
+	}
+	idx.first = append(idx.first, n)
+	for code, seeds := range idx.seeds {
+		if opts.MaxOccurrences > 0 && len(seeds) > opts.MaxOccurrences {
+			delete(idx.seeds, code)
+		}
+	}
+	return idx
+}
+
+// fragments returns the number of fragments.
+func (idx *fragmentIndex) fragments() int {
+	return idx.first[len(idx.first)-1]
+}
+
+// fragment returns the contig and start of fragment f.
+func (idx *fragmentIndex) fragment(f, length int) (int, int) {
+	c := sort.Search(len(idx.first)-1, func(c int) bool { return idx.first[c+1] > f })
+	return c, (f - idx.first[c]) * length
+}
+
+// eachKMer calls fn with the start, canonical code and orientation of
+// every k-mer of bases free of ambiguous bases.
+func eachKMer(bases string, k int, fn func(i int, code uint64, forward bool)) {
+	var fwd, rev uint64
+	shift := 2 * uint(k-1)
+	run := 0
+	for i := 0; i < len(bases); i++ {
+		next, err := kmer.Roll(fwd, k, bases[i])
+		if err != nil {
+			run = 0
+			continue
+		}
+		fwd = next
+		rev = rev>>2 | (3-next&3)<<shift
+		if run++; run < k {
+			continue
+		}
+		if rev < fwd {
+			fn(i-k+1, rev, false)
+		} else {
+			fn(i-k+1, fwd, true)
+		}
+	}
+}
+
+// fragmentHit is where a fragment aligns best in the other genome.
+type fragmentHit struct {
+	identity float64
+	// fragment is the other genome's fragment containing the middle of
+	// the hit, or -1 if the hit is in the short end of a contig.
+	fragment int
+}
+
+// mapFragments returns the best hit of each fragment of q in r, or a hit
+// with fragment -1.
+func mapFragments(q, r *fragmentIndex, opts ANIOptions) ([]fragmentHit, error) {
+	length := opts.FragmentLength
+	hits := make([]fragmentHit, q.fragments())
+	for f := range hits {
+		hits[f].fragment = -1
+		c, start := q.fragment(f, length)
+		frag := q.bases[c][start : start+length]
+		hit, ok, err := alignFragment(frag, r, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s fragment %d: %w", q.genome.Name, f+1, err)
+		}
+		if ok {
+			hits[f] = hit
+		}
+	}
+	return hits, nil
+}
+
+// diagonal identifies a bin of alignment diagonals of a fragment, or of
+// its reverse complement, on a contig.
+type diagonal struct {
+	contig  int32
+	reverse bool
+	bin     int
+}
+
+// alignFragment locates a fragment in r by its most voted diagonal bin
+// and aligns it there.
+func alignFragment(frag string, r *fragmentIndex, opts ANIOptions) (fragmentHit, bool, error) {
+	length, k := len(frag), opts.K
+	bin := max(1, length/4)
+	votes := make(map[diagonal]int)
+	eachKMer(frag, k, func(i int, code uint64, forward bool) {
+		for _, s := range r.seeds[code] {
+			d := diagonal{contig: s.contig, reverse: s.forward != forward}
+			offset := int(s.pos) - i
+			if d.reverse {
+				offset = int(s.pos) - (length - k - i)
+			}
+			d.bin = int(math.Floor(float64(offset) / float64(bin)))
+			votes[d]++
+		}
+	})
+	// The best pair of adjacent bins, as indels shift the diagonal.
+	var best diagonal
+	bestVotes := 0
+	for d := range votes {
+		next := d
+		next.bin++
+		n := votes[d] + votes[next]
+		if n > bestVotes || n == bestVotes && lessDiagonal(d, best) {
+			best, bestVotes = d, n
+		}
+	}
+	if bestVotes == 0 {
+		return fragmentHit{}, false, nil
+	}
+
+	contig := r.bases[best.contig]
+	slack := length / 10
+	from := max(0, best.bin*bin-slack)
+	to := min(len(contig), (best.bin+2)*bin+length+slack)
+	if from >= to {
+		return fragmentHit{}, false, nil
+	}
+	query := frag
+	if best.reverse {
+		query = reverseComplement(frag)
+	}
+	qs, err := sequence.New(query)
+	if err != nil {
+		return fragmentHit{}, false, err
+	}
+	ws, err := sequence.New(contig[from:to])
+	if err != nil {
+		return fragmentHit{}, false, err
+	}
+	aln, err := alignment.SmithWaterman(qs, ws, nil)
+	if err != nil {
+		return fragmentHit{}, false, err
+	}
+	if aln.Length() == 0 {
+		return fragmentHit{}, false, nil
+	}
+	identity := float64(aln.MatchCount()) / float64(aln.Length())
+	coverage := float64(aln.End1-aln.Start1) / float64(length)
+	if identity < opts.MinIdentity || coverage < opts.MinCoverage {
+		return fragmentHit{}, false, nil
+	}
+	hit := fragmentHit{identity: identity, fragment: -1}
+	if mid := from + (aln.Start2+aln.End2)/2; mid/opts.FragmentLength < len(contig)/opts.FragmentLength {
+		hit.fragment = r.first[best.contig] + mid/opts.FragmentLength
+	}
+	return hit, true, nil
+}
+
+func lessDiagonal(a, b diagonal) bool {
+	if a.contig != b.contig {
+		return a.contig < b.contig
+	}
+	if a.reverse != b.reverse {
+		return !a.reverse
+	}
+	return a.bin < b.bin
+}
+
+func reverseComplement(bases string) string {
+	out := make([]byte, len(bases))
+	for i := 0; i < len(bases); i++ {
+		var c byte
+		switch bases[i] {
+		case 'A':
+			c = 'T'
+		case 'C':
+			c = 'G'
+		case 'G':
+			c = 'C'
+		case 'T':
+			c = 'A'
+		default:
+			c = 'N'
+		}
+		out[len(bases)-1-i] = c
+	}
+	return string(out)
+}
+
+// fragmentANI averages the identities of fragments of a and b that are
+// each other's best hit, both ways.
+func fragmentANI(a, b *fragmentIndex, opts ANIOptions) (ANIResult, error) {
+	r := ANIResult{Query: a.genome.Name, Reference: b.genome.Name}
+	ab, err := mapFragments(a, b, opts)
+	if err != nil {
+		return r, err
+	}
+	ba, err := mapFragments(b, a, opts)
+	if err != nil {
+		return r, err
+	}
+	total := 0.0
+	for f, hit := range ab {
+		if hit.fragment >= 0 && ba[hit.fragment].fragment == f {
+			total += (hit.identity + ba[hit.fragment].identity) / 2
+			r.Fragments++
+		}
+	}
+	if r.Fragments == 0 {
+		return r, nil
+	}
+	r.ANI = 100 * total / float64(r.Fragments)
+	r.QueryFraction = float64(r.Fragments) / float64(len(ab))
+	r.ReferenceFraction = float64(r.Fragments) / float64(len(ba))
+	return r, nil
+}
+
+// ANIMatrix is the ANI of every pair of genomes, in percent, with 100 on
+// the diagonal, ready to draw as a heatmap.
+type ANIMatrix struct {
+	Method string      `json:"method"`
+	Names  []string    `json:"names"`
+	Values [][]float64 `json:"values"`
+	// Pairs holds the result of each pair, the earlier genome first.
+	Pairs []ANIResult `json:"pairs"`
+}
+
+// PairwiseANI returns the ANI of every pair of genomes. Each genome is
+// indexed once.
+//
+// Aria equivalent:
+//
+//	fn pairwise_ani(genomes: [Genome], options: ANIOptions) -> Result<ANIMatrix, GenomeError>
+//	  ensures result.values.len() == genomes.len()
+//	  ensures result.pairs.len() == genomes.len() * (genomes.len() - 1) / 2
+func PairwiseANI(genomes []*Genome, opts ANIOptions) (*ANIMatrix, error) {
+	opts = opts.withK()
+	if err := checkANIOptions(opts); err != nil {
+		return nil, err
+	}
+	n := len(genomes)
+	m := &ANIMatrix{Method: opts.Method.String(), Names: make([]string, n), Values: make([][]float64, n), Pairs: make([]ANIResult, 0)}
+	for i, g := range genomes {
+		m.Names[i] = g.Name
+		m.Values[i] = make([]float64, n)
+		m.Values[i][i] = 100
+	}
+	var sets []map[uint64]int
+	var indexes []*fragmentIndex
+	for _, g := range genomes {
+		if opts.Method == KMerANI {
+			sets = append(sets, kmerSet(g, opts.K))
+		} else {
+			indexes = append(indexes, newFragmentIndex(g, opts))
+		}
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			var r ANIResult
+			var err error
+			if opts.Method == KMerANI {
+				r = kmerANI(sets[i], sets[j], genomes[i].Name, genomes[j].Name, opts.K)
+			} else if r, err = fragmentANI(indexes[i], indexes[j], opts); err != nil {
+				return nil, err
+			}
+			m.Values[i][j], m.Values[j][i] = r.ANI, r.ANI
+			m.Pairs = append(m.Pairs, r)
+		}
+	}
+	return m, nil
+}
+
+// WriteTSV writes the matrix with a header row of names and one row per
+// name.
+func (m *ANIMatrix) WriteTSV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "\t%s\n", strings.Join(m.Names, "\t"))
+	for i, row := range m.Values {
+		bw.WriteString(m.Names[i])
+		for _, v := range row {
+			fmt.Fprintf(bw, "\t%.3f", v)
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// WritePairs writes one tab-separated row per pair, in the long form
+// heatmap tools and species clustering read.
+func (m *ANIMatrix) WritePairs(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "query\treference\tani\tquery_fraction\treference_fraction\tfragments\tsame_species")
+	for _, r := range m.Pairs {
+		fmt.Fprintf(bw, "%s\t%s\t%.3f\t%.4f\t%.4f\t%d\t%t\n",
+			r.Query, r.Reference, r.ANI, r.QueryFraction, r.ReferenceFraction, r.Fragments, r.SameSpecies())
+	}
+	return bw.Flush()
+}
+
+// WritePHYLIP writes the distances 1 - ANI/100 in square PHYLIP format,
+// with relaxed names, for tree building.
+func (m *ANIMatrix) WritePHYLIP(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%d\n", len(m.Names))
+	for i, row := range m.Values {
+		if strings.ContainsAny(m.Names[i], " \t") {
+			return fmt.Errorf("name %q contains whitespace, not allowed in relaxed PHYLIP", m.Names[i])
+		}
+		bw.WriteString(m.Names[i])
+		for _, v := range row {
+			fmt.Fprintf(bw, " %.6f", 1-v/100)
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,254 @@
+package genome
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/kmer"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Marker is a conserved, usually single-copy, gene represented by its
+// k-mers.
+type Marker struct {
+	Name  string   `json:"name"`
+	KMers []string `json:"kmers"`
+}
+
+// Panel is a set of markers expected in every complete genome of a
+// lineage, with k-mers of one length.
+type Panel struct {
+	K       int      `json:"k"`
+	Markers []Marker `json:"markers"`
+}
+
+// PanelFromSequences makes a panel of the distinct k-mers of each marker
+// sequence, named by its ID. K-mers with ambiguous bases are left out.
+//
+// Aria equivalent:
+//
+//	fn panel_from_sequences(markers: [Sequence], k: Int) -> Result<Panel, GenomeError>
+//	  requires k > 0 and k <= 32
+//	  ensures result.markers.len() == markers.len()
+func PanelFromSequences(markers []*sequence.Sequence, k int) (*Panel, error) {
+	if k <= 0 || k > kmer.MaxPackedK {
+		return nil, fmt.Errorf("k must be between 1 and %d", kmer.MaxPackedK)
+	}
+	p := &Panel{K: k}
+	for _, s := range markers {
+		counter, _ := kmer.NewPackedCounter(k)
+		counter.CountKMers(strings.ToUpper(s.Bases))
+		if len(counter.Counts) == 0 {
+			return nil, fmt.Errorf("marker %s has no %d-mers", s.ID, k)
+		}
+		m := Marker{Name: s.ID}
+		for code := range counter.Counts {
+			m.KMers = append(m.KMers, kmer.Decode(code, k))
+		}
+		sort.Strings(m.KMers)
+		p.Markers = append(p.Markers, m)
+	}
+	return p, nil
+}
+
+// ReadPanel parses a panel with one marker per line: its name, a tab, and
+// its k-mers separated by spaces or commas. Blank lines and lines starting
+// with "#" are skipped.
+//
+// Aria equivalent:
+//
+//	fn read_panel(r: Reader) -> Result<Panel, GenomeError> with IO
+//	  ensures result.markers.all(|m| m.kmers.all(|s| s.len() == result.k))
+func ReadPanel(r io.Reader) (*Panel, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	p := &Panel{}
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, rest, ok := strings.Cut(text, "\t")
+		kmers := strings.FieldsFunc(rest, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+		if !ok || len(kmers) == 0 {
+			return nil, fmt.Errorf("line %d: expected a marker name, a tab and k-mers", line)
+		}
+		m := Marker{Name: strings.TrimSpace(name)}
+		for _, s := range kmers {
+			if p.K == 0 {
+				p.K = len(s)
+			}
+			if len(s) != p.K {
+				return nil, fmt.Errorf("line %d: k-mer %s is not %d bases long", line, s, p.K)
+			}
+			if _, err := kmer.Encode(s); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			m.KMers = append(m.KMers, strings.ToUpper(s))
+		}
+		p.Markers = append(p.Markers, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(p.Markers) == 0 {
+		return nil, fmt.Errorf("empty marker panel")
+	}
+	if p.K > kmer.MaxPackedK {
+		return nil, fmt.Errorf("k-mers of %d bases are longer than %d", p.K, kmer.MaxPackedK)
+	}
+	return p, nil
+}
+
+// Write writes the panel in the format ReadPanel reads.
+func (p *Panel) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# k=%d\n", p.K)
+	for _, m := range p.Markers {
+		fmt.Fprintf(bw, "%s\t%s\n", m.Name, strings.Join(m.KMers, ","))
+	}
+	return bw.Flush()
+}
+
+// DefaultMarkerFraction is the fraction of a marker's k-mers that must be
+// found, on either strand, for it to count as present.
+const DefaultMarkerFraction = 0.5
+
+// MarkerHit is how much of one marker was found in a genome.
+type MarkerHit struct {
+	Name  string `json:"name"`
+	Found int    `json:"found"`
+	Total int    `json:"total"`
+	// Fraction is Found / Total.
+	Fraction float64 `json:"fraction"`
+	Present  bool    `json:"present"`
+	// Copies is the median count of the found k-mers in the genome; more
+	// than one copy of a single-copy marker hints at contamination.
+	Copies int `json:"copies"`
+}
+
+// Completeness is the marker content of one genome.
+type Completeness struct {
+	Genome  string `json:"genome"`
+	Markers int    `json:"markers"`
+	Present int    `json:"present"`
+	// Completeness is the fraction of markers present and Redundancy
+	// the fraction of present markers with more than one copy.
+	Completeness float64     `json:"completeness"`
+	Redundancy   float64     `json:"redundancy"`
+	Hits         []MarkerHit `json:"hits"`
+}
+
+// Missing returns the names of the markers not present.
+func (c *Completeness) Missing() []string {
+	var names []string
+	for _, h := range c.Hits {
+		if !h.Present {
+			names = append(names, h.Name)
+		}
+	}
+	return names
+}
+
+// EstimateCompleteness reports which markers of the panel a genome
+// contains. A marker is present when at least minFraction of its k-mers
+// are found on either strand; a zero minFraction means
+// DefaultMarkerFraction. This is a quick proxy for, not a replacement of,
+// gene-based estimates such as CheckM's or BUSCO's.
+//
+// Aria equivalent:
+//
+//	fn estimate_completeness(genome: Genome, panel: Panel, min_fraction: Float) -> Result<Completeness, GenomeError>
+//	  requires min_fraction >= 0.0 and min_fraction <= 1.0
+//	  ensures result.completeness >= 0.0 and result.completeness <= 1.0
+func EstimateCompleteness(g *Genome, p *Panel, minFraction float64) (*Completeness, error) {
+	if minFraction < 0 || minFraction > 1 {
+		return nil, fmt.Errorf("minimum marker fraction must be in [0, 1]")
+	}
+	if minFraction == 0 {
+		minFraction = DefaultMarkerFraction
+	}
+	if p.K <= 0 || p.K > kmer.MaxPackedK {
+		return nil, fmt.Errorf("panel k must be between 1 and %d", kmer.MaxPackedK)
+	}
+	counts := kmerSet(g, p.K)
+	c := &Completeness{Genome: g.Name, Markers: len(p.Markers), Hits: make([]MarkerHit, 0, len(p.Markers))}
+	duplicated := 0
+	for _, m := range p.Markers {
+		h := MarkerHit{Name: m.Name, Total: len(m.KMers)}
+		var copies []int
+		for _, s := range m.KMers {
+			code, err := kmer.Encode(s)
+			if err != nil {
+				return nil, fmt.Errorf("marker %s: %w", m.Name, err)
+			}
+			code = min(code, kmer.ReverseComplementCode(code, p.K))
+			if n := counts[code]; n > 0 {
+				h.Found++
+				copies = append(copies, n)
+			}
+		}
+		if h.Total > 0 {
+			h.Fraction = float64(h.Found) / float64(h.Total)
+		}
+		h.Present = h.Total > 0 && h.Fraction >= minFraction
+		if len(copies) > 0 {
+			sort.Ints(copies)
+			h.Copies = copies[len(copies)/2]
+		}
+		if h.Present {
+			c.Present++
+			if h.Copies > 1 {
+				duplicated++
+			}
+		}
+		c.Hits = append(c.Hits, h)
+	}
+	if c.Markers > 0 {
+		c.Completeness = float64(c.Present) / float64(c.Markers)
+	}
+	if c.Present > 0 {
+		c.Redundancy = float64(duplicated) / float64(c.Present)
+	}
+	return c, nil
+}
+
+// WriteCompletenessTSV writes one summary row per genome.
+func WriteCompletenessTSV(w io.Writer, results []*Completeness) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "genome\tmarkers\tpresent\tcompleteness\tredundancy\tmissing")
+	for _, c := range results {
+		missing := strings.Join(c.Missing(), ",")
+		if missing == "" {
+			missing = "-"
+		}
+		fmt.Fprintf(bw, "%s\t%d\t%d\t%.4f\t%.4f\t%s\n", c.Genome, c.Markers, c.Present, c.Completeness, c.Redundancy, missing)
+	}
+	return bw.Flush()
+}
+
+// WritePresenceMatrix writes the fraction of each marker's k-mers found,
+// one row per marker and one column per genome.
+func WritePresenceMatrix(w io.Writer, results []*Completeness) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("marker")
+	for _, c := range results {
+		bw.WriteString("\t" + c.Genome)
+	}
+	bw.WriteByte('\n')
+	if len(results) > 0 {
+		for i, h := range results[0].Hits {
+			bw.WriteString(h.Name)
+			for _, c := range results {
+				fmt.Fprintf(bw, "\t%.3f", c.Hits[i].Fraction)
+			}
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
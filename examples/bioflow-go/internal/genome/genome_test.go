@@ -0,0 +1,175 @@
+package genome
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randomBases(rng *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = "ACGT"[rng.Intn(4)]
+	}
+	return string(b)
+}
+
+// mutate substitutes n bases at random.
+func mutate(rng *rand.Rand, bases string, n int) string {
+	b := []byte(bases)
+	for _, i := range rng.Perm(len(b))[:n] {
+		b[i] = "CGTA"[strings.IndexByte("ACGT", b[i])]
+	}
+	return string(b)
+}
+
+func newGenome(t *testing.T, name string, contigs ...string) *Genome {
+	g := &Genome{Name: name}
+	for i, bases := range contigs {
+		s, err := sequence.New(bases)
+		require.NoError(t, err)
+		s.ID = name + "_" + string(rune('1'+i))
+		g.Contigs = append(g.Contigs, s)
+	}
+	return g
+}
+
+func TestANI(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	bases := randomBases(rng, 6000)
+	a := newGenome(t, "a", bases[:4000], bases[4000:])
+	// b is a at 96% identity, with its second contig reverse complemented.
+	diverged := mutate(rng, bases, 240)
+	b := newGenome(t, "b", diverged[:4000], reverseComplement(diverged[4000:]))
+	c := newGenome(t, "c", randomBases(rng, 5000))
+	assert.Equal(t, 6000, a.Length())
+
+	opts := DefaultANIOptions()
+	opts.FragmentLength = 500
+	r, err := ANI(a, b, opts)
+	require.NoError(t, err)
+	assert.InDelta(t, 96, r.ANI, 0.5)
+	assert.Equal(t, 12, r.Fragments)
+	assert.Equal(t, 1.0, r.QueryFraction)
+	assert.True(t, r.SameSpecies())
+
+	r, err = ANI(a, a, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, r.ANI)
+
+	r, err = ANI(a, c, opts)
+	require.NoError(t, err)
+	assert.Equal(t, ANIResult{Query: "a", Reference: "c"}, r)
+	assert.False(t, r.SameSpecies())
+
+	opts.Method = KMerANI
+	r, err = ANI(a, b, opts)
+	require.NoError(t, err)
+	assert.InDelta(t, 96, r.ANI, 1)
+	assert.Zero(t, r.Fragments)
+	r, err = ANI(a, c, opts)
+	require.NoError(t, err)
+	assert.Zero(t, r.ANI)
+
+	opts.K = 40
+	_, err = ANI(a, b, opts)
+	assert.Error(t, err)
+
+	m, err := PairwiseANI([]*Genome{a, b, c}, DefaultANIOptions())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, m.Names)
+	require.Len(t, m.Pairs, 3)
+	assert.Equal(t, 100.0, m.Values[1][1])
+	assert.Equal(t, m.Values[0][1], m.Values[1][0])
+	assert.Zero(t, m.Values[0][2])
+
+	method, err := ParseANIMethod("mash")
+	require.NoError(t, err)
+	assert.Equal(t, KMerANI, method)
+	_, err = ParseANIMethod("blast")
+	assert.Error(t, err)
+}
+
+func TestANIMatrixWriters(t *testing.T) {
+	m := &ANIMatrix{
+		Method: "fragment",
+		Names:  []string{"a", "b"},
+		Values: [][]float64{{100, 97.5}, {97.5, 100}},
+		Pairs:  []ANIResult{{Query: "a", Reference: "b", ANI: 97.5, QueryFraction: 0.9, ReferenceFraction: 0.8, Fragments: 9}},
+	}
+	var sb strings.Builder
+	require.NoError(t, m.WriteTSV(&sb))
+	assert.Equal(t, "\ta\tb\na\t100.000\t97.500\nb\t97.500\t100.000\n", sb.String())
+
+	sb.Reset()
+	require.NoError(t, m.WritePairs(&sb))
+	assert.Equal(t, "query\treference\tani\tquery_fraction\treference_fraction\tfragments\tsame_species\n"+
+		"a\tb\t97.500\t0.9000\t0.8000\t9\ttrue\n", sb.String())
+
+	sb.Reset()
+	require.NoError(t, m.WritePHYLIP(&sb))
+	assert.Equal(t, "2\na 0.000000 0.025000\nb 0.025000 0.000000\n", sb.String())
+}
+
+func TestEstimateCompleteness(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	var markers []*sequence.Sequence
+	for i := 0; i < 4; i++ {
+		s, err := sequence.New(randomBases(rng, 200))
+		require.NoError(t, err)
+		s.ID = "m" + string(rune('1'+i))
+		markers = append(markers, s)
+	}
+	panel, err := PanelFromSequences(markers, 15)
+	require.NoError(t, err)
+	require.Len(t, panel.Markers, 4)
+	assert.Len(t, panel.Markers[0].KMers, 186)
+
+	// The genome holds m1 twice, m2 reverse complemented, m3 with every
+	// tenth base changed, and not m4.
+	m3 := []byte(markers[2].Bases)
+	for i := 5; i < len(m3); i += 10 {
+		m3[i] = "CGTA"[strings.IndexByte("ACGT", m3[i])]
+	}
+	g := newGenome(t, "g", randomBases(rng, 300)+markers[0].Bases+randomBases(rng, 300)+markers[0].Bases,
+		reverseComplement(markers[1].Bases)+string(m3))
+	c, err := EstimateCompleteness(g, panel, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 4, c.Markers)
+	assert.Equal(t, 2, c.Present)
+	assert.Equal(t, 0.5, c.Completeness)
+	assert.Equal(t, 0.5, c.Redundancy)
+	assert.Equal(t, []string{"m3", "m4"}, c.Missing())
+	assert.Equal(t, MarkerHit{Name: "m1", Found: 186, Total: 186, Fraction: 1, Present: true, Copies: 2}, c.Hits[0])
+	assert.Equal(t, 1, c.Hits[1].Copies)
+	assert.Zero(t, c.Hits[2].Found)
+
+	c, err = EstimateCompleteness(g, panel, 0.001)
+	require.NoError(t, err)
+	assert.Equal(t, 2, c.Present)
+	_, err = EstimateCompleteness(g, panel, 2)
+	assert.Error(t, err)
+
+	var sb strings.Builder
+	require.NoError(t, panel.Write(&sb))
+	read, err := ReadPanel(strings.NewReader(sb.String()))
+	require.NoError(t, err)
+	assert.Equal(t, panel, read)
+
+	_, err = ReadPanel(strings.NewReader("m1\tACGT,ACG\n"))
+	assert.Error(t, err)
+	_, err = ReadPanel(strings.NewReader("# nothing\n"))
+	assert.Error(t, err)
+
+	sb.Reset()
+	require.NoError(t, WriteCompletenessTSV(&sb, []*Completeness{c}))
+	assert.Equal(t, "genome\tmarkers\tpresent\tcompleteness\tredundancy\tmissing\ng\t4\t2\t0.5000\t0.5000\tm3,m4\n", sb.String())
+	sb.Reset()
+	require.NoError(t, WritePresenceMatrix(&sb, []*Completeness{c}))
+	assert.True(t, strings.HasPrefix(sb.String(), "marker\tg\nm1\t1.000\nm2\t1.000\nm3\t0.000\n"))
+}
@@ -0,0 +1,328 @@
+// Package jobqueue schedules background jobs by priority on a fixed
+// number of workers, with a bounded queue per priority class.
+//
+// Jobs of a higher class always start before queued jobs of a lower one,
+// and batch jobs never take the last free worker, so that a long batch
+// job cannot hold up interactive requests. Each class has its own queue
+// limit: when a class is full, Submit fails with ErrFull and an estimate
+// of when to retry, instead of letting the backlog grow without bound.
+//
+// Comparison with Aria:
+//
+//	Aria would express the bound in the queue type:
+//	  struct Queue
+//	    invariant self.queued(p) <= self.max_queued for all p
+//
+//	Go checks the bound in Submit and reports ErrFull.
+package jobqueue
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Priority is the class of a job.
+type Priority int
+
+const (
+	// Batch jobs run when no other work is waiting and leave one worker
+	// free.
+	Batch Priority = iota
+	// Normal is the default class.
+	Normal
+	// Interactive jobs start before all others.
+	Interactive
+)
+
+// priorities lists the classes from highest to lowest.
+var priorities = []Priority{Interactive, Normal, Batch}
+
+func (p Priority) String() string {
+	switch p {
+	case Batch:
+		return "batch"
+	case Normal:
+		return "normal"
+	case Interactive:
+		return "interactive"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes the priority by name.
+func (p Priority) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText decodes a priority name.
+func (p *Priority) UnmarshalText(text []byte) error {
+	parsed, err := ParsePriority(string(text))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// ParsePriority returns the named priority; an empty name is Normal.
+func ParsePriority(name string) (Priority, error) {
+	switch strings.ToLower(name) {
+	case "interactive":
+		return Interactive, nil
+	case "normal", "":
+		return Normal, nil
+	case "batch":
+		return Batch, nil
+	}
+	return 0, fmt.Errorf("unknown priority %q (use interactive, normal or batch)", name)
+}
+
+// ErrFull is returned by Submit when the queue of a class is full.
+var ErrFull = errors.New("job queue is full")
+
+// Options configures a Queue.
+type Options struct {
+	// Workers is the number of jobs run at once; at least 1.
+	Workers int
+	// MaxQueued is the number of jobs of each class that may wait for a
+	// worker; 0 means jobs are rejected unless a worker is free.
+	MaxQueued int
+}
+
+// DefaultOptions returns two workers and 32 queued jobs per class.
+func DefaultOptions() Options {
+	return Options{Workers: 2, MaxQueued: 32}
+}
+
+// job is a queued job.
+type job struct {
+	run       func()
+	submitted time.Time
+}
+
+// classStats accumulates the statistics of a class.
+type classStats struct {
+	running  int
+	started  int64
+	rejected int64
+	waitSum  time.Duration
+	waitMax  time.Duration
+}
+
+// Queue runs jobs by priority. Its methods may be called concurrently.
+type Queue struct {
+	mu      sync.Mutex
+	opts    Options
+	queues  map[Priority][]job
+	stats   map[Priority]*classStats
+	running int
+	// runAvg is a moving average of job run times, used to estimate
+	// when a rejected client should retry.
+	runAvg time.Duration
+	idle   *sync.Cond
+}
+
+// New creates a Queue.
+//
+// Aria equivalent:
+//
+//	fn new(options: Options) -> Queue
+//	  requires options.workers >= 1
+func New(opts Options) *Queue {
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	if opts.MaxQueued < 0 {
+		opts.MaxQueued = 0
+	}
+	q := &Queue{
+		opts:   opts,
+		queues: make(map[Priority][]job),
+		stats:  make(map[Priority]*classStats),
+	}
+	for _, p := range priorities {
+		q.stats[p] = &classStats{}
+	}
+	q.idle = sync.NewCond(&q.mu)
+	return q
+}
+
+// Submit queues a job to run in its own goroutine. It returns ErrFull if
+// the queue of the class is full.
+//
+// Aria equivalent:
+//
+//	fn submit(self, priority: Priority, run: fn()) -> Result<(), QueueError>
+func (q *Queue) Submit(p Priority, run func()) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.stats[p]; !ok {
+		return fmt.Errorf("unknown priority %d", p)
+	}
+	if len(q.queues[p]) >= q.opts.MaxQueued && !q.canStart(p) {
+		q.stats[p].rejected++
+		return ErrFull
+	}
+	q.queues[p] = append(q.queues[p], job{run: run, submitted: time.Now()})
+	q.dispatch()
+	return nil
+}
+
+// canStart reports whether a job of class p could start now, ahead of
+// anything queued; q.mu must be held.
+func (q *Queue) canStart(p Priority) bool {
+	for _, higher := range priorities {
+		if higher == p {
+			break
+		}
+		if len(q.queues[higher]) > 0 {
+			return false
+		}
+	}
+	return len(q.queues[p]) == 0 && q.free(p)
+}
+
+// free reports whether a worker is free for a job of class p; q.mu must
+// be held.
+func (q *Queue) free(p Priority) bool {
+	limit := q.opts.Workers
+	if p == Batch && limit > 1 {
+		limit--
+	}
+	return q.running < limit
+}
+
+// dispatch starts queued jobs while workers are free, highest class
+// first; q.mu must be held.
+func (q *Queue) dispatch() {
+	for _, p := range priorities {
+		for len(q.queues[p]) > 0 && q.free(p) {
+			j := q.queues[p][0]
+			q.queues[p] = q.queues[p][1:]
+			q.start(p, j)
+		}
+		if len(q.queues[p]) > 0 {
+			// Lower classes wait behind this one.
+			return
+		}
+	}
+}
+
+// start runs a job; q.mu must be held.
+func (q *Queue) start(p Priority, j job) {
+	s := q.stats[p]
+	wait := time.Since(j.submitted)
+	s.started++
+	s.waitSum += wait
+	if wait > s.waitMax {
+		s.waitMax = wait
+	}
+	s.running++
+	q.running++
+	go func() {
+		began := time.Now()
+		defer func() { q.finish(p, time.Since(began)) }()
+		j.run()
+	}()
+}
+
+// finish records the end of a job and starts queued ones.
+func (q *Queue) finish(p Priority, took time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.stats[p].running--
+	q.running--
+	if q.runAvg == 0 {
+		q.runAvg = took
+	} else {
+		q.runAvg = (4*q.runAvg + took) / 5
+	}
+	q.dispatch()
+	if q.running == 0 {
+		q.idle.Broadcast()
+	}
+}
+
+// RetryAfter estimates how long a client whose job of class p was
+// rejected should wait before trying again, from the number of jobs
+// ahead of it and recent run times. It is at least one second.
+func (q *Queue) RetryAfter(p Priority) time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	ahead := 0
+	for _, higher := range priorities {
+		ahead += len(q.queues[higher])
+		if higher == p {
+			break
+		}
+	}
+	wait := q.runAvg * time.Duration(ahead+1) / time.Duration(q.opts.Workers)
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return wait.Round(time.Second)
+}
+
+// ClassStats describes the jobs of one priority class.
+type ClassStats struct {
+	Queued   int   `json:"queued"`
+	Running  int   `json:"running"`
+	Started  int64 `json:"started"`
+	Rejected int64 `json:"rejected"`
+	// MeanWait and MaxWait are the times jobs waited in the queue before
+	// starting, in seconds.
+	MeanWait float64 `json:"mean_wait_seconds"`
+	MaxWait  float64 `json:"max_wait_seconds"`
+	// OldestWait is how long the oldest queued job has waited, in seconds.
+	OldestWait float64 `json:"oldest_wait_seconds"`
+}
+
+// Stats describes the state of a Queue.
+type Stats struct {
+	Workers   int                   `json:"workers"`
+	MaxQueued int                   `json:"max_queued"`
+	Running   int                   `json:"running"`
+	Queued    int                   `json:"queued"`
+	Classes   map[string]ClassStats `json:"classes"`
+}
+
+// Stats returns the queue depth, running jobs and wait times of each
+// class.
+func (q *Queue) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	st := Stats{Workers: q.opts.Workers, MaxQueued: q.opts.MaxQueued, Running: q.running, Classes: make(map[string]ClassStats)}
+	now := time.Now()
+	for _, p := range priorities {
+		s := q.stats[p]
+		cs := ClassStats{
+			Queued:   len(q.queues[p]),
+			Running:  s.running,
+			Started:  s.started,
+			Rejected: s.rejected,
+			MaxWait:  s.waitMax.Seconds(),
+		}
+		if s.started > 0 {
+			cs.MeanWait = (s.waitSum / time.Duration(s.started)).Seconds()
+		}
+		if queued := q.queues[p]; len(queued) > 0 {
+			cs.OldestWait = now.Sub(queued[0].submitted).Seconds()
+		}
+		st.Queued += cs.Queued
+		st.Classes[p.String()] = cs
+	}
+	return st
+}
+
+// Wait blocks until no job is running or queued.
+func (q *Queue) Wait() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.running > 0 {
+		q.idle.Wait()
+	}
+}
@@ -0,0 +1,113 @@
+package jobqueue
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePriority(t *testing.T) {
+	for name, want := range map[string]Priority{"": Normal, "normal": Normal, "Interactive": Interactive, "batch": Batch} {
+		p, err := ParsePriority(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, p, name)
+	}
+	_, err := ParsePriority("urgent")
+	assert.Error(t, err)
+
+	var v struct{ Priority Priority }
+	require.NoError(t, json.Unmarshal([]byte(`{"Priority":"batch"}`), &v))
+	assert.Equal(t, Batch, v.Priority)
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"Priority":"batch"}`, string(data))
+}
+
+// blockingJob returns a job that reports its name on started, then waits
+// for release to be closed.
+func blockingJob(name string, started chan<- string, release <-chan struct{}) func() {
+	return func() {
+		started <- name
+		<-release
+	}
+}
+
+func next(t *testing.T, started <-chan string) string {
+	t.Helper()
+	select {
+	case name := <-started:
+		return name
+	case <-time.After(5 * time.Second):
+		t.Fatal("no job started")
+		return ""
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	q := New(Options{Workers: 2, MaxQueued: 1})
+	started := make(chan string, 10)
+	release := map[string]chan struct{}{}
+	submit := func(p Priority, name string) error {
+		release[name] = make(chan struct{})
+		return q.Submit(p, blockingJob(name, started, release[name]))
+	}
+
+	require.NoError(t, submit(Batch, "b1"))
+	assert.Equal(t, "b1", next(t, started))
+	// Batch jobs leave the last worker free.
+	require.NoError(t, submit(Batch, "b2"))
+	assert.ErrorIs(t, submit(Batch, "b3"), ErrFull)
+	require.NoError(t, submit(Normal, "n1"))
+	assert.Equal(t, "n1", next(t, started))
+
+	require.NoError(t, submit(Normal, "n2"))
+	require.NoError(t, submit(Interactive, "i1"))
+	assert.ErrorIs(t, submit(Interactive, "i2"), ErrFull)
+
+	st := q.Stats()
+	assert.Equal(t, 2, st.Running)
+	assert.Equal(t, 3, st.Queued)
+	assert.Equal(t, 1, st.Classes["interactive"].Queued)
+	assert.Equal(t, int64(1), st.Classes["batch"].Rejected)
+	assert.Equal(t, int64(1), st.Classes["interactive"].Rejected)
+	assert.GreaterOrEqual(t, q.RetryAfter(Batch), time.Second)
+
+	// Freed workers go to the highest class first.
+	close(release["n1"])
+	assert.Equal(t, "i1", next(t, started))
+	close(release["b1"])
+	assert.Equal(t, "n2", next(t, started))
+	// b2 waits for both workers to be free, as one is kept for others.
+	close(release["i1"])
+	close(release["n2"])
+	assert.Equal(t, "b2", next(t, started))
+	close(release["b2"])
+	q.Wait()
+
+	st = q.Stats()
+	assert.Equal(t, 0, st.Running)
+	assert.Equal(t, 0, st.Queued)
+	assert.Equal(t, int64(2), st.Classes["normal"].Started)
+	assert.Greater(t, st.Classes["batch"].MaxWait, 0.0)
+}
+
+func TestSingleWorker(t *testing.T) {
+	q := New(Options{Workers: 1})
+	started := make(chan string, 1)
+	release := make(chan struct{})
+	// With one worker, batch jobs may use it; with no queue, a busy
+	// worker means rejection.
+	require.NoError(t, q.Submit(Batch, blockingJob("b", started, release)))
+	assert.Equal(t, "b", next(t, started))
+	assert.ErrorIs(t, q.Submit(Interactive, func() {}), ErrFull)
+	close(release)
+	q.Wait()
+
+	done := make(chan struct{})
+	require.NoError(t, q.Submit(Interactive, func() { close(done) }))
+	<-done
+	q.Wait()
+}
@@ -0,0 +1,257 @@
+// Package jobstore keeps background jobs on disk so that they survive
+// restarts of the server running them.
+//
+// A Store is a directory holding a bbolt database of job records, and
+// the input and result files of the jobs. A record holds what the store
+// needs to manage a job (its status, attempts and file paths) together
+// with the job's specification and last reported state, which the store
+// keeps as opaque JSON for the job system to interpret.
+//
+// After a crash or restart, Recover puts jobs that were running back in
+// the queue, and fails those that have been interrupted too often, so
+// that a job that crashes the server cannot do so forever.
+//
+// Comparison with Aria:
+//
+//	Aria would make the status transitions part of the record type:
+//	  enum Status { Queued, Running, Done, Failed }
+//	  fn transition(from: Status, to: Status) -> Bool
+//	    ensures from == Done or from == Failed implies not result
+//
+//	Go uses string statuses and leaves transitions to the job system.
+package jobstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Job statuses.
+const (
+	Queued  = "queued"
+	Running = "running"
+	Done    = "done"
+	Failed  = "failed"
+)
+
+// ErrNotFound is returned for unknown job IDs.
+var ErrNotFound = errors.New("job not found")
+
+// jobsBucket holds the records, keyed by ID.
+var jobsBucket = []byte("jobs")
+
+// Record is a stored job. Input and Result are file names within the
+// store directory.
+type Record struct {
+	ID       string          `json:"id"`
+	Seq      uint64          `json:"seq"`
+	Status   string          `json:"status"`
+	Attempts int             `json:"attempts"`
+	Spec     json.RawMessage `json:"spec,omitempty"`
+	State    json.RawMessage `json:"state,omitempty"`
+	Input    string          `json:"input,omitempty"`
+	Result   string          `json:"result,omitempty"`
+}
+
+// Store is a directory of jobs. Its methods may be called concurrently.
+type Store struct {
+	dir string
+	db  *bolt.DB
+}
+
+// Open opens the store in dir, creating it if needed. Only one process
+// may have a store open; Open fails if another holds it for more than a
+// second.
+//
+// Aria equivalent:
+//
+//	fn open(dir: Path) -> Result<Store, StoreError> with FileSystem
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating job store: %w", err)
+	}
+	db, err := bolt.Open(filepath.Join(dir, "jobs.db"), 0o644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening job store: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(jobsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening job store: %w", err)
+	}
+	return &Store{dir: dir, db: db}, nil
+}
+
+// Close closes the store.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Check reports whether the store is usable: the database can be read
+// and the directory written.
+func (s *Store) Check() error {
+	if err := s.db.View(func(tx *bolt.Tx) error { return nil }); err != nil {
+		return fmt.Errorf("reading job store: %w", err)
+	}
+	f, err := os.CreateTemp(s.dir, ".check-*")
+	if err != nil {
+		return fmt.Errorf("writing job store: %w", err)
+	}
+	name := f.Name()
+	_, err = f.Write([]byte("ok\n"))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if rerr := os.Remove(name); err == nil {
+		err = rerr
+	}
+	if err != nil {
+		return fmt.Errorf("writing job store: %w", err)
+	}
+	return nil
+}
+
+// Path returns the path of a file of the store.
+func (s *Store) Path(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+// NextSeq returns a sequence number never returned before, for naming a
+// new job.
+func (s *Store) NextSeq() (uint64, error) {
+	var seq uint64
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		seq, err = tx.Bucket(jobsBucket).NextSequence()
+		return err
+	})
+	return seq, err
+}
+
+// Put saves a record, replacing any with the same ID. It returns once the
+// record is on disk.
+func (s *Store) Put(rec *Record) error {
+	if rec.ID == "" {
+		return errors.New("job record has no id")
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encoding job %s: %w", rec.ID, err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).Put([]byte(rec.ID), data)
+	})
+}
+
+// Get returns the record of a job.
+func (s *Store) Get(id string) (*Record, error) {
+	var rec *Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(jobsBucket).Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		var err error
+		rec, err = decode(data)
+		return err
+	})
+	return rec, err
+}
+
+// List returns all records in order of creation.
+func (s *Store) List() ([]*Record, error) {
+	records := make([]*Record, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).ForEach(func(_, data []byte) error {
+			rec, err := decode(data)
+			if err != nil {
+				return err
+			}
+			records = append(records, rec)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Seq < records[j].Seq })
+	return records, nil
+}
+
+// decode parses a stored record.
+func decode(data []byte) (*Record, error) {
+	var rec Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("decoding job record: %w", err)
+	}
+	return &rec, nil
+}
+
+// Delete removes a job and its files.
+func (s *Store) Delete(id string) error {
+	rec, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).Delete([]byte(id))
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{rec.Input, rec.Result} {
+		if name == "" {
+			continue
+		}
+		if err := os.Remove(s.Path(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Recover prepares the store after a restart: jobs left running were
+// interrupted, so they are queued again, or failed once they have been
+// started maxAttempts times. It returns the jobs to run, queued ones
+// included, in order of creation.
+//
+// Aria equivalent:
+//
+//	fn recover(self, max_attempts: Int) -> Result<List<Record>, StoreError>
+//	  ensures self.list().all(|r| r.status != Running)
+func (s *Store) Recover(maxAttempts int) ([]*Record, error) {
+	records, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]*Record, 0)
+	for _, rec := range records {
+		switch rec.Status {
+		case Running:
+			if rec.Attempts >= maxAttempts {
+				rec.Status = Failed
+			} else {
+				rec.Status = Queued
+			}
+			if err := s.Put(rec); err != nil {
+				return nil, err
+			}
+			if rec.Status == Queued {
+				pending = append(pending, rec)
+			}
+		case Queued:
+			pending = append(pending, rec)
+		}
+	}
+	return pending, nil
+}
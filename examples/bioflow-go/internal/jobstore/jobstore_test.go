@@ -0,0 +1,94 @@
+package jobstore
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	require.NoError(t, err)
+
+	seq1, err := s.NextSeq()
+	require.NoError(t, err)
+	seq2, err := s.NextSeq()
+	require.NoError(t, err)
+	assert.Greater(t, seq2, seq1)
+
+	require.NoError(t, os.WriteFile(s.Path("in-2.jsonl"), []byte("{}\n"), 0o644))
+	require.NoError(t, s.Put(&Record{ID: "job-2", Seq: seq2, Status: Queued, Spec: json.RawMessage(`{"stages":[]}`), Input: "in-2.jsonl"}))
+	require.NoError(t, s.Put(&Record{ID: "job-1", Seq: seq1, Status: Done}))
+	assert.Error(t, s.Put(&Record{}))
+
+	rec, err := s.Get("job-2")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"stages":[]}`, string(rec.Spec))
+	_, err = s.Get("job-3")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, s.Check())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2) // jobs.db and in-2.jsonl
+
+	// A second server cannot open the store while it is in use.
+	_, err = Open(dir)
+	assert.Error(t, err)
+
+	// Records survive reopening.
+	require.NoError(t, s.Close())
+	s, err = Open(dir)
+	require.NoError(t, err)
+	defer s.Close()
+	records, err := s.List()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "job-1", records[0].ID)
+	assert.Equal(t, "job-2", records[1].ID)
+	seq3, err := s.NextSeq()
+	require.NoError(t, err)
+	assert.Greater(t, seq3, seq2)
+
+	require.NoError(t, s.Delete("job-2"))
+	_, err = os.Stat(s.Path("in-2.jsonl"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.ErrorIs(t, s.Delete("job-2"), ErrNotFound)
+
+	require.NoError(t, s.Close())
+	assert.Error(t, s.Check())
+}
+
+func TestRecover(t *testing.T) {
+	s, err := Open(t.TempDir())
+	require.NoError(t, err)
+	defer s.Close()
+
+	for i, rec := range []*Record{
+		{ID: "done", Status: Done, Attempts: 1},
+		{ID: "interrupted", Status: Running, Attempts: 1},
+		{ID: "queued", Status: Queued},
+		{ID: "crashing", Status: Running, Attempts: 3},
+	} {
+		rec.Seq = uint64(i + 1)
+		require.NoError(t, s.Put(rec))
+	}
+
+	pending, err := s.Recover(3)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, "interrupted", pending[0].ID)
+	assert.Equal(t, Queued, pending[0].Status)
+	assert.Equal(t, "queued", pending[1].ID)
+
+	rec, err := s.Get("crashing")
+	require.NoError(t, err)
+	assert.Equal(t, Failed, rec.Status)
+	rec, err = s.Get("interrupted")
+	require.NoError(t, err)
+	assert.Equal(t, Queued, rec.Status)
+}
@@ -0,0 +1,162 @@
+// Package jsonl defines the JSON Lines (NDJSON) record format for
+// sequences and reads: one JSON object per line,
+//
+//	{"id":"r1","description":"lane 1","seq":"ACGT","qual":"IIII","metadata":{"read_group":"A"}}
+//
+// id and seq are required; qual holds Phred+33 qualities of the same
+// length as seq and is set for reads only; description and metadata are
+// optional. Unlike FASTA and FASTQ, metadata survives a round trip, which
+// makes the format suitable as an intermediate between pipeline stages
+// and for streaming records from the server, since each line can be
+// written and read on its own.
+//
+// Comparison with Aria:
+//
+//	Aria would derive the record codec from the type:
+//	  @derive(Json)
+//	  struct Record
+//	    id: String where id.len() > 0
+//	    seq: String where seq.len() > 0
+//	    qual: Option<String> where qual.map(|q| q.len() == seq.len())
+//
+//	Go declares the schema with struct tags and checks the invariants
+//	in Validate.
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MediaType is the content type of a JSON Lines stream.
+const MediaType = "application/x-ndjson"
+
+// maxLine is the longest line a Reader accepts.
+const maxLine = 1 << 30
+
+// Record is one sequence or read.
+type Record struct {
+	ID          string            `json:"id"`
+	Description string            `json:"description,omitempty"`
+	Seq         string            `json:"seq"`
+	Qual        string            `json:"qual,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// Validate checks the invariants of the schema.
+func (r *Record) Validate() error {
+	if r.ID == "" {
+		return errors.New("record has no id")
+	}
+	if r.Seq == "" {
+		return fmt.Errorf("record %s has no seq", r.ID)
+	}
+	if r.Qual != "" && len(r.Qual) != len(r.Seq) {
+		return fmt.Errorf("record %s: qual length %d does not match seq length %d", r.ID, len(r.Qual), len(r.Seq))
+	}
+	return nil
+}
+
+// Reader reads records one line at a time. Blank lines are skipped.
+type Reader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+// NewReader creates a record reader.
+func NewReader(r io.Reader) *Reader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxLine)
+	return &Reader{s: s}
+}
+
+// Line returns the number of lines consumed so far.
+func (r *Reader) Line() int {
+	return r.line
+}
+
+// Next returns the next record, or io.EOF when there are no more. Fields
+// outside the schema are errors, so that misspelled keys are not
+// silently dropped.
+//
+// Aria equivalent:
+//
+//	fn next(self) -> Result<Option<Record>, JsonlError>
+//	  ensures result.is_ok() implies result.unwrap().all(|r| r.validate().is_ok())
+func (r *Reader) Next() (*Record, error) {
+	for r.s.Scan() {
+		r.line++
+		line := bytes.TrimSpace(r.s.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.DisallowUnknownFields()
+		var rec Record
+		if err := dec.Decode(&rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		if dec.More() {
+			return nil, fmt.Errorf("line %d: more than one value", r.line)
+		}
+		if err := rec.Validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		return &rec, nil
+	}
+	if err := r.s.Err(); err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+	return nil, io.EOF
+}
+
+// ReadAll reads all remaining records.
+func ReadAll(r io.Reader) ([]*Record, error) {
+	reader := NewReader(r)
+	records := make([]*Record, 0)
+	for {
+		rec, err := reader.Next()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+}
+
+// Writer writes records one per line. Output is buffered; call Flush when
+// done, or after each record when streaming.
+type Writer struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewWriter creates a record writer.
+func NewWriter(w io.Writer) *Writer {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	return &Writer{w: bw, enc: enc}
+}
+
+// Write validates a record and writes it as one line.
+func (w *Writer) Write(rec *Record) error {
+	if err := rec.Validate(); err != nil {
+		return err
+	}
+	if err := w.enc.Encode(rec); err != nil {
+		return fmt.Errorf("writing record: %w", err)
+	}
+	return nil
+}
+
+// Flush writes any buffered records to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
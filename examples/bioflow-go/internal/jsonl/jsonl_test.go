@@ -0,0 +1,61 @@
+package jsonl
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	records := []*Record{
+		{ID: "r1", Description: "lane <1>", Seq: "ACGT", Qual: "II#I", Metadata: map[string]string{"rg.id": "A", "well": "B2"}},
+		{ID: "s1", Seq: "GATTACA"},
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, rec := range records {
+		require.NoError(t, w.Write(rec))
+	}
+	require.NoError(t, w.Flush())
+	assert.Equal(t, `{"id":"r1","description":"lane <1>","seq":"ACGT","qual":"II#I","metadata":{"rg.id":"A","well":"B2"}}
+{"id":"s1","seq":"GATTACA"}
+`, buf.String())
+
+	got, err := ReadAll(strings.NewReader("\n" + buf.String() + "\n"))
+	require.NoError(t, err)
+	assert.Equal(t, records, got)
+
+	assert.Error(t, w.Write(&Record{ID: "bad", Seq: "ACGT", Qual: "II"}), "invalid records are not written")
+}
+
+func TestReader(t *testing.T) {
+	r := NewReader(strings.NewReader(`{"id":"a","seq":"AC"}` + "\n\n" + `{"id":"b","seq":"GT"}`))
+	rec, err := r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "a", rec.ID)
+	rec, err = r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "b", rec.ID)
+	assert.Equal(t, 3, r.Line())
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+
+	for name, line := range map[string]string{
+		"not json":      `id=a seq=AC`,
+		"unknown field": `{"id":"a","sequence":"AC"}`,
+		"two values":    `{"id":"a","seq":"AC"} {"id":"b","seq":"GT"}`,
+		"no id":         `{"seq":"AC"}`,
+		"no seq":        `{"id":"a"}`,
+		"qual length":   `{"id":"a","seq":"ACGT","qual":"III"}`,
+		"wrong type":    `{"id":"a","seq":"AC","metadata":{"lane":1}}`,
+	} {
+		_, err := ReadAll(strings.NewReader(`{"id":"ok","seq":"A"}` + "\n" + line + "\n"))
+		if assert.Error(t, err, name) {
+			assert.Contains(t, err.Error(), "line 2", name)
+		}
+	}
+}
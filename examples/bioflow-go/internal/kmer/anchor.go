@@ -0,0 +1,294 @@
+package kmer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Anchor is an exact k-mer match between two sequences. Pos1 and Pos2 are
+// the 0-based starts of the k-mer on the forward strands of seq1 and seq2;
+// Strand is '-' when the seq1 k-mer matches the reverse complement of the
+// seq2 k-mer.
+type Anchor struct {
+	Pos1   int  `json:"pos1"`
+	Pos2   int  `json:"pos2"`
+	Strand byte `json:"-"`
+}
+
+// ErrTooManyAnchors is returned when two sequences share more k-mer
+// positions than AnchorOptions.MaxAnchors allows. Use errors.Is to detect
+// it.
+var ErrTooManyAnchors = errors.New("too many anchors")
+
+// AnchorOptions configures anchor finding.
+type AnchorOptions struct {
+	K int
+	// BothStrands also reports reverse-complement matches.
+	BothStrands bool
+	// MaxOccurrences skips k-mers occurring more than this many times in
+	// seq2, which would otherwise flood the result with repeat anchors.
+	// Zero means no limit.
+	MaxOccurrences int
+	// MaxAnchors stops anchor finding with an error wrapping
+	// ErrTooManyAnchors once more than this many anchors are found, so
+	// that low-complexity input cannot build |seq1|×|seq2| anchors. Zero
+	// means no limit.
+	MaxAnchors int
+	// SkipSoftMasked skips k-mers overlapping soft-masked bases of either
+	// sequence (see SoftMaskedRuns). Alignments guided by the anchors
+	// still cover those bases.
+	SkipSoftMasked bool
+}
+
+// FindAnchors returns the positions of all k-mers shared by seq1 and seq2
+// (the positional counterpart of SharedKMers), sorted by strand, then
+// Pos1, then Pos2. K-mers containing N are ignored, and runs of N are
+// skipped whole.
+//
+// Aria equivalent:
+//
+//	fn find_anchors(seq1: Sequence, seq2: Sequence, options: AnchorOptions) -> Result<[Anchor], KMerError>
+//	  requires options.k > 0
+//	  ensures result.all(|a| seq1.bases[a.pos1..a.pos1+k] matches seq2 at a.pos2 on a.strand)
+func FindAnchors(seq1, seq2 *sequence.Sequence, opts AnchorOptions) ([]Anchor, error) {
+	return FindAnchorsContext(context.Background(), seq1, seq2, opts)
+}
+
+// FindAnchorsContext finds anchors as FindAnchors does, and stops with the
+// context's error once ctx is done.
+func FindAnchorsContext(ctx context.Context, seq1, seq2 *sequence.Sequence, opts AnchorOptions) ([]Anchor, error) {
+	k := opts.K
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be positive")
+	}
+	anchors := make([]Anchor, 0)
+	if k > seq1.Len() || k > seq2.Len() {
+		return anchors, nil
+	}
+
+	b1 := strings.ToUpper(seq1.Bases)
+	b2 := strings.ToUpper(seq2.Bases)
+	var soft1, soft2 []sequence.MaskRun
+	if opts.SkipSoftMasked {
+		soft1, soft2 = SoftMaskedRuns(seq1), SoftMaskedRuns(seq2)
+	}
+	index := make(map[string][]int)
+	eachWindow(b2, k, soft2, func(j int) {
+		kmer := b2[j : j+k]
+		index[kmer] = append(index[kmer], j)
+	})
+	if opts.MaxOccurrences > 0 {
+		for kmer, positions := range index {
+			if len(positions) > opts.MaxOccurrences {
+				delete(index, kmer)
+			}
+		}
+	}
+
+	rc1 := ""
+	if opts.BothStrands {
+		rc1 = reverseComplementString(b1)
+	}
+	n1 := len(b1)
+	var err error
+	windows := 0
+	eachWindow(b1, k, soft1, func(i int) {
+		if err != nil {
+			return
+		}
+		if windows++; windows%countBlock == 0 {
+			if err = ctx.Err(); err != nil {
+				err = fmt.Errorf("finding anchors: %w", err)
+				return
+			}
+		}
+		for _, j := range index[b1[i:i+k]] {
+			anchors = append(anchors, Anchor{Pos1: i, Pos2: j, Strand: '+'})
+		}
+		if opts.BothStrands {
+			for _, j := range index[rc1[n1-i-k:n1-i]] {
+				anchors = append(anchors, Anchor{Pos1: i, Pos2: j, Strand: '-'})
+			}
+		}
+		if opts.MaxAnchors > 0 && len(anchors) > opts.MaxAnchors {
+			err = fmt.Errorf("%w: more than %d shared k-mer positions", ErrTooManyAnchors, opts.MaxAnchors)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("finding anchors: %w", err)
+	}
+
+	sortAnchors(anchors)
+	return anchors, nil
+}
+
+// sortAnchors orders anchors by strand, then Pos1, then Pos2.
+func sortAnchors(anchors []Anchor) {
+	sort.Slice(anchors, func(a, b int) bool {
+		x, y := anchors[a], anchors[b]
+		if x.Strand != y.Strand {
+			return x.Strand < y.Strand
+		}
+		if x.Pos1 != y.Pos1 {
+			return x.Pos1 < y.Pos1
+		}
+		return x.Pos2 < y.Pos2
+	})
+}
+
+// ChainOptions configures collinear chaining of anchors.
+type ChainOptions struct {
+	// K is the anchor length.
+	K int
+	// MaxGap is the largest distance allowed between consecutive anchors
+	// on either sequence (default 5000).
+	MaxGap int
+	// MaxPredecessors bounds how many preceding anchors are tried for
+	// each anchor (default 50), keeping chaining near-linear.
+	MaxPredecessors int
+	// MinAnchors is the minimum number of anchors in a reported chain
+	// (default 3).
+	MinAnchors int
+}
+
+// DefaultChainOptions returns chaining defaults for anchors of length k.
+func DefaultChainOptions(k int) ChainOptions {
+	return ChainOptions{K: k, MaxGap: 5000, MaxPredecessors: 50, MinAnchors: 3}
+}
+
+// Chain is a collinear run of anchors. Coordinates are 0-based,
+// half-open on the forward strands; on the '-' strand Pos2 decreases
+// along the chain.
+type Chain struct {
+	Anchors []Anchor `json:"anchors"`
+	Strand  byte     `json:"-"`
+	Score   float64  `json:"score"`
+	Start1  int      `json:"start1"`
+	End1    int      `json:"end1"`
+	Start2  int      `json:"start2"`
+	End2    int      `json:"end2"`
+}
+
+// ChainAnchors groups anchors into collinear chains by dynamic
+// programming, as in minimap2: an anchor extends a preceding one on the
+// same strand when both coordinates advance, gaining the newly covered
+// bases and paying a penalty that grows with the diagonal shift. Each
+// anchor is used in at most one chain; chains are returned best first.
+//
+// Aria equivalent:
+//
+//	fn chain_anchors(anchors: [Anchor], options: ChainOptions) -> Result<[Chain], KMerError>
+//	  requires options.k > 0
+//	  ensures result.all(|c| c.anchors.len() >= options.min_anchors)
+//	  ensures result.is_sorted_by(|a, b| a.score >= b.score)
+func ChainAnchors(anchors []Anchor, opts ChainOptions) ([]Chain, error) {
+	if opts.K <= 0 {
+		return nil, fmt.Errorf("k must be positive")
+	}
+	defaults := DefaultChainOptions(opts.K)
+	if opts.MaxGap <= 0 {
+		opts.MaxGap = defaults.MaxGap
+	}
+	if opts.MaxPredecessors <= 0 {
+		opts.MaxPredecessors = defaults.MaxPredecessors
+	}
+	if opts.MinAnchors <= 0 {
+		opts.MinAnchors = defaults.MinAnchors
+	}
+
+	sorted := make([]Anchor, len(anchors))
+	copy(sorted, anchors)
+	sortAnchors(sorted)
+
+	n := len(sorted)
+	k := float64(opts.K)
+	score := make([]float64, n)
+	prev := make([]int, n)
+	for i, a := range sorted {
+		score[i], prev[i] = k, -1
+		for j := i - 1; j >= 0 && i-j <= opts.MaxPredecessors; j-- {
+			b := sorted[j]
+			if b.Strand != a.Strand {
+				break
+			}
+			dx := a.Pos1 - b.Pos1
+			dy := a.Pos2 - b.Pos2
+			if a.Strand == '-' {
+				dy = -dy
+			}
+			if dx <= 0 || dy <= 0 || dx > opts.MaxGap || dy > opts.MaxGap {
+				continue
+			}
+			gain := math.Min(math.Min(float64(dx), float64(dy)), k)
+			shift := dx - dy
+			if shift < 0 {
+				shift = -shift
+			}
+			penalty := 0.0
+			if shift > 0 {
+				penalty = 0.01*k*float64(shift) + 0.5*math.Log2(float64(shift))
+			}
+			if s := score[j] + gain - penalty; s > score[i] {
+				score[i], prev[i] = s, j
+			}
+		}
+	}
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return score[order[a]] > score[order[b]] })
+
+	used := make([]bool, n)
+	chains := make([]Chain, 0)
+	for _, end := range order {
+		if used[end] {
+			continue
+		}
+		var members []int
+		for i := end; i >= 0 && !used[i]; i = prev[i] {
+			members = append(members, i)
+		}
+		for _, i := range members {
+			used[i] = true
+		}
+		if len(members) < opts.MinAnchors {
+			continue
+		}
+		// A chain that ran into an already-used anchor only owns the
+		// score gained after it.
+		first := members[len(members)-1]
+		chainScore := score[end]
+		if prev[first] >= 0 {
+			chainScore -= score[prev[first]]
+		}
+
+		c := Chain{Strand: sorted[end].Strand, Score: chainScore, Anchors: make([]Anchor, 0, len(members))}
+		for m := len(members) - 1; m >= 0; m-- {
+			c.Anchors = append(c.Anchors, sorted[members[m]])
+		}
+		c.Start1 = c.Anchors[0].Pos1
+		c.End1 = c.Anchors[len(c.Anchors)-1].Pos1 + opts.K
+		c.Start2, c.End2 = c.Anchors[0].Pos2, c.Anchors[0].Pos2+opts.K
+		for _, a := range c.Anchors {
+			if a.Pos2 < c.Start2 {
+				c.Start2 = a.Pos2
+			}
+			if a.Pos2+opts.K > c.End2 {
+				c.End2 = a.Pos2 + opts.K
+			}
+		}
+		chains = append(chains, c)
+	}
+	return chains, nil
+}
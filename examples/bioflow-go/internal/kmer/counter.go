@@ -1,7 +1,9 @@
 // Package kmer provides k-mer counting and analysis functionality.
 //
 // K-mers are subsequences of length k. This package provides efficient
-// counting, frequency analysis, and distance calculations.
+// counting, frequency analysis, and distance calculations. Counter keys
+// k-mers by string; PackedCounter keys k-mers of up to 32 bases by their
+// 2-bit packed codes, for large inputs.
 //
 // Comparison with Aria:
 //
@@ -13,6 +15,7 @@
 package kmer
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strings"
@@ -111,6 +114,20 @@ type Counter struct {
 	K      int
 	Counts map[string]int
 	Total  int
+	// Seed is the spaced-seed pattern the k-mers were extracted with, or
+	// empty for contiguous k-mers. K is then the seed weight.
+	Seed string
+	// Canonical is set when each k-mer was counted together with its
+	// reverse complement, under the lexicographically smaller form.
+	Canonical bool
+	// SkipSoftMasked leaves out k-mers overlapping soft-masked bases,
+	// so that repeats do not dominate the counts.
+	SkipSoftMasked bool
+	// Strand selects the strands counted without merging reverse
+	// complements: the forward strand (the zero value), the reverse
+	// strand, whose k-mers are the reverse complements of the forward
+	// ones, or both. It does not apply to canonical counts.
+	Strand sequence.Strand
 }
 
 // NewCounter creates a new k-mer counter with the specified k value.
@@ -154,21 +171,87 @@ func (c *Counter) Add(kmer string, count int) error {
 	return nil
 }
 
-// CountKMers counts all k-mers in a sequence string.
+// CountKMers counts all k-mers in a sequence string, skipping those
+// with an N and, with SkipSoftMasked, those with lower-case bases.
 func (c *Counter) CountKMers(seq string) {
-	seq = strings.ToUpper(seq)
-	for i := 0; i <= len(seq)-c.K; i++ {
-		kmer := seq[i : i+c.K]
-		if !strings.ContainsRune(kmer, 'N') {
-			c.Counts[kmer]++
-			c.Total++
-		}
+	var soft []sequence.MaskRun
+	if c.SkipSoftMasked {
+		soft = lowerCaseRuns(seq)
 	}
+	c.count(seq, soft)
 }
 
-// CountFromSequence counts all k-mers from a Sequence object.
+// CountFromSequence counts all k-mers from a Sequence object. With
+// SkipSoftMasked, k-mers overlapping its SoftMaskedRuns are skipped.
 func (c *Counter) CountFromSequence(seq *sequence.Sequence) {
-	c.CountKMers(seq.Bases)
+	var soft []sequence.MaskRun
+	if c.SkipSoftMasked {
+		soft = SoftMaskedRuns(seq)
+	}
+	c.count(seq.Bases, soft)
+}
+
+// CountFromSequenceContext adds the k-mers of a sequence as
+// CountFromSequence does, a block of windows at a time, and stops with
+// the context's error once ctx is done. The counts are then partial.
+func (c *Counter) CountFromSequenceContext(ctx context.Context, seq *sequence.Sequence) error {
+	var soft []sequence.MaskRun
+	if c.SkipSoftMasked {
+		soft = SoftMaskedRuns(seq)
+	}
+	bases := seq.Bases
+	for start := 0; start < len(bases); start += countBlock {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("counting k-mers: %w", err)
+		}
+		// The block holds the windows starting in [start, start+countBlock).
+		end := min(start+countBlock+c.K-1, len(bases))
+		c.count(bases[start:end], clipRuns(soft, start, end))
+	}
+	return nil
+}
+
+// countBlock is the number of windows counted between checks of the
+// context.
+const countBlock = 1 << 16
+
+// clipRuns returns the parts of runs within [start, end), shifted to
+// start at 0.
+func clipRuns(runs []sequence.MaskRun, start, end int) []sequence.MaskRun {
+	var clipped []sequence.MaskRun
+	for _, r := range runs {
+		if r.End > start && r.Start < end {
+			clipped = append(clipped, sequence.MaskRun{Start: max(r.Start, start) - start, End: min(r.End, end) - start})
+		}
+	}
+	return clipped
+}
+
+// count adds the k-mers of bases clear of N and of the soft runs, on the
+// strands of c.Strand.
+func (c *Counter) count(bases string, soft []sequence.MaskRun) {
+	bases = strings.ToUpper(bases)
+	strand := c.strand()
+	forward, reverse := strand.Includes(sequence.Forward), strand.Includes(sequence.Reverse)
+	eachWindow(bases, c.K, soft, func(i int) {
+		window := bases[i : i+c.K]
+		if forward {
+			c.Counts[window]++
+			c.Total++
+		}
+		if reverse {
+			c.Counts[reverseComplementString(window)]++
+			c.Total++
+		}
+	})
+}
+
+// strand returns the strands counted, Forward when Strand is unset.
+func (c *Counter) strand() sequence.Strand {
+	if c.Strand == 0 {
+		return sequence.Forward
+	}
+	return c.Strand
 }
 
 // GetCount returns the count for a specific k-mer.
@@ -298,6 +381,18 @@ func (c *Counter) Merge(other *Counter) error {
 	if c.K != other.K {
 		return fmt.Errorf("k values must match")
 	}
+	if c.Seed != other.Seed {
+		return fmt.Errorf("spaced seeds must match")
+	}
+	if c.Canonical != other.Canonical {
+		return fmt.Errorf("canonical and strand-specific counts cannot be merged")
+	}
+	if c.SkipSoftMasked != other.SkipSoftMasked {
+		return fmt.Errorf("masked and unmasked counts cannot be merged")
+	}
+	if !c.Canonical && c.strand() != other.strand() {
+		return fmt.Errorf("counts of different strands cannot be merged")
+	}
 
 	for kmer, count := range other.Counts {
 		c.Counts[kmer] += count
@@ -307,6 +402,9 @@ func (c *Counter) Merge(other *Counter) error {
 }
 
 func (c *Counter) String() string {
+	if c.Seed != "" {
+		return fmt.Sprintf("KMerCounter { seed: %s, k: %d, unique: %d, total: %d }", c.Seed, c.K, c.UniqueCount(), c.Total)
+	}
 	return fmt.Sprintf("KMerCounter { k: %d, unique: %d, total: %d }", c.K, c.UniqueCount(), c.Total)
 }
 
@@ -334,6 +432,89 @@ func CountKMers(seq *sequence.Sequence, k int) (*Counter, error) {
 	return counter, nil
 }
 
+// CountKMersContext counts the k-mers of a sequence as CountKMers does,
+// and stops with the context's error once ctx is done, so that a caller
+// giving up on a long sequence does not leave the count running.
+//
+// Aria equivalent:
+//
+//	fn count_kmers_context(ctx: Context, sequence: Sequence, k: Int) -> Result<KMerCounts, CountError>
+//	  requires k > 0
+//	  requires k <= sequence.len()
+//	  ensures result.is_ok() implies result.unwrap() == count_kmers(sequence, k)
+func CountKMersContext(ctx context.Context, seq *sequence.Sequence, k int) (*Counter, error) {
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be positive")
+	}
+	if k > seq.Len() {
+		return nil, fmt.Errorf("k cannot exceed sequence length")
+	}
+
+	counter, err := NewCounter(k)
+	if err != nil {
+		return nil, err
+	}
+	if err := counter.CountFromSequenceContext(ctx, seq); err != nil {
+		return nil, err
+	}
+	return counter, nil
+}
+
+// CountUnmaskedKMers counts the k-mers of a sequence that overlap no
+// soft-masked base, as seeds for repeat-masked genomes.
+//
+// Aria equivalent:
+//
+//	fn count_unmasked_kmers(sequence: Sequence, k: Int) -> KMerCounts
+//	  requires k > 0
+//	  requires k <= sequence.len()
+//	  ensures result.skip_soft_masked
+func CountUnmaskedKMers(seq *sequence.Sequence, k int) (*Counter, error) {
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be positive")
+	}
+	if k > seq.Len() {
+		return nil, fmt.Errorf("k cannot exceed sequence length")
+	}
+
+	counter, err := NewCounter(k)
+	if err != nil {
+		return nil, err
+	}
+	counter.SkipSoftMasked = true
+	counter.CountFromSequence(seq)
+	return counter, nil
+}
+
+// CountStrandedKMers counts the k-mers of the strands selected, keeping
+// each apart from its reverse complement, as strand-specific (e.g.
+// RNA-seq) analyses need. With skipSoftMasked, k-mers overlapping
+// soft-masked bases are left out as CountUnmaskedKMers does.
+//
+// Aria equivalent:
+//
+//	fn count_stranded_kmers(sequence: Sequence, k: Int, strand: Strand, skip_soft_masked: Bool) -> KMerCounts
+//	  requires k > 0
+//	  requires k <= sequence.len()
+//	  ensures !result.canonical
+func CountStrandedKMers(seq *sequence.Sequence, k int, strand sequence.Strand, skipSoftMasked bool) (*Counter, error) {
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be positive")
+	}
+	if k > seq.Len() {
+		return nil, fmt.Errorf("k cannot exceed sequence length")
+	}
+
+	counter, err := NewCounter(k)
+	if err != nil {
+		return nil, err
+	}
+	counter.Strand = strand
+	counter.SkipSoftMasked = skipSoftMasked
+	counter.CountFromSequence(seq)
+	return counter, nil
+}
+
 // MostFrequentKMers returns the n most frequent k-mers.
 //
 // Aria equivalent:
@@ -453,17 +634,13 @@ func CountKMersCanonical(seq *sequence.Sequence, k int) (*Counter, error) {
 	if err != nil {
 		return nil, err
 	}
+	counter.Canonical = true
 
-	for i := 0; i <= seq.Len()-k; i++ {
-		kmerStr := seq.Bases[i : i+k]
-
-		if !strings.ContainsRune(kmerStr, 'N') {
-			km, _ := NewKMer(kmerStr)
-			canonical := km.Canonical()
-			counter.Counts[canonical.Sequence]++
-			counter.Total++
-		}
-	}
+	eachWindow(seq.Bases, k, nil, func(i int) {
+		km, _ := NewKMer(seq.Bases[i : i+k])
+		counter.Counts[km.Canonical().Sequence]++
+		counter.Total++
+	})
 
 	return counter, nil
 }
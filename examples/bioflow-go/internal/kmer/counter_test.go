@@ -1,9 +1,13 @@
 package kmer
 
 import (
+	"context"
+	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/aria-lang/bioflow-go/internal/track"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -158,6 +162,98 @@ func TestCountKMersFromSequence(t *testing.T) {
 	assert.Equal(t, 7, counter.Total)
 }
 
+func TestCountStrandedKMers(t *testing.T) {
+	seq, err := sequence.New("AACGNTTG")
+	require.NoError(t, err)
+
+	forward, err := CountStrandedKMers(seq, 3, sequence.Forward, false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"AAC": 1, "ACG": 1, "TTG": 1}, forward.Counts)
+
+	reverse, err := CountStrandedKMers(seq, 3, sequence.Reverse, false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"GTT": 1, "CGT": 1, "CAA": 1}, reverse.Counts)
+	assert.False(t, reverse.Canonical)
+
+	both, err := CountStrandedKMers(seq, 3, sequence.BothStrands, false)
+	require.NoError(t, err)
+	assert.Equal(t, 6, both.Total)
+	assert.Equal(t, 1, both.Counts["CAA"])
+
+	// The default counter counts the forward strand.
+	plain, err := CountKMers(seq, 3)
+	require.NoError(t, err)
+	assert.Equal(t, forward.Counts, plain.Counts)
+	require.NoError(t, plain.Merge(forward))
+	assert.Error(t, forward.Merge(reverse))
+}
+
+func TestEncodeDecode(t *testing.T) {
+	code, err := Encode("acgt")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0x1b), code)
+	assert.Equal(t, "ACGT", Decode(code, 4))
+	assert.Equal(t, "ACGT", Decode(ReverseComplementCode(code, 4), 4))
+
+	long := strings.Repeat("GATTACA", 4) + "CGTT"
+	code, err = Encode(long)
+	require.NoError(t, err)
+	assert.Equal(t, long, Decode(code, 32))
+	assert.Equal(t, reverseComplementString(long), Decode(ReverseComplementCode(code, 32), 32))
+
+	rolled, err := Roll(code, 32, 'a')
+	require.NoError(t, err)
+	assert.Equal(t, long[1:]+"A", Decode(rolled, 32))
+
+	_, err = Encode(long + "A")
+	assert.Error(t, err)
+	_, err = Encode("ACNT")
+	assert.Error(t, err)
+	_, err = Roll(code, 32, 'N')
+	assert.Error(t, err)
+}
+
+func TestPackedCounter(t *testing.T) {
+	seq, err := sequence.New("AACGNTTGacgTTGAC")
+	require.NoError(t, err)
+
+	// Packed counts match string-keyed ones on every strand setting.
+	for _, strand := range []sequence.Strand{sequence.Forward, sequence.Reverse, sequence.BothStrands} {
+		for _, skip := range []bool{false, true} {
+			want, err := CountStrandedKMers(seq, 3, strand, skip)
+			require.NoError(t, err)
+			packed, err := CountPackedKMers(seq, 3, strand, skip)
+			require.NoError(t, err)
+			assert.Equal(t, want.Counts, packed.Unpack().Counts, "strand %s, skip %v", strand, skip)
+			assert.Equal(t, want.Total, packed.Total)
+
+			repacked, err := Pack(want)
+			require.NoError(t, err)
+			assert.Equal(t, packed.Counts, repacked.Counts)
+		}
+	}
+
+	canonical, err := NewPackedCounter(3)
+	require.NoError(t, err)
+	canonical.Canonical = true
+	canonical.CountFromSequence(seq)
+	want, err := CountKMersCanonical(seq, 3)
+	require.NoError(t, err)
+	assert.Equal(t, want.Counts, canonical.Unpack().Counts)
+	n, err := canonical.GetCount("CAA")
+	require.NoError(t, err)
+	assert.Equal(t, want.Counts["CAA"], n)
+
+	// ACG and its reverse complement CGT occur three times; four k-mers
+	// occur twice, and the tie goes to the first in sort order.
+	top, err := canonical.MostFrequent(2)
+	require.NoError(t, err)
+	assert.Equal(t, []KMerCount{{KMer: "ACG", Count: 3}, {KMer: "AAC", Count: 2}}, top)
+
+	_, err = NewPackedCounter(33)
+	assert.Error(t, err)
+}
+
 func TestFindUniqueKMers(t *testing.T) {
 	seq, err := sequence.New("ATGCATGC")
 	require.NoError(t, err)
@@ -281,6 +377,463 @@ func TestEstimateGenomeSize(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestParseSpacedSeed(t *testing.T) {
+	seed, err := ParseSpacedSeed("1101101")
+	require.NoError(t, err)
+	assert.Equal(t, 7, seed.Span)
+	assert.Equal(t, 5, seed.Weight)
+	assert.False(t, seed.IsContiguous())
+
+	kmer, err := seed.Extract("ACGTACG")
+	require.NoError(t, err)
+	assert.Equal(t, "ACTAG", kmer)
+	_, err = seed.Extract("ACG")
+	assert.Error(t, err)
+
+	for _, bad := range []string{"", "0110", "1100", "1201"} {
+		_, err := ParseSpacedSeed(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestCountSpacedKMers(t *testing.T) {
+	seq, err := sequence.New("ACGTACG")
+	require.NoError(t, err)
+	seed, err := ParseSpacedSeed("101")
+	require.NoError(t, err)
+
+	counter, err := CountSpacedKMers(seq, seed)
+	require.NoError(t, err)
+	assert.Equal(t, 2, counter.K)
+	assert.Equal(t, "101", counter.Seed)
+	assert.Equal(t, 5, counter.Total)
+	assert.Equal(t, 2, counter.Counts["AG"])
+
+	short, err := sequence.New("ACGT")
+	require.NoError(t, err)
+	canonical, err := CountSpacedKMersCanonical(short, seed)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"AG": 2}, canonical.Counts)
+
+	// An all-ones seed is an ordinary k-mer count.
+	contiguous, err := ContiguousSeed(3)
+	require.NoError(t, err)
+	spaced, err := CountSpacedKMers(seq, contiguous)
+	require.NoError(t, err)
+	plain, err := CountKMers(seq, 3)
+	require.NoError(t, err)
+	assert.Equal(t, plain.Counts, spaced.Counts)
+	assert.Empty(t, spaced.Seed)
+
+	assert.Error(t, spaced.Merge(counter))
+	_, err = CountSpacedKMers(seq, &SpacedSeed{Pattern: "1000000001", Span: 10, Weight: 2})
+	assert.Error(t, err)
+}
+
+func TestSpacedDistance(t *testing.T) {
+	seq1, err := sequence.New("ACGTACGTTA")
+	require.NoError(t, err)
+	// A substitution at every third position, under the seed's '0'.
+	seq2, err := sequence.New("ACCTAGGTGA")
+	require.NoError(t, err)
+
+	seed, err := ParseSpacedSeed("11011")
+	require.NoError(t, err)
+
+	contiguous, err := JaccardDistance(seq1, seq2, 4)
+	require.NoError(t, err)
+	spaced, err := SpacedJaccardDistance(seq1, seq2, seed)
+	require.NoError(t, err)
+	assert.Less(t, spaced, contiguous)
+
+	self, err := SpacedJaccardDistance(seq1, seq1, seed)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, self)
+
+	cos, err := SpacedCosineDistance(seq1, seq1, seed)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.0, cos, 1e-9)
+
+	euc, err := SpacedEuclideanDistance(seq1, seq2, seed)
+	require.NoError(t, err)
+	assert.Greater(t, euc, 0.0)
+
+	matrix, err := SpacedSimilarityMatrix([]*sequence.Sequence{seq1, seq2}, seed)
+	require.NoError(t, err)
+	assert.Equal(t, spaced, matrix[0][1])
+}
+
+func TestKMerHashesCanonical(t *testing.T) {
+	fwd, valid := kmerHashes("ACGTTGCA", 5, true)
+	rev, _ := kmerHashes("TGCAACGT", 5, true)
+	require.Len(t, fwd, 4)
+	assert.True(t, valid[0])
+	for i := range fwd {
+		assert.Equal(t, fwd[i], rev[len(rev)-1-i])
+	}
+
+	_, valid = kmerHashes("ACGNACGTA", 3, false)
+	assert.Equal(t, []bool{true, false, false, false, true, true, true}, valid)
+}
+
+func TestSyncmers(t *testing.T) {
+	seq, err := sequence.New("ACGTTGCATGCCATGACGTTAGCATGCANNACGTGCA")
+	require.NoError(t, err)
+
+	all, err := Syncmers(seq, SyncmerOptions{K: 8, S: 3, Closed: true})
+	require.NoError(t, err)
+	require.NotEmpty(t, all)
+	for _, m := range all {
+		assert.Len(t, m.KMer, 8)
+		assert.NotContains(t, m.KMer, "N")
+	}
+
+	// Every k-mer has its minimal s-mer at exactly one offset, so the
+	// open syncmers over all offsets partition the valid k-mers.
+	total := 0
+	for offset := 0; offset <= 5; offset++ {
+		open, err := Syncmers(seq, SyncmerOptions{K: 8, S: 3, Offset: offset})
+		require.NoError(t, err)
+		total += len(open)
+	}
+	_, valid := kmerHashes(seq.Bases, 8, false)
+	expected := 0
+	for _, v := range valid {
+		if v {
+			expected++
+		}
+	}
+	assert.Equal(t, expected, total)
+
+	_, err = Syncmers(seq, SyncmerOptions{K: 8, S: 8})
+	assert.Error(t, err)
+	_, err = Syncmers(seq, SyncmerOptions{K: 8, S: 3, Offset: 6})
+	assert.Error(t, err)
+}
+
+func TestRandstrobes(t *testing.T) {
+	seq, err := sequence.New("ACGTTGCATGCCATGACGTTAGCATGCATTACGTGCAGGCTAGCTAGGATCCA")
+	require.NoError(t, err)
+
+	opts := RandstrobeOptions{Order: 2, K: 5, WMin: 3, WMax: 8}
+	strobes, err := Randstrobes(seq, opts)
+	require.NoError(t, err)
+	require.NotEmpty(t, strobes)
+	for _, r := range strobes {
+		require.Len(t, r.Positions, 2)
+		gap := r.Positions[1] - r.Positions[0]
+		assert.GreaterOrEqual(t, gap, opts.WMin)
+		assert.LessOrEqual(t, gap, opts.WMax)
+		assert.LessOrEqual(t, r.End(opts.K), seq.Len())
+	}
+
+	again, err := Randstrobes(seq, opts)
+	require.NoError(t, err)
+	assert.Equal(t, strobes, again)
+
+	opts.Order = 3
+	strobes, err = Randstrobes(seq, opts)
+	require.NoError(t, err)
+	require.NotEmpty(t, strobes)
+	for _, r := range strobes {
+		require.Len(t, r.Positions, 3)
+		assert.Less(t, r.Positions[1], r.Positions[2])
+	}
+
+	opts.Order = 4
+	_, err = Randstrobes(seq, opts)
+	assert.Error(t, err)
+}
+
+func TestHyperLogLog(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	bases := make([]byte, 50000)
+	for i := range bases {
+		bases[i] = "ACGT"[rng.Intn(4)]
+	}
+	seq, err := sequence.New(string(bases))
+	require.NoError(t, err)
+	exact, err := CountKMersCanonical(seq, 21)
+	require.NoError(t, err)
+
+	hll, err := NewHyperLogLog(21, DefaultHLLPrecision, true)
+	require.NoError(t, err)
+	hll.AddSequence(seq.Bases)
+	est := hll.Estimate()
+	assert.Equal(t, int64(exact.Total), est.Total)
+	assert.InEpsilon(t, float64(exact.UniqueCount()), est.Distinct, 3*est.StandardError)
+	assert.LessOrEqual(t, est.Lower, float64(exact.UniqueCount()))
+	assert.GreaterOrEqual(t, est.Upper, float64(exact.UniqueCount()))
+
+	// Small cardinalities are counted almost exactly.
+	small, err := NewHyperLogLog(3, DefaultHLLPrecision, false)
+	require.NoError(t, err)
+	small.AddSequence("ACGTACGTAC")
+	assert.InDelta(t, 4.0, small.Estimate().Distinct, 0.01)
+
+	_, err = NewHyperLogLog(21, 3, true)
+	assert.Error(t, err)
+	_, err = NewHyperLogLog(33, DefaultHLLPrecision, true)
+	assert.Error(t, err)
+}
+
+func TestHyperLogLogReadFrom(t *testing.T) {
+	single, err := NewHyperLogLog(5, 10, false)
+	require.NoError(t, err)
+	single.AddSequence("ACGTTGCATGCCATGA")
+	single.AddSequence("TTTTGGGGCCCCAAAA")
+
+	fasta, err := NewHyperLogLog(5, 10, false)
+	require.NoError(t, err)
+	_, err = fasta.ReadFrom(strings.NewReader(">a\nACGTTGCA\nTGCCATGA\n\n>b desc\r\nTTTTGGGG\r\nCCCCAAAA"))
+	require.NoError(t, err)
+	assert.Equal(t, single.Total, fasta.Total)
+	assert.Equal(t, single.Estimate(), fasta.Estimate())
+
+	fastq, err := NewHyperLogLog(5, 10, false)
+	require.NoError(t, err)
+	_, err = fastq.ReadFrom(strings.NewReader("@r1\nACGTTGCATGCCATGA\n+\nIIIIIIIIIIIIIIII\n@r2\nTTTTGGGGCCCCAAAA\n+\nIIIIIIIIIIIIIIII\n"))
+	require.NoError(t, err)
+	assert.Equal(t, single.Estimate(), fastq.Estimate())
+
+	left, err := NewHyperLogLog(5, 10, false)
+	require.NoError(t, err)
+	left.AddSequence("ACGTTGCATGCCATGA")
+	right, err := NewHyperLogLog(5, 10, false)
+	require.NoError(t, err)
+	right.AddSequence("TTTTGGGGCCCCAAAA")
+	require.NoError(t, left.Merge(right))
+	assert.Equal(t, single.Estimate(), left.Estimate())
+
+	other, err := NewHyperLogLog(5, 12, false)
+	require.NoError(t, err)
+	assert.Error(t, left.Merge(other))
+}
+
+func TestMappability(t *testing.T) {
+	chr1, err := sequence.WithID("ACGTTTACGG", "chr1")
+	require.NoError(t, err)
+	chr2, err := sequence.WithID("GGGACGNCC", "chr2")
+	require.NoError(t, err)
+
+	index, err := BuildIndex([]*sequence.Sequence{chr1, chr2}, 3, false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, index.Counts["ACG"])
+
+	m, err := Mappability(chr1, index)
+	require.NoError(t, err)
+	assert.Equal(t, "chr1", m.SequenceID)
+	// ACG occurs three times; CGT..TAC are unique; ACG again; CGG unique.
+	assert.Equal(t, []track.Point{
+		{Start: 0, End: 1, Value: 1.0 / 3},
+		{Start: 1, End: 6, Value: 1},
+		{Start: 6, End: 7, Value: 1.0 / 3},
+		{Start: 7, End: 8, Value: 1},
+	}, m.Points)
+	assert.InDelta(t, 6.0/8.0, UniqueFraction(m), 1e-9)
+
+	m, err = Mappability(chr2, index)
+	require.NoError(t, err)
+	last := m.Points[len(m.Points)-1]
+	assert.Equal(t, 0.0, last.Value)
+	assert.Equal(t, 7, last.End)
+
+	// Canonical: CGT is the reverse complement of ACG.
+	canonical, err := BuildIndex([]*sequence.Sequence{chr1}, 3, true)
+	require.NoError(t, err)
+	m, err = Mappability(chr1, canonical)
+	require.NoError(t, err)
+	assert.Equal(t, track.Point{Start: 0, End: 2, Value: 1.0 / 3}, m.Points[0])
+
+	_, err = BuildIndex(nil, 3, false)
+	assert.Error(t, err)
+}
+
+func TestMaskByAbundance(t *testing.T) {
+	seq, err := sequence.WithID("ACGTACGTACGGTTCA", "s")
+	require.NoError(t, err)
+	counter, err := CountKMers(seq, 4)
+	require.NoError(t, err)
+	// ACGT and CGTA occur twice; GTAC twice; TACG twice.
+
+	hard, err := MaskByAbundance(seq, counter, MaskOptions{Threshold: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "NNNNNNNNNNNGTTCA", hard.Sequence.Bases)
+	assert.Equal(t, []MaskedRegion{{Start: 0, End: 11}}, hard.Regions)
+	assert.Equal(t, 11, hard.MaskedBases)
+	assert.Equal(t, "s", hard.Sequence.ID)
+
+	soft, err := MaskByAbundance(seq, counter, MaskOptions{Threshold: 1, Mode: SoftMask})
+	require.NoError(t, err)
+	assert.Equal(t, "acgtacgtacgGTTCA", soft.Sequence.Bases)
+	assert.InDelta(t, 11.0/16.0, soft.MaskedFraction(), 1e-9)
+
+	none, err := MaskByAbundance(seq, counter, MaskOptions{Threshold: 2})
+	require.NoError(t, err)
+	assert.Equal(t, seq.Bases, none.Sequence.Bases)
+	assert.Empty(t, none.Regions)
+
+	// Canonical counts mask a k-mer whose reverse complement is abundant.
+	ref, err := sequence.New("TTTTTTTT")
+	require.NoError(t, err)
+	canonical, err := CountKMersCanonical(ref, 4)
+	require.NoError(t, err)
+	target, err := sequence.New("GAAAAC")
+	require.NoError(t, err)
+	masked, err := MaskByAbundance(target, canonical, MaskOptions{Threshold: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "GNNNNC", masked.Sequence.Bases)
+
+	_, err = MaskByAbundance(seq, counter, MaskOptions{})
+	assert.Error(t, err)
+}
+
+func TestDumpRoundTrip(t *testing.T) {
+	seq, err := sequence.New("ACGTACGTTT")
+	require.NoError(t, err)
+	counter, err := CountKMers(seq, 3)
+	require.NoError(t, err)
+
+	for _, format := range []DumpFormat{JellyfishFASTA, JellyfishColumn, KMCDump} {
+		var buf strings.Builder
+		require.NoError(t, WriteDump(&buf, counter, format))
+
+		back, err := ReadDump(strings.NewReader(buf.String()), AutoDump)
+		require.NoError(t, err, format)
+		assert.Equal(t, counter.K, back.K)
+		assert.Equal(t, counter.Total, back.Total)
+		assert.Equal(t, counter.Counts, back.Counts)
+	}
+
+	var buf strings.Builder
+	require.NoError(t, WriteDump(&buf, counter, JellyfishFASTA))
+	assert.True(t, strings.HasPrefix(buf.String(), ">2\nACG\n"))
+
+	buf.Reset()
+	require.NoError(t, WriteDump(&buf, counter, KMCDump))
+	assert.True(t, strings.HasPrefix(buf.String(), "ACG\t2\n"))
+}
+
+func TestReadDumpErrors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"ACG\t2\nACGT\t1\n",
+		"ACG two\n",
+		">3\n>4\nACG\n",
+		"ACG\t0\n",
+		">3\n",
+	} {
+		_, err := ReadDump(strings.NewReader(input), AutoDump)
+		assert.Error(t, err, input)
+	}
+
+	c, err := ReadDump(strings.NewReader("acg 5\nTTT 1\n"), JellyfishColumn)
+	require.NoError(t, err)
+	assert.Equal(t, 5, c.Counts["ACG"])
+
+	_, err = ParseDumpFormat("bloom")
+	assert.Error(t, err)
+}
+
+func randomBases(rng *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = "ACGT"[rng.Intn(4)]
+	}
+	return string(b)
+}
+
+func TestFindAnchors(t *testing.T) {
+	seq1, err := sequence.New("ACGTNACGT")
+	require.NoError(t, err)
+	seq2, err := sequence.New("TTACGTT")
+	require.NoError(t, err)
+
+	anchors, err := FindAnchors(seq1, seq2, AnchorOptions{K: 4})
+	require.NoError(t, err)
+	assert.Equal(t, []Anchor{{Pos1: 0, Pos2: 2, Strand: '+'}, {Pos1: 5, Pos2: 2, Strand: '+'}}, anchors)
+
+	// AACG is the reverse complement of CGTT.
+	rev, err := sequence.New("AACG")
+	require.NoError(t, err)
+	anchors, err = FindAnchors(rev, seq2, AnchorOptions{K: 4, BothStrands: true})
+	require.NoError(t, err)
+	assert.Equal(t, []Anchor{{Pos1: 0, Pos2: 3, Strand: '-'}}, anchors)
+
+	repeat, err := sequence.New("AAAAAAAA")
+	require.NoError(t, err)
+	anchors, err = FindAnchors(repeat, repeat, AnchorOptions{K: 3, MaxOccurrences: 2})
+	require.NoError(t, err)
+	assert.Empty(t, anchors)
+
+	_, err = FindAnchors(seq1, seq2, AnchorOptions{})
+	assert.Error(t, err)
+
+	// Poly-A against itself pairs every position with every other one.
+	polyA, err := sequence.New(strings.Repeat("A", 2000))
+	require.NoError(t, err)
+	anchors, err = FindAnchors(polyA, polyA, AnchorOptions{K: 11, MaxAnchors: 1000})
+	assert.ErrorIs(t, err, ErrTooManyAnchors)
+	assert.Nil(t, anchors)
+	anchors, err = FindAnchors(seq1, seq2, AnchorOptions{K: 4, MaxAnchors: 2})
+	require.NoError(t, err)
+	assert.Len(t, anchors, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = FindAnchorsContext(ctx, seq1, seq2, AnchorOptions{K: 4})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestChainAnchors(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	target := randomBases(rng, 400)
+	query := []byte(target[100:300])
+	// A substitution in the middle breaks the anchors overlapping it.
+	if query[100] == 'A' {
+		query[100] = 'C'
+	} else {
+		query[100] = 'A'
+	}
+
+	seq1, err := sequence.New(string(query))
+	require.NoError(t, err)
+	seq2, err := sequence.New(target)
+	require.NoError(t, err)
+
+	anchors, err := FindAnchors(seq1, seq2, AnchorOptions{K: 11, BothStrands: true})
+	require.NoError(t, err)
+	chains, err := ChainAnchors(anchors, DefaultChainOptions(11))
+	require.NoError(t, err)
+	require.NotEmpty(t, chains)
+
+	best := chains[0]
+	assert.Equal(t, byte('+'), best.Strand)
+	assert.Equal(t, 0, best.Start1)
+	assert.Equal(t, 200, best.End1)
+	assert.Equal(t, 100, best.Start2)
+	assert.Equal(t, 300, best.End2)
+	for i := 1; i < len(best.Anchors); i++ {
+		assert.Greater(t, best.Anchors[i].Pos1, best.Anchors[i-1].Pos1)
+		assert.Greater(t, best.Anchors[i].Pos2, best.Anchors[i-1].Pos2)
+	}
+
+	// The reverse complement of the query chains on the '-' strand.
+	rc, err := seq1.ReverseComplement()
+	require.NoError(t, err)
+	anchors, err = FindAnchors(rc, seq2, AnchorOptions{K: 11, BothStrands: true})
+	require.NoError(t, err)
+	chains, err = ChainAnchors(anchors, DefaultChainOptions(11))
+	require.NoError(t, err)
+	require.NotEmpty(t, chains)
+	assert.Equal(t, byte('-'), chains[0].Strand)
+	assert.Equal(t, 100, chains[0].Start2)
+	assert.Equal(t, 300, chains[0].End2)
+
+	_, err = ChainAnchors(anchors, ChainOptions{})
+	assert.Error(t, err)
+}
+
 func BenchmarkCountKMers(b *testing.B) {
 	seq, _ := sequence.New("ATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGC")
 	b.ResetTimer()
@@ -289,6 +842,14 @@ func BenchmarkCountKMers(b *testing.B) {
 	}
 }
 
+func BenchmarkCountPackedKMers(b *testing.B) {
+	seq, _ := sequence.New("ATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGC")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = CountPackedKMers(seq, 21, sequence.Forward, false)
+	}
+}
+
 func BenchmarkJaccardDistance(b *testing.B) {
 	seq1, _ := sequence.New("ATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGCATGC")
 	seq2, _ := sequence.New("GCTAGCTAGCTAGCTAGCTAGCTAGCTAGCTAGCTAGCTAGCTAGCTAGCTAGCTAGCTAGCTAGCTAGCTAGCTAGCTAGCTAGCTAGCTAGCTAGCTAGC")
@@ -297,3 +858,144 @@ func BenchmarkJaccardDistance(b *testing.B) {
 		_, _ = JaccardDistance(seq1, seq2, 11)
 	}
 }
+
+func TestSoftMasking(t *testing.T) {
+	policy := sequence.StrictPolicy()
+	policy.PreserveCase = true
+	// A repeat, soft-masked twice, between unique flanks; NNNN is
+	// hard-masked.
+	seq, err := sequence.NewWithPolicy("ACGTTGCAacacacacGATCNNNNCCGGacacacacTTAA", "chr", "", policy)
+	require.NoError(t, err)
+
+	soft := SoftMaskedRuns(seq)
+	assert.Equal(t, []sequence.MaskRun{{Start: 8, End: 16}, {Start: 28, End: 36}}, soft)
+	assert.Equal(t, 16, MaskedBases(soft))
+	assert.Equal(t, []sequence.MaskRun{{Start: 20, End: 24}}, HardMaskedRuns(seq))
+
+	all, err := CountKMers(seq, 4)
+	require.NoError(t, err)
+	assert.Equal(t, 6, all.Counts["ACAC"])
+	unmasked, err := CountUnmaskedKMers(seq, 4)
+	require.NoError(t, err)
+	assert.Zero(t, unmasked.Counts["ACAC"])
+	assert.Equal(t, 1, unmasked.Counts["ACGT"])
+	// Only the 4-mers within ACGTTGCA, GATC, CCGG and TTAA remain.
+	assert.Equal(t, 5+1+1+1, unmasked.Total)
+	assert.Error(t, all.Merge(unmasked))
+
+	// Lower-case bases count as soft-masked too.
+	c, _ := NewCounter(4)
+	c.SkipSoftMasked = true
+	c.CountKMers("ACGTacgtACGT")
+	assert.Equal(t, 2, c.Total)
+
+	// Masked repeats give no anchors; the unique flanks still do.
+	other, err := sequence.New("ACGTTGCAACACACACGATC")
+	require.NoError(t, err)
+	anchors, err := FindAnchors(seq, other, AnchorOptions{K: 8})
+	require.NoError(t, err)
+	masked, err := FindAnchors(seq, other, AnchorOptions{K: 8, SkipSoftMasked: true})
+	require.NoError(t, err)
+	assert.Less(t, len(masked), len(anchors))
+	require.NotEmpty(t, masked)
+	for _, a := range masked {
+		assert.True(t, a.Pos1+8 <= 8 || a.Pos1 >= 16, "anchor at %d overlaps the repeat", a.Pos1)
+	}
+
+	syncmers, err := Syncmers(seq, SyncmerOptions{K: 4, S: 2, Closed: true, SkipSoftMasked: true})
+	require.NoError(t, err)
+	require.NotEmpty(t, syncmers)
+	for _, m := range syncmers {
+		w := seq.Masked()[m.Position : m.Position+4]
+		assert.Equal(t, strings.ToUpper(w), w)
+	}
+}
+
+func TestEachWindowSkipsMaskedRuns(t *testing.T) {
+	bases := strings.Repeat("A", 5) + strings.Repeat("N", 1000) + strings.Repeat("C", 5)
+	var starts []int
+	eachWindow(bases, 3, []sequence.MaskRun{{Start: 1, End: 2}}, func(i int) { starts = append(starts, i) })
+	assert.Equal(t, []int{2, 1005, 1006, 1007}, starts)
+}
+
+func TestMinimizers(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	b := make([]byte, 500)
+	for i := range b {
+		b[i] = "ACGT"[rng.Intn(4)]
+	}
+	seq, err := sequence.New(string(b))
+	require.NoError(t, err)
+
+	opts := MinimizerOptions{K: 11, W: 5, Canonical: true}
+	ms, err := Minimizers(seq, opts)
+	require.NoError(t, err)
+	require.NotEmpty(t, ms)
+	// Every window of W k-mers holds a minimizer, and positions increase.
+	for i := 1; i < len(ms); i++ {
+		assert.Greater(t, ms[i].Position, ms[i-1].Position)
+		assert.LessOrEqual(t, ms[i].Position-ms[i-1].Position, opts.W)
+	}
+
+	// The reverse complement has the same minimizers on the other strand.
+	rc, err := seq.ReverseComplement()
+	require.NoError(t, err)
+	rms, err := Minimizers(rc, opts)
+	require.NoError(t, err)
+	require.Len(t, rms, len(ms))
+	last := rms[len(rms)-1]
+	assert.Equal(t, ms[0].Hash, last.Hash)
+	assert.Equal(t, 500-11-ms[0].Position, last.Position)
+	assert.NotEqual(t, ms[0].Strand, last.Strand)
+
+	_, err = Minimizers(seq, MinimizerOptions{K: 11})
+	assert.Error(t, err)
+}
+
+func TestCountKMersContext(t *testing.T) {
+	// A sequence over several count blocks, with N runs and soft-masked
+	// repeats across their boundaries.
+	rng := rand.New(rand.NewSource(7))
+	b := make([]byte, 3*countBlock+1000)
+	for i := range b {
+		b[i] = "ACGT"[rng.Intn(4)]
+	}
+	for _, at := range []int{countBlock - 3, 2*countBlock + 10} {
+		copy(b[at:], "NNNNNN")
+	}
+	copy(b[2*countBlock-20:], strings.Repeat("ac", 20))
+	policy := sequence.StrictPolicy()
+	policy.PreserveCase = true
+	seq, err := sequence.NewWithPolicy(string(b), "chr", "", policy)
+	require.NoError(t, err)
+
+	want, err := CountKMers(seq, 11)
+	require.NoError(t, err)
+	got, err := CountKMersContext(context.Background(), seq, 11)
+	require.NoError(t, err)
+	assert.Equal(t, want.Total, got.Total)
+	assert.Equal(t, want.Counts, got.Counts)
+
+	want, err = CountUnmaskedKMers(seq, 11)
+	require.NoError(t, err)
+	counter, err := NewCounter(11)
+	require.NoError(t, err)
+	counter.SkipSoftMasked = true
+	require.NoError(t, counter.CountFromSequenceContext(context.Background(), seq))
+	assert.Equal(t, want.Counts, counter.Counts)
+
+	seed, err := ParseSpacedSeed("1101101")
+	require.NoError(t, err)
+	spaced, err := CountSpacedKMers(seq, seed)
+	require.NoError(t, err)
+	spacedCtx, err := CountSpacedKMersContext(context.Background(), seq, seed)
+	require.NoError(t, err)
+	assert.Equal(t, spaced.Counts, spacedCtx.Counts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = CountKMersContext(ctx, seq, 11)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = CountSpacedKMersContext(ctx, seq, seed)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -35,31 +35,7 @@ func JaccardDistance(seq1, seq2 *sequence.Sequence, k int) (float64, error) {
 		return 0, err
 	}
 
-	// Calculate intersection and union
-	set1 := make(map[string]bool)
-	for kmer := range counter1.Counts {
-		set1[kmer] = true
-	}
-
-	set2 := make(map[string]bool)
-	for kmer := range counter2.Counts {
-		set2[kmer] = true
-	}
-
-	intersection := 0
-	for kmer := range set1 {
-		if set2[kmer] {
-			intersection++
-		}
-	}
-
-	union := len(set1) + len(set2) - intersection
-
-	if union == 0 {
-		return 0.0, nil
-	}
-
-	return 1.0 - float64(intersection)/float64(union), nil
+	return jaccardCounters(counter1, counter2), nil
 }
 
 // SharedKMers finds k-mers shared between two sequences.
@@ -118,33 +94,7 @@ func CosineDistance(seq1, seq2 *sequence.Sequence, k int) (float64, error) {
 		return 0, err
 	}
 
-	// Get all unique k-mers
-	allKMers := make(map[string]bool)
-	for kmer := range counter1.Counts {
-		allKMers[kmer] = true
-	}
-	for kmer := range counter2.Counts {
-		allKMers[kmer] = true
-	}
-
-	// Calculate dot product and magnitudes
-	var dotProduct, mag1, mag2 float64
-
-	for kmer := range allKMers {
-		v1 := float64(counter1.Counts[kmer])
-		v2 := float64(counter2.Counts[kmer])
-
-		dotProduct += v1 * v2
-		mag1 += v1 * v1
-		mag2 += v2 * v2
-	}
-
-	if mag1 == 0 || mag2 == 0 {
-		return 1.0, nil
-	}
-
-	cosineSimilarity := dotProduct / (math.Sqrt(mag1) * math.Sqrt(mag2))
-	return 1.0 - cosineSimilarity, nil
+	return cosineCounters(counter1, counter2), nil
 }
 
 // EuclideanDistance calculates the Euclidean distance between k-mer frequency vectors.
@@ -166,26 +116,7 @@ func EuclideanDistance(seq1, seq2 *sequence.Sequence, k int) (float64, error) {
 		return 0, err
 	}
 
-	// Get all unique k-mers
-	allKMers := make(map[string]bool)
-	for kmer := range counter1.Counts {
-		allKMers[kmer] = true
-	}
-	for kmer := range counter2.Counts {
-		allKMers[kmer] = true
-	}
-
-	// Calculate sum of squared differences
-	var sumSqDiff float64
-
-	for kmer := range allKMers {
-		v1 := float64(counter1.Counts[kmer])
-		v2 := float64(counter2.Counts[kmer])
-		diff := v1 - v2
-		sumSqDiff += diff * diff
-	}
-
-	return math.Sqrt(sumSqDiff), nil
+	return euclideanCounters(counter1, counter2), nil
 }
 
 // SimilarityMatrix calculates a similarity matrix for multiple sequences.
@@ -214,3 +145,65 @@ func SimilarityMatrix(sequences []*sequence.Sequence, k int) ([][]float64, error
 
 	return matrix, nil
 }
+
+// jaccardCounters returns 1 - |A ∩ B| / |A ∪ B| over the distinct k-mers
+// of two counters.
+func jaccardCounters(counter1, counter2 *Counter) float64 {
+	intersection := 0
+	for kmer := range counter1.Counts {
+		if _, ok := counter2.Counts[kmer]; ok {
+			intersection++
+		}
+	}
+
+	union := len(counter1.Counts) + len(counter2.Counts) - intersection
+	if union == 0 {
+		return 0.0
+	}
+	return 1.0 - float64(intersection)/float64(union)
+}
+
+// unionKMers returns the k-mers present in either counter.
+func unionKMers(counter1, counter2 *Counter) map[string]bool {
+	allKMers := make(map[string]bool, len(counter1.Counts)+len(counter2.Counts))
+	for kmer := range counter1.Counts {
+		allKMers[kmer] = true
+	}
+	for kmer := range counter2.Counts {
+		allKMers[kmer] = true
+	}
+	return allKMers
+}
+
+// cosineCounters returns the cosine distance between two count vectors.
+func cosineCounters(counter1, counter2 *Counter) float64 {
+	var dotProduct, mag1, mag2 float64
+
+	for kmer := range unionKMers(counter1, counter2) {
+		v1 := float64(counter1.Counts[kmer])
+		v2 := float64(counter2.Counts[kmer])
+
+		dotProduct += v1 * v2
+		mag1 += v1 * v1
+		mag2 += v2 * v2
+	}
+
+	if mag1 == 0 || mag2 == 0 {
+		return 1.0
+	}
+
+	cosineSimilarity := dotProduct / (math.Sqrt(mag1) * math.Sqrt(mag2))
+	return 1.0 - cosineSimilarity
+}
+
+// euclideanCounters returns the Euclidean distance between two count vectors.
+func euclideanCounters(counter1, counter2 *Counter) float64 {
+	var sumSqDiff float64
+
+	for kmer := range unionKMers(counter1, counter2) {
+		diff := float64(counter1.Counts[kmer] - counter2.Counts[kmer])
+		sumSqDiff += diff * diff
+	}
+
+	return math.Sqrt(sumSqDiff)
+}
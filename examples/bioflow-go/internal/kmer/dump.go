@@ -0,0 +1,195 @@
+package kmer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DumpFormat is a text format for k-mer counts shared with other tools.
+type DumpFormat int
+
+const (
+	// AutoDump detects the format when reading: FASTA-style if the first
+	// line starts with '>', columns otherwise. Writing uses KMCDump.
+	AutoDump DumpFormat = iota
+	// JellyfishFASTA is the default output of "jellyfish dump":
+	// ">count" header lines each followed by the k-mer.
+	JellyfishFASTA
+	// JellyfishColumn is "jellyfish dump -c": "KMER count" per line.
+	JellyfishColumn
+	// KMCDump is the output of "kmc_dump" / "kmc_tools transform dump":
+	// "KMER<TAB>count" per line.
+	KMCDump
+)
+
+func (f DumpFormat) String() string {
+	switch f {
+	case AutoDump:
+		return "auto"
+	case JellyfishFASTA:
+		return "jellyfish"
+	case JellyfishColumn:
+		return "jellyfish-column"
+	case KMCDump:
+		return "kmc"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseDumpFormat parses a dump format name.
+func ParseDumpFormat(name string) (DumpFormat, error) {
+	switch strings.ToLower(name) {
+	case "", "auto":
+		return AutoDump, nil
+	case "jellyfish", "jellyfish-fasta", "jf":
+		return JellyfishFASTA, nil
+	case "jellyfish-column", "jellyfish-c", "column":
+		return JellyfishColumn, nil
+	case "kmc", "kmc-dump", "tsv":
+		return KMCDump, nil
+	default:
+		return 0, fmt.Errorf("unknown k-mer dump format: %s", name)
+	}
+}
+
+// WriteDump writes counts in a text dump format, sorted by k-mer so the
+// output is reproducible.
+//
+// Aria equivalent:
+//
+//	fn write_dump(counts: KMerCounts, w: Writer, format: DumpFormat) -> Result<(), IOError>
+//	  requires counts.seed.is_empty()
+func WriteDump(w io.Writer, c *Counter, format DumpFormat) error {
+	if c.Seed != "" {
+		return fmt.Errorf("spaced-seed counts cannot be exported")
+	}
+	kmers := make([]string, 0, len(c.Counts))
+	for kmer := range c.Counts {
+		kmers = append(kmers, kmer)
+	}
+	sort.Strings(kmers)
+
+	bw := bufio.NewWriter(w)
+	buf := make([]byte, 0, c.K+24)
+	for _, kmer := range kmers {
+		count := int64(c.Counts[kmer])
+		buf = buf[:0]
+		switch format {
+		case JellyfishFASTA:
+			buf = append(buf, '>')
+			buf = strconv.AppendInt(buf, count, 10)
+			buf = append(buf, '\n')
+			buf = append(buf, kmer...)
+		case JellyfishColumn:
+			buf = append(buf, kmer...)
+			buf = append(buf, ' ')
+			buf = strconv.AppendInt(buf, count, 10)
+		case KMCDump, AutoDump:
+			buf = append(buf, kmer...)
+			buf = append(buf, '\t')
+			buf = strconv.AppendInt(buf, count, 10)
+		default:
+			return fmt.Errorf("unknown k-mer dump format")
+		}
+		buf = append(buf, '\n')
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadDump reads counts written by jellyfish, KMC or WriteDump. K is taken
+// from the first k-mer. Text dumps don't record whether counts are
+// canonical; callers set Canonical on the result when they know it.
+//
+// Aria equivalent:
+//
+//	fn read_dump(r: Reader, format: DumpFormat) -> Result<KMerCounts, KMerError>
+//	  ensures result.counts.all(|(kmer, count)| kmer.len() == result.k and count > 0)
+func ReadDump(r io.Reader, format DumpFormat) (*Counter, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var c *Counter
+	add := func(kmer string, count, lineNo int) error {
+		if c == nil {
+			var err error
+			if c, err = NewCounter(len(kmer)); err != nil {
+				return fmt.Errorf("line %d: %w", lineNo, err)
+			}
+		}
+		if count <= 0 {
+			return fmt.Errorf("line %d: count must be positive", lineNo)
+		}
+		if err := c.Add(kmer, count); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		return nil
+	}
+
+	lineNo := 0
+	pending := -1
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if format == AutoDump {
+			format = KMCDump
+			if line[0] == '>' {
+				format = JellyfishFASTA
+			}
+		}
+
+		if format == JellyfishFASTA {
+			if line[0] == '>' {
+				if pending >= 0 {
+					return nil, fmt.Errorf("line %d: header without k-mer", lineNo)
+				}
+				count, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid count %q", lineNo, line[1:])
+				}
+				pending = count
+				continue
+			}
+			if pending < 0 {
+				return nil, fmt.Errorf("line %d: k-mer without header", lineNo)
+			}
+			if err := add(line, pending, lineNo); err != nil {
+				return nil, err
+			}
+			pending = -1
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected k-mer and count", lineNo)
+		}
+		count, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid count %q", lineNo, fields[1])
+		}
+		if err := add(fields[0], count, lineNo); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading dump: %w", err)
+	}
+	if pending >= 0 {
+		return nil, fmt.Errorf("line %d: header without k-mer", lineNo)
+	}
+	if c == nil {
+		return nil, fmt.Errorf("no k-mers in dump")
+	}
+	return c, nil
+}
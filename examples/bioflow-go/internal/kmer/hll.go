@@ -0,0 +1,226 @@
+package kmer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+)
+
+// HyperLogLog precision bounds and default. The estimator uses 2^precision
+// one-byte registers; the default (14) takes 16 KiB and has a standard
+// error of about 0.8%.
+const (
+	MinHLLPrecision     = 4
+	MaxHLLPrecision     = 18
+	DefaultHLLPrecision = 14
+)
+
+// HyperLogLog estimates the number of distinct k-mers in a stream using
+// constant memory (Flajolet et al. 2007, with linear counting for small
+// cardinalities). Total counts every k-mer added, distinct or not.
+//
+// Aria equivalent:
+//
+//	struct HyperLogLog
+//	  k: Int
+//	  precision: Int
+//	  invariant self.registers.len() == 1 << self.precision
+type HyperLogLog struct {
+	K         int
+	Precision int
+	Canonical bool
+	Total     int64
+	registers []uint8
+	roller    *roller
+}
+
+// CardinalityEstimate is a distinct-count estimate with approximate 95%
+// bounds (two standard errors).
+type CardinalityEstimate struct {
+	Distinct      float64 `json:"distinct"`
+	Lower         float64 `json:"lower"`
+	Upper         float64 `json:"upper"`
+	StandardError float64 `json:"standard_error"`
+	Total         int64   `json:"total"`
+}
+
+// NewHyperLogLog creates an empty estimator for k-mers of length k.
+//
+// Aria equivalent:
+//
+//	fn new(k: Int, precision: Int, canonical: Bool) -> Result<HyperLogLog, KMerError>
+//	  requires 0 < k <= MAX_SKETCH_K
+//	  requires MIN_HLL_PRECISION <= precision <= MAX_HLL_PRECISION
+func NewHyperLogLog(k, precision int, canonical bool) (*HyperLogLog, error) {
+	if k <= 0 || k > MaxSketchK {
+		return nil, fmt.Errorf("k must be between 1 and %d", MaxSketchK)
+	}
+	if precision < MinHLLPrecision || precision > MaxHLLPrecision {
+		return nil, fmt.Errorf("precision must be between %d and %d", MinHLLPrecision, MaxHLLPrecision)
+	}
+	return &HyperLogLog{
+		K:         k,
+		Precision: precision,
+		Canonical: canonical,
+		registers: make([]uint8, 1<<uint(precision)),
+		roller:    newRoller(k, canonical),
+	}, nil
+}
+
+// addHash records a k-mer hash.
+func (h *HyperLogLog) addHash(x uint64) {
+	p := uint(h.Precision)
+	idx := x >> (64 - p)
+	rank := uint8(bits.LeadingZeros64(x<<p|1<<(p-1)) + 1)
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+	h.Total++
+}
+
+// AddSequence adds every k-mer of a complete sequence.
+func (h *HyperLogLog) AddSequence(bases string) {
+	h.roller.reset()
+	h.AddBases([]byte(bases))
+	h.roller.reset()
+}
+
+// AddBases continues the current sequence with more bases, so a sequence
+// can be fed in chunks (e.g. line by line) without losing the k-mers
+// spanning chunk boundaries. Call EndSequence between sequences.
+func (h *HyperLogLog) AddBases(bases []byte) {
+	for _, b := range bases {
+		if x, ok := h.roller.push(b); ok {
+			h.addHash(x)
+		}
+	}
+}
+
+// EndSequence marks the end of the current sequence.
+func (h *HyperLogLog) EndSequence() {
+	h.roller.reset()
+}
+
+// ReadFrom adds the k-mers of every record in a FASTA or FASTQ stream
+// (detected from the first character) or of plain sequence lines. Memory
+// use is independent of the input size.
+func (h *HyperLogLog) ReadFrom(r io.Reader) (int64, error) {
+	br := bufio.NewReaderSize(r, 256*1024)
+	var read int64
+	lineNo := 0
+	format := byte(0)
+	atLineStart, skipping := true, false
+	for {
+		// ReadSlice returns long lines in buffer-sized chunks, so memory
+		// stays bounded even for unwrapped chromosome-length sequences.
+		chunk, err := br.ReadSlice('\n')
+		read += int64(len(chunk))
+		if body := trimEOL(chunk); len(body) > 0 {
+			if atLineStart {
+				lineNo++
+				if format == 0 {
+					format = body[0]
+				}
+				skipping = false
+				switch format {
+				case '@':
+					// FASTQ: only the second line of each four-line record.
+					skipping = lineNo%4 != 2
+					h.EndSequence()
+				case '>':
+					if body[0] == '>' {
+						skipping = true
+						h.EndSequence()
+					}
+				default:
+					h.EndSequence()
+				}
+			}
+			if !skipping {
+				h.AddBases(body)
+			}
+		}
+		atLineStart = err != bufio.ErrBufferFull
+		if err == io.EOF {
+			h.EndSequence()
+			return read, nil
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return read, err
+		}
+	}
+}
+
+// trimEOL removes a trailing newline and carriage return.
+func trimEOL(line []byte) []byte {
+	n := len(line)
+	for n > 0 && (line[n-1] == '\n' || line[n-1] == '\r') {
+		n--
+	}
+	return line[:n]
+}
+
+// Merge folds another estimator into this one, as if its k-mers had been
+// added here. Both must use the same k, precision and strand handling.
+func (h *HyperLogLog) Merge(other *HyperLogLog) error {
+	if h.K != other.K || h.Precision != other.Precision || h.Canonical != other.Canonical {
+		return fmt.Errorf("HyperLogLog parameters must match")
+	}
+	for i, r := range other.registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+		}
+	}
+	h.Total += other.Total
+	return nil
+}
+
+// StandardError returns the relative standard error, 1.04/sqrt(m).
+func (h *HyperLogLog) StandardError() float64 {
+	return 1.04 / math.Sqrt(float64(len(h.registers)))
+}
+
+// Estimate returns the estimated number of distinct k-mers.
+//
+// Aria equivalent:
+//
+//	fn estimate(self) -> CardinalityEstimate
+//	  ensures result.lower <= result.distinct <= result.upper
+func (h *HyperLogLog) Estimate() CardinalityEstimate {
+	m := float64(len(h.registers))
+	var alpha float64
+	switch len(h.registers) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	e := alpha * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		e = m * math.Log(m/float64(zeros))
+	}
+
+	se := h.StandardError()
+	return CardinalityEstimate{
+		Distinct:      e,
+		Lower:         math.Max(0, e*(1-2*se)),
+		Upper:         e * (1 + 2*se),
+		StandardError: se,
+		Total:         h.Total,
+	}
+}
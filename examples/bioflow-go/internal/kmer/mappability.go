@@ -0,0 +1,150 @@
+package kmer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/aria-lang/bioflow-go/internal/track"
+)
+
+// addAll counts every k-mer of bases without N, optionally canonical.
+func addAll(counter *Counter, bases string, canonical bool) {
+	bases = strings.ToUpper(bases)
+	rc := ""
+	if canonical {
+		rc = reverseComplementString(bases)
+	}
+	n := len(bases)
+	eachWindow(bases, counter.K, nil, func(i int) {
+		kmer := bases[i : i+counter.K]
+		if canonical {
+			if r := rc[n-i-counter.K : n-i]; r < kmer {
+				kmer = r
+			}
+		}
+		counter.Counts[kmer]++
+		counter.Total++
+	})
+}
+
+// reverseComplementString complements ACGT and maps anything else to N.
+func reverseComplementString(bases string) string {
+	n := len(bases)
+	b := make([]byte, n)
+	for i := 0; i < n; i++ {
+		switch bases[n-1-i] {
+		case 'A':
+			b[i] = 'T'
+		case 'C':
+			b[i] = 'G'
+		case 'G':
+			b[i] = 'C'
+		case 'T':
+			b[i] = 'A'
+		default:
+			b[i] = 'N'
+		}
+	}
+	return string(b)
+}
+
+// BuildIndex counts the k-mers of every sequence of a reference into one
+// counter, to be used as a genome-wide k-mer index. Sequences shorter than
+// k contribute nothing. With canonical set, each k-mer is counted together
+// with its reverse complement.
+//
+// Aria equivalent:
+//
+//	fn build_index(reference: [Sequence], k: Int, canonical: Bool) -> Result<KMerCounts, KMerError>
+//	  requires k > 0
+//	  requires reference.len() > 0
+//	  ensures result.k == k
+func BuildIndex(reference []*sequence.Sequence, k int, canonical bool) (*Counter, error) {
+	if len(reference) == 0 {
+		return nil, fmt.Errorf("reference cannot be empty")
+	}
+	counter, err := NewCounter(k)
+	if err != nil {
+		return nil, err
+	}
+	counter.Canonical = canonical
+	for _, seq := range reference {
+		addAll(counter, seq.Bases, canonical)
+	}
+	return counter, nil
+}
+
+// Mappability computes a per-position mappability track for seq against
+// a k-mer index: the value at position i is 1/c, where c is the number
+// of occurrences of the k-mer starting at i in the index, so uniquely
+// mappable positions have value 1. Positions whose k-mer contains N or is
+// absent from the index have value 0. Runs of equal values are merged
+// into single intervals; the track covers [0, len(seq)-k+1).
+//
+// Aria equivalent:
+//
+//	fn mappability(seq: Sequence, index: KMerCounts) -> Result<Track, KMerError>
+//	  requires index.k <= seq.len()
+//	  ensures result.points.all(|p| p.value >= 0.0 and p.value <= 1.0)
+func Mappability(seq *sequence.Sequence, index *Counter) (*track.Track, error) {
+	if index == nil {
+		return nil, fmt.Errorf("k-mer index is required")
+	}
+	if index.Seed != "" {
+		return nil, fmt.Errorf("mappability requires a contiguous k-mer index")
+	}
+	k := index.K
+	if k > seq.Len() {
+		return nil, fmt.Errorf("k cannot exceed sequence length")
+	}
+
+	t := track.New("mappability", seq.ID)
+	bases := strings.ToUpper(seq.Bases)
+	rc := ""
+	if index.Canonical {
+		rc = reverseComplementString(bases)
+	}
+	n := len(bases)
+	runStart, runValue := 0, -1.0
+	for i := 0; i+k <= n; i++ {
+		value := 0.0
+		kmer := bases[i : i+k]
+		if !strings.ContainsRune(kmer, 'N') {
+			if index.Canonical {
+				if r := rc[n-i-k : n-i]; r < kmer {
+					kmer = r
+				}
+			}
+			if c := index.Counts[kmer]; c > 0 {
+				value = 1 / float64(c)
+			}
+		}
+		if value != runValue {
+			if runValue >= 0 {
+				t.Add(runStart, i, runValue)
+			}
+			runStart, runValue = i, value
+		}
+	}
+	if runValue >= 0 {
+		t.Add(runStart, n-k+1, runValue)
+	}
+	return t, nil
+}
+
+// UniqueFraction returns the fraction of track positions with value 1,
+// i.e. the uniquely mappable fraction of a mappability track.
+func UniqueFraction(t *track.Track) float64 {
+	covered, unique := 0, 0
+	for _, p := range t.Points {
+		covered += p.End - p.Start
+		if p.Value == 1 {
+			unique += p.End - p.Start
+		}
+	}
+	if covered == 0 {
+		return 0
+	}
+	return float64(unique) / float64(covered)
+}
@@ -0,0 +1,126 @@
+package kmer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// MaskMode selects how masked bases are written.
+type MaskMode int
+
+const (
+	// HardMask replaces masked bases with N.
+	HardMask MaskMode = iota
+	// SoftMask lowercases masked bases, the convention used by
+	// RepeatMasker and most aligners.
+	SoftMask
+)
+
+// MaskOptions configures abundance masking.
+type MaskOptions struct {
+	// Threshold is the abundance above which a k-mer is masked: every
+	// base covered by a k-mer with count > Threshold is masked.
+	Threshold int
+	Mode      MaskMode
+}
+
+// MaskedRegion is a maximal run of masked bases (0-based, half-open).
+type MaskedRegion struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// MaskResult is a masked copy of a sequence. With SoftMask, masked bases
+// of Sequence are lowercase, so it is meant for output rather than
+// further analysis.
+type MaskResult struct {
+	Sequence    *sequence.Sequence `json:"-"`
+	Regions     []MaskedRegion     `json:"regions"`
+	MaskedBases int                `json:"masked_bases"`
+}
+
+// MaskedFraction returns the fraction of bases that were masked.
+func (r *MaskResult) MaskedFraction() float64 {
+	if r.Sequence.Len() == 0 {
+		return 0
+	}
+	return float64(r.MaskedBases) / float64(r.Sequence.Len())
+}
+
+// MaskByAbundance masks the positions of seq covered by high-abundance
+// k-mers, typically to remove repeats before alignment. Abundances come
+// from counter, which may be built from seq itself, from a reference or
+// loaded from a saved k-mer database; canonical counters are looked up
+// with canonical k-mers.
+//
+// Aria equivalent:
+//
+//	fn mask_by_abundance(seq: Sequence, counts: KMerCounts, options: MaskOptions) -> Result<MaskResult, KMerError>
+//	  requires options.threshold > 0
+//	  ensures result.sequence.len() == seq.len()
+func MaskByAbundance(seq *sequence.Sequence, counter *Counter, opts MaskOptions) (*MaskResult, error) {
+	if counter == nil {
+		return nil, fmt.Errorf("k-mer counts are required")
+	}
+	if counter.Seed != "" {
+		return nil, fmt.Errorf("masking requires contiguous k-mer counts")
+	}
+	if opts.Threshold <= 0 {
+		return nil, fmt.Errorf("threshold must be positive")
+	}
+
+	k := counter.K
+	bases := strings.ToUpper(seq.Bases)
+	n := len(bases)
+	rc := ""
+	if counter.Canonical {
+		rc = reverseComplementString(bases)
+	}
+
+	// delta[i] counts masking k-mers starting at i minus those ending
+	// before i, so its prefix sum is the masking depth at each base.
+	delta := make([]int, n+1)
+	eachWindow(bases, k, nil, func(i int) {
+		kmer := bases[i : i+k]
+		if counter.Canonical {
+			if r := rc[n-i-k : n-i]; r < kmer {
+				kmer = r
+			}
+		}
+		if counter.Counts[kmer] > opts.Threshold {
+			delta[i]++
+			delta[i+k]--
+		}
+	})
+
+	masked := []byte(seq.Bases)
+	result := &MaskResult{Regions: make([]MaskedRegion, 0)}
+	depth := 0
+	for i := 0; i < n; i++ {
+		depth += delta[i]
+		if depth == 0 {
+			continue
+		}
+		if opts.Mode == SoftMask {
+			masked[i] = strings.ToLower(string(masked[i]))[0]
+		} else {
+			masked[i] = 'N'
+		}
+		result.MaskedBases++
+		if last := len(result.Regions) - 1; last >= 0 && result.Regions[last].End == i {
+			result.Regions[last].End++
+		} else {
+			result.Regions = append(result.Regions, MaskedRegion{Start: i, End: i + 1})
+		}
+	}
+
+	result.Sequence = &sequence.Sequence{
+		Bases:       string(masked),
+		ID:          seq.ID,
+		Description: seq.Description,
+		SeqType:     seq.SeqType,
+	}
+	return result, nil
+}
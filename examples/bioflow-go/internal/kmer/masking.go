@@ -0,0 +1,129 @@
+package kmer
+
+import (
+	"sort"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// SoftMaskedRuns returns the soft-masked runs of a sequence: those
+// recorded by a policy with PreserveCase, and runs of lower-case bases,
+// as left by SoftMask masking. Runs are 0-based, half-open, sorted and
+// do not overlap.
+//
+// Soft-masking is how RepeatMasker output and the UCSC and Ensembl
+// genomes mark repeats: with SkipSoftMasked set, counting and seeding
+// leave these runs out, while alignment still covers them.
+//
+// Aria equivalent:
+//
+//	fn soft_masked_runs(seq: Sequence) -> [MaskRun]
+//	  ensures result.windows(2).all(|w| w[0].end < w[1].start)
+func SoftMaskedRuns(seq *sequence.Sequence) []sequence.MaskRun {
+	lower := lowerCaseRuns(seq.Bases)
+	if len(seq.SoftMask) == 0 {
+		return lower
+	}
+	runs := append(append([]sequence.MaskRun(nil), seq.SoftMask...), lower...)
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Start < runs[j].Start })
+	merged := runs[:0]
+	for _, r := range runs {
+		if n := len(merged); n > 0 && r.Start <= merged[n-1].End {
+			merged[n-1].End = max(merged[n-1].End, r.End)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// HardMaskedRuns returns the runs of N in a sequence, 0-based and
+// half-open.
+//
+// Aria equivalent:
+//
+//	fn hard_masked_runs(seq: Sequence) -> [MaskRun]
+func HardMaskedRuns(seq *sequence.Sequence) []sequence.MaskRun {
+	runs := make([]sequence.MaskRun, 0)
+	bases := seq.Bases
+	for i := 0; i < len(bases); i++ {
+		if !isN(bases[i]) {
+			continue
+		}
+		start := i
+		for i < len(bases) && isN(bases[i]) {
+			i++
+		}
+		runs = append(runs, sequence.MaskRun{Start: start, End: i})
+	}
+	return runs
+}
+
+// MaskedBases returns the number of bases covered by runs.
+func MaskedBases(runs []sequence.MaskRun) int {
+	n := 0
+	for _, r := range runs {
+		n += r.End - r.Start
+	}
+	return n
+}
+
+// lowerCaseRuns returns the runs of lower-case letters in bases.
+func lowerCaseRuns(bases string) []sequence.MaskRun {
+	var runs []sequence.MaskRun
+	for i := 0; i < len(bases); i++ {
+		if bases[i] < 'a' || bases[i] > 'z' {
+			continue
+		}
+		if n := len(runs); n > 0 && runs[n-1].End == i {
+			runs[n-1].End++
+		} else {
+			runs = append(runs, sequence.MaskRun{Start: i, End: i + 1})
+		}
+	}
+	return runs
+}
+
+func isN(b byte) bool {
+	return b == 'N' || b == 'n'
+}
+
+// eachWindow calls fn with the start of every window of length span of
+// bases that contains no N and overlaps none of the sorted runs in soft.
+// Runs of N and soft-masked runs are stepped over whole, so masked
+// stretches cost one step rather than one per window.
+func eachWindow(bases string, span int, soft []sequence.MaskRun, fn func(start int)) {
+	n := len(bases)
+	next := 0 // the first start of a window clear of masked bases
+	r := 0
+	for j := 0; j < n; {
+		for r < len(soft) && soft[r].End <= j {
+			r++
+		}
+		if r < len(soft) && soft[r].Start <= j {
+			j, next = soft[r].End, soft[r].End
+			continue
+		}
+		if isN(bases[j]) {
+			for j < n && isN(bases[j]) {
+				j++
+			}
+			next = j
+			continue
+		}
+		if start := j - span + 1; start >= next {
+			fn(start)
+		}
+		j++
+	}
+}
+
+// clearSoftMasked marks the windows of length span overlapping soft as
+// invalid.
+func clearSoftMasked(valid []bool, soft []sequence.MaskRun, span int) {
+	for _, r := range soft {
+		for i := max(0, r.Start-span+1); i < r.End && i < len(valid); i++ {
+			valid[i] = false
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package kmer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// MaxPackedK is the largest k a packed k-mer holds: two bits per base in
+// a uint64.
+const MaxPackedK = MaxSketchK
+
+// packedMask returns the bits of a packed k-mer of length k.
+func packedMask(k int) uint64 {
+	if k >= 32 {
+		return ^uint64(0)
+	}
+	return uint64(1)<<(2*uint(k)) - 1
+}
+
+// Encode packs a k-mer of A, C, G and T, in either case, into two bits
+// per base, the first base in the highest bits. Codes of the same k sort
+// as the k-mers do.
+//
+// Aria equivalent:
+//
+//	fn encode(kmer: String) -> Result<UInt64, KMerError>
+//	  requires kmer.len() > 0 and kmer.len() <= 32
+//	  ensures decode(result, kmer.len()) == kmer.to_upper()
+func Encode(kmer string) (uint64, error) {
+	if len(kmer) == 0 || len(kmer) > MaxPackedK {
+		return 0, fmt.Errorf("k-mer length %d is outside 1-%d", len(kmer), MaxPackedK)
+	}
+	var code uint64
+	for i := 0; i < len(kmer); i++ {
+		c := baseCode(kmer[i])
+		if c < 0 {
+			return 0, fmt.Errorf("k-mer %s: invalid base %q at %d", kmer, kmer[i], i+1)
+		}
+		code = code<<2 | uint64(c)
+	}
+	return code, nil
+}
+
+// Decode unpacks a k-mer of length k packed by Encode.
+//
+// Aria equivalent:
+//
+//	fn decode(code: UInt64, k: Int) -> String
+//	  requires k > 0 and k <= 32
+//	  ensures result.len() == k
+func Decode(code uint64, k int) string {
+	b := make([]byte, k)
+	for i := k - 1; i >= 0; i-- {
+		b[i] = "ACGT"[code&3]
+		code >>= 2
+	}
+	return string(b)
+}
+
+// Roll returns the packed k-mer that follows code when base b is read:
+// its first base is dropped and b appended. It fails on bases other than
+// A, C, G and T.
+//
+// Aria equivalent:
+//
+//	fn roll(code: UInt64, k: Int, base: Char) -> Result<UInt64, KMerError>
+//	  requires k > 0 and k <= 32
+//	  ensures decode(result, k) == decode(code, k)[1..] + base.to_upper()
+func Roll(code uint64, k int, b byte) (uint64, error) {
+	c := baseCode(b)
+	if c < 0 {
+		return 0, fmt.Errorf("invalid base %q", b)
+	}
+	return (code<<2 | uint64(c)) & packedMask(k), nil
+}
+
+// ReverseComplementCode returns the packed reverse complement of a packed
+// k-mer of length k.
+func ReverseComplementCode(code uint64, k int) uint64 {
+	var rc uint64
+	for i := 0; i < k; i++ {
+		rc = rc<<2 | (3 - code&3)
+		code >>= 2
+	}
+	return rc
+}
+
+// PackedCounter counts k-mers of up to MaxPackedK bases keyed by their
+// packed codes. It counts as a Counter does, but reads each k-mer with a
+// rolling update instead of slicing a string per position, and a uint64
+// key takes a fraction of the memory of a string one.
+//
+// Aria equivalent:
+//
+//	struct PackedKMerCounts
+//	  k: Int
+//	  counts: Map<UInt64, Int>
+//	  total_kmers: Int
+//	  invariant self.k > 0 and self.k <= 32
+type PackedCounter struct {
+	K      int
+	Counts map[uint64]int
+	Total  int
+	// Canonical counts each k-mer together with its reverse complement,
+	// under the smaller code.
+	Canonical bool
+	// SkipSoftMasked leaves out k-mers overlapping soft-masked bases.
+	SkipSoftMasked bool
+	// Strand selects the strands counted when not Canonical, as in
+	// Counter.
+	Strand sequence.Strand
+}
+
+// NewPackedCounter creates a packed k-mer counter.
+//
+// Aria equivalent:
+//
+//	fn new_packed(k: Int) -> Result<PackedKMerCounts, KMerError>
+//	  requires k > 0 and k <= 32
+func NewPackedCounter(k int) (*PackedCounter, error) {
+	if k <= 0 || k > MaxPackedK {
+		return nil, fmt.Errorf("k must be between 1 and %d for packed k-mers", MaxPackedK)
+	}
+	return &PackedCounter{K: k, Counts: make(map[uint64]int)}, nil
+}
+
+// Add adds count occurrences of a k-mer.
+func (c *PackedCounter) Add(kmer string, count int) error {
+	if len(kmer) != c.K {
+		return fmt.Errorf("k-mer length %d doesn't match k=%d", len(kmer), c.K)
+	}
+	if count <= 0 {
+		return fmt.Errorf("count must be positive")
+	}
+	code, err := Encode(kmer)
+	if err != nil {
+		return err
+	}
+	if c.Canonical {
+		code = min(code, ReverseComplementCode(code, c.K))
+	}
+	c.Counts[code] += count
+	c.Total += count
+	return nil
+}
+
+// CountKMers counts all k-mers in a sequence string, skipping those with
+// a base other than A, C, G or T and, with SkipSoftMasked, those with
+// lower-case bases.
+func (c *PackedCounter) CountKMers(seq string) {
+	var soft []sequence.MaskRun
+	if c.SkipSoftMasked {
+		soft = lowerCaseRuns(seq)
+	}
+	c.count(seq, soft)
+}
+
+// CountFromSequence counts all k-mers from a Sequence object. With
+// SkipSoftMasked, k-mers overlapping its SoftMaskedRuns are skipped.
+func (c *PackedCounter) CountFromSequence(seq *sequence.Sequence) {
+	var soft []sequence.MaskRun
+	if c.SkipSoftMasked {
+		soft = SoftMaskedRuns(seq)
+	}
+	c.count(seq.Bases, soft)
+}
+
+// count rolls over bases once, restarting the k-mer at ambiguous bases
+// and at each of the sorted soft runs.
+func (c *PackedCounter) count(bases string, soft []sequence.MaskRun) {
+	strand := c.Strand
+	if strand == 0 {
+		strand = sequence.Forward
+	}
+	forward, reverse := strand.Includes(sequence.Forward), strand.Includes(sequence.Reverse)
+	r := newRoller(c.K, c.Canonical)
+	run := 0
+	for i := 0; i < len(bases); i++ {
+		for run < len(soft) && soft[run].End <= i {
+			run++
+		}
+		if run < len(soft) && soft[run].Start <= i {
+			r.reset()
+			continue
+		}
+		code, ok := r.next(bases[i])
+		switch {
+		case !ok:
+		case c.Canonical:
+			c.Counts[code]++
+			c.Total++
+		default:
+			if forward {
+				c.Counts[r.fwd]++
+				c.Total++
+			}
+			if reverse {
+				c.Counts[r.rev]++
+				c.Total++
+			}
+		}
+	}
+}
+
+// GetCount returns the count of a k-mer, under its canonical form for
+// canonical counts.
+func (c *PackedCounter) GetCount(kmer string) (int, error) {
+	if len(kmer) != c.K {
+		return 0, fmt.Errorf("k-mer length doesn't match k=%d", c.K)
+	}
+	code, err := Encode(kmer)
+	if err != nil {
+		return 0, err
+	}
+	if c.Canonical {
+		code = min(code, ReverseComplementCode(code, c.K))
+	}
+	return c.Counts[code], nil
+}
+
+// UniqueCount returns the number of unique k-mers.
+func (c *PackedCounter) UniqueCount() int {
+	return len(c.Counts)
+}
+
+// MostFrequent returns the n most frequent k-mers, decoding only those.
+// Ties are broken by k-mer.
+//
+// Aria equivalent:
+//
+//	fn most_frequent(self, n: Int) -> [(String, Int)]
+//	  requires n > 0
+//	  ensures result.len() <= n
+func (c *PackedCounter) MostFrequent(n int) ([]KMerCount, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("n must be positive")
+	}
+	codes := make([]uint64, 0, len(c.Counts))
+	for code := range c.Counts {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if c.Counts[codes[i]] != c.Counts[codes[j]] {
+			return c.Counts[codes[i]] > c.Counts[codes[j]]
+		}
+		return codes[i] < codes[j]
+	})
+	n = min(n, len(codes))
+	top := make([]KMerCount, n)
+	for i, code := range codes[:n] {
+		top[i] = KMerCount{KMer: Decode(code, c.K), Count: c.Counts[code]}
+	}
+	return top, nil
+}
+
+// Unpack returns the counts keyed by k-mer string, as a Counter.
+func (c *PackedCounter) Unpack() *Counter {
+	counter := &Counter{
+		K:              c.K,
+		Counts:         make(map[string]int, len(c.Counts)),
+		Total:          c.Total,
+		Canonical:      c.Canonical,
+		SkipSoftMasked: c.SkipSoftMasked,
+		Strand:         c.Strand,
+	}
+	for code, n := range c.Counts {
+		counter.Counts[Decode(code, c.K)] = n
+	}
+	return counter
+}
+
+// Pack returns the counts of a Counter of contiguous k-mers keyed by
+// their packed codes. It fails if k exceeds MaxPackedK or a k-mer has a
+// base other than A, C, G or T.
+func Pack(counter *Counter) (*PackedCounter, error) {
+	if counter.Seed != "" {
+		return nil, fmt.Errorf("spaced-seed counts cannot be packed")
+	}
+	c, err := NewPackedCounter(counter.K)
+	if err != nil {
+		return nil, err
+	}
+	c.Canonical, c.SkipSoftMasked, c.Strand = counter.Canonical, counter.SkipSoftMasked, counter.Strand
+	for kmer, n := range counter.Counts {
+		code, err := Encode(kmer)
+		if err != nil {
+			return nil, err
+		}
+		c.Counts[code] += n
+	}
+	c.Total = counter.Total
+	return c, nil
+}
+
+// CountPackedKMers counts the k-mers of a sequence with a PackedCounter,
+// on the strands selected; with skipSoftMasked, k-mers overlapping
+// soft-masked bases are left out.
+//
+// Aria equivalent:
+//
+//	fn count_packed_kmers(sequence: Sequence, k: Int, strand: Strand, skip_soft_masked: Bool) -> PackedKMerCounts
+//	  requires k > 0 and k <= 32
+//	  requires k <= sequence.len()
+func CountPackedKMers(seq *sequence.Sequence, k int, strand sequence.Strand, skipSoftMasked bool) (*PackedCounter, error) {
+	if k > seq.Len() {
+		return nil, fmt.Errorf("k cannot exceed sequence length")
+	}
+	counter, err := NewPackedCounter(k)
+	if err != nil {
+		return nil, err
+	}
+	counter.Strand = strand
+	counter.SkipSoftMasked = skipSoftMasked
+	counter.CountFromSequence(seq)
+	return counter, nil
+}
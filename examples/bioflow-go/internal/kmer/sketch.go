@@ -0,0 +1,383 @@
+package kmer
+
+import (
+	"fmt"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// MaxSketchK is the largest k-mer length that fits the 2-bit packed
+// encoding used for sketching.
+const MaxSketchK = 32
+
+// baseCode maps a nucleotide to its 2-bit code, or -1 for ambiguous bases.
+func baseCode(b byte) int {
+	switch b {
+	case 'A', 'a':
+		return 0
+	case 'C', 'c':
+		return 1
+	case 'G', 'g':
+		return 2
+	case 'T', 't':
+		return 3
+	default:
+		return -1
+	}
+}
+
+// mix64 is the splitmix64 finalizer, used to turn packed k-mers into
+// well-distributed hash values.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// roller maintains the 2-bit packed forward and reverse-complement
+// encodings of the last k bases of a stream.
+type roller struct {
+	k         int
+	canonical bool
+	mask      uint64
+	shift     uint
+	fwd, rev  uint64
+	run       int
+}
+
+func newRoller(k int, canonical bool) *roller {
+	mask := ^uint64(0)
+	if k < 32 {
+		mask = uint64(1)<<(2*uint(k)) - 1
+	}
+	return &roller{k: k, canonical: canonical, mask: mask, shift: 2 * uint(k-1)}
+}
+
+// push appends a base and returns the hash of the k-mer ending at it, or
+// false while fewer than k unambiguous bases have been seen since the
+// last reset or ambiguous base.
+func (r *roller) push(b byte) (uint64, bool) {
+	code, ok := r.next(b)
+	if !ok {
+		return 0, false
+	}
+	return mix64(code), true
+}
+
+// next appends a base and returns the packed k-mer ending at it, the
+// smaller of it and its reverse complement when canonical, or false as
+// push does.
+func (r *roller) next(b byte) (uint64, bool) {
+	c := baseCode(b)
+	if c < 0 {
+		r.reset()
+		return 0, false
+	}
+	r.fwd = (r.fwd<<2 | uint64(c)) & r.mask
+	r.rev = r.rev>>2 | uint64(3-c)<<r.shift
+	r.run++
+	if r.run < r.k {
+		return 0, false
+	}
+	if r.canonical && r.rev < r.fwd {
+		return r.rev, true
+	}
+	return r.fwd, true
+}
+
+// reset starts a new sequence.
+func (r *roller) reset() {
+	r.fwd, r.rev, r.run = 0, 0, 0
+}
+
+// kmerHashes returns the hash of every k-mer of bases, and whether each
+// k-mer is free of ambiguous bases. With canonical set, a k-mer and its
+// reverse complement hash to the same value.
+func kmerHashes(bases string, k int, canonical bool) ([]uint64, []bool) {
+	n := len(bases) - k + 1
+	if n <= 0 {
+		return nil, nil
+	}
+	hashes := make([]uint64, n)
+	valid := make([]bool, n)
+
+	r := newRoller(k, canonical)
+	for i := 0; i < len(bases); i++ {
+		if h, ok := r.push(bases[i]); ok {
+			hashes[i-k+1] = h
+			valid[i-k+1] = true
+		}
+	}
+	return hashes, valid
+}
+
+// Syncmer is a k-mer selected by its minimal s-mer position.
+type Syncmer struct {
+	Position int    `json:"position"`
+	KMer     string `json:"kmer"`
+	Hash     uint64 `json:"hash"`
+}
+
+// SyncmerOptions configures syncmer selection.
+type SyncmerOptions struct {
+	K int
+	// S is the length of the sub-k-mers compared within each k-mer.
+	S int
+	// Offset is the position (0-based, within the k-mer) at which the
+	// smallest s-mer must occur for an open syncmer.
+	Offset int
+	// Closed selects closed syncmers: the smallest s-mer is at either
+	// end of the k-mer. Offset is then ignored.
+	Closed bool
+	// Canonical hashes k-mers and s-mers strand-independently. Open
+	// syncmers are then only strand-symmetric when Offset == (K-S)/2.
+	Canonical bool
+	// SkipSoftMasked selects no k-mer overlapping soft-masked bases.
+	SkipSoftMasked bool
+}
+
+// Syncmers extracts open (or closed) syncmers from a sequence. Unlike
+// minimizers, whether a k-mer is selected depends only on the k-mer
+// itself, not on its neighbours, so selection is unaffected by
+// mutations outside the k-mer.
+//
+// Aria equivalent:
+//
+//	fn syncmers(seq: Sequence, options: SyncmerOptions) -> Result<[Syncmer], KMerError>
+//	  requires 0 < options.s < options.k <= MAX_SKETCH_K
+//	  requires options.offset <= options.k - options.s
+//	  ensures result.all(|m| m.kmer.len() == options.k)
+func Syncmers(seq *sequence.Sequence, opts SyncmerOptions) ([]Syncmer, error) {
+	if opts.K <= 0 || opts.K > MaxSketchK {
+		return nil, fmt.Errorf("k must be between 1 and %d", MaxSketchK)
+	}
+	if opts.S <= 0 || opts.S >= opts.K {
+		return nil, fmt.Errorf("s must be between 1 and k-1")
+	}
+	if !opts.Closed && (opts.Offset < 0 || opts.Offset > opts.K-opts.S) {
+		return nil, fmt.Errorf("offset must be between 0 and k-s")
+	}
+
+	result := make([]Syncmer, 0)
+	kHashes, kValid := kmerHashes(seq.Bases, opts.K, opts.Canonical)
+	sHashes, _ := kmerHashes(seq.Bases, opts.S, opts.Canonical)
+	if opts.SkipSoftMasked {
+		clearSoftMasked(kValid, SoftMaskedRuns(seq), opts.K)
+	}
+	last := opts.K - opts.S
+	for i := range kHashes {
+		if !kValid[i] {
+			continue
+		}
+		minPos := 0
+		for j := 1; j <= last; j++ {
+			if sHashes[i+j] < sHashes[i+minPos] {
+				minPos = j
+			}
+		}
+		selected := minPos == opts.Offset
+		if opts.Closed {
+			selected = minPos == 0 || minPos == last
+		}
+		if selected {
+			result = append(result, Syncmer{Position: i, KMer: seq.Bases[i : i+opts.K], Hash: kHashes[i]})
+		}
+	}
+	return result, nil
+}
+
+// Minimizer is the k-mer of smallest hash in a window of consecutive
+// k-mers. Strand is '+' when the forward k-mer was hashed and '-' when its
+// reverse complement was, so that two minimizers of equal hash on
+// different strands mark an inverted match.
+type Minimizer struct {
+	Position int    `json:"position"`
+	Hash     uint64 `json:"hash"`
+	Strand   byte   `json:"-"`
+}
+
+// MinimizerOptions configures minimizer selection.
+type MinimizerOptions struct {
+	K int
+	// W is the number of consecutive k-mers in each window.
+	W int
+	// Canonical hashes k-mers strand-independently. Palindromic k-mers,
+	// whose strand is undefined, are then never selected.
+	Canonical bool
+	// SkipSoftMasked selects no k-mer overlapping soft-masked bases.
+	SkipSoftMasked bool
+}
+
+// Minimizers extracts the (w,k)-minimizers of a sequence (Roberts 2004),
+// as used for seeding by minimap2: every window of W consecutive k-mers
+// contributes its smallest-hash k-mer (the leftmost on ties), reported
+// once however many windows select it. K-mers spanning ambiguous bases
+// are never selected.
+//
+// Aria equivalent:
+//
+//	fn minimizers(seq: Sequence, options: MinimizerOptions) -> Result<[Minimizer], KMerError>
+//	  requires 0 < options.k <= MAX_SKETCH_K and options.w > 0
+//	  ensures result.is_sorted_by_key(|m| m.position)
+func Minimizers(seq *sequence.Sequence, opts MinimizerOptions) ([]Minimizer, error) {
+	if opts.K <= 0 || opts.K > MaxSketchK {
+		return nil, fmt.Errorf("k must be between 1 and %d", MaxSketchK)
+	}
+	if opts.W <= 0 {
+		return nil, fmt.Errorf("window must be positive")
+	}
+
+	result := make([]Minimizer, 0)
+	n := len(seq.Bases) - opts.K + 1
+	if n <= 0 {
+		return result, nil
+	}
+	hashes := make([]uint64, n)
+	strands := make([]byte, n)
+	valid := make([]bool, n)
+	r := newRoller(opts.K, opts.Canonical)
+	for i := 0; i < len(seq.Bases); i++ {
+		h, ok := r.push(seq.Bases[i])
+		if !ok {
+			continue
+		}
+		start := i - opts.K + 1
+		if opts.Canonical && r.fwd == r.rev {
+			continue
+		}
+		hashes[start], valid[start], strands[start] = h, true, '+'
+		if opts.Canonical && r.rev < r.fwd {
+			strands[start] = '-'
+		}
+	}
+	if opts.SkipSoftMasked {
+		clearSoftMasked(valid, SoftMaskedRuns(seq), opts.K)
+	}
+
+	last := -1
+	for w := 0; w+opts.W <= n || (w == 0 && n < opts.W); w++ {
+		best := -1
+		for i := w; i < w+opts.W && i < n; i++ {
+			if valid[i] && (best < 0 || hashes[i] < hashes[best]) {
+				best = i
+			}
+		}
+		if best >= 0 && best != last {
+			result = append(result, Minimizer{Position: best, Hash: hashes[best], Strand: strands[best]})
+			last = best
+		}
+	}
+	return result, nil
+}
+
+// randstrobePrime is the modulus used when linking strobes.
+const randstrobePrime = (1 << 31) - 1
+
+// Randstrobe is a linked set of k-mers ("strobes") starting at Positions.
+type Randstrobe struct {
+	Positions []int  `json:"positions"`
+	Hash      uint64 `json:"hash"`
+}
+
+// Start returns the position of the first strobe.
+func (r *Randstrobe) Start() int {
+	return r.Positions[0]
+}
+
+// End returns the end (exclusive) of the last strobe of length k.
+func (r *Randstrobe) End(k int) int {
+	return r.Positions[len(r.Positions)-1] + k
+}
+
+// RandstrobeOptions configures randstrobe extraction.
+type RandstrobeOptions struct {
+	// Order is the number of strobes (2 or 3).
+	Order int
+	// K is the strobe length.
+	K int
+	// WMin and WMax bound the offset of each following strobe's window,
+	// relative to the end of the previous strobe window.
+	WMin int
+	WMax int
+	// Canonical hashes strobes strand-independently.
+	Canonical bool
+	// SkipSoftMasked selects no strobe overlapping soft-masked bases.
+	SkipSoftMasked bool
+}
+
+// DefaultRandstrobeOptions are the order-2 parameters suggested for
+// long-read seeding.
+var DefaultRandstrobeOptions = RandstrobeOptions{Order: 2, K: 15, WMin: 16, WMax: 64}
+
+// Randstrobes extracts randstrobes from a sequence (Sahlin 2021). For each
+// position i the first strobe is the k-mer at i; strobe j is the k-mer in
+// the window [i + WMin + (j-2)*WMax, i + (j-1)*WMax] minimizing
+// (h(previous) + h(m)) mod p. Windows are truncated at the sequence end.
+// Strobes spanning ambiguous bases are never selected, nor with
+// SkipSoftMasked those overlapping soft-masked bases.
+//
+// Aria equivalent:
+//
+//	fn randstrobes(seq: Sequence, options: RandstrobeOptions) -> Result<[Randstrobe], KMerError>
+//	  requires options.order == 2 or options.order == 3
+//	  requires 0 < options.w_min <= options.w_max
+//	  ensures result.all(|r| r.positions.len() == options.order)
+func Randstrobes(seq *sequence.Sequence, opts RandstrobeOptions) ([]Randstrobe, error) {
+	if opts.Order != 2 && opts.Order != 3 {
+		return nil, fmt.Errorf("randstrobe order must be 2 or 3")
+	}
+	if opts.K <= 0 || opts.K > MaxSketchK {
+		return nil, fmt.Errorf("k must be between 1 and %d", MaxSketchK)
+	}
+	if opts.WMin <= 0 || opts.WMax < opts.WMin {
+		return nil, fmt.Errorf("window bounds must satisfy 0 < w_min <= w_max")
+	}
+
+	result := make([]Randstrobe, 0)
+	hashes, valid := kmerHashes(seq.Bases, opts.K, opts.Canonical)
+	if opts.SkipSoftMasked {
+		clearSoftMasked(valid, SoftMaskedRuns(seq), opts.K)
+	}
+	last := len(hashes) - 1
+	for i := range hashes {
+		if !valid[i] {
+			continue
+		}
+		positions := []int{i}
+		link := hashes[i]
+		hash := hashes[i] / 2
+		for j := 2; j <= opts.Order; j++ {
+			lo := i + opts.WMin + (j-2)*opts.WMax
+			hi := i + (j-1)*opts.WMax
+			if hi > last {
+				hi = last
+			}
+			best := -1
+			var bestKey uint64
+			for m := lo; m <= hi; m++ {
+				if !valid[m] {
+					continue
+				}
+				key := (link + hashes[m]) % randstrobePrime
+				if best < 0 || key < bestKey {
+					best, bestKey = m, key
+				}
+			}
+			if best < 0 {
+				positions = nil
+				break
+			}
+			positions = append(positions, best)
+			link = hashes[best]
+			hash += hashes[best] / uint64(j+1)
+		}
+		if positions == nil {
+			continue
+		}
+		result = append(result, Randstrobe{Positions: positions, Hash: hash})
+	}
+	return result, nil
+}
@@ -0,0 +1,255 @@
+package kmer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// SpacedSeed is a spaced-seed pattern such as "1101101". Positions marked
+// '1' are compared ("care" positions); positions marked '0' are ignored.
+// A seed of all '1's is an ordinary contiguous k-mer.
+//
+// Spaced seeds make alignment-free comparison more sensitive at higher
+// divergence: a substitution under a '0' leaves the spaced k-mer intact,
+// and hits at neighbouring positions are less correlated than for
+// contiguous k-mers.
+//
+// Aria equivalent:
+//
+//	struct SpacedSeed
+//	  pattern: String
+//	  invariant self.pattern.all(|c| c == '0' or c == '1')
+//	  invariant self.pattern.first() == '1' and self.pattern.last() == '1'
+type SpacedSeed struct {
+	Pattern string
+	// Span is the window length covered by the seed (len(Pattern)).
+	Span int
+	// Weight is the number of care positions, i.e. the length of the
+	// extracted spaced k-mers.
+	Weight int
+	care   []int
+}
+
+// ParseSpacedSeed parses a pattern of '1' (care) and '0' (don't care)
+// characters. The pattern must start and end with '1'.
+//
+// Aria equivalent:
+//
+//	fn parse_spaced_seed(pattern: String) -> Result<SpacedSeed, KMerError>
+//	  requires pattern.len() > 0
+//	  ensures result.span == pattern.len()
+func ParseSpacedSeed(pattern string) (*SpacedSeed, error) {
+	if len(pattern) == 0 {
+		return nil, fmt.Errorf("spaced seed cannot be empty")
+	}
+	care := make([]int, 0, len(pattern))
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '1':
+			care = append(care, i)
+		case '0':
+		default:
+			return nil, fmt.Errorf("invalid spaced seed character '%c' at position %d", pattern[i], i)
+		}
+	}
+	if pattern[0] != '1' || pattern[len(pattern)-1] != '1' {
+		return nil, fmt.Errorf("spaced seed must start and end with '1'")
+	}
+	return &SpacedSeed{Pattern: pattern, Span: len(pattern), Weight: len(care), care: care}, nil
+}
+
+// ContiguousSeed returns the seed of k consecutive care positions.
+func ContiguousSeed(k int) (*SpacedSeed, error) {
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be positive")
+	}
+	return ParseSpacedSeed(strings.Repeat("1", k))
+}
+
+// IsContiguous reports whether the seed has no don't-care positions.
+func (s *SpacedSeed) IsContiguous() bool {
+	return s.Weight == s.Span
+}
+
+// Extract returns the care positions of a window of length Span.
+func (s *SpacedSeed) Extract(window string) (string, error) {
+	if len(window) != s.Span {
+		return "", fmt.Errorf("window length %d doesn't match seed span %d", len(window), s.Span)
+	}
+	return s.extract(window), nil
+}
+
+func (s *SpacedSeed) extract(window string) string {
+	b := make([]byte, len(s.care))
+	for i, p := range s.care {
+		b[i] = window[p]
+	}
+	return string(b)
+}
+
+func (s *SpacedSeed) String() string {
+	return s.Pattern
+}
+
+// countSpaced adds every spaced k-mer of bases to counter, skipping
+// windows with an N at a care position. It stops with the context's error
+// once ctx is done.
+func countSpaced(ctx context.Context, counter *Counter, bases string, seed *SpacedSeed, canonical bool) error {
+	bases = strings.ToUpper(bases)
+	for i := 0; i+seed.Span <= len(bases); i++ {
+		if i%countBlock == 0 {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("counting k-mers: %w", err)
+			}
+		}
+		window := bases[i : i+seed.Span]
+		kmer := seed.extract(window)
+		if strings.ContainsRune(kmer, 'N') {
+			continue
+		}
+		if canonical {
+			// The reverse strand reads the window reverse-complemented,
+			// through the same seed.
+			km := &KMer{Sequence: window, K: seed.Span}
+			if rc := seed.extract(km.ReverseComplement().Sequence); rc < kmer {
+				kmer = rc
+			}
+		}
+		counter.Counts[kmer]++
+		counter.Total++
+	}
+	return nil
+}
+
+// CountSpacedKMers counts the spaced k-mers of a sequence. The returned
+// counter has K equal to the seed weight and Seed set to its pattern.
+//
+// Aria equivalent:
+//
+//	fn count_spaced_kmers(sequence: Sequence, seed: SpacedSeed) -> KMerCounts
+//	  requires seed.span <= sequence.len()
+//	  ensures result.k == seed.weight
+func CountSpacedKMers(seq *sequence.Sequence, seed *SpacedSeed) (*Counter, error) {
+	return newSpacedCounter(context.Background(), seq, seed, false)
+}
+
+// CountSpacedKMersContext counts the spaced k-mers of a sequence as
+// CountSpacedKMers does, and stops with the context's error once ctx is
+// done.
+func CountSpacedKMersContext(ctx context.Context, seq *sequence.Sequence, seed *SpacedSeed) (*Counter, error) {
+	return newSpacedCounter(ctx, seq, seed, false)
+}
+
+// CountSpacedKMersCanonical counts spaced k-mers, merging each window with
+// its reverse complement.
+//
+// Aria equivalent:
+//
+//	fn count_spaced_kmers_canonical(sequence: Sequence, seed: SpacedSeed) -> KMerCounts
+//	  requires seed.span <= sequence.len()
+//	  ensures result.k == seed.weight
+func CountSpacedKMersCanonical(seq *sequence.Sequence, seed *SpacedSeed) (*Counter, error) {
+	return newSpacedCounter(context.Background(), seq, seed, true)
+}
+
+func newSpacedCounter(ctx context.Context, seq *sequence.Sequence, seed *SpacedSeed, canonical bool) (*Counter, error) {
+	if seed == nil {
+		return nil, fmt.Errorf("spaced seed is required")
+	}
+	if seed.Span > seq.Len() {
+		return nil, fmt.Errorf("seed span cannot exceed sequence length")
+	}
+	counter, err := NewCounter(seed.Weight)
+	if err != nil {
+		return nil, err
+	}
+	if !seed.IsContiguous() {
+		counter.Seed = seed.Pattern
+	}
+	counter.Canonical = canonical
+	if err := countSpaced(ctx, counter, seq.Bases, seed, canonical); err != nil {
+		return nil, err
+	}
+	return counter, nil
+}
+
+// spacedPair counts two sequences with the same seed.
+func spacedPair(seq1, seq2 *sequence.Sequence, seed *SpacedSeed) (*Counter, *Counter, error) {
+	if seed == nil {
+		return nil, nil, fmt.Errorf("spaced seed is required")
+	}
+	if seed.Span > seq1.Len() || seed.Span > seq2.Len() {
+		return nil, nil, fmt.Errorf("seed span cannot exceed sequence lengths")
+	}
+	counter1, err := CountSpacedKMers(seq1, seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	counter2, err := CountSpacedKMers(seq2, seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return counter1, counter2, nil
+}
+
+// SpacedJaccardDistance is JaccardDistance over spaced k-mers.
+//
+// Aria equivalent:
+//
+//	fn spaced_jaccard_distance(seq1: Sequence, seq2: Sequence, seed: SpacedSeed) -> Float
+//	  requires seed.span <= seq1.len() and seed.span <= seq2.len()
+//	  ensures result >= 0.0 and result <= 1.0
+func SpacedJaccardDistance(seq1, seq2 *sequence.Sequence, seed *SpacedSeed) (float64, error) {
+	counter1, counter2, err := spacedPair(seq1, seq2, seed)
+	if err != nil {
+		return 0, err
+	}
+	return jaccardCounters(counter1, counter2), nil
+}
+
+// SpacedCosineDistance is CosineDistance over spaced k-mers.
+func SpacedCosineDistance(seq1, seq2 *sequence.Sequence, seed *SpacedSeed) (float64, error) {
+	counter1, counter2, err := spacedPair(seq1, seq2, seed)
+	if err != nil {
+		return 0, err
+	}
+	return cosineCounters(counter1, counter2), nil
+}
+
+// SpacedEuclideanDistance is EuclideanDistance over spaced k-mers.
+func SpacedEuclideanDistance(seq1, seq2 *sequence.Sequence, seed *SpacedSeed) (float64, error) {
+	counter1, counter2, err := spacedPair(seq1, seq2, seed)
+	if err != nil {
+		return 0, err
+	}
+	return euclideanCounters(counter1, counter2), nil
+}
+
+// SpacedSimilarityMatrix is SimilarityMatrix over spaced k-mers.
+func SpacedSimilarityMatrix(sequences []*sequence.Sequence, seed *SpacedSeed) ([][]float64, error) {
+	n := len(sequences)
+	if n == 0 {
+		return nil, fmt.Errorf("sequence list cannot be empty")
+	}
+
+	matrix := make([][]float64, n)
+	for i := range matrix {
+		matrix[i] = make([]float64, n)
+	}
+
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			dist, err := SpacedJaccardDistance(sequences[i], sequences[j], seed)
+			if err != nil {
+				return nil, err
+			}
+			matrix[i][j] = dist
+			matrix[j][i] = dist
+		}
+	}
+
+	return matrix, nil
+}
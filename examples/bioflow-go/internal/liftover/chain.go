@@ -0,0 +1,345 @@
+// Package liftover maps intervals between assemblies through alignment
+// chains.
+//
+// A Chain is a UCSC-style chain: an ordered list of gapless aligned
+// blocks between a source ("target" in UCSC terms, the assembly the
+// intervals come from) and a destination ("query") sequence. Chains can
+// be read from and written to the UCSC chain format, or built from
+// collinear k-mer anchor chains and gapped pairwise alignments. A Lifter
+// then maps each interval through the chain that covers most of it and
+// reports whether it lifted completely, partially or not at all.
+//
+// Comparison with Aria:
+//
+//	Aria states the block invariants on the chain:
+//	  struct Chain
+//	    invariant self.blocks.is_sorted_by(|a, b| a.t_end() <= b.t_start and a.q_end() <= b.q_start)
+//	    invariant self.blocks.all(|b| b.size > 0)
+//
+//	Go checks them in Validate and when parsing.
+package liftover
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/kmer"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Block is a gapless aligned block. TStart and QStart are 0-based
+// positions on the source and destination; QStart is on the reverse
+// complement when the chain's QStrand is '-'.
+type Block struct {
+	TStart int `json:"t_start"`
+	QStart int `json:"q_start"`
+	Size   int `json:"size"`
+}
+
+// Chain is a UCSC chain between a source (T) and destination (Q)
+// sequence. Coordinates are 0-based, half-open; Q coordinates are on the
+// reverse complement of the destination when QStrand is '-'.
+type Chain struct {
+	ID      int     `json:"id"`
+	Score   float64 `json:"score"`
+	TName   string  `json:"t_name"`
+	TSize   int     `json:"t_size"`
+	TStart  int     `json:"t_start"`
+	TEnd    int     `json:"t_end"`
+	QName   string  `json:"q_name"`
+	QSize   int     `json:"q_size"`
+	QStrand byte    `json:"-"`
+	QStart  int     `json:"q_start"`
+	QEnd    int     `json:"q_end"`
+	Blocks  []Block `json:"blocks"`
+}
+
+// Validate checks that the blocks are non-empty, ordered, non-overlapping
+// and within the chain's bounds.
+func (c *Chain) Validate() error {
+	if c.QStrand != '+' && c.QStrand != '-' {
+		return fmt.Errorf("chain %d: strand must be '+' or '-'", c.ID)
+	}
+	if len(c.Blocks) == 0 {
+		return fmt.Errorf("chain %d: no blocks", c.ID)
+	}
+	for i, b := range c.Blocks {
+		if b.Size <= 0 {
+			return fmt.Errorf("chain %d: block %d has size %d", c.ID, i, b.Size)
+		}
+		if i > 0 {
+			prev := c.Blocks[i-1]
+			if b.TStart < prev.TStart+prev.Size || b.QStart < prev.QStart+prev.Size {
+				return fmt.Errorf("chain %d: block %d overlaps the previous block", c.ID, i)
+			}
+		}
+	}
+	first, last := c.Blocks[0], c.Blocks[len(c.Blocks)-1]
+	if first.TStart < c.TStart || last.TStart+last.Size > c.TEnd || c.TEnd > c.TSize {
+		return fmt.Errorf("chain %d: blocks outside source interval %d-%d", c.ID, c.TStart, c.TEnd)
+	}
+	if first.QStart < c.QStart || last.QStart+last.Size > c.QEnd || c.QEnd > c.QSize {
+		return fmt.Errorf("chain %d: blocks outside destination interval %d-%d", c.ID, c.QStart, c.QEnd)
+	}
+	return nil
+}
+
+// AlignedBases returns the number of bases in the chain's blocks.
+func (c *Chain) AlignedBases() int {
+	n := 0
+	for _, b := range c.Blocks {
+		n += b.Size
+	}
+	return n
+}
+
+// newChain creates a chain from ordered blocks, deriving its bounds and
+// using the aligned bases as score.
+func newChain(id int, tName string, tSize int, qName string, qSize int, strand byte, blocks []Block) *Chain {
+	first, last := blocks[0], blocks[len(blocks)-1]
+	c := &Chain{
+		ID: id, TName: tName, TSize: tSize, QName: qName, QSize: qSize, QStrand: strand,
+		TStart: first.TStart, TEnd: last.TStart + last.Size,
+		QStart: first.QStart, QEnd: last.QStart + last.Size,
+		Blocks: blocks,
+	}
+	c.Score = float64(c.AlignedBases())
+	return c
+}
+
+// Read parses chains in the UCSC chain format: a header line
+// "chain score tName tSize tStrand tStart tEnd qName qSize qStrand qStart
+// qEnd id" followed by "size dt dq" lines and a final "size" line.
+//
+// Aria equivalent:
+//
+//	fn read(r: Reader) -> Result<[Chain], LiftoverError>
+//	  ensures result.is_ok() implies result.unwrap().all(|c| c.validate().is_ok())
+func Read(r io.Reader) ([]*Chain, error) {
+	chains := make([]*Chain, 0)
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	var current *Chain
+	tPos, qPos := 0, 0
+	finish := func() error {
+		if current == nil {
+			return nil
+		}
+		if err := current.Validate(); err != nil {
+			return err
+		}
+		chains = append(chains, current)
+		current = nil
+		return nil
+	}
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if fields[0] == "chain" {
+			if current != nil {
+				return nil, fmt.Errorf("line %d: chain %d has no final block line", lineNum, current.ID)
+			}
+			c, err := parseHeader(fields)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			current, tPos, qPos = c, c.TStart, c.QStart
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("line %d: block outside a chain", lineNum)
+		}
+		nums := make([]int, len(fields))
+		for i, f := range fields {
+			n, err := strconv.Atoi(f)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("line %d: invalid block field %q", lineNum, f)
+			}
+			nums[i] = n
+		}
+		switch len(nums) {
+		case 1:
+			current.Blocks = append(current.Blocks, Block{TStart: tPos, QStart: qPos, Size: nums[0]})
+			if err := finish(); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+		case 3:
+			current.Blocks = append(current.Blocks, Block{TStart: tPos, QStart: qPos, Size: nums[0]})
+			tPos += nums[0] + nums[1]
+			qPos += nums[0] + nums[2]
+		default:
+			return nil, fmt.Errorf("line %d: expected 1 or 3 block fields, got %d", lineNum, len(nums))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading chains: %w", err)
+	}
+	if current != nil {
+		return nil, fmt.Errorf("chain %d has no final block line", current.ID)
+	}
+	return chains, nil
+}
+
+// parseHeader parses a "chain" header line.
+func parseHeader(fields []string) (*Chain, error) {
+	if len(fields) < 12 {
+		return nil, fmt.Errorf("chain header needs at least 12 fields, got %d", len(fields))
+	}
+	score, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid score %q", fields[1])
+	}
+	ints := make([]int, 0, 8)
+	for _, i := range []int{3, 5, 6, 8, 10, 11} {
+		n, err := strconv.Atoi(fields[i])
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid coordinate %q", fields[i])
+		}
+		ints = append(ints, n)
+	}
+	if fields[4] != "+" {
+		return nil, fmt.Errorf("source strand must be '+'")
+	}
+	if fields[9] != "+" && fields[9] != "-" {
+		return nil, fmt.Errorf("invalid strand %q", fields[9])
+	}
+	c := &Chain{
+		Score: score, TName: fields[2], TSize: ints[0], TStart: ints[1], TEnd: ints[2],
+		QName: fields[7], QSize: ints[3], QStrand: fields[9][0], QStart: ints[4], QEnd: ints[5],
+	}
+	if len(fields) > 12 {
+		if c.ID, err = strconv.Atoi(fields[12]); err != nil {
+			return nil, fmt.Errorf("invalid chain id %q", fields[12])
+		}
+	}
+	return c, nil
+}
+
+// Write writes chains in the UCSC chain format.
+func Write(w io.Writer, chains []*Chain) error {
+	bw := bufio.NewWriter(w)
+	for _, c := range chains {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+		fmt.Fprintf(bw, "chain %s %s %d + %d %d %s %d %c %d %d %d\n",
+			strconv.FormatFloat(c.Score, 'f', -1, 64), c.TName, c.TSize, c.TStart, c.TEnd,
+			c.QName, c.QSize, c.QStrand, c.QStart, c.QEnd, c.ID)
+		for i, b := range c.Blocks {
+			if i == len(c.Blocks)-1 {
+				fmt.Fprintf(bw, "%d\n\n", b.Size)
+				break
+			}
+			next := c.Blocks[i+1]
+			fmt.Fprintf(bw, "%d\t%d\t%d\n", b.Size, next.TStart-b.TStart-b.Size, next.QStart-b.QStart-b.Size)
+		}
+	}
+	return bw.Flush()
+}
+
+// FromAnchorChain converts a collinear k-mer anchor chain between a
+// source (seq1) and destination (seq2) sequence into a chain of gapless
+// blocks. Anchors on the same diagonal merge into one block; an anchor
+// overlapping the previous block on either sequence is trimmed.
+//
+// Aria equivalent:
+//
+//	fn from_anchor_chain(id: Int, t_name: String, t_size: Int, q_name: String, q_size: Int,
+//	                     chain: kmer.Chain, k: Int) -> Option<Chain>
+//	  requires k > 0
+//	  ensures result.is_some() implies result.unwrap().validate().is_ok()
+func FromAnchorChain(id int, tName string, tSize int, qName string, qSize int, chain kmer.Chain, k int) *Chain {
+	blocks := make([]Block, 0)
+	for _, a := range chain.Anchors {
+		t, q := a.Pos1, a.Pos2
+		if chain.Strand == '-' {
+			q = qSize - a.Pos2 - k
+		}
+		size := k
+		if n := len(blocks); n > 0 {
+			last := &blocks[n-1]
+			tEnd, qEnd := last.TStart+last.Size, last.QStart+last.Size
+			if t-q == last.TStart-last.QStart && t <= tEnd {
+				if t+k > tEnd {
+					last.Size = t + k - last.TStart
+				}
+				continue
+			}
+			d := 0
+			if tEnd > t {
+				d = tEnd - t
+			}
+			if qEnd-q > d {
+				d = qEnd - q
+			}
+			t, q, size = t+d, q+d, size-d
+		}
+		if size > 0 {
+			blocks = append(blocks, Block{TStart: t, QStart: q, Size: size})
+		}
+	}
+	if len(blocks) == 0 {
+		return nil
+	}
+	strand := chain.Strand
+	if strand != '-' {
+		strand = '+'
+	}
+	return newChain(id, tName, tSize, qName, qSize, strand, blocks)
+}
+
+// FromAlignedRows builds a forward-strand chain from a gapped pairwise
+// alignment of a source row against a destination row, starting at
+// 0-based positions tStart and qStart. Each gapless run of aligned
+// columns becomes a block.
+//
+// Aria equivalent:
+//
+//	fn from_aligned_rows(id: Int, t_name: String, t_size: Int, t_start: Int,
+//	                     q_name: String, q_size: Int, q_start: Int,
+//	                     t_row: String, q_row: String) -> Result<Chain, LiftoverError>
+//	  requires t_row.len() == q_row.len()
+func FromAlignedRows(id int, tName string, tSize, tStart int, qName string, qSize, qStart int, tRow, qRow string) (*Chain, error) {
+	if len(tRow) != len(qRow) {
+		return nil, fmt.Errorf("aligned rows must have equal length")
+	}
+	blocks := make([]Block, 0)
+	t, q := tStart, qStart
+	inBlock := false
+	for i := 0; i < len(tRow); i++ {
+		switch {
+		case sequence.IsGap(tRow[i]) && sequence.IsGap(qRow[i]):
+			continue
+		case sequence.IsGap(tRow[i]):
+			q++
+			inBlock = false
+		case sequence.IsGap(qRow[i]):
+			t++
+			inBlock = false
+		default:
+			if inBlock {
+				blocks[len(blocks)-1].Size++
+			} else {
+				blocks = append(blocks, Block{TStart: t, QStart: q, Size: 1})
+				inBlock = true
+			}
+			t++
+			q++
+		}
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("alignment has no aligned columns")
+	}
+	c := newChain(id, tName, tSize, qName, qSize, '+', blocks)
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,253 @@
+package liftover
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultMinMatch is the minimum fraction of an interval's bases that must
+// map for a partial lift to succeed, as in UCSC liftOver.
+const DefaultMinMatch = 0.95
+
+// Interval is a named, 0-based half-open interval, as in BED.
+type Interval struct {
+	Chrom  string `json:"chrom"`
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+	Name   string `json:"name,omitempty"`
+	Strand byte   `json:"-"`
+}
+
+// Len returns the interval length.
+func (iv Interval) Len() int {
+	return iv.End - iv.Start
+}
+
+// Status is the outcome of lifting one interval.
+type Status string
+
+const (
+	// Lifted means every base of the interval mapped through one chain.
+	Lifted Status = "lifted"
+	// Partial means at least the minimum fraction of bases mapped; the
+	// lifted interval spans the first to the last mapped base.
+	Partial Status = "partial"
+	// Failed means the interval could not be mapped.
+	Failed Status = "failed"
+)
+
+// Result is the lift of one interval. Target is nil when Status is
+// Failed, and Reason explains failures and partial lifts.
+type Result struct {
+	Source         Interval  `json:"source"`
+	Target         *Interval `json:"target,omitempty"`
+	Status         Status    `json:"status"`
+	MappedFraction float64   `json:"mapped_fraction"`
+	ChainID        int       `json:"chain_id,omitempty"`
+	Reason         string    `json:"reason,omitempty"`
+}
+
+// Lifter maps intervals through a set of chains.
+type Lifter struct {
+	// MinMatch is the minimum mapped fraction for a partial lift.
+	MinMatch float64
+	chains   map[string][]*Chain
+}
+
+// NewLifter indexes chains by source sequence.
+//
+// Aria equivalent:
+//
+//	fn new(chains: [Chain], min_match: Float) -> Result<Lifter, LiftoverError>
+//	  requires min_match > 0.0 and min_match <= 1.0
+func NewLifter(chains []*Chain, minMatch float64) (*Lifter, error) {
+	if minMatch <= 0 || minMatch > 1 {
+		return nil, fmt.Errorf("min match must be in (0, 1], got %g", minMatch)
+	}
+	l := &Lifter{MinMatch: minMatch, chains: make(map[string][]*Chain)}
+	for _, c := range chains {
+		if err := c.Validate(); err != nil {
+			return nil, err
+		}
+		l.chains[c.TName] = append(l.chains[c.TName], c)
+	}
+	for _, cs := range l.chains {
+		sort.Slice(cs, func(i, j int) bool { return cs[i].TStart < cs[j].TStart })
+	}
+	return l, nil
+}
+
+// Lift maps an interval through the chain covering most of its bases,
+// preferring the higher-scoring chain on ties. On '-' chains the lifted
+// interval is converted back to forward-strand coordinates and its
+// strand flipped.
+//
+// Aria equivalent:
+//
+//	fn lift(self, interval: Interval) -> Result
+//	  requires interval.start < interval.end
+//	  ensures result.status == Failed or result.mapped_fraction >= self.min_match
+func (l *Lifter) Lift(iv Interval) Result {
+	res := Result{Source: iv, Status: Failed}
+	if iv.Start < 0 || iv.End <= iv.Start {
+		res.Reason = "empty or invalid interval"
+		return res
+	}
+	chains, ok := l.chains[iv.Chrom]
+	if !ok {
+		res.Reason = "no chain for " + iv.Chrom
+		return res
+	}
+
+	var best *Chain
+	bestMapped, bestQStart, bestQEnd := 0, 0, 0
+	for _, c := range chains {
+		if c.TStart >= iv.End {
+			break
+		}
+		if c.TEnd <= iv.Start {
+			continue
+		}
+		mapped, qStart, qEnd := 0, -1, -1
+		i := sort.Search(len(c.Blocks), func(i int) bool { return c.Blocks[i].TStart+c.Blocks[i].Size > iv.Start })
+		for ; i < len(c.Blocks) && c.Blocks[i].TStart < iv.End; i++ {
+			b := c.Blocks[i]
+			s, e := maxInt(b.TStart, iv.Start), minInt(b.TStart+b.Size, iv.End)
+			mapped += e - s
+			if qStart < 0 {
+				qStart = b.QStart + s - b.TStart
+			}
+			qEnd = b.QStart + e - b.TStart
+		}
+		if mapped > bestMapped || (mapped == bestMapped && mapped > 0 && c.Score > best.Score) {
+			best, bestMapped, bestQStart, bestQEnd = c, mapped, qStart, qEnd
+		}
+	}
+	if best == nil {
+		res.Reason = "deleted in new"
+		return res
+	}
+
+	res.MappedFraction = float64(bestMapped) / float64(iv.Len())
+	res.ChainID = best.ID
+	if res.MappedFraction < l.MinMatch {
+		res.Reason = fmt.Sprintf("partially deleted in new (%.1f%% mapped)", 100*res.MappedFraction)
+		return res
+	}
+
+	target := Interval{Chrom: best.QName, Start: bestQStart, End: bestQEnd, Name: iv.Name, Strand: iv.Strand}
+	if best.QStrand == '-' {
+		target.Start, target.End = best.QSize-bestQEnd, best.QSize-bestQStart
+		switch iv.Strand {
+		case '+':
+			target.Strand = '-'
+		case '-':
+			target.Strand = '+'
+		}
+	}
+	res.Target = &target
+	res.Status = Lifted
+	if bestMapped < iv.Len() {
+		res.Status = Partial
+		res.Reason = fmt.Sprintf("%d of %d bases mapped", bestMapped, iv.Len())
+	}
+	return res
+}
+
+// LiftAll lifts every interval.
+func (l *Lifter) LiftAll(intervals []Interval) []Result {
+	results := make([]Result, len(intervals))
+	for i, iv := range intervals {
+		results[i] = l.Lift(iv)
+	}
+	return results
+}
+
+// ReadBED reads intervals from BED (at least three columns; the name and
+// strand are taken from columns 4 and 6 when present). Track, browser and
+// comment lines are skipped.
+func ReadBED(r io.Reader) ([]Interval, error) {
+	intervals := make([]Interval, 0)
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") ||
+			strings.HasPrefix(line, "track") || strings.HasPrefix(line, "browser") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			fields = strings.Fields(line)
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected at least 3 columns", lineNum)
+		}
+		start, err1 := strconv.Atoi(fields[1])
+		end, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil || start < 0 || end < start {
+			return nil, fmt.Errorf("line %d: invalid interval %s-%s", lineNum, fields[1], fields[2])
+		}
+		iv := Interval{Chrom: fields[0], Start: start, End: end}
+		if len(fields) > 3 {
+			iv.Name = fields[3]
+		}
+		if len(fields) > 5 && (fields[5] == "+" || fields[5] == "-") {
+			iv.Strand = fields[5][0]
+		}
+		intervals = append(intervals, iv)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading BED: %w", err)
+	}
+	return intervals, nil
+}
+
+// WriteBED writes intervals as BED3, BED4 when any has a name, or BED6
+// when any has a strand.
+func WriteBED(w io.Writer, intervals []Interval) error {
+	named, stranded := false, false
+	for _, iv := range intervals {
+		named = named || iv.Name != ""
+		stranded = stranded || iv.Strand != 0
+	}
+	bw := bufio.NewWriter(w)
+	for _, iv := range intervals {
+		fmt.Fprintf(bw, "%s\t%d\t%d", iv.Chrom, iv.Start, iv.End)
+		if named || stranded {
+			name := iv.Name
+			if name == "" {
+				name = "."
+			}
+			fmt.Fprintf(bw, "\t%s", name)
+		}
+		if stranded {
+			strand := byte('.')
+			if iv.Strand != 0 {
+				strand = iv.Strand
+			}
+			fmt.Fprintf(bw, "\t0\t%c", strand)
+		}
+		fmt.Fprintln(bw)
+	}
+	return bw.Flush()
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
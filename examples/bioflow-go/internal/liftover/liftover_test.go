@@ -0,0 +1,109 @@
+package liftover
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/kmer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testChains maps chr1 to chrA with a 10 bp deletion in the new assembly,
+// and chr2 to the reverse strand of chrB.
+const testChains = `chain 1000 chr1 1000 + 100 300 chrA 900 + 0 190 1
+100	10	0
+90
+
+chain 500 chr2 500 + 0 100 chrB 200 - 50 150 2
+100
+`
+
+func TestReadWriteChains(t *testing.T) {
+	chains, err := Read(strings.NewReader(testChains))
+	require.NoError(t, err)
+	require.Len(t, chains, 2)
+	assert.Equal(t, []Block{{TStart: 100, QStart: 0, Size: 100}, {TStart: 210, QStart: 100, Size: 90}}, chains[0].Blocks)
+	assert.Equal(t, byte('-'), chains[1].QStrand)
+	assert.Equal(t, 190, chains[0].AlignedBases())
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, chains))
+	again, err := Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, chains, again)
+
+	_, err = Read(strings.NewReader("chain 1 chr1 10 + 0 10 chrA 10 + 0 10 1\n5\t0\t0\n"))
+	assert.Error(t, err)
+	_, err = Read(strings.NewReader("chain 1 chr1 10 + 0 10 chrA 10 + 0 10 1\n20\n"))
+	assert.Error(t, err)
+}
+
+func TestLift(t *testing.T) {
+	chains, err := Read(strings.NewReader(testChains))
+	require.NoError(t, err)
+	l, err := NewLifter(chains, 0.9)
+	require.NoError(t, err)
+
+	res := l.Lift(Interval{Chrom: "chr1", Start: 110, End: 150, Name: "a"})
+	assert.Equal(t, Lifted, res.Status)
+	assert.Equal(t, &Interval{Chrom: "chrA", Start: 10, End: 50, Name: "a"}, res.Target)
+	assert.Equal(t, 1, res.ChainID)
+
+	// 100 bases spanning the deletion: 90 map.
+	res = l.Lift(Interval{Chrom: "chr1", Start: 150, End: 250})
+	assert.Equal(t, Partial, res.Status)
+	assert.InDelta(t, 0.9, res.MappedFraction, 1e-9)
+	assert.Equal(t, &Interval{Chrom: "chrA", Start: 50, End: 140}, res.Target)
+
+	res = l.Lift(Interval{Chrom: "chr1", Start: 190, End: 230})
+	assert.Equal(t, Failed, res.Status)
+	assert.Nil(t, res.Target)
+	assert.Contains(t, res.Reason, "partially deleted")
+
+	assert.Equal(t, Failed, l.Lift(Interval{Chrom: "chr1", Start: 400, End: 410}).Status)
+	assert.Equal(t, Failed, l.Lift(Interval{Chrom: "chrX", Start: 0, End: 10}).Status)
+
+	res = l.Lift(Interval{Chrom: "chr2", Start: 10, End: 20, Strand: '+'})
+	require.Equal(t, Lifted, res.Status)
+	assert.Equal(t, &Interval{Chrom: "chrB", Start: 130, End: 140, Strand: '-'}, res.Target)
+
+	_, err = NewLifter(chains, 0)
+	assert.Error(t, err)
+}
+
+func TestFromAnchorChainAndRows(t *testing.T) {
+	chain := kmer.Chain{
+		Strand: '+',
+		Anchors: []kmer.Anchor{
+			{Pos1: 0, Pos2: 5}, {Pos1: 2, Pos2: 7}, {Pos1: 10, Pos2: 12}, {Pos1: 12, Pos2: 13},
+		},
+	}
+	c := FromAnchorChain(1, "s", 100, "d", 100, chain, 4)
+	require.NotNil(t, c)
+	assert.Equal(t, []Block{{0, 5, 6}, {10, 12, 4}, {15, 16, 1}}, c.Blocks)
+	require.NoError(t, c.Validate())
+
+	rc := FromAnchorChain(2, "s", 100, "d", 50, kmer.Chain{Strand: '-', Anchors: []kmer.Anchor{{Pos1: 0, Pos2: 40}, {Pos1: 5, Pos2: 35}}}, 5)
+	require.NotNil(t, rc)
+	assert.Equal(t, byte('-'), rc.QStrand)
+	assert.Equal(t, []Block{{0, 5, 10}}, rc.Blocks)
+
+	c, err := FromAlignedRows(3, "s", 20, 2, "d", 20, 0, "ACGT--ACGT", "AC-TGGACGT")
+	require.NoError(t, err)
+	assert.Equal(t, []Block{{2, 0, 2}, {5, 2, 1}, {6, 5, 4}}, c.Blocks)
+}
+
+func TestBED(t *testing.T) {
+	intervals, err := ReadBED(strings.NewReader("track name=x\nchr1\t10\t20\tgene1\t0\t-\nchr2 5 6\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []Interval{{Chrom: "chr1", Start: 10, End: 20, Name: "gene1", Strand: '-'}, {Chrom: "chr2", Start: 5, End: 6}}, intervals)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteBED(&buf, intervals))
+	assert.Equal(t, "chr1\t10\t20\tgene1\t0\t-\nchr2\t5\t6\t.\t0\t.\n", buf.String())
+
+	_, err = ReadBED(strings.NewReader("chr1\t20\t10\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,259 @@
+// Package manifest records the provenance of output files: for each file
+// its size, SHA-256 digest and, where known, record count, together with
+// the tool version and parameters that produced it. A manifest written
+// alongside the outputs lets anyone check later that they are complete
+// and unmodified, as regulated environments require.
+//
+// A manifest is a JSON document:
+//
+//	{
+//	  "tool": "bioflow",
+//	  "version": "1.0.0",
+//	  "created": "2024-05-01T12:00:00Z",
+//	  "command": "filter",
+//	  "parameters": {"min-quality": "25"},
+//	  "files": [{"path": "clean.fq.gz", "size": 1234, "sha256": "...", "records": 100}]
+//	}
+//
+// File paths are relative to the directory of the manifest when the
+// files lie beneath it, so that a directory of results can be moved or
+// archived as a whole.
+//
+// Comparison with Aria:
+//
+//	Aria would state what Verify guarantees in its contract:
+//	  fn verify(self, base: Path) -> List<Result> with FileSystem
+//	  ensures result.all(|r| r.ok() implies sha256(r.path) == r.entry.sha256)
+//
+//	Go documents the guarantee and checks it at run time.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry describes one file.
+type Entry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// Records is the number of records (sequences, reads, rows) in the
+	// file, or nil when the writer did not count them.
+	Records *int64 `json:"records,omitempty"`
+}
+
+// Manifest describes the files written by one run of a tool. Its methods
+// may be called concurrently.
+type Manifest struct {
+	Tool       string            `json:"tool"`
+	Version    string            `json:"version"`
+	Created    time.Time         `json:"created"`
+	Command    string            `json:"command,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+	Arguments  []string          `json:"arguments,omitempty"`
+	Files      []Entry           `json:"files"`
+
+	mu sync.Mutex
+}
+
+// New creates an empty manifest for a tool.
+func New(tool, version string) *Manifest {
+	return &Manifest{
+		Tool:       tool,
+		Version:    version,
+		Created:    time.Now().UTC().Truncate(time.Second),
+		Parameters: make(map[string]string),
+	}
+}
+
+// Add records a file. Paths are made absolute until the manifest is
+// written; a file written again replaces its earlier entry.
+func (m *Manifest) Add(e Entry) {
+	if abs, err := filepath.Abs(e.Path); err == nil {
+		e.Path = abs
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.Files {
+		if m.Files[i].Path == e.Path {
+			m.Files[i] = e
+			return
+		}
+	}
+	m.Files = append(m.Files, e)
+}
+
+// SetParameter records a parameter of the run.
+func (m *Manifest) SetParameter(name, value string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Parameters == nil {
+		m.Parameters = make(map[string]string)
+	}
+	m.Parameters[name] = value
+}
+
+// Encode writes the manifest as indented JSON, with the paths of files
+// beneath dir relative to it and the files sorted by path.
+//
+// Aria equivalent:
+//
+//	fn encode(self, w: Writer, dir: Path) -> Result<(), IoError>
+func (m *Manifest) Encode(w io.Writer, dir string) error {
+	m.mu.Lock()
+	out := Manifest{
+		Tool:       m.Tool,
+		Version:    m.Version,
+		Created:    m.Created,
+		Command:    m.Command,
+		Parameters: make(map[string]string, len(m.Parameters)),
+		Arguments:  m.Arguments,
+		Files:      make([]Entry, len(m.Files)),
+	}
+	for name, value := range m.Parameters {
+		out.Parameters[name] = value
+	}
+	copy(out.Files, m.Files)
+	m.mu.Unlock()
+
+	base, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	for i := range out.Files {
+		rel, err := filepath.Rel(base, out.Files[i].Path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			out.Files[i].Path = filepath.ToSlash(rel)
+		}
+	}
+	sort.Slice(out.Files, func(i, j int) bool { return out.Files[i].Path < out.Files[j].Path })
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(&out); err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	return nil
+}
+
+// Decode reads a manifest.
+func Decode(r io.Reader) (*Manifest, error) {
+	var m Manifest
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+	for _, e := range m.Files {
+		if e.Path == "" {
+			return nil, errors.New("parsing manifest: file without a path")
+		}
+		if len(e.SHA256) != sha256.Size*2 {
+			return nil, fmt.Errorf("parsing manifest: %s: invalid sha256 %q", e.Path, e.SHA256)
+		}
+	}
+	return &m, nil
+}
+
+// Digest is an io.Writer that measures and hashes what is written to it.
+type Digest struct {
+	h    hash.Hash
+	size int64
+}
+
+// NewDigest creates a Digest.
+func NewDigest() *Digest {
+	return &Digest{h: sha256.New()}
+}
+
+// Write hashes p.
+func (d *Digest) Write(p []byte) (int, error) {
+	d.size += int64(len(p))
+	return d.h.Write(p)
+}
+
+// Size returns the number of bytes written.
+func (d *Digest) Size() int64 {
+	return d.size
+}
+
+// Sum returns the SHA-256 digest of the bytes written, in hex.
+func (d *Digest) Sum() string {
+	return hex.EncodeToString(d.h.Sum(nil))
+}
+
+// HashFile returns the size and SHA-256 digest of a file.
+func HashFile(path string) (int64, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, "", fmt.Errorf("opening file: %w", err)
+	}
+	defer file.Close()
+
+	d := NewDigest()
+	if _, err := io.Copy(d, file); err != nil {
+		return 0, "", fmt.Errorf("reading file: %w", err)
+	}
+	return d.Size(), d.Sum(), nil
+}
+
+// Result is the outcome of verifying one file; Err is nil if the file
+// matches its entry.
+type Result struct {
+	Entry Entry
+	Err   error
+}
+
+// Verify checks every file against its entry. Relative paths are
+// resolved against base, the directory of the manifest.
+//
+// Aria equivalent:
+//
+//	fn verify(self, base: Path) -> List<Result> with FileSystem
+//	  ensures result.len() == self.files.len()
+func (m *Manifest) Verify(base string) []Result {
+	m.mu.Lock()
+	files := make([]Entry, len(m.Files))
+	copy(files, m.Files)
+	m.mu.Unlock()
+
+	results := make([]Result, len(files))
+	for i, e := range files {
+		results[i] = Result{Entry: e, Err: verifyFile(base, e)}
+	}
+	return results
+}
+
+// verifyFile checks one file against its entry.
+func verifyFile(base string, e Entry) error {
+	path := filepath.FromSlash(e.Path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	size, sum, err := HashFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return errors.New("missing")
+		}
+		return err
+	}
+	if size != e.Size {
+		return fmt.Errorf("size %d, expected %d", size, e.Size)
+	}
+	if !strings.EqualFold(sum, e.SHA256) {
+		return fmt.Errorf("sha256 %s, expected %s", sum, e.SHA256)
+	}
+	return nil
+}
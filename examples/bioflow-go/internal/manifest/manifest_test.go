@@ -0,0 +1,92 @@
+package manifest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	dir := t.TempDir()
+	records := int64(2)
+	m := New("bioflow", "1.0.0")
+	m.Command = "filter"
+	m.SetParameter("min-quality", "25")
+	m.Add(Entry{Path: filepath.Join(dir, "sub", "b.fq"), Size: 1, SHA256: strings.Repeat("0", 64)})
+	m.Add(Entry{Path: filepath.Join(dir, "a.fa"), Size: 2, SHA256: strings.Repeat("1", 64), Records: &records})
+	m.Add(Entry{Path: filepath.Join(dir, "a.fa"), Size: 3, SHA256: strings.Repeat("2", 64), Records: &records})
+	m.Add(Entry{Path: "/elsewhere/c.fa", Size: 4, SHA256: strings.Repeat("3", 64)})
+
+	var buf bytes.Buffer
+	require.NoError(t, m.Encode(&buf, dir))
+	decoded, err := Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, "filter", decoded.Command)
+	assert.Equal(t, map[string]string{"min-quality": "25"}, decoded.Parameters)
+	require.Len(t, decoded.Files, 3)
+	assert.Equal(t, "/elsewhere/c.fa", decoded.Files[0].Path, "outside the manifest directory")
+	assert.Equal(t, "a.fa", decoded.Files[1].Path)
+	assert.Equal(t, int64(3), decoded.Files[1].Size, "a file written twice")
+	require.NotNil(t, decoded.Files[1].Records)
+	assert.Equal(t, int64(2), *decoded.Files[1].Records)
+	assert.Equal(t, "sub/b.fq", decoded.Files[2].Path)
+	assert.Nil(t, decoded.Files[2].Records)
+
+	for name, input := range map[string]string{
+		"unknown field":  `{"tool":"bioflow","files":[],"extra":1}`,
+		"no path":        `{"files":[{"size":1,"sha256":"` + strings.Repeat("0", 64) + `"}]}`,
+		"invalid digest": `{"files":[{"path":"a.fa","size":1,"sha256":"abc"}]}`,
+	} {
+		_, err := Decode(strings.NewReader(input))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+		return path
+	}
+	m := New("bioflow", "1.0.0")
+	for _, name := range []string{"ok.fa", "changed.fa", "truncated.fa", "missing.fa"} {
+		path := write(name, ">s\nACGT\n")
+		size, sum, err := HashFile(path)
+		require.NoError(t, err)
+		m.Add(Entry{Path: path, Size: size, SHA256: sum})
+	}
+	write("changed.fa", ">s\nACGA\n")
+	write("truncated.fa", ">s\n")
+	require.NoError(t, os.Remove(filepath.Join(dir, "missing.fa")))
+
+	var buf bytes.Buffer
+	require.NoError(t, m.Encode(&buf, dir))
+	decoded, err := Decode(&buf)
+	require.NoError(t, err)
+	errs := make(map[string]string)
+	for _, r := range decoded.Verify(dir) {
+		if r.Err != nil {
+			errs[r.Entry.Path] = r.Err.Error()
+		} else {
+			errs[r.Entry.Path] = ""
+		}
+	}
+	assert.Equal(t, "", errs["ok.fa"])
+	assert.Contains(t, errs["changed.fa"], "sha256")
+	assert.Contains(t, errs["truncated.fa"], "size 3, expected 8")
+	assert.Equal(t, "missing", errs["missing.fa"])
+}
+
+func TestDigest(t *testing.T) {
+	d := NewDigest()
+	d.Write([]byte("AC"))
+	d.Write([]byte("GT"))
+	assert.Equal(t, int64(4), d.Size())
+	assert.Equal(t, "1dff3e84fe7877e0673b69bbddcf40124e396e3f9943dd890c91b6a09adb9af0", d.Sum())
+}
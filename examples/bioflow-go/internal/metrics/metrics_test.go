@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	requests := r.Counter("http_requests_total", "HTTP requests served.", "method", "status")
+	inFlight := r.Gauge("http_requests_in_flight", "Requests being served.")
+	latency := r.Histogram("http_request_duration_seconds", "Latency.\nIn seconds.", []float64{1, 0.1}, "route")
+
+	requests.Inc("POST", "200")
+	requests.Add(2, "GET", "404")
+	inFlight.Inc()
+	inFlight.Inc()
+	inFlight.Dec()
+	latency.Observe(0.05, `/a"b`)
+	latency.Observe(0.1, `/a"b`)
+	latency.Observe(3, `/a"b`)
+
+	var b bytes.Buffer
+	require.NoError(t, r.WriteText(&b))
+	assert.Equal(t, `# HELP http_request_duration_seconds Latency.\nIn seconds.
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{route="/a\"b",le="0.1"} 2
+http_request_duration_seconds_bucket{route="/a\"b",le="1"} 2
+http_request_duration_seconds_bucket{route="/a\"b",le="+Inf"} 3
+http_request_duration_seconds_sum{route="/a\"b"} 3.15
+http_request_duration_seconds_count{route="/a\"b"} 3
+# HELP http_requests_in_flight Requests being served.
+# TYPE http_requests_in_flight gauge
+http_requests_in_flight 1
+# HELP http_requests_total HTTP requests served.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",status="404"} 2
+http_requests_total{method="POST",status="200"} 1
+`, b.String())
+
+	assert.Equal(t, 2.0, requests.Value("GET", "404"))
+	assert.Equal(t, 0.0, requests.Value("GET", "500"))
+	assert.Equal(t, uint64(3), latency.Count(`/a"b`))
+	assert.NotContains(t, b.String(), "500", "reading a value creates no series")
+}
+
+func TestRegisterErrors(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("jobs_total", "", "queue")
+	assert.Panics(t, func() { r.Gauge("jobs_total", "") }, "names are unique")
+	assert.Panics(t, func() { r.Counter("2xx", "") })
+	assert.Panics(t, func() { r.Histogram("latency", "", nil, "le") })
+	assert.Panics(t, func() { c.Inc() }, "label values must match")
+	assert.Panics(t, func() { c.Add(-1, "a") }, "counters only go up")
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("events_total", "", "kind")
+	h := r.Histogram("sizes", "", nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.Inc("a")
+				h.Observe(0.2)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 8000.0, c.Value("a"))
+	assert.Equal(t, uint64(8000), h.Count())
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("up_total", "").Inc()
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	assert.Equal(t, "# TYPE up_total counter\nup_total 1\n", rec.Body.String())
+}
@@ -0,0 +1,136 @@
+package motif
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/msa"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sitesMSA(t *testing.T) *msa.MSA {
+	m, err := msa.New(
+		[]string{"s1", "s2", "s3", "s4"},
+		[]string{"TATAAT", "TATAAT", "TACAAT", "TATGAT"},
+	)
+	require.NoError(t, err)
+	return m
+}
+
+func TestFromMSA(t *testing.T) {
+	p, err := FromMSA(sitesMSA(t), Options{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 6, p.Len())
+	assert.Equal(t, 4, p.Sites)
+	assert.Equal(t, "TATAAT", p.Consensus())
+
+	// Column 1 is all T: (4 + 0.25) / 5 = 0.85 against 0.25 background.
+	assert.InDelta(t, math.Log2(0.85/0.25), p.Scores[0][3], 1e-9)
+	assert.InDelta(t, math.Log2(0.05/0.25), p.Scores[0][0], 1e-9)
+
+	score, err := p.Score("TATAAT")
+	require.NoError(t, err)
+	assert.InDelta(t, p.MaxScore(), score, 1e-9)
+
+	_, err = p.Score("TATAA")
+	assert.Error(t, err)
+	_, err = p.Score("TATNAT")
+	assert.Error(t, err)
+}
+
+func TestFromMSAGapColumns(t *testing.T) {
+	m, err := msa.New(
+		[]string{"s1", "s2", "s3"},
+		[]string{"AC-GT", "AC-GT", "ACTGT"},
+	)
+	require.NoError(t, err)
+
+	p, err := FromMSA(m, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 4, p.Len())
+
+	p, err = FromMSA(m, Options{KeepGapColumns: true})
+	require.NoError(t, err)
+	assert.Equal(t, 5, p.Len())
+}
+
+func TestBackgroundCorrection(t *testing.T) {
+	m, err := msa.New([]string{"a", "b"}, []string{"AAAT", "AAAT"})
+	require.NoError(t, err)
+
+	uniform, err := FromMSA(m, Options{})
+	require.NoError(t, err)
+	skewed, err := FromMSA(m, Options{BackgroundFromAlignment: true})
+	require.NoError(t, err)
+
+	assert.InDelta(t, 0.75, skewed.Background[0], 1e-9)
+	// An A-rich background makes A matches less surprising.
+	assert.Less(t, skewed.Scores[0][0], uniform.Scores[0][0])
+}
+
+func TestFromCounts(t *testing.T) {
+	_, err := FromCounts(nil, 1, UniformBackground)
+	assert.Error(t, err)
+	_, err = FromCounts([][4]int{{1, 0, 0, 0}}, -1, UniformBackground)
+	assert.Error(t, err)
+	_, err = FromCounts([][4]int{{1, 0, 0, 0}}, 0, [4]float64{1, 0, 0, 0})
+	assert.Error(t, err)
+
+	p, err := FromCounts([][4]int{{2, 0, 0, 0}}, 0, [4]float64{})
+	require.NoError(t, err)
+	assert.True(t, math.IsInf(p.Scores[0][1], -1))
+	assert.InDelta(t, 2.0, p.Scores[0][0], 1e-9)
+}
+
+func TestScan(t *testing.T) {
+	p, err := FromMSA(sitesMSA(t), Options{})
+	require.NoError(t, err)
+
+	// TATAAT at 3 (forward) and ATTATA (reverse complement) at 14.
+	seq, err := sequence.New("GGGTATAATCCCCCATTATAGGNN")
+	require.NoError(t, err)
+	seq.ID = "promoter"
+
+	threshold := p.RelativeThreshold(0.9)
+	hits := p.Scan(seq, threshold, sequence.Forward)
+	require.Len(t, hits, 1)
+	assert.Equal(t, 3, hits[0].Start)
+	assert.Equal(t, 9, hits[0].End)
+	assert.Equal(t, sequence.Forward, hits[0].Strand)
+	assert.Equal(t, "TATAAT", hits[0].Match)
+	assert.Equal(t, "promoter", hits[0].SequenceID)
+
+	hits = p.Scan(seq, threshold, sequence.BothStrands)
+	require.Len(t, hits, 2)
+	assert.Equal(t, sequence.Reverse, hits[1].Strand)
+	assert.Equal(t, 14, hits[1].Start)
+	assert.Equal(t, "ATTATA", hits[1].Match)
+	for _, h := range hits {
+		assert.GreaterOrEqual(t, h.Score, threshold)
+	}
+
+	reverse := p.Scan(seq, threshold, sequence.Reverse)
+	require.Len(t, reverse, 1)
+	assert.Equal(t, hits[1], reverse[0])
+
+	encoded, err := json.Marshal(reverse[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"strand":"-"`)
+
+	short, err := sequence.New("TATA")
+	require.NoError(t, err)
+	assert.Empty(t, p.Scan(short, 0, sequence.BothStrands))
+}
+
+func TestReverseComplement(t *testing.T) {
+	p, err := FromMSA(sitesMSA(t), Options{})
+	require.NoError(t, err)
+
+	rc := p.ReverseComplement()
+	assert.Equal(t, "ATTATA", rc.Consensus())
+	assert.InDelta(t, p.MaxScore(), rc.MaxScore(), 1e-9)
+}
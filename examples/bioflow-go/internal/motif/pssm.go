@@ -0,0 +1,324 @@
+// Package motif provides sequence motif models and motif search.
+//
+// The central type is the position-specific scoring matrix (PSSM), a
+// log-odds profile built from aligned sites that scores each position of
+// a candidate window against a background model.
+//
+// Comparison with Aria:
+//
+//	Aria checks profile shape at compile time:
+//	  struct PSSM
+//	    invariant self.scores.all(|col| col.len() == ALPHABET.len())
+//
+//	Go relies on construction through FromMSA/FromCounts.
+package motif
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/msa"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Alphabet is the nucleotide alphabet used by PSSMs, in column order.
+const Alphabet = "ACGT"
+
+// DefaultPseudocount is the total pseudocount added to each column,
+// distributed according to the background frequencies.
+const DefaultPseudocount = 1.0
+
+// UniformBackground is the equiprobable nucleotide background.
+var UniformBackground = [4]float64{0.25, 0.25, 0.25, 0.25}
+
+// PSSM is a position-specific scoring matrix in bits (log2 odds).
+//
+// Aria equivalent:
+//
+//	struct PSSM
+//	  scores: [[Float; 4]]
+//	  background: [Float; 4]
+//	  invariant self.scores.len() > 0
+//	  invariant self.background.sum() == 1.0
+type PSSM struct {
+	Scores     [][4]float64
+	Background [4]float64
+	Sites      int
+}
+
+// Options configures PSSM construction.
+type Options struct {
+	// Pseudocount is the total pseudocount per column (default DefaultPseudocount).
+	Pseudocount float64
+	// Background frequencies for A, C, G, T. Zero value means uniform.
+	Background [4]float64
+	// BackgroundFromAlignment derives the background from the alignment's
+	// overall composition instead of Background.
+	BackgroundFromAlignment bool
+	// KeepGapColumns keeps columns in which most rows are gaps (dropped by default).
+	KeepGapColumns bool
+}
+
+// baseIndex returns the alphabet index of a base, or -1.
+func baseIndex(c byte) int {
+	switch c {
+	case 'A', 'a':
+		return 0
+	case 'C', 'c':
+		return 1
+	case 'G', 'g':
+		return 2
+	case 'T', 't', 'U', 'u':
+		return 3
+	default:
+		return -1
+	}
+}
+
+// FromCounts builds a PSSM from per-column base counts.
+//
+// Aria equivalent:
+//
+//	fn from_counts(counts: [[Int; 4]], pseudocount: Float, background: [Float; 4]) -> Result<PSSM, MotifError>
+//	  requires counts.len() > 0
+//	  requires pseudocount >= 0.0
+func FromCounts(counts [][4]int, pseudocount float64, background [4]float64) (*PSSM, error) {
+	if len(counts) == 0 {
+		return nil, fmt.Errorf("count matrix cannot be empty")
+	}
+	if pseudocount < 0 {
+		return nil, fmt.Errorf("pseudocount must be non-negative")
+	}
+
+	bgSum := 0.0
+	for _, b := range background {
+		if b < 0 {
+			return nil, fmt.Errorf("background frequencies must be non-negative")
+		}
+		bgSum += b
+	}
+	if bgSum == 0 {
+		background = UniformBackground
+		bgSum = 1
+	}
+	for i := range background {
+		background[i] /= bgSum
+		if background[i] == 0 && pseudocount == 0 {
+			return nil, fmt.Errorf("zero background frequency requires a positive pseudocount")
+		}
+	}
+
+	p := &PSSM{
+		Scores:     make([][4]float64, len(counts)),
+		Background: background,
+	}
+
+	for i, col := range counts {
+		total := 0
+		for _, c := range col {
+			total += c
+		}
+		if total > p.Sites {
+			p.Sites = total
+		}
+		denom := float64(total) + pseudocount
+		for b := 0; b < 4; b++ {
+			freq := (float64(col[b]) + pseudocount*background[b]) / denom
+			switch {
+			case freq == 0:
+				p.Scores[i][b] = math.Inf(-1)
+			case background[b] == 0:
+				p.Scores[i][b] = math.Inf(1)
+			default:
+				p.Scores[i][b] = math.Log2(freq / background[b])
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// FromMSA builds a PSSM from the columns of a nucleotide alignment.
+//
+// Aria equivalent:
+//
+//	fn from_msa(msa: MSA, options: Options) -> Result<PSSM, MotifError>
+//	  requires msa.width() > 0
+func FromMSA(m *msa.MSA, opts Options) (*PSSM, error) {
+	if m.Width() == 0 {
+		return nil, fmt.Errorf("alignment is empty")
+	}
+
+	pseudocount := opts.Pseudocount
+	if pseudocount == 0 {
+		pseudocount = DefaultPseudocount
+	}
+
+	background := opts.Background
+	if opts.BackgroundFromAlignment {
+		var comp [4]int
+		total := 0
+		for _, row := range m.Rows {
+			for i := 0; i < len(row); i++ {
+				if idx := baseIndex(row[i]); idx >= 0 {
+					comp[idx]++
+					total++
+				}
+			}
+		}
+		if total > 0 {
+			for i := range comp {
+				background[i] = float64(comp[i]) / float64(total)
+			}
+		}
+	}
+
+	counts := make([][4]int, 0, m.Width())
+	for c := 0; c < m.Width(); c++ {
+		var col [4]int
+		gaps := 0
+		for _, row := range m.Rows {
+			if idx := baseIndex(row[c]); idx >= 0 {
+				col[idx]++
+			} else if row[c] == msa.Gap {
+				gaps++
+			}
+		}
+		if !opts.KeepGapColumns && gaps*2 > m.Len() {
+			continue
+		}
+		counts = append(counts, col)
+	}
+
+	return FromCounts(counts, pseudocount, background)
+}
+
+// Len returns the motif length.
+func (p *PSSM) Len() int {
+	return len(p.Scores)
+}
+
+// Score scores a window of exactly Len() bases. Any non-ACGT base makes the
+// window unscorable.
+func (p *PSSM) Score(window string) (float64, error) {
+	if len(window) != p.Len() {
+		return 0, fmt.Errorf("window length %d doesn't match motif length %d", len(window), p.Len())
+	}
+	total := 0.0
+	for i := 0; i < len(window); i++ {
+		idx := baseIndex(window[i])
+		if idx < 0 {
+			return 0, fmt.Errorf("invalid base '%c' at position %d", window[i], i)
+		}
+		total += p.Scores[i][idx]
+	}
+	return total, nil
+}
+
+// MaxScore returns the highest achievable score.
+func (p *PSSM) MaxScore() float64 {
+	total := 0.0
+	for _, col := range p.Scores {
+		total += math.Max(math.Max(col[0], col[1]), math.Max(col[2], col[3]))
+	}
+	return total
+}
+
+// MinScore returns the lowest achievable score.
+func (p *PSSM) MinScore() float64 {
+	total := 0.0
+	for _, col := range p.Scores {
+		total += math.Min(math.Min(col[0], col[1]), math.Min(col[2], col[3]))
+	}
+	return total
+}
+
+// RelativeThreshold converts a fraction of the score range (0 = MinScore,
+// 1 = MaxScore) into an absolute bit-score threshold.
+func (p *PSSM) RelativeThreshold(fraction float64) float64 {
+	min, max := p.MinScore(), p.MaxScore()
+	return min + fraction*(max-min)
+}
+
+// ReverseComplement returns the PSSM for the opposite strand.
+func (p *PSSM) ReverseComplement() *PSSM {
+	rc := &PSSM{
+		Scores:     make([][4]float64, len(p.Scores)),
+		Background: [4]float64{p.Background[3], p.Background[2], p.Background[1], p.Background[0]},
+		Sites:      p.Sites,
+	}
+	n := len(p.Scores)
+	for i, col := range p.Scores {
+		rc.Scores[n-1-i] = [4]float64{col[3], col[2], col[1], col[0]}
+	}
+	return rc
+}
+
+// Consensus returns the highest-scoring base at each position.
+func (p *PSSM) Consensus() string {
+	var sb strings.Builder
+	for _, col := range p.Scores {
+		best := 0
+		for b := 1; b < 4; b++ {
+			if col[b] > col[best] {
+				best = b
+			}
+		}
+		sb.WriteByte(Alphabet[best])
+	}
+	return sb.String()
+}
+
+// Hit is a motif match in a sequence.
+type Hit struct {
+	SequenceID string          `json:"sequence_id,omitempty"`
+	Start      int             `json:"start"` // 0-based, inclusive
+	End        int             `json:"end"`   // 0-based, exclusive
+	Strand     sequence.Strand `json:"strand"`
+	Score      float64         `json:"score"`
+	Match      string          `json:"match"`
+}
+
+// Scan searches a sequence for windows scoring at or above threshold bits
+// on the strands selected. Windows containing non-ACGT bases are skipped.
+// Hits on the reverse strand, scored with the reverse-complement matrix,
+// carry Strand '-'; Match is always the forward-strand window.
+//
+// Aria equivalent:
+//
+//	fn scan(self, seq: Sequence, threshold: Float, strand: Strand) -> [Hit]
+//	  ensures result.all(|h| h.score >= threshold)
+//	  ensures result.all(|h| strand.includes(h.strand))
+func (p *PSSM) Scan(seq *sequence.Sequence, threshold float64, strand sequence.Strand) []Hit {
+	hits := make([]Hit, 0)
+	n := p.Len()
+	if seq.Len() < n {
+		return hits
+	}
+
+	var rc *PSSM
+	if strand.Includes(sequence.Reverse) {
+		rc = p.ReverseComplement()
+	}
+	forward := strand.Includes(sequence.Forward)
+
+	for i := 0; i <= seq.Len()-n; i++ {
+		window := seq.Bases[i : i+n]
+		if forward {
+			if score, err := p.Score(window); err == nil && score >= threshold {
+				hits = append(hits, Hit{SequenceID: seq.ID, Start: i, End: i + n, Strand: sequence.Forward, Score: score, Match: window})
+			}
+		}
+		if rc != nil {
+			if score, err := rc.Score(window); err == nil && score >= threshold {
+				hits = append(hits, Hit{SequenceID: seq.ID, Start: i, End: i + n, Strand: sequence.Reverse, Score: score, Match: window})
+			}
+		}
+	}
+	return hits
+}
+
+func (p *PSSM) String() string {
+	return fmt.Sprintf("PSSM { length: %d, sites: %d, max_score: %.2f }", p.Len(), p.Sites, p.MaxScore())
+}
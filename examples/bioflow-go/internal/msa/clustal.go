@@ -0,0 +1,86 @@
+package msa
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// clustalBlockWidth is the number of columns per Clustal block.
+const clustalBlockWidth = 60
+
+// ReadClustal parses a Clustal W/X (.aln) alignment.
+//
+// The header line must start with "CLUSTAL" (MUSCLE and T-Coffee headers
+// are accepted too). Conservation lines and trailing residue counts are
+// ignored.
+func ReadClustal(r io.Reader) (*MSA, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	lineNum := 0
+	headerSeen := false
+	builder := newRowBuilder()
+
+	for scanner.Scan() {
+		raw := scanner.Text()
+		lineNum++
+
+		if !headerSeen {
+			if strings.TrimSpace(raw) == "" {
+				continue
+			}
+			upper := strings.ToUpper(raw)
+			if !strings.HasPrefix(upper, "CLUSTAL") && !strings.HasPrefix(upper, "MUSCLE") &&
+				!strings.HasPrefix(upper, "T-COFFEE") {
+				return nil, fmt.Errorf("line %d: expected CLUSTAL header", lineNum)
+			}
+			headerSeen = true
+			continue
+		}
+
+		// Blank lines separate blocks; conservation lines start with whitespace.
+		if strings.TrimSpace(raw) == "" || raw[0] == ' ' || raw[0] == '\t' {
+			continue
+		}
+
+		fields := strings.Fields(raw)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected name and sequence", lineNum)
+		}
+		builder.append(fields[0], fields[1])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading alignment: %w", err)
+	}
+	if !headerSeen {
+		return nil, fmt.Errorf("empty Clustal file")
+	}
+
+	return builder.build()
+}
+
+// WriteClustal writes an alignment in Clustal W format with 60-column blocks
+// and a conservation line under each block.
+func WriteClustal(w io.Writer, m *MSA) error {
+	bw := bufio.NewWriter(w)
+	nameWidth := m.nameWidth() + 6
+
+	fmt.Fprint(bw, "CLUSTAL W multiple sequence alignment\n\n\n")
+
+	for start := 0; start < m.Width(); start += clustalBlockWidth {
+		end := start + clustalBlockWidth
+		if end > m.Width() {
+			end = m.Width()
+		}
+
+		for i, name := range m.Names {
+			fmt.Fprintf(bw, "%-*s%s\n", nameWidth, name, m.Rows[i][start:end])
+		}
+		fmt.Fprintf(bw, "%-*s%s\n\n", nameWidth, "", m.conservationLine(start, end))
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,184 @@
+package msa
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// DNAAlphabet is the residue alphabet used for nucleotide profiles.
+const DNAAlphabet = "ACGT"
+
+// DefaultConsensusFraction is the minimum base frequency for a base to be
+// included in an ambiguity-code consensus.
+const DefaultConsensusFraction = 0.25
+
+// ColumnProfile summarizes one alignment column.
+//
+// Aria equivalent:
+//
+//	struct ColumnProfile
+//	  invariant self.frequencies.sum() == 1.0 or self.residues == 0
+//	  invariant self.information_content >= 0.0
+type ColumnProfile struct {
+	Position           int       `json:"position"`
+	Counts             []int     `json:"counts"`
+	Frequencies        []float64 `json:"frequencies"`
+	Heights            []float64 `json:"heights"`
+	Gaps               int       `json:"gaps"`
+	Residues           int       `json:"residues"`
+	InformationContent float64   `json:"information_content"`
+	Consensus          string    `json:"consensus"`
+}
+
+// Logo is a per-column frequency/information matrix suitable for rendering
+// a sequence logo (letter height = frequency * information content).
+type Logo struct {
+	Alphabet  string          `json:"alphabet"`
+	MaxBits   float64         `json:"max_bits"`
+	Consensus string          `json:"consensus"`
+	Columns   []ColumnProfile `json:"columns"`
+}
+
+// Profile computes per-column base counts, frequencies, information content
+// and IUPAC consensus over the DNA alphabet. Characters outside the alphabet
+// (including N) count towards neither residues nor gaps.
+//
+// Aria equivalent:
+//
+//	fn profile(msa: MSA) -> Logo
+//	  ensures result.columns.len() == msa.width()
+func Profile(m *MSA) *Logo {
+	maxBits := math.Log2(float64(len(DNAAlphabet)))
+	logo := &Logo{
+		Alphabet: DNAAlphabet,
+		MaxBits:  maxBits,
+		Columns:  make([]ColumnProfile, m.Width()),
+	}
+
+	var consensus strings.Builder
+	for c := 0; c < m.Width(); c++ {
+		col := ColumnProfile{
+			Position:    c + 1,
+			Counts:      make([]int, len(DNAAlphabet)),
+			Frequencies: make([]float64, len(DNAAlphabet)),
+			Heights:     make([]float64, len(DNAAlphabet)),
+		}
+
+		for _, row := range m.Rows {
+			ch := row[c]
+			if ch == Gap {
+				col.Gaps++
+				continue
+			}
+			if idx := strings.IndexByte(DNAAlphabet, normalizeBase(ch)); idx >= 0 {
+				col.Counts[idx]++
+				col.Residues++
+			}
+		}
+
+		entropy := 0.0
+		if col.Residues > 0 {
+			for i, count := range col.Counts {
+				f := float64(count) / float64(col.Residues)
+				col.Frequencies[i] = f
+				if f > 0 {
+					entropy -= f * math.Log2(f)
+				}
+			}
+			col.InformationContent = maxBits - entropy
+			for i, f := range col.Frequencies {
+				col.Heights[i] = f * col.InformationContent
+			}
+		}
+
+		col.Consensus = string(consensusBase(col, m.Len(), DefaultConsensusFraction))
+		consensus.WriteString(col.Consensus)
+		logo.Columns[c] = col
+	}
+
+	logo.Consensus = consensus.String()
+	return logo
+}
+
+// normalizeBase maps U to T so RNA alignments share the DNA alphabet.
+func normalizeBase(c byte) byte {
+	if c == 'U' {
+		return 'T'
+	}
+	return c
+}
+
+// consensusBase returns the IUPAC code for all bases whose frequency is at
+// least minFraction, or a gap if most sequences have a gap in the column.
+func consensusBase(col ColumnProfile, rows int, minFraction float64) byte {
+	if col.Residues == 0 || col.Gaps*2 > rows {
+		return Gap
+	}
+
+	type baseFreq struct {
+		base byte
+		freq float64
+	}
+	freqs := make([]baseFreq, 0, len(DNAAlphabet))
+	for i, f := range col.Frequencies {
+		freqs = append(freqs, baseFreq{DNAAlphabet[i], f})
+	}
+	sort.SliceStable(freqs, func(i, j int) bool { return freqs[i].freq > freqs[j].freq })
+
+	// The most frequent base is always included.
+	bases := []byte{freqs[0].base}
+	for _, bf := range freqs[1:] {
+		if bf.freq >= minFraction {
+			bases = append(bases, bf.base)
+		}
+	}
+	return sequence.IUPACCode(string(bases))
+}
+
+// Consensus returns the IUPAC consensus sequence (gaps removed) using the
+// given minimum base fraction.
+func Consensus(m *MSA, minFraction float64) string {
+	logo := Profile(m)
+	var sb strings.Builder
+	for _, col := range logo.Columns {
+		sb.WriteByte(consensusBase(col, m.Len(), minFraction))
+	}
+	return sequence.Degap(sb.String())
+}
+
+// ConservationTrack renders one character per column: '*' for columns that
+// are fully conserved, ':' for information content >= 1.5 bits, '.' for
+// >= 0.5 bits and ' ' otherwise. Columns that are mostly gaps are blank.
+func (l *Logo) ConservationTrack() string {
+	var sb strings.Builder
+	for _, col := range l.Columns {
+		switch {
+		case col.Consensus == string(Gap):
+			sb.WriteByte(' ')
+		case col.Gaps == 0 && col.InformationContent >= l.MaxBits-1e-9:
+			sb.WriteByte('*')
+		case col.InformationContent >= 1.5:
+			sb.WriteByte(':')
+		case col.InformationContent >= 0.5:
+			sb.WriteByte('.')
+		default:
+			sb.WriteByte(' ')
+		}
+	}
+	return sb.String()
+}
+
+// MeanInformation returns the mean information content over all columns.
+func (l *Logo) MeanInformation() float64 {
+	if len(l.Columns) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, col := range l.Columns {
+		total += col.InformationContent
+	}
+	return total / float64(len(l.Columns))
+}
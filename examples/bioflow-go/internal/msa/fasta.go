@@ -0,0 +1,63 @@
+package msa
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ReadFASTA parses an aligned (gapped) FASTA file. Only the first word of
+// each header is used as the row name.
+func ReadFASTA(r io.Reader) (*MSA, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	builder := newRowBuilder()
+	name := ""
+	lineNum := 0
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		lineNum++
+		if line == "" {
+			continue
+		}
+		if line[0] == '>' {
+			fields := strings.Fields(line[1:])
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("line %d: empty FASTA header", lineNum)
+			}
+			name = fields[0]
+			builder.append(name, "")
+			continue
+		}
+		if name == "" {
+			return nil, fmt.Errorf("line %d: sequence data before first header", lineNum)
+		}
+		builder.append(name, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading alignment: %w", err)
+	}
+
+	return builder.build()
+}
+
+// WriteFASTA writes an alignment as gapped FASTA with 60-column lines.
+func WriteFASTA(w io.Writer, m *MSA) error {
+	bw := bufio.NewWriter(w)
+	for i, name := range m.Names {
+		fmt.Fprintf(bw, ">%s\n", name)
+		row := m.Rows[i]
+		for start := 0; start < len(row); start += clustalBlockWidth {
+			end := start + clustalBlockWidth
+			if end > len(row) {
+				end = len(row)
+			}
+			fmt.Fprintln(bw, row[start:end])
+		}
+	}
+	return bw.Flush()
+}
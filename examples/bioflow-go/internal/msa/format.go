@@ -0,0 +1,130 @@
+package msa
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Format identifies an MSA file format.
+type Format int
+
+const (
+	// Clustal represents the Clustal W/X .aln format
+	Clustal Format = iota
+	// Stockholm represents the Stockholm 1.0 format used by Pfam/Rfam
+	Stockholm
+	// PHYLIP represents the relaxed interleaved/sequential PHYLIP format
+	PHYLIP
+	// AlignedFASTA represents FASTA with gapped sequences
+	AlignedFASTA
+)
+
+func (f Format) String() string {
+	switch f {
+	case Clustal:
+		return "clustal"
+	case Stockholm:
+		return "stockholm"
+	case PHYLIP:
+		return "phylip"
+	case AlignedFASTA:
+		return "fasta"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseFormat parses a format name such as "clustal" or "phylip".
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "clustal", "aln":
+		return Clustal, nil
+	case "stockholm", "sto", "sth":
+		return Stockholm, nil
+	case "phylip", "phy":
+		return PHYLIP, nil
+	case "fasta", "fa", "afa":
+		return AlignedFASTA, nil
+	default:
+		return 0, fmt.Errorf("unknown MSA format %q", name)
+	}
+}
+
+// FormatFromPath guesses the format from a file extension.
+func FormatFromPath(path string) (Format, error) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	return ParseFormat(ext)
+}
+
+// Read parses an alignment in the given format.
+func Read(r io.Reader, format Format) (*MSA, error) {
+	switch format {
+	case Clustal:
+		return ReadClustal(r)
+	case Stockholm:
+		return ReadStockholm(r)
+	case PHYLIP:
+		return ReadPHYLIP(r)
+	case AlignedFASTA:
+		return ReadFASTA(r)
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// Write serializes an alignment in the given format.
+func Write(w io.Writer, m *MSA, format Format) error {
+	switch format {
+	case Clustal:
+		return WriteClustal(w, m)
+	case Stockholm:
+		return WriteStockholm(w, m)
+	case PHYLIP:
+		return WritePHYLIP(w, m, false)
+	case AlignedFASTA:
+		return WriteFASTA(w, m)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// rowBuilder accumulates interleaved rows while preserving first-seen order.
+type rowBuilder struct {
+	names []string
+	rows  map[string]*strings.Builder
+}
+
+func newRowBuilder() *rowBuilder {
+	return &rowBuilder{rows: make(map[string]*strings.Builder)}
+}
+
+func (b *rowBuilder) append(name, chunk string) {
+	sb, ok := b.rows[name]
+	if !ok {
+		sb = &strings.Builder{}
+		b.rows[name] = sb
+		b.names = append(b.names, name)
+	}
+	sb.WriteString(chunk)
+}
+
+func (b *rowBuilder) build() (*MSA, error) {
+	rows := make([]string, len(b.names))
+	for i, name := range b.names {
+		rows[i] = b.rows[name].String()
+	}
+	return New(b.names, rows)
+}
+
+// nameWidth returns the column width needed to align row names.
+func (m *MSA) nameWidth() int {
+	width := 0
+	for _, n := range m.Names {
+		if len(n) > width {
+			width = len(n)
+		}
+	}
+	return width
+}
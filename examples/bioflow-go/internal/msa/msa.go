@@ -0,0 +1,185 @@
+// Package msa provides multiple sequence alignment types and file formats.
+//
+// An MSA is a set of equal-length gapped rows, one per sequence. Gaps are
+// written as '-' (or '.' in some formats, which is normalized on read).
+//
+// Comparison with Aria:
+//
+//	Aria expresses the row-length invariant directly:
+//	  struct MSA
+//	    invariant self.rows.all(|r| r.len() == self.width())
+//
+//	Go validates it at construction time.
+package msa
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Gap is the gap character used in aligned rows.
+const Gap = sequence.GapChar
+
+// MSA represents a multiple sequence alignment.
+//
+// Aria equivalent:
+//
+//	struct MSA
+//	  names: [String]
+//	  rows: [String]
+//	  invariant self.names.len() == self.rows.len()
+//	  invariant self.rows.all(|r| r.len() == self.rows[0].len())
+type MSA struct {
+	Names []string
+	Rows  []string
+	// Annotations holds per-file annotations (e.g. Stockholm #=GF lines).
+	Annotations map[string]string
+}
+
+// New creates a new alignment, validating that rows have equal length.
+//
+// Aria equivalent:
+//
+//	fn new(names: [String], rows: [String]) -> Result<MSA, MSAError>
+//	  requires names.len() == rows.len()
+//	  requires rows.len() > 0
+func New(names, rows []string) (*MSA, error) {
+	if len(names) != len(rows) {
+		return nil, fmt.Errorf("names and rows must have the same length")
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("alignment must have at least one row")
+	}
+
+	width := len(rows[0])
+	normalized := make([]string, len(rows))
+	for i, row := range rows {
+		if len(row) != width {
+			return nil, fmt.Errorf("row %q has length %d, expected %d", names[i], len(row), width)
+		}
+		normalized[i] = strings.ToUpper(strings.ReplaceAll(row, ".", string(Gap)))
+	}
+
+	n := make([]string, len(names))
+	copy(n, names)
+
+	return &MSA{
+		Names:       n,
+		Rows:        normalized,
+		Annotations: make(map[string]string),
+	}, nil
+}
+
+// Len returns the number of sequences in the alignment.
+func (m *MSA) Len() int {
+	return len(m.Rows)
+}
+
+// Width returns the number of alignment columns.
+func (m *MSA) Width() int {
+	if len(m.Rows) == 0 {
+		return 0
+	}
+	return len(m.Rows[0])
+}
+
+// Column returns the characters of column i, one per row.
+func (m *MSA) Column(i int) (string, error) {
+	if i < 0 || i >= m.Width() {
+		return "", fmt.Errorf("column %d out of range [0, %d)", i, m.Width())
+	}
+	col := make([]byte, len(m.Rows))
+	for r, row := range m.Rows {
+		col[r] = row[i]
+	}
+	return string(col), nil
+}
+
+// Row returns the aligned row for a sequence name.
+func (m *MSA) Row(name string) (string, bool) {
+	for i, n := range m.Names {
+		if n == name {
+			return m.Rows[i], true
+		}
+	}
+	return "", false
+}
+
+// Slice returns the sub-alignment of columns [start, end).
+//
+// Aria equivalent:
+//
+//	fn slice(self, start: Int, end: Int) -> Result<MSA, MSAError>
+//	  requires 0 <= start and start < end and end <= self.width()
+//	  ensures result.width() == end - start
+func (m *MSA) Slice(start, end int) (*MSA, error) {
+	if start < 0 || end > m.Width() || start >= end {
+		return nil, fmt.Errorf("invalid column range [%d, %d) for width %d", start, end, m.Width())
+	}
+	rows := make([]string, len(m.Rows))
+	for i, row := range m.Rows {
+		rows[i] = row[start:end]
+	}
+	return New(m.Names, rows)
+}
+
+// RemoveGapColumns returns the alignment without columns that are gaps in
+// every row.
+func (m *MSA) RemoveGapColumns() (*MSA, error) {
+	keep := make([]int, 0, m.Width())
+	for c := 0; c < m.Width(); c++ {
+		for _, row := range m.Rows {
+			if !sequence.IsGap(row[c]) {
+				keep = append(keep, c)
+				break
+			}
+		}
+	}
+	rows := make([]string, len(m.Rows))
+	for i, row := range m.Rows {
+		b := make([]byte, len(keep))
+		for j, c := range keep {
+			b[j] = row[c]
+		}
+		rows[i] = string(b)
+	}
+	return New(m.Names, rows)
+}
+
+// Ungapped returns the unaligned sequence of row i.
+func (m *MSA) Ungapped(i int) string {
+	return sequence.Degap(m.Rows[i])
+}
+
+// ColumnMap returns the column-to-position map of row i.
+func (m *MSA) ColumnMap(i int) *sequence.ColumnMap {
+	return sequence.NewColumnMap(m.Rows[i])
+}
+
+func (m *MSA) String() string {
+	return fmt.Sprintf("MSA { sequences: %d, width: %d }", m.Len(), m.Width())
+}
+
+// conservationLine returns a Clustal-style conservation line: '*' for fully
+// conserved columns (without gaps) and ' ' otherwise.
+func (m *MSA) conservationLine(start, end int) string {
+	var sb strings.Builder
+	for c := start; c < end; c++ {
+		first := m.Rows[0][c]
+		conserved := first != Gap
+		for _, row := range m.Rows[1:] {
+			if row[c] != first {
+				conserved = false
+				break
+			}
+		}
+		if conserved {
+			sb.WriteByte('*')
+		} else {
+			sb.WriteByte(' ')
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,190 @@
+package msa
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleMSA(t *testing.T) *MSA {
+	m, err := New(
+		[]string{"seq1", "seq2", "seq3"},
+		[]string{"ATGC-TGCA", "ATGCATGCA", "ATG--TGCA"},
+	)
+	require.NoError(t, err)
+	return m
+}
+
+func TestNew(t *testing.T) {
+	m := sampleMSA(t)
+	assert.Equal(t, 3, m.Len())
+	assert.Equal(t, 9, m.Width())
+
+	col, err := m.Column(4)
+	require.NoError(t, err)
+	assert.Equal(t, "-A-", col)
+
+	_, err = New([]string{"a", "b"}, []string{"ACGT", "ACG"})
+	require.Error(t, err)
+
+	_, err = New([]string{"a"}, []string{"ACGT", "ACGT"})
+	require.Error(t, err)
+}
+
+func TestNewNormalizesDots(t *testing.T) {
+	m, err := New([]string{"a"}, []string{"ac..gt"})
+	require.NoError(t, err)
+	assert.Equal(t, "AC--GT", m.Rows[0])
+}
+
+func TestRoundTrip(t *testing.T) {
+	formats := []Format{Clustal, Stockholm, PHYLIP, AlignedFASTA}
+
+	for _, format := range formats {
+		t.Run(format.String(), func(t *testing.T) {
+			m := sampleMSA(t)
+
+			var buf bytes.Buffer
+			require.NoError(t, Write(&buf, m, format))
+
+			parsed, err := Read(&buf, format)
+			require.NoError(t, err)
+			assert.Equal(t, m.Names, parsed.Names)
+			assert.Equal(t, m.Rows, parsed.Rows)
+		})
+	}
+}
+
+func TestClustalMultipleBlocks(t *testing.T) {
+	long := strings.Repeat("ACGT", 40)
+	m, err := New([]string{"a", "b"}, []string{long, long})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteClustal(&buf, m))
+	assert.True(t, strings.HasPrefix(buf.String(), "CLUSTAL"))
+
+	parsed, err := ReadClustal(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, long, parsed.Rows[0])
+}
+
+func TestReadStockholmAnnotations(t *testing.T) {
+	input := `# STOCKHOLM 1.0
+#=GF ID   test_family
+#=GF DE   A test
+#=GF DE   alignment
+seq1 ACG-T
+#=GR seq1 SS ....
+seq2 ACGGT
+//
+`
+	m, err := ReadStockholm(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"seq1", "seq2"}, m.Names)
+	assert.Equal(t, "test_family", m.Annotations["ID"])
+	assert.Equal(t, "A test alignment", m.Annotations["DE"])
+
+	_, err = ReadStockholm(strings.NewReader("# STOCKHOLM 1.0\nseq1 ACGT\n"))
+	require.Error(t, err)
+}
+
+func TestReadPHYLIPInterleaved(t *testing.T) {
+	input := `2 12
+alpha ACGTAC
+beta  ACGTTC
+
+GTACGT
+GTAC-T
+`
+	m, err := ReadPHYLIP(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alpha", "beta"}, m.Names)
+	assert.Equal(t, "ACGTACGTACGT", m.Rows[0])
+	assert.Equal(t, "ACGTTCGTAC-T", m.Rows[1])
+}
+
+func TestReadPHYLIPSequentialWrapped(t *testing.T) {
+	input := `2 8
+alpha ACGT
+ACGT
+beta ACGA
+ACGA
+`
+	m, err := ReadPHYLIP(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, "ACGTACGT", m.Rows[0])
+	assert.Equal(t, "ACGAACGA", m.Rows[1])
+}
+
+func TestWritePHYLIPStrict(t *testing.T) {
+	m, err := New([]string{"a_very_long_name"}, []string{"ACGT"})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePHYLIP(&buf, m, true))
+	assert.Equal(t, "1 4\na_very_lonACGT\n", buf.String())
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("aln")
+	require.NoError(t, err)
+	assert.Equal(t, Clustal, f)
+
+	f, err = FormatFromPath("family.sto")
+	require.NoError(t, err)
+	assert.Equal(t, Stockholm, f)
+
+	_, err = ParseFormat("nexus")
+	require.Error(t, err)
+}
+
+func TestProfile(t *testing.T) {
+	m, err := New(
+		[]string{"a", "b", "c", "d"},
+		[]string{"ACGT-", "ACGA-", "ACTA-", "ACTAA"},
+	)
+	require.NoError(t, err)
+
+	logo := Profile(m)
+	require.Len(t, logo.Columns, 5)
+
+	// Column 1 is fully conserved A: 2 bits.
+	assert.Equal(t, []int{4, 0, 0, 0}, logo.Columns[0].Counts)
+	assert.InDelta(t, 2.0, logo.Columns[0].InformationContent, 1e-9)
+	assert.InDelta(t, 2.0, logo.Columns[0].Heights[0], 1e-9)
+
+	// Column 3 is G/T 50:50: 1 bit, consensus K.
+	assert.InDelta(t, 1.0, logo.Columns[2].InformationContent, 1e-9)
+	assert.Equal(t, "K", logo.Columns[2].Consensus)
+
+	// Column 5 is mostly gaps.
+	assert.Equal(t, "-", logo.Columns[4].Consensus)
+
+	assert.Equal(t, "ACKW-", logo.Consensus)
+	assert.Equal(t, "**.. ", logo.ConservationTrack())
+	assert.Equal(t, "ACKW", Consensus(m, DefaultConsensusFraction))
+}
+
+func TestSliceAndGapColumns(t *testing.T) {
+	m := sampleMSA(t)
+	sub, err := m.Slice(2, 6)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GC-T", "GCAT", "G--T"}, sub.Rows)
+	_, err = m.Slice(5, 5)
+	require.Error(t, err)
+
+	gapped, err := New([]string{"a", "b"}, []string{"A--CG", "A-TC-"})
+	require.NoError(t, err)
+	trimmed, err := gapped.RemoveGapColumns()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A-CG", "ATC-"}, trimmed.Rows)
+
+	assert.Equal(t, "ATGTGCA", m.Ungapped(2))
+	col, err := m.ColumnMap(2).PositionToColumn(3)
+	require.NoError(t, err)
+	assert.Equal(t, 5, col)
+}
@@ -0,0 +1,141 @@
+package msa
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// phylipStrictNameWidth is the fixed name width of strict PHYLIP.
+const phylipStrictNameWidth = 10
+
+// ReadPHYLIP parses a relaxed PHYLIP alignment in sequential or interleaved
+// layout. Names are separated from sequence data by whitespace; spaces
+// inside sequence data are ignored.
+func ReadPHYLIP(r io.Reader) (*MSA, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	lineNum := 0
+	nSeqs, width := -1, -1
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if _, err := fmt.Sscanf(line, "%d %d", &nSeqs, &width); err != nil {
+			return nil, fmt.Errorf("line %d: expected '<sequences> <columns>' header", lineNum)
+		}
+		break
+	}
+	if nSeqs <= 0 || width <= 0 {
+		return nil, fmt.Errorf("invalid PHYLIP header")
+	}
+
+	lines := make([]string, 0, nSeqs)
+	for scanner.Scan() {
+		lineNum++
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading alignment: %w", err)
+	}
+
+	names, rows, ok := parsePHYLIPSequential(lines, nSeqs, width)
+	if !ok {
+		names, rows, ok = parsePHYLIPInterleaved(lines, nSeqs, width)
+	}
+	if !ok {
+		return nil, fmt.Errorf("could not parse %d sequences of %d columns", nSeqs, width)
+	}
+
+	return New(names, rows)
+}
+
+// parsePHYLIPSequential parses records where each sequence is complete
+// (possibly wrapped over several lines) before the next name appears.
+func parsePHYLIPSequential(lines []string, nSeqs, width int) ([]string, []string, bool) {
+	names := make([]string, 0, nSeqs)
+	rows := make([]string, 0, nSeqs)
+	var current strings.Builder
+
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if current.Len() == 0 {
+			if len(names) == nSeqs {
+				return nil, nil, false
+			}
+			names = append(names, fields[0])
+			fields = fields[1:]
+		}
+		current.WriteString(strings.Join(fields, ""))
+
+		if current.Len() > width {
+			return nil, nil, false
+		}
+		if current.Len() == width {
+			rows = append(rows, current.String())
+			current.Reset()
+		}
+	}
+
+	return names, rows, len(rows) == nSeqs && current.Len() == 0
+}
+
+// parsePHYLIPInterleaved parses a first block of named lines followed by
+// unnamed blocks in the same sequence order.
+func parsePHYLIPInterleaved(lines []string, nSeqs, width int) ([]string, []string, bool) {
+	if len(lines) < nSeqs {
+		return nil, nil, false
+	}
+
+	names := make([]string, nSeqs)
+	builders := make([]strings.Builder, nSeqs)
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if i < nSeqs {
+			names[i] = fields[0]
+			fields = fields[1:]
+		}
+		builders[i%nSeqs].WriteString(strings.Join(fields, ""))
+	}
+
+	rows := make([]string, nSeqs)
+	for i := range builders {
+		rows[i] = builders[i].String()
+		if len(rows[i]) != width {
+			return nil, nil, false
+		}
+	}
+	return names, rows, true
+}
+
+// WritePHYLIP writes an alignment in sequential PHYLIP format. In strict
+// mode names are truncated/padded to 10 characters as required by the
+// original PHYLIP programs; otherwise relaxed (whitespace-separated) names
+// are written.
+func WritePHYLIP(w io.Writer, m *MSA, strict bool) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%d %d\n", m.Len(), m.Width())
+
+	for i, name := range m.Names {
+		if strict {
+			if len(name) > phylipStrictNameWidth {
+				name = name[:phylipStrictNameWidth]
+			}
+			fmt.Fprintf(bw, "%-*s%s\n", phylipStrictNameWidth, name, m.Rows[i])
+		} else {
+			if strings.ContainsAny(name, " \t") {
+				return fmt.Errorf("name %q contains whitespace, not allowed in relaxed PHYLIP", name)
+			}
+			fmt.Fprintf(bw, "%s %s\n", name, m.Rows[i])
+		}
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,112 @@
+package msa
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ReadStockholm parses a single Stockholm 1.0 alignment.
+//
+// #=GF feature lines are kept in MSA.Annotations (repeated features are
+// joined with a space); other markup (#=GS, #=GR, #=GC) is skipped.
+func ReadStockholm(r io.Reader) (*MSA, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	lineNum := 0
+	headerSeen := false
+	terminated := false
+	builder := newRowBuilder()
+	annotations := make(map[string]string)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		lineNum++
+
+		if !headerSeen {
+			if line == "" {
+				continue
+			}
+			if !strings.HasPrefix(line, "# STOCKHOLM") {
+				return nil, fmt.Errorf("line %d: expected '# STOCKHOLM 1.0' header", lineNum)
+			}
+			headerSeen = true
+			continue
+		}
+
+		switch {
+		case line == "":
+			continue
+		case line == "//":
+			terminated = true
+		case strings.HasPrefix(line, "#=GF"):
+			fields := strings.SplitN(line, " ", 3)
+			if len(fields) == 3 {
+				key := fields[1]
+				value := strings.TrimSpace(fields[2])
+				if prev, ok := annotations[key]; ok {
+					value = prev + " " + value
+				}
+				annotations[key] = value
+			}
+		case strings.HasPrefix(line, "#"):
+			continue
+		default:
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: expected name and sequence", lineNum)
+			}
+			builder.append(fields[0], fields[1])
+		}
+
+		if terminated {
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading alignment: %w", err)
+	}
+	if !headerSeen {
+		return nil, fmt.Errorf("empty Stockholm file")
+	}
+	if !terminated {
+		return nil, fmt.Errorf("missing '//' terminator")
+	}
+
+	m, err := builder.build()
+	if err != nil {
+		return nil, err
+	}
+	m.Annotations = annotations
+	return m, nil
+}
+
+// WriteStockholm writes an alignment in non-interleaved Stockholm 1.0 format.
+func WriteStockholm(w io.Writer, m *MSA) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# STOCKHOLM 1.0")
+
+	keys := make([]string, 0, len(m.Annotations))
+	for k := range m.Annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(bw, "#=GF %s %s\n", k, m.Annotations[k])
+	}
+	if len(keys) > 0 {
+		fmt.Fprintln(bw)
+	}
+
+	nameWidth := m.nameWidth() + 1
+	for i, name := range m.Names {
+		fmt.Fprintf(bw, "%-*s%s\n", nameWidth, name, m.Rows[i])
+	}
+	fmt.Fprintln(bw, "//")
+
+	return bw.Flush()
+}
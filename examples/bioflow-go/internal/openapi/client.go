@@ -0,0 +1,456 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ClientOptions configures GenerateClient.
+type ClientOptions struct {
+	// Package is the package name of the generated file.
+	Package string
+	// Generator names the command that generated the file, for its
+	// header.
+	Generator string
+}
+
+// GenerateClient generates Go source for a client of a document: a type
+// per component schema and a method on *Client per operation, named after
+// its operation ID. The package must define the Client type with these
+// methods, which the generated ones call:
+//
+//	do(ctx, method, path string, query url.Values, in, out interface{}) error
+//	stream(ctx, method, path string, query url.Values, in interface{}) (io.ReadCloser, error)
+//
+// do sends in as JSON, unless nil, and decodes a JSON reply into out,
+// unless nil; stream returns the body of other replies. Both return
+// replies that are not a success as errors, so the Error schema has no
+// type of its own.
+//
+// Aria equivalent:
+//
+//	fn generate_client(doc: Document, options: ClientOptions) -> Result<String, OpenAPIError>
+//	  requires options.package.is_identifier()
+//	  ensures go_parse(result.unwrap()).is_ok()
+func GenerateClient(d *Document, opts ClientOptions) ([]byte, error) {
+	if !token.IsIdentifier(opts.Package) {
+		return nil, fmt.Errorf("package name %q is not an identifier", opts.Package)
+	}
+	g := &clientGen{doc: d, imports: map[string]bool{}}
+
+	names := make([]string, 0, len(d.Components.Schemas))
+	for name := range d.Components.Schemas {
+		if name == ErrorSchema {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := g.component(name, d.Components.Schemas[name]); err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range d.SortedPaths() {
+		for _, op := range d.Paths[path].Operations() {
+			if err := g.operation(path, op.Method, op.Operation); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", op.Method, path, err)
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	generator := opts.Generator
+	if generator == "" {
+		generator = "openapi"
+	}
+	fmt.Fprintf(&out, "// Code generated by %s; DO NOT EDIT.\n\n", generator)
+	fmt.Fprintf(&out, "package %s\n\n", opts.Package)
+	imports := make([]string, 0, len(g.imports))
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	if len(imports) > 0 {
+		out.WriteString("import (\n")
+		for _, imp := range imports {
+			fmt.Fprintf(&out, "\t%q\n", imp)
+		}
+		out.WriteString(")\n")
+	}
+	out.Write(g.body.Bytes())
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting client: %w", err)
+	}
+	return src, nil
+}
+
+// clientGen accumulates the declarations of a generated client.
+type clientGen struct {
+	doc     *Document
+	imports map[string]bool
+	body    bytes.Buffer
+}
+
+// component declares the struct type of a component schema.
+func (g *clientGen) component(name string, s *Schema) error {
+	if !token.IsIdentifier(name) || !token.IsExported(name) {
+		return fmt.Errorf("schema name %q is not an exported identifier", name)
+	}
+	if s.Type != "object" || s.AdditionalProperties != nil {
+		return fmt.Errorf("schema %s: only objects with properties can be components", name)
+	}
+	fields, err := g.structFields(s.Properties)
+	if err != nil {
+		return fmt.Errorf("schema %s: %w", name, err)
+	}
+	g.body.WriteString("\n")
+	if s.Description != "" {
+		writeComment(&g.body, "", s.Description)
+	} else {
+		fmt.Fprintf(&g.body, "// %s is the %s schema of the API.\n", name, name)
+	}
+	fmt.Fprintf(&g.body, "type %s struct {\n%s}\n", name, fields)
+	return nil
+}
+
+// structFields returns the field declarations of properties, one per
+// line, with JSON tags that leave out zero values.
+func (g *clientGen) structFields(props Properties) (string, error) {
+	var b strings.Builder
+	seen := make(map[string]string)
+	for _, p := range props {
+		field := GoName(p.Name)
+		if field == "" {
+			return "", fmt.Errorf("property %q has no Go name", p.Name)
+		}
+		if other, dup := seen[field]; dup {
+			return "", fmt.Errorf("properties %s and %s are both %s in Go", other, p.Name, field)
+		}
+		seen[field] = p.Name
+		typ, err := g.goType(p.Schema, true)
+		if err != nil {
+			return "", fmt.Errorf("property %s: %w", p.Name, err)
+		}
+		if p.Schema.Description != "" && p.Schema.Ref == "" {
+			writeComment(&b, "\t", p.Schema.Description)
+		}
+		fmt.Fprintf(&b, "\t%s %s `json:\"%s,omitempty\"`\n", field, typ, p.Name)
+	}
+	return b.String(), nil
+}
+
+// goType returns the Go type of a schema. References in fields are
+// pointers, so that an absent object is not sent as an empty one.
+func (g *clientGen) goType(s *Schema, field bool) (string, error) {
+	if s == nil {
+		return "", fmt.Errorf("missing schema")
+	}
+	if s.Ref != "" {
+		name := s.RefName()
+		if g.doc.Components.Schemas[name] == nil {
+			return "", fmt.Errorf("unknown schema %s", s.Ref)
+		}
+		if field {
+			return "*" + name, nil
+		}
+		return name, nil
+	}
+	pointer := ""
+	if s.Nullable {
+		pointer = "*"
+	}
+	switch s.Type {
+	case "boolean":
+		return pointer + "bool", nil
+	case "integer":
+		if s.Format == "int64" {
+			return pointer + "int64", nil
+		}
+		return pointer + "int", nil
+	case "number":
+		if s.Format == "float" {
+			return pointer + "float32", nil
+		}
+		return pointer + "float64", nil
+	case "string":
+		switch s.Format {
+		case "date-time":
+			g.imports["time"] = true
+			return pointer + "time.Time", nil
+		case "byte":
+			return "[]byte", nil
+		}
+		return pointer + "string", nil
+	case "array":
+		elem, err := g.goType(s.Items, false)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + elem, nil
+	case "object":
+		if s.AdditionalProperties != nil {
+			elem, err := g.goType(s.AdditionalProperties, false)
+			if err != nil {
+				return "", err
+			}
+			return "map[string]" + elem, nil
+		}
+		if len(s.Properties) == 0 {
+			return "map[string]interface{}", nil
+		}
+		fields, err := g.structFields(s.Properties)
+		if err != nil {
+			return "", err
+		}
+		return pointer + "struct {\n" + fields + "}", nil
+	case "":
+		return "interface{}", nil
+	}
+	return "", fmt.Errorf("unsupported schema type %q", s.Type)
+}
+
+// operation declares the client method of an operation, and the type of
+// its query parameters if it has any.
+func (g *clientGen) operation(path, method string, op *Operation) error {
+	name := GoName(op.OperationID)
+	if !token.IsIdentifier(name) {
+		return fmt.Errorf("operation ID %q has no Go name", op.OperationID)
+	}
+	g.imports["context"] = true
+	args := []string{"ctx context.Context"}
+
+	// The path, with its parameters as arguments.
+	pathExpr := strconv.Quote(path)
+	for _, p := range op.Parameters {
+		if p.In != "path" {
+			continue
+		}
+		arg := goArgName(p.Name)
+		args = append(args, arg+" string")
+		g.imports["net/url"] = true
+		pathExpr = strings.Replace(pathExpr, "{"+p.Name+"}", `" + url.PathEscape(`+arg+`) + "`, 1)
+	}
+	pathExpr = strings.TrimSuffix(strings.TrimPrefix(pathExpr, `"" + `), ` + ""`)
+
+	// Query parameters, as a struct.
+	query := "nil"
+	var queryCode strings.Builder
+	var queryFields strings.Builder
+	for _, p := range op.Parameters {
+		if p.In != "query" {
+			continue
+		}
+		field := GoName(p.Name)
+		if p.Description != "" {
+			writeComment(&queryFields, "\t", p.Description)
+		}
+		switch {
+		case p.Schema.Type == "array" && p.Schema.Items != nil && p.Schema.Items.Type == "string":
+			fmt.Fprintf(&queryFields, "\t%s []string\n", field)
+			fmt.Fprintf(&queryCode, "for _, v := range params.%s {\nq.Add(%q, v)\n}\n", field, p.Name)
+		case p.Schema.Type == "string":
+			fmt.Fprintf(&queryFields, "\t%s string\n", field)
+			fmt.Fprintf(&queryCode, "if params.%s != \"\" {\nq.Set(%q, params.%s)\n}\n", field, p.Name, field)
+		default:
+			return fmt.Errorf("query parameter %s: only strings and arrays of strings are supported", p.Name)
+		}
+	}
+	if queryFields.Len() > 0 {
+		g.imports["net/url"] = true
+		fmt.Fprintf(&g.body, "\n// %sParams are the query parameters of %s.\ntype %sParams struct {\n%s}\n", name, name, name, queryFields.String())
+		args = append(args, "params "+name+"Params")
+		query = "q"
+	}
+
+	// The request body.
+	in := "nil"
+	if op.RequestBody != nil {
+		mt, ok := op.RequestBody.Content["application/json"]
+		if !ok {
+			return fmt.Errorf("only JSON request bodies are supported")
+		}
+		typ, err := g.goType(mt.Schema, false)
+		if err != nil {
+			return fmt.Errorf("request body: %w", err)
+		}
+		args = append(args, "req "+typ)
+		in = "req"
+	}
+
+	// The reply: decoded JSON, a stream of another media type, or none.
+	status, resp := successResponse(op)
+	if resp == nil {
+		return fmt.Errorf("no success response")
+	}
+	var results, call string
+	callArgs := fmt.Sprintf("ctx, %q, %s, %s, %s", method, pathExpr, query, in)
+	mt, isJSON := resp.Content["application/json"]
+	switch {
+	case isJSON && mt.Schema.Ref != "":
+		typ, _ := g.goType(mt.Schema, false)
+		results = "(*" + typ + ", error)"
+		call = fmt.Sprintf("var resp %s\nif err := c.do(%s, &resp); err != nil {\nreturn nil, err\n}\nreturn &resp, nil\n", typ, callArgs)
+	case isJSON && mt.Schema.Type == "":
+		g.imports["encoding/json"] = true
+		results = "(json.RawMessage, error)"
+		call = fmt.Sprintf("var resp json.RawMessage\nif err := c.do(%s, &resp); err != nil {\nreturn nil, err\n}\nreturn resp, nil\n", callArgs)
+	case isJSON && (mt.Schema.Type == "array" || mt.Schema.Type == "object"):
+		typ, err := g.goType(mt.Schema, false)
+		if err != nil {
+			return fmt.Errorf("response: %w", err)
+		}
+		results = "(" + typ + ", error)"
+		call = fmt.Sprintf("var resp %s\nif err := c.do(%s, &resp); err != nil {\nreturn nil, err\n}\nreturn resp, nil\n", typ, callArgs)
+	case isJSON:
+		return fmt.Errorf("JSON responses must be objects or arrays")
+	case len(resp.Content) > 0:
+		g.imports["io"] = true
+		results = "(io.ReadCloser, error)"
+		call = fmt.Sprintf("return c.stream(%s)\n", callArgs)
+	default:
+		results = "error"
+		call = fmt.Sprintf("return c.do(%s, nil)\n", callArgs)
+	}
+
+	g.body.WriteString("\n")
+	fmt.Fprintf(&g.body, "// %s calls %s %s", name, method, path)
+	if status != http.StatusOK {
+		fmt.Fprintf(&g.body, ", which replies %d %s", status, http.StatusText(status))
+	}
+	g.body.WriteString(".\n")
+	if text := strings.TrimSpace(op.Summary + " " + op.Description); text != "" {
+		g.body.WriteString("//\n")
+		writeComment(&g.body, "", text)
+	}
+	if strings.HasPrefix(results, "(io.ReadCloser") {
+		g.body.WriteString("//\n// The caller must close the body.\n")
+	}
+	fmt.Fprintf(&g.body, "func (c *Client) %s(%s) %s {\n", name, strings.Join(args, ", "), results)
+	if query == "q" {
+		fmt.Fprintf(&g.body, "q := url.Values{}\n%s", queryCode.String())
+	}
+	g.body.WriteString(call)
+	g.body.WriteString("}\n")
+	return nil
+}
+
+// successResponse returns the first 2xx response of an operation and its
+// status.
+func successResponse(op *Operation) (int, *Response) {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if n, err := strconv.Atoi(code); err == nil && n >= 200 && n < 300 {
+			return n, op.Responses[code]
+		}
+	}
+	return 0, nil
+}
+
+// initialisms are the words GoName writes in upper case.
+var initialisms = map[string]bool{
+	"api": true, "csv": true, "dna": true, "fasta": true, "fastq": true,
+	"gc": true, "html": true, "http": true, "id": true, "json": true,
+	"msa": true, "qc": true, "rna": true, "sam": true, "ttl": true,
+	"uri": true, "url": true, "vcf": true,
+}
+
+// GoName returns the exported Go name of a JSON property or operation ID:
+// words split at underscores, hyphens, dots and case changes, each
+// capitalized, and initialisms in upper case, so that "fastq_a" is
+// FASTQA and "read_group_id" ReadGroupID.
+func GoName(name string) string {
+	var b strings.Builder
+	for _, word := range splitWords(name) {
+		if initialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		r := []rune(word)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	out := b.String()
+	if out != "" && !unicode.IsLetter([]rune(out)[0]) {
+		out = "X" + out
+	}
+	return out
+}
+
+// goArgName returns the unexported Go name of a parameter.
+func goArgName(name string) string {
+	words := splitWords(name)
+	if len(words) == 0 {
+		return "arg"
+	}
+	words[0] = strings.ToLower(words[0])
+	for i := 1; i < len(words); i++ {
+		words[i] = GoName(words[i])
+	}
+	arg := strings.Join(words, "")
+	if token.IsKeyword(arg) || arg == "ctx" || arg == "params" || arg == "req" || arg == "q" {
+		arg += "Arg"
+	}
+	return arg
+}
+
+// splitWords splits a name into words at separators and case changes,
+// keeping runs of capitals such as "GC" in "GCContent" together.
+func splitWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && len(word) > 0:
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+// writeComment writes text as a line comment wrapped at 72 columns, each
+// line starting with indent.
+func writeComment(b interface{ WriteString(string) (int, error) }, indent, text string) {
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > 72 {
+			b.WriteString(indent + "// " + line + "\n")
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		b.WriteString(indent + "// " + line + "\n")
+	}
+}
@@ -0,0 +1,615 @@
+// Package openapi describes an HTTP API as an OpenAPI 3 document, with
+// the schemas of request and response bodies derived from the Go types
+// the handlers encode and decode, and generates a Go client from such a
+// document.
+//
+// Schemas follow encoding/json: exported fields under their tag names,
+// embedded structs flattened, pointers nullable, maps with string keys
+// as objects, time.Time as date-time strings and types with a text
+// encoding as strings. Named structs become components referenced by
+// name, so a type shared by several endpoints is described once.
+//
+// Comparison with Aria:
+//
+//	Aria would derive the schema from the type at compile time:
+//	  @derive(JsonSchema)
+//	  struct GCContentResponse
+//	    gc_content: Float where gc_content >= 0.0 and gc_content <= 1.0
+//
+//	Go has no derive, so the schema is built by reflection when the
+//	document is, and refinements such as ranges are left to the
+//	descriptions.
+package openapi
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Version is the OpenAPI version of the documents built here.
+const Version = "3.0.3"
+
+// ErrorSchema names the component of error bodies, {"error": "..."}.
+const ErrorSchema = "Error"
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Tags       []Tag                `json:"tags,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+
+	// types maps the Go types described so far to their component names,
+	// and names the other way.
+	types map[reflect.Type]string
+	names map[string]reflect.Type
+	// like maps types with a custom JSON encoding to a type encoded the
+	// same way.
+	like map[reflect.Type]reflect.Type
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a base URL of the API.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag groups operations.
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations on a path, by method.
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Put    *Operation `json:"put,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+}
+
+// Operations returns the operations of the item by method, in the order
+// GET, PUT, POST, DELETE.
+func (p *PathItem) Operations() []MethodOperation {
+	var ops []MethodOperation
+	for _, m := range []struct {
+		method string
+		op     *Operation
+	}{{http.MethodGet, p.Get}, {http.MethodPut, p.Put}, {http.MethodPost, p.Post}, {http.MethodDelete, p.Delete}} {
+		if m.op != nil {
+			ops = append(ops, MethodOperation{Method: m.method, Operation: m.op})
+		}
+	}
+	return ops
+}
+
+// MethodOperation is an operation with its method.
+type MethodOperation struct {
+	Method    string
+	Operation *Operation
+}
+
+// Operation is one endpoint.
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter is a path or query parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // "path" or "query"
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the body of a request.
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+// Response is a response, by status code or "default".
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// Header is a response header.
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// MediaType is the schema of a body in one media type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the schemas referenced by name.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema describes a JSON value. An empty schema accepts any value.
+type Schema struct {
+	Ref                  string     `json:"$ref,omitempty"`
+	Type                 string     `json:"type,omitempty"`
+	Format               string     `json:"format,omitempty"`
+	Description          string     `json:"description,omitempty"`
+	Nullable             bool       `json:"nullable,omitempty"`
+	Enum                 []string   `json:"enum,omitempty"`
+	Items                *Schema    `json:"items,omitempty"`
+	Properties           Properties `json:"properties,omitempty"`
+	AdditionalProperties *Schema    `json:"additionalProperties,omitempty"`
+}
+
+// RefName returns the component name of a reference, or "".
+func (s *Schema) RefName() string {
+	name, _ := strings.CutPrefix(s.Ref, "#/components/schemas/")
+	return name
+}
+
+// Property is a named property of an object schema.
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+// Properties are the properties of an object schema, kept in field order
+// rather than sorted, so that generated types follow the Go ones.
+type Properties []Property
+
+// MarshalJSON encodes the properties as an object, in order.
+func (ps Properties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, p := range ps {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(p.Name)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := json.Marshal(p.Schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(schema)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes an object of properties, keeping their order.
+func (ps *Properties) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("properties must be an object")
+	}
+	*ps = nil
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		p := Property{Name: tok.(string)}
+		if err := dec.Decode(&p.Schema); err != nil {
+			return fmt.Errorf("property %s: %w", p.Name, err)
+		}
+		*ps = append(*ps, p)
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// New returns a document without paths, with the Error component.
+func New(info Info) *Document {
+	d := &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Paths:      make(map[string]*PathItem),
+		Components: Components{Schemas: make(map[string]*Schema)},
+		types:      make(map[reflect.Type]string),
+		names:      make(map[string]reflect.Type),
+		like:       make(map[reflect.Type]reflect.Type),
+	}
+	d.Components.Schemas[ErrorSchema] = &Schema{
+		Type:       "object",
+		Properties: Properties{{Name: "error", Schema: &Schema{Type: "string"}}},
+	}
+	d.names[ErrorSchema] = nil
+	return d
+}
+
+// Endpoint describes an endpoint to add to a document.
+type Endpoint struct {
+	Method string
+	// Path is the path from the server URL, with {name} path parameters.
+	Path string
+	// ID is the operationId, which names the client method.
+	ID          string
+	Summary     string
+	Description string
+	Tag         string
+	// Query are the query parameters; path parameters are taken from
+	// Path.
+	Query []Parameter
+	// Request is a value of the type of the JSON request body, or nil.
+	Request interface{}
+	// Response is a value of the type of the JSON response body, or nil
+	// for a response without one or with only Content.
+	Response interface{}
+	// Content are the media types of a response that is not JSON, such
+	// as a file download, with a schema of their items if they are JSON
+	// Lines.
+	Content map[string]*Schema
+	// Status is the status of success; zero is 200.
+	Status int
+	// Headers are headers of the response on success, by name.
+	Headers map[string]string
+}
+
+// Add adds an endpoint, describing the types of its bodies. Errors are
+// described as replies with an Error body.
+func (d *Document) Add(e Endpoint) error {
+	if e.ID == "" {
+		return fmt.Errorf("%s %s has no operation ID", e.Method, e.Path)
+	}
+	item := d.Paths[e.Path]
+	if item == nil {
+		item = &PathItem{}
+	}
+	var slot **Operation
+	switch e.Method {
+	case http.MethodGet:
+		slot = &item.Get
+	case http.MethodPut:
+		slot = &item.Put
+	case http.MethodPost:
+		slot = &item.Post
+	case http.MethodDelete:
+		slot = &item.Delete
+	default:
+		return fmt.Errorf("%s %s: unsupported method", e.Method, e.Path)
+	}
+	if *slot != nil {
+		return fmt.Errorf("%s %s added twice", e.Method, e.Path)
+	}
+	for _, ops := range d.Paths {
+		for _, op := range ops.Operations() {
+			if op.Operation.OperationID == e.ID {
+				return fmt.Errorf("%s %s: operation ID %s already used", e.Method, e.Path, e.ID)
+			}
+		}
+	}
+
+	op := &Operation{
+		OperationID: e.ID,
+		Summary:     e.Summary,
+		Description: e.Description,
+		Responses:   make(map[string]*Response),
+	}
+	if e.Tag != "" {
+		op.Tags = []string{e.Tag}
+	}
+	for _, name := range pathParams(e.Path) {
+		op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	for _, p := range e.Query {
+		p.In = "query"
+		if p.Schema == nil {
+			p.Schema = &Schema{Type: "string"}
+		}
+		op.Parameters = append(op.Parameters, p)
+	}
+	if e.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: d.Schema(reflect.TypeOf(e.Request))}},
+		}
+	}
+
+	status := e.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	ok := &Response{Description: http.StatusText(status)}
+	if e.Response != nil {
+		ok.Content = map[string]MediaType{"application/json": {Schema: d.Schema(reflect.TypeOf(e.Response))}}
+	}
+	for mediaType, schema := range e.Content {
+		if ok.Content == nil {
+			ok.Content = make(map[string]MediaType)
+		}
+		if schema == nil {
+			schema = &Schema{Type: "string"}
+		}
+		ok.Content[mediaType] = MediaType{Schema: schema}
+	}
+	for name, desc := range e.Headers {
+		if ok.Headers == nil {
+			ok.Headers = make(map[string]Header)
+		}
+		ok.Headers[name] = Header{Description: desc, Schema: &Schema{Type: "string"}}
+	}
+	op.Responses[fmt.Sprint(status)] = ok
+	op.Responses["default"] = &Response{
+		Description: "Error",
+		Content:     map[string]MediaType{"application/json": {Schema: &Schema{Ref: ref(ErrorSchema)}}},
+	}
+
+	*slot = op
+	d.Paths[e.Path] = item
+	if e.Tag != "" && !d.hasTag(e.Tag) {
+		d.Tags = append(d.Tags, Tag{Name: e.Tag})
+	}
+	return nil
+}
+
+// hasTag reports whether a tag is declared.
+func (d *Document) hasTag(name string) bool {
+	for _, t := range d.Tags {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// pathParams returns the names of the {name} parameters of a path.
+func pathParams(path string) []string {
+	var names []string
+	for _, part := range strings.Split(path, "/") {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			names = append(names, part[1:len(part)-1])
+		}
+	}
+	return names
+}
+
+// JSONSchema returns the schema of a value's type, for Endpoint.Content.
+func (d *Document) JSONSchema(v interface{}) *Schema {
+	return d.Schema(reflect.TypeOf(v))
+}
+
+// Name sets the component name of a value's named struct type, in place
+// of its Go name, such as to tell apart types of the same name from two
+// packages. It must be called before the type is first described.
+func (d *Document) Name(v interface{}, name string) error {
+	t := indirect(reflect.TypeOf(v))
+	if other, used := d.names[name]; used && other != t {
+		return fmt.Errorf("schema name %s already used", name)
+	}
+	if old, named := d.types[t]; named && old != name {
+		return fmt.Errorf("type %s already described as %s", t, old)
+	}
+	d.types[t] = name
+	d.names[name] = t
+	return nil
+}
+
+// Like describes a value's type, which encodes itself to JSON, as the
+// type of another value encoded the same way.
+func (d *Document) Like(v, like interface{}) {
+	d.like[indirect(reflect.TypeOf(v))] = indirect(reflect.TypeOf(like))
+}
+
+// indirect returns the type pointers point to.
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// ref returns the reference to a component.
+func ref(name string) string {
+	return "#/components/schemas/" + name
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Schema returns the schema of a type, adding components for the named
+// structs it uses.
+//
+// Aria equivalent:
+//
+//	fn schema(self, t: Type) -> Schema
+//	  ensures t.is_struct() and t.name().len() > 0 implies result.ref.len() > 0
+func (d *Document) Schema(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t, nullable = t.Elem(), true
+	}
+	s := d.schema(t)
+	if nullable && s.Ref == "" {
+		s.Nullable = true
+	}
+	return s
+}
+
+// schema returns the schema of a type that is not a pointer.
+func (d *Document) schema(t reflect.Type) *Schema {
+	if like, ok := d.like[t]; ok {
+		if t.Kind() == reflect.Struct && t.Name() != "" && like.Kind() == reflect.Struct {
+			return d.component(t, like)
+		}
+		return d.schema(like)
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return &Schema{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int64, reflect.Uint64:
+		s := &Schema{Type: "integer", Format: "int64"}
+		if t.String() == "time.Duration" {
+			s.Description = "Nanoseconds."
+		}
+		return s
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uintptr:
+		return &Schema{Type: "integer"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.Schema(t.Elem())}
+	case reflect.Array:
+		return &Schema{Type: "array", Items: d.Schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.Schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.object(t)
+		}
+		return d.component(t, t)
+	}
+	// Interfaces, and kinds encoding/json cannot encode.
+	return &Schema{}
+}
+
+// component returns a reference to the component of a named struct,
+// adding it, with the fields of like, if it is new.
+func (d *Document) component(t, like reflect.Type) *Schema {
+	name, ok := d.types[t]
+	if !ok {
+		name = t.Name()
+		if other, used := d.names[name]; used && other != t {
+			// Another package's type of the same name: qualify it.
+			pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+		}
+		d.types[t] = name
+		d.names[name] = t
+	}
+	if d.Components.Schemas[name] == nil {
+		// Set before the fields, for types that refer to themselves.
+		s := &Schema{Type: "object"}
+		d.Components.Schemas[name] = s
+		*s = *d.object(like)
+	}
+	return &Schema{Ref: ref(name)}
+}
+
+// object returns the object schema of a struct's fields.
+func (d *Document) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object"}
+	d.fields(t, s, nil)
+	return s
+}
+
+// fields adds the properties of a struct's fields to s in field order,
+// flattening embedded structs as encoding/json does: their fields are
+// left out when shadowed by a field of an outer struct.
+func (d *Document) fields(t reflect.Type, s *Schema, shadowed map[string]bool) {
+	inner := make(map[string]bool, len(shadowed))
+	for name := range shadowed {
+		inner[name] = true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if name, ok := fieldName(t.Field(i)); ok {
+			inner[name] = true
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if et, ok := embeddedStruct(f); ok {
+			d.fields(et, s, inner)
+			continue
+		}
+		name, ok := fieldName(f)
+		if !ok || shadowed[name] {
+			continue
+		}
+		s.Properties = append(s.Properties, Property{Name: name, Schema: d.Schema(f.Type)})
+	}
+}
+
+// fieldName returns the JSON name of a field, unless the field is not
+// encoded or is an embedded struct.
+func fieldName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	if _, ok := embeddedStruct(f); ok || !f.IsExported() {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, true
+}
+
+// embeddedStruct returns the struct type of an embedded field without a
+// JSON name, whose fields encoding/json promotes.
+func embeddedStruct(f reflect.StructField) (reflect.Type, bool) {
+	if !f.Anonymous || f.Tag.Get("json") == "-" {
+		return nil, false
+	}
+	if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" {
+		return nil, false
+	}
+	t := indirect(f.Type)
+	return t, t.Kind() == reflect.Struct
+}
+
+// SortedPaths returns the paths of the document in order.
+func (d *Document) SortedPaths() []string {
+	paths := make([]string, 0, len(d.Paths))
+	for p := range d.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,186 @@
+package openapi
+
+import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Level int
+
+func (l Level) MarshalText() ([]byte, error) { return []byte("high"), nil }
+
+type Base struct {
+	ID   string `json:"id"`
+	Note string `json:"note,omitempty"`
+}
+
+type Item struct {
+	Base
+	Note     string            `json:"note"`
+	Score    float64           `json:"score"`
+	Count    *int              `json:"count,omitempty"`
+	Level    Level             `json:"level"`
+	When     time.Time         `json:"when"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Children []Item            `json:"children,omitempty"`
+	Extra    json.RawMessage   `json:"extra,omitempty"`
+	Skipped  string            `json:"-"`
+	internal int
+}
+
+type ListRequest struct {
+	Items []Item `json:"items"`
+}
+
+type Custom struct{ n int }
+
+func (c Custom) MarshalJSON() ([]byte, error) { return json.Marshal(map[string]int{"n": c.n}) }
+
+type CustomBody struct {
+	N int `json:"n"`
+}
+
+func TestSchema(t *testing.T) {
+	d := New(Info{Title: "test", Version: "1"})
+	s := d.JSONSchema(Item{})
+	assert.Equal(t, "#/components/schemas/Item", s.Ref)
+
+	c := d.Components.Schemas["Item"]
+	require.NotNil(t, c)
+	var names []string
+	for _, p := range c.Properties {
+		names = append(names, p.Name)
+	}
+	assert.Equal(t, []string{"id", "note", "score", "count", "level", "when", "tags", "children", "extra"}, names,
+		"embedded fields come first and are shadowed by outer ones")
+	props := map[string]*Schema{}
+	for _, p := range c.Properties {
+		props[p.Name] = p.Schema
+	}
+	assert.Equal(t, &Schema{Type: "number", Format: "double"}, props["score"])
+	assert.Equal(t, &Schema{Type: "integer", Nullable: true}, props["count"])
+	assert.Equal(t, &Schema{Type: "string"}, props["level"], "text marshalers are strings")
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, props["when"])
+	assert.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}, props["tags"])
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/Item"}}, props["children"], "recursive types refer to themselves")
+	assert.Equal(t, &Schema{}, props["extra"])
+
+	assert.Equal(t, &Schema{}, d.JSONSchema(Custom{}), "custom encodings are free-form")
+	d.Like(Custom{}, CustomBody{})
+	assert.Equal(t, "#/components/schemas/Custom", d.JSONSchema(Custom{}).Ref)
+	assert.Equal(t, "n", d.Components.Schemas["Custom"].Properties[0].Name)
+}
+
+func TestName(t *testing.T) {
+	d := New(Info{Title: "test", Version: "1"})
+	require.NoError(t, d.Name(Base{}, "Root"))
+	assert.Equal(t, "#/components/schemas/Root", d.JSONSchema(&Base{}).Ref)
+	assert.Error(t, d.Name(Item{}, "Root"), "names are unique")
+	assert.Error(t, d.Name(Base{}, "Other"), "types are named once")
+	assert.Error(t, d.Name(Item{}, ErrorSchema))
+}
+
+func TestPropertiesRoundTrip(t *testing.T) {
+	d := New(Info{Title: "test", Version: "1"})
+	d.JSONSchema(Item{})
+	data, err := json.Marshal(d.Components.Schemas["Item"])
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), `{"type":"object","properties":{"id":`), string(data))
+
+	var back Schema
+	require.NoError(t, json.Unmarshal(data, &back))
+	assert.Equal(t, d.Components.Schemas["Item"], &back)
+}
+
+func TestAdd(t *testing.T) {
+	d := New(Info{Title: "test", Version: "1"})
+	require.NoError(t, d.Add(Endpoint{Method: "POST", Path: "/items", ID: "AddItems", Tag: "items", Request: ListRequest{}, Response: Item{}, Status: 201,
+		Headers: map[string]string{"Location": "URL of the item."}}))
+	require.NoError(t, d.Add(Endpoint{Method: "GET", Path: "/items/{id}/file", ID: "ItemFile", Content: map[string]*Schema{"text/plain": nil},
+		Query: []Parameter{{Name: "format"}}}))
+
+	op := d.Paths["/items"].Post
+	require.NotNil(t, op)
+	assert.Equal(t, []string{"items"}, op.Tags)
+	assert.Equal(t, "#/components/schemas/ListRequest", op.RequestBody.Content["application/json"].Schema.Ref)
+	require.Contains(t, op.Responses, "201")
+	assert.Contains(t, op.Responses["201"].Headers, "Location")
+	assert.Equal(t, "#/components/schemas/Error", op.Responses["default"].Content["application/json"].Schema.Ref)
+
+	op = d.Paths["/items/{id}/file"].Get
+	require.Len(t, op.Parameters, 2)
+	assert.Equal(t, Parameter{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}}, op.Parameters[0])
+	assert.Equal(t, "query", op.Parameters[1].In)
+	assert.Equal(t, &Schema{Type: "string"}, op.Responses["200"].Content["text/plain"].Schema)
+
+	assert.Error(t, d.Add(Endpoint{Method: "POST", Path: "/items", ID: "Again"}), "one operation per method and path")
+	assert.Error(t, d.Add(Endpoint{Method: "PUT", Path: "/other", ID: "AddItems"}), "operation IDs are unique")
+	assert.Error(t, d.Add(Endpoint{Method: "PATCH", Path: "/other", ID: "Patch"}))
+	assert.Error(t, d.Add(Endpoint{Method: "GET", Path: "/other"}))
+}
+
+func TestGenerateClient(t *testing.T) {
+	d := New(Info{Title: "test", Version: "1"})
+	require.NoError(t, d.Add(Endpoint{Method: "POST", Path: "/items", ID: "AddItems", Request: ListRequest{}, Response: []Item{}}))
+	require.NoError(t, d.Add(Endpoint{Method: "GET", Path: "/items/{id}", ID: "Item", Response: Item{}}))
+	require.NoError(t, d.Add(Endpoint{Method: "DELETE", Path: "/items/{id}", ID: "DeleteItem", Status: 204}))
+	require.NoError(t, d.Add(Endpoint{Method: "GET", Path: "/items/{id}/file", ID: "ItemFile", Content: map[string]*Schema{"text/plain": nil},
+		Query: []Parameter{{Name: "format", Description: "Format of the file."}, {Name: "region", Schema: &Schema{Type: "array", Items: &Schema{Type: "string"}}}}}))
+
+	src, err := GenerateClient(d, ClientOptions{Package: "client", Generator: "test"})
+	require.NoError(t, err)
+	code := string(src)
+	_, err = parser.ParseFile(token.NewFileSet(), "client.go", src, 0)
+	require.NoError(t, err, code)
+
+	assert.True(t, strings.HasPrefix(code, "// Code generated by test; DO NOT EDIT.\n"))
+	assert.Contains(t, code, "type Item struct {")
+	assert.Contains(t, code, "ID       string            `json:\"id,omitempty\"`")
+	assert.Contains(t, code, "Count    *int              `json:\"count,omitempty\"`")
+	assert.Contains(t, code, "When     time.Time")
+	assert.Contains(t, code, "Children []Item")
+	assert.NotContains(t, code, "type Error struct", "errors are returned, not decoded")
+	assert.Contains(t, code, "func (c *Client) AddItems(ctx context.Context, req ListRequest) ([]Item, error) {")
+	assert.Contains(t, code, "func (c *Client) Item(ctx context.Context, id string) (*Item, error) {")
+	assert.Contains(t, code, `c.do(ctx, "GET", "/items/"+url.PathEscape(id), nil, nil, &resp)`)
+	assert.Contains(t, code, "func (c *Client) DeleteItem(ctx context.Context, id string) error {")
+	assert.Contains(t, code, "type ItemFileParams struct {")
+	assert.Contains(t, code, "func (c *Client) ItemFile(ctx context.Context, id string, params ItemFileParams) (io.ReadCloser, error) {")
+	assert.Contains(t, code, `q.Add("region", v)`)
+
+	// The same client comes out of the document read back from JSON.
+	data, err := json.Marshal(d)
+	require.NoError(t, err)
+	var back Document
+	require.NoError(t, json.Unmarshal(data, &back))
+	again, err := GenerateClient(&back, ClientOptions{Package: "client", Generator: "test"})
+	require.NoError(t, err)
+	assert.Equal(t, code, string(again))
+
+	_, err = GenerateClient(d, ClientOptions{Package: "not a name"})
+	assert.Error(t, err)
+}
+
+func TestGoName(t *testing.T) {
+	for in, want := range map[string]string{
+		"fastq_a":       "FASTQA",
+		"read_group_id": "ReadGroupID",
+		"GCContent":     "GCContent",
+		"gc-content":    "GCContent",
+		"sequence1":     "Sequence1",
+		"e_value":       "EValue",
+		"KMerCount":     "KMerCount",
+		"2bit":          "X2bit",
+	} {
+		assert.Equal(t, want, GoName(in), in)
+	}
+	assert.Equal(t, "typeArg", goArgName("type"))
+	assert.Equal(t, "readID", goArgName("read_id"))
+}
@@ -0,0 +1,474 @@
+package orf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/protein"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// DefaultShiftPenalty is the cost of a frameshift, in codons. A shift is
+// only proposed when it extends an ORF by more than this many codons.
+const DefaultShiftPenalty = 30
+
+// ShiftKind is the type of indel a frameshift implies in the read.
+type ShiftKind int
+
+const (
+	// Insertion is an extra base in the sequence; the correction drops it.
+	Insertion ShiftKind = iota
+	// Deletion is a missing base; the correction inserts an N.
+	Deletion
+)
+
+func (k ShiftKind) String() string {
+	if k == Insertion {
+		return "insertion"
+	}
+	return "deletion"
+}
+
+// MarshalText encodes the kind by name.
+func (k ShiftKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Frameshift is a suspected indel. Position is the 0-based forward-strand
+// coordinate of the extra base (insertion) or of the base following the
+// missing one on the ORF's strand (deletion).
+type Frameshift struct {
+	Position int       `json:"position"`
+	Kind     ShiftKind `json:"kind"`
+}
+
+// CorrectedORF is an ORF whose translation tolerates frameshifts.
+// CorrectedDNA is the coding sequence on the ORF's strand after dropping
+// inserted bases and filling deletions with N.
+type CorrectedORF struct {
+	ORF
+	Shifts       []Frameshift `json:"shifts"`
+	CorrectedDNA string       `json:"corrected_dna"`
+	Score        int          `json:"score"`
+}
+
+// FrameshiftOptions configures frameshift-tolerant ORF detection.
+type FrameshiftOptions struct {
+	// MinLength is the minimum corrected length in amino acids.
+	MinLength int
+	// ShiftPenalty is the cost of each frameshift in codons.
+	ShiftPenalty int
+	// Strand selects the strands searched (default both).
+	Strand sequence.Strand
+}
+
+// codonStep records how the path moved from one codon to the next.
+type codonStep struct {
+	prev  int // start of the previous codon, or -1 at the ORF start
+	score int
+}
+
+const unreachable = math.MinInt32
+
+// traceCodons walks back from codon c and returns the codon starts in order.
+func traceCodons(steps []codonStep, c int) []int {
+	var path []int
+	for ; c >= 0; c = steps[c].prev {
+		path = append(path, c)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// buildCorrected assembles the corrected DNA, translation and shifts for
+// a path of codon starts ending with a stop codon at stop (or -1).
+func buildCorrected(bases string, path []int, stop int) (string, string, []Frameshift, int) {
+	var dna, aa strings.Builder
+	shifts := make([]Frameshift, 0)
+	next := append(append([]int(nil), path[1:]...), stop)
+	for i, c := range path {
+		step := 3
+		if next[i] >= 0 {
+			step = next[i] - c
+		}
+		switch step {
+		case 2:
+			// Missing base: only two real bases belong to this codon.
+			dna.WriteString(bases[c : c+2])
+			dna.WriteByte('N')
+			aa.WriteByte('X')
+			shifts = append(shifts, Frameshift{Position: c + 2, Kind: Deletion})
+		case 4:
+			dna.WriteString(bases[c : c+3])
+			aa.WriteByte(protein.TranslateCodon(bases[c : c+3]))
+			shifts = append(shifts, Frameshift{Position: c + 3, Kind: Insertion})
+		default:
+			dna.WriteString(bases[c : c+3])
+			aa.WriteByte(protein.TranslateCodon(bases[c : c+3]))
+		}
+	}
+	end := path[len(path)-1] + 3
+	if stop >= 0 {
+		dna.WriteString(bases[stop : stop+3])
+		end = stop + 3
+	}
+	return dna.String(), aa.String(), shifts, end
+}
+
+// FindFrameshifted detects ORFs allowing single-base frameshifts. It
+// finds, on each strand selected, the highest-scoring paths of codons
+// from an ATG to a stop where each sense codon scores 1 and each
+// frameshift costs ShiftPenalty. A frameshift is therefore proposed where an abrupt stop
+// would otherwise truncate a long ORF that continues in another frame.
+// Overlapping candidates on the same strand are resolved by score.
+//
+// Aria equivalent:
+//
+//	fn find_frameshifted(seq: Sequence, options: FrameshiftOptions) -> Result<[CorrectedORF], OrfError>
+//	  ensures result.all(|o| o.protein.len() >= options.min_length)
+//	  ensures result.all(|o| o.corrected_dna.len() % 3 == 0)
+func FindFrameshifted(seq *sequence.Sequence, opts FrameshiftOptions) ([]CorrectedORF, error) {
+	if opts.MinLength <= 0 {
+		opts.MinLength = DefaultMinLength
+	}
+	if opts.ShiftPenalty <= 0 {
+		opts.ShiftPenalty = DefaultShiftPenalty
+	}
+	fwd, rev, err := strands(seq)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]CorrectedORF, 0)
+	n := len(fwd)
+	for _, strand := range selectStrands(opts.Strand) {
+		bases := fwd
+		if strand == sequence.Reverse {
+			bases = rev
+		}
+
+		type candidate struct {
+			last, stop, score int
+		}
+		var candidates []candidate
+
+		steps := make([]codonStep, n)
+		for c := 0; c+3 <= n; c++ {
+			steps[c] = codonStep{prev: -1, score: unreachable}
+
+			best, bestPrev := unreachable, -1
+			for _, delta := range []int{3, 4, 2} {
+				p := c - delta
+				if p < 0 || steps[p].score == unreachable {
+					continue
+				}
+				s := steps[p].score
+				if delta != 3 {
+					s -= opts.ShiftPenalty
+				}
+				if s > best {
+					best, bestPrev = s, p
+				}
+			}
+
+			codon := bases[c : c+3]
+			if protein.IsStopCodon(codon) {
+				if bestPrev >= 0 {
+					candidates = append(candidates, candidate{last: bestPrev, stop: c, score: best})
+				}
+				continue
+			}
+			if protein.IsStartCodon(codon) && best < 1 {
+				best, bestPrev = 0, -1
+			} else if bestPrev < 0 {
+				continue
+			}
+			steps[c] = codonStep{prev: bestPrev, score: best + 1}
+		}
+
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+		var taken [][2]int
+		for _, cand := range candidates {
+			path := traceCodons(steps, cand.last)
+			if len(path) < opts.MinLength {
+				continue
+			}
+			dna, aa, shifts, end := buildCorrected(bases, path, cand.stop)
+			start := path[0]
+
+			overlaps := false
+			for _, t := range taken {
+				if start < t[1] && t[0] < end {
+					overlaps = true
+					break
+				}
+			}
+			if overlaps {
+				continue
+			}
+			taken = append(taken, [2]int{start, end})
+
+			o := CorrectedORF{
+				ORF: ORF{
+					SequenceID: seq.ID,
+					Start:      start,
+					End:        end,
+					Strand:     strand,
+					Frame:      start % 3,
+					Protein:    aa,
+					Complete:   true,
+				},
+				Shifts:       shifts,
+				CorrectedDNA: dna,
+				Score:        cand.score,
+			}
+			if strand == sequence.Reverse {
+				o.Start, o.End = toForward(start, end, n)
+				for i := range o.Shifts {
+					o.Shifts[i].Position = n - 1 - o.Shifts[i].Position
+				}
+			}
+			result = append(result, o)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Start < result[j].Start })
+	return result, nil
+}
+
+// ReferenceOptions configures frameshift-aware alignment to a protein.
+type ReferenceOptions struct {
+	Match        int
+	Mismatch     int
+	Gap          int
+	ShiftPenalty int
+	// Strand selects the strands aligned (default both).
+	Strand sequence.Strand
+}
+
+// DefaultReferenceOptions returns identity-based scores suitable for
+// close homologs.
+func DefaultReferenceOptions() ReferenceOptions {
+	return ReferenceOptions{Match: 5, Mismatch: -2, Gap: -8, ShiftPenalty: 15}
+}
+
+// MaxReferenceCells bounds the DNA x protein DP matrix size.
+const MaxReferenceCells = 20_000_000
+
+// ReferenceHit is a frameshift-tolerant local alignment of a nucleotide
+// sequence against a reference protein.
+type ReferenceHit struct {
+	CorrectedORF
+	RefStart int     `json:"ref_start"`
+	RefEnd   int     `json:"ref_end"`
+	Identity float64 `json:"identity"`
+}
+
+// AlignToReference aligns the six-frame translation of seq against a
+// reference protein, allowing single-base frameshifts, and reports the
+// best local alignment as a corrected ORF. It is intended for gene-sized
+// regions: the DP matrix is limited to MaxReferenceCells.
+//
+// Aria equivalent:
+//
+//	fn align_to_reference(seq: Sequence, reference: Protein, options: ReferenceOptions) -> Result<ReferenceHit, OrfError>
+//	  requires seq.len() * reference.len() <= MAX_REFERENCE_CELLS
+func AlignToReference(seq *sequence.Sequence, ref *protein.Protein, opts ReferenceOptions) (*ReferenceHit, error) {
+	target := ref.TrimStop()
+	if len(target) == 0 {
+		return nil, fmt.Errorf("reference protein is empty")
+	}
+	if (seq.Len()+1)*(len(target)+1) > MaxReferenceCells {
+		return nil, fmt.Errorf("alignment of %d bp against %d aa exceeds the %d cell limit", seq.Len(), len(target), MaxReferenceCells)
+	}
+	fwd, rev, err := strands(seq)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *ReferenceHit
+	n := len(fwd)
+	for _, strand := range selectStrands(opts.Strand) {
+		bases := fwd
+		if strand == sequence.Reverse {
+			bases = rev
+		}
+		hit := alignStrand(bases, target, opts)
+		if hit == nil || (best != nil && hit.Score <= best.Score) {
+			continue
+		}
+		hit.SequenceID = seq.ID
+		hit.Strand = strand
+		if strand == sequence.Reverse {
+			hit.Frame = hit.Start % 3
+			hit.Start, hit.End = toForward(hit.Start, hit.End, n)
+			for i := range hit.Shifts {
+				hit.Shifts[i].Position = n - 1 - hit.Shifts[i].Position
+			}
+		}
+		best = hit
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("no alignment to the reference found")
+	}
+	return best, nil
+}
+
+// Alignment moves for traceback.
+const (
+	moveStop = iota
+	moveCodon
+	moveDeletion  // two bases + missing base against one residue
+	moveInsertion // codon + extra base against one residue
+	moveExtraCodon
+	moveMissingResidue
+)
+
+// alignStrand runs the frameshift-aware Smith-Waterman on one strand.
+func alignStrand(bases, ref string, opts ReferenceOptions) *ReferenceHit {
+	n, m := len(bases), len(ref)
+	H := make([][]int32, n+1)
+	move := make([][]uint8, n+1)
+	for i := range H {
+		H[i] = make([]int32, m+1)
+		move[i] = make([]uint8, m+1)
+	}
+
+	sub := func(aa, r byte) int32 {
+		switch {
+		case aa == protein.Stop:
+			return int32(opts.Gap)
+		case aa == 'X':
+			return 0
+		case aa == r:
+			return int32(opts.Match)
+		default:
+			return int32(opts.Mismatch)
+		}
+	}
+
+	var bestScore int32
+	bestI, bestJ := 0, 0
+	for i := 1; i <= n; i++ {
+		for j := 0; j <= m; j++ {
+			var h int32
+			mv := uint8(moveStop)
+			try := func(s int32, which uint8) {
+				if s > h {
+					h, mv = s, which
+				}
+			}
+			if j > 0 {
+				r := ref[j-1]
+				if i >= 3 {
+					try(H[i-3][j-1]+sub(protein.TranslateCodon(bases[i-3:i]), r), moveCodon)
+				}
+				if i >= 2 {
+					try(H[i-2][j-1]+sub('X', r)-int32(opts.ShiftPenalty), moveDeletion)
+				}
+				if i >= 4 {
+					try(H[i-4][j-1]+sub(protein.TranslateCodon(bases[i-4:i-1]), r)-int32(opts.ShiftPenalty), moveInsertion)
+				}
+				try(H[i][j-1]+int32(opts.Gap), moveMissingResidue)
+			}
+			if i >= 3 {
+				try(H[i-3][j]+int32(opts.Gap), moveExtraCodon)
+			}
+			H[i][j], move[i][j] = h, mv
+			if h > bestScore {
+				bestScore, bestI, bestJ = h, i, j
+			}
+		}
+	}
+
+	if bestScore == 0 {
+		return nil
+	}
+
+	// Traceback, collecting pieces in reverse.
+	var dnaParts, aaParts []string
+	var shifts []Frameshift
+	matches, columns := 0, 0
+	i, j := bestI, bestJ
+	for i > 0 && H[i][j] > 0 {
+		switch move[i][j] {
+		case moveCodon:
+			aa := protein.TranslateCodon(bases[i-3 : i])
+			dnaParts = append(dnaParts, bases[i-3:i])
+			aaParts = append(aaParts, string(aa))
+			if aa == ref[j-1] {
+				matches++
+			}
+			columns++
+			i, j = i-3, j-1
+		case moveDeletion:
+			dnaParts = append(dnaParts, bases[i-2:i]+"N")
+			aaParts = append(aaParts, "X")
+			shifts = append(shifts, Frameshift{Position: i, Kind: Deletion})
+			columns++
+			i, j = i-2, j-1
+		case moveInsertion:
+			aa := protein.TranslateCodon(bases[i-4 : i-1])
+			dnaParts = append(dnaParts, bases[i-4:i-1])
+			aaParts = append(aaParts, string(aa))
+			shifts = append(shifts, Frameshift{Position: i - 1, Kind: Insertion})
+			if aa == ref[j-1] {
+				matches++
+			}
+			columns++
+			i, j = i-4, j-1
+		case moveExtraCodon:
+			aa := protein.TranslateCodon(bases[i-3 : i])
+			dnaParts = append(dnaParts, bases[i-3:i])
+			aaParts = append(aaParts, string(aa))
+			columns++
+			i -= 3
+		case moveMissingResidue:
+			columns++
+			j--
+		default:
+			i = 0
+		}
+	}
+
+	reverse := func(parts []string) string {
+		var sb strings.Builder
+		for k := len(parts) - 1; k >= 0; k-- {
+			sb.WriteString(parts[k])
+		}
+		return sb.String()
+	}
+	for a, b := 0, len(shifts)-1; a < b; a, b = a+1, b-1 {
+		shifts[a], shifts[b] = shifts[b], shifts[a]
+	}
+
+	hit := &ReferenceHit{
+		CorrectedORF: CorrectedORF{
+			ORF: ORF{
+				Start:    i,
+				End:      bestI,
+				Frame:    i % 3,
+				Protein:  reverse(aaParts),
+				Complete: false,
+			},
+			Shifts:       shifts,
+			CorrectedDNA: reverse(dnaParts),
+			Score:        int(bestScore),
+		},
+		RefStart: j,
+		RefEnd:   bestJ,
+	}
+	if hit.Shifts == nil {
+		hit.Shifts = []Frameshift{}
+	}
+	if columns > 0 {
+		hit.Identity = float64(matches) / float64(columns)
+	}
+	return hit
+}
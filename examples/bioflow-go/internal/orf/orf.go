@@ -0,0 +1,152 @@
+// Package orf provides open reading frame detection.
+//
+// Find performs classic six-frame ORF calling (ATG to stop).
+// FindFrameshifted and AlignToReference tolerate the insertion and
+// deletion errors common in long-read assemblies: they report corrected
+// ORFs together with the positions of the suspected indels.
+//
+// Comparison with Aria:
+//
+//	Aria states coordinate invariants on the type:
+//	  struct ORF
+//	    invariant self.start < self.end
+//	    invariant self.strand == '+' or self.strand == '-'
+//
+//	Go documents them and checks them in tests.
+package orf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/protein"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// DefaultMinLength is the default minimum ORF length in amino acids.
+const DefaultMinLength = 100
+
+// ORF is an open reading frame. Start and End are 0-based, half-open
+// coordinates on the forward strand and include the stop codon when the
+// ORF is complete.
+type ORF struct {
+	SequenceID string          `json:"sequence_id,omitempty"`
+	Start      int             `json:"start"`
+	End        int             `json:"end"`
+	Strand     sequence.Strand `json:"strand"`
+	Frame      int             `json:"frame"`
+	Protein    string          `json:"protein"`
+	Complete   bool            `json:"complete"`
+}
+
+// Length returns the ORF length in nucleotides.
+func (o *ORF) Length() int {
+	return o.End - o.Start
+}
+
+func (o *ORF) String() string {
+	return fmt.Sprintf("ORF { %d-%d (%c), frame: %d, length: %d aa }", o.Start, o.End, o.Strand, o.Frame, len(o.Protein))
+}
+
+// strands returns the forward and reverse-complement bases of a sequence.
+func strands(seq *sequence.Sequence) (string, string, error) {
+	rc, err := seq.ReverseComplement()
+	if err != nil {
+		return "", "", err
+	}
+	return seq.Bases, rc.Bases, nil
+}
+
+// selectStrands returns the strands a strand option covers, forward first.
+func selectStrands(strand sequence.Strand) []sequence.Strand {
+	selected := make([]sequence.Strand, 0, 2)
+	for _, s := range []sequence.Strand{sequence.Forward, sequence.Reverse} {
+		if strand.Includes(s) {
+			selected = append(selected, s)
+		}
+	}
+	return selected
+}
+
+// toForward converts a half-open interval on the reverse strand of a
+// sequence of length n to forward-strand coordinates.
+func toForward(start, end, n int) (int, int) {
+	return n - end, n - start
+}
+
+// Find reports ORFs of at least minLength amino acids (excluding the stop)
+// on the strands selected. An ORF starts at the first ATG after a stop and ends
+// at the next in-frame stop; ORFs running off the end of the sequence are
+// reported with Complete unset.
+//
+// Aria equivalent:
+//
+//	fn find(seq: Sequence, min_length: Int, strand: Strand) -> Result<[ORF], OrfError>
+//	  requires min_length > 0
+//	  ensures result.all(|o| o.protein.len() >= min_length)
+//	  ensures result.all(|o| strand.includes(o.strand))
+func Find(seq *sequence.Sequence, minLength int, strand sequence.Strand) ([]ORF, error) {
+	if minLength <= 0 {
+		return nil, fmt.Errorf("minimum length must be positive")
+	}
+	fwd, rev, err := strands(seq)
+	if err != nil {
+		return nil, err
+	}
+
+	orfs := make([]ORF, 0)
+	n := len(fwd)
+	for _, strand := range selectStrands(strand) {
+		bases := fwd
+		if strand == sequence.Reverse {
+			bases = rev
+		}
+		for frame := 0; frame < 3; frame++ {
+			start := -1
+			var aa strings.Builder
+			emit := func(end int, complete bool) {
+				if start >= 0 && aa.Len() >= minLength {
+					o := ORF{SequenceID: seq.ID, Start: start, End: end, Strand: strand, Frame: frame, Protein: aa.String(), Complete: complete}
+					if strand == sequence.Reverse {
+						o.Start, o.End = toForward(start, end, n)
+					}
+					orfs = append(orfs, o)
+				}
+				start = -1
+				aa.Reset()
+			}
+
+			i := frame
+			for ; i+3 <= n; i += 3 {
+				codon := bases[i : i+3]
+				if start < 0 {
+					if protein.IsStartCodon(codon) {
+						start = i
+						aa.WriteByte('M')
+					}
+					continue
+				}
+				if protein.IsStopCodon(codon) {
+					emit(i+3, true)
+					continue
+				}
+				aa.WriteByte(protein.TranslateCodon(codon))
+			}
+			emit(i, false)
+		}
+	}
+
+	sortORFs(orfs)
+	return orfs, nil
+}
+
+// sortORFs orders ORFs by start, then strand.
+func sortORFs(orfs []ORF) {
+	sort.Slice(orfs, func(i, j int) bool {
+		if orfs[i].Start != orfs[j].Start {
+			return orfs[i].Start < orfs[j].Start
+		}
+		return orfs[i].Strand < orfs[j].Strand
+	})
+}
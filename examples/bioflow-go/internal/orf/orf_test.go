@@ -0,0 +1,172 @@
+package orf
+
+import (
+	"encoding/json"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/protein"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// codingSequence returns ATG, n random sense codons and TAA.
+func codingSequence(n int, seed int64) string {
+	rng := rand.New(rand.NewSource(seed))
+	var sense []string
+	for codon, aa := range protein.StandardCode {
+		if aa != protein.Stop {
+			sense = append(sense, codon)
+		}
+	}
+	// Map iteration order is random; sort for determinism.
+	sort.Strings(sense)
+
+	var sb strings.Builder
+	sb.WriteString("ATG")
+	for i := 0; i < n; i++ {
+		sb.WriteString(sense[rng.Intn(len(sense))])
+	}
+	sb.WriteString("TAA")
+	return sb.String()
+}
+
+func TestFind(t *testing.T) {
+	seq, err := sequence.WithID("CC"+"ATGAAACCCGGGTTTTAG"+"CC", "s1")
+	require.NoError(t, err)
+
+	orfs, err := Find(seq, 5, sequence.BothStrands)
+	require.NoError(t, err)
+	require.Len(t, orfs, 1)
+	o := orfs[0]
+	assert.Equal(t, 2, o.Start)
+	assert.Equal(t, 20, o.End)
+	assert.Equal(t, sequence.Forward, o.Strand)
+	assert.Equal(t, "MKPGF", o.Protein)
+	assert.True(t, o.Complete)
+	assert.Equal(t, "s1", o.SequenceID)
+
+	_, err = Find(seq, 0, sequence.BothStrands)
+	assert.Error(t, err)
+}
+
+func TestFindReverseStrand(t *testing.T) {
+	fwd, err := sequence.New("GG" + "ATGAAACCCGGGTTTTAG" + "GG")
+	require.NoError(t, err)
+	rc, err := fwd.ReverseComplement()
+	require.NoError(t, err)
+
+	orfs, err := Find(rc, 5, sequence.BothStrands)
+	require.NoError(t, err)
+	require.Len(t, orfs, 1)
+	assert.Equal(t, sequence.Reverse, orfs[0].Strand)
+	assert.Equal(t, 2, orfs[0].Start)
+	assert.Equal(t, 20, orfs[0].End)
+	assert.Equal(t, "MKPGF", orfs[0].Protein)
+
+	reverse, err := Find(rc, 5, sequence.Reverse)
+	require.NoError(t, err)
+	assert.Equal(t, orfs, reverse)
+	forward, err := Find(rc, 5, sequence.Forward)
+	require.NoError(t, err)
+	assert.Empty(t, forward)
+
+	encoded, err := json.Marshal(orfs[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"strand":"-"`)
+}
+
+func TestFindIncomplete(t *testing.T) {
+	seq, err := sequence.New("ATGAAACCCGGGTTT")
+	require.NoError(t, err)
+	orfs, err := Find(seq, 5, sequence.BothStrands)
+	require.NoError(t, err)
+	require.Len(t, orfs, 1)
+	assert.False(t, orfs[0].Complete)
+	assert.Equal(t, 15, orfs[0].End)
+}
+
+func TestFindFrameshifted(t *testing.T) {
+	coding := codingSequence(300, 7)
+	// Insert a single extra base in the middle of the gene.
+	ins := 450
+	mutated := coding[:ins] + "A" + coding[ins:]
+	seq, err := sequence.New(mutated)
+	require.NoError(t, err)
+
+	// Classic ORF calling truncates the gene at the frameshift.
+	plain, err := Find(seq, 250, sequence.BothStrands)
+	require.NoError(t, err)
+	assert.Empty(t, plain)
+
+	orfs, err := FindFrameshifted(seq, FrameshiftOptions{MinLength: 250})
+	require.NoError(t, err)
+	require.NotEmpty(t, orfs)
+
+	o := orfs[0]
+	assert.Equal(t, 0, o.Start)
+	assert.Equal(t, len(mutated), o.End)
+	require.Len(t, o.Shifts, 1)
+	assert.Equal(t, Insertion, o.Shifts[0].Kind)
+	assert.InDelta(t, ins, o.Shifts[0].Position, 120)
+	assert.Equal(t, 0, len(o.CorrectedDNA)%3)
+	assert.Len(t, o.Protein, 301)
+}
+
+func TestFindFrameshiftedDeletion(t *testing.T) {
+	coding := codingSequence(300, 11)
+	del := 451
+	mutated := coding[:del] + coding[del+1:]
+	seq, err := sequence.New(mutated)
+	require.NoError(t, err)
+	rc, err := seq.ReverseComplement()
+	require.NoError(t, err)
+
+	orfs, err := FindFrameshifted(rc, FrameshiftOptions{MinLength: 250})
+	require.NoError(t, err)
+	require.NotEmpty(t, orfs)
+
+	o := orfs[0]
+	assert.Equal(t, sequence.Reverse, o.Strand)
+	require.Len(t, o.Shifts, 1)
+	assert.Equal(t, Deletion, o.Shifts[0].Kind)
+	assert.Contains(t, o.Protein, "X")
+	assert.Equal(t, 0, len(o.CorrectedDNA)%3)
+
+	forward, err := FindFrameshifted(rc, FrameshiftOptions{MinLength: 250, Strand: sequence.Forward})
+	require.NoError(t, err)
+	for _, f := range forward {
+		assert.Equal(t, sequence.Forward, f.Strand)
+	}
+}
+
+func TestAlignToReference(t *testing.T) {
+	coding := codingSequence(200, 3)
+	refSeq, err := sequence.New(coding)
+	require.NoError(t, err)
+	ref, err := protein.Translate(refSeq, 0, true)
+	require.NoError(t, err)
+
+	ins := 301
+	mutated := "GGCC" + coding[:ins] + "T" + coding[ins:] + "GGCC"
+	seq, err := sequence.New(mutated)
+	require.NoError(t, err)
+
+	hit, err := AlignToReference(seq, ref, DefaultReferenceOptions())
+	require.NoError(t, err)
+	assert.Equal(t, sequence.Forward, hit.Strand)
+	require.Len(t, hit.Shifts, 1)
+	assert.Equal(t, Insertion, hit.Shifts[0].Kind)
+	assert.InDelta(t, ins+4, hit.Shifts[0].Position, 6)
+	assert.Greater(t, hit.Identity, 0.95)
+	assert.Equal(t, 0, hit.RefStart)
+	assert.Equal(t, len(ref.Residues), hit.RefEnd)
+
+	empty, err := protein.New("*")
+	require.NoError(t, err)
+	_, err = AlignToReference(seq, empty, DefaultReferenceOptions())
+	assert.Error(t, err)
+}
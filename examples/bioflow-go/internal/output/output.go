@@ -0,0 +1,293 @@
+// Package output writes result files safely: data goes to a temporary
+// file next to the destination, which replaces the destination only when
+// the file is closed successfully. A crash or an error part way through
+// leaves any earlier file at the destination untouched, never a partial
+// one.
+//
+// Output can be compressed with any codec of package compress, chosen
+// explicitly or from the file name (.gz, .zst), at a given level, and
+// synced to disk before it is renamed into place. Files can be recorded,
+// with their size and digest, in a provenance manifest.
+//
+// Comparison with Aria:
+//
+//	Aria would make the commit explicit in the type, so that an output
+//	cannot be forgotten half-written:
+//	  fn create(path: Path) -> Output with FileSystem
+//	  fn commit(self: own Output) -> Result<(), IoError>
+//
+//	Go relies on callers to Close (commit) or Abort every File, and
+//	AbortAll discards those left pending when a program exits early.
+package output
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aria-lang/bioflow-go/internal/compress"
+	"github.com/aria-lang/bioflow-go/internal/manifest"
+)
+
+// Compression names understood by Options besides codec names.
+const (
+	Auto = "auto"
+	None = compress.None
+)
+
+// Options configures a File. The zero value compresses by file name,
+// at the default level, without syncing.
+type Options struct {
+	// Compression is a codec name, None, or Auto (or empty) to choose
+	// from the file name. Standard output is only compressed when a
+	// codec is named.
+	Compression string
+	// Level is the compression level; zero means the codec's default.
+	Level int
+	// Sync flushes the file, and the rename, to stable storage before
+	// Close returns.
+	Sync bool
+	// Manifest, if set, records each file when it is committed.
+	// Standard output is not recorded.
+	Manifest *manifest.Manifest
+}
+
+// File is an output being written. Close commits it and Abort discards
+// it. Abort after Close does nothing, which makes
+//
+//	f, err := output.Create(path, opts)
+//	...
+//	defer f.Abort()
+//	...
+//	return f.Close()
+//
+// the usual pattern.
+type File struct {
+	path    string
+	opts    Options
+	file    *os.File // temporary file; nil for standard output
+	buf     *bufio.Writer
+	codec   io.WriteCloser
+	w       io.Writer
+	digest  *manifest.Digest
+	records *int64
+	done    bool
+	ok      bool
+}
+
+// pending holds the files neither closed nor aborted.
+var pending = struct {
+	sync.Mutex
+	files map[*File]struct{}
+}{files: make(map[*File]struct{})}
+
+// Create starts writing path. An empty path or "-" writes to standard
+// output, which is never renamed or closed.
+//
+// Aria equivalent:
+//
+//	fn create(path: Path, options: Options) -> Result<File, OutputError> with FileSystem
+func Create(path string, opts Options) (*File, error) {
+	compression := strings.ToLower(opts.Compression)
+	stdout := path == "" || path == "-"
+	if compression == "" || compression == Auto {
+		compression = None
+		if !stdout {
+			compression = compress.ForPath(path)
+		}
+	}
+	codec, err := compress.Lookup(compression)
+	if err != nil {
+		return nil, err
+	}
+	if codec == nil && opts.Level != 0 {
+		return nil, errors.New("a compression level needs compression")
+	}
+
+	f := &File{path: path, opts: opts}
+	var dst io.Writer = os.Stdout
+	if !stdout {
+		f.file, err = os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+		if err != nil {
+			return nil, fmt.Errorf("creating file: %w", err)
+		}
+		if err := f.file.Chmod(0o644); err != nil {
+			f.discard()
+			return nil, fmt.Errorf("creating file: %w", err)
+		}
+		dst = f.file
+		if opts.Manifest != nil {
+			f.digest = manifest.NewDigest()
+			dst = io.MultiWriter(f.file, f.digest)
+		}
+	}
+	f.buf = bufio.NewWriterSize(dst, 64*1024)
+	f.w = f.buf
+	if codec != nil {
+		if f.codec, err = codec.NewWriter(f.buf, opts.Level); err != nil {
+			f.discard()
+			return nil, err
+		}
+		f.w = f.codec
+	}
+
+	if f.file != nil {
+		pending.Lock()
+		pending.files[f] = struct{}{}
+		pending.Unlock()
+	}
+	return f, nil
+}
+
+// Path returns the destination path, empty for standard output.
+func (f *File) Path() string {
+	if f.file == nil {
+		return ""
+	}
+	return f.path
+}
+
+// Write writes (and compresses) p.
+func (f *File) Write(p []byte) (int, error) {
+	if f.done {
+		return 0, os.ErrClosed
+	}
+	return f.w.Write(p)
+}
+
+// AddRecords adds n to the number of records recorded in the manifest
+// for the file.
+func (f *File) AddRecords(n int) {
+	if f.records == nil {
+		f.records = new(int64)
+	}
+	*f.records += int64(n)
+}
+
+// Close finishes the output and, for files, replaces the destination with
+// it. If Close fails the destination is left as it was.
+func (f *File) Close() error {
+	if f.done {
+		if f.ok {
+			return nil
+		}
+		return os.ErrClosed
+	}
+	err := f.finish()
+	if err != nil {
+		f.discard()
+		return err
+	}
+	f.done, f.ok = true, true
+	f.forget()
+	if f.digest != nil {
+		f.opts.Manifest.Add(manifest.Entry{Path: f.path, Size: f.digest.Size(), SHA256: f.digest.Sum(), Records: f.records})
+	}
+	return nil
+}
+
+// finish flushes the output and commits a file.
+func (f *File) finish() error {
+	if f.codec != nil {
+		if err := f.codec.Close(); err != nil {
+			return fmt.Errorf("compressing output: %w", err)
+		}
+	}
+	if err := f.buf.Flush(); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	if f.file == nil {
+		if f.opts.Sync {
+			// Syncing a pipe or terminal fails harmlessly.
+			_ = os.Stdout.Sync()
+		}
+		return nil
+	}
+	if f.opts.Sync {
+		if err := f.file.Sync(); err != nil {
+			return fmt.Errorf("syncing file: %w", err)
+		}
+	}
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("closing file: %w", err)
+	}
+	if err := os.Rename(f.file.Name(), f.path); err != nil {
+		return fmt.Errorf("replacing file: %w", err)
+	}
+	if f.opts.Sync {
+		return syncDir(filepath.Dir(f.path))
+	}
+	return nil
+}
+
+// syncDir makes a rename in dir durable. Some platforms cannot sync
+// directories; that is not an error.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return nil
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil && !errors.Is(err, os.ErrInvalid) {
+		return fmt.Errorf("syncing directory: %w", err)
+	}
+	return nil
+}
+
+// Abort discards the output, leaving the destination as it was. It does
+// nothing once the file has been closed.
+func (f *File) Abort() {
+	if f.done {
+		return
+	}
+	f.discard()
+}
+
+// discard removes the temporary file.
+func (f *File) discard() {
+	f.done = true
+	if f.file != nil {
+		f.file.Close()
+		os.Remove(f.file.Name())
+	}
+	f.forget()
+}
+
+// forget removes the file from the pending set.
+func (f *File) forget() {
+	pending.Lock()
+	delete(pending.files, f)
+	pending.Unlock()
+}
+
+// AbortAll discards every file neither closed nor aborted, as a program
+// should before exiting on an error.
+func AbortAll() {
+	pending.Lock()
+	files := make([]*File, 0, len(pending.files))
+	for f := range pending.files {
+		files = append(files, f)
+	}
+	pending.Unlock()
+	for _, f := range files {
+		f.Abort()
+	}
+}
+
+// WriteFile writes data to path as Create and Close would.
+func WriteFile(path string, data []byte, opts Options) error {
+	f, err := Create(path, opts)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	return f.Close()
+}
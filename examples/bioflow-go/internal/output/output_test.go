@@ -0,0 +1,114 @@
+package output
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/compress"
+	"github.com/aria-lang/bioflow-go/internal/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.fa")
+	require.NoError(t, os.WriteFile(path, []byte("old\n"), 0o644))
+
+	f, err := Create(path, Options{Sync: true})
+	require.NoError(t, err)
+	_, err = f.Write([]byte(">s\nACGT\n"))
+	require.NoError(t, err)
+	data, _ := os.ReadFile(path)
+	assert.Equal(t, "old\n", string(data), "not replaced before Close")
+	require.NoError(t, f.Close())
+	f.Abort()
+	data, _ = os.ReadFile(path)
+	assert.Equal(t, ">s\nACGT\n", string(data))
+	assert.NoError(t, f.Close(), "closing twice")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+
+	// An aborted write leaves the old file and no temporary file.
+	f, err = Create(path, Options{})
+	require.NoError(t, err)
+	f.Write([]byte("partial"))
+	f.Abort()
+	assert.ErrorIs(t, f.Close(), os.ErrClosed)
+	data, _ = os.ReadFile(path)
+	assert.Equal(t, ">s\nACGT\n", string(data))
+
+	// So does exiting early.
+	f, err = Create(filepath.Join(dir, "new.fa"), Options{})
+	require.NoError(t, err)
+	AbortAll()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "out.fa", entries[0].Name())
+
+	_, err = Create(filepath.Join(dir, "missing", "x.fa"), Options{})
+	assert.Error(t, err)
+}
+
+func TestCompression(t *testing.T) {
+	dir := t.TempDir()
+	for name, opts := range map[string]Options{
+		"reads.fq.gz":  {},
+		"reads.fq.zst": {Level: 19},
+		"reads.fq":     {Compression: "gzip", Level: 9},
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, WriteFile(path, []byte("@r\nACGT\n+\nIIII\n"), opts))
+		file, err := compress.Open(path)
+		require.NoError(t, err, name)
+		assert.NotEqual(t, None, file.Codec(), name)
+		data, _ := io.ReadAll(file)
+		file.Close()
+		assert.Equal(t, "@r\nACGT\n+\nIIII\n", string(data), name)
+	}
+
+	for name, opts := range map[string]Options{
+		"unknown codec":       {Compression: "lz4"},
+		"level out of range":  {Compression: "gzip", Level: 10},
+		"level without codec": {Level: 3},
+	} {
+		_, err := Create(filepath.Join(dir, "x.txt"), opts)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestManifest(t *testing.T) {
+	dir := t.TempDir()
+	m := manifest.New("bioflow", "test")
+	opts := Options{Manifest: m}
+
+	path := filepath.Join(dir, "out.fa.gz")
+	f, err := Create(path, opts)
+	require.NoError(t, err)
+	f.Write([]byte(">a\nACGT\n>b\nGGCC\n"))
+	f.AddRecords(2)
+	require.NoError(t, f.Close())
+
+	// Aborted files and standard output are not recorded.
+	f, err = Create(filepath.Join(dir, "aborted.fa"), opts)
+	require.NoError(t, err)
+	f.Abort()
+	f, err = Create("", opts)
+	require.NoError(t, err)
+	f.Close()
+
+	require.Len(t, m.Files, 1)
+	size, sum, err := manifest.HashFile(path)
+	require.NoError(t, err)
+	entry := m.Files[0]
+	assert.Equal(t, path, entry.Path)
+	assert.Equal(t, size, entry.Size, "size of the compressed file")
+	assert.Equal(t, sum, entry.SHA256)
+	require.NotNil(t, entry.Records)
+	assert.Equal(t, int64(2), *entry.Records)
+}
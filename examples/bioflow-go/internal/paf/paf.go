@@ -0,0 +1,242 @@
+// Package paf reads and writes the Pairwise mApping Format (PAF).
+//
+// PAF is the tab-separated format produced by minimap2 and understood by
+// most long-read and whole-genome alignment tooling. Each line holds
+// twelve mandatory columns (query and target names, lengths and
+// coordinates, strand, residue matches, block length and mapping quality)
+// followed by optional SAM-style TYPE:VALUE tags.
+//
+// Comparison with Aria:
+//
+//	Aria states the coordinate invariants on the record:
+//	  struct Record
+//	    invariant 0 <= self.query_start <= self.query_end <= self.query_len
+//	    invariant 0 <= self.target_start <= self.target_end <= self.target_len
+//
+//	Go checks them when parsing and when writing.
+package paf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/kmer"
+)
+
+// MissingMapQ is the mapping quality used when none is available.
+const MissingMapQ = 255
+
+// Record is one PAF line. Coordinates are 0-based, half-open.
+type Record struct {
+	QueryName   string   `json:"query_name"`
+	QueryLen    int      `json:"query_len"`
+	QueryStart  int      `json:"query_start"`
+	QueryEnd    int      `json:"query_end"`
+	Strand      byte     `json:"-"`
+	TargetName  string   `json:"target_name"`
+	TargetLen   int      `json:"target_len"`
+	TargetStart int      `json:"target_start"`
+	TargetEnd   int      `json:"target_end"`
+	Matches     int      `json:"matches"`
+	BlockLen    int      `json:"block_len"`
+	MapQ        int      `json:"mapq"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// Validate checks the record's coordinates and strand.
+func (r *Record) Validate() error {
+	if r.QueryName == "" || r.TargetName == "" {
+		return fmt.Errorf("query and target names are required")
+	}
+	if r.Strand != '+' && r.Strand != '-' {
+		return fmt.Errorf("strand must be '+' or '-'")
+	}
+	if r.QueryStart < 0 || r.QueryStart > r.QueryEnd || r.QueryEnd > r.QueryLen {
+		return fmt.Errorf("query interval %d-%d outside 0-%d", r.QueryStart, r.QueryEnd, r.QueryLen)
+	}
+	if r.TargetStart < 0 || r.TargetStart > r.TargetEnd || r.TargetEnd > r.TargetLen {
+		return fmt.Errorf("target interval %d-%d outside 0-%d", r.TargetStart, r.TargetEnd, r.TargetLen)
+	}
+	if r.Matches < 0 || r.BlockLen < 0 || r.MapQ < 0 || r.MapQ > 255 {
+		return fmt.Errorf("matches, block length and mapping quality must be in range")
+	}
+	return nil
+}
+
+// Identity returns the fraction of residue matches in the alignment block.
+func (r *Record) Identity() float64 {
+	if r.BlockLen == 0 {
+		return 0
+	}
+	return float64(r.Matches) / float64(r.BlockLen)
+}
+
+// Tag returns the value of the optional tag with the given two-letter
+// name (e.g. "tp"), without its type, and whether it was present.
+func (r *Record) Tag(name string) (string, bool) {
+	for _, t := range r.Tags {
+		if len(t) >= 5 && t[:2] == name && t[2] == ':' && t[4] == ':' {
+			return t[5:], true
+		}
+	}
+	return "", false
+}
+
+// String formats the record as a PAF line without the trailing newline.
+func (r *Record) String() string {
+	fields := []string{
+		r.QueryName,
+		strconv.Itoa(r.QueryLen),
+		strconv.Itoa(r.QueryStart),
+		strconv.Itoa(r.QueryEnd),
+		string(r.Strand),
+		r.TargetName,
+		strconv.Itoa(r.TargetLen),
+		strconv.Itoa(r.TargetStart),
+		strconv.Itoa(r.TargetEnd),
+		strconv.Itoa(r.Matches),
+		strconv.Itoa(r.BlockLen),
+		strconv.Itoa(r.MapQ),
+	}
+	return strings.Join(append(fields, r.Tags...), "\t")
+}
+
+// Parse parses one PAF line.
+//
+// Aria equivalent:
+//
+//	fn parse(line: String) -> Result<Record, PafError>
+//	  ensures result.is_ok() implies result.unwrap().validate().is_ok()
+func Parse(line string) (*Record, error) {
+	fields := strings.Split(strings.TrimRight(line, "\r\n"), "\t")
+	if len(fields) < 12 {
+		return nil, fmt.Errorf("expected at least 12 columns, found %d", len(fields))
+	}
+
+	ints := make([]int, 0, 9)
+	for _, i := range []int{1, 2, 3, 6, 7, 8, 9, 10, 11} {
+		v, err := strconv.Atoi(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("column %d: invalid integer %q", i+1, fields[i])
+		}
+		ints = append(ints, v)
+	}
+	if len(fields[4]) != 1 {
+		return nil, fmt.Errorf("column 5: invalid strand %q", fields[4])
+	}
+
+	r := &Record{
+		QueryName:   fields[0],
+		QueryLen:    ints[0],
+		QueryStart:  ints[1],
+		QueryEnd:    ints[2],
+		Strand:      fields[4][0],
+		TargetName:  fields[5],
+		TargetLen:   ints[3],
+		TargetStart: ints[4],
+		TargetEnd:   ints[5],
+		Matches:     ints[6],
+		BlockLen:    ints[7],
+		MapQ:        ints[8],
+	}
+	if len(fields) > 12 {
+		r.Tags = fields[12:]
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Read parses all records of a PAF stream. Blank lines are skipped.
+func Read(r io.Reader) ([]*Record, error) {
+	records := make([]*Record, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		rec, err := Parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading PAF: %w", err)
+	}
+	return records, nil
+}
+
+// Write writes records as PAF lines.
+func Write(w io.Writer, records []*Record) error {
+	bw := bufio.NewWriter(w)
+	for _, r := range records {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("record %s: %w", r.QueryName, err)
+		}
+		if _, err := bw.WriteString(r.String() + "\n"); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// FromChain converts an anchor chain between a query (seq1) and a target
+// (seq2) into an approximate PAF record, in the way minimap2 reports
+// chains without base-level alignment: residue matches are the bases
+// covered by anchors on the query, the block length is the longer of the
+// two spans, and the mapping quality is unknown. The record is tagged
+// tp:A:P, cm:i:<anchors> and s1:i:<chain score>.
+//
+// Aria equivalent:
+//
+//	fn from_chain(query: Name, query_len: Int, target: Name, target_len: Int, chain: Chain, k: Int) -> Record
+//	  requires chain.anchors.len() > 0
+func FromChain(queryName string, queryLen int, targetName string, targetLen int, chain kmer.Chain, k int) *Record {
+	covered, end := 0, -1
+	for _, a := range chain.Anchors {
+		start := a.Pos1
+		if start < end {
+			start = end
+		}
+		if a.Pos1+k > start {
+			covered += a.Pos1 + k - start
+		}
+		if a.Pos1+k > end {
+			end = a.Pos1 + k
+		}
+	}
+
+	block := chain.End1 - chain.Start1
+	if span := chain.End2 - chain.Start2; span > block {
+		block = span
+	}
+
+	return &Record{
+		QueryName:   queryName,
+		QueryLen:    queryLen,
+		QueryStart:  chain.Start1,
+		QueryEnd:    chain.End1,
+		Strand:      chain.Strand,
+		TargetName:  targetName,
+		TargetLen:   targetLen,
+		TargetStart: chain.Start2,
+		TargetEnd:   chain.End2,
+		Matches:     covered,
+		BlockLen:    block,
+		MapQ:        MissingMapQ,
+		Tags: []string{
+			"tp:A:P",
+			fmt.Sprintf("cm:i:%d", len(chain.Anchors)),
+			fmt.Sprintf("s1:i:%d", int(chain.Score)),
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package paf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/kmer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const example = "read1\t1000\t10\t990\t+\tchr1\t50000\t2000\t2985\t950\t985\t60\ttp:A:P\tcm:i:87\n" +
+	"read2\t500\t0\t500\t-\tchr2\t80000\t100\t600\t480\t500\t12\n"
+
+func TestReadWrite(t *testing.T) {
+	records, err := Read(strings.NewReader(example))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	r := records[0]
+	assert.Equal(t, "read1", r.QueryName)
+	assert.Equal(t, byte('+'), r.Strand)
+	assert.Equal(t, 2985, r.TargetEnd)
+	assert.Equal(t, 60, r.MapQ)
+	assert.InDelta(t, 950.0/985.0, r.Identity(), 1e-9)
+	v, ok := r.Tag("cm")
+	assert.True(t, ok)
+	assert.Equal(t, "87", v)
+	_, ok = r.Tag("NM")
+	assert.False(t, ok)
+	assert.Nil(t, records[1].Tags)
+
+	var buf strings.Builder
+	require.NoError(t, Write(&buf, records))
+	assert.Equal(t, example, buf.String())
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, line := range []string{
+		"read1\t1000\t10\t990\t+\tchr1",
+		"read1\t1000\t10\t990\t*\tchr1\t50000\t2000\t2985\t950\t985\t60",
+		"read1\t1000\t10\t1990\t+\tchr1\t50000\t2000\t2985\t950\t985\t60",
+		"read1\tten\t10\t990\t+\tchr1\t50000\t2000\t2985\t950\t985\t60",
+		"read1\t1000\t10\t990\t+\tchr1\t50000\t2000\t2985\t950\t985\t300",
+	} {
+		_, err := Parse(line)
+		assert.Error(t, err, line)
+	}
+
+	_, err := Read(strings.NewReader(example + "bad line\n"))
+	assert.ErrorContains(t, err, "line 3")
+}
+
+func TestFromChain(t *testing.T) {
+	chain := kmer.Chain{
+		Anchors: []kmer.Anchor{{Pos1: 0, Pos2: 100, Strand: '+'}, {Pos1: 5, Pos2: 105, Strand: '+'}, {Pos1: 20, Pos2: 122, Strand: '+'}},
+		Strand:  '+',
+		Score:   27.5,
+		Start1:  0,
+		End1:    30,
+		Start2:  100,
+		End2:    132,
+	}
+	r := FromChain("q", 40, "t", 200, chain, 10)
+	require.NoError(t, r.Validate())
+	assert.Equal(t, 25, r.Matches)
+	assert.Equal(t, 32, r.BlockLen)
+	assert.Equal(t, MissingMapQ, r.MapQ)
+	assert.Equal(t, "q\t40\t0\t30\t+\tt\t200\t100\t132\t25\t32\t255\ttp:A:P\tcm:i:3\ts1:i:27", r.String())
+}
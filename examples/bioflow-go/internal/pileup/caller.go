@@ -0,0 +1,134 @@
+package pileup
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aria-lang/bioflow-go/internal/variant"
+)
+
+// CallOptions holds the thresholds a candidate allele must pass.
+type CallOptions struct {
+	// MinDepth is the minimum depth at the position.
+	MinDepth int
+	// MinAltReads is the minimum number of reads supporting the allele.
+	MinAltReads int
+	// MinAlleleFraction is the minimum fraction of the depth supporting
+	// the allele.
+	MinAlleleFraction float64
+	// MinMeanQuality is the minimum mean base quality of an SNV allele.
+	MinMeanQuality float64
+}
+
+// DefaultCallOptions returns thresholds suited to germline calling from
+// moderate-depth data.
+func DefaultCallOptions() CallOptions {
+	return CallOptions{MinDepth: 8, MinAltReads: 2, MinAlleleFraction: 0.2, MinMeanQuality: 20}
+}
+
+// Call is a variant called from the pileup with its support.
+type Call struct {
+	variant.Variant
+	Depth          int     `json:"depth"`
+	AltReads       int     `json:"alt_reads"`
+	AlleleFraction float64 `json:"allele_fraction"`
+	// MeanQuality is the mean base quality of SNV alleles (0 for indels).
+	MeanQuality float64 `json:"mean_quality"`
+	// ForwardReads is the number of supporting forward-strand reads (SNVs
+	// only).
+	ForwardReads int `json:"forward_reads"`
+}
+
+// Call emits the SNVs and small indels of a chromosome that pass the
+// thresholds, in position order. Positions whose reference base is
+// unknown (N) are skipped, as are indels at the first base.
+//
+// Aria equivalent:
+//
+//	fn call(self, chrom: String, options: CallOptions) -> [Call]
+//	  ensures result.all(|c| c.depth >= options.min_depth and c.alt_reads >= options.min_alt_reads)
+//	  ensures result.all(|c| c.allele_fraction >= options.min_allele_fraction)
+func (p *Pileup) Call(chrom string, opts CallOptions) []Call {
+	calls := make([]Call, 0)
+	for _, c := range p.Columns(chrom) {
+		depth := c.Depth()
+		if depth == 0 || depth < opts.MinDepth || c.RefBase == 'N' {
+			continue
+		}
+		passes := func(n int) bool {
+			return n >= opts.MinAltReads && float64(n)/float64(depth) >= opts.MinAlleleFraction
+		}
+		ref := string(c.RefBase)
+
+		for i := 0; i < 4; i++ {
+			n := c.Counts[i]
+			if Bases[i] == c.RefBase || !passes(n) || c.MeanQuality(Bases[i]) < opts.MinMeanQuality {
+				continue
+			}
+			calls = append(calls, Call{
+				Variant:        variant.Variant{Chrom: chrom, Pos: c.Pos, Ref: ref, Alt: Bases[i : i+1]},
+				Depth:          depth,
+				AltReads:       n,
+				AlleleFraction: float64(n) / float64(depth),
+				MeanQuality:    c.MeanQuality(Bases[i]),
+				ForwardReads:   c.Forward[i],
+			})
+		}
+		for _, ins := range sortedAlleles(c.Insertions) {
+			if n := c.Insertions[ins]; passes(n) {
+				calls = append(calls, Call{
+					Variant:        variant.Variant{Chrom: chrom, Pos: c.Pos, Ref: ref, Alt: ref + ins},
+					Depth:          depth,
+					AltReads:       n,
+					AlleleFraction: float64(n) / float64(depth),
+				})
+			}
+		}
+		for _, del := range sortedAlleles(c.Deletions) {
+			if n := c.Deletions[del]; passes(n) {
+				calls = append(calls, Call{
+					Variant:        variant.Variant{Chrom: chrom, Pos: c.Pos, Ref: ref + del, Alt: ref},
+					Depth:          depth,
+					AltReads:       n,
+					AlleleFraction: float64(n) / float64(depth),
+				})
+			}
+		}
+	}
+	return calls
+}
+
+// sortedAlleles returns the keys of an allele count map in order.
+func sortedAlleles(counts map[string]int) []string {
+	alleles := make([]string, 0, len(counts))
+	for a := range counts {
+		alleles = append(alleles, a)
+	}
+	sort.Strings(alleles)
+	return alleles
+}
+
+// Record converts a call into a VCF record with depth, support and allele
+// fraction annotations in INFO.
+func (c Call) Record() *variant.Record {
+	rec := variant.FromVariant(c.Variant)
+	rec.Info = fmt.Sprintf("DP=%d;AO=%d;AF=%.3f", c.Depth, c.AltReads, c.AlleleFraction)
+	if c.MeanQuality > 0 {
+		rec.Info += fmt.Sprintf(";MQB=%.1f;SAF=%d;SAR=%d", c.MeanQuality, c.ForwardReads, c.AltReads-c.ForwardReads)
+	}
+	return rec
+}
+
+// CallMeta returns the VCF header lines describing the INFO fields
+// written by Call.Record.
+func CallMeta() []string {
+	return []string{
+		"##fileformat=VCFv4.2",
+		`##INFO=<ID=DP,Number=1,Type=Integer,Description="Read depth at the position">`,
+		`##INFO=<ID=AO,Number=1,Type=Integer,Description="Reads supporting the alternate allele">`,
+		`##INFO=<ID=AF,Number=1,Type=Float,Description="Alternate allele fraction">`,
+		`##INFO=<ID=MQB,Number=1,Type=Float,Description="Mean base quality of the alternate allele">`,
+		`##INFO=<ID=SAF,Number=1,Type=Integer,Description="Alternate reads on the forward strand">`,
+		`##INFO=<ID=SAR,Number=1,Type=Integer,Description="Alternate reads on the reverse strand">`,
+	}
+}
@@ -0,0 +1,391 @@
+// Package pileup stacks mapped reads per reference position and calls
+// simple variants and consensus sequences from the stacks.
+//
+// Each Column counts the bases (and their summed qualities) observed at
+// one reference position, the reads whose deletion spans it, and the
+// insertion and deletion alleles that start right after it. The caller in
+// this package applies depth, allele-fraction and quality thresholds to
+// those counts, in the spirit of a basic "bcftools mpileup | call" or
+// VarScan run.
+//
+// Comparison with Aria:
+//
+//	Aria states the count invariants on the column:
+//	  struct Column
+//	    invariant self.depth() == self.counts.sum() + self.deleted
+//	    invariant self.qual_sums.all(|q| q >= 0)
+//
+//	Go maintains them in Add and checks nothing at runtime.
+package pileup
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/alignment"
+	"github.com/aria-lang/bioflow-go/internal/sam"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/aria-lang/bioflow-go/internal/track"
+)
+
+// Bases are the alleles counted per column, in count-array order.
+const Bases = "ACGTN"
+
+// DefaultQuality is assumed for bases of reads without qualities.
+const DefaultQuality = 20
+
+// baseIndex returns the index of a base in Bases (N for anything else).
+func baseIndex(b byte) int {
+	switch b {
+	case 'A', 'a':
+		return 0
+	case 'C', 'c':
+		return 1
+	case 'G', 'g':
+		return 2
+	case 'T', 't', 'U', 'u':
+		return 3
+	default:
+		return 4
+	}
+}
+
+// Column is the pileup at one reference position.
+type Column struct {
+	Chrom string `json:"chrom"`
+	// Pos is the 1-based reference position.
+	Pos     int    `json:"pos"`
+	RefBase byte   `json:"-"`
+	Counts  [5]int `json:"counts"`
+	// QualSums holds the summed base qualities per allele in Counts.
+	QualSums [5]int `json:"qual_sums"`
+	// Deleted counts reads with a deletion covering this position.
+	Deleted int `json:"deleted"`
+	// Insertions counts inserted sequences following this position.
+	Insertions map[string]int `json:"insertions,omitempty"`
+	// Deletions counts deleted reference sequences following this
+	// position.
+	Deletions map[string]int `json:"deletions,omitempty"`
+	// Forward counts the observations in Counts from forward-strand reads.
+	Forward [5]int `json:"forward"`
+}
+
+// Depth returns the number of reads covering the position, including
+// reads with a deletion here.
+func (c *Column) Depth() int {
+	d := c.Deleted
+	for _, n := range c.Counts {
+		d += n
+	}
+	return d
+}
+
+// BaseCount returns the count of a base.
+func (c *Column) BaseCount(b byte) int {
+	return c.Counts[baseIndex(b)]
+}
+
+// MeanQuality returns the mean quality of the observations of a base.
+func (c *Column) MeanQuality(b byte) float64 {
+	i := baseIndex(b)
+	if c.Counts[i] == 0 {
+		return 0
+	}
+	return float64(c.QualSums[i]) / float64(c.Counts[i])
+}
+
+// Options filters the reads and bases that enter the pileup.
+type Options struct {
+	// MinMapQ skips reads with lower mapping quality.
+	MinMapQ int
+	// MinBaseQ skips bases with lower base quality.
+	MinBaseQ int
+	// KeepDuplicates includes reads flagged as duplicates.
+	KeepDuplicates bool
+	// KeepSecondary includes secondary and supplementary alignments.
+	KeepSecondary bool
+	// Strand keeps only reads aligned to the forward or reverse strand,
+	// for strand-specific coverage. Zero or BothStrands keeps all reads.
+	Strand sequence.Strand
+}
+
+// DefaultOptions returns the filters used by samtools mpileup: mapping
+// quality 0 and base quality 13, dropping duplicates and secondary
+// alignments.
+func DefaultOptions() Options {
+	return Options{MinMapQ: 0, MinBaseQ: 13}
+}
+
+// Pileup holds the columns of every covered position, grouped by
+// chromosome.
+type Pileup struct {
+	Options Options
+	// Reads and Skipped count the records used and filtered out.
+	Reads   int
+	Skipped int
+	columns map[string]map[int]*Column
+	refs    map[string]string
+}
+
+// New creates an empty pileup. reference maps chromosome names to their
+// sequences and may be nil; with it, columns record the reference base
+// and deletion alleles are spelled out.
+func New(reference map[string]string, opts Options) *Pileup {
+	return &Pileup{
+		Options: opts,
+		columns: make(map[string]map[int]*Column),
+		refs:    reference,
+	}
+}
+
+// Build creates a pileup from mapped reads.
+//
+// Aria equivalent:
+//
+//	fn build(reference: Map<String, String>, records: [sam.Record], options: Options) -> Result<Pileup, PileupError>
+//	  ensures result.reads + result.skipped == records.len()
+func Build(reference map[string]string, records []*sam.Record, opts Options) (*Pileup, error) {
+	p := New(reference, opts)
+	for _, rec := range records {
+		if err := p.Add(rec); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// column returns the column at a 1-based position, creating it.
+func (p *Pileup) column(chrom string, pos int) *Column {
+	cols, ok := p.columns[chrom]
+	if !ok {
+		cols = make(map[int]*Column)
+		p.columns[chrom] = cols
+	}
+	c, ok := cols[pos]
+	if !ok {
+		c = &Column{Chrom: chrom, Pos: pos, RefBase: 'N'}
+		if ref, ok := p.refs[chrom]; ok && pos <= len(ref) {
+			c.RefBase = strings.ToUpper(ref[pos-1 : pos])[0]
+		}
+		cols[pos] = c
+	}
+	return c
+}
+
+// Add stacks one read onto the pileup. Filtered reads only increment
+// Skipped.
+func (p *Pileup) Add(rec *sam.Record) error {
+	if rec.IsUnmapped() || rec.Seq == "*" || rec.MapQ < p.Options.MinMapQ ||
+		(!p.Options.KeepDuplicates && rec.IsDuplicate()) ||
+		(!p.Options.KeepSecondary && rec.IsSecondary()) || rec.FailsQC() ||
+		!p.Options.Strand.Includes(readStrand(rec)) {
+		p.Skipped++
+		return nil
+	}
+	ops, err := alignment.ParseCIGAR(rec.CIGAR)
+	if err != nil {
+		return fmt.Errorf("%s: %w", rec.QName, err)
+	}
+	quality := func(i int) int {
+		if rec.Qual == "*" {
+			return DefaultQuality
+		}
+		return int(rec.Qual[i]) - 33
+	}
+
+	ref := p.refs[rec.RName]
+	refPos := rec.Pos // 1-based position of the next reference base
+	readPos := 0
+	for _, op := range ops {
+		switch op.Op {
+		case 'M', '=', 'X':
+			if readPos+op.Len > len(rec.Seq) {
+				return fmt.Errorf("%s: CIGAR longer than sequence", rec.QName)
+			}
+			for i := 0; i < op.Len; i++ {
+				if q := quality(readPos + i); q >= p.Options.MinBaseQ {
+					c := p.column(rec.RName, refPos+i)
+					b := baseIndex(rec.Seq[readPos+i])
+					c.Counts[b]++
+					c.QualSums[b] += q
+					if !rec.IsReverse() {
+						c.Forward[b]++
+					}
+				}
+			}
+			refPos += op.Len
+			readPos += op.Len
+		case 'I':
+			if readPos+op.Len > len(rec.Seq) {
+				return fmt.Errorf("%s: CIGAR longer than sequence", rec.QName)
+			}
+			if refPos > 1 {
+				c := p.column(rec.RName, refPos-1)
+				if c.Insertions == nil {
+					c.Insertions = make(map[string]int)
+				}
+				c.Insertions[strings.ToUpper(rec.Seq[readPos:readPos+op.Len])]++
+			}
+			readPos += op.Len
+		case 'D':
+			if refPos > 1 {
+				c := p.column(rec.RName, refPos-1)
+				if c.Deletions == nil {
+					c.Deletions = make(map[string]int)
+				}
+				deleted := strings.Repeat("N", op.Len)
+				if refPos-1+op.Len <= len(ref) {
+					deleted = strings.ToUpper(ref[refPos-1 : refPos-1+op.Len])
+				}
+				c.Deletions[deleted]++
+			}
+			for i := 0; i < op.Len; i++ {
+				p.column(rec.RName, refPos+i).Deleted++
+			}
+			refPos += op.Len
+		case 'N':
+			refPos += op.Len
+		case 'S':
+			readPos += op.Len
+		}
+	}
+	p.Reads++
+	return nil
+}
+
+// Chromosomes returns the names of chromosomes with coverage, sorted.
+func (p *Pileup) Chromosomes() []string {
+	names := make([]string, 0, len(p.columns))
+	for name := range p.columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Columns returns the covered columns of a chromosome in position order.
+func (p *Pileup) Columns(chrom string) []*Column {
+	cols := make([]*Column, 0, len(p.columns[chrom]))
+	for _, c := range p.columns[chrom] {
+		cols = append(cols, c)
+	}
+	sort.Slice(cols, func(i, j int) bool { return cols[i].Pos < cols[j].Pos })
+	return cols
+}
+
+// Column returns the column at a 1-based position, or nil when nothing
+// covers it.
+func (p *Pileup) Column(chrom string, pos int) *Column {
+	return p.columns[chrom][pos]
+}
+
+// Depths returns the per-base depth of a chromosome over length
+// positions; length 0 uses the reference length, or the last covered
+// position when there is no reference.
+func (p *Pileup) Depths(chrom string, length int) []int {
+	if length == 0 {
+		if ref, ok := p.refs[chrom]; ok {
+			length = len(ref)
+		}
+		for pos := range p.columns[chrom] {
+			if pos > length {
+				length = pos
+			}
+		}
+	}
+	depths := make([]int, length)
+	for pos, c := range p.columns[chrom] {
+		if pos <= length {
+			depths[pos-1] = c.Depth()
+		}
+	}
+	return depths
+}
+
+// readStrand returns the strand a read is aligned to.
+func readStrand(rec *sam.Record) sequence.Strand {
+	if rec.IsReverse() {
+		return sequence.Reverse
+	}
+	return sequence.Forward
+}
+
+// CoverageName names the coverage tracks of the pileup: "coverage", or
+// "coverage+" and "coverage-" when it holds the reads of one strand.
+func (p *Pileup) CoverageName() string {
+	switch p.Options.Strand {
+	case sequence.Forward, sequence.Reverse:
+		return "coverage" + p.Options.Strand.String()
+	}
+	return "coverage"
+}
+
+// CoverageTrack returns the per-base depth of a chromosome as a track of
+// runs of equal depth, named by CoverageName.
+func (p *Pileup) CoverageTrack(chrom string) *track.Track {
+	t := track.New(p.CoverageName(), chrom)
+	depths := p.Depths(chrom, 0)
+	for start := 0; start < len(depths); {
+		end := start + 1
+		for end < len(depths) && depths[end] == depths[start] {
+			end++
+		}
+		t.Add(start, end, float64(depths[start]))
+		start = end
+	}
+	return t
+}
+
+// Consensus returns the majority-rule consensus of a chromosome. Positions
+// with depth below minDepth, or where no allele reaches minFraction of the
+// depth, become N; a majority deletion drops the base and a majority
+// insertion is spliced in after it.
+//
+// Aria equivalent:
+//
+//	fn consensus(self, chrom: String, min_depth: Int, min_fraction: Float) -> String
+//	  requires min_depth >= 0
+//	  requires min_fraction >= 0.0 and min_fraction <= 1.0
+func (p *Pileup) Consensus(chrom string, minDepth int, minFraction float64) string {
+	depths := p.Depths(chrom, 0)
+	var sb strings.Builder
+	for pos := 1; pos <= len(depths); pos++ {
+		c := p.Column(chrom, pos)
+		if c == nil || c.Depth() < minDepth || c.Depth() == 0 {
+			sb.WriteByte('N')
+			continue
+		}
+		depth := float64(c.Depth())
+		best, bestCount := 4, 0
+		for i, n := range c.Counts {
+			if n > bestCount {
+				best, bestCount = i, n
+			}
+		}
+		switch {
+		case c.Deleted > bestCount && float64(c.Deleted)/depth >= minFraction:
+			// Deleted in the majority of reads.
+		case float64(bestCount)/depth >= minFraction:
+			sb.WriteByte(Bases[best])
+		default:
+			sb.WriteByte('N')
+		}
+		if ins, n := majorityAllele(c.Insertions); n > 0 && float64(n)/depth > 0.5 {
+			sb.WriteString(ins)
+		}
+	}
+	return sb.String()
+}
+
+// majorityAllele returns the most frequent allele of a count map, breaking
+// ties alphabetically.
+func majorityAllele(counts map[string]int) (string, int) {
+	best, bestCount := "", 0
+	for allele, n := range counts {
+		if n > bestCount || (n == bestCount && allele < best) {
+			best, bestCount = allele, n
+		}
+	}
+	return best, bestCount
+}
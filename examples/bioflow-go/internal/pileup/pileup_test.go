@@ -0,0 +1,137 @@
+package pileup
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/sam"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/aria-lang/bioflow-go/internal/track"
+	"github.com/aria-lang/bioflow-go/internal/variant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRef is the 16 bp reference shared by the pileup tests.
+const testRef = "ACGTACGTAAGGCCTT"
+
+func read(name string, pos int, cigar, seq string, flag int) *sam.Record {
+	return &sam.Record{
+		QName: name, Flag: flag, RName: "chr1", Pos: pos, MapQ: 60,
+		CIGAR: cigar, RNext: "*", Seq: seq, Qual: strings.Repeat("I", len(seq)),
+	}
+}
+
+func TestAddCountsBasesAndIndels(t *testing.T) {
+	p := New(map[string]string{"chr1": testRef}, DefaultOptions())
+	require.NoError(t, p.Add(read("r1", 1, "8M", "ACGTACGT", 0)))
+	require.NoError(t, p.Add(read("r2", 3, "2M2D2M", "GTGT", sam.FlagReverse)))
+	require.NoError(t, p.Add(read("r3", 2, "2M2I2M", "CGTTTA", 0)))
+	assert.Equal(t, 3, p.Reads)
+
+	c := p.Column("chr1", 3)
+	require.NotNil(t, c)
+	assert.Equal(t, byte('G'), c.RefBase)
+	assert.Equal(t, 3, c.BaseCount('G'))
+	assert.Equal(t, 2, c.Forward[2])
+	assert.InDelta(t, 40.0, c.MeanQuality('G'), 1e-9)
+	assert.Equal(t, map[string]int{"TT": 1}, c.Insertions)
+
+	c = p.Column("chr1", 4)
+	assert.Equal(t, map[string]int{"AC": 1}, c.Deletions)
+	assert.Equal(t, 1, p.Column("chr1", 5).Deleted)
+	assert.Equal(t, 3, p.Column("chr1", 5).Depth())
+	assert.Equal(t, []int{1, 2, 3, 3, 3, 2, 2, 2}, p.Depths("chr1", 8))
+	assert.Equal(t, []string{"chr1"}, p.Chromosomes())
+
+	tr := p.CoverageTrack("chr1")
+	require.NotEmpty(t, tr.Points)
+	assert.Equal(t, track.Point{Start: 0, End: 1, Value: 1}, tr.Points[0])
+	assert.Equal(t, track.Point{Start: 1, End: 2, Value: 2}, tr.Points[1])
+}
+
+func TestAddFilters(t *testing.T) {
+	p := New(nil, Options{MinMapQ: 20, MinBaseQ: 30})
+	low := read("low", 1, "4M", "ACGT", 0)
+	low.MapQ = 10
+	dup := read("dup", 1, "4M", "ACGT", sam.FlagDuplicate)
+	unmapped := read("un", 1, "4M", "ACGT", sam.FlagUnmapped)
+	lowQual := read("q", 1, "4M", "ACGT", 0)
+	lowQual.Qual = "II#I"
+
+	for _, r := range []*sam.Record{low, dup, unmapped, lowQual} {
+		require.NoError(t, p.Add(r))
+	}
+	assert.Equal(t, 3, p.Skipped)
+	assert.Equal(t, 1, p.Reads)
+	assert.Nil(t, p.Column("chr1", 3))
+	assert.Equal(t, 1, p.Column("chr1", 4).Depth())
+
+	err := p.Add(read("bad", 1, "10M", "ACGT", 0))
+	assert.Error(t, err)
+}
+
+func TestStrandCoverage(t *testing.T) {
+	records := []*sam.Record{
+		read("f1", 1, "4M", "ACGT", 0),
+		read("f2", 3, "4M", "GTAC", 0),
+		read("r1", 2, "4M", "CGTA", sam.FlagReverse),
+	}
+	all, err := Build(nil, records, DefaultOptions())
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 3, 2, 1}, all.Depths("chr1", 0))
+	assert.Equal(t, "coverage", all.CoverageTrack("chr1").Name)
+
+	opts := DefaultOptions()
+	opts.Strand = sequence.Reverse
+	reverse, err := Build(nil, records, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reverse.Reads)
+	assert.Equal(t, 2, reverse.Skipped)
+	assert.Equal(t, []int{0, 1, 1, 1, 1}, reverse.Depths("chr1", 0))
+	assert.Equal(t, "coverage-", reverse.CoverageTrack("chr1").Name)
+
+	opts.Strand = sequence.Forward
+	forward, err := Build(nil, records, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 1, 2, 2, 1, 1}, forward.Depths("chr1", 0))
+}
+
+func TestConsensusAndCall(t *testing.T) {
+	records := make([]*sam.Record, 0)
+	// Ten reads with an SNV at position 6 (C>T) in half of them, and a
+	// deletion of positions 10-11 (AG) in all of them.
+	for i := 0; i < 10; i++ {
+		seq := "ACGTATGTA" + "GCCTT"
+		if i%2 == 0 {
+			seq = "ACGTACGTA" + "GCCTT"
+		}
+		flag := 0
+		if i%3 == 0 {
+			flag = sam.FlagReverse
+		}
+		records = append(records, read("r", 1, "9M2D5M", seq, flag))
+	}
+	p, err := Build(map[string]string{"chr1": testRef}, records, DefaultOptions())
+	require.NoError(t, err)
+
+	assert.Equal(t, "ACGTANGTAGCCTT", p.Consensus("chr1", 1, 0.6))
+	assert.Equal(t, "ACGTACGTAGCCTT", p.Consensus("chr1", 1, 0.5))
+	assert.Equal(t, strings.Repeat("N", 16), p.Consensus("chr1", 20, 0.5))
+
+	calls := p.Call("chr1", DefaultCallOptions())
+	require.Len(t, calls, 2)
+	assert.Equal(t, variant.Variant{Chrom: "chr1", Pos: 6, Ref: "C", Alt: "T"}, calls[0].Variant)
+	assert.Equal(t, 5, calls[0].AltReads)
+	assert.InDelta(t, 0.5, calls[0].AlleleFraction, 1e-9)
+	assert.Equal(t, variant.Variant{Chrom: "chr1", Pos: 9, Ref: "AAG", Alt: "A"}, calls[1].Variant)
+	assert.Equal(t, 10, calls[1].AltReads)
+
+	rec := calls[0].Record()
+	assert.Equal(t, "chr1\t6\t.\tC\tT\t.\t.\tDP=10;AO=5;AF=0.500;MQB=40.0;SAF=3;SAR=2", rec.String())
+	assert.Equal(t, "DP=10;AO=10;AF=1.000", calls[1].Record().Info)
+
+	strict := DefaultCallOptions()
+	strict.MinAlleleFraction = 0.6
+	assert.Len(t, p.Call("chr1", strict), 1)
+}
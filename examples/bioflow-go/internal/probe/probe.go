@@ -0,0 +1,287 @@
+// Package probe designs hybridization probes: oligos from a target that
+// have no close match anywhere else in the target or in a background set,
+// such as the rest of a genome or transcriptome, and meet GC content and
+// melting temperature constraints.
+//
+// Comparison with Aria:
+//
+//	Aria can state the guarantees of a design as postconditions:
+//	  fn design(targets: [Sequence], background: [Sequence], options: Options) -> [Probe]
+//	    ensures result.all(|p| p.gc >= options.min_gc and p.gc <= options.max_gc)
+//
+//	Go checks each candidate against the options explicitly.
+package probe
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Options configures probe design.
+type Options struct {
+	// Length is the probe length (default 25).
+	Length int
+	// MaxDistance is the largest edit distance at which another site,
+	// on either strand, counts as a match that makes a probe non-unique.
+	// Zero requires only that the probe occurs once.
+	MaxDistance int
+	// MinGC and MaxGC bound the GC fraction; a zero MaxGC means no upper
+	// bound.
+	MinGC float64
+	MaxGC float64
+	// MinTm and MaxTm bound the melting temperature in °C; a zero MaxTm
+	// means no upper bound.
+	MinTm float64
+	MaxTm float64
+	// Conditions are the hybridization conditions for the melting
+	// temperature.
+	Conditions TmConditions
+	// MinSpacing, when positive, keeps only probes starting at least this
+	// many bases after the previous one, chosen from left to right.
+	MinSpacing int
+}
+
+// DefaultOptions returns options for 25-base probes with at most two
+// edits to any other site, 40-60% GC.
+func DefaultOptions() Options {
+	return Options{Length: 25, MaxDistance: 2, MinGC: 0.4, MaxGC: 0.6, Conditions: DefaultTmConditions()}
+}
+
+// Probe is an oligo selected from a target, 0-based and half-open. It
+// hybridizes to the reverse complement of Sequence, so it can be used as
+// is to detect the opposite strand, or reverse-complemented to detect the
+// strand given.
+type Probe struct {
+	SequenceID string  `json:"sequence_id"`
+	Start      int     `json:"start"`
+	End        int     `json:"end"`
+	Sequence   string  `json:"sequence"`
+	GC         float64 `json:"gc"`
+	Tm         float64 `json:"tm"`
+}
+
+// Design selects probes from targets. A candidate must consist of A, C,
+// G and T only, meet the GC and melting temperature bounds, and have no
+// site within MaxDistance edits in the background, in any other target or
+// elsewhere in its own target, on either strand. Sites overlapping the
+// candidate's own position within MaxDistance bases are not counted.
+//
+// By the pigeonhole principle a site within MaxDistance edits shares an
+// exact piece with one of MaxDistance+1 disjoint pieces of the candidate,
+// so only sites seeded by those pieces are aligned.
+//
+// Aria equivalent:
+//
+//	fn design(targets: [Sequence], background: [Sequence], options: Options) -> Result<[Probe], ProbeError>
+//	  requires options.length >= 4 * (options.max_distance + 1)
+//	  ensures result.all(|p| p.end - p.start == options.length)
+func Design(targets, background []*sequence.Sequence, opts Options) ([]Probe, error) {
+	if opts.Length <= 0 {
+		opts.Length = DefaultOptions().Length
+	}
+	if opts.MaxDistance < 0 {
+		return nil, fmt.Errorf("maximum distance must be non-negative")
+	}
+	if opts.Length < 4*(opts.MaxDistance+1) {
+		return nil, fmt.Errorf("probes of %d bases are too short for %d edits", opts.Length, opts.MaxDistance)
+	}
+	if opts.MaxGC > 0 && opts.MaxGC < opts.MinGC {
+		return nil, fmt.Errorf("maximum GC is less than the minimum")
+	}
+	if opts.MaxTm > 0 && opts.MaxTm < opts.MinTm {
+		return nil, fmt.Errorf("maximum Tm is less than the minimum")
+	}
+
+	idx := newIndex(targets, background, opts.Length, opts.MaxDistance)
+	probes := make([]Probe, 0)
+	for t, target := range targets {
+		offset := idx.offsets[t]
+		next := 0
+		for i := 0; i+opts.Length <= target.Len(); i++ {
+			if i < next {
+				continue
+			}
+			oligo := idx.text[offset+i : offset+i+opts.Length]
+			if strings.IndexFunc(oligo, func(r rune) bool { return !strings.ContainsRune("ACGT", r) }) >= 0 {
+				continue
+			}
+			gc := float64(strings.Count(oligo, "G")+strings.Count(oligo, "C")) / float64(len(oligo))
+			if gc < opts.MinGC || (opts.MaxGC > 0 && gc > opts.MaxGC) {
+				continue
+			}
+			tm, err := MeltingTemperature(oligo, opts.Conditions)
+			if err != nil {
+				return nil, err
+			}
+			if tm < opts.MinTm || (opts.MaxTm > 0 && tm > opts.MaxTm) {
+				continue
+			}
+			if !idx.unique(oligo, offset+i) {
+				continue
+			}
+			probes = append(probes, Probe{
+				SequenceID: target.ID,
+				Start:      i,
+				End:        i + opts.Length,
+				Sequence:   oligo,
+				GC:         gc,
+				Tm:         tm,
+			})
+			if opts.MinSpacing > 0 {
+				next = i + opts.MinSpacing
+			}
+		}
+	}
+	return probes, nil
+}
+
+// index finds the sites of the targets and background within a given
+// edit distance of a probe.
+type index struct {
+	// text holds the targets and then the background, upper case, each
+	// followed by enough N that no site spans two sequences.
+	text    string
+	offsets []int
+	length  int
+	dist    int
+	// seed is the length of the pieces seeds are looked up by.
+	seed  int
+	seeds map[uint64][]int32
+}
+
+func newIndex(targets, background []*sequence.Sequence, length, dist int) *index {
+	idx := &index{length: length, dist: dist, seed: min(length/(dist+1), 31)}
+	var b strings.Builder
+	sep := strings.Repeat("N", dist+1)
+	for _, s := range append(append([]*sequence.Sequence(nil), targets...), background...) {
+		idx.offsets = append(idx.offsets, b.Len())
+		b.WriteString(strings.ToUpper(s.Bases))
+		b.WriteString(sep)
+	}
+	idx.text = b.String()
+
+	idx.seeds = make(map[uint64][]int32)
+	eachSeed(idx.text, idx.seed, func(pos int, key uint64) {
+		idx.seeds[key] = append(idx.seeds[key], int32(pos))
+	})
+	return idx
+}
+
+// eachSeed calls fn with the position and 2-bit packed key of every
+// k-length window of s made of A, C, G and T.
+func eachSeed(s string, k int, fn func(pos int, key uint64)) {
+	mask := uint64(1)<<(2*k) - 1
+	var key uint64
+	valid := 0
+	for i := 0; i < len(s); i++ {
+		code := strings.IndexByte("ACGT", s[i])
+		if code < 0 {
+			valid = 0
+			continue
+		}
+		key = (key<<2 | uint64(code)) & mask
+		if valid++; valid >= k {
+			fn(i-k+1, key)
+		}
+	}
+}
+
+// unique reports whether oligo, found at pos in the text, has no other
+// site within the edit distance on either strand.
+func (idx *index) unique(oligo string, pos int) bool {
+	strands := []string{oligo}
+	if rc := reverseComplement(oligo); rc != oligo {
+		strands = append(strands, rc)
+	}
+	for s, strand := range strands {
+		checked := make(map[int]bool)
+		for p := 0; p <= idx.dist; p++ {
+			piece := p * idx.seed
+			key := packSeed(strand[piece : piece+idx.seed])
+			for _, hit := range idx.seeds[key] {
+				start := int(hit) - piece
+				if s == 0 && start >= pos-idx.dist && start <= pos+idx.dist {
+					continue
+				}
+				if checked[start] {
+					continue
+				}
+				checked[start] = true
+				if withinDistance(strand, idx.text, start-idx.dist, start+idx.length+idx.dist, idx.dist) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// packSeed packs an A/C/G/T string into 2 bits per base, as eachSeed
+// does.
+func packSeed(s string) uint64 {
+	var key uint64
+	for i := 0; i < len(s); i++ {
+		key = key<<2 | uint64(strings.IndexByte("ACGT", s[i]))
+	}
+	return key
+}
+
+// withinDistance reports whether pattern matches some substring of
+// text[lo:hi] with at most maxEdits edits.
+func withinDistance(pattern, text string, lo, hi, maxEdits int) bool {
+	lo, hi = max(lo, 0), min(hi, len(text))
+	if hi <= lo {
+		return false
+	}
+	window := text[lo:hi]
+	// prev[j] is the fewest edits aligning the pattern so far with a
+	// substring of window ending at j.
+	prev := make([]int, len(window)+1)
+	curr := make([]int, len(window)+1)
+	for i := 1; i <= len(pattern); i++ {
+		curr[0] = i
+		best := curr[0]
+		for j := 1; j <= len(window); j++ {
+			cost := 1
+			if pattern[i-1] == window[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j-1]+cost, min(prev[j], curr[j-1])+1)
+			best = min(best, curr[j])
+		}
+		if best > maxEdits {
+			return false
+		}
+		prev, curr = curr, prev
+	}
+	for _, d := range prev {
+		if d <= maxEdits {
+			return true
+		}
+	}
+	return false
+}
+
+// reverseComplement returns the reverse complement of an A/C/G/T string.
+func reverseComplement(s string) string {
+	b := make([]byte, len(s))
+	for i := 0; i < len(s); i++ {
+		var c byte
+		switch s[len(s)-1-i] {
+		case 'A':
+			c = 'T'
+		case 'C':
+			c = 'G'
+		case 'G':
+			c = 'C'
+		case 'T':
+			c = 'A'
+		default:
+			c = 'N'
+		}
+		b[i] = c
+	}
+	return string(b)
+}
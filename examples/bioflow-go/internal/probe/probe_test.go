@@ -0,0 +1,129 @@
+package probe
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randomBases(rng *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = "ACGT"[rng.Intn(4)]
+	}
+	return string(b)
+}
+
+func TestMeltingTemperature(t *testing.T) {
+	tm, err := MeltingTemperature("ACGTGCAAGCTTCGATGGCA", DefaultTmConditions())
+	require.NoError(t, err)
+	assert.InDelta(t, 59.76, tm, 0.01)
+
+	// More salt stabilizes the duplex; longer and GC-richer oligos melt
+	// higher.
+	salty, err := MeltingTemperature("ACGTGCAAGCTTCGATGGCA", TmConditions{Sodium: 500})
+	require.NoError(t, err)
+	assert.Greater(t, salty, tm)
+	rich, err := MeltingTemperature("GCGTGCGAGCTCCGATGGCG", DefaultTmConditions())
+	require.NoError(t, err)
+	assert.Greater(t, rich, tm)
+
+	// The complementary strand melts at the same temperature.
+	rc, err := MeltingTemperature(reverseComplement("ACGTGCAAGCTTCGATGGCA"), DefaultTmConditions())
+	require.NoError(t, err)
+	assert.InDelta(t, tm, rc, 1e-9)
+
+	_, err = MeltingTemperature("ACGNT", DefaultTmConditions())
+	assert.Error(t, err)
+}
+
+func TestWithinDistance(t *testing.T) {
+	assert.True(t, withinDistance("ACGTACGT", "TTACGTACGTTT", 0, 12, 0))
+	assert.True(t, withinDistance("ACGTACGT", "TTACGAACGTTT", 0, 12, 1))
+	assert.False(t, withinDistance("ACGTACGT", "TTACGAACGTTT", 0, 12, 0))
+	// One deleted base is one edit.
+	assert.True(t, withinDistance("ACGTACGT", "TTACGACGTTT", 0, 11, 1))
+	assert.False(t, withinDistance("ACGTACGT", "TTACGTACGTTT", 4, 12, 1))
+}
+
+func TestDesign(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	target := randomBases(rng, 300)
+	// The background holds a copy of target[50:100] with one substitution
+	// and the reverse complement of target[200:250].
+	copied := []byte(target[50:100])
+	copied[25] = "ACGT"[(strings.IndexByte("ACGT", copied[25])+1)%4]
+	bg, err := sequence.WithID(randomBases(rng, 1000)+string(copied)+randomBases(rng, 1000)+
+		reverseComplement(target[200:250])+randomBases(rng, 1000), "bg")
+	require.NoError(t, err)
+	tgt, err := sequence.WithID(target, "gene")
+	require.NoError(t, err)
+
+	opts := DefaultOptions()
+	opts.Length = 20
+	opts.MinGC, opts.MaxGC = 0, 0
+	probes, err := Design([]*sequence.Sequence{tgt}, []*sequence.Sequence{bg}, opts)
+	require.NoError(t, err)
+	require.NotEmpty(t, probes)
+	for _, p := range probes {
+		assert.Equal(t, "gene", p.SequenceID)
+		assert.Equal(t, target[p.Start:p.End], p.Sequence)
+		// Probes overlapping either copy by enough to match within two
+		// edits are rejected.
+		assert.False(t, p.Start > 50-3 && p.End < 100+3, "probe at %d in the copy", p.Start)
+		assert.False(t, p.Start > 200-3 && p.End < 250+3, "probe at %d in the inverted copy", p.Start)
+	}
+	starts := make(map[int]bool)
+	for _, p := range probes {
+		starts[p.Start] = true
+	}
+	assert.True(t, starts[0])
+	assert.True(t, starts[150])
+	assert.False(t, starts[60])
+	assert.False(t, starts[210])
+
+	// Exact uniqueness only rejects the inverted copy: the other has a
+	// substitution in every probe spanning base 75.
+	opts.MaxDistance = 0
+	exact, err := Design([]*sequence.Sequence{tgt}, []*sequence.Sequence{bg}, opts)
+	require.NoError(t, err)
+	starts = make(map[int]bool)
+	for _, p := range exact {
+		starts[p.Start] = true
+	}
+	assert.True(t, starts[60])
+	assert.False(t, starts[50])
+	assert.False(t, starts[210])
+
+	// GC and Tm bounds, and spacing.
+	opts = DefaultOptions()
+	opts.MinTm, opts.MaxTm, opts.MinSpacing = 55, 65, 30
+	spaced, err := Design([]*sequence.Sequence{tgt}, nil, opts)
+	require.NoError(t, err)
+	require.NotEmpty(t, spaced)
+	for i, p := range spaced {
+		assert.True(t, p.GC >= 0.4 && p.GC <= 0.6)
+		assert.True(t, p.Tm >= 55 && p.Tm <= 65)
+		if i > 0 {
+			assert.GreaterOrEqual(t, p.Start-spaced[i-1].Start, 30)
+		}
+	}
+
+	// A repeat within the target itself leaves only probes across the
+	// junction of the copies.
+	repeated, err := sequence.WithID(target[:100]+target[:100], "dup")
+	require.NoError(t, err)
+	probes, err = Design([]*sequence.Sequence{repeated}, nil, DefaultOptions())
+	require.NoError(t, err)
+	require.NotEmpty(t, probes)
+	for _, p := range probes {
+		assert.True(t, p.Start < 100 && p.End > 100+2, "probe at %d", p.Start)
+	}
+
+	_, err = Design([]*sequence.Sequence{tgt}, nil, Options{Length: 10, MaxDistance: 3})
+	assert.Error(t, err)
+}
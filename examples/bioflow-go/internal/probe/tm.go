@@ -0,0 +1,90 @@
+package probe
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// TmConditions are the hybridization conditions melting temperatures are
+// computed for.
+type TmConditions struct {
+	// Sodium is the monovalent cation concentration in mM (default 50).
+	Sodium float64
+	// Oligo is the total oligo strand concentration in nM (default 250).
+	Oligo float64
+}
+
+// DefaultTmConditions returns typical PCR and hybridization conditions.
+func DefaultTmConditions() TmConditions {
+	return TmConditions{Sodium: 50, Oligo: 250}
+}
+
+// nearestNeighbor holds SantaLucia's (1998) unified enthalpy (kcal/mol)
+// and entropy (cal/K/mol) of each dinucleotide stack, keyed by the top
+// strand read 5' to 3'.
+var nearestNeighbor = map[string][2]float64{
+	"AA": {-7.9, -22.2}, "TT": {-7.9, -22.2},
+	"AT": {-7.2, -20.4},
+	"TA": {-7.2, -21.3},
+	"CA": {-8.5, -22.7}, "TG": {-8.5, -22.7},
+	"GT": {-8.4, -22.4}, "AC": {-8.4, -22.4},
+	"CT": {-7.8, -21.0}, "AG": {-7.8, -21.0},
+	"GA": {-8.2, -22.2}, "TC": {-8.2, -22.2},
+	"CG": {-10.6, -27.2},
+	"GC": {-9.8, -24.4},
+	"GG": {-8.0, -19.9}, "CC": {-8.0, -19.9},
+}
+
+// MeltingTemperature returns the melting temperature, in °C, of an oligo
+// paired with its perfect complement, by the nearest-neighbor model with
+// SantaLucia's (1998) unified parameters and salt correction.
+//
+// Aria equivalent:
+//
+//	fn melting_temperature(oligo: String, conditions: TmConditions) -> Result<Float, ProbeError>
+//	  requires oligo.len() >= 2
+//	  requires oligo.chars().all(|c| "ACGT".contains(c))
+func MeltingTemperature(oligo string, cond TmConditions) (float64, error) {
+	if cond.Sodium <= 0 {
+		cond.Sodium = DefaultTmConditions().Sodium
+	}
+	if cond.Oligo <= 0 {
+		cond.Oligo = DefaultTmConditions().Oligo
+	}
+	oligo = strings.ToUpper(oligo)
+	if len(oligo) < 2 {
+		return 0, fmt.Errorf("oligo must be at least 2 bases")
+	}
+
+	var dH, dS float64
+	for i := 0; i+1 < len(oligo); i++ {
+		p, ok := nearestNeighbor[oligo[i:i+2]]
+		if !ok {
+			return 0, fmt.Errorf("invalid base in oligo at position %d", i+1)
+		}
+		dH += p[0]
+		dS += p[1]
+	}
+	// Initiation, by the base pair at each end.
+	for _, end := range []byte{oligo[0], oligo[len(oligo)-1]} {
+		if end == 'G' || end == 'C' {
+			dH += 0.1
+			dS += -2.8
+		} else {
+			dH += 2.3
+			dS += 4.1
+		}
+	}
+	// A self-complementary oligo pairs with itself, so every strand is a
+	// potential partner.
+	x := 4.0
+	if oligo == reverseComplement(oligo) {
+		dS += -1.4
+		x = 1
+	}
+	dS += 0.368 * float64(len(oligo)-1) * math.Log(cond.Sodium/1000)
+
+	const gasConstant = 1.987 // cal/K/mol
+	return dH*1000/(dS+gasConstant*math.Log(cond.Oligo*1e-9/x)) - 273.15, nil
+}
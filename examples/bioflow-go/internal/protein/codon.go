@@ -0,0 +1,356 @@
+package protein
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/data"
+	"github.com/aria-lang/bioflow-go/internal/random"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// CodonUsage maps DNA codons to their usage (any scale, typically
+// per-thousand). Codons missing from the table are treated as unused.
+type CodonUsage map[string]float64
+
+// CodonTable returns a codon usage table by organism name: a built-in one
+// (ecoli or human, per thousand codons from the Kazusa codon usage
+// database) or one added by the data directory.
+func CodonTable(name string) (CodonUsage, error) {
+	_, content, err := data.Lookup("codon-usage", name)
+	if errors.Is(err, data.ErrNotFound) {
+		return nil, fmt.Errorf("unknown codon table: %s (available: %s)", name, strings.Join(CodonTableNames(), ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	usage, err := ParseCodonUsage(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("codon table %s: %w", name, err)
+	}
+	return usage, nil
+}
+
+// CodonTableNames returns the names of the codon usage tables.
+func CodonTableNames() []string {
+	return data.Names("codon-usage")
+}
+
+// ParseCodonUsage reads a codon usage table. Any whitespace-separated
+// codon followed by a number is accepted, so both simple "codon value"
+// lists and Kazusa-style tables ("UUU 17.6(  714298)") parse. Lines
+// starting with '#' are ignored.
+func ParseCodonUsage(r io.Reader) (CodonUsage, error) {
+	usage := make(CodonUsage)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(strings.NewReplacer("(", " (", ",", " ").Replace(line))
+		for i := 0; i+1 < len(fields); i++ {
+			codon := strings.ReplaceAll(strings.ToUpper(fields[i]), "U", "T")
+			if _, ok := StandardCode[codon]; !ok {
+				continue
+			}
+			value, err := strconv.ParseFloat(fields[i+1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid usage for codon %s: %q", codon, fields[i+1])
+			}
+			if value < 0 {
+				return nil, fmt.Errorf("negative usage for codon %s", codon)
+			}
+			usage[codon] = value
+			i++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading codon table: %w", err)
+	}
+	if len(usage) == 0 {
+		return nil, fmt.Errorf("no codons found in codon table")
+	}
+	return usage, nil
+}
+
+// RestrictionSite returns the site recognized by a restriction enzyme, by
+// case-insensitive name, from the enzymes.tsv table of the data
+// directory or the built-in one. An unknown enzyme is data.ErrNotFound.
+//
+// Aria equivalent:
+//
+//	fn restriction_site(enzyme: String) -> Result<String, ProteinError> with FileSystem
+//	  ensures result.is_ok() implies result.unwrap().chars().all(is_iupac_base)
+func RestrictionSite(enzyme string) (string, error) {
+	content, err := data.ReadFile("enzymes.tsv")
+	if err != nil {
+		return "", err
+	}
+	for i, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) != 2 {
+			return "", fmt.Errorf("enzymes.tsv line %d: want a name and a site", i+1)
+		}
+		if strings.EqualFold(fields[0], enzyme) {
+			return strings.ToUpper(fields[1]), nil
+		}
+	}
+	return "", fmt.Errorf("%w: restriction enzyme %s", data.ErrNotFound, enzyme)
+}
+
+// synonymous returns the codons for an amino acid sorted by decreasing
+// usage, dropping unused codons and codons whose usage relative to the
+// most used synonymous codon is below minFraction.
+func (u CodonUsage) synonymous(aa byte, minFraction float64) []string {
+	codons := make([]string, 0, 6)
+	for codon, a := range StandardCode {
+		if a == aa && u[codon] > 0 {
+			codons = append(codons, codon)
+		}
+	}
+	sort.Slice(codons, func(i, j int) bool {
+		if u[codons[i]] != u[codons[j]] {
+			return u[codons[i]] > u[codons[j]]
+		}
+		return codons[i] < codons[j]
+	})
+	if len(codons) > 0 && minFraction > 0 {
+		best := u[codons[0]]
+		kept := codons[:1]
+		for _, c := range codons[1:] {
+			if u[c]/best >= minFraction {
+				kept = append(kept, c)
+			}
+		}
+		codons = kept
+	}
+	return codons
+}
+
+// CAI returns the codon adaptation index of a coding sequence: the
+// geometric mean of each codon's usage relative to the most used
+// synonymous codon. Stops, Met, Trp and ambiguous codons are skipped.
+func (u CodonUsage) CAI(coding string) float64 {
+	logSum, n := 0.0, 0
+	for i := 0; i+3 <= len(coding); i += 3 {
+		codon := coding[i : i+3]
+		aa := TranslateCodon(codon)
+		if aa == 'X' || aa == Stop || aa == 'M' || aa == 'W' {
+			continue
+		}
+		syn := u.synonymous(aa, 0)
+		if len(syn) == 0 || u[codon] == 0 {
+			continue
+		}
+		logSum += math.Log(u[codon] / u[syn[0]])
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return math.Exp(logSum / float64(n))
+}
+
+// Strategy selects how codons are chosen during back-translation.
+type Strategy int
+
+const (
+	// MostFrequent always prefers the most used synonymous codon.
+	MostFrequent Strategy = iota
+	// Weighted samples codons in proportion to their usage.
+	Weighted
+)
+
+// ParseStrategy parses a strategy name ("frequent" or "weighted").
+func ParseStrategy(name string) (Strategy, error) {
+	switch strings.ToLower(name) {
+	case "", "frequent", "most-frequent":
+		return MostFrequent, nil
+	case "weighted", "random":
+		return Weighted, nil
+	default:
+		return 0, fmt.Errorf("unknown back-translation strategy: %s", name)
+	}
+}
+
+// BackTranslateOptions configures back-translation.
+type BackTranslateOptions struct {
+	Usage    CodonUsage
+	Strategy Strategy
+	// Seed makes Weighted back-translation reproducible. Rand, if set, is
+	// drawn from instead.
+	Seed int64
+	Rand *rand.Rand
+	// MinFraction drops rare codons used less than this fraction of the
+	// most used synonymous codon.
+	MinFraction float64
+	// Avoid lists sites (IUPAC allowed), or the names of restriction
+	// enzymes recognizing them, that must not appear on either strand.
+	Avoid []string
+}
+
+// maxBacktrackSteps bounds the search for a site-free back-translation.
+const maxBacktrackSteps = 1000000
+
+// BackTranslate reverse-translates a protein into DNA using a codon usage
+// table, avoiding the given restriction sites on both strands. A trailing
+// stop is encoded with the table's preferred stop codon.
+//
+// Aria equivalent:
+//
+//	fn back_translate(protein: Protein, options: BackTranslateOptions) -> Result<Sequence, ProteinError>
+//	  ensures translate(result, 0, false) == protein
+//	  ensures options.avoid.all(|site| !result.contains_iupac(site))
+func BackTranslate(p *Protein, opts BackTranslateOptions) (*sequence.Sequence, error) {
+	if opts.Usage == nil {
+		return nil, fmt.Errorf("codon usage table is required")
+	}
+
+	var rng *rand.Rand
+	if opts.Strategy == Weighted {
+		rng = random.Or(opts.Rand, opts.Seed)
+	}
+
+	candidates := make([][]string, len(p.Residues))
+	for i := 0; i < len(p.Residues); i++ {
+		aa := p.Residues[i]
+		if aa == 'X' {
+			return nil, fmt.Errorf("cannot back-translate unknown residue at position %d", i)
+		}
+		syn := opts.Usage.synonymous(aa, opts.MinFraction)
+		if len(syn) == 0 {
+			return nil, fmt.Errorf("codon table has no codon for '%c'", aa)
+		}
+		if rng != nil {
+			syn = weightedOrder(syn, opts.Usage, rng)
+		}
+		candidates[i] = syn
+	}
+
+	sites := make([]string, 0, 2*len(opts.Avoid))
+	for _, site := range opts.Avoid {
+		site = strings.TrimSpace(site)
+		if site == "" {
+			continue
+		}
+		enzyme, err := RestrictionSite(site)
+		switch {
+		case err == nil:
+			site = enzyme
+		case !errors.Is(err, data.ErrNotFound):
+			return nil, err
+		}
+		site = strings.ToUpper(site)
+		for j := 0; j < len(site); j++ {
+			if !sequence.IsIUPACBase(site[j]) {
+				return nil, fmt.Errorf("invalid base '%c' in site %s", site[j], site)
+			}
+		}
+		sites = append(sites, site)
+		if rc := sequence.IUPACReverseComplement(site); rc != site {
+			sites = append(sites, rc)
+		}
+	}
+
+	// containsSite checks only windows ending in the last codon, since
+	// earlier windows were checked when their codons were placed.
+	containsSite := func(dna []byte) bool {
+		for _, site := range sites {
+			start := len(dna) - 3 - len(site) + 1
+			if start < 0 {
+				start = 0
+			}
+			for s := start; s+len(site) <= len(dna); s++ {
+				if matchesIUPAC(dna[s:s+len(site)], site) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	dna := make([]byte, 0, 3*len(p.Residues))
+	choice := make([]int, len(p.Residues))
+	steps := 0
+	for i := 0; i < len(p.Residues); {
+		placed := false
+		for choice[i] < len(candidates[i]) {
+			steps++
+			if steps > maxBacktrackSteps {
+				return nil, fmt.Errorf("could not avoid restriction sites")
+			}
+			dna = append(dna[:3*i], candidates[i][choice[i]]...)
+			choice[i]++
+			if !containsSite(dna) {
+				placed = true
+				break
+			}
+		}
+		if placed {
+			i++
+			if i < len(choice) {
+				choice[i] = 0
+			}
+			continue
+		}
+		// Every codon here creates a site: revisit the previous residue.
+		if i == 0 {
+			return nil, fmt.Errorf("could not avoid restriction sites")
+		}
+		dna = dna[:3*(i-1)]
+		i--
+	}
+
+	return &sequence.Sequence{
+		Bases:       string(dna),
+		ID:          p.ID,
+		Description: p.Description,
+		SeqType:     sequence.DNA,
+	}, nil
+}
+
+// weightedOrder orders codons by weighted sampling without replacement.
+func weightedOrder(codons []string, usage CodonUsage, rng *rand.Rand) []string {
+	remaining := append([]string(nil), codons...)
+	ordered := make([]string, 0, len(codons))
+	for len(remaining) > 0 {
+		total := 0.0
+		for _, c := range remaining {
+			total += usage[c]
+		}
+		r := rng.Float64() * total
+		pick := len(remaining) - 1
+		for j, c := range remaining {
+			r -= usage[c]
+			if r < 0 {
+				pick = j
+				break
+			}
+		}
+		ordered = append(ordered, remaining[pick])
+		remaining = append(remaining[:pick], remaining[pick+1:]...)
+	}
+	return ordered
+}
+
+// matchesIUPAC reports whether concrete bases match an IUPAC pattern.
+func matchesIUPAC(bases []byte, pattern string) bool {
+	for i := range bases {
+		if !sequence.IUPACCompatible(bases[i], pattern[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,209 @@
+package protein
+
+import (
+	"math"
+)
+
+// waterMass is the average mass of H2O added once per chain.
+const waterMass = 18.01524
+
+// StableThreshold is the instability index below which a protein is
+// predicted to be stable (Guruprasad et al., 1990).
+const StableThreshold = 40.0
+
+// residueMass holds average residue masses in daltons (ExPASy).
+var residueMass = map[byte]float64{
+	'A': 71.0788, 'R': 156.1875, 'N': 114.1038, 'D': 115.0886, 'C': 103.1388,
+	'E': 129.1155, 'Q': 128.1307, 'G': 57.0519, 'H': 137.1411, 'I': 113.1594,
+	'L': 113.1594, 'K': 128.1741, 'M': 131.1926, 'F': 147.1766, 'P': 97.1167,
+	'S': 87.0782, 'T': 101.1051, 'W': 186.2132, 'Y': 163.1760, 'V': 99.1326,
+}
+
+// kyteDoolittle is the Kyte-Doolittle hydropathy scale.
+var kyteDoolittle = map[byte]float64{
+	'A': 1.8, 'R': -4.5, 'N': -3.5, 'D': -3.5, 'C': 2.5,
+	'Q': -3.5, 'E': -3.5, 'G': -0.4, 'H': -3.2, 'I': 4.5,
+	'L': 3.8, 'K': -3.9, 'M': 1.9, 'F': 2.8, 'P': -1.6,
+	'S': -0.8, 'T': -0.7, 'W': -0.9, 'Y': -1.3, 'V': 4.2,
+}
+
+// pKa values (EMBOSS) for ionizable groups.
+const (
+	pKaNTerm = 8.6
+	pKaCTerm = 3.6
+)
+
+var pKaPositive = map[byte]float64{'K': 10.8, 'R': 12.5, 'H': 6.5}
+var pKaNegative = map[byte]float64{'D': 3.9, 'E': 4.1, 'C': 8.5, 'Y': 10.1}
+
+// Properties summarizes the physico-chemical properties of a protein.
+// Stops and unknown residues (X) are excluded from every calculation.
+//
+// Aria equivalent:
+//
+//	struct Properties
+//	  invariant self.isoelectric_point >= 0.0 and self.isoelectric_point <= 14.0
+//	  invariant self.composition.values().sum() == self.length
+type Properties struct {
+	Length             int                `json:"length"`
+	MolecularWeight    float64            `json:"molecular_weight"`
+	IsoelectricPoint   float64            `json:"isoelectric_point"`
+	ChargeAtPH7        float64            `json:"charge_at_ph7"`
+	GRAVY              float64            `json:"gravy"`
+	InstabilityIndex   float64            `json:"instability_index"`
+	Stable             bool               `json:"stable"`
+	Composition        map[string]int     `json:"composition"`
+	CompositionPercent map[string]float64 `json:"composition_percent"`
+}
+
+// standardResidues returns the residues restricted to the 20 standard amino acids.
+func standardResidues(p *Protein) []byte {
+	out := make([]byte, 0, len(p.Residues))
+	for i := 0; i < len(p.Residues); i++ {
+		if _, ok := residueMass[p.Residues[i]]; ok {
+			out = append(out, p.Residues[i])
+		}
+	}
+	return out
+}
+
+// MolecularWeight returns the average molecular weight in daltons.
+func (p *Protein) MolecularWeight() float64 {
+	residues := standardResidues(p)
+	if len(residues) == 0 {
+		return 0
+	}
+	total := waterMass
+	for _, r := range residues {
+		total += residueMass[r]
+	}
+	return total
+}
+
+// GRAVY returns the grand average of hydropathy (Kyte-Doolittle).
+func (p *Protein) GRAVY() float64 {
+	residues := standardResidues(p)
+	if len(residues) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, r := range residues {
+		total += kyteDoolittle[r]
+	}
+	return total / float64(len(residues))
+}
+
+// NetCharge returns the net charge of the protein at the given pH using
+// the Henderson-Hasselbalch equation.
+func (p *Protein) NetCharge(pH float64) float64 {
+	residues := standardResidues(p)
+	if len(residues) == 0 {
+		return 0
+	}
+
+	positive := 1 / (1 + math.Pow(10, pH-pKaNTerm))
+	negative := 1 / (1 + math.Pow(10, pKaCTerm-pH))
+	for _, r := range residues {
+		if pKa, ok := pKaPositive[r]; ok {
+			positive += 1 / (1 + math.Pow(10, pH-pKa))
+		} else if pKa, ok := pKaNegative[r]; ok {
+			negative += 1 / (1 + math.Pow(10, pKa-pH))
+		}
+	}
+	return positive - negative
+}
+
+// IsoelectricPoint returns the pH at which the net charge is zero, found
+// by bisection to within 0.001 pH units.
+//
+// Aria equivalent:
+//
+//	fn isoelectric_point(self) -> Float
+//	  ensures result >= 0.0 and result <= 14.0
+func (p *Protein) IsoelectricPoint() float64 {
+	lo, hi := 0.0, 14.0
+	for hi-lo > 0.001 {
+		mid := (lo + hi) / 2
+		if p.NetCharge(mid) > 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// InstabilityIndex returns the Guruprasad instability index. Values below
+// StableThreshold predict a stable protein. Only dipeptides of adjacent
+// standard residues are weighed: an X or stop between two residues breaks
+// the pair rather than joining them.
+func (p *Protein) InstabilityIndex() float64 {
+	n := len(standardResidues(p))
+	if n < 2 {
+		return 0
+	}
+	total := 0.0
+	for i := 0; i+1 < len(p.Residues); i++ {
+		if weights, ok := diwv[p.Residues[i]]; ok {
+			total += weights[p.Residues[i+1]]
+		}
+	}
+	return 10 / float64(n) * total
+}
+
+// Composition returns the count of each standard amino acid present.
+func (p *Protein) Composition() map[string]int {
+	counts := make(map[string]int)
+	for _, r := range standardResidues(p) {
+		counts[string(r)]++
+	}
+	return counts
+}
+
+// ComputeProperties calculates all properties of a protein.
+func ComputeProperties(p *Protein) *Properties {
+	residues := standardResidues(p)
+	composition := p.Composition()
+	percent := make(map[string]float64, len(composition))
+	for aa, c := range composition {
+		percent[aa] = float64(c) / float64(len(residues)) * 100
+	}
+
+	instability := p.InstabilityIndex()
+	return &Properties{
+		Length:             len(residues),
+		MolecularWeight:    p.MolecularWeight(),
+		IsoelectricPoint:   p.IsoelectricPoint(),
+		ChargeAtPH7:        p.NetCharge(7.0),
+		GRAVY:              p.GRAVY(),
+		InstabilityIndex:   instability,
+		Stable:             instability < StableThreshold,
+		Composition:        composition,
+		CompositionPercent: percent,
+	}
+}
+
+// diwv is the dipeptide instability weight value table (Guruprasad et
+// al., 1990), indexed by the first and then second residue.
+var diwv = map[byte]map[byte]float64{
+	'A': {'A': 1.0, 'C': 44.94, 'E': 1.0, 'D': -7.49, 'G': 1.0, 'F': 1.0, 'I': 1.0, 'H': -7.49, 'K': 1.0, 'M': 1.0, 'L': 1.0, 'N': 1.0, 'Q': 1.0, 'P': 20.26, 'S': 1.0, 'R': 1.0, 'T': 1.0, 'W': 1.0, 'V': 1.0, 'Y': 1.0},
+	'C': {'A': 1.0, 'C': 1.0, 'E': 1.0, 'D': 20.26, 'G': 1.0, 'F': 1.0, 'I': 1.0, 'H': 33.60, 'K': 1.0, 'M': 33.60, 'L': 20.26, 'N': 1.0, 'Q': -6.54, 'P': 20.26, 'S': 1.0, 'R': 1.0, 'T': 33.60, 'W': 24.68, 'V': -6.54, 'Y': 1.0},
+	'E': {'A': 1.0, 'C': 44.94, 'E': 33.60, 'D': 20.26, 'G': 1.0, 'F': 1.0, 'I': 20.26, 'H': -6.54, 'K': 1.0, 'M': 1.0, 'L': 1.0, 'N': 1.0, 'Q': 20.26, 'P': 20.26, 'S': 20.26, 'R': 1.0, 'T': 1.0, 'W': -14.03, 'V': 1.0, 'Y': 1.0},
+	'D': {'A': 1.0, 'C': 1.0, 'E': 1.0, 'D': 1.0, 'G': 1.0, 'F': -6.54, 'I': 1.0, 'H': 1.0, 'K': -7.49, 'M': 1.0, 'L': 1.0, 'N': 1.0, 'Q': 1.0, 'P': 1.0, 'S': 20.26, 'R': -6.54, 'T': -14.03, 'W': 1.0, 'V': 1.0, 'Y': 1.0},
+	'G': {'A': -7.49, 'C': 1.0, 'E': -6.54, 'D': 1.0, 'G': 13.34, 'F': 1.0, 'I': -7.49, 'H': 1.0, 'K': -7.49, 'M': 1.0, 'L': 1.0, 'N': -7.49, 'Q': 1.0, 'P': 1.0, 'S': 1.0, 'R': 1.0, 'T': -7.49, 'W': 13.34, 'V': 1.0, 'Y': -7.49},
+	'F': {'A': 1.0, 'C': 1.0, 'E': 1.0, 'D': 13.34, 'G': 1.0, 'F': 1.0, 'I': 1.0, 'H': 1.0, 'K': -14.03, 'M': 1.0, 'L': 1.0, 'N': 1.0, 'Q': 1.0, 'P': 20.26, 'S': 1.0, 'R': 1.0, 'T': 1.0, 'W': 1.0, 'V': 1.0, 'Y': 33.601},
+	'I': {'A': 1.0, 'C': 1.0, 'E': 44.94, 'D': 1.0, 'G': 1.0, 'F': 1.0, 'I': 1.0, 'H': 13.34, 'K': -7.49, 'M': 1.0, 'L': 20.26, 'N': 1.0, 'Q': 1.0, 'P': -1.88, 'S': 1.0, 'R': 1.0, 'T': 1.0, 'W': 1.0, 'V': -7.49, 'Y': 1.0},
+	'H': {'A': 1.0, 'C': 1.0, 'E': 1.0, 'D': 1.0, 'G': -9.37, 'F': -9.37, 'I': 44.94, 'H': 1.0, 'K': 24.68, 'M': 1.0, 'L': 1.0, 'N': 24.68, 'Q': 1.0, 'P': -1.88, 'S': 1.0, 'R': 1.0, 'T': -6.54, 'W': -1.88, 'V': 1.0, 'Y': 44.94},
+	'K': {'A': 1.0, 'C': 1.0, 'E': 1.0, 'D': 1.0, 'G': -7.49, 'F': 1.0, 'I': -7.49, 'H': 1.0, 'K': 1.0, 'M': 33.60, 'L': -7.49, 'N': 1.0, 'Q': 24.64, 'P': -6.54, 'S': 1.0, 'R': 33.60, 'T': 1.0, 'W': 1.0, 'V': -7.49, 'Y': 1.0},
+	'M': {'A': 13.34, 'C': 1.0, 'E': 1.0, 'D': 1.0, 'G': 1.0, 'F': 1.0, 'I': 1.0, 'H': 58.28, 'K': 1.0, 'M': -1.88, 'L': 1.0, 'N': 1.0, 'Q': -6.54, 'P': 44.94, 'S': 44.94, 'R': -6.54, 'T': -1.88, 'W': 1.0, 'V': 1.0, 'Y': 24.68},
+	'L': {'A': 1.0, 'C': 1.0, 'E': 1.0, 'D': 1.0, 'G': 1.0, 'F': 1.0, 'I': 1.0, 'H': 1.0, 'K': -7.49, 'M': 1.0, 'L': 1.0, 'N': 1.0, 'Q': 33.60, 'P': 20.26, 'S': 1.0, 'R': 20.26, 'T': 1.0, 'W': 24.68, 'V': 1.0, 'Y': 1.0},
+	'N': {'A': 1.0, 'C': -1.88, 'E': 1.0, 'D': 1.0, 'G': -14.03, 'F': -14.03, 'I': 44.94, 'H': 1.0, 'K': 24.68, 'M': 1.0, 'L': 1.0, 'N': 1.0, 'Q': -6.54, 'P': -1.88, 'S': 1.0, 'R': 1.0, 'T': -7.49, 'W': -9.37, 'V': 1.0, 'Y': 1.0},
+	'Q': {'A': 1.0, 'C': -6.54, 'E': 20.26, 'D': 20.26, 'G': 1.0, 'F': -6.54, 'I': 1.0, 'H': 1.0, 'K': 1.0, 'M': 1.0, 'L': 1.0, 'N': 1.0, 'Q': 20.26, 'P': 20.26, 'S': 44.94, 'R': 1.0, 'T': 1.0, 'W': 1.0, 'V': -6.54, 'Y': -6.54},
+	'P': {'A': 20.26, 'C': -6.54, 'E': 18.38, 'D': -6.54, 'G': 1.0, 'F': 20.26, 'I': 1.0, 'H': 1.0, 'K': 1.0, 'M': -6.54, 'L': 1.0, 'N': 1.0, 'Q': 20.26, 'P': 20.26, 'S': 20.26, 'R': -6.54, 'T': 1.0, 'W': -1.88, 'V': 20.26, 'Y': 1.0},
+	'S': {'A': 1.0, 'C': 33.60, 'E': 20.26, 'D': 1.0, 'G': 1.0, 'F': 1.0, 'I': 1.0, 'H': 1.0, 'K': 1.0, 'M': 1.0, 'L': 1.0, 'N': 1.0, 'Q': 20.26, 'P': 44.94, 'S': 20.26, 'R': 20.26, 'T': 1.0, 'W': 1.0, 'V': 1.0, 'Y': 1.0},
+	'R': {'A': 1.0, 'C': 1.0, 'E': 1.0, 'D': 1.0, 'G': -7.49, 'F': 1.0, 'I': 1.0, 'H': 20.26, 'K': 1.0, 'M': 1.0, 'L': 1.0, 'N': 13.34, 'Q': 20.26, 'P': 20.26, 'S': 44.94, 'R': 58.28, 'T': 1.0, 'W': 58.28, 'V': 1.0, 'Y': -6.54},
+	'T': {'A': 1.0, 'C': 1.0, 'E': 20.26, 'D': 1.0, 'G': -7.49, 'F': 13.34, 'I': 1.0, 'H': 1.0, 'K': 1.0, 'M': 1.0, 'L': 1.0, 'N': -14.03, 'Q': -6.54, 'P': 1.0, 'S': 1.0, 'R': 1.0, 'T': 1.0, 'W': -14.03, 'V': 1.0, 'Y': 1.0},
+	'W': {'A': -14.03, 'C': 1.0, 'E': 1.0, 'D': 1.0, 'G': -9.37, 'F': 1.0, 'I': 1.0, 'H': 24.68, 'K': 1.0, 'M': 24.68, 'L': 13.34, 'N': 13.34, 'Q': 1.0, 'P': 1.0, 'S': 1.0, 'R': 1.0, 'T': -14.03, 'W': 1.0, 'V': -7.49, 'Y': 1.0},
+	'V': {'A': 1.0, 'C': 1.0, 'E': 1.0, 'D': -14.03, 'G': -7.49, 'F': 1.0, 'I': 1.0, 'H': 1.0, 'K': -1.88, 'M': 1.0, 'L': 1.0, 'N': 1.0, 'Q': 1.0, 'P': 20.26, 'S': 1.0, 'R': 1.0, 'T': -7.49, 'W': 1.0, 'V': 1.0, 'Y': -6.54},
+	'Y': {'A': 24.68, 'C': 1.0, 'E': -6.54, 'D': 24.68, 'G': -7.49, 'F': 1.0, 'I': 1.0, 'H': 13.34, 'K': 1.0, 'M': 44.94, 'L': 1.0, 'N': 1.0, 'Q': 1.0, 'P': 13.34, 'S': 1.0, 'R': -15.91, 'T': -7.49, 'W': -9.37, 'V': 1.0, 'Y': 13.34},
+}
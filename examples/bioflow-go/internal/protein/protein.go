@@ -0,0 +1,166 @@
+// Package protein provides protein sequences, translation from DNA and
+// physico-chemical property calculations.
+//
+// Comparison with Aria:
+//
+//	Aria refines residue strings at the type level:
+//	  type Protein = String where self.all(|c| AMINO_ACIDS.contains(c))
+//
+//	Go validates residues once in New and trusts the value afterwards.
+package protein
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// AminoAcids lists the 20 standard amino acids in one-letter code.
+const AminoAcids = "ACDEFGHIKLMNPQRSTVWY"
+
+// Stop is the translation stop symbol.
+const Stop = '*'
+
+// Protein represents an amino-acid sequence.
+//
+// Aria equivalent:
+//
+//	struct Protein
+//	  residues: String
+//	  id: Option<String>
+//	  invariant self.residues.len() > 0
+type Protein struct {
+	Residues    string
+	ID          string
+	Description string
+}
+
+// IsValidResidue checks if a character is a standard amino acid, the
+// unknown residue X or a stop.
+func IsValidResidue(c byte) bool {
+	return strings.IndexByte(AminoAcids, c) >= 0 || c == 'X' || c == Stop
+}
+
+// New creates a protein from one-letter residues. Residues are uppercased;
+// the 20 standard amino acids, X and '*' (stop) are accepted.
+//
+// Aria equivalent:
+//
+//	fn new(residues: String) -> Result<Protein, ProteinError>
+//	  requires residues.len() > 0
+func New(residues string) (*Protein, error) {
+	normalized := strings.ToUpper(residues)
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("protein sequence cannot be empty")
+	}
+	for i := 0; i < len(normalized); i++ {
+		if !IsValidResidue(normalized[i]) {
+			return nil, fmt.Errorf("invalid residue '%c' at position %d", normalized[i], i)
+		}
+	}
+	return &Protein{Residues: normalized}, nil
+}
+
+// WithID creates a protein with an identifier.
+func WithID(residues, id string) (*Protein, error) {
+	p, err := New(residues)
+	if err != nil {
+		return nil, err
+	}
+	p.ID = id
+	return p, nil
+}
+
+// Len returns the number of residues, including any stop symbols.
+func (p *Protein) Len() int {
+	return len(p.Residues)
+}
+
+// TrimStop returns the residues without a trailing stop symbol.
+func (p *Protein) TrimStop() string {
+	return strings.TrimRight(p.Residues, string(Stop))
+}
+
+// HasInternalStop reports whether a stop appears before the final residue.
+func (p *Protein) HasInternalStop() bool {
+	return strings.IndexByte(p.TrimStop(), Stop) >= 0
+}
+
+// ToFASTA formats the protein as a FASTA record with 60-column lines.
+func (p *Protein) ToFASTA() string {
+	var sb strings.Builder
+	sb.WriteByte('>')
+	sb.WriteString(p.ID)
+	if p.Description != "" {
+		sb.WriteByte(' ')
+		sb.WriteString(p.Description)
+	}
+	sb.WriteByte('\n')
+	for i := 0; i < len(p.Residues); i += 60 {
+		end := i + 60
+		if end > len(p.Residues) {
+			end = len(p.Residues)
+		}
+		sb.WriteString(p.Residues[i:end])
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func (p *Protein) String() string {
+	if len(p.Residues) > 50 {
+		return p.Residues[:50] + "..."
+	}
+	return p.Residues
+}
+
+// ParseFASTA parses protein FASTA records from a reader.
+func ParseFASTA(r io.Reader) ([]*Protein, error) {
+	proteins := make([]*Protein, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var id, desc string
+	var residues strings.Builder
+
+	flush := func() error {
+		if residues.Len() == 0 {
+			return nil
+		}
+		p, err := New(residues.String())
+		if err != nil {
+			return fmt.Errorf("record %s: %w", id, err)
+		}
+		p.ID, p.Description = id, desc
+		proteins = append(proteins, p)
+		residues.Reset()
+		return nil
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] == '>' {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			parts := strings.SplitN(line[1:], " ", 2)
+			id, desc = parts[0], ""
+			if len(parts) > 1 {
+				desc = parts[1]
+			}
+			continue
+		}
+		residues.WriteString(line)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+	return proteins, nil
+}
@@ -0,0 +1,286 @@
+package protein
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/data"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	p, err := New("mkv*")
+	require.NoError(t, err)
+	assert.Equal(t, "MKV*", p.Residues)
+	assert.Equal(t, "MKV", p.TrimStop())
+	assert.False(t, p.HasInternalStop())
+
+	p, err = New("MK*V")
+	require.NoError(t, err)
+	assert.True(t, p.HasInternalStop())
+
+	_, err = New("")
+	assert.Error(t, err)
+	_, err = New("MKB")
+	assert.Error(t, err)
+}
+
+func TestTranslate(t *testing.T) {
+	seq, err := sequence.WithID("ATGGCCTAAGGG", "gene1")
+	require.NoError(t, err)
+
+	p, err := Translate(seq, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, "MA*G", p.Residues)
+	assert.Equal(t, "gene1", p.ID)
+
+	p, err = Translate(seq, 0, true)
+	require.NoError(t, err)
+	assert.Equal(t, "MA", p.Residues)
+
+	p, err = Translate(seq, 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, "WPK", p.Residues)
+
+	_, err = Translate(seq, 3, false)
+	assert.Error(t, err)
+
+	assert.Equal(t, byte('X'), TranslateCodon("ANG"))
+	assert.Equal(t, byte('M'), TranslateCodon("AUG"))
+	assert.True(t, IsStopCodon("uga"))
+	assert.True(t, IsStartCodon("ATG"))
+	assert.Len(t, StandardCode, 64)
+}
+
+func TestMolecularWeight(t *testing.T) {
+	p, err := New("G")
+	require.NoError(t, err)
+	assert.InDelta(t, 75.07, p.MolecularWeight(), 0.01)
+
+	// Stops and X are ignored.
+	q, err := New("GX*")
+	require.NoError(t, err)
+	assert.InDelta(t, p.MolecularWeight(), q.MolecularWeight(), 1e-9)
+
+	// Each peptide bond releases one water.
+	p, err = New("GG")
+	require.NoError(t, err)
+	assert.InDelta(t, 132.12, p.MolecularWeight(), 0.01)
+}
+
+func TestGRAVY(t *testing.T) {
+	tests := []struct {
+		residues string
+		expected float64
+	}{
+		{"A", 1.8},
+		{"IR", 0.0},
+		{"KKKK", -3.9},
+	}
+	for _, tt := range tests {
+		p, err := New(tt.residues)
+		require.NoError(t, err)
+		assert.InDelta(t, tt.expected, p.GRAVY(), 1e-9, tt.residues)
+	}
+}
+
+func TestIsoelectricPoint(t *testing.T) {
+	basic, err := New("KKKKRRRR")
+	require.NoError(t, err)
+	acidic, err := New("DDDDEEEE")
+	require.NoError(t, err)
+	neutral, err := New("GGGG")
+	require.NoError(t, err)
+
+	assert.Greater(t, basic.IsoelectricPoint(), 10.0)
+	assert.Less(t, acidic.IsoelectricPoint(), 4.5)
+	// Only the termini ionize: pI is their midpoint.
+	assert.InDelta(t, (pKaNTerm+pKaCTerm)/2, neutral.IsoelectricPoint(), 0.01)
+
+	assert.Greater(t, basic.NetCharge(7), 0.0)
+	assert.Less(t, acidic.NetCharge(7), 0.0)
+	assert.InDelta(t, 0, neutral.NetCharge(neutral.IsoelectricPoint()), 0.01)
+}
+
+func TestInstabilityIndex(t *testing.T) {
+	p, err := New("AAAA")
+	require.NoError(t, err)
+	// Three AA dipeptides with weight 1.0: 10/4 * 3.
+	assert.InDelta(t, 7.5, p.InstabilityIndex(), 1e-9)
+
+	p, err = New("RRRR")
+	require.NoError(t, err)
+	assert.InDelta(t, 10.0/4*3*58.28, p.InstabilityIndex(), 1e-9)
+
+	// X breaks the dipeptides around it: of CXW, only CX and XW exist,
+	// and neither is weighed, while CW (weight 24.68) is never formed.
+	want := 10.0 / 6 * (diwv['A']['A'] + diwv['A']['C'] + diwv['W']['A'] + diwv['A']['A'])
+	for _, residues := range []string{"AACXWAA", "AAC*WAA"} {
+		p, err = New(residues)
+		require.NoError(t, err)
+		assert.InDelta(t, want, p.InstabilityIndex(), 1e-9, residues)
+	}
+
+	for a := range diwv {
+		assert.Len(t, diwv[a], 20, string(a))
+	}
+}
+
+func TestComputeProperties(t *testing.T) {
+	p, err := New("MKVLAAGIVGLLLA*")
+	require.NoError(t, err)
+
+	props := ComputeProperties(p)
+	assert.Equal(t, 14, props.Length)
+	assert.Equal(t, 4, props.Composition["L"])
+	assert.InDelta(t, 100.0*4/14, props.CompositionPercent["L"], 1e-9)
+	assert.Greater(t, props.GRAVY, 0.0)
+	assert.Equal(t, props.InstabilityIndex < StableThreshold, props.Stable)
+
+	total := 0
+	for _, c := range props.Composition {
+		total += c
+	}
+	assert.Equal(t, props.Length, total)
+}
+
+func TestParseFASTA(t *testing.T) {
+	input := ">p1 first protein\nMKV\nLAA\n>p2\nGGG*\n"
+	proteins, err := ParseFASTA(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, proteins, 2)
+	assert.Equal(t, "MKVLAA", proteins[0].Residues)
+	assert.Equal(t, "first protein", proteins[0].Description)
+	assert.Equal(t, "p2", proteins[1].ID)
+
+	_, err = ParseFASTA(strings.NewReader(">bad\nMK1\n"))
+	assert.Error(t, err)
+}
+
+func TestParseCodonUsage(t *testing.T) {
+	input := "# codon table\nUUU 17.6(  714298)  UCU 15.2(  618711)\nATG 22.0\n"
+	usage, err := ParseCodonUsage(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.InDelta(t, 17.6, usage["TTT"], 1e-9)
+	assert.InDelta(t, 15.2, usage["TCT"], 1e-9)
+	assert.InDelta(t, 22.0, usage["ATG"], 1e-9)
+
+	_, err = ParseCodonUsage(strings.NewReader("nothing here\n"))
+	assert.Error(t, err)
+
+	ecoli, err := CodonTable("EColi")
+	require.NoError(t, err)
+	assert.Len(t, ecoli, 64)
+	_, err = CodonTable("martian")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"ecoli", "human"}, CodonTableNames())
+}
+
+func TestBackTranslate(t *testing.T) {
+	usage, err := CodonTable("ecoli")
+	require.NoError(t, err)
+	p, err := New("MKLGEF*")
+	require.NoError(t, err)
+
+	dna, err := BackTranslate(p, BackTranslateOptions{Usage: usage})
+	require.NoError(t, err)
+	assert.Equal(t, "ATGAAACTGGGCGAATTTTAA", dna.Bases)
+
+	back, err := Translate(dna, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, p.Residues, back.Residues)
+	assert.InDelta(t, 1.0, usage.CAI(dna.Bases), 1e-9)
+
+	// Weighted back-translation is reproducible for a seed.
+	opts := BackTranslateOptions{Usage: usage, Strategy: Weighted, Seed: 42}
+	a, err := BackTranslate(p, opts)
+	require.NoError(t, err)
+	b, err := BackTranslate(p, opts)
+	require.NoError(t, err)
+	assert.Equal(t, a.Bases, b.Bases)
+	back, err = Translate(a, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, p.Residues, back.Residues)
+	assert.LessOrEqual(t, usage.CAI(a.Bases), 1.0)
+}
+
+func TestBackTranslateAvoidsSites(t *testing.T) {
+	usage, err := CodonTable("ecoli")
+	require.NoError(t, err)
+	// GAA TTC (E F) would create an EcoRI site.
+	p, err := New("MKLGEF")
+	require.NoError(t, err)
+
+	dna, err := BackTranslate(p, BackTranslateOptions{Usage: usage, Avoid: []string{"GAATTC", "GGATCC"}})
+	require.NoError(t, err)
+	assert.NotContains(t, dna.Bases, "GAATTC")
+	assert.NotContains(t, dna.Bases, "GGATCC")
+
+	back, err := Translate(dna, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, p.Residues, back.Residues)
+
+	// A non-palindromic site is avoided on the reverse strand too.
+	dna, err = BackTranslate(p, BackTranslateOptions{Usage: usage, Avoid: []string{"GGGCGAA"}})
+	require.NoError(t, err)
+	assert.NotContains(t, dna.Bases, "GGGCGAA")
+	assert.NotContains(t, dna.Bases, "TTCGCCC")
+
+	// Impossible: every Met codon is ATG.
+	_, err = BackTranslate(p, BackTranslateOptions{Usage: usage, Avoid: []string{"ATG"}})
+	assert.Error(t, err)
+
+	_, err = BackTranslate(p, BackTranslateOptions{Usage: usage, Avoid: []string{"GAZ"}})
+	assert.Error(t, err)
+	_, err = BackTranslate(p, BackTranslateOptions{})
+	assert.Error(t, err)
+
+	// Enzymes are avoided by their sites.
+	dna, err = BackTranslate(p, BackTranslateOptions{Usage: usage, Avoid: []string{"ecori"}})
+	require.NoError(t, err)
+	assert.NotContains(t, dna.Bases, "GAATTC")
+}
+
+func TestGeneticCodes(t *testing.T) {
+	assert.Contains(t, GeneticCodeNames(), "standard")
+	assert.Contains(t, GeneticCodeNames(), "vertebrate-mitochondrial")
+
+	standard, err := GeneticCodeByName("Standard")
+	require.NoError(t, err)
+	assert.Equal(t, StandardCode, standard)
+
+	mito, err := GeneticCodeByName("vertebrate-mitochondrial")
+	require.NoError(t, err)
+	assert.Len(t, mito, 64)
+	assert.Equal(t, byte('W'), mito["TGA"])
+	assert.Equal(t, byte('*'), mito["AGA"])
+	assert.Equal(t, byte('M'), mito["ATA"])
+
+	_, err = GeneticCodeByName("martian")
+	assert.Error(t, err)
+	_, err = ParseGeneticCode(strings.NewReader("AAs = FFLL\n"))
+	assert.Error(t, err)
+}
+
+func TestDataDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "codon-usage"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "codon-usage", "yeast.txt"), []byte("TTT 26.1\nTTC 18.4\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "enzymes.tsv"), []byte("# name site\nMyI GGATCC\n"), 0o644))
+	t.Setenv(data.EnvDir, dir)
+
+	assert.Equal(t, []string{"ecoli", "human", "yeast"}, CodonTableNames())
+	yeast, err := CodonTable("yeast")
+	require.NoError(t, err)
+	assert.InDelta(t, 26.1, yeast["TTT"], 1e-9)
+
+	site, err := RestrictionSite("myi")
+	require.NoError(t, err)
+	assert.Equal(t, "GGATCC", site)
+	_, err = RestrictionSite("EcoRI")
+	assert.ErrorIs(t, err, data.ErrNotFound, "the enzymes of the directory replace the built-in ones")
+}
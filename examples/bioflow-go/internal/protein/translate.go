@@ -0,0 +1,154 @@
+package protein
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/data"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// StandardCode is the standard genetic code (NCBI translation table 1),
+// mapping DNA codons to one-letter amino acids ('*' for stop). It is
+// always the built-in table, whatever the data directory holds.
+var StandardCode = builtinGeneticCode("standard")
+
+// builtinGeneticCode parses a built-in genetic code, which cannot fail.
+func builtinGeneticCode(name string) map[string]byte {
+	content, err := data.Embedded("genetic-codes/" + name + ".txt")
+	if err != nil {
+		panic(err)
+	}
+	code, err := ParseGeneticCode(bytes.NewReader(content))
+	if err != nil {
+		panic(fmt.Sprintf("genetic code %s: %v", name, err))
+	}
+	return code
+}
+
+// ParseGeneticCode reads a genetic code in the layout of the NCBI
+// translation tables: "AAs = ..." gives the amino acid of each of the 64
+// codons, whose bases are given by the "Base1", "Base2" and "Base3"
+// lines. Other lines, such as "Starts", and lines starting with '#' are
+// ignored.
+//
+// Aria equivalent:
+//
+//	fn parse_genetic_code(reader: Reader) -> Result<Map<String, Char>, ProteinError>
+//	  ensures result.is_ok() implies result.unwrap().len() == 64
+func ParseGeneticCode(r io.Reader) (map[string]byte, error) {
+	rows := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid genetic code line: %q", line)
+		}
+		rows[strings.ToLower(strings.TrimSpace(key))] = strings.ToUpper(strings.TrimSpace(value))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading genetic code: %w", err)
+	}
+	for _, key := range []string{"aas", "base1", "base2", "base3"} {
+		if len(rows[key]) != 64 {
+			return nil, fmt.Errorf("genetic code needs 64 %s, got %d", key, len(rows[key]))
+		}
+	}
+	code := make(map[string]byte, 64)
+	for i := 0; i < 64; i++ {
+		codon := strings.ReplaceAll(string([]byte{rows["base1"][i], rows["base2"][i], rows["base3"][i]}), "U", "T")
+		if strings.Trim(codon, "ACGT") != "" {
+			return nil, fmt.Errorf("invalid codon %s in genetic code", codon)
+		}
+		if _, ok := code[codon]; ok {
+			return nil, fmt.Errorf("codon %s repeated in genetic code", codon)
+		}
+		code[codon] = rows["aas"][i]
+	}
+	return code, nil
+}
+
+// GeneticCodeByName returns a genetic code by name: one of the built-in
+// NCBI tables (such as standard, vertebrate-mitochondrial or bacterial)
+// or one added by the data directory.
+func GeneticCodeByName(name string) (map[string]byte, error) {
+	_, content, err := data.Lookup("genetic-codes", name)
+	if errors.Is(err, data.ErrNotFound) {
+		return nil, fmt.Errorf("unknown genetic code: %s (available: %s)", name, strings.Join(GeneticCodeNames(), ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	code, err := ParseGeneticCode(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("genetic code %s: %w", name, err)
+	}
+	return code, nil
+}
+
+// GeneticCodeNames returns the names of the genetic codes.
+func GeneticCodeNames() []string {
+	return data.Names("genetic-codes")
+}
+
+// TranslateCodon translates one codon (DNA or RNA) with the standard code.
+// Codons containing ambiguous bases translate to 'X'.
+func TranslateCodon(codon string) byte {
+	key := strings.ReplaceAll(strings.ToUpper(codon), "U", "T")
+	if aa, ok := StandardCode[key]; ok {
+		return aa
+	}
+	return 'X'
+}
+
+// IsStopCodon reports whether a codon is a stop codon.
+func IsStopCodon(codon string) bool {
+	return TranslateCodon(codon) == Stop
+}
+
+// IsStartCodon reports whether a codon is ATG (AUG).
+func IsStartCodon(codon string) bool {
+	key := strings.ReplaceAll(strings.ToUpper(codon), "U", "T")
+	return key == "ATG"
+}
+
+// Translate translates a nucleotide sequence from the given frame (0, 1
+// or 2) with the standard code. Trailing bases that don't fill a codon are
+// ignored. Stops are kept as '*' unless toStop is set, in which case
+// translation ends before the first stop.
+//
+// Aria equivalent:
+//
+//	fn translate(seq: Sequence, frame: Int, to_stop: Bool) -> Result<Protein, ProteinError>
+//	  requires frame >= 0 and frame < 3
+//	  requires seq.len() >= frame + 3
+func Translate(seq *sequence.Sequence, frame int, toStop bool) (*Protein, error) {
+	if frame < 0 || frame > 2 {
+		return nil, fmt.Errorf("frame must be 0, 1 or 2, got %d", frame)
+	}
+	if seq.Len()-frame < 3 {
+		return nil, fmt.Errorf("sequence too short to translate in frame %d", frame)
+	}
+
+	var sb strings.Builder
+	for i := frame; i+3 <= seq.Len(); i += 3 {
+		aa := TranslateCodon(seq.Bases[i : i+3])
+		if aa == Stop && toStop {
+			break
+		}
+		sb.WriteByte(aa)
+	}
+
+	if sb.Len() == 0 {
+		return nil, fmt.Errorf("translation is empty")
+	}
+	return &Protein{Residues: sb.String(), ID: seq.ID, Description: seq.Description}, nil
+}
@@ -0,0 +1,185 @@
+package quality
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/data"
+)
+
+// Adapter is a named sequencing adapter, as read into at the 3' end of
+// reads whose insert is shorter than the read.
+type Adapter struct {
+	Name     string `json:"name"`
+	Sequence string `json:"sequence"`
+}
+
+// TruSeqAdapters are the Illumina TruSeq read 1 and read 2 adapters.
+var TruSeqAdapters = builtinAdapters("truseq")
+
+// NexteraAdapters is the Illumina Nextera transposase adapter.
+var NexteraAdapters = builtinAdapters("nextera")
+
+// FastQCAdapters are the 12 bp adapter prefixes FastQC searches for.
+var FastQCAdapters = builtinAdapters("fastqc")
+
+// DefaultAdapters returns the TruSeq and Nextera adapters.
+func DefaultAdapters() []Adapter {
+	return append(append([]Adapter{}, TruSeqAdapters...), NexteraAdapters...)
+}
+
+// builtinAdapters parses a built-in adapter set, which cannot fail.
+func builtinAdapters(name string) []Adapter {
+	content, err := data.Embedded("adapters/" + name + ".fa")
+	if err != nil {
+		panic(err)
+	}
+	adapters, err := ParseAdapterFASTA(bytes.NewReader(content))
+	if err != nil {
+		panic(fmt.Sprintf("adapter set %s: %v", name, err))
+	}
+	return adapters
+}
+
+// ParseAdapterFASTA reads adapters from FASTA, named by their headers.
+//
+// Aria equivalent:
+//
+//	fn parse_adapter_fasta(reader: Reader) -> Result<[Adapter], QualityError>
+//	  ensures result.is_ok() implies result.unwrap().all(|a| a.sequence.len() > 0)
+func ParseAdapterFASTA(r io.Reader) ([]Adapter, error) {
+	var adapters []Adapter
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line[0] == ';':
+		case line[0] == '>':
+			adapters = append(adapters, Adapter{Name: strings.TrimSpace(line[1:])})
+		case len(adapters) == 0:
+			return nil, fmt.Errorf("adapter sequence before any header")
+		default:
+			adapters[len(adapters)-1].Sequence += strings.ToUpper(line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading adapters: %w", err)
+	}
+	if len(adapters) == 0 {
+		return nil, fmt.Errorf("no adapters found")
+	}
+	for _, a := range adapters {
+		if a.Sequence == "" || strings.Trim(a.Sequence, "ACGTN") != "" {
+			return nil, fmt.Errorf("invalid sequence of adapter %q", a.Name)
+		}
+	}
+	return adapters, nil
+}
+
+// AdapterSet returns an adapter set by name: a built-in one (truseq,
+// nextera or fastqc) or one added by the data directory.
+func AdapterSet(name string) ([]Adapter, error) {
+	_, content, err := data.Lookup("adapters", name)
+	if err != nil {
+		return nil, err
+	}
+	adapters, err := ParseAdapterFASTA(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("adapter set %s: %w", name, err)
+	}
+	return adapters, nil
+}
+
+// AdapterSetNames returns the names of the adapter sets.
+func AdapterSetNames() []string {
+	return data.Names("adapters")
+}
+
+// Adapter matching defaults, as in cutadapt.
+const (
+	DefaultAdapterErrorRate  = 0.1
+	DefaultAdapterMinOverlap = 3
+)
+
+// ParseAdapters resolves a comma-separated list of adapter sets (such as
+// truseq, nextera, or default for both) and adapter sequences. Sets are
+// looked up in the data directory too.
+//
+// Aria equivalent:
+//
+//	fn parse_adapters(spec: String) -> Result<[Adapter], QualityError>
+func ParseAdapters(spec string) ([]Adapter, error) {
+	var adapters []Adapter
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		names := []string{item}
+		switch strings.ToLower(item) {
+		case "":
+			continue
+		case "default":
+			names = []string{"truseq", "nextera"}
+		}
+		for _, name := range names {
+			set, err := AdapterSet(name)
+			if errors.Is(err, data.ErrNotFound) {
+				bases := strings.ToUpper(item)
+				if strings.Trim(bases, "ACGTN") != "" {
+					return nil, fmt.Errorf("unknown adapter %q (want %s, default or a sequence)", item, strings.Join(AdapterSetNames(), ", "))
+				}
+				set = []Adapter{{Name: bases, Sequence: bases}}
+			} else if err != nil {
+				return nil, err
+			}
+			adapters = append(adapters, set...)
+		}
+	}
+	return adapters, nil
+}
+
+// FindAdapter returns where the first of f.Adapters starts in bases, read
+// through in full or running off the 3' end of the read, and its name. A
+// match may have up to AdapterErrorRate mismatches per base of overlap,
+// and an N in either sequence matches anything. It returns len(bases) and
+// "" when no adapter overlaps the read by AdapterMinOverlap bases or
+// more.
+//
+// Aria equivalent:
+//
+//	fn find_adapter(self, bases: String) -> (Int, String)
+//	  ensures result.0 >= 0 and result.0 <= bases.len()
+func (f *Filter) FindAdapter(bases string) (int, string) {
+	bases = strings.ToUpper(bases)
+	minOverlap := f.AdapterMinOverlap
+	if minOverlap <= 0 {
+		minOverlap = DefaultAdapterMinOverlap
+	}
+	best, name := len(bases), ""
+	for _, a := range f.Adapters {
+		adapter := strings.ToUpper(a.Sequence)
+		for p := 0; p < best && p+minOverlap <= len(bases); p++ {
+			overlap := min(len(adapter), len(bases)-p)
+			if matchesWithin(bases[p:p+overlap], adapter[:overlap], int(f.AdapterErrorRate*float64(overlap))) {
+				best, name = p, a.Name
+				break
+			}
+		}
+	}
+	return best, name
+}
+
+// matchesWithin reports whether a and b, of equal length, differ at no
+// more than maxMismatches positions.
+func matchesWithin(a, b string, maxMismatches int) bool {
+	for i := 0; i < len(a); i++ {
+		if a[i] != b[i] && a[i] != 'N' && b[i] != 'N' {
+			if maxMismatches--; maxMismatches < 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -1,6 +1,7 @@
 package quality
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/aria-lang/bioflow-go/internal/sequence"
@@ -23,6 +24,12 @@ type Filter struct {
 	QualityThreshold   int     // Threshold for quality-based trimming
 	WindowSize         int     // Window size for sliding window trimming
 	MinWindowQuality   float64 // Minimum average quality in window
+
+	// Adapters are trimmed from the 3' end, with everything after
+	// them, before quality trimming. None are by default.
+	Adapters          []Adapter
+	AdapterErrorRate  float64 // Mismatches allowed per base of adapter overlap
+	AdapterMinOverlap int     // Shortest adapter prefix trimmed at the read end (0: 3)
 }
 
 // DefaultFilter creates a filter with default settings.
@@ -160,8 +167,23 @@ func (f *Filter) TrimAndFilter(seq *sequence.Sequence, scores *Scores) (*TrimAnd
 		return nil, fmt.Errorf("sequence and quality scores must have the same length")
 	}
 
-	// Perform sliding window trimming
-	trimStart, trimEnd := f.SlidingWindowTrim(scores)
+	// Trim adapters, then perform sliding window trimming on what is left
+	adapterStart, adapter := seq.Len(), ""
+	if len(f.Adapters) > 0 {
+		adapterStart, adapter = f.FindAdapter(seq.Bases)
+	}
+	if adapterStart == 0 {
+		return &TrimAndFilterResult{
+			Passed:  false,
+			Reason:  "no bases left after adapter trimming",
+			Adapter: adapter,
+		}, nil
+	}
+	beforeAdapter, err := scores.Slice(0, adapterStart)
+	if err != nil {
+		return nil, err
+	}
+	trimStart, trimEnd := f.SlidingWindowTrim(beforeAdapter)
 
 	// Check if remaining sequence is long enough
 	trimmedLen := trimEnd - trimStart
@@ -173,6 +195,7 @@ func (f *Filter) TrimAndFilter(seq *sequence.Sequence, scores *Scores) (*TrimAnd
 			TrimEnd:     trimEnd,
 			TrimmedSeq:  nil,
 			TrimmedQual: nil,
+			Adapter:     adapter,
 		}, nil
 	}
 
@@ -201,6 +224,7 @@ func (f *Filter) TrimAndFilter(seq *sequence.Sequence, scores *Scores) (*TrimAnd
 		TrimmedSeq:  trimmedSeq,
 		TrimmedQual: trimmedQual,
 		MeanQuality: result.MeanQuality,
+		Adapter:     adapter,
 	}, nil
 }
 
@@ -213,10 +237,24 @@ type TrimAndFilterResult struct {
 	TrimmedSeq  *sequence.Sequence
 	TrimmedQual *Scores
 	MeanQuality float64
+	// Adapter names the adapter trimmed, if any.
+	Adapter string
 }
 
 // BatchFilter filters multiple sequences.
 func (f *Filter) BatchFilter(sequences []*sequence.Sequence, qualities []*Scores) (*BatchFilterResult, error) {
+	return f.BatchFilterContext(context.Background(), sequences, qualities)
+}
+
+// BatchFilterContext filters multiple sequences as BatchFilter does, and
+// stops with the context's error once ctx is done.
+//
+// Aria equivalent:
+//
+//	fn batch_filter_context(self, ctx: Context, sequences: [Sequence], qualities: [QualityScores]) -> Result<BatchFilterResult, FilterError>
+//	  requires sequences.len() == qualities.len()
+//	  ensures result.is_ok() implies result.unwrap().total_processed == sequences.len()
+func (f *Filter) BatchFilterContext(ctx context.Context, sequences []*sequence.Sequence, qualities []*Scores) (*BatchFilterResult, error) {
 	if len(sequences) != len(qualities) {
 		return nil, fmt.Errorf("sequences and qualities must have the same length")
 	}
@@ -229,10 +267,18 @@ func (f *Filter) BatchFilter(sequences []*sequence.Sequence, qualities []*Scores
 	}
 
 	for i := range sequences {
+		if i%checkEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("filtering: %w", err)
+			}
+		}
 		filterResult, err := f.TrimAndFilter(sequences[i], qualities[i])
 		if err != nil {
 			return nil, err
 		}
+		if filterResult.Adapter != "" {
+			result.AdapterTrimmed++
+		}
 
 		if filterResult.Passed {
 			result.PassedSequences = append(result.PassedSequences, filterResult.TrimmedSeq)
@@ -250,6 +296,10 @@ func (f *Filter) BatchFilter(sequences []*sequence.Sequence, qualities []*Scores
 	return result, nil
 }
 
+// checkEvery is the number of reads filtered between checks of the
+// context.
+const checkEvery = 256
+
 // BatchFilterResult represents the result of batch filtering.
 type BatchFilterResult struct {
 	TotalProcessed   int
@@ -259,6 +309,7 @@ type BatchFilterResult struct {
 	PassedQualities  []*Scores
 	FailedIndices    []int
 	FailReasons      map[int]string
+	AdapterTrimmed   int // Reads an adapter was trimmed from
 }
 
 // PassRate returns the proportion of sequences that passed filtering.
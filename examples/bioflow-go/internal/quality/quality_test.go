@@ -0,0 +1,224 @@
+package quality
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRead returns a read of bases, all of quality q.
+func testRead(t *testing.T, bases string, q int) (*sequence.Sequence, *Scores) {
+	t.Helper()
+	seq, err := sequence.New(bases)
+	require.NoError(t, err)
+	values := make([]int, len(bases))
+	for i := range values {
+		values[i] = q
+	}
+	scores, err := New(values)
+	require.NoError(t, err)
+	return seq, scores
+}
+
+func TestBatchFilterContext(t *testing.T) {
+	var seqs []*sequence.Sequence
+	var quals []*Scores
+	for i := 0; i < 3*checkEvery; i++ {
+		q := 35
+		if i%3 == 0 {
+			q = 5
+		}
+		seq, scores := testRead(t, strings.Repeat("ACGT", 15), q)
+		seqs, quals = append(seqs, seq), append(quals, scores)
+	}
+	filter := DefaultFilter()
+
+	want, err := filter.BatchFilter(seqs, quals)
+	require.NoError(t, err)
+	assert.Equal(t, 3*checkEvery, want.TotalProcessed)
+	assert.Equal(t, checkEvery, want.FailedCount)
+	assert.Equal(t, 0, want.FailedIndices[0])
+
+	got, err := filter.BatchFilterContext(context.Background(), seqs, quals)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = filter.BatchFilterContext(ctx, seqs, quals)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = filter.BatchFilterContext(context.Background(), seqs, quals[1:])
+	assert.Error(t, err)
+}
+
+// testInsert is a read insert matching none of the built-in adapters.
+var testInsert = strings.Repeat("ACGTTGCA", 8)[:60]
+
+func TestFindAdapter(t *testing.T) {
+	truseq1 := TruSeqAdapters[0].Sequence
+	nextera := NexteraAdapters[0].Sequence
+	// mutate replaces the bases of s at positions with their complement.
+	mutate := func(s string, positions ...int) string {
+		b := []byte(s)
+		for _, i := range positions {
+			b[i] = map[byte]byte{'A': 'T', 'C': 'G', 'G': 'C', 'T': 'A'}[b[i]]
+		}
+		return string(b)
+	}
+
+	tests := []struct {
+		name       string
+		bases      string
+		adapters   []Adapter
+		errorRate  float64
+		minOverlap int
+		wantStart  int // -1: no adapter
+		wantName   string
+	}{
+		{"full adapter", testInsert + truseq1 + "GGCCAATT", TruSeqAdapters, 0.1, 0, 60, "TruSeq Read 1"},
+		{"full adapter at the end", testInsert + truseq1, TruSeqAdapters, 0.1, 0, 60, "TruSeq Read 1"},
+		{"partial 3' overlap", testInsert + truseq1[:5], TruSeqAdapters, 0.1, 0, 60, "TruSeq Read 1"},
+		{"overlap of the default minimum", testInsert + truseq1[:3], TruSeqAdapters, 0.1, 0, 60, "TruSeq Read 1"},
+		{"overlap below the default minimum", testInsert + truseq1[:2], TruSeqAdapters, 0.1, 0, -1, ""},
+		{"overlap below a set minimum", testInsert + truseq1[:5], TruSeqAdapters, 0.1, 6, -1, ""},
+		{"mismatches within the error rate", testInsert + mutate(truseq1, 4, 15, 30), TruSeqAdapters, 0.1, 0, 60, "TruSeq Read 1"},
+		{"mismatches beyond the error rate", testInsert + mutate(truseq1, 4, 10, 15, 30), TruSeqAdapters, 0.1, 0, -1, ""},
+		{"mismatch with no errors allowed", testInsert + mutate(truseq1, 20), TruSeqAdapters, 0, 0, -1, ""},
+		{"partial overlap too short for a mismatch", testInsert + mutate(truseq1[:9], 4), TruSeqAdapters, 0.1, 0, -1, ""},
+		{"N in the read matches anything", testInsert + "AGATNNNAAGAGCACACGTCTGAACTCCAGTCA", TruSeqAdapters, 0, 0, 60, "TruSeq Read 1"},
+		{"N in the adapter matches anything", testInsert + truseq1, []Adapter{{Name: "n", Sequence: "AGANNGGAAG"}}, 0, 0, 60, "n"},
+		{"lowercase read", strings.ToLower(testInsert + truseq1), TruSeqAdapters, 0.1, 0, 60, "TruSeq Read 1"},
+		{"adapter at the start", truseq1 + testInsert, TruSeqAdapters, 0.1, 0, 0, "TruSeq Read 1"},
+		{"earliest of the default set", testInsert + nextera + truseq1, DefaultAdapters(), 0.1, 0, 60, "Nextera"},
+		{"no adapters", testInsert + truseq1, nil, 0.1, 0, -1, ""},
+		{"no adapter in the read", testInsert, DefaultAdapters(), 0.1, 0, -1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Filter{Adapters: tt.adapters, AdapterErrorRate: tt.errorRate, AdapterMinOverlap: tt.minOverlap}
+			start, name := f.FindAdapter(tt.bases)
+			want := tt.wantStart
+			if want < 0 {
+				want = len(tt.bases)
+			}
+			assert.Equal(t, want, start)
+			assert.Equal(t, tt.wantName, name)
+		})
+	}
+}
+
+func TestMatchesWithin(t *testing.T) {
+	tests := []struct {
+		a, b          string
+		maxMismatches int
+		want          bool
+	}{
+		{"ACGT", "ACGT", 0, true},
+		{"ACGT", "ACCT", 0, false},
+		{"ACGT", "ACCT", 1, true},
+		{"AGGA", "ACCT", 2, false},
+		{"ANGT", "ACGT", 0, true},
+		{"ACGT", "NNNN", 0, true},
+		{"", "", 0, true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchesWithin(tt.a, tt.b, tt.maxMismatches), "%s %s %d", tt.a, tt.b, tt.maxMismatches)
+	}
+}
+
+func TestParseAdapters(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []Adapter
+		wantErr string
+	}{
+		{"empty", "", nil, ""},
+		{"default set", "default", DefaultAdapters(), ""},
+		{"default set in capitals", " DEFAULT ", DefaultAdapters(), ""},
+		{"named set", "nextera", NexteraAdapters, ""},
+		{"sequences", "acgtn, TTGCA", []Adapter{{Name: "ACGTN", Sequence: "ACGTN"}, {Name: "TTGCA", Sequence: "TTGCA"}}, ""},
+		{"set and sequence", "truseq,,CTGTCT", append(append([]Adapter{}, TruSeqAdapters...), Adapter{Name: "CTGTCT", Sequence: "CTGTCT"}), ""},
+		{"unknown set", "illumina", nil, `unknown adapter "illumina"`},
+		{"RNA bases", "ACGU", nil, `unknown adapter "ACGU"`},
+		{"bad item after good ones", "truseq,AC-GT", nil, `unknown adapter "AC-GT"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAdapters(tt.spec)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	assert.Len(t, DefaultAdapters(), 3)
+	assert.Equal(t, "AGATCGGAAGAGCACACGTCTGAACTCCAGTCA", TruSeqAdapters[0].Sequence)
+}
+
+func TestParseAdapterFASTA(t *testing.T) {
+	got, err := ParseAdapterFASTA(strings.NewReader("; comment\n>a one\nacgt\nNN\n\n>b\nTTTT\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []Adapter{{Name: "a one", Sequence: "ACGTNN"}, {Name: "b", Sequence: "TTTT"}}, got)
+
+	for _, bad := range []string{"", "ACGT\n>a\nACGT\n", ">a\n", ">a\nACGU\n", ">a\nACGT\n>b\n"} {
+		_, err := ParseAdapterFASTA(strings.NewReader(bad))
+		assert.Error(t, err, "%q", bad)
+	}
+}
+
+func TestTrimAndFilterAdapters(t *testing.T) {
+	filter := DefaultFilter()
+	filter.Adapters = DefaultAdapters()
+	filter.AdapterErrorRate = DefaultAdapterErrorRate
+
+	tests := []struct {
+		name        string
+		bases       string
+		wantPassed  bool
+		wantReason  string
+		wantAdapter string
+		wantBases   string
+	}{
+		{"adapter trimmed", testInsert + TruSeqAdapters[1].Sequence + "ACGT", true, "", "TruSeq Read 2", testInsert},
+		{"partial adapter trimmed", testInsert + NexteraAdapters[0].Sequence[:8], true, "", "Nextera", testInsert},
+		{"no adapter", testInsert + "ACGTTGCA", true, "", "", testInsert + "ACGTTGCA"},
+		{"too short once trimmed", testInsert[:30] + TruSeqAdapters[0].Sequence, false, "sequence too short after trimming: 30", "TruSeq Read 1", ""},
+		{"adapter dimer", TruSeqAdapters[0].Sequence + "ACGTTGCAACGT", false, "no bases left after adapter trimming", "TruSeq Read 1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seq, scores := testRead(t, tt.bases, 35)
+			result, err := filter.TrimAndFilter(seq, scores)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPassed, result.Passed)
+			assert.Contains(t, result.Reason, tt.wantReason)
+			assert.Equal(t, tt.wantAdapter, result.Adapter)
+			if tt.wantPassed {
+				assert.Equal(t, tt.wantBases, result.TrimmedSeq.Bases)
+				assert.Equal(t, len(tt.wantBases), result.TrimmedQual.Len())
+			}
+		})
+	}
+
+	// Batches count the reads an adapter was trimmed from, passed or not.
+	var seqs []*sequence.Sequence
+	var quals []*Scores
+	for _, tt := range tests {
+		seq, scores := testRead(t, tt.bases, 35)
+		seqs, quals = append(seqs, seq), append(quals, scores)
+	}
+	batch, err := filter.BatchFilter(seqs, quals)
+	require.NoError(t, err)
+	assert.Equal(t, 4, batch.AdapterTrimmed)
+	assert.Equal(t, 3, batch.PassedCount)
+}
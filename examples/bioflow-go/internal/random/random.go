@@ -0,0 +1,95 @@
+// Package random is the randomness policy of this module: every
+// stochastic feature (bootstrap resampling, weighted codon
+// back-translation, subsampling, shuffling) draws from an explicit
+// *rand.Rand created here from a seed, never from the global math/rand
+// source or the clock.
+//
+// Reproducibility guarantees:
+//
+//   - The same inputs, options and seed give bit-for-bit identical
+//     results on every run and platform. math/rand's seeded generator is
+//     fixed by the Go 1 compatibility promise.
+//   - Seeds default to DefaultSeed, so runs are reproducible unless a
+//     seed is changed deliberately. Zero is an ordinary seed.
+//   - Features that take both a Seed and a *rand.Rand use the Rand when
+//     it is set. Sharing one Rand between features makes each result
+//     depend on the calls before it; Derive gives each feature its own
+//     stream instead.
+//   - A *rand.Rand is not safe for concurrent use. Results of parallel
+//     work are only reproducible if each worker has its own Rand.
+//
+// Comparison with Aria:
+//
+//	Aria tracks randomness as an effect, so a function that draws random
+//	numbers says so in its signature:
+//	  fn sample(items: [T], k: Int) -> [T] with Random
+//
+//	Go passes the generator, or its seed, explicitly instead.
+package random
+
+import (
+	"hash/fnv"
+	"math/rand"
+)
+
+// DefaultSeed is the seed used when none is given.
+const DefaultSeed int64 = 1
+
+// New returns a generator seeded with seed.
+func New(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// Or returns rng, or a new generator seeded with seed if rng is nil.
+func Or(rng *rand.Rand, seed int64) *rand.Rand {
+	if rng != nil {
+		return rng
+	}
+	return New(seed)
+}
+
+// Derive returns the seed of a named stream derived from seed, so that
+// the stochastic steps of one run can be seeded once yet draw
+// independent numbers, and adding a step does not change the others.
+//
+// Aria equivalent:
+//
+//	fn derive(seed: Int, stream: String) -> Int
+//	  ensures derive(seed, stream) == derive(seed, stream)
+func Derive(seed int64, stream string) int64 {
+	h := fnv.New64a()
+	var b [8]byte
+	for i := range b {
+		b[i] = byte(uint64(seed) >> (8 * i))
+	}
+	h.Write(b[:])
+	h.Write([]byte(stream))
+	return int64(h.Sum64())
+}
+
+// Sample chooses k of the indices 0..n-1 uniformly without replacement
+// and returns them in increasing order, so sampled items keep their
+// original order. If k >= n all indices are returned.
+//
+// Aria equivalent:
+//
+//	fn sample(n: Int, k: Int) -> [Int] with Random
+//	  requires n >= 0 and k >= 0
+//	  ensures result.len() == min(n, k)
+//	  ensures result.is_sorted()
+func Sample(rng *rand.Rand, n, k int) []int {
+	if k > n {
+		k = n
+	}
+	if k < 0 {
+		k = 0
+	}
+	// Selection sampling (Knuth's Algorithm S): one pass, in order.
+	out := make([]int, 0, k)
+	for i := 0; i < n && len(out) < k; i++ {
+		if rng.Intn(n-i) < k-len(out) {
+			out = append(out, i)
+		}
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package random
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSample(t *testing.T) {
+	a := Sample(New(42), 100, 10)
+	assert.Len(t, a, 10)
+	assert.Equal(t, a, Sample(New(42), 100, 10), "same seed, same sample")
+	assert.NotEqual(t, a, Sample(New(43), 100, 10))
+	for i := 1; i < len(a); i++ {
+		assert.Less(t, a[i-1], a[i])
+	}
+	assert.Equal(t, []int{0, 1, 2}, Sample(New(1), 3, 5))
+	assert.Empty(t, Sample(New(1), 3, 0))
+
+	// Every index is about equally likely.
+	counts := make([]int, 10)
+	rng := New(7)
+	for i := 0; i < 10000; i++ {
+		for _, j := range Sample(rng, 10, 3) {
+			counts[j]++
+		}
+	}
+	for _, c := range counts {
+		assert.InDelta(t, 3000, c, 200)
+	}
+}
+
+func TestDerive(t *testing.T) {
+	assert.Equal(t, Derive(1, "sample"), Derive(1, "sample"))
+	assert.NotEqual(t, Derive(1, "sample"), Derive(1, "shuffle"))
+	assert.NotEqual(t, Derive(1, "sample"), Derive(2, "sample"))
+
+	rng := New(5)
+	assert.Same(t, rng, Or(rng, 9))
+	assert.Equal(t, New(9).Int63(), Or(nil, 9).Int63())
+}
@@ -0,0 +1,370 @@
+// Package readgroup models the provenance of sequencing reads: which
+// sample, library, run and lane they came from and on which platform.
+//
+// Read groups are recovered from Illumina and SRA read names, or declared
+// in small "key=value" sidecar files next to the reads, and written out
+// as SAM @RG header lines so that multi-sample processing keeps track of
+// where each read came from.
+//
+// Comparison with Aria:
+//
+//	Aria states the identity requirement on the type:
+//	  struct ReadGroup
+//	    invariant self.id.len() > 0
+//	    invariant not self.id.contains('\t')
+//
+//	Go fills in a derived ID when none is given and rejects tabs and
+//	newlines when parsing.
+package readgroup
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ReadGroup describes where a set of reads came from. Lane is 0 when
+// unknown.
+type ReadGroup struct {
+	ID       string `json:"id"`
+	Sample   string `json:"sample,omitempty"`
+	Library  string `json:"library,omitempty"`
+	Run      string `json:"run,omitempty"`
+	Flowcell string `json:"flowcell,omitempty"`
+	Lane     int    `json:"lane,omitempty"`
+	Barcode  string `json:"barcode,omitempty"`
+	Platform string `json:"platform,omitempty"`
+}
+
+// IsZero reports whether no field is set.
+func (g ReadGroup) IsZero() bool {
+	return g == ReadGroup{}
+}
+
+// PlatformUnit returns the SAM PU value: flowcell (or run), lane and
+// barcode joined by dots, skipping unknown parts.
+func (g ReadGroup) PlatformUnit() string {
+	unit := g.lane()
+	if g.Barcode != "" {
+		if unit != "" {
+			unit += "."
+		}
+		unit += g.Barcode
+	}
+	return unit
+}
+
+// lane returns the flowcell (or run) and lane joined by a dot, skipping
+// unknown parts.
+func (g ReadGroup) lane() string {
+	unit := g.Flowcell
+	if unit == "" {
+		unit = g.Run
+	}
+	if g.Lane > 0 {
+		if unit != "" {
+			unit += "."
+		}
+		unit += strconv.Itoa(g.Lane)
+	}
+	return unit
+}
+
+// DefaultID derives an ID from the flowcell (or run) and lane, as is
+// conventional, falling back to the sample, then to "unknown". The
+// barcode is left out so that reads named with and without their index
+// comment, as in FASTQ and SAM, fall into the same group.
+func (g ReadGroup) DefaultID() string {
+	if id := g.lane(); id != "" {
+		return id
+	}
+	if g.Sample != "" {
+		return g.Sample
+	}
+	return "unknown"
+}
+
+// WithDefaults returns the group with ID set to DefaultID if it is empty.
+func (g ReadGroup) WithDefaults() ReadGroup {
+	if g.ID == "" {
+		g.ID = g.DefaultID()
+	}
+	return g
+}
+
+// Merge returns g with every field that is set in other replacing its
+// own, so declared metadata overrides metadata recovered from read names.
+func (g ReadGroup) Merge(other ReadGroup) ReadGroup {
+	for _, f := range []struct{ dst, src *string }{
+		{&g.ID, &other.ID}, {&g.Sample, &other.Sample}, {&g.Library, &other.Library},
+		{&g.Run, &other.Run}, {&g.Flowcell, &other.Flowcell}, {&g.Barcode, &other.Barcode},
+		{&g.Platform, &other.Platform},
+	} {
+		if *f.src != "" {
+			*f.dst = *f.src
+		}
+	}
+	if other.Lane > 0 {
+		g.Lane = other.Lane
+	}
+	return g
+}
+
+// Name patterns recognized by FromReadName.
+var (
+	// sraName matches SRA/ENA/DDBJ run accessions such as SRR001666.1.
+	sraName = regexp.MustCompile(`^([SED]RR\d+)\.\d+`)
+)
+
+// FromReadName recovers a read group from a read name, which may include
+// the comment after the first space. It understands Casava 1.8+ names
+// ("instrument:run:flowcell:lane:tile:x:y 1:N:0:barcode"), older Illumina
+// names ("instrument:lane:tile:x:y#barcode/1") and SRA accessions
+// ("SRR001666.1"). The ID is derived with DefaultID.
+//
+// Aria equivalent:
+//
+//	fn from_read_name(name: String) -> Option<ReadGroup>
+//	  ensures result.is_some() implies result.unwrap().id.len() > 0
+func FromReadName(name string) (ReadGroup, bool) {
+	name = strings.TrimPrefix(name, "@")
+	id, comment := name, ""
+	if i := strings.IndexAny(name, " \t"); i >= 0 {
+		id, comment = name[:i], strings.TrimSpace(name[i+1:])
+	}
+
+	var g ReadGroup
+	fields := strings.Split(id, ":")
+	switch {
+	case len(fields) >= 7:
+		lane, err := strconv.Atoi(fields[3])
+		if err != nil {
+			return ReadGroup{}, false
+		}
+		g = ReadGroup{
+			Run: fields[0] + "_" + fields[1], Flowcell: fields[2], Lane: lane, Platform: "ILLUMINA",
+		}
+		// The comment is read:filtered:control:barcode.
+		if words := strings.Fields(comment); len(words) > 0 {
+			if c := strings.Split(words[0], ":"); len(c) == 4 {
+				g.Barcode = c[3]
+			}
+		}
+	case len(fields) == 5:
+		last := fields[4]
+		barcode := ""
+		if i := strings.IndexByte(last, '#'); i >= 0 {
+			last, barcode = last[:i], last[i+1:]
+			if j := strings.IndexByte(barcode, '/'); j >= 0 {
+				barcode = barcode[:j]
+			}
+		}
+		lane, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return ReadGroup{}, false
+		}
+		if _, err := strconv.Atoi(strings.SplitN(last, "/", 2)[0]); err != nil {
+			return ReadGroup{}, false
+		}
+		if barcode == "0" {
+			barcode = ""
+		}
+		g = ReadGroup{Run: fields[0], Lane: lane, Barcode: barcode, Platform: "ILLUMINA"}
+	default:
+		m := sraName.FindStringSubmatch(id)
+		if m == nil {
+			return ReadGroup{}, false
+		}
+		g = ReadGroup{Run: m[1]}
+	}
+	return g.WithDefaults(), true
+}
+
+// sidecarKeys maps sidecar keys, including the SAM @RG tags, to fields.
+var sidecarKeys = map[string]string{
+	"id": "id", "sample": "sample", "sm": "sample", "library": "library", "lb": "library",
+	"run": "run", "flowcell": "flowcell", "lane": "lane", "barcode": "barcode",
+	"platform": "platform", "pl": "platform",
+}
+
+// Parse reads a sidecar file of "key=value" (or "key: value", or
+// tab-separated) lines. Blank lines and lines starting with '#' are
+// ignored. Keys are id, sample, library, run, flowcell, lane, barcode and
+// platform, or the SAM tags SM, LB and PL.
+//
+// Aria equivalent:
+//
+//	fn parse(reader: Reader) -> Result<ReadGroup, ReadGroupError>
+//	  ensures result.is_ok() implies result.unwrap().lane >= 0
+func Parse(r io.Reader) (ReadGroup, error) {
+	var g ReadGroup
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		i := strings.IndexAny(line, "=:\t")
+		if i < 0 {
+			return ReadGroup{}, fmt.Errorf("line %d: expected key=value", lineNum)
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:i]))
+		value := strings.TrimSpace(line[i+1:])
+		field, ok := sidecarKeys[key]
+		if !ok {
+			return ReadGroup{}, fmt.Errorf("line %d: unknown key %q", lineNum, key)
+		}
+		if err := g.set(field, value); err != nil {
+			return ReadGroup{}, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ReadGroup{}, fmt.Errorf("reading read group: %w", err)
+	}
+	return g, nil
+}
+
+// ReadFile reads a sidecar file.
+func ReadFile(filename string) (ReadGroup, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return ReadGroup{}, fmt.Errorf("opening file: %w", err)
+	}
+	defer file.Close()
+
+	return Parse(file)
+}
+
+// set sets a field by name.
+func (g *ReadGroup) set(field, value string) error {
+	if strings.ContainsAny(value, "\t\n") {
+		return fmt.Errorf("%s must not contain tabs", field)
+	}
+	switch field {
+	case "id":
+		g.ID = value
+	case "sample":
+		g.Sample = value
+	case "library":
+		g.Library = value
+	case "run":
+		g.Run = value
+	case "flowcell":
+		g.Flowcell = value
+	case "barcode":
+		g.Barcode = value
+	case "platform":
+		g.Platform = strings.ToUpper(value)
+	case "lane":
+		lane, err := strconv.Atoi(value)
+		if err != nil || lane < 0 {
+			return fmt.Errorf("invalid lane %q", value)
+		}
+		g.Lane = lane
+	}
+	return nil
+}
+
+// SAMHeaderLine formats the group as a SAM @RG header line (without
+// newline). The ID is derived if empty.
+//
+// Aria equivalent:
+//
+//	fn sam_header_line(self) -> String
+//	  ensures result.starts_with("@RG\tID:")
+func (g ReadGroup) SAMHeaderLine() string {
+	g = g.WithDefaults()
+	fields := []string{"@RG", "ID:" + g.ID}
+	for _, f := range []struct{ tag, value string }{
+		{"SM", g.Sample}, {"LB", g.Library}, {"PL", g.Platform}, {"PU", g.PlatformUnit()},
+	} {
+		if f.value != "" {
+			fields = append(fields, f.tag+":"+f.value)
+		}
+	}
+	return strings.Join(fields, "\t")
+}
+
+// ParseSAMHeaderLine parses a SAM @RG header line. PU is kept as the
+// flowcell, since its parts cannot be told apart reliably.
+func ParseSAMHeaderLine(line string) (ReadGroup, error) {
+	fields := strings.Split(strings.TrimRight(line, "\r\n"), "\t")
+	if fields[0] != "@RG" {
+		return ReadGroup{}, fmt.Errorf("not an @RG line")
+	}
+	var g ReadGroup
+	for _, f := range fields[1:] {
+		if len(f) < 3 || f[2] != ':' {
+			continue
+		}
+		value := f[3:]
+		switch f[:2] {
+		case "ID":
+			g.ID = value
+		case "SM":
+			g.Sample = value
+		case "LB":
+			g.Library = value
+		case "PL":
+			g.Platform = value
+		case "PU":
+			g.Flowcell = value
+		}
+	}
+	if g.ID == "" {
+		return ReadGroup{}, fmt.Errorf("@RG line without ID")
+	}
+	return g, nil
+}
+
+// Summary is a read group with the number of reads assigned to it.
+type Summary struct {
+	ReadGroup
+	Reads int `json:"reads"`
+}
+
+// Assigner assigns reads to read groups by name, merging in declared
+// metadata and collecting the distinct groups in order of appearance.
+type Assigner struct {
+	declared ReadGroup
+	groups   []Summary
+	byID     map[string]int
+}
+
+// NewAssigner creates an assigner. Fields set in declared override those
+// recovered from read names; if declared has an ID, all reads share it.
+func NewAssigner(declared ReadGroup) *Assigner {
+	return &Assigner{declared: declared, byID: make(map[string]int)}
+}
+
+// Assign returns the index of the group of a read, or -1 if neither the
+// name nor the declared metadata say anything about it.
+func (a *Assigner) Assign(name string) int {
+	g, _ := FromReadName(name)
+	g = g.Merge(a.declared)
+	if g.IsZero() {
+		return -1
+	}
+	if a.declared.ID == "" {
+		g.ID = g.DefaultID()
+	}
+	i, ok := a.byID[g.ID]
+	if !ok {
+		i = len(a.groups)
+		a.byID[g.ID] = i
+		a.groups = append(a.groups, Summary{ReadGroup: g})
+	}
+	a.groups[i].Reads++
+	return i
+}
+
+// Groups returns the groups assigned so far.
+func (a *Assigner) Groups() []Summary {
+	return a.groups
+}
@@ -0,0 +1,93 @@
+package readgroup
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromReadName(t *testing.T) {
+	g, ok := FromReadName("@M00123:45:000000000-A1B2C:1:1101:15589:1331 1:N:0:ATCACG")
+	require.True(t, ok)
+	assert.Equal(t, ReadGroup{
+		ID: "000000000-A1B2C.1", Run: "M00123_45", Flowcell: "000000000-A1B2C",
+		Lane: 1, Barcode: "ATCACG", Platform: "ILLUMINA",
+	}, g)
+
+	g, ok = FromReadName("M00123:45:FC1:2:1101:15589:1331")
+	require.True(t, ok)
+	assert.Equal(t, "FC1.2", g.ID)
+
+	g, ok = FromReadName("HWUSI-EAS100R:6:73:941:1973#0/1")
+	require.True(t, ok)
+	assert.Equal(t, ReadGroup{ID: "HWUSI-EAS100R.6", Run: "HWUSI-EAS100R", Lane: 6, Platform: "ILLUMINA"}, g)
+	g, ok = FromReadName("HWUSI-EAS100R:6:73:941:1973#ACGT/1")
+	require.True(t, ok)
+	assert.Equal(t, "ACGT", g.Barcode)
+
+	g, ok = FromReadName("SRR001666.1 071112_SLXA-EAS1_s_7:5:1:817:345 length=36")
+	require.True(t, ok)
+	assert.Equal(t, ReadGroup{ID: "SRR001666", Run: "SRR001666"}, g)
+
+	for _, name := range []string{"read1", "a:b:c:d:e:f:g", "x:y:1:2:z", ""} {
+		_, ok := FromReadName(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestParse(t *testing.T) {
+	g, err := Parse(strings.NewReader("# sample sheet\nsample=NA12878\nLB: lib1\nplatform\tillumina\nlane = 3\n\n"))
+	require.NoError(t, err)
+	assert.Equal(t, ReadGroup{Sample: "NA12878", Library: "lib1", Platform: "ILLUMINA", Lane: 3}, g)
+
+	_, err = Parse(strings.NewReader("colour=blue\n"))
+	assert.ErrorContains(t, err, "line 1")
+	_, err = Parse(strings.NewReader("sample=a\nlane=two\n"))
+	assert.ErrorContains(t, err, "line 2")
+	_, err = Parse(strings.NewReader("sample\n"))
+	assert.Error(t, err)
+}
+
+func TestSAMHeaderLine(t *testing.T) {
+	g := ReadGroup{Sample: "S1", Library: "L1", Flowcell: "FC1", Lane: 2, Platform: "ILLUMINA"}
+	line := g.SAMHeaderLine()
+	assert.Equal(t, "@RG\tID:FC1.2\tSM:S1\tLB:L1\tPL:ILLUMINA\tPU:FC1.2", line)
+
+	parsed, err := ParseSAMHeaderLine(line)
+	require.NoError(t, err)
+	assert.Equal(t, ReadGroup{ID: "FC1.2", Sample: "S1", Library: "L1", Platform: "ILLUMINA", Flowcell: "FC1.2"}, parsed)
+	_, err = ParseSAMHeaderLine("@RG\tSM:x")
+	assert.Error(t, err)
+
+	assert.Equal(t, "@RG\tID:unknown", ReadGroup{}.SAMHeaderLine())
+}
+
+func TestAssigner(t *testing.T) {
+	names := []string{"I:1:FC:1:1:1:1", "I:1:FC:2:1:1:1", "I:1:FC:1:1:1:2", "read"}
+
+	a := NewAssigner(ReadGroup{Sample: "S1"})
+	got := make([]int, len(names))
+	for i, n := range names {
+		got[i] = a.Assign(n)
+	}
+	assert.Equal(t, []int{0, 1, 0, 2}, got)
+	groups := a.Groups()
+	require.Len(t, groups, 3)
+	assert.Equal(t, "FC.1", groups[0].ID)
+	assert.Equal(t, "S1", groups[0].Sample)
+	assert.Equal(t, 2, groups[0].Reads)
+	assert.Equal(t, "S1", groups[2].ID, "names without provenance fall back to the sample")
+
+	a = NewAssigner(ReadGroup{})
+	assert.Equal(t, -1, a.Assign("read"))
+	assert.Empty(t, a.Groups())
+
+	// A declared ID puts every read in one group.
+	a = NewAssigner(ReadGroup{ID: "rg1"})
+	for _, n := range names {
+		assert.Equal(t, 0, a.Assign(n))
+	}
+	assert.Equal(t, 4, a.Groups()[0].Reads)
+}
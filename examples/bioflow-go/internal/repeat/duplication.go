@@ -0,0 +1,245 @@
+package repeat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/aria-lang/bioflow-go/internal/kmer"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// DuplicationOptions configures repeat annotation by self-comparison.
+type DuplicationOptions struct {
+	// K and W are the minimizer k-mer length and window (default 15
+	// and 10).
+	K int
+	W int
+	// MinLength is the shortest duplicated segment reported (default
+	// 500).
+	MinLength int
+	// MaxOccurrences skips minimizers occurring more often than this
+	// (default 100), which bounds the work in high-copy simple repeats.
+	MaxOccurrences int
+	// MaxGap is the largest distance between consecutive anchors of a
+	// copy (default 1000).
+	MaxGap int
+	// ForwardOnly disables finding inverted copies.
+	ForwardOnly bool
+}
+
+// DefaultDuplicationOptions returns the default self-comparison options.
+func DefaultDuplicationOptions() DuplicationOptions {
+	return DuplicationOptions{K: 15, W: 10, MinLength: 500, MaxOccurrences: 100, MaxGap: 1000}
+}
+
+// Duplication is one copy of a repeat found by self-comparison, 0-based
+// and half-open. Copies related by a match share a Family; Strand is
+// their orientation relative to the family's first copy. A copy that
+// matches itself at an offset is a tandem array of Period-base units.
+type Duplication struct {
+	SequenceID string `json:"sequence_id,omitempty"`
+	Start      int    `json:"start"`
+	End        int    `json:"end"`
+	Family     int    `json:"family"`
+	// Copies is the number of copies in the family, or of units in a
+	// tandem array found on its own.
+	Copies int    `json:"copies"`
+	Period int    `json:"period,omitempty"`
+	Strand string `json:"strand"`
+}
+
+// FindDuplications annotates large duplicated and repetitive regions of a
+// sequence by comparing it with itself: minimizers occurring more than
+// once become anchors, anchors are chained into matching segments as for
+// pairwise comparison, and segments of at least MinLength bases are
+// merged into copies and grouped into families. Tandem units shorter than
+// K, and repeats whose minimizers exceed MaxOccurrences, are not found;
+// Scan covers known short motifs.
+//
+// Aria equivalent:
+//
+//	fn find_duplications(seq: Sequence, options: DuplicationOptions) -> Result<[Duplication], RepeatError>
+//	  ensures result.all(|d| d.end - d.start >= options.min_length)
+//	  ensures result.is_sorted_by_key(|d| d.start)
+func FindDuplications(seq *sequence.Sequence, opts DuplicationOptions) ([]Duplication, error) {
+	defaults := DefaultDuplicationOptions()
+	if opts.K <= 0 {
+		opts.K = defaults.K
+	}
+	if opts.W <= 0 {
+		opts.W = defaults.W
+	}
+	if opts.MinLength <= 0 {
+		opts.MinLength = defaults.MinLength
+	}
+	if opts.MaxOccurrences <= 0 {
+		opts.MaxOccurrences = defaults.MaxOccurrences
+	}
+	if opts.MaxGap <= 0 {
+		opts.MaxGap = defaults.MaxGap
+	}
+	if opts.MinLength < opts.K {
+		return nil, fmt.Errorf("minimum length must be at least k")
+	}
+
+	minimizers, err := kmer.Minimizers(seq, kmer.MinimizerOptions{K: opts.K, W: opts.W, Canonical: !opts.ForwardOnly})
+	if err != nil {
+		return nil, err
+	}
+	byHash := make(map[uint64][]kmer.Minimizer)
+	for _, m := range minimizers {
+		byHash[m.Hash] = append(byHash[m.Hash], m)
+	}
+	anchors := make([]kmer.Anchor, 0)
+	for _, group := range byHash {
+		if len(group) < 2 || len(group) > opts.MaxOccurrences {
+			continue
+		}
+		for a := 0; a < len(group); a++ {
+			for b := a + 1; b < len(group); b++ {
+				x, y := group[a], group[b]
+				if y.Position-x.Position < opts.K {
+					continue
+				}
+				strand := byte('+')
+				if x.Strand != y.Strand {
+					strand = '-'
+				}
+				anchors = append(anchors, kmer.Anchor{Pos1: x.Position, Pos2: y.Position, Strand: strand})
+			}
+		}
+	}
+
+	chainOpts := kmer.DefaultChainOptions(opts.K)
+	chainOpts.MaxGap = opts.MaxGap
+	chains, err := kmer.ChainAnchors(anchors, chainOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each chain matches two segments, or spans a tandem array when its
+	// segments overlap.
+	type segment struct {
+		start, end, period int
+	}
+	type link struct {
+		a, b     int
+		inverted bool
+	}
+	var segments []segment
+	var links []link
+	for _, c := range chains {
+		if c.End1-c.Start1 < opts.MinLength || c.End2-c.Start2 < opts.MinLength {
+			continue
+		}
+		if c.Strand == '+' && c.Start2 < c.End1 {
+			period := c.Anchors[0].Pos2 - c.Anchors[0].Pos1
+			segments = append(segments, segment{c.Start1, c.End2, period})
+			continue
+		}
+		segments = append(segments, segment{c.Start1, c.End1, 0}, segment{c.Start2, c.End2, 0})
+		links = append(links, link{len(segments) - 2, len(segments) - 1, c.Strand == '-'})
+	}
+
+	// Overlapping segments are the same copy.
+	order := make([]int, len(segments))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return segments[order[a]].start < segments[order[b]].start })
+	copyOf := make([]int, len(segments))
+	copies := make([]Duplication, 0)
+	periods := make([]int, 0)
+	for _, i := range order {
+		s := segments[i]
+		if n := len(copies); n > 0 && s.start < copies[n-1].End {
+			copies[n-1].End = max(copies[n-1].End, s.end)
+			if s.period > 0 && (periods[n-1] == 0 || s.period < periods[n-1]) {
+				periods[n-1] = s.period
+			}
+			copyOf[i] = n - 1
+			continue
+		}
+		copies = append(copies, Duplication{SequenceID: seq.ID, Start: s.start, End: s.end})
+		periods = append(periods, s.period)
+		copyOf[i] = len(copies) - 1
+	}
+
+	// Families are the connected copies; orientation follows the links
+	// from the first copy of each family.
+	type edge struct {
+		to       int
+		inverted bool
+	}
+	adjacent := make([][]edge, len(copies))
+	for _, l := range links {
+		a, b := copyOf[l.a], copyOf[l.b]
+		if a != b {
+			adjacent[a] = append(adjacent[a], edge{b, l.inverted})
+			adjacent[b] = append(adjacent[b], edge{a, l.inverted})
+		}
+	}
+	family := make([]int, len(copies))
+	inverted := make([]bool, len(copies))
+	families := 0
+	var members []int
+	for first := range copies {
+		if family[first] != 0 {
+			continue
+		}
+		families++
+		family[first] = families
+		members = append(members[:0], first)
+		for q := 0; q < len(members); q++ {
+			for _, e := range adjacent[members[q]] {
+				if family[e.to] == 0 {
+					family[e.to] = families
+					inverted[e.to] = inverted[members[q]] != e.inverted
+					members = append(members, e.to)
+				}
+			}
+		}
+		for _, m := range members {
+			d := &copies[m]
+			d.Family, d.Copies, d.Period, d.Strand = families, len(members), periods[m], "+"
+			if inverted[m] {
+				d.Strand = "-"
+			}
+			if len(members) == 1 && d.Period > 0 {
+				d.Copies = max(2, int(math.Round(float64(d.End-d.Start)/float64(d.Period))))
+			}
+		}
+	}
+	return copies, nil
+}
+
+// WriteDuplicationsBED writes duplications as BED6, named by family, with
+// the copy count as score (capped at 1000).
+func WriteDuplicationsBED(w io.Writer, dups []Duplication) error {
+	bw := bufio.NewWriter(w)
+	for _, d := range dups {
+		fmt.Fprintf(bw, "%s\t%d\t%d\trepeat%d\t%d\t%s\n", d.SequenceID, d.Start, d.End, d.Family, min(d.Copies, 1000), d.Strand)
+	}
+	return bw.Flush()
+}
+
+// WriteDuplicationsGFF writes duplications as GFF3 repeat_region features,
+// with the family, copy count and tandem period as attributes.
+func WriteDuplicationsGFF(w io.Writer, dups []Duplication) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "##gff-version 3")
+	seen := make(map[string]int)
+	for _, d := range dups {
+		name := fmt.Sprintf("repeat%d", d.Family)
+		seen[d.SequenceID+"\t"+name]++
+		attrs := fmt.Sprintf("ID=%s.%d;Name=%s;copies=%d", name, seen[d.SequenceID+"\t"+name], name, d.Copies)
+		if d.Period > 0 {
+			attrs += fmt.Sprintf(";period=%d", d.Period)
+		}
+		fmt.Fprintf(bw, "%s\tbioflow\trepeat_region\t%d\t%d\t.\t%s\t.\t%s\n", d.SequenceID, d.Start+1, d.End, d.Strand, attrs)
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,174 @@
+package repeat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// InvertedRepeatOptions configures inverted repeat detection.
+type InvertedRepeatOptions struct {
+	// MinStem and MaxStem bound the length of each arm, in base pairs
+	// (default 10 and 100). Arms are extended up to MaxStem.
+	MinStem int
+	MaxStem int
+	// MinLoop and MaxLoop bound the spacer between the arms (0 and 100
+	// by default). A zero-length loop is a perfect palindrome, the kind
+	// that can extrude as a cruciform; hairpins need a loop of at least 3.
+	// With MaxLoop 0 only palindromes are found.
+	MinLoop int
+	MaxLoop int
+	// MaxMismatches is the largest number of unpaired positions allowed
+	// in a stem.
+	MaxMismatches int
+}
+
+// DefaultInvertedRepeatOptions returns the default detection options.
+func DefaultInvertedRepeatOptions() InvertedRepeatOptions {
+	return InvertedRepeatOptions{MinStem: 10, MaxStem: 100, MinLoop: 0, MaxLoop: 100}
+}
+
+// InvertedRepeat is a pair of reverse-complementary arms separated by a
+// loop, 0-based and half-open: the left arm is Start..Start+StemLength,
+// the right arm ends at End.
+type InvertedRepeat struct {
+	SequenceID string `json:"sequence_id,omitempty"`
+	Start      int    `json:"start"`
+	End        int    `json:"end"`
+	StemLength int    `json:"stem_length"`
+	LoopLength int    `json:"loop_length"`
+	Mismatches int    `json:"mismatches"`
+}
+
+// FindInvertedRepeats finds inverted repeats: arms of at least MinStem
+// bases whose reverse complements pair across a loop of MinLoop to MaxLoop
+// bases, with at most MaxMismatches unpaired positions. Such sites mark
+// the ends of many transposons and can fold into hairpins or, for perfect
+// palindromes, cruciforms.
+//
+// Stems start and end with a paired position and are extended as far as
+// the mismatch budget allows. Of the repeats around the same axis, only
+// those reaching further out than a shorter-looped one are kept, and
+// repeats lying within a reported one are dropped. N pairs with nothing.
+//
+// Aria equivalent:
+//
+//	fn find_inverted_repeats(seq: Sequence, options: InvertedRepeatOptions) -> Result<[InvertedRepeat], RepeatError>
+//	  requires options.min_stem > 0 and options.min_loop >= 0
+//	  ensures result.all(|r| r.stem_length >= options.min_stem)
+//	  ensures result.is_sorted_by_key(|r| r.start)
+func FindInvertedRepeats(seq *sequence.Sequence, opts InvertedRepeatOptions) ([]InvertedRepeat, error) {
+	defaults := DefaultInvertedRepeatOptions()
+	if opts.MinStem <= 0 {
+		opts.MinStem = defaults.MinStem
+	}
+	if opts.MaxStem <= 0 {
+		opts.MaxStem = defaults.MaxStem
+	}
+	if opts.MinLoop < 0 || opts.MaxMismatches < 0 {
+		return nil, fmt.Errorf("loop length and mismatches must be non-negative")
+	}
+	if opts.MaxStem < opts.MinStem {
+		return nil, fmt.Errorf("maximum stem length is less than the minimum")
+	}
+	if opts.MaxLoop < opts.MinLoop {
+		return nil, fmt.Errorf("maximum loop length is less than the minimum")
+	}
+
+	bases := seq.Bases
+	n := len(bases)
+	found := make([]InvertedRepeat, 0)
+	// The loop [c, c+loop) has axis 2c+loop; repeats sharing an axis are
+	// found from the shortest loop outward.
+	for axis := 0; axis <= 2*n; axis++ {
+		reach := -1
+		for loop := opts.MinLoop + (axis-opts.MinLoop)&1; loop <= opts.MaxLoop; loop += 2 {
+			c := (axis - loop) / 2
+			if c < opts.MinStem || c+loop+opts.MinStem > n {
+				continue
+			}
+			if !complementary(bases[c-1], bases[c+loop]) {
+				continue
+			}
+			stem, mismatches, paired := 0, 0, 0
+			for t := 0; t < opts.MaxStem && c-1-t >= 0 && c+loop+t < n; t++ {
+				if !complementary(bases[c-1-t], bases[c+loop+t]) {
+					if mismatches == opts.MaxMismatches {
+						break
+					}
+					mismatches++
+					continue
+				}
+				stem, paired = t+1, mismatches
+			}
+			if stem < opts.MinStem || c+loop+stem <= reach {
+				continue
+			}
+			reach = c + loop + stem
+			found = append(found, InvertedRepeat{
+				SequenceID: seq.ID,
+				Start:      c - stem,
+				End:        reach,
+				StemLength: stem,
+				LoopLength: loop,
+				Mismatches: paired,
+			})
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Start != found[j].Start {
+			return found[i].Start < found[j].Start
+		}
+		return found[i].End > found[j].End
+	})
+	kept := found[:0]
+	end := -1
+	for _, r := range found {
+		if r.End <= end {
+			continue
+		}
+		kept = append(kept, r)
+		end = r.End
+	}
+	return kept, nil
+}
+
+// pairsWith maps each base, in either case, to the lower-case base it
+// pairs with; other bytes map to zero.
+var pairsWith = func() (t [256]byte) {
+	for _, p := range []string{"at", "cg", "gc", "ta"} {
+		t[p[0]], t[p[0]-'a'+'A'] = p[1], p[1]
+	}
+	return t
+}()
+
+// complementary reports whether two bases pair in a DNA duplex.
+func complementary(a, b byte) bool {
+	return pairsWith[a] != 0 && pairsWith[a] == b|0x20
+}
+
+// WriteInvertedRepeatsBED writes inverted repeats as BED6, with the stem
+// length as score (capped at 1000).
+func WriteInvertedRepeatsBED(w io.Writer, repeats []InvertedRepeat) error {
+	bw := bufio.NewWriter(w)
+	for _, r := range repeats {
+		fmt.Fprintf(bw, "%s\t%d\t%d\tstem%d_loop%d\t%d\t.\n", r.SequenceID, r.Start, r.End, r.StemLength, r.LoopLength, min(r.StemLength, 1000))
+	}
+	return bw.Flush()
+}
+
+// WriteInvertedRepeatsGFF writes inverted repeats as GFF3 inverted_repeat
+// features, with the stem, loop and mismatches as attributes.
+func WriteInvertedRepeatsGFF(w io.Writer, repeats []InvertedRepeat) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "##gff-version 3")
+	for i, r := range repeats {
+		fmt.Fprintf(bw, "%s\tbioflow\tinverted_repeat\t%d\t%d\t.\t.\t.\tID=ir%d;stem_length=%d;loop_length=%d;mismatches=%d\n",
+			r.SequenceID, r.Start+1, r.End, i+1, r.StemLength, r.LoopLength, r.Mismatches)
+	}
+	return bw.Flush()
+}
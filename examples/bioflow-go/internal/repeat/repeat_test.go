@@ -0,0 +1,212 @@
+package repeat
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// filler returns a repeat-free, deterministic sequence of length n.
+func filler(n int) string {
+	unit := "ACGTTGCAAGCTTCGA"
+	return strings.Repeat(unit, n/len(unit)+1)[:n]
+}
+
+func TestScanTelomeres(t *testing.T) {
+	// CCCTAA repeats at the 5' end, TTAGGG repeats at the 3' end.
+	bases := strings.Repeat("CCCTAA", 50) + filler(1200) + strings.Repeat("TTAGGG", 50)
+	seq, err := sequence.WithID(bases, "chr1")
+	require.NoError(t, err)
+
+	report, err := Scan(seq, ScanOptions{WindowSize: 300})
+	require.NoError(t, err)
+
+	assert.Equal(t, "chr1", report.SequenceID)
+	assert.Equal(t, []string{DefaultTelomereMotif}, report.Motifs)
+	require.Len(t, report.Regions, 2)
+	assert.Equal(t, 0, report.Regions[0].Start)
+	assert.Equal(t, "-", report.Regions[0].Strand)
+	assert.Equal(t, len(bases), report.Regions[1].End)
+	assert.Equal(t, "+", report.Regions[1].Strand)
+	assert.True(t, report.Regions[1].Terminal)
+	assert.True(t, report.TelomereAtStart)
+	assert.True(t, report.TelomereAtEnd)
+
+	first := report.Windows[0]
+	assert.Equal(t, 50, first.Counts["CCCTAA"])
+	assert.Equal(t, 50, first.Reverse)
+	assert.InDelta(t, 1.0, first.Density, 1e-9)
+	assert.InDelta(t, 0.0, report.Windows[2].Density, 1e-9)
+}
+
+func TestScanForwardOnlyAndCustomMotif(t *testing.T) {
+	bases := filler(500) + strings.Repeat("CCCTAA", 40) + filler(500)
+	seq, err := sequence.New(bases)
+	require.NoError(t, err)
+
+	report, err := Scan(seq, ScanOptions{WindowSize: 240, ForwardOnly: true})
+	require.NoError(t, err)
+	assert.Empty(t, report.Regions)
+
+	report, err = Scan(seq, ScanOptions{Motifs: []string{"ccctaa"}, WindowSize: 240, Step: 120})
+	require.NoError(t, err)
+	require.Len(t, report.Regions, 1)
+	assert.False(t, report.Regions[0].Terminal)
+	assert.False(t, report.TelomereAtStart)
+	assert.False(t, report.TelomereAtEnd)
+
+	_, err = Scan(seq, ScanOptions{Motifs: []string{"TTXGGG"}})
+	assert.Error(t, err)
+	_, err = Scan(seq, ScanOptions{MinDensity: 2})
+	assert.Error(t, err)
+}
+
+func TestReportTSV(t *testing.T) {
+	seq, err := sequence.WithID(strings.Repeat("TTAGGG", 10), "s")
+	require.NoError(t, err)
+	report, err := Scan(seq, ScanOptions{WindowSize: 30})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteTSV(&buf, true))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "s\t0\t30\t5\t0\t1.0000", lines[1])
+}
+
+// randomBases returns n random bases, deterministic for a seed.
+func randomBases(rng *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = "ACGT"[rng.Intn(4)]
+	}
+	return string(b)
+}
+
+func TestFindDuplications(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	unit := randomBases(rng, 800)
+	rcSeq, err := sequence.New(unit)
+	require.NoError(t, err)
+	rc, err := rcSeq.ReverseComplement()
+	require.NoError(t, err)
+	tandem := strings.Repeat(randomBases(rng, 300), 5)
+
+	// Three direct copies, one inverted copy and a tandem array, apart.
+	parts := []string{
+		randomBases(rng, 2000), unit, randomBases(rng, 2000), unit,
+		randomBases(rng, 2000), rc.Bases, randomBases(rng, 2000), unit,
+		randomBases(rng, 2000), tandem, randomBases(rng, 2000),
+	}
+	seq, err := sequence.WithID(strings.Join(parts, ""), "chr1")
+	require.NoError(t, err)
+
+	dups, err := FindDuplications(seq, DefaultDuplicationOptions())
+	require.NoError(t, err)
+	require.Len(t, dups, 5)
+
+	offset := 0
+	starts := make([]int, len(parts))
+	for i, p := range parts {
+		starts[i] = offset
+		offset += len(p)
+	}
+	for i, part := range []int{1, 3, 5, 7} {
+		d := dups[i]
+		assert.InDelta(t, starts[part], d.Start, 30, "copy %d", i)
+		assert.InDelta(t, starts[part]+800, d.End, 30, "copy %d", i)
+		assert.Equal(t, 1, d.Family)
+		assert.Equal(t, 4, d.Copies)
+		assert.Zero(t, d.Period)
+	}
+	assert.Equal(t, []string{"+", "+", "-", "+"}, []string{dups[0].Strand, dups[1].Strand, dups[2].Strand, dups[3].Strand})
+
+	array := dups[4]
+	assert.Equal(t, 2, array.Family)
+	assert.Equal(t, 300, array.Period)
+	assert.Equal(t, 5, array.Copies)
+	assert.InDelta(t, starts[9], array.Start, 30)
+	assert.InDelta(t, starts[9]+1500, array.End, 30)
+
+	// Without inverted copies the reverse complement is not found.
+	forward, err := FindDuplications(seq, DuplicationOptions{ForwardOnly: true})
+	require.NoError(t, err)
+	assert.Len(t, forward, 4)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteDuplicationsBED(&buf, dups))
+	assert.Equal(t, 5, strings.Count(buf.String(), "\n"))
+	assert.Contains(t, buf.String(), "\trepeat1\t4\t-\n")
+	buf.Reset()
+	require.NoError(t, WriteDuplicationsGFF(&buf, dups))
+	assert.True(t, strings.HasPrefix(buf.String(), "##gff-version 3\n"))
+	assert.Contains(t, buf.String(), "ID=repeat2.1;Name=repeat2;copies=5;period=300")
+	assert.Contains(t, buf.String(), "ID=repeat1.4;")
+
+	_, err = FindDuplications(seq, DuplicationOptions{K: 15, MinLength: 10})
+	assert.Error(t, err)
+}
+
+func TestFindInvertedRepeats(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	arm := randomBases(rng, 15)
+	palindrome := randomBases(rng, 10)
+	long := randomBases(rng, 30)
+	mismatched := []byte(sequence.IUPACReverseComplement(long))
+	mismatched[12] = "ACGT"[(strings.IndexByte("ACGT", mismatched[12])+1)%4]
+
+	// A hairpin, a perfect palindrome and a stem with one unpaired base,
+	// flanked by A so that no arm extends into its neighbours.
+	parts := []string{
+		"A" + randomBases(rng, 300) + "A", arm, "AAAAAA", sequence.IUPACReverseComplement(arm),
+		"A" + randomBases(rng, 300) + "A", palindrome, sequence.IUPACReverseComplement(palindrome),
+		"A" + randomBases(rng, 300) + "A", long, "AAAA", string(mismatched),
+		"A" + randomBases(rng, 300) + "A",
+	}
+	seq, err := sequence.WithID(strings.Join(parts, ""), "chr1")
+	require.NoError(t, err)
+	starts := make([]int, len(parts))
+	for i := 1; i < len(parts); i++ {
+		starts[i] = starts[i-1] + len(parts[i-1])
+	}
+
+	opts := DefaultInvertedRepeatOptions()
+	opts.MinStem = 10
+	found, err := FindInvertedRepeats(seq, opts)
+	require.NoError(t, err)
+	require.Len(t, found, 3)
+	assert.Equal(t, InvertedRepeat{SequenceID: "chr1", Start: starts[1], End: starts[4], StemLength: 15, LoopLength: 6}, found[0])
+	assert.Equal(t, InvertedRepeat{SequenceID: "chr1", Start: starts[5], End: starts[7], StemLength: 10}, found[1])
+	// Without mismatches the unpaired base splits the stem: the outer 17
+	// pairs are found with the inner 12 as part of the loop.
+	assert.Equal(t, InvertedRepeat{SequenceID: "chr1", Start: starts[8], End: starts[11], StemLength: 17, LoopLength: 30}, found[2])
+
+	opts.MinStem, opts.MaxMismatches = 20, 1
+	found, err = FindInvertedRepeats(seq, opts)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, InvertedRepeat{SequenceID: "chr1", Start: starts[8], End: starts[11], StemLength: 30, LoopLength: 4, Mismatches: 1}, found[0])
+
+	// Palindromes only.
+	opts.MinStem, opts.MaxMismatches, opts.MaxLoop = 10, 0, 0
+	found, err = FindInvertedRepeats(seq, opts)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Zero(t, found[0].LoopLength)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteInvertedRepeatsGFF(&buf, found))
+	assert.Contains(t, buf.String(), "\tinverted_repeat\t")
+	assert.Contains(t, buf.String(), "ID=ir1;stem_length=10;loop_length=0;mismatches=0\n")
+	buf.Reset()
+	require.NoError(t, WriteInvertedRepeatsBED(&buf, found))
+	assert.Contains(t, buf.String(), "\tstem10_loop0\t10\t.\n")
+
+	_, err = FindInvertedRepeats(seq, InvertedRepeatOptions{MinLoop: 5, MaxLoop: 3})
+	assert.Error(t, err)
+}
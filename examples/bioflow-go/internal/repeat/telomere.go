@@ -0,0 +1,276 @@
+// Package repeat provides repeat detection and repeat content reports.
+//
+// Comparison with Aria:
+//
+//	Aria can constrain window parameters in the signature:
+//	  fn scan(seq: Sequence, window: Int) -> Report
+//	    requires window > 0 and window <= seq.len()
+//
+//	Go validates options at the start of each function.
+package repeat
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// DefaultTelomereMotif is the vertebrate telomeric repeat unit.
+const DefaultTelomereMotif = "TTAGGG"
+
+// Default scanning parameters.
+const (
+	DefaultWindowSize = 1000
+	DefaultMinDensity = 0.5
+)
+
+// ScanOptions configures a repeat-motif scan.
+type ScanOptions struct {
+	// Motifs to count (default DefaultTelomereMotif).
+	Motifs []string
+	// ForwardOnly disables counting reverse complements of the motifs.
+	ForwardOnly bool
+	// WindowSize and Step in bases (Step defaults to WindowSize).
+	WindowSize int
+	Step       int
+	// MinDensity is the fraction of a window covered by motif occurrences
+	// for it to count as part of a candidate repeat region.
+	MinDensity float64
+}
+
+// Window holds motif counts for one window of a sequence.
+type Window struct {
+	Start   int            `json:"start"`
+	End     int            `json:"end"`
+	Counts  map[string]int `json:"counts"`
+	Forward int            `json:"forward"`
+	Reverse int            `json:"reverse"`
+	Density float64        `json:"density"`
+}
+
+// Region is a run of consecutive dense windows. Strand is '+' when forward
+// motif occurrences dominate and '-' when reverse complements dominate.
+type Region struct {
+	Start       int     `json:"start"`
+	End         int     `json:"end"`
+	Strand      string  `json:"strand"`
+	MeanDensity float64 `json:"mean_density"`
+	Terminal    bool    `json:"terminal"`
+}
+
+// Report summarizes repeat-motif content across a sequence.
+//
+// Aria equivalent:
+//
+//	struct Report
+//	  invariant self.regions.all(|r| r.start < r.end)
+//	  invariant self.windows.all(|w| w.density >= 0.0 and w.density <= 1.0)
+type Report struct {
+	SequenceID string   `json:"sequence_id"`
+	Length     int      `json:"length"`
+	Motifs     []string `json:"motifs"`
+	Windows    []Window `json:"windows"`
+	Regions    []Region `json:"regions"`
+	// TelomereAtStart/End report a dense region within one window of the
+	// sequence ends, as expected for a complete telomere-to-telomere contig.
+	TelomereAtStart bool `json:"telomere_at_start"`
+	TelomereAtEnd   bool `json:"telomere_at_end"`
+}
+
+// Scan counts motif occurrences in windows across a sequence and reports
+// candidate repeat regions. With default options this is a telomere scan.
+//
+// Aria equivalent:
+//
+//	fn scan(seq: Sequence, options: ScanOptions) -> Result<Report, RepeatError>
+//	  ensures result.windows.len() > 0 or seq.len() == 0
+func Scan(seq *sequence.Sequence, opts ScanOptions) (*Report, error) {
+	if len(opts.Motifs) == 0 {
+		opts.Motifs = []string{DefaultTelomereMotif}
+	}
+	if opts.WindowSize <= 0 {
+		opts.WindowSize = DefaultWindowSize
+	}
+	if opts.Step <= 0 {
+		opts.Step = opts.WindowSize
+	}
+	if opts.MinDensity <= 0 {
+		opts.MinDensity = DefaultMinDensity
+	}
+	if opts.MinDensity > 1 {
+		return nil, fmt.Errorf("minimum density must be at most 1")
+	}
+
+	bases := seq.Bases
+	n := len(bases)
+
+	// Collect forward and reverse motif patterns.
+	type pattern struct {
+		text    string
+		reverse bool
+	}
+	var patterns []pattern
+	motifs := make([]string, 0, len(opts.Motifs))
+	for _, m := range opts.Motifs {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		if m == "" {
+			return nil, fmt.Errorf("motif cannot be empty")
+		}
+		motifSeq, err := sequence.New(m)
+		if err != nil {
+			return nil, fmt.Errorf("invalid motif %s: %w", m, err)
+		}
+		motifs = append(motifs, m)
+		patterns = append(patterns, pattern{text: m})
+		if !opts.ForwardOnly {
+			rc, err := motifSeq.ReverseComplement()
+			if err != nil {
+				return nil, err
+			}
+			if rc.Bases != m {
+				patterns = append(patterns, pattern{text: rc.Bases, reverse: true})
+			}
+		}
+	}
+
+	// Mark occurrence starts and covered bases.
+	type hit struct {
+		pattern int
+		pos     int
+	}
+	var hits []hit
+	covered := make([]bool, n)
+	for pi, p := range patterns {
+		for i := 0; i+len(p.text) <= n; i++ {
+			if bases[i:i+len(p.text)] == p.text {
+				hits = append(hits, hit{pi, i})
+				for k := i; k < i+len(p.text); k++ {
+					covered[k] = true
+				}
+			}
+		}
+	}
+
+	// Prefix sums for coverage and per-pattern occurrence starts.
+	coverSum := make([]int, n+1)
+	for i := 0; i < n; i++ {
+		coverSum[i+1] = coverSum[i]
+		if covered[i] {
+			coverSum[i+1]++
+		}
+	}
+	starts := make([][]int, len(patterns))
+	for pi := range starts {
+		starts[pi] = make([]int, n+1)
+	}
+	for _, h := range hits {
+		starts[h.pattern][h.pos+1]++
+	}
+	for pi := range starts {
+		for i := 0; i < n; i++ {
+			starts[pi][i+1] += starts[pi][i]
+		}
+	}
+
+	report := &Report{
+		SequenceID: seq.ID,
+		Length:     n,
+		Motifs:     motifs,
+		Windows:    make([]Window, 0),
+		Regions:    make([]Region, 0),
+	}
+
+	for start := 0; start < n; start += opts.Step {
+		end := start + opts.WindowSize
+		if end > n {
+			end = n
+		}
+		w := Window{Start: start, End: end, Counts: make(map[string]int)}
+		for pi, p := range patterns {
+			// Count occurrences that lie entirely within the window.
+			last := end - len(p.text) + 1
+			if last <= start {
+				continue
+			}
+			c := starts[pi][last] - starts[pi][start]
+			if c == 0 {
+				continue
+			}
+			w.Counts[p.text] = c
+			if p.reverse {
+				w.Reverse += c
+			} else {
+				w.Forward += c
+			}
+		}
+		w.Density = float64(coverSum[end]-coverSum[start]) / float64(end-start)
+		report.Windows = append(report.Windows, w)
+		if end == n {
+			break
+		}
+	}
+
+	// Merge dense windows into regions.
+	var current *Region
+	var densitySum float64
+	var windows, forward, reverse int
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.MeanDensity = densitySum / float64(windows)
+		current.Strand = "+"
+		if reverse > forward {
+			current.Strand = "-"
+		}
+		current.Terminal = current.Start < opts.WindowSize || n-current.End < opts.WindowSize
+		report.Regions = append(report.Regions, *current)
+		current = nil
+	}
+	for _, w := range report.Windows {
+		if w.Density < opts.MinDensity {
+			flush()
+			continue
+		}
+		if current == nil || w.Start > current.End {
+			flush()
+			current = &Region{Start: w.Start, End: w.End}
+			densitySum, windows, forward, reverse = 0, 0, 0, 0
+		}
+		current.End = w.End
+		densitySum += w.Density
+		windows++
+		forward += w.Forward
+		reverse += w.Reverse
+	}
+	flush()
+
+	for _, r := range report.Regions {
+		if r.Start < opts.WindowSize {
+			report.TelomereAtStart = true
+		}
+		if n-r.End < opts.WindowSize {
+			report.TelomereAtEnd = true
+		}
+	}
+
+	return report, nil
+}
+
+// WriteTSV writes one line per window: start, end, forward and reverse
+// counts and density. Coordinates are 0-based, half-open.
+func (r *Report) WriteTSV(w io.Writer, header bool) error {
+	if header {
+		if _, err := io.WriteString(w, "sequence\tstart\tend\tforward\treverse\tdensity\n"); err != nil {
+			return err
+		}
+	}
+	for _, win := range r.Windows {
+		if _, err := fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%.4f\n", r.SequenceID, win.Start, win.End, win.Forward, win.Reverse, win.Density); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,329 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+)
+
+// Chart dimensions in SVG user units.
+const (
+	chartWidth   = 760
+	chartHeight  = 320
+	marginLeft   = 60
+	marginRight  = 150
+	marginTop    = 20
+	marginBottom = 50
+)
+
+// palette is the series color cycle.
+var palette = []string{"#1f77b4", "#d62728", "#2ca02c", "#ff7f0e", "#9467bd", "#8c564b", "#e377c2", "#7f7f7f"}
+
+// Chart is anything that renders itself as an inline SVG element.
+type Chart interface {
+	SVG() string
+}
+
+// Series is one named line of a LineChart.
+type Series struct {
+	Name string
+	X    []float64
+	Y    []float64
+}
+
+// Band is a horizontal background band, such as the good/fair/poor zones
+// of a quality plot.
+type Band struct {
+	From  float64
+	To    float64
+	Color string
+}
+
+// LineChart plots one or more series against a shared numeric x axis.
+// When YMin equals YMax the y range is taken from the data.
+type LineChart struct {
+	XLabel string
+	YLabel string
+	Series []Series
+	YMin   float64
+	YMax   float64
+	Bands  []Band
+}
+
+// BarChart plots one value per category.
+type BarChart struct {
+	XLabel string
+	YLabel string
+	Labels []string
+	Values []float64
+}
+
+// Box is one box of a BoxChart: whiskers at Low and High, the box from Q1
+// to Q3, a line at the median and a marker at the mean.
+type Box struct {
+	Label  string
+	Low    float64
+	Q1     float64
+	Median float64
+	Q3     float64
+	High   float64
+	Mean   float64
+}
+
+// BoxChart draws a box per category, as in the per-base quality plot of
+// FastQC.
+type BoxChart struct {
+	XLabel string
+	YLabel string
+	Boxes  []Box
+	YMin   float64
+	YMax   float64
+	Bands  []Band
+}
+
+// Heatmap shades a grid of counts; Counts[i][j] is the cell at x bin i
+// and y bin j.
+type Heatmap struct {
+	XLabel string
+	YLabel string
+	XEdges []float64
+	YEdges []float64
+	Counts [][]int
+}
+
+// plot maps data coordinates to SVG coordinates.
+type plot struct {
+	xMin, xMax, yMin, yMax float64
+}
+
+func (p plot) x(v float64) float64 {
+	w := float64(chartWidth - marginLeft - marginRight)
+	if p.xMax == p.xMin {
+		return marginLeft + w/2
+	}
+	return marginLeft + (v-p.xMin)/(p.xMax-p.xMin)*w
+}
+
+func (p plot) y(v float64) float64 {
+	h := float64(chartHeight - marginTop - marginBottom)
+	if p.yMax == p.yMin {
+		return marginTop + h/2
+	}
+	return marginTop + h - (v-p.yMin)/(p.yMax-p.yMin)*h
+}
+
+// SVG renders the line chart.
+func (c LineChart) SVG() string {
+	p := plot{xMin: math.Inf(1), xMax: math.Inf(-1), yMin: c.YMin, yMax: c.YMax}
+	autoY := c.YMin == c.YMax
+	if autoY {
+		p.yMin, p.yMax = 0, math.Inf(-1)
+	}
+	for _, s := range c.Series {
+		for i := range s.X {
+			p.xMin, p.xMax = math.Min(p.xMin, s.X[i]), math.Max(p.xMax, s.X[i])
+			if autoY {
+				p.yMin, p.yMax = math.Min(p.yMin, s.Y[i]), math.Max(p.yMax, s.Y[i])
+			}
+		}
+	}
+	if math.IsInf(p.xMin, 1) {
+		p.xMin, p.xMax = 0, 1
+	}
+	if math.IsInf(p.yMax, -1) || p.yMax == p.yMin {
+		p.yMax = p.yMin + 1
+	}
+
+	var sb strings.Builder
+	openSVG(&sb)
+	drawBands(&sb, p, c.Bands)
+	drawAxes(&sb, p, c.XLabel, c.YLabel, true)
+	for i, s := range c.Series {
+		color := palette[i%len(palette)]
+		points := make([]string, len(s.X))
+		for j := range s.X {
+			points[j] = fmt.Sprintf("%.1f,%.1f", p.x(s.X[j]), p.y(s.Y[j]))
+		}
+		fmt.Fprintf(&sb, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`, color, strings.Join(points, " "))
+		drawLegend(&sb, i, s.Name, color)
+	}
+	sb.WriteString("</svg>")
+	return sb.String()
+}
+
+// SVG renders the bar chart.
+func (c BarChart) SVG() string {
+	p := plot{xMin: 0, xMax: float64(len(c.Values)), yMin: 0, yMax: 1}
+	for _, v := range c.Values {
+		p.yMax = math.Max(p.yMax, v)
+	}
+
+	var sb strings.Builder
+	openSVG(&sb)
+	drawAxes(&sb, p, c.XLabel, c.YLabel, false)
+	slot := (p.x(1) - p.x(0))
+	for i, v := range c.Values {
+		x := p.x(float64(i)) + slot*0.1
+		fmt.Fprintf(&sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s: %g</title></rect>`,
+			x, p.y(v), slot*0.8, p.y(0)-p.y(v), palette[0], html.EscapeString(c.label(i)), v)
+		fmt.Fprintf(&sb, `<text x="%.1f" y="%d" font-size="10" text-anchor="middle">%s</text>`,
+			p.x(float64(i)+0.5), chartHeight-marginBottom+14, html.EscapeString(c.label(i)))
+	}
+	sb.WriteString("</svg>")
+	return sb.String()
+}
+
+func (c BarChart) label(i int) string {
+	if i < len(c.Labels) {
+		return c.Labels[i]
+	}
+	return fmt.Sprint(i + 1)
+}
+
+// SVG renders the box chart.
+func (c BoxChart) SVG() string {
+	p := plot{xMin: 0, xMax: float64(len(c.Boxes)), yMin: c.YMin, yMax: c.YMax}
+	if c.YMin == c.YMax {
+		p.yMin, p.yMax = 0, 1
+		for _, b := range c.Boxes {
+			p.yMax = math.Max(p.yMax, b.High)
+		}
+	}
+
+	var sb strings.Builder
+	openSVG(&sb)
+	drawBands(&sb, p, c.Bands)
+	drawAxes(&sb, p, c.XLabel, c.YLabel, false)
+	slot := p.x(1) - p.x(0)
+	labelEvery := int(math.Ceil(float64(len(c.Boxes)) / 20))
+	means := make([]string, len(c.Boxes))
+	for i, b := range c.Boxes {
+		mid := p.x(float64(i) + 0.5)
+		half := math.Max(slot*0.35, 0.5)
+		fmt.Fprintf(&sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#333"/>`, mid, p.y(b.Low), mid, p.y(b.High))
+		fmt.Fprintf(&sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="#ffd700" stroke="#333" stroke-width="0.5"><title>%s: median %g, IQR %g-%g</title></rect>`,
+			mid-half, p.y(b.Q3), 2*half, p.y(b.Q1)-p.y(b.Q3), html.EscapeString(b.Label), b.Median, b.Q1, b.Q3)
+		fmt.Fprintf(&sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#d62728" stroke-width="1.5"/>`, mid-half, p.y(b.Median), mid+half, p.y(b.Median))
+		means[i] = fmt.Sprintf("%.1f,%.1f", mid, p.y(b.Mean))
+		if i%labelEvery == 0 {
+			fmt.Fprintf(&sb, `<text x="%.1f" y="%d" font-size="10" text-anchor="middle">%s</text>`,
+				mid, chartHeight-marginBottom+14, html.EscapeString(b.Label))
+		}
+	}
+	fmt.Fprintf(&sb, `<polyline fill="none" stroke="%s" stroke-width="1" points="%s"/>`, palette[0], strings.Join(means, " "))
+	drawLegend(&sb, 0, "mean", palette[0])
+	sb.WriteString("</svg>")
+	return sb.String()
+}
+
+// SVG renders the heatmap, shading cells by count relative to the
+// largest cell.
+func (c Heatmap) SVG() string {
+	p := plot{xMin: 0, xMax: 1, yMin: 0, yMax: 1}
+	if len(c.XEdges) > 1 && len(c.YEdges) > 1 {
+		p = plot{xMin: c.XEdges[0], xMax: c.XEdges[len(c.XEdges)-1], yMin: c.YEdges[0], yMax: c.YEdges[len(c.YEdges)-1]}
+	}
+	max := 0
+	for _, row := range c.Counts {
+		for _, n := range row {
+			if n > max {
+				max = n
+			}
+		}
+	}
+
+	var sb strings.Builder
+	openSVG(&sb)
+	for i, row := range c.Counts {
+		for j, n := range row {
+			if n == 0 || i+1 >= len(c.XEdges) || j+1 >= len(c.YEdges) {
+				continue
+			}
+			x0, x1 := p.x(c.XEdges[i]), p.x(c.XEdges[i+1])
+			y0, y1 := p.y(c.YEdges[j+1]), p.y(c.YEdges[j])
+			fmt.Fprintf(&sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" fill-opacity="%.3f"><title>%d</title></rect>`,
+				x0, y0, x1-x0, y1-y0, palette[0], 0.15+0.85*float64(n)/float64(max), n)
+		}
+	}
+	drawAxes(&sb, p, c.XLabel, c.YLabel, true)
+	sb.WriteString("</svg>")
+	return sb.String()
+}
+
+func openSVG(sb *strings.Builder) {
+	fmt.Fprintf(sb, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" font-family="sans-serif">`,
+		chartWidth, chartHeight, chartWidth, chartHeight)
+}
+
+func drawBands(sb *strings.Builder, p plot, bands []Band) {
+	for _, b := range bands {
+		lo, hi := math.Max(b.From, p.yMin), math.Min(b.To, p.yMax)
+		if hi <= lo {
+			continue
+		}
+		fmt.Fprintf(sb, `<rect x="%d" y="%.1f" width="%d" height="%.1f" fill="%s" opacity="0.25"/>`,
+			marginLeft, p.y(hi), chartWidth-marginLeft-marginRight, p.y(lo)-p.y(hi), b.Color)
+	}
+}
+
+// drawAxes draws both axes with y ticks, x ticks when numericX is set,
+// and axis labels.
+func drawAxes(sb *strings.Builder, p plot, xLabel, yLabel string, numericX bool) {
+	left, right := marginLeft, chartWidth-marginRight
+	bottom := chartHeight - marginBottom
+	fmt.Fprintf(sb, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#000"/>`, left, bottom, right, bottom)
+	fmt.Fprintf(sb, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#000"/>`, left, marginTop, left, bottom)
+	for _, t := range ticks(p.yMin, p.yMax, 5) {
+		y := p.y(t)
+		fmt.Fprintf(sb, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#ddd"/>`, left, y, right, y)
+		fmt.Fprintf(sb, `<text x="%d" y="%.1f" font-size="10" text-anchor="end">%s</text>`, left-4, y+3, formatTick(t))
+	}
+	if numericX {
+		for _, t := range ticks(p.xMin, p.xMax, 8) {
+			fmt.Fprintf(sb, `<text x="%.1f" y="%d" font-size="10" text-anchor="middle">%s</text>`, p.x(t), bottom+14, formatTick(t))
+		}
+	}
+	fmt.Fprintf(sb, `<text x="%d" y="%d" font-size="12" text-anchor="middle">%s</text>`,
+		(left+right)/2, chartHeight-12, html.EscapeString(xLabel))
+	fmt.Fprintf(sb, `<text x="14" y="%d" font-size="12" text-anchor="middle" transform="rotate(-90 14 %d)">%s</text>`,
+		(marginTop+bottom)/2, (marginTop+bottom)/2, html.EscapeString(yLabel))
+}
+
+func drawLegend(sb *strings.Builder, i int, name, color string) {
+	if name == "" {
+		return
+	}
+	x, y := chartWidth-marginRight+10, marginTop+10+i*16
+	fmt.Fprintf(sb, `<rect x="%d" y="%d" width="10" height="10" fill="%s"/>`, x, y-9, color)
+	fmt.Fprintf(sb, `<text x="%d" y="%d" font-size="11">%s</text>`, x+14, y, html.EscapeString(name))
+}
+
+// ticks returns about n evenly spaced round values covering [lo, hi].
+func ticks(lo, hi float64, n int) []float64 {
+	if hi <= lo || n < 1 {
+		return []float64{lo}
+	}
+	raw := (hi - lo) / float64(n)
+	mag := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := mag
+	for _, m := range []float64{1, 2, 5, 10} {
+		if m*mag >= raw {
+			step = m * mag
+			break
+		}
+	}
+	values := make([]float64, 0, n+2)
+	first := math.Ceil(lo / step)
+	for i := 0.0; (first+i)*step <= hi+step*1e-9; i++ {
+		values = append(values, (first+i)*step)
+	}
+	return values
+}
+
+func formatTick(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return fmt.Sprintf("%d", int64(v))
+	}
+	return fmt.Sprintf("%.3g", v)
+}
@@ -0,0 +1,366 @@
+// Package report renders analysis results as a single self-contained
+// HTML page. Charts are drawn as inline SVG and styles are embedded, so
+// the file needs no external assets and can be mailed, archived or served
+// as a download.
+//
+// Comparison with Aria:
+//
+//	Aria marks rendering as pure over its inputs:
+//	  fn render(report: Report) -> String
+//	    ensures result.starts_with("<!DOCTYPE html>")
+//
+//	Go builds the page with html/template, which escapes all text.
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/stats"
+)
+
+// Table is a simple table with a header row.
+type Table struct {
+	Headers []string
+	Rows    [][]string
+}
+
+// Section is one titled part of a report: optional text, a table and any
+// number of charts.
+type Section struct {
+	Title  string
+	Text   string
+	Table  *Table
+	Charts []Chart
+}
+
+// Report is an HTML report made of sections.
+type Report struct {
+	Title    string
+	Sections []Section
+}
+
+// New creates an empty report.
+func New(title string) *Report {
+	return &Report{Title: title}
+}
+
+// Add appends sections to the report.
+func (r *Report) Add(sections ...Section) {
+	r.Sections = append(r.Sections, sections...)
+}
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h1 { border-bottom: 2px solid #1f77b4; padding-bottom: 0.2em; }
+nav a { margin-right: 1em; }
+section { margin-bottom: 2.5em; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: left; }
+th { background: #f3f3f3; }
+svg { display: block; margin: 1em 0; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<nav>{{range $i, $s := .Sections}}<a href="#s{{$i}}">{{$s.Title}}</a>{{end}}</nav>
+{{range $i, $s := .Sections}}<section id="s{{$i}}">
+<h2>{{$s.Title}}</h2>
+{{if $s.Text}}<p>{{$s.Text}}</p>
+{{end}}{{with $s.Table}}<table>
+<tr>{{range .Headers}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}{{range $s.SVG}}{{.}}
+{{end}}</section>
+{{end}}</body>
+</html>
+`))
+
+// WriteHTML writes the report as a standalone HTML page.
+func (r *Report) WriteHTML(w io.Writer) error {
+	type section struct {
+		Section
+		SVG []template.HTML
+	}
+	data := struct {
+		Title    string
+		Sections []section
+	}{Title: r.Title}
+	for _, s := range r.Sections {
+		sec := section{Section: s}
+		for _, c := range s.Charts {
+			// Chart SVG is generated here with all text escaped.
+			sec.SVG = append(sec.SVG, template.HTML(c.SVG()))
+		}
+		data.Sections = append(data.Sections, sec)
+	}
+	return pageTemplate.Execute(w, data)
+}
+
+// qualityBands are FastQC's good, reasonable and poor quality zones.
+var qualityBands = []Band{
+	{From: 0, To: 20, Color: "#e6a0a0"},
+	{From: 20, To: 28, Color: "#e6d2a0"},
+	{From: 28, To: 1000, Color: "#a0e6a0"},
+}
+
+// ReadSetSections describes read statistics: a summary, the per-position
+// quality box plot, the number of reads reaching each length and the
+// distribution of mean read quality.
+//
+// Aria equivalent:
+//
+//	fn read_set_sections(stats: ReadSetStats) -> [Section]
+func ReadSetSections(s *stats.ReadSetStats) []Section {
+	summary := Section{Title: "Summary", Table: &Table{
+		Headers: []string{"Metric", "Value"},
+		Rows: [][]string{
+			{"Reads", fmt.Sprint(s.Count)},
+			{"Total bases", fmt.Sprint(s.TotalBases)},
+			{"Length range", fmt.Sprintf("%d - %d bp", s.MinLength, s.MaxLength)},
+			{"Mean length", fmt.Sprintf("%.1f bp", s.MeanLength)},
+			{"Mean quality", fmt.Sprintf("%.1f", s.MeanQuality)},
+			{"Median quality", fmt.Sprintf("%.1f", s.MedianQuality)},
+			{"High quality reads (Q30+)", fmt.Sprintf("%d (%.1f%%)", s.HighQualityCount, 100*s.HighQualityRatio())},
+		},
+	}}
+	summary.Table.Rows = append(summary.Table.Rows, intervalRows(s.Intervals)...)
+
+	boxes := make([]Box, len(s.PositionQuality))
+	lengths := Series{Name: "reads"}
+	for i, p := range s.PositionQuality {
+		boxes[i] = Box{
+			Label: fmt.Sprint(p.Position), Low: float64(p.Percentile10), Q1: float64(p.LowerQuartile),
+			Median: float64(p.Median), Q3: float64(p.UpperQuartile), High: float64(p.Percentile90), Mean: p.Mean,
+		}
+		lengths.X = append(lengths.X, float64(p.Position))
+		lengths.Y = append(lengths.Y, float64(p.Reads))
+	}
+	perPosition := Section{
+		Title:  "Per-position quality",
+		Text:   "Boxes span the quartiles, whiskers the 10th and 90th percentiles; the line follows the mean.",
+		Charts: []Chart{BoxChart{XLabel: "Position in read (bp)", YLabel: "Phred quality", Boxes: boxes, YMin: 0, YMax: 41, Bands: qualityBands}},
+	}
+	readLengths := Section{
+		Title:  "Reads reaching each position",
+		Charts: []Chart{LineChart{XLabel: "Position in read (bp)", YLabel: "Reads", Series: []Series{lengths}}},
+	}
+
+	sections := []Section{summary, perPosition, readLengths}
+	if d := s.QualityDistribution; d != nil {
+		sections = append(sections, Section{
+			Title: "Mean read quality",
+			Charts: []Chart{BarChart{
+				XLabel: "Quality category", YLabel: "Reads",
+				Labels: []string{"Q<10", "Q10-20", "Q20-30", "Q30-40", "Q40+"},
+				Values: []float64{float64(d.PoorCount), float64(d.LowCount), float64(d.MediumCount), float64(d.HighCount), float64(d.ExcellentCount)},
+			}},
+		})
+	}
+	return sections
+}
+
+// maxKMerSeries is the number of enriched k-mers plotted by position.
+const maxKMerSeries = 6
+
+// QC builds the full read QC report.
+//
+// Aria equivalent:
+//
+//	fn qc(report: QCReport) -> Report
+//	  ensures result.sections.len() >= 4
+func QC(title string, qc *stats.QCReport) *Report {
+	r := New(title)
+	r.Add(ReadSetSections(qc.Reads)...)
+	if len(qc.ReadGroups) > 0 {
+		groups := Section{Title: "Read groups", Table: &Table{Headers: []string{"ID", "Sample", "Library", "Platform unit", "Platform", "Reads"}}}
+		for _, g := range qc.ReadGroups {
+			groups.Table.Rows = append(groups.Table.Rows, []string{g.ID, g.Sample, g.Library, g.PlatformUnit(), g.Platform, fmt.Sprint(g.Reads)})
+		}
+		r.Add(groups)
+	}
+
+	kmers := Section{Title: "Overrepresented k-mers"}
+	if len(qc.KMers) == 0 {
+		kmers.Text = "No k-mer is enriched at any position."
+	} else {
+		kmers.Text = "K-mers whose observed/expected ratio peaks at particular positions, typical of adapters and ligation bias."
+		kmers.Table = &Table{Headers: []string{"K-mer", "Count", "Max obs/exp", "Position"}}
+		chart := LineChart{XLabel: "Position in read (bp)", YLabel: "Observed/expected"}
+		for i, k := range qc.KMers {
+			kmers.Table.Rows = append(kmers.Table.Rows, []string{k.KMer, fmt.Sprint(k.Count), fmt.Sprintf("%.2f", k.MaxRatio), fmt.Sprint(k.MaxPosition)})
+			if i < maxKMerSeries {
+				chart.Series = append(chart.Series, positionSeries(k.KMer, k.Ratios))
+			}
+		}
+		kmers.Charts = []Chart{chart}
+	}
+	r.Add(kmers)
+
+	adapters := Section{Title: "Adapter content", Text: "Cumulative percentage of reads in which each adapter has started by a position."}
+	chart := LineChart{XLabel: "Position in read (bp)", YLabel: "% reads", YMin: 0, YMax: 100}
+	for _, a := range qc.Adapters {
+		percent := make([]float64, len(a.Fractions))
+		for i, f := range a.Fractions {
+			percent[i] = 100 * f
+		}
+		chart.Series = append(chart.Series, positionSeries(a.Name, percent))
+	}
+	adapters.Charts = []Chart{chart}
+	r.Add(adapters)
+	return r
+}
+
+// positionSeries plots values against 1-based positions.
+func positionSeries(name string, values []float64) Series {
+	s := Series{Name: name, X: make([]float64, len(values)), Y: values}
+	for i := range values {
+		s.X[i] = float64(i + 1)
+	}
+	return s
+}
+
+// SequenceSetSection summarizes a set of sequences.
+func SequenceSetSection(s *stats.SequenceSetStats) Section {
+	sec := Section{Title: "Sequence statistics", Table: &Table{
+		Headers: []string{"Metric", "Value"},
+		Rows: [][]string{
+			{"Sequences", fmt.Sprint(s.Count)},
+			{"Total bases", fmt.Sprint(s.TotalBases)},
+			{"Length range", fmt.Sprintf("%d - %d bp", s.MinLength, s.MaxLength)},
+			{"Mean length", fmt.Sprintf("%.1f bp", s.MeanLength)},
+			{"Median length", fmt.Sprintf("%d bp", s.MedianLength)},
+			{"N50", fmt.Sprintf("%d bp", s.N50)},
+			{"Mean GC content", fmt.Sprintf("%.2f%%", 100*s.MeanGCContent)},
+			{"Ambiguous bases", fmt.Sprint(s.TotalAmbiguous)},
+		},
+	}}
+	sec.Table.Rows = append(sec.Table.Rows, intervalRows(s.Intervals)...)
+	return sec
+}
+
+// intervalRows formats bootstrap confidence intervals as table rows.
+func intervalRows(ci *stats.Intervals) [][]string {
+	if ci == nil {
+		return nil
+	}
+	level := fmt.Sprintf("%g%% CI", 100*ci.Level)
+	rows := [][]string{
+		{"Mean length (" + level + ")", fmt.Sprintf("%.1f - %.1f bp", ci.MeanLength.Lower, ci.MeanLength.Upper)},
+		{"Mean GC content (" + level + ")", fmt.Sprintf("%.2f%% - %.2f%%", 100*ci.MeanGCContent.Lower, 100*ci.MeanGCContent.Upper)},
+	}
+	if q := ci.MeanQuality; q != nil {
+		rows = append(rows, []string{"Mean quality (" + level + ")", fmt.Sprintf("%.1f - %.1f", q.Lower, q.Upper)})
+	}
+	return rows
+}
+
+// HistogramChart plots a histogram as bars labeled by bin start.
+func HistogramChart(h *stats.Histogram, xLabel string) BarChart {
+	chart := BarChart{XLabel: xLabel, YLabel: "Count"}
+	for i, c := range h.Counts {
+		chart.Labels = append(chart.Labels, formatTick(h.Edges[i]))
+		chart.Values = append(chart.Values, float64(c))
+	}
+	return chart
+}
+
+// HistogramSection shows a histogram with its quartiles.
+func HistogramSection(title, xLabel string, h *stats.Histogram) Section {
+	sec := Section{Title: title, Charts: []Chart{HistogramChart(h, xLabel)}}
+	q1, err1 := h.Percentile(0.25)
+	median, err2 := h.Percentile(0.5)
+	q3, err3 := h.Percentile(0.75)
+	if err1 == nil && err2 == nil && err3 == nil {
+		sec.Text = fmt.Sprintf("Quartiles (interpolated): %.3g, %.3g, %.3g.", q1, median, q3)
+	}
+	return sec
+}
+
+// JointSection shows length/GC and quality/length correlations as
+// heatmaps, with any artifacts flagged.
+func JointSection(j *stats.JointStats) Section {
+	sec := Section{Title: "Joint statistics", Table: &Table{Headers: []string{"Variables", "Pairs", "Pearson", "Spearman"}}}
+	for _, c := range []*stats.Correlation{j.LengthGC, j.QualityLength} {
+		if c == nil {
+			continue
+		}
+		sec.Table.Rows = append(sec.Table.Rows, []string{c.X + " vs " + c.Y, fmt.Sprint(c.Pairs),
+			fmt.Sprintf("%.3f", c.Pearson), fmt.Sprintf("%.3f", c.Spearman)})
+		sec.Charts = append(sec.Charts, Heatmap{
+			XLabel: c.X, YLabel: c.Y, XEdges: c.Grid.XEdges, YEdges: c.Grid.YEdges, Counts: c.Grid.Counts,
+		})
+	}
+	for _, a := range j.Artifacts {
+		sec.Text += "Warning: " + a.Message + ". "
+	}
+	return sec
+}
+
+// FilterSummary is the outcome of quality filtering. Reasons counts the
+// failed reads by rejection reason and may be empty.
+type FilterSummary struct {
+	Total   int
+	Passed  int
+	Failed  int
+	Reasons map[string]int
+}
+
+// NewFilterSummary counts filtering outcomes, grouping rejection reasons
+// by their text before the first number (so "sequence too short: 12 (min:
+// 50)" counts as "sequence too short").
+func NewFilterSummary(total, passed int, reasons []string) FilterSummary {
+	f := FilterSummary{Total: total, Passed: passed, Failed: total - passed, Reasons: make(map[string]int)}
+	for _, reason := range reasons {
+		if i := strings.IndexAny(reason, "0123456789"); i >= 0 {
+			reason = reason[:i]
+		}
+		reason = strings.TrimRight(reason, " :")
+		f.Reasons[reason]++
+	}
+	return f
+}
+
+// FilterSection summarizes filtering, with a chart of rejection reasons.
+func FilterSection(f FilterSummary) Section {
+	rate := 0.0
+	if f.Total > 0 {
+		rate = float64(f.Passed) / float64(f.Total)
+	}
+	sec := Section{Title: "Quality filtering", Table: &Table{
+		Headers: []string{"Metric", "Value"},
+		Rows: [][]string{
+			{"Reads processed", fmt.Sprint(f.Total)},
+			{"Passed", fmt.Sprintf("%d (%.1f%%)", f.Passed, 100*rate)},
+			{"Failed", fmt.Sprintf("%d (%.1f%%)", f.Failed, 100*(1-rate))},
+		},
+	}}
+	if len(f.Reasons) > 0 {
+		reasons := make([]string, 0, len(f.Reasons))
+		for reason := range f.Reasons {
+			reasons = append(reasons, reason)
+		}
+		sort.Slice(reasons, func(i, j int) bool {
+			if f.Reasons[reasons[i]] != f.Reasons[reasons[j]] {
+				return f.Reasons[reasons[i]] > f.Reasons[reasons[j]]
+			}
+			return reasons[i] < reasons[j]
+		})
+		chart := BarChart{XLabel: "Rejection reason", YLabel: "Reads"}
+		for _, reason := range reasons {
+			chart.Labels = append(chart.Labels, reason)
+			chart.Values = append(chart.Values, float64(f.Reasons[reason]))
+		}
+		sec.Charts = []Chart{chart}
+	}
+	return sec
+}
@@ -0,0 +1,132 @@
+package report
+
+import (
+	"encoding/xml"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/quality"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/aria-lang/bioflow-go/internal/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wellFormed reports whether every inline SVG parses as XML.
+func wellFormed(t *testing.T, page string) int {
+	charts := regexp.MustCompile(`(?s)<svg.*?</svg>`).FindAllString(page, -1)
+	for _, c := range charts {
+		var v struct{}
+		require.NoError(t, xml.Unmarshal([]byte(c), &v), c)
+	}
+	return len(charts)
+}
+
+func TestQCReport(t *testing.T) {
+	reads := make([]*sequence.Sequence, 0)
+	qualities := make([]*quality.Scores, 0)
+	for _, bases := range []string{"ACGTACGTAGATCGGAAGAG", "TTGACCAGATCGGAAGAG", "GGCATTAC"} {
+		s, err := sequence.New(bases)
+		require.NoError(t, err)
+		q := make([]int, len(bases))
+		for i := range q {
+			q[i] = 40 - i
+		}
+		scores, err := quality.New(q)
+		require.NoError(t, err)
+		reads = append(reads, s)
+		qualities = append(qualities, scores)
+	}
+	qc, err := stats.NewQCReport(reads, qualities, stats.DefaultQCOptions())
+	require.NoError(t, err)
+
+	r := QC("QC <sample>", qc)
+	titles := make([]string, len(r.Sections))
+	for i, s := range r.Sections {
+		titles[i] = s.Title
+	}
+	assert.Equal(t, []string{"Summary", "Per-position quality", "Reads reaching each position",
+		"Mean read quality", "Overrepresented k-mers", "Adapter content"}, titles)
+
+	var sb strings.Builder
+	require.NoError(t, r.WriteHTML(&sb))
+	page := sb.String()
+	assert.True(t, strings.HasPrefix(page, "<!DOCTYPE html>"))
+	assert.Contains(t, page, "<title>QC &lt;sample&gt;</title>")
+	assert.Contains(t, page, "Illumina Universal Adapter")
+	assert.NotContains(t, page, "<script src")
+	assert.NotContains(t, page, "<link")
+	// Three reads are too few for enriched k-mers, so that section has
+	// text instead of a chart.
+	assert.Contains(t, page, "No k-mer is enriched")
+	assert.Equal(t, 4, wellFormed(t, page))
+}
+
+func TestFilterSection(t *testing.T) {
+	f := NewFilterSummary(5, 2, []string{
+		"sequence too short: 12 (min: 50)",
+		"average quality 15.20 below minimum 20",
+		"sequence too short: 30 (min: 50)",
+	})
+	assert.Equal(t, 3, f.Failed)
+	assert.Equal(t, map[string]int{"sequence too short": 2, "average quality": 1}, f.Reasons)
+
+	sec := FilterSection(f)
+	require.NotNil(t, sec.Table)
+	assert.Equal(t, []string{"Passed", "2 (40.0%)"}, sec.Table.Rows[1])
+	require.Len(t, sec.Charts, 1)
+	bars := sec.Charts[0].(BarChart)
+	assert.Equal(t, []string{"sequence too short", "average quality"}, bars.Labels)
+
+	r := New("Filter")
+	r.Add(sec)
+	var sb strings.Builder
+	require.NoError(t, r.WriteHTML(&sb))
+	assert.Equal(t, 1, wellFormed(t, sb.String()))
+}
+
+func TestTicks(t *testing.T) {
+	assert.Equal(t, []float64{0, 10, 20, 30, 40}, ticks(0, 41, 5))
+	labels := make([]string, 0)
+	for _, v := range ticks(0, 1, 5) {
+		labels = append(labels, formatTick(v))
+	}
+	assert.Equal(t, []string{"0", "0.2", "0.4", "0.6", "0.8", "1"}, labels)
+	assert.Equal(t, []float64{3}, ticks(3, 3, 5))
+	assert.Equal(t, "40", formatTick(40))
+	assert.Equal(t, "0.25", formatTick(0.25))
+}
+
+func TestHistogramSection(t *testing.T) {
+	h, err := stats.NewUniformHistogram(0, 1, 4)
+	require.NoError(t, err)
+	h.Add(0.1, 0.3, 0.6, 0.9)
+	sec := HistogramSection("GC content", "GC fraction", h)
+	assert.Equal(t, "Quartiles (interpolated): 0.25, 0.5, 0.75.", sec.Text)
+	bars := sec.Charts[0].(BarChart)
+	assert.Equal(t, []string{"0", "0.25", "0.5", "0.75"}, bars.Labels)
+	assert.Equal(t, []float64{1, 1, 1, 1}, bars.Values)
+}
+
+func TestJointSection(t *testing.T) {
+	seqs := make([]*sequence.Sequence, 0)
+	for _, bases := range []string{"ACGTACGTAT", "ACGTAT", "GGCC", "ATATATATATAT"} {
+		s, err := sequence.New(bases)
+		require.NoError(t, err)
+		seqs = append(seqs, s)
+	}
+	joint, err := stats.NewJointStats(seqs, nil, stats.DefaultJointOptions())
+	require.NoError(t, err)
+
+	sec := JointSection(joint)
+	require.Len(t, sec.Table.Rows, 1)
+	assert.Equal(t, "length vs gc_content", sec.Table.Rows[0][0])
+	require.Len(t, sec.Charts, 1)
+
+	r := New("Joint")
+	r.Add(sec)
+	var sb strings.Builder
+	require.NoError(t, r.WriteHTML(&sb))
+	assert.Equal(t, 1, wellFormed(t, sb.String()))
+}
@@ -0,0 +1,350 @@
+// Package rna provides RNA secondary structure prediction.
+//
+// Folding uses Nussinov base-pair maximization with an optional
+// hydrogen-bond weighting, a minimum hairpin loop length and user
+// constraints in dot-bracket notation. Structures are nested (no
+// pseudoknots) and returned both as dot-bracket strings and as pair
+// coordinates.
+//
+// Comparison with Aria:
+//
+//	Aria can state the nesting property as a postcondition:
+//	  fn fold(seq: RNA) -> Structure
+//	    ensures result.pairs.all(|p, q| !p.crosses(q))
+//
+//	Go documents the property and tests it.
+package rna
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// DefaultMinLoop is the minimum number of unpaired bases in a hairpin loop.
+const DefaultMinLoop = 3
+
+// MaxLength is the longest sequence Fold accepts; folding is O(n^3).
+const MaxLength = 3000
+
+// Model selects how base pairs are scored.
+type Model int
+
+const (
+	// PairCount maximizes the number of base pairs.
+	PairCount Model = iota
+	// HydrogenBonds weights pairs by hydrogen bonds (GC=3, AU=2, GU=1),
+	// a crude energy model favouring GC-rich stems.
+	HydrogenBonds
+)
+
+func (m Model) String() string {
+	switch m {
+	case PairCount:
+		return "pairs"
+	case HydrogenBonds:
+		return "hbonds"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseModel parses a model name ("pairs" or "hbonds").
+func ParseModel(name string) (Model, error) {
+	switch strings.ToLower(name) {
+	case "", "pairs", "nussinov":
+		return PairCount, nil
+	case "hbonds", "energy":
+		return HydrogenBonds, nil
+	default:
+		return 0, fmt.Errorf("unknown folding model: %s", name)
+	}
+}
+
+// Options configures folding.
+type Options struct {
+	// MinLoop is the minimum hairpin loop length (default DefaultMinLoop).
+	MinLoop int
+	// NoWobble disallows G-U wobble pairs.
+	NoWobble bool
+	// Model selects the pair scoring scheme.
+	Model Model
+	// Constraint is an optional dot-bracket string of the same length as
+	// the sequence: '(' and ')' force a pair, 'x' forces a base unpaired
+	// and '.' leaves it free.
+	Constraint string
+}
+
+// Pair is a base pair between 0-based positions I < J.
+type Pair struct {
+	I int `json:"i"`
+	J int `json:"j"`
+}
+
+// Structure is a predicted secondary structure.
+//
+// Aria equivalent:
+//
+//	struct Structure
+//	  invariant self.dot_bracket.len() == self.sequence.len()
+//	  invariant self.pairs.all(|p| p.i < p.j)
+type Structure struct {
+	Sequence   string `json:"sequence"`
+	DotBracket string `json:"dot_bracket"`
+	Pairs      []Pair `json:"pairs"`
+	Score      int    `json:"score"`
+	Model      string `json:"model"`
+}
+
+// PairedFraction returns the fraction of bases that are paired.
+func (s *Structure) PairedFraction() float64 {
+	if len(s.Sequence) == 0 {
+		return 0
+	}
+	return float64(2*len(s.Pairs)) / float64(len(s.Sequence))
+}
+
+func (s *Structure) String() string {
+	return fmt.Sprintf("%s\n%s (%d pairs, score %d)", s.Sequence, s.DotBracket, len(s.Pairs), s.Score)
+}
+
+// pairWeight returns the score of pairing a and b, or 0 if they can't pair.
+func pairWeight(a, b byte, opts Options) int {
+	var bonds int
+	switch string([]byte{a, b}) {
+	case "GC", "CG":
+		bonds = 3
+	case "AU", "UA":
+		bonds = 2
+	case "GU", "UG":
+		if opts.NoWobble {
+			return 0
+		}
+		bonds = 1
+	default:
+		return 0
+	}
+	if opts.Model == HydrogenBonds {
+		return bonds
+	}
+	return 1
+}
+
+// ParseDotBracket returns the pairs encoded by a dot-bracket string.
+// Characters other than '(' and ')' are treated as unpaired.
+func ParseDotBracket(db string) ([]Pair, error) {
+	var stack []int
+	pairs := make([]Pair, 0)
+	for i := 0; i < len(db); i++ {
+		switch db[i] {
+		case '(':
+			stack = append(stack, i)
+		case ')':
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("unbalanced ')' at position %d", i)
+			}
+			open := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			pairs = append(pairs, Pair{I: open, J: i})
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("unbalanced '(' at position %d", stack[len(stack)-1])
+	}
+	return pairs, nil
+}
+
+// DotBracket renders pairs as a dot-bracket string of length n.
+func DotBracket(n int, pairs []Pair) string {
+	db := []byte(strings.Repeat(".", n))
+	for _, p := range pairs {
+		db[p.I] = '('
+		db[p.J] = ')'
+	}
+	return string(db)
+}
+
+// normalize uppercases the sequence, converts T to U and validates bases.
+func normalize(bases string) (string, error) {
+	b := []byte(strings.ToUpper(bases))
+	for i, c := range b {
+		switch c {
+		case 'A', 'C', 'G', 'U', 'N':
+		case 'T':
+			b[i] = 'U'
+		default:
+			return "", fmt.Errorf("invalid RNA base '%c' at position %d", c, i)
+		}
+	}
+	return string(b), nil
+}
+
+// Fold predicts a nested secondary structure by Nussinov dynamic
+// programming. DNA input is accepted and treated as RNA (T -> U).
+//
+// Aria equivalent:
+//
+//	fn fold(bases: String, options: Options) -> Result<Structure, FoldError>
+//	  requires bases.len() <= MAX_LENGTH
+//	  requires options.constraint.is_empty() or options.constraint.len() == bases.len()
+//	  ensures result.dot_bracket.len() == bases.len()
+func Fold(bases string, opts Options) (*Structure, error) {
+	return FoldContext(context.Background(), bases, opts)
+}
+
+// FoldContext folds as Fold does, and stops with the context's error once
+// ctx is done, checking it once per row of the DP table, so that a caller
+// giving up on a long sequence does not leave the fold running.
+func FoldContext(ctx context.Context, bases string, opts Options) (*Structure, error) {
+	seq, err := normalize(bases)
+	if err != nil {
+		return nil, err
+	}
+	n := len(seq)
+	if n > MaxLength {
+		return nil, fmt.Errorf("sequence length %d exceeds maximum of %d", n, MaxLength)
+	}
+	if opts.MinLoop <= 0 {
+		opts.MinLoop = DefaultMinLoop
+	}
+
+	// partner[i] is the forced partner of i, -1 if free, -2 if forced unpaired.
+	partner := make([]int, n)
+	for i := range partner {
+		partner[i] = -1
+	}
+	if opts.Constraint != "" {
+		if len(opts.Constraint) != n {
+			return nil, fmt.Errorf("constraint length %d doesn't match sequence length %d", len(opts.Constraint), n)
+		}
+		forced, err := ParseDotBracket(opts.Constraint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid constraint: %w", err)
+		}
+		for _, p := range forced {
+			if pairWeight(seq[p.I], seq[p.J], opts) == 0 {
+				return nil, fmt.Errorf("constraint pairs %c%d with %c%d, which can't pair", seq[p.I], p.I+1, seq[p.J], p.J+1)
+			}
+			if p.J-p.I <= opts.MinLoop {
+				return nil, fmt.Errorf("constrained pair %d-%d encloses a loop shorter than %d", p.I+1, p.J+1, opts.MinLoop)
+			}
+			partner[p.I], partner[p.J] = p.J, p.I
+		}
+		for i := 0; i < n; i++ {
+			switch opts.Constraint[i] {
+			case 'x', 'X':
+				partner[i] = -2
+			case '.', '(', ')':
+			default:
+				return nil, fmt.Errorf("invalid constraint character '%c' at position %d", opts.Constraint[i], i)
+			}
+		}
+	}
+
+	canPair := func(i, j int) int {
+		if j-i <= opts.MinLoop {
+			return 0
+		}
+		if partner[i] == -2 || partner[j] == -2 {
+			return 0
+		}
+		if (partner[i] >= 0 && partner[i] != j) || (partner[j] >= 0 && partner[j] != i) {
+			return 0
+		}
+		return pairWeight(seq[i], seq[j], opts)
+	}
+
+	// dp[i][j] is the best score for seq[i..j]; infeasible intervals (a
+	// forced pair with its partner outside) hold math.MinInt32.
+	const infeasible = math.MinInt32
+	dp := make([][]int32, n+1)
+	for i := range dp {
+		dp[i] = make([]int32, n+1)
+	}
+	score := func(i, j int) int32 {
+		if i > j {
+			return 0
+		}
+		return dp[i][j]
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("folding: %w", err)
+		}
+		for j := i; j < n; j++ {
+			best := int32(infeasible)
+			// i unpaired
+			if partner[i] < 0 {
+				best = score(i+1, j)
+			}
+			// i pairs with k
+			for k := i + opts.MinLoop + 1; k <= j; k++ {
+				w := canPair(i, k)
+				if w == 0 {
+					continue
+				}
+				inner, outer := score(i+1, k-1), score(k+1, j)
+				if inner == infeasible || outer == infeasible {
+					continue
+				}
+				if s := int32(w) + inner + outer; s > best {
+					best = s
+				}
+			}
+			dp[i][j] = best
+		}
+	}
+
+	if n > 0 && dp[0][n-1] == infeasible {
+		return nil, fmt.Errorf("constraint cannot be satisfied")
+	}
+
+	pairs := make([]Pair, 0)
+	var traceback func(i, j int)
+	traceback = func(i, j int) {
+		for i < j {
+			if partner[i] < 0 && score(i+1, j) == dp[i][j] {
+				i++
+				continue
+			}
+			next := i + 1
+			for k := i + opts.MinLoop + 1; k <= j; k++ {
+				w := canPair(i, k)
+				if w == 0 {
+					continue
+				}
+				inner, outer := score(i+1, k-1), score(k+1, j)
+				if inner == infeasible || outer == infeasible {
+					continue
+				}
+				if int32(w)+inner+outer == dp[i][j] {
+					pairs = append(pairs, Pair{I: i, J: k})
+					traceback(i+1, k-1)
+					next = k + 1
+					break
+				}
+			}
+			i = next
+		}
+	}
+	if n > 0 {
+		traceback(0, n-1)
+	}
+
+	total := 0
+	if n > 0 {
+		total = int(dp[0][n-1])
+	}
+
+	sort.Slice(pairs, func(a, b int) bool { return pairs[a].I < pairs[b].I })
+	return &Structure{
+		Sequence:   seq,
+		DotBracket: DotBracket(n, pairs),
+		Pairs:      pairs,
+		Score:      total,
+		Model:      opts.Model.String(),
+	}, nil
+}
@@ -0,0 +1,131 @@
+package rna
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertNested checks that no two pairs cross and each base pairs at most once.
+func assertNested(t *testing.T, s *Structure) {
+	used := make(map[int]bool)
+	for _, p := range s.Pairs {
+		assert.Less(t, p.I, p.J)
+		assert.False(t, used[p.I] || used[p.J], "base paired twice")
+		used[p.I], used[p.J] = true, true
+		for _, q := range s.Pairs {
+			crosses := p.I < q.I && q.I < p.J && p.J < q.J
+			assert.False(t, crosses, "pairs %v and %v cross", p, q)
+		}
+	}
+}
+
+func TestFoldHairpin(t *testing.T) {
+	s, err := Fold("GGGGAAAACCCC", Options{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "((((....))))", s.DotBracket)
+	assert.Equal(t, 4, s.Score)
+	assert.Len(t, s.Pairs, 4)
+	assert.Equal(t, Pair{I: 0, J: 11}, s.Pairs[0])
+	assert.InDelta(t, 8.0/12.0, s.PairedFraction(), 1e-9)
+	assertNested(t, s)
+}
+
+func TestFoldMinLoop(t *testing.T) {
+	s, err := Fold("GCAAC", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "(...)", s.DotBracket)
+
+	s, err = Fold("GCAAC", Options{MinLoop: 4})
+	require.NoError(t, err)
+	assert.Equal(t, ".....", s.DotBracket)
+	assert.Equal(t, 0, s.Score)
+}
+
+func TestFoldDNAInput(t *testing.T) {
+	s, err := Fold("ggggttttcccc", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "GGGGUUUUCCCC", s.Sequence)
+
+	_, err = Fold("GGGXCCC", Options{})
+	assert.Error(t, err)
+}
+
+func TestFoldModels(t *testing.T) {
+	s, err := Fold("GGGAAAUCC", Options{Model: HydrogenBonds})
+	require.NoError(t, err)
+	assert.Equal(t, "hbonds", s.Model)
+	assertNested(t, s)
+
+	// Wobble pairs only.
+	s, err = Fold("GGGUUUUUU", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, s.Score)
+	s, err = Fold("GGGUUUUUU", Options{NoWobble: true})
+	require.NoError(t, err)
+	assert.Equal(t, 0, s.Score)
+
+	m, err := ParseModel("energy")
+	require.NoError(t, err)
+	assert.Equal(t, HydrogenBonds, m)
+	_, err = ParseModel("mfe")
+	assert.Error(t, err)
+}
+
+func TestFoldConstraints(t *testing.T) {
+	seq := "GGGGAAAACCCC"
+
+	s, err := Fold(seq, Options{Constraint: "x..........."})
+	require.NoError(t, err)
+	assert.Equal(t, byte('.'), s.DotBracket[0])
+	assertNested(t, s)
+
+	s, err = Fold(seq, Options{Constraint: "...(....)..."})
+	require.NoError(t, err)
+	assert.Contains(t, s.Pairs, Pair{I: 3, J: 8})
+	assertNested(t, s)
+
+	_, err = Fold(seq, Options{Constraint: "(..........)x"})
+	assert.Error(t, err, "length mismatch")
+	_, err = Fold(seq, Options{Constraint: "((.........."})
+	assert.Error(t, err, "unbalanced")
+	_, err = Fold(seq, Options{Constraint: "(..........."[:11] + ")"})
+	assert.NoError(t, err)
+	_, err = Fold(seq, Options{Constraint: "....(......)"})
+	assert.Error(t, err, "A-C can't pair")
+}
+
+func TestFoldEmpty(t *testing.T) {
+	s, err := Fold("", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "", s.DotBracket)
+	assert.Empty(t, s.Pairs)
+}
+
+func TestDotBracketRoundTrip(t *testing.T) {
+	db := "((..((...))..))."
+	pairs, err := ParseDotBracket(db)
+	require.NoError(t, err)
+	assert.Equal(t, db, DotBracket(len(db), pairs))
+
+	_, err = ParseDotBracket("())")
+	assert.Error(t, err)
+}
+
+func TestFoldContext(t *testing.T) {
+	bases := strings.Repeat("GGGAAAUCCC", 20)
+	want, err := Fold(bases, Options{})
+	require.NoError(t, err)
+	got, err := FoldContext(context.Background(), bases, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = FoldContext(ctx, bases, Options{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,559 @@
+// Package sam reads mapped reads from SAM and BAM files.
+//
+// Only what downstream analyses need is decoded: the eleven mandatory
+// fields of each record plus, for SAM, the optional tags as raw text. BAM
+// files are BGZF-compressed, which the standard gzip reader handles as a
+// series of gzip members, so no external dependency is required.
+//
+// Comparison with Aria:
+//
+//	Aria states the record invariants on the type:
+//	  struct Record
+//	    invariant self.seq == "*" or self.qual == "*" or self.seq.len() == self.qual.len()
+//	    invariant self.pos >= 0
+//
+//	Go checks them when parsing.
+package sam
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/readgroup"
+)
+
+// SAM flag bits.
+const (
+	FlagPaired        = 0x1
+	FlagProperPair    = 0x2
+	FlagUnmapped      = 0x4
+	FlagMateUnmapped  = 0x8
+	FlagReverse       = 0x10
+	FlagMateReverse   = 0x20
+	FlagRead1         = 0x40
+	FlagRead2         = 0x80
+	FlagSecondary     = 0x100
+	FlagQCFail        = 0x200
+	FlagDuplicate     = 0x400
+	FlagSupplementary = 0x800
+)
+
+// Record is one alignment line. Pos is 1-based (0 when unmapped); Seq and
+// Qual are "*" when absent, and Qual is Phred+33 encoded.
+type Record struct {
+	QName string   `json:"qname"`
+	Flag  int      `json:"flag"`
+	RName string   `json:"rname"`
+	Pos   int      `json:"pos"`
+	MapQ  int      `json:"mapq"`
+	CIGAR string   `json:"cigar"`
+	RNext string   `json:"rnext"`
+	PNext int      `json:"pnext"`
+	TLen  int      `json:"tlen"`
+	Seq   string   `json:"seq"`
+	Qual  string   `json:"qual"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// IsUnmapped reports whether the read is unmapped.
+func (r *Record) IsUnmapped() bool {
+	return r.Flag&FlagUnmapped != 0 || r.RName == "*" || r.CIGAR == "*"
+}
+
+// IsReverse reports whether the read is mapped to the reverse strand.
+func (r *Record) IsReverse() bool { return r.Flag&FlagReverse != 0 }
+
+// IsSecondary reports whether the record is a secondary or supplementary
+// alignment.
+func (r *Record) IsSecondary() bool { return r.Flag&(FlagSecondary|FlagSupplementary) != 0 }
+
+// IsDuplicate reports whether the read is marked as a PCR or optical
+// duplicate.
+func (r *Record) IsDuplicate() bool { return r.Flag&FlagDuplicate != 0 }
+
+// FailsQC reports whether the read failed vendor quality checks.
+func (r *Record) FailsQC() bool { return r.Flag&FlagQCFail != 0 }
+
+// Reference is a @SQ header entry.
+type Reference struct {
+	Name   string `json:"name"`
+	Length int    `json:"length"`
+}
+
+// Header holds the header text lines, the reference dictionary and the
+// read groups.
+type Header struct {
+	Lines      []string
+	References []Reference
+	ReadGroups []readgroup.ReadGroup
+}
+
+// AddReadGroup adds an @RG line, replacing any line with the same ID.
+func (h *Header) AddReadGroup(g readgroup.ReadGroup) {
+	g = g.WithDefaults()
+	line := g.SAMHeaderLine()
+	for i, existing := range h.ReadGroups {
+		if existing.ID != g.ID {
+			continue
+		}
+		h.ReadGroups[i] = g
+		for j, l := range h.Lines {
+			if old, err := readgroup.ParseSAMHeaderLine(l); err == nil && old.ID == g.ID {
+				h.Lines[j] = line
+			}
+		}
+		return
+	}
+	h.ReadGroups = append(h.ReadGroups, g)
+	h.Lines = append(h.Lines, line)
+}
+
+// Tag returns the value of an optional TAG:TYPE:VALUE field.
+func (r *Record) Tag(tag string) (string, bool) {
+	for _, t := range r.Tags {
+		if len(t) > 5 && t[:2] == tag && t[2] == ':' && t[4] == ':' {
+			return t[5:], true
+		}
+	}
+	return "", false
+}
+
+// SetTag sets an optional field, replacing an existing field with the
+// same tag. Type is the SAM type character, such as 'Z' for strings.
+func (r *Record) SetTag(tag string, typ byte, value string) {
+	field := tag + ":" + string(typ) + ":" + value
+	for i, t := range r.Tags {
+		if len(t) > 3 && t[:2] == tag && t[2] == ':' {
+			r.Tags[i] = field
+			return
+		}
+	}
+	r.Tags = append(r.Tags, field)
+}
+
+// ParseRecord parses a SAM alignment line.
+func ParseRecord(line string) (*Record, error) {
+	fields := strings.Split(strings.TrimRight(line, "\r\n"), "\t")
+	if len(fields) < 11 {
+		return nil, fmt.Errorf("expected at least 11 columns, got %d", len(fields))
+	}
+	ints := make([]int, 0, 5)
+	for _, i := range []int{1, 3, 4, 7, 8} {
+		v, err := strconv.Atoi(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("column %d: invalid integer %q", i+1, fields[i])
+		}
+		ints = append(ints, v)
+	}
+	rec := &Record{
+		QName: fields[0],
+		Flag:  ints[0],
+		RName: fields[2],
+		Pos:   ints[1],
+		MapQ:  ints[2],
+		CIGAR: fields[5],
+		RNext: fields[6],
+		PNext: ints[3],
+		TLen:  ints[4],
+		Seq:   fields[9],
+		Qual:  fields[10],
+	}
+	if len(fields) > 11 {
+		rec.Tags = fields[11:]
+	}
+	if err := rec.validate(); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// validate checks the record invariants.
+func (r *Record) validate() error {
+	if r.Pos < 0 {
+		return fmt.Errorf("negative position %d", r.Pos)
+	}
+	if r.Seq != "*" && r.Qual != "*" && len(r.Seq) != len(r.Qual) {
+		return fmt.Errorf("%s: SEQ and QUAL lengths differ (%d vs %d)", r.QName, len(r.Seq), len(r.Qual))
+	}
+	return nil
+}
+
+// String formats the record as a SAM line (without newline).
+func (r *Record) String() string {
+	fields := []string{
+		r.QName, strconv.Itoa(r.Flag), r.RName, strconv.Itoa(r.Pos), strconv.Itoa(r.MapQ),
+		r.CIGAR, r.RNext, strconv.Itoa(r.PNext), strconv.Itoa(r.TLen), r.Seq, r.Qual,
+	}
+	return strings.Join(append(fields, r.Tags...), "\t")
+}
+
+// Read parses SAM text.
+func Read(r io.Reader) (*Header, []*Record, error) {
+	header := &Header{}
+	records := make([]*Record, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] == '@' {
+			header.addLine(line)
+			continue
+		}
+		rec, err := ParseRecord(line)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("reading SAM: %w", err)
+	}
+	return header, records, nil
+}
+
+// addLine records a header line, picking up @SQ and @RG entries.
+func (h *Header) addLine(line string) {
+	h.Lines = append(h.Lines, line)
+	h.addReadGroupLine(line)
+	if !strings.HasPrefix(line, "@SQ\t") {
+		return
+	}
+	var ref Reference
+	for _, field := range strings.Split(line, "\t")[1:] {
+		switch {
+		case strings.HasPrefix(field, "SN:"):
+			ref.Name = field[3:]
+		case strings.HasPrefix(field, "LN:"):
+			ref.Length, _ = strconv.Atoi(field[3:])
+		}
+	}
+	h.References = append(h.References, ref)
+}
+
+// addReadGroupLine records the read group of an @RG line. Malformed
+// lines are kept as text only.
+func (h *Header) addReadGroupLine(line string) {
+	if !strings.HasPrefix(line, "@RG\t") {
+		return
+	}
+	if g, err := readgroup.ParseSAMHeaderLine(line); err == nil {
+		h.ReadGroups = append(h.ReadGroups, g)
+	}
+}
+
+// Write writes a header and records as SAM text.
+//
+// Aria equivalent:
+//
+//	fn write(writer: Writer, header: Header, records: [Record]) -> Result<(), IOError>
+//	  ensures read(writer.output()) == Ok((header, records))
+func Write(w io.Writer, header *Header, records []*Record) error {
+	bw := bufio.NewWriter(w)
+	if header != nil {
+		for _, line := range header.Lines {
+			bw.WriteString(line)
+			bw.WriteByte('\n')
+		}
+	}
+	for _, rec := range records {
+		bw.WriteString(rec.String())
+		bw.WriteByte('\n')
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("writing SAM: %w", err)
+	}
+	return nil
+}
+
+// MissingMapQ is the mapping quality of records whose quality is unknown.
+const MissingMapQ = 255
+
+// FromAlignedRows builds the record of a query aligned to a reference from
+// the gapped rows of a pairwise alignment. refStart is the 0-based
+// reference position of the first column, and queryStart that of the
+// first query base within query, the whole query: bases outside the rows
+// are soft clipped. Matches and mismatches are written as = and X, and the
+// record carries the edit distance in an NM tag. Rows without aligned
+// bases give an unmapped record.
+//
+// Aria equivalent:
+//
+//	fn from_aligned_rows(qname: Name, rname: Name, ref_start: Int, ref_row: String,
+//	                     query_row: String, query: String, query_start: Int) -> Result<Record, Error>
+//	  requires ref_row.len() == query_row.len()
+//	  ensures result.seq == query
+func FromAlignedRows(qname, rname string, refStart int, refRow, queryRow, query string, queryStart int) (*Record, error) {
+	if len(refRow) != len(queryRow) {
+		return nil, fmt.Errorf("aligned rows differ in length (%d vs %d)", len(refRow), len(queryRow))
+	}
+	seq := query
+	if seq == "" {
+		seq = "*"
+	}
+	rec := &Record{QName: qname, RName: rname, MapQ: MissingMapQ, RNext: "*", Seq: seq, Qual: "*"}
+
+	var cigar strings.Builder
+	var op byte
+	count, aligned, edits := 0, 0, 0
+	flush := func() {
+		if count > 0 {
+			cigar.WriteString(strconv.Itoa(count))
+			cigar.WriteByte(op)
+		}
+	}
+	add := func(o byte, n int) {
+		if n == 0 {
+			return
+		}
+		if o != op {
+			flush()
+			op, count = o, 0
+		}
+		count += n
+	}
+
+	add('S', queryStart)
+	for i := 0; i < len(refRow); i++ {
+		r, q := refRow[i], queryRow[i]
+		switch {
+		case r == '-' && q == '-':
+			continue
+		case r == '-':
+			add('I', 1)
+			aligned++
+			edits++
+		case q == '-':
+			add('D', 1)
+			edits++
+		case upper(r) == upper(q):
+			add('=', 1)
+			aligned++
+		default:
+			add('X', 1)
+			aligned++
+			edits++
+		}
+	}
+	if queryStart+aligned > len(query) {
+		return nil, fmt.Errorf("%s: aligned bases run past the query (%d > %d)", qname, queryStart+aligned, len(query))
+	}
+	if aligned == 0 {
+		rec.Flag, rec.RName, rec.CIGAR = FlagUnmapped, "*", "*"
+		return rec, nil
+	}
+	add('S', len(query)-queryStart-aligned)
+	flush()
+
+	rec.Pos = refStart + 1
+	rec.CIGAR = cigar.String()
+	rec.Tags = []string{"NM:i:" + strconv.Itoa(edits)}
+	return rec, nil
+}
+
+// upper returns the upper case of an ASCII letter.
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
+
+// bamCigarOps maps BAM CIGAR operation codes to SAM characters.
+const bamCigarOps = "MIDNSHP=X"
+
+// bamBases maps 4-bit BAM base codes to IUPAC characters.
+const bamBases = "=ACMGRSVTWYHKDBN"
+
+// ReadBAM parses a BAM stream. Optional tags are not decoded.
+func ReadBAM(r io.Reader) (*Header, []*Record, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading BGZF: %w", err)
+	}
+	defer gz.Close()
+	br := bufio.NewReader(gz)
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != "BAM\x01" {
+		return nil, nil, fmt.Errorf("not a BAM file")
+	}
+
+	var lText int32
+	if err := binary.Read(br, binary.LittleEndian, &lText); err != nil {
+		return nil, nil, fmt.Errorf("reading BAM header: %w", err)
+	}
+	text := make([]byte, lText)
+	if _, err := io.ReadFull(br, text); err != nil {
+		return nil, nil, fmt.Errorf("reading BAM header: %w", err)
+	}
+	header := &Header{}
+	for _, line := range strings.Split(strings.TrimRight(string(bytes.TrimRight(text, "\x00")), "\n"), "\n") {
+		if line != "" {
+			header.Lines = append(header.Lines, line)
+			header.addReadGroupLine(line)
+		}
+	}
+
+	var nRef int32
+	if err := binary.Read(br, binary.LittleEndian, &nRef); err != nil {
+		return nil, nil, fmt.Errorf("reading BAM references: %w", err)
+	}
+	names := make([]string, nRef)
+	for i := range names {
+		var lName int32
+		if err := binary.Read(br, binary.LittleEndian, &lName); err != nil {
+			return nil, nil, fmt.Errorf("reading BAM references: %w", err)
+		}
+		name := make([]byte, lName)
+		if _, err := io.ReadFull(br, name); err != nil {
+			return nil, nil, fmt.Errorf("reading BAM references: %w", err)
+		}
+		var lRef int32
+		if err := binary.Read(br, binary.LittleEndian, &lRef); err != nil {
+			return nil, nil, fmt.Errorf("reading BAM references: %w", err)
+		}
+		names[i] = string(bytes.TrimRight(name, "\x00"))
+		header.References = append(header.References, Reference{Name: names[i], Length: int(lRef)})
+	}
+	refName := func(id int32) string {
+		if id < 0 || int(id) >= len(names) {
+			return "*"
+		}
+		return names[id]
+	}
+
+	records := make([]*Record, 0)
+	for {
+		var blockSize int32
+		if err := binary.Read(br, binary.LittleEndian, &blockSize); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, nil, fmt.Errorf("reading BAM record %d: %w", len(records)+1, err)
+		}
+		block := make([]byte, blockSize)
+		if _, err := io.ReadFull(br, block); err != nil {
+			return nil, nil, fmt.Errorf("reading BAM record %d: %w", len(records)+1, err)
+		}
+		rec, err := decodeBAMRecord(block, refName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("BAM record %d: %w", len(records)+1, err)
+		}
+		records = append(records, rec)
+	}
+	return header, records, nil
+}
+
+// decodeBAMRecord decodes one BAM alignment block (without block_size).
+func decodeBAMRecord(b []byte, refName func(int32) string) (*Record, error) {
+	if len(b) < 32 {
+		return nil, fmt.Errorf("truncated record")
+	}
+	le := binary.LittleEndian
+	refID := int32(le.Uint32(b[0:]))
+	pos := int32(le.Uint32(b[4:]))
+	lReadName := int(b[8])
+	mapQ := int(b[9])
+	nCigar := int(le.Uint16(b[12:]))
+	flag := int(le.Uint16(b[14:]))
+	lSeq := int(int32(le.Uint32(b[16:])))
+	nextRefID := int32(le.Uint32(b[20:]))
+	nextPos := int32(le.Uint32(b[24:]))
+	tlen := int32(le.Uint32(b[28:]))
+
+	off := 32
+	need := off + lReadName + 4*nCigar + (lSeq+1)/2 + lSeq
+	if lSeq < 0 || len(b) < need {
+		return nil, fmt.Errorf("truncated record")
+	}
+	name := string(bytes.TrimRight(b[off:off+lReadName], "\x00"))
+	off += lReadName
+
+	var cigar strings.Builder
+	for i := 0; i < nCigar; i++ {
+		v := le.Uint32(b[off:])
+		op := v & 0xf
+		if int(op) >= len(bamCigarOps) {
+			return nil, fmt.Errorf("invalid CIGAR operation %d", op)
+		}
+		cigar.WriteString(strconv.Itoa(int(v >> 4)))
+		cigar.WriteByte(bamCigarOps[op])
+		off += 4
+	}
+
+	seq := make([]byte, lSeq)
+	for i := 0; i < lSeq; i++ {
+		packed := b[off+i/2]
+		if i%2 == 0 {
+			seq[i] = bamBases[packed>>4]
+		} else {
+			seq[i] = bamBases[packed&0xf]
+		}
+	}
+	off += (lSeq + 1) / 2
+
+	qual := "*"
+	if lSeq > 0 && b[off] != 0xff {
+		q := make([]byte, lSeq)
+		for i := 0; i < lSeq; i++ {
+			q[i] = b[off+i] + 33
+		}
+		qual = string(q)
+	}
+
+	rec := &Record{
+		QName: name,
+		Flag:  flag,
+		RName: refName(refID),
+		Pos:   int(pos) + 1,
+		MapQ:  mapQ,
+		CIGAR: cigar.String(),
+		RNext: refName(nextRefID),
+		PNext: int(nextPos) + 1,
+		TLen:  int(tlen),
+		Seq:   string(seq),
+		Qual:  qual,
+	}
+	if rec.CIGAR == "" {
+		rec.CIGAR = "*"
+	}
+	if lSeq == 0 {
+		rec.Seq = "*"
+	}
+	if nextRefID >= 0 && nextRefID == refID {
+		rec.RNext = "="
+	}
+	return rec, nil
+}
+
+// ReadFile reads a SAM or BAM file, detecting BAM by its gzip magic; "-"
+// reads standard input.
+func ReadFile(filename string) (*Header, []*Record, error) {
+	var r io.Reader = os.Stdin
+	if filename != "-" {
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening file: %w", err)
+		}
+		defer file.Close()
+		r = file
+	}
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(2)
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return ReadBAM(br)
+	}
+	return Read(br)
+}
@@ -0,0 +1,196 @@
+package sam
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/readgroup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSAM = `@HD	VN:1.6	SO:coordinate
+@SQ	SN:chr1	LN:100
+r1	0	chr1	5	60	4M1I3M	*	0	0	ACGTTACG	IIIIIIII	NM:i:1
+r2	16	chr1	10	30	2S6M	=	20	15	GGACGTAC	*
+r3	4	*	0	0	*	*	0	0	ACGT	IIII
+`
+
+func TestReadSAM(t *testing.T) {
+	header, records, err := Read(strings.NewReader(testSAM))
+	require.NoError(t, err)
+	assert.Len(t, header.Lines, 2)
+	assert.Equal(t, []Reference{{Name: "chr1", Length: 100}}, header.References)
+	require.Len(t, records, 3)
+
+	r := records[0]
+	assert.Equal(t, "r1", r.QName)
+	assert.Equal(t, 5, r.Pos)
+	assert.Equal(t, "4M1I3M", r.CIGAR)
+	assert.Equal(t, []string{"NM:i:1"}, r.Tags)
+	assert.False(t, r.IsReverse())
+	assert.True(t, records[1].IsReverse())
+	assert.True(t, records[2].IsUnmapped())
+	assert.Equal(t, strings.Split(testSAM, "\n")[2], r.String())
+
+	_, _, err = Read(strings.NewReader("r1\t0\tchr1\tx\t60\t4M\t*\t0\t0\tACGT\tIIII\n"))
+	assert.Error(t, err)
+	_, _, err = Read(strings.NewReader("r1\t0\tchr1\t1\t60\t4M\t*\t0\t0\tACGT\tIII\n"))
+	assert.Error(t, err)
+}
+
+func TestReadGroups(t *testing.T) {
+	header, records, err := Read(strings.NewReader("@RG\tID:old\tSM:x\n" + testSAM))
+	require.NoError(t, err)
+	assert.Equal(t, []readgroup.ReadGroup{{ID: "old", Sample: "x"}}, header.ReadGroups)
+
+	header.AddReadGroup(readgroup.ReadGroup{ID: "old", Sample: "y"})
+	header.AddReadGroup(readgroup.ReadGroup{Sample: "z"})
+	require.Len(t, header.ReadGroups, 2)
+	assert.Equal(t, "@RG\tID:old\tSM:y", header.Lines[0])
+	assert.Equal(t, "@RG\tID:z\tSM:z", header.Lines[3])
+
+	r := records[0]
+	r.SetTag("RG", 'Z', "old")
+	r.SetTag("NM", 'i', "2")
+	assert.Equal(t, []string{"NM:i:2", "RG:Z:old"}, r.Tags)
+	v, ok := r.Tag("RG")
+	assert.True(t, ok)
+	assert.Equal(t, "old", v)
+	_, ok = records[1].Tag("RG")
+	assert.False(t, ok)
+
+	var out bytes.Buffer
+	require.NoError(t, Write(&out, header, records))
+	again, reread, err := Read(&out)
+	require.NoError(t, err)
+	assert.Equal(t, header.Lines, again.Lines)
+	assert.Equal(t, header.ReadGroups, again.ReadGroups)
+	assert.Equal(t, records, reread)
+}
+
+// encodeBAM writes records as a minimal single-member BAM for tests.
+func encodeBAM(t *testing.T, refs []Reference, records []*Record) []byte {
+	var raw bytes.Buffer
+	le := binary.LittleEndian
+	write := func(v interface{}) { require.NoError(t, binary.Write(&raw, le, v)) }
+
+	raw.WriteString("BAM\x01")
+	text := "@HD\tVN:1.6\n"
+	write(int32(len(text)))
+	raw.WriteString(text)
+	write(int32(len(refs)))
+	refIDs := map[string]int32{"*": -1}
+	for i, ref := range refs {
+		write(int32(len(ref.Name) + 1))
+		raw.WriteString(ref.Name + "\x00")
+		write(int32(ref.Length))
+		refIDs[ref.Name] = int32(i)
+	}
+	for _, r := range records {
+		var rec bytes.Buffer
+		w := func(v interface{}) { require.NoError(t, binary.Write(&rec, le, v)) }
+		ops := make([]uint32, 0)
+		n := 0
+		for _, c := range r.CIGAR {
+			if c >= '0' && c <= '9' {
+				n = n*10 + int(c-'0')
+				continue
+			}
+			ops = append(ops, uint32(n)<<4|uint32(strings.IndexRune(bamCigarOps, c)))
+			n = 0
+		}
+		w(refIDs[r.RName])
+		w(int32(r.Pos - 1))
+		w(uint8(len(r.QName) + 1))
+		w(uint8(r.MapQ))
+		w(uint16(0))
+		w(uint16(len(ops)))
+		w(uint16(r.Flag))
+		w(int32(len(r.Seq)))
+		w(int32(-1))
+		w(int32(-1))
+		w(int32(r.TLen))
+		rec.WriteString(r.QName + "\x00")
+		w(ops)
+		packed := make([]byte, (len(r.Seq)+1)/2)
+		for i := 0; i < len(r.Seq); i++ {
+			code := byte(strings.IndexByte(bamBases, r.Seq[i]))
+			if i%2 == 0 {
+				packed[i/2] = code << 4
+			} else {
+				packed[i/2] |= code
+			}
+		}
+		rec.Write(packed)
+		for i := 0; i < len(r.Qual); i++ {
+			rec.WriteByte(r.Qual[i] - 33)
+		}
+		write(int32(rec.Len()))
+		raw.Write(rec.Bytes())
+	}
+
+	var out bytes.Buffer
+	gz := gzip.NewWriter(&out)
+	_, err := gz.Write(raw.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return out.Bytes()
+}
+
+func TestReadBAM(t *testing.T) {
+	want := []*Record{
+		{QName: "r1", Flag: 0, RName: "chr1", Pos: 5, MapQ: 60, CIGAR: "4M1I3M", RNext: "*", PNext: 0, Seq: "ACGTTACG", Qual: "IIIII#II"},
+		{QName: "r2", Flag: 16, RName: "chr1", Pos: 10, MapQ: 30, CIGAR: "2S5M", RNext: "*", PNext: 0, Seq: "GGACGTA", Qual: "5555555"},
+	}
+	data := encodeBAM(t, []Reference{{Name: "chr1", Length: 100}}, want)
+
+	header, records, err := ReadBAM(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, []Reference{{Name: "chr1", Length: 100}}, header.References)
+	assert.Equal(t, []string{"@HD\tVN:1.6"}, header.Lines)
+	assert.Equal(t, want, records)
+
+	dir := t.TempDir()
+	bamPath := filepath.Join(dir, "reads.bam")
+	require.NoError(t, os.WriteFile(bamPath, data, 0o644))
+	_, records, err = ReadFile(bamPath)
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	samPath := filepath.Join(dir, "reads.sam")
+	require.NoError(t, os.WriteFile(samPath, []byte(testSAM), 0o644))
+	_, records, err = ReadFile(samPath)
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	_, _, err = ReadBAM(strings.NewReader("not gzip"))
+	assert.Error(t, err)
+}
+
+func TestFromAlignedRows(t *testing.T) {
+	// Query GGACGTTACGCC aligns from its third base to chr1:5.
+	rec, err := FromAlignedRows("q", "chr1", 4, "ACG-TAcAC", "ACGTTA-Ag", "GGACGTTAAGCC", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 5, rec.Pos)
+	assert.Equal(t, "2S3=1I2=1D1=1X2S", rec.CIGAR)
+	assert.Equal(t, MissingMapQ, rec.MapQ)
+	nm, _ := rec.Tag("NM")
+	assert.Equal(t, "3", nm)
+	assert.Equal(t, "q\t0\tchr1\t5\t255\t2S3=1I2=1D1=1X2S\t*\t0\t0\tGGACGTTAAGCC\t*\tNM:i:3", rec.String())
+
+	rec, err = FromAlignedRows("q", "chr1", 0, "", "", "ACGT", 0)
+	require.NoError(t, err)
+	assert.True(t, rec.IsUnmapped())
+	assert.Equal(t, "*", rec.CIGAR)
+
+	_, err = FromAlignedRows("q", "chr1", 0, "ACGT", "ACG", "ACGT", 0)
+	assert.Error(t, err)
+	_, err = FromAlignedRows("q", "chr1", 0, "ACGT", "ACGT", "ACG", 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+package sequence
+
+// NucleotideThreshold is the fraction of A, C, G, T, U and N residues
+// above which DetectType calls input nucleotide rather than protein.
+const NucleotideThreshold = 0.9
+
+// proteinLetters are the one-letter amino-acid codes, including the
+// ambiguity codes B, Z, J and X and the rare residues U and O.
+const proteinLetters = "ACDEFGHIKLMNPQRSTVWYBZJXUO"
+
+// DetectType classifies raw input by its alphabet composition. Case,
+// whitespace, alignment gaps and stop symbols ('*') are ignored.
+//
+// Input is nucleotide when at least NucleotideThreshold of its letters
+// are A, C, G, T, U or N: RNA if it contains U but no T, DNA if it
+// contains T but no U or neither, and Ambiguous if it contains both.
+// Otherwise it is Protein when it contains a letter that is an amino acid
+// but not an IUPAC nucleotide code (E, F, I, L, P, Q, J, O, X or Z), and
+// Ambiguous when every letter is valid in both alphabets. Empty input and
+// input with characters from neither alphabet are Unknown.
+//
+// Aria equivalent:
+//
+//	fn detect_type(bases: String) -> SequenceType
+//	  ensures bases.trim().is_empty() implies result == Unknown
+//	  ensures result == RNA implies bases.contains('U') and not bases.contains('T')
+func DetectType(bases string) SequenceType {
+	var counts [256]int
+	total := 0
+	for i := 0; i < len(bases); i++ {
+		c := bases[i]
+		switch c {
+		case ' ', '\t', '\n', '\r', '-', '.', '*':
+			continue
+		}
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		counts[c]++
+		total++
+	}
+	if total == 0 {
+		return Unknown
+	}
+
+	nucleotide, proteinOnly := 0, false
+	for c, n := range counts {
+		if n == 0 {
+			continue
+		}
+		b := byte(c)
+		switch {
+		case b == 'A' || b == 'C' || b == 'G' || b == 'T' || b == 'U' || b == 'N':
+			nucleotide += n
+		case IsIUPACBase(b):
+		case isProteinLetter(b):
+			proteinOnly = true
+		default:
+			return Unknown
+		}
+	}
+
+	if float64(nucleotide) >= NucleotideThreshold*float64(total) {
+		hasT, hasU := counts['T'] > 0, counts['U'] > 0
+		switch {
+		case hasT && hasU:
+			return Ambiguous
+		case hasU:
+			return RNA
+		default:
+			return DNA
+		}
+	}
+	if proteinOnly {
+		return Protein
+	}
+	return Ambiguous
+}
+
+// isProteinLetter reports whether an upper-case letter is a one-letter
+// amino-acid code.
+func isProteinLetter(c byte) bool {
+	for i := 0; i < len(proteinLetters); i++ {
+		if proteinLetters[i] == c {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package sequence
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GapChar is the canonical gap character of aligned rows. '.' is also
+// accepted as a gap on input.
+const GapChar = '-'
+
+// IsGap reports whether c is an alignment gap ('-' or '.').
+func IsGap(c byte) bool {
+	return c == '-' || c == '.'
+}
+
+// Degap removes all gap characters from an aligned row.
+//
+// Aria equivalent:
+//
+//	fn degap(row: String) -> String
+//	  ensures result.chars().all(|c| not is_gap(c))
+func Degap(row string) string {
+	var sb strings.Builder
+	sb.Grow(len(row))
+	for i := 0; i < len(row); i++ {
+		if !IsGap(row[i]) {
+			sb.WriteByte(row[i])
+		}
+	}
+	return sb.String()
+}
+
+// GapRun is a run of gap columns, 0-based and half-open.
+type GapRun struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Len returns the run length.
+func (g GapRun) Len() int {
+	return g.End - g.Start
+}
+
+// GapRuns returns the maximal runs of gap columns in an aligned row.
+//
+// Aria equivalent:
+//
+//	fn gap_runs(row: String) -> [GapRun]
+//	  ensures result.all(|g| row[g.start..g.end].chars().all(is_gap))
+func GapRuns(row string) []GapRun {
+	runs := make([]GapRun, 0)
+	start := -1
+	for i := 0; i <= len(row); i++ {
+		gap := i < len(row) && IsGap(row[i])
+		switch {
+		case gap && start < 0:
+			start = i
+		case !gap && start >= 0:
+			runs = append(runs, GapRun{Start: start, End: i})
+			start = -1
+		}
+	}
+	return runs
+}
+
+// GapPositions returns the columns of an aligned row that hold gaps.
+func GapPositions(row string) []int {
+	positions := make([]int, 0)
+	for i := 0; i < len(row); i++ {
+		if IsGap(row[i]) {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+// PadRow extends an aligned row with trailing gaps to width columns.
+// Rows that are already at least width long are returned unchanged.
+func PadRow(row string, width int) string {
+	if len(row) >= width {
+		return row
+	}
+	return row + strings.Repeat(string(GapChar), width-len(row))
+}
+
+// PadRows pads every row with trailing gaps to the length of the longest.
+//
+// Aria equivalent:
+//
+//	fn pad_rows(rows: [String]) -> [String]
+//	  ensures result.all(|r| r.len() == rows.map(|r| r.len()).max())
+func PadRows(rows []string) []string {
+	width := 0
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	padded := make([]string, len(rows))
+	for i, row := range rows {
+		padded[i] = PadRow(row, width)
+	}
+	return padded
+}
+
+// ColumnMap maps between alignment columns of a gapped row and 0-based
+// positions in the ungapped sequence.
+//
+// Aria equivalent:
+//
+//	struct ColumnMap
+//	  columns: [Int]    # column of each residue
+//	  positions: [Int]  # residues before each column
+//	  invariant self.columns.is_sorted()
+type ColumnMap struct {
+	columns []int
+	// before[c] is the number of residues in columns [0, c).
+	before []int
+	gaps   []bool
+}
+
+// NewColumnMap indexes an aligned row.
+func NewColumnMap(row string) *ColumnMap {
+	m := &ColumnMap{
+		columns: make([]int, 0, len(row)),
+		before:  make([]int, len(row)+1),
+		gaps:    make([]bool, len(row)),
+	}
+	for c := 0; c < len(row); c++ {
+		m.before[c] = len(m.columns)
+		if IsGap(row[c]) {
+			m.gaps[c] = true
+			continue
+		}
+		m.columns = append(m.columns, c)
+	}
+	m.before[len(row)] = len(m.columns)
+	return m
+}
+
+// Width returns the number of columns of the row.
+func (m *ColumnMap) Width() int {
+	return len(m.gaps)
+}
+
+// Len returns the ungapped sequence length.
+func (m *ColumnMap) Len() int {
+	return len(m.columns)
+}
+
+// ColumnToPosition returns the sequence position of the residue in column
+// col. For a gap column it returns the position of the next residue (the
+// insertion point) and false.
+//
+// Aria equivalent:
+//
+//	fn column_to_position(self, col: Int) -> Result<(Int, Bool), SequenceError>
+//	  requires col >= 0 and col < self.width()
+func (m *ColumnMap) ColumnToPosition(col int) (int, bool, error) {
+	if col < 0 || col >= m.Width() {
+		return 0, false, fmt.Errorf("column %d out of range [0, %d)", col, m.Width())
+	}
+	return m.before[col], !m.gaps[col], nil
+}
+
+// PositionToColumn returns the alignment column of the residue at the
+// 0-based sequence position pos.
+//
+// Aria equivalent:
+//
+//	fn position_to_column(self, pos: Int) -> Result<Int, SequenceError>
+//	  requires pos >= 0 and pos < self.len()
+func (m *ColumnMap) PositionToColumn(pos int) (int, error) {
+	if pos < 0 || pos >= m.Len() {
+		return 0, fmt.Errorf("position %d out of range [0, %d)", pos, m.Len())
+	}
+	return m.columns[pos], nil
+}
+
+// SpanToColumns converts the half-open sequence interval [start, end) to
+// the half-open column interval from its first to its last residue.
+func (m *ColumnMap) SpanToColumns(start, end int) (int, int, error) {
+	if start < 0 || end > m.Len() || start >= end {
+		return 0, 0, fmt.Errorf("invalid span [%d, %d) for length %d", start, end, m.Len())
+	}
+	return m.columns[start], m.columns[end-1] + 1, nil
+}
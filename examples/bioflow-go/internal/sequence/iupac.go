@@ -0,0 +1,79 @@
+package sequence
+
+import "strings"
+
+// iupacMasks maps each IUPAC nucleotide code to a bitmask of A=1, C=2, G=4, T=8.
+var iupacMasks = map[byte]uint8{
+	'A': 1, 'C': 2, 'G': 4, 'T': 8, 'U': 8,
+	'R': 1 | 4, // A or G (purine)
+	'Y': 2 | 8, // C or T (pyrimidine)
+	'S': 2 | 4, // G or C (strong)
+	'W': 1 | 8, // A or T (weak)
+	'K': 4 | 8, // G or T (keto)
+	'M': 1 | 2, // A or C (amino)
+	'B': 2 | 4 | 8,
+	'D': 1 | 4 | 8,
+	'H': 1 | 2 | 8,
+	'V': 1 | 2 | 4,
+	'N': 1 | 2 | 4 | 8,
+}
+
+// iupacCodes is the inverse of iupacMasks (using T rather than U).
+var iupacCodes = [16]byte{
+	0: 0, 1: 'A', 2: 'C', 3: 'M', 4: 'G', 5: 'R', 6: 'S', 7: 'V',
+	8: 'T', 9: 'W', 10: 'Y', 11: 'H', 12: 'K', 13: 'D', 14: 'B', 15: 'N',
+}
+
+// IsIUPACBase checks if a character is a valid IUPAC nucleotide code.
+func IsIUPACBase(c byte) bool {
+	_, ok := iupacMasks[c]
+	return ok
+}
+
+// IUPACBases returns the concrete bases represented by an IUPAC code,
+// e.g. "AG" for 'R'. It returns an empty string for unknown codes.
+func IUPACBases(code byte) string {
+	mask, ok := iupacMasks[code]
+	if !ok {
+		return ""
+	}
+	var sb strings.Builder
+	for i, b := range "ACGT" {
+		if mask&(1<<uint(i)) != 0 {
+			sb.WriteRune(b)
+		}
+	}
+	return sb.String()
+}
+
+// IUPACCode returns the IUPAC code representing a set of bases, e.g. 'Y'
+// for "CT". Unknown characters are ignored; an empty set yields 0.
+func IUPACCode(bases string) byte {
+	var mask uint8
+	for i := 0; i < len(bases); i++ {
+		mask |= iupacMasks[bases[i]]
+	}
+	return iupacCodes[mask]
+}
+
+// IUPACCompatible reports whether two IUPAC codes share at least one base.
+func IUPACCompatible(a, b byte) bool {
+	return iupacMasks[a]&iupacMasks[b] != 0
+}
+
+// IUPACReverseComplement returns the reverse complement of a string of
+// IUPAC codes, e.g. "YGN" for "NCR". Unknown characters become 'N'.
+func IUPACReverseComplement(s string) string {
+	out := make([]byte, len(s))
+	for i := 0; i < len(s); i++ {
+		mask, ok := iupacMasks[s[i]]
+		if !ok {
+			out[len(s)-1-i] = 'N'
+			continue
+		}
+		// Complementing swaps A<->T (bits 0 and 3) and C<->G (bits 1 and 2).
+		comp := (mask&1)<<3 | (mask&8)>>3 | (mask&2)<<1 | (mask&4)>>1
+		out[len(s)-1-i] = iupacCodes[comp]
+	}
+	return string(out)
+}
@@ -0,0 +1,240 @@
+package sequence
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PolicyMode selects which characters a Policy accepts.
+type PolicyMode int
+
+const (
+	// Strict accepts only A, C, G, T (or U) and N, as New does.
+	Strict PolicyMode = iota
+	// IUPAC also accepts the IUPAC ambiguity codes R, Y, S, W, K, M, B,
+	// D, H and V.
+	IUPAC
+	// Permissive replaces any character that is not an IUPAC code with
+	// the policy's replacement base instead of failing.
+	Permissive
+)
+
+func (m PolicyMode) String() string {
+	switch m {
+	case Strict:
+		return "strict"
+	case IUPAC:
+		return "iupac"
+	case Permissive:
+		return "permissive"
+	default:
+		return "unknown"
+	}
+}
+
+// Policy controls how raw bases are normalized and validated when a
+// sequence is constructed, so that dirty files can be ingested
+// deliberately rather than rejected or silently altered.
+//
+// Bases are always stored in upper case, since every analysis in this
+// module compares upper-case bases. With PreserveCase, lower-case
+// (soft-masked) runs are recorded in Sequence.SoftMask instead and can
+// be restored with Sequence.Masked.
+//
+// Aria equivalent:
+//
+//	struct Policy
+//	  mode: PolicyMode
+//	  replacement: Char
+//	  preserve_case: Bool
+//	  strip_whitespace: Bool
+//	  detect_rna: Bool
+//	  invariant is_iupac_base(self.replacement)
+type Policy struct {
+	Mode PolicyMode
+	// Replacement substitutes invalid characters in Permissive mode.
+	// Zero means 'N'.
+	Replacement byte
+	// PreserveCase records lower-case runs as a soft mask.
+	PreserveCase bool
+	// StripWhitespace removes spaces, tabs and line breaks inside the
+	// bases, as found in GenBank-style or hand-edited records.
+	StripWhitespace bool
+	// DetectRNA treats bases that DetectType classifies as RNA (U but
+	// no T) as RNA, whatever type was requested.
+	DetectRNA bool
+}
+
+// StrictPolicy returns the policy applied by the parsers: ACGTN only (ACGUN
+// for input detected as RNA), upper-cased, no whitespace.
+func StrictPolicy() Policy {
+	return Policy{Mode: Strict, DetectRNA: true}
+}
+
+// IUPACPolicy accepts IUPAC ambiguity codes, strips whitespace and detects
+// RNA.
+func IUPACPolicy() Policy {
+	return Policy{Mode: IUPAC, StripWhitespace: true, DetectRNA: true}
+}
+
+// PermissivePolicy replaces invalid characters with N, strips whitespace,
+// detects RNA and keeps soft-masking.
+func PermissivePolicy() Policy {
+	return Policy{Mode: Permissive, Replacement: 'N', PreserveCase: true, StripWhitespace: true, DetectRNA: true}
+}
+
+// ParsePolicy returns the named preset: "strict", "iupac" or "permissive".
+func ParsePolicy(name string) (Policy, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "strict":
+		return StrictPolicy(), nil
+	case "iupac":
+		return IUPACPolicy(), nil
+	case "permissive":
+		return PermissivePolicy(), nil
+	default:
+		return Policy{}, fmt.Errorf("unknown validation policy %q (use strict, iupac or permissive)", name)
+	}
+}
+
+// MaskRun is a run of soft-masked (originally lower-case) bases, 0-based
+// and half-open.
+type MaskRun struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Normalized is the result of applying a policy to raw bases.
+type Normalized struct {
+	Bases    string
+	SeqType  SequenceType
+	Replaced int
+	SoftMask []MaskRun
+}
+
+// Normalize applies the policy to raw bases. seqType is the requested
+// type; with DetectRNA it may become RNA. Replaced counts the characters
+// substituted in Permissive mode.
+//
+// Aria equivalent:
+//
+//	fn normalize(self, bases: String, seq_type: SequenceType) -> Result<Normalized, SequenceError>
+//	  ensures result.is_ok() implies result.unwrap().bases.len() > 0
+//	  ensures self.mode != Permissive implies result.is_ok() implies result.unwrap().replaced == 0
+func (p Policy) Normalize(bases string, seqType SequenceType) (*Normalized, error) {
+	if p.Mode < Strict || p.Mode > Permissive {
+		return nil, fmt.Errorf("unknown policy mode %d", p.Mode)
+	}
+	replacement := p.Replacement
+	if replacement == 0 {
+		replacement = 'N'
+	}
+	if !IsIUPACBase(replacement) {
+		return nil, fmt.Errorf("replacement %q is not an IUPAC base", replacement)
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(bases))
+	var mask []MaskRun
+	for i := 0; i < len(bases); i++ {
+		c := bases[i]
+		if p.StripWhitespace && (c == ' ' || c == '\t' || c == '\n' || c == '\r') {
+			continue
+		}
+		if p.PreserveCase && c >= 'a' && c <= 'z' {
+			pos := sb.Len()
+			if n := len(mask); n > 0 && mask[n-1].End == pos {
+				mask[n-1].End++
+			} else {
+				mask = append(mask, MaskRun{Start: pos, End: pos + 1})
+			}
+		}
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		sb.WriteByte(c)
+	}
+	if sb.Len() == 0 {
+		return nil, &EmptySequenceError{}
+	}
+
+	out := []byte(sb.String())
+	detected := DetectType(sb.String())
+	if p.DetectRNA && detected == RNA {
+		seqType = RNA
+	}
+	if seqType != RNA {
+		seqType = DNA
+	}
+	if detected == Protein && p.Mode != Permissive {
+		return nil, &TypeMismatchError{Expected: seqType, Detected: Protein}
+	}
+
+	replaced := 0
+	for i, c := range out {
+		if p.accepts(c, seqType) {
+			continue
+		}
+		if p.Mode != Permissive {
+			return nil, &InvalidBaseError{Position: i, Found: rune(c)}
+		}
+		out[i] = replacement
+		replaced++
+	}
+	return &Normalized{Bases: string(out), SeqType: seqType, Replaced: replaced, SoftMask: mask}, nil
+}
+
+// accepts reports whether an upper-case character is valid under the
+// policy for the given type.
+func (p Policy) accepts(c byte, seqType SequenceType) bool {
+	if p.Mode == Strict {
+		if seqType == RNA {
+			return ValidRNABases[rune(c)]
+		}
+		return ValidDNABases[rune(c)]
+	}
+	switch c {
+	case 'T':
+		return seqType == DNA
+	case 'U':
+		return seqType == RNA
+	}
+	return IsIUPACBase(c)
+}
+
+// NewWithPolicy creates a sequence from raw bases under a policy.
+//
+// Aria equivalent:
+//
+//	fn new_with_policy(bases: String, id: String, description: String, policy: Policy) -> Result<Sequence, SequenceError>
+//	  ensures result.is_ok() implies result.unwrap().bases.chars().all(|c| c.is_uppercase())
+func NewWithPolicy(bases, id, description string, policy Policy) (*Sequence, error) {
+	n, err := policy.Normalize(bases, DNA)
+	if err != nil {
+		return nil, err
+	}
+	return &Sequence{
+		Bases:       n.Bases,
+		ID:          id,
+		Description: description,
+		SeqType:     n.SeqType,
+		SoftMask:    n.SoftMask,
+	}, nil
+}
+
+// Masked returns the bases with soft-masked runs in lower case, as they
+// were read.
+func (s *Sequence) Masked() string {
+	if len(s.SoftMask) == 0 {
+		return s.Bases
+	}
+	b := []byte(s.Bases)
+	for _, r := range s.SoftMask {
+		for i := r.Start; i < r.End && i < len(b); i++ {
+			if b[i] >= 'A' && b[i] <= 'Z' {
+				b[i] += 'a' - 'A'
+			}
+		}
+	}
+	return string(b)
+}
@@ -20,6 +20,11 @@ const (
 	RNA
 	// Unknown represents an unknown sequence type
 	Unknown
+	// Protein represents an amino-acid sequence, as reported by DetectType
+	Protein
+	// Ambiguous represents input that DetectType cannot tell apart, such
+	// as a short run of IUPAC codes that is also valid protein
+	Ambiguous
 )
 
 func (t SequenceType) String() string {
@@ -28,6 +33,10 @@ func (t SequenceType) String() string {
 		return "DNA"
 	case RNA:
 		return "RNA"
+	case Protein:
+		return "Protein"
+	case Ambiguous:
+		return "Ambiguous"
 	default:
 		return "Unknown"
 	}
@@ -53,6 +62,9 @@ type Sequence struct {
 	ID          string
 	Description string
 	SeqType     SequenceType
+	// SoftMask lists lower-case runs recorded by a Policy with
+	// PreserveCase. Operations that build new sequences drop it.
+	SoftMask []MaskRun
 }
 
 // New creates a new DNA sequence with validation.
@@ -1,8 +1,12 @@
 package sequence
 
 import (
+	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/aria-lang/bioflow-go/internal/random"
+	"github.com/aria-lang/bioflow-go/internal/readgroup"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -339,6 +343,226 @@ func TestEqual(t *testing.T) {
 	assert.False(t, seq1.Equal(nil))
 }
 
+func TestIUPAC(t *testing.T) {
+	assert.Equal(t, byte('R'), IUPACCode("AG"))
+	assert.Equal(t, byte('Y'), IUPACCode("TC"))
+	assert.Equal(t, byte('N'), IUPACCode("ACGT"))
+	assert.Equal(t, byte('A'), IUPACCode("A"))
+	assert.Equal(t, "AG", IUPACBases('R'))
+	assert.Equal(t, "", IUPACBases('X'))
+	assert.True(t, IUPACCompatible('R', 'A'))
+	assert.False(t, IUPACCompatible('R', 'C'))
+	assert.True(t, IsIUPACBase('K'))
+	assert.False(t, IsIUPACBase('Z'))
+	assert.Equal(t, "GAATTC", IUPACReverseComplement("GAATTC"))
+	assert.Equal(t, "YGN", IUPACReverseComplement("NCR"))
+	assert.Equal(t, "NA", IUPACReverseComplement("TZ"))
+}
+
+func TestGappedRows(t *testing.T) {
+	row := "--AC-G..T-"
+	assert.True(t, IsGap('-'))
+	assert.True(t, IsGap('.'))
+	assert.False(t, IsGap('N'))
+	assert.Equal(t, "ACGT", Degap(row))
+	assert.Equal(t, []int{0, 1, 4, 6, 7, 9}, GapPositions(row))
+	assert.Equal(t, []GapRun{{0, 2}, {4, 5}, {6, 8}, {9, 10}}, GapRuns(row))
+	assert.Equal(t, "AC--", PadRow("AC", 4))
+	assert.Equal(t, []string{"A--", "ACG", "AC-"}, PadRows([]string{"A", "ACG", "AC"}))
+
+	m := NewColumnMap(row)
+	assert.Equal(t, 10, m.Width())
+	assert.Equal(t, 4, m.Len())
+
+	pos, residue, err := m.ColumnToPosition(3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pos)
+	assert.True(t, residue)
+
+	// A gap column maps to the next residue's position.
+	pos, residue, err = m.ColumnToPosition(6)
+	require.NoError(t, err)
+	assert.Equal(t, 3, pos)
+	assert.False(t, residue)
+
+	col, err := m.PositionToColumn(3)
+	require.NoError(t, err)
+	assert.Equal(t, 8, col)
+
+	start, end, err := m.SpanToColumns(1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, start)
+	assert.Equal(t, 6, end)
+
+	_, _, err = m.ColumnToPosition(10)
+	require.Error(t, err)
+	_, err = m.PositionToColumn(4)
+	require.Error(t, err)
+}
+
+func TestPolicy(t *testing.T) {
+	// The strict policy matches New.
+	seq, err := NewWithPolicy("acgtn", "s1", "", StrictPolicy())
+	require.NoError(t, err)
+	assert.Equal(t, "ACGTN", seq.Bases)
+	assert.Empty(t, seq.SoftMask)
+	_, err = NewWithPolicy("ACRT", "s1", "", StrictPolicy())
+	var invalid *InvalidBaseError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, 2, invalid.Position)
+	_, err = NewWithPolicy("AC GT", "s1", "", StrictPolicy())
+	assert.Error(t, err)
+
+	seq, err = NewWithPolicy("AC RY\tGT", "s1", "", IUPACPolicy())
+	require.NoError(t, err)
+	assert.Equal(t, "ACRYGT", seq.Bases)
+	assert.Equal(t, DNA, seq.SeqType)
+	_, err = NewWithPolicy("ACX", "s1", "", IUPACPolicy())
+	assert.Error(t, err)
+
+	// U without T is detected as RNA; mixed T and U is rejected.
+	seq, err = NewWithPolicy("acgu", "r1", "", IUPACPolicy())
+	require.NoError(t, err)
+	assert.Equal(t, RNA, seq.SeqType)
+	assert.True(t, seq.IsValid())
+	_, err = NewWithPolicy("ACGTU", "r1", "", IUPACPolicy())
+	assert.Error(t, err)
+
+	n, err := PermissivePolicy().Normalize("ACgt*-XAc", DNA)
+	require.NoError(t, err)
+	assert.Equal(t, "ACGTNNNAC", n.Bases)
+	assert.Equal(t, 3, n.Replaced)
+	assert.Equal(t, []MaskRun{{Start: 2, End: 4}, {Start: 8, End: 9}}, n.SoftMask)
+
+	seq, err = NewWithPolicy("ACgt\nnnAC", "s1", "d", PermissivePolicy())
+	require.NoError(t, err)
+	assert.Equal(t, "ACGTNNAC", seq.Bases)
+	assert.Equal(t, "ACgtnnAC", seq.Masked())
+	assert.InDelta(t, 0.375, seq.GCContent(), 1e-9)
+
+	p := PermissivePolicy()
+	p.Replacement = 'X'
+	_, err = p.Normalize("ACGT", DNA)
+	assert.Error(t, err)
+	_, err = NewWithPolicy(" \t", "s1", "", PermissivePolicy())
+	assert.IsType(t, &EmptySequenceError{}, err)
+
+	for _, name := range []string{"strict", "IUPAC", "permissive"} {
+		p, err := ParsePolicy(name)
+		require.NoError(t, err)
+		assert.Equal(t, strings.ToLower(name), p.Mode.String())
+	}
+	_, err = ParsePolicy("lenient")
+	assert.Error(t, err)
+}
+
+func TestDetectType(t *testing.T) {
+	tests := []struct {
+		bases string
+		want  SequenceType
+	}{
+		{"ACGTACGTNN", DNA},
+		{"acgt acgt\n", DNA},
+		{"ACGTACGTACGTACGTACGR", DNA},
+		{"ACGUACGU", RNA},
+		{"ACGTU", Ambiguous},
+		{"MKTAYIAKQRQISFVKSHFSRQ*", Protein},
+		{"MKV-LLE", Protein},
+		{"RYKMSW", Ambiguous},
+		{"ACGT123", Unknown},
+		{" -*", Unknown},
+		{"", Unknown},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, DetectType(tt.bases), tt.bases)
+	}
+	assert.Equal(t, "Protein", Protein.String())
+
+	_, err := NewWithPolicy("MKTLLVAEQ", "p1", "", IUPACPolicy())
+	var mismatch *TypeMismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, Protein, mismatch.Detected)
+	assert.Equal(t, "expected DNA sequence, input looks like Protein", err.Error())
+}
+
+func TestSequenceSet(t *testing.T) {
+	mk := func(id, desc, bases string) *Sequence {
+		s, err := WithMetadata(bases, id, desc, DNA)
+		require.NoError(t, err)
+		return s
+	}
+	a := mk("a", "sample=x", "ATATAT")
+	b := mk("b", "sample=y", "GCGCGCGC")
+	c := mk("c", "sample=x", "ATGC")
+	dup := mk("a", "", "GGGG")
+	set := NewSequenceSet(a, b, c, dup)
+
+	assert.Equal(t, 4, set.Len())
+	assert.Equal(t, 22, set.TotalBases())
+	got, ok := set.Get("a")
+	require.True(t, ok)
+	assert.Same(t, a, got)
+	_, ok = set.Get("z")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"a", "c", "a"}, set.FilterLength(4, 6).IDs())
+	assert.Equal(t, []string{"a"}, set.FilterLength(5, 6).IDs())
+	assert.Equal(t, []string{"b", "a"}, set.FilterGC(0.6, 1).IDs())
+	assert.Equal(t, []string{"c", "a", "a", "b"}, set.Sort(SortByLength, false).IDs())
+	assert.Equal(t, []string{"b", "a", "c", "a"}, set.Sort(SortByGC, true).IDs())
+	assert.Equal(t, []string{"a", "b", "c", "a"}, set.IDs(), "receiver is unchanged")
+
+	groups := set.GroupBy(regexp.MustCompile(`sample=(\w+)`))
+	require.Len(t, groups, 3)
+	assert.Equal(t, "x", groups[0].Key)
+	assert.Equal(t, []string{"a", "c"}, groups[0].Set.IDs())
+	assert.Equal(t, "", groups[2].Key)
+
+	other := NewSequenceSet(mk("c", "", "ATGC"), mk("d", "", "ATATAT"))
+	assert.Equal(t, []string{"a", "b", "c", "a", "d"}, set.Union(other, ByID).IDs())
+	assert.Equal(t, []string{"a", "b", "c", "a"}, set.Union(other, ByChecksum).IDs())
+	assert.Equal(t, []string{"c"}, set.Intersect(other, ByID).IDs())
+	assert.Equal(t, []string{"a", "c"}, set.Intersect(other, ByChecksum).IDs())
+	assert.Equal(t, []string{"b", "a"}, set.Subtract(other, ByChecksum).IDs())
+
+	_, ok = set.ReadGroup()
+	assert.False(t, ok)
+	set.SetReadGroup(readgroup.ReadGroup{ID: "rg1", Sample: "S1"})
+	g, ok := set.Sort(SortByID, false).FilterLength(1, 0).ReadGroup()
+	require.True(t, ok)
+	assert.Equal(t, "S1", g.Sample)
+
+	assert.Equal(t, "f1f8f4bf413b16ad135722aa4591043e", mk("x", "", "acgt").Checksum())
+	_, err := ParseSortKey("size")
+	assert.Error(t, err)
+	k, err := ParseSetKey("checksum")
+	require.NoError(t, err)
+	assert.Equal(t, ByChecksum, k)
+}
+
+func TestSequenceSetSample(t *testing.T) {
+	set := NewSequenceSet()
+	for i := 0; i < 20; i++ {
+		s, err := WithID(strings.Repeat("ACGT", i+1), string(rune('a'+i)))
+		require.NoError(t, err)
+		set.Add(s)
+	}
+
+	sample := set.Sample(5, random.New(3))
+	assert.Equal(t, 5, sample.Len())
+	assert.Equal(t, sample.IDs(), set.Sample(5, random.New(3)).IDs(), "same seed, same sample")
+	ids := sample.IDs()
+	for i := 1; i < len(ids); i++ {
+		assert.Less(t, ids[i-1], ids[i], "original order kept")
+	}
+	assert.Equal(t, 20, set.Sample(50, random.New(3)).Len())
+
+	shuffled := set.Shuffle(random.New(3))
+	assert.Equal(t, shuffled.IDs(), set.Shuffle(random.New(3)).IDs())
+	assert.NotEqual(t, set.IDs(), shuffled.IDs())
+	assert.ElementsMatch(t, set.IDs(), shuffled.IDs())
+}
+
 func BenchmarkNew(b *testing.B) {
 	bases := "ATGCATGCATGCATGCATGCATGCATGCATGCATGCATGC"
 	b.ResetTimer()
@@ -0,0 +1,329 @@
+package sequence
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/random"
+	"github.com/aria-lang/bioflow-go/internal/readgroup"
+)
+
+// Checksum returns the MD5 digest of the bases in lower-case hex, as used
+// by the M5 tag of SAM @SQ header lines. Sequences with the same bases
+// have the same checksum whatever their IDs.
+func (s *Sequence) Checksum() string {
+	sum := md5.Sum([]byte(s.Bases))
+	return hex.EncodeToString(sum[:])
+}
+
+// SequenceSet is an ordered collection of sequences indexed by ID.
+// Duplicate IDs are kept; Get returns the first sequence with an ID.
+//
+// Operations that select or reorder sequences return a new set sharing
+// the same *Sequence values and read group, so the receiver is never
+// modified.
+//
+// Aria equivalent:
+//
+//	struct SequenceSet
+//	  sequences: [Sequence]
+//	  index: Map<String, Int>
+//	  invariant self.index.values().all(|i| i < self.sequences.len())
+type SequenceSet struct {
+	sequences []*Sequence
+	index     map[string]int
+	group     *readgroup.ReadGroup
+}
+
+// NewSequenceSet creates a set holding sequences in the given order.
+func NewSequenceSet(sequences ...*Sequence) *SequenceSet {
+	set := &SequenceSet{
+		sequences: make([]*Sequence, 0, len(sequences)),
+		index:     make(map[string]int, len(sequences)),
+	}
+	for _, s := range sequences {
+		set.Add(s)
+	}
+	return set
+}
+
+// derive creates a set holding sequences with the receiver's read group.
+func (set *SequenceSet) derive(sequences ...*Sequence) *SequenceSet {
+	out := NewSequenceSet(sequences...)
+	out.group = set.group
+	return out
+}
+
+// SetReadGroup records where the sequences came from.
+func (set *SequenceSet) SetReadGroup(g readgroup.ReadGroup) {
+	set.group = &g
+}
+
+// ReadGroup returns the read group of the set, if one was recorded.
+func (set *SequenceSet) ReadGroup() (readgroup.ReadGroup, bool) {
+	if set.group == nil {
+		return readgroup.ReadGroup{}, false
+	}
+	return *set.group, true
+}
+
+// Add appends a sequence.
+func (set *SequenceSet) Add(s *Sequence) {
+	if _, ok := set.index[s.ID]; !ok {
+		set.index[s.ID] = len(set.sequences)
+	}
+	set.sequences = append(set.sequences, s)
+}
+
+// Len returns the number of sequences.
+func (set *SequenceSet) Len() int {
+	return len(set.sequences)
+}
+
+// Sequences returns the sequences in order. The slice must not be
+// modified.
+func (set *SequenceSet) Sequences() []*Sequence {
+	return set.sequences
+}
+
+// Get returns the first sequence with the given ID.
+func (set *SequenceSet) Get(id string) (*Sequence, bool) {
+	i, ok := set.index[id]
+	if !ok {
+		return nil, false
+	}
+	return set.sequences[i], true
+}
+
+// IDs returns the sequence IDs in order.
+func (set *SequenceSet) IDs() []string {
+	ids := make([]string, len(set.sequences))
+	for i, s := range set.sequences {
+		ids[i] = s.ID
+	}
+	return ids
+}
+
+// TotalBases returns the summed sequence length.
+func (set *SequenceSet) TotalBases() int {
+	total := 0
+	for _, s := range set.sequences {
+		total += s.Len()
+	}
+	return total
+}
+
+// Filter returns the sequences for which keep returns true.
+func (set *SequenceSet) Filter(keep func(*Sequence) bool) *SequenceSet {
+	out := set.derive()
+	for _, s := range set.sequences {
+		if keep(s) {
+			out.Add(s)
+		}
+	}
+	return out
+}
+
+// FilterLength returns the sequences with min <= length <= max. A max of
+// zero means no upper bound.
+func (set *SequenceSet) FilterLength(min, max int) *SequenceSet {
+	return set.Filter(func(s *Sequence) bool {
+		return s.Len() >= min && (max <= 0 || s.Len() <= max)
+	})
+}
+
+// FilterGC returns the sequences with min <= GC content <= max.
+func (set *SequenceSet) FilterGC(min, max float64) *SequenceSet {
+	return set.Filter(func(s *Sequence) bool {
+		gc := s.GCContent()
+		return gc >= min && gc <= max
+	})
+}
+
+// SortKey selects the order of SequenceSet.Sort.
+type SortKey int
+
+const (
+	// SortByID orders sequences by ID.
+	SortByID SortKey = iota
+	// SortByLength orders sequences by length.
+	SortByLength
+	// SortByGC orders sequences by GC content.
+	SortByGC
+)
+
+// ParseSortKey returns the sort key named "id", "length" or "gc".
+func ParseSortKey(name string) (SortKey, error) {
+	switch strings.ToLower(name) {
+	case "id":
+		return SortByID, nil
+	case "length", "len":
+		return SortByLength, nil
+	case "gc":
+		return SortByGC, nil
+	default:
+		return 0, fmt.Errorf("unknown sort key %q (use id, length or gc)", name)
+	}
+}
+
+// Sort returns the sequences ordered by key, ascending unless descending
+// is set. Ties keep their original order.
+func (set *SequenceSet) Sort(key SortKey, descending bool) *SequenceSet {
+	sorted := make([]*Sequence, len(set.sequences))
+	copy(sorted, set.sequences)
+	less := func(a, b *Sequence) bool {
+		switch key {
+		case SortByLength:
+			return a.Len() < b.Len()
+		case SortByGC:
+			return a.GCContent() < b.GCContent()
+		default:
+			return a.ID < b.ID
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if descending {
+			return less(sorted[j], sorted[i])
+		}
+		return less(sorted[i], sorted[j])
+	})
+	return set.derive(sorted...)
+}
+
+// Sample returns n sequences chosen uniformly at random without
+// replacement, in their original order. All sequences are returned if
+// there are no more than n.
+//
+// Aria equivalent:
+//
+//	fn sample(self, n: Int) -> SequenceSet with Random
+//	  ensures result.len() == min(n, self.len())
+func (set *SequenceSet) Sample(n int, rng *rand.Rand) *SequenceSet {
+	out := set.derive()
+	for _, i := range random.Sample(rng, len(set.sequences), n) {
+		out.Add(set.sequences[i])
+	}
+	return out
+}
+
+// Shuffle returns the sequences in random order.
+func (set *SequenceSet) Shuffle(rng *rand.Rand) *SequenceSet {
+	shuffled := make([]*Sequence, len(set.sequences))
+	copy(shuffled, set.sequences)
+	rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	return set.derive(shuffled...)
+}
+
+// Group is a named subset produced by GroupBy.
+type Group struct {
+	Key string
+	Set *SequenceSet
+}
+
+// GroupBy groups sequences by the part of their description matched by
+// pattern: the first capture group if the pattern has one, otherwise the
+// whole match. Sequences whose description does not match fall into the
+// group with an empty key. Groups are returned in order of first
+// appearance.
+//
+// Aria equivalent:
+//
+//	fn group_by(self, pattern: Regex) -> [Group]
+//	  ensures result.map(|g| g.set.len()).sum() == self.len()
+func (set *SequenceSet) GroupBy(pattern *regexp.Regexp) []Group {
+	groups := make([]Group, 0)
+	byKey := make(map[string]int)
+	for _, s := range set.sequences {
+		key := ""
+		if m := pattern.FindStringSubmatch(s.Description); m != nil {
+			key = m[0]
+			if len(m) > 1 {
+				key = m[1]
+			}
+		}
+		i, ok := byKey[key]
+		if !ok {
+			i = len(groups)
+			byKey[key] = i
+			groups = append(groups, Group{Key: key, Set: set.derive()})
+		}
+		groups[i].Set.Add(s)
+	}
+	return groups
+}
+
+// SetKey selects how set operations decide that two sequences are the
+// same.
+type SetKey int
+
+const (
+	// ByID matches sequences by ID.
+	ByID SetKey = iota
+	// ByChecksum matches sequences by the checksum of their bases.
+	ByChecksum
+)
+
+// ParseSetKey returns the set key named "id" or "checksum".
+func ParseSetKey(name string) (SetKey, error) {
+	switch strings.ToLower(name) {
+	case "id":
+		return ByID, nil
+	case "checksum", "md5", "sequence":
+		return ByChecksum, nil
+	default:
+		return 0, fmt.Errorf("unknown set key %q (use id or checksum)", name)
+	}
+}
+
+// keyOf returns the key of a sequence.
+func (k SetKey) keyOf(s *Sequence) string {
+	if k == ByChecksum {
+		return s.Checksum()
+	}
+	return s.ID
+}
+
+// keys returns the keys present in set.
+func (set *SequenceSet) keys(k SetKey) map[string]bool {
+	keys := make(map[string]bool, len(set.sequences))
+	for _, s := range set.sequences {
+		keys[k.keyOf(s)] = true
+	}
+	return keys
+}
+
+// Union returns the sequences of set followed by those of other whose key
+// is not in set.
+//
+// Aria equivalent:
+//
+//	fn union(self, other: SequenceSet, key: SetKey) -> SequenceSet
+//	  ensures result.len() >= self.len()
+func (set *SequenceSet) Union(other *SequenceSet, key SetKey) *SequenceSet {
+	seen := set.keys(key)
+	out := set.derive(set.sequences...)
+	for _, s := range other.sequences {
+		if k := key.keyOf(s); !seen[k] {
+			seen[k] = true
+			out.Add(s)
+		}
+	}
+	return out
+}
+
+// Intersect returns the sequences of set whose key is also in other.
+func (set *SequenceSet) Intersect(other *SequenceSet, key SetKey) *SequenceSet {
+	keys := other.keys(key)
+	return set.Filter(func(s *Sequence) bool { return keys[key.keyOf(s)] })
+}
+
+// Subtract returns the sequences of set whose key is not in other.
+func (set *SequenceSet) Subtract(other *SequenceSet, key SetKey) *SequenceSet {
+	keys := other.keys(key)
+	return set.Filter(func(s *Sequence) bool { return !keys[key.keyOf(s)] })
+}
@@ -0,0 +1,83 @@
+package sequence
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Strand is a strand of a DNA sequence. Intervals found on a sequence,
+// such as motif hits and ORFs, are on Forward or Reverse; analyses that
+// take a Strand option also accept BothStrands. The zero value is treated
+// as BothStrands by Includes.
+//
+// Aria equivalent:
+//
+//	enum Strand
+//	  Forward    # '+'
+//	  Reverse    # '-'
+//	  Both       # '.'
+type Strand byte
+
+const (
+	// Forward is the strand the sequence is written on.
+	Forward Strand = '+'
+	// Reverse is its reverse complement.
+	Reverse Strand = '-'
+	// BothStrands selects both strands.
+	BothStrands Strand = '.'
+)
+
+// ParseStrand parses a strand option: forward (or +, fwd), reverse (or -,
+// rev) or both (or .), case-insensitively.
+func ParseStrand(name string) (Strand, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "forward", "fwd", "+":
+		return Forward, nil
+	case "reverse", "rev", "-":
+		return Reverse, nil
+	case "both", ".":
+		return BothStrands, nil
+	}
+	return 0, fmt.Errorf("unknown strand %q (want forward, reverse or both)", name)
+}
+
+// String returns the strand as written in BED and GFF: "+", "-", or "."
+// for both strands.
+func (s Strand) String() string {
+	switch s {
+	case Forward, Reverse:
+		return string(s)
+	}
+	return "."
+}
+
+// Name returns forward, reverse or both.
+func (s Strand) Name() string {
+	switch s {
+	case Forward:
+		return "forward"
+	case Reverse:
+		return "reverse"
+	}
+	return "both"
+}
+
+// Includes reports whether the strand option s covers strand t.
+func (s Strand) Includes(t Strand) bool {
+	return s == t || s == BothStrands || s == 0
+}
+
+// MarshalText encodes the strand as "+", "-" or ".".
+func (s Strand) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes any form ParseStrand accepts.
+func (s *Strand) UnmarshalText(text []byte) error {
+	v, err := ParseStrand(string(text))
+	if err != nil {
+		return err
+	}
+	*s = v
+	return nil
+}
@@ -41,6 +41,19 @@ func (e *InvalidLengthError) Error() string {
 
 func (e *InvalidLengthError) IsSequenceError() {}
 
+// TypeMismatchError is returned when input looks like a different kind of
+// sequence than the one requested, such as a protein given as DNA.
+type TypeMismatchError struct {
+	Expected SequenceType
+	Detected SequenceType
+}
+
+func (e *TypeMismatchError) Error() string {
+	return fmt.Sprintf("expected %s sequence, input looks like %s", e.Expected, e.Detected)
+}
+
+func (e *TypeMismatchError) IsSequenceError() {}
+
 // ValidateDNA validates that a string contains only valid DNA bases.
 func ValidateDNA(bases string) error {
 	for i, b := range bases {
@@ -0,0 +1,224 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/aria-lang/bioflow-go/internal/quality"
+	"github.com/aria-lang/bioflow-go/internal/random"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Default bootstrap parameters.
+const (
+	DefaultBootstrapResamples = 1000
+	DefaultConfidenceLevel    = 0.95
+)
+
+// BootstrapOptions configures bootstrap resampling. A fixed Seed makes
+// the intervals reproducible; Rand, if set, is drawn from instead.
+type BootstrapOptions struct {
+	Resamples int
+	Level     float64
+	Seed      int64
+	Rand      *rand.Rand
+}
+
+// DefaultBootstrapOptions returns 1000 resamples at the 95% level.
+func DefaultBootstrapOptions() BootstrapOptions {
+	return BootstrapOptions{Resamples: DefaultBootstrapResamples, Level: DefaultConfidenceLevel, Seed: random.DefaultSeed}
+}
+
+// ConfidenceInterval is a point estimate with lower and upper bounds.
+type ConfidenceInterval struct {
+	Estimate float64 `json:"estimate"`
+	Lower    float64 `json:"lower"`
+	Upper    float64 `json:"upper"`
+}
+
+// Contains reports whether v lies within the interval.
+func (c ConfidenceInterval) Contains(v float64) bool {
+	return v >= c.Lower && v <= c.Upper
+}
+
+// Intervals holds bootstrap confidence intervals for the means of a
+// sequence or read set. MeanQuality is set for reads only.
+//
+// Aria equivalent:
+//
+//	struct Intervals
+//	  mean_length: ConfidenceInterval
+//	  mean_gc_content: ConfidenceInterval
+//	  mean_quality: ConfidenceInterval?
+//	  invariant self.level > 0.0 and self.level < 1.0
+type Intervals struct {
+	Resamples     int                 `json:"resamples"`
+	Level         float64             `json:"level"`
+	MeanLength    ConfidenceInterval  `json:"mean_length"`
+	MeanGCContent ConfidenceInterval  `json:"mean_gc_content"`
+	MeanQuality   *ConfidenceInterval `json:"mean_quality,omitempty"`
+}
+
+// BootstrapMeans resamples the rows of values with replacement and
+// returns a percentile confidence interval for the mean of each column.
+// All columns are resampled with the same indices.
+//
+// Aria equivalent:
+//
+//	fn bootstrap_means(columns: [[Float]], options: BootstrapOptions) -> Result<[ConfidenceInterval], StatsError>
+//	  requires columns.all(|c| c.len() == columns[0].len() and c.len() > 0)
+//	  requires options.resamples > 0
+//	  ensures result.all(|ci| ci.lower <= ci.upper)
+func BootstrapMeans(columns [][]float64, opts BootstrapOptions) ([]ConfidenceInterval, error) {
+	return BootstrapMeansContext(context.Background(), columns, opts)
+}
+
+// BootstrapMeansContext computes intervals as BootstrapMeans does, and
+// stops with the context's error once ctx is done, checking it between
+// resamples.
+func BootstrapMeansContext(ctx context.Context, columns [][]float64, opts BootstrapOptions) ([]ConfidenceInterval, error) {
+	if opts.Resamples <= 0 {
+		return nil, fmt.Errorf("number of resamples must be positive")
+	}
+	if !(opts.Level > 0 && opts.Level < 1) {
+		return nil, fmt.Errorf("confidence level must be in (0, 1), got %g", opts.Level)
+	}
+	if len(columns) == 0 || len(columns[0]) == 0 {
+		return nil, fmt.Errorf("cannot bootstrap an empty sample")
+	}
+	n := len(columns[0])
+	for _, c := range columns {
+		if len(c) != n {
+			return nil, fmt.Errorf("all columns must have the same length")
+		}
+	}
+
+	rng := random.Or(opts.Rand, opts.Seed)
+	means := make([][]float64, len(columns))
+	for j := range means {
+		means[j] = make([]float64, opts.Resamples)
+	}
+	sums := make([]float64, len(columns))
+	for r := 0; r < opts.Resamples; r++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("bootstrapping: %w", err)
+		}
+		for j := range sums {
+			sums[j] = 0
+		}
+		for i := 0; i < n; i++ {
+			k := rng.Intn(n)
+			for j, c := range columns {
+				sums[j] += c[k]
+			}
+		}
+		for j := range columns {
+			means[j][r] = sums[j] / float64(n)
+		}
+	}
+
+	alpha := (1 - opts.Level) / 2
+	intervals := make([]ConfidenceInterval, len(columns))
+	for j, c := range columns {
+		sort.Float64s(means[j])
+		intervals[j] = ConfidenceInterval{
+			Estimate: mean(c),
+			Lower:    quantile(means[j], alpha),
+			Upper:    quantile(means[j], 1-alpha),
+		}
+	}
+	return intervals, nil
+}
+
+// BootstrapSequences computes confidence intervals for the mean length
+// and mean GC content of sequences.
+func BootstrapSequences(sequences []*sequence.Sequence, opts BootstrapOptions) (*Intervals, error) {
+	return BootstrapSequencesContext(context.Background(), sequences, opts)
+}
+
+// BootstrapSequencesContext computes intervals as BootstrapSequences does,
+// and stops with the context's error once ctx is done.
+func BootstrapSequencesContext(ctx context.Context, sequences []*sequence.Sequence, opts BootstrapOptions) (*Intervals, error) {
+	lengths, gc := lengthsAndGC(sequences)
+	ci, err := BootstrapMeansContext(ctx, [][]float64{lengths, gc}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Intervals{Resamples: opts.Resamples, Level: opts.Level, MeanLength: ci[0], MeanGCContent: ci[1]}, nil
+}
+
+// BootstrapReads computes confidence intervals for the mean length, mean
+// GC content and mean read quality of reads.
+func BootstrapReads(sequences []*sequence.Sequence, qualities []*quality.Scores, opts BootstrapOptions) (*Intervals, error) {
+	return BootstrapReadsContext(context.Background(), sequences, qualities, opts)
+}
+
+// BootstrapReadsContext computes intervals as BootstrapReads does, and
+// stops with the context's error once ctx is done.
+func BootstrapReadsContext(ctx context.Context, sequences []*sequence.Sequence, qualities []*quality.Scores, opts BootstrapOptions) (*Intervals, error) {
+	if len(sequences) != len(qualities) {
+		return nil, fmt.Errorf("sequences and qualities must have same length")
+	}
+	lengths, gc := lengthsAndGC(sequences)
+	ci, err := BootstrapMeansContext(ctx, [][]float64{lengths, gc, averageQualities(qualities)}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Intervals{Resamples: opts.Resamples, Level: opts.Level, MeanLength: ci[0], MeanGCContent: ci[1], MeanQuality: &ci[2]}, nil
+}
+
+// Bootstrap sets the confidence intervals of the statistics computed from
+// sequences.
+func (s *SequenceSetStats) Bootstrap(sequences []*sequence.Sequence, opts BootstrapOptions) error {
+	intervals, err := BootstrapSequences(sequences, opts)
+	if err != nil {
+		return err
+	}
+	s.Intervals = intervals
+	return nil
+}
+
+// Bootstrap sets the confidence intervals of the statistics computed from
+// the reads.
+func (s *ReadSetStats) Bootstrap(sequences []*sequence.Sequence, qualities []*quality.Scores, opts BootstrapOptions) error {
+	intervals, err := BootstrapReads(sequences, qualities, opts)
+	if err != nil {
+		return err
+	}
+	s.Intervals = intervals
+	return nil
+}
+
+// lengthsAndGC returns the length and GC content of each sequence.
+func lengthsAndGC(sequences []*sequence.Sequence) ([]float64, []float64) {
+	lengths := make([]float64, len(sequences))
+	gc := make([]float64, len(sequences))
+	for i, s := range sequences {
+		lengths[i] = float64(s.Len())
+		gc[i] = s.GCContent()
+	}
+	return lengths, gc
+}
+
+// mean returns the arithmetic mean of values.
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// quantile returns the p-quantile of sorted values, interpolating between
+// neighbouring ranks.
+func quantile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	if lo+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lo] + (pos-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
@@ -0,0 +1,255 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/aria-lang/bioflow-go/internal/quality"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// KSTest is the result of a two-sample Kolmogorov-Smirnov test.
+type KSTest struct {
+	// Statistic is the largest distance between the two empirical
+	// distribution functions.
+	Statistic float64 `json:"statistic"`
+	// PValue is the asymptotic probability of a distance at least this
+	// large when both samples come from the same distribution.
+	PValue float64 `json:"p_value"`
+}
+
+// KolmogorovSmirnov compares the distributions of two samples.
+//
+// Aria equivalent:
+//
+//	fn kolmogorov_smirnov(a: [Float], b: [Float]) -> Result<KSTest, StatsError>
+//	  requires a.len() > 0 and b.len() > 0
+//	  ensures result.statistic >= 0.0 and result.statistic <= 1.0
+//	  ensures result.p_value >= 0.0 and result.p_value <= 1.0
+func KolmogorovSmirnov(a, b []float64) (KSTest, error) {
+	if len(a) == 0 || len(b) == 0 {
+		return KSTest{}, fmt.Errorf("both samples must be non-empty")
+	}
+	x, y := sortedCopy(a), sortedCopy(b)
+	na, nb := float64(len(x)), float64(len(y))
+	d := 0.0
+	for i, j := 0, 0; i < len(x) && j < len(y); {
+		v := x[i]
+		if y[j] < v {
+			v = y[j]
+		}
+		for i < len(x) && x[i] == v {
+			i++
+		}
+		for j < len(y) && y[j] == v {
+			j++
+		}
+		if diff := math.Abs(float64(i)/na - float64(j)/nb); diff > d {
+			d = diff
+		}
+	}
+	en := math.Sqrt(na * nb / (na + nb))
+	return KSTest{Statistic: d, PValue: ksProbability((en + 0.12 + 0.11/en) * d)}, nil
+}
+
+// ksProbability evaluates the Kolmogorov distribution's survival
+// function Q(lambda) = 2 * sum((-1)^(j-1) * exp(-2 j^2 lambda^2)).
+func ksProbability(lambda float64) float64 {
+	if lambda < 1e-3 {
+		return 1
+	}
+	sum, sign := 0.0, 1.0
+	for j := 1; j <= 100; j++ {
+		term := sign * math.Exp(-2*float64(j*j)*lambda*lambda)
+		sum += term
+		if math.Abs(term) < 1e-10 {
+			break
+		}
+		sign = -sign
+	}
+	return math.Max(0, math.Min(1, 2*sum))
+}
+
+// CohensD returns the standardized mean difference (mean(b) - mean(a))
+// divided by the pooled standard deviation; zero when both samples are
+// constant.
+func CohensD(a, b []float64) float64 {
+	if len(a)+len(b) <= 2 {
+		return 0
+	}
+	ma, mb := mean(a), mean(b)
+	ss := 0.0
+	for _, v := range a {
+		ss += (v - ma) * (v - ma)
+	}
+	for _, v := range b {
+		ss += (v - mb) * (v - mb)
+	}
+	pooled := math.Sqrt(ss / float64(len(a)+len(b)-2))
+	if pooled == 0 {
+		return 0
+	}
+	return (mb - ma) / pooled
+}
+
+// CliffsDelta returns P(b > a) - P(b < a) over all pairs, a rank-based
+// effect size in [-1, 1] that is robust to skewed distributions.
+func CliffsDelta(a, b []float64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	x := sortedCopy(a)
+	greater, less := 0, 0
+	for _, v := range b {
+		below := sort.SearchFloat64s(x, v)
+		above := len(x) - sort.Search(len(x), func(i int) bool { return x[i] > v })
+		greater += below
+		less += above
+	}
+	return float64(greater-less) / float64(len(a)*len(b))
+}
+
+// SampleSummary describes one sample of a comparison.
+type SampleSummary struct {
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	StdDev float64 `json:"std_dev"`
+}
+
+// summarize returns the summary of a non-empty sample.
+func summarize(values []float64) SampleSummary {
+	m := mean(values)
+	ss := 0.0
+	for _, v := range values {
+		ss += (v - m) * (v - m)
+	}
+	sd := 0.0
+	if len(values) > 1 {
+		sd = math.Sqrt(ss / float64(len(values)-1))
+	}
+	return SampleSummary{Count: len(values), Mean: m, Median: median(values), StdDev: sd}
+}
+
+// MetricComparison compares one per-sequence measurement between two
+// sets.
+type MetricComparison struct {
+	Metric         string        `json:"metric"`
+	A              SampleSummary `json:"a"`
+	B              SampleSummary `json:"b"`
+	MeanDifference float64       `json:"mean_difference"`
+	KS             KSTest        `json:"ks"`
+	CohensD        float64       `json:"cohens_d"`
+	CliffsDelta    float64       `json:"cliffs_delta"`
+}
+
+// CompareMetric compares the values of a metric in two samples.
+// Differences are b relative to a.
+func CompareMetric(metric string, a, b []float64) (*MetricComparison, error) {
+	ks, err := KolmogorovSmirnov(a, b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", metric, err)
+	}
+	sa, sb := summarize(a), summarize(b)
+	return &MetricComparison{
+		Metric: metric, A: sa, B: sb, MeanDifference: sb.Mean - sa.Mean,
+		KS: ks, CohensD: CohensD(a, b), CliffsDelta: CliffsDelta(a, b),
+	}, nil
+}
+
+// SetComparison compares the length, GC content and, for reads, mean
+// quality distributions of two sets.
+//
+// Aria equivalent:
+//
+//	struct SetComparison
+//	  label_a: String
+//	  label_b: String
+//	  length: MetricComparison
+//	  gc_content: MetricComparison
+//	  mean_quality: MetricComparison?
+type SetComparison struct {
+	LabelA      string            `json:"label_a"`
+	LabelB      string            `json:"label_b"`
+	Length      *MetricComparison `json:"length"`
+	GCContent   *MetricComparison `json:"gc_content"`
+	MeanQuality *MetricComparison `json:"mean_quality,omitempty"`
+}
+
+// CompareSequenceSets compares the length and GC content distributions
+// of two sequence sets.
+//
+// Aria equivalent:
+//
+//	fn compare_sequence_sets(a: [Sequence], b: [Sequence]) -> Result<SetComparison, StatsError>
+//	  requires a.len() > 0 and b.len() > 0
+func CompareSequenceSets(a, b []*sequence.Sequence) (*SetComparison, error) {
+	lengthsA, gcA := lengthsAndGC(a)
+	lengthsB, gcB := lengthsAndGC(b)
+	length, err := CompareMetric("length", lengthsA, lengthsB)
+	if err != nil {
+		return nil, err
+	}
+	gc, err := CompareMetric("gc_content", gcA, gcB)
+	if err != nil {
+		return nil, err
+	}
+	return &SetComparison{LabelA: "a", LabelB: "b", Length: length, GCContent: gc}, nil
+}
+
+// CompareReadSets compares two read sets, adding the distribution of mean
+// read quality to the sequence comparison.
+func CompareReadSets(seqA []*sequence.Sequence, qualA []*quality.Scores, seqB []*sequence.Sequence, qualB []*quality.Scores) (*SetComparison, error) {
+	if len(seqA) != len(qualA) || len(seqB) != len(qualB) {
+		return nil, fmt.Errorf("sequences and qualities must have same length")
+	}
+	c, err := CompareSequenceSets(seqA, seqB)
+	if err != nil {
+		return nil, err
+	}
+	c.MeanQuality, err = CompareMetric("mean_quality", averageQualities(qualA), averageQualities(qualB))
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// WriteText writes the comparison as a table of metrics.
+func (c *SetComparison) WriteText(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "Comparison of %s (%d) and %s (%d)\n", c.LabelA, c.Length.A.Count, c.LabelB, c.Length.B.Count); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%-14s %12s %12s %12s %8s %10s %9s %9s\n",
+		"metric", "mean a", "mean b", "difference", "KS D", "p-value", "Cohen d", "Cliff d"); err != nil {
+		return err
+	}
+	for _, m := range []*MetricComparison{c.Length, c.GCContent, c.MeanQuality} {
+		if m == nil {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%-14s %12.4g %12.4g %12.4g %8.4f %10.3g %9.3f %9.3f\n",
+			m.Metric, m.A.Mean, m.B.Mean, m.MeanDifference, m.KS.Statistic, m.KS.PValue, m.CohensD, m.CliffsDelta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// averageQualities returns the mean quality of each read.
+func averageQualities(qualities []*quality.Scores) []float64 {
+	averages := make([]float64, len(qualities))
+	for i, q := range qualities {
+		averages[i] = q.Average()
+	}
+	return averages
+}
+
+// sortedCopy returns a sorted copy of values.
+func sortedCopy(values []float64) []float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	return sorted
+}
@@ -0,0 +1,147 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/aria-lang/bioflow-go/internal/track"
+)
+
+// Default complexity profile parameters.
+const (
+	DefaultComplexityWindow = 64
+	DefaultComplexityMaxK   = 6
+)
+
+// ShannonEntropy returns the Shannon entropy of the base composition in
+// bits (0 to 2). Bases other than A, C, G and T are ignored.
+//
+// Aria equivalent:
+//
+//	fn shannon_entropy(bases: String) -> Float
+//	  ensures result >= 0.0 and result <= 2.0
+func ShannonEntropy(bases string) float64 {
+	var counts [4]int
+	total := 0
+	for i := 0; i < len(bases); i++ {
+		switch bases[i] {
+		case 'A':
+			counts[0]++
+		case 'C':
+			counts[1]++
+		case 'G':
+			counts[2]++
+		case 'T', 'U':
+			counts[3]++
+		default:
+			continue
+		}
+		total++
+	}
+	if total == 0 {
+		return 0
+	}
+	entropy := 0.0
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / float64(total)
+			entropy -= p * math.Log2(p)
+		}
+	}
+	return entropy
+}
+
+// LinguisticComplexity returns the fraction of possible distinct k-mers
+// actually observed, summed over k = 1..maxK:
+//
+//	LC = sum_k observed_k / sum_k min(4^k, L-k+1)
+//
+// k-mers containing bases other than A, C, G, T are skipped. The result
+// is 1 for maximally diverse sequences and approaches 0 for simple repeats.
+//
+// Aria equivalent:
+//
+//	fn linguistic_complexity(bases: String, max_k: Int) -> Float
+//	  requires max_k > 0
+//	  ensures result >= 0.0 and result <= 1.0
+func LinguisticComplexity(bases string, maxK int) float64 {
+	observed, possible := 0, 0
+	for k := 1; k <= maxK && k <= len(bases); k++ {
+		maxDistinct := len(bases) - k + 1
+		if k < 16 && 1<<(2*uint(k)) < maxDistinct {
+			maxDistinct = 1 << (2 * uint(k))
+		}
+		possible += maxDistinct
+
+		seen := make(map[string]struct{})
+		for i := 0; i+k <= len(bases); i++ {
+			kmer := bases[i : i+k]
+			valid := true
+			for j := 0; j < k; j++ {
+				switch kmer[j] {
+				case 'A', 'C', 'G', 'T', 'U':
+				default:
+					valid = false
+				}
+			}
+			if valid {
+				seen[kmer] = struct{}{}
+			}
+		}
+		observed += len(seen)
+	}
+	if possible == 0 {
+		return 0
+	}
+	return float64(observed) / float64(possible)
+}
+
+// ComplexityOptions configures a complexity profile.
+type ComplexityOptions struct {
+	Window int // window size (default DefaultComplexityWindow)
+	Step   int // window step (default Window/2)
+	MaxK   int // largest k for linguistic complexity (default DefaultComplexityMaxK)
+}
+
+// ComplexityProfile computes per-window Shannon entropy and linguistic
+// complexity tracks across a sequence. The final window is truncated at
+// the sequence end.
+//
+// Aria equivalent:
+//
+//	fn complexity_profile(seq: Sequence, options: ComplexityOptions) -> Result<(Track, Track), StatsError>
+//	  ensures result.0.len() == result.1.len()
+func ComplexityProfile(seq *sequence.Sequence, opts ComplexityOptions) (*track.Track, *track.Track, error) {
+	if opts.MaxK < 0 {
+		return nil, nil, fmt.Errorf("maximum k must be non-negative")
+	}
+	if opts.MaxK == 0 {
+		opts.MaxK = DefaultComplexityMaxK
+	}
+	tracks, err := WindowTracks(seq, WindowOptions{Window: opts.Window, Step: opts.Step},
+		EntropyStatistic, ComplexityStatistic(opts.MaxK))
+	if err != nil {
+		return nil, nil, err
+	}
+	return tracks[0], tracks[1], nil
+}
+
+// GCProfile computes a per-window GC content track (0 to 1) across a
+// sequence. GC is measured over unambiguous bases only; windows without
+// any are reported as 0. Window and step default as in
+// ComplexityProfile, and the final window is truncated at the sequence
+// end.
+//
+// Aria equivalent:
+//
+//	fn gc_profile(seq: Sequence, window: Int, step: Int) -> Result<Track, StatsError>
+//	  requires window >= 0 and step >= 0
+//	  ensures result.points.all(|p| p.value >= 0.0 and p.value <= 1.0)
+func GCProfile(seq *sequence.Sequence, window, step int) (*track.Track, error) {
+	tracks, err := WindowTracks(seq, WindowOptions{Window: window, Step: step}, GCStatistic)
+	if err != nil {
+		return nil, err
+	}
+	return tracks[0], nil
+}
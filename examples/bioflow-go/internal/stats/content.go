@@ -0,0 +1,187 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/quality"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Default k-mer content parameters, following FastQC.
+const (
+	DefaultContentK        = 7
+	DefaultContentMinCount = 10
+	DefaultContentMinRatio = 5.0
+	DefaultContentTop      = 20
+)
+
+// KMerContentOptions configures positional k-mer enrichment.
+type KMerContentOptions struct {
+	// K is the k-mer size.
+	K int
+	// MinCount is the fewest occurrences a k-mer needs to be reported.
+	MinCount int
+	// MinRatio is the smallest observed/expected ratio, at the k-mer's
+	// most enriched position, to report.
+	MinRatio float64
+	// Top caps the number of reported k-mers; zero reports all.
+	Top int
+}
+
+// DefaultKMerContentOptions returns the default enrichment parameters.
+func DefaultKMerContentOptions() KMerContentOptions {
+	return KMerContentOptions{
+		K:        DefaultContentK,
+		MinCount: DefaultContentMinCount,
+		MinRatio: DefaultContentMinRatio,
+		Top:      DefaultContentTop,
+	}
+}
+
+// KMerEnrichment is a k-mer that is unusually frequent at some cycles.
+// Expected counts assume the k-mer is spread over positions in proportion
+// to the number of k-mers starting there.
+type KMerEnrichment struct {
+	KMer  string `json:"kmer"`
+	Count int    `json:"count"`
+	// MaxRatio is the observed/expected ratio at MaxPosition, the 1-based
+	// cycle where the k-mer is most enriched.
+	MaxRatio    float64 `json:"max_ratio"`
+	MaxPosition int     `json:"max_position"`
+	// Ratios holds the observed/expected ratio at every cycle.
+	Ratios []float64 `json:"ratios"`
+}
+
+// KMerContent finds k-mers whose occurrences concentrate at particular
+// read positions, the signature of adapter read-through, primer
+// dimers and ligation bias. K-mers containing N are skipped.
+//
+// Aria equivalent:
+//
+//	fn kmer_content(reads: [Sequence], options: KMerContentOptions) -> Result<[KMerEnrichment], StatsError>
+//	  requires options.k > 0
+//	  ensures result.all(|e| e.count >= options.min_count and e.max_ratio >= options.min_ratio)
+func KMerContent(reads []*sequence.Sequence, opts KMerContentOptions) ([]KMerEnrichment, error) {
+	if opts.K <= 0 {
+		return nil, fmt.Errorf("k must be positive")
+	}
+
+	byPosition := make(map[string][]int)
+	totals := make([]int, 0)
+	total := 0
+	for _, r := range reads {
+		bases := strings.ToUpper(r.Bases)
+		for i := 0; i+opts.K <= len(bases); i++ {
+			kmer := bases[i : i+opts.K]
+			if strings.IndexByte(kmer, 'N') >= 0 {
+				continue
+			}
+			counts := byPosition[kmer]
+			for len(counts) <= i {
+				counts = append(counts, 0)
+			}
+			counts[i]++
+			byPosition[kmer] = counts
+			for len(totals) <= i {
+				totals = append(totals, 0)
+			}
+			totals[i]++
+			total++
+		}
+	}
+
+	enriched := make([]KMerEnrichment, 0)
+	for kmer, counts := range byPosition {
+		count := 0
+		for _, c := range counts {
+			count += c
+		}
+		if count < opts.MinCount {
+			continue
+		}
+		e := KMerEnrichment{KMer: kmer, Count: count, Ratios: make([]float64, len(totals))}
+		for i, c := range counts {
+			expected := float64(count) * float64(totals[i]) / float64(total)
+			if expected == 0 {
+				continue
+			}
+			e.Ratios[i] = float64(c) / expected
+			if e.Ratios[i] > e.MaxRatio {
+				e.MaxRatio, e.MaxPosition = e.Ratios[i], i+1
+			}
+		}
+		if e.MaxRatio >= opts.MinRatio {
+			enriched = append(enriched, e)
+		}
+	}
+
+	sort.Slice(enriched, func(i, j int) bool {
+		if enriched[i].MaxRatio != enriched[j].MaxRatio {
+			return enriched[i].MaxRatio > enriched[j].MaxRatio
+		}
+		return enriched[i].KMer < enriched[j].KMer
+	})
+	if opts.Top > 0 && len(enriched) > opts.Top {
+		enriched = enriched[:opts.Top]
+	}
+	return enriched, nil
+}
+
+// Adapter is a named adapter or artefact sequence to scan reads for, as
+// the quality filter trims them.
+type Adapter = quality.Adapter
+
+// DefaultAdapters are the 12 bp adapter prefixes FastQC searches for.
+var DefaultAdapters = quality.FastQCAdapters
+
+// AdapterContent is the cumulative fraction of reads in which an adapter
+// has started at or before each cycle.
+type AdapterContent struct {
+	Adapter
+	Fractions []float64 `json:"fractions"`
+	// Total is the fraction of reads containing the adapter anywhere.
+	Total float64 `json:"total"`
+}
+
+// AdapterContents scans reads for the first exact occurrence of each
+// adapter and accumulates the fraction of reads by cycle. The fractions
+// span the longest read.
+//
+// Aria equivalent:
+//
+//	fn adapter_contents(reads: [Sequence], adapters: [Adapter]) -> [AdapterContent]
+//	  ensures result.all(|a| a.fractions.is_sorted())
+func AdapterContents(reads []*sequence.Sequence, adapters []Adapter) []AdapterContent {
+	maxLen := 0
+	for _, r := range reads {
+		if r.Len() > maxLen {
+			maxLen = r.Len()
+		}
+	}
+
+	contents := make([]AdapterContent, len(adapters))
+	for a, adapter := range adapters {
+		starts := make([]int, maxLen)
+		found := 0
+		motif := strings.ToUpper(adapter.Sequence)
+		for _, r := range reads {
+			if i := strings.Index(strings.ToUpper(r.Bases), motif); i >= 0 {
+				starts[i]++
+				found++
+			}
+		}
+		c := AdapterContent{Adapter: adapter, Fractions: make([]float64, maxLen)}
+		if len(reads) > 0 {
+			cum := 0
+			for i, n := range starts {
+				cum += n
+				c.Fractions[i] = float64(cum) / float64(len(reads))
+			}
+			c.Total = float64(found) / float64(len(reads))
+		}
+		contents[a] = c
+	}
+	return contents
+}
@@ -0,0 +1,262 @@
+package stats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Default coverage report parameters.
+const (
+	DefaultCoverageMaxDepth = 1000
+	DefaultGCWindow         = 100
+	DefaultGCBins           = 20
+)
+
+// DefaultCoverageThresholds are the depths reported as "fraction of the
+// genome covered at >= X".
+var DefaultCoverageThresholds = []int{1, 5, 10, 20, 30, 50, 100}
+
+// CoverageOptions configures a coverage report.
+type CoverageOptions struct {
+	// Thresholds are the depths for which the covered fraction is
+	// reported.
+	Thresholds []int
+	// MaxDepth caps the histogram; deeper positions are counted in the
+	// last bin.
+	MaxDepth int
+	// Window is the window size used to relate coverage to GC content.
+	Window int
+	// GCBins is the number of equal-width GC content bins.
+	GCBins int
+}
+
+// DefaultCoverageOptions returns the default report parameters.
+func DefaultCoverageOptions() CoverageOptions {
+	return CoverageOptions{
+		Thresholds: DefaultCoverageThresholds,
+		MaxDepth:   DefaultCoverageMaxDepth,
+		Window:     DefaultGCWindow,
+		GCBins:     DefaultGCBins,
+	}
+}
+
+// ThresholdFraction is the fraction of positions covered at or above a
+// depth.
+type ThresholdFraction struct {
+	Depth    int     `json:"depth"`
+	Fraction float64 `json:"fraction"`
+}
+
+// GCBiasBin summarizes the coverage of windows within a GC content range.
+// NormalizedCoverage is the mean window coverage divided by the overall
+// mean depth, so 1.0 means no bias.
+type GCBiasBin struct {
+	GCLow              float64 `json:"gc_low"`
+	GCHigh             float64 `json:"gc_high"`
+	Windows            int     `json:"windows"`
+	MeanCoverage       float64 `json:"mean_coverage"`
+	NormalizedCoverage float64 `json:"normalized_coverage"`
+}
+
+// CoverageReport summarizes per-base coverage for sequencing QC.
+//
+// Aria equivalent:
+//
+//	struct CoverageReport
+//	  invariant self.histogram.sum() == self.positions
+//	  invariant self.thresholds.all(|t| t.fraction >= 0.0 and t.fraction <= 1.0)
+//	  invariant self.gc_bias.map(|b| b.windows).sum() <= self.positions / window
+type CoverageReport struct {
+	Sequences int     `json:"sequences"`
+	Positions int     `json:"positions"`
+	MeanDepth float64 `json:"mean_depth"`
+	// MedianDepth is capped at MaxDepth.
+	MedianDepth int     `json:"median_depth"`
+	StdDev      float64 `json:"std_dev"`
+	// CV is the coefficient of variation (StdDev / MeanDepth).
+	CV float64 `json:"cv"`
+	// Uniformity is the fraction of positions with depth >= 0.2 x mean.
+	Uniformity float64 `json:"uniformity"`
+	// Histogram counts positions per depth; the last bin holds depths
+	// >= len(Histogram)-1.
+	Histogram  []int               `json:"histogram"`
+	Thresholds []ThresholdFraction `json:"thresholds"`
+	GCWindow   int                 `json:"gc_window"`
+	GCBias     []GCBiasBin         `json:"gc_bias"`
+}
+
+// NewCoverageReport computes a coverage report over sequences and their
+// per-base depths, given in the same order. GC bias uses non-overlapping
+// windows; windows with more than half ambiguous bases are skipped.
+//
+// Aria equivalent:
+//
+//	fn coverage_report(sequences: [Sequence], depths: [[Int]], options: CoverageOptions) -> Result<CoverageReport, StatsError>
+//	  requires sequences.len() == depths.len()
+//	  requires sequences.zip(depths).all(|(s, d)| s.len() == d.len())
+//	  ensures result.positions == sequences.map(|s| s.len()).sum()
+func NewCoverageReport(sequences []*sequence.Sequence, depths [][]int, opts CoverageOptions) (*CoverageReport, error) {
+	if len(sequences) != len(depths) {
+		return nil, fmt.Errorf("got %d sequences but %d depth arrays", len(sequences), len(depths))
+	}
+	if opts.MaxDepth <= 0 {
+		return nil, fmt.Errorf("max depth must be positive")
+	}
+	if opts.Window <= 0 || opts.GCBins <= 0 {
+		return nil, fmt.Errorf("GC window and bin count must be positive")
+	}
+
+	report := &CoverageReport{
+		Sequences: len(sequences),
+		Histogram: make([]int, opts.MaxDepth+1),
+		GCWindow:  opts.Window,
+	}
+	sum, sumSq := 0.0, 0.0
+	for i, seq := range sequences {
+		if len(depths[i]) != seq.Len() {
+			return nil, fmt.Errorf("sequence %d: %d depths for %d bases", i, len(depths[i]), seq.Len())
+		}
+		for _, d := range depths[i] {
+			if d < 0 {
+				return nil, fmt.Errorf("sequence %d: negative depth", i)
+			}
+			report.Histogram[minInt(d, opts.MaxDepth)]++
+			sum += float64(d)
+			sumSq += float64(d) * float64(d)
+		}
+		report.Positions += seq.Len()
+	}
+	if report.Positions == 0 {
+		return nil, fmt.Errorf("no positions to report")
+	}
+
+	n := float64(report.Positions)
+	report.MeanDepth = sum / n
+	report.StdDev = math.Sqrt(math.Max(sumSq/n-report.MeanDepth*report.MeanDepth, 0))
+	if report.MeanDepth > 0 {
+		report.CV = report.StdDev / report.MeanDepth
+	}
+
+	// Cumulative counts from the histogram give the median, the threshold
+	// fractions and the uniformity without a second pass over the depths.
+	atLeast := make([]int, len(report.Histogram)+1)
+	for d := len(report.Histogram) - 1; d >= 0; d-- {
+		atLeast[d] = atLeast[d+1] + report.Histogram[d]
+	}
+	for d := 0; d < len(report.Histogram); d++ {
+		if atLeast[d+1] <= report.Positions-(report.Positions+1)/2 {
+			report.MedianDepth = d
+			break
+		}
+	}
+	thresholds := append([]int(nil), opts.Thresholds...)
+	sort.Ints(thresholds)
+	report.Thresholds = make([]ThresholdFraction, len(thresholds))
+	for i, t := range thresholds {
+		report.Thresholds[i] = ThresholdFraction{Depth: t, Fraction: float64(atLeast[clampInt(t, 0, opts.MaxDepth)]) / n}
+	}
+	report.Uniformity = float64(atLeast[clampInt(int(math.Ceil(0.2*report.MeanDepth)), 0, opts.MaxDepth)]) / n
+
+	report.GCBias = gcBias(sequences, depths, opts, report.MeanDepth)
+	return report, nil
+}
+
+// gcBias bins windows by GC content and averages their coverage.
+func gcBias(sequences []*sequence.Sequence, depths [][]int, opts CoverageOptions, mean float64) []GCBiasBin {
+	bins := make([]GCBiasBin, opts.GCBins)
+	sums := make([]float64, opts.GCBins)
+	width := 1.0 / float64(opts.GCBins)
+	for i := range bins {
+		bins[i].GCLow = float64(i) * width
+		bins[i].GCHigh = float64(i+1) * width
+	}
+	for s, seq := range sequences {
+		bases := seq.Bases
+		for start := 0; start+opts.Window <= len(bases); start += opts.Window {
+			gc, acgt, total := 0, 0, 0
+			for i := start; i < start+opts.Window; i++ {
+				switch bases[i] {
+				case 'G', 'C':
+					gc++
+					acgt++
+				case 'A', 'T', 'U':
+					acgt++
+				}
+				total += depths[s][i]
+			}
+			if acgt*2 < opts.Window {
+				continue
+			}
+			bin := minInt(int(float64(gc)/float64(acgt)/width), opts.GCBins-1)
+			bins[bin].Windows++
+			sums[bin] += float64(total) / float64(opts.Window)
+		}
+	}
+	for i := range bins {
+		if bins[i].Windows == 0 {
+			continue
+		}
+		bins[i].MeanCoverage = sums[i] / float64(bins[i].Windows)
+		if mean > 0 {
+			bins[i].NormalizedCoverage = bins[i].MeanCoverage / mean
+		}
+	}
+	return bins
+}
+
+// WriteTSV writes the report as three tab-separated sections (summary,
+// histogram and GC bias), each introduced by a "#" line naming it.
+func (r *CoverageReport) WriteTSV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# summary")
+	fmt.Fprintln(bw, "metric\tvalue")
+	fmt.Fprintf(bw, "sequences\t%d\n", r.Sequences)
+	fmt.Fprintf(bw, "positions\t%d\n", r.Positions)
+	fmt.Fprintf(bw, "mean_depth\t%.4f\n", r.MeanDepth)
+	fmt.Fprintf(bw, "median_depth\t%d\n", r.MedianDepth)
+	fmt.Fprintf(bw, "std_dev\t%.4f\n", r.StdDev)
+	fmt.Fprintf(bw, "cv\t%.4f\n", r.CV)
+	fmt.Fprintf(bw, "uniformity\t%.4f\n", r.Uniformity)
+	for _, t := range r.Thresholds {
+		fmt.Fprintf(bw, "fraction_ge_%dx\t%.4f\n", t.Depth, t.Fraction)
+	}
+
+	fmt.Fprintln(bw, "# histogram")
+	fmt.Fprintln(bw, "depth\tpositions\tfraction")
+	last := len(r.Histogram) - 1
+	for last > 0 && r.Histogram[last] == 0 {
+		last--
+	}
+	for d := 0; d <= last; d++ {
+		fmt.Fprintf(bw, "%d\t%d\t%.6f\n", d, r.Histogram[d], float64(r.Histogram[d])/float64(r.Positions))
+	}
+
+	fmt.Fprintln(bw, "# gc_bias")
+	fmt.Fprintln(bw, "gc_low\tgc_high\twindows\tmean_coverage\tnormalized_coverage")
+	for _, b := range r.GCBias {
+		fmt.Fprintf(bw, "%.3f\t%.3f\t%d\t%.4f\t%.4f\n", b.GCLow, b.GCHigh, b.Windows, b.MeanCoverage, b.NormalizedCoverage)
+	}
+	return bw.Flush()
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
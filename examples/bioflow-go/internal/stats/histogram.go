@@ -0,0 +1,309 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Histogram counts values in bins with explicit edges. Bin i covers
+// [Edges[i], Edges[i+1]); the last bin also includes its upper edge.
+// Values outside the edges are counted as underflow or overflow.
+//
+// Aria equivalent:
+//
+//	struct Histogram
+//	  edges: [Float]
+//	  counts: [Int]
+//	  invariant self.edges.len() == self.counts.len() + 1
+//	  invariant self.edges.is_strictly_sorted()
+type Histogram struct {
+	Edges     []float64
+	Counts    []int
+	Underflow int
+	Overflow  int
+}
+
+// NewHistogram creates an empty histogram with the given bin edges.
+//
+// Aria equivalent:
+//
+//	fn new(edges: [Float]) -> Result<Histogram, StatsError>
+//	  requires edges.len() >= 2
+//	  requires edges.is_strictly_sorted()
+func NewHistogram(edges []float64) (*Histogram, error) {
+	if len(edges) < 2 {
+		return nil, fmt.Errorf("histogram needs at least two edges")
+	}
+	for i := 1; i < len(edges); i++ {
+		if !(edges[i] > edges[i-1]) {
+			return nil, fmt.Errorf("histogram edges must be strictly increasing")
+		}
+	}
+	e := make([]float64, len(edges))
+	copy(e, edges)
+	return &Histogram{Edges: e, Counts: make([]int, len(edges)-1)}, nil
+}
+
+// UniformEdges returns bins+1 equally spaced edges from min to max.
+func UniformEdges(min, max float64, bins int) ([]float64, error) {
+	if bins <= 0 {
+		return nil, fmt.Errorf("number of bins must be positive")
+	}
+	if !(max > min) {
+		return nil, fmt.Errorf("histogram range must be non-empty")
+	}
+	edges := make([]float64, bins+1)
+	for i := range edges {
+		edges[i] = min + (max-min)*float64(i)/float64(bins)
+	}
+	edges[bins] = max
+	return edges, nil
+}
+
+// NewUniformHistogram creates an empty histogram of equal-width bins.
+func NewUniformHistogram(min, max float64, bins int) (*Histogram, error) {
+	edges, err := UniformEdges(min, max, bins)
+	if err != nil {
+		return nil, err
+	}
+	return NewHistogram(edges)
+}
+
+// Bin returns the bin index of v, or -1 when v is outside the edges.
+func (h *Histogram) Bin(v float64) int {
+	last := len(h.Edges) - 1
+	if v < h.Edges[0] || v > h.Edges[last] || math.IsNaN(v) {
+		return -1
+	}
+	if v == h.Edges[last] {
+		return last - 1
+	}
+	// Binary search for the last edge <= v.
+	lo, hi := 0, last
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		if h.Edges[mid] <= v {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// Add counts values.
+func (h *Histogram) Add(values ...float64) {
+	for _, v := range values {
+		switch b := h.Bin(v); {
+		case b >= 0:
+			h.Counts[b]++
+		case v < h.Edges[0]:
+			h.Underflow++
+		default:
+			h.Overflow++
+		}
+	}
+}
+
+// Total returns the number of values inside the edges.
+func (h *Histogram) Total() int {
+	total := 0
+	for _, c := range h.Counts {
+		total += c
+	}
+	return total
+}
+
+// Fractions returns each bin's share of the in-range values.
+func (h *Histogram) Fractions() []float64 {
+	fractions := make([]float64, len(h.Counts))
+	if total := h.Total(); total > 0 {
+		for i, c := range h.Counts {
+			fractions[i] = float64(c) / float64(total)
+		}
+	}
+	return fractions
+}
+
+// Density returns the probability density of each bin: its fraction
+// divided by its width, so that the densities integrate to one.
+func (h *Histogram) Density() []float64 {
+	density := h.Fractions()
+	for i := range density {
+		density[i] /= h.Edges[i+1] - h.Edges[i]
+	}
+	return density
+}
+
+// Merge adds the counts of another histogram with identical edges.
+//
+// Aria equivalent:
+//
+//	fn merge(self, other: Histogram) -> Result<(), StatsError>
+//	  requires self.edges == other.edges
+//	  ensures self.total() == old(self.total()) + other.total()
+func (h *Histogram) Merge(other *Histogram) error {
+	if len(other.Edges) != len(h.Edges) {
+		return fmt.Errorf("cannot merge histograms with %d and %d bins", len(h.Counts), len(other.Counts))
+	}
+	for i, e := range h.Edges {
+		if other.Edges[i] != e {
+			return fmt.Errorf("cannot merge histograms with different edges")
+		}
+	}
+	for i, c := range other.Counts {
+		h.Counts[i] += c
+	}
+	h.Underflow += other.Underflow
+	h.Overflow += other.Overflow
+	return nil
+}
+
+// Percentile estimates the value below which fraction p of the in-range
+// values fall, interpolating linearly within the bin.
+//
+// Aria equivalent:
+//
+//	fn percentile(self, p: Float) -> Result<Float, StatsError>
+//	  requires p >= 0.0 and p <= 1.0
+//	  requires self.total() > 0
+//	  ensures result >= self.edges.first() and result <= self.edges.last()
+func (h *Histogram) Percentile(p float64) (float64, error) {
+	if p < 0 || p > 1 {
+		return 0, fmt.Errorf("percentile must be in [0, 1], got %g", p)
+	}
+	total := h.Total()
+	if total == 0 {
+		return 0, fmt.Errorf("histogram is empty")
+	}
+	rank := p * float64(total)
+	cum := 0.0
+	for i, c := range h.Counts {
+		if c == 0 {
+			continue
+		}
+		if cum+float64(c) >= rank {
+			within := (rank - cum) / float64(c)
+			return h.Edges[i] + within*(h.Edges[i+1]-h.Edges[i]), nil
+		}
+		cum += float64(c)
+	}
+	return h.Edges[len(h.Edges)-1], nil
+}
+
+// histogramJSON is the serialized form of a Histogram; fractions and
+// density are derived and ignored when decoding.
+type histogramJSON struct {
+	Edges     []float64 `json:"edges"`
+	Counts    []int     `json:"counts"`
+	Fractions []float64 `json:"fractions,omitempty"`
+	Density   []float64 `json:"density,omitempty"`
+	Underflow int       `json:"underflow"`
+	Overflow  int       `json:"overflow"`
+	Total     int       `json:"total"`
+}
+
+// MarshalJSON encodes the edges and counts with derived fractions and
+// density.
+func (h *Histogram) MarshalJSON() ([]byte, error) {
+	return json.Marshal(histogramJSON{
+		Edges: h.Edges, Counts: h.Counts, Fractions: h.Fractions(), Density: h.Density(),
+		Underflow: h.Underflow, Overflow: h.Overflow, Total: h.Total(),
+	})
+}
+
+// UnmarshalJSON decodes and validates a histogram.
+func (h *Histogram) UnmarshalJSON(data []byte) error {
+	var raw histogramJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	decoded, err := NewHistogram(raw.Edges)
+	if err != nil {
+		return err
+	}
+	if len(raw.Counts) != len(decoded.Counts) {
+		return fmt.Errorf("histogram has %d counts for %d bins", len(raw.Counts), len(decoded.Counts))
+	}
+	copy(decoded.Counts, raw.Counts)
+	decoded.Underflow, decoded.Overflow = raw.Underflow, raw.Overflow
+	*h = *decoded
+	return nil
+}
+
+// Text renders the histogram as labeled bars scaled so the largest bin
+// is width characters long.
+func (h *Histogram) Text(width int) string {
+	max := 0
+	for _, c := range h.Counts {
+		if c > max {
+			max = c
+		}
+	}
+	var sb strings.Builder
+	for i, c := range h.Counts {
+		bar := 0
+		if max > 0 {
+			bar = int(math.Round(float64(c) * float64(width) / float64(max)))
+		}
+		fmt.Fprintf(&sb, "%10.4g-%-10.4g %s (%d)\n", h.Edges[i], h.Edges[i+1], strings.Repeat("#", bar), c)
+	}
+	return sb.String()
+}
+
+// GCContentHistogram counts the GC content of sequences in bins with the
+// given edges.
+func GCContentHistogram(sequences []*sequence.Sequence, edges []float64) (*Histogram, error) {
+	h, err := NewHistogram(edges)
+	if err != nil {
+		return nil, err
+	}
+	for _, seq := range sequences {
+		h.Add(seq.GCContent())
+	}
+	return h, nil
+}
+
+// SequenceLengthHistogram counts sequence lengths in bins with the given
+// edges.
+func SequenceLengthHistogram(sequences []*sequence.Sequence, edges []float64) (*Histogram, error) {
+	h, err := NewHistogram(edges)
+	if err != nil {
+		return nil, err
+	}
+	for _, seq := range sequences {
+		h.Add(float64(seq.Len()))
+	}
+	return h, nil
+}
+
+// Histogram converts the GC histogram to a Histogram over [0, 1].
+func (h *GCHistogram) Histogram() *Histogram {
+	edges := make([]float64, h.NumBins+1)
+	for i := range edges {
+		edges[i] = float64(i) * h.BinSize
+	}
+	edges[h.NumBins] = 1
+	counts := make([]int, h.NumBins)
+	copy(counts, h.Bins)
+	return &Histogram{Edges: edges, Counts: counts}
+}
+
+// Histogram converts the length histogram to a Histogram. The last bin
+// extends to the longest length.
+func (h *LengthHistogram) Histogram() *Histogram {
+	edges := make([]float64, h.NumBins+1)
+	for i := range edges {
+		edges[i] = float64(h.MinLength + i*h.BinWidth)
+	}
+	if last := float64(h.MaxLength); last > edges[h.NumBins] {
+		edges[h.NumBins] = last
+	}
+	counts := make([]int, h.NumBins)
+	copy(counts, h.Bins)
+	return &Histogram{Edges: edges, Counts: counts}
+}
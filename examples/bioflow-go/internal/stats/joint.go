@@ -0,0 +1,260 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/aria-lang/bioflow-go/internal/quality"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Pearson returns the Pearson correlation coefficient of x and y. It is
+// zero when either variable is constant.
+//
+// Aria equivalent:
+//
+//	fn pearson(x: [Float], y: [Float]) -> Result<Float, StatsError>
+//	  requires x.len() == y.len() and x.len() >= 2
+//	  ensures result >= -1.0 and result <= 1.0
+func Pearson(x, y []float64) (float64, error) {
+	if len(x) != len(y) {
+		return 0, fmt.Errorf("x and y must have the same length")
+	}
+	if len(x) < 2 {
+		return 0, fmt.Errorf("correlation needs at least two pairs")
+	}
+	n := float64(len(x))
+	meanX, meanY := 0.0, 0.0
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= n
+	meanY /= n
+	cov, varX, varY := 0.0, 0.0, 0.0
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0, nil
+	}
+	return math.Max(-1, math.Min(1, cov/math.Sqrt(varX*varY))), nil
+}
+
+// Spearman returns the Spearman rank correlation of x and y, giving tied
+// values their average rank.
+func Spearman(x, y []float64) (float64, error) {
+	if len(x) != len(y) {
+		return 0, fmt.Errorf("x and y must have the same length")
+	}
+	return Pearson(ranks(x), ranks(y))
+}
+
+// ranks returns the 1-based ranks of values, averaging ties.
+func ranks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return values[order[a]] < values[order[b]] })
+	r := make([]float64, len(values))
+	for i := 0; i < len(order); {
+		j := i
+		for j+1 < len(order) && values[order[j+1]] == values[order[i]] {
+			j++
+		}
+		avg := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			r[order[k]] = avg
+		}
+		i = j + 1
+	}
+	return r
+}
+
+// Histogram2D counts pairs in a grid of bins for heatmap plotting.
+// Counts[i][j] holds pairs with x in bin i and y in bin j; pairs outside
+// the edges are counted in Outside.
+type Histogram2D struct {
+	XEdges  []float64 `json:"x_edges"`
+	YEdges  []float64 `json:"y_edges"`
+	Counts  [][]int   `json:"counts"`
+	Outside int       `json:"outside"`
+}
+
+// NewHistogram2D creates an empty grid with the given edges.
+func NewHistogram2D(xEdges, yEdges []float64) (*Histogram2D, error) {
+	hx, err := NewHistogram(xEdges)
+	if err != nil {
+		return nil, fmt.Errorf("x edges: %w", err)
+	}
+	hy, err := NewHistogram(yEdges)
+	if err != nil {
+		return nil, fmt.Errorf("y edges: %w", err)
+	}
+	counts := make([][]int, len(hx.Counts))
+	for i := range counts {
+		counts[i] = make([]int, len(hy.Counts))
+	}
+	return &Histogram2D{XEdges: hx.Edges, YEdges: hy.Edges, Counts: counts}, nil
+}
+
+// Add counts a pair.
+func (h *Histogram2D) Add(x, y float64) {
+	i := (&Histogram{Edges: h.XEdges}).Bin(x)
+	j := (&Histogram{Edges: h.YEdges}).Bin(y)
+	if i < 0 || j < 0 {
+		h.Outside++
+		return
+	}
+	h.Counts[i][j]++
+}
+
+// Correlation relates two per-sequence measurements.
+type Correlation struct {
+	X        string       `json:"x"`
+	Y        string       `json:"y"`
+	Pairs    int          `json:"pairs"`
+	Pearson  float64      `json:"pearson"`
+	Spearman float64      `json:"spearman"`
+	Grid     *Histogram2D `json:"grid"`
+}
+
+// NewCorrelation correlates x and y and bins them on a bins x bins grid
+// spanning their ranges.
+func NewCorrelation(xName, yName string, x, y []float64, bins int) (*Correlation, error) {
+	pearson, err := Pearson(x, y)
+	if err != nil {
+		return nil, err
+	}
+	spearman, err := Spearman(x, y)
+	if err != nil {
+		return nil, err
+	}
+	grid, err := NewHistogram2D(rangeEdges(x, bins), rangeEdges(y, bins))
+	if err != nil {
+		return nil, err
+	}
+	for i := range x {
+		grid.Add(x[i], y[i])
+	}
+	return &Correlation{X: xName, Y: yName, Pairs: len(x), Pearson: pearson, Spearman: spearman, Grid: grid}, nil
+}
+
+// rangeEdges returns uniform edges over the range of values, widened to
+// a unit range when all values are equal.
+func rangeEdges(values []float64, bins int) []float64 {
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	if hi == lo {
+		hi = lo + 1
+	}
+	edges, _ := UniformEdges(lo, hi, bins)
+	return edges
+}
+
+// Default joint statistics parameters.
+const (
+	DefaultJointBins = 10
+	// DefaultShortFraction flags sequences shorter than this fraction of
+	// the median length.
+	DefaultShortFraction = 0.5
+	// DefaultGCExcess flags sequences whose GC content exceeds the median
+	// by more than this.
+	DefaultGCExcess = 0.15
+)
+
+// JointOptions configures joint statistics.
+type JointOptions struct {
+	Bins          int
+	ShortFraction float64
+	GCExcess      float64
+}
+
+// DefaultJointOptions returns the default joint statistics parameters.
+func DefaultJointOptions() JointOptions {
+	return JointOptions{Bins: DefaultJointBins, ShortFraction: DefaultShortFraction, GCExcess: DefaultGCExcess}
+}
+
+// Artifact is a pattern in the joint statistics that often indicates a
+// library or contamination problem.
+type Artifact struct {
+	Kind     string  `json:"kind"`
+	Count    int     `json:"count"`
+	Fraction float64 `json:"fraction"`
+	Message  string  `json:"message"`
+}
+
+// ShortHighGC flags short sequences with unusually high GC content,
+// typical of adapter dimers and bacterial contamination.
+const ShortHighGC = "short_high_gc"
+
+// JointStats holds correlations across a sequence or read set.
+type JointStats struct {
+	LengthGC *Correlation `json:"length_vs_gc"`
+	// QualityLength is set for reads.
+	QualityLength *Correlation `json:"quality_vs_length,omitempty"`
+	Artifacts     []Artifact   `json:"artifacts"`
+}
+
+// NewJointStats correlates length with GC content and, when qualities
+// are given, mean quality with length, and flags short high-GC sequences.
+//
+// Aria equivalent:
+//
+//	fn new_joint_stats(sequences: [Sequence], qualities: [QualityScores]?, options: JointOptions) -> Result<JointStats, StatsError>
+//	  requires sequences.len() >= 2
+//	  requires qualities.is_none() or qualities.len() == sequences.len()
+func NewJointStats(sequences []*sequence.Sequence, qualities []*quality.Scores, opts JointOptions) (*JointStats, error) {
+	if qualities != nil && len(qualities) != len(sequences) {
+		return nil, fmt.Errorf("sequences and qualities must have same length")
+	}
+	if opts.Bins <= 0 {
+		return nil, fmt.Errorf("number of bins must be positive")
+	}
+	lengths, gc := lengthsAndGC(sequences)
+	lengthGC, err := NewCorrelation("length", "gc_content", lengths, gc, opts.Bins)
+	if err != nil {
+		return nil, err
+	}
+	joint := &JointStats{LengthGC: lengthGC, Artifacts: make([]Artifact, 0)}
+
+	if qualities != nil {
+		joint.QualityLength, err = NewCorrelation("mean_quality", "length", averageQualities(qualities), lengths, opts.Bins)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	medianLength, medianGC := median(lengths), median(gc)
+	flagged := 0
+	for i := range lengths {
+		if lengths[i] < opts.ShortFraction*medianLength && gc[i] > medianGC+opts.GCExcess {
+			flagged++
+		}
+	}
+	if flagged > 0 {
+		joint.Artifacts = append(joint.Artifacts, Artifact{
+			Kind: ShortHighGC, Count: flagged, Fraction: float64(flagged) / float64(len(lengths)),
+			Message: fmt.Sprintf("%d sequences shorter than %.0f bp with GC above %.1f%%; possible adapter dimers or contamination",
+				flagged, opts.ShortFraction*medianLength, 100*(medianGC+opts.GCExcess)),
+		})
+	}
+	return joint, nil
+}
+
+// median returns the median of values without modifying them.
+func median(values []float64) float64 {
+	sorted := sortedCopy(values)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,95 @@
+package stats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/aria-lang/bioflow-go/internal/quality"
+)
+
+// PositionQuality summarizes the quality scores of all reads at one cycle
+// (read position), as in the per-base quality plot of FastQC.
+type PositionQuality struct {
+	// Position is the 1-based cycle.
+	Position int `json:"position"`
+	// Reads is the number of reads at least Position bases long.
+	Reads         int     `json:"reads"`
+	Mean          float64 `json:"mean"`
+	Median        int     `json:"median"`
+	LowerQuartile int     `json:"lower_quartile"`
+	UpperQuartile int     `json:"upper_quartile"`
+	Percentile10  int     `json:"percentile_10"`
+	Percentile90  int     `json:"percentile_90"`
+}
+
+// PerPositionQuality aggregates quality scores by cycle across reads.
+// Scores are counted in a histogram per position, so memory grows with the
+// longest read rather than with the number of reads.
+//
+// Aria equivalent:
+//
+//	fn per_position_quality(qualities: [QualityScores]) -> [PositionQuality]
+//	  ensures result.len() == qualities.map(|q| q.len()).max()
+//	  ensures result.windows(2).all(|w| w[0].reads >= w[1].reads)
+func PerPositionQuality(qualities []*quality.Scores) []PositionQuality {
+	histograms := make([][quality.PhredMax + 1]int, 0)
+	for _, q := range qualities {
+		for len(histograms) < q.Len() {
+			histograms = append(histograms, [quality.PhredMax + 1]int{})
+		}
+		for i, v := range q.Values {
+			histograms[i][clampInt(v, quality.PhredMin, quality.PhredMax)]++
+		}
+	}
+
+	positions := make([]PositionQuality, len(histograms))
+	for i, h := range histograms {
+		p := PositionQuality{Position: i + 1}
+		sum := 0
+		for score, count := range h {
+			p.Reads += count
+			sum += score * count
+		}
+		if p.Reads > 0 {
+			p.Mean = float64(sum) / float64(p.Reads)
+			p.Percentile10 = histogramPercentile(h[:], p.Reads, 0.10)
+			p.LowerQuartile = histogramPercentile(h[:], p.Reads, 0.25)
+			p.Median = histogramPercentile(h[:], p.Reads, 0.50)
+			p.UpperQuartile = histogramPercentile(h[:], p.Reads, 0.75)
+			p.Percentile90 = histogramPercentile(h[:], p.Reads, 0.90)
+		}
+		positions[i] = p
+	}
+	return positions
+}
+
+// histogramPercentile returns the smallest value whose cumulative count
+// reaches fraction of total (the nearest-rank percentile).
+func histogramPercentile(h []int, total int, fraction float64) int {
+	rank := int(math.Ceil(fraction * float64(total)))
+	if rank < 1 {
+		rank = 1
+	}
+	cum := 0
+	for v, count := range h {
+		cum += count
+		if cum >= rank {
+			return v
+		}
+	}
+	return len(h) - 1
+}
+
+// WritePositionQualityTSV writes per-position quality statistics as a
+// tab-separated table with a header line.
+func WritePositionQualityTSV(w io.Writer, positions []PositionQuality) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "position\treads\tmean\tmedian\tq1\tq3\tp10\tp90")
+	for _, p := range positions {
+		fmt.Fprintf(bw, "%d\t%d\t%.2f\t%d\t%d\t%d\t%d\t%d\n", p.Position, p.Reads, p.Mean,
+			p.Median, p.LowerQuartile, p.UpperQuartile, p.Percentile10, p.Percentile90)
+	}
+	return bw.Flush()
+}
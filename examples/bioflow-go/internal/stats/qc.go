@@ -0,0 +1,113 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/quality"
+	"github.com/aria-lang/bioflow-go/internal/readgroup"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// QCOptions configures a read QC report.
+type QCOptions struct {
+	KMers    KMerContentOptions
+	Adapters []Adapter
+}
+
+// DefaultQCOptions returns the default QC parameters.
+func DefaultQCOptions() QCOptions {
+	return QCOptions{KMers: DefaultKMerContentOptions(), Adapters: DefaultAdapters}
+}
+
+// QCReport is a FastQC-style summary of a read set.
+type QCReport struct {
+	Reads    *ReadSetStats    `json:"reads"`
+	KMers    []KMerEnrichment `json:"overrepresented_kmers"`
+	Adapters []AdapterContent `json:"adapter_content"`
+	// ReadGroups lists the read groups of the reads, when known.
+	ReadGroups []readgroup.Summary `json:"read_groups,omitempty"`
+}
+
+// NewQCReport computes read statistics, per-position quality, enriched
+// k-mers and adapter content.
+//
+// Aria equivalent:
+//
+//	fn new_qc_report(reads: [Sequence], qualities: [QualityScores], options: QCOptions) -> Result<QCReport, StatsError>
+//	  requires reads.len() == qualities.len()
+func NewQCReport(reads []*sequence.Sequence, qualities []*quality.Scores, opts QCOptions) (*QCReport, error) {
+	readStats, err := FromReads(reads, qualities)
+	if err != nil {
+		return nil, err
+	}
+	kmers, err := KMerContent(reads, opts.KMers)
+	if err != nil {
+		return nil, err
+	}
+	return &QCReport{
+		Reads:    readStats,
+		KMers:    kmers,
+		Adapters: AdapterContents(reads, opts.Adapters),
+	}, nil
+}
+
+// WriteText writes the report as plain-text sections.
+func (r *QCReport) WriteText(w io.Writer) error {
+	s := r.Reads
+	fmt.Fprintln(w, "Read Set Statistics")
+	fmt.Fprintln(w, strings.Repeat("-", 40))
+	fmt.Fprintf(w, "Number of reads: %d\n", s.Count)
+	fmt.Fprintf(w, "Total bases: %d\n", s.TotalBases)
+	fmt.Fprintf(w, "Length range: %d - %d bp\n", s.MinLength, s.MaxLength)
+	fmt.Fprintf(w, "Mean quality: %.1f\n", s.MeanQuality)
+	fmt.Fprintf(w, "High quality reads (Q30+): %d (%.1f%%)\n", s.HighQualityCount, s.HighQualityRatio()*100)
+
+	if len(r.ReadGroups) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Read Groups")
+		fmt.Fprintln(w, strings.Repeat("-", 40))
+		fmt.Fprintln(w, "id\tsample\tlibrary\tplatform_unit\tplatform\treads")
+		for _, g := range r.ReadGroups {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n", g.ID, dash(g.Sample), dash(g.Library), dash(g.PlatformUnit()), dash(g.Platform), g.Reads)
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Per-position Quality")
+	fmt.Fprintln(w, strings.Repeat("-", 40))
+	if err := WritePositionQualityTSV(w, s.PositionQuality); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Overrepresented K-mers")
+	fmt.Fprintln(w, strings.Repeat("-", 40))
+	if len(r.KMers) == 0 {
+		fmt.Fprintln(w, "none")
+	} else {
+		fmt.Fprintln(w, "kmer\tcount\tmax_obs_exp\tmax_position")
+		for _, k := range r.KMers {
+			fmt.Fprintf(w, "%s\t%d\t%.2f\t%d\n", k.KMer, k.Count, k.MaxRatio, k.MaxPosition)
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Adapter Content")
+	fmt.Fprintln(w, strings.Repeat("-", 40))
+	for _, a := range r.Adapters {
+		if _, err := fmt.Fprintf(w, "%-32s %6.2f%%\n", a.Name, a.Total*100); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dash returns s, or "-" if it is empty.
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -95,6 +95,8 @@ type SequenceSetStats struct {
 	MeanGCContent  float64
 	N50            int
 	TotalAmbiguous int
+	// Intervals is set by Bootstrap.
+	Intervals *Intervals `json:",omitempty"`
 }
 
 // FromSequences calculates statistics for a collection of sequences.
@@ -203,12 +205,12 @@ func (s *SequenceSetStats) String() string {
 
 // QualityDistribution represents quality score distribution.
 type QualityDistribution struct {
-	PoorCount      int
-	LowCount       int
-	MediumCount    int
-	HighCount      int
-	ExcellentCount int
-	Total          int
+	PoorCount      int `json:"poor"`
+	LowCount       int `json:"low"`
+	MediumCount    int `json:"medium"`
+	HighCount      int `json:"high"`
+	ExcellentCount int `json:"excellent"`
+	Total          int `json:"total"`
 }
 
 // FromCategories creates distribution from list of categories.
@@ -254,15 +256,18 @@ func (d *QualityDistribution) String() string {
 
 // ReadSetStats represents statistics for a collection of reads.
 type ReadSetStats struct {
-	Count               int
-	TotalBases          int
-	MinLength           int
-	MaxLength           int
-	MeanLength          float64
-	MeanQuality         float64
-	MedianQuality       float64
-	HighQualityCount    int
-	QualityDistribution *QualityDistribution
+	Count               int                  `json:"count"`
+	TotalBases          int                  `json:"total_bases"`
+	MinLength           int                  `json:"min_length"`
+	MaxLength           int                  `json:"max_length"`
+	MeanLength          float64              `json:"mean_length"`
+	MeanQuality         float64              `json:"mean_quality"`
+	MedianQuality       float64              `json:"median_quality"`
+	HighQualityCount    int                  `json:"high_quality_count"`
+	QualityDistribution *QualityDistribution `json:"quality_distribution"`
+	PositionQuality     []PositionQuality    `json:"position_quality"`
+	// Intervals is set by Bootstrap.
+	Intervals *Intervals `json:"intervals,omitempty"`
 }
 
 // FromReads calculates statistics for a collection of reads.
@@ -346,6 +351,7 @@ func FromReads(sequences []*sequence.Sequence, qualities []*quality.Scores) (*Re
 		MedianQuality:       medianQuality,
 		HighQualityCount:    highQualityCount,
 		QualityDistribution: distribution,
+		PositionQuality:     PerPositionQuality(qualities),
 	}, nil
 }
 
@@ -1,10 +1,16 @@
 package stats
 
 import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
 	"testing"
 
 	"github.com/aria-lang/bioflow-go/internal/quality"
+	"github.com/aria-lang/bioflow-go/internal/random"
 	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/aria-lang/bioflow-go/internal/track"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -129,6 +135,9 @@ func TestFromReads(t *testing.T) {
 	assert.Equal(t, 12, stats.TotalBases)
 	assert.InDelta(t, 32.5, stats.MeanQuality, 0.1)
 	assert.Equal(t, 2, stats.HighQualityCount)
+	require.Len(t, stats.PositionQuality, 8)
+	assert.Equal(t, 2, stats.PositionQuality[3].Reads)
+	assert.Equal(t, 1, stats.PositionQuality[4].Reads)
 }
 
 func TestFromReadsMismatchedLength(t *testing.T) {
@@ -189,6 +198,593 @@ func TestEmptyHistograms(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestShannonEntropy(t *testing.T) {
+	tests := []struct {
+		bases    string
+		expected float64
+	}{
+		{"AAAA", 0.0},
+		{"ACGT", 2.0},
+		{"AACC", 1.0},
+		{"NNNN", 0.0},
+		{"AANN", 0.0},
+	}
+	for _, tt := range tests {
+		assert.InDelta(t, tt.expected, ShannonEntropy(tt.bases), 1e-9, tt.bases)
+	}
+}
+
+func TestLinguisticComplexity(t *testing.T) {
+	// Homopolymer: one distinct k-mer per k.
+	assert.InDelta(t, 3.0/(4+7+6), LinguisticComplexity("AAAAAAAA", 3), 1e-9)
+	// Every 1-mer and 2-mer of ACGT is distinct.
+	assert.InDelta(t, 1.0, LinguisticComplexity("ACGT", 2), 1e-9)
+	assert.Greater(t, LinguisticComplexity("ACGTTGCAAGCT", 4), LinguisticComplexity("ATATATATATAT", 4))
+	assert.InDelta(t, 0.0, LinguisticComplexity("", 3), 1e-9)
+}
+
+func TestComplexityProfile(t *testing.T) {
+	seq, err := sequence.WithID(strings.Repeat("A", 64)+"ACGTTGCAAGCTTCGAACGGTCATGCAATGCTAGCTGATCGTAGCTAGTCGATCGACTGACTA", "s1")
+	require.NoError(t, err)
+
+	entropy, complexity, err := ComplexityProfile(seq, ComplexityOptions{Window: 64, Step: 64})
+	require.NoError(t, err)
+	require.Equal(t, 2, entropy.Len())
+	require.Equal(t, 2, complexity.Len())
+	assert.Equal(t, "s1", entropy.SequenceID)
+	assert.InDelta(t, 0.0, entropy.Points[0].Value, 1e-9)
+	assert.Greater(t, entropy.Points[1].Value, 1.8)
+	assert.Less(t, complexity.Points[0].Value, complexity.Points[1].Value)
+	assert.Equal(t, seq.Len(), entropy.Points[1].End)
+
+	_, _, err = ComplexityProfile(seq, ComplexityOptions{Window: -1})
+	assert.Error(t, err)
+}
+
+func TestCoverageReport(t *testing.T) {
+	// Two 100 bp windows: an AT-only one covered at 10x and a GC-only one
+	// covered at 30x, plus an all-N window with no coverage.
+	seq, err := sequence.New(strings.Repeat("AT", 50) + strings.Repeat("GC", 50) + strings.Repeat("N", 100))
+	require.NoError(t, err)
+	depths := make([]int, 300)
+	for i := 0; i < 100; i++ {
+		depths[i] = 10
+		depths[100+i] = 30
+	}
+
+	opts := DefaultCoverageOptions()
+	opts.Thresholds = []int{20, 1}
+	opts.MaxDepth = 25
+	opts.GCBins = 4
+	report, err := NewCoverageReport([]*sequence.Sequence{seq}, [][]int{depths}, opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, 300, report.Positions)
+	assert.InDelta(t, 40.0/3, report.MeanDepth, 1e-9)
+	assert.Equal(t, 10, report.MedianDepth)
+	assert.Greater(t, report.CV, 0.8)
+	assert.Equal(t, 100, report.Histogram[0])
+	assert.Equal(t, 100, report.Histogram[10])
+	assert.Equal(t, 100, report.Histogram[25])
+	assert.Equal(t, []ThresholdFraction{{Depth: 1, Fraction: 2.0 / 3}, {Depth: 20, Fraction: 1.0 / 3}}, report.Thresholds)
+	assert.InDelta(t, 2.0/3, report.Uniformity, 1e-9)
+
+	require.Len(t, report.GCBias, 4)
+	assert.Equal(t, 1, report.GCBias[0].Windows)
+	assert.InDelta(t, 10.0, report.GCBias[0].MeanCoverage, 1e-9)
+	assert.Equal(t, 1, report.GCBias[3].Windows)
+	assert.InDelta(t, 30.0/(40.0/3), report.GCBias[3].NormalizedCoverage, 1e-9)
+	assert.Zero(t, report.GCBias[1].Windows)
+
+	var sb strings.Builder
+	require.NoError(t, report.WriteTSV(&sb))
+	assert.Contains(t, sb.String(), "fraction_ge_20x\t0.3333\n")
+	assert.Contains(t, sb.String(), "# gc_bias\n")
+
+	_, err = NewCoverageReport([]*sequence.Sequence{seq}, [][]int{depths[:10]}, opts)
+	assert.Error(t, err)
+}
+
+func TestGCProfile(t *testing.T) {
+	seq, err := sequence.New("AAAAGGGGNNNN")
+	require.NoError(t, err)
+	gc, err := GCProfile(seq, 4, 4)
+	require.NoError(t, err)
+	require.Equal(t, 3, gc.Len())
+	assert.Equal(t, "gc", gc.Name)
+	assert.Equal(t, 0.0, gc.Points[0].Value)
+	assert.Equal(t, 1.0, gc.Points[1].Value)
+	assert.Equal(t, 0.0, gc.Points[2].Value)
+
+	gc, err = GCProfile(seq, 8, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, gc.Len())
+	assert.InDelta(t, 0.5, gc.Points[0].Value, 1e-9)
+
+	_, err = GCProfile(seq, -1, 0)
+	assert.Error(t, err)
+}
+
+func TestPerPositionQuality(t *testing.T) {
+	q1, _ := quality.New([]int{10, 20, 30})
+	q2, _ := quality.New([]int{20, 30})
+	q3, _ := quality.New([]int{30, 40, 40, 40})
+	q4, _ := quality.New([]int{40})
+
+	positions := PerPositionQuality([]*quality.Scores{q1, q2, q3, q4})
+	require.Len(t, positions, 4)
+
+	first := positions[0]
+	assert.Equal(t, 1, first.Position)
+	assert.Equal(t, 4, first.Reads)
+	assert.InDelta(t, 25.0, first.Mean, 0.001)
+	assert.Equal(t, 10, first.LowerQuartile)
+	assert.Equal(t, 20, first.Median)
+	assert.Equal(t, 30, first.UpperQuartile)
+	assert.Equal(t, 10, first.Percentile10)
+	assert.Equal(t, 40, first.Percentile90)
+
+	// Read counts fall off as shorter reads end.
+	assert.Equal(t, []int{4, 3, 2, 1}, []int{positions[0].Reads, positions[1].Reads, positions[2].Reads, positions[3].Reads})
+	assert.Equal(t, 40, positions[3].Median)
+
+	var buf strings.Builder
+	require.NoError(t, WritePositionQualityTSV(&buf, positions[:1]))
+	assert.Equal(t, "position\treads\tmean\tmedian\tq1\tq3\tp10\tp90\n1\t4\t25.00\t20\t10\t30\t10\t40\n", buf.String())
+}
+
+func TestKMerAndAdapterContent(t *testing.T) {
+	// Ten distinct reads share an adapter at position 12; the random part
+	// never contains the adapter k-mers.
+	prefixes := []string{"ACCTGACTTCAC", "TTCACGTTCAAC", "CACTTGTCCTCA", "GTCCACTTCGTT", "CTTCACCTGTAC",
+		"TCAGTTCCACGT", "ACGTTCACCTTG", "TGCACCTTCAGT", "CCTTGCACGTCA", "GTTCACGTCCAT"}
+	reads := make([]*sequence.Sequence, 0, len(prefixes))
+	for _, p := range prefixes {
+		s, err := sequence.New(p + "AGATCGGAAGAG")
+		require.NoError(t, err)
+		reads = append(reads, s)
+	}
+
+	opts := DefaultKMerContentOptions()
+	opts.K = 5
+	opts.MinRatio = 1.5
+	opts.Top = 0
+	enriched, err := KMerContent(reads, opts)
+	require.NoError(t, err)
+	require.NotEmpty(t, enriched)
+	kmers := make(map[string]KMerEnrichment)
+	for _, e := range enriched {
+		kmers[e.KMer] = e
+	}
+	require.Contains(t, kmers, "AGATC")
+	assert.Equal(t, 10, kmers["AGATC"].Count)
+	assert.Equal(t, 13, kmers["AGATC"].MaxPosition)
+	assert.Len(t, kmers["AGATC"].Ratios, 20)
+
+	_, err = KMerContent(reads, KMerContentOptions{K: 0})
+	require.Error(t, err)
+
+	contents := AdapterContents(reads, DefaultAdapters)
+	require.Len(t, contents, len(DefaultAdapters))
+	universal := contents[0]
+	assert.Equal(t, "Illumina Universal Adapter", universal.Name)
+	assert.InDelta(t, 1.0, universal.Total, 1e-9)
+	assert.InDelta(t, 0.0, universal.Fractions[11], 1e-9)
+	assert.InDelta(t, 1.0, universal.Fractions[12], 1e-9)
+	assert.InDelta(t, 0.0, contents[4].Total, 1e-9)
+
+	qualities := make([]*quality.Scores, len(reads))
+	for i := range reads {
+		qualities[i], err = quality.New(make([]int, reads[i].Len()))
+		require.NoError(t, err)
+	}
+	report, err := NewQCReport(reads, qualities, QCOptions{KMers: opts, Adapters: DefaultAdapters[:1]})
+	require.NoError(t, err)
+	var sb strings.Builder
+	require.NoError(t, report.WriteText(&sb))
+	assert.Contains(t, sb.String(), "Overrepresented K-mers")
+	assert.Contains(t, sb.String(), "AGATC\t10\t")
+}
+
+func TestHistogram(t *testing.T) {
+	h, err := NewHistogram([]float64{0, 10, 20, 40})
+	require.NoError(t, err)
+	h.Add(-1, 0, 5, 10, 19.9, 20, 40, 41)
+	assert.Equal(t, []int{2, 2, 2}, h.Counts)
+	assert.Equal(t, 1, h.Underflow)
+	assert.Equal(t, 1, h.Overflow)
+	assert.Equal(t, 6, h.Total())
+	assert.InDeltaSlice(t, []float64{1.0 / 3, 1.0 / 3, 1.0 / 3}, h.Fractions(), 1e-9)
+	assert.InDeltaSlice(t, []float64{1.0 / 30, 1.0 / 30, 1.0 / 60}, h.Density(), 1e-9)
+
+	median, err := h.Percentile(0.5)
+	require.NoError(t, err)
+	assert.InDelta(t, 15.0, median, 1e-9)
+	p90, err := h.Percentile(0.9)
+	require.NoError(t, err)
+	assert.InDelta(t, 34.0, p90, 1e-9)
+	_, err = h.Percentile(1.5)
+	require.Error(t, err)
+
+	other, err := NewHistogram([]float64{0, 10, 20, 40})
+	require.NoError(t, err)
+	other.Add(1, 2)
+	require.NoError(t, h.Merge(other))
+	assert.Equal(t, []int{4, 2, 2}, h.Counts)
+	uniform, err := NewUniformHistogram(0, 40, 4)
+	require.NoError(t, err)
+	require.Error(t, h.Merge(uniform))
+
+	data, err := json.Marshal(h)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"density":[`)
+	var decoded Histogram
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, h.Edges, decoded.Edges)
+	assert.Equal(t, h.Counts, decoded.Counts)
+	assert.Equal(t, 1, decoded.Overflow)
+	require.Error(t, json.Unmarshal([]byte(`{"edges":[0,1],"counts":[1,2]}`), &decoded))
+
+	_, err = NewHistogram([]float64{0, 0})
+	require.Error(t, err)
+	assert.Contains(t, h.Text(4), "####")
+}
+
+func TestHistogramConversions(t *testing.T) {
+	sequences := make([]*sequence.Sequence, 0)
+	for _, bases := range []string{"AAAA", "GGCC", "ATGC", "ATGCATGCAT"} {
+		s, _ := sequence.New(bases)
+		sequences = append(sequences, s)
+	}
+
+	gc, err := GCContentHistogram(sequences, []float64{0, 0.25, 0.75, 1})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 1}, gc.Counts)
+
+	lengths, err := SequenceLengthHistogram(sequences, []float64{0, 5, 10})
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 1}, lengths.Counts)
+
+	legacy, err := NewGCHistogram(sequences, 4)
+	require.NoError(t, err)
+	assert.Equal(t, legacy.Bins, legacy.Histogram().Counts)
+	assert.Equal(t, 1.0, legacy.Histogram().Edges[4])
+
+	legacyLen, err := NewLengthHistogram(sequences, 3)
+	require.NoError(t, err)
+	converted := legacyLen.Histogram()
+	assert.Equal(t, legacyLen.Bins, converted.Counts)
+	assert.Equal(t, 10.0, converted.Edges[3])
+}
+
+func TestCorrelation(t *testing.T) {
+	r, err := Pearson([]float64{1, 2, 3, 4}, []float64{2, 4, 6, 8})
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0, r, 1e-9)
+
+	// Monotonic but non-linear: Spearman is exactly 1.
+	rho, err := Spearman([]float64{1, 2, 3, 4}, []float64{1, 8, 27, 1000})
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0, rho, 1e-9)
+
+	assert.Equal(t, []float64{1, 2.5, 2.5, 4}, ranks([]float64{1, 5, 5, 9}))
+
+	flat, err := Pearson([]float64{1, 2, 3}, []float64{5, 5, 5})
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, flat)
+
+	_, err = Pearson([]float64{1}, []float64{1})
+	require.Error(t, err)
+}
+
+func TestJointStats(t *testing.T) {
+	sequences := make([]*sequence.Sequence, 0)
+	qualities := make([]*quality.Scores, 0)
+	add := func(bases string, q int) {
+		s, err := sequence.New(bases)
+		require.NoError(t, err)
+		scores := make([]int, len(bases))
+		for i := range scores {
+			scores[i] = q
+		}
+		qs, err := quality.New(scores)
+		require.NoError(t, err)
+		sequences = append(sequences, s)
+		qualities = append(qualities, qs)
+	}
+	for i := 0; i < 8; i++ {
+		add(strings.Repeat("ACGTAATT", 5+i), 30+i)
+	}
+	add("GGCCGCGG", 20)
+	add("GCGCGGCC", 21)
+
+	joint, err := NewJointStats(sequences, qualities, DefaultJointOptions())
+	require.NoError(t, err)
+	assert.Equal(t, 10, joint.LengthGC.Pairs)
+	assert.Less(t, joint.LengthGC.Spearman, 0.0)
+	require.NotNil(t, joint.QualityLength)
+	assert.Greater(t, joint.QualityLength.Pearson, 0.9)
+
+	total := joint.LengthGC.Grid.Outside
+	for _, row := range joint.LengthGC.Grid.Counts {
+		for _, c := range row {
+			total += c
+		}
+	}
+	assert.Equal(t, 10, total)
+	assert.Len(t, joint.LengthGC.Grid.Counts, DefaultJointBins)
+
+	require.Len(t, joint.Artifacts, 1)
+	assert.Equal(t, ShortHighGC, joint.Artifacts[0].Kind)
+	assert.Equal(t, 2, joint.Artifacts[0].Count)
+
+	plain, err := NewJointStats(sequences, nil, DefaultJointOptions())
+	require.NoError(t, err)
+	assert.Nil(t, plain.QualityLength)
+
+	_, err = NewJointStats(sequences, qualities[:1], DefaultJointOptions())
+	require.Error(t, err)
+}
+
+func TestBootstrap(t *testing.T) {
+	seqs := make([]*sequence.Sequence, 0)
+	qualities := make([]*quality.Scores, 0)
+	for i := 0; i < 40; i++ {
+		bases := strings.Repeat("ACGT", 5+i%7) + strings.Repeat("GC", i%3)
+		s, err := sequence.New(bases)
+		require.NoError(t, err)
+		q := make([]int, len(bases))
+		for j := range q {
+			q[j] = 20 + i%15
+		}
+		scores, err := quality.New(q)
+		require.NoError(t, err)
+		seqs = append(seqs, s)
+		qualities = append(qualities, scores)
+	}
+
+	st, err := FromReads(seqs, qualities)
+	require.NoError(t, err)
+	require.NoError(t, st.Bootstrap(seqs, qualities, DefaultBootstrapOptions()))
+	ci := st.Intervals
+	require.NotNil(t, ci.MeanQuality)
+	assert.InDelta(t, st.MeanLength, ci.MeanLength.Estimate, 1e-9)
+	assert.InDelta(t, st.MeanQuality, ci.MeanQuality.Estimate, 1e-9)
+	for _, c := range []ConfidenceInterval{ci.MeanLength, ci.MeanGCContent, *ci.MeanQuality} {
+		assert.True(t, c.Lower < c.Upper)
+		assert.True(t, c.Contains(c.Estimate))
+	}
+
+	// The same seed gives the same intervals; a constant column has a
+	// degenerate interval.
+	again, err := BootstrapReads(seqs, qualities, DefaultBootstrapOptions())
+	require.NoError(t, err)
+	assert.Equal(t, ci, again)
+	withRand, err := BootstrapReads(seqs, qualities, BootstrapOptions{
+		Resamples: DefaultBootstrapResamples, Level: DefaultConfidenceLevel, Seed: 99, Rand: random.New(1),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ci, withRand, "Rand takes precedence over Seed")
+	constant, err := BootstrapMeans([][]float64{{2, 2, 2}}, DefaultBootstrapOptions())
+	require.NoError(t, err)
+	assert.Equal(t, ConfidenceInterval{Estimate: 2, Lower: 2, Upper: 2}, constant[0])
+
+	set, err := FromSequences(seqs)
+	require.NoError(t, err)
+	require.NoError(t, set.Bootstrap(seqs, BootstrapOptions{Resamples: 200, Level: 0.9, Seed: 7}))
+	assert.Nil(t, set.Intervals.MeanQuality)
+	assert.True(t, set.Intervals.MeanGCContent.Contains(set.MeanGCContent))
+
+	_, err = BootstrapMeans([][]float64{{1}}, BootstrapOptions{Resamples: 10, Level: 1})
+	assert.Error(t, err)
+	_, err = BootstrapMeans([][]float64{{1, 2}, {1}}, DefaultBootstrapOptions())
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = BootstrapReadsContext(ctx, seqs, qualities, DefaultBootstrapOptions())
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = BootstrapSequencesContext(ctx, seqs, DefaultBootstrapOptions())
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCompareSets(t *testing.T) {
+	ks, err := KolmogorovSmirnov([]float64{1, 2, 3, 4}, []float64{1, 2, 3, 4})
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, ks.Statistic)
+	assert.InDelta(t, 1.0, ks.PValue, 1e-9)
+	ks, err = KolmogorovSmirnov([]float64{1, 2, 3, 4}, []float64{5, 6, 7, 8})
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, ks.Statistic)
+	assert.Less(t, ks.PValue, 0.05)
+	ks, err = KolmogorovSmirnov([]float64{1, 2, 3, 4}, []float64{3, 4, 5, 6})
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, ks.Statistic)
+	_, err = KolmogorovSmirnov(nil, []float64{1})
+	assert.Error(t, err)
+
+	assert.Equal(t, 1.0, CliffsDelta([]float64{1, 2}, []float64{3, 4}))
+	assert.Equal(t, 0.0, CliffsDelta([]float64{1, 2}, []float64{1, 2}))
+	assert.Equal(t, -0.75, CliffsDelta([]float64{2, 3}, []float64{1, 2}))
+	assert.InDelta(t, 1.75/math.Sqrt(0.3125), CohensD([]float64{1, 2}, []float64{3, 3, 3, 4}), 1e-9)
+
+	setA, setB := make([]*sequence.Sequence, 0), make([]*sequence.Sequence, 0)
+	for _, bases := range []string{"ATATATAT", "ATATGC", "AATTAT"} {
+		s, err := sequence.New(bases)
+		require.NoError(t, err)
+		setA = append(setA, s)
+	}
+	for _, bases := range []string{"GCGCGCGCGC", "GGCCATGC", "GCGCGCGCGCAT"} {
+		s, err := sequence.New(bases)
+		require.NoError(t, err)
+		setB = append(setB, s)
+	}
+	c, err := CompareSequenceSets(setA, setB)
+	require.NoError(t, err)
+	assert.Nil(t, c.MeanQuality)
+	assert.Equal(t, 1.0, c.GCContent.KS.Statistic)
+	assert.Equal(t, 1.0, c.GCContent.CliffsDelta)
+	assert.InDelta(t, 10-20.0/3, c.Length.MeanDifference, 1e-9)
+	assert.Equal(t, 3, c.Length.B.Count)
+
+	var sb strings.Builder
+	require.NoError(t, c.WriteText(&sb))
+	assert.Contains(t, sb.String(), "gc_content")
+	_, err = json.Marshal(c)
+	require.NoError(t, err)
+}
+
+func TestStreamingQuantiles(t *testing.T) {
+	digest, err := NewTDigest(DefaultCompression)
+	require.NoError(t, err)
+	values := make([]float64, 0, 100000)
+	for i := 0; i < 100000; i++ {
+		// A deterministic permutation of 0..99999.
+		v := float64((i * 7919) % 100000)
+		values = append(values, v)
+		digest.Add(v)
+	}
+	assert.Equal(t, 100000, digest.Count())
+	for _, q := range []float64{0.001, 0.01, 0.25, 0.5, 0.75, 0.99, 0.999} {
+		est, err := digest.Quantile(q)
+		require.NoError(t, err)
+		assert.InDelta(t, q*100000, est, 100000*0.01, "q=%g", q)
+	}
+	lo, _ := digest.Quantile(0)
+	hi, _ := digest.Quantile(1)
+	assert.Equal(t, 0.0, lo)
+	assert.Equal(t, 99999.0, hi)
+
+	// Merged digests of two halves agree with the whole.
+	a, _ := NewTDigest(DefaultCompression)
+	b, _ := NewTDigest(DefaultCompression)
+	for i, v := range values {
+		if i%2 == 0 {
+			a.Add(v)
+		} else {
+			b.Add(v)
+		}
+	}
+	a.Merge(b)
+	median, err := a.Quantile(0.5)
+	require.NoError(t, err)
+	assert.InDelta(t, 50000, median, 1000)
+
+	empty, _ := NewTDigest(DefaultCompression)
+	_, err = empty.Quantile(0.5)
+	assert.Error(t, err)
+	_, err = NewTDigest(1)
+	assert.Error(t, err)
+}
+
+func TestStreamingN50(t *testing.T) {
+	// Same contigs as the assembly package: 100, 80, 60, 40, 20 (total
+	// 300) give N50 80 with two contigs.
+	d := NewLengthDistribution()
+	for _, l := range []int{20, 100, 60, 80, 40} {
+		d.Add(l)
+	}
+	n50, l50 := d.Nx(0, 0.5)
+	assert.Equal(t, 80, n50)
+	assert.Equal(t, int64(2), l50)
+	ng50, _ := d.Nx(1000, 0.5)
+	assert.Equal(t, 0, ng50)
+
+	// Many equal lengths: only as many as needed are counted.
+	d = NewLengthDistribution()
+	for i := 0; i < 1000; i++ {
+		d.Add(150)
+	}
+	d.Add(1000)
+	n50, l50 = d.Nx(0, 0.5)
+	assert.Equal(t, 150, n50)
+	assert.Equal(t, int64(1+(75500-1000+149)/150), l50)
+	median, err := d.Quantile(0.5)
+	require.NoError(t, err)
+	assert.Equal(t, 150, median)
+
+	seqs := make([]*sequence.Sequence, 0)
+	s := mustStreamingStats(t)
+	other := mustStreamingStats(t)
+	for i, bases := range []string{"ACGTACGTAA", "GGCCGG", "ATATATAT", "ACGTACGTACGTAC"} {
+		seq, err := sequence.New(bases)
+		require.NoError(t, err)
+		seqs = append(seqs, seq)
+		if i < 2 {
+			s.AddSequence(seq)
+		} else {
+			other.AddSequence(seq)
+		}
+	}
+	s.Merge(other)
+	sum, err := s.Summary()
+	require.NoError(t, err)
+	exact, err := FromSequences(seqs)
+	require.NoError(t, err)
+	assert.Equal(t, int64(exact.Count), sum.Count)
+	assert.Equal(t, int64(exact.TotalBases), sum.TotalBases)
+	assert.Equal(t, exact.N50, sum.N50)
+	assert.Equal(t, float64(exact.MinLength), sum.Length.P5)
+	assert.Nil(t, sum.Quality)
+}
+
+func mustStreamingStats(t *testing.T) *StreamingStats {
+	s, err := NewStreamingStats(DefaultCompression)
+	require.NoError(t, err)
+	return s
+}
+
+func TestWindowTracks(t *testing.T) {
+	windows, err := Windows(10, WindowOptions{Window: 4, Step: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []Window{{0, 4}, {3, 7}, {6, 10}}, windows)
+	windows, err = Windows(5, WindowOptions{Window: 4})
+	require.NoError(t, err)
+	assert.Equal(t, []Window{{0, 4}, {2, 5}}, windows)
+	windows, err = Windows(0, WindowOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, windows)
+	_, err = Windows(10, WindowOptions{Step: -1})
+	assert.Error(t, err)
+
+	seq, err := sequence.WithID("GGGCAAATNNNN", "s1")
+	require.NoError(t, err)
+	statistics, err := ParseWindowStatistics("gc, gc_skew,at_skew,ambiguous,entropy", 0)
+	require.NoError(t, err)
+	tracks, err := WindowTracks(seq, WindowOptions{Window: 4, Step: 4}, statistics...)
+	require.NoError(t, err)
+	require.Len(t, tracks, 5)
+	values := func(tr *track.Track) []float64 {
+		v := make([]float64, tr.Len())
+		for i, p := range tr.Points {
+			v[i] = p.Value
+		}
+		return v
+	}
+	assert.Equal(t, "s1", tracks[0].SequenceID)
+	assert.Equal(t, []float64{1, 0, 0}, values(tracks[0]))
+	assert.Equal(t, []float64{0.5, 0, 0}, values(tracks[1]))
+	assert.Equal(t, []float64{0, 0.5, 0}, values(tracks[2]))
+	assert.Equal(t, []float64{0, 0, 1}, values(tracks[3]))
+	assert.Equal(t, "entropy", tracks[4].Name)
+
+	// The profiles are the engine with fixed statistics.
+	gc, err := GCProfile(seq, 4, 4)
+	require.NoError(t, err)
+	assert.Equal(t, tracks[0], gc)
+
+	_, err = ParseWindowStatistics("gc,cpg", 0)
+	assert.ErrorContains(t, err, "complexity")
+	_, err = ParseWindowStatistics(" ", 0)
+	assert.Error(t, err)
+
+	depths := []float64{10, 10, 30, 30, 0}
+	cov, err := WindowMeans("coverage", "chr1", depths, WindowOptions{Window: 2, Step: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{10, 30, 0}, values(cov))
+	assert.Equal(t, 5, cov.Points[2].End)
+}
+
 func BenchmarkFromSequences(b *testing.B) {
 	sequences := make([]*sequence.Sequence, 100)
 	for i := 0; i < 100; i++ {
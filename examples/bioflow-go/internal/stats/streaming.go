@@ -0,0 +1,376 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/aria-lang/bioflow-go/internal/quality"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// DefaultCompression is the t-digest compression: the digest keeps
+// roughly this many centroids, and quantile errors shrink towards the
+// tails.
+const DefaultCompression = 100
+
+// centroid is a cluster of values summarized by their mean and count.
+type centroid struct {
+	Mean  float64 `json:"mean"`
+	Count float64 `json:"count"`
+}
+
+// TDigest estimates quantiles of a stream in bounded memory using the
+// merging t-digest of Dunning and Ertl. Values are buffered and merged
+// into centroids whose size is limited by the k1 scale function, so
+// centroids near the extremes stay small and tail quantiles stay
+// accurate.
+//
+// Aria equivalent:
+//
+//	struct TDigest
+//	  compression: Float
+//	  centroids: [Centroid]
+//	  invariant self.centroids.is_sorted_by(|c| c.mean)
+//	  invariant self.centroids.len() <= 2 * self.compression
+type TDigest struct {
+	compression float64
+	centroids   []centroid
+	buffer      []float64
+	count       float64
+	min, max    float64
+}
+
+// NewTDigest creates an empty digest with the given compression.
+func NewTDigest(compression float64) (*TDigest, error) {
+	if compression < 10 {
+		return nil, fmt.Errorf("compression must be at least 10, got %g", compression)
+	}
+	return &TDigest{compression: compression, min: math.Inf(1), max: math.Inf(-1)}, nil
+}
+
+// Add adds a value. NaN is ignored.
+func (t *TDigest) Add(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+	t.buffer = append(t.buffer, v)
+	t.count++
+	if v < t.min {
+		t.min = v
+	}
+	if v > t.max {
+		t.max = v
+	}
+	if len(t.buffer) >= int(5*t.compression) {
+		t.compress(nil)
+	}
+}
+
+// Count returns the number of values added.
+func (t *TDigest) Count() int {
+	return int(t.count)
+}
+
+// Merge adds the values summarized by another digest.
+func (t *TDigest) Merge(other *TDigest) {
+	if other.count == 0 {
+		return
+	}
+	other.compress(nil)
+	t.count += other.count
+	t.min = math.Min(t.min, other.min)
+	t.max = math.Max(t.max, other.max)
+	t.compress(other.centroids)
+}
+
+// scale is the k1 scale function, which maps quantiles to an index
+// that may grow by at most one within a centroid.
+func (t *TDigest) scale(q float64) float64 {
+	return t.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// compress merges the buffer and extra centroids into the centroids.
+func (t *TDigest) compress(extra []centroid) {
+	if len(t.buffer) == 0 && len(extra) == 0 {
+		return
+	}
+	all := make([]centroid, 0, len(t.centroids)+len(t.buffer)+len(extra))
+	all = append(all, t.centroids...)
+	all = append(all, extra...)
+	for _, v := range t.buffer {
+		all = append(all, centroid{Mean: v, Count: 1})
+	}
+	t.buffer = t.buffer[:0]
+	sort.Slice(all, func(i, j int) bool { return all[i].Mean < all[j].Mean })
+
+	total := 0.0
+	for _, c := range all {
+		total += c.Count
+	}
+	merged := make([]centroid, 0, len(t.centroids)+1)
+	cur := all[0]
+	before := 0.0
+	for _, c := range all[1:] {
+		if t.scale((before+cur.Count+c.Count)/total)-t.scale(before/total) <= 1 {
+			cur.Mean += (c.Mean - cur.Mean) * c.Count / (cur.Count + c.Count)
+			cur.Count += c.Count
+			continue
+		}
+		merged = append(merged, cur)
+		before += cur.Count
+		cur = c
+	}
+	t.centroids = append(merged, cur)
+}
+
+// Quantile estimates the value below which fraction q of the values
+// fall, interpolating between centroid centers.
+//
+// Aria equivalent:
+//
+//	fn quantile(self, q: Float) -> Result<Float, StatsError>
+//	  requires q >= 0.0 and q <= 1.0
+//	  requires self.count() > 0
+//	  ensures result >= self.min and result <= self.max
+func (t *TDigest) Quantile(q float64) (float64, error) {
+	if q < 0 || q > 1 {
+		return 0, fmt.Errorf("quantile must be in [0, 1], got %g", q)
+	}
+	if t.count == 0 {
+		return 0, fmt.Errorf("digest is empty")
+	}
+	t.compress(nil)
+	target := q * t.count
+	first := t.centroids[0]
+	if target < first.Count/2 {
+		if first.Count == 1 {
+			return t.min, nil
+		}
+		return t.min + (first.Mean-t.min)*target/(first.Count/2), nil
+	}
+	cum := 0.0
+	for i := 0; i+1 < len(t.centroids); i++ {
+		a, b := t.centroids[i], t.centroids[i+1]
+		lo, hi := cum+a.Count/2, cum+a.Count+b.Count/2
+		if target < hi {
+			return a.Mean + (b.Mean-a.Mean)*(target-lo)/(hi-lo), nil
+		}
+		cum += a.Count
+	}
+	last := t.centroids[len(t.centroids)-1]
+	lo := t.count - last.Count/2
+	if last.Count == 1 || target >= t.count {
+		return t.max, nil
+	}
+	return last.Mean + (t.max-last.Mean)*(target-lo)/(last.Count/2), nil
+}
+
+// LengthDistribution counts sequence lengths exactly in memory
+// proportional to the number of distinct lengths, not the number of
+// sequences, so N50 and length quantiles of arbitrarily large read sets
+// are computed in a single pass.
+type LengthDistribution struct {
+	counts map[int]int64
+	count  int64
+	total  int64
+}
+
+// NewLengthDistribution creates an empty length distribution.
+func NewLengthDistribution() *LengthDistribution {
+	return &LengthDistribution{counts: make(map[int]int64)}
+}
+
+// Add counts a sequence of the given length.
+func (d *LengthDistribution) Add(length int) {
+	d.counts[length]++
+	d.count++
+	d.total += int64(length)
+}
+
+// Merge adds the lengths counted by another distribution.
+func (d *LengthDistribution) Merge(other *LengthDistribution) {
+	for l, c := range other.counts {
+		d.counts[l] += c
+	}
+	d.count += other.count
+	d.total += other.total
+}
+
+// Count returns the number of sequences.
+func (d *LengthDistribution) Count() int64 {
+	return d.count
+}
+
+// Total returns the number of bases.
+func (d *LengthDistribution) Total() int64 {
+	return d.total
+}
+
+// lengths returns the distinct lengths in increasing order.
+func (d *LengthDistribution) lengths() []int {
+	lengths := make([]int, 0, len(d.counts))
+	for l := range d.counts {
+		lengths = append(lengths, l)
+	}
+	sort.Ints(lengths)
+	return lengths
+}
+
+// Nx returns the length at which the longest sequences first cover
+// fraction of total bases, and how many sequences that takes. Pass the
+// genome size as total for NGx, or zero for the counted bases. Both are
+// zero when the sequences never reach the fraction.
+//
+// Aria equivalent:
+//
+//	fn nx(self, total: Int, fraction: Float) -> (Int, Int)
+//	  requires fraction > 0.0 and fraction <= 1.0
+func (d *LengthDistribution) Nx(total int64, fraction float64) (int, int64) {
+	if total <= 0 {
+		total = d.total
+	}
+	target := fraction * float64(total)
+	lengths := d.lengths()
+	sum, n := int64(0), int64(0)
+	for i := len(lengths) - 1; i >= 0; i-- {
+		l := lengths[i]
+		c := d.counts[l]
+		if float64(sum+int64(l)*c) >= target {
+			// Only as many sequences of this length as needed.
+			need := int64(math.Ceil((target - float64(sum)) / float64(l)))
+			if need < 1 {
+				need = 1
+			}
+			return l, n + need
+		}
+		sum += int64(l) * c
+		n += c
+	}
+	return 0, 0
+}
+
+// Quantile returns the length below which fraction q of the sequences
+// fall, using the nearest rank.
+func (d *LengthDistribution) Quantile(q float64) (int, error) {
+	if q < 0 || q > 1 {
+		return 0, fmt.Errorf("quantile must be in [0, 1], got %g", q)
+	}
+	if d.count == 0 {
+		return 0, fmt.Errorf("length distribution is empty")
+	}
+	rank := int64(math.Ceil(q * float64(d.count)))
+	if rank < 1 {
+		rank = 1
+	}
+	lengths := d.lengths()
+	cum := int64(0)
+	for _, l := range lengths {
+		cum += d.counts[l]
+		if cum >= rank {
+			return l, nil
+		}
+	}
+	return lengths[len(lengths)-1], nil
+}
+
+// StreamQuantiles are the quartiles and 5th/95th percentiles of a
+// streamed measurement.
+type StreamQuantiles struct {
+	P5     float64 `json:"p5"`
+	Q1     float64 `json:"q1"`
+	Median float64 `json:"median"`
+	Q3     float64 `json:"q3"`
+	P95    float64 `json:"p95"`
+}
+
+// StreamSummary is the summary of a streamed sequence or read set.
+type StreamSummary struct {
+	Count      int64            `json:"count"`
+	TotalBases int64            `json:"total_bases"`
+	MinLength  int              `json:"min_length"`
+	MaxLength  int              `json:"max_length"`
+	MeanLength float64          `json:"mean_length"`
+	N50        int              `json:"n50"`
+	L50        int64            `json:"l50"`
+	N90        int              `json:"n90"`
+	Length     StreamQuantiles  `json:"length"`
+	GCContent  StreamQuantiles  `json:"gc_content"`
+	Quality    *StreamQuantiles `json:"mean_quality,omitempty"`
+}
+
+// StreamingStats accumulates length, GC and quality statistics one
+// sequence at a time. Lengths are exact; GC content and mean read quality
+// quantiles are t-digest estimates.
+type StreamingStats struct {
+	Lengths *LengthDistribution
+	GC      *TDigest
+	Quality *TDigest
+}
+
+// NewStreamingStats creates empty streaming statistics with the given
+// t-digest compression.
+func NewStreamingStats(compression float64) (*StreamingStats, error) {
+	gc, err := NewTDigest(compression)
+	if err != nil {
+		return nil, err
+	}
+	q, _ := NewTDigest(compression)
+	return &StreamingStats{Lengths: NewLengthDistribution(), GC: gc, Quality: q}, nil
+}
+
+// AddSequence adds a sequence.
+func (s *StreamingStats) AddSequence(seq *sequence.Sequence) {
+	s.Lengths.Add(seq.Len())
+	s.GC.Add(seq.GCContent())
+}
+
+// AddRead adds a read, including its mean quality.
+func (s *StreamingStats) AddRead(seq *sequence.Sequence, q *quality.Scores) {
+	s.AddSequence(seq)
+	s.Quality.Add(q.Average())
+}
+
+// Merge adds statistics accumulated separately, for example on another
+// chunk of the input.
+func (s *StreamingStats) Merge(other *StreamingStats) {
+	s.Lengths.Merge(other.Lengths)
+	s.GC.Merge(other.GC)
+	s.Quality.Merge(other.Quality)
+}
+
+// Summary returns the current summary.
+func (s *StreamingStats) Summary() (*StreamSummary, error) {
+	d := s.Lengths
+	if d.Count() == 0 {
+		return nil, fmt.Errorf("no sequences added")
+	}
+	lengths := d.lengths()
+	sum := &StreamSummary{
+		Count: d.Count(), TotalBases: d.Total(),
+		MinLength: lengths[0], MaxLength: lengths[len(lengths)-1],
+		MeanLength: float64(d.Total()) / float64(d.Count()),
+	}
+	sum.N50, sum.L50 = d.Nx(0, 0.5)
+	sum.N90, _ = d.Nx(0, 0.9)
+
+	for i, q := range []float64{0.05, 0.25, 0.5, 0.75, 0.95} {
+		l, _ := d.Quantile(q)
+		gc, _ := s.GC.Quantile(q)
+		*sum.Length.at(i) = float64(l)
+		*sum.GCContent.at(i) = gc
+		if s.Quality.Count() > 0 {
+			if sum.Quality == nil {
+				sum.Quality = &StreamQuantiles{}
+			}
+			mq, _ := s.Quality.Quantile(q)
+			*sum.Quality.at(i) = mq
+		}
+	}
+	return sum, nil
+}
+
+// at returns the i-th quantile field in increasing order.
+func (q *StreamQuantiles) at(i int) *float64 {
+	return [...]*float64{&q.P5, &q.Q1, &q.Median, &q.Q3, &q.P95}[i]
+}
@@ -0,0 +1,238 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/aria-lang/bioflow-go/internal/track"
+)
+
+// WindowOptions configures sliding windows. Zero values select the
+// defaults: DefaultComplexityWindow, and a step of half the window.
+type WindowOptions struct {
+	Window int
+	Step   int
+}
+
+// resolve validates the options and fills in defaults.
+func (o WindowOptions) resolve() (WindowOptions, error) {
+	if o.Window < 0 || o.Step < 0 {
+		return o, fmt.Errorf("window and step must be non-negative")
+	}
+	if o.Window == 0 {
+		o.Window = DefaultComplexityWindow
+	}
+	if o.Step == 0 {
+		o.Step = o.Window / 2
+		if o.Step == 0 {
+			o.Step = 1
+		}
+	}
+	return o, nil
+}
+
+// Window is a 0-based, half-open range of positions.
+type Window struct {
+	Start int
+	End   int
+}
+
+// Windows returns the windows over n positions. The final window is
+// truncated at n, and no window starts after a window that reached n.
+//
+// Aria equivalent:
+//
+//	fn windows(n: Int, options: WindowOptions) -> Result<[Window], StatsError>
+//	  requires n >= 0
+//	  ensures result.all(|w| w.start < w.end and w.end <= n)
+//	  ensures n == 0 or result.last().end == n
+func Windows(n int, opts WindowOptions) ([]Window, error) {
+	opts, err := opts.resolve()
+	if err != nil {
+		return nil, err
+	}
+	windows := make([]Window, 0, n/opts.Step+1)
+	for start := 0; start < n; start += opts.Step {
+		end := start + opts.Window
+		if end > n {
+			end = n
+		}
+		windows = append(windows, Window{Start: start, End: end})
+		if end == n {
+			break
+		}
+	}
+	return windows, nil
+}
+
+// WindowStatistic is a named function of the bases in a window. The
+// name becomes the track name.
+type WindowStatistic struct {
+	Name    string
+	Compute func(bases string) float64
+}
+
+// Built-in window statistics.
+var (
+	// GCStatistic is the GC fraction of unambiguous bases (0 to 1).
+	GCStatistic = WindowStatistic{Name: "gc", Compute: gcFraction}
+	// GCSkewStatistic is (G - C) / (G + C), positive on the leading
+	// strand of many bacterial replichores.
+	GCSkewStatistic = WindowStatistic{Name: "gc_skew", Compute: func(b string) float64 { return skew(b, 'G', 'C') }}
+	// ATSkewStatistic is (A - T) / (A + T).
+	ATSkewStatistic = WindowStatistic{Name: "at_skew", Compute: func(b string) float64 { return skew(b, 'A', 'T') }}
+	// EntropyStatistic is the Shannon entropy in bits (0 to 2).
+	EntropyStatistic = WindowStatistic{Name: "entropy", Compute: ShannonEntropy}
+	// AmbiguousStatistic is the fraction of bases other than A, C, G, T
+	// and U.
+	AmbiguousStatistic = WindowStatistic{Name: "ambiguous", Compute: ambiguousFraction}
+)
+
+// ComplexityStatistic is the linguistic complexity for k = 1..maxK.
+func ComplexityStatistic(maxK int) WindowStatistic {
+	return WindowStatistic{Name: "complexity", Compute: func(b string) float64 { return LinguisticComplexity(b, maxK) }}
+}
+
+// WindowStatisticNames lists the names accepted by ParseWindowStatistics.
+func WindowStatisticNames() []string {
+	names := make([]string, 0, len(windowStatistics)+1)
+	for name := range windowStatistics {
+		names = append(names, name)
+	}
+	names = append(names, "complexity")
+	sort.Strings(names)
+	return names
+}
+
+var windowStatistics = map[string]WindowStatistic{
+	GCStatistic.Name:        GCStatistic,
+	GCSkewStatistic.Name:    GCSkewStatistic,
+	ATSkewStatistic.Name:    ATSkewStatistic,
+	EntropyStatistic.Name:   EntropyStatistic,
+	AmbiguousStatistic.Name: AmbiguousStatistic,
+}
+
+// ParseWindowStatistics looks up comma-separated statistic names; maxK
+// is used for "complexity".
+func ParseWindowStatistics(list string, maxK int) ([]WindowStatistic, error) {
+	statistics := make([]WindowStatistic, 0)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if name == "complexity" {
+			if maxK <= 0 {
+				maxK = DefaultComplexityMaxK
+			}
+			statistics = append(statistics, ComplexityStatistic(maxK))
+			continue
+		}
+		s, ok := windowStatistics[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown window statistic %q (use %s)", name, strings.Join(WindowStatisticNames(), ", "))
+		}
+		statistics = append(statistics, s)
+	}
+	if len(statistics) == 0 {
+		return nil, fmt.Errorf("no window statistics given")
+	}
+	return statistics, nil
+}
+
+// WindowTracks computes one track per statistic over the windows of a
+// sequence. All tracks share the same windows, so they can be written
+// side by side.
+//
+// Aria equivalent:
+//
+//	fn window_tracks(seq: Sequence, options: WindowOptions, statistics: [WindowStatistic]) -> Result<[Track], StatsError>
+//	  ensures result.len() == statistics.len()
+//	  ensures result.all(|t| t.len() == result[0].len())
+func WindowTracks(seq *sequence.Sequence, opts WindowOptions, statistics ...WindowStatistic) ([]*track.Track, error) {
+	windows, err := Windows(seq.Len(), opts)
+	if err != nil {
+		return nil, err
+	}
+	tracks := make([]*track.Track, len(statistics))
+	for i, s := range statistics {
+		tracks[i] = track.New(s.Name, seq.ID)
+	}
+	for _, w := range windows {
+		bases := seq.Bases[w.Start:w.End]
+		for i, s := range statistics {
+			tracks[i].Add(w.Start, w.End, s.Compute(bases))
+		}
+	}
+	return tracks, nil
+}
+
+// WindowMeans averages per-position values, such as coverage depths, over
+// windows.
+func WindowMeans(name, sequenceID string, values []float64, opts WindowOptions) (*track.Track, error) {
+	windows, err := Windows(len(values), opts)
+	if err != nil {
+		return nil, err
+	}
+	t := track.New(name, sequenceID)
+	for _, w := range windows {
+		t.Add(w.Start, w.End, mean(values[w.Start:w.End]))
+	}
+	return t, nil
+}
+
+// gcFraction returns the GC fraction of the unambiguous bases, or 0 when
+// there are none.
+func gcFraction(bases string) float64 {
+	strong, total := 0, 0
+	for i := 0; i < len(bases); i++ {
+		switch bases[i] {
+		case 'G', 'C':
+			strong++
+			total++
+		case 'A', 'T', 'U':
+			total++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(strong) / float64(total)
+}
+
+// skew returns (count(a) - count(b)) / (count(a) + count(b)), or 0 when
+// neither occurs.
+func skew(bases string, a, b byte) float64 {
+	na, nb := 0, 0
+	for i := 0; i < len(bases); i++ {
+		switch bases[i] {
+		case a:
+			na++
+		case b:
+			nb++
+		}
+	}
+	if na+nb == 0 {
+		return 0
+	}
+	return float64(na-nb) / float64(na+nb)
+}
+
+// ambiguousFraction returns the fraction of bases other than A, C, G, T
+// and U.
+func ambiguousFraction(bases string) float64 {
+	if len(bases) == 0 {
+		return 0
+	}
+	n := 0
+	for i := 0; i < len(bases); i++ {
+		switch bases[i] {
+		case 'A', 'C', 'G', 'T', 'U':
+		default:
+			n++
+		}
+	}
+	return float64(n) / float64(len(bases))
+}
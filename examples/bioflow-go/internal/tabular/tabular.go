@@ -0,0 +1,377 @@
+// Package tabular reads sequences and reads from delimited text files
+// (CSV and TSV), as exported by plate readers, LIMS and spreadsheets.
+//
+// A column mapping says which columns hold the ID, sequence, quality and
+// description; columns are named by header or by 1-based number. Any
+// other columns become metadata, which is kept in order and folded into
+// the description as "key=value" pairs so that it survives a round trip
+// through FASTA.
+//
+// Comparison with Aria:
+//
+//	Aria would type the mapping against the header at load time:
+//	  struct ColumnMap
+//	    sequence: Column
+//	    invariant self.columns().all_distinct()
+//
+//	Go resolves the mapping against the header of each file and reports
+//	missing or repeated columns as errors.
+package tabular
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Field names used in a column mapping.
+const (
+	FieldID          = "id"
+	FieldSequence    = "seq"
+	FieldQuality     = "qual"
+	FieldDescription = "desc"
+	FieldMetadata    = "meta"
+)
+
+// headerAliases are the header names recognized without a mapping.
+var headerAliases = map[string]string{
+	"id": FieldID, "name": FieldID, "seq_id": FieldID, "sequence_id": FieldID, "sample_id": FieldID,
+	"seq": FieldSequence, "sequence": FieldSequence, "bases": FieldSequence,
+	"qual": FieldQuality, "quality": FieldQuality,
+	"desc": FieldDescription, "description": FieldDescription,
+}
+
+// ColumnMap says which columns hold which field. Columns are header
+// names or 1-based numbers. With no Metadata, all unmapped columns are
+// metadata.
+type ColumnMap struct {
+	ID          string
+	Sequence    string
+	Quality     string
+	Description string
+	Metadata    []string
+}
+
+// ParseColumnMap parses a mapping such as
+// "id=name,seq=sequence,qual=4,meta=plate;well". Field names are id, seq,
+// qual, desc and meta; meta takes a ';'-separated list.
+//
+// Aria equivalent:
+//
+//	fn parse_column_map(spec: String) -> Result<ColumnMap, TabularError>
+func ParseColumnMap(spec string) (ColumnMap, error) {
+	var m ColumnMap
+	if strings.TrimSpace(spec) == "" {
+		return m, nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(part, "=")
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		if !ok || value == "" {
+			return ColumnMap{}, fmt.Errorf("expected field=column, got %q", part)
+		}
+		switch key {
+		case FieldID:
+			m.ID = value
+		case FieldSequence, "sequence":
+			m.Sequence = value
+		case FieldQuality, "quality":
+			m.Quality = value
+		case FieldDescription, "description":
+			m.Description = value
+		case FieldMetadata, "metadata":
+			for _, col := range strings.Split(value, ";") {
+				if col = strings.TrimSpace(col); col != "" {
+					m.Metadata = append(m.Metadata, col)
+				}
+			}
+		default:
+			return ColumnMap{}, fmt.Errorf("unknown field %q (use id, seq, qual, desc or meta)", key)
+		}
+	}
+	return m, nil
+}
+
+// Options configures reading. A zero Comma is chosen from the file name
+// by ReadFile (see CommaFor) and is a comma for Parse. Without a header, columns can only be mapped by number; if
+// none is mapped, the first column is the sequence.
+type Options struct {
+	Comma    rune
+	NoHeader bool
+	Columns  ColumnMap
+}
+
+// Metadata is one metadata column value.
+type Metadata struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Record is one row: the mapped fields and metadata in column order. Line
+// is the 1-based line the row starts on.
+type Record struct {
+	ID          string
+	Sequence    string
+	Quality     string
+	Description string
+	Metadata    []Metadata
+	Line        int
+}
+
+// FullDescription returns the description followed by the metadata as
+// "key=value" pairs. Spaces in metadata keys and values are replaced by
+// '_' so that each pair stays one word.
+func (r Record) FullDescription() string {
+	parts := make([]string, 0, len(r.Metadata)+1)
+	if r.Description != "" {
+		parts = append(parts, r.Description)
+	}
+	for _, m := range r.Metadata {
+		if m.Value == "" {
+			continue
+		}
+		parts = append(parts, oneWord(m.Key)+"="+oneWord(m.Value))
+	}
+	return strings.Join(parts, " ")
+}
+
+// oneWord joins the words of s with '_'.
+func oneWord(s string) string {
+	return strings.Join(strings.Fields(s), "_")
+}
+
+// layout is a mapping resolved to column indices; -1 means absent.
+type layout struct {
+	id, seq, qual, desc int
+	meta                []int
+	names               []string
+}
+
+// resolve resolves the mapping against a header (nil without header).
+func (m ColumnMap) resolve(header []string, width int) (*layout, error) {
+	find := func(col string) (int, error) {
+		if n, err := strconv.Atoi(col); err == nil {
+			if n < 1 || n > width {
+				return 0, fmt.Errorf("column %d out of range (1-%d)", n, width)
+			}
+			return n - 1, nil
+		}
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), col) {
+				return i, nil
+			}
+		}
+		if header == nil {
+			return 0, fmt.Errorf("column %q must be a number without a header", col)
+		}
+		return 0, fmt.Errorf("no column %q in header", col)
+	}
+
+	l := &layout{id: -1, seq: -1, qual: -1, desc: -1}
+	used := make(map[int]string)
+	assign := func(dst *int, field, col string) error {
+		if col == "" {
+			return nil
+		}
+		i, err := find(col)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+		if other, dup := used[i]; dup {
+			return fmt.Errorf("column %d mapped to both %s and %s", i+1, other, field)
+		}
+		used[i] = field
+		*dst = i
+		return nil
+	}
+	for _, f := range []struct {
+		dst   *int
+		field string
+		col   string
+	}{
+		{&l.id, FieldID, m.ID}, {&l.seq, FieldSequence, m.Sequence},
+		{&l.qual, FieldQuality, m.Quality}, {&l.desc, FieldDescription, m.Description},
+	} {
+		if err := assign(f.dst, f.field, f.col); err != nil {
+			return nil, err
+		}
+	}
+
+	// Unmapped fields are recognized by header name.
+	for i, h := range header {
+		field, ok := headerAliases[strings.ToLower(strings.TrimSpace(h))]
+		if _, taken := used[i]; !ok || taken {
+			continue
+		}
+		var dst *int
+		switch field {
+		case FieldID:
+			dst = &l.id
+		case FieldSequence:
+			dst = &l.seq
+		case FieldQuality:
+			dst = &l.qual
+		case FieldDescription:
+			dst = &l.desc
+		}
+		if *dst < 0 {
+			*dst = i
+			used[i] = field
+		}
+	}
+	if l.seq < 0 && header == nil && len(used) == 0 {
+		l.seq = 0
+		used[0] = FieldSequence
+	}
+	if l.seq < 0 {
+		return nil, fmt.Errorf("no sequence column (map one with seq=<column>)")
+	}
+
+	name := func(i int) string {
+		if header != nil && strings.TrimSpace(header[i]) != "" {
+			return strings.TrimSpace(header[i])
+		}
+		return "col" + strconv.Itoa(i+1)
+	}
+	if m.Metadata != nil {
+		for _, col := range m.Metadata {
+			i, err := find(col)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", FieldMetadata, err)
+			}
+			if other, dup := used[i]; dup {
+				return nil, fmt.Errorf("column %d mapped to both %s and %s", i+1, other, FieldMetadata)
+			}
+			used[i] = FieldMetadata
+			l.meta = append(l.meta, i)
+			l.names = append(l.names, name(i))
+		}
+		return l, nil
+	}
+	for i := 0; i < width; i++ {
+		if _, ok := used[i]; !ok {
+			l.meta = append(l.meta, i)
+			l.names = append(l.names, name(i))
+		}
+	}
+	return l, nil
+}
+
+// Parse reads delimited records. Blank lines and lines starting with '#'
+// are skipped; fields may be quoted as in RFC 4180. Rows without a
+// sequence are errors, as are rows with the wrong number of fields.
+//
+// Aria equivalent:
+//
+//	fn parse(reader: Reader, options: Options) -> Result<[Record], TabularError>
+//	  ensures result.is_ok() implies result.unwrap().all(|r| r.sequence.len() > 0)
+func Parse(r io.Reader, opts Options) ([]Record, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = opts.Comma
+	if cr.Comma == 0 {
+		cr.Comma = ','
+	}
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+	if cr.Comma == '\t' {
+		// Tab-separated exports rarely quote, and quotes in them are data.
+		cr.LazyQuotes = true
+	}
+
+	var l *layout
+	var header []string
+	records := make([]Record, 0)
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing delimited file: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		if l == nil {
+			if !opts.NoHeader {
+				header = row
+			}
+			if l, err = opts.Columns.resolve(header, len(row)); err != nil {
+				return nil, err
+			}
+			if !opts.NoHeader {
+				continue
+			}
+		}
+
+		field := func(i int) string {
+			if i < 0 {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+		rec := Record{
+			ID:          field(l.id),
+			Sequence:    field(l.seq),
+			Quality:     field(l.qual),
+			Description: field(l.desc),
+			Line:        line,
+		}
+		if rec.Sequence == "" {
+			return nil, fmt.Errorf("line %d: empty sequence", line)
+		}
+		for j, i := range l.meta {
+			rec.Metadata = append(rec.Metadata, Metadata{Key: l.names[j], Value: field(i)})
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// ReadFile reads a delimited file, choosing the delimiter from the file
+// name unless opts.Comma is set.
+func ReadFile(filename string, opts Options) ([]Record, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	defer file.Close()
+
+	if opts.Comma == 0 {
+		opts.Comma = CommaFor(filename)
+	}
+	return Parse(file, opts)
+}
+
+// CommaFor returns the delimiter suggested by a file name: tab for .tsv,
+// .tab and .txt, otherwise comma.
+func CommaFor(filename string) rune {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".tsv", ".tab", ".txt":
+		return '\t'
+	default:
+		return ','
+	}
+}
+
+// ParseComma parses a delimiter name: "csv", "tsv", "tab", "comma",
+// "semicolon" or a single character.
+func ParseComma(name string) (rune, error) {
+	switch strings.ToLower(name) {
+	case "", "auto":
+		return 0, nil
+	case "csv", "comma", ",":
+		return ',', nil
+	case "tsv", "tab", `\t`, "\t":
+		return '\t', nil
+	case "semicolon", ";":
+		return ';', nil
+	}
+	if r := []rune(name); len(r) == 1 && r[0] != '"' && r[0] != '\n' {
+		return r[0], nil
+	}
+	return 0, fmt.Errorf("unknown delimiter %q (use csv, tsv, semicolon or a single character)", name)
+}
@@ -0,0 +1,90 @@
+package tabular
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const plate = `# exported from the plate reader
+well,name,Sequence,quality,plate id,notes
+A1,s1,ACGTAC,IIIIII,P 1,"first, good"
+
+A2,s2,GGGTTT,IIII##,P1,
+`
+
+func TestParse(t *testing.T) {
+	records, err := Parse(strings.NewReader(plate), Options{})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	r := records[0]
+	assert.Equal(t, "s1", r.ID)
+	assert.Equal(t, "ACGTAC", r.Sequence)
+	assert.Equal(t, "IIIIII", r.Quality)
+	assert.Equal(t, 3, r.Line)
+	assert.Equal(t, []Metadata{{"well", "A1"}, {"plate id", "P 1"}, {"notes", "first, good"}}, r.Metadata)
+	assert.Equal(t, "well=A1 plate_id=P_1 notes=first,_good", r.FullDescription())
+	assert.Equal(t, 5, records[1].Line)
+	assert.Equal(t, "well=A2 plate_id=P1", records[1].FullDescription(), "empty values left out")
+
+	t.Run("mapping", func(t *testing.T) {
+		m, err := ParseColumnMap("id=well, seq=3, desc=notes, meta=plate id")
+		require.NoError(t, err)
+		records, err := Parse(strings.NewReader(plate), Options{Columns: m})
+		require.NoError(t, err)
+		r := records[0]
+		assert.Equal(t, "A1", r.ID)
+		assert.Equal(t, "IIIIII", r.Quality, "still recognized by header")
+		assert.Equal(t, "first, good plate_id=P_1", r.FullDescription())
+	})
+
+	t.Run("no header", func(t *testing.T) {
+		records, err := Parse(strings.NewReader("ACGT\tx\nGGCC\ty\n"), Options{Comma: '\t', NoHeader: true})
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, "GGCC", records[1].Sequence)
+		assert.Equal(t, []Metadata{{"col2", "y"}}, records[1].Metadata)
+
+		records, err = Parse(strings.NewReader("ACGT\tx\n"), Options{Comma: '\t', NoHeader: true, Columns: ColumnMap{ID: "2", Sequence: "1"}})
+		require.NoError(t, err)
+		assert.Equal(t, "x", records[0].ID)
+		assert.Empty(t, records[0].Metadata)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			input string
+			opts  Options
+		}{
+			"no sequence column":  {"a,b\n1,2\n", Options{}},
+			"unknown column":      {plate, Options{Columns: ColumnMap{Sequence: "bases"}}},
+			"out of range":        {plate, Options{Columns: ColumnMap{Sequence: "9"}}},
+			"mapped twice":        {plate, Options{Columns: ColumnMap{ID: "name", Sequence: "name"}}},
+			"name without header": {"ACGT,x\n", Options{NoHeader: true, Columns: ColumnMap{Sequence: "seq"}}},
+			"empty sequence":      {"id,seq\na,\n", Options{}},
+			"ragged row":          {"id,seq\na,ACGT,extra\n", Options{}},
+		} {
+			_, err := Parse(strings.NewReader(tc.input), tc.opts)
+			assert.Error(t, err, name)
+		}
+		_, err := ParseColumnMap("sequence")
+		assert.Error(t, err)
+		_, err = ParseColumnMap("bases=2")
+		assert.Error(t, err)
+	})
+}
+
+func TestComma(t *testing.T) {
+	assert.Equal(t, '\t', CommaFor("plate.TSV"))
+	assert.Equal(t, ',', CommaFor("plate.csv"))
+	for name, want := range map[string]rune{"auto": 0, "csv": ',', "tsv": '\t', "semicolon": ';', "|": '|'} {
+		got, err := ParseComma(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, name)
+	}
+	_, err := ParseComma("pipes")
+	assert.Error(t, err)
+}
@@ -0,0 +1,454 @@
+// Package tooldef describes command-line tools for workflow engines, as
+// CWL CommandLineTool documents or WDL tasks, so that the commands of a
+// tool can be used in institutional workflows without hand-written
+// wrappers.
+//
+// A tool is described from the flag set of its command: each flag
+// becomes an input of the matching type, passed to the command only
+// when given (or with its default). String flags are typed from their
+// usage text, which in this code base names what the flag holds:
+//
+//   - "Write ... to this file", "Output ..." and "(default: stdout)"
+//     flags name a file the command writes, which becomes an output too;
+//   - flags about a file, directory or a sequence format (FASTA, SAM,
+//     VCF, ...) are input files or directories, staged by the engine;
+//   - other flags are plain strings.
+//
+// Standard output is always captured as an output. Positional arguments
+// cannot be told from a flag set and are declared by the caller.
+//
+// Comparison with Aria:
+//
+//	Aria would derive the descriptor from the typed signature of the
+//	command, where a file is a Path and an output an out parameter:
+//	  @derive(ToolDescriptor)
+//	  fn gc(file: Path, window: Int = 100, out o: Path?) with FileSystem
+//
+//	Go flags are untyped strings, so the kind of a string flag is
+//	inferred from its usage text.
+package tooldef
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Type is the type of an input of a tool, named as in CWL.
+type Type string
+
+// Types of inputs.
+const (
+	Boolean   Type = "boolean"
+	Int       Type = "int"
+	Long      Type = "long"
+	Float     Type = "double"
+	String    Type = "string"
+	File      Type = "File"
+	Directory Type = "Directory"
+)
+
+// Input is a flag of a tool.
+type Input struct {
+	// Flag is the name of the flag, without the dash.
+	Flag string
+	Type Type
+	// Default is the value of the flag when not given, as the flag
+	// package prints it, or "" for none.
+	Default string
+	Doc     string
+	// Output is set for flags naming a file or directory the tool
+	// writes. Their input is the name to write to.
+	Output bool
+}
+
+// Argument is a positional argument of a tool, following the flags.
+type Argument struct {
+	Name string
+	Type Type
+	// Many arguments may be given, and Optional ones left out.
+	Many     bool
+	Optional bool
+	Doc      string
+}
+
+// Tool describes a command.
+type Tool struct {
+	// Command is the command line before the arguments, such as
+	// ["bioflow", "gc"].
+	Command     []string
+	Description string
+	Inputs      []Input
+	Arguments   []Argument
+	// Docker, if set, is the image to run the tool in.
+	Docker string
+}
+
+// ID returns the identifier of the tool: its command with the words
+// joined by underscores, such as bioflow_kmer_matrix.
+func (t *Tool) ID() string {
+	return identifier(strings.Join(t.Command, "_"))
+}
+
+var (
+	// outputUsage matches the usage of flags naming files written.
+	outputUsage = regexp.MustCompile(`^(Also )?[Ww]rite |^Output (file|FASTA)|\(default:? stdout`)
+	// directoryUsage matches the usage of flags naming directories.
+	directoryUsage = regexp.MustCompile(`(?i)\bdirectory\b`)
+	// fileUsage matches the usage of flags naming files: the word file,
+	// or a format of file not followed by a word it qualifies, as in
+	// "VCF sample".
+	fileUsage = regexp.MustCompile(`(?i)\bfile\b|\b(FASTA|FASTQ|SAM|BAM|VCF|BED|Newick|alignment|table|sheet|panel)\b($|[,(]| \(| (of|file|for|to)\b)`)
+	// usageAside matches what follows the subject of a usage: a list of
+	// choices after a colon, or an alternative.
+	usageAside = regexp.MustCompile(`: .*|\binstead of .*`)
+)
+
+// Inputs returns the inputs of the flags of a flag set, in lexical
+// order. Flags documented as "Same as -name" are aliases and left out.
+//
+// Aria equivalent:
+//
+//	fn inputs(flags: FlagSet) -> List<Input>
+//	  ensures result.all(|i| flags.lookup(i.flag).is_some())
+//	  ensures result.is_sorted_by(|i| i.flag)
+func Inputs(fs *flag.FlagSet) []Input {
+	var inputs []Input
+	fs.VisitAll(func(f *flag.Flag) {
+		if strings.HasPrefix(f.Usage, "Same as -") {
+			return
+		}
+		in := Input{Flag: f.Name, Type: String, Default: f.DefValue, Doc: f.Usage}
+		var value interface{}
+		if g, ok := f.Value.(flag.Getter); ok {
+			value = g.Get()
+		}
+		switch value.(type) {
+		case bool:
+			in.Type = Boolean
+		case int:
+			in.Type = Int
+		case int64, uint, uint64:
+			in.Type = Long
+		case float64:
+			in.Type = Float
+		case time.Duration:
+		default:
+			if in.Default != "" || strings.Contains(in.Flag, "format") {
+				break
+			}
+			in.Output = f.Name == "o" || outputUsage.MatchString(f.Usage)
+			switch {
+			case directoryUsage.MatchString(f.Usage):
+				in.Type = Directory
+			case in.Output:
+			case fileUsage.MatchString(usageAside.ReplaceAllString(f.Usage, "")):
+				in.Type = File
+			}
+		}
+		if in.Type == Boolean && in.Default == "false" || in.Type != String && in.Default == "0" {
+			in.Default = ""
+		}
+		inputs = append(inputs, in)
+	})
+	return inputs
+}
+
+// WriteCWL writes tools as a CWL v1.2 document: a CommandLineTool, or a
+// $graph of them when there are several.
+//
+// Boolean flags that default to true are turned off by a no_ input, as
+// CWL only passes a flag for a true boolean.
+//
+// Aria equivalent:
+//
+//	fn write_cwl(w: Writer, tools: List<Tool>) -> Result<(), IoError> with IO
+//	  requires tools.len() > 0
+func WriteCWL(w io.Writer, tools ...*Tool) error {
+	if len(tools) == 0 {
+		return fmt.Errorf("no tools to describe")
+	}
+	var b bytes.Buffer
+	b.WriteString("#!/usr/bin/env cwl-runner\ncwlVersion: v1.2\n")
+	if len(tools) == 1 {
+		writeCWLTool(&b, tools[0], "")
+	} else {
+		b.WriteString("$graph:\n")
+		for _, t := range tools {
+			var tb bytes.Buffer
+			writeCWLTool(&tb, t, "  ")
+			text := tb.String()
+			b.WriteString("- " + text[2:])
+		}
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// writeCWLTool writes the CommandLineTool of a tool, indented.
+func writeCWLTool(b *bytes.Buffer, t *Tool, indent string) {
+	line := func(depth int, format string, args ...interface{}) {
+		b.WriteString(indent + strings.Repeat("  ", depth) + fmt.Sprintf(format, args...) + "\n")
+	}
+	line(0, "class: CommandLineTool")
+	line(0, "id: %s", t.ID())
+	if t.Description != "" {
+		line(0, "doc: %s", quote(t.Description))
+	}
+	words := make([]string, len(t.Command))
+	for i, word := range t.Command {
+		words[i] = quote(word)
+	}
+	line(0, "baseCommand: [%s]", strings.Join(words, ", "))
+	if t.Docker != "" {
+		line(0, "hints:")
+		line(1, "DockerRequirement:")
+		line(2, "dockerPull: %s", quote(t.Docker))
+	}
+
+	line(0, "inputs:")
+	for _, in := range t.Inputs {
+		id, typ := identifier(in.Flag), string(in.Type)
+		if in.Output {
+			typ = string(String)
+		}
+		if in.Type == Boolean && in.Default == "true" {
+			line(1, "no_%s:", id)
+			line(2, "type: boolean")
+			line(2, "default: false")
+			line(2, "doc: %s", quote("Turn off: "+in.Doc))
+			line(2, "inputBinding:")
+			line(3, "prefix: %s", quote("-"+in.Flag+"=false"))
+			continue
+		}
+		line(1, "%s:", id)
+		if in.Default == "" {
+			typ += "?"
+		}
+		line(2, "type: %s", quote(typ))
+		if in.Default != "" {
+			line(2, "default: %s", cwlValue(in.Type, in.Default))
+		}
+		line(2, "doc: %s", quote(in.Doc))
+		line(2, "inputBinding:")
+		line(3, "prefix: %s", quote("-"+in.Flag))
+	}
+	for _, arg := range t.Arguments {
+		typ := string(arg.Type)
+		if arg.Many {
+			typ += "[]"
+		}
+		if arg.Optional {
+			typ += "?"
+		}
+		line(1, "%s:", identifier(arg.Name))
+		line(2, "type: %s", quote(typ))
+		line(2, "doc: %s", quote(arg.Doc))
+		line(2, "inputBinding:")
+		line(3, "position: 1")
+	}
+
+	line(0, "outputs:")
+	line(1, "standard_output:")
+	line(2, "type: stdout")
+	for _, in := range t.Inputs {
+		if !in.Output {
+			continue
+		}
+		typ := File
+		if in.Type == Directory {
+			typ = Directory
+		}
+		line(1, "%s_output:", identifier(in.Flag))
+		line(2, "type: %s", quote(string(typ)+"?"))
+		line(2, "outputBinding:")
+		line(3, "glob: $(inputs.%s)", identifier(in.Flag))
+	}
+	line(0, "stdout: %s", quote(t.ID()+".out"))
+}
+
+// cwlValue formats a default value in YAML.
+func cwlValue(typ Type, value string) string {
+	switch typ {
+	case Boolean, Int, Long, Float:
+		return value
+	}
+	return quote(value)
+}
+
+// wdlKeywords are the reserved words of WDL, which inputs are renamed
+// from.
+var wdlKeywords = map[string]bool{
+	"alias": true, "as": true, "call": true, "command": true, "else": true,
+	"false": true, "if": true, "import": true, "in": true, "input": true,
+	"meta": true, "null": true, "object": true, "output": true,
+	"parameter_meta": true, "runtime": true, "scatter": true, "struct": true,
+	"task": true, "then": true, "true": true, "version": true, "workflow": true,
+}
+
+// WriteWDL writes tools as tasks of a WDL 1.0 document.
+//
+// WDL 1.0 has no directories: directory inputs are strings, and
+// directories written are not collected as outputs.
+//
+// Aria equivalent:
+//
+//	fn write_wdl(w: Writer, tools: List<Tool>) -> Result<(), IoError> with IO
+//	  requires tools.len() > 0
+func WriteWDL(w io.Writer, tools ...*Tool) error {
+	if len(tools) == 0 {
+		return fmt.Errorf("no tools to describe")
+	}
+	var b bytes.Buffer
+	b.WriteString("version 1.0\n")
+	for _, t := range tools {
+		b.WriteString("\n")
+		writeWDLTask(&b, t)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// writeWDLTask writes the task of a tool.
+func writeWDLTask(b *bytes.Buffer, t *Tool) {
+	line := func(depth int, format string, args ...interface{}) {
+		b.WriteString(strings.Repeat("  ", depth) + fmt.Sprintf(format, args...) + "\n")
+	}
+	name := func(s string) string {
+		id := identifier(s)
+		if wdlKeywords[id] {
+			id += "_"
+		}
+		return id
+	}
+
+	line(0, "task %s {", t.ID())
+	if t.Description != "" {
+		line(1, "meta {")
+		line(2, "description: %s", quote(t.Description))
+		line(1, "}")
+		b.WriteString("\n")
+	}
+
+	line(1, "input {")
+	for _, in := range t.Inputs {
+		typ := wdlType(in.Type)
+		if in.Output {
+			typ = "String"
+		}
+		switch {
+		case in.Type == Boolean && in.Default == "":
+			line(2, "Boolean %s = false", name(in.Flag))
+		case in.Default == "":
+			line(2, "%s? %s", typ, name(in.Flag))
+		default:
+			line(2, "%s %s = %s", typ, name(in.Flag), cwlValue(in.Type, in.Default))
+		}
+	}
+	for _, arg := range t.Arguments {
+		typ := wdlType(arg.Type)
+		switch {
+		case arg.Many && arg.Optional:
+			line(2, "Array[%s] %s = []", typ, name(arg.Name))
+		case arg.Many:
+			line(2, "Array[%s]+ %s", typ, name(arg.Name))
+		case arg.Optional:
+			line(2, "%s? %s", typ, name(arg.Name))
+		default:
+			line(2, "%s %s", typ, name(arg.Name))
+		}
+	}
+	line(1, "}")
+	b.WriteString("\n")
+
+	// Optional values are passed with the flag, as concatenating an
+	// undefined value leaves the placeholder empty.
+	line(1, "command <<<")
+	parts := []string{strings.Join(t.Command, " ")}
+	for _, in := range t.Inputs {
+		id, flag := name(in.Flag), "-"+in.Flag
+		switch {
+		case in.Type == Boolean && in.Default == "true":
+			parts = append(parts, fmt.Sprintf(`~{true="" false="%s=false" %s}`, flag, id))
+		case in.Type == Boolean:
+			parts = append(parts, fmt.Sprintf(`~{true="%s" false="" %s}`, flag, id))
+		case in.Default == "":
+			parts = append(parts, fmt.Sprintf(`~{"%s '" + %s + "'"}`, flag, id))
+		default:
+			parts = append(parts, fmt.Sprintf(`%s '~{%s}'`, flag, id))
+		}
+	}
+	for _, arg := range t.Arguments {
+		if arg.Many {
+			parts = append(parts, fmt.Sprintf(`~{sep=" " %s}`, name(arg.Name)))
+		} else {
+			parts = append(parts, fmt.Sprintf(`~{%s}`, name(arg.Name)))
+		}
+	}
+	line(2, "%s", strings.Join(parts, " \\\n      "))
+	line(1, ">>>")
+	b.WriteString("\n")
+
+	line(1, "output {")
+	line(2, "File standard_output = stdout()")
+	for _, in := range t.Inputs {
+		if in.Output && in.Type != Directory {
+			line(2, "File? %s_output = %s", identifier(in.Flag), name(in.Flag))
+		}
+	}
+	line(1, "}")
+
+	if t.Docker != "" {
+		b.WriteString("\n")
+		line(1, "runtime {")
+		line(2, "docker: %s", quote(t.Docker))
+		line(1, "}")
+	}
+
+	b.WriteString("\n")
+	line(1, "parameter_meta {")
+	for _, in := range t.Inputs {
+		line(2, "%s: %s", name(in.Flag), quote(in.Doc))
+	}
+	for _, arg := range t.Arguments {
+		line(2, "%s: %s", name(arg.Name), quote(arg.Doc))
+	}
+	line(1, "}")
+	line(0, "}")
+}
+
+// wdlType returns the WDL type of an input type.
+func wdlType(typ Type) string {
+	switch typ {
+	case Boolean:
+		return "Boolean"
+	case Int, Long:
+		return "Int"
+	case Float:
+		return "Float"
+	case File:
+		return "File"
+	}
+	return "String"
+}
+
+// identifier turns a flag or command name into an identifier valid in
+// CWL parameter references and WDL.
+func identifier(name string) string {
+	return strings.NewReplacer("-", "_", ".", "_").Replace(name)
+}
+
+// quote quotes a string for YAML and WDL, whose double-quoted strings
+// accept JSON escapes.
+func quote(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,127 @@
+package tooldef
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTool() *Tool {
+	fs := flag.NewFlagSet("kmer-matrix", flag.ContinueOnError)
+	fs.String("file", "", "FASTA file to analyze")
+	fs.String("ref", "", "Optional reference FASTA for genome fraction")
+	fs.String("sample", "", "VCF sample whose genotypes -het reads")
+	fs.String("matrix", "", "Align proteins with a substitution matrix: BLOSUM62 or an NCBI-format file")
+	fs.String("group", "", "Summarize groups by a description regex instead of writing FASTA")
+	fs.String("format", "", "Alignment format (default: from file extension)")
+	fs.String("o", "", "Output file (default: stdout)")
+	out := fs.String("csv", "", "Write the matrix to this CSV file")
+	fs.StringVar(out, "out", "", "Same as -o")
+	fs.String("png-dir", "", "Write one PNG image per sequence to this directory")
+	fs.String("norm", "relative", "Normalization: raw or relative")
+	fs.Int("k", 0, "K-mer length")
+	fs.Int64("seed", 42, "Random seed")
+	fs.Float64("min", 0.5, "Lowest fraction")
+	fs.Bool("json", false, "Print JSON")
+	fs.Bool("canonical", true, "Strand-independent hashing")
+	fs.Duration("interval", 10*time.Second, "Log progress at this interval")
+	return &Tool{
+		Command:     []string{"bioflow", "kmer-matrix"},
+		Description: "Normalized k-mer feature matrices",
+		Inputs:      Inputs(fs),
+		Arguments:   []Argument{{Name: "in", Type: File, Many: true, Doc: "More files"}},
+		Docker:      "bioflow:1.0",
+	}
+}
+
+func TestInputs(t *testing.T) {
+	tool := testTool()
+	got := map[string]Input{}
+	var names []string
+	for _, in := range tool.Inputs {
+		got[in.Flag] = in
+		names = append(names, in.Flag)
+	}
+	assert.NotContains(t, names, "out", "aliases are left out")
+	assert.Equal(t, "bioflow_kmer_matrix", tool.ID())
+
+	for flag, want := range map[string]Type{
+		"file": File, "ref": File, "sample": String, "matrix": String, "group": String,
+		"format": String, "o": String, "csv": String, "png-dir": Directory, "norm": String,
+		"k": Int, "seed": Long, "min": Float, "json": Boolean, "canonical": Boolean, "interval": String,
+	} {
+		assert.Equal(t, want, got[flag].Type, flag)
+	}
+	for _, flag := range []string{"o", "csv", "png-dir"} {
+		assert.True(t, got[flag].Output, flag)
+	}
+	assert.False(t, got["file"].Output)
+
+	assert.Equal(t, "", got["k"].Default, "zero values are no default")
+	assert.Equal(t, "", got["json"].Default)
+	assert.Equal(t, "true", got["canonical"].Default)
+	assert.Equal(t, "10s", got["interval"].Default)
+	assert.Equal(t, "0.5", got["min"].Default)
+}
+
+func TestWriteCWL(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, WriteCWL(&b, testTool()))
+	cwl := b.String()
+	assert.True(t, strings.HasPrefix(cwl, "#!/usr/bin/env cwl-runner\ncwlVersion: v1.2\nclass: CommandLineTool\nid: bioflow_kmer_matrix\n"), cwl)
+	assert.Contains(t, cwl, `baseCommand: ["bioflow", "kmer-matrix"]`)
+	assert.Contains(t, cwl, "    dockerPull: \"bioflow:1.0\"\n")
+	assert.Contains(t, cwl, "  file:\n    type: \"File?\"\n    doc: \"FASTA file to analyze\"\n    inputBinding:\n      prefix: \"-file\"\n")
+	assert.Contains(t, cwl, "  min:\n    type: \"double\"\n    default: 0.5\n")
+	assert.Contains(t, cwl, "  no_canonical:\n    type: boolean\n    default: false\n", "true booleans are turned off")
+	assert.Contains(t, cwl, "      prefix: \"-canonical=false\"\n")
+	assert.Contains(t, cwl, "  in:\n    type: \"File[]\"\n    doc: \"More files\"\n    inputBinding:\n      position: 1\n")
+	assert.Contains(t, cwl, "  png_dir_output:\n    type: \"Directory?\"\n    outputBinding:\n      glob: $(inputs.png_dir)\n")
+	assert.Contains(t, cwl, "  o_output:\n    type: \"File?\"\n")
+	assert.Contains(t, cwl, "stdout: \"bioflow_kmer_matrix.out\"\n")
+
+	b.Reset()
+	other := testTool()
+	other.Command = []string{"bioflow", "gc"}
+	require.NoError(t, WriteCWL(&b, testTool(), other))
+	graph := b.String()
+	assert.Contains(t, graph, "$graph:\n- class: CommandLineTool\n  id: bioflow_kmer_matrix\n")
+	assert.Contains(t, graph, "- class: CommandLineTool\n  id: bioflow_gc\n")
+	assert.Contains(t, graph, "\n  inputs:\n    no_canonical:\n", "tools are indented in the graph")
+
+	assert.Error(t, WriteCWL(&b))
+}
+
+func TestWriteWDL(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, WriteWDL(&b, testTool()))
+	wdl := b.String()
+	assert.True(t, strings.HasPrefix(wdl, "version 1.0\n\ntask bioflow_kmer_matrix {\n"), wdl)
+	for _, want := range []string{
+		"    File? file\n",
+		"    Boolean canonical = true\n",
+		"    Boolean json = false\n",
+		"    Float min = 0.5\n",
+		"    Int? k\n",
+		"    String norm = \"relative\"\n",
+		"    String? o\n",
+		"    Array[File]+ in_\n",
+		`~{true="" false="-canonical=false" canonical}`,
+		`~{true="-json" false="" json}`,
+		`~{"-file '" + file + "'"}`,
+		`-norm '~{norm}'`,
+		`~{sep=" " in_}`,
+		"    File? o_output = o\n",
+		"    docker: \"bioflow:1.0\"\n",
+		"    in_: \"More files\"\n",
+	} {
+		assert.Contains(t, wdl, want)
+	}
+	assert.NotContains(t, wdl, "png_dir_output", "directories are not outputs in WDL 1.0")
+	assert.True(t, strings.HasSuffix(wdl, "  }\n}\n"))
+}
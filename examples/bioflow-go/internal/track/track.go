@@ -0,0 +1,326 @@
+// Package track provides numeric tracks over sequence windows and their
+// serialization.
+//
+// A Track is a named series of values, one per window of a sequence.
+// Several tracks computed over the same windows can be written together
+// as TSV columns or as a JSON document, and any track can be written as
+// bedGraph or fixedStep/variableStep WIG for genome browsers.
+//
+// Comparison with Aria:
+//
+//	Aria can require aligned windows when writing tracks side by side:
+//	  fn write_tsv(tracks: [Track]) -> Result<(), IOError>
+//	    requires tracks.all(|t| t.windows == tracks[0].windows)
+//
+//	Go checks the windows at runtime and returns an error.
+package track
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Point is the value of a track over one window. Start and End are
+// 0-based, half-open sequence coordinates.
+type Point struct {
+	Start int     `json:"start"`
+	End   int     `json:"end"`
+	Value float64 `json:"value"`
+}
+
+// Track is a named series of window values for one sequence.
+type Track struct {
+	Name       string  `json:"name"`
+	SequenceID string  `json:"sequence_id"`
+	Points     []Point `json:"points"`
+}
+
+// New creates an empty track.
+func New(name, sequenceID string) *Track {
+	return &Track{Name: name, SequenceID: sequenceID, Points: make([]Point, 0)}
+}
+
+// Add appends a window value.
+func (t *Track) Add(start, end int, value float64) {
+	t.Points = append(t.Points, Point{Start: start, End: end, Value: value})
+}
+
+// Len returns the number of windows.
+func (t *Track) Len() int {
+	return len(t.Points)
+}
+
+// Mean returns the mean value over all windows.
+func (t *Track) Mean() float64 {
+	if len(t.Points) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, p := range t.Points {
+		total += p.Value
+	}
+	return total / float64(len(t.Points))
+}
+
+// sameWindows checks that all tracks share the windows of the first.
+func sameWindows(tracks []*Track) error {
+	if len(tracks) == 0 {
+		return fmt.Errorf("no tracks to write")
+	}
+	first := tracks[0]
+	for _, t := range tracks[1:] {
+		if t.SequenceID != first.SequenceID || len(t.Points) != len(first.Points) {
+			return fmt.Errorf("track %s doesn't share windows with track %s", t.Name, first.Name)
+		}
+		for i, p := range t.Points {
+			if p.Start != first.Points[i].Start || p.End != first.Points[i].End {
+				return fmt.Errorf("track %s doesn't share windows with track %s", t.Name, first.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// WriteTSV writes tracks that share windows as TSV columns:
+// sequence, start, end, then one column per track. The header line is
+// written when header is set.
+func WriteTSV(w io.Writer, header bool, tracks ...*Track) error {
+	if err := sameWindows(tracks); err != nil {
+		return err
+	}
+
+	if header {
+		line := "sequence\tstart\tend"
+		for _, t := range tracks {
+			line += "\t" + t.Name
+		}
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+
+	buf := make([]byte, 0, 64)
+	for i, p := range tracks[0].Points {
+		buf = buf[:0]
+		buf = append(buf, tracks[0].SequenceID...)
+		buf = append(buf, '\t')
+		buf = strconv.AppendInt(buf, int64(p.Start), 10)
+		buf = append(buf, '\t')
+		buf = strconv.AppendInt(buf, int64(p.End), 10)
+		for _, t := range tracks {
+			buf = append(buf, '\t')
+			buf = strconv.AppendFloat(buf, t.Points[i].Value, 'f', 4, 64)
+		}
+		buf = append(buf, '\n')
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes tracks as an indented JSON array.
+func WriteJSON(w io.Writer, tracks ...*Track) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(tracks)
+}
+
+// WriteBedGraph writes tracks (typically one per chromosome, sharing a
+// name) as bedGraph with 0-based, half-open intervals. A browser "track"
+// line precedes each run of tracks with the same name. bedGraph intervals
+// must not overlap, so a window that overlaps the next one (a step smaller
+// than the window) is clipped at the next window's start.
+func WriteBedGraph(w io.Writer, tracks ...*Track) error {
+	if len(tracks) == 0 {
+		return fmt.Errorf("no tracks to write")
+	}
+	buf := make([]byte, 0, 64)
+	for ti, t := range tracks {
+		if ti == 0 || t.Name != tracks[ti-1].Name {
+			if _, err := fmt.Fprintf(w, "track type=bedGraph name=%q\n", t.Name); err != nil {
+				return err
+			}
+		}
+		for i, p := range t.Points {
+			end := p.End
+			if i+1 < len(t.Points) && t.Points[i+1].Start > p.Start && t.Points[i+1].Start < end {
+				end = t.Points[i+1].Start
+			}
+			buf = buf[:0]
+			buf = append(buf, t.SequenceID...)
+			buf = append(buf, '\t')
+			buf = strconv.AppendInt(buf, int64(p.Start), 10)
+			buf = append(buf, '\t')
+			buf = strconv.AppendInt(buf, int64(end), 10)
+			buf = append(buf, '\t')
+			buf = strconv.AppendFloat(buf, p.Value, 'g', 6, 64)
+			buf = append(buf, '\n')
+			if _, err := w.Write(buf); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteWIG writes tracks as fixedStep WIG (1-based), with a "track" line
+// before each run of tracks with the same name. Consecutive points of
+// equal width and a constant step share one fixedStep block; a new block
+// starts wherever the width or step changes.
+func WriteWIG(w io.Writer, tracks ...*Track) error {
+	if len(tracks) == 0 {
+		return fmt.Errorf("no tracks to write")
+	}
+	buf := make([]byte, 0, 32)
+	for ti, t := range tracks {
+		if ti == 0 || t.Name != tracks[ti-1].Name {
+			if _, err := fmt.Fprintf(w, "track type=wiggle_0 name=%q\n", t.Name); err != nil {
+				return err
+			}
+		}
+		step := 0
+		for i, p := range t.Points {
+			width := p.End - p.Start
+			if i == 0 || width != t.Points[i-1].End-t.Points[i-1].Start || p.Start-t.Points[i-1].Start != step {
+				step = width
+				if i+1 < len(t.Points) {
+					next := t.Points[i+1]
+					if next.End-next.Start == width && next.Start > p.Start {
+						step = next.Start - p.Start
+					}
+				}
+				if _, err := fmt.Fprintf(w, "fixedStep chrom=%s start=%d step=%d span=%d\n", t.SequenceID, p.Start+1, step, width); err != nil {
+					return err
+				}
+			}
+			buf = strconv.AppendFloat(buf[:0], p.Value, 'g', 6, 64)
+			buf = append(buf, '\n')
+			if _, err := w.Write(buf); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteVariableStepWIG writes tracks as variableStep WIG (1-based), which
+// suits sparse tracks such as coverage runs. Each point is written at its
+// start; a new block starts wherever the point width (span) changes.
+func WriteVariableStepWIG(w io.Writer, tracks ...*Track) error {
+	if len(tracks) == 0 {
+		return fmt.Errorf("no tracks to write")
+	}
+	buf := make([]byte, 0, 32)
+	for ti, t := range tracks {
+		if ti == 0 || t.Name != tracks[ti-1].Name {
+			if _, err := fmt.Fprintf(w, "track type=wiggle_0 name=%q\n", t.Name); err != nil {
+				return err
+			}
+		}
+		for i, p := range t.Points {
+			width := p.End - p.Start
+			if i == 0 || width != t.Points[i-1].End-t.Points[i-1].Start {
+				if _, err := fmt.Fprintf(w, "variableStep chrom=%s span=%d\n", t.SequenceID, width); err != nil {
+					return err
+				}
+			}
+			buf = strconv.AppendInt(buf[:0], int64(p.Start+1), 10)
+			buf = append(buf, '\t')
+			buf = strconv.AppendFloat(buf, p.Value, 'g', 6, 64)
+			buf = append(buf, '\n')
+			if _, err := w.Write(buf); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Format is a track output format.
+type Format int
+
+const (
+	// FormatTSV writes tracks sharing windows as columns.
+	FormatTSV Format = iota
+	// FormatJSON writes tracks as a JSON array.
+	FormatJSON
+	// FormatBedGraph writes bedGraph intervals.
+	FormatBedGraph
+	// FormatWIG writes fixedStep WIG.
+	FormatWIG
+	// FormatVariableStepWIG writes variableStep WIG.
+	FormatVariableStepWIG
+)
+
+// ParseFormat parses a format name: tsv, json, bedgraph, wig (or
+// fixedstep) and variablestep.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "tsv":
+		return FormatTSV, nil
+	case "json":
+		return FormatJSON, nil
+	case "bedgraph":
+		return FormatBedGraph, nil
+	case "wig", "fixedstep":
+		return FormatWIG, nil
+	case "variablestep":
+		return FormatVariableStepWIG, nil
+	default:
+		return FormatTSV, fmt.Errorf("unknown track format %q (want tsv, json, bedgraph, wig or variablestep)", name)
+	}
+}
+
+// Write writes tracks in a format. For TSV, tracks are grouped by
+// sequence and each group becomes one block of columns, with a single
+// header line. For bedGraph and WIG, tracks are grouped by name so that
+// each name gets one browser track.
+func Write(w io.Writer, format Format, tracks ...*Track) error {
+	switch format {
+	case FormatJSON:
+		return WriteJSON(w, tracks...)
+	case FormatTSV:
+		for i, group := range groupTracks(tracks, func(t *Track) string { return t.SequenceID }) {
+			if err := WriteTSV(w, i == 0, group...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var ordered []*Track
+	for _, group := range groupTracks(tracks, func(t *Track) string { return t.Name }) {
+		ordered = append(ordered, group...)
+	}
+	switch format {
+	case FormatBedGraph:
+		return WriteBedGraph(w, ordered...)
+	case FormatWIG:
+		return WriteWIG(w, ordered...)
+	case FormatVariableStepWIG:
+		return WriteVariableStepWIG(w, ordered...)
+	default:
+		return fmt.Errorf("unknown track format %d", format)
+	}
+}
+
+// groupTracks groups tracks by a key, keeping the order of first
+// appearance.
+func groupTracks(tracks []*Track, key func(*Track) string) [][]*Track {
+	index := make(map[string]int)
+	groups := make([][]*Track, 0)
+	for _, t := range tracks {
+		i, ok := index[key(t)]
+		if !ok {
+			i = len(groups)
+			index[key(t)] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], t)
+	}
+	return groups
+}
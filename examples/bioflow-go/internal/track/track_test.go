@@ -0,0 +1,113 @@
+package track
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrack(t *testing.T) {
+	tr := New("gc", "chr1")
+	tr.Add(0, 10, 0.5)
+	tr.Add(10, 20, 0.25)
+
+	assert.Equal(t, 2, tr.Len())
+	assert.InDelta(t, 0.375, tr.Mean(), 1e-9)
+	assert.InDelta(t, 0.0, New("empty", "x").Mean(), 1e-9)
+}
+
+func TestWriteTSV(t *testing.T) {
+	a := New("entropy", "chr1")
+	b := New("complexity", "chr1")
+	a.Add(0, 10, 1.5)
+	b.Add(0, 10, 0.75)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteTSV(&buf, true, a, b))
+	assert.Equal(t, "sequence\tstart\tend\tentropy\tcomplexity\nchr1\t0\t10\t1.5000\t0.7500\n", buf.String())
+
+	b.Add(10, 20, 1)
+	assert.Error(t, WriteTSV(&buf, false, a, b))
+	assert.Error(t, WriteTSV(&buf, false))
+}
+
+func TestWriteJSON(t *testing.T) {
+	a := New("entropy", "chr1")
+	a.Add(0, 10, 1.5)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, a))
+
+	var decoded []Track
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Len(t, decoded, 1)
+	assert.Equal(t, "entropy", decoded[0].Name)
+	assert.Equal(t, Point{Start: 0, End: 10, Value: 1.5}, decoded[0].Points[0])
+}
+
+func TestWriteBedGraphAndWIG(t *testing.T) {
+	tr := New("map", "chr1")
+	tr.Add(0, 5, 1)
+	tr.Add(5, 10, 0.5)
+	tr.Add(10, 12, 1)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteBedGraph(&buf, tr))
+	assert.Equal(t, "track type=bedGraph name=\"map\"\nchr1\t0\t5\t1\nchr1\t5\t10\t0.5\nchr1\t10\t12\t1\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteWIG(&buf, tr))
+	assert.Equal(t, "track type=wiggle_0 name=\"map\"\n"+
+		"fixedStep chrom=chr1 start=1 step=5 span=5\n1\n0.5\n"+
+		"fixedStep chrom=chr1 start=11 step=2 span=2\n1\n", buf.String())
+}
+
+func TestWriteOverlappingWindows(t *testing.T) {
+	tr := New("gc", "chr1")
+	tr.Add(0, 10, 0.5)
+	tr.Add(5, 15, 0.25)
+	tr.Add(10, 20, 0.75)
+	tr.Add(15, 18, 1)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteBedGraph(&buf, tr))
+	assert.Equal(t, "track type=bedGraph name=\"gc\"\n"+
+		"chr1\t0\t5\t0.5\nchr1\t5\t10\t0.25\nchr1\t10\t15\t0.75\nchr1\t15\t18\t1\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteWIG(&buf, tr))
+	assert.Equal(t, "track type=wiggle_0 name=\"gc\"\n"+
+		"fixedStep chrom=chr1 start=1 step=5 span=10\n0.5\n0.25\n0.75\n"+
+		"fixedStep chrom=chr1 start=16 step=3 span=3\n1\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteVariableStepWIG(&buf, tr))
+	assert.Equal(t, "track type=wiggle_0 name=\"gc\"\n"+
+		"variableStep chrom=chr1 span=10\n1\t0.5\n6\t0.25\n11\t0.75\n"+
+		"variableStep chrom=chr1 span=3\n16\t1\n", buf.String())
+}
+
+func TestWriteFormat(t *testing.T) {
+	a1, b1 := New("a", "chr1"), New("b", "chr1")
+	a2, b2 := New("a", "chr2"), New("b", "chr2")
+	for _, tr := range []*Track{a1, b1, a2, b2} {
+		tr.Add(0, 2, 1)
+	}
+
+	f, err := ParseFormat("bedGraph")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, f, a1, b1, a2, b2))
+	assert.Equal(t, "track type=bedGraph name=\"a\"\nchr1\t0\t2\t1\nchr2\t0\t2\t1\n"+
+		"track type=bedGraph name=\"b\"\nchr1\t0\t2\t1\nchr2\t0\t2\t1\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, FormatTSV, a1, b1, a2, b2))
+	assert.Equal(t, "sequence\tstart\tend\ta\tb\nchr1\t0\t2\t1.0000\t1.0000\nchr2\t0\t2\t1.0000\t1.0000\n", buf.String())
+
+	_, err = ParseFormat("bigwig")
+	assert.Error(t, err)
+}
@@ -0,0 +1,201 @@
+package tree
+
+import (
+	"fmt"
+	"math"
+)
+
+// validateMatrix checks that a distance matrix is square and matches names.
+func validateMatrix(names []string, dist [][]float64) error {
+	if len(names) < 2 {
+		return fmt.Errorf("at least two taxa are required")
+	}
+	if len(dist) != len(names) {
+		return fmt.Errorf("distance matrix has %d rows, expected %d", len(dist), len(names))
+	}
+	for i, row := range dist {
+		if len(row) != len(names) {
+			return fmt.Errorf("distance matrix row %d has %d columns, expected %d", i, len(row), len(names))
+		}
+	}
+	return nil
+}
+
+// UPGMA builds a rooted ultrametric tree by average-linkage clustering.
+//
+// Aria equivalent:
+//
+//	fn upgma(names: [String], dist: [[Float]]) -> Result<Tree, TreeError>
+//	  requires names.len() >= 2
+//	  requires dist.len() == names.len()
+func UPGMA(names []string, dist [][]float64) (*Tree, error) {
+	if err := validateMatrix(names, dist); err != nil {
+		return nil, err
+	}
+
+	type cluster struct {
+		node   *Node
+		size   int
+		height float64
+	}
+
+	n := len(names)
+	clusters := make([]*cluster, n)
+	d := make([][]float64, n)
+	for i := range names {
+		clusters[i] = &cluster{node: &Node{Name: names[i]}, size: 1}
+		d[i] = make([]float64, n)
+		copy(d[i], dist[i])
+	}
+
+	active := n
+	for active > 1 {
+		// Find the closest pair of active clusters.
+		bi, bj := -1, -1
+		best := math.Inf(1)
+		for i := 0; i < n; i++ {
+			if clusters[i] == nil {
+				continue
+			}
+			for j := i + 1; j < n; j++ {
+				if clusters[j] != nil && d[i][j] < best {
+					best, bi, bj = d[i][j], i, j
+				}
+			}
+		}
+
+		ci, cj := clusters[bi], clusters[bj]
+		height := best / 2
+		parent := &Node{}
+		ci.node.Length, ci.node.HasLength = height-ci.height, true
+		cj.node.Length, cj.node.HasLength = height-cj.height, true
+		parent.AddChild(ci.node)
+		parent.AddChild(cj.node)
+
+		// Merge j into i with size-weighted average distances.
+		for k := 0; k < n; k++ {
+			if clusters[k] == nil || k == bi || k == bj {
+				continue
+			}
+			avg := (d[bi][k]*float64(ci.size) + d[bj][k]*float64(cj.size)) / float64(ci.size+cj.size)
+			d[bi][k], d[k][bi] = avg, avg
+		}
+		clusters[bi] = &cluster{node: parent, size: ci.size + cj.size, height: height}
+		clusters[bj] = nil
+		active--
+	}
+
+	for _, c := range clusters {
+		if c != nil {
+			return &Tree{Root: c.node}, nil
+		}
+	}
+	return nil, fmt.Errorf("clustering produced no tree")
+}
+
+// NeighborJoining builds an unrooted tree (returned with a trifurcating
+// root) using the Saitou-Nei neighbor-joining algorithm. Negative branch
+// lengths are clamped to zero.
+//
+// Aria equivalent:
+//
+//	fn neighbor_joining(names: [String], dist: [[Float]]) -> Result<Tree, TreeError>
+//	  requires names.len() >= 2
+//	  requires dist.len() == names.len()
+func NeighborJoining(names []string, dist [][]float64) (*Tree, error) {
+	if err := validateMatrix(names, dist); err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*Node, len(names))
+	d := make([][]float64, len(names))
+	for i := range names {
+		nodes[i] = &Node{Name: names[i]}
+		d[i] = make([]float64, len(names))
+		copy(d[i], dist[i])
+	}
+
+	setLength := func(n *Node, l float64) {
+		if l < 0 {
+			l = 0
+		}
+		n.Length, n.HasLength = l, true
+	}
+
+	for len(nodes) > 2 {
+		r := len(nodes)
+		rowSums := make([]float64, r)
+		for i := 0; i < r; i++ {
+			for j := 0; j < r; j++ {
+				rowSums[i] += d[i][j]
+			}
+		}
+
+		// Minimize the Q criterion.
+		bi, bj := 0, 1
+		best := math.Inf(1)
+		for i := 0; i < r; i++ {
+			for j := i + 1; j < r; j++ {
+				q := float64(r-2)*d[i][j] - rowSums[i] - rowSums[j]
+				if q < best {
+					best, bi, bj = q, i, j
+				}
+			}
+		}
+
+		parent := &Node{}
+		li := d[bi][bj]/2 + (rowSums[bi]-rowSums[bj])/(2*float64(r-2))
+		setLength(nodes[bi], li)
+		setLength(nodes[bj], d[bi][bj]-li)
+		parent.AddChild(nodes[bi])
+		parent.AddChild(nodes[bj])
+
+		// Build the reduced matrix with the new node in place of bi.
+		newNodes := make([]*Node, 0, r-1)
+		newIdx := make([]int, 0, r-1)
+		for k := 0; k < r; k++ {
+			if k != bi && k != bj {
+				newNodes = append(newNodes, nodes[k])
+				newIdx = append(newIdx, k)
+			}
+		}
+		newNodes = append(newNodes, parent)
+
+		m := len(newNodes)
+		nd := make([][]float64, m)
+		for a := range nd {
+			nd[a] = make([]float64, m)
+		}
+		for a := 0; a < m-1; a++ {
+			for b := 0; b < m-1; b++ {
+				nd[a][b] = d[newIdx[a]][newIdx[b]]
+			}
+			k := newIdx[a]
+			du := (d[bi][k] + d[bj][k] - d[bi][bj]) / 2
+			nd[a][m-1], nd[m-1][a] = du, du
+		}
+
+		nodes, d = newNodes, nd
+	}
+
+	// Join the final two nodes: attach the first to the last (internal) node.
+	root := nodes[1]
+	if nodes[1].IsLeaf() && !nodes[0].IsLeaf() {
+		root = nodes[0]
+		nodes[0], nodes[1] = nodes[1], nodes[0]
+	}
+	if root.IsLeaf() {
+		// Only two taxa: create an explicit root.
+		root = &Node{}
+		setLength(nodes[0], d[0][1]/2)
+		setLength(nodes[1], d[0][1]/2)
+		root.AddChild(nodes[0])
+		root.AddChild(nodes[1])
+		return &Tree{Root: root}, nil
+	}
+	setLength(nodes[0], d[0][1])
+	root.AddChild(nodes[0])
+	root.Length, root.HasLength = 0, false
+
+	return &Tree{Root: root}, nil
+}
@@ -0,0 +1,209 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseNewick parses a single tree in Newick format.
+//
+// Quoted labels ('...'), bracketed comments ([...]) and optional branch
+// lengths are supported. Unquoted underscores are kept as-is.
+//
+// Aria equivalent:
+//
+//	fn parse_newick(input: String) -> Result<Tree, TreeError>
+//	  requires input.trim().ends_with(";")
+func ParseNewick(input string) (*Tree, error) {
+	p := &newickParser{input: input}
+	p.skipSpace()
+
+	root, err := p.parseSubtree()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if !p.consume(';') {
+		return nil, p.errorf("expected ';'")
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected trailing input")
+	}
+
+	return &Tree{Root: root}, nil
+}
+
+// ReadNewick reads a single Newick tree from r.
+func ReadNewick(r io.Reader) (*Tree, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading tree: %w", err)
+	}
+	return ParseNewick(string(data))
+}
+
+type newickParser struct {
+	input string
+	pos   int
+}
+
+func (p *newickParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("newick: position %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *newickParser) peek() byte {
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *newickParser) consume(c byte) bool {
+	if p.peek() == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skipSpace skips whitespace and [bracketed] comments.
+func (p *newickParser) skipSpace() {
+	for p.pos < len(p.input) {
+		switch p.input[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		case '[':
+			end := strings.IndexByte(p.input[p.pos:], ']')
+			if end < 0 {
+				p.pos = len(p.input)
+				return
+			}
+			p.pos += end + 1
+		default:
+			return
+		}
+	}
+}
+
+func (p *newickParser) parseSubtree() (*Node, error) {
+	node := &Node{}
+
+	if p.consume('(') {
+		for {
+			p.skipSpace()
+			child, err := p.parseSubtree()
+			if err != nil {
+				return nil, err
+			}
+			node.AddChild(child)
+
+			p.skipSpace()
+			if p.consume(',') {
+				continue
+			}
+			if p.consume(')') {
+				break
+			}
+			return nil, p.errorf("expected ',' or ')'")
+		}
+	}
+
+	p.skipSpace()
+	name, err := p.parseLabel()
+	if err != nil {
+		return nil, err
+	}
+	node.Name = name
+
+	p.skipSpace()
+	if p.consume(':') {
+		p.skipSpace()
+		start := p.pos
+		for p.pos < len(p.input) && strings.IndexByte("0123456789.eE+-", p.input[p.pos]) >= 0 {
+			p.pos++
+		}
+		length, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			return nil, p.errorf("invalid branch length %q", p.input[start:p.pos])
+		}
+		node.Length = length
+		node.HasLength = true
+	}
+
+	return node, nil
+}
+
+func (p *newickParser) parseLabel() (string, error) {
+	if p.consume('\'') {
+		var sb strings.Builder
+		for {
+			if p.pos >= len(p.input) {
+				return "", p.errorf("unterminated quoted label")
+			}
+			c := p.input[p.pos]
+			p.pos++
+			if c == '\'' {
+				// '' is an escaped quote inside a quoted label
+				if p.consume('\'') {
+					sb.WriteByte('\'')
+					continue
+				}
+				return sb.String(), nil
+			}
+			sb.WriteByte(c)
+		}
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && strings.IndexByte("(),:;[ \t\n\r", p.input[p.pos]) < 0 {
+		p.pos++
+	}
+	return p.input[start:p.pos], nil
+}
+
+// Newick serializes the tree in Newick format, terminated by ';'.
+func (t *Tree) Newick() string {
+	var sb strings.Builder
+	if t.Root != nil {
+		writeNode(&sb, t.Root)
+	}
+	sb.WriteByte(';')
+	return sb.String()
+}
+
+// WriteNewick writes the tree in Newick format followed by a newline.
+func (t *Tree) WriteNewick(w io.Writer) error {
+	_, err := io.WriteString(w, t.Newick()+"\n")
+	return err
+}
+
+func writeNode(sb *strings.Builder, n *Node) {
+	if !n.IsLeaf() {
+		sb.WriteByte('(')
+		for i, c := range n.Children {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeNode(sb, c)
+		}
+		sb.WriteByte(')')
+	}
+
+	sb.WriteString(quoteLabel(n.Name))
+	if n.HasLength {
+		sb.WriteByte(':')
+		sb.WriteString(strconv.FormatFloat(n.Length, 'g', -1, 64))
+	}
+}
+
+// quoteLabel quotes a label if it contains Newick metacharacters.
+func quoteLabel(name string) string {
+	if !strings.ContainsAny(name, "(),:;[]' \t") {
+		return name
+	}
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
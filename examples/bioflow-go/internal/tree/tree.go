@@ -0,0 +1,322 @@
+// Package tree provides phylogenetic trees with Newick I/O and manipulation.
+//
+// Trees are rooted, n-ary and mutable: re-rooting and pruning restructure
+// the tree in place. Branch lengths are optional; a node without a branch
+// length is serialized without the ":length" suffix.
+//
+// Comparison with Aria:
+//
+//	Aria models parent links as weak references checked by the borrow
+//	checker:
+//	  struct Node
+//	    parent: Weak<Node>
+//	    children: [Node]
+//
+//	Go uses plain pointers and keeps them consistent at runtime.
+package tree
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Node represents a node in a phylogenetic tree.
+type Node struct {
+	Name      string
+	Length    float64 // Length of the branch leading to this node
+	HasLength bool
+	Children  []*Node
+	Parent    *Node
+}
+
+// IsLeaf reports whether the node has no children.
+func (n *Node) IsLeaf() bool {
+	return len(n.Children) == 0
+}
+
+// AddChild attaches child to n.
+func (n *Node) AddChild(child *Node) {
+	child.Parent = n
+	n.Children = append(n.Children, child)
+}
+
+// removeChild detaches child from n.
+func (n *Node) removeChild(child *Node) {
+	for i, c := range n.Children {
+		if c == child {
+			n.Children = append(n.Children[:i], n.Children[i+1:]...)
+			child.Parent = nil
+			return
+		}
+	}
+}
+
+// replaceChild swaps old for replacement in n's children, keeping order.
+func (n *Node) replaceChild(old, replacement *Node) {
+	for i, c := range n.Children {
+		if c == old {
+			n.Children[i] = replacement
+			replacement.Parent = n
+			old.Parent = nil
+			return
+		}
+	}
+}
+
+// Tree represents a rooted phylogenetic tree.
+type Tree struct {
+	Root *Node
+}
+
+// PreOrder visits every node, parents before children.
+func (t *Tree) PreOrder(visit func(*Node)) {
+	var walk func(*Node)
+	walk = func(n *Node) {
+		visit(n)
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	if t.Root != nil {
+		walk(t.Root)
+	}
+}
+
+// PostOrder visits every node, children before parents.
+func (t *Tree) PostOrder(visit func(*Node)) {
+	var walk func(*Node)
+	walk = func(n *Node) {
+		for _, c := range n.Children {
+			walk(c)
+		}
+		visit(n)
+	}
+	if t.Root != nil {
+		walk(t.Root)
+	}
+}
+
+// Leaves returns the leaf nodes in left-to-right order.
+func (t *Tree) Leaves() []*Node {
+	leaves := make([]*Node, 0)
+	t.PreOrder(func(n *Node) {
+		if n.IsLeaf() {
+			leaves = append(leaves, n)
+		}
+	})
+	return leaves
+}
+
+// LeafNames returns the names of all leaves in left-to-right order.
+func (t *Tree) LeafNames() []string {
+	leaves := t.Leaves()
+	names := make([]string, len(leaves))
+	for i, l := range leaves {
+		names[i] = l.Name
+	}
+	return names
+}
+
+// Find returns the first node with the given name.
+func (t *Tree) Find(name string) (*Node, bool) {
+	var found *Node
+	t.PreOrder(func(n *Node) {
+		if found == nil && n.Name == name {
+			found = n
+		}
+	})
+	return found, found != nil
+}
+
+// Reroot places the root on the branch leading to target, splitting that
+// branch in half. The former root is removed if it becomes unary.
+//
+// Aria equivalent:
+//
+//	fn reroot(mut self, target: Node)
+//	  requires self.contains(target)
+//	  ensures self.leaf_names().sorted() == old(self.leaf_names().sorted())
+func (t *Tree) Reroot(target *Node) error {
+	if target == nil {
+		return fmt.Errorf("target node is nil")
+	}
+	if target == t.Root {
+		return nil
+	}
+
+	oldRoot := t.Root
+	parent := target.Parent
+	half := target.Length / 2
+
+	newRoot := &Node{}
+	parent.removeChild(target)
+	target.Length -= half
+	newRoot.AddChild(target)
+
+	// Reverse the parent links on the path from target's parent to the old root.
+	prev, prevLen, prevHas := newRoot, half, target.HasLength
+	cur := parent
+	for cur != nil {
+		next := cur.Parent
+		curLen, curHas := cur.Length, cur.HasLength
+		if next != nil {
+			next.removeChild(cur)
+		}
+		cur.Length, cur.HasLength = prevLen, prevHas
+		prev.AddChild(cur)
+
+		prev, prevLen, prevHas = cur, curLen, curHas
+		cur = next
+	}
+
+	t.Root = newRoot
+	collapseUnary(oldRoot)
+	return nil
+}
+
+// RerootAt re-roots on the branch leading to the named node.
+func (t *Tree) RerootAt(name string) error {
+	node, ok := t.Find(name)
+	if !ok {
+		return fmt.Errorf("node %q not found", name)
+	}
+	return t.Reroot(node)
+}
+
+// collapseUnary removes n if it has a single child and a parent, joining
+// the two branches.
+func collapseUnary(n *Node) {
+	if len(n.Children) != 1 || n.Parent == nil {
+		return
+	}
+	child := n.Children[0]
+	child.Length += n.Length
+	child.HasLength = child.HasLength || n.HasLength
+	n.Parent.replaceChild(n, child)
+}
+
+// Prune removes the named leaves, collapsing internal nodes that are left
+// with a single child.
+func (t *Tree) Prune(names ...string) error {
+	remove := make(map[string]bool, len(names))
+	for _, n := range names {
+		remove[n] = true
+	}
+
+	for _, leaf := range t.Leaves() {
+		if !remove[leaf.Name] {
+			continue
+		}
+		if leaf == t.Root {
+			return fmt.Errorf("cannot prune the only node")
+		}
+
+		parent := leaf.Parent
+		parent.removeChild(leaf)
+		delete(remove, leaf.Name)
+
+		// Drop internal nodes that became leaves, then collapse unary ones.
+		for parent != nil && parent.IsLeaf() && parent != t.Root {
+			gp := parent.Parent
+			gp.removeChild(parent)
+			parent = gp
+		}
+		if parent == nil {
+			continue
+		}
+		if parent == t.Root {
+			if len(parent.Children) == 1 {
+				child := parent.Children[0]
+				parent.removeChild(child)
+				child.Length, child.HasLength = 0, false
+				t.Root = child
+			}
+		} else {
+			collapseUnary(parent)
+		}
+	}
+
+	if len(remove) > 0 {
+		missing := make([]string, 0, len(remove))
+		for n := range remove {
+			missing = append(missing, n)
+		}
+		sort.Strings(missing)
+		return fmt.Errorf("leaves not found: %v", missing)
+	}
+	return nil
+}
+
+// depth returns the summed branch length from the root to n.
+func depth(n *Node) float64 {
+	d := 0.0
+	for cur := n; cur.Parent != nil; cur = cur.Parent {
+		d += cur.Length
+	}
+	return d
+}
+
+// lowestCommonAncestor returns the deepest node that is an ancestor of both.
+func lowestCommonAncestor(a, b *Node) *Node {
+	ancestors := make(map[*Node]bool)
+	for cur := a; cur != nil; cur = cur.Parent {
+		ancestors[cur] = true
+	}
+	for cur := b; cur != nil; cur = cur.Parent {
+		if ancestors[cur] {
+			return cur
+		}
+	}
+	return nil
+}
+
+// PatristicDistance returns the sum of branch lengths on the path between
+// two nodes.
+//
+// Aria equivalent:
+//
+//	fn patristic_distance(self, a: String, b: String) -> Float
+//	  ensures result >= 0.0
+func (t *Tree) PatristicDistance(a, b string) (float64, error) {
+	na, ok := t.Find(a)
+	if !ok {
+		return 0, fmt.Errorf("node %q not found", a)
+	}
+	nb, ok := t.Find(b)
+	if !ok {
+		return 0, fmt.Errorf("node %q not found", b)
+	}
+
+	lca := lowestCommonAncestor(na, nb)
+	return depth(na) + depth(nb) - 2*depth(lca), nil
+}
+
+// DistanceMatrix returns pairwise patristic distances between all leaves,
+// in the order of LeafNames.
+func (t *Tree) DistanceMatrix() [][]float64 {
+	leaves := t.Leaves()
+	n := len(leaves)
+
+	depths := make([]float64, n)
+	for i, l := range leaves {
+		depths[i] = depth(l)
+	}
+
+	matrix := make([][]float64, n)
+	for i := range matrix {
+		matrix[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			lca := lowestCommonAncestor(leaves[i], leaves[j])
+			d := depths[i] + depths[j] - 2*depth(lca)
+			matrix[i][j] = d
+			matrix[j][i] = d
+		}
+	}
+	return matrix
+}
+
+func (t *Tree) String() string {
+	return t.Newick()
+}
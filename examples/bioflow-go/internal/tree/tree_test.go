@@ -0,0 +1,144 @@
+package tree
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNewick(t *testing.T) {
+	tr, err := ParseNewick("((A:0.1,B:0.2)AB:0.3,C:0.4);")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"A", "B", "C"}, tr.LeafNames())
+
+	ab, ok := tr.Find("AB")
+	require.True(t, ok)
+	assert.InDelta(t, 0.3, ab.Length, 1e-9)
+	assert.Len(t, ab.Children, 2)
+}
+
+func TestParseNewickQuotedAndComments(t *testing.T) {
+	tr, err := ParseNewick("('Homo sapiens':1,[comment]'it''s':2);")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Homo sapiens", "it's"}, tr.LeafNames())
+	assert.Equal(t, "('Homo sapiens':1,'it''s':2);", tr.Newick())
+}
+
+func TestParseNewickErrors(t *testing.T) {
+	inputs := []string{
+		"(A,B",
+		"(A,B)",
+		"(A:x,B);",
+		"(A,B);extra",
+	}
+	for _, in := range inputs {
+		_, err := ParseNewick(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestNewickRoundTrip(t *testing.T) {
+	in := "((A:1,B:2)X:0.5,(C:3,D:4):1.5);"
+	tr, err := ParseNewick(in)
+	require.NoError(t, err)
+	assert.Equal(t, in, tr.Newick())
+}
+
+func TestPatristicDistance(t *testing.T) {
+	tr, err := ParseNewick("((A:1,B:2):3,C:4);")
+	require.NoError(t, err)
+
+	d, err := tr.PatristicDistance("A", "B")
+	require.NoError(t, err)
+	assert.InDelta(t, 3.0, d, 1e-9)
+
+	d, err = tr.PatristicDistance("A", "C")
+	require.NoError(t, err)
+	assert.InDelta(t, 8.0, d, 1e-9)
+
+	_, err = tr.PatristicDistance("A", "Z")
+	require.Error(t, err)
+}
+
+func TestReroot(t *testing.T) {
+	tr, err := ParseNewick("((A:1,B:2):3,C:4);")
+	require.NoError(t, err)
+	before := tr.DistanceMatrix()
+
+	require.NoError(t, tr.RerootAt("A"))
+
+	// A hangs directly off the new root with half its branch.
+	assert.Contains(t, tr.Root.Children, mustFind(t, tr, "A"))
+	assert.InDelta(t, 0.5, mustFind(t, tr, "A").Length, 1e-9)
+
+	// Patristic distances are unchanged by re-rooting.
+	names := tr.LeafNames()
+	sort.Strings(names)
+	assert.Equal(t, []string{"A", "B", "C"}, names)
+	for _, pair := range [][2]string{{"A", "B"}, {"A", "C"}, {"B", "C"}} {
+		d, err := tr.PatristicDistance(pair[0], pair[1])
+		require.NoError(t, err)
+		orig := map[[2]string]float64{{"A", "B"}: before[0][1], {"A", "C"}: before[0][2], {"B", "C"}: before[1][2]}
+		assert.InDelta(t, orig[pair], d, 1e-9, pair)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tr, err := ParseNewick("((A:1,B:2):3,C:4);")
+	require.NoError(t, err)
+
+	require.NoError(t, tr.Prune("B"))
+	assert.Equal(t, "(A:4,C:4);", tr.Newick())
+
+	require.Error(t, tr.Prune("Z"))
+}
+
+func TestUPGMA(t *testing.T) {
+	names := []string{"A", "B", "C"}
+	dist := [][]float64{
+		{0, 2, 6},
+		{2, 0, 6},
+		{6, 6, 0},
+	}
+	tr, err := UPGMA(names, dist)
+	require.NoError(t, err)
+
+	d, err := tr.PatristicDistance("A", "B")
+	require.NoError(t, err)
+	assert.InDelta(t, 2.0, d, 1e-9)
+
+	d, err = tr.PatristicDistance("A", "C")
+	require.NoError(t, err)
+	assert.InDelta(t, 6.0, d, 1e-9)
+}
+
+func TestNeighborJoiningAdditive(t *testing.T) {
+	// Additive distances from the tree ((A:2,B:3):4,C:5,D:6)
+	names := []string{"A", "B", "C", "D"}
+	dist := [][]float64{
+		{0, 5, 11, 12},
+		{5, 0, 12, 13},
+		{11, 12, 0, 11},
+		{12, 13, 11, 0},
+	}
+	tr, err := NeighborJoining(names, dist)
+	require.NoError(t, err)
+
+	got := tr.DistanceMatrix()
+	leaves := tr.LeafNames()
+	index := map[string]int{"A": 0, "B": 1, "C": 2, "D": 3}
+	for i, a := range leaves {
+		for j, b := range leaves {
+			assert.InDelta(t, dist[index[a]][index[b]], got[i][j], 1e-9, a+"-"+b)
+		}
+	}
+}
+
+func mustFind(t *testing.T, tr *Tree, name string) *Node {
+	n, ok := tr.Find(name)
+	require.True(t, ok)
+	return n
+}
@@ -0,0 +1,480 @@
+// Package validate checks FASTA and FASTQ files for problems that break
+// or silently corrupt downstream analysis: duplicate record IDs, invalid
+// characters, empty records, inconsistent line wrapping, sequence and
+// quality length mismatches and truncated final records.
+//
+// Unlike the parsers, which stop at the first error, the validators read
+// the whole input and report every problem with its line number, so a
+// file can be fixed in one pass.
+//
+// Comparison with Aria:
+//
+//	Aria can state what a valid record is as a type invariant:
+//	  struct FastqRecord
+//	    invariant self.id.len() > 0
+//	    invariant self.sequence.len() == self.quality.len()
+//
+//	Go checks the invariants line by line and collects violations as
+//	issues instead of failing on the first one.
+package validate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// DefaultMaxIssues is the number of issues reported in detail; further
+// issues are only counted.
+const DefaultMaxIssues = 100
+
+// Format is a sequence file format.
+type Format string
+
+// Supported formats.
+const (
+	FormatAuto  Format = "auto"
+	FormatFASTA Format = "fasta"
+	FormatFASTQ Format = "fastq"
+)
+
+// Alphabet is the set of accepted sequence characters. Matching is case
+// insensitive.
+type Alphabet string
+
+// Supported alphabets.
+const (
+	// AlphabetDNA accepts A, C, G, T and N, as the sequence parsers do.
+	AlphabetDNA Alphabet = "dna"
+	// AlphabetRNA accepts A, C, G, U and N.
+	AlphabetRNA Alphabet = "rna"
+	// AlphabetIUPAC accepts all IUPAC nucleotide codes.
+	AlphabetIUPAC Alphabet = "iupac"
+)
+
+// valid reports whether c belongs to the alphabet.
+func (a Alphabet) valid(c byte) bool {
+	if c >= 'a' && c <= 'z' {
+		c -= 'a' - 'A'
+	}
+	switch a {
+	case AlphabetRNA:
+		return sequence.IsValidRNABase(rune(c))
+	case AlphabetIUPAC:
+		return sequence.IsIUPACBase(c)
+	default:
+		return sequence.IsValidDNABase(rune(c))
+	}
+}
+
+// Severity classifies issues: errors fail validation, warnings do not.
+type Severity string
+
+// Issue severities.
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+)
+
+// Issue kinds.
+const (
+	DuplicateID          = "duplicate_id"
+	MissingID            = "missing_id"
+	MalformedRecord      = "malformed_record"
+	InvalidCharacter     = "invalid_character"
+	InvalidQuality       = "invalid_quality"
+	EmptyRecord          = "empty_record"
+	InconsistentWrapping = "inconsistent_wrapping"
+	LengthMismatch       = "length_mismatch"
+	HeaderMismatch       = "header_mismatch"
+	TruncatedRecord      = "truncated_record"
+	BlankLine            = "blank_line"
+	MissingFinalNewline  = "missing_final_newline"
+)
+
+// Issue is one problem found in the input. Line is 1-based.
+type Issue struct {
+	Line     int      `json:"line"`
+	Record   string   `json:"record,omitempty"`
+	Kind     string   `json:"kind"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (i Issue) String() string {
+	if i.Record != "" {
+		return fmt.Sprintf("line %d: %s: %s [%s] (%s)", i.Line, i.Severity, i.Message, i.Kind, i.Record)
+	}
+	return fmt.Sprintf("line %d: %s: %s [%s]", i.Line, i.Severity, i.Message, i.Kind)
+}
+
+// Options configures validation.
+type Options struct {
+	Format   Format
+	Alphabet Alphabet
+	// MaxIssues limits the issues kept in the report; zero means
+	// DefaultMaxIssues and a negative value keeps all.
+	MaxIssues int
+}
+
+// DefaultOptions detects the format and accepts the DNA alphabet.
+func DefaultOptions() Options {
+	return Options{Format: FormatAuto, Alphabet: AlphabetDNA, MaxIssues: DefaultMaxIssues}
+}
+
+// Report is the result of validating one input.
+type Report struct {
+	Format   Format         `json:"format"`
+	Records  int            `json:"records"`
+	Lines    int            `json:"lines"`
+	Errors   int            `json:"errors"`
+	Warnings int            `json:"warnings"`
+	Counts   map[string]int `json:"counts"`
+	Issues   []Issue        `json:"issues"`
+	// Truncated is set when more issues were found than kept.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Passed reports whether no errors were found.
+func (r *Report) Passed() bool {
+	return r.Errors == 0
+}
+
+// WriteText writes the issues followed by a PASS or FAIL summary.
+func (r *Report) WriteText(w io.Writer) error {
+	for _, i := range r.Issues {
+		if _, err := fmt.Fprintln(w, i); err != nil {
+			return err
+		}
+	}
+	if r.Truncated {
+		if _, err := fmt.Fprintf(w, "... %d more issues not shown\n", r.Errors+r.Warnings-len(r.Issues)); err != nil {
+			return err
+		}
+	}
+	status := "PASS"
+	if !r.Passed() {
+		status = "FAIL"
+	}
+	_, err := fmt.Fprintf(w, "%s: %d %s records, %d errors, %d warnings\n", status, r.Records, strings.ToUpper(string(r.Format)), r.Errors, r.Warnings)
+	return err
+}
+
+// validator holds the state shared by the FASTA and FASTQ checks.
+type validator struct {
+	r      *bufio.Reader
+	opts   Options
+	report *Report
+	// line counts the lines read; cur is the number of the line last
+	// returned by next, which differs after unread.
+	line, cur  int
+	ids        map[string]int
+	lastLine   bool // the last line read had no newline
+	unreadFrom int
+	unreadText []string
+}
+
+// newValidator creates a validator reading from r.
+func newValidator(r io.Reader, format Format, opts Options) *validator {
+	if opts.MaxIssues == 0 {
+		opts.MaxIssues = DefaultMaxIssues
+	}
+	if opts.Alphabet == "" {
+		opts.Alphabet = AlphabetDNA
+	}
+	return &validator{
+		r:      bufio.NewReaderSize(r, 64*1024),
+		opts:   opts,
+		report: &Report{Format: format, Counts: make(map[string]int), Issues: make([]Issue, 0)},
+		ids:    make(map[string]int),
+	}
+}
+
+// next returns the next line without its line ending; ok is false at the
+// end of the input.
+func (v *validator) next() (string, bool, error) {
+	if len(v.unreadText) > 0 {
+		s := v.unreadText[0]
+		v.unreadText = v.unreadText[1:]
+		v.cur = v.unreadFrom
+		v.unreadFrom++
+		return s, true, nil
+	}
+	s, err := v.r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", false, fmt.Errorf("reading input: %w", err)
+	}
+	if len(s) == 0 {
+		return "", false, nil
+	}
+	v.line++
+	v.cur = v.line
+	v.lastLine = !strings.HasSuffix(s, "\n")
+	return strings.TrimRight(s, "\r\n"), true, nil
+}
+
+// unread returns lines, starting at line number from, to be read again.
+func (v *validator) unread(from int, lines ...string) {
+	v.unreadFrom, v.unreadText = from, lines
+}
+
+// add records an issue.
+func (v *validator) add(line int, record, kind string, severity Severity, format string, args ...interface{}) {
+	if severity == Error {
+		v.report.Errors++
+	} else {
+		v.report.Warnings++
+	}
+	v.report.Counts[kind]++
+	if v.opts.MaxIssues > 0 && len(v.report.Issues) >= v.opts.MaxIssues {
+		v.report.Truncated = true
+		return
+	}
+	v.report.Issues = append(v.report.Issues, Issue{
+		Line: line, Record: record, Kind: kind, Severity: severity, Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// checkID reports missing and duplicate IDs of a header line. The ID is
+// the text before the first space, as in the parsers.
+func (v *validator) checkID(header string, line int) string {
+	id := strings.SplitN(header, " ", 2)[0]
+	if id == "" {
+		v.add(line, "", MissingID, Error, "record has no ID")
+		return ""
+	}
+	if first, ok := v.ids[id]; ok {
+		v.add(line, id, DuplicateID, Error, "duplicate ID, first seen on line %d", first)
+	} else {
+		v.ids[id] = line
+	}
+	return id
+}
+
+// checkBases reports the first invalid character of a sequence line.
+func (v *validator) checkBases(bases, id string, line int) {
+	for i := 0; i < len(bases); i++ {
+		if !v.opts.Alphabet.valid(bases[i]) {
+			v.add(line, id, InvalidCharacter, Error, "invalid %s character %q at column %d", v.opts.Alphabet, bases[i], i+1)
+			return
+		}
+	}
+}
+
+// finish adds end-of-input checks and returns the report.
+func (v *validator) finish() *Report {
+	if v.lastLine {
+		v.add(v.line, "", MissingFinalNewline, Warning, "last line has no newline; the file may be truncated")
+	}
+	v.report.Lines = v.line
+	return v.report
+}
+
+// Validate checks an input in the given format; FormatAuto detects it
+// from the first non-blank character. The error is only set for read
+// failures and undetectable formats; problems in the data are issues.
+//
+// Aria equivalent:
+//
+//	fn validate(r: Reader, options: Options) -> Result<Report, IOError>
+//	  ensures result.passed() == (result.errors == 0)
+//	  ensures result.issues.len() <= options.max_issues or options.max_issues < 0
+func Validate(r io.Reader, opts Options) (*Report, error) {
+	switch opts.Alphabet {
+	case "", AlphabetDNA, AlphabetRNA, AlphabetIUPAC:
+	default:
+		return nil, fmt.Errorf("unknown alphabet %q, use dna, rna or iupac", opts.Alphabet)
+	}
+	format := opts.Format
+	if format == "" || format == FormatAuto {
+		br := bufio.NewReader(r)
+		detected, err := detect(br)
+		if err != nil {
+			return nil, err
+		}
+		format, r = detected, br
+	}
+	switch format {
+	case FormatFASTA:
+		return FASTA(r, opts)
+	case FormatFASTQ:
+		return FASTQ(r, opts)
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// detect peeks at the first non-blank character.
+func detect(br *bufio.Reader) (Format, error) {
+	for n := 1; ; n++ {
+		b, err := br.Peek(n)
+		if len(b) < n {
+			if err == io.EOF || err == bufio.ErrBufferFull {
+				return "", fmt.Errorf("cannot detect format of an empty input")
+			}
+			return "", fmt.Errorf("reading input: %w", err)
+		}
+		switch b[n-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '>':
+			return FormatFASTA, nil
+		case '@':
+			return FormatFASTQ, nil
+		}
+		return "", fmt.Errorf("cannot detect format: input starts with %q", b[n-1])
+	}
+}
+
+// FASTA validates FASTA input. Line wrapping is consistent when every
+// line of a record but the last has the same length and the last is not
+// longer; records wrapped at a different width than the first wrapped
+// record are also reported. Both are warnings.
+func FASTA(r io.Reader, opts Options) (*Report, error) {
+	v := newValidator(r, FormatFASTA, opts)
+	fileWidth := 0
+	var id string
+	header, seqLines, width, wrapLine := 0, 0, 0, 0
+	lastLen, lastLine, blank := 0, 0, 0
+
+	endRecord := func() {
+		if header <= 0 {
+			return
+		}
+		if seqLines == 0 {
+			v.add(header, id, EmptyRecord, Error, "record has no sequence")
+		}
+		if seqLines > 1 && wrapLine == 0 && lastLen > width {
+			wrapLine = lastLine
+		}
+		if wrapLine > 0 {
+			v.add(wrapLine, id, InconsistentWrapping, Warning, "line length differs from the record's wrap width %d", width)
+		} else if seqLines > 1 {
+			if fileWidth == 0 {
+				fileWidth = width
+			} else if width != fileWidth {
+				v.add(header+1, id, InconsistentWrapping, Warning, "record wrapped at %d, file at %d", width, fileWidth)
+			}
+		}
+	}
+
+	for {
+		line, ok, err := v.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		if strings.TrimSpace(line) == "" {
+			blank = v.line
+			continue
+		}
+		if line[0] == '>' {
+			endRecord()
+			v.report.Records++
+			header, seqLines, width, wrapLine, blank = v.line, 0, 0, 0, 0
+			id = v.checkID(line[1:], v.line)
+			continue
+		}
+		if header == 0 {
+			v.add(v.line, "", MalformedRecord, Error, "sequence data before the first '>' header")
+			header = -1
+		}
+		if header < 0 {
+			continue
+		}
+		if blank > 0 {
+			v.add(blank, id, BlankLine, Warning, "blank line inside a record")
+			blank = 0
+		}
+		v.checkBases(line, id, v.line)
+		if seqLines == 0 {
+			width = len(line)
+		} else if lastLen != width && wrapLine == 0 {
+			// The previous line was not the last, so it must be full.
+			wrapLine = lastLine
+		}
+		lastLen, lastLine = len(line), v.line
+		seqLines++
+	}
+	endRecord()
+	return v.finish(), nil
+}
+
+// FASTQ validates four-line FASTQ input with Phred+33 or Phred+64
+// qualities. After a malformed record, validation resumes at the next
+// line starting with '@'.
+func FASTQ(r io.Reader, opts Options) (*Report, error) {
+	v := newValidator(r, FormatFASTQ, opts)
+	for {
+		line, ok, err := v.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] != '@' {
+			v.add(v.cur, "", MalformedRecord, Error, "expected a header starting with '@'")
+			// Skip to the next plausible header.
+			for ok && !strings.HasPrefix(line, "@") {
+				if line, ok, err = v.next(); err != nil {
+					return nil, err
+				}
+			}
+			if !ok {
+				break
+			}
+		}
+
+		headerLine := v.cur
+		v.report.Records++
+		id := v.checkID(line[1:], headerLine)
+
+		var fields [3]string
+		for i := range fields {
+			if fields[i], ok, err = v.next(); err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+		}
+		if !ok {
+			v.add(v.line, id, TruncatedRecord, Error, "record starting on line %d ends early", headerLine)
+			break
+		}
+		bases, plus, qual := fields[0], fields[1], fields[2]
+
+		if !strings.HasPrefix(plus, "+") {
+			v.add(headerLine+2, id, MalformedRecord, Error, "expected a '+' separator line")
+			// The separator may be missing because the record is cut
+			// short; resume from the line after the sequence.
+			v.unread(headerLine+2, plus, qual)
+			continue
+		}
+		if rest := plus[1:]; rest != "" && rest != strings.TrimSpace(line[1:]) && rest != id {
+			v.add(headerLine+2, id, HeaderMismatch, Warning, "'+' line does not repeat the header")
+		}
+		if bases == "" {
+			v.add(headerLine+1, id, EmptyRecord, Error, "record has no sequence")
+		}
+		v.checkBases(bases, id, headerLine+1)
+		if len(qual) != len(bases) {
+			v.add(headerLine+3, id, LengthMismatch, Error, "quality length %d does not match sequence length %d", len(qual), len(bases))
+		}
+		for i := 0; i < len(qual); i++ {
+			if qual[i] < '!' || qual[i] > '~' {
+				v.add(headerLine+3, id, InvalidQuality, Error, "invalid quality character %q at column %d", qual[i], i+1)
+				break
+			}
+		}
+	}
+	return v.finish(), nil
+}
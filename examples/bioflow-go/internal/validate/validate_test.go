@@ -0,0 +1,100 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// kinds returns the kind and line of each issue.
+func kinds(r *Report) []string {
+	out := make([]string, len(r.Issues))
+	for i, issue := range r.Issues {
+		out[i] = fmt.Sprintf("%s@%d", issue.Kind, issue.Line)
+	}
+	return out
+}
+
+func TestFASTA(t *testing.T) {
+	valid := ">s1 first\nACGTACGT\nACGT\n>s2\nacgtn\n"
+	r, err := Validate(strings.NewReader(valid), DefaultOptions())
+	require.NoError(t, err)
+	assert.Equal(t, FormatFASTA, r.Format)
+	assert.Equal(t, 2, r.Records)
+	assert.True(t, r.Passed())
+	assert.Empty(t, r.Issues)
+
+	bad := ">s1\nACGT\nACGTAC\nAC\n>s1\n>\nACXT\n>s3\nACGTACGT\n\nAC"
+	r, err = FASTA(strings.NewReader(bad), DefaultOptions())
+	require.NoError(t, err)
+	assert.False(t, r.Passed())
+	assert.Equal(t, 4, r.Records)
+	assert.Equal(t, []string{
+		"inconsistent_wrapping@3", "duplicate_id@5", "empty_record@5", "missing_id@6",
+		"invalid_character@7", "blank_line@10", "missing_final_newline@11",
+	}, kinds(r))
+	assert.Equal(t, 1, r.Counts[DuplicateID])
+	assert.Equal(t, 3, r.Warnings)
+
+	// Records wrapped at different widths.
+	r, err = FASTA(strings.NewReader(">a\nACGT\nAC\n>b\nACG\nA\n"), DefaultOptions())
+	require.NoError(t, err)
+	assert.True(t, r.Passed())
+	assert.Equal(t, []string{"inconsistent_wrapping@5"}, kinds(r))
+
+	r, err = FASTA(strings.NewReader("ACGT\n>a\nRY\n"), Options{Alphabet: AlphabetIUPAC})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"malformed_record@1"}, kinds(r))
+}
+
+func TestFASTQ(t *testing.T) {
+	valid := "@r1\nACGT\n+\nIIII\n\n@r2 x\nGGCA\n+r2 x\nII#I\n"
+	r, err := Validate(strings.NewReader(valid), DefaultOptions())
+	require.NoError(t, err)
+	assert.Equal(t, FormatFASTQ, r.Format)
+	assert.Equal(t, 2, r.Records)
+	assert.True(t, r.Passed())
+
+	bad := "@r1\nACGT\n+\nIII\n@r1\nACNT\n+other\nII I\n@r3\nACGT\n@r4\nAC\n+\nI"
+	r, err = FASTQ(strings.NewReader(bad), DefaultOptions())
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"length_mismatch@4", "duplicate_id@5", "header_mismatch@7", "invalid_quality@8",
+		"malformed_record@11", "length_mismatch@14", "missing_final_newline@14",
+	}, kinds(r))
+	assert.Equal(t, 4, r.Records)
+
+	r, err = FASTQ(strings.NewReader("@r1\nACGT\n+\n"), DefaultOptions())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"truncated_record@3"}, kinds(r))
+
+	r, err = FASTQ(strings.NewReader("junk\n@r1\nACGT\n+\nIIII\n"), Options{MaxIssues: -1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"malformed_record@1"}, kinds(r))
+	assert.Equal(t, 1, r.Records)
+}
+
+func TestReport(t *testing.T) {
+	r, err := FASTA(strings.NewReader(">a\nXX\n>a\nXX\n"), Options{MaxIssues: 2})
+	require.NoError(t, err)
+	assert.Len(t, r.Issues, 2)
+	assert.True(t, r.Truncated)
+	assert.Equal(t, 3, r.Errors)
+
+	var sb strings.Builder
+	require.NoError(t, r.WriteText(&sb))
+	assert.Equal(t, "line 2: error: invalid dna character 'X' at column 1 [invalid_character] (a)\n"+
+		"line 3: error: duplicate ID, first seen on line 1 [duplicate_id] (a)\n"+
+		"... 1 more issues not shown\n"+
+		"FAIL: 2 FASTA records, 3 errors, 0 warnings\n", sb.String())
+
+	_, err = Validate(strings.NewReader("  \n"), DefaultOptions())
+	assert.Error(t, err)
+	_, err = Validate(strings.NewReader("ACGT\n"), DefaultOptions())
+	assert.Error(t, err)
+	_, err = Validate(strings.NewReader(">a\nACGT\n"), Options{Alphabet: "protein"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,146 @@
+package variant
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/aria-lang/bioflow-go/internal/stats"
+	"github.com/aria-lang/bioflow-go/internal/track"
+)
+
+// DefaultDensityWindow is the window of variant density tracks, in bases.
+const DefaultDensityWindow = 10_000
+
+// DensityOptions configures variant density tracks.
+type DensityOptions struct {
+	// Window and Step place the windows; zero values select
+	// DefaultDensityWindow and windows that do not overlap.
+	Window int
+	Step   int
+	// Kinds are the kinds of variants counted; empty counts SNVs only.
+	Kinds []Kind
+	// Name names the tracks; empty is "snp_density".
+	Name string
+}
+
+// ParseKinds parses comma-separated variant kinds (snv, mnv, insertion,
+// deletion, complex), or "all".
+func ParseKinds(list string) ([]Kind, error) {
+	var kinds []Kind
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "all" {
+			return []Kind{SNV, MNV, Insertion, Deletion, Complex}, nil
+		}
+		found := false
+		for k := SNV; k <= Complex; k++ {
+			if strings.ToLower(k.String()) == name {
+				kinds, found = append(kinds, k), true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown variant kind %q, use snv, mnv, insertion, deletion, complex or all", name)
+		}
+	}
+	return kinds, nil
+}
+
+// Density returns, per reference sequence and in reference order, a track
+// of variant sites per kilobase of unambiguous reference bases in each
+// window, for bedGraph or WIG export. Sites are counted once however
+// many alleles they have, by the position of their first base; windows
+// of ambiguous bases only have a density of zero.
+//
+// Aria equivalent:
+//
+//	fn density(variants: [Variant], references: [Sequence], options: DensityOptions) -> Result<[Track], VariantError>
+//	  requires variants.all(|v| references.any(|r| r.id == v.chrom and v.pos <= r.len()))
+//	  ensures result.len() == references.len()
+func Density(variants []Variant, references []*sequence.Sequence, opts DensityOptions) ([]*track.Track, error) {
+	if opts.Window < 0 || opts.Step < 0 {
+		return nil, fmt.Errorf("window and step must be non-negative")
+	}
+	if opts.Window == 0 {
+		opts.Window = DefaultDensityWindow
+	}
+	if opts.Step == 0 {
+		opts.Step = opts.Window
+	}
+	if opts.Name == "" {
+		opts.Name = "snp_density"
+	}
+	counted := map[Kind]bool{SNV: true}
+	if len(opts.Kinds) > 0 {
+		counted = make(map[Kind]bool, len(opts.Kinds))
+		for _, k := range opts.Kinds {
+			counted[k] = true
+		}
+	}
+
+	lengths := make(map[string]int, len(references))
+	for _, ref := range references {
+		lengths[ref.ID] = ref.Len()
+	}
+	// sites holds the distinct 0-based positions of each sequence.
+	sites := make(map[string][]int)
+	for _, v := range variants {
+		n, ok := lengths[v.Chrom]
+		if !ok {
+			return nil, fmt.Errorf("%s: chromosome not in reference", v)
+		}
+		if v.Pos < 1 || v.Pos > n {
+			return nil, fmt.Errorf("%s: position outside the %d bases of %s", v, n, v.Chrom)
+		}
+		if counted[v.Kind()] {
+			sites[v.Chrom] = append(sites[v.Chrom], v.Pos-1)
+		}
+	}
+
+	tracks := make([]*track.Track, 0, len(references))
+	for _, ref := range references {
+		positions := distinctSorted(sites[ref.ID])
+		windows, err := stats.Windows(ref.Len(), stats.WindowOptions{Window: opts.Window, Step: opts.Step})
+		if err != nil {
+			return nil, err
+		}
+		t := track.New(opts.Name, ref.ID)
+		for _, w := range windows {
+			callable := unambiguous(ref.Bases[w.Start:w.End])
+			n := sort.SearchInts(positions, w.End) - sort.SearchInts(positions, w.Start)
+			density := 0.0
+			if callable > 0 {
+				density = float64(n) * 1000 / float64(callable)
+			}
+			t.Add(w.Start, w.End, density)
+		}
+		tracks = append(tracks, t)
+	}
+	return tracks, nil
+}
+
+// distinctSorted sorts positions and drops repeats in place.
+func distinctSorted(positions []int) []int {
+	sort.Ints(positions)
+	out := positions[:0]
+	for _, p := range positions {
+		if len(out) == 0 || out[len(out)-1] != p {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// unambiguous counts the A, C, G and T bases, in either case.
+func unambiguous(bases string) int {
+	n := 0
+	for i := 0; i < len(bases); i++ {
+		switch bases[i] {
+		case 'A', 'C', 'G', 'T', 'a', 'c', 'g', 't':
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,243 @@
+// Package variant represents small sequence variants and converts them
+// between alignments and VCF.
+//
+// Variants use VCF conventions: a 1-based position and REF/ALT alleles
+// that, for indels, include the preceding reference base. Normalize
+// rewrites a variant into its unique left-aligned, parsimonious form so
+// that the same event reported by different callers or alignments
+// compares equal.
+//
+// Comparison with Aria:
+//
+//	Aria states the allele invariants on the type:
+//	  struct Variant
+//	    invariant self.pos >= 1
+//	    invariant self.ref.len() > 0 and self.alt.len() > 0
+//	    invariant self.ref != self.alt
+//
+//	Go checks them in Validate and in every constructor.
+package variant
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Kind classifies a variant by the lengths of its alleles.
+type Kind int
+
+const (
+	// SNV is a single-base substitution.
+	SNV Kind = iota
+	// MNV is a multi-base substitution of equal-length alleles.
+	MNV
+	// Insertion adds bases after the shared anchor base.
+	Insertion
+	// Deletion removes bases after the shared anchor base.
+	Deletion
+	// Complex replaces bases with a different number of bases.
+	Complex
+)
+
+func (k Kind) String() string {
+	switch k {
+	case SNV:
+		return "SNV"
+	case MNV:
+		return "MNV"
+	case Insertion:
+		return "insertion"
+	case Deletion:
+		return "deletion"
+	case Complex:
+		return "complex"
+	default:
+		return "unknown"
+	}
+}
+
+// Variant is a single-ALT variant at a 1-based reference position.
+type Variant struct {
+	Chrom string `json:"chrom"`
+	Pos   int    `json:"pos"`
+	Ref   string `json:"ref"`
+	Alt   string `json:"alt"`
+}
+
+// Validate checks the VCF allele invariants.
+func (v Variant) Validate() error {
+	if v.Pos < 1 {
+		return fmt.Errorf("position must be >= 1, got %d", v.Pos)
+	}
+	if v.Ref == "" || v.Alt == "" {
+		return fmt.Errorf("alleles must be non-empty")
+	}
+	if v.Ref == v.Alt {
+		return fmt.Errorf("REF and ALT are identical")
+	}
+	return nil
+}
+
+// Kind classifies the variant.
+func (v Variant) Kind() Kind {
+	switch {
+	case len(v.Ref) == 1 && len(v.Alt) == 1:
+		return SNV
+	case len(v.Ref) == len(v.Alt):
+		return MNV
+	case len(v.Ref) == 1 && v.Alt[0] == v.Ref[0]:
+		return Insertion
+	case len(v.Alt) == 1 && v.Ref[0] == v.Alt[0]:
+		return Deletion
+	default:
+		return Complex
+	}
+}
+
+// End returns the 1-based position of the last reference base covered.
+func (v Variant) End() int {
+	return v.Pos + len(v.Ref) - 1
+}
+
+func (v Variant) String() string {
+	return fmt.Sprintf("%s:%d %s>%s", v.Chrom, v.Pos, v.Ref, v.Alt)
+}
+
+// Normalize returns the left-aligned, parsimonious representation of v
+// against the reference sequence of its chromosome (Tan et al. 2015):
+// shared trailing bases are trimmed, the variant is shifted left through
+// repeats, and shared leading bases are trimmed down to a single anchor
+// base for indels.
+//
+// Aria equivalent:
+//
+//	fn normalize(v: Variant, reference: String) -> Result<Variant, VariantError>
+//	  requires v.validate().is_ok()
+//	  requires reference[v.pos-1 .. v.end()] == v.ref
+//	  ensures result.pos <= v.pos
+func Normalize(v Variant, reference string) (Variant, error) {
+	if err := v.Validate(); err != nil {
+		return v, err
+	}
+	if v.End() > len(reference) {
+		return v, fmt.Errorf("%s extends past the reference (%d bp)", v, len(reference))
+	}
+	if !strings.EqualFold(reference[v.Pos-1:v.End()], v.Ref) {
+		return v, fmt.Errorf("%s: REF does not match reference %q", v, reference[v.Pos-1:v.End()])
+	}
+
+	ref, alt, pos := strings.ToUpper(v.Ref), strings.ToUpper(v.Alt), v.Pos
+	for {
+		changed := false
+		if len(ref) > 0 && len(alt) > 0 && ref[len(ref)-1] == alt[len(alt)-1] {
+			ref, alt = ref[:len(ref)-1], alt[:len(alt)-1]
+			changed = true
+		}
+		if (len(ref) == 0 || len(alt) == 0) && pos > 1 {
+			base := strings.ToUpper(reference[pos-2 : pos-1])
+			ref, alt = base+ref, base+alt
+			pos--
+			changed = true
+		}
+		if !changed {
+			break
+		}
+	}
+	// At the very start of the reference an indel is anchored on the
+	// following base instead.
+	if len(ref) == 0 || len(alt) == 0 {
+		base := strings.ToUpper(reference[pos-1+len(ref) : pos+len(ref)])
+		ref, alt = ref+base, alt+base
+	}
+	for len(ref) > 1 && len(alt) > 1 && ref[0] == alt[0] {
+		ref, alt = ref[1:], alt[1:]
+		pos++
+	}
+
+	return Variant{Chrom: v.Chrom, Pos: pos, Ref: ref, Alt: alt}, nil
+}
+
+// FromAlignedRows extracts variants from a gapped pairwise alignment of a
+// reference row against a query row. refStart is the 0-based reference
+// position of the first column; ref is the full reference sequence, used
+// to anchor indels that start the alignment. Each mismatch becomes an SNV
+// and each gap run an insertion or deletion anchored on the preceding
+// reference base.
+//
+// Aria equivalent:
+//
+//	fn from_aligned_rows(chrom: String, reference: String, ref_start: Int,
+//	                     ref_row: String, query_row: String) -> Result<[Variant], VariantError>
+//	  requires ref_row.len() == query_row.len()
+//	  ensures result.all(|v| v.validate().is_ok())
+func FromAlignedRows(chrom, reference string, refStart int, refRow, queryRow string) ([]Variant, error) {
+	if len(refRow) != len(queryRow) {
+		return nil, fmt.Errorf("aligned rows must have equal length")
+	}
+	variants := make([]Variant, 0)
+	refPos := refStart // 0-based reference position of the next ref base
+	for c := 0; c < len(refRow); {
+		switch {
+		case sequence.IsGap(refRow[c]) || sequence.IsGap(queryRow[c]):
+			end := c
+			inserted := sequence.IsGap(refRow[c])
+			for end < len(refRow) && sequence.IsGap(refRow[end]) == inserted && sequence.IsGap(queryRow[end]) != inserted {
+				end++
+			}
+			var bases string
+			if inserted {
+				bases = queryRow[c:end]
+			} else {
+				bases = refRow[c:end]
+			}
+			var v Variant
+			if refPos > 0 {
+				if refPos > len(reference) {
+					return nil, fmt.Errorf("alignment extends past the reference")
+				}
+				anchor := strings.ToUpper(reference[refPos-1 : refPos])
+				v = Variant{Chrom: chrom, Pos: refPos, Ref: anchor, Alt: anchor}
+				if inserted {
+					v.Alt += strings.ToUpper(bases)
+				} else {
+					v.Ref += strings.ToUpper(bases)
+				}
+			} else {
+				// No preceding base: anchor on the base after the event.
+				after := refPos
+				if !inserted {
+					after += len(bases)
+				}
+				if after >= len(reference) {
+					return nil, fmt.Errorf("cannot anchor indel at the start of a reference")
+				}
+				anchor := strings.ToUpper(reference[after : after+1])
+				v = Variant{Chrom: chrom, Pos: 1, Ref: anchor, Alt: anchor}
+				if inserted {
+					v.Alt = strings.ToUpper(bases) + anchor
+				} else {
+					v.Ref = strings.ToUpper(bases) + anchor
+				}
+			}
+			variants = append(variants, v)
+			if !inserted {
+				refPos += end - c
+			}
+			c = end
+		default:
+			if !strings.EqualFold(refRow[c:c+1], queryRow[c:c+1]) {
+				variants = append(variants, Variant{
+					Chrom: chrom,
+					Pos:   refPos + 1,
+					Ref:   strings.ToUpper(refRow[c : c+1]),
+					Alt:   strings.ToUpper(queryRow[c : c+1]),
+				})
+			}
+			refPos++
+			c++
+		}
+	}
+	return variants, nil
+}
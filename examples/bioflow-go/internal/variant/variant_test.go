@@ -0,0 +1,185 @@
+package variant
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/aria-lang/bioflow-go/internal/track"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reference is the 16 bp sequence the normalization tests are written against.
+const reference = "GGCTCAAAATGCCGCA"
+
+func TestKind(t *testing.T) {
+	assert.Equal(t, SNV, Variant{Pos: 1, Ref: "A", Alt: "G"}.Kind())
+	assert.Equal(t, MNV, Variant{Pos: 1, Ref: "AC", Alt: "GT"}.Kind())
+	assert.Equal(t, Insertion, Variant{Pos: 1, Ref: "A", Alt: "AT"}.Kind())
+	assert.Equal(t, Deletion, Variant{Pos: 1, Ref: "AT", Alt: "A"}.Kind())
+	assert.Equal(t, Complex, Variant{Pos: 1, Ref: "AT", Alt: "G"}.Kind())
+	assert.Equal(t, 6, Variant{Pos: 5, Ref: "AT", Alt: "A"}.End())
+	assert.Error(t, Variant{Pos: 0, Ref: "A", Alt: "G"}.Validate())
+	assert.Error(t, Variant{Pos: 1, Ref: "A", Alt: "A"}.Validate())
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Variant
+		want Variant
+	}{
+		{"deletion shifted left", Variant{"chr1", 8, "AA", "A"}, Variant{"chr1", 5, "CA", "C"}},
+		{"insertion shifted left", Variant{"chr1", 9, "A", "AA"}, Variant{"chr1", 5, "C", "CA"}},
+		{"right-anchored deletion", Variant{"chr1", 9, "AT", "T"}, Variant{"chr1", 5, "CA", "C"}},
+		{"padded SNV", Variant{"chr1", 3, "CTC", "CGC"}, Variant{"chr1", 4, "T", "G"}},
+		{"already normal", Variant{"chr1", 4, "T", "G"}, Variant{"chr1", 4, "T", "G"}},
+		{"deletion at start", Variant{"chr1", 1, "GG", "G"}, Variant{"chr1", 1, "GG", "G"}},
+		{"lowercase", Variant{"chr1", 10, "t", "c"}, Variant{"chr1", 10, "T", "C"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.in, reference)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := Normalize(Variant{"chr1", 4, "A", "G"}, reference)
+	assert.Error(t, err, "REF mismatch")
+	_, err = Normalize(Variant{"chr1", 16, "AC", "A"}, reference)
+	assert.Error(t, err, "past the end")
+}
+
+func TestFromAlignedRows(t *testing.T) {
+	// Deletion of one A, an SNV and an insertion.
+	refRow := "GGCTCAAAATGCC-GCA"
+	qryRow := "GGCTC-AAATGACTGCA"
+	variants, err := FromAlignedRows("chr1", reference, 0, refRow, qryRow)
+	require.NoError(t, err)
+	assert.Equal(t, []Variant{
+		{"chr1", 5, "CA", "C"},
+		{"chr1", 12, "C", "A"},
+		{"chr1", 13, "C", "CT"},
+	}, variants)
+
+	// Rows starting mid-reference and with a leading insertion.
+	variants, err = FromAlignedRows("chr1", reference, 0, "-GGC", "TGGC")
+	require.NoError(t, err)
+	assert.Equal(t, []Variant{{"chr1", 1, "G", "TG"}}, variants)
+
+	variants, err = FromAlignedRows("chr1", reference, 9, "TGCC", "TG-C")
+	require.NoError(t, err)
+	assert.Equal(t, []Variant{{"chr1", 11, "GC", "G"}}, variants)
+
+	_, err = FromAlignedRows("chr1", reference, 0, "AC", "A")
+	assert.Error(t, err)
+}
+
+const testVCF = `##fileformat=VCFv4.2
+##contig=<ID=chr1>
+#CHROM	POS	ID	REF	ALT	QUAL	FILTER	INFO	FORMAT	S1	S2
+chr1	8	rs1	AA	A,AAA	50	PASS	DP=10	GT:DP	1/2:7	0|1:3
+chr1	10	.	T	<DEL>	.	.	SVTYPE=DEL	GT	0/1	./.
+`
+
+func TestVCFRoundTrip(t *testing.T) {
+	vcf, err := Read(strings.NewReader(testVCF))
+	require.NoError(t, err)
+	require.Len(t, vcf.Records, 2)
+	assert.Len(t, vcf.Meta, 2)
+	assert.Equal(t, []string{"A", "AAA"}, vcf.Records[0].Alt)
+	assert.Equal(t, []string{"GT:DP", "1/2:7", "0|1:3"}, vcf.Records[0].Samples)
+	assert.Len(t, vcf.Records[0].Variants(), 2)
+	assert.Empty(t, vcf.Records[1].Variants())
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, vcf))
+	assert.Equal(t, testVCF, buf.String())
+
+	_, err = Read(strings.NewReader("chr1\tx\t.\tA\tG\t.\t.\t.\n"))
+	assert.Error(t, err)
+}
+
+func TestSplitAndNormalizeRecords(t *testing.T) {
+	vcf, err := Read(strings.NewReader(testVCF))
+	require.NoError(t, err)
+
+	split := vcf.Records[0].Split()
+	require.Len(t, split, 2)
+	assert.Equal(t, []string{"GT:DP", "1/0:7", "0|1:3"}, split[0].Samples)
+	assert.Equal(t, []string{"GT:DP", "0/1:7", "0|0:3"}, split[1].Samples)
+
+	out, err := NormalizeRecords(vcf.Records, map[string]string{"chr1": reference}, true)
+	require.NoError(t, err)
+	require.Len(t, out, 3)
+	assert.Equal(t, "chr1\t5\trs1\tCA\tC\t50\tPASS\tDP=10\tGT:DP\t1/0:7\t0|1:3", out[0].String())
+	assert.Equal(t, 5, out[1].Pos)
+	assert.Equal(t, []string{"CA"}, out[1].Alt)
+	assert.Equal(t, "<DEL>", out[2].Alt[0])
+
+	_, err = NormalizeRecords(vcf.Records, map[string]string{}, true)
+	assert.Error(t, err)
+
+	rec := FromVariant(Variant{"chr2", 3, "A", "G"})
+	assert.Equal(t, "chr2\t3\t.\tA\tG\t.\t.\t.", rec.String())
+}
+
+func TestGenotypes(t *testing.T) {
+	vcf, err := Read(strings.NewReader(testVCF))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"S1", "S2"}, vcf.Samples())
+	i, err := vcf.SampleIndex("S2")
+	require.NoError(t, err)
+	assert.Equal(t, 1, i)
+	_, err = vcf.SampleIndex("S3")
+	assert.Error(t, err)
+
+	rec := vcf.Records[0]
+	assert.True(t, rec.Passed())
+	assert.True(t, rec.Heterozygous(0))
+	assert.True(t, rec.Heterozygous(1))
+	assert.False(t, rec.Heterozygous(2))
+	rec.Samples = []string{"GT", "1/1", "./1"}
+	assert.False(t, rec.Heterozygous(0))
+	assert.False(t, rec.Heterozygous(1))
+}
+
+func TestDensity(t *testing.T) {
+	refs := []*sequence.Sequence{
+		{ID: "chr1", Bases: strings.Repeat("ACGT", 500) + strings.Repeat("N", 500)},
+		{ID: "chr2", Bases: strings.Repeat("A", 100)},
+	}
+	variants := []Variant{
+		{"chr1", 1, "A", "G"},
+		{"chr1", 1, "A", "T"}, // same site
+		{"chr1", 10, "G", "C"},
+		{"chr1", 1200, "A", "AT"},
+		{"chr1", 1500, "T", "C"},
+	}
+	tracks, err := Density(variants, refs, DensityOptions{Window: 1000})
+	require.NoError(t, err)
+	require.Len(t, tracks, 2)
+	assert.Equal(t, "snp_density", tracks[0].Name)
+	require.Len(t, tracks[0].Points, 3)
+	assert.InDelta(t, 2.0, tracks[0].Points[0].Value, 1e-9)
+	assert.InDelta(t, 1.0, tracks[0].Points[1].Value, 1e-9)
+	assert.Equal(t, track.Point{Start: 2000, End: 2500}, tracks[0].Points[2])
+	assert.Equal(t, 0.0, tracks[1].Points[0].Value)
+
+	kinds, err := ParseKinds("snv,insertion")
+	require.NoError(t, err)
+	tracks, err = Density(variants, refs, DensityOptions{Window: 1000, Step: 500, Kinds: kinds, Name: "variants"})
+	require.NoError(t, err)
+	assert.Len(t, tracks[0].Points, 4)
+	assert.InDelta(t, 2.0, tracks[0].Points[2].Value, 1e-9)
+
+	_, err = ParseKinds("snp")
+	assert.Error(t, err)
+	_, err = Density([]Variant{{"chr3", 1, "A", "G"}}, refs, DensityOptions{})
+	assert.Error(t, err)
+	_, err = Density([]Variant{{"chr2", 101, "A", "G"}}, refs, DensityOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,311 @@
+package variant
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Record is one VCF data line. QUAL, FILTER and INFO are kept verbatim,
+// and FORMAT plus sample columns are kept in Samples (FORMAT first).
+type Record struct {
+	Chrom   string   `json:"chrom"`
+	Pos     int      `json:"pos"`
+	ID      string   `json:"id"`
+	Ref     string   `json:"ref"`
+	Alt     []string `json:"alt"`
+	Qual    string   `json:"qual"`
+	Filter  string   `json:"filter"`
+	Info    string   `json:"info"`
+	Samples []string `json:"samples,omitempty"`
+}
+
+// VCF is a parsed VCF file: meta-information lines, the #CHROM header
+// line and the records.
+type VCF struct {
+	Meta    []string
+	Header  string
+	Records []*Record
+}
+
+// DefaultHeader is the column header written when none is available.
+const DefaultHeader = "#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO"
+
+// ParseRecord parses a VCF data line.
+func ParseRecord(line string) (*Record, error) {
+	fields := strings.Split(strings.TrimRight(line, "\r\n"), "\t")
+	if len(fields) < 8 {
+		return nil, fmt.Errorf("expected at least 8 columns, got %d", len(fields))
+	}
+	pos, err := strconv.Atoi(fields[1])
+	if err != nil || pos < 1 {
+		return nil, fmt.Errorf("invalid POS %q", fields[1])
+	}
+	if fields[3] == "" {
+		return nil, fmt.Errorf("empty REF")
+	}
+	rec := &Record{
+		Chrom:  fields[0],
+		Pos:    pos,
+		ID:     fields[2],
+		Ref:    fields[3],
+		Alt:    strings.Split(fields[4], ","),
+		Qual:   fields[5],
+		Filter: fields[6],
+		Info:   fields[7],
+	}
+	if len(fields) > 8 {
+		rec.Samples = fields[8:]
+	}
+	return rec, nil
+}
+
+// String formats the record as a VCF data line (without newline).
+func (r *Record) String() string {
+	fields := []string{
+		r.Chrom, strconv.Itoa(r.Pos), orMissing(r.ID), r.Ref,
+		orMissing(strings.Join(r.Alt, ",")), orMissing(r.Qual), orMissing(r.Filter), orMissing(r.Info),
+	}
+	return strings.Join(append(fields, r.Samples...), "\t")
+}
+
+// orMissing returns "." for empty VCF fields.
+func orMissing(s string) string {
+	if s == "" {
+		return "."
+	}
+	return s
+}
+
+// Read parses a VCF stream.
+func Read(r io.Reader) (*VCF, error) {
+	vcf := &VCF{Records: make([]*Record, 0)}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+			continue
+		case strings.HasPrefix(line, "##"):
+			vcf.Meta = append(vcf.Meta, line)
+		case strings.HasPrefix(line, "#"):
+			vcf.Header = line
+		default:
+			rec, err := ParseRecord(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			vcf.Records = append(vcf.Records, rec)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading VCF: %w", err)
+	}
+	return vcf, nil
+}
+
+// Write writes a VCF file. A missing fileformat line or column header is
+// filled in.
+func Write(w io.Writer, vcf *VCF) error {
+	bw := bufio.NewWriter(w)
+	if len(vcf.Meta) == 0 || !strings.HasPrefix(vcf.Meta[0], "##fileformat=") {
+		fmt.Fprintln(bw, "##fileformat=VCFv4.2")
+	}
+	for _, line := range vcf.Meta {
+		fmt.Fprintln(bw, line)
+	}
+	header := vcf.Header
+	if header == "" {
+		header = DefaultHeader
+	}
+	fmt.Fprintln(bw, header)
+	for _, rec := range vcf.Records {
+		if _, err := fmt.Fprintln(bw, rec.String()); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Variants returns one Variant per ALT allele of the record. Symbolic
+// (<DEL>), breakend and missing ("." or "*") alleles are skipped.
+func (r *Record) Variants() []Variant {
+	variants := make([]Variant, 0, len(r.Alt))
+	for _, alt := range r.Alt {
+		if !isSequenceAllele(alt) {
+			continue
+		}
+		variants = append(variants, Variant{Chrom: r.Chrom, Pos: r.Pos, Ref: r.Ref, Alt: alt})
+	}
+	return variants
+}
+
+// Samples returns the sample names of the column header.
+func (v *VCF) Samples() []string {
+	fields := strings.Split(v.Header, "\t")
+	if len(fields) <= 9 {
+		return nil
+	}
+	return fields[9:]
+}
+
+// SampleIndex returns the index of a named sample among Samples; an empty
+// name is the first sample.
+func (v *VCF) SampleIndex(name string) (int, error) {
+	samples := v.Samples()
+	if len(samples) == 0 {
+		return 0, fmt.Errorf("VCF has no samples")
+	}
+	if name == "" {
+		return 0, nil
+	}
+	for i, s := range samples {
+		if s == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("sample %s not in VCF", name)
+}
+
+// Passed reports whether the record passed all filters, or was not
+// filtered ("PASS" or ".").
+func (r *Record) Passed() bool {
+	return r.Filter == "PASS" || r.Filter == "." || r.Filter == ""
+}
+
+// Heterozygous reports whether the genotype of a sample, by index, calls
+// two different alleles. Records without a leading GT FORMAT field, and
+// genotypes with missing alleles, are not heterozygous.
+func (r *Record) Heterozygous(sample int) bool {
+	if sample < 0 || sample+1 >= len(r.Samples) || !strings.HasPrefix(r.Samples[0], "GT") {
+		return false
+	}
+	gt, _, _ := strings.Cut(r.Samples[sample+1], ":")
+	alleles := strings.FieldsFunc(gt, func(c rune) bool { return c == '/' || c == '|' })
+	for _, a := range alleles {
+		if a == "." {
+			return false
+		}
+	}
+	for _, a := range alleles[min(1, len(alleles)):] {
+		if a != alleles[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// isSequenceAllele reports whether an ALT allele is spelled out in bases.
+func isSequenceAllele(alt string) bool {
+	if alt == "" || alt == "." || alt == "*" {
+		return false
+	}
+	return !strings.ContainsAny(alt, "<>[]")
+}
+
+// FromVariant creates a minimal record for a variant.
+func FromVariant(v Variant) *Record {
+	return &Record{Chrom: v.Chrom, Pos: v.Pos, ID: ".", Ref: v.Ref, Alt: []string{v.Alt}, Qual: ".", Filter: ".", Info: "."}
+}
+
+// Split splits a multi-allelic record into one record per ALT allele, as
+// "bcftools norm -m-" does. Genotypes in a leading GT FORMAT field are
+// recoded so that the record's ALT is 1, other ALTs become 0 and missing
+// alleles stay missing; other FORMAT fields and INFO are copied as is.
+//
+// Aria equivalent:
+//
+//	fn split(self) -> [Record]
+//	  ensures result.len() == self.alt.len()
+//	  ensures result.all(|r| r.alt.len() == 1)
+func (r *Record) Split() []*Record {
+	if len(r.Alt) <= 1 {
+		return []*Record{r}
+	}
+	records := make([]*Record, len(r.Alt))
+	for i, alt := range r.Alt {
+		rec := *r
+		rec.Alt = []string{alt}
+		if len(r.Samples) > 1 && strings.HasPrefix(r.Samples[0], "GT") {
+			rec.Samples = make([]string, len(r.Samples))
+			rec.Samples[0] = r.Samples[0]
+			for s, sample := range r.Samples[1:] {
+				rec.Samples[s+1] = recodeGT(sample, i+1)
+			}
+		}
+		records[i] = &rec
+	}
+	return records
+}
+
+// recodeGT rewrites the GT subfield of a sample column for the split
+// record holding ALT allele keep.
+func recodeGT(sample string, keep int) string {
+	gt, rest := sample, ""
+	if i := strings.IndexByte(sample, ':'); i >= 0 {
+		gt, rest = sample[:i], sample[i:]
+	}
+	var sb strings.Builder
+	start := 0
+	for i := 0; i <= len(gt); i++ {
+		if i < len(gt) && gt[i] != '/' && gt[i] != '|' {
+			continue
+		}
+		allele := gt[start:i]
+		if n, err := strconv.Atoi(allele); err == nil {
+			if n == keep {
+				allele = "1"
+			} else {
+				allele = "0"
+			}
+		}
+		sb.WriteString(allele)
+		if i < len(gt) {
+			sb.WriteByte(gt[i])
+		}
+		start = i + 1
+	}
+	return sb.String() + rest
+}
+
+// NormalizeRecords splits multi-allelic records (when split is set) and
+// normalizes every sequence allele against the reference sequences, keyed
+// by chromosome name. Multi-allelic records that are not split, and
+// symbolic alleles, are passed through unchanged.
+//
+// Aria equivalent:
+//
+//	fn normalize_records(records: [Record], reference: Map<String, String>, split: Bool) -> Result<[Record], VariantError>
+//	  ensures split implies result.all(|r| r.alt.len() == 1)
+func NormalizeRecords(records []*Record, reference map[string]string, split bool) ([]*Record, error) {
+	out := make([]*Record, 0, len(records))
+	for _, rec := range records {
+		parts := []*Record{rec}
+		if split {
+			parts = rec.Split()
+		}
+		for _, part := range parts {
+			seq, ok := reference[part.Chrom]
+			if !ok {
+				return nil, fmt.Errorf("%s:%d: chromosome not in reference", part.Chrom, part.Pos)
+			}
+			if len(part.Alt) != 1 || !isSequenceAllele(part.Alt[0]) {
+				out = append(out, part)
+				continue
+			}
+			v, err := Normalize(Variant{Chrom: part.Chrom, Pos: part.Pos, Ref: part.Ref, Alt: part.Alt[0]}, seq)
+			if err != nil {
+				return nil, err
+			}
+			norm := *part
+			norm.Pos, norm.Ref, norm.Alt = v.Pos, v.Ref, []string{v.Alt}
+			out = append(out, &norm)
+		}
+	}
+	return out, nil
+}
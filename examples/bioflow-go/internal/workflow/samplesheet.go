@@ -0,0 +1,274 @@
+package workflow
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SamplePlaceholder is replaced by the sample ID in the string parameters
+// of a per-sample spec, so that each sample writes its own files.
+const SamplePlaceholder = "{sample}"
+
+// Sample is one row of a sample sheet. Params override the parameters of
+// pipeline stages, keyed "stage.param" as in the column headers; Fields
+// holds the other columns, such as Illumina's Sample_Name or index.
+type Sample struct {
+	ID     string            `json:"id"`
+	FASTQ  string            `json:"fastq,omitempty"`
+	Params map[string]string `json:"params,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// SampleSheet maps samples to their reads and parameters.
+type SampleSheet struct {
+	Samples []Sample `json:"samples"`
+}
+
+// Column names recognized in sample sheets, lower-cased.
+var (
+	sampleIDColumns = []string{"sample_id", "sample", "id"}
+	fastqColumns    = []string{"fastq", "fastq_1", "fastq1", "r1", "reads"}
+)
+
+// ParseSampleSheet reads a sample sheet: either an Illumina sample sheet,
+// whose [Data] section is a CSV table, or a plain CSV or TSV table with a
+// header row. The table needs a sample ID column (Sample_ID, sample or
+// id); a FASTQ column (fastq, fastq_1, R1 or reads) is optional, and
+// columns named "stage.param" set stage parameters per sample. FASTQ paths
+// are resolved against dir. Lines starting with "#" are skipped.
+//
+// Aria equivalent:
+//
+//	fn parse_sample_sheet(r: Reader, dir: Path) -> Result<SampleSheet, WorkflowError> with IO
+//	  ensures result.samples.map(|s| s.id).is_unique()
+func ParseSampleSheet(r io.Reader, dir string) (*SampleSheet, error) {
+	var lines []string
+	illumina, inData := false, false
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(strings.Trim(line, ",\t"))
+		switch {
+		case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+			// Illumina sections: only [Data] holds the samples.
+			illumina, inData = true, strings.EqualFold(trimmed, "[Data]")
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+		case !illumina || inData:
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading sample sheet: %w", err)
+	}
+	if illumina && len(lines) == 0 {
+		return nil, fmt.Errorf("sample sheet has no [Data] section")
+	}
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("sample sheet has no samples")
+	}
+
+	cr := csv.NewReader(strings.NewReader(strings.Join(lines, "\n")))
+	if strings.Contains(lines[0], "\t") {
+		cr.Comma = '\t'
+	}
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parsing sample sheet: %w", err)
+	}
+	header := rows[0]
+	idCol, fastqCol := findColumn(header, sampleIDColumns), findColumn(header, fastqColumns)
+	if idCol < 0 {
+		return nil, fmt.Errorf("sample sheet has no sample ID column (%s)", strings.Join(sampleIDColumns, ", "))
+	}
+
+	sheet := &SampleSheet{}
+	seen := make(map[string]int)
+	for i, row := range rows[1:] {
+		line := i + 2
+		if len(row) > len(header) {
+			return nil, fmt.Errorf("sample sheet row %d: %d columns, header has %d", line, len(row), len(header))
+		}
+		s := Sample{Params: make(map[string]string), Fields: make(map[string]string)}
+		for j, v := range row {
+			v = strings.TrimSpace(v)
+			switch {
+			case j == idCol:
+				s.ID = v
+			case j == fastqCol:
+				if v != "" && !filepath.IsAbs(v) && dir != "" {
+					v = filepath.Join(dir, v)
+				}
+				s.FASTQ = v
+			case v == "":
+			case strings.Contains(header[j], "."):
+				s.Params[strings.TrimSpace(header[j])] = v
+			default:
+				s.Fields[strings.TrimSpace(header[j])] = v
+			}
+		}
+		if s.ID == "" {
+			return nil, fmt.Errorf("sample sheet row %d: no sample ID", line)
+		}
+		if strings.ContainsAny(s.ID, `/\`) || s.ID == "." || s.ID == ".." {
+			return nil, fmt.Errorf("sample sheet row %d: sample ID %q is not a valid file name", line, s.ID)
+		}
+		if prev, dup := seen[s.ID]; dup {
+			return nil, fmt.Errorf("sample sheet row %d: sample %s already on row %d", line, s.ID, prev)
+		}
+		seen[s.ID] = line
+		sheet.Samples = append(sheet.Samples, s)
+	}
+	return sheet, nil
+}
+
+// findColumn returns the index of the first header matching one of the
+// names, ignoring case, or -1.
+func findColumn(header []string, names []string) int {
+	for _, name := range names {
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// LoadSampleSheet reads a sample sheet file, resolving FASTQ paths against
+// its directory.
+func LoadSampleSheet(path string) (*SampleSheet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	defer file.Close()
+	return ParseSampleSheet(file, filepath.Dir(path))
+}
+
+// FindFASTQ sets the FASTQ of the samples without one from the files of a
+// directory: the first, in name order, whose name starts with the sample
+// ID followed by "_" or ".", preferring read 1 files (containing "_R1")
+// as bcl2fastq names them (Sample_S1_L001_R1_001.fastq.gz).
+func (sheet *SampleSheet) FindFASTQ(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("reading FASTQ directory: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.Contains(strings.ToLower(e.Name()), ".f") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	for i := range sheet.Samples {
+		s := &sheet.Samples[i]
+		if s.FASTQ != "" {
+			continue
+		}
+		var match string
+		for _, name := range names {
+			if !strings.HasPrefix(name, s.ID+"_") && !strings.HasPrefix(name, s.ID+".") {
+				continue
+			}
+			if strings.Contains(name, "_R2") {
+				continue
+			}
+			if match == "" || (strings.Contains(name, "_R1") && !strings.Contains(match, "_R1")) {
+				match = name
+			}
+		}
+		if match == "" {
+			return fmt.Errorf("no FASTQ for sample %s in %s", s.ID, dir)
+		}
+		s.FASTQ = filepath.Join(dir, match)
+	}
+	return nil
+}
+
+// ForSample returns the spec of one sample: its FASTQ as the input, a work
+// directory of its own under the spec's, its parameters in place of the
+// stages', and SamplePlaceholder replaced by its ID in string parameters.
+// Parameter values are read as YAML scalars and checked against the stage
+// schemas.
+//
+// Aria equivalent:
+//
+//	fn for_sample(self, sample: Sample) -> Result<Spec, WorkflowError>
+//	  requires sample.fastq.len() > 0
+//	  ensures result.stages.len() == self.stages.len()
+func (s *Spec) ForSample(sample Sample) (*Spec, error) {
+	if sample.FASTQ == "" {
+		return nil, fmt.Errorf("sample %s has no FASTQ", sample.ID)
+	}
+	input, err := filepath.Abs(sample.FASTQ)
+	if err != nil {
+		return nil, err
+	}
+	out := &Spec{
+		Name:    s.Name,
+		Input:   input,
+		WorkDir: filepath.Join(s.WorkDir, sample.ID),
+		Stages:  make([]Stage, len(s.Stages)),
+		dir:     s.dir,
+	}
+	if out.Name != "" {
+		out.Name += "/" + sample.ID
+	}
+	for i, stage := range s.Stages {
+		params := make(map[string]interface{}, len(stage.Params))
+		for k, v := range stage.Params {
+			if str, ok := v.(string); ok {
+				v = strings.ReplaceAll(str, SamplePlaceholder, sample.ID)
+			}
+			params[k] = v
+		}
+		out.Stages[i] = Stage{Name: stage.Name, Type: stage.Type, Params: params}
+	}
+
+	keys := make([]string, 0, len(sample.Params))
+	for k := range sample.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name, param, _ := strings.Cut(key, ".")
+		i := out.stageIndex(name)
+		if i < 0 {
+			return nil, fmt.Errorf("sample %s: column %s names no stage of the pipeline", sample.ID, key)
+		}
+		var v interface{}
+		if err := yaml.Unmarshal([]byte(sample.Params[key]), &v); err != nil {
+			return nil, fmt.Errorf("sample %s: column %s: %w", sample.ID, key, err)
+		}
+		if str, ok := v.(string); ok {
+			v = strings.ReplaceAll(str, SamplePlaceholder, sample.ID)
+		}
+		out.Stages[i].Params[param] = v
+	}
+	if err := out.Validate(); err != nil {
+		return nil, fmt.Errorf("sample %s: %w", sample.ID, err)
+	}
+	return out, nil
+}
+
+// stageIndex returns the index of the stage with a name, or -1.
+func (s *Spec) stageIndex(name string) int {
+	for i, stage := range s.Stages {
+		if stage.Name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,512 @@
+// Package workflow describes declarative read-processing pipelines.
+//
+// A pipeline is a YAML file naming an input FASTQ or JSON Lines file and
+// a chain of stages (trim, primers, filter, dedupe, subsample, stats,
+// write) with their parameters:
+//
+//	name: clean-reads
+//	input: reads.fastq
+//	stages:
+//	  - type: trim
+//	    params: {threshold: 20}
+//	  - type: filter
+//	    params: {preset: strict, min_length: 40}
+//	  - type: dedupe
+//	  - type: stats
+//	  - type: write
+//	    params: {output: clean.fastq}
+//
+// This package parses and validates specs, fingerprints each stage from
+// the input file and the stage parameters, and keeps the run state that
+// lets an interrupted run resume after its last completed stage. Sample
+// sheets map samples to FASTQ files and per-sample parameters, and
+// ForSample derives the spec of each sample of a batch. Running the
+// stages is left to the caller.
+//
+// Comparison with Aria:
+//
+//	Aria would check the parameters of each stage against its schema at
+//	compile time:
+//	  enum Stage
+//	    Trim(threshold: Int where threshold >= 0)
+//	    Write(output: Path)
+//
+//	Go checks them when the spec is loaded, before any stage runs.
+package workflow
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Stage types.
+const (
+	Trim      = "trim"
+	Primers   = "primers"
+	Filter    = "filter"
+	Dedupe    = "dedupe"
+	Subsample = "subsample"
+	Stats     = "stats"
+	Write     = "write"
+)
+
+// Kind is the type of a stage parameter.
+type Kind int
+
+const (
+	// Int parameters accept YAML integers.
+	Int Kind = iota
+	// Float parameters accept YAML integers and floats.
+	Float
+	// String parameters accept YAML strings.
+	String
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Int:
+		return "integer"
+	case Float:
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// Param describes a stage parameter. Choices, when set, lists the allowed
+// string values.
+type Param struct {
+	Name     string
+	Kind     Kind
+	Default  interface{}
+	Required bool
+	Choices  []string
+	Doc      string
+}
+
+// Schemas lists the parameters of each stage type, including those added
+// with Register.
+var Schemas = map[string][]Param{
+	Trim: {
+		{Name: "threshold", Kind: Int, Default: 20, Doc: "trim bases below this quality from both ends"},
+		{Name: "min_length", Kind: Int, Default: 1, Doc: "drop reads shorter than this after trimming"},
+	},
+	Primers: {
+		{Name: "primers", Kind: String, Required: true, Doc: "FASTA file of the amplicon primers"},
+		{Name: "window", Kind: Int, Default: 0, Doc: "bases allowed before a 5' primer or after a 3' primer, trimmed with it"},
+		{Name: "require", Kind: String, Default: "none", Choices: []string{"none", "5prime", "both"}, Doc: "discard reads missing a primer at the 5' end or at both ends"},
+	},
+	Filter: {
+		{Name: "preset", Kind: String, Default: "default", Choices: []string{"default", "strict"}, Doc: "filter settings to start from"},
+		{Name: "min_quality", Kind: Int, Doc: "minimum mean quality"},
+		{Name: "min_length", Kind: Int, Doc: "minimum read length"},
+		{Name: "max_ambiguous", Kind: Int, Doc: "maximum number of N bases"},
+	},
+	Dedupe: {
+		{Name: "by", Kind: String, Default: "sequence", Choices: []string{"sequence", "id"}, Doc: "what makes two reads duplicates"},
+	},
+	Subsample: {
+		{Name: "n", Kind: Int, Doc: "number of reads to keep"},
+		{Name: "fraction", Kind: Float, Doc: "fraction of reads to keep (instead of n)"},
+		{Name: "seed", Kind: Int, Default: 1, Doc: "random seed; the same seed keeps the same reads"},
+	},
+	Stats: {
+		{Name: "html", Kind: String, Doc: "also write an HTML report to this file"},
+	},
+	Write: {
+		{Name: "output", Kind: String, Required: true, Doc: "output file"},
+		{Name: "format", Kind: String, Choices: []string{"fastq", "fasta", "jsonl"}, Doc: "output format (default: from the extension)"},
+	},
+}
+
+// Register adds a stage type with its parameter schema, so that specs
+// may use stages provided outside this package. It is meant to be called
+// from init functions and fails if the type is already known or the
+// schema is inconsistent.
+//
+// Aria equivalent:
+//
+//	fn register(stage_type: String, params: [Param]) -> Result<(), WorkflowError>
+//	  requires stage_type.len() > 0
+//	  ensures result.is_ok() implies stage_types().contains(stage_type)
+func Register(stageType string, params []Param) error {
+	if stageType == "" || strings.ContainsAny(stageType, " \t/\\") {
+		return fmt.Errorf("invalid stage type %q", stageType)
+	}
+	if _, ok := Schemas[stageType]; ok {
+		return fmt.Errorf("stage type %q already registered", stageType)
+	}
+	seen := make(map[string]bool, len(params))
+	for _, p := range params {
+		if p.Name == "" {
+			return fmt.Errorf("stage type %q: parameter without a name", stageType)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("stage type %q: duplicate parameter %q", stageType, p.Name)
+		}
+		seen[p.Name] = true
+		if p.Default != nil {
+			if err := p.check(p.Default); err != nil {
+				return fmt.Errorf("stage type %q: default of %q: %w", stageType, p.Name, err)
+			}
+		}
+	}
+	Schemas[stageType] = params
+	return nil
+}
+
+// StageTypes lists the stage types in alphabetical order.
+func StageTypes() []string {
+	types := make([]string, 0, len(Schemas))
+	for t := range Schemas {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Stage is one step of a pipeline. Name defaults to the type and must be
+// unique within the pipeline.
+type Stage struct {
+	Name   string                 `yaml:"name" json:"name"`
+	Type   string                 `yaml:"type" json:"type"`
+	Params map[string]interface{} `yaml:"params" json:"params,omitempty"`
+}
+
+// Int returns an integer parameter, or its default. ok is false when the
+// parameter is neither set nor defaulted.
+func (s Stage) Int(name string) (int, bool) {
+	v, ok := s.value(name)
+	if !ok {
+		return 0, false
+	}
+	return v.(int), true
+}
+
+// Float returns a numeric parameter, or its default.
+func (s Stage) Float(name string) (float64, bool) {
+	v, ok := s.value(name)
+	if !ok {
+		return 0, false
+	}
+	if i, isInt := v.(int); isInt {
+		return float64(i), true
+	}
+	return v.(float64), true
+}
+
+// String returns a string parameter, or its default.
+func (s Stage) String(name string) (string, bool) {
+	v, ok := s.value(name)
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+// value returns a validated parameter value or its default.
+func (s Stage) value(name string) (interface{}, bool) {
+	if v, ok := s.Params[name]; ok {
+		return v, true
+	}
+	for _, p := range Schemas[s.Type] {
+		if p.Name == name && p.Default != nil {
+			return p.Default, true
+		}
+	}
+	return nil, false
+}
+
+// validate checks the stage type and parameters against the schema,
+// converting whole numbers given for integer parameters to int.
+func (s Stage) validate() error {
+	schema, ok := Schemas[s.Type]
+	if !ok {
+		return fmt.Errorf("unknown stage type %q (use %s)", s.Type, strings.Join(StageTypes(), ", "))
+	}
+	params := make(map[string]Param, len(schema))
+	for _, p := range schema {
+		params[p.Name] = p
+		if _, set := s.Params[p.Name]; p.Required && !set {
+			return fmt.Errorf("parameter %q is required", p.Name)
+		}
+	}
+	names := make([]string, 0, len(s.Params))
+	for name := range s.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p, ok := params[name]
+		if !ok {
+			return fmt.Errorf("unknown parameter %q", name)
+		}
+		// JSON decodes every number as float64.
+		if f, isFloat := s.Params[name].(float64); isFloat && p.Kind == Int && f == math.Trunc(f) {
+			s.Params[name] = int(f)
+		}
+		if err := p.check(s.Params[name]); err != nil {
+			return fmt.Errorf("parameter %q: %w", name, err)
+		}
+	}
+	if s.Type == Subsample {
+		_, n := s.Params["n"]
+		fraction, ok := s.Float("fraction")
+		if n == ok {
+			return fmt.Errorf("exactly one of n and fraction is required")
+		}
+		if ok && fraction > 1 {
+			return fmt.Errorf("parameter \"fraction\": must be at most 1")
+		}
+	}
+	return nil
+}
+
+// check checks a value against the parameter kind and choices.
+func (p Param) check(v interface{}) error {
+	switch v := v.(type) {
+	case int:
+		if p.Kind == String {
+			return fmt.Errorf("expected %s, got %d", p.Kind, v)
+		}
+		if v < 0 {
+			return fmt.Errorf("must not be negative")
+		}
+	case float64:
+		if p.Kind != Float {
+			return fmt.Errorf("expected %s, got %g", p.Kind, v)
+		}
+		if v < 0 {
+			return fmt.Errorf("must not be negative")
+		}
+	case string:
+		if p.Kind != String {
+			return fmt.Errorf("expected %s, got %q", p.Kind, v)
+		}
+		if v == "" {
+			return fmt.Errorf("must not be empty")
+		}
+		if len(p.Choices) > 0 {
+			for _, c := range p.Choices {
+				if v == c {
+					return nil
+				}
+			}
+			return fmt.Errorf("must be one of %s, got %q", strings.Join(p.Choices, ", "), v)
+		}
+	default:
+		return fmt.Errorf("expected %s", p.Kind)
+	}
+	return nil
+}
+
+// Spec is a pipeline definition. Relative paths are resolved against the
+// directory of the spec file.
+type Spec struct {
+	Name    string  `yaml:"name"`
+	Input   string  `yaml:"input"`
+	WorkDir string  `yaml:"workdir"`
+	Stages  []Stage `yaml:"stages"`
+
+	dir string
+}
+
+// Parse reads and validates a YAML spec. dir is the directory relative
+// paths are resolved against.
+//
+// Aria equivalent:
+//
+//	fn parse(data: Bytes, dir: Path) -> Result<Spec, WorkflowError>
+//	  ensures result.is_ok() implies result.unwrap().stages.len() > 0
+func Parse(data []byte, dir string) (*Spec, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var spec Spec
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("parsing pipeline: %w", err)
+	}
+	spec.dir = dir
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// Load reads and validates a spec file. The work directory defaults to
+// the spec file name with ".run" in place of its extension.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	spec, err := Parse(data, filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	if spec.WorkDir == "" {
+		base := filepath.Base(path)
+		spec.WorkDir = strings.TrimSuffix(base, filepath.Ext(base)) + ".run"
+	}
+	return spec, nil
+}
+
+// Validate checks the spec: an input, at least one stage, known stage
+// types and parameters, and unique stage names. Stage names default to
+// their type.
+func (s *Spec) Validate() error {
+	if s.Input == "" {
+		return fmt.Errorf("pipeline has no input")
+	}
+	if len(s.Stages) == 0 {
+		return fmt.Errorf("pipeline has no stages")
+	}
+	seen := make(map[string]int, len(s.Stages))
+	for i := range s.Stages {
+		stage := &s.Stages[i]
+		if stage.Name == "" {
+			stage.Name = stage.Type
+		}
+		if j, dup := seen[stage.Name]; dup {
+			return fmt.Errorf("stage %d: name %q already used by stage %d", i+1, stage.Name, j+1)
+		}
+		seen[stage.Name] = i
+		if strings.ContainsAny(stage.Name, `/\`) {
+			return fmt.Errorf("stage %d: name %q must not contain path separators", i+1, stage.Name)
+		}
+		if err := stage.validate(); err != nil {
+			return fmt.Errorf("stage %d (%s): %w", i+1, stage.Name, err)
+		}
+	}
+	return nil
+}
+
+// Path resolves a path from the spec against the spec directory.
+func (s *Spec) Path(p string) string {
+	if filepath.IsAbs(p) || s.dir == "" {
+		return p
+	}
+	return filepath.Join(s.dir, p)
+}
+
+// StageFile returns the path of a per-stage file in the work directory,
+// such as "02-filter.json".
+func (s *Spec) StageFile(i int, ext string) string {
+	return filepath.Join(s.Path(s.WorkDir), fmt.Sprintf("%02d-%s%s", i+1, s.Stages[i].Name, ext))
+}
+
+// Fingerprints returns one fingerprint per stage. Each covers the input
+// file's size and modification time and the configuration of the stage
+// and every stage before it, so changing either invalidates the stage
+// and all later ones.
+func (s *Spec) Fingerprints(input os.FileInfo) []string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%d\x00%d\x00", filepath.Clean(s.Input), input.Size(), input.ModTime().UnixNano())
+	prints := make([]string, len(s.Stages))
+	for i, stage := range s.Stages {
+		// Maps are marshalled with sorted keys, so this is canonical.
+		b, _ := json.Marshal(stage)
+		h.Write(b)
+		prints[i] = hex.EncodeToString(h.Sum(nil))[:16]
+	}
+	return prints
+}
+
+// StageState records a completed stage. Reads names the file (the input
+// FASTQ or a JSON Lines checkpoint) holding the reads after the stage,
+// which later stages resume from.
+type StageState struct {
+	Name        string    `json:"name"`
+	Fingerprint string    `json:"fingerprint"`
+	Reads       string    `json:"reads"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// State is the progress of a run, saved in the work directory.
+type State struct {
+	Stages []StageState `json:"stages"`
+}
+
+// StateFile returns the path of the run state.
+func (s *Spec) StateFile() string {
+	return filepath.Join(s.Path(s.WorkDir), "state.json")
+}
+
+// LoadState reads the run state. It returns an empty state if there is
+// none.
+func (s *Spec) LoadState() (*State, error) {
+	data, err := os.ReadFile(s.StateFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading run state: %w", err)
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parsing run state: %w", err)
+	}
+	return &state, nil
+}
+
+// SaveState writes the run state.
+func (s *Spec) SaveState(state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding run state: %w", err)
+	}
+	tmp := s.StateFile() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing run state: %w", err)
+	}
+	if err := os.Rename(tmp, s.StateFile()); err != nil {
+		return fmt.Errorf("writing run state: %w", err)
+	}
+	return nil
+}
+
+// Plan is what a run will do: stages before Resume completed in an
+// earlier run and are skipped, and the run starts from ReadsFrom.
+type Plan struct {
+	Fingerprints []string
+	Resume       int
+	ReadsFrom    string
+}
+
+// Plan decides where a run resumes: after the longest prefix of stages
+// recorded in state with matching fingerprints and an existing reads
+// file. With fresh, or no usable state, it starts from the input.
+//
+// Aria equivalent:
+//
+//	fn plan(self, state: State, input: FileInfo, fresh: Bool) -> Plan
+//	  ensures result.resume <= self.stages.len()
+//	  ensures fresh implies result.resume == 0
+func (s *Spec) Plan(state *State, input os.FileInfo, fresh bool) *Plan {
+	plan := &Plan{Fingerprints: s.Fingerprints(input), ReadsFrom: s.Path(s.Input)}
+	if fresh {
+		return plan
+	}
+	for i, done := range state.Stages {
+		if i >= len(s.Stages) || done.Name != s.Stages[i].Name || done.Fingerprint != plan.Fingerprints[i] {
+			break
+		}
+		if _, err := os.Stat(done.Reads); err != nil {
+			break
+		}
+		plan.Resume = i + 1
+		plan.ReadsFrom = done.Reads
+	}
+	return plan
+}
@@ -0,0 +1,235 @@
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const spec = `
+name: clean
+input: reads.fq
+stages:
+  - type: trim
+    params: {threshold: 25}
+  - name: strict
+    type: filter
+    params: {preset: strict, min_length: 40}
+  - type: write
+    params: {output: out.fq}
+`
+
+func TestParse(t *testing.T) {
+	s, err := Parse([]byte(spec), "data")
+	require.NoError(t, err)
+	assert.Equal(t, "clean", s.Name)
+	require.Len(t, s.Stages, 3)
+	assert.Equal(t, "trim", s.Stages[0].Name)
+	assert.Equal(t, "strict", s.Stages[1].Name)
+	assert.Equal(t, filepath.Join("data", "reads.fq"), s.Path(s.Input))
+	assert.Equal(t, "/abs/reads.fq", s.Path("/abs/reads.fq"))
+
+	threshold, ok := s.Stages[0].Int("threshold")
+	assert.True(t, ok)
+	assert.Equal(t, 25, threshold)
+	minLength, ok := s.Stages[0].Int("min_length")
+	assert.True(t, ok)
+	assert.Equal(t, 1, minLength, "default")
+	_, ok = s.Stages[1].Int("max_ambiguous")
+	assert.False(t, ok, "no default")
+	preset, _ := s.Stages[1].String("preset")
+	assert.Equal(t, "strict", preset)
+
+	t.Run("json numbers", func(t *testing.T) {
+		s := &Spec{Input: "-", Stages: []Stage{{Type: Trim, Params: map[string]interface{}{"threshold": 25.0}}}}
+		require.NoError(t, s.Validate())
+		threshold, _ := s.Stages[0].Int("threshold")
+		assert.Equal(t, 25, threshold)
+		s.Stages[0].Params["threshold"] = 25.5
+		assert.Error(t, s.Validate())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"no input":       "stages: [{type: stats}]",
+			"no stages":      "input: r.fq",
+			"unknown field":  "input: r.fq\nstage: []",
+			"unknown type":   "input: r.fq\nstages: [{type: sort}]",
+			"unknown param":  "input: r.fq\nstages: [{type: trim, params: {treshold: 20}}]",
+			"wrong kind":     "input: r.fq\nstages: [{type: trim, params: {threshold: high}}]",
+			"negative":       "input: r.fq\nstages: [{type: trim, params: {threshold: -1}}]",
+			"bad choice":     "input: r.fq\nstages: [{type: dedupe, params: {by: name}}]",
+			"missing output": "input: r.fq\nstages: [{type: write}]",
+			"duplicate name": "input: r.fq\nstages: [{type: stats}, {type: stats}]",
+			"no sample size": "input: r.fq\nstages: [{type: subsample}]",
+			"two sizes":      "input: r.fq\nstages: [{type: subsample, params: {n: 5, fraction: 0.5}}]",
+			"fraction > 1":   "input: r.fq\nstages: [{type: subsample, params: {fraction: 2}}]",
+		} {
+			_, err := Parse([]byte(body), "")
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestPlan(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "reads.fq")
+	require.NoError(t, os.WriteFile(input, []byte("@r\nACGT\n+\nIIII\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "p.yaml"), []byte(spec), 0o644))
+
+	s, err := Load(filepath.Join(dir, "p.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "p.run", s.WorkDir)
+	require.NoError(t, os.MkdirAll(s.Path(s.WorkDir), 0o755))
+
+	info, err := os.Stat(input)
+	require.NoError(t, err)
+	state, err := s.LoadState()
+	require.NoError(t, err)
+	assert.Empty(t, state.Stages)
+
+	plan := s.Plan(state, info, false)
+	assert.Equal(t, 0, plan.Resume)
+	assert.Equal(t, input, plan.ReadsFrom)
+	require.Len(t, plan.Fingerprints, 3)
+	assert.Equal(t, plan.Fingerprints, s.Fingerprints(info), "deterministic")
+
+	// Complete the first two stages.
+	trimmed := s.StageFile(0, ".fastq")
+	assert.Equal(t, filepath.Join(dir, "p.run", "01-trim.fastq"), trimmed)
+	require.NoError(t, os.WriteFile(trimmed, nil, 0o644))
+	state.Stages = []StageState{
+		{Name: "trim", Fingerprint: plan.Fingerprints[0], Reads: trimmed},
+		{Name: "strict", Fingerprint: plan.Fingerprints[1], Reads: trimmed},
+	}
+	require.NoError(t, s.SaveState(state))
+	state, err = s.LoadState()
+	require.NoError(t, err)
+
+	plan = s.Plan(state, info, false)
+	assert.Equal(t, 2, plan.Resume)
+	assert.Equal(t, trimmed, plan.ReadsFrom)
+	assert.Equal(t, 0, s.Plan(state, info, true).Resume, "fresh")
+
+	// Changing a stage invalidates it and every later stage.
+	s.Stages[1].Params["min_length"] = 50
+	plan = s.Plan(state, info, false)
+	assert.Equal(t, 1, plan.Resume)
+
+	// So does losing the saved reads.
+	require.NoError(t, os.Remove(trimmed))
+	assert.Equal(t, 0, s.Plan(state, info, false).Resume)
+}
+
+func TestRegister(t *testing.T) {
+	params := []Param{
+		{Name: "adapter", Kind: String, Required: true},
+		{Name: "mismatches", Kind: Int, Default: 1},
+	}
+	require.NoError(t, Register("test-clip", params))
+	defer delete(Schemas, "test-clip")
+	assert.Contains(t, StageTypes(), "test-clip")
+
+	s, err := Parse([]byte("input: r.fq\nstages: [{type: test-clip, params: {adapter: AGATCGG}}]"), "")
+	require.NoError(t, err)
+	mismatches, _ := s.Stages[0].Int("mismatches")
+	assert.Equal(t, 1, mismatches)
+	_, err = Parse([]byte("input: r.fq\nstages: [{type: test-clip}]"), "")
+	assert.Error(t, err, "required parameter")
+
+	assert.Error(t, Register("test-clip", nil), "already registered")
+	assert.Error(t, Register("trim", nil), "built-in")
+	assert.Error(t, Register("", nil))
+	assert.Error(t, Register("bad name", nil))
+	assert.Error(t, Register("test-dup", []Param{{Name: "a"}, {Name: "a"}}))
+	assert.Error(t, Register("test-default", []Param{{Name: "a", Kind: Int, Default: "x"}}))
+}
+
+const illuminaSheet = `[Header]
+IEMFileVersion,5
+Experiment Name,run42
+
+[Reads]
+151
+
+[Data]
+Sample_ID,Sample_Name,index,trim.threshold,write.output
+S1,liver,ACGT,30,
+S2,kidney,TTGA,,{sample}-custom.fq
+`
+
+func TestParseSampleSheet(t *testing.T) {
+	sheet, err := ParseSampleSheet(strings.NewReader(illuminaSheet), "runs")
+	require.NoError(t, err)
+	require.Len(t, sheet.Samples, 2)
+	assert.Equal(t, "S1", sheet.Samples[0].ID)
+	assert.Empty(t, sheet.Samples[0].FASTQ)
+	assert.Equal(t, map[string]string{"trim.threshold": "30"}, sheet.Samples[0].Params)
+	assert.Equal(t, map[string]string{"Sample_Name": "liver", "index": "ACGT"}, sheet.Samples[0].Fields)
+
+	tsv := "# samples\nsample\tfastq\tfilter.preset\nA\ta.fq\tstrict\nB\t/data/b.fq\t\n"
+	sheet, err = ParseSampleSheet(strings.NewReader(tsv), "runs")
+	require.NoError(t, err)
+	require.Len(t, sheet.Samples, 2)
+	assert.Equal(t, filepath.Join("runs", "a.fq"), sheet.Samples[0].FASTQ)
+	assert.Equal(t, "/data/b.fq", sheet.Samples[1].FASTQ)
+	assert.Empty(t, sheet.Samples[1].Params)
+
+	for _, bad := range []string{
+		"fastq\na.fq\n",                  // no ID column
+		"sample,fastq\nA,a.fq\nA,b.fq\n", // duplicate
+		"sample,fastq\n../x,a.fq\n",      // not a file name
+		"[Header]\nIEMFileVersion,5\n",   // no [Data]
+		"sample,fastq\n",                 // no samples
+		"sample,fastq\nA,a.fq,extra\n",   // too many columns
+	} {
+		_, err := ParseSampleSheet(strings.NewReader(bad), "")
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestFindFASTQ(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"S1_S1_L001_R2_001.fastq.gz", "S1_S1_L001_R1_001.fastq.gz", "S10_S3_L001_R1_001.fastq.gz", "S2.fq", "notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+	sheet := &SampleSheet{Samples: []Sample{{ID: "S1"}, {ID: "S2"}, {ID: "S3", FASTQ: "given.fq"}}}
+	require.NoError(t, sheet.FindFASTQ(dir))
+	assert.Equal(t, filepath.Join(dir, "S1_S1_L001_R1_001.fastq.gz"), sheet.Samples[0].FASTQ)
+	assert.Equal(t, filepath.Join(dir, "S2.fq"), sheet.Samples[1].FASTQ)
+	assert.Equal(t, "given.fq", sheet.Samples[2].FASTQ)
+
+	sheet = &SampleSheet{Samples: []Sample{{ID: "S4"}}}
+	assert.Error(t, sheet.FindFASTQ(dir))
+}
+
+func TestForSample(t *testing.T) {
+	s, err := Parse([]byte(spec+"  - type: stats\n    params: {html: \"{sample}.html\"}\n"), "data")
+	require.NoError(t, err)
+	s.WorkDir = "clean.run"
+
+	per, err := s.ForSample(Sample{ID: "S1", FASTQ: "/data/s1.fq", Params: map[string]string{"trim.threshold": "30", "write.output": "out/{sample}.fq"}})
+	require.NoError(t, err)
+	assert.Equal(t, "/data/s1.fq", per.Input)
+	assert.Equal(t, filepath.Join("clean.run", "S1"), per.WorkDir)
+	assert.Equal(t, "clean/S1", per.Name)
+	threshold, _ := per.Stages[0].Int("threshold")
+	assert.Equal(t, 30, threshold)
+	output, _ := per.Stages[2].String("output")
+	assert.Equal(t, "out/S1.fq", output)
+	html, _ := per.Stages[3].String("html")
+	assert.Equal(t, "S1.html", html)
+	original, _ := s.Stages[0].Int("threshold")
+	assert.Equal(t, 25, original, "the shared spec is unchanged")
+
+	_, err = s.ForSample(Sample{ID: "S1", FASTQ: "s1.fq", Params: map[string]string{"dedupe.by": "id"}})
+	assert.Error(t, err, "no such stage")
+	_, err = s.ForSample(Sample{ID: "S1", FASTQ: "s1.fq", Params: map[string]string{"trim.threshold": "high"}})
+	assert.Error(t, err, "not an integer")
+	_, err = s.ForSample(Sample{ID: "S1"})
+	assert.Error(t, err, "no FASTQ")
+}
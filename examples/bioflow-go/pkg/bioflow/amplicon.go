@@ -0,0 +1,264 @@
+package bioflow
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/aria-lang/bioflow-go/internal/amplicon"
+)
+
+// Primer is a named PCR primer.
+type Primer = amplicon.Primer
+
+// PrimerOptions configures primer trimming.
+type PrimerOptions = amplicon.Options
+
+// PrimerRequirement selects the reads kept by primer they contain.
+type PrimerRequirement = amplicon.Requirement
+
+// PrimerMatch is where primers were found in a read.
+type PrimerMatch = amplicon.Match
+
+// PrimerStats counts the primers found in reads.
+type PrimerStats = amplicon.Stats
+
+// Primer requirements.
+const (
+	RequireNoPrimer        = amplicon.RequireNone
+	RequireFivePrimePrimer = amplicon.RequireFivePrime
+	RequireBothPrimers     = amplicon.RequireBoth
+)
+
+// ParsePrimerRequirement parses none, 5prime or both.
+func ParsePrimerRequirement(name string) (PrimerRequirement, error) {
+	return amplicon.ParseRequirement(name)
+}
+
+// ReadPrimers reads a primer FASTA file, one primer per record named by
+// its ID.
+//
+// Aria equivalent:
+//
+//	fn read_primers(filename: Path) -> Result<[Primer], IOError> with FileSystem
+func ReadPrimers(filename string) ([]Primer, error) {
+	sequences, err := ReadFASTA(filename)
+	if err != nil {
+		return nil, err
+	}
+	primers := make([]Primer, len(sequences))
+	for i, s := range sequences {
+		primers[i] = Primer{Name: s.ID, Sequence: s.Bases}
+	}
+	if len(primers) == 0 {
+		return nil, fmt.Errorf("no primers in %s", filename)
+	}
+	return primers, nil
+}
+
+// PrimerTrimmer trims amplicon primers from reads and counts them. It is
+// a ReadProcessor, so it can be added to a Pipeline.
+type PrimerTrimmer struct {
+	t *amplicon.Trimmer
+
+	mu    sync.Mutex
+	stats PrimerStats
+}
+
+// NewPrimerTrimmer creates a PrimerTrimmer for a primer set.
+func NewPrimerTrimmer(primers []Primer, opts PrimerOptions) (*PrimerTrimmer, error) {
+	t, err := amplicon.NewTrimmer(primers, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &PrimerTrimmer{t: t}, nil
+}
+
+// TrimRead returns a read with its primers trimmed, or nil and the reason
+// the read is discarded.
+func (pt *PrimerTrimmer) TrimRead(read *Read) (*Read, string, error) {
+	if read.Sequence.Len() != read.Quality.Len() {
+		return nil, "", fmt.Errorf("read %s: sequence and quality lengths differ", read.Sequence.ID)
+	}
+	m := pt.t.Match(read.Sequence.Bases)
+	reason := pt.t.Check(m)
+	pt.mu.Lock()
+	pt.stats.Add(m, reason != "")
+	pt.mu.Unlock()
+	if reason != "" {
+		return nil, reason, nil
+	}
+	if m.Start == 0 && m.End == read.Sequence.Len() {
+		return read, "", nil
+	}
+	seq, err := read.Sequence.Subsequence(m.Start, m.End)
+	if err != nil {
+		return nil, "", err
+	}
+	qual, err := read.Quality.Slice(m.Start, m.End)
+	if err != nil {
+		return nil, "", err
+	}
+	return &Read{Sequence: seq, Quality: qual, Group: read.Group}, "", nil
+}
+
+// ProcessReads trims the primers of reads, as a ReadProcessor, dropping
+// the reads the trimmer's requirement discards.
+func (pt *PrimerTrimmer) ProcessReads(reads []*Read) ([]*Read, error) {
+	out := make([]*Read, 0, len(reads))
+	for _, read := range reads {
+		trimmed, _, err := pt.TrimRead(read)
+		if err != nil {
+			return nil, err
+		}
+		if trimmed != nil {
+			out = append(out, trimmed)
+		}
+	}
+	return out, nil
+}
+
+// Stats returns the primers counted so far.
+func (pt *PrimerTrimmer) Stats() PrimerStats {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	s := pt.stats
+	s.ByPrimer = make(map[string]int, len(pt.stats.ByPrimer))
+	for name, n := range pt.stats.ByPrimer {
+		s.ByPrimer[name] = n
+	}
+	return s
+}
+
+// ChimeraOptions configures reference-based chimera detection.
+type ChimeraOptions = amplicon.ChimeraOptions
+
+// ChimeraCall is the chimeric or clean verdict on one sequence.
+type ChimeraCall = amplicon.ChimeraCall
+
+// DefaultChimeraOptions returns the defaults of UCHIME's reference mode.
+func DefaultChimeraOptions() ChimeraOptions {
+	return amplicon.DefaultChimeraOptions()
+}
+
+// DetectChimeras calls each query chimeric or clean against a reference
+// set of non-chimeric sequences.
+func DetectChimeras(queries, refs []*Sequence, opts ChimeraOptions) ([]ChimeraCall, error) {
+	return amplicon.DetectChimeras(queries, refs, opts)
+}
+
+// ClusterOptions configures abundance-sorted greedy clustering.
+type ClusterOptions = amplicon.ClusterOptions
+
+// AmpliconCluster is an OTU or ASV-like group of amplicon sequences.
+type AmpliconCluster = amplicon.Cluster
+
+// Clustering is a set of clusters with their abundances across samples.
+type Clustering = amplicon.Clustering
+
+// DefaultClusterOptions returns 97% OTU clustering without singletons.
+func DefaultClusterOptions() ClusterOptions {
+	return amplicon.DefaultClusterOptions()
+}
+
+// ClusterAmplicons dereplicates the sequences of each sample, named by
+// samples, and clusters them, most abundant first.
+//
+// Aria equivalent:
+//
+//	fn cluster_amplicons(samples: [String], sets: [[Sequence]], options: ClusterOptions) -> Result<Clustering, AmpliconError>
+//	  requires samples.len() == sets.len()
+func ClusterAmplicons(samples []string, sets [][]*Sequence, opts ClusterOptions) (*Clustering, error) {
+	if len(samples) != len(sets) {
+		return nil, fmt.Errorf("%d sample names for %d sequence sets", len(samples), len(sets))
+	}
+	d := amplicon.NewDereplicator()
+	for i, set := range sets {
+		for _, s := range set {
+			d.Add(samples[i], s.Bases)
+		}
+	}
+	return amplicon.ClusterUniques(d.Uniques(), d.Samples(), opts)
+}
+
+// AbundanceTable counts OTUs, taxa or other features in samples.
+type AbundanceTable = amplicon.AbundanceTable
+
+// AlphaDiversity is the diversity within one sample.
+type AlphaDiversity = amplicon.Alpha
+
+// BetaMetric selects a dissimilarity between samples.
+type BetaMetric = amplicon.BetaMetric
+
+// DiversityMatrix is a matrix of dissimilarities between samples.
+type DiversityMatrix = amplicon.DistanceMatrix
+
+// Beta diversity metrics.
+const (
+	BrayCurtis = amplicon.BrayCurtis
+	Jaccard    = amplicon.Jaccard
+)
+
+// ParseBetaMetric parses bray-curtis or jaccard.
+func ParseBetaMetric(name string) (BetaMetric, error) {
+	return amplicon.ParseBetaMetric(name)
+}
+
+// ReadAbundanceTable reads a tab-separated "#OTU ID" abundance table, as
+// written by the cluster command or biom convert.
+//
+// Aria equivalent:
+//
+//	fn read_abundance_table(filename: Path) -> Result<AbundanceTable, AmpliconError> with FileSystem
+func ReadAbundanceTable(filename string) (*AbundanceTable, error) {
+	f, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	t, err := amplicon.ReadAbundanceTable(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return t, nil
+}
+
+// RarefactionOptions configures rarefaction curves: the subsampling depths
+// and how many seeded subsamples are drawn at each.
+type RarefactionOptions = amplicon.RarefactionOptions
+
+// RarefactionPoint is the richness in subsamples of one depth.
+type RarefactionPoint = amplicon.RarefactionPoint
+
+// RarefactionCurve is the richness of one sample by subsampled depth.
+type RarefactionCurve = amplicon.RarefactionCurve
+
+// DefaultRarefactionOptions returns 10 depths of 10 iterations each.
+func DefaultRarefactionOptions() RarefactionOptions {
+	return amplicon.DefaultRarefactionOptions()
+}
+
+// RarefyReads returns the rarefaction curve of each sample's reads, named
+// by samples, counting distinct sequences as features.
+//
+// Aria equivalent:
+//
+//	fn rarefy_reads(samples: [String], sets: [[Sequence]], options: RarefactionOptions) -> Result<[RarefactionCurve], AmpliconError> with Random
+//	  requires samples.len() == sets.len()
+func RarefyReads(samples []string, sets [][]*Sequence, opts RarefactionOptions) ([]RarefactionCurve, error) {
+	if len(samples) != len(sets) {
+		return nil, fmt.Errorf("%d sample names for %d sequence sets", len(samples), len(sets))
+	}
+	d := amplicon.NewDereplicator()
+	for i, set := range sets {
+		for _, s := range set {
+			d.Add(samples[i], s.Bases)
+		}
+	}
+	return d.Table().Rarefaction(opts)
+}
+
+// WriteRarefactionTSV writes curves as one row per sample and depth.
+func WriteRarefactionTSV(w io.Writer, curves []RarefactionCurve) error {
+	return amplicon.WriteRarefactionTSV(w, curves)
+}
@@ -0,0 +1,95 @@
+package bioflow
+
+import (
+	"sync"
+
+	"github.com/aria-lang/bioflow-go/internal/anonymize"
+)
+
+// AnonymizeOptions configures an Anonymizer.
+type AnonymizeOptions = anonymize.Options
+
+// MinAnonymizationKeySize is the shortest anonymization key, in bytes.
+const MinAnonymizationKeySize = anonymize.MinKeySize
+
+// Anonymizer replaces sequence and read identifiers with keyed HMAC
+// pseudonyms and strips header descriptions. Records anonymized under the
+// same key keep matching identifiers, so they stay joinable across files.
+type Anonymizer struct {
+	a *anonymize.Anonymizer
+
+	mu     sync.Mutex
+	groups map[*ReadGroup]*ReadGroup
+}
+
+// NewAnonymizer creates an Anonymizer with a secret key of at least
+// MinAnonymizationKeySize bytes.
+//
+// Aria equivalent:
+//
+//	fn new_anonymizer(key: Bytes, options: AnonymizeOptions) -> Result<Anonymizer, AnonymizeError>
+//	  requires key.len() >= MIN_ANONYMIZATION_KEY_SIZE
+func NewAnonymizer(key []byte, opts AnonymizeOptions) (*Anonymizer, error) {
+	a, err := anonymize.New(key, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Anonymizer{a: a, groups: make(map[*ReadGroup]*ReadGroup)}, nil
+}
+
+// ReadAnonymizationKey reads a key file, as written by
+// GenerateAnonymizationKey.
+func ReadAnonymizationKey(filename string) ([]byte, error) {
+	return anonymize.ReadKeyFile(filename)
+}
+
+// GenerateAnonymizationKey returns a new random key for a key file.
+func GenerateAnonymizationKey() (string, error) {
+	return anonymize.GenerateKey()
+}
+
+// Pseudonym returns the pseudonym of an identifier, as key holders
+// compute it to find a record in anonymized data.
+func (an *Anonymizer) Pseudonym(id string) string {
+	return an.a.ID(id)
+}
+
+// AnonymizeSequence anonymizes the header of a sequence in place.
+func (an *Anonymizer) AnonymizeSequence(seq *Sequence) {
+	seq.ID, seq.Description = an.a.Header(seq.ID, seq.Description)
+}
+
+// AnonymizeRead anonymizes the header and read group of a read in place.
+// Reads that shared a read group share the anonymized one.
+func (an *Anonymizer) AnonymizeRead(read *Read) {
+	an.AnonymizeSequence(read.Sequence)
+	if read.Group == nil {
+		return
+	}
+	an.mu.Lock()
+	defer an.mu.Unlock()
+	g, ok := an.groups[read.Group]
+	if !ok {
+		anon := an.a.ReadGroup(*read.Group)
+		g = &anon
+		an.groups[read.Group] = g
+	}
+	read.Group = g
+}
+
+// ProcessSequences anonymizes sequences in place, as a SequenceProcessor.
+func (an *Anonymizer) ProcessSequences(sequences []*Sequence) ([]*Sequence, error) {
+	for _, seq := range sequences {
+		an.AnonymizeSequence(seq)
+	}
+	return sequences, nil
+}
+
+// ProcessReads anonymizes reads in place, as a ReadProcessor, so that an
+// Anonymizer can be added to a Pipeline.
+func (an *Anonymizer) ProcessReads(reads []*Read) ([]*Read, error) {
+	for _, read := range reads {
+		an.AnonymizeRead(read)
+	}
+	return reads, nil
+}
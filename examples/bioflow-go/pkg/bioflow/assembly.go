@@ -0,0 +1,61 @@
+package bioflow
+
+import (
+	"io"
+
+	"github.com/aria-lang/bioflow-go/internal/assembly"
+)
+
+// AssemblyReport is an assembly QC report: contiguity metrics and, with a
+// reference, genome fraction and misassembly candidates.
+type AssemblyReport = assembly.Report
+
+// AssemblyOptions configures assembly metrics.
+type AssemblyOptions = assembly.Options
+
+// AssemblyAlignOptions configures contig-to-reference alignment.
+type AssemblyAlignOptions = assembly.AlignOptions
+
+// DefaultAssemblyAlignOptions returns the default contig alignment
+// parameters.
+func DefaultAssemblyAlignOptions() AssemblyAlignOptions {
+	return assembly.DefaultAlignOptions()
+}
+
+// AssemblyStats computes an assembly QC report; references may be empty.
+func AssemblyStats(contigs, references []*Sequence, opts AssemblyOptions, align AssemblyAlignOptions) (*AssemblyReport, error) {
+	return assembly.NewReport(contigs, references, opts, align)
+}
+
+// DefaultMinContig is the default shortest contig counted in assembly
+// metrics.
+const DefaultMinContig = assembly.DefaultMinContig
+
+// Gap is a run of N bases in a sequence.
+type Gap = assembly.Gap
+
+// GapStats summarizes gap counts and lengths.
+type GapStats = assembly.GapStats
+
+// AGPRecord is one line of an AGP file.
+type AGPRecord = assembly.AGPRecord
+
+// FindGaps returns the gaps of at least minLen N bases in sequences,
+// with their statistics.
+func FindGaps(sequences []*Sequence, minLen int) (*GapStats, []Gap) {
+	return assembly.NewGapStats(sequences, minLen)
+}
+
+// SplitScaffolds breaks scaffolds into contigs at gaps of at least minGap
+// bases, returning the contigs and AGP records placing them.
+func SplitScaffolds(scaffolds []*Sequence, minGap int) ([]*Sequence, []AGPRecord, error) {
+	return assembly.SplitScaffolds(scaffolds, minGap)
+}
+
+// WriteAGP writes AGP 2.1 records.
+func WriteAGP(w io.Writer, records []AGPRecord) error {
+	return assembly.WriteAGP(w, records)
+}
+
+// UnknownGapLength is the conventional length of gaps of unknown size.
+const UnknownGapLength = assembly.UnknownGapLength
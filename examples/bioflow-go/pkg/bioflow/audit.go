@@ -0,0 +1,54 @@
+package bioflow
+
+import (
+	"io"
+
+	"github.com/aria-lang/bioflow-go/internal/audit"
+)
+
+// AuditLog is an append-only, hash-chained log of operations, kept in a
+// directory of rotated JSON Lines files.
+type AuditLog = audit.Log
+
+// AuditEntry is one operation in an AuditLog.
+type AuditEntry = audit.Entry
+
+// AuditOptions configures an AuditLog.
+type AuditOptions = audit.Options
+
+// AuditFilter selects the entries ExportAuditLog writes.
+type AuditFilter = audit.Filter
+
+// OpenAuditLog opens the audit log in a directory, creating it if needed.
+//
+// Aria equivalent:
+//
+//	fn open_audit_log(dir: Path, opts: AuditOptions) -> Result<AuditLog, IOError> with IO
+func OpenAuditLog(dir string, opts AuditOptions) (*AuditLog, error) {
+	return audit.Open(dir, opts)
+}
+
+// AuditDigest returns the digest of data recorded in audit entries.
+func AuditDigest(data []byte) string {
+	return audit.Digest(data)
+}
+
+// ExportAuditLog writes the entries of an audit log directory selected by
+// f to w as JSON Lines, returning how many it wrote.
+//
+// Aria equivalent:
+//
+//	fn export_audit_log(dir: Path, f: AuditFilter, w: Writer) -> Result<Int, IOError> with IO
+func ExportAuditLog(dir string, f AuditFilter, w io.Writer) (int, error) {
+	return audit.Export(dir, f, w)
+}
+
+// VerifyAuditLog checks that no entry of an audit log directory was
+// changed, removed or reordered, returning how many it checked.
+//
+// Aria equivalent:
+//
+//	fn verify_audit_log(dir: Path) -> Result<Int, ChainError> with IO
+func VerifyAuditLog(dir string) (int, error) {
+	return audit.Verify(dir)
+}
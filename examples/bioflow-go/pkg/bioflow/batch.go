@@ -0,0 +1,249 @@
+package bioflow
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/report"
+	"github.com/aria-lang/bioflow-go/internal/workflow"
+)
+
+// SampleSheet maps samples to FASTQ files and per-sample stage parameters.
+type SampleSheet = workflow.SampleSheet
+
+// Sample is one row of a sample sheet.
+type Sample = workflow.Sample
+
+// SamplePlaceholder is replaced by the sample ID in the string parameters
+// of each sample's pipeline.
+const SamplePlaceholder = workflow.SamplePlaceholder
+
+// LoadSampleSheet reads an Illumina sample sheet, or a CSV or TSV table
+// with a header row, resolving FASTQ paths against its directory.
+func LoadSampleSheet(path string) (*SampleSheet, error) {
+	return workflow.LoadSampleSheet(path)
+}
+
+// BatchOptions controls a batch run. The workflow options apply to every
+// sample.
+type BatchOptions struct {
+	WorkflowOptions
+	// KeepGoing runs the remaining samples after one fails, instead of
+	// stopping the batch.
+	KeepGoing bool
+}
+
+// SampleResult is the outcome of the pipeline of one sample; Error is set
+// if it failed.
+type SampleResult struct {
+	Sample string          `json:"sample"`
+	FASTQ  string          `json:"fastq"`
+	Error  string          `json:"error,omitempty"`
+	Result *WorkflowResult `json:"result,omitempty"`
+}
+
+// InputReads returns the number of reads the first stage took.
+func (r *SampleResult) InputReads() int {
+	if r.Result == nil || len(r.Result.Stages) == 0 {
+		return 0
+	}
+	return r.Result.Stages[0].InputReads
+}
+
+// OutputReads returns the number of reads left after the last stage.
+func (r *SampleResult) OutputReads() int {
+	if r.Result == nil || len(r.Result.Stages) == 0 {
+		return 0
+	}
+	return r.Result.Stages[len(r.Result.Stages)-1].OutputReads
+}
+
+// lastStats returns the statistics of the last stats stage, or nil.
+func (r *SampleResult) lastStats() *ReadSetStats {
+	if r.Result == nil {
+		return nil
+	}
+	for i := len(r.Result.Stages) - 1; i >= 0; i-- {
+		if s := r.Result.Stages[i].Stats; s != nil {
+			return s
+		}
+	}
+	return nil
+}
+
+// BatchResult is the outcome of a batch run, one result per sample in
+// sheet order.
+type BatchResult struct {
+	Name    string         `json:"name,omitempty"`
+	Stages  []string       `json:"stages"`
+	Samples []SampleResult `json:"samples"`
+}
+
+// Failed returns the number of samples whose pipeline failed.
+func (b *BatchResult) Failed() int {
+	n := 0
+	for _, s := range b.Samples {
+		if s.Error != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// RunBatch runs a pipeline over every sample of a sheet, one sample after
+// another, each with its own spec (see WorkflowSpec.ForSample) and work
+// directory, so that a rerun resumes each sample where it stopped. Every
+// sample spec is checked before any runs, and output files shared by two
+// samples are refused. Unless opts.KeepGoing is set, the batch stops at
+// the first failed sample and returns the results so far with its error.
+//
+// Aria equivalent:
+//
+//	fn run_batch(spec: Spec, sheet: SampleSheet, opts: BatchOptions) -> Result<BatchResult, WorkflowError>
+//	  ensures result.is_ok() implies result.unwrap().samples.len() == sheet.samples.len()
+func RunBatch(spec *WorkflowSpec, sheet *SampleSheet, opts BatchOptions) (*BatchResult, error) {
+	logw := opts.Log
+	if logw == nil {
+		logw = io.Discard
+	}
+	specs := make([]*WorkflowSpec, len(sheet.Samples))
+	outputs := make(map[string]string)
+	for i, sample := range sheet.Samples {
+		s, err := spec.ForSample(sample)
+		if err != nil {
+			return nil, err
+		}
+		for _, stage := range s.Stages {
+			for _, param := range []string{"output", "html"} {
+				name, ok := stage.String(param)
+				if !ok {
+					continue
+				}
+				path := s.Path(name)
+				if other, dup := outputs[path]; dup {
+					return nil, fmt.Errorf("samples %s and %s both write %s; use %s in the file name", other, sample.ID, path, SamplePlaceholder)
+				}
+				outputs[path] = sample.ID
+			}
+		}
+		specs[i] = s
+	}
+
+	batch := &BatchResult{Name: spec.Name, Stages: make([]string, len(spec.Stages)), Samples: make([]SampleResult, 0, len(specs))}
+	for i, stage := range spec.Stages {
+		batch.Stages[i] = stage.Name
+	}
+	for i, s := range specs {
+		sample := sheet.Samples[i]
+		fmt.Fprintf(logw, "Sample %s (%d/%d): %s\n", sample.ID, i+1, len(specs), sample.FASTQ)
+		result, err := RunWorkflow(s, opts.WorkflowOptions)
+		sr := SampleResult{Sample: sample.ID, FASTQ: sample.FASTQ, Result: result}
+		if err != nil {
+			sr.Error = err.Error()
+			batch.Samples = append(batch.Samples, sr)
+			if !opts.KeepGoing {
+				return batch, fmt.Errorf("sample %s: %w", sample.ID, err)
+			}
+			fmt.Fprintf(logw, "Sample %s failed: %v\n", sample.ID, err)
+			continue
+		}
+		batch.Samples = append(batch.Samples, sr)
+	}
+	return batch, nil
+}
+
+// WriteTSV writes one summary row per sample: its reads in and out, the
+// reads each stage removed, and the read count, mean length and mean
+// quality of the last stats stage.
+func (b *BatchResult) WriteTSV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	header := []string{"sample", "status", "input_reads", "output_reads", "kept"}
+	for _, stage := range b.Stages {
+		header = append(header, stage+"_removed")
+	}
+	header = append(header, "reads", "mean_length", "mean_quality")
+	fmt.Fprintln(bw, strings.Join(header, "\t"))
+	for _, row := range b.rows() {
+		fmt.Fprintln(bw, strings.Join(row, "\t"))
+	}
+	return bw.Flush()
+}
+
+// rows returns the summary rows of WriteTSV.
+func (b *BatchResult) rows() [][]string {
+	rows := make([][]string, 0, len(b.Samples))
+	for _, s := range b.Samples {
+		status := "done"
+		if s.Error != "" {
+			status = "failed"
+		}
+		in, out := s.InputReads(), s.OutputReads()
+		kept := "-"
+		if in > 0 {
+			kept = strconv.FormatFloat(float64(out)/float64(in), 'f', 4, 64)
+		}
+		row := []string{s.Sample, status, strconv.Itoa(in), strconv.Itoa(out), kept}
+		for i := range b.Stages {
+			removed := "-"
+			if s.Result != nil && i < len(s.Result.Stages) {
+				removed = strconv.Itoa(s.Result.Stages[i].Removed)
+			}
+			row = append(row, removed)
+		}
+		if st := s.lastStats(); st != nil {
+			row = append(row, strconv.Itoa(st.Count), strconv.FormatFloat(st.MeanLength, 'f', 2, 64), strconv.FormatFloat(st.MeanQuality, 'f', 2, 64))
+		} else {
+			row = append(row, "-", "-", "-")
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// BatchHTMLReport renders a batch as an HTML report: the summary table of
+// WriteTSV, bar charts of the reads kept and the mean quality per sample,
+// and the errors of failed samples.
+func BatchHTMLReport(title string, b *BatchResult) *HTMLReport {
+	page := report.New(title)
+	headers := []string{"Sample", "Status", "Input reads", "Output reads", "Kept"}
+	for _, stage := range b.Stages {
+		headers = append(headers, stage+" removed")
+	}
+	headers = append(headers, "Reads", "Mean length", "Mean quality")
+	page.Add(report.Section{
+		Title: "Samples",
+		Text:  fmt.Sprintf("%d samples, %d failed.", len(b.Samples), b.Failed()),
+		Table: &report.Table{Headers: headers, Rows: b.rows()},
+	})
+
+	kept := report.BarChart{XLabel: "Sample", YLabel: "Fraction of reads kept"}
+	meanQuality := report.BarChart{XLabel: "Sample", YLabel: "Mean quality"}
+	var failures [][]string
+	for _, s := range b.Samples {
+		if s.Error != "" {
+			failures = append(failures, []string{s.Sample, s.Error})
+			continue
+		}
+		if in := s.InputReads(); in > 0 {
+			kept.Labels = append(kept.Labels, s.Sample)
+			kept.Values = append(kept.Values, float64(s.OutputReads())/float64(in))
+		}
+		if st := s.lastStats(); st != nil {
+			meanQuality.Labels = append(meanQuality.Labels, s.Sample)
+			meanQuality.Values = append(meanQuality.Values, st.MeanQuality)
+		}
+	}
+	if len(kept.Values) > 0 {
+		page.Add(report.Section{Title: "Reads kept", Charts: []report.Chart{kept}})
+	}
+	if len(meanQuality.Values) > 0 {
+		page.Add(report.Section{Title: "Mean quality", Text: "From the last stats stage of each sample.", Charts: []report.Chart{meanQuality}})
+	}
+	if len(failures) > 0 {
+		page.Add(report.Section{Title: "Failed samples", Table: &report.Table{Headers: []string{"Sample", "Error"}, Rows: failures}})
+	}
+	return page
+}
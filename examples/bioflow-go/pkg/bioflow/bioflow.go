@@ -22,9 +22,9 @@ package bioflow
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 
 	"github.com/aria-lang/bioflow-go/internal/alignment"
@@ -36,22 +36,52 @@ import (
 
 // Re-export types for convenience
 type (
-	Sequence      = sequence.Sequence
-	SequenceType  = sequence.SequenceType
-	Alignment     = alignment.Alignment
-	ScoringMatrix = alignment.ScoringMatrix
-	KMerCounter   = kmer.Counter
-	KMerCount     = kmer.KMerCount
-	QualityScores = quality.Scores
-	QualityStats  = quality.Stats
-	Filter        = quality.Filter
+	Sequence         = sequence.Sequence
+	SequenceType     = sequence.SequenceType
+	Alignment        = alignment.Alignment
+	AlignmentSummary = alignment.Summary
+	AlignmentLimits  = alignment.Limits
+	RealignOptions   = alignment.RealignOptions
+	Realignment      = alignment.Realignment
+	ScoringMatrix    = alignment.ScoringMatrix
+	AmbiguityMode    = alignment.AmbiguityMode
+	KarlinAltschul   = alignment.KarlinAltschul
+	ScoreProfile     = alignment.ScoreProfile
+
+	ScoreProfileOptions = alignment.ScoreProfileOptions
+	SearchSpace         = alignment.SearchSpace
+	Significance        = alignment.Significance
+	KMerCounter         = kmer.Counter
+	PackedKMerCounter   = kmer.PackedCounter
+	KMerCount           = kmer.KMerCount
+	SpacedSeed          = kmer.SpacedSeed
+	QualityScores       = quality.Scores
+	QualityStats        = quality.Stats
+	Filter              = quality.Filter
+	TrimAndFilterResult = quality.TrimAndFilterResult
 )
 
+// ErrAlignmentTooLarge is returned when an alignment exceeds its cell budget.
+var ErrAlignmentTooLarge = alignment.ErrTooLarge
+
+// ErrTooManyAnchors is returned when two sequences share more k-mer
+// positions than AnchorOptions.MaxAnchors allows.
+var ErrTooManyAnchors = kmer.ErrTooManyAnchors
+
 // Constants
 const (
 	DNA     = sequence.DNA
 	RNA     = sequence.RNA
 	Unknown = sequence.Unknown
+
+	// ProteinType and AmbiguousType are reported by DetectType; the names
+	// avoid clashing with the Protein type.
+	ProteinType   = sequence.Protein
+	AmbiguousType = sequence.Ambiguous
+
+	AmbiguityMismatch = alignment.AmbiguityMismatch
+	AmbiguityNeutral  = alignment.AmbiguityNeutral
+	AmbiguityIUPAC    = alignment.AmbiguityIUPAC
 )
 
 // NewSequence creates a new DNA sequence.
@@ -64,6 +94,12 @@ func NewSequenceWithID(bases, id string) (*Sequence, error) {
 	return sequence.WithID(bases, id)
 }
 
+// DetectType classifies raw input as DNA, RNA, protein or ambiguous by
+// its alphabet composition.
+func DetectType(bases string) SequenceType {
+	return sequence.DetectType(bases)
+}
+
 // NewRNASequence creates a new RNA sequence.
 func NewRNASequence(bases string) (*Sequence, error) {
 	return sequence.WithMetadata(bases, "", "", sequence.RNA)
@@ -84,16 +120,90 @@ func AlignWithScoring(seq1, seq2 *Sequence, scoring *ScoringMatrix) (*Alignment,
 	return alignment.SmithWaterman(seq1, seq2, scoring)
 }
 
+// AlignGlobalWithScoring performs global alignment with custom scoring.
+func AlignGlobalWithScoring(seq1, seq2 *Sequence, scoring *ScoringMatrix) (*Alignment, error) {
+	return alignment.NeedlemanWunsch(seq1, seq2, scoring)
+}
+
+// AlignContext performs local alignment with custom scoring, bounded by
+// ctx and limits, in an "align" span.
+func AlignContext(ctx context.Context, seq1, seq2 *Sequence, scoring *ScoringMatrix, limits AlignmentLimits) (*Alignment, error) {
+	ctx, span := alignSpan(ctx, "local", seq1, seq2)
+	a, err := alignment.SmithWatermanContext(ctx, seq1, seq2, scoring, limits)
+	EndSpan(span, err)
+	return a, err
+}
+
+// AlignGlobalContext performs global alignment with custom scoring,
+// bounded by ctx and limits, in an "align" span.
+func AlignGlobalContext(ctx context.Context, seq1, seq2 *Sequence, scoring *ScoringMatrix, limits AlignmentLimits) (*Alignment, error) {
+	ctx, span := alignSpan(ctx, "global", seq1, seq2)
+	a, err := alignment.NeedlemanWunschContext(ctx, seq1, seq2, scoring, limits)
+	EndSpan(span, err)
+	return a, err
+}
+
+// RealignIndels re-aligns a read around its indels and left-normalizes
+// its CIGAR. refStart is the 0-based position of the first aligned base.
+func RealignIndels(reference, read *Sequence, refStart int, cigar string, opts RealignOptions) (*Realignment, error) {
+	return alignment.RealignIndels(reference, read, refStart, cigar, opts)
+}
+
+// ParseAmbiguityMode parses an ambiguity mode: mismatch, neutral or iupac.
+func ParseAmbiguityMode(s string) (AmbiguityMode, error) {
+	return alignment.ParseAmbiguityMode(s)
+}
+
+// LocalScoreProfile computes a local alignment score, its coordinates and
+// a per-band score profile without a full traceback.
+func LocalScoreProfile(seq1, seq2 *Sequence, scoring *ScoringMatrix, opts ScoreProfileOptions) (*ScoreProfile, error) {
+	return alignment.LocalScoreProfile(seq1, seq2, scoring, opts)
+}
+
+// LocalScoreProfileContext computes a score profile as LocalScoreProfile
+// does, bounded by ctx.
+func LocalScoreProfileContext(ctx context.Context, seq1, seq2 *Sequence, scoring *ScoringMatrix, opts ScoreProfileOptions) (*ScoreProfile, error) {
+	return alignment.LocalScoreProfileContext(ctx, seq1, seq2, scoring, opts)
+}
+
 // DefaultScoring returns the default DNA scoring matrix.
 func DefaultScoring() *ScoringMatrix {
 	return alignment.DefaultDNA()
 }
 
+// AlignmentStatistics returns the Karlin-Altschul parameters of a scoring
+// matrix, used for bit scores and E-values of local alignments.
+func AlignmentStatistics(scoring *ScoringMatrix) (*KarlinAltschul, error) {
+	return alignment.NewKarlinAltschul(scoring)
+}
+
 // CountKMers counts k-mers in a sequence.
 func CountKMers(seq *Sequence, k int) (*KMerCounter, error) {
 	return kmer.CountKMers(seq, k)
 }
 
+// CountStrandedKMers counts the k-mers of the strands selected without
+// merging reverse complements, optionally skipping soft-masked bases.
+func CountStrandedKMers(seq *Sequence, k int, strand Strand, skipSoftMasked bool) (*KMerCounter, error) {
+	return kmer.CountStrandedKMers(seq, k, strand, skipSoftMasked)
+}
+
+// CountPackedKMers counts k-mers of up to 32 bases keyed by their 2-bit
+// packed codes, which takes far less memory than CountStrandedKMers.
+func CountPackedKMers(seq *Sequence, k int, strand Strand, skipSoftMasked bool) (*PackedKMerCounter, error) {
+	return kmer.CountPackedKMers(seq, k, strand, skipSoftMasked)
+}
+
+// EncodeKMer packs a k-mer of up to 32 bases into 2 bits per base.
+func EncodeKMer(s string) (uint64, error) {
+	return kmer.Encode(s)
+}
+
+// DecodeKMer unpacks a k-mer of length k packed by EncodeKMer.
+func DecodeKMer(code uint64, k int) string {
+	return kmer.Decode(code, k)
+}
+
 // MostFrequentKMers returns the n most frequent k-mers.
 func MostFrequentKMers(seq *Sequence, k, n int) ([]KMerCount, error) {
 	return kmer.MostFrequentKMers(seq, k, n)
@@ -109,6 +219,21 @@ func SharedKMers(seq1, seq2 *Sequence, k int) ([]string, error) {
 	return kmer.SharedKMers(seq1, seq2, k)
 }
 
+// ParseSpacedSeed parses a spaced-seed pattern such as "1101101".
+func ParseSpacedSeed(pattern string) (*SpacedSeed, error) {
+	return kmer.ParseSpacedSeed(pattern)
+}
+
+// CountSpacedKMers counts the spaced k-mers of a sequence.
+func CountSpacedKMers(seq *Sequence, seed *SpacedSeed) (*KMerCounter, error) {
+	return kmer.CountSpacedKMers(seq, seed)
+}
+
+// SpacedKMerDistance calculates the Jaccard distance over spaced k-mers.
+func SpacedKMerDistance(seq1, seq2 *Sequence, seed *SpacedSeed) (float64, error) {
+	return kmer.SpacedJaccardDistance(seq1, seq2, seed)
+}
+
 // NewQualityScores creates quality scores from an array.
 func NewQualityScores(scores []int) (*QualityScores, error) {
 	return quality.New(scores)
@@ -134,6 +259,30 @@ func StrictFilter() *Filter {
 	return quality.StrictFilter()
 }
 
+// Adapter matching defaults of a Filter.
+const (
+	DefaultAdapterErrorRate  = quality.DefaultAdapterErrorRate
+	DefaultAdapterMinOverlap = quality.DefaultAdapterMinOverlap
+)
+
+// DefaultTrimAdapters returns the Illumina TruSeq and Nextera adapters
+// trimmed by a Filter.
+func DefaultTrimAdapters() []Adapter {
+	return quality.DefaultAdapters()
+}
+
+// ParseAdapters resolves a comma-separated list of adapter sets (truseq,
+// nextera or default) and adapter sequences for a Filter to trim.
+func ParseAdapters(spec string) ([]Adapter, error) {
+	return quality.ParseAdapters(spec)
+}
+
+// SequenceStatistics holds the statistics of a sequence.
+type SequenceStatistics = stats.SequenceStats
+
+// SequenceSetStatistics holds the statistics of a set of sequences.
+type SequenceSetStatistics = stats.SequenceSetStats
+
 // SequenceStats calculates statistics for a sequence.
 func SequenceStats(seq *Sequence) *stats.SequenceStats {
 	return stats.FromSequence(seq)
@@ -144,11 +293,114 @@ func SequenceSetStats(sequences []*Sequence) (*stats.SequenceSetStats, error) {
 	return stats.FromSequences(sequences)
 }
 
+// ReadSetStats holds length and quality statistics for a set of reads.
+type ReadSetStats = stats.ReadSetStats
+
+// PositionQuality summarizes the quality scores at one read position.
+type PositionQuality = stats.PositionQuality
+
+// PerPositionQuality aggregates quality scores by read position.
+func PerPositionQuality(reads []*Read) []PositionQuality {
+	qualities := make([]*QualityScores, len(reads))
+	for i, r := range reads {
+		qualities[i] = r.Quality
+	}
+	return stats.PerPositionQuality(qualities)
+}
+
+// WritePositionQualityTSV writes per-position quality statistics as TSV.
+func WritePositionQualityTSV(w io.Writer, positions []PositionQuality) error {
+	return stats.WritePositionQualityTSV(w, positions)
+}
+
+// ReadStats calculates length and quality statistics for a set of reads.
+func ReadStats(reads []*Read) (*ReadSetStats, error) {
+	sequences := make([]*Sequence, len(reads))
+	qualities := make([]*QualityScores, len(reads))
+	for i, r := range reads {
+		sequences[i] = r.Sequence
+		qualities[i] = r.Quality
+	}
+	return stats.FromReads(sequences, qualities)
+}
+
+// BootstrapOptions configures bootstrap resampling.
+type BootstrapOptions = stats.BootstrapOptions
+
+// ConfidenceInterval is a point estimate with lower and upper bounds.
+type ConfidenceInterval = stats.ConfidenceInterval
+
+// Intervals holds bootstrap confidence intervals for set means.
+type Intervals = stats.Intervals
+
+// DefaultBootstrapOptions returns 1000 resamples at the 95% level.
+func DefaultBootstrapOptions() BootstrapOptions {
+	return stats.DefaultBootstrapOptions()
+}
+
+// BootstrapSequences computes confidence intervals for the mean length
+// and GC content of sequences.
+func BootstrapSequences(sequences []*Sequence, opts BootstrapOptions) (*Intervals, error) {
+	return stats.BootstrapSequences(sequences, opts)
+}
+
+// BootstrapReads computes confidence intervals for the mean length, GC
+// content and quality of reads.
+func BootstrapReads(reads []*Read, opts BootstrapOptions) (*Intervals, error) {
+	sequences, qualities := splitReads(reads)
+	return stats.BootstrapReads(sequences, qualities, opts)
+}
+
+// BootstrapSequencesContext computes intervals as BootstrapSequences
+// does, and stops with the context's error once ctx is done.
+func BootstrapSequencesContext(ctx context.Context, sequences []*Sequence, opts BootstrapOptions) (*Intervals, error) {
+	return stats.BootstrapSequencesContext(ctx, sequences, opts)
+}
+
+// BootstrapReadsContext computes intervals as BootstrapReads does, and
+// stops with the context's error once ctx is done.
+func BootstrapReadsContext(ctx context.Context, reads []*Read, opts BootstrapOptions) (*Intervals, error) {
+	sequences, qualities := splitReads(reads)
+	return stats.BootstrapReadsContext(ctx, sequences, qualities, opts)
+}
+
+// SetComparison compares the length, GC and quality distributions of two
+// sequence or read sets.
+type SetComparison = stats.SetComparison
+
+// MetricComparison compares one measurement between two sets.
+type MetricComparison = stats.MetricComparison
+
+// CompareSequenceSets compares the length and GC content distributions of
+// two sequence sets.
+func CompareSequenceSets(a, b []*Sequence) (*SetComparison, error) {
+	return stats.CompareSequenceSets(a, b)
+}
+
+// CompareReadSets compares the length, GC content and mean quality
+// distributions of two read sets.
+func CompareReadSets(a, b []*Read) (*SetComparison, error) {
+	seqA, qualA := splitReads(a)
+	seqB, qualB := splitReads(b)
+	return stats.CompareReadSets(seqA, qualA, seqB, qualB)
+}
+
+// splitReads separates reads into their sequences and quality scores.
+func splitReads(reads []*Read) ([]*Sequence, []*QualityScores) {
+	sequences := make([]*Sequence, len(reads))
+	qualities := make([]*QualityScores, len(reads))
+	for i, r := range reads {
+		sequences[i] = r.Sequence
+		qualities[i] = r.Quality
+	}
+	return sequences, qualities
+}
+
 // ReadFASTA reads sequences from a FASTA file.
 func ReadFASTA(filename string) ([]*Sequence, error) {
-	file, err := os.Open(filename)
+	file, err := OpenInput(filename)
 	if err != nil {
-		return nil, fmt.Errorf("opening file: %w", err)
+		return nil, err
 	}
 	defer file.Close()
 
@@ -157,6 +409,12 @@ func ReadFASTA(filename string) ([]*Sequence, error) {
 
 // ParseFASTA parses FASTA format from a reader.
 func ParseFASTA(r io.Reader) ([]*Sequence, error) {
+	return ParseFASTAWithPolicy(r, StrictPolicy())
+}
+
+// ParseFASTAWithPolicy parses FASTA format from a reader, normalizing and
+// validating the bases of each record under policy.
+func ParseFASTAWithPolicy(r io.Reader, policy Policy) ([]*Sequence, error) {
 	sequences := make([]*Sequence, 0)
 	scanner := bufio.NewScanner(r)
 
@@ -165,11 +423,11 @@ func ParseFASTA(r io.Reader) ([]*Sequence, error) {
 
 	flushSequence := func() error {
 		if currentBases.Len() > 0 {
-			seq, err := sequence.WithMetadata(
+			seq, err := sequence.NewWithPolicy(
 				currentBases.String(),
 				currentID,
 				currentDesc,
-				sequence.DNA,
+				policy,
 			)
 			if err != nil {
 				return err
@@ -221,26 +479,29 @@ func ParseFASTA(r io.Reader) ([]*Sequence, error) {
 
 // WriteFASTA writes sequences to a FASTA file.
 func WriteFASTA(filename string, sequences []*Sequence) error {
-	file, err := os.Create(filename)
+	file, err := createFile(filename)
 	if err != nil {
-		return fmt.Errorf("creating file: %w", err)
+		return err
 	}
-	defer file.Close()
+	defer file.Abort()
 
 	for _, seq := range sequences {
-		_, err := file.WriteString(seq.ToFASTA())
+		_, err := io.WriteString(file, seq.ToFASTA())
 		if err != nil {
 			return fmt.Errorf("writing sequence: %w", err)
 		}
 	}
+	file.AddRecords(len(sequences))
 
-	return nil
+	return file.Close()
 }
 
-// Read represents a sequencing read with sequence and quality.
+// Read represents a sequencing read with sequence and quality. Group is
+// set by AssignReadGroups and shared by all reads of a group.
 type Read struct {
 	Sequence *Sequence
 	Quality  *QualityScores
+	Group    *ReadGroup
 }
 
 // NewRead creates a new read from sequence and quality.
@@ -267,6 +528,12 @@ func NewRead(bases string, qualityScores []int) (*Read, error) {
 
 // ParseFASTQ parses FASTQ format from a reader.
 func ParseFASTQ(r io.Reader) ([]*Read, error) {
+	return ParseFASTQWithPolicy(r, StrictPolicy())
+}
+
+// ParseFASTQWithPolicy parses FASTQ format from a reader, normalizing and
+// validating the bases of each read under policy.
+func ParseFASTQWithPolicy(r io.Reader, policy Policy) ([]*Read, error) {
 	reads := make([]*Read, 0)
 	scanner := bufio.NewScanner(r)
 
@@ -293,7 +560,7 @@ func ParseFASTQ(r io.Reader) ([]*Read, error) {
 			qualStr = line
 
 			// Create read
-			seq, err := sequence.WithID(bases, id)
+			seq, err := newReadSequence(bases, id, policy)
 			if err != nil {
 				return nil, fmt.Errorf("line %d: %w", lineNum, err)
 			}
@@ -319,18 +586,60 @@ func ParseFASTQ(r io.Reader) ([]*Read, error) {
 
 // ReadFASTQ reads reads from a FASTQ file.
 func ReadFASTQ(filename string) ([]*Read, error) {
-	file, err := os.Open(filename)
+	file, err := OpenInput(filename)
 	if err != nil {
-		return nil, fmt.Errorf("opening file: %w", err)
+		return nil, err
 	}
 	defer file.Close()
 
 	return ParseFASTQ(file)
 }
 
-// Pipeline represents a processing pipeline for reads.
+// FormatFASTQ writes reads in FASTQ format with Phred+33 qualities.
+func FormatFASTQ(w io.Writer, reads []*Read) error {
+	fw := NewFASTQWriter(w)
+	for _, read := range reads {
+		if err := fw.Write(read); err != nil {
+			return err
+		}
+	}
+	return fw.Flush()
+}
+
+// WriteFASTQ writes reads to a FASTQ file.
+func WriteFASTQ(filename string, reads []*Read) error {
+	file, err := createFile(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+
+	if err := FormatFASTQ(file, reads); err != nil {
+		return err
+	}
+	file.AddRecords(len(reads))
+	return file.Close()
+}
+
+// Pipeline represents a processing pipeline for reads: a quality filter
+// followed by any stages added with AddStage.
 type Pipeline struct {
-	filter *Filter
+	filter  *Filter
+	stages  []ReadProcessor
+	monitor Monitor
+}
+
+// SetMonitor sets the events the pipeline emits while processing. The
+// filter is reported as a stage named "filter".
+func (p *Pipeline) SetMonitor(m Monitor) *Pipeline {
+	p.monitor = m
+	return p
+}
+
+// AddStage appends a processing stage, run by Run after filtering.
+func (p *Pipeline) AddStage(stage ReadProcessor) *Pipeline {
+	p.stages = append(p.stages, stage)
+	return p
 }
 
 // NewPipeline creates a new processing pipeline.
@@ -343,15 +652,88 @@ func NewPipeline(filter *Filter) *Pipeline {
 
 // ProcessReads processes reads through the pipeline.
 func (p *Pipeline) ProcessReads(reads []*Read) (*quality.BatchFilterResult, error) {
-	sequences := make([]*Sequence, len(reads))
-	qualities := make([]*QualityScores, len(reads))
+	return p.ProcessReadsContext(context.Background(), reads)
+}
 
-	for i, read := range reads {
-		sequences[i] = read.Sequence
-		qualities[i] = read.Quality
+// processBlock is the number of reads filtered at a time by
+// ProcessReadsContext, between the events of their progress.
+const processBlock = 4096
+
+// ProcessReadsContext processes reads as ProcessReads does, and stops with
+// the context's error once ctx is done.
+func (p *Pipeline) ProcessReadsContext(ctx context.Context, reads []*Read) (*quality.BatchFilterResult, error) {
+	result := &quality.BatchFilterResult{
+		PassedSequences: make([]*Sequence, 0),
+		PassedQualities: make([]*QualityScores, 0),
+		FailedIndices:   make([]int, 0),
+		FailReasons:     make(map[int]string),
 	}
 
-	return p.filter.BatchFilter(sequences, qualities)
+	mon := p.monitor.stage("filter", 0, 1)
+	for start := 0; start < len(reads); start += processBlock {
+		block := reads[start:min(start+processBlock, len(reads))]
+		sequences := make([]*Sequence, len(block))
+		qualities := make([]*QualityScores, len(block))
+		for i, read := range block {
+			sequences[i], qualities[i] = read.Sequence, read.Quality
+		}
+		batch, err := p.filter.BatchFilterContext(ctx, sequences, qualities)
+		if err != nil {
+			return nil, err
+		}
+
+		failed := 0
+		for i, read := range block {
+			if failed < len(batch.FailedIndices) && batch.FailedIndices[failed] == i {
+				mon.record(read, false, batch.FailReasons[i])
+				result.FailedIndices = append(result.FailedIndices, start+i)
+				result.FailReasons[start+i] = batch.FailReasons[i]
+				failed++
+				continue
+			}
+			mon.record(read, true, "")
+		}
+		result.PassedSequences = append(result.PassedSequences, batch.PassedSequences...)
+		result.PassedQualities = append(result.PassedQualities, batch.PassedQualities...)
+		result.AdapterTrimmed += batch.AdapterTrimmed
+	}
+
+	result.TotalProcessed = len(reads)
+	result.PassedCount = len(result.PassedSequences)
+	result.FailedCount = len(result.FailedIndices)
+	mon.finish(result.TotalProcessed, result.PassedCount)
+
+	return result, nil
+}
+
+// Run filters reads and passes those that pass through each added stage
+// in turn, returning the reads left at the end.
+func (p *Pipeline) Run(reads []*Read) ([]*Read, error) {
+	stages := len(p.stages) + 1
+	mon := p.monitor.stage("filter", 0, stages)
+	kept := make([]*Read, 0, len(reads))
+	for i, read := range reads {
+		result, err := p.filter.Check(read.Sequence, read.Quality)
+		if err != nil {
+			return nil, fmt.Errorf("read %d: %w", i+1, err)
+		}
+		mon.record(read, result.Passed, result.Reason)
+		if result.Passed {
+			kept = append(kept, read)
+		}
+	}
+	mon.finish(len(reads), len(kept))
+
+	for i, stage := range p.stages {
+		mon := p.monitor.stage(fmt.Sprintf("stage %d", i+1), i+1, stages)
+		in := len(kept)
+		var err error
+		if kept, err = stage.ProcessReads(kept); err != nil {
+			return nil, fmt.Errorf("stage %d: %w", i+1, err)
+		}
+		mon.finish(in, len(kept))
+	}
+	return kept, nil
 }
 
 // Version returns the BioFlow version.
@@ -0,0 +1,324 @@
+package bioflow
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFASTQ returns n FASTQ records of 60 bases; every third read is of
+// low quality and fails the default filter.
+func testFASTQ(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		q := "I"
+		if i%3 == 2 {
+			q = "#"
+		}
+		fmt.Fprintf(&b, "@r%d\n%s\n+\n%s\n", i, strings.Repeat("ACGTTGCA", 8)[:60], strings.Repeat(q, 60))
+	}
+	return b.String()
+}
+
+// writeTestFile writes data to a file in a temporary directory.
+func writeTestFile(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+	return path
+}
+
+func TestProcessFASTQFileResume(t *testing.T) {
+	input := writeTestFile(t, "reads.fq", testFASTQ(20))
+	cpPath := filepath.Join(t.TempDir(), "reads.checkpoint")
+
+	want, err := NewPipeline(nil).ProcessFASTQFile(input, StreamOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, &StreamResult{TotalProcessed: 20, PassedCount: 14, FailedCount: 6}, want)
+
+	// The first run is killed after its 8th read; the last checkpoint
+	// is that after the 6th.
+	errKilled := errors.New("killed")
+	seen := 0
+	_, err = NewPipeline(nil).ProcessFASTQFile(input, StreamOptions{
+		CheckpointPath:  cpPath,
+		CheckpointEvery: 3,
+		OnRead: func(*Read, *TrimAndFilterResult) error {
+			if seen++; seen == 8 {
+				return errKilled
+			}
+			return nil
+		},
+	})
+	require.ErrorIs(t, err, errKilled)
+
+	cp, err := LoadCheckpoint(cpPath)
+	require.NoError(t, err)
+	require.NotNil(t, cp)
+	file, err := os.Open(input)
+	require.NoError(t, err)
+	defer file.Close()
+	reader := NewFASTQReader(file)
+	for i := 0; i < 6; i++ {
+		_, err := reader.Next()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, reader.Offset(), cp.Offset)
+	assert.Equal(t, 24, cp.Line)
+	assert.Equal(t, reader.Line(), cp.Line)
+	assert.Equal(t, 6, cp.RecordsProcessed)
+	assert.Equal(t, 4, cp.Passed)
+	assert.Equal(t, 2, cp.Failed)
+	assert.Equal(t, input, cp.Input)
+
+	// Other filter settings would filter the rest under another rule set.
+	strict := DefaultFilter()
+	strict.MinQuality = 30
+	_, err = NewPipeline(strict).ProcessFASTQFile(input, StreamOptions{CheckpointPath: cpPath})
+	assert.ErrorContains(t, err, "other filter settings")
+	adapters := DefaultFilter()
+	adapters.Adapters = DefaultAdapters
+	_, err = NewPipeline(adapters).ProcessFASTQFile(input, StreamOptions{CheckpointPath: cpPath})
+	assert.ErrorContains(t, err, "other filter settings")
+
+	// Resuming reads the rest of the file and ends with the counters of
+	// the uninterrupted run; the checkpoint is then removed.
+	var ids []string
+	got, err := NewPipeline(nil).ProcessFASTQFile(input, StreamOptions{
+		CheckpointPath:  cpPath,
+		CheckpointEvery: 3,
+		OnRead: func(read *Read, _ *TrimAndFilterResult) error {
+			ids = append(ids, read.Sequence.ID)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, want.TotalProcessed, got.TotalProcessed)
+	assert.Equal(t, want.PassedCount, got.PassedCount)
+	assert.Equal(t, want.FailedCount, got.FailedCount)
+	assert.Equal(t, cp.Offset, got.ResumedFrom)
+	require.Len(t, ids, 14)
+	assert.Equal(t, "r6", ids[0])
+	assert.NoFileExists(t, cpPath)
+
+	missing, err := LoadCheckpoint(cpPath)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestProcessFASTQFileResumeCompressed(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, err := zw.Write([]byte(testFASTQ(10)))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	input := writeTestFile(t, "reads.fq.gz", gz.String())
+	cpPath := filepath.Join(t.TempDir(), "reads.checkpoint")
+
+	seen := 0
+	_, err = NewPipeline(nil).ProcessFASTQFile(input, StreamOptions{
+		CheckpointPath:  cpPath,
+		CheckpointEvery: 4,
+		OnRead: func(*Read, *TrimAndFilterResult) error {
+			if seen++; seen == 5 {
+				return io.ErrClosedPipe
+			}
+			return nil
+		},
+	})
+	require.ErrorIs(t, err, io.ErrClosedPipe)
+	cp, err := LoadCheckpoint(cpPath)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(testFASTQ(4))), cp.Offset, "offsets count uncompressed bytes")
+
+	var ids []string
+	got, err := NewPipeline(nil).ProcessFASTQFile(input, StreamOptions{
+		CheckpointPath: cpPath,
+		OnRead: func(read *Read, _ *TrimAndFilterResult) error {
+			ids = append(ids, read.Sequence.ID)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 10, got.TotalProcessed)
+	assert.Equal(t, []string{"r4", "r5", "r6", "r7", "r8", "r9"}, ids)
+}
+
+func TestCheckpointMatches(t *testing.T) {
+	input := writeTestFile(t, "reads.fq", testFASTQ(4))
+	cpPath := filepath.Join(t.TempDir(), "reads.checkpoint")
+	info, err := os.Stat(input)
+	require.NoError(t, err)
+
+	cp := &Checkpoint{Input: input, InputSize: info.Size(), InputModTime: info.ModTime().UTC(), Offset: 0}
+	require.NoError(t, cp.Save(cpPath))
+	saved, err := LoadCheckpoint(cpPath)
+	require.NoError(t, err)
+	assert.True(t, saved.Matches(info))
+	assert.False(t, saved.UpdatedAt.IsZero())
+	entries, err := os.ReadDir(filepath.Dir(cpPath))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary file is left behind")
+
+	// A file of the same size but touched since, or of another size,
+	// does not match.
+	later := info.ModTime().Add(time.Minute)
+	require.NoError(t, os.Chtimes(input, later, later))
+	touched, err := os.Stat(input)
+	require.NoError(t, err)
+	assert.False(t, saved.Matches(touched))
+
+	require.NoError(t, os.WriteFile(input, []byte(testFASTQ(5)), 0o644))
+	changed, err := os.Stat(input)
+	require.NoError(t, err)
+	assert.False(t, saved.Matches(changed))
+
+	_, err = NewPipeline(nil).ProcessFASTQFile(input, StreamOptions{CheckpointPath: cpPath})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match")
+	assert.FileExists(t, cpPath, "a checkpoint of another file is kept")
+
+	require.NoError(t, os.WriteFile(cpPath, []byte("{"), 0o644))
+	_, err = LoadCheckpoint(cpPath)
+	assert.Error(t, err)
+}
+
+func TestFASTQReader(t *testing.T) {
+	data := "@r1 first\nACGT\n+\nIIII\n\n@r2\nacgt\n+r2\n#+5I\n"
+	reader := NewFASTQReader(strings.NewReader(data))
+
+	read, err := reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "r1 first", read.Sequence.ID, "the whole header is the ID")
+	assert.Equal(t, "ACGT", read.Sequence.Bases)
+	assert.Equal(t, []int{40, 40, 40, 40}, read.Quality.Values)
+	assert.Equal(t, int64(22), reader.Offset())
+	assert.Equal(t, 4, reader.Line())
+
+	read, err = reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "ACGT", read.Sequence.Bases)
+	assert.Equal(t, []int{2, 10, 20, 40}, read.Quality.Values)
+	assert.Equal(t, int64(len(data)), reader.Offset())
+	assert.Equal(t, 9, reader.Line())
+
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+
+	// A reader started at an offset counts from it.
+	at := NewFASTQReaderAt(strings.NewReader(data[22:]), 22, 4)
+	read, err = at.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "r2", read.Sequence.ID)
+	assert.Equal(t, int64(len(data)), at.Offset())
+	assert.Equal(t, 9, at.Line())
+}
+
+func TestFASTQReaderErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"truncated after header", "@r1\nACGT\n+\nIIII\n@r2\n", "line 5: truncated record"},
+		{"truncated before quality", "@r1\nACGT\n+\nIIII\n@r2\nACGT\n+\n", "line 7: truncated record"},
+		{"no header", "r1\nACGT\n+\nIIII\n", "expected header"},
+		{"no plus line", "@r1\nACGT\nIIII\nIIII\n", "expected '+' line"},
+		{"bad base", "@r1\nACXT\n+\nIIII\n", "line 4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewFASTQReader(strings.NewReader(tt.data))
+			var err error
+			for err == nil {
+				_, err = reader.Next()
+			}
+			require.NotEqual(t, io.EOF, err)
+			assert.Contains(t, err.Error(), tt.want)
+			if strings.Contains(tt.want, "truncated") {
+				assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+			}
+		})
+	}
+
+	// A truncated final record fails a streaming run, rather than being
+	// dropped, and leaves its checkpoint for a rerun.
+	input := writeTestFile(t, "reads.fq", testFASTQ(4)+"@r4\nACGT\n")
+	cpPath := filepath.Join(t.TempDir(), "reads.checkpoint")
+	_, err := NewPipeline(nil).ProcessFASTQFile(input, StreamOptions{CheckpointPath: cpPath, CheckpointEvery: 2})
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.FileExists(t, cpPath)
+}
+
+func TestFASTQWriter(t *testing.T) {
+	newRead := func(id, desc, bases string, scores []int) *Read {
+		seq, err := NewSequence(bases)
+		require.NoError(t, err)
+		seq.ID, seq.Description = id, desc
+		qual, err := NewQualityScores(scores)
+		require.NoError(t, err)
+		return &Read{Sequence: seq, Quality: qual}
+	}
+	reads := []*Read{
+		newRead("r1", "", "ACGTACGT", []int{40, 40, 30, 30, 20, 20, 10, 2}),
+		newRead("r2", "lane 1", "GGCC", []int{0, 40, 35, 12}),
+		newRead("", "", "T", []int{25}),
+	}
+
+	var b bytes.Buffer
+	fw := NewFASTQWriter(&b)
+	for _, read := range reads {
+		require.NoError(t, fw.Write(read))
+	}
+	assert.Equal(t, 3, fw.Count())
+	assert.Empty(t, b.String(), "records are buffered until Flush")
+	require.NoError(t, fw.Flush())
+	assert.Equal(t, "@r1\nACGTACGT\n+\nII??55+#\n@r2 lane 1\nGGCC\n+\n!ID-\n@read\nT\n+\n:\n", b.String())
+
+	// The records read back as written, through both FASTQ parsers.
+	parsed, err := ParseFASTQ(bytes.NewReader(b.Bytes()))
+	require.NoError(t, err)
+	require.Len(t, parsed, 3)
+	reader := NewFASTQReader(bytes.NewReader(b.Bytes()))
+	for i, read := range reads {
+		assert.Equal(t, read.Sequence.Bases, parsed[i].Sequence.Bases)
+		assert.Equal(t, read.Quality.Values, parsed[i].Quality.Values)
+		streamed, err := reader.Next()
+		require.NoError(t, err)
+		assert.Equal(t, read.Sequence.Bases, streamed.Sequence.Bases)
+		assert.Equal(t, read.Quality.Values, streamed.Quality.Values)
+	}
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+
+	// A read trimmed by the filter is written with one score per base.
+	scores := make([]int, 80)
+	for i := range scores {
+		scores[i] = 38
+		if i >= 64 {
+			scores[i] = 3
+		}
+	}
+	long := newRead("r4", "", strings.Repeat("ACGTTGCA", 10), scores)
+	result, err := DefaultFilter().TrimAndFilter(long.Sequence, long.Quality)
+	require.NoError(t, err)
+	require.True(t, result.Passed)
+	b.Reset()
+	require.NoError(t, FormatFASTQ(&b, []*Read{{Sequence: result.TrimmedSeq, Quality: result.TrimmedQual}}))
+	parsed, err = ParseFASTQ(&b)
+	require.NoError(t, err)
+	require.Len(t, parsed, 1)
+	assert.Equal(t, result.TrimEnd-result.TrimStart, parsed[0].Sequence.Len())
+	assert.Equal(t, parsed[0].Sequence.Len(), parsed[0].Quality.Len())
+	assert.Less(t, parsed[0].Sequence.Len(), 80)
+}
@@ -0,0 +1,72 @@
+package bioflow
+
+import (
+	"io"
+
+	"github.com/aria-lang/bioflow-go/internal/bisulfite"
+)
+
+// MethylationContext is the context of a cytosine: CG, CHG or CHH.
+type MethylationContext = bisulfite.Context
+
+// Methylation contexts.
+const (
+	ContextCG  = bisulfite.CG
+	ContextCHG = bisulfite.CHG
+	ContextCHH = bisulfite.CHH
+)
+
+// BisulfiteStrand is the strand bisulfite conversion is applied to.
+type BisulfiteStrand = bisulfite.Strand
+
+// Bisulfite strands: the top converts C to T, the bottom G to A.
+const (
+	BisulfiteTop    = bisulfite.Top
+	BisulfiteBottom = bisulfite.Bottom
+)
+
+// BisulfiteOptions configures in-silico bisulfite conversion.
+type BisulfiteOptions = bisulfite.ConvertOptions
+
+// MethylationSite is a cytosine and its methylation context.
+type MethylationSite = bisulfite.Site
+
+// ContextSummary counts cytosines by methylation context.
+type ContextSummary = bisulfite.ContextSummary
+
+// ParseMethylationContext parses a context name (CG, CpG, CHG or CHH).
+func ParseMethylationContext(name string) (MethylationContext, error) {
+	return bisulfite.ParseContext(name)
+}
+
+// BisulfiteConvert returns a sequence as bisulfite converts it when its
+// cytosines are unmethylated, except those in protected contexts.
+//
+// Aria equivalent:
+//
+//	fn bisulfite_convert(seq: Sequence, options: BisulfiteOptions) -> Result<Sequence, BisulfiteError>
+func BisulfiteConvert(seq *Sequence, opts BisulfiteOptions) (*Sequence, error) {
+	return bisulfite.Convert(seq, opts)
+}
+
+// FindMethylationSites returns the cytosines of both strands of a
+// sequence with their methylation context.
+func FindMethylationSites(seq *Sequence) []MethylationSite {
+	return bisulfite.FindSites(seq)
+}
+
+// SummarizeMethylationSites counts sites by context.
+func SummarizeMethylationSites(sites []MethylationSite) ContextSummary {
+	return bisulfite.Summarize(sites)
+}
+
+// WriteMethylationSitesBED writes sites as BED6 named by context.
+func WriteMethylationSitesBED(w io.Writer, sites []MethylationSite) error {
+	return bisulfite.WriteSitesBED(w, sites)
+}
+
+// WriteCytosineReport writes sites as a Bismark-style cytosine report
+// with zero counts.
+func WriteCytosineReport(w io.Writer, sites []MethylationSite) error {
+	return bisulfite.WriteCytosineReport(w, sites)
+}
@@ -0,0 +1,70 @@
+package bioflow
+
+import (
+	"context"
+
+	"github.com/aria-lang/bioflow-go/internal/broker"
+)
+
+// Broker is a queue shared by an API server and worker processes, which
+// run the jobs the server submits.
+type Broker = broker.Broker
+
+// BrokerTask is a job sent to workers through a Broker.
+type BrokerTask = broker.Task
+
+// BrokerReply is a message from the worker running a BrokerTask.
+type BrokerReply = broker.Reply
+
+// BrokerClient submits tasks to workers and tracks them until they are
+// over.
+type BrokerClient = broker.Client
+
+// BrokerClientOptions configures a BrokerClient.
+type BrokerClientOptions = broker.ClientOptions
+
+// WorkerOptions configures ServeTasks.
+type WorkerOptions = broker.WorkerOptions
+
+// TaskHandler runs a BrokerTask in a worker.
+type TaskHandler = broker.Handler
+
+// Kinds of BrokerReply.
+const (
+	TaskStarted   = broker.Started
+	TaskProgress  = broker.Progress
+	TaskHeartbeat = broker.Heartbeat
+	TaskDone      = broker.Done
+	TaskFailed    = broker.Failed
+)
+
+// OpenBroker connects to the broker at a URL, such as
+// redis://host:6379/0.
+//
+// Aria equivalent:
+//
+//	fn open_broker(url: String) -> Result<Broker, BrokerError> with Network
+func OpenBroker(url string) (Broker, error) {
+	return broker.Open(url)
+}
+
+// NewBrokerClient creates a BrokerClient; call its Run method to receive
+// replies.
+//
+// Aria equivalent:
+//
+//	fn new_broker_client(broker: Broker, options: BrokerClientOptions) -> BrokerClient
+func NewBrokerClient(b Broker, opts BrokerClientOptions) *BrokerClient {
+	return broker.NewClient(b, opts)
+}
+
+// ServeTasks runs tasks from a broker with the handler of their kind until
+// ctx is done.
+//
+// Aria equivalent:
+//
+//	fn serve_tasks(ctx: Context, broker: Broker, options: WorkerOptions,
+//	               handlers: Map<String, TaskHandler>) -> Result<(), BrokerError> with Network
+func ServeTasks(ctx context.Context, b Broker, opts WorkerOptions, handlers map[string]TaskHandler) error {
+	return broker.Serve(ctx, b, opts, handlers)
+}
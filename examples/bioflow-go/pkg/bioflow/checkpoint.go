@@ -0,0 +1,266 @@
+package bioflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aria-lang/bioflow-go/internal/quality"
+)
+
+// DefaultCheckpointEvery is the default number of records between checkpoints.
+const DefaultCheckpointEvery = 100000
+
+// Checkpoint records the progress of a streaming job so that it can be
+// resumed after the process is killed.
+//
+// The input size and modification time are stored alongside the offset so
+// that a checkpoint is never applied to a file that has since changed, and
+// a digest of the filter settings so that reads are never filtered under
+// two rule sets.
+type Checkpoint struct {
+	Input            string    `json:"input"`
+	InputSize        int64     `json:"input_size"`
+	InputModTime     time.Time `json:"input_mod_time"`
+	Filter           string    `json:"filter"`
+	Offset           int64     `json:"offset"`
+	Line             int       `json:"line"`
+	RecordsProcessed int       `json:"records_processed"`
+	Passed           int       `json:"passed"`
+	Failed           int       `json:"failed"`
+	AdapterTrimmed   int       `json:"adapter_trimmed"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// LoadCheckpoint reads a checkpoint file. It returns nil and no error if
+// the file does not exist.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading checkpoint: %w", err)
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("parsing checkpoint: %w", err)
+	}
+	return &cp, nil
+}
+
+// Save writes the checkpoint atomically (write to a temporary file, then
+// rename) so that a crash mid-write never leaves a corrupt checkpoint.
+func (c *Checkpoint) Save(path string) error {
+	c.UpdatedAt = time.Now().UTC()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("creating checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing checkpoint: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("syncing checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing checkpoint: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replacing checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Matches reports whether the checkpoint was taken against the given file.
+func (c *Checkpoint) Matches(info os.FileInfo) bool {
+	return c.InputSize == info.Size() && c.InputModTime.Equal(info.ModTime().UTC())
+}
+
+// filterDigest returns the SHA-256 digest of a filter's settings, in hex.
+func filterDigest(f *Filter) (string, error) {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return "", fmt.Errorf("encoding filter settings: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// StreamOptions configures streaming processing of a read file.
+type StreamOptions struct {
+	// CheckpointPath enables checkpointing when non-empty. An existing
+	// checkpoint at this path is resumed from; it is removed once the
+	// input has been fully processed.
+	CheckpointPath string
+
+	// CheckpointEvery is the number of records between checkpoint saves.
+	// Defaults to DefaultCheckpointEvery.
+	CheckpointEvery int
+
+	// OnRead, if set, is called with every read and its filter result.
+	OnRead func(read *Read, result *quality.TrimAndFilterResult) error
+}
+
+// StreamResult summarizes a streaming run.
+type StreamResult struct {
+	TotalProcessed int
+	PassedCount    int
+	FailedCount    int
+	// AdapterTrimmed counts the reads an adapter was trimmed from, passed
+	// or not.
+	AdapterTrimmed int
+	// ResumedFrom is the byte offset processing resumed at, or 0 for a fresh run.
+	ResumedFrom int64
+}
+
+// PassRate returns the proportion of reads that passed filtering.
+func (r *StreamResult) PassRate() float64 {
+	if r.TotalProcessed == 0 {
+		return 0.0
+	}
+	return float64(r.PassedCount) / float64(r.TotalProcessed)
+}
+
+func (r *StreamResult) String() string {
+	return fmt.Sprintf("StreamResult { processed: %d, passed: %d (%.1f%%), failed: %d }",
+		r.TotalProcessed, r.PassedCount, r.PassRate()*100, r.FailedCount)
+}
+
+// ProcessFASTQFile streams reads from a FASTQ file, which may be gzip or
+// zstd compressed, through the pipeline without loading the file into
+// memory, optionally checkpointing progress. Checkpoint offsets count
+// uncompressed bytes.
+func (p *Pipeline) ProcessFASTQFile(filename string, opts StreamOptions) (*StreamResult, error) {
+	file, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("reading file info: %w", err)
+	}
+
+	every := opts.CheckpointEvery
+	if every <= 0 {
+		every = DefaultCheckpointEvery
+	}
+
+	digest, err := filterDigest(p.filter)
+	if err != nil {
+		return nil, err
+	}
+	cp := &Checkpoint{
+		Input:        filename,
+		InputSize:    info.Size(),
+		InputModTime: info.ModTime().UTC(),
+		Filter:       digest,
+	}
+
+	if opts.CheckpointPath != "" {
+		saved, err := LoadCheckpoint(opts.CheckpointPath)
+		if err != nil {
+			return nil, err
+		}
+		if saved != nil {
+			if !saved.Matches(info) {
+				return nil, fmt.Errorf("checkpoint %s does not match %s (file changed since checkpoint)",
+					opts.CheckpointPath, filename)
+			}
+			if saved.Filter != digest {
+				return nil, fmt.Errorf("checkpoint %s was taken with other filter settings (rerun with those, or remove it to start over)",
+					opts.CheckpointPath)
+			}
+			if err := file.SeekTo(saved.Offset); err != nil {
+				return nil, fmt.Errorf("seeking to checkpoint: %w", err)
+			}
+			cp = saved
+		}
+	}
+
+	result := &StreamResult{
+		TotalProcessed: cp.RecordsProcessed,
+		PassedCount:    cp.Passed,
+		FailedCount:    cp.Failed,
+		AdapterTrimmed: cp.AdapterTrimmed,
+		ResumedFrom:    cp.Offset,
+	}
+
+	// Counters of a resumed run continue from the checkpoint.
+	mon := p.monitor.stage("filter", 0, 1)
+	mon.event.Processed, mon.event.Passed, mon.event.Rejected = cp.RecordsProcessed, cp.Passed, cp.Failed
+
+	reader := NewFASTQReaderAt(file, cp.Offset, cp.Line)
+	for {
+		read, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		filterResult, err := p.filter.TrimAndFilter(read.Sequence, read.Quality)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", result.TotalProcessed+1, err)
+		}
+
+		mon.record(read, filterResult.Passed, filterResult.Reason)
+		result.TotalProcessed++
+		if filterResult.Adapter != "" {
+			result.AdapterTrimmed++
+		}
+		if filterResult.Passed {
+			result.PassedCount++
+		} else {
+			result.FailedCount++
+		}
+
+		if opts.OnRead != nil {
+			if err := opts.OnRead(read, filterResult); err != nil {
+				return nil, err
+			}
+		}
+
+		if opts.CheckpointPath != "" && result.TotalProcessed%every == 0 {
+			cp.Offset = reader.Offset()
+			cp.Line = reader.Line()
+			cp.RecordsProcessed = result.TotalProcessed
+			cp.Passed = result.PassedCount
+			cp.Failed = result.FailedCount
+			cp.AdapterTrimmed = result.AdapterTrimmed
+			if err := cp.Save(opts.CheckpointPath); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if opts.CheckpointPath != "" {
+		if err := os.Remove(opts.CheckpointPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("removing checkpoint: %w", err)
+		}
+	}
+	mon.finish(result.TotalProcessed, result.PassedCount)
+
+	return result, nil
+}
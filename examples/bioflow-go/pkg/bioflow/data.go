@@ -0,0 +1,53 @@
+package bioflow
+
+import (
+	"github.com/aria-lang/bioflow-go/internal/data"
+	"github.com/aria-lang/bioflow-go/internal/protein"
+	"github.com/aria-lang/bioflow-go/internal/quality"
+)
+
+// DataDirEnv is the environment variable naming a directory whose files
+// override the built-in data tables (genetic codes, codon usage tables,
+// adapter sets, restriction enzymes and substitution matrices) or add
+// tables to them.
+const DataDirEnv = data.EnvDir
+
+// DataDir returns the directory overriding the built-in data tables, or
+// "" for none.
+func DataDir() string {
+	return data.Dir()
+}
+
+// SetDataDir sets the directory overriding the built-in data tables, in
+// place of DataDirEnv. Call it on start, before tables are looked up.
+func SetDataDir(dir string) {
+	data.SetDir(dir)
+}
+
+// GeneticCode returns a genetic code by name, mapping DNA codons to
+// one-letter amino acids: an NCBI translation table (standard,
+// vertebrate-mitochondrial, bacterial, ...) or one in the data directory.
+func GeneticCode(name string) (map[string]byte, error) {
+	return protein.GeneticCodeByName(name)
+}
+
+// GeneticCodeNames returns the names of the genetic codes.
+func GeneticCodeNames() []string {
+	return protein.GeneticCodeNames()
+}
+
+// RestrictionSite returns the site recognized by a restriction enzyme.
+func RestrictionSite(enzyme string) (string, error) {
+	return protein.RestrictionSite(enzyme)
+}
+
+// AdapterSet returns an adapter set by name (truseq, nextera, fastqc or
+// one in the data directory).
+func AdapterSet(name string) ([]Adapter, error) {
+	return quality.AdapterSet(name)
+}
+
+// AdapterSetNames returns the names of the adapter sets.
+func AdapterSetNames() []string {
+	return quality.AdapterSetNames()
+}
@@ -0,0 +1,50 @@
+package bioflow
+
+import (
+	"io"
+
+	"github.com/aria-lang/bioflow-go/internal/faidx"
+)
+
+// FastaIndex reads regions of an indexed FASTA file without reading the
+// rest of it.
+type FastaIndex = faidx.FastaIndex
+
+// FastaIndexEntry is the index of one sequence: one line of a .fai file.
+type FastaIndexEntry = faidx.Entry
+
+// Region is a region of a named sequence, 0-based and half-open.
+type Region = faidx.Region
+
+// OpenFastaIndex opens an uncompressed FASTA file for random access with
+// its samtools-compatible .fai index, building and writing the index if
+// there is none.
+//
+// Aria equivalent:
+//
+//	fn open_fasta_index(filename: Path) -> Result<FastaIndex, FaidxError> with FileSystem
+func OpenFastaIndex(filename string) (*FastaIndex, error) {
+	return faidx.Open(filename)
+}
+
+// BuildFastaIndex indexes a FASTA file and writes its .fai index next to
+// it.
+func BuildFastaIndex(filename string) ([]FastaIndexEntry, error) {
+	return faidx.BuildFile(filename)
+}
+
+// WriteFastaIndex writes index entries in .fai format.
+func WriteFastaIndex(w io.Writer, entries []FastaIndexEntry) error {
+	return faidx.WriteIndex(w, entries)
+}
+
+// FastaIndexPath returns the path of the .fai index of a FASTA file.
+func FastaIndexPath(filename string) string {
+	return faidx.IndexPath(filename)
+}
+
+// ParseRegion parses a samtools region such as chr1:1,000-2,000 (1-based,
+// inclusive).
+func ParseRegion(s string) (Region, error) {
+	return faidx.ParseRegion(s)
+}
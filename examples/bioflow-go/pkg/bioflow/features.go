@@ -0,0 +1,73 @@
+package bioflow
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/aria-lang/bioflow-go/internal/features"
+)
+
+// FCGR is a frequency chaos game representation of a sequence.
+type FCGR = features.FCGR
+
+// CGRPoint is a point of a chaos game representation.
+type CGRPoint = features.Point
+
+// CGR returns the chaos game representation coordinates of a sequence.
+func CGR(seq *Sequence) []CGRPoint {
+	return features.CGR(seq)
+}
+
+// ComputeFCGR computes the FCGR of a sequence at resolution k (a 4^k vector).
+func ComputeFCGR(seq *Sequence, k int, normalize bool) (*FCGR, error) {
+	return features.NewFCGR(seq, k, normalize)
+}
+
+// WriteFCGRCSV writes FCGR vectors, one row per sequence.
+func WriteFCGRCSV(w io.Writer, fcgrs []*FCGR) error {
+	return features.WriteFCGRCSV(w, fcgrs)
+}
+
+// SaveFCGRPNG writes an FCGR image to a PNG file.
+func SaveFCGRPNG(filename string, f *FCGR, scale int) error {
+	file, err := createFile(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+
+	if err := f.WritePNG(file, scale); err != nil {
+		return fmt.Errorf("writing image: %w", err)
+	}
+	return file.Close()
+}
+
+// KMerMatrix is a sequences x k-mers feature matrix.
+type KMerMatrix = features.Matrix
+
+// SparseMatrix is a compressed sparse row matrix.
+type SparseMatrix = features.CSR
+
+// KMerMatrixOptions configures k-mer feature extraction.
+type KMerMatrixOptions = features.MatrixOptions
+
+// Normalization selects how k-mer counts are turned into features.
+type Normalization = features.Normalization
+
+// Normalization schemes for k-mer feature matrices.
+const (
+	NormRaw      = features.Raw
+	NormRelative = features.Relative
+	NormTFIDF    = features.TFIDF
+	NormCLR      = features.CLR
+)
+
+// ParseNormalization parses a normalization name (raw, relative, tfidf, clr).
+func ParseNormalization(name string) (Normalization, error) {
+	return features.ParseNormalization(name)
+}
+
+// BuildKMerMatrix converts sequences into a normalized k-mer feature matrix.
+func BuildKMerMatrix(seqs []*Sequence, opts KMerMatrixOptions) (*KMerMatrix, error) {
+	return features.KMerMatrix(seqs, opts)
+}
@@ -0,0 +1,123 @@
+package bioflow
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/genome"
+)
+
+// Genome is a named assembly of one or more contigs.
+type Genome = genome.Genome
+
+// ANIMethod selects fragment alignment or a k-mer estimate for ANI.
+type ANIMethod = genome.ANIMethod
+
+// ANI methods.
+const (
+	FragmentANI = genome.FragmentANI
+	KMerANI     = genome.KMerANI
+)
+
+// SpeciesANI is the usual species boundary, in percent ANI.
+const SpeciesANI = genome.SpeciesANI
+
+// ANIOptions configures average nucleotide identity.
+type ANIOptions = genome.ANIOptions
+
+// ANIResult is the average nucleotide identity of two genomes.
+type ANIResult = genome.ANIResult
+
+// ANIMatrix is the ANI of every pair of genomes.
+type ANIMatrix = genome.ANIMatrix
+
+// DefaultANIOptions returns ANIb's fragment length and thresholds.
+func DefaultANIOptions() ANIOptions {
+	return genome.DefaultANIOptions()
+}
+
+// ParseANIMethod parses fragment or kmer.
+func ParseANIMethod(name string) (ANIMethod, error) {
+	return genome.ParseANIMethod(name)
+}
+
+// ANI returns the average nucleotide identity of two genomes.
+func ANI(a, b *Genome, opts ANIOptions) (ANIResult, error) {
+	return genome.ANI(a, b, opts)
+}
+
+// PairwiseANI returns the ANI of every pair of genomes.
+func PairwiseANI(genomes []*Genome, opts ANIOptions) (*ANIMatrix, error) {
+	return genome.PairwiseANI(genomes, opts)
+}
+
+// ReadGenome reads the contigs of a FASTA file as a genome named after the
+// file, without its extensions.
+//
+// Aria equivalent:
+//
+//	fn read_genome(filename: Path) -> Result<Genome, IOError> with FileSystem
+func ReadGenome(filename string) (*Genome, error) {
+	contigs, err := ReadFASTA(filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(contigs) == 0 {
+		return nil, fmt.Errorf("%s: no sequences", filename)
+	}
+	name := filepath.Base(TrimCompressionExt(filename))
+	return &Genome{Name: strings.TrimSuffix(name, filepath.Ext(name)), Contigs: contigs}, nil
+}
+
+// MarkerPanel is a set of conserved marker genes given by their k-mers.
+type MarkerPanel = genome.Panel
+
+// MarkerHit is how much of one marker was found in a genome.
+type MarkerHit = genome.MarkerHit
+
+// Completeness is the marker content of one genome.
+type Completeness = genome.Completeness
+
+// DefaultMarkerFraction is the fraction of a marker's k-mers that must be
+// found for it to count as present.
+const DefaultMarkerFraction = genome.DefaultMarkerFraction
+
+// ReadMarkerPanel reads a panel of tab-separated marker names and k-mers.
+//
+// Aria equivalent:
+//
+//	fn read_marker_panel(filename: Path) -> Result<Panel, GenomeError> with FileSystem
+func ReadMarkerPanel(filename string) (*MarkerPanel, error) {
+	f, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p, err := genome.ReadPanel(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return p, nil
+}
+
+// MarkerPanelFromSequences makes a panel of the k-mers of marker genes.
+func MarkerPanelFromSequences(markers []*Sequence, k int) (*MarkerPanel, error) {
+	return genome.PanelFromSequences(markers, k)
+}
+
+// EstimateCompleteness reports which markers of a panel a genome contains.
+func EstimateCompleteness(g *Genome, p *MarkerPanel, minFraction float64) (*Completeness, error) {
+	return genome.EstimateCompleteness(g, p, minFraction)
+}
+
+// WriteCompletenessTSV writes one summary row per genome.
+func WriteCompletenessTSV(w io.Writer, results []*Completeness) error {
+	return genome.WriteCompletenessTSV(w, results)
+}
+
+// WriteMarkerPresence writes the fraction of each marker found, by genome.
+func WriteMarkerPresence(w io.Writer, results []*Completeness) error {
+	return genome.WritePresenceMatrix(w, results)
+}
@@ -0,0 +1,34 @@
+package bioflow
+
+import (
+	"github.com/aria-lang/bioflow-go/internal/report"
+	"github.com/aria-lang/bioflow-go/internal/stats"
+)
+
+// Histogram counts values in bins with explicit edges.
+type Histogram = stats.Histogram
+
+// NewHistogram creates an empty histogram with the given bin edges.
+func NewHistogram(edges []float64) (*Histogram, error) {
+	return stats.NewHistogram(edges)
+}
+
+// UniformEdges returns bins+1 equally spaced edges from min to max.
+func UniformEdges(min, max float64, bins int) ([]float64, error) {
+	return stats.UniformEdges(min, max, bins)
+}
+
+// GCContentHistogram counts the GC content of sequences by bin.
+func GCContentHistogram(sequences []*Sequence, edges []float64) (*Histogram, error) {
+	return stats.GCContentHistogram(sequences, edges)
+}
+
+// SequenceLengthHistogram counts sequence lengths by bin.
+func SequenceLengthHistogram(sequences []*Sequence, edges []float64) (*Histogram, error) {
+	return stats.SequenceLengthHistogram(sequences, edges)
+}
+
+// HistogramSection shows a histogram in an HTML report.
+func HistogramSection(title, xLabel string, h *Histogram) ReportSection {
+	return report.HistogramSection(title, xLabel, h)
+}
@@ -0,0 +1,51 @@
+package bioflow
+
+import (
+	"github.com/aria-lang/bioflow-go/internal/jobqueue"
+)
+
+// JobQueue runs background jobs by priority on a fixed number of workers,
+// with a bounded queue per priority class.
+type JobQueue = jobqueue.Queue
+
+// JobQueueOptions configures a JobQueue.
+type JobQueueOptions = jobqueue.Options
+
+// JobQueueStats describes the depth, running jobs and wait times of a
+// JobQueue.
+type JobQueueStats = jobqueue.Stats
+
+// JobPriority is the class of a job.
+type JobPriority = jobqueue.Priority
+
+// Job priority classes, from lowest to highest.
+const (
+	BatchPriority       = jobqueue.Batch
+	NormalPriority      = jobqueue.Normal
+	InteractivePriority = jobqueue.Interactive
+)
+
+// ErrJobQueueFull is returned by JobQueue.Submit when the queue of a
+// priority class is full.
+var ErrJobQueueFull = jobqueue.ErrFull
+
+// DefaultJobQueueOptions returns two workers and 32 queued jobs per
+// priority class.
+func DefaultJobQueueOptions() JobQueueOptions {
+	return jobqueue.DefaultOptions()
+}
+
+// NewJobQueue creates a JobQueue.
+//
+// Aria equivalent:
+//
+//	fn new_job_queue(options: JobQueueOptions) -> JobQueue
+func NewJobQueue(opts JobQueueOptions) *JobQueue {
+	return jobqueue.New(opts)
+}
+
+// ParseJobPriority returns the named priority (interactive, normal or
+// batch); an empty name is normal.
+func ParseJobPriority(name string) (JobPriority, error) {
+	return jobqueue.ParsePriority(name)
+}
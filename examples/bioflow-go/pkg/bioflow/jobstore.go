@@ -0,0 +1,33 @@
+package bioflow
+
+import (
+	"github.com/aria-lang/bioflow-go/internal/jobstore"
+)
+
+// JobStore keeps jobs, with their input and result files, in a directory
+// so that they survive restarts.
+type JobStore = jobstore.Store
+
+// JobRecord is a job saved in a JobStore.
+type JobRecord = jobstore.Record
+
+// Job statuses of a JobRecord.
+const (
+	JobQueued  = jobstore.Queued
+	JobRunning = jobstore.Running
+	JobDone    = jobstore.Done
+	JobFailed  = jobstore.Failed
+)
+
+// ErrJobNotFound is returned by a JobStore for unknown job IDs.
+var ErrJobNotFound = jobstore.ErrNotFound
+
+// OpenJobStore opens the job store in a directory, creating it if needed.
+// Call Recover on it before running jobs after a restart.
+//
+// Aria equivalent:
+//
+//	fn open_job_store(dir: Path) -> Result<JobStore, StoreError> with FileSystem
+func OpenJobStore(dir string) (*JobStore, error) {
+	return jobstore.Open(dir)
+}
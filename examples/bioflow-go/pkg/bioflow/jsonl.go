@@ -0,0 +1,297 @@
+package bioflow
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/jsonl"
+	"github.com/aria-lang/bioflow-go/internal/quality"
+)
+
+// JSONRecord is one sequence or read in JSON Lines format.
+type JSONRecord = jsonl.Record
+
+// JSONLinesMediaType is the content type of a JSON Lines stream.
+const JSONLinesMediaType = jsonl.MediaType
+
+// Metadata keys holding the read group of a read.
+const (
+	MetaReadGroup = "rg.id"
+	MetaSample    = "rg.sample"
+	MetaLibrary   = "rg.library"
+	MetaRun       = "rg.run"
+	MetaFlowcell  = "rg.flowcell"
+	MetaLane      = "rg.lane"
+	MetaBarcode   = "rg.barcode"
+	MetaPlatform  = "rg.platform"
+)
+
+// IsJSONLines reports whether a file name has a JSON Lines extension
+// (.jsonl or .ndjson), possibly followed by a compression extension.
+func IsJSONLines(filename string) bool {
+	switch strings.ToLower(filepath.Ext(TrimCompressionExt(filename))) {
+	case ".jsonl", ".ndjson":
+		return true
+	}
+	return false
+}
+
+// SequenceRecord returns the JSON Lines record of a sequence.
+func SequenceRecord(seq *Sequence) *JSONRecord {
+	return &JSONRecord{ID: seq.ID, Description: seq.Description, Seq: seq.Bases}
+}
+
+// ReadRecord returns the JSON Lines record of a read, with its read group
+// as metadata.
+func ReadRecord(read *Read) *JSONRecord {
+	rec := SequenceRecord(read.Sequence)
+	rec.Qual = read.Quality.ToPhred33()
+	if g := read.Group; g != nil {
+		rec.Metadata = make(map[string]string)
+		for key, value := range map[string]string{
+			MetaReadGroup: g.ID, MetaSample: g.Sample, MetaLibrary: g.Library, MetaRun: g.Run,
+			MetaFlowcell: g.Flowcell, MetaBarcode: g.Barcode, MetaPlatform: g.Platform,
+		} {
+			if value != "" {
+				rec.Metadata[key] = value
+			}
+		}
+		if g.Lane != 0 {
+			rec.Metadata[MetaLane] = strconv.Itoa(g.Lane)
+		}
+	}
+	return rec
+}
+
+// JSONLReader reads sequences or reads from JSON Lines one record at a
+// time. Metadata other than the read group is appended to the
+// description as key=value pairs.
+type JSONLReader struct {
+	r      *jsonl.Reader
+	policy Policy
+	groups map[string]*ReadGroup
+}
+
+// NewJSONLReader creates a streaming JSON Lines reader.
+func NewJSONLReader(r io.Reader) *JSONLReader {
+	return &JSONLReader{r: jsonl.NewReader(r), policy: StrictPolicy(), groups: make(map[string]*ReadGroup)}
+}
+
+// SetPolicy sets the validation policy applied to the bases of each
+// record.
+func (jr *JSONLReader) SetPolicy(policy Policy) {
+	jr.policy = policy
+}
+
+// NextSequence returns the next sequence, or io.EOF when there are no
+// more records. Qualities are ignored.
+func (jr *JSONLReader) NextSequence() (*Sequence, error) {
+	rec, err := jr.r.Next()
+	if err != nil {
+		return nil, err
+	}
+	seq, err := NewSequenceWithPolicy(rec.Seq, rec.ID, jr.description(rec, false), jr.policy)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", jr.r.Line(), err)
+	}
+	return seq, nil
+}
+
+// NextRead returns the next read, or io.EOF when there are no more
+// records. Reads of the same read group share one Group.
+//
+// Aria equivalent:
+//
+//	fn next_read(self) -> Result<Option<Read>, JsonlError>
+//	  ensures result.is_ok() implies result.unwrap().all(|r| r.sequence.len() == r.quality.len())
+func (jr *JSONLReader) NextRead() (*Read, error) {
+	rec, err := jr.r.Next()
+	if err != nil {
+		return nil, err
+	}
+	line := jr.r.Line()
+	if rec.Qual == "" {
+		return nil, fmt.Errorf("line %d: record %s has no qual", line, rec.ID)
+	}
+	seq, err := newReadSequence(rec.Seq, rec.ID, jr.policy)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", line, err)
+	}
+	seq.Description = jr.description(rec, true)
+	qual, err := quality.FromPhred33(rec.Qual)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", line, err)
+	}
+	group, err := jr.group(rec)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", line, err)
+	}
+	return &Read{Sequence: seq, Quality: qual, Group: group}, nil
+}
+
+// description returns the description of a record followed by its
+// metadata, leaving out the read group keys of reads.
+func (jr *JSONLReader) description(rec *JSONRecord, read bool) string {
+	keys := make([]string, 0, len(rec.Metadata))
+	for key := range rec.Metadata {
+		if read && strings.HasPrefix(key, "rg.") {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys)+1)
+	if rec.Description != "" {
+		parts = append(parts, rec.Description)
+	}
+	for _, key := range keys {
+		parts = append(parts, key+"="+strings.Join(strings.Fields(rec.Metadata[key]), "_"))
+	}
+	return strings.Join(parts, " ")
+}
+
+// group returns the read group of a record, shared with earlier reads of
+// the same group.
+func (jr *JSONLReader) group(rec *JSONRecord) (*ReadGroup, error) {
+	id := rec.Metadata[MetaReadGroup]
+	if id == "" {
+		return nil, nil
+	}
+	if g, ok := jr.groups[id]; ok {
+		return g, nil
+	}
+	g := &ReadGroup{
+		ID: id, Sample: rec.Metadata[MetaSample], Library: rec.Metadata[MetaLibrary], Run: rec.Metadata[MetaRun],
+		Flowcell: rec.Metadata[MetaFlowcell], Barcode: rec.Metadata[MetaBarcode], Platform: rec.Metadata[MetaPlatform],
+	}
+	if lane := rec.Metadata[MetaLane]; lane != "" {
+		n, err := strconv.Atoi(lane)
+		if err != nil {
+			return nil, fmt.Errorf("record %s: invalid %s %q", rec.ID, MetaLane, lane)
+		}
+		g.Lane = n
+	}
+	jr.groups[id] = g
+	return g, nil
+}
+
+// JSONLWriter writes sequences and reads as JSON Lines.
+type JSONLWriter struct {
+	w *jsonl.Writer
+}
+
+// NewJSONLWriter creates a JSON Lines writer. Output is buffered; call
+// Flush when done.
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	return &JSONLWriter{w: jsonl.NewWriter(w)}
+}
+
+// Write validates and writes one record.
+func (jw *JSONLWriter) Write(rec *JSONRecord) error {
+	return jw.w.Write(rec)
+}
+
+// WriteSequence writes one sequence.
+func (jw *JSONLWriter) WriteSequence(seq *Sequence) error {
+	return jw.w.Write(SequenceRecord(seq))
+}
+
+// WriteRead writes one read.
+func (jw *JSONLWriter) WriteRead(read *Read) error {
+	return jw.w.Write(ReadRecord(read))
+}
+
+// Flush writes buffered records to the underlying writer.
+func (jw *JSONLWriter) Flush() error {
+	return jw.w.Flush()
+}
+
+// ParseJSONLReads parses reads from JSON Lines under a policy.
+func ParseJSONLReads(r io.Reader, policy Policy) ([]*Read, error) {
+	jr := NewJSONLReader(r)
+	jr.SetPolicy(policy)
+	reads := make([]*Read, 0)
+	for {
+		read, err := jr.NextRead()
+		if err == io.EOF {
+			return reads, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		reads = append(reads, read)
+	}
+}
+
+// ParseJSONLSequences parses sequences from JSON Lines under a policy.
+func ParseJSONLSequences(r io.Reader, policy Policy) ([]*Sequence, error) {
+	jr := NewJSONLReader(r)
+	jr.SetPolicy(policy)
+	sequences := make([]*Sequence, 0)
+	for {
+		seq, err := jr.NextSequence()
+		if err == io.EOF {
+			return sequences, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		sequences = append(sequences, seq)
+	}
+}
+
+// FormatJSONLReads writes reads as JSON Lines.
+func FormatJSONLReads(w io.Writer, reads []*Read) error {
+	jw := NewJSONLWriter(w)
+	for _, read := range reads {
+		if err := jw.WriteRead(read); err != nil {
+			return err
+		}
+	}
+	return jw.Flush()
+}
+
+// FormatJSONLSequences writes sequences as JSON Lines.
+func FormatJSONLSequences(w io.Writer, sequences []*Sequence) error {
+	jw := NewJSONLWriter(w)
+	for _, seq := range sequences {
+		if err := jw.WriteSequence(seq); err != nil {
+			return err
+		}
+	}
+	return jw.Flush()
+}
+
+// ReadReadsFile reads reads from a FASTQ file, or from JSON Lines if the
+// name ends in .jsonl or .ndjson.
+func ReadReadsFile(filename string) ([]*Read, error) {
+	if !IsJSONLines(filename) {
+		return ReadFASTQ(filename)
+	}
+	file, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseJSONLReads(file, StrictPolicy())
+}
+
+// WriteJSONLReads writes reads to a JSON Lines file.
+func WriteJSONLReads(filename string, reads []*Read) error {
+	file, err := createFile(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+
+	if err := FormatJSONLReads(file, reads); err != nil {
+		return err
+	}
+	file.AddRecords(len(reads))
+	return file.Close()
+}
@@ -0,0 +1,107 @@
+package bioflow
+
+import (
+	"io"
+	"os"
+
+	"github.com/aria-lang/bioflow-go/internal/kmer"
+	"github.com/aria-lang/bioflow-go/internal/liftover"
+)
+
+// LiftChain is a UCSC-style chain of gapless blocks between two
+// assemblies.
+type LiftChain = liftover.Chain
+
+// LiftInterval is a BED-style interval to lift.
+type LiftInterval = liftover.Interval
+
+// LiftResult is the outcome of lifting one interval.
+type LiftResult = liftover.Result
+
+// Lifter maps intervals through chains.
+type Lifter = liftover.Lifter
+
+// LiftStatus is the outcome of lifting an interval.
+type LiftStatus = liftover.Status
+
+// Lift statuses.
+const (
+	LiftLifted  = liftover.Lifted
+	LiftPartial = liftover.Partial
+	LiftFailed  = liftover.Failed
+)
+
+// DefaultLiftMinMatch is the default minimum mapped fraction.
+const DefaultLiftMinMatch = liftover.DefaultMinMatch
+
+// ReadChains reads a UCSC chain file.
+func ReadChains(filename string) ([]*LiftChain, error) {
+	file, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return liftover.Read(file)
+}
+
+// WriteChains writes chains in the UCSC chain format.
+func WriteChains(w io.Writer, chains []*LiftChain) error {
+	return liftover.Write(w, chains)
+}
+
+// BuildLiftChains builds chains from every source sequence to every
+// destination sequence from collinear k-mer anchors on both strands.
+func BuildLiftChains(source, dest []*Sequence, k int) ([]*LiftChain, error) {
+	chains := make([]*LiftChain, 0)
+	for _, s := range source {
+		for _, d := range dest {
+			anchors, err := kmer.FindAnchors(s, d, kmer.AnchorOptions{K: k, BothStrands: true, MaxOccurrences: 10})
+			if err != nil {
+				return nil, err
+			}
+			anchorChains, err := kmer.ChainAnchors(anchors, kmer.DefaultChainOptions(k))
+			if err != nil {
+				return nil, err
+			}
+			for _, ac := range anchorChains {
+				c := liftover.FromAnchorChain(len(chains)+1, nameOr(s.ID, "source"), s.Len(), nameOr(d.ID, "dest"), d.Len(), ac, k)
+				if c != nil {
+					chains = append(chains, c)
+				}
+			}
+		}
+	}
+	return chains, nil
+}
+
+// ChainFromAlignment builds a chain from an alignment whose first
+// sequence is on the source and second on the destination assembly.
+func ChainFromAlignment(source, dest *Sequence, a *Alignment) (*LiftChain, error) {
+	return liftover.FromAlignedRows(1, nameOr(source.ID, "source"), source.Len(), a.Start1,
+		nameOr(dest.ID, "dest"), dest.Len(), a.Start2, a.AlignedSeq1, a.AlignedSeq2)
+}
+
+// NewLifter indexes chains for lifting with a minimum mapped fraction.
+func NewLifter(chains []*LiftChain, minMatch float64) (*Lifter, error) {
+	return liftover.NewLifter(chains, minMatch)
+}
+
+// ReadBED reads BED intervals; "-" reads standard input.
+func ReadBED(filename string) ([]LiftInterval, error) {
+	if filename == "-" {
+		return liftover.ReadBED(os.Stdin)
+	}
+	file, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return liftover.ReadBED(file)
+}
+
+// WriteBED writes intervals as BED.
+func WriteBED(w io.Writer, intervals []LiftInterval) error {
+	return liftover.WriteBED(w, intervals)
+}
@@ -0,0 +1,76 @@
+package bioflow
+
+import (
+	"path/filepath"
+
+	"github.com/aria-lang/bioflow-go/internal/manifest"
+	"github.com/aria-lang/bioflow-go/internal/output"
+)
+
+// Manifest records the size, SHA-256 digest and record count of output
+// files, with the tool version and parameters that produced them.
+type Manifest = manifest.Manifest
+
+// ManifestEntry describes one file of a manifest.
+type ManifestEntry = manifest.Entry
+
+// ManifestResult is the outcome of verifying one file of a manifest.
+type ManifestResult = manifest.Result
+
+// NewManifest creates a manifest for a run of a bioflow command. Set it
+// as the Manifest of OutputOptions (or of SetOutputDefaults) to record
+// every file written with them.
+//
+// Aria equivalent:
+//
+//	fn new_manifest(command: String) -> Manifest
+func NewManifest(command string) *Manifest {
+	m := manifest.New("bioflow", Version())
+	m.Command = command
+	return m
+}
+
+// WriteManifest writes a manifest file. Paths of files in the manifest's
+// directory, or beneath it, are written relative to it.
+func WriteManifest(filename string, m *Manifest) error {
+	opts := defaultOutputOptions()
+	opts.Compression = CompressionAuto
+	opts.Level = 0
+	opts.Manifest = nil
+	file, err := output.Create(filename, opts)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+
+	if err := m.Encode(file, filepath.Dir(filename)); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// ReadManifest reads a manifest file.
+func ReadManifest(filename string) (*Manifest, error) {
+	file, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return manifest.Decode(file)
+}
+
+// VerifyManifest checks every file listed in a manifest file against its
+// recorded size and digest. Relative paths are taken from the directory
+// of the manifest.
+//
+// Aria equivalent:
+//
+//	fn verify_manifest(filename: Path) -> Result<List<ManifestResult>, ManifestError> with FileSystem
+func VerifyManifest(filename string) ([]ManifestResult, error) {
+	m, err := ReadManifest(filename)
+	if err != nil {
+		return nil, err
+	}
+	return m.Verify(filepath.Dir(filename)), nil
+}
@@ -0,0 +1,35 @@
+package bioflow
+
+import (
+	"github.com/aria-lang/bioflow-go/internal/metrics"
+)
+
+// MetricsRegistry holds counters, gauges and histograms, and writes them
+// in the Prometheus text format.
+type MetricsRegistry = metrics.Registry
+
+// MetricCounter is a metric that only goes up.
+type MetricCounter = metrics.Counter
+
+// MetricGauge is a metric that goes up and down.
+type MetricGauge = metrics.Gauge
+
+// MetricHistogram is a metric counting observations in buckets, unlike
+// Histogram, which bins a set of sequences.
+type MetricHistogram = metrics.Histogram
+
+// Metrics is the registry of the metrics of the server, served at
+// /metrics.
+var Metrics = metrics.Default
+
+// MetricsContentType is the media type of the Prometheus text format.
+const MetricsContentType = metrics.ContentType
+
+// DefaultLatencyBuckets are the default bucket bounds of histograms, in
+// seconds.
+var DefaultLatencyBuckets = metrics.DefBuckets
+
+// NewMetricsRegistry returns an empty metrics registry.
+func NewMetricsRegistry() *MetricsRegistry {
+	return metrics.NewRegistry()
+}
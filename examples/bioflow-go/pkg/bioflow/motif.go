@@ -0,0 +1,32 @@
+package bioflow
+
+import (
+	"github.com/aria-lang/bioflow-go/internal/motif"
+)
+
+// PSSM is a position-specific scoring matrix in bits.
+type PSSM = motif.PSSM
+
+// PSSMOptions configures PSSM construction.
+type PSSMOptions = motif.Options
+
+// MotifHit is a PSSM match in a sequence.
+type MotifHit = motif.Hit
+
+// DefaultPseudocount is the default total pseudocount per PSSM column.
+const DefaultPseudocount = motif.DefaultPseudocount
+
+// BuildPSSM builds a position-specific scoring matrix from an alignment.
+func BuildPSSM(m *MSA, opts PSSMOptions) (*PSSM, error) {
+	return motif.FromMSA(m, opts)
+}
+
+// ScanPSSM searches the selected strands of each sequence for windows
+// scoring at least threshold bits.
+func ScanPSSM(p *PSSM, sequences []*Sequence, threshold float64, strand Strand) []MotifHit {
+	hits := make([]MotifHit, 0)
+	for _, seq := range sequences {
+		hits = append(hits, p.Scan(seq, threshold, strand)...)
+	}
+	return hits
+}
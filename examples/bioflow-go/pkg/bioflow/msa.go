@@ -0,0 +1,113 @@
+package bioflow
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/aria-lang/bioflow-go/internal/msa"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// MSA is a multiple sequence alignment.
+type MSA = msa.MSA
+
+// MSAFormat identifies an alignment file format.
+type MSAFormat = msa.Format
+
+// Alignment file formats
+const (
+	FormatClustal      = msa.Clustal
+	FormatStockholm    = msa.Stockholm
+	FormatPHYLIP       = msa.PHYLIP
+	FormatAlignedFASTA = msa.AlignedFASTA
+)
+
+// Logo is a per-column frequency and information content matrix.
+type Logo = msa.Logo
+
+// MSAProfile computes per-column conservation and logo data for an alignment.
+func MSAProfile(m *MSA) *Logo {
+	return msa.Profile(m)
+}
+
+// NewMSA creates a multiple sequence alignment from named gapped rows.
+func NewMSA(names, rows []string) (*MSA, error) {
+	return msa.New(names, rows)
+}
+
+// ColumnMap maps alignment columns of a gapped row to sequence positions.
+type ColumnMap = sequence.ColumnMap
+
+// GapRun is a run of gap columns in an aligned row.
+type GapRun = sequence.GapRun
+
+// NewColumnMap indexes a gapped row for column/position conversion.
+func NewColumnMap(row string) *ColumnMap {
+	return sequence.NewColumnMap(row)
+}
+
+// Degap removes gap characters from an aligned row.
+func Degap(row string) string {
+	return sequence.Degap(row)
+}
+
+// GapRuns returns the runs of gap columns in an aligned row.
+func GapRuns(row string) []GapRun {
+	return sequence.GapRuns(row)
+}
+
+// PadRows pads aligned rows with trailing gaps to a common width.
+func PadRows(rows []string) []string {
+	return sequence.PadRows(rows)
+}
+
+// ParseMSAFormat parses an alignment format name such as "clustal".
+func ParseMSAFormat(name string) (MSAFormat, error) {
+	return msa.ParseFormat(name)
+}
+
+// ReadMSA reads an alignment file, guessing the format from its extension
+// (.aln, .sto, .phy, .afa) before any compression extension.
+func ReadMSA(filename string) (*MSA, error) {
+	format, err := msa.FormatFromPath(TrimCompressionExt(filename))
+	if err != nil {
+		return nil, err
+	}
+	return ReadMSAFormat(filename, format)
+}
+
+// ReadMSAFormat reads an alignment file in an explicit format.
+func ReadMSAFormat(filename string, format MSAFormat) (*MSA, error) {
+	file, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return msa.Read(file, format)
+}
+
+// ParseMSA parses an alignment in the given format.
+func ParseMSA(r io.Reader, format MSAFormat) (*MSA, error) {
+	return msa.Read(r, format)
+}
+
+// FormatMSA writes an alignment in the given format.
+func FormatMSA(w io.Writer, m *MSA, format MSAFormat) error {
+	return msa.Write(w, m, format)
+}
+
+// WriteMSA writes an alignment file in the given format.
+func WriteMSA(filename string, m *MSA, format MSAFormat) error {
+	file, err := createFile(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+
+	if err := msa.Write(file, m, format); err != nil {
+		return fmt.Errorf("writing alignment: %w", err)
+	}
+	file.AddRecords(len(m.Rows))
+	return file.Close()
+}
@@ -0,0 +1,197 @@
+package bioflow
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// EventKind identifies what a pipeline Event reports.
+type EventKind int
+
+const (
+	// StageStarted is emitted before a stage processes its first read.
+	StageStarted EventKind = iota
+	// StageProgress is emitted periodically while a stage runs.
+	StageProgress
+	// StageFinished is emitted once a stage has processed all reads.
+	StageFinished
+	// ReadRejected reports a read removed by a stage, for the first
+	// Monitor.SampleRejected rejections of each stage.
+	ReadRejected
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case StageStarted:
+		return "started"
+	case StageProgress:
+		return "progress"
+	case StageFinished:
+		return "finished"
+	case ReadRejected:
+		return "rejected"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes the kind by name, so events read well as JSON.
+func (k EventKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText decodes a kind name, so that saved events can be read
+// back.
+func (k *EventKind) UnmarshalText(text []byte) error {
+	for kind := StageStarted; kind <= ReadRejected; kind++ {
+		if kind.String() == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown event kind %q", text)
+}
+
+// Event reports the progress of one pipeline stage. Index counts stages
+// from zero out of Stages. The counters are cumulative for the stage;
+// Read and Reason are set for ReadRejected events.
+type Event struct {
+	Kind      EventKind     `json:"kind"`
+	Stage     string        `json:"stage"`
+	Index     int           `json:"index"`
+	Stages    int           `json:"stages"`
+	Processed int           `json:"processed"`
+	Passed    int           `json:"passed"`
+	Rejected  int           `json:"rejected"`
+	Elapsed   time.Duration `json:"elapsed_ns"`
+	Read      *Read         `json:"-"`
+	ReadID    string        `json:"read_id,omitempty"`
+	Reason    string        `json:"reason,omitempty"`
+}
+
+// ReadsPerSecond returns the processing rate of the stage so far.
+func (e Event) ReadsPerSecond() float64 {
+	if e.Elapsed <= 0 {
+		return 0
+	}
+	return float64(e.Processed) / e.Elapsed.Seconds()
+}
+
+// Observer receives pipeline events. Observe is called synchronously from
+// the goroutine running the pipeline, so it should return quickly.
+type Observer interface {
+	Observe(e Event)
+}
+
+// ObserverFunc adapts a function to Observer.
+type ObserverFunc func(e Event)
+
+// Observe calls f.
+func (f ObserverFunc) Observe(e Event) {
+	f(e)
+}
+
+// DefaultProgressEvery is the default number of reads between progress
+// events.
+const DefaultProgressEvery = 100000
+
+// Monitor configures the events a pipeline emits. The zero value emits
+// nothing.
+type Monitor struct {
+	Observer Observer
+	// ProgressEvery is the number of reads between progress events.
+	// Zero means DefaultProgressEvery; negative disables them.
+	ProgressEvery int
+	// ProgressInterval, if positive, also emits a progress event when
+	// this much time has passed since the last one.
+	ProgressInterval time.Duration
+	// SampleRejected is the number of rejected reads reported per stage.
+	SampleRejected int
+}
+
+// stageMonitor emits the events of one running stage.
+type stageMonitor struct {
+	Monitor
+	event Event
+	start time.Time
+	last  time.Time
+}
+
+// stage starts monitoring a stage and emits StageStarted.
+func (m Monitor) stage(name string, index, stages int) *stageMonitor {
+	now := time.Now()
+	s := &stageMonitor{Monitor: m, event: Event{Stage: name, Index: index, Stages: stages}, start: now, last: now}
+	if m.ProgressEvery == 0 {
+		s.ProgressEvery = DefaultProgressEvery
+	}
+	s.emit(StageStarted)
+	return s
+}
+
+// emit sends an event of the given kind with the current counters.
+func (s *stageMonitor) emit(kind EventKind) {
+	if s.Observer == nil {
+		return
+	}
+	e := s.event
+	e.Kind = kind
+	e.Elapsed = time.Since(s.start)
+	s.Observer.Observe(e)
+}
+
+// record counts one processed read. reason is why a rejected read was
+// removed.
+func (s *stageMonitor) record(read *Read, passed bool, reason string) {
+	if s.Observer == nil {
+		return
+	}
+	s.event.Processed++
+	if passed {
+		s.event.Passed++
+	} else {
+		s.event.Rejected++
+		if s.event.Rejected <= s.SampleRejected {
+			e := s.event
+			e.Read, e.ReadID, e.Reason = read, read.Sequence.ID, reason
+			e.Kind, e.Elapsed = ReadRejected, time.Since(s.start)
+			s.Observer.Observe(e)
+		}
+	}
+
+	due := s.ProgressEvery > 0 && s.event.Processed%s.ProgressEvery == 0
+	// Checking the clock every read would be wasteful.
+	if !due && s.ProgressInterval > 0 && s.event.Processed%1024 == 0 {
+		due = time.Since(s.last) >= s.ProgressInterval
+	}
+	if due {
+		s.last = time.Now()
+		s.emit(StageProgress)
+	}
+}
+
+// finish emits StageFinished with the final counts, which also cover
+// stages that do not record reads one by one.
+func (s *stageMonitor) finish(in, out int) {
+	s.event.Processed, s.event.Passed, s.event.Rejected = in, out, in-out
+	s.emit(StageFinished)
+}
+
+// LogObserver writes one line per event to w, as the CLI does with
+// -progress.
+func LogObserver(w io.Writer) Observer {
+	return ObserverFunc(func(e Event) {
+		prefix := fmt.Sprintf("[%d/%d] %s:", e.Index+1, e.Stages, e.Stage)
+		switch e.Kind {
+		case StageStarted:
+			fmt.Fprintf(w, "%s started\n", prefix)
+		case StageProgress:
+			fmt.Fprintf(w, "%s %d reads, %d passed, %d rejected (%.1fs, %.0f reads/s)\n",
+				prefix, e.Processed, e.Passed, e.Rejected, e.Elapsed.Seconds(), e.ReadsPerSecond())
+		case StageFinished:
+			fmt.Fprintf(w, "%s %d -> %d reads (%.1fs)\n", prefix, e.Processed, e.Passed, e.Elapsed.Seconds())
+		case ReadRejected:
+			fmt.Fprintf(w, "%s rejected %s: %s\n", prefix, e.ReadID, e.Reason)
+		}
+	})
+}
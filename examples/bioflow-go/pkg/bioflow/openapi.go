@@ -0,0 +1,38 @@
+package bioflow
+
+import (
+	"github.com/aria-lang/bioflow-go/internal/openapi"
+)
+
+// OpenAPIDocument is an OpenAPI 3 description of an HTTP API.
+type OpenAPIDocument = openapi.Document
+
+// OpenAPIInfo describes an API.
+type OpenAPIInfo = openapi.Info
+
+// OpenAPIServer is a base URL of an API.
+type OpenAPIServer = openapi.Server
+
+// OpenAPIEndpoint describes an endpoint to add to a document, with the Go
+// types of its bodies.
+type OpenAPIEndpoint = openapi.Endpoint
+
+// OpenAPIParameter is a path or query parameter.
+type OpenAPIParameter = openapi.Parameter
+
+// OpenAPISchema describes a JSON value.
+type OpenAPISchema = openapi.Schema
+
+// OpenAPIClientOptions configures GenerateOpenAPIClient.
+type OpenAPIClientOptions = openapi.ClientOptions
+
+// NewOpenAPIDocument returns a document without endpoints.
+func NewOpenAPIDocument(info OpenAPIInfo) *OpenAPIDocument {
+	return openapi.New(info)
+}
+
+// GenerateOpenAPIClient generates the Go source of a client of a
+// document, with a type per schema and a method per operation.
+func GenerateOpenAPIClient(doc *OpenAPIDocument, opts OpenAPIClientOptions) ([]byte, error) {
+	return openapi.GenerateClient(doc, opts)
+}
@@ -0,0 +1,43 @@
+package bioflow
+
+import (
+	"github.com/aria-lang/bioflow-go/internal/orf"
+)
+
+// ORF is an open reading frame.
+type ORF = orf.ORF
+
+// CorrectedORF is an ORF with suspected frameshifts corrected.
+type CorrectedORF = orf.CorrectedORF
+
+// FrameshiftOptions configures frameshift-tolerant ORF detection.
+type FrameshiftOptions = orf.FrameshiftOptions
+
+// Frameshift is a suspected single-base indel.
+type Frameshift = orf.Frameshift
+
+// ReferenceHit is a frameshift-tolerant alignment against a reference protein.
+type ReferenceHit = orf.ReferenceHit
+
+// DefaultMinORFLength is the default minimum ORF length in amino acids.
+const DefaultMinORFLength = orf.DefaultMinLength
+
+// FindORFs reports ORFs of at least minLength amino acids on the strands
+// selected.
+func FindORFs(seq *Sequence, minLength int, strand Strand) ([]ORF, error) {
+	return orf.Find(seq, minLength, strand)
+}
+
+// FindFrameshiftedORFs reports ORFs allowing single-base frameshifts,
+// with the positions of the suspected indels.
+func FindFrameshiftedORFs(seq *Sequence, opts FrameshiftOptions) ([]CorrectedORF, error) {
+	return orf.FindFrameshifted(seq, opts)
+}
+
+// AlignORFToReference aligns the selected strands of a sequence against a
+// reference protein allowing frameshifts, using default identity scores.
+func AlignORFToReference(seq *Sequence, reference *Protein, strand Strand) (*ReferenceHit, error) {
+	opts := orf.DefaultReferenceOptions()
+	opts.Strand = strand
+	return orf.AlignToReference(seq, reference, opts)
+}
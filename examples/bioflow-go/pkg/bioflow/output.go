@@ -0,0 +1,106 @@
+package bioflow
+
+import (
+	"sync"
+
+	"github.com/aria-lang/bioflow-go/internal/compress"
+	"github.com/aria-lang/bioflow-go/internal/output"
+)
+
+// OutputOptions configures compression and syncing of output files.
+type OutputOptions = output.Options
+
+// OutputFile is an output being written: Close replaces the destination
+// with it, Abort discards it.
+type OutputFile = output.File
+
+// CompressionCodec is a compression format for output files.
+type CompressionCodec = compress.Codec
+
+// Compression names accepted by OutputOptions besides codec names.
+const (
+	CompressionAuto = output.Auto
+	CompressionNone = output.None
+)
+
+// outputDefaults are the level and syncing of files written by functions
+// of this package that take a file name, such as WriteFASTA.
+var outputDefaults struct {
+	sync.Mutex
+	opts OutputOptions
+}
+
+// SetOutputDefaults sets the compression level and syncing of files
+// written by functions of this package that take a file name. Their
+// compression always follows the file name, so that files such as
+// pipeline checkpoints can be read back.
+func SetOutputDefaults(opts OutputOptions) {
+	outputDefaults.Lock()
+	defer outputDefaults.Unlock()
+	outputDefaults.opts = opts
+}
+
+// defaultOutputOptions returns the options set by SetOutputDefaults.
+func defaultOutputOptions() OutputOptions {
+	outputDefaults.Lock()
+	defer outputDefaults.Unlock()
+	return outputDefaults.opts
+}
+
+// CreateOutput starts writing a file, or standard output for "" or "-".
+// Nothing appears at filename until Close succeeds, so a failed or
+// interrupted write never leaves a partial file.
+//
+// Aria equivalent:
+//
+//	fn create_output(filename: Path, options: OutputOptions) -> Result<OutputFile, OutputError> with FileSystem
+func CreateOutput(filename string, opts OutputOptions) (*OutputFile, error) {
+	return output.Create(filename, opts)
+}
+
+// createFile starts writing a file, compressed as its name says, with
+// the default level and syncing.
+func createFile(filename string) (*OutputFile, error) {
+	opts := defaultOutputOptions()
+	opts.Compression = compress.ForPath(filename)
+	if opts.Compression == output.None {
+		opts.Level = 0
+	}
+	return output.Create(filename, opts)
+}
+
+// AbortOutputs discards every output file not yet closed. Programs that
+// exit on an error should call it first, as deferred Aborts do not run.
+func AbortOutputs() {
+	output.AbortAll()
+}
+
+// RegisterCompression adds a compression codec for reading and writing
+// files; gzip and zstd are built in.
+func RegisterCompression(codec CompressionCodec) error {
+	return compress.Register(codec)
+}
+
+// CompressionCodecs returns the names of the available compression codecs.
+func CompressionCodecs() []string {
+	return compress.Codecs()
+}
+
+// InputFile is an open input file, decompressed as it is read.
+type InputFile = compress.File
+
+// OpenInput opens a file for reading. gzip and zstd data is decompressed
+// whatever the file is called.
+//
+// Aria equivalent:
+//
+//	fn open_input(filename: Path) -> Result<InputFile, IoError> with FileSystem
+func OpenInput(filename string) (*InputFile, error) {
+	return compress.Open(filename)
+}
+
+// TrimCompressionExt returns a file name without a compression extension
+// such as .gz or .zst, so that its format can be told from what remains.
+func TrimCompressionExt(filename string) string {
+	return compress.TrimExt(filename)
+}
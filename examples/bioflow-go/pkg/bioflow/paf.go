@@ -0,0 +1,40 @@
+package bioflow
+
+import (
+	"io"
+
+	"github.com/aria-lang/bioflow-go/internal/paf"
+)
+
+// PAFRecord is one line of a PAF (Pairwise mApping Format) file.
+type PAFRecord = paf.Record
+
+// ReadPAF reads all records of a PAF file.
+func ReadPAF(filename string) ([]*PAFRecord, error) {
+	file, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return paf.Read(file)
+}
+
+// WritePAF writes records as PAF lines.
+func WritePAF(w io.Writer, records []*PAFRecord) error {
+	return paf.Write(w, records)
+}
+
+// ChainToPAF converts an anchor chain between query and target into an
+// approximate PAF record.
+func ChainToPAF(query, target *Sequence, chain Chain, k int) *PAFRecord {
+	return paf.FromChain(nameOr(query.ID, "query"), query.Len(), nameOr(target.ID, "target"), target.Len(), chain, k)
+}
+
+// nameOr returns name, or fallback when name is empty.
+func nameOr(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
@@ -0,0 +1,133 @@
+package bioflow
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/aria-lang/bioflow-go/internal/pileup"
+	"github.com/aria-lang/bioflow-go/internal/sam"
+	"github.com/aria-lang/bioflow-go/internal/stats"
+)
+
+// SAMRecord is one SAM alignment line.
+type SAMRecord = sam.Record
+
+// SAMHeader holds the header of a SAM or BAM file.
+type SAMHeader = sam.Header
+
+// Pileup holds per-position base counts of mapped reads.
+type Pileup = pileup.Pileup
+
+// PileupColumn is the pileup at one reference position.
+type PileupColumn = pileup.Column
+
+// PileupOptions filters the reads and bases entering a pileup.
+type PileupOptions = pileup.Options
+
+// VariantCall is a variant called from a pileup with its support.
+type VariantCall = pileup.Call
+
+// CallOptions holds the thresholds of the pileup variant caller.
+type CallOptions = pileup.CallOptions
+
+// DefaultPileupOptions returns samtools-like read and base filters.
+func DefaultPileupOptions() PileupOptions {
+	return pileup.DefaultOptions()
+}
+
+// DefaultCallOptions returns the default variant calling thresholds.
+func DefaultCallOptions() CallOptions {
+	return pileup.DefaultCallOptions()
+}
+
+// ReadSAM reads a SAM or BAM file, detected from its content; "-" reads
+// standard input.
+func ReadSAM(filename string) (*SAMHeader, []*SAMRecord, error) {
+	return sam.ReadFile(filename)
+}
+
+// WriteSAM writes a header and records as SAM text.
+func WriteSAM(w io.Writer, header *SAMHeader, records []*SAMRecord) error {
+	return sam.Write(w, header, records)
+}
+
+// AlignmentToSAM converts a pairwise alignment whose first sequence is the
+// reference into a SAM header and record for the second, soft clipping
+// the query bases outside a local alignment. The record carries the
+// alignment score in an AS tag.
+//
+// Aria equivalent:
+//
+//	fn alignment_to_sam(reference: Sequence, query: Sequence, a: Alignment)
+//	  -> Result<(SAMHeader, [SAMRecord]), Error>
+func AlignmentToSAM(reference, query *Sequence, a *Alignment) (*SAMHeader, []*SAMRecord, error) {
+	rname, qname := nameOr(reference.ID, "ref"), nameOr(query.ID, "query")
+	rec, err := sam.FromAlignedRows(qname, rname, a.Start1, a.AlignedSeq1, a.AlignedSeq2, query.Bases, a.Start2)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !rec.IsUnmapped() {
+		rec.SetTag("AS", 'i', fmt.Sprint(a.Score))
+	}
+	header := &SAMHeader{
+		Lines: []string{
+			"@HD\tVN:1.6\tSO:unsorted",
+			fmt.Sprintf("@SQ\tSN:%s\tLN:%d", rname, reference.Len()),
+			"@PG\tID:bioflow\tPN:bioflow\tVN:" + Version(),
+		},
+		References: []sam.Reference{{Name: rname, Length: reference.Len()}},
+	}
+	return header, []*SAMRecord{rec}, nil
+}
+
+// BuildPileup stacks mapped reads against reference sequences matched by
+// ID.
+func BuildPileup(references []*Sequence, records []*SAMRecord, opts PileupOptions) (*Pileup, error) {
+	refs := make(map[string]string, len(references))
+	for _, s := range references {
+		refs[s.ID] = s.Bases
+	}
+	return pileup.Build(refs, records, opts)
+}
+
+// PileupCalls calls variants on every covered chromosome, in chromosome
+// and position order.
+func PileupCalls(p *Pileup, opts CallOptions) []VariantCall {
+	calls := make([]VariantCall, 0)
+	for _, chrom := range p.Chromosomes() {
+		calls = append(calls, p.Call(chrom, opts)...)
+	}
+	return calls
+}
+
+// PileupVCF calls variants on every covered chromosome and wraps them in
+// a VCF with INFO header lines.
+func PileupVCF(p *Pileup, opts CallOptions) *VCF {
+	vcf := &VCF{Meta: pileup.CallMeta(), Records: make([]*VCFRecord, 0)}
+	for _, call := range PileupCalls(p, opts) {
+		vcf.Records = append(vcf.Records, call.Record())
+	}
+	return vcf
+}
+
+// CoverageReport summarizes per-base coverage: depth histogram, covered
+// fractions, uniformity and GC bias.
+type CoverageReport = stats.CoverageReport
+
+// CoverageOptions configures a coverage report.
+type CoverageOptions = stats.CoverageOptions
+
+// DefaultCoverageOptions returns the default coverage report parameters.
+func DefaultCoverageOptions() CoverageOptions {
+	return stats.DefaultCoverageOptions()
+}
+
+// PileupCoverageReport computes a coverage report over the full length of
+// the reference sequences from a pileup.
+func PileupCoverageReport(references []*Sequence, p *Pileup, opts CoverageOptions) (*CoverageReport, error) {
+	depths := make([][]int, len(references))
+	for i, ref := range references {
+		depths[i] = p.Depths(ref.ID, ref.Len())
+	}
+	return stats.NewCoverageReport(references, depths, opts)
+}
@@ -0,0 +1,207 @@
+package bioflow
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plugin"
+	"sort"
+	"sync"
+
+	"github.com/aria-lang/bioflow-go/internal/workflow"
+)
+
+// ReadProcessor is a processing stage over reads. It may drop, reorder or
+// rewrite reads, and returns the reads that continue down the pipeline.
+// Implementations must not modify the input slice.
+type ReadProcessor interface {
+	ProcessReads(reads []*Read) ([]*Read, error)
+}
+
+// SequenceProcessor is a processing stage over sequences, with the same
+// contract as ReadProcessor.
+type SequenceProcessor interface {
+	ProcessSequences(sequences []*Sequence) ([]*Sequence, error)
+}
+
+// ReadProcessorFunc adapts a function to ReadProcessor.
+type ReadProcessorFunc func(reads []*Read) ([]*Read, error)
+
+// ProcessReads calls f.
+func (f ReadProcessorFunc) ProcessReads(reads []*Read) ([]*Read, error) {
+	return f(reads)
+}
+
+// SequenceProcessorFunc adapts a function to SequenceProcessor.
+type SequenceProcessorFunc func(sequences []*Sequence) ([]*Sequence, error)
+
+// ProcessSequences calls f.
+func (f SequenceProcessorFunc) ProcessSequences(sequences []*Sequence) ([]*Sequence, error) {
+	return f(sequences)
+}
+
+// ReadsFromSequences runs a SequenceProcessor over the sequences of reads.
+// Each returned sequence is matched to the read it came from, by identity
+// or else by ID, and keeps that read's qualities and read group. Returned
+// sequences must keep the length of their read.
+func ReadsFromSequences(p SequenceProcessor) ReadProcessor {
+	return ReadProcessorFunc(func(reads []*Read) ([]*Read, error) {
+		sequences, _ := splitReads(reads)
+		out, err := p.ProcessSequences(sequences)
+		if err != nil {
+			return nil, err
+		}
+		bySeq := make(map[*Sequence]*Read, len(reads))
+		byID := make(map[string][]*Read, len(reads))
+		for _, r := range reads {
+			bySeq[r.Sequence] = r
+			byID[r.Sequence.ID] = append(byID[r.Sequence.ID], r)
+		}
+		kept := make([]*Read, 0, len(out))
+		for _, seq := range out {
+			read, ok := bySeq[seq]
+			if !ok {
+				if candidates := byID[seq.ID]; len(candidates) > 0 {
+					read, byID[seq.ID] = candidates[0], candidates[1:]
+				}
+			}
+			if read == nil {
+				return nil, fmt.Errorf("sequence %q does not match an input read", seq.ID)
+			}
+			if seq.Len() != read.Quality.Len() {
+				return nil, fmt.Errorf("sequence %q changed length from %d to %d", seq.ID, read.Quality.Len(), seq.Len())
+			}
+			kept = append(kept, &Read{Sequence: seq, Quality: read.Quality, Group: read.Group})
+		}
+		return kept, nil
+	})
+}
+
+// StageParam describes a parameter of a registered pipeline stage.
+type StageParam = workflow.Param
+
+// ParamKind is the type of a stage parameter.
+type ParamKind = workflow.Kind
+
+// Stage parameter kinds.
+const (
+	ParamInt    = workflow.Int
+	ParamFloat  = workflow.Float
+	ParamString = workflow.String
+)
+
+// StageFactory builds the processor for one stage of a pipeline from its
+// validated configuration. It is called before the run starts, also for a
+// dry run, so it may reject parameter combinations the schema cannot
+// express.
+type StageFactory func(stage WorkflowStage) (ReadProcessor, error)
+
+// Command is a CLI subcommand provided by a plugin. Run receives the
+// arguments after the command name.
+type Command struct {
+	Name        string
+	Description string
+	Run         func(args []string) error
+}
+
+// registry holds the stages and commands registered by plugins.
+var registry = struct {
+	sync.Mutex
+	stages   map[string]StageFactory
+	commands map[string]Command
+}{stages: make(map[string]StageFactory), commands: make(map[string]Command)}
+
+// RegisterStage adds a pipeline stage type that pipeline definitions can
+// use like the built-in ones. Packages providing stages call it from an
+// init function; importing the package, or loading it with LoadPlugins,
+// makes the stage available.
+//
+// Aria equivalent:
+//
+//	fn register_stage(stage_type: String, params: [StageParam], factory: StageFactory) -> Result<(), WorkflowError>
+//	  ensures result.is_ok() implies workflow_stage_types().contains(stage_type)
+func RegisterStage(stageType string, params []StageParam, factory StageFactory) error {
+	if factory == nil {
+		return fmt.Errorf("stage type %q: nil factory", stageType)
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if err := workflow.Register(stageType, params); err != nil {
+		return err
+	}
+	registry.stages[stageType] = factory
+	return nil
+}
+
+// stageFactory returns the factory of a registered stage type.
+func stageFactory(stageType string) (StageFactory, bool) {
+	registry.Lock()
+	defer registry.Unlock()
+	f, ok := registry.stages[stageType]
+	return f, ok
+}
+
+// RegisterCommand adds a CLI subcommand. Built-in commands take precedence
+// over registered ones with the same name.
+func RegisterCommand(cmd Command) error {
+	if cmd.Name == "" || cmd.Run == nil {
+		return fmt.Errorf("command needs a name and a Run function")
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.commands[cmd.Name]; ok {
+		return fmt.Errorf("command %q already registered", cmd.Name)
+	}
+	registry.commands[cmd.Name] = cmd
+	return nil
+}
+
+// LookupCommand returns a registered CLI subcommand.
+func LookupCommand(name string) (Command, bool) {
+	registry.Lock()
+	defer registry.Unlock()
+	cmd, ok := registry.commands[name]
+	return cmd, ok
+}
+
+// Commands returns the registered CLI subcommands ordered by name.
+func Commands() []Command {
+	registry.Lock()
+	defer registry.Unlock()
+	cmds := make([]Command, 0, len(registry.commands))
+	for _, cmd := range registry.commands {
+		cmds = append(cmds, cmd)
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds
+}
+
+// PluginPathEnv names the environment variable listing Go plugin files
+// (built with -buildmode=plugin) for LoadPluginsFromEnv, separated like
+// PATH.
+const PluginPathEnv = "BIOFLOW_PLUGINS"
+
+// LoadPlugins opens Go plugins. A plugin registers its stages and commands
+// from init functions, which run when it is opened. Go plugins must be
+// built with the same Go version and dependency versions as the program
+// loading them; where that is impractical, import the plugin package into
+// a custom build of the program instead.
+func LoadPlugins(paths []string) error {
+	for _, path := range paths {
+		if _, err := plugin.Open(path); err != nil {
+			return fmt.Errorf("loading plugin %s: %w", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
+
+// LoadPluginsFromEnv loads the plugins listed in PluginPathEnv, if any.
+func LoadPluginsFromEnv() error {
+	var paths []string
+	for _, path := range filepath.SplitList(os.Getenv(PluginPathEnv)) {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return LoadPlugins(paths)
+}
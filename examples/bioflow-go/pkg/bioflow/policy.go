@@ -0,0 +1,83 @@
+package bioflow
+
+import (
+	"fmt"
+
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Policy controls how raw bases are normalized and validated at parse
+// time: strict ACGTN, IUPAC codes, or permissive replacement, with
+// optional case preservation, whitespace stripping and RNA detection.
+type Policy = sequence.Policy
+
+// PolicyMode selects which characters a Policy accepts.
+type PolicyMode = sequence.PolicyMode
+
+// MaskRun is a run of soft-masked bases recorded with PreserveCase.
+type MaskRun = sequence.MaskRun
+
+// Policy modes.
+const (
+	PolicyStrict     = sequence.Strict
+	PolicyIUPAC      = sequence.IUPAC
+	PolicyPermissive = sequence.Permissive
+)
+
+// StrictPolicy returns the default policy: ACGTN only, upper-cased.
+func StrictPolicy() Policy {
+	return sequence.StrictPolicy()
+}
+
+// IUPACPolicy accepts IUPAC ambiguity codes.
+func IUPACPolicy() Policy {
+	return sequence.IUPACPolicy()
+}
+
+// PermissivePolicy replaces invalid characters with N.
+func PermissivePolicy() Policy {
+	return sequence.PermissivePolicy()
+}
+
+// ParsePolicy returns the named policy preset.
+func ParsePolicy(name string) (Policy, error) {
+	return sequence.ParsePolicy(name)
+}
+
+// NewSequenceWithPolicy creates a sequence from raw bases under a policy.
+func NewSequenceWithPolicy(bases, id, description string, policy Policy) (*Sequence, error) {
+	return sequence.NewWithPolicy(bases, id, description, policy)
+}
+
+// ReadFASTAWithPolicy reads sequences from a FASTA file under a policy.
+func ReadFASTAWithPolicy(filename string, policy Policy) ([]*Sequence, error) {
+	file, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseFASTAWithPolicy(file, policy)
+}
+
+// ReadFASTQWithPolicy reads reads from a FASTQ file under a policy.
+func ReadFASTQWithPolicy(filename string, policy Policy) ([]*Read, error) {
+	file, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseFASTQWithPolicy(file, policy)
+}
+
+// newReadSequence creates the sequence of a read. Reads are DNA unless the
+// policy detects RNA, and must keep one base per quality score, so
+// whitespace stripping is not applied.
+func newReadSequence(bases, id string, policy Policy) (*Sequence, error) {
+	if len(id) == 0 {
+		return nil, fmt.Errorf("ID cannot be empty")
+	}
+	policy.StripWhitespace = false
+	return sequence.NewWithPolicy(bases, id, "", policy)
+}
@@ -0,0 +1,39 @@
+package bioflow
+
+import "github.com/aria-lang/bioflow-go/internal/probe"
+
+// Probe is a hybridization probe selected from a target.
+type Probe = probe.Probe
+
+// ProbeOptions configures probe design.
+type ProbeOptions = probe.Options
+
+// TmConditions are the conditions melting temperatures are computed for.
+type TmConditions = probe.TmConditions
+
+// DefaultProbeOptions returns the default probe design options.
+func DefaultProbeOptions() ProbeOptions {
+	return probe.DefaultOptions()
+}
+
+// DefaultTmConditions returns typical hybridization conditions.
+func DefaultTmConditions() TmConditions {
+	return probe.DefaultTmConditions()
+}
+
+// DesignProbes selects oligos from targets that have no close match
+// elsewhere in the targets or in the background, within GC and melting
+// temperature bounds.
+//
+// Aria equivalent:
+//
+//	fn design_probes(targets: [Sequence], background: [Sequence], options: ProbeOptions) -> Result<[Probe], ProbeError>
+func DesignProbes(targets, background []*Sequence, opts ProbeOptions) ([]Probe, error) {
+	return probe.Design(targets, background, opts)
+}
+
+// MeltingTemperature returns the nearest-neighbor melting temperature of
+// an oligo in °C.
+func MeltingTemperature(oligo string, cond TmConditions) (float64, error) {
+	return probe.MeltingTemperature(oligo, cond)
+}
@@ -0,0 +1,86 @@
+package bioflow
+
+import (
+	"github.com/aria-lang/bioflow-go/internal/protein"
+)
+
+// Protein represents an amino-acid sequence.
+type Protein = protein.Protein
+
+// ProteinProperties summarizes the physico-chemical properties of a protein.
+type ProteinProperties = protein.Properties
+
+// AminoAcids lists the 20 standard amino acids in one-letter code.
+const AminoAcids = protein.AminoAcids
+
+// NewProtein creates a protein from one-letter residues.
+func NewProtein(residues string) (*Protein, error) {
+	return protein.New(residues)
+}
+
+// Translate translates a nucleotide sequence in the given frame (0-2)
+// with the standard genetic code.
+func Translate(seq *Sequence, frame int, toStop bool) (*Protein, error) {
+	return protein.Translate(seq, frame, toStop)
+}
+
+// ProteinStats calculates molecular weight, pI, GRAVY, instability index
+// and amino-acid composition.
+func ProteinStats(p *Protein) *ProteinProperties {
+	return protein.ComputeProperties(p)
+}
+
+// ReadProteinFASTA reads protein sequences from a FASTA file.
+func ReadProteinFASTA(filename string) ([]*Protein, error) {
+	file, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return protein.ParseFASTA(file)
+}
+
+// CodonUsage maps codons to their usage in an organism.
+type CodonUsage = protein.CodonUsage
+
+// BackTranslateOptions configures back-translation.
+type BackTranslateOptions = protein.BackTranslateOptions
+
+// Back-translation strategies
+const (
+	MostFrequentCodon = protein.MostFrequent
+	WeightedCodon     = protein.Weighted
+)
+
+// CodonTable returns a built-in codon usage table ("ecoli", "human"), or
+// one in the data directory.
+func CodonTable(organism string) (CodonUsage, error) {
+	return protein.CodonTable(organism)
+}
+
+// CodonTableNames returns the names of the codon usage tables.
+func CodonTableNames() []string {
+	return protein.CodonTableNames()
+}
+
+// ParseCodonStrategy parses a back-translation strategy name.
+func ParseCodonStrategy(name string) (protein.Strategy, error) {
+	return protein.ParseStrategy(name)
+}
+
+// ReadCodonUsage reads a codon usage table file.
+func ReadCodonUsage(filename string) (CodonUsage, error) {
+	file, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return protein.ParseCodonUsage(file)
+}
+
+// BackTranslate reverse-translates a protein into DNA using codon usage.
+func BackTranslate(p *Protein, opts BackTranslateOptions) (*Sequence, error) {
+	return protein.BackTranslate(p, opts)
+}
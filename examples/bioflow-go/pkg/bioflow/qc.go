@@ -0,0 +1,89 @@
+package bioflow
+
+import (
+	"github.com/aria-lang/bioflow-go/internal/stats"
+)
+
+// QCReport is a FastQC-style summary of a read set.
+type QCReport = stats.QCReport
+
+// QCOptions configures a read QC report.
+type QCOptions = stats.QCOptions
+
+// KMerEnrichment is a k-mer concentrated at particular read positions.
+type KMerEnrichment = stats.KMerEnrichment
+
+// KMerContentOptions configures positional k-mer enrichment.
+type KMerContentOptions = stats.KMerContentOptions
+
+// Adapter is a named adapter sequence to scan reads for or trim.
+type Adapter = stats.Adapter
+
+// AdapterContent is the cumulative fraction of reads containing an
+// adapter by cycle.
+type AdapterContent = stats.AdapterContent
+
+// DefaultAdapters are the common adapter prefixes scanned by default.
+var DefaultAdapters = stats.DefaultAdapters
+
+// DefaultQCOptions returns the default QC parameters.
+func DefaultQCOptions() QCOptions {
+	return stats.DefaultQCOptions()
+}
+
+// ReadQC builds a QC report for a set of reads.
+func ReadQC(reads []*Read, opts QCOptions) (*QCReport, error) {
+	sequences := make([]*Sequence, len(reads))
+	qualities := make([]*QualityScores, len(reads))
+	for i, r := range reads {
+		sequences[i] = r.Sequence
+		qualities[i] = r.Quality
+	}
+	report, err := stats.NewQCReport(sequences, qualities, opts)
+	if err != nil {
+		return nil, err
+	}
+	report.ReadGroups = ReadGroupSummaries(reads)
+	return report, nil
+}
+
+// KMerContent finds k-mers enriched at particular positions of the reads.
+func KMerContent(reads []*Read, opts KMerContentOptions) ([]KMerEnrichment, error) {
+	sequences := make([]*Sequence, len(reads))
+	for i, r := range reads {
+		sequences[i] = r.Sequence
+	}
+	return stats.KMerContent(sequences, opts)
+}
+
+// JointStats holds length/GC and quality/length correlations.
+type JointStats = stats.JointStats
+
+// Correlation relates two per-sequence measurements, with a 2D grid of
+// counts for heatmaps.
+type Correlation = stats.Correlation
+
+// JointOptions configures joint statistics.
+type JointOptions = stats.JointOptions
+
+// DefaultJointOptions returns the default joint statistics parameters.
+func DefaultJointOptions() JointOptions {
+	return stats.DefaultJointOptions()
+}
+
+// SequenceJointStats correlates length with GC content across sequences.
+func SequenceJointStats(sequences []*Sequence, opts JointOptions) (*JointStats, error) {
+	return stats.NewJointStats(sequences, nil, opts)
+}
+
+// ReadJointStats correlates length with GC content and mean quality with
+// length across reads.
+func ReadJointStats(reads []*Read, opts JointOptions) (*JointStats, error) {
+	sequences := make([]*Sequence, len(reads))
+	qualities := make([]*QualityScores, len(reads))
+	for i, r := range reads {
+		sequences[i] = r.Sequence
+		qualities[i] = r.Quality
+	}
+	return stats.NewJointStats(sequences, qualities, opts)
+}
@@ -0,0 +1,27 @@
+package bioflow
+
+import (
+	"math/rand"
+
+	"github.com/aria-lang/bioflow-go/internal/random"
+)
+
+// DefaultSeed is the seed stochastic features use when none is given.
+// See the randomness policy in NewRand.
+const DefaultSeed = random.DefaultSeed
+
+// NewRand returns a random number generator seeded with seed. Every
+// stochastic feature (bootstrap intervals, weighted back-translation,
+// SequenceSet.Sample and Shuffle, the subsample pipeline stage) takes a
+// seed or such a generator and never uses global randomness, so the same
+// inputs and seed give bit-for-bit identical results. A generator is not
+// safe for concurrent use.
+func NewRand(seed int64) *rand.Rand {
+	return random.New(seed)
+}
+
+// DeriveSeed returns the seed of a named stream derived from seed, giving
+// each stochastic step of a run its own reproducible numbers.
+func DeriveSeed(seed int64, stream string) int64 {
+	return random.Derive(seed, stream)
+}
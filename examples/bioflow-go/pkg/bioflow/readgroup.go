@@ -0,0 +1,92 @@
+package bioflow
+
+import (
+	"github.com/aria-lang/bioflow-go/internal/readgroup"
+)
+
+// ReadGroup describes where reads came from: sample, library, run, lane
+// and platform.
+type ReadGroup = readgroup.ReadGroup
+
+// ReadGroupSummary is a read group with its number of reads.
+type ReadGroupSummary = readgroup.Summary
+
+// ReadGroupFromName recovers a read group from an Illumina or SRA read
+// name.
+func ReadGroupFromName(name string) (ReadGroup, bool) {
+	return readgroup.FromReadName(name)
+}
+
+// ParseReadGroup reads a read group from a "key=value" sidecar file.
+func ParseReadGroup(filename string) (ReadGroup, error) {
+	return readgroup.ReadFile(filename)
+}
+
+// AssignReadGroups sets the Group of each read from its name, with the
+// fields set in declared taking precedence, and returns the distinct
+// groups. Reads about which nothing is known keep a nil Group.
+//
+// Aria equivalent:
+//
+//	fn assign_read_groups(reads: [Read], declared: ReadGroup) -> [ReadGroupSummary]
+//	  ensures result.map(|g| g.reads).sum() <= reads.len()
+func AssignReadGroups(reads []*Read, declared ReadGroup) []ReadGroupSummary {
+	a := readgroup.NewAssigner(declared)
+	assigned := make([]int, len(reads))
+	for i, r := range reads {
+		assigned[i] = a.Assign(r.Sequence.ID)
+	}
+	groups := a.Groups()
+	shared := make([]*ReadGroup, len(groups))
+	for i := range groups {
+		g := groups[i].ReadGroup
+		shared[i] = &g
+	}
+	for i, r := range reads {
+		if assigned[i] >= 0 {
+			r.Group = shared[assigned[i]]
+		}
+	}
+	return groups
+}
+
+// ReadGroupSummaries counts the reads of each group set on the reads, in
+// order of first appearance.
+func ReadGroupSummaries(reads []*Read) []ReadGroupSummary {
+	var groups []ReadGroupSummary
+	byID := make(map[string]int)
+	for _, r := range reads {
+		if r.Group == nil {
+			continue
+		}
+		i, ok := byID[r.Group.ID]
+		if !ok {
+			i = len(groups)
+			byID[r.Group.ID] = i
+			groups = append(groups, ReadGroupSummary{ReadGroup: *r.Group})
+		}
+		groups[i].Reads++
+	}
+	return groups
+}
+
+// TagReadGroups adds an @RG header line for each read group and sets the
+// RG tag of each record, recovering the group from the query name and
+// merging in declared. It returns the groups used.
+func TagReadGroups(header *SAMHeader, records []*SAMRecord, declared ReadGroup) []ReadGroupSummary {
+	a := readgroup.NewAssigner(declared)
+	groups := make([]int, len(records))
+	for i, rec := range records {
+		groups[i] = a.Assign(rec.QName)
+	}
+	summaries := a.Groups()
+	for _, g := range summaries {
+		header.AddReadGroup(g.ReadGroup)
+	}
+	for i, rec := range records {
+		if groups[i] >= 0 {
+			rec.SetTag("RG", 'Z', summaries[groups[i]].ID)
+		}
+	}
+	return summaries
+}
@@ -0,0 +1,86 @@
+package bioflow
+
+import (
+	"io"
+
+	"github.com/aria-lang/bioflow-go/internal/repeat"
+)
+
+// RepeatScanOptions configures a repeat-motif content scan.
+type RepeatScanOptions = repeat.ScanOptions
+
+// RepeatReport summarizes repeat-motif content across a sequence.
+type RepeatReport = repeat.Report
+
+// DefaultTelomereMotif is the vertebrate telomeric repeat unit.
+const DefaultTelomereMotif = repeat.DefaultTelomereMotif
+
+// ScanRepeatMotifs counts repeat motifs in windows across a sequence and
+// reports candidate repeat (by default telomeric) regions.
+func ScanRepeatMotifs(seq *Sequence, opts RepeatScanOptions) (*RepeatReport, error) {
+	return repeat.Scan(seq, opts)
+}
+
+// Duplication is a copy of a repeat found by self-comparison.
+type Duplication = repeat.Duplication
+
+// DuplicationOptions configures repeat annotation by self-comparison.
+type DuplicationOptions = repeat.DuplicationOptions
+
+// DefaultDuplicationOptions returns the default self-comparison options.
+func DefaultDuplicationOptions() DuplicationOptions {
+	return repeat.DefaultDuplicationOptions()
+}
+
+// FindDuplications annotates large duplicated and repetitive regions of a
+// sequence by chaining its repeated minimizers against itself, with the
+// number of copies of each.
+//
+// Aria equivalent:
+//
+//	fn find_duplications(seq: Sequence, options: DuplicationOptions) -> Result<[Duplication], RepeatError>
+func FindDuplications(seq *Sequence, opts DuplicationOptions) ([]Duplication, error) {
+	return repeat.FindDuplications(seq, opts)
+}
+
+// WriteDuplicationsBED writes duplications as BED6.
+func WriteDuplicationsBED(w io.Writer, dups []Duplication) error {
+	return repeat.WriteDuplicationsBED(w, dups)
+}
+
+// WriteDuplicationsGFF writes duplications as GFF3 repeat_region features.
+func WriteDuplicationsGFF(w io.Writer, dups []Duplication) error {
+	return repeat.WriteDuplicationsGFF(w, dups)
+}
+
+// InvertedRepeat is a pair of reverse-complementary arms around a loop.
+type InvertedRepeat = repeat.InvertedRepeat
+
+// InvertedRepeatOptions configures inverted repeat detection.
+type InvertedRepeatOptions = repeat.InvertedRepeatOptions
+
+// DefaultInvertedRepeatOptions returns the default detection options.
+func DefaultInvertedRepeatOptions() InvertedRepeatOptions {
+	return repeat.DefaultInvertedRepeatOptions()
+}
+
+// FindInvertedRepeats finds inverted repeats and hairpins: arms whose
+// reverse complements pair across a loop, with bounded mismatches.
+//
+// Aria equivalent:
+//
+//	fn find_inverted_repeats(seq: Sequence, options: InvertedRepeatOptions) -> Result<[InvertedRepeat], RepeatError>
+func FindInvertedRepeats(seq *Sequence, opts InvertedRepeatOptions) ([]InvertedRepeat, error) {
+	return repeat.FindInvertedRepeats(seq, opts)
+}
+
+// WriteInvertedRepeatsBED writes inverted repeats as BED6.
+func WriteInvertedRepeatsBED(w io.Writer, repeats []InvertedRepeat) error {
+	return repeat.WriteInvertedRepeatsBED(w, repeats)
+}
+
+// WriteInvertedRepeatsGFF writes inverted repeats as GFF3 inverted_repeat
+// features.
+func WriteInvertedRepeatsGFF(w io.Writer, repeats []InvertedRepeat) error {
+	return repeat.WriteInvertedRepeatsGFF(w, repeats)
+}
@@ -0,0 +1,63 @@
+package bioflow
+
+import (
+	"github.com/aria-lang/bioflow-go/internal/quality"
+	"github.com/aria-lang/bioflow-go/internal/report"
+	"github.com/aria-lang/bioflow-go/internal/stats"
+)
+
+// HTMLReport is a self-contained HTML report made of sections.
+type HTMLReport = report.Report
+
+// ReportSection is one titled part of an HTML report.
+type ReportSection = report.Section
+
+// FilterSummary is the outcome of quality filtering, for reports.
+type FilterSummary = report.FilterSummary
+
+// NewHTMLReport creates an empty HTML report.
+func NewHTMLReport(title string) *HTMLReport {
+	return report.New(title)
+}
+
+// QCHTMLReport renders a read QC report as an HTML report.
+func QCHTMLReport(title string, qc *QCReport) *HTMLReport {
+	return report.QC(title, qc)
+}
+
+// SequenceSetSection summarizes a set of sequences for an HTML report.
+func SequenceSetSection(s *stats.SequenceSetStats) ReportSection {
+	return report.SequenceSetSection(s)
+}
+
+// ReadSetSections describes read statistics and per-position quality for
+// an HTML report.
+func ReadSetSections(s *ReadSetStats) []ReportSection {
+	return report.ReadSetSections(s)
+}
+
+// FilterSection summarizes filtering for an HTML report.
+func FilterSection(f FilterSummary) ReportSection {
+	return report.FilterSection(f)
+}
+
+// BatchFilterSummary summarizes a batch filter result, grouping rejection
+// reasons.
+func BatchFilterSummary(result *quality.BatchFilterResult) FilterSummary {
+	reasons := make([]string, 0, len(result.FailReasons))
+	for _, reason := range result.FailReasons {
+		reasons = append(reasons, reason)
+	}
+	return report.NewFilterSummary(result.TotalProcessed, result.PassedCount, reasons)
+}
+
+// StreamFilterSummary summarizes a streaming filter run, which does not
+// record rejection reasons.
+func StreamFilterSummary(result *StreamResult) FilterSummary {
+	return FilterSummary{Total: result.TotalProcessed, Passed: result.PassedCount, Failed: result.FailedCount}
+}
+
+// JointSection shows joint statistics as heatmaps in an HTML report.
+func JointSection(j *JointStats) ReportSection {
+	return report.JointSection(j)
+}
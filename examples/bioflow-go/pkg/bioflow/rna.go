@@ -0,0 +1,44 @@
+package bioflow
+
+import (
+	"context"
+
+	"github.com/aria-lang/bioflow-go/internal/rna"
+)
+
+// RNAStructure is a predicted RNA secondary structure.
+type RNAStructure = rna.Structure
+
+// RNAPair is a base pair of an RNA structure.
+type RNAPair = rna.Pair
+
+// FoldOptions configures RNA folding.
+type FoldOptions = rna.Options
+
+// FoldModel selects how base pairs are scored when folding.
+type FoldModel = rna.Model
+
+// Folding models
+const (
+	FoldPairCount     = rna.PairCount
+	FoldHydrogenBonds = rna.HydrogenBonds
+)
+
+// DefaultMinLoop is the default minimum hairpin loop length.
+const DefaultMinLoop = rna.DefaultMinLoop
+
+// ParseFoldModel parses a folding model name ("pairs" or "hbonds").
+func ParseFoldModel(name string) (FoldModel, error) {
+	return rna.ParseModel(name)
+}
+
+// FoldRNA predicts the secondary structure of an RNA (or DNA) sequence.
+func FoldRNA(seq *Sequence, opts FoldOptions) (*RNAStructure, error) {
+	return rna.Fold(seq.Bases, opts)
+}
+
+// FoldRNAContext predicts the secondary structure of a sequence as
+// FoldRNA does, bounded by ctx.
+func FoldRNAContext(ctx context.Context, seq *Sequence, opts FoldOptions) (*RNAStructure, error) {
+	return rna.FoldContext(ctx, seq.Bases, opts)
+}
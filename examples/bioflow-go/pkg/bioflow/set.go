@@ -0,0 +1,53 @@
+package bioflow
+
+import (
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// SequenceSet is an ordered collection of sequences indexed by ID, with
+// filtering, sorting, grouping and set operations.
+type SequenceSet = sequence.SequenceSet
+
+// SequenceGroup is a named subset produced by SequenceSet.GroupBy.
+type SequenceGroup = sequence.Group
+
+// SortKey selects the order of SequenceSet.Sort.
+type SortKey = sequence.SortKey
+
+// SetKey selects whether set operations match sequences by ID or by
+// checksum.
+type SetKey = sequence.SetKey
+
+// Sort and set keys.
+const (
+	SortByID     = sequence.SortByID
+	SortByLength = sequence.SortByLength
+	SortByGC     = sequence.SortByGC
+
+	ByID       = sequence.ByID
+	ByChecksum = sequence.ByChecksum
+)
+
+// NewSequenceSet creates a set holding sequences in the given order.
+func NewSequenceSet(sequences ...*Sequence) *SequenceSet {
+	return sequence.NewSequenceSet(sequences...)
+}
+
+// ParseSortKey returns the sort key named "id", "length" or "gc".
+func ParseSortKey(name string) (SortKey, error) {
+	return sequence.ParseSortKey(name)
+}
+
+// ParseSetKey returns the set key named "id" or "checksum".
+func ParseSetKey(name string) (SetKey, error) {
+	return sequence.ParseSetKey(name)
+}
+
+// ReadSequenceSet reads a FASTA file into a sequence set.
+func ReadSequenceSet(filename string) (*SequenceSet, error) {
+	sequences, err := ReadFASTA(filename)
+	if err != nil {
+		return nil, err
+	}
+	return NewSequenceSet(sequences...), nil
+}
@@ -0,0 +1,253 @@
+package bioflow
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aria-lang/bioflow-go/internal/alignment"
+	"github.com/aria-lang/bioflow-go/internal/kmer"
+)
+
+// Syncmer is a k-mer selected by the position of its smallest s-mer.
+type Syncmer = kmer.Syncmer
+
+// SyncmerOptions configures syncmer selection.
+type SyncmerOptions = kmer.SyncmerOptions
+
+// Randstrobe is a linked set of k-mers used as a long-read seed.
+type Randstrobe = kmer.Randstrobe
+
+// RandstrobeOptions configures randstrobe extraction.
+type RandstrobeOptions = kmer.RandstrobeOptions
+
+// DefaultRandstrobeOptions are order-2 randstrobe parameters for long reads.
+var DefaultRandstrobeOptions = kmer.DefaultRandstrobeOptions
+
+// Minimizer is the smallest-hash k-mer of a window of k-mers.
+type Minimizer = kmer.Minimizer
+
+// MinimizerOptions configures minimizer selection.
+type MinimizerOptions = kmer.MinimizerOptions
+
+// Minimizers extracts the (w,k)-minimizers of a sequence.
+func Minimizers(seq *Sequence, opts MinimizerOptions) ([]Minimizer, error) {
+	return kmer.Minimizers(seq, opts)
+}
+
+// Syncmers extracts open or closed syncmers from a sequence.
+func Syncmers(seq *Sequence, opts SyncmerOptions) ([]Syncmer, error) {
+	return kmer.Syncmers(seq, opts)
+}
+
+// Randstrobes extracts randstrobes from a sequence.
+func Randstrobes(seq *Sequence, opts RandstrobeOptions) ([]Randstrobe, error) {
+	return kmer.Randstrobes(seq, opts)
+}
+
+// HyperLogLog estimates distinct k-mer counts in constant memory.
+type HyperLogLog = kmer.HyperLogLog
+
+// CardinalityEstimate is a distinct-count estimate with error bounds.
+type CardinalityEstimate = kmer.CardinalityEstimate
+
+// DefaultHLLPrecision is the default HyperLogLog precision (~0.8% error).
+const DefaultHLLPrecision = kmer.DefaultHLLPrecision
+
+// NewHyperLogLog creates an empty distinct k-mer estimator.
+func NewHyperLogLog(k, precision int, canonical bool) (*HyperLogLog, error) {
+	return kmer.NewHyperLogLog(k, precision, canonical)
+}
+
+// CountDistinctKMers estimates the number of distinct k-mers in a FASTA,
+// FASTQ or plain-sequence stream without holding it in memory.
+func CountDistinctKMers(r io.Reader, k, precision int, canonical bool) (CardinalityEstimate, error) {
+	hll, err := kmer.NewHyperLogLog(k, precision, canonical)
+	if err != nil {
+		return CardinalityEstimate{}, err
+	}
+	if _, err := hll.ReadFrom(r); err != nil {
+		return CardinalityEstimate{}, err
+	}
+	return hll.Estimate(), nil
+}
+
+// BuildKMerIndex counts the k-mers of all reference sequences into one
+// genome-wide index.
+func BuildKMerIndex(reference []*Sequence, k int, canonical bool) (*KMerCounter, error) {
+	return kmer.BuildIndex(reference, k, canonical)
+}
+
+// Mappability computes a per-position mappability track (1/occurrences
+// of the k-mer starting at each position) against a k-mer index.
+func Mappability(seq *Sequence, index *KMerCounter) (*Track, error) {
+	return kmer.Mappability(seq, index)
+}
+
+// UniqueFraction returns the uniquely mappable fraction of a mappability track.
+func UniqueFraction(t *Track) float64 {
+	return kmer.UniqueFraction(t)
+}
+
+// MaskOptions configures k-mer abundance masking.
+type MaskOptions = kmer.MaskOptions
+
+// MaskResult is a masked sequence with its masked regions.
+type MaskResult = kmer.MaskResult
+
+// Masking modes.
+const (
+	HardMask = kmer.HardMask
+	SoftMask = kmer.SoftMask
+)
+
+// CountKMersCanonical counts k-mers merged with their reverse complements.
+func CountKMersCanonical(seq *Sequence, k int) (*KMerCounter, error) {
+	return kmer.CountKMersCanonical(seq, k)
+}
+
+// CountUnmaskedKMers counts the k-mers of a sequence overlapping no
+// soft-masked base. Read sequences with a policy that has PreserveCase
+// set, or keep their lower-case bases, for their masking to be seen.
+func CountUnmaskedKMers(seq *Sequence, k int) (*KMerCounter, error) {
+	return kmer.CountUnmaskedKMers(seq, k)
+}
+
+// SoftMaskedRuns returns the soft-masked (lower-case) runs of a sequence.
+func SoftMaskedRuns(seq *Sequence) []MaskRun {
+	return kmer.SoftMaskedRuns(seq)
+}
+
+// HardMaskedRuns returns the runs of N in a sequence.
+func HardMaskedRuns(seq *Sequence) []MaskRun {
+	return kmer.HardMaskedRuns(seq)
+}
+
+// MaskedBases returns the number of bases covered by mask runs.
+func MaskedBases(runs []MaskRun) int {
+	return kmer.MaskedBases(runs)
+}
+
+// MaskByAbundance hard- or soft-masks bases covered by k-mers whose count
+// in counter exceeds the threshold.
+func MaskByAbundance(seq *Sequence, counter *KMerCounter, opts MaskOptions) (*MaskResult, error) {
+	return kmer.MaskByAbundance(seq, counter, opts)
+}
+
+// KMerDumpFormat is a k-mer count text format shared with other tools.
+type KMerDumpFormat = kmer.DumpFormat
+
+// K-mer dump formats.
+const (
+	AutoDump        = kmer.AutoDump
+	JellyfishFASTA  = kmer.JellyfishFASTA
+	JellyfishColumn = kmer.JellyfishColumn
+	KMCDump         = kmer.KMCDump
+)
+
+// ParseKMerDumpFormat parses a dump format name (auto, jellyfish,
+// jellyfish-column, kmc).
+func ParseKMerDumpFormat(name string) (KMerDumpFormat, error) {
+	return kmer.ParseDumpFormat(name)
+}
+
+// SaveKMerDump writes counts to a file in a Jellyfish or KMC text format.
+func SaveKMerDump(filename string, counter *KMerCounter, format KMerDumpFormat) error {
+	file, err := createFile(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+
+	if err := kmer.WriteDump(file, counter, format); err != nil {
+		return fmt.Errorf("writing k-mer dump: %w", err)
+	}
+	file.AddRecords(counter.UniqueCount())
+	return file.Close()
+}
+
+// LoadKMerDump reads counts from a Jellyfish or KMC text dump.
+func LoadKMerDump(filename string, format KMerDumpFormat) (*KMerCounter, error) {
+	file, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return kmer.ReadDump(file, format)
+}
+
+// Anchor is an exact k-mer match between two sequences.
+type Anchor = kmer.Anchor
+
+// AnchorOptions configures anchor finding.
+type AnchorOptions = kmer.AnchorOptions
+
+// Chain is a collinear run of anchors.
+type Chain = kmer.Chain
+
+// ChainOptions configures anchor chaining.
+type ChainOptions = kmer.ChainOptions
+
+// DefaultChainOptions returns chaining defaults for anchors of length k.
+func DefaultChainOptions(k int) ChainOptions {
+	return kmer.DefaultChainOptions(k)
+}
+
+// FindAnchors returns the positions of k-mers shared by two sequences.
+func FindAnchors(seq1, seq2 *Sequence, opts AnchorOptions) ([]Anchor, error) {
+	return kmer.FindAnchors(seq1, seq2, opts)
+}
+
+// FindAnchorsContext finds anchors as FindAnchors does, and stops with the
+// context's error once ctx is done.
+func FindAnchorsContext(ctx context.Context, seq1, seq2 *Sequence, opts AnchorOptions) ([]Anchor, error) {
+	return kmer.FindAnchorsContext(ctx, seq1, seq2, opts)
+}
+
+// ChainAnchors groups anchors into collinear chains, best first.
+func ChainAnchors(anchors []Anchor, opts ChainOptions) ([]Chain, error) {
+	return kmer.ChainAnchors(anchors, opts)
+}
+
+// AnchorPair is a known gapless match used to guide alignment.
+type AnchorPair = alignment.AnchorPair
+
+// ChainAnchorPairs converts a forward-strand chain of k-mer anchors into
+// anchor pairs for AlignWithAnchors.
+func ChainAnchorPairs(chain Chain, k int) []AnchorPair {
+	pairs := make([]AnchorPair, len(chain.Anchors))
+	for i, a := range chain.Anchors {
+		pairs[i] = AnchorPair{Pos1: a.Pos1, Pos2: a.Pos2, Length: k}
+	}
+	return pairs
+}
+
+// AlignWithAnchors performs global alignment with DP only between the
+// given anchors.
+func AlignWithAnchors(seq1, seq2 *Sequence, anchors []AnchorPair, scoring *ScoringMatrix) (*Alignment, error) {
+	return alignment.AnchoredAlignment(seq1, seq2, anchors, scoring)
+}
+
+// AlignAnchored globally aligns two long, mostly similar sequences by
+// anchoring the best forward-strand chain of shared k-mers and running DP
+// only between anchors. Without such a chain it is a plain global
+// alignment.
+func AlignAnchored(seq1, seq2 *Sequence, k int, scoring *ScoringMatrix) (*Alignment, error) {
+	anchors, err := kmer.FindAnchors(seq1, seq2, kmer.AnchorOptions{K: k})
+	if err != nil {
+		return nil, err
+	}
+	chains, err := kmer.ChainAnchors(anchors, kmer.DefaultChainOptions(k))
+	if err != nil {
+		return nil, err
+	}
+	var pairs []AnchorPair
+	for _, chain := range chains {
+		if chain.Strand == '+' {
+			pairs = ChainAnchorPairs(chain, k)
+			break
+		}
+	}
+	return alignment.AnchoredAlignment(seq1, seq2, pairs, scoring)
+}
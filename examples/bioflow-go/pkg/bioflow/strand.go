@@ -0,0 +1,22 @@
+package bioflow
+
+import (
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+)
+
+// Strand is a strand of a DNA sequence, or both strands as an analysis
+// option. Motif hits, ORFs and other intervals report theirs as "+" or "-".
+type Strand = sequence.Strand
+
+// Strands.
+const (
+	ForwardStrand = sequence.Forward
+	ReverseStrand = sequence.Reverse
+	BothStrands   = sequence.BothStrands
+)
+
+// ParseStrand parses a strand option: forward, reverse or both (or +, -
+// and .).
+func ParseStrand(name string) (Strand, error) {
+	return sequence.ParseStrand(name)
+}
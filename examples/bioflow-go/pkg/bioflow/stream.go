@@ -0,0 +1,296 @@
+package bioflow
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aria-lang/bioflow-go/internal/quality"
+	"github.com/aria-lang/bioflow-go/internal/sequence"
+	"github.com/aria-lang/bioflow-go/internal/stats"
+)
+
+// FASTQReader reads FASTQ records one at a time.
+//
+// Unlike ParseFASTQ, it never holds more than one record in memory and
+// tracks the byte offset of the next record, which is what makes
+// checkpointing of very large inputs possible.
+type FASTQReader struct {
+	r      *bufio.Reader
+	offset int64
+	line   int
+	policy Policy
+}
+
+// NewFASTQReader creates a streaming FASTQ reader.
+func NewFASTQReader(r io.Reader) *FASTQReader {
+	return NewFASTQReaderAt(r, 0, 0)
+}
+
+// NewFASTQReaderAt creates a streaming FASTQ reader for a stream that has
+// already been positioned at offset (for example with Seek when resuming
+// from a checkpoint). Line is the number of lines preceding offset and is
+// only used for error messages.
+func NewFASTQReaderAt(r io.Reader, offset int64, line int) *FASTQReader {
+	return &FASTQReader{
+		r:      bufio.NewReaderSize(r, 64*1024),
+		offset: offset,
+		line:   line,
+		policy: StrictPolicy(),
+	}
+}
+
+// SetPolicy sets the validation policy applied to the bases of each read.
+func (fr *FASTQReader) SetPolicy(policy Policy) {
+	fr.policy = policy
+}
+
+// Offset returns the byte offset just past the last record returned by Next.
+func (fr *FASTQReader) Offset() int64 {
+	return fr.offset
+}
+
+// Line returns the number of lines consumed so far.
+func (fr *FASTQReader) Line() int {
+	return fr.line
+}
+
+// readLine reads the next line, returning io.EOF once the input is exhausted.
+func (fr *FASTQReader) readLine() (string, error) {
+	s, err := fr.r.ReadString('\n')
+	if len(s) > 0 {
+		fr.offset += int64(len(s))
+		fr.line++
+		return strings.TrimSpace(s), nil
+	}
+	if err == nil {
+		err = io.EOF
+	}
+	return "", err
+}
+
+// Next returns the next read, or io.EOF when there are no more records.
+func (fr *FASTQReader) Next() (*Read, error) {
+	var header string
+	for header == "" {
+		line, err := fr.readLine()
+		if err != nil {
+			return nil, err
+		}
+		header = line
+	}
+
+	if header[0] != '@' {
+		return nil, fmt.Errorf("line %d: expected header starting with @", fr.line)
+	}
+
+	bases, err := fr.readRecordLine()
+	if err != nil {
+		return nil, err
+	}
+
+	plus, err := fr.readRecordLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(plus) == 0 || plus[0] != '+' {
+		return nil, fmt.Errorf("line %d: expected '+' line", fr.line)
+	}
+
+	qualStr, err := fr.readRecordLine()
+	if err != nil {
+		return nil, err
+	}
+
+	seq, err := newReadSequence(bases, header[1:], fr.policy)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", fr.line, err)
+	}
+
+	qual, err := quality.FromPhred33(qualStr)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", fr.line, err)
+	}
+
+	return &Read{
+		Sequence: seq,
+		Quality:  qual,
+	}, nil
+}
+
+// readRecordLine reads a line inside a record, where EOF means truncation.
+func (fr *FASTQReader) readRecordLine() (string, error) {
+	line, err := fr.readLine()
+	if err == io.EOF {
+		return "", fmt.Errorf("line %d: truncated record: %w", fr.line, io.ErrUnexpectedEOF)
+	}
+	return line, err
+}
+
+// FASTAReader reads FASTA records one at a time, holding only the current
+// record in memory.
+type FASTAReader struct {
+	r      *bufio.Reader
+	header string
+	line   int
+	done   bool
+	policy Policy
+}
+
+// NewFASTAReader creates a streaming FASTA reader.
+func NewFASTAReader(r io.Reader) *FASTAReader {
+	return &FASTAReader{r: bufio.NewReaderSize(r, 64*1024), policy: StrictPolicy()}
+}
+
+// SetPolicy sets the validation policy applied to the bases of each
+// record.
+func (fr *FASTAReader) SetPolicy(policy Policy) {
+	fr.policy = policy
+}
+
+// Next returns the next sequence, or io.EOF when there are no more
+// records. Records without bases are skipped, as in ParseFASTA.
+func (fr *FASTAReader) Next() (*Sequence, error) {
+	for !fr.done {
+		header := fr.header
+		var bases strings.Builder
+		for {
+			s, err := fr.r.ReadString('\n')
+			if err != nil && err != io.EOF {
+				return nil, fmt.Errorf("reading file: %w", err)
+			}
+			if len(s) == 0 && err == io.EOF {
+				fr.done = true
+				break
+			}
+			fr.line++
+			line := strings.TrimSpace(s)
+			if len(line) > 0 && line[0] == '>' {
+				fr.header = line[1:]
+				break
+			}
+			bases.WriteString(line)
+		}
+		if bases.Len() == 0 {
+			continue
+		}
+		parts := strings.SplitN(header, " ", 2)
+		desc := ""
+		if len(parts) > 1 {
+			desc = parts[1]
+		}
+		seq, err := sequence.NewWithPolicy(bases.String(), parts[0], desc, fr.policy)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", fr.line, err)
+		}
+		return seq, nil
+	}
+	return nil, io.EOF
+}
+
+// FASTQWriter writes FASTQ records one at a time, with Phred+33
+// qualities, buffering its output until Flush.
+type FASTQWriter struct {
+	w     *bufio.Writer
+	count int
+}
+
+// NewFASTQWriter creates a streaming FASTQ writer.
+func NewFASTQWriter(w io.Writer) *FASTQWriter {
+	return &FASTQWriter{w: bufio.NewWriterSize(w, 64*1024)}
+}
+
+// Write writes a read. A read without an ID is written as "read".
+func (fw *FASTQWriter) Write(read *Read) error {
+	id := read.Sequence.ID
+	if id == "" {
+		id = "read"
+	}
+	if read.Sequence.Description != "" {
+		id += " " + read.Sequence.Description
+	}
+	if _, err := fmt.Fprintf(fw.w, "@%s\n%s\n+\n%s\n", id, read.Sequence.Bases, read.Quality.ToPhred33()); err != nil {
+		return fmt.Errorf("writing read: %w", err)
+	}
+	fw.count++
+	return nil
+}
+
+// Count returns the number of reads written.
+func (fw *FASTQWriter) Count() int {
+	return fw.count
+}
+
+// Flush writes any buffered records to the underlying writer.
+func (fw *FASTQWriter) Flush() error {
+	return fw.w.Flush()
+}
+
+// DefaultCompression is the default t-digest compression.
+const DefaultCompression = stats.DefaultCompression
+
+// TDigest estimates quantiles of a stream in bounded memory.
+type TDigest = stats.TDigest
+
+// LengthDistribution counts sequence lengths exactly for streaming N50.
+type LengthDistribution = stats.LengthDistribution
+
+// StreamingStats accumulates set statistics one sequence at a time.
+type StreamingStats = stats.StreamingStats
+
+// StreamSummary is the summary of a streamed sequence or read set.
+type StreamSummary = stats.StreamSummary
+
+// NewTDigest creates an empty t-digest with the given compression.
+func NewTDigest(compression float64) (*TDigest, error) {
+	return stats.NewTDigest(compression)
+}
+
+// NewStreamingStats creates empty streaming statistics.
+func NewStreamingStats(compression float64) (*StreamingStats, error) {
+	return stats.NewStreamingStats(compression)
+}
+
+// StreamFileStats summarizes a FASTA or FASTQ file without holding its
+// records in memory.
+func StreamFileStats(filename string, fastq bool, compression float64, policy Policy) (*StreamSummary, error) {
+	s, err := stats.NewStreamingStats(compression)
+	if err != nil {
+		return nil, err
+	}
+	file, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if fastq {
+		reader := NewFASTQReader(file)
+		reader.SetPolicy(policy)
+		for {
+			read, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			s.AddRead(read.Sequence, read.Quality)
+		}
+	} else {
+		reader := NewFASTAReader(file)
+		reader.SetPolicy(policy)
+		for {
+			seq, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			s.AddSequence(seq)
+		}
+	}
+	return s.Summary()
+}
@@ -0,0 +1,58 @@
+package bioflow
+
+import (
+	"context"
+
+	"github.com/aria-lang/bioflow-go/internal/alignment"
+)
+
+// SubstitutionMatrix scores every pair of residues, as BLOSUM and PAM
+// matrices do.
+type SubstitutionMatrix = alignment.SubstitutionMatrix
+
+// SubstitutionMatrixNames returns the names of the built-in substitution
+// matrices (BLOSUM62, BLOSUM80 and PAM250) and of those in the data
+// directory.
+func SubstitutionMatrixNames() []string {
+	return alignment.SubstitutionMatrixNames()
+}
+
+// SubstitutionMatrixByName returns a built-in substitution matrix.
+func SubstitutionMatrixByName(name string) (*SubstitutionMatrix, error) {
+	return alignment.SubstitutionMatrixByName(name)
+}
+
+// LoadSubstitutionMatrix reads a substitution matrix in NCBI format.
+//
+// Aria equivalent:
+//
+//	fn load_substitution_matrix(filename: Path) -> Result<SubstitutionMatrix, AlignmentError> with FileSystem
+func LoadSubstitutionMatrix(filename string) (*SubstitutionMatrix, error) {
+	return alignment.LoadSubstitutionMatrix(filename)
+}
+
+// NewSubstitutionScoring returns a scoring matrix backed by a substitution
+// matrix, with affine gap penalties (a gap of length L scores
+// gapOpen + (L-1)*gapExtend).
+func NewSubstitutionScoring(m *SubstitutionMatrix, gapOpen, gapExtend int) (*ScoringMatrix, error) {
+	return alignment.NewSubstitutionScoring(m, gapOpen, gapExtend)
+}
+
+// DefaultProteinScoring returns BLOSUM62 with BLAST's default gap costs.
+func DefaultProteinScoring() *ScoringMatrix {
+	return alignment.DefaultProtein()
+}
+
+// AlignProteins aligns two proteins, locally or, with global, end to end.
+// A nil scoring uses DefaultProteinScoring.
+//
+// Aria equivalent:
+//
+//	fn align_proteins(p1: Protein, p2: Protein, scoring: ScoringMatrix, global: Bool) -> Result<Alignment, AlignmentError>
+func AlignProteins(p1, p2 *Protein, scoring *ScoringMatrix, global bool) (*Alignment, error) {
+	kind := alignment.Local
+	if global {
+		kind = alignment.Global
+	}
+	return alignment.AlignProteins(context.Background(), p1, p2, scoring, kind)
+}
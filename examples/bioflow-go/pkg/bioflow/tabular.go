@@ -0,0 +1,130 @@
+package bioflow
+
+import (
+	"fmt"
+
+	"github.com/aria-lang/bioflow-go/internal/quality"
+	"github.com/aria-lang/bioflow-go/internal/tabular"
+)
+
+// DelimitedColumns says which columns of a delimited file hold the ID, sequence,
+// quality, description and metadata.
+type DelimitedColumns = tabular.ColumnMap
+
+// DelimitedOptions configures reading CSV and TSV files.
+type DelimitedOptions = tabular.Options
+
+// ParseDelimitedColumns parses a column mapping such as "id=name,seq=2,meta=plate;well".
+func ParseDelimitedColumns(spec string) (DelimitedColumns, error) {
+	return tabular.ParseColumnMap(spec)
+}
+
+// ParseDelimiter parses a delimiter name such as "csv" or "tsv"; "auto"
+// chooses it from the file name.
+func ParseDelimiter(name string) (rune, error) {
+	return tabular.ParseComma(name)
+}
+
+// ReadDelimitedSequences reads sequences from a CSV or TSV file under a
+// policy. Rows without an ID are named "row<line>"; metadata columns are
+// appended to the description as key=value pairs.
+func ReadDelimitedSequences(filename string, opts DelimitedOptions, policy Policy) ([]*Sequence, error) {
+	records, err := readDelimited(filename, opts)
+	if err != nil {
+		return nil, err
+	}
+	sequences := make([]*Sequence, 0, len(records))
+	for _, rec := range records {
+		seq, err := NewSequenceWithPolicy(rec.Sequence, recordID(rec), rec.FullDescription(), policy)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", rec.Line, err)
+		}
+		sequences = append(sequences, seq)
+	}
+	return sequences, nil
+}
+
+// ReadDelimitedReads reads reads from a CSV or TSV file with a Phred+33
+// quality column under a policy.
+func ReadDelimitedReads(filename string, opts DelimitedOptions, policy Policy) ([]*Read, error) {
+	records, err := readDelimited(filename, opts)
+	if err != nil {
+		return nil, err
+	}
+	reads := make([]*Read, 0, len(records))
+	for _, rec := range records {
+		if rec.Quality == "" {
+			return nil, fmt.Errorf("line %d: no quality (map a quality column with qual=<column>)", rec.Line)
+		}
+		seq, err := newReadSequence(rec.Sequence, recordID(rec), policy)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", rec.Line, err)
+		}
+		seq.Description = rec.FullDescription()
+		qual, err := quality.FromPhred33(rec.Quality)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", rec.Line, err)
+		}
+		if seq.Len() != qual.Len() {
+			return nil, fmt.Errorf("line %d: sequence and quality must have same length", rec.Line)
+		}
+		reads = append(reads, &Read{Sequence: seq, Quality: qual})
+	}
+	return reads, nil
+}
+
+// readDelimited reads a possibly compressed delimited file, choosing the
+// delimiter from the file name unless opts.Comma is set.
+func readDelimited(filename string, opts DelimitedOptions) ([]tabular.Record, error) {
+	file, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if opts.Comma == 0 {
+		opts.Comma = tabular.CommaFor(TrimCompressionExt(filename))
+	}
+	return tabular.Parse(file, opts)
+}
+
+// recordID returns the ID of a record, or one made from its line number.
+func recordID(rec tabular.Record) string {
+	if rec.ID != "" {
+		return rec.ID
+	}
+	return fmt.Sprintf("row%d", rec.Line)
+}
+
+// ReadDelimitedRecords reads a CSV or TSV file as JSON Lines records under
+// a policy, keeping metadata columns as record metadata rather than
+// folding them into the description.
+func ReadDelimitedRecords(filename string, opts DelimitedOptions, policy Policy) ([]*JSONRecord, error) {
+	rows, err := readDelimited(filename, opts)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*JSONRecord, 0, len(rows))
+	for _, row := range rows {
+		seq, err := NewSequenceWithPolicy(row.Sequence, recordID(row), row.Description, policy)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", row.Line, err)
+		}
+		rec := SequenceRecord(seq)
+		rec.Qual = row.Quality
+		for _, m := range row.Metadata {
+			if m.Value == "" {
+				continue
+			}
+			if rec.Metadata == nil {
+				rec.Metadata = make(map[string]string)
+			}
+			rec.Metadata[m.Key] = m.Value
+		}
+		if err := rec.Validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", row.Line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
@@ -0,0 +1,46 @@
+package bioflow
+
+import (
+	"flag"
+	"io"
+
+	"github.com/aria-lang/bioflow-go/internal/tooldef"
+)
+
+// ToolDescriptor describes a command for workflow engines.
+type ToolDescriptor = tooldef.Tool
+
+// ToolInput is a flag of a described command.
+type ToolInput = tooldef.Input
+
+// ToolArgument is a positional argument of a described command.
+type ToolArgument = tooldef.Argument
+
+// ToolType is the type of an input of a described command.
+type ToolType = tooldef.Type
+
+// Types of the inputs of described commands.
+const (
+	ToolBoolean   = tooldef.Boolean
+	ToolInt       = tooldef.Int
+	ToolLong      = tooldef.Long
+	ToolFloat     = tooldef.Float
+	ToolString    = tooldef.String
+	ToolFile      = tooldef.File
+	ToolDirectory = tooldef.Directory
+)
+
+// ToolInputs returns the inputs of the flags of a command.
+func ToolInputs(fs *flag.FlagSet) []ToolInput {
+	return tooldef.Inputs(fs)
+}
+
+// WriteCWL writes commands as a CWL v1.2 document.
+func WriteCWL(w io.Writer, tools ...*ToolDescriptor) error {
+	return tooldef.WriteCWL(w, tools...)
+}
+
+// WriteWDL writes commands as tasks of a WDL 1.0 document.
+func WriteWDL(w io.Writer, tools ...*ToolDescriptor) error {
+	return tooldef.WriteWDL(w, tools...)
+}
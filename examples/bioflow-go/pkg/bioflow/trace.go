@@ -0,0 +1,141 @@
+package bioflow
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aria-lang/bioflow-go/internal/kmer"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of library operations. It follows the global
+// tracer provider, so spans are only recorded once SetupTracing (or the
+// application) has installed one.
+var tracer = otel.Tracer("github.com/aria-lang/bioflow-go")
+
+// SetupTracing installs a global tracer provider exporting spans, and the
+// W3C trace context propagator. exporter is "otlp", to send spans over
+// OTLP/HTTP to the endpoint set by the standard OTEL_EXPORTER_OTLP_*
+// variables, "stdout" to print them as JSON, or "" to record nothing.
+// The returned function flushes pending spans and stops exporting.
+//
+// Aria equivalent:
+//
+//	fn setup_tracing(exporter: String, service: String)
+//	  -> Result<fn() -> Result<(), TraceError>, TraceError> with Network
+func SetupTracing(ctx context.Context, exporter, service string) (func(context.Context) error, error) {
+	var exp sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case "":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		exp, err = otlptracehttp.New(ctx)
+	case "stdout":
+		exp, err = stdouttrace.New()
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q (use otlp or stdout)", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating trace exporter: %w", err)
+	}
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(service), semconv.ServiceVersion(Version())),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("describing trace resource: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return tp.Shutdown, nil
+}
+
+// StartSpan starts a span as a child of the one in ctx, for applications
+// tracing their own phases alongside those of the library. End it with
+// EndSpan.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan ends a span, marking it failed if err is not nil.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceCarrier returns the trace context of ctx as text fields, to carry
+// with a job to wherever it runs; it is nil when there is nothing to
+// carry.
+func TraceCarrier(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// ContextWithTrace returns ctx with the trace context of a carrier from
+// TraceCarrier, so that spans started from it continue the trace.
+func ContextWithTrace(ctx context.Context, carrier map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// ParseFASTQContext parses FASTQ as ParseFASTQ does, in a "parse" span.
+//
+// Aria equivalent:
+//
+//	fn parse_fastq_context(ctx: Context, r: Reader) -> Result<[Read], ParseError> with IO
+func ParseFASTQContext(ctx context.Context, r io.Reader) ([]*Read, error) {
+	_, span := StartSpan(ctx, "parse", attribute.String("bioflow.format", "fastq"))
+	reads, err := ParseFASTQ(r)
+	span.SetAttributes(attribute.Int("bioflow.reads", len(reads)))
+	EndSpan(span, err)
+	return reads, err
+}
+
+// CountKMersContext counts k-mers as CountKMers does, in a "count" span,
+// and stops with the context's error once ctx is done.
+func CountKMersContext(ctx context.Context, seq *Sequence, k int) (*KMerCounter, error) {
+	ctx, span := StartSpan(ctx, "count",
+		attribute.Int("bioflow.k", k), attribute.Int("bioflow.sequence.length", seq.Len()))
+	counter, err := kmer.CountKMersContext(ctx, seq, k)
+	EndSpan(span, err)
+	return counter, err
+}
+
+// CountSpacedKMersContext counts spaced k-mers as CountSpacedKMers does,
+// in a "count" span, and stops with the context's error once ctx is done.
+func CountSpacedKMersContext(ctx context.Context, seq *Sequence, seed *SpacedSeed) (*KMerCounter, error) {
+	ctx, span := StartSpan(ctx, "count",
+		attribute.String("bioflow.seed", seed.String()), attribute.Int("bioflow.sequence.length", seq.Len()))
+	counter, err := kmer.CountSpacedKMersContext(ctx, seq, seed)
+	EndSpan(span, err)
+	return counter, err
+}
+
+// alignSpan starts the span of an alignment.
+func alignSpan(ctx context.Context, mode string, seq1, seq2 *Sequence) (context.Context, trace.Span) {
+	return StartSpan(ctx, "align",
+		attribute.String("bioflow.align.mode", mode),
+		attribute.Int("bioflow.sequence1.length", seq1.Len()),
+		attribute.Int("bioflow.sequence2.length", seq2.Len()),
+		attribute.Int64("bioflow.align.cells", int64(seq1.Len()+1)*int64(seq2.Len()+1)))
+}
@@ -0,0 +1,128 @@
+package bioflow
+
+import (
+	"io"
+
+	"github.com/aria-lang/bioflow-go/internal/stats"
+	"github.com/aria-lang/bioflow-go/internal/track"
+)
+
+// Track is a named series of values over windows of a sequence.
+type Track = track.Track
+
+// ComplexityOptions configures an entropy/linguistic complexity profile.
+type ComplexityOptions = stats.ComplexityOptions
+
+// ComplexityProfile computes per-window Shannon entropy and linguistic
+// complexity tracks for a sequence.
+func ComplexityProfile(seq *Sequence, opts ComplexityOptions) (entropy, complexity *Track, err error) {
+	return stats.ComplexityProfile(seq, opts)
+}
+
+// WriteTracksTSV writes tracks sharing the same windows as TSV columns.
+func WriteTracksTSV(w io.Writer, header bool, tracks ...*Track) error {
+	return track.WriteTSV(w, header, tracks...)
+}
+
+// WriteTracksJSON writes tracks as a JSON array.
+func WriteTracksJSON(w io.Writer, tracks ...*Track) error {
+	return track.WriteJSON(w, tracks...)
+}
+
+// WriteBedGraph writes tracks as bedGraph for genome browsers.
+func WriteBedGraph(w io.Writer, tracks ...*Track) error {
+	return track.WriteBedGraph(w, tracks...)
+}
+
+// WriteWIG writes tracks as fixedStep WIG for genome browsers.
+func WriteWIG(w io.Writer, tracks ...*Track) error {
+	return track.WriteWIG(w, tracks...)
+}
+
+// WriteVariableStepWIG writes tracks as variableStep WIG for genome
+// browsers.
+func WriteVariableStepWIG(w io.Writer, tracks ...*Track) error {
+	return track.WriteVariableStepWIG(w, tracks...)
+}
+
+// TrackFormat is a track output format.
+type TrackFormat = track.Format
+
+// Track output formats.
+const (
+	TrackTSV             = track.FormatTSV
+	TrackJSON            = track.FormatJSON
+	TrackBedGraph        = track.FormatBedGraph
+	TrackWIG             = track.FormatWIG
+	TrackVariableStepWIG = track.FormatVariableStepWIG
+)
+
+// ParseTrackFormat parses a track format name (tsv, json, bedgraph, wig,
+// variablestep).
+func ParseTrackFormat(name string) (TrackFormat, error) {
+	return track.ParseFormat(name)
+}
+
+// WriteTracks writes tracks in any track format.
+func WriteTracks(w io.Writer, format TrackFormat, tracks ...*Track) error {
+	return track.Write(w, format, tracks...)
+}
+
+// GCProfile computes a per-window GC content track for a sequence.
+func GCProfile(seq *Sequence, window, step int) (*Track, error) {
+	return stats.GCProfile(seq, window, step)
+}
+
+// PileupCoverageTracks returns one per-base depth track per covered
+// chromosome of a pileup.
+func PileupCoverageTracks(p *Pileup) []*Track {
+	chroms := p.Chromosomes()
+	tracks := make([]*Track, len(chroms))
+	for i, chrom := range chroms {
+		tracks[i] = p.CoverageTrack(chrom)
+	}
+	return tracks
+}
+
+// WindowOptions configures sliding windows.
+type WindowOptions = stats.WindowOptions
+
+// WindowStatistic is a named function of the bases in a window.
+type WindowStatistic = stats.WindowStatistic
+
+// ParseWindowStatistics looks up comma-separated window statistic names
+// (gc, gc_skew, at_skew, entropy, complexity, ambiguous).
+func ParseWindowStatistics(list string, maxK int) ([]WindowStatistic, error) {
+	return stats.ParseWindowStatistics(list, maxK)
+}
+
+// WindowStatisticNames lists the built-in window statistics.
+func WindowStatisticNames() []string {
+	return stats.WindowStatisticNames()
+}
+
+// WindowTracks computes one track per statistic over the windows of a
+// sequence.
+func WindowTracks(seq *Sequence, opts WindowOptions, statistics ...WindowStatistic) ([]*Track, error) {
+	return stats.WindowTracks(seq, opts, statistics...)
+}
+
+// PileupWindowCoverage returns one mean-depth track per covered
+// chromosome of a pileup, over the same windows as WindowTracks.
+func PileupWindowCoverage(p *Pileup, opts WindowOptions) ([]*Track, error) {
+	chroms := p.Chromosomes()
+	tracks := make([]*Track, len(chroms))
+	for i, chrom := range chroms {
+		depths := p.Depths(chrom, 0)
+		values := make([]float64, len(depths))
+		for j, d := range depths {
+			values[j] = float64(d)
+		}
+		t, err := stats.WindowMeans(p.CoverageName(), chrom, values, opts)
+		if err != nil {
+			return nil, err
+		}
+		tracks[i] = t
+	}
+	return tracks, nil
+}
@@ -0,0 +1,53 @@
+package bioflow
+
+import (
+	"fmt"
+
+	"github.com/aria-lang/bioflow-go/internal/kmer"
+	"github.com/aria-lang/bioflow-go/internal/tree"
+)
+
+// Tree is a rooted phylogenetic tree.
+type Tree = tree.Tree
+
+// ParseNewick parses a Newick tree string.
+func ParseNewick(s string) (*Tree, error) {
+	return tree.ParseNewick(s)
+}
+
+// ReadNewick reads a Newick tree from a file.
+func ReadNewick(filename string) (*Tree, error) {
+	file, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return tree.ReadNewick(file)
+}
+
+// BuildTree builds a tree from sequences using k-mer Jaccard distances.
+// Method is "nj" (neighbor joining) or "upgma".
+func BuildTree(sequences []*Sequence, k int, method string) (*Tree, error) {
+	dist, err := kmer.SimilarityMatrix(sequences, k)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(sequences))
+	for i, s := range sequences {
+		names[i] = s.ID
+		if names[i] == "" {
+			names[i] = fmt.Sprintf("seq%d", i+1)
+		}
+	}
+
+	switch method {
+	case "nj", "":
+		return tree.NeighborJoining(names, dist)
+	case "upgma":
+		return tree.UPGMA(names, dist)
+	default:
+		return nil, fmt.Errorf("unknown tree method %q (use nj or upgma)", method)
+	}
+}
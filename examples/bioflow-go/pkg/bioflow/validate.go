@@ -0,0 +1,39 @@
+package bioflow
+
+import (
+	"github.com/aria-lang/bioflow-go/internal/validate"
+)
+
+// ValidationOptions configures FASTA/FASTQ validation.
+type ValidationOptions = validate.Options
+
+// ValidationReport lists the problems found in a FASTA or FASTQ file.
+type ValidationReport = validate.Report
+
+// ValidationIssue is one problem found in a file, with its line number.
+type ValidationIssue = validate.Issue
+
+// DefaultValidationOptions detects the format and accepts DNA bases.
+func DefaultValidationOptions() ValidationOptions {
+	return validate.DefaultOptions()
+}
+
+// ValidateFile checks a FASTA or FASTQ file for duplicate IDs, invalid
+// characters, empty records, inconsistent wrapping, sequence/quality
+// length mismatches and truncated records.
+func ValidateFile(filename string, opts ValidationOptions) (*ValidationReport, error) {
+	file, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return validate.Validate(file, opts)
+}
+
+// ValidationFormat is the format of a file to validate: auto, fasta or
+// fastq.
+type ValidationFormat = validate.Format
+
+// ValidationAlphabet is the set of accepted bases: dna, rna or iupac.
+type ValidationAlphabet = validate.Alphabet
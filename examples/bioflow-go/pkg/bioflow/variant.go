@@ -0,0 +1,135 @@
+package bioflow
+
+import (
+	"io"
+	"os"
+
+	"github.com/aria-lang/bioflow-go/internal/variant"
+)
+
+// Variant is a single-ALT variant in VCF coordinates.
+type Variant = variant.Variant
+
+// VCF is a parsed VCF file.
+type VCF = variant.VCF
+
+// VCFRecord is one VCF data line.
+type VCFRecord = variant.Record
+
+// VariantKind classifies a variant by the lengths of its alleles.
+type VariantKind = variant.Kind
+
+// DensityOptions configures variant density tracks.
+type DensityOptions = variant.DensityOptions
+
+// DefaultDensityWindow is the window of variant density tracks, in bases.
+const DefaultDensityWindow = variant.DefaultDensityWindow
+
+// ReadVCF reads a VCF file; "-" reads standard input.
+func ReadVCF(filename string) (*VCF, error) {
+	if filename == "-" {
+		return variant.Read(os.Stdin)
+	}
+	file, err := OpenInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return variant.Read(file)
+}
+
+// WriteVCF writes a VCF file.
+func WriteVCF(w io.Writer, vcf *VCF) error {
+	return variant.Write(w, vcf)
+}
+
+// NormalizeVariant left-aligns and trims a variant against the sequence
+// of its chromosome.
+func NormalizeVariant(v Variant, reference *Sequence) (Variant, error) {
+	return variant.Normalize(v, reference.Bases)
+}
+
+// NormalizeVCF normalizes the records of a VCF against reference
+// sequences matched by ID, optionally splitting multi-allelic records.
+func NormalizeVCF(vcf *VCF, references []*Sequence, split bool) (*VCF, error) {
+	refs := make(map[string]string, len(references))
+	for _, s := range references {
+		refs[s.ID] = s.Bases
+	}
+	records, err := variant.NormalizeRecords(vcf.Records, refs, split)
+	if err != nil {
+		return nil, err
+	}
+	return &VCF{Meta: vcf.Meta, Header: vcf.Header, Records: records}, nil
+}
+
+// VariantsFromAlignment extracts normalized variants from an alignment
+// whose first sequence is (a region of) the reference.
+func VariantsFromAlignment(reference *Sequence, a *Alignment) ([]Variant, error) {
+	return variantsFromRows(reference, a.Start1, a.AlignedSeq1, a.AlignedSeq2)
+}
+
+// VariantsFromRealignment extracts normalized variants from a realigned
+// read.
+func VariantsFromRealignment(reference *Sequence, r *Realignment) ([]Variant, error) {
+	return variantsFromRows(reference, r.RefStart, r.AlignedRef, r.AlignedRead)
+}
+
+// variantsFromRows extracts and normalizes the variants of gapped rows.
+func variantsFromRows(reference *Sequence, refStart int, refRow, queryRow string) ([]Variant, error) {
+	chrom := nameOr(reference.ID, "ref")
+	variants, err := variant.FromAlignedRows(chrom, reference.Bases, refStart, refRow, queryRow)
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range variants {
+		if variants[i], err = variant.Normalize(v, reference.Bases); err != nil {
+			return nil, err
+		}
+	}
+	return variants, nil
+}
+
+// VariantsToVCF wraps variants in a minimal VCF.
+func VariantsToVCF(variants []Variant) *VCF {
+	records := make([]*VCFRecord, len(variants))
+	for i, v := range variants {
+		records[i] = variant.FromVariant(v)
+	}
+	return &VCF{Records: records}
+}
+
+// VCFVariants returns the variants of the sequence alleles of a VCF. With
+// passOnly, records that failed a filter are left out; with heterozygous,
+// so are records whose genotype in sample (by name, "" for the first) is
+// not heterozygous.
+func VCFVariants(vcf *VCF, passOnly, heterozygous bool, sample string) ([]Variant, error) {
+	index := 0
+	if heterozygous {
+		var err error
+		if index, err = vcf.SampleIndex(sample); err != nil {
+			return nil, err
+		}
+	}
+	variants := make([]Variant, 0, len(vcf.Records))
+	for _, rec := range vcf.Records {
+		if (passOnly && !rec.Passed()) || (heterozygous && !rec.Heterozygous(index)) {
+			continue
+		}
+		variants = append(variants, rec.Variants()...)
+	}
+	return variants, nil
+}
+
+// ParseVariantKinds parses comma-separated variant kinds (snv, mnv,
+// insertion, deletion, complex), or "all".
+func ParseVariantKinds(list string) ([]VariantKind, error) {
+	return variant.ParseKinds(list)
+}
+
+// VariantDensity returns one track per reference sequence of the variant
+// sites per kilobase of unambiguous bases in each window.
+func VariantDensity(variants []Variant, references []*Sequence, opts DensityOptions) ([]*Track, error) {
+	return variant.Density(variants, references, opts)
+}
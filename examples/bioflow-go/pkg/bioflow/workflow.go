@@ -0,0 +1,516 @@
+package bioflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/aria-lang/bioflow-go/internal/compress"
+	"github.com/aria-lang/bioflow-go/internal/quality"
+	"github.com/aria-lang/bioflow-go/internal/random"
+	"github.com/aria-lang/bioflow-go/internal/report"
+	"github.com/aria-lang/bioflow-go/internal/workflow"
+)
+
+// WorkflowSpec is a declarative pipeline definition.
+type WorkflowSpec = workflow.Spec
+
+// WorkflowStage is one step of a pipeline definition.
+type WorkflowStage = workflow.Stage
+
+// WorkflowPlan says which stages a run skips and where it resumes from.
+type WorkflowPlan = workflow.Plan
+
+// LoadWorkflow reads and validates a YAML pipeline definition.
+func LoadWorkflow(path string) (*WorkflowSpec, error) {
+	return workflow.Load(path)
+}
+
+// WorkflowStageTypes lists the stage types a pipeline may use.
+func WorkflowStageTypes() []string {
+	return workflow.StageTypes()
+}
+
+// WorkflowOptions controls a pipeline run.
+type WorkflowOptions struct {
+	// DryRun validates the spec and input and plans the run without
+	// running any stage.
+	DryRun bool
+	// Fresh ignores the state of earlier runs and runs every stage.
+	Fresh bool
+	// Log receives the plan: which stages are skipped or would run. Nil
+	// discards it.
+	Log io.Writer
+	// Monitor receives the events of the stages that run.
+	Monitor Monitor
+}
+
+// StageReport is the outcome of one pipeline stage, also saved as JSON in
+// the work directory. Reasons counts removed reads by reason; Stats is set
+// by stats stages.
+type StageReport struct {
+	Stage       string         `json:"stage"`
+	Type        string         `json:"type"`
+	Skipped     bool           `json:"skipped,omitempty"`
+	InputReads  int            `json:"input_reads"`
+	OutputReads int            `json:"output_reads"`
+	Removed     int            `json:"removed"`
+	Reasons     map[string]int `json:"reasons,omitempty"`
+	Stats       *ReadSetStats  `json:"stats,omitempty"`
+	Output      string         `json:"output,omitempty"`
+	Seconds     float64        `json:"seconds"`
+}
+
+// WorkflowResult is the outcome of a pipeline run. Stages has one report
+// per stage; skipped stages carry the report saved by the run that
+// completed them, if it can still be read.
+type WorkflowResult struct {
+	Name   string        `json:"name,omitempty"`
+	DryRun bool          `json:"dry_run,omitempty"`
+	Resume int           `json:"resumed_after"`
+	Stages []StageReport `json:"stages"`
+}
+
+// RunWorkflow runs a pipeline. Each stage's report is written to the work
+// directory, along with the reads left after every stage that changes
+// them (as JSON Lines, which keeps read groups), so that a rerun of an unchanged pipeline on an unchanged input
+// resumes after the last completed stage.
+//
+// Aria equivalent:
+//
+//	fn run_workflow(spec: Spec, opts: WorkflowOptions) -> Result<WorkflowResult, WorkflowError>
+//	  ensures result.is_ok() implies result.unwrap().stages.len() == spec.stages.len()
+func RunWorkflow(spec *WorkflowSpec, opts WorkflowOptions) (*WorkflowResult, error) {
+	logw := opts.Log
+	if logw == nil {
+		logw = io.Discard
+	}
+	info, err := os.Stat(spec.Path(spec.Input))
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	state, err := spec.LoadState()
+	if err != nil {
+		return nil, err
+	}
+	plan := spec.Plan(state, info, opts.Fresh)
+	plugins, err := buildPluginStages(spec, plan.Resume)
+	if err != nil {
+		return nil, err
+	}
+	result := &WorkflowResult{Name: spec.Name, DryRun: opts.DryRun, Resume: plan.Resume}
+
+	for i, stage := range spec.Stages[:plan.Resume] {
+		rep := StageReport{Stage: stage.Name, Type: stage.Type}
+		if data, err := os.ReadFile(spec.StageFile(i, ".json")); err == nil {
+			_ = json.Unmarshal(data, &rep)
+		}
+		rep.Skipped = true
+		result.Stages = append(result.Stages, rep)
+		fmt.Fprintf(logw, "[%d/%d] %s (%s): completed earlier, skipping\n", i+1, len(spec.Stages), stage.Name, stage.Type)
+	}
+	if opts.DryRun {
+		for i, stage := range spec.Stages[plan.Resume:] {
+			i += plan.Resume
+			result.Stages = append(result.Stages, StageReport{Stage: stage.Name, Type: stage.Type})
+			fmt.Fprintf(logw, "[%d/%d] %s (%s): would run\n", i+1, len(spec.Stages), stage.Name, stage.Type)
+		}
+		return result, nil
+	}
+	if plan.Resume == len(spec.Stages) {
+		return result, nil
+	}
+
+	if err := os.MkdirAll(spec.Path(spec.WorkDir), 0o755); err != nil {
+		return nil, fmt.Errorf("creating work directory: %w", err)
+	}
+	reads, err := ReadReadsFile(plan.ReadsFrom)
+	if err != nil {
+		return nil, err
+	}
+	readsFile := plan.ReadsFrom
+	state.Stages = state.Stages[:plan.Resume]
+
+	for i := plan.Resume; i < len(spec.Stages); i++ {
+		stage := spec.Stages[i]
+		rep, out, err := runMonitoredStage(spec, i, plugins[i], reads, opts.Monitor)
+		if err != nil {
+			return nil, err
+		}
+		reads = out
+
+		// Stages that may change the reads save them to resume from.
+		if stage.Type != workflow.Stats && stage.Type != workflow.Write {
+			readsFile = spec.StageFile(i, ".jsonl")
+			if err := WriteJSONLReads(readsFile, reads); err != nil {
+				return nil, fmt.Errorf("stage %d (%s): %w", i+1, stage.Name, err)
+			}
+		}
+		if err := writeStageReport(spec.StageFile(i, ".json"), &rep); err != nil {
+			return nil, err
+		}
+		state.Stages = append(state.Stages, workflow.StageState{
+			Name: stage.Name, Fingerprint: plan.Fingerprints[i], Reads: readsFile, CompletedAt: time.Now().UTC(),
+		})
+		if err := spec.SaveState(state); err != nil {
+			return nil, err
+		}
+		result.Stages = append(result.Stages, rep)
+	}
+	return result, nil
+}
+
+// CheckWorkflowStages validates stages for ProcessWorkflowReads, filling
+// in default stage names.
+func CheckWorkflowStages(stages []WorkflowStage) error {
+	spec := &WorkflowSpec{Input: "-", Stages: stages}
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	for i, stage := range spec.Stages {
+		_, html := stage.String("html")
+		if stage.Type == workflow.Write || (stage.Type == workflow.Stats && html) {
+			return fmt.Errorf("stage %d (%s): writing files is not supported here", i+1, stage.Name)
+		}
+	}
+	return nil
+}
+
+// runMonitoredStage runs stage i of spec, emitting its events, and
+// reports on it.
+func runMonitoredStage(spec *WorkflowSpec, i int, proc ReadProcessor, reads []*Read, monitor Monitor) (StageReport, []*Read, error) {
+	stage := spec.Stages[i]
+	start := time.Now()
+	mon := monitor.stage(stage.Name, i, len(spec.Stages))
+	rep := StageReport{Stage: stage.Name, Type: stage.Type, InputReads: len(reads)}
+	out, err := runStage(spec, stage, proc, reads, &rep, mon)
+	if err != nil {
+		return rep, nil, fmt.Errorf("stage %d (%s): %w", i+1, stage.Name, err)
+	}
+	mon.finish(len(reads), len(out))
+	rep.OutputReads = len(out)
+	rep.Removed = len(reads) - len(out)
+	rep.Seconds = time.Since(start).Seconds()
+	return rep, out, nil
+}
+
+// ProcessWorkflowReads runs pipeline stages over reads in memory, without
+// a spec file, work directory or resumption. Stages that write files
+// (write, and stats with html) are rejected.
+//
+// Aria equivalent:
+//
+//	fn process_workflow_reads(stages: [Stage], reads: [Read], monitor: Monitor) -> Result<([Read], [StageReport]), WorkflowError>
+//	  ensures result.is_ok() implies result.unwrap().1.len() == stages.len()
+func ProcessWorkflowReads(stages []WorkflowStage, reads []*Read, monitor Monitor) ([]*Read, []StageReport, error) {
+	return ProcessWorkflowReadsContext(context.Background(), stages, reads, monitor)
+}
+
+// ProcessWorkflowReadsContext runs stages as ProcessWorkflowReads does,
+// in a "pipeline" span with a child span per stage, so that the time of
+// a job can be attributed to its stages.
+func ProcessWorkflowReadsContext(ctx context.Context, stages []WorkflowStage, reads []*Read, monitor Monitor) (out []*Read, reports []StageReport, err error) {
+	ctx, span := StartSpan(ctx, "pipeline",
+		attribute.Int("bioflow.stages", len(stages)), attribute.Int("bioflow.reads", len(reads)))
+	defer func() {
+		span.SetAttributes(attribute.Int("bioflow.output_reads", len(out)))
+		EndSpan(span, err)
+	}()
+
+	if err := CheckWorkflowStages(stages); err != nil {
+		return nil, nil, err
+	}
+	spec := &WorkflowSpec{Input: "-", Stages: stages}
+	plugins, err := buildPluginStages(spec, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	reports = make([]StageReport, 0, len(spec.Stages))
+	for i, stage := range spec.Stages {
+		_, stageSpan := StartSpan(ctx, "stage "+stage.Type,
+			attribute.String("bioflow.stage", stage.Name), attribute.Int("bioflow.stage.index", i),
+			attribute.Int("bioflow.reads", len(reads)))
+		rep, out, err := runMonitoredStage(spec, i, plugins[i], reads, monitor)
+		stageSpan.SetAttributes(attribute.Int("bioflow.output_reads", len(out)))
+		EndSpan(stageSpan, err)
+		if err != nil {
+			return nil, nil, err
+		}
+		reports = append(reports, rep)
+		reads = out
+	}
+	return reads, reports, nil
+}
+
+// writeStageReport saves a stage report as JSON.
+func writeStageReport(path string, rep *StageReport) error {
+	data, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding stage report: %w", err)
+	}
+	file, err := createFile(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("writing stage report: %w", err)
+	}
+	return file.Close()
+}
+
+// buildPluginStages builds the processors of the registered stages from
+// index start on, so that their configuration is checked before any stage
+// runs.
+func buildPluginStages(spec *WorkflowSpec, start int) (map[int]ReadProcessor, error) {
+	procs := make(map[int]ReadProcessor)
+	for i := start; i < len(spec.Stages); i++ {
+		stage := spec.Stages[i]
+		factory, ok := stageFactory(stage.Type)
+		if !ok {
+			continue
+		}
+		proc, err := factory(stage)
+		if err != nil {
+			return nil, fmt.Errorf("stage %d (%s): %w", i+1, stage.Name, err)
+		}
+		procs[i] = proc
+	}
+	return procs, nil
+}
+
+// runStage runs one stage, returning the reads it keeps and filling in
+// stage-specific parts of rep. proc is the processor of a registered
+// stage type.
+func runStage(spec *WorkflowSpec, stage WorkflowStage, proc ReadProcessor, reads []*Read, rep *StageReport, mon *stageMonitor) ([]*Read, error) {
+	if proc != nil {
+		return proc.ProcessReads(reads)
+	}
+	switch stage.Type {
+	case workflow.Trim:
+		return trimStage(stage, reads, rep, mon)
+	case workflow.Primers:
+		return primerStage(spec, stage, reads, rep, mon)
+	case workflow.Filter:
+		return filterStage(stage, reads, rep, mon)
+	case workflow.Dedupe:
+		return dedupeStage(stage, reads, mon), nil
+	case workflow.Subsample:
+		return subsampleStage(stage, reads, mon), nil
+	case workflow.Stats:
+		return reads, statsStage(spec, stage, reads, rep)
+	case workflow.Write:
+		return reads, writeStage(spec, stage, reads, rep)
+	default:
+		return nil, fmt.Errorf("unknown stage type %q", stage.Type)
+	}
+}
+
+// trimStage trims low-quality ends, dropping reads with no high-quality
+// bases or shorter than min_length afterwards.
+func trimStage(stage WorkflowStage, reads []*Read, rep *StageReport, mon *stageMonitor) ([]*Read, error) {
+	threshold, _ := stage.Int("threshold")
+	minLength, _ := stage.Int("min_length")
+	trimmer := quality.NewQualityTrimmer(threshold)
+	out := make([]*Read, 0, len(reads))
+	var reasons []string
+	for _, read := range reads {
+		start, end := trimmer.Trim(read.Quality)
+		reason := ""
+		switch {
+		case end <= start:
+			reason = "no high-quality bases"
+		case end-start < minLength:
+			reason = "too short after trimming"
+		}
+		if reason != "" {
+			reasons = append(reasons, reason)
+			mon.record(read, false, reason)
+			continue
+		}
+		seq, qual, err := trimmer.TrimSequence(read.Sequence, read.Quality)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", read.Sequence.ID, err)
+		}
+		out = append(out, &Read{Sequence: seq, Quality: qual, Group: read.Group})
+		mon.record(read, true, "")
+	}
+	rep.Reasons = report.NewFilterSummary(len(reads), len(out), reasons).Reasons
+	return out, nil
+}
+
+// primerStage trims amplicon primers, dropping the reads the require
+// parameter discards.
+func primerStage(spec *WorkflowSpec, stage WorkflowStage, reads []*Read, rep *StageReport, mon *stageMonitor) ([]*Read, error) {
+	name, _ := stage.String("primers")
+	primers, err := ReadPrimers(spec.Path(name))
+	if err != nil {
+		return nil, err
+	}
+	window, _ := stage.Int("window")
+	require, _ := stage.String("require")
+	requirement, err := ParsePrimerRequirement(require)
+	if err != nil {
+		return nil, err
+	}
+	trimmer, err := NewPrimerTrimmer(primers, PrimerOptions{Window: window, Require: requirement})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Read, 0, len(reads))
+	var reasons []string
+	for _, read := range reads {
+		trimmed, reason, err := trimmer.TrimRead(read)
+		if err != nil {
+			return nil, err
+		}
+		mon.record(read, trimmed != nil, reason)
+		if trimmed == nil {
+			reasons = append(reasons, reason)
+			continue
+		}
+		out = append(out, trimmed)
+	}
+	rep.Reasons = report.NewFilterSummary(len(reads), len(out), reasons).Reasons
+	return out, nil
+}
+
+// filterStage applies a quality filter preset with optional overrides.
+func filterStage(stage WorkflowStage, reads []*Read, rep *StageReport, mon *stageMonitor) ([]*Read, error) {
+	filter := DefaultFilter()
+	if preset, _ := stage.String("preset"); preset == "strict" {
+		filter = StrictFilter()
+	}
+	if v, ok := stage.Int("min_quality"); ok {
+		filter.MinQuality = v
+	}
+	if v, ok := stage.Int("min_length"); ok {
+		filter.MinLength = v
+	}
+	if v, ok := stage.Int("max_ambiguous"); ok {
+		filter.MaxAmbiguous = v
+	}
+	out := make([]*Read, 0, len(reads))
+	var reasons []string
+	for _, read := range reads {
+		res, err := filter.Check(read.Sequence, read.Quality)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", read.Sequence.ID, err)
+		}
+		mon.record(read, res.Passed, res.Reason)
+		if !res.Passed {
+			reasons = append(reasons, res.Reason)
+			continue
+		}
+		out = append(out, read)
+	}
+	rep.Reasons = report.NewFilterSummary(len(reads), len(out), reasons).Reasons
+	return out, nil
+}
+
+// dedupeStage keeps the first read of each sequence (or ID).
+func dedupeStage(stage WorkflowStage, reads []*Read, mon *stageMonitor) []*Read {
+	by, _ := stage.String("by")
+	seen := make(map[string]bool, len(reads))
+	out := make([]*Read, 0, len(reads))
+	for _, read := range reads {
+		key := read.Sequence.Bases
+		if by == "id" {
+			key = read.Sequence.ID
+		}
+		if seen[key] {
+			mon.record(read, false, "duplicate "+by)
+			continue
+		}
+		mon.record(read, true, "")
+		seen[key] = true
+		out = append(out, read)
+	}
+	return out
+}
+
+// subsampleStage keeps n reads, or a fraction of them, chosen at random
+// from the stage's seed, in their original order.
+func subsampleStage(stage WorkflowStage, reads []*Read, mon *stageMonitor) []*Read {
+	n, ok := stage.Int("n")
+	if !ok {
+		fraction, _ := stage.Float("fraction")
+		n = int(math.Round(fraction * float64(len(reads))))
+	}
+	seed, _ := stage.Int("seed")
+	keep := random.Sample(random.New(int64(seed)), len(reads), n)
+	out := make([]*Read, 0, len(keep))
+	for i, read := range reads {
+		if len(out) < len(keep) && keep[len(out)] == i {
+			out = append(out, read)
+			mon.record(read, true, "")
+		} else {
+			mon.record(read, false, "not sampled")
+		}
+	}
+	return out
+}
+
+// statsStage records read statistics, optionally as an HTML report too.
+func statsStage(spec *WorkflowSpec, stage WorkflowStage, reads []*Read, rep *StageReport) error {
+	if len(reads) == 0 {
+		return nil
+	}
+	s, err := ReadStats(reads)
+	if err != nil {
+		return err
+	}
+	rep.Stats = s
+	html, ok := stage.String("html")
+	if !ok {
+		return nil
+	}
+	rep.Output = spec.Path(html)
+	page := NewHTMLReport(stage.Name)
+	page.Add(ReadSetSections(s)...)
+	file, err := createFile(rep.Output)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+	if err := page.WriteHTML(file); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	return file.Close()
+}
+
+// writeStage writes the reads as FASTQ, FASTA or JSON Lines. Without a
+// format, names ending in .fa, .fasta or .fna are written as FASTA and
+// names ending in .jsonl or .ndjson as JSON Lines, before any compression
+// extension such as .gz.
+func writeStage(spec *WorkflowSpec, stage WorkflowStage, reads []*Read, rep *StageReport) error {
+	name, _ := stage.String("output")
+	rep.Output = spec.Path(name)
+	format, ok := stage.String("format")
+	if !ok {
+		switch strings.ToLower(filepath.Ext(compress.TrimExt(name))) {
+		case ".fa", ".fasta", ".fna":
+			format = "fasta"
+		case ".jsonl", ".ndjson":
+			format = "jsonl"
+		default:
+			format = "fastq"
+		}
+	}
+	switch format {
+	case "fasta":
+		sequences, _ := splitReads(reads)
+		return WriteFASTA(rep.Output, sequences)
+	case "jsonl":
+		return WriteJSONLReads(rep.Output, reads)
+	}
+	return WriteFASTQ(rep.Output, reads)
+}
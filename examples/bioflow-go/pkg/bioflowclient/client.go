@@ -0,0 +1,134 @@
+// Package bioflowclient is a Go client of the BioFlow REST API, generated
+// from the OpenAPI specification the server serves at /api/openapi.json.
+// Each endpoint is a method of Client named after its operation ID, with
+// the request and response bodies as Go types:
+//
+//	c := bioflowclient.New("http://localhost:8080")
+//	gc, err := c.GCContent(ctx, bioflowclient.SequenceRequest{Sequence: "ATGC"})
+//
+// Errors replied by the server are *Error values. Downloads and JSON
+// Lines streams are returned as bodies for the caller to read and close.
+//
+// The types and methods in client_gen.go are generated; after changing
+// the API, regenerate them with go generate.
+package bioflowclient
+
+//go:generate go run ./internal/generate -o client_gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client calls a BioFlow server.
+type Client struct {
+	// BaseURL is the URL of the server, such as http://localhost:8080.
+	BaseURL string
+	// APIKey, if set, is sent as a bearer token.
+	APIKey string
+	// HTTPClient sends the requests; nil uses http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// New returns a client of the server at baseURL.
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Error is an error replied by the server.
+type Error struct {
+	StatusCode int
+	Message    string
+	// RetryAfter is how long the server asked to wait before trying
+	// again, when a queue was full.
+	RetryAfter time.Duration
+}
+
+// Error returns the message of the server with the status.
+func (e *Error) Error() string {
+	return fmt.Sprintf("bioflow: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// do sends a request with in as its JSON body, unless nil, and decodes
+// the JSON reply into out, unless nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	body, err := c.stream(ctx, method, path, query, in)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	if out == nil {
+		_, err = io.Copy(io.Discard, body)
+		return err
+	}
+	if err := json.NewDecoder(body).Decode(out); err != nil {
+		return fmt.Errorf("decoding reply of %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// stream sends a request with in as its JSON body, unless nil, and
+// returns the body of a successful reply.
+func (c *Client) stream(ctx context.Context, method, path string, query url.Values, in interface{}) (io.ReadCloser, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, fmt.Errorf("encoding request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, replyError(resp)
+	}
+	return resp.Body, nil
+}
+
+// replyError builds the Error of a reply that is not a success.
+func replyError(resp *http.Response) *Error {
+	e := &Error{StatusCode: resp.StatusCode}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		e.Message = body.Error
+	} else {
+		e.Message = strings.TrimSpace(string(data))
+	}
+	if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		e.RetryAfter = time.Duration(s) * time.Second
+	}
+	return e
+}
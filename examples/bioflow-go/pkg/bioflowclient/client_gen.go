@@ -0,0 +1,1187 @@
+// Code generated by bioflowclient/internal/generate; DO NOT EDIT.
+
+package bioflowclient
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"time"
+)
+
+// ATContentResponse is the ATContentResponse schema of the API.
+type ATContentResponse struct {
+	AtContent float64 `json:"at_content,omitempty"`
+	Percent   float64 `json:"percent,omitempty"`
+}
+
+// AlignmentExportRequest is the AlignmentExportRequest schema of the API.
+type AlignmentExportRequest struct {
+	Sequence1         string `json:"sequence1,omitempty"`
+	Sequence2         string `json:"sequence2,omitempty"`
+	DatabaseLength    int64  `json:"database_length,omitempty"`
+	DatabaseSequences int    `json:"database_sequences,omitempty"`
+	Ambiguity         string `json:"ambiguity,omitempty"`
+	Mode              string `json:"mode,omitempty"`
+	Name1             string `json:"name1,omitempty"`
+	Name2             string `json:"name2,omitempty"`
+	Format            string `json:"format,omitempty"`
+}
+
+// AlignmentRequest is the AlignmentRequest schema of the API.
+type AlignmentRequest struct {
+	Sequence1         string `json:"sequence1,omitempty"`
+	Sequence2         string `json:"sequence2,omitempty"`
+	DatabaseLength    int64  `json:"database_length,omitempty"`
+	DatabaseSequences int    `json:"database_sequences,omitempty"`
+	Ambiguity         string `json:"ambiguity,omitempty"`
+}
+
+// AlignmentSummary is the AlignmentSummary schema of the API.
+type AlignmentSummary struct {
+	AlignmentType string  `json:"alignment_type,omitempty"`
+	AlignedSeq1   string  `json:"aligned_seq1,omitempty"`
+	AlignedSeq2   string  `json:"aligned_seq2,omitempty"`
+	Start1        int     `json:"start1,omitempty"`
+	End1          int     `json:"end1,omitempty"`
+	Start2        int     `json:"start2,omitempty"`
+	End2          int     `json:"end2,omitempty"`
+	Score         int     `json:"score,omitempty"`
+	Identity      float64 `json:"identity,omitempty"`
+	Cigar         string  `json:"cigar,omitempty"`
+	AlignedLength int     `json:"aligned_length,omitempty"`
+	Matches       int     `json:"matches,omitempty"`
+	Mismatches    int     `json:"mismatches,omitempty"`
+	Gaps          int     `json:"gaps,omitempty"`
+	GapOpens      int     `json:"gap_opens,omitempty"`
+	BitScore      float64 `json:"bit_score,omitempty"`
+	EValue        float64 `json:"e_value,omitempty"`
+}
+
+// AnchorItem is the AnchorItem schema of the API.
+type AnchorItem struct {
+	Pos1   int    `json:"pos1,omitempty"`
+	Pos2   int    `json:"pos2,omitempty"`
+	Strand string `json:"strand,omitempty"`
+}
+
+// AsyncJob is the AsyncJob schema of the API.
+type AsyncJob struct {
+	ID        string      `json:"id,omitempty"`
+	Operation string      `json:"operation,omitempty"`
+	Status    string      `json:"status,omitempty"`
+	Priority  string      `json:"priority,omitempty"`
+	Owner     string      `json:"owner,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Submitted time.Time   `json:"submitted,omitempty"`
+	Started   *time.Time  `json:"started,omitempty"`
+	Finished  *time.Time  `json:"finished,omitempty"`
+	Expires   *time.Time  `json:"expires,omitempty"`
+	Result    interface{} `json:"result,omitempty"`
+}
+
+// AuditEntry is the AuditEntry schema of the API.
+type AuditEntry struct {
+	Seq             int64                  `json:"seq,omitempty"`
+	Time            time.Time              `json:"time,omitempty"`
+	User            string                 `json:"user,omitempty"`
+	Remote          string                 `json:"remote,omitempty"`
+	RequestID       string                 `json:"request_id,omitempty"`
+	Operation       string                 `json:"operation,omitempty"`
+	Inputs          map[string]string      `json:"inputs,omitempty"`
+	Params          map[string]interface{} `json:"params,omitempty"`
+	Status          int                    `json:"status,omitempty"`
+	Result          string                 `json:"result,omitempty"`
+	DurationSeconds float64                `json:"duration_seconds,omitempty"`
+	Prev            string                 `json:"prev,omitempty"`
+}
+
+// ChainItem is the ChainItem schema of the API.
+type ChainItem struct {
+	Strand  string  `json:"strand,omitempty"`
+	Score   float64 `json:"score,omitempty"`
+	Start1  int     `json:"start1,omitempty"`
+	End1    int     `json:"end1,omitempty"`
+	Start2  int     `json:"start2,omitempty"`
+	End2    int     `json:"end2,omitempty"`
+	Anchors int     `json:"anchors,omitempty"`
+}
+
+// CheckResult is the CheckResult schema of the API.
+type CheckResult struct {
+	Status          string  `json:"status,omitempty"`
+	Detail          string  `json:"detail,omitempty"`
+	Error           string  `json:"error,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+}
+
+// ClassStats is the ClassStats schema of the API.
+type ClassStats struct {
+	Queued            int     `json:"queued,omitempty"`
+	Running           int     `json:"running,omitempty"`
+	Started           int64   `json:"started,omitempty"`
+	Rejected          int64   `json:"rejected,omitempty"`
+	MeanWaitSeconds   float64 `json:"mean_wait_seconds,omitempty"`
+	MaxWaitSeconds    float64 `json:"max_wait_seconds,omitempty"`
+	OldestWaitSeconds float64 `json:"oldest_wait_seconds,omitempty"`
+}
+
+// CompareSetsRequest is the CompareSetsRequest schema of the API.
+type CompareSetsRequest struct {
+	A      []string `json:"a,omitempty"`
+	B      []string `json:"b,omitempty"`
+	FASTQA string   `json:"fastq_a,omitempty"`
+	FASTQB string   `json:"fastq_b,omitempty"`
+}
+
+// ComplementResponse is the ComplementResponse schema of the API.
+type ComplementResponse struct {
+	Complement string `json:"complement,omitempty"`
+}
+
+// ConfidenceInterval is the ConfidenceInterval schema of the API.
+type ConfidenceInterval struct {
+	Estimate float64 `json:"estimate,omitempty"`
+	Lower    float64 `json:"lower,omitempty"`
+	Upper    float64 `json:"upper,omitempty"`
+}
+
+// Event is the Event schema of the API.
+type Event struct {
+	Kind      string `json:"kind,omitempty"`
+	Stage     string `json:"stage,omitempty"`
+	Index     int    `json:"index,omitempty"`
+	Stages    int    `json:"stages,omitempty"`
+	Processed int    `json:"processed,omitempty"`
+	Passed    int    `json:"passed,omitempty"`
+	Rejected  int    `json:"rejected,omitempty"`
+	// Nanoseconds.
+	ElapsedNs int64  `json:"elapsed_ns,omitempty"`
+	ReadID    string `json:"read_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// FilterReadRequest is the FilterReadRequest schema of the API.
+type FilterReadRequest struct {
+	Sequence          string   `json:"sequence,omitempty"`
+	Scores            []int    `json:"scores,omitempty"`
+	MinQuality        int      `json:"min_quality,omitempty"`
+	MinLength         int      `json:"min_length,omitempty"`
+	Strict            bool     `json:"strict,omitempty"`
+	Adapters          []string `json:"adapters,omitempty"`
+	AdapterErrorRate  *float64 `json:"adapter_error_rate,omitempty"`
+	AdapterMinOverlap int      `json:"adapter_min_overlap,omitempty"`
+}
+
+// FilterReadResponse is the FilterReadResponse schema of the API.
+type FilterReadResponse struct {
+	Passed          bool    `json:"passed,omitempty"`
+	Reason          string  `json:"reason,omitempty"`
+	TrimmedSequence string  `json:"trimmed_sequence,omitempty"`
+	TrimmedScores   []int   `json:"trimmed_scores,omitempty"`
+	TrimStart       int     `json:"trim_start,omitempty"`
+	TrimEnd         int     `json:"trim_end,omitempty"`
+	OriginalLength  int     `json:"original_length,omitempty"`
+	TrimmedLength   int     `json:"trimmed_length,omitempty"`
+	MeanQuality     float64 `json:"mean_quality,omitempty"`
+	Adapter         string  `json:"adapter,omitempty"`
+}
+
+// FoldRequest is the FoldRequest schema of the API.
+type FoldRequest struct {
+	Sequence   string `json:"sequence,omitempty"`
+	Constraint string `json:"constraint,omitempty"`
+	MinLoop    int    `json:"min_loop,omitempty"`
+	Model      string `json:"model,omitempty"`
+	NoWobble   bool   `json:"no_wobble,omitempty"`
+}
+
+// FoldResponse is the FoldResponse schema of the API.
+type FoldResponse struct {
+	Sequence       string    `json:"sequence,omitempty"`
+	DotBracket     string    `json:"dot_bracket,omitempty"`
+	Pairs          []RNAPair `json:"pairs,omitempty"`
+	Score          int       `json:"score,omitempty"`
+	Model          string    `json:"model,omitempty"`
+	PairCount      int       `json:"pair_count,omitempty"`
+	PairedFraction float64   `json:"paired_fraction,omitempty"`
+}
+
+// GCContentResponse is the GCContentResponse schema of the API.
+type GCContentResponse struct {
+	GCContent float64 `json:"gc_content,omitempty"`
+	Percent   float64 `json:"percent,omitempty"`
+}
+
+// HealthReport is the HealthReport schema of the API.
+type HealthReport struct {
+	Status        string                 `json:"status,omitempty"`
+	Version       string                 `json:"version,omitempty"`
+	UptimeSeconds float64                `json:"uptime_seconds,omitempty"`
+	Checks        map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Histogram is the Histogram schema of the API.
+type Histogram struct {
+	Edges     []float64 `json:"edges,omitempty"`
+	Counts    []int     `json:"counts,omitempty"`
+	Fractions []float64 `json:"fractions,omitempty"`
+	Density   []float64 `json:"density,omitempty"`
+	Underflow int       `json:"underflow,omitempty"`
+	Overflow  int       `json:"overflow,omitempty"`
+	Total     int       `json:"total,omitempty"`
+}
+
+// HistogramRequest is the HistogramRequest schema of the API.
+type HistogramRequest struct {
+	Sequences []string  `json:"sequences,omitempty"`
+	Metric    string    `json:"metric,omitempty"`
+	Bins      int       `json:"bins,omitempty"`
+	Edges     []float64 `json:"edges,omitempty"`
+}
+
+// Intervals is the Intervals schema of the API.
+type Intervals struct {
+	Resamples     int                 `json:"resamples,omitempty"`
+	Level         float64             `json:"level,omitempty"`
+	MeanLength    *ConfidenceInterval `json:"mean_length,omitempty"`
+	MeanGCContent *ConfidenceInterval `json:"mean_gc_content,omitempty"`
+	MeanQuality   *ConfidenceInterval `json:"mean_quality,omitempty"`
+}
+
+// JSONRecord is the JSONRecord schema of the API.
+type JSONRecord struct {
+	ID          string            `json:"id,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Seq         string            `json:"seq,omitempty"`
+	Qual        string            `json:"qual,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// JobQueueStats is the JobQueueStats schema of the API.
+type JobQueueStats struct {
+	Workers   int                   `json:"workers,omitempty"`
+	MaxQueued int                   `json:"max_queued,omitempty"`
+	Running   int                   `json:"running,omitempty"`
+	Queued    int                   `json:"queued,omitempty"`
+	Classes   map[string]ClassStats `json:"classes,omitempty"`
+}
+
+// JobRequest is the JobRequest schema of the API.
+type JobRequest struct {
+	Operation string      `json:"operation,omitempty"`
+	FASTA     string      `json:"fasta,omitempty"`
+	FASTQ     string      `json:"fastq,omitempty"`
+	Encoding  string      `json:"encoding,omitempty"`
+	Params    interface{} `json:"params,omitempty"`
+	Priority  string      `json:"priority,omitempty"`
+}
+
+// KMerCountResponse is the KMerCountResponse schema of the API.
+type KMerCountResponse struct {
+	K           int            `json:"k,omitempty"`
+	Seed        string         `json:"seed,omitempty"`
+	Strand      string         `json:"strand,omitempty"`
+	UniqueCount int            `json:"unique_count,omitempty"`
+	TotalCount  int            `json:"total_count,omitempty"`
+	Counts      map[string]int `json:"counts,omitempty"`
+}
+
+// KMerDistanceRequest is the KMerDistanceRequest schema of the API.
+type KMerDistanceRequest struct {
+	Sequence1 string `json:"sequence1,omitempty"`
+	Sequence2 string `json:"sequence2,omitempty"`
+	K         int    `json:"k,omitempty"`
+	Seed      string `json:"seed,omitempty"`
+}
+
+// KMerDistanceResponse is the KMerDistanceResponse schema of the API.
+type KMerDistanceResponse struct {
+	Distance   float64 `json:"distance,omitempty"`
+	Similarity float64 `json:"similarity,omitempty"`
+}
+
+// KMerItem is the KMerItem schema of the API.
+type KMerItem struct {
+	Kmer  string `json:"kmer,omitempty"`
+	Count int    `json:"count,omitempty"`
+}
+
+// KMerRequest is the KMerRequest schema of the API.
+type KMerRequest struct {
+	Sequence   string `json:"sequence,omitempty"`
+	K          int    `json:"k,omitempty"`
+	Seed       string `json:"seed,omitempty"`
+	SkipMasked bool   `json:"skip_masked,omitempty"`
+	Strand     string `json:"strand,omitempty"`
+}
+
+// KSTest is the KSTest schema of the API.
+type KSTest struct {
+	Statistic float64 `json:"statistic,omitempty"`
+	PValue    float64 `json:"p_value,omitempty"`
+}
+
+// MSAExportRequest is the MSAExportRequest schema of the API.
+type MSAExportRequest struct {
+	Names       []string `json:"names,omitempty"`
+	Rows        []string `json:"rows,omitempty"`
+	Alignment   string   `json:"alignment,omitempty"`
+	InputFormat string   `json:"input_format,omitempty"`
+	Format      string   `json:"format,omitempty"`
+}
+
+// MetricComparison is the MetricComparison schema of the API.
+type MetricComparison struct {
+	Metric         string         `json:"metric,omitempty"`
+	A              *SampleSummary `json:"a,omitempty"`
+	B              *SampleSummary `json:"b,omitempty"`
+	MeanDifference float64        `json:"mean_difference,omitempty"`
+	Ks             *KSTest        `json:"ks,omitempty"`
+	CohensD        float64        `json:"cohens_d,omitempty"`
+	CliffsDelta    float64        `json:"cliffs_delta,omitempty"`
+}
+
+// MostFrequentRequest is the MostFrequentRequest schema of the API.
+type MostFrequentRequest struct {
+	Sequence string `json:"sequence,omitempty"`
+	K        int    `json:"k,omitempty"`
+	N        int    `json:"n,omitempty"`
+}
+
+// MostFrequentResponse is the MostFrequentResponse schema of the API.
+type MostFrequentResponse struct {
+	Kmers []KMerItem `json:"kmers,omitempty"`
+}
+
+// PipelineJob is the PipelineJob schema of the API.
+type PipelineJob struct {
+	ID        string        `json:"id,omitempty"`
+	Status    string        `json:"status,omitempty"`
+	Priority  string        `json:"priority,omitempty"`
+	Owner     string        `json:"owner,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	Submitted time.Time     `json:"submitted,omitempty"`
+	Started   *time.Time    `json:"started,omitempty"`
+	Worker    string        `json:"worker,omitempty"`
+	Finished  *time.Time    `json:"finished,omitempty"`
+	Progress  []Event       `json:"progress,omitempty"`
+	Rejected  []Event       `json:"rejected,omitempty"`
+	Reports   []StageReport `json:"reports,omitempty"`
+	FASTQ     string        `json:"fastq,omitempty"`
+}
+
+// PipelineJobRequest is the PipelineJobRequest schema of the API.
+type PipelineJobRequest struct {
+	Reads          []ReadInput     `json:"reads,omitempty"`
+	FASTQ          string          `json:"fastq,omitempty"`
+	Encoding       string          `json:"encoding,omitempty"`
+	Stages         []WorkflowStage `json:"stages,omitempty"`
+	SampleRejected int             `json:"sample_rejected,omitempty"`
+	Priority       string          `json:"priority,omitempty"`
+}
+
+// PositionQuality is the PositionQuality schema of the API.
+type PositionQuality struct {
+	Position      int     `json:"position,omitempty"`
+	Reads         int     `json:"reads,omitempty"`
+	Mean          float64 `json:"mean,omitempty"`
+	Median        int     `json:"median,omitempty"`
+	LowerQuartile int     `json:"lower_quartile,omitempty"`
+	UpperQuartile int     `json:"upper_quartile,omitempty"`
+	Percentile10  int     `json:"percentile_10,omitempty"`
+	Percentile90  int     `json:"percentile_90,omitempty"`
+}
+
+// ProteinPropertiesRequest is the ProteinPropertiesRequest schema of the API.
+type ProteinPropertiesRequest struct {
+	Protein string `json:"protein,omitempty"`
+	DNA     string `json:"dna,omitempty"`
+	Frame   int    `json:"frame,omitempty"`
+}
+
+// ProteinPropertiesResponse is the ProteinPropertiesResponse schema of the API.
+type ProteinPropertiesResponse struct {
+	Protein            string             `json:"protein,omitempty"`
+	Length             int                `json:"length,omitempty"`
+	MolecularWeight    float64            `json:"molecular_weight,omitempty"`
+	IsoelectricPoint   float64            `json:"isoelectric_point,omitempty"`
+	ChargeAtPh7        float64            `json:"charge_at_ph7,omitempty"`
+	Gravy              float64            `json:"gravy,omitempty"`
+	InstabilityIndex   float64            `json:"instability_index,omitempty"`
+	Stable             bool               `json:"stable,omitempty"`
+	Composition        map[string]int     `json:"composition,omitempty"`
+	CompositionPercent map[string]float64 `json:"composition_percent,omitempty"`
+}
+
+// QualityDistribution is the QualityDistribution schema of the API.
+type QualityDistribution struct {
+	Poor      int `json:"poor,omitempty"`
+	Low       int `json:"low,omitempty"`
+	Medium    int `json:"medium,omitempty"`
+	High      int `json:"high,omitempty"`
+	Excellent int `json:"excellent,omitempty"`
+	Total     int `json:"total,omitempty"`
+}
+
+// QualityRequest is the QualityRequest schema of the API.
+type QualityRequest struct {
+	Scores   []int  `json:"scores,omitempty"`
+	Encoded  string `json:"encoded,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// QualityResponse is the QualityResponse schema of the API.
+type QualityResponse struct {
+	Scores []int `json:"scores,omitempty"`
+	Length int   `json:"length,omitempty"`
+}
+
+// QualityStatsRequest is the QualityStatsRequest schema of the API.
+type QualityStatsRequest struct {
+	Scores []int `json:"scores,omitempty"`
+}
+
+// QualityStatsResponse is the QualityStatsResponse schema of the API.
+type QualityStatsResponse struct {
+	Count            int     `json:"count,omitempty"`
+	Min              int     `json:"min,omitempty"`
+	Max              int     `json:"max,omitempty"`
+	Mean             float64 `json:"mean,omitempty"`
+	Median           int     `json:"median,omitempty"`
+	HighQualityRatio float64 `json:"high_quality_ratio,omitempty"`
+	Category         string  `json:"category,omitempty"`
+}
+
+// RNAPair is the RNAPair schema of the API.
+type RNAPair struct {
+	I int `json:"i,omitempty"`
+	J int `json:"j,omitempty"`
+}
+
+// ReadInput is the ReadInput schema of the API.
+type ReadInput struct {
+	Sequence string `json:"sequence,omitempty"`
+	Quality  string `json:"quality,omitempty"`
+	Scores   []int  `json:"scores,omitempty"`
+}
+
+// ReadSetStats is the ReadSetStats schema of the API.
+type ReadSetStats struct {
+	Count               int                  `json:"count,omitempty"`
+	TotalBases          int                  `json:"total_bases,omitempty"`
+	MinLength           int                  `json:"min_length,omitempty"`
+	MaxLength           int                  `json:"max_length,omitempty"`
+	MeanLength          float64              `json:"mean_length,omitempty"`
+	MeanQuality         float64              `json:"mean_quality,omitempty"`
+	MedianQuality       float64              `json:"median_quality,omitempty"`
+	HighQualityCount    int                  `json:"high_quality_count,omitempty"`
+	QualityDistribution *QualityDistribution `json:"quality_distribution,omitempty"`
+	PositionQuality     []PositionQuality    `json:"position_quality,omitempty"`
+	Intervals           *Intervals           `json:"intervals,omitempty"`
+}
+
+// ReadSetStatsRequest is the ReadSetStatsRequest schema of the API.
+type ReadSetStatsRequest struct {
+	Reads     []ReadInput `json:"reads,omitempty"`
+	FASTQ     string      `json:"fastq,omitempty"`
+	Encoding  string      `json:"encoding,omitempty"`
+	Bootstrap int         `json:"bootstrap,omitempty"`
+	Seed      *int64      `json:"seed,omitempty"`
+}
+
+// ReadSetStatsResponse is the ReadSetStatsResponse schema of the API.
+type ReadSetStatsResponse struct {
+	Count               int                  `json:"count,omitempty"`
+	TotalBases          int                  `json:"total_bases,omitempty"`
+	MinLength           int                  `json:"min_length,omitempty"`
+	MaxLength           int                  `json:"max_length,omitempty"`
+	MeanLength          float64              `json:"mean_length,omitempty"`
+	MeanQuality         float64              `json:"mean_quality,omitempty"`
+	MedianQuality       float64              `json:"median_quality,omitempty"`
+	HighQualityCount    int                  `json:"high_quality_count,omitempty"`
+	QualityDistribution *QualityDistribution `json:"quality_distribution,omitempty"`
+	PositionQuality     []PositionQuality    `json:"position_quality,omitempty"`
+	Intervals           *Intervals           `json:"intervals,omitempty"`
+	HighQualityRatio    float64              `json:"high_quality_ratio,omitempty"`
+}
+
+// ReferenceInfo is the ReferenceInfo schema of the API.
+type ReferenceInfo struct {
+	Name      string              `json:"name,omitempty"`
+	Sequences []ReferenceSequence `json:"sequences,omitempty"`
+}
+
+// ReferenceSequence is the ReferenceSequence schema of the API.
+type ReferenceSequence struct {
+	Name   string `json:"name,omitempty"`
+	Length int64  `json:"length,omitempty"`
+}
+
+// RegionSequence is the RegionSequence schema of the API.
+type RegionSequence struct {
+	Region   string `json:"region,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Start    int64  `json:"start,omitempty"`
+	End      int64  `json:"end,omitempty"`
+	Sequence string `json:"sequence,omitempty"`
+}
+
+// ReverseComplementResponse is the ReverseComplementResponse schema of the API.
+type ReverseComplementResponse struct {
+	ReverseComplement string `json:"reverse_complement,omitempty"`
+}
+
+// SampleSummary is the SampleSummary schema of the API.
+type SampleSummary struct {
+	Count  int     `json:"count,omitempty"`
+	Mean   float64 `json:"mean,omitempty"`
+	Median float64 `json:"median,omitempty"`
+	StdDev float64 `json:"std_dev,omitempty"`
+}
+
+// ScoreResponse is the ScoreResponse schema of the API.
+type ScoreResponse struct {
+	Score   int   `json:"score,omitempty"`
+	Start1  int   `json:"start1,omitempty"`
+	End1    int   `json:"end1,omitempty"`
+	Start2  int   `json:"start2,omitempty"`
+	End2    int   `json:"end2,omitempty"`
+	Profile []int `json:"profile,omitempty"`
+}
+
+// SequenceInfoResponse is the SequenceInfoResponse schema of the API.
+type SequenceInfoResponse struct {
+	Length       int     `json:"length,omitempty"`
+	GCContent    float64 `json:"gc_content,omitempty"`
+	AtContent    float64 `json:"at_content,omitempty"`
+	ACount       int     `json:"a_count,omitempty"`
+	CCount       int     `json:"c_count,omitempty"`
+	GCount       int     `json:"g_count,omitempty"`
+	TCount       int     `json:"t_count,omitempty"`
+	NCount       int     `json:"n_count,omitempty"`
+	HasAmbiguous bool    `json:"has_ambiguous,omitempty"`
+}
+
+// SequenceRequest is the SequenceRequest schema of the API.
+type SequenceRequest struct {
+	Sequence string `json:"sequence,omitempty"`
+}
+
+// SequenceSetRequest is the SequenceSetRequest schema of the API.
+type SequenceSetRequest struct {
+	Sequences []string `json:"sequences,omitempty"`
+	Bootstrap int      `json:"bootstrap,omitempty"`
+	Seed      *int64   `json:"seed,omitempty"`
+}
+
+// SequenceSetStats is the SequenceSetStats schema of the API.
+type SequenceSetStats struct {
+	Count          int        `json:"Count,omitempty"`
+	TotalBases     int        `json:"TotalBases,omitempty"`
+	MinLength      int        `json:"MinLength,omitempty"`
+	MaxLength      int        `json:"MaxLength,omitempty"`
+	MeanLength     float64    `json:"MeanLength,omitempty"`
+	MedianLength   int        `json:"MedianLength,omitempty"`
+	MeanGCContent  float64    `json:"MeanGCContent,omitempty"`
+	N50            int        `json:"N50,omitempty"`
+	TotalAmbiguous int        `json:"TotalAmbiguous,omitempty"`
+	Intervals      *Intervals `json:"Intervals,omitempty"`
+}
+
+// SequenceStats is the SequenceStats schema of the API.
+type SequenceStats struct {
+	Length       int     `json:"Length,omitempty"`
+	GCContent    float64 `json:"GCContent,omitempty"`
+	ATContent    float64 `json:"ATContent,omitempty"`
+	ACount       int     `json:"ACount,omitempty"`
+	CCount       int     `json:"CCount,omitempty"`
+	GCount       int     `json:"GCount,omitempty"`
+	TCount       int     `json:"TCount,omitempty"`
+	NCount       int     `json:"NCount,omitempty"`
+	HasAmbiguous bool    `json:"HasAmbiguous,omitempty"`
+}
+
+// SetComparison is the SetComparison schema of the API.
+type SetComparison struct {
+	LabelA      string            `json:"label_a,omitempty"`
+	LabelB      string            `json:"label_b,omitempty"`
+	Length      *MetricComparison `json:"length,omitempty"`
+	GCContent   *MetricComparison `json:"gc_content,omitempty"`
+	MeanQuality *MetricComparison `json:"mean_quality,omitempty"`
+}
+
+// SharedKMersRequest is the SharedKMersRequest schema of the API.
+type SharedKMersRequest struct {
+	Sequence1   string `json:"sequence1,omitempty"`
+	Sequence2   string `json:"sequence2,omitempty"`
+	K           int    `json:"k,omitempty"`
+	Positions   bool   `json:"positions,omitempty"`
+	BothStrands bool   `json:"both_strands,omitempty"`
+	Chain       bool   `json:"chain,omitempty"`
+	SkipMasked  bool   `json:"skip_masked,omitempty"`
+}
+
+// SharedKMersResponse is the SharedKMersResponse schema of the API.
+type SharedKMersResponse struct {
+	SharedKmers []string     `json:"shared_kmers,omitempty"`
+	Count       int          `json:"count,omitempty"`
+	Anchors     []AnchorItem `json:"anchors,omitempty"`
+	Chains      []ChainItem  `json:"chains,omitempty"`
+}
+
+// StageReport is the StageReport schema of the API.
+type StageReport struct {
+	Stage       string         `json:"stage,omitempty"`
+	Type        string         `json:"type,omitempty"`
+	Skipped     bool           `json:"skipped,omitempty"`
+	InputReads  int            `json:"input_reads,omitempty"`
+	OutputReads int            `json:"output_reads,omitempty"`
+	Removed     int            `json:"removed,omitempty"`
+	Reasons     map[string]int `json:"reasons,omitempty"`
+	Stats       *ReadSetStats  `json:"stats,omitempty"`
+	Output      string         `json:"output,omitempty"`
+	Seconds     float64        `json:"seconds,omitempty"`
+}
+
+// TranscribeResponse is the TranscribeResponse schema of the API.
+type TranscribeResponse struct {
+	RNA string `json:"rna,omitempty"`
+}
+
+// ValidateRequest is the ValidateRequest schema of the API.
+type ValidateRequest struct {
+	Sequence string `json:"sequence,omitempty"`
+	Type     string `json:"type,omitempty"`
+}
+
+// ValidateResponse is the ValidateResponse schema of the API.
+type ValidateResponse struct {
+	Valid   bool   `json:"valid,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// WorkflowStage is the WorkflowStage schema of the API.
+type WorkflowStage struct {
+	Name   string                 `json:"name,omitempty"`
+	Type   string                 `json:"type,omitempty"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// AlignmentExport calls POST /api/v1/alignment/export.
+//
+// Download a local or global alignment as pairwise FASTA, Clustal, or SAM
+// with sequence2 mapped to sequence1.
+//
+// The caller must close the body.
+func (c *Client) AlignmentExport(ctx context.Context, req AlignmentExportRequest) (io.ReadCloser, error) {
+	return c.stream(ctx, "POST", "/api/v1/alignment/export", nil, req)
+}
+
+// GlobalAlign calls POST /api/v1/alignment/global.
+//
+// Perform global alignment (Needleman-Wunsch).
+func (c *Client) GlobalAlign(ctx context.Context, req AlignmentRequest) (*AlignmentSummary, error) {
+	var resp AlignmentSummary
+	if err := c.do(ctx, "POST", "/api/v1/alignment/global", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// LocalAlign calls POST /api/v1/alignment/local.
+//
+// Perform local alignment (Smith-Waterman).
+func (c *Client) LocalAlign(ctx context.Context, req AlignmentRequest) (*AlignmentSummary, error) {
+	var resp AlignmentSummary
+	if err := c.do(ctx, "POST", "/api/v1/alignment/local", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// AlignmentScore calls POST /api/v1/alignment/score.
+//
+// Score of the local alignment of two sequences, with its significance.
+func (c *Client) AlignmentScore(ctx context.Context, req AlignmentRequest) (*ScoreResponse, error) {
+	var resp ScoreResponse
+	if err := c.do(ctx, "POST", "/api/v1/alignment/score", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// AuditExportParams are the query parameters of AuditExport.
+type AuditExportParams struct {
+	// Earliest time, RFC 3339.
+	From string
+	// Latest time, RFC 3339.
+	To string
+	// Only the entries of this user.
+	User string
+}
+
+// AuditExport calls GET /api/v1/audit.
+//
+// Export the audit log as JSON Lines, oldest first, optionally by time
+// range and user. Only for audit administrators when sign-in is on.
+//
+// The caller must close the body.
+func (c *Client) AuditExport(ctx context.Context, params AuditExportParams) (io.ReadCloser, error) {
+	q := url.Values{}
+	if params.From != "" {
+		q.Set("from", params.From)
+	}
+	if params.To != "" {
+		q.Set("to", params.To)
+	}
+	if params.User != "" {
+		q.Set("user", params.User)
+	}
+	return c.stream(ctx, "GET", "/api/v1/audit", q, nil)
+}
+
+// ListJobs calls GET /api/v1/jobs.
+//
+// The asynchronous jobs of your workspace, newest first, without their
+// results.
+func (c *Client) ListJobs(ctx context.Context) ([]AsyncJob, error) {
+	var resp []AsyncJob
+	if err := c.do(ctx, "GET", "/api/v1/jobs", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// StartJob calls POST /api/v1/jobs, which replies 202 Accepted.
+//
+// Queue an asynchronous job for inputs too large to answer within the
+// request timeout: align-local or align-global (the two sequences of the
+// FASTA), sequence-stats, kmer-count (k and top), read-stats or filter
+// (FASTQ). Params take the options of the matching endpoint. Replies 503
+// with Retry-After when the queue is full.
+func (c *Client) StartJob(ctx context.Context, req JobRequest) (*AsyncJob, error) {
+	var resp AsyncJob
+	if err := c.do(ctx, "POST", "/api/v1/jobs", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// JobQueue calls GET /api/v1/jobs/queue.
+//
+// Metrics of the asynchronous job queue, as for pipeline jobs.
+func (c *Client) JobQueue(ctx context.Context) (*JobQueueStats, error) {
+	var resp JobQueueStats
+	if err := c.do(ctx, "GET", "/api/v1/jobs/queue", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Job calls GET /api/v1/jobs/{id}.
+//
+// Status of an asynchronous job (queued, running, done or failed), with
+// its result once done. Finished jobs are forgotten after a while, one
+// hour by default.
+func (c *Client) Job(ctx context.Context, id string) (*AsyncJob, error) {
+	var resp AsyncJob
+	if err := c.do(ctx, "GET", "/api/v1/jobs/"+url.PathEscape(id), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteJob calls DELETE /api/v1/jobs/{id}, which replies 204 No Content.
+//
+// Cancel an asynchronous job that has not finished, and forget it and its
+// result.
+func (c *Client) DeleteJob(ctx context.Context, id string) error {
+	return c.do(ctx, "DELETE", "/api/v1/jobs/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// KMerCount calls POST /api/v1/kmer/count.
+//
+// Count k-mers in a sequence. With skip_masked, k-mers overlapping
+// soft-masked (lower-case) bases are left out. strand counts the forward
+// strand (default), the reverse strand or both, without merging reverse
+// complements.
+func (c *Client) KMerCount(ctx context.Context, req KMerRequest) (*KMerCountResponse, error) {
+	var resp KMerCountResponse
+	if err := c.do(ctx, "POST", "/api/v1/kmer/count", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// KMerDistance calls POST /api/v1/kmer/distance.
+//
+// K-mer distance between two sequences.
+func (c *Client) KMerDistance(ctx context.Context, req KMerDistanceRequest) (*KMerDistanceResponse, error) {
+	var resp KMerDistanceResponse
+	if err := c.do(ctx, "POST", "/api/v1/kmer/distance", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// MostFrequentKMers calls POST /api/v1/kmer/most-frequent.
+//
+// The most frequent k-mers of a sequence.
+func (c *Client) MostFrequentKMers(ctx context.Context, req MostFrequentRequest) (*MostFrequentResponse, error) {
+	var resp MostFrequentResponse
+	if err := c.do(ctx, "POST", "/api/v1/kmer/most-frequent", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SharedKMers calls POST /api/v1/kmer/shared.
+//
+// K-mers shared by two sequences, as anchors and chains of anchors.
+func (c *Client) SharedKMers(ctx context.Context, req SharedKMersRequest) (*SharedKMersResponse, error) {
+	var resp SharedKMersResponse
+	if err := c.do(ctx, "POST", "/api/v1/kmer/shared", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// MSAExport calls POST /api/v1/msa/export.
+//
+// Download a multiple sequence alignment, given as rows or as text in
+// another format, as FASTA, Clustal, Stockholm or PHYLIP.
+//
+// The caller must close the body.
+func (c *Client) MSAExport(ctx context.Context, req MSAExportRequest) (io.ReadCloser, error) {
+	return c.stream(ctx, "POST", "/api/v1/msa/export", nil, req)
+}
+
+// ListPipelineJobs calls GET /api/v1/pipeline/jobs.
+//
+// The jobs of your workspace, newest first, without their progress and
+// output.
+func (c *Client) ListPipelineJobs(ctx context.Context) ([]PipelineJob, error) {
+	var resp []PipelineJob
+	if err := c.do(ctx, "GET", "/api/v1/pipeline/jobs", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// StartPipelineJob calls POST /api/v1/pipeline/jobs, which replies 202 Accepted.
+//
+// Queue a pipeline job over reads (trim, filter, dedupe, stats and
+// registered stages). Replies 503 with Retry-After when the queue of its
+// priority (interactive, normal or batch) is full.
+func (c *Client) StartPipelineJob(ctx context.Context, req PipelineJobRequest) (*PipelineJob, error) {
+	var resp PipelineJob
+	if err := c.do(ctx, "POST", "/api/v1/pipeline/jobs", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PipelineJob calls GET /api/v1/pipeline/jobs/{id}.
+//
+// Progress of a pipeline job: per-stage counters and timings, sampled
+// rejected reads, and the stage reports and FASTQ output once done.
+func (c *Client) PipelineJob(ctx context.Context, id string) (*PipelineJob, error) {
+	var resp PipelineJob
+	if err := c.do(ctx, "GET", "/api/v1/pipeline/jobs/"+url.PathEscape(id), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PipelineJobReads calls GET /api/v1/pipeline/jobs/{id}/reads.
+//
+// Stream the output reads of a finished job as JSON Lines, one record per
+// line.
+//
+// The caller must close the body.
+func (c *Client) PipelineJobReads(ctx context.Context, id string) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/api/v1/pipeline/jobs/"+url.PathEscape(id)+"/reads", nil, nil)
+}
+
+// PipelineQueue calls GET /api/v1/pipeline/queue.
+//
+// Job queue metrics: running and queued jobs, rejections, and mean, max
+// and oldest wait times per priority class.
+func (c *Client) PipelineQueue(ctx context.Context) (*JobQueueStats, error) {
+	var resp JobQueueStats
+	if err := c.do(ctx, "GET", "/api/v1/pipeline/queue", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ProteinProperties calls POST /api/v1/protein/properties.
+//
+// Molecular weight, pI, GRAVY, instability index and composition of a
+// protein (or translated DNA).
+func (c *Client) ProteinProperties(ctx context.Context, req ProteinPropertiesRequest) (*ProteinPropertiesResponse, error) {
+	var resp ProteinPropertiesResponse
+	if err := c.do(ctx, "POST", "/api/v1/protein/properties", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// FilterRead calls POST /api/v1/quality/filter.
+//
+// Trim and filter a read, reporting why it was rejected.
+func (c *Client) FilterRead(ctx context.Context, req FilterReadRequest) (*FilterReadResponse, error) {
+	var resp FilterReadResponse
+	if err := c.do(ctx, "POST", "/api/v1/quality/filter", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ParseQuality calls POST /api/v1/quality/parse.
+//
+// Decode a quality string into Phred scores.
+func (c *Client) ParseQuality(ctx context.Context, req QualityRequest) (*QualityResponse, error) {
+	var resp QualityResponse
+	if err := c.do(ctx, "POST", "/api/v1/quality/parse", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// QualityStats calls POST /api/v1/quality/stats.
+//
+// Calculate quality score statistics.
+func (c *Client) QualityStats(ctx context.Context, req QualityStatsRequest) (*QualityStatsResponse, error) {
+	var resp QualityStatsResponse
+	if err := c.do(ctx, "POST", "/api/v1/quality/stats", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListReferences calls GET /api/v1/references.
+//
+// The references served, with the name and length of each of their
+// sequences.
+func (c *Client) ListReferences(ctx context.Context) ([]ReferenceInfo, error) {
+	var resp []ReferenceInfo
+	if err := c.do(ctx, "GET", "/api/v1/references", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ReferenceRegionsParams are the query parameters of ReferenceRegions.
+type ReferenceRegionsParams struct {
+	// Regions as samtools faidx takes them, 1-based and inclusive, such as
+	// chr1:1,001-2,000; repeat for several.
+	Region []string
+	// json (default) or fasta.
+	Format string
+}
+
+// ReferenceRegions calls GET /api/v1/references/{name}/regions.
+//
+// Extract regions from an indexed reference, read by seeking rather than
+// parsing the whole file. Start and end are 0-based and half-open in the
+// reply.
+func (c *Client) ReferenceRegions(ctx context.Context, name string, params ReferenceRegionsParams) ([]RegionSequence, error) {
+	q := url.Values{}
+	for _, v := range params.Region {
+		q.Add("region", v)
+	}
+	if params.Format != "" {
+		q.Set("format", params.Format)
+	}
+	var resp []RegionSequence
+	if err := c.do(ctx, "GET", "/api/v1/references/"+url.PathEscape(name)+"/regions", q, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Fold calls POST /api/v1/rna/fold.
+//
+// Predict RNA secondary structure (Nussinov).
+func (c *Client) Fold(ctx context.Context, req FoldRequest) (*FoldResponse, error) {
+	var resp FoldResponse
+	if err := c.do(ctx, "POST", "/api/v1/rna/fold", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ATContent calls POST /api/v1/sequence/at-content.
+//
+// Calculate the AT content of a sequence.
+func (c *Client) ATContent(ctx context.Context, req SequenceRequest) (*ATContentResponse, error) {
+	var resp ATContentResponse
+	if err := c.do(ctx, "POST", "/api/v1/sequence/at-content", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Complement calls POST /api/v1/sequence/complement.
+//
+// Get the complement of a DNA sequence.
+func (c *Client) Complement(ctx context.Context, req SequenceRequest) (*ComplementResponse, error) {
+	var resp ComplementResponse
+	if err := c.do(ctx, "POST", "/api/v1/sequence/complement", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GCContent calls POST /api/v1/sequence/gc-content.
+//
+// Calculate the GC content of a sequence.
+func (c *Client) GCContent(ctx context.Context, req SequenceRequest) (*GCContentResponse, error) {
+	var resp GCContentResponse
+	if err := c.do(ctx, "POST", "/api/v1/sequence/gc-content", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SequenceInfo calls POST /api/v1/sequence/info.
+//
+// Length, composition and type of a sequence.
+func (c *Client) SequenceInfo(ctx context.Context, req SequenceRequest) (*SequenceInfoResponse, error) {
+	var resp SequenceInfoResponse
+	if err := c.do(ctx, "POST", "/api/v1/sequence/info", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ReverseComplement calls POST /api/v1/sequence/reverse-complement.
+//
+// Get the reverse complement of a DNA sequence.
+func (c *Client) ReverseComplement(ctx context.Context, req SequenceRequest) (*ReverseComplementResponse, error) {
+	var resp ReverseComplementResponse
+	if err := c.do(ctx, "POST", "/api/v1/sequence/reverse-complement", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Transcribe calls POST /api/v1/sequence/transcribe.
+//
+// Transcribe DNA into RNA.
+func (c *Client) Transcribe(ctx context.Context, req SequenceRequest) (*TranscribeResponse, error) {
+	var resp TranscribeResponse
+	if err := c.do(ctx, "POST", "/api/v1/sequence/transcribe", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Validate calls POST /api/v1/sequence/validate.
+//
+// Validate a sequence. The type (DNA, RNA or protein) is detected from the
+// input unless given.
+func (c *Client) Validate(ctx context.Context, req ValidateRequest) (*ValidateResponse, error) {
+	var resp ValidateResponse
+	if err := c.do(ctx, "POST", "/api/v1/sequence/validate", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CompareSets calls POST /api/v1/stats/compare.
+//
+// Compare length and GC content (and mean quality for two FASTQ sets)
+// between two sets: KS test and effect sizes.
+func (c *Client) CompareSets(ctx context.Context, req CompareSetsRequest) (*SetComparison, error) {
+	var resp SetComparison
+	if err := c.do(ctx, "POST", "/api/v1/stats/compare", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Histogram calls POST /api/v1/stats/histogram.
+//
+// GC content or length histogram of a sequence set, with explicit bin
+// edges, fractions and density.
+func (c *Client) Histogram(ctx context.Context, req HistogramRequest) (*Histogram, error) {
+	var resp Histogram
+	if err := c.do(ctx, "POST", "/api/v1/stats/histogram", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// QCReport calls POST /api/v1/stats/qc-report.
+//
+// Download a self-contained HTML QC report (per-position quality, k-mer
+// and adapter content) for a read set.
+//
+// The caller must close the body.
+func (c *Client) QCReport(ctx context.Context, req ReadSetStatsRequest) (io.ReadCloser, error) {
+	return c.stream(ctx, "POST", "/api/v1/stats/qc-report", nil, req)
+}
+
+// ReadSetStats calls POST /api/v1/stats/reads.
+//
+// Length and quality statistics of a read set, with the quality
+// distribution. Set "bootstrap" to a number of resamples for 95%
+// confidence intervals of the means.
+func (c *Client) ReadSetStats(ctx context.Context, req ReadSetStatsRequest) (*ReadSetStatsResponse, error) {
+	var resp ReadSetStatsResponse
+	if err := c.do(ctx, "POST", "/api/v1/stats/reads", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SequenceStats calls POST /api/v1/stats/sequence.
+//
+// Statistics of a sequence.
+func (c *Client) SequenceStats(ctx context.Context, req SequenceRequest) (*SequenceStats, error) {
+	var resp SequenceStats
+	if err := c.do(ctx, "POST", "/api/v1/stats/sequence", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SequenceSetStats calls POST /api/v1/stats/set.
+//
+// Statistics of a set of sequences, with bootstrap confidence intervals of
+// the means.
+func (c *Client) SequenceSetStats(ctx context.Context, req SequenceSetRequest) (*SequenceSetStats, error) {
+	var resp SequenceSetStats
+	if err := c.do(ctx, "POST", "/api/v1/stats/set", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Liveness calls GET /healthz.
+//
+// Liveness: replies 200 with the version and uptime while the server is
+// up.
+func (c *Client) Liveness(ctx context.Context) (*HealthReport, error) {
+	var resp HealthReport
+	if err := c.do(ctx, "GET", "/healthz", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Readiness calls GET /readyz.
+//
+// Readiness: checks the job queue (or broker) and the job store, replying
+// 200 if all pass and 503 otherwise, with the result of each check.
+func (c *Client) Readiness(ctx context.Context) (*HealthReport, error) {
+	var resp HealthReport
+	if err := c.do(ctx, "GET", "/readyz", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,56 @@
+// Command generate writes the types and methods of the bioflowclient
+// package from the OpenAPI specification of the API handlers, or from a
+// specification file saved from a server with -spec.
+//
+// Usage:
+//
+//	go run ./internal/generate [-spec openapi.json] [-o client_gen.go]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/aria-lang/bioflow-go/api/handlers"
+	"github.com/aria-lang/bioflow-go/pkg/bioflow"
+)
+
+func main() {
+	specFile := flag.String("spec", "", "OpenAPI specification to generate from (default: that of the handlers)")
+	output := flag.String("o", "", "Output file (default: stdout)")
+	flag.Parse()
+
+	var doc *bioflow.OpenAPIDocument
+	if *specFile != "" {
+		data, err := os.ReadFile(*specFile)
+		if err != nil {
+			log.Fatalf("Could not read the specification: %v", err)
+		}
+		doc = &bioflow.OpenAPIDocument{}
+		if err := json.Unmarshal(data, doc); err != nil {
+			log.Fatalf("Could not parse the specification: %v", err)
+		}
+	} else {
+		var err error
+		if doc, err = handlers.OpenAPISpec(); err != nil {
+			log.Fatalf("Could not describe the API: %v", err)
+		}
+	}
+
+	src, err := bioflow.GenerateOpenAPIClient(doc, bioflow.OpenAPIClientOptions{
+		Package:   "bioflowclient",
+		Generator: "bioflowclient/internal/generate",
+	})
+	if err != nil {
+		log.Fatalf("Could not generate the client: %v", err)
+	}
+	if *output == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatalf("Could not write the client: %v", err)
+	}
+}